@@ -1,18 +1,33 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"golang-food-backend/configs"
 	"golang-food-backend/internal/handlers"
 	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/migrations"
 	"golang-food-backend/internal/models"
 	"golang-food-backend/internal/repositories"
 	"golang-food-backend/internal/services"
 	"golang-food-backend/pkg/auth"
 	"golang-food-backend/pkg/cache"
 	"golang-food-backend/pkg/database"
+	"golang-food-backend/pkg/fieldcrypt"
+	"golang-food-backend/pkg/geo"
+	"golang-food-backend/pkg/jobs"
 	"golang-food-backend/pkg/messaging"
+	"golang-food-backend/pkg/notify"
 	"golang-food-backend/pkg/sms"
+	"golang-food-backend/pkg/storage"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -20,25 +35,78 @@ import (
 
 func main() {
 	// Load configuration
-	config := configs.LoadConfig()
+	config, err := configs.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		log.Fatal(err)
+	}
 
 	// Set Gin mode
 	gin.SetMode(config.Server.Mode)
 
+	// Emails, phone numbers and addresses are encrypted at rest once data keys are configured
+	if config.PII.KeySource != "" {
+		keys, err := fieldcrypt.LoadKeys(context.Background(), config.PII.KeySource, config.PII.DataKeys, fieldcrypt.KMSConfig{
+			Region:    config.PII.KMSRegion,
+			AccessKey: config.PII.KMSAccessKey,
+			SecretKey: config.PII.KMSSecretKey,
+		})
+		if err != nil {
+			log.Fatal("Failed to load PII data keys:", err)
+		}
+		keyRing, err := fieldcrypt.NewKeyRing(keys, config.PII.ActiveKeyID, []byte(config.PII.HashKey))
+		if err != nil {
+			log.Fatal("Failed to set up PII encryption:", err)
+		}
+		fieldcrypt.Use(keyRing)
+	} else {
+		log.Println("PII_KEY_SOURCE is not set; personal data is stored in plaintext")
+	}
+
 	// Initialize database connections
-	db, err := database.NewDatabase(config.Database.PostgresURL, config.Database.MongoURL, config.Database.MongoDBName)
+	db, err := database.NewDatabase(config.Database.PostgresURL, config.Database.MongoURL, config.Database.MongoDBName, database.Options{
+		PostgresReplicaURL: config.Database.PostgresReplicaURL,
+		MaxOpenConns:       config.Database.MaxOpenConns,
+		MaxIdleConns:       config.Database.MaxIdleConns,
+		ConnMaxLifetime:    time.Duration(config.Database.ConnMaxLifetimeMinutes) * time.Minute,
+		ConnMaxIdleTime:    time.Duration(config.Database.ConnMaxIdleTimeMinutes) * time.Minute,
+		MongoMaxPoolSize:   uint64(config.Database.MongoMaxPoolSize),
+		MongoMinPoolSize:   uint64(config.Database.MongoMinPoolSize),
+		SlowQueryThreshold: time.Duration(config.Database.SlowQueryMs) * time.Millisecond,
+		QueryTimeout:       config.Database.QueryTimeout,
+	})
 	if err != nil {
 		log.Fatal("Failed to connect to databases:", err)
 	}
 	defer db.Close()
 
-	// Auto-migrate PostgreSQL tables
-	if err := autoMigratePostgres(db); err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	// Schema changes are versioned migrations. Release mode expects them to have been applied
+	// with cmd/migrate and never AutoMigrates; other modes AutoMigrate and then apply them.
+	migrationRunner, err := migrations.NewRunner(db)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+	if config.Server.Mode == gin.ReleaseMode {
+		pending, err := migrationRunner.Pending(context.Background())
+		if err != nil {
+			log.Fatal("Failed to check migrations:", err)
+		}
+		if len(pending) > 0 {
+			log.Fatalf("%d database migrations are pending (%s); run `migrate up` before starting", len(pending), strings.Join(pending, ", "))
+		}
+	} else {
+		if err := autoMigratePostgres(db); err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		if _, err := migrationRunner.Up(context.Background()); err != nil {
+			log.Fatal("Failed to run migrations:", err)
+		}
 	}
 
 	// Initialize Redis cache
-	redisCache := cache.NewRedisCache(config.Redis.URL, config.Redis.Password, config.Redis.DB)
+	redisCache := cache.NewRedisCache(config.Redis.URL, config.Redis.Password, config.Redis.DB, config.Redis.CommandTimeout)
 	if redisCache == nil {
 		log.Fatal("Failed to connect to Redis")
 	}
@@ -46,7 +114,19 @@ func main() {
 
 	// Initialize Kafka
 	kafkaProducer := messaging.NewKafkaProducer(config.Kafka.Brokers)
+	kafkaProducer.SetRetryPolicy(messaging.RetryPolicy{
+		MaxAttempts:    config.Kafka.ProducerRetries + 1,
+		InitialBackoff: time.Duration(config.Kafka.RetryBackoffMs) * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+	})
 	defer kafkaProducer.Close()
+	kafkaConsumer := messaging.NewKafkaConsumer(config.Kafka.Brokers, kafkaProducer)
+
+	// Background jobs run on a Redis-backed queue; services register their job types below
+	jobQueue := jobs.NewQueue(redisCache.Client(), jobs.Config{
+		Workers:     config.Jobs.Workers,
+		MaxAttempts: config.Jobs.MaxAttempts,
+	})
 
 	// Initialize JWT manager (access: 1 hour, refresh: 30 days)
 	jwtManager := auth.NewJWTManager(config.JWT.SecretKey, config.JWT.ExpiryHours, 30)
@@ -60,63 +140,480 @@ func main() {
 	deliveryPartnerRepo := repositories.NewDeliveryPartnerRepository(db.Postgres)
 	restaurantDeliveryPartnerRepo := repositories.NewRestaurantDeliveryPartnerRepository(db.Postgres)
 	porterDeliveryRepo := repositories.NewPorterDeliveryRepository(db.Postgres)
+	dispatchRepo := repositories.NewDispatchRepository(db.Postgres)
 	otpRepo := repositories.NewOTPRepository(db.Postgres) // OTP repository for SMS authentication
+	authSessionRepo := repositories.NewAuthSessionRepository(db.Postgres)
+	userIdentityRepo := repositories.NewUserIdentityRepository(db.Postgres)
+	passwordHistoryRepo := repositories.NewPasswordHistoryRepository(db.Postgres)
+	piiRepo := repositories.NewPIIRepository(db.Postgres)
+	smsDeliveryRepo := repositories.NewSMSDeliveryRepository(db.Postgres)
 	// TODO: Uncomment when services are ready
 	refundRepo := repositories.NewRefundRepository(db.Postgres)
+	supportTicketRepo := repositories.NewSupportTicketRepository(db.Postgres)
 	couponRepo := repositories.NewCouponRepository(db.Postgres)
+	pricingRuleRepo := repositories.NewPricingRuleRepository(db.Postgres)
+	featureFlagRepo := repositories.NewFeatureFlagRepository(db.Postgres)
+	riskRepo := repositories.NewRiskRepository(db.Postgres)
+	blocklistRepo := repositories.NewBlocklistRepository(db.Postgres)
+	webhookRepo := repositories.NewWebhookRepository(db.Postgres)
+	partnerAPIKeyRepo := repositories.NewPartnerAPIKeyRepository(db.Postgres)
 	addressRepo := repositories.NewAddressRepository(db.Postgres)
+	deliveryBoundaryRepo := repositories.NewDeliveryBoundaryRepository(db.Postgres)
+	specialHoursRepo := repositories.NewRestaurantSpecialHoursRepository(db.Postgres)
+	restaurantPauseRepo := repositories.NewRestaurantPauseRepository(db.Postgres)
+	maintenanceWindowRepo := repositories.NewMaintenanceWindowRepository(db.Postgres)
+	riderRepo := repositories.NewRiderRepository(db.Postgres)
+	riderAssignmentRepo := repositories.NewRiderAssignmentRepository(db.Postgres)
+	taxConfigRepo := repositories.NewTaxConfigRepository(db.Postgres)
+	adminRepo := repositories.NewAdminRepository(db.Postgres)
+	dashboardRepo := repositories.NewDashboardRepository(db.Postgres)
+	auditLogRepo := repositories.NewAuditLogRepository(db.Postgres)
+	outboxRepo := repositories.NewOutboxRepository(db.Postgres)
+	restaurantDocumentRepo := repositories.NewRestaurantDocumentRepository(db.Postgres)
+	invoiceRepo := repositories.NewInvoiceRepository(db.Postgres)
+	commissionRepo := repositories.NewCommissionRepository(db.Postgres)
+	payoutRepo := repositories.NewPayoutRepository(db.Postgres)
+	staffRepo := repositories.NewStaffRepository(db.Postgres)
+	tableRepo := repositories.NewRestaurantTableRepository(db.Postgres)
+	tableTabRepo := repositories.NewTableTabRepository(db.Postgres)
+	cateringQuoteRepo := repositories.NewCateringQuoteRepository(db.Postgres)
+	mealPlanRepo := repositories.NewMealPlanRepository(db.Postgres)
+	mealPlanSubscriptionRepo := repositories.NewMealPlanSubscriptionRepository(db.Postgres)
+	savedPaymentMethodRepo := repositories.NewSavedPaymentMethodRepository(db.Postgres)
+	dietaryPreferenceRepo := repositories.NewDietaryPreferenceRepository(db.Postgres)
+	reconciliationRepo := repositories.NewReconciliationRepository(db.Postgres)
+	ledgerRepo := repositories.NewLedgerRepository(db.Postgres)
 
 	// MongoDB repositories
 	productRepo := repositories.NewProductRepository(db.MongoDB)
 	categoryRepo := repositories.NewProductCategoryRepository(db.MongoDB)
-	// reviewRepo := repositories.NewRatingReviewRepository(db.MongoDB) // TODO: Add review service
+	reviewRepo := repositories.NewRatingReviewRepository(db.MongoDB) // TODO: Add review service
 	inventoryRepo := repositories.NewInventoryRepository(db.MongoDB)
 	timeRangeProductRepo := repositories.NewTimeRangeProductRepository(db.MongoDB)
+	deliveryLocationRepo := repositories.NewDeliveryLocationRepository(db.MongoDB)
+	orderChatRepo := repositories.NewOrderChatRepository(db.MongoDB)
+	bannerRepo := repositories.NewBannerRepository(db.MongoDB)
+	highlightRepo := repositories.NewHighlightProductRepository(db.MongoDB)
+	menuSectionRepo := repositories.NewMenuSectionRepository(db.MongoDB)
+	restaurantAnalyticsRepo := repositories.NewRestaurantAnalyticsRepository(db.MongoDB)
+	searchLogRepo := repositories.NewSearchLogRepository(db.MongoDB)
+	userActivityRepo := repositories.NewUserActivityRepository(db.MongoDB)
+	mediaRepo := repositories.NewMediaRepository(db.MongoDB)
+	systemLogRepo := repositories.NewSystemLogRepository(db.MongoDB)
+	priceHistoryRepo := repositories.NewPriceHistoryRepository(db.MongoDB)
+	priceScheduleRepo := repositories.NewPriceScheduleRepository(db.MongoDB)
+
+	// Slow queries are logged once the log store is available
+	if db.MongoDB != nil {
+		logSlowQueries(db, systemLogRepo)
+	}
 
 	// Initialize services
-	authService := services.NewAuthService(userRepo, jwtManager, redisCache)
+	outboxService := services.NewOutboxService(outboxRepo, kafkaProducer, config.Kafka.Brokers, messaging.RetryPolicy{
+		MaxAttempts:    config.Kafka.OutboxMaxAttempts,
+		InitialBackoff: 5 * time.Second,
+		MaxBackoff:     time.Duration(config.Kafka.OutboxMaxBackoffSec) * time.Second,
+	})
+	outboxService.Start()
+	defer outboxService.Stop()
+	maintenanceService := services.NewMaintenanceService(maintenanceWindowRepo)
+	featureFlagService := services.NewFeatureFlagService(featureFlagRepo)
+	auditService := services.NewAuditService(auditLogRepo, restaurantRepo, productRepo, couponRepo, refundRepo, payoutRepo)
+	sessionService := services.NewSessionService(authSessionRepo, auditLogRepo, staffRepo, jwtManager, redisCache)
+	blocklistService := services.NewBlocklistService(blocklistRepo, sessionService, redisCache)
+	sessionService.SetBlocklist(blocklistService)
+	adminService := services.NewAdminService(adminRepo, jwtManager, redisCache, config.Admin.TOTPIssuer)
+	if err := adminService.EnsureBootstrapAdmin(context.Background(), config.Admin.BootstrapEmail, config.Admin.BootstrapPassword); err != nil {
+		log.Printf("Failed to bootstrap admin: %v", err)
+	}
 
 	// SMS and OTP services
-	smsService := sms.NewSMSService("R9Jfx2ile8a6VTHu", "MYDTEH") // API credentials provided
-	otpService := services.NewOTPService(otpRepo, userRepo, jwtManager, redisCache, smsService)
+	smsService := sms.NewSMSService(smsProviders(config.SMS), services.NewSMSDeliveryService(smsDeliveryRepo))
+	emailSender := notify.NewEmailSender(
+		config.Email.SMTPHost, config.Email.SMTPPort, config.Email.SMTPUsername, config.Email.SMTPPassword, config.Email.From,
+	)
+	otpSenders := map[string]notify.Sender{
+		notify.ChannelSMS: notify.NewSMSSender(smsService),
+		notify.ChannelWhatsApp: notify.NewWhatsAppSender(
+			config.WhatsApp.BaseURL, config.WhatsApp.PhoneNumberID, config.WhatsApp.AccessToken,
+			config.WhatsApp.TemplateName, config.WhatsApp.LanguageCode, config.WhatsApp.DefaultCountryCode,
+		),
+		notify.ChannelEmail: emailSender,
+	}
+	otpService := services.NewOTPService(otpRepo, userRepo, outboxRepo, sessionService, blocklistService, redisCache, otpSenders, services.OTPDeliveryPolicy{
+		DefaultChannel:  config.OTP.DefaultChannel,
+		FallbackChannel: config.OTP.FallbackChannel,
+		Templates: map[string]services.OTPTemplate{
+			notify.ChannelSMS:      {Body: config.OTP.SMSTemplate},
+			notify.ChannelWhatsApp: {Body: config.OTP.WhatsAppTemplate},
+			notify.ChannelEmail:    {Subject: config.OTP.EmailSubject, Body: config.OTP.EmailTemplate},
+		},
+	}, services.OTPSecurityPolicy{
+		MaxAttempts:         config.OTP.MaxAttempts,
+		SendLimitPerPhone:   config.OTP.SendLimitPerPhone,
+		SendLimitPerIP:      config.OTP.SendLimitPerIP,
+		VerifyLimitPerPhone: config.OTP.VerifyLimitPerPhone,
+		VerifyLimitPerIP:    config.OTP.VerifyLimitPerIP,
+		LockoutFailures:     config.OTP.LockoutFailures,
+		LockoutDuration:     time.Duration(config.OTP.LockoutMinutes) * time.Minute,
+		HashKey:             config.OTP.HashKey,
+	})
+	passwordService := services.NewPasswordService(userRepo, passwordHistoryRepo, auditLogRepo, otpService, sessionService, emailSender, redisCache, services.PasswordPolicy{
+		Hasher: auth.PasswordHasher{
+			Algorithm:  config.Password.HashAlgorithm,
+			BcryptCost: config.Password.BcryptCost,
+			Argon2: auth.Argon2Params{
+				Memory:      uint32(config.Password.Argon2MemoryKB),
+				Iterations:  uint32(config.Password.Argon2Iterations),
+				Parallelism: uint8(config.Password.Argon2Parallelism),
+			},
+		},
+		Strength:      auth.PasswordPolicy{MinLength: config.Password.MinLength, MinClasses: config.Password.MinClasses},
+		HistorySize:   config.Password.HistorySize,
+		ResetURL:      config.Password.ResetURL,
+		ResetTokenTTL: time.Duration(config.Password.ResetTokenMinutes) * time.Minute,
+	})
+	authService := services.NewAuthService(userRepo, sessionService, passwordService, redisCache)
+	staffService := services.NewStaffService(staffRepo, restaurantRepo, userRepo, auditLogRepo, otpService, sessionService)
 
-	restaurantService := services.NewRestaurantService(restaurantRepo)
-	productService := services.NewProductService(productRepo, categoryRepo, inventoryRepo, redisCache, kafkaProducer, config.Kafka.Brokers)
+	identityService := services.NewIdentityService(userIdentityRepo, userRepo, auditLogRepo, sessionService, []auth.IdentityProvider{
+		auth.NewGoogleProvider(config.Identity.GoogleClientIDs),
+		auth.NewAppleProvider(config.Identity.AppleClientIDs),
+		auth.NewTruecallerProvider(config.Identity.TruecallerBaseURL, config.Identity.TruecallerClientID, config.Identity.TruecallerDefaultCountryCode),
+	})
+
+	profileService := services.NewProfileService(userRepo, auditLogRepo, otpService, redisCache)
+	dietaryPreferenceService := services.NewDietaryPreferenceService(dietaryPreferenceRepo, redisCache)
+
+	restaurantService := services.NewRestaurantService(restaurantRepo, restaurantPauseRepo, orderRepo)
+	restaurantPauseService := services.NewRestaurantPauseService(restaurantPauseRepo, restaurantRepo, staffRepo, redisCache)
+	bannerService := services.NewBannerService(bannerRepo, restaurantRepo, redisCache)
+	highlightService := services.NewHighlightService(highlightRepo, productRepo, redisCache)
+	menuSectionService := services.NewMenuSectionService(menuSectionRepo, productRepo, restaurantRepo, redisCache)
+	adminDashboardService := services.NewAdminDashboardService(dashboardRepo, redisCache, config.Payout.DefaultCommissionPercent)
+	productService := services.NewProductService(productRepo, categoryRepo, inventoryRepo, priceHistoryRepo, redisCache, kafkaProducer, config.Kafka.Brokers)
 	categoryService := services.NewCategoryService(categoryRepo, productRepo, redisCache)
-	orderService := services.NewOrderService(orderRepo, cartRepo, paymentRepo, userRepo, inventoryRepo, redisCache, kafkaProducer, config.Kafka.Brokers)
+	productImportService := services.NewProductImportService(productService, productRepo, categoryRepo, inventoryRepo, redisCache)
+	shoptimeService := services.NewShopTimeService(restaurantRepo, timeRangeProductRepo, productRepo, specialHoursRepo, restaurantPauseRepo, redisCache)
+	storefrontService := services.NewStorefrontService(shoptimeService, categoryService, highlightService, bannerService, redisCache)
+	orderTrackingService := services.NewOrderTrackingService(orderRepo, deliveryLocationRepo, redisCache, kafkaProducer, config.Kafka.Brokers)
+	riskService := services.NewRiskService(riskRepo, userRepo, addressRepo, refundRepo, blocklistService)
+	// Double-entry ledger; payments, refunds, payouts and meal plan bills post their money to it
+	ledgerService := services.NewLedgerService(ledgerRepo, outboxRepo)
+	tableService := services.NewTableService(tableRepo, tableTabRepo, restaurantRepo, ledgerService, config.Cart.TableURL)
+	orderService := services.NewOrderService(orderRepo, cartRepo, paymentRepo, userRepo, inventoryRepo, productRepo, restaurantService, shoptimeService, orderTrackingService, riskService, tableService, redisCache)
+	riskService.SetOrderCanceller(orderService)
 
 	// Delivery and payment services
-	deliveryPartnerService := services.NewDeliveryPartnerService(restaurantRepo, deliveryPartnerRepo, restaurantDeliveryPartnerRepo, orderRepo, porterDeliveryRepo)
-	porterService := services.NewPorterService(orderRepo, porterDeliveryRepo)
-	razorpayService := services.NewRazorpayService(config.Razorpay.KeyID, config.Razorpay.KeySecret, config.Razorpay.WebhookSecret, paymentRepo, orderRepo, deliveryPartnerService)
+	porterService := services.NewPorterService(config.Porter.APIKey, config.Porter.BaseURL, orderRepo, addressRepo, porterDeliveryRepo, orderTrackingService, services.PorterReconcilePolicy{
+		StaleAfter: time.Duration(config.Porter.ReconcileAfterMinutes) * time.Minute,
+		StuckAfter: time.Duration(config.Porter.StuckAfterMinutes) * time.Minute,
+	})
+	riderService := services.NewRiderService(riderRepo, riderAssignmentRepo, userRepo, orderRepo, orderTrackingService, smsService)
+	deliveryProviders := services.NewDeliveryProviderRegistry(config.Delivery.DefaultProvider)
+	for _, name := range config.Delivery.EnabledProviders {
+		switch name {
+		case "porter":
+			deliveryProviders.Register(services.NewPorterProvider(porterService))
+		case "self":
+			deliveryProviders.Register(services.NewSelfDeliveryProvider(riderService))
+		default:
+			log.Printf("Unknown delivery provider %q in DELIVERY_PROVIDERS, skipping", name)
+		}
+	}
+	deliveryPartnerService := services.NewDeliveryPartnerService(restaurantRepo, deliveryPartnerRepo, restaurantDeliveryPartnerRepo, orderRepo, porterDeliveryRepo, deliveryProviders)
+	partnerAPIService := services.NewPartnerAPIService(partnerAPIKeyRepo, deliveryPartnerRepo, restaurantDeliveryPartnerRepo, restaurantRepo, orderRepo, orderService, redisCache)
+	// Product, category and restaurant images with periodic orphan cleanup
+	objectStorage, err := storage.NewObjectStorage(
+		config.Storage.Endpoint, config.Storage.Region, config.Storage.Bucket,
+		config.Storage.AccessKey, config.Storage.SecretKey, config.Storage.PublicBaseURL,
+	)
+	if err != nil {
+		log.Fatalf("Failed to configure media storage: %v", err)
+	}
+	mediaService := services.NewMediaService(mediaRepo, productRepo, categoryRepo, restaurantRepo, restaurantDocumentRepo, invoiceRepo, supportTicketRepo, objectStorage, redisCache, services.MediaPolicy{
+		UploadURLTTL:   time.Duration(config.Storage.UploadURLMinutes) * time.Minute,
+		MaxUploadBytes: config.Storage.MaxUploadBytes,
+	})
+	if err := mediaService.Start(); err != nil {
+		log.Printf("Failed to start media cleanup: %v", err)
+	}
+	defer mediaService.Stop()
+
+	// Support tickets; failed refunds and deliveries open tickets by themselves
+	supportService := services.NewSupportService(supportTicketRepo, orderRepo, adminRepo, mediaService)
+	dispatchService := services.NewDispatchService(dispatchRepo, orderRepo, restaurantRepo, deliveryPartnerService, supportService, services.DispatchPolicy{
+		Strategy:      config.Dispatch.Strategy,
+		TriggerStatus: config.Dispatch.TriggerStatus,
+		MaxAttempts:   config.Dispatch.MaxAttempts,
+	})
+	razorpayService := services.NewRazorpayService(
+		config.Razorpay.KeyID, config.Razorpay.KeySecret, config.Razorpay.WebhookSecret,
+		paymentRepo, orderRepo, savedPaymentMethodRepo, restaurantService, dispatchService, orderTrackingService, riskService, ledgerService,
+		kafkaProducer, config.Kafka.Brokers,
+		services.PaymentRecoveryPolicy{
+			MaxRetries:    config.Payment.MaxRetries,
+			ReminderAfter: time.Duration(config.Payment.ReminderMinutes) * time.Minute,
+			ExpireAfter:   time.Duration(config.Payment.ExpiryMinutes) * time.Minute,
+		},
+	)
+	razorpayService.SetOrderExpirer(orderService)
+	cateringService := services.NewCateringService(cateringQuoteRepo, cartRepo, orderRepo, paymentRepo, productRepo, addressRepo, restaurantRepo, restaurantService, razorpayService, services.CateringPolicy{
+		MinHeadcount:  config.Catering.MinHeadcount,
+		MinNotice:     time.Duration(config.Catering.MinNoticeHours) * time.Hour,
+		QuoteValidity: time.Duration(config.Catering.QuoteValidityHours) * time.Hour,
+	})
+	mealPlanService := services.NewMealPlanService(mealPlanRepo, mealPlanSubscriptionRepo, cartRepo, orderRepo, paymentRepo, productRepo, addressRepo, restaurantRepo, restaurantService, razorpayService, ledgerService)
+	paymentMethodService := services.NewPaymentMethodService(savedPaymentMethodRepo, razorpayService)
+	priceScheduleService := services.NewPriceScheduleService(priceScheduleRepo, priceHistoryRepo, productRepo, productService)
+	reconciliationService := services.NewReconciliationService(reconciliationRepo, paymentRepo, mealPlanSubscriptionRepo, razorpayService)
 	// TODO: Uncomment when handlers are ready
-	refundService := services.NewRefundService(refundRepo, orderRepo, paymentRepo)
+	refundService := services.NewRefundService(refundRepo, orderRepo, paymentRepo, supportService, riskService, ledgerService)
 	// TODO: Uncomment when handler is used: paymentService := services.NewPaymentService(paymentRepo, orderRepo, cartRepo)
-	couponService := services.NewCouponService(couponRepo)
-	addressService := services.NewAddressService(addressRepo)
-	cartService := services.NewCartService(cartRepo, productRepo, orderRepo, paymentRepo, redisCache)
-	shoptimeService := services.NewShopTimeService(restaurantRepo, timeRangeProductRepo, productRepo, redisCache)
+	couponService := services.NewCouponService(couponRepo, orderRepo)
+	geocodeService := services.NewGeocodeService(geocoder(config.Geocoding), redisCache, services.GeocodePolicy{
+		CacheTTL: time.Duration(config.Geocoding.CacheHours) * time.Hour,
+		Strict:   config.Geocoding.Strict,
+	})
+	addressService := services.NewAddressService(addressRepo, geocodeService)
+	taxService := services.NewTaxService(taxConfigRepo, services.TaxDefaults{
+		GSTRate:      config.Tax.DefaultGSTRate,
+		PackagingFee: models.NewMoney(config.Tax.DefaultPackagingFee),
+		PlatformFee:  models.NewMoney(config.Tax.DefaultPlatformFee),
+	})
+	deliveryFeeService := services.NewDeliveryFeeService(deliveryPartnerService, restaurantRepo, deliveryBoundaryRepo, addressRepo, redisCache, services.DeliveryFeePolicy{
+		DefaultFee:         models.NewMoney(config.Delivery.DefaultFee),
+		FreeDeliveryAbove:  models.NewMoney(config.Delivery.FreeDeliveryAbove),
+		QuoteTTL:           time.Duration(config.Delivery.QuoteTTLSeconds) * time.Second,
+		MaxSurgeMultiplier: config.Delivery.MaxSurgeMultiplier,
+	})
+	pricingService := services.NewPricingService(pricingRuleRepo, restaurantRepo, redisCache)
+	cartService := services.NewCartService(cartRepo, productRepo, orderRepo, paymentRepo, restaurantService, couponService, taxService, deliveryFeeService, pricingService, orderTrackingService, riskService, tableService, redisCache)
+	groupOrderService := services.NewGroupOrderService(cartService, userRepo, featureFlagService, redisCache)
+	otpService.SetGuestCartMerger(cartService)
+	cartService.SetAbandonmentPolicy(services.CartAbandonmentPolicy{
+		After:              time.Duration(config.Cart.AbandonAfterHours) * time.Hour,
+		RecoveryCouponCode: config.Cart.RecoveryCouponCode,
+	})
+	searchService := services.NewSearchService(deliveryBoundaryRepo, addressRepo, productRepo, redisCache)
+
+	// Restaurant auto open/close, scheduled order release, unpaid order expiry and cart abandonment
+	enhancedCronService := services.NewEnhancedCronService(restaurantRepo, specialHoursRepo, orderService, razorpayService, porterService, cartService, restaurantPauseService)
+	if err := enhancedCronService.StartAutomaticStatusManagement(); err != nil {
+		log.Printf("Failed to start cron service: %v", err)
+	}
+	defer enhancedCronService.StopAutomaticStatusManagement()
+
+	// Nightly popularity badges
+	popularityService := services.NewPopularityService(restaurantRepo, orderRepo, productRepo, redisCache)
+	if err := popularityService.Start(); err != nil {
+		log.Printf("Failed to start popularity service: %v", err)
+	}
+	defer popularityService.Stop()
+
+	// Weekly restaurant settlements against the commission terms in effect at order time
+	commissionService := services.NewCommissionService(commissionRepo, restaurantRepo, auditLogRepo, config.Payout.DefaultCommissionPercent)
+	payoutService := services.NewPayoutService(payoutRepo, commissionRepo, restaurantRepo, couponRepo, restaurantDocumentRepo, staffRepo, ledgerService, services.PayoutPolicy{
+		DefaultCommissionPercent: config.Payout.DefaultCommissionPercent,
+		GatewayFeePercent:        config.Payout.GatewayFeePercent,
+		FeeGSTRate:               config.Payout.FeeGSTRate,
+	})
+	if err := payoutService.Start(); err != nil {
+		log.Printf("Failed to start payout service: %v", err)
+	}
+	defer payoutService.Stop()
+
+	// Nightly "customers also ordered" recommendations
+	recommendationService := services.NewRecommendationService(orderRepo, productRepo, restaurantRepo, userActivityRepo, redisCache)
+	if err := recommendationService.Start(); err != nil {
+		log.Printf("Failed to start recommendation service: %v", err)
+	}
+	defer recommendationService.Stop()
+
+	// Restaurant analytics from order_completed events
+	restaurantAnalyticsService := services.NewRestaurantAnalyticsService(restaurantAnalyticsRepo, orderRepo, productRepo, restaurantRepo, redisCache)
+	restaurantAnalyticsService.Subscribe(kafkaConsumer, config.Kafka.GroupID+"-analytics")
+
+	// Brands share a master menu with their outlets
+	franchiseService := services.NewFranchiseService(restaurantRepo, productRepo, categoryRepo, orderRepo, productService, categoryService, restaurantAnalyticsService)
+
+	// Stock reservations from order events; products go unavailable when stock runs out
+	inventoryService := services.NewInventoryService(inventoryRepo, productRepo, orderRepo, restaurantRepo, auditLogRepo, redisCache, kafkaProducer, config.Kafka.Brokers)
+	inventoryService.Subscribe(kafkaConsumer, config.Kafka.GroupID+"-inventory")
+
+	// Deliveries are booked automatically when orders reach the dispatch trigger status
+	dispatchService.Subscribe(kafkaConsumer, config.Kafka.GroupID+"-dispatch")
+
+	// Coupon uses are given back when an order is cancelled
+	couponService.Subscribe(kafkaConsumer, config.Kafka.GroupID+"-coupons")
+
+	// GST invoices for delivered orders, emailed to the customer
+	invoiceService := services.NewInvoiceService(invoiceRepo, orderRepo, restaurantRepo, userRepo, mediaService, emailSender)
+	invoiceService.Subscribe(kafkaConsumer, config.Kafka.GroupID+"-invoices")
+
+	// Signed order and payment webhooks for restaurant POS integrations
+	webhookService := services.NewWebhookService(webhookRepo, restaurantRepo, time.Duration(config.Webhooks.TimeoutSec)*time.Second, messaging.RetryPolicy{
+		MaxAttempts:    config.Webhooks.MaxAttempts,
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     time.Duration(config.Webhooks.MaxBackoffSec) * time.Second,
+	})
+	webhookService.Subscribe(kafkaConsumer, config.Kafka.GroupID+"-webhooks")
+	webhookService.Start()
+	defer webhookService.Stop()
+
+	// Kitchen tickets, printed through printer agents subscribed to ticket.print webhooks
+	kitchenTicketService := services.NewKitchenTicketService(orderRepo, webhookService)
+	kitchenTicketService.Subscribe(kafkaConsumer, config.Kafka.GroupID+"-tickets")
+
+	// Order chat between customers, restaurants and riders, closed when the order ends
+	orderChatService := services.NewOrderChatService(orderChatRepo, orderRepo, riderRepo, riderAssignmentRepo, supportTicketRepo, redisCache,
+		time.Duration(config.Retention.OrderChatDays)*24*time.Hour,
+		services.NewWordFilter(config.Chat.BlockedWords),
+	)
+	orderChatService.SubscribeOrders(kafkaConsumer, config.Kafka.GroupID+"-chat")
+
+	// Ledger postings that failed when their payment, refund, payout or bill was saved
+	ledgerService.Subscribe(kafkaConsumer, config.Kafka.GroupID+"-ledger")
+
+	// Consumers start once every service has subscribed
+	if err := kafkaConsumer.Start(); err != nil {
+		log.Printf("Failed to start Kafka consumer: %v", err)
+	}
+	defer kafkaConsumer.Stop()
+
+	// Search and user activity logging, batched into MongoDB
+	activityTracker := services.NewActivityTracker(searchLogRepo, userActivityRepo)
+	activityTracker.Start()
+	defer activityTracker.Stop()
+
+	restaurantOnboardingService := services.NewRestaurantOnboardingService(restaurantRepo, restaurantDocumentRepo, auditLogRepo, mediaService)
+	accountService := services.NewAccountService(
+		userRepo, addressRepo, orderRepo, paymentRepo, refundRepo, reviewRepo, userIdentityRepo,
+		searchLogRepo, userActivityRepo, auditLogRepo, sessionService, objectStorage, redisCache,
+		services.AccountDataPolicy{
+			HashKey:      config.Privacy.HashKey,
+			ExportURLTTL: time.Duration(config.Privacy.ExportURLMinutes) * time.Minute,
+		},
+	)
+
+	// Soft-delete restores, nightly order archival and purging of long-deleted records
+	archiveService := services.NewArchiveService(orderRepo, restaurantRepo, productRepo, productService, services.RetentionPolicy{
+		OrderArchiveAfter: time.Duration(config.Retention.OrderArchiveMonths) * 30 * 24 * time.Hour,
+		PurgeDeletedAfter: time.Duration(config.Retention.PurgeDeletedDays) * 24 * time.Hour,
+	})
+
+	// Moves stored personal data onto the active encryption key
+	piiService := services.NewPIIService(piiRepo)
+
+	// Workers start once every service has registered its jobs
+	productImportService.RegisterJobs(jobQueue)
+	deliveryPartnerService.RegisterJobs(jobQueue)
+	accountService.RegisterJobs(jobQueue)
+	archiveService.RegisterJobs(jobQueue)
+	orderChatService.RegisterJobs(jobQueue)
+	piiService.RegisterJobs(jobQueue)
+	mealPlanService.RegisterJobs(jobQueue)
+	priceScheduleService.RegisterJobs(jobQueue)
+	reconciliationService.RegisterJobs(jobQueue)
+	jobQueue.Start()
+	defer jobQueue.Stop()
+
+	if err := archiveService.Start(); err != nil {
+		log.Printf("Failed to start archive service: %v", err)
+	}
+	defer archiveService.Stop()
+
+	if err := mealPlanService.Start(); err != nil {
+		log.Printf("Failed to start meal plan service: %v", err)
+	}
+	defer mealPlanService.Stop()
+
+	if err := priceScheduleService.Start(); err != nil {
+		log.Printf("Failed to start price schedule service: %v", err)
+	}
+	defer priceScheduleService.Stop()
+
+	if err := reconciliationService.Start(); err != nil {
+		log.Printf("Failed to start reconciliation service: %v", err)
+	}
+	defer reconciliationService.Stop()
 
 	// Initialize middleware
-	authMiddleware := middleware.NewAuthMiddleware(jwtManager)
+	authMiddleware := middleware.NewAuthMiddleware(jwtManager, sessionService)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService, otpService)
+	passwordHandler := handlers.NewPasswordHandler(passwordService)
+	staffHandler := handlers.NewStaffHandler(staffService)
+	deviceHandler := handlers.NewDeviceHandler(sessionService)
+	identityHandler := handlers.NewIdentityHandler(identityService)
+	profileHandler := handlers.NewProfileHandler(profileService)
+	dietaryPreferenceHandler := handlers.NewDietaryPreferenceHandler(dietaryPreferenceService)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	smsHandler := handlers.NewSMSHandler(smsService)
 	restaurantHandler := handlers.NewRestaurantHandler(restaurantService)
-	productHandler := handlers.NewProductHandler(productService, categoryService)
+	restaurantOnboardingHandler := handlers.NewRestaurantOnboardingHandler(restaurantOnboardingService)
+	restaurantPauseHandler := handlers.NewRestaurantPauseHandler(restaurantPauseService)
+	payoutHandler := handlers.NewPayoutHandler(payoutService)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationService)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService)
+	commissionHandler := handlers.NewCommissionHandler(commissionService)
+	pricingHandler := handlers.NewPricingHandler(pricingService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService, featureFlagService)
+	featureFlagHandler := handlers.NewFeatureFlagHandler(featureFlagService)
+	riskHandler := handlers.NewRiskHandler(riskService)
+	blocklistHandler := handlers.NewBlocklistHandler(blocklistService)
+	partnerAPIHandler := handlers.NewPartnerAPIHandler(partnerAPIService)
+	productHandler := handlers.NewProductHandler(productService, categoryService, activityTracker, dietaryPreferenceService)
+	priceScheduleHandler := handlers.NewPriceScheduleHandler(priceScheduleService)
 	orderHandler := handlers.NewOrderHandler(orderService)
 
 	// Additional handlers
 	refundHandler := handlers.NewRefundHandler(refundService)
 	couponHandler := handlers.NewCouponHandler(couponService)
-	addressHandler := handlers.NewAddressHandler(addressService)
-	cartHandler := handlers.NewCartHandler(cartService)
+	addressHandler := handlers.NewAddressHandler(addressService, geocodeService)
+	cartHandler := handlers.NewCartHandler(cartService, activityTracker)
 	shoptimeHandler := handlers.NewShopTimeHandler(shoptimeService)
+	searchHandler := handlers.NewSearchHandler(searchService, activityTracker, dietaryPreferenceService)
+	orderTrackingHandler := handlers.NewOrderTrackingHandler(orderTrackingService)
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenanceService)
 
 	// Payment and delivery handlers
 	// TODO: Uncomment when RegisterRoutes is implemented: paymentHandler := handlers.NewPaymentHandler(paymentService)
 	razorpayHandler := handlers.NewRazorpayHandler(razorpayService)
 	porterHandler := handlers.NewPorterHandler(porterService, porterDeliveryRepo, orderRepo, deliveryPartnerService)
+	deliveryHandler := handlers.NewDeliveryHandler(deliveryPartnerService, deliveryFeeService)
+	dispatchHandler := handlers.NewDispatchHandler(dispatchService)
+	outboxHandler := handlers.NewOutboxHandler(outboxService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	metricsHandler := handlers.NewMetricsHandler(db)
+	migrationHandler := handlers.NewMigrationHandler(migrationRunner)
+	piiHandler := handlers.NewPIIHandler(piiService)
+	jobHandler := handlers.NewJobHandler(jobQueue)
+	archiveHandler := handlers.NewArchiveHandler(archiveService)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
+	kitchenTicketHandler := handlers.NewKitchenTicketHandler(kitchenTicketService)
+	tableHandler := handlers.NewTableHandler(tableService)
+	cateringHandler := handlers.NewCateringHandler(cateringService)
+	mealPlanHandler := handlers.NewMealPlanHandler(mealPlanService)
+	paymentMethodHandler := handlers.NewPaymentMethodHandler(paymentMethodService)
+	supportHandler := handlers.NewSupportHandler(supportService)
+	orderChatHandler := handlers.NewOrderChatHandler(orderChatService)
+	groupOrderHandler := handlers.NewGroupOrderHandler(groupOrderService)
+	riderHandler := handlers.NewRiderHandler(riderService)
+	taxHandler := handlers.NewTaxHandler(taxService)
+	bannerHandler := handlers.NewBannerHandler(bannerService)
+	highlightHandler := handlers.NewHighlightHandler(highlightService)
+	menuSectionHandler := handlers.NewMenuSectionHandler(menuSectionService)
+	storefrontHandler := handlers.NewStorefrontHandler(storefrontService)
+	adminHandler := handlers.NewAdminHandler(adminService)
+	adminDashboardHandler := handlers.NewAdminDashboardHandler(adminDashboardService)
+	restaurantAnalyticsHandler := handlers.NewRestaurantAnalyticsHandler(restaurantAnalyticsService)
+	franchiseHandler := handlers.NewFranchiseHandler(franchiseService)
+	recommendationHandler := handlers.NewRecommendationHandler(recommendationService)
+	productImportHandler := handlers.NewProductImportHandler(productImportService)
+	mediaHandler := handlers.NewMediaHandler(mediaService)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
 
 	// Initialize Gin router
 	router := gin.Default()
@@ -128,6 +625,18 @@ func main() {
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
+	// Response language from Accept-Language
+	router.Use(middleware.Locale())
+
+	// Device, location and IP address for the fraud rules
+	router.Use(middleware.ClientInfo())
+
+	// Request IDs, echoed in X-Request-ID and the v2 envelope
+	router.Use(middleware.RequestIDMiddleware())
+
+	// Every request gets a deadline that its database, Mongo and Redis calls share
+	router.Use(middleware.RequestTimeout(config.Server.RequestTimeout))
+
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -136,45 +645,291 @@ func main() {
 		})
 	})
 
-	// API routes
-	api := router.Group("/api/v1")
+	// API routes. /api/v2 serves the same handlers with every JSON response in the v2 envelope
+	// (data, error, meta, request_id); v1 keeps its response shapes for existing clients.
+	v1 := router.Group("/api/v1")
+	v2 := router.Group("/api/v2")
+	v2.Use(middleware.Envelope())
+
+	for _, api := range []*gin.RouterGroup{v1, v2} {
+		prefix := api.BasePath()
+
+		// Error responses for handlers that report failures with c.Error
+		api.Use(middleware.ErrorHandler())
+
+		// Client session IDs for activity tracking
+		api.Use(middleware.SessionMiddleware())
 
-	// Register routes
-	authHandler.RegisterRoutes(api, authMiddleware)
-	restaurantHandler.RegisterRoutes(api, authMiddleware)
-	productHandler.RegisterRoutes(api, authMiddleware)
-	orderHandler.RegisterRoutes(api, authMiddleware)
+		// Read-only requests may be served from the Postgres read replica
+		api.Use(middleware.ReadReplica())
 
-	// Additional routes
-	addressHandler.RegisterRoutes(api, authMiddleware)
-	couponHandler.RegisterRoutes(api, authMiddleware)
-	cartHandler.RegisterRoutes(api, authMiddleware)
-	refundHandler.RegisterRoutes(api, authMiddleware)
-	shoptimeHandler.RegisterRoutes(api, authMiddleware)
+		// Read-only mode during maintenance; delivery/payment webhooks keep processing
+		api.Use(middleware.ReadOnlyDuringMaintenance(maintenanceService,
+			prefix+"/porter/webhook",
+			prefix+"/webhooks/",
+			prefix+"/delivery/webhooks/",
+			prefix+"/admin/maintenance",
+			prefix+"/admin/auth/",
+		))
 
-	// Payment and delivery routes
-	// TODO: Add paymentHandler.RegisterRoutes(api, authMiddleware) when RegisterRoutes is implemented
-	razorpayHandler.RegisterRoutes(api)
-	porterHandler.RegisterRoutes(api)
+		// Audit log of changes to restaurants, products, coupons, refunds and payouts
+		api.Use(middleware.Audit(auditService,
+			middleware.AuditRoute{Prefix: prefix + "/restaurants", EntityType: services.AuditEntityRestaurant, IDParam: "id"},
+			middleware.AuditRoute{Prefix: prefix + "/admin/restaurants", EntityType: services.AuditEntityRestaurant, IDParam: "id"},
+			middleware.AuditRoute{Prefix: prefix + "/admin/onboarding", EntityType: services.AuditEntityRestaurant, IDParam: "id"},
+			middleware.AuditRoute{Prefix: prefix + "/products", EntityType: services.AuditEntityProduct, IDParam: "id"},
+			middleware.AuditRoute{Prefix: prefix + "/admin/products", EntityType: services.AuditEntityProduct, IDParam: "id"},
+			middleware.AuditRoute{Prefix: prefix + "/brands/:id/products", EntityType: services.AuditEntityProduct, IDParam: "product_id"},
+			middleware.AuditRoute{Prefix: prefix + "/brands/:id/outlets/:outlet_id/products", EntityType: services.AuditEntityProduct, IDParam: "product_id"},
+			middleware.AuditRoute{Prefix: prefix + "/coupons/validate"},
+			middleware.AuditRoute{Prefix: prefix + "/coupons", EntityType: services.AuditEntityCoupon, IDParam: "id"},
+			middleware.AuditRoute{Prefix: prefix + "/refunds", EntityType: services.AuditEntityRefund, IDParam: "id"},
+			middleware.AuditRoute{Prefix: prefix + "/admin/payouts/:id", EntityType: services.AuditEntityPayout, IDParam: "id"},
+			middleware.AuditRoute{Prefix: prefix + "/admin/payouts", EntityType: services.AuditEntityPayoutBatch},
+		))
 
-	log.Printf("🚀 Server starting on port %s", config.Server.Port)
-	log.Fatal(router.Run(":" + config.Server.Port))
+		// Register routes
+		authHandler.RegisterRoutes(api, authMiddleware)
+		passwordHandler.RegisterRoutes(api, authMiddleware)
+		staffHandler.RegisterRoutes(api, authMiddleware)
+		deviceHandler.RegisterRoutes(api, authMiddleware)
+		identityHandler.RegisterRoutes(api, authMiddleware)
+		profileHandler.RegisterRoutes(api, authMiddleware)
+		dietaryPreferenceHandler.RegisterRoutes(api, authMiddleware)
+		accountHandler.RegisterRoutes(api, authMiddleware)
+		restaurantHandler.RegisterRoutes(api, authMiddleware)
+		restaurantOnboardingHandler.RegisterRoutes(api, authMiddleware)
+		restaurantPauseHandler.RegisterRoutes(api, authMiddleware)
+		payoutHandler.RegisterRoutes(api, authMiddleware)
+		reconciliationHandler.RegisterRoutes(api, authMiddleware)
+		ledgerHandler.RegisterRoutes(api, authMiddleware)
+		commissionHandler.RegisterRoutes(api, authMiddleware)
+		pricingHandler.RegisterRoutes(api, authMiddleware)
+		webhookHandler.RegisterRoutes(api, authMiddleware)
+		featureFlagHandler.RegisterRoutes(api, authMiddleware)
+		riskHandler.RegisterRoutes(api, authMiddleware)
+		blocklistHandler.RegisterRoutes(api, authMiddleware)
+		productHandler.RegisterRoutes(api, authMiddleware)
+		priceScheduleHandler.RegisterRoutes(api, authMiddleware)
+		orderHandler.RegisterRoutes(api, authMiddleware)
+
+		// Additional routes
+		addressHandler.RegisterRoutes(api, authMiddleware)
+		couponHandler.RegisterRoutes(api, authMiddleware)
+		cartHandler.RegisterRoutes(api, authMiddleware)
+		refundHandler.RegisterRoutes(api, authMiddleware)
+		shoptimeHandler.RegisterRoutes(api, authMiddleware)
+		searchHandler.RegisterRoutes(api, authMiddleware)
+		orderTrackingHandler.RegisterRoutes(api, authMiddleware)
+		maintenanceHandler.RegisterRoutes(api, authMiddleware)
+		riderHandler.RegisterRoutes(api, authMiddleware)
+		taxHandler.RegisterRoutes(api, authMiddleware)
+		bannerHandler.RegisterRoutes(api, authMiddleware)
+		highlightHandler.RegisterRoutes(api, authMiddleware)
+		menuSectionHandler.RegisterRoutes(api, authMiddleware)
+		storefrontHandler.RegisterRoutes(api, authMiddleware)
+		adminHandler.RegisterRoutes(api, authMiddleware)
+		adminDashboardHandler.RegisterRoutes(api, authMiddleware)
+		restaurantAnalyticsHandler.RegisterRoutes(api, authMiddleware)
+		franchiseHandler.RegisterRoutes(api, authMiddleware)
+		recommendationHandler.RegisterRoutes(api, authMiddleware)
+		productImportHandler.RegisterRoutes(api, authMiddleware)
+		mediaHandler.RegisterRoutes(api, authMiddleware)
+		inventoryHandler.RegisterRoutes(api, authMiddleware)
+
+		// Payment and delivery routes
+		// TODO: Add paymentHandler.RegisterRoutes(api, authMiddleware) when RegisterRoutes is implemented
+		razorpayHandler.RegisterRoutes(api, authMiddleware)
+		porterHandler.RegisterRoutes(api, authMiddleware)
+		deliveryHandler.RegisterRoutes(api, authMiddleware)
+		dispatchHandler.RegisterRoutes(api, authMiddleware)
+		outboxHandler.RegisterRoutes(api, authMiddleware)
+		auditHandler.RegisterRoutes(api, authMiddleware)
+		metricsHandler.RegisterRoutes(api, authMiddleware)
+		migrationHandler.RegisterRoutes(api, authMiddleware)
+		piiHandler.RegisterRoutes(api, authMiddleware)
+		jobHandler.RegisterRoutes(api, authMiddleware)
+		archiveHandler.RegisterRoutes(api, authMiddleware)
+		invoiceHandler.RegisterRoutes(api, authMiddleware)
+		kitchenTicketHandler.RegisterRoutes(api, authMiddleware)
+		tableHandler.RegisterRoutes(api, authMiddleware)
+		cateringHandler.RegisterRoutes(api, authMiddleware)
+		mealPlanHandler.RegisterRoutes(api, authMiddleware)
+		paymentMethodHandler.RegisterRoutes(api, authMiddleware)
+		supportHandler.RegisterRoutes(api, authMiddleware)
+		orderChatHandler.RegisterRoutes(api, authMiddleware)
+		groupOrderHandler.RegisterRoutes(api, authMiddleware)
+		smsHandler.RegisterRoutes(api, authMiddleware)
+		partnerAPIHandler.RegisterRoutes(api, authMiddleware)
+	}
+
+	// Partner API for delivery companies and aggregators, authenticated with API keys
+	partner := router.Group("/partner/v1")
+	partner.Use(middleware.ErrorHandler())
+	partner.Use(middleware.ReadOnlyDuringMaintenance(maintenanceService))
+	partnerAPIHandler.RegisterPartnerRoutes(partner, middleware.NewPartnerAuthMiddleware(partnerAPIService))
+
+	// Serve until SIGINT/SIGTERM, then drain requests so the deferred shutdowns (Kafka
+	// consumers, outbox relay, job workers, cron jobs) run before exit
+	server := &http.Server{
+		Addr:              ":" + config.Server.Port,
+		Handler:           router,
+		ReadHeaderTimeout: config.Server.ReadHeaderTimeout,
+		IdleTimeout:       config.Server.IdleTimeout,
+	}
+	go func() {
+		log.Printf("🚀 Server starting on port %s", config.Server.Port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+
+	log.Println("🛑 Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown failed: %v", err)
+	}
+}
+
+// logSlowQueries records slow database queries in system_logs
+func logSlowQueries(db *database.Database, systemLogRepo repositories.SystemLogRepository) {
+	db.OnSlowQuery(func(query database.SlowQuery) {
+		entry := &models.SystemLog{
+			Level:   "warning",
+			Service: "database",
+			Message: fmt.Sprintf("slow %s query took %dms", query.Store, query.Duration.Milliseconds()),
+			Data: map[string]interface{}{
+				"store":       query.Store,
+				"statement":   query.Statement,
+				"duration_ms": query.Duration.Milliseconds(),
+				"rows":        query.Rows,
+				"error":       query.Error,
+			},
+			Timestamp: query.OccurredAt,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := systemLogRepo.Create(ctx, entry); err != nil {
+			log.Printf("Failed to log slow %s query: %v", query.Store, err)
+		}
+	})
+}
+
+// smsProviders builds the configured SMS providers in failover order
+func smsProviders(cfg configs.SMSConfig) []sms.Provider {
+	var providers []sms.Provider
+	for _, name := range cfg.Providers {
+		switch name {
+		case "gateway":
+			providers = append(providers, sms.NewGatewayProvider(cfg.APIKey, cfg.SenderID, cfg.BaseURL))
+		case "msg91":
+			providers = append(providers, sms.NewMSG91Provider(
+				cfg.MSG91BaseURL, cfg.MSG91AuthKey, cfg.MSG91SenderID, cfg.MSG91Route, cfg.DefaultCountryCode, cfg.WebhookToken,
+			))
+		case "twilio":
+			statusCallbackURL := ""
+			if cfg.WebhookBaseURL != "" {
+				statusCallbackURL = strings.TrimSuffix(cfg.WebhookBaseURL, "/") + "/api/v1/webhooks/sms/twilio"
+			}
+			providers = append(providers, sms.NewTwilioProvider(
+				cfg.TwilioBaseURL, cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.TwilioFrom,
+				cfg.TwilioMessagingServiceSID, cfg.DefaultCountryCode, statusCallbackURL,
+			))
+		case "sns":
+			providers = append(providers, sms.NewSNSProvider(
+				cfg.SNSRegion, cfg.SNSAccessKey, cfg.SNSSecretKey, cfg.SNSSenderID, cfg.DefaultCountryCode,
+			))
+		default:
+			log.Printf("Ignoring unknown SMS provider %q", name)
+		}
+	}
+	return providers
+}
+
+// geocoder builds the configured address lookup provider, or nil when none is configured
+func geocoder(cfg configs.GeocodingConfig) geo.Geocoder {
+	switch cfg.Provider {
+	case "google":
+		return geo.NewGoogleGeocoder(cfg.GoogleAPIKey, cfg.CountryCode)
+	case "nominatim":
+		return geo.NewNominatimGeocoder(cfg.NominatimBaseURL, cfg.UserAgent, cfg.CountryCode)
+	case "":
+		return nil
+	default:
+		log.Printf("Ignoring unknown geocoding provider %q", cfg.Provider)
+		return nil
+	}
 }
 
+// autoMigratePostgres keeps development databases in step with the models. Release builds
+// rely on the versioned migrations instead.
 func autoMigratePostgres(db *database.Database) error {
 	return db.Postgres.AutoMigrate(
 		&models.User{},
 		&models.Restaurant{},
 		&models.RestaurantDeliveryPartners{},
 		&models.RestaurantDeliveryLocationBoundary{},
+		&models.RestaurantSpecialHours{},
+		&models.RestaurantPause{},
 		&models.CommissionToRestaurant{},
 		&models.Cart{},
 		&models.Order{},
 		&models.OrderLog{},
 		&models.Payment{},
 		&models.Refund{},
+		&models.SupportTicket{},
+		&models.SupportTicketMessage{},
 		&models.DeliveryPartnerCompany{},
+		&models.PartnerAPIKey{},
 		&models.PorterDelivery{},
 		&models.OTP{}, // Add OTP model for SMS authentication
+		&models.MaintenanceWindow{},
+		&models.FeatureFlag{},
+		&models.RiskRule{},
+		&models.RiskAssessment{},
+		&models.BlocklistEntry{},
+		&models.Rider{},
+		&models.RiderAssignment{},
+		&models.RestaurantTable{},
+		&models.TableTab{},
+		&models.CateringQuote{},
+		&models.MealPlan{},
+		&models.MealPlanSubscription{},
+		&models.MealPlanDelivery{},
+		&models.MealPlanBill{},
+		&models.PaymentCustomer{},
+		&models.SavedPaymentMethod{},
+		&models.PaymentReconciliation{},
+		&models.ReconciliationMismatch{},
+		&models.LedgerAccount{},
+		&models.JournalEntry{},
+		&models.JournalLine{},
+		&models.DietaryPreference{},
+		&models.RestaurantTaxConfig{},
+		&models.AdminUser{},
+		&models.AuditLog{},
+		&models.AuthSession{},
+		&models.PasswordHistory{},
+		&models.UserIdentity{},
+		&models.SMSDelivery{},
+		&models.CouponRedemption{},
+		&models.PricingRule{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.RestaurantDocument{},
+		&models.DeliveryDispatch{},
+		&models.OutboxEvent{},
+		&models.RestaurantStaff{},
+		&models.PayoutBatch{},
+		&models.RestaurantPayout{},
+		&models.OrderSettlement{},
+		&models.ArchivedOrder{},
+		&models.Invoice{},
+		&models.InvoiceCounter{},
 	)
 }
@@ -0,0 +1,144 @@
+// Command migrate applies and reverts the versioned database migrations.
+//
+//	migrate up              apply every pending migration
+//	migrate down [steps]    revert the last applied migrations (default 1)
+//	migrate status          list migrations and whether each is applied
+//	migrate create <name>   add an empty up/down pair to the migrations directory
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-food-backend/configs"
+	"golang-food-backend/internal/migrations"
+	"golang-food-backend/pkg/database"
+)
+
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+func main() {
+	dir := flag.String("dir", "migrations", "directory new migrations are created in")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: migrate [-dir migrations] up | down [steps] | status | create <name>")
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		if err := create(*dir, args[1]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	config, err := configs.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	db, err := database.NewDatabase(config.Database.PostgresURL, config.Database.MongoURL, config.Database.MongoDBName, database.Options{
+		MaxOpenConns:    2,
+		MaxIdleConns:    1,
+		ConnMaxLifetime: time.Hour,
+	})
+	if err != nil {
+		log.Fatal("Failed to connect to databases:", err)
+	}
+	defer db.Close()
+
+	runner, err := migrations.NewRunner(db)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := runner.Up(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Applied %d migrations\n", len(applied))
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatalf("invalid number of steps %q", args[1])
+			}
+		}
+		reverted, err := runner.Down(ctx, steps)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Reverted %d migrations\n", len(reverted))
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			switch {
+			case status.Unknown:
+				state = "unknown " + status.AppliedAt.Format(time.RFC3339)
+			case status.Applied:
+				state = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%-45s %s\n", status.ID, state)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// create adds an empty migration pair named after today's date and the next free sequence number
+func create(dir, name string) error {
+	if !migrationName.MatchString(name) {
+		return fmt.Errorf("migration name %q must be lowercase letters, digits and underscores", name)
+	}
+
+	prefix := time.Now().Format("20060102")
+	existing, err := filepath.Glob(filepath.Join(dir, prefix+"_*.up.sql"))
+	if err != nil {
+		return err
+	}
+	sequence := 0
+	for _, path := range existing {
+		parts := strings.SplitN(filepath.Base(path), "_", 3)
+		if n, err := strconv.Atoi(parts[1]); err == nil && n > sequence {
+			sequence = n
+		}
+	}
+
+	id := fmt.Sprintf("%s_%02d_%s", prefix, sequence+1, name)
+	files := map[string]string{
+		id + ".up.sql":   "-- " + strings.ReplaceAll(name, "_", " ") + "\n",
+		id + ".down.sql": "-- revert " + strings.ReplaceAll(name, "_", " ") + "\n",
+	}
+	for file, content := range files {
+		path := filepath.Join(dir, file)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return err
+		}
+		fmt.Println("Created", path)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package configs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// secretFetchTimeout bounds the secret store request made at startup
+const secretFetchTimeout = 10 * time.Second
+
+// SecretsConfig selects where credentials are fetched from at startup: vault (a KV secret),
+// aws (a Secrets Manager secret) or empty to read them from the environment and config files
+// only. Either secret holds a JSON object keyed by setting name, e.g. {"JWT_SECRET": "..."}.
+type SecretsConfig struct {
+	Provider string
+
+	VaultAddr      string
+	VaultToken     string
+	VaultNamespace string
+	VaultPath      string // API path under /v1, e.g. secret/data/food-backend for KV v2
+
+	AWSRegion       string
+	AWSSecretID     string
+	AWSAccessKey    string
+	AWSSecretKey    string
+	AWSSessionToken string
+}
+
+func loadSecretsConfig() SecretsConfig {
+	return SecretsConfig{
+		Provider:        getEnv("SECRETS_PROVIDER", ""),
+		VaultAddr:       getEnv("VAULT_ADDR", ""),
+		VaultToken:      getEnv("VAULT_TOKEN", ""),
+		VaultNamespace:  getEnv("VAULT_NAMESPACE", ""),
+		VaultPath:       getEnv("VAULT_SECRET_PATH", ""),
+		AWSRegion:       getEnv("SECRETS_AWS_REGION", getEnv("AWS_REGION", "ap-south-1")),
+		AWSSecretID:     getEnv("SECRETS_AWS_SECRET_ID", ""),
+		AWSAccessKey:    getEnv("AWS_ACCESS_KEY_ID", ""),
+		AWSSecretKey:    getEnv("AWS_SECRET_ACCESS_KEY", ""),
+		AWSSessionToken: getEnv("AWS_SESSION_TOKEN", ""),
+	}
+}
+
+// fetchSecrets reads the configured secret store's values
+func fetchSecrets(cfg SecretsConfig) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretFetchTimeout)
+	defer cancel()
+
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "vault":
+		if cfg.VaultAddr == "" || cfg.VaultToken == "" || cfg.VaultPath == "" {
+			return nil, fmt.Errorf("vault needs VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH")
+		}
+		return fetchVaultSecret(ctx, cfg)
+	case "aws":
+		if cfg.AWSSecretID == "" || cfg.AWSAccessKey == "" || cfg.AWSSecretKey == "" {
+			return nil, fmt.Errorf("aws needs SECRETS_AWS_SECRET_ID, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		return fetchAWSSecret(ctx, cfg, time.Now())
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q (use vault or aws)", cfg.Provider)
+	}
+}
+
+// fetchVaultSecret reads a KV secret, accepting both the v2 ({"data":{"data":{...}}}) and v1
+// ({"data":{...}}) response shapes
+func fetchVaultSecret(ctx context.Context, cfg SecretsConfig) (map[string]string, error) {
+	url := strings.TrimSuffix(cfg.VaultAddr, "/") + "/v1/" + strings.TrimPrefix(cfg.VaultPath, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", cfg.VaultToken)
+	if cfg.VaultNamespace != "" {
+		req.Header.Set("X-Vault-Namespace", cfg.VaultNamespace)
+	}
+
+	body, err := doSecretRequest(req, "vault")
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("vault: failed to decode secret: %w", err)
+	}
+	if nested, ok := result.Data["data"].(map[string]interface{}); ok {
+		if _, versioned := result.Data["metadata"]; versioned {
+			return secretValues(nested), nil
+		}
+	}
+	return secretValues(result.Data), nil
+}
+
+// fetchAWSSecret calls Secrets Manager's GetSecretValue; the secret string must be a JSON object
+func fetchAWSSecret(ctx context.Context, cfg SecretsConfig, now time.Time) (map[string]string, error) {
+	payload, err := json.Marshal(map[string]string{"SecretId": cfg.AWSSecretID})
+	if err != nil {
+		return nil, err
+	}
+
+	url := "https://secretsmanager." + cfg.AWSRegion + ".amazonaws.com/"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signAWSRequest(req, payload, cfg, now)
+
+	body, err := doSecretRequest(req, "aws")
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("aws: failed to decode secret: %w", err)
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(result.SecretString), &values); err != nil {
+		return nil, fmt.Errorf("aws: secret %s is not a JSON object of settings", cfg.AWSSecretID)
+	}
+	return secretValues(values), nil
+}
+
+func doSecretRequest(req *http.Request, provider string) ([]byte, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", provider, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: secret request failed with status %d", provider, resp.StatusCode)
+	}
+	return body, nil
+}
+
+// secretValues flattens a secret's fields to setting values; non-string fields keep their
+// JSON form so numbers and booleans parse like environment values
+func secretValues(fields map[string]interface{}) map[string]string {
+	values := make(map[string]string, len(fields))
+	for key, field := range fields {
+		switch value := field.(type) {
+		case string:
+			values[key] = value
+		case nil:
+		default:
+			encoded, _ := json.Marshal(value)
+			values[key] = string(encoded)
+		}
+	}
+	return values
+}
+
+// signAWSRequest adds a SigV4 Authorization header for Secrets Manager
+func signAWSRequest(req *http.Request, payload []byte, cfg SecretsConfig, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	scope := day + "/" + cfg.AWSRegion + "/secretsmanager/aws4_request"
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := []string{
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + req.URL.Host,
+		"x-amz-date:" + amzDate,
+	}
+	signedHeaders := "content-type;host;x-amz-date"
+	if cfg.AWSSessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", cfg.AWSSessionToken)
+		headers = append(headers, "x-amz-security-token:"+cfg.AWSSessionToken)
+		signedHeaders += ";x-amz-security-token"
+	}
+	headers = append(headers, "x-amz-target:"+req.Header.Get("X-Amz-Target"))
+	signedHeaders += ";x-amz-target"
+
+	payloadHash := sha256.Sum256(payload)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		strings.Join(headers, "\n"),
+		"",
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+cfg.AWSSecretKey), day)
+	signingKey = hmacSHA256(signingKey, cfg.AWSRegion)
+	signingKey = hmacSHA256(signingKey, "secretsmanager")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.AWSAccessKey, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,196 @@
+package configs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sources resolves a setting from, in order of precedence: the process environment, a file
+// named by <KEY>_FILE (mounted Docker/Kubernetes secrets), the secret store, the config files
+// and finally the default in LoadConfig
+type sources struct {
+	files   map[string]string
+	secrets map[string]string
+	errs    []error
+}
+
+// current is the set of sources LoadConfig is reading from
+var current = &sources{}
+
+func (s *sources) lookup(key string) (string, bool) {
+	if value := os.Getenv(key); value != "" {
+		return value, true
+	}
+	if path := os.Getenv(key + "_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			s.fail(key+"_FILE", err)
+			return "", false
+		}
+		return strings.TrimRight(string(data), "\r\n"), true
+	}
+	if value, ok := s.secrets[key]; ok && value != "" {
+		return value, true
+	}
+	if value, ok := s.files[key]; ok && value != "" {
+		return value, true
+	}
+	return "", false
+}
+
+func (s *sources) fail(key string, err error) {
+	s.errs = append(s.errs, fmt.Errorf("%s: %w", key, err))
+}
+
+// loadFiles reads the comma-separated CONFIG_FILE list, later files overriding earlier ones.
+// Without CONFIG_FILE a .env in the working directory is read when there is one.
+func (s *sources) loadFiles() {
+	s.files = map[string]string{}
+	paths, explicit := os.Getenv("CONFIG_FILE"), true
+	if paths == "" {
+		paths, explicit = ".env", false
+	}
+
+	for _, path := range strings.Split(paths, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		values, err := readEnvFile(path)
+		if err != nil {
+			if !explicit && errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			s.fail("CONFIG_FILE", err)
+			continue
+		}
+		for key, value := range values {
+			s.files[key] = value
+		}
+	}
+}
+
+// readEnvFile parses KEY=VALUE lines, skipping blank lines and # comments. An "export " prefix
+// and matching quotes around the value are dropped.
+func readEnvFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNo)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+func getEnv(key, defaultValue string) string {
+	if value, ok := current.lookup(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvList(key, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, ok := current.lookup(key); ok {
+		intValue, err := strconv.Atoi(value)
+		if err != nil {
+			current.fail(key, fmt.Errorf("%q is not a whole number", value))
+			return defaultValue
+		}
+		return intValue
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, ok := current.lookup(key); ok {
+		floatValue, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			current.fail(key, fmt.Errorf("%q is not a number", value))
+			return defaultValue
+		}
+		return floatValue
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, ok := current.lookup(key); ok {
+		boolValue, err := strconv.ParseBool(value)
+		if err != nil {
+			current.fail(key, fmt.Errorf("%q is not true or false", value))
+			return defaultValue
+		}
+		return boolValue
+	}
+	return defaultValue
+}
+
+// getEnvDuration parses Go durations such as 90s, 15m or 1h30m
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, ok := current.lookup(key); ok {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			current.fail(key, fmt.Errorf("%q is not a duration such as 30s or 5m", value))
+			return defaultValue
+		}
+		return duration
+	}
+	return defaultValue
+}
+
+// getEnvBytes parses sizes such as 512KB, 5MB or 1GB (binary multiples); a bare number is bytes
+func getEnvBytes(key string, defaultValue int64) int64 {
+	value, ok := current.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+
+	number, unit := strings.ToUpper(value), int64(1)
+	for _, suffix := range []struct {
+		name string
+		size int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"B", 1}} {
+		if strings.HasSuffix(number, suffix.name) {
+			number, unit = strings.TrimSpace(strings.TrimSuffix(number, suffix.name)), suffix.size
+			break
+		}
+	}
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || size < 0 {
+		current.fail(key, fmt.Errorf("%q is not a size such as 512KB or 5MB", value))
+		return defaultValue
+	}
+	return size * unit
+}
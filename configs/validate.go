@@ -0,0 +1,149 @@
+package configs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// minReleaseSecretLength is the shortest JWT secret, privacy hash key and OTP hash key accepted
+// in release mode
+const minReleaseSecretLength = 32
+
+// Validate reports every missing or inconsistent setting at once so a misconfigured deployment
+// fails at startup instead of on the first request that needs the value. Credentials are
+// required for each provider that is enabled; release mode also rejects short secrets.
+func (c *Config) Validate() error {
+	var problems []string
+	require := func(value, key, reason string) {
+		if strings.TrimSpace(value) == "" {
+			problems = append(problems, key+" is required"+reason)
+		}
+	}
+
+	if _, err := strconv.Atoi(c.Server.Port); err != nil {
+		problems = append(problems, fmt.Sprintf("SERVER_PORT %q is not a port number", c.Server.Port))
+	}
+	switch c.Server.Mode {
+	case "debug", "release", "test":
+	default:
+		problems = append(problems, fmt.Sprintf("GIN_MODE %q must be debug, release or test", c.Server.Mode))
+	}
+
+	require(c.Database.PostgresURL, "POSTGRES_URL", "")
+	require(c.Database.MongoURL, "MONGO_URL", "")
+	require(c.Redis.URL, "REDIS_URL", "")
+	if len(c.Kafka.Brokers) == 0 {
+		problems = append(problems, "KAFKA_BROKERS is required")
+	}
+	require(c.JWT.SecretKey, "JWT_SECRET", "")
+	require(c.Privacy.HashKey, "PRIVACY_HASH_KEY", "")
+	require(c.OTP.HashKey, "OTP_HASH_KEY", "")
+	require(c.Razorpay.KeyID, "RAZORPAY_KEY_ID", "")
+	require(c.Razorpay.KeySecret, "RAZORPAY_KEY_SECRET", "")
+	require(c.Razorpay.WebhookSecret, "RAZORPAY_WEBHOOK_SECRET", "")
+
+	for _, provider := range c.Delivery.EnabledProviders {
+		if provider == "porter" {
+			require(c.Porter.APIKey, "PORTER_API_KEY", " with the porter delivery provider")
+			require(c.Porter.BaseURL, "PORTER_BASE_URL", " with the porter delivery provider")
+		}
+	}
+
+	for _, provider := range c.SMS.Providers {
+		with := " with the " + provider + " SMS provider"
+		switch provider {
+		case "gateway":
+			require(c.SMS.APIKey, "SMS_API_KEY", with)
+			require(c.SMS.SenderID, "SMS_SENDER_ID", with)
+			require(c.SMS.BaseURL, "SMS_BASE_URL", with)
+		case "msg91":
+			require(c.SMS.MSG91AuthKey, "MSG91_AUTH_KEY", with)
+			require(c.SMS.MSG91SenderID, "MSG91_SENDER_ID", with)
+		case "twilio":
+			require(c.SMS.TwilioAccountSID, "TWILIO_ACCOUNT_SID", with)
+			require(c.SMS.TwilioAuthToken, "TWILIO_AUTH_TOKEN", with)
+			require(c.SMS.TwilioFrom+c.SMS.TwilioMessagingServiceSID, "TWILIO_FROM or TWILIO_MESSAGING_SERVICE_SID", with)
+		case "sns":
+			require(c.SMS.SNSAccessKey, "SNS_ACCESS_KEY", with)
+			require(c.SMS.SNSSecretKey, "SNS_SECRET_KEY", with)
+		default:
+			problems = append(problems, fmt.Sprintf("SMS_PROVIDERS: unknown provider %q", provider))
+		}
+	}
+
+	for _, channel := range []string{c.OTP.DefaultChannel, c.OTP.FallbackChannel} {
+		with := " with the " + channel + " OTP channel"
+		switch channel {
+		case "", "sms":
+		case "whatsapp":
+			require(c.WhatsApp.PhoneNumberID, "WHATSAPP_PHONE_NUMBER_ID", with)
+			require(c.WhatsApp.AccessToken, "WHATSAPP_ACCESS_TOKEN", with)
+		case "email":
+			require(c.Email.SMTPHost, "SMTP_HOST", with)
+			require(c.Email.From, "EMAIL_FROM", with)
+		default:
+			problems = append(problems, fmt.Sprintf("OTP channel %q must be sms, whatsapp or email", channel))
+		}
+	}
+
+	switch c.Geocoding.Provider {
+	case "", "nominatim":
+	case "google":
+		require(c.Geocoding.GoogleAPIKey, "GOOGLE_MAPS_API_KEY", " with the google geocoding provider")
+	default:
+		problems = append(problems, fmt.Sprintf("GEOCODING_PROVIDER %q must be google, nominatim or empty", c.Geocoding.Provider))
+	}
+
+	switch c.PII.KeySource {
+	case "":
+	case "local", "kms":
+		with := " with PII_KEY_SOURCE " + c.PII.KeySource
+		if len(c.PII.DataKeys) == 0 {
+			problems = append(problems, "PII_DATA_KEYS is required"+with)
+		}
+		require(c.PII.ActiveKeyID, "PII_ACTIVE_KEY_ID", with)
+		require(c.PII.HashKey, "PII_HASH_KEY", with)
+		if len(c.PII.HashKey) > 0 && len(c.PII.HashKey) < minReleaseSecretLength {
+			problems = append(problems, fmt.Sprintf("PII_HASH_KEY must be at least %d characters", minReleaseSecretLength))
+		}
+		if c.PII.KeySource == "kms" {
+			require(c.PII.KMSRegion, "PII_KMS_REGION", with)
+			require(c.PII.KMSAccessKey, "PII_KMS_ACCESS_KEY", with)
+			require(c.PII.KMSSecretKey, "PII_KMS_SECRET_KEY", with)
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("PII_KEY_SOURCE %q must be local, kms or empty", c.PII.KeySource))
+	}
+
+	switch c.Password.HashAlgorithm {
+	case "bcrypt", "argon2id":
+	default:
+		problems = append(problems, fmt.Sprintf("PASSWORD_HASH_ALGORITHM %q must be bcrypt or argon2id", c.Password.HashAlgorithm))
+	}
+	if c.Password.ResetURL != "" {
+		require(c.Email.SMTPHost, "SMTP_HOST", " with PASSWORD_RESET_URL")
+		require(c.Email.From, "EMAIL_FROM", " with PASSWORD_RESET_URL")
+	}
+
+	if (c.Storage.AccessKey == "") != (c.Storage.SecretKey == "") {
+		problems = append(problems, "STORAGE_ACCESS_KEY and STORAGE_SECRET_KEY must be set together")
+	}
+
+	if c.Server.Mode == "release" {
+		if len(c.JWT.SecretKey) > 0 && len(c.JWT.SecretKey) < minReleaseSecretLength {
+			problems = append(problems, fmt.Sprintf("JWT_SECRET must be at least %d characters in release mode", minReleaseSecretLength))
+		}
+		if len(c.Privacy.HashKey) > 0 && len(c.Privacy.HashKey) < minReleaseSecretLength {
+			problems = append(problems, fmt.Sprintf("PRIVACY_HASH_KEY must be at least %d characters in release mode", minReleaseSecretLength))
+		}
+		if len(c.OTP.HashKey) > 0 && len(c.OTP.HashKey) < minReleaseSecretLength {
+			problems = append(problems, fmt.Sprintf("OTP_HASH_KEY must be at least %d characters in release mode", minReleaseSecretLength))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
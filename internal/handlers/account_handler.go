@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandler struct {
+	accountService *services.AccountService
+}
+
+func NewAccountHandler(accountService *services.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+// RegisterRoutes registers account deletion and data export for the signed-in user
+func (h *AccountHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	account := router.Group("/users/me", authMiddleware.AuthRequired())
+	{
+		account.DELETE("", h.DeleteAccount)
+		account.GET("/export", h.GetDataExport)
+	}
+}
+
+// DeleteAccount godoc
+// @Summary Delete my account
+// @Description Erase the user's personal data and sign out every device. Orders and payments are kept for accounting with the customer's details anonymized.
+// @Description Fails while an order is still in progress.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.DeleteAccountRequest false "Optional reason"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users/me [delete]
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	var req services.DeleteAccountRequest
+	// The body is optional
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid request body",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	if err := h.accountService.DeleteAccount(c.Request.Context(), middleware.GetUserID(c), &req); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrActiveOrders) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, ErrorResponse{
+			Error:   "Failed to delete account",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+}
+
+// GetDataExport godoc
+// @Summary Export my data
+// @Description Get a JSON archive of the user's profile, addresses, orders, payments, refunds and reviews.
+// @Description The archive is generated in the background: the first call starts it and returns 202; poll until the status is completed and download_url is set.
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} services.DataExportJob
+// @Success 202 {object} services.DataExportJob
+// @Failure 400 {object} ErrorResponse
+// @Router /users/me/export [get]
+func (h *AccountHandler) GetDataExport(c *gin.Context) {
+	job, err := h.accountService.GetDataExport(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to export data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if job.Status == services.DataExportProcessing {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, job)
+}
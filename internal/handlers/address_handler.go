@@ -1,23 +1,26 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 
 	"golang-food-backend/internal/middleware"
 	"golang-food-backend/internal/services"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/geo"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AddressHandler struct {
 	addressService *services.AddressService
+	geocodeService *services.GeocodeService
 }
 
-func NewAddressHandler(addressService *services.AddressService) *AddressHandler {
+func NewAddressHandler(addressService *services.AddressService, geocodeService *services.GeocodeService) *AddressHandler {
 	return &AddressHandler{
 		addressService: addressService,
+		geocodeService: geocodeService,
 	}
 }
 
@@ -28,6 +31,10 @@ func (h *AddressHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware
 	// Protected routes
 	addresses.Use(authMiddleware.AuthRequired())
 	{
+		// Address search and map pin lookup
+		addresses.GET("/autocomplete", h.Autocomplete)
+		addresses.GET("/reverse-geocode", h.ReverseGeocode)
+		addresses.GET("/places/:place_id", h.LookupPlace)
 		// Create a new address
 		addresses.POST("", h.CreateAddress)
 		// Get all user addresses
@@ -43,6 +50,106 @@ func (h *AddressHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware
 	}
 }
 
+// Autocomplete godoc
+// @Summary Search addresses
+// @Description Suggest addresses for partial input, nearest to lat/lng first when given. Suggestions without a location are resolved with /addresses/places/{place_id}.
+// @Tags address
+// @Security BearerAuth
+// @Produce json
+// @Param q query string true "Partial address (at least 3 characters)"
+// @Param lat query number false "Latitude to bias results towards"
+// @Param lng query number false "Longitude to bias results towards"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /addresses/autocomplete [get]
+func (h *AddressHandler) Autocomplete(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Query is required",
+			Message: "Please provide q parameter",
+		})
+		return
+	}
+
+	var near *geo.Point
+	if c.Query("lat") != "" && c.Query("lng") != "" {
+		lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+		lng, lngErr := strconv.ParseFloat(c.Query("lng"), 64)
+		if latErr != nil || lngErr != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid coordinates",
+				Message: "lat and lng must be numbers",
+			})
+			return
+		}
+		near = &geo.Point{Lat: lat, Lng: lng}
+	}
+
+	suggestions, err := h.geocodeService.Autocomplete(c.Request.Context(), query, near)
+	if err != nil {
+		abortWithError(c, "Address search failed", apperr.Classify(err, apperr.KindUpstream))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+}
+
+// ReverseGeocode godoc
+// @Summary Address at a map pin
+// @Description Reverse-geocode coordinates into an address to prefill the address form
+// @Tags address
+// @Security BearerAuth
+// @Produce json
+// @Param lat query number true "Latitude"
+// @Param lng query number true "Longitude"
+// @Success 200 {object} geo.Place
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /addresses/reverse-geocode [get]
+func (h *AddressHandler) ReverseGeocode(c *gin.Context) {
+	lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(c.Query("lng"), 64)
+	if latErr != nil || lngErr != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid coordinates",
+			Message: "Please provide numeric lat and lng parameters",
+		})
+		return
+	}
+
+	place, err := h.geocodeService.ReverseGeocode(c.Request.Context(), lat, lng)
+	if err != nil {
+		abortWithError(c, "Reverse geocoding failed", apperr.Classify(err, apperr.KindUpstream))
+		return
+	}
+
+	c.JSON(http.StatusOK, place)
+}
+
+// LookupPlace godoc
+// @Summary Resolve an address suggestion
+// @Description Get the full address and location for an autocomplete suggestion
+// @Tags address
+// @Security BearerAuth
+// @Produce json
+// @Param place_id path string true "Place ID from autocomplete"
+// @Success 200 {object} geo.Place
+// @Failure 404 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /addresses/places/{place_id} [get]
+func (h *AddressHandler) LookupPlace(c *gin.Context) {
+	place, err := h.geocodeService.LookupPlace(c.Request.Context(), c.Param("place_id"))
+	if err != nil {
+		abortWithError(c, "Place lookup failed", apperr.Classify(err, apperr.KindUpstream))
+		return
+	}
+
+	c.JSON(http.StatusOK, place)
+}
+
 // CreateAddress godoc
 // @Summary Create a new address
 // @Description Create a new address for the user
@@ -74,13 +181,10 @@ func (h *AddressHandler) CreateAddress(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	address, err := h.addressService.CreateAddress(ctx, userID.(string), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to create address",
-			Message: err.Error(),
-		})
+		abortWithError(c, "Failed to create address", err)
 		return
 	}
 
@@ -112,7 +216,7 @@ func (h *AddressHandler) GetAddresses(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	response, err := h.addressService.GetAddresses(ctx, userID.(string), page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -156,7 +260,7 @@ func (h *AddressHandler) GetAddressByID(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	address, err := h.addressService.GetAddressByID(ctx, userID.(string), addressID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{
@@ -211,13 +315,10 @@ func (h *AddressHandler) UpdateAddress(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	address, err := h.addressService.UpdateAddress(ctx, userID.(string), addressID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to update address",
-			Message: err.Error(),
-		})
+		abortWithError(c, "Failed to update address", err)
 		return
 	}
 
@@ -256,7 +357,7 @@ func (h *AddressHandler) DeleteAddress(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	if err := h.addressService.DeleteAddress(ctx, userID.(string), addressID); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to delete address",
@@ -300,7 +401,7 @@ func (h *AddressHandler) SetDefaultAddress(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	address, err := h.addressService.SetDefaultAddress(ctx, userID.(string), addressID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
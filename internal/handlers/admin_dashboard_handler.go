@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminDashboardHandler struct {
+	dashboardService *services.AdminDashboardService
+}
+
+func NewAdminDashboardHandler(dashboardService *services.AdminDashboardService) *AdminDashboardHandler {
+	return &AdminDashboardHandler{
+		dashboardService: dashboardService,
+	}
+}
+
+// RegisterRoutes registers platform dashboard routes
+func (h *AdminDashboardHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	dashboard := router.Group("/admin/dashboard",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionReports),
+	)
+	{
+		dashboard.GET("", h.GetOverview)
+		dashboard.GET("/orders", h.GetOrderStatusSummary)
+		dashboard.GET("/gmv", h.GetGMVSummary)
+		dashboard.GET("/restaurants/top", h.GetTopRestaurants)
+		dashboard.GET("/refunds", h.GetRefundSummary)
+		dashboard.GET("/users", h.GetUserSummary)
+		dashboard.GET("/carts", h.GetCartSummary)
+	}
+}
+
+// parseRange reads the from/to query parameters, writing a 400 response when invalid
+func (h *AdminDashboardHandler) parseRange(c *gin.Context) (services.DashboardRange, bool) {
+	dateRange, err := services.ParseDashboardRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid date range",
+			Message: err.Error(),
+		})
+		return dateRange, false
+	}
+	return dateRange, true
+}
+
+// GetOverview godoc
+// @Summary Get dashboard overview
+// @Description Get order, GMV, top restaurant, refund and user metrics for a date range (requires reports.view)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD), defaults to 30 days ago"
+// @Param to query string false "End date (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} services.DashboardOverview
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/dashboard [get]
+func (h *AdminDashboardHandler) GetOverview(c *gin.Context) {
+	dateRange, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+
+	overview, err := h.dashboardService.GetOverview(c.Request.Context(), dateRange)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to load dashboard",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
+
+// GetOrderStatusSummary godoc
+// @Summary Get order counts by status
+// @Description Get the number of orders placed in a date range, grouped by status (requires reports.view)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} services.OrderStatusSummary
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/dashboard/orders [get]
+func (h *AdminDashboardHandler) GetOrderStatusSummary(c *gin.Context) {
+	dateRange, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.dashboardService.GetOrderStatusSummary(c.Request.Context(), dateRange)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to load order metrics",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetGMVSummary godoc
+// @Summary Get GMV per day
+// @Description Get gross merchandise value and order counts per day, excluding unpaid and cancelled orders (requires reports.view)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} services.GMVSummary
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/dashboard/gmv [get]
+func (h *AdminDashboardHandler) GetGMVSummary(c *gin.Context) {
+	dateRange, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.dashboardService.GetGMVSummary(c.Request.Context(), dateRange)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to load GMV metrics",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetTopRestaurants godoc
+// @Summary Get top restaurants
+// @Description Get restaurants ranked by GMV in a date range (requires reports.view)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Param limit query int false "Number of restaurants" default(10)
+// @Success 200 {object} services.TopRestaurantsSummary
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/dashboard/restaurants/top [get]
+func (h *AdminDashboardHandler) GetTopRestaurants(c *gin.Context) {
+	dateRange, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	summary, err := h.dashboardService.GetTopRestaurants(c.Request.Context(), dateRange, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to load restaurant metrics",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetRefundSummary godoc
+// @Summary Get refund rate
+// @Description Get refunded orders and amounts as a share of sales in a date range (requires reports.view)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} services.RefundSummary
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/dashboard/refunds [get]
+func (h *AdminDashboardHandler) GetRefundSummary(c *gin.Context) {
+	dateRange, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.dashboardService.GetRefundSummary(c.Request.Context(), dateRange)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to load refund metrics",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetUserSummary godoc
+// @Summary Get user activity
+// @Description Get ordering, new and active user counts for a date range (requires reports.view)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} services.UserSummary
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/dashboard/users [get]
+func (h *AdminDashboardHandler) GetUserSummary(c *gin.Context) {
+	dateRange, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.dashboardService.GetUserSummary(c.Request.Context(), dateRange)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to load user metrics",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetCartSummary godoc
+// @Summary Get cart abandonment metrics
+// @Description Get how many carts created in a date range were abandoned, recovered after abandonment and ordered, with the abandonment, recovery and conversion rates (requires reports.view)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} services.CartSummary
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/dashboard/carts [get]
+func (h *AdminDashboardHandler) GetCartSummary(c *gin.Context) {
+	dateRange, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.dashboardService.GetCartSummary(c.Request.Context(), dateRange)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to load cart metrics",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	adminService *services.AdminService
+}
+
+func NewAdminHandler(adminService *services.AdminService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+	}
+}
+
+type AdminRefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RegisterRoutes registers admin authentication and admin management routes
+func (h *AdminHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	adminAuth := router.Group("/admin/auth")
+	{
+		adminAuth.POST("/login", h.Login)
+		adminAuth.POST("/refresh", h.RefreshToken)
+	}
+
+	session := router.Group("/admin/auth", authMiddleware.AuthRequired(), authMiddleware.AdminLoginRequired())
+	{
+		session.POST("/logout", h.Logout)
+		session.GET("/me", h.GetMe)
+		session.POST("/totp/setup", h.SetupTOTP)
+		session.POST("/totp/enable", h.EnableTOTP)
+		session.POST("/totp/disable", h.DisableTOTP)
+	}
+
+	admins := router.Group("/admin/admins",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionAdmins),
+	)
+	{
+		admins.POST("", h.CreateAdmin)
+		admins.GET("", h.ListAdmins)
+		admins.GET("/:id", h.GetAdmin)
+		admins.PUT("/:id", h.UpdateAdmin)
+		admins.DELETE("/:id", h.DeactivateAdmin)
+	}
+}
+
+// Login godoc
+// @Summary Admin login
+// @Description Authenticate an admin with email, password and, when enabled, a TOTP code
+// @Tags admin-auth
+// @Accept json
+// @Produce json
+// @Param request body services.AdminLoginRequest true "Admin credentials"
+// @Success 200 {object} services.AdminAuthResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /admin/auth/login [post]
+func (h *AdminHandler) Login(c *gin.Context) {
+	var req services.AdminLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response, err := h.adminService.Login(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrTOTPRequired) {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "TOTP required",
+				Message: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "Login failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RefreshToken godoc
+// @Summary Refresh admin token
+// @Description Issue new admin tokens with the admin's current permissions
+// @Tags admin-auth
+// @Accept json
+// @Produce json
+// @Param request body AdminRefreshRequest true "Refresh token"
+// @Success 200 {object} services.AdminAuthResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /admin/auth/refresh [post]
+func (h *AdminHandler) RefreshToken(c *gin.Context) {
+	var req AdminRefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response, err := h.adminService.RefreshToken(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "Token refresh failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout godoc
+// @Summary Admin logout
+// @Description Revoke the admin's refresh token
+// @Tags admin-auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/auth/logout [post]
+func (h *AdminHandler) Logout(c *gin.Context) {
+	if err := h.adminService.Logout(c.Request.Context(), middleware.GetUserID(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Logout failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// GetMe godoc
+// @Summary Get current admin
+// @Description Get the logged-in admin's profile and permissions
+// @Tags admin-auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.AdminUser
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/auth/me [get]
+func (h *AdminHandler) GetMe(c *gin.Context) {
+	admin, err := h.adminService.GetAdmin(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Admin not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, admin)
+}
+
+// SetupTOTP godoc
+// @Summary Start TOTP setup
+// @Description Generate a TOTP secret and provisioning URI for an authenticator app
+// @Tags admin-auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} services.TOTPSetupResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/auth/totp/setup [post]
+func (h *AdminHandler) SetupTOTP(c *gin.Context) {
+	setup, err := h.adminService.SetupTOTP(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to set up TOTP",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// EnableTOTP godoc
+// @Summary Enable TOTP
+// @Description Confirm TOTP setup with a code from the authenticator app
+// @Tags admin-auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.TOTPCodeRequest true "TOTP code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/auth/totp/enable [post]
+func (h *AdminHandler) EnableTOTP(c *gin.Context) {
+	var req services.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.adminService.EnableTOTP(c.Request.Context(), middleware.GetUserID(c), req.Code); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to enable TOTP",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "TOTP enabled"})
+}
+
+// DisableTOTP godoc
+// @Summary Disable TOTP
+// @Description Turn off TOTP for the logged-in admin
+// @Tags admin-auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.TOTPCodeRequest true "TOTP code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/auth/totp/disable [post]
+func (h *AdminHandler) DisableTOTP(c *gin.Context) {
+	var req services.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.adminService.DisableTOTP(c.Request.Context(), middleware.GetUserID(c), req.Code); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to disable TOTP",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "TOTP disabled"})
+}
+
+// CreateAdmin godoc
+// @Summary Create an admin
+// @Description Create an admin user with a set of permissions (requires admins.manage)
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.CreateAdminRequest true "Admin details"
+// @Success 201 {object} models.AdminUser
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/admins [post]
+func (h *AdminHandler) CreateAdmin(c *gin.Context) {
+	var req services.CreateAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	admin, err := h.adminService.CreateAdmin(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to create admin",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, admin)
+}
+
+// ListAdmins godoc
+// @Summary List admins
+// @Description List admin users (requires admins.manage)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {array} models.AdminUser
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/admins [get]
+func (h *AdminHandler) ListAdmins(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	admins, err := h.adminService.ListAdmins(c.Request.Context(), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to list admins",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, admins)
+}
+
+// GetAdmin godoc
+// @Summary Get an admin
+// @Description Get an admin user by ID (requires admins.manage)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Admin ID"
+// @Success 200 {object} models.AdminUser
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/admins/{id} [get]
+func (h *AdminHandler) GetAdmin(c *gin.Context) {
+	admin, err := h.adminService.GetAdmin(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Admin not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, admin)
+}
+
+// UpdateAdmin godoc
+// @Summary Update an admin
+// @Description Update an admin's name, role, permissions, password or status (requires admins.manage)
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Admin ID"
+// @Param request body services.UpdateAdminRequest true "Admin changes"
+// @Success 200 {object} models.AdminUser
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/admins/{id} [put]
+func (h *AdminHandler) UpdateAdmin(c *gin.Context) {
+	var req services.UpdateAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	admin, err := h.adminService.UpdateAdmin(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to update admin",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, admin)
+}
+
+// DeactivateAdmin godoc
+// @Summary Deactivate an admin
+// @Description Disable an admin account and revoke its sessions (requires admins.manage)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Admin ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/admins/{id} [delete]
+func (h *AdminHandler) DeactivateAdmin(c *gin.Context) {
+	if err := h.adminService.DeactivateAdmin(c.Request.Context(), middleware.GetUserID(c), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to deactivate admin",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Admin deactivated successfully"})
+}
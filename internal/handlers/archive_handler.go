@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ArchiveHandler struct {
+	archiveService *services.ArchiveService
+}
+
+func NewArchiveHandler(archiveService *services.ArchiveService) *ArchiveHandler {
+	return &ArchiveHandler{
+		archiveService: archiveService,
+	}
+}
+
+// RegisterRoutes registers the admin routes for deleting, listing and restoring soft-deleted
+// orders, restaurants and products
+func (h *ArchiveHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	orders := router.Group("/admin/orders",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionOrders),
+	)
+	{
+		orders.GET("/deleted", h.ListDeletedOrders)
+		orders.GET("/archived/:id", h.GetArchivedOrder)
+		orders.DELETE("/:id", h.DeleteOrder)
+		orders.POST("/:id/restore", h.RestoreOrder)
+	}
+
+	restaurants := router.Group("/admin/restaurants",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionRestaurants),
+	)
+	{
+		restaurants.GET("/deleted", h.ListDeletedRestaurants)
+		restaurants.POST("/:id/restore", h.RestoreRestaurant)
+	}
+
+	products := router.Group("/admin/products",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionRestaurants),
+	)
+	{
+		products.GET("/deleted", h.ListDeletedProducts)
+		products.POST("/:id/restore", h.RestoreProduct)
+	}
+}
+
+// archivePage reads the page and limit query parameters
+func archivePage(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
+
+// DeleteOrder godoc
+// @Summary Delete an order
+// @Description Soft-delete an order that took no money (pending, pending payment, payment failed or cancelled). It is hidden everywhere until restored, and archived once the purge period has passed (admin only).
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/orders/{id} [delete]
+func (h *ArchiveHandler) DeleteOrder(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid order ID",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.archiveService.DeleteOrder(c.Request.Context(), orderID); err != nil {
+		abortWithError(c, "Failed to delete order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order deleted"})
+}
+
+// ListDeletedOrders godoc
+// @Summary List deleted orders
+// @Description List soft-deleted orders, most recently deleted first (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} services.DeletedOrdersResponse
+// @Router /admin/orders/deleted [get]
+func (h *ArchiveHandler) ListDeletedOrders(c *gin.Context) {
+	page, limit := archivePage(c)
+	response, err := h.archiveService.GetDeletedOrders(c.Request.Context(), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list deleted orders", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreOrder godoc
+// @Summary Restore a deleted order
+// @Description Undo the soft delete of an order that has not been archived yet (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/orders/{id}/restore [post]
+func (h *ArchiveHandler) RestoreOrder(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid order ID",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.archiveService.RestoreOrder(c.Request.Context(), orderID); err != nil {
+		abortWithError(c, "Failed to restore order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order restored"})
+}
+
+// GetArchivedOrder godoc
+// @Summary Get an archived order
+// @Description Get an order moved to the archive, with a snapshot of its logs, payments, refunds and Porter deliveries (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} models.ArchivedOrder
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/orders/archived/{id} [get]
+func (h *ArchiveHandler) GetArchivedOrder(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid order ID",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	order, err := h.archiveService.GetArchivedOrder(c.Request.Context(), orderID)
+	if err != nil {
+		abortWithError(c, "Failed to get archived order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// ListDeletedRestaurants godoc
+// @Summary List deleted restaurants
+// @Description List soft-deleted restaurants, most recently deleted first (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} services.DeletedRestaurantsResponse
+// @Router /admin/restaurants/deleted [get]
+func (h *ArchiveHandler) ListDeletedRestaurants(c *gin.Context) {
+	page, limit := archivePage(c)
+	response, err := h.archiveService.GetDeletedRestaurants(c.Request.Context(), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list deleted restaurants", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreRestaurant godoc
+// @Summary Restore a deleted restaurant
+// @Description Undo the soft delete of a restaurant (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/restaurants/{id}/restore [post]
+func (h *ArchiveHandler) RestoreRestaurant(c *gin.Context) {
+	restaurantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid restaurant ID",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.archiveService.RestoreRestaurant(c.Request.Context(), restaurantID); err != nil {
+		abortWithError(c, "Failed to restore restaurant", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Restaurant restored"})
+}
+
+// ListDeletedProducts godoc
+// @Summary List deleted products
+// @Description List a restaurant's soft-deleted products that have not been purged yet, most recently deleted first (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param restaurant_id query string true "Restaurant ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} services.DeletedProductsResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/products/deleted [get]
+func (h *ArchiveHandler) ListDeletedProducts(c *gin.Context) {
+	page, limit := archivePage(c)
+	response, err := h.archiveService.GetDeletedProducts(c.Request.Context(), c.Query("restaurant_id"), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list deleted products", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreProduct godoc
+// @Summary Restore a deleted product
+// @Description Undo the soft delete of a product that has not been purged yet (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} models.Product
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/products/{id}/restore [post]
+func (h *ArchiveHandler) RestoreProduct(c *gin.Context) {
+	product, err := h.archiveService.RestoreProduct(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to restore product", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// RegisterRoutes registers the admin routes for searching the audit log
+func (h *AuditHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := router.Group("/admin/audit-logs",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionAudit),
+	)
+	{
+		admin.GET("", h.ListEntries)
+		admin.GET("/:id", h.GetEntry)
+	}
+}
+
+// ListEntries godoc
+// @Summary List audit entries
+// @Description List who changed restaurants, products, coupons, refunds and payouts, newest first. Entries of requests hold the route, the actor's role and the changed fields with their old and new values (admin only).
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param entity_type query string false "restaurant, product, coupon, refund, payout or payout_batch"
+// @Param entity_id query string false "Entity ID"
+// @Param actor_id query string false "ID of the user or admin who made the change"
+// @Param action query string false "Action, such as create, update, delete or status.update"
+// @Param from query string false "Start time (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "End time (RFC 3339, exclusive, or YYYY-MM-DD, inclusive)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} services.AuditLogsResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/audit-logs [get]
+func (h *AuditHandler) ListEntries(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	entries, err := h.auditService.ListEntries(c.Request.Context(), services.AuditLogQuery{
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		Action:     c.Query("action"),
+		ActorID:    c.Query("actor_id"),
+		From:       c.Query("from"),
+		To:         c.Query("to"),
+		Page:       page,
+		Limit:      limit,
+	})
+	if err != nil {
+		abortWithError(c, "Failed to list audit entries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// GetEntry godoc
+// @Summary Get an audit entry
+// @Description Get an audit entry with its metadata (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Audit entry ID"
+// @Success 200 {object} models.AuditLog
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/audit-logs/{id} [get]
+func (h *AuditHandler) GetEntry(c *gin.Context) {
+	entry, err := h.auditService.GetEntry(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get audit entry", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
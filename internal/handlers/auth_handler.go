@@ -3,6 +3,7 @@ package handlers
 import (
 	"golang-food-backend/internal/middleware"
 	"golang-food-backend/internal/services"
+	"golang-food-backend/pkg/apperr"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -21,7 +22,7 @@ func NewAuthHandler(authService AuthServiceInterface, otpService *services.OTPSe
 }
 
 // @Summary Register a new user
-// @Description Create a new user account
+// @Description Create a new user account. The password must meet the password policy; weak ones get 400 weak_password.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -37,9 +38,12 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
 	response, err := h.authService.Register(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		abortWithError(c, "Registration failed", apperr.Classify(err, apperr.KindConflict))
 		return
 	}
 
@@ -63,6 +67,9 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
 	response, err := h.authService.Login(c.Request.Context(), &req)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
@@ -130,7 +137,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 }
 
 // @Summary Refresh access token
-// @Description Exchange refresh token for new access token
+// @Description Exchange a refresh token for a new access and refresh token. The old refresh token stops working, and reusing it revokes the session.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -159,7 +166,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 }
 
 // @Summary Logout user
-// @Description Logout user and invalidate refresh token
+// @Description Log out of the current device by revoking its session and refresh token
 // @Tags auth
 // @Security BearerAuth
 // @Produce json
@@ -173,7 +180,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.Logout(c.Request.Context(), userID); err != nil {
+	if err := h.authService.Logout(c.Request.Context(), userID, middleware.GetAuthSessionID(c)); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -181,14 +188,38 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
-// @Summary Send OTP to phone
-// @Description Send OTP for phone number authentication
+// @Summary Logout from all devices
+// @Description Revoke every session of the user so all refresh tokens stop working and access tokens are rejected
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.authService.LogoutAll(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out from all devices"})
+}
+
+// @Summary Send OTP
+// @Description Send an OTP for phone number authentication over SMS, WhatsApp or email, falling back to the configured secondary channel if delivery fails
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body services.SendOTPRequest true "Send OTP request"
 // @Success 200 {object} services.OTPResponse
 // @Failure 400 {object} map[string]string
+// @Failure 429 {object} ErrorResponse
 // @Router /api/v1/auth/send-otp [post]
 func (h *AuthHandler) SendOTP(c *gin.Context) {
 	var req services.SendOTPRequest
@@ -199,7 +230,7 @@ func (h *AuthHandler) SendOTP(c *gin.Context) {
 
 	response, err := h.otpService.SendOTP(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, "Failed to send OTP", apperr.Classify(err, apperr.KindValidation))
 		return
 	}
 
@@ -207,13 +238,14 @@ func (h *AuthHandler) SendOTP(c *gin.Context) {
 }
 
 // @Summary Verify OTP and login
-// @Description Verify OTP and authenticate user
+// @Description Verify OTP and authenticate user. A customer's guest carts, from guest_token or the X-Guest-Token header, are merged into their carts.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body services.VerifyOTPRequest true "Verify OTP request"
 // @Success 200 {object} services.AuthResponse
 // @Failure 400 {object} map[string]string
+// @Failure 429 {object} ErrorResponse
 // @Router /api/v1/auth/verify-otp [post]
 func (h *AuthHandler) VerifyOTP(c *gin.Context) {
 	var req services.VerifyOTPRequest
@@ -222,9 +254,15 @@ func (h *AuthHandler) VerifyOTP(c *gin.Context) {
 		return
 	}
 
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+	if req.GuestToken == "" {
+		req.GuestToken = c.GetHeader(services.GuestTokenHeader)
+	}
+
 	response, err := h.otpService.VerifyOTPAndLogin(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, "Failed to verify OTP", apperr.Classify(err, apperr.KindValidation))
 		return
 	}
 
@@ -248,6 +286,7 @@ func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *mi
 			protected.GET("/profile", h.GetProfile)
 			protected.PUT("/profile", h.UpdateProfile)
 			protected.POST("/logout", h.Logout)
+			protected.POST("/logout-all", h.LogoutAll)
 		}
 	}
 }
@@ -13,5 +13,6 @@ type AuthServiceInterface interface {
 	GetUserProfile(ctx context.Context, userID string) (*models.User, error)
 	UpdateProfile(ctx context.Context, userID string, updates map[string]interface{}) error
 	RefreshAccessToken(ctx context.Context, refreshToken string) (*services.AuthResponse, error)
-	Logout(ctx context.Context, userID string) error
+	Logout(ctx context.Context, userID, sessionID string) error
+	LogoutAll(ctx context.Context, userID string) error
 }
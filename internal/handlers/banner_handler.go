@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BannerHandler struct {
+	bannerService *services.BannerService
+}
+
+func NewBannerHandler(bannerService *services.BannerService) *BannerHandler {
+	return &BannerHandler{
+		bannerService: bannerService,
+	}
+}
+
+// RegisterRoutes registers public banner and banner administration routes
+func (h *BannerHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	router.GET("/banners", h.GetActiveBanners)
+
+	admin := router.Group("/admin/banners", authMiddleware.AuthRequired(), authMiddleware.AdminRequired())
+	{
+		admin.POST("", h.CreateBanner)
+		admin.GET("", h.ListBanners)
+		admin.GET("/:id", h.GetBanner)
+		admin.PUT("/:id", h.UpdateBanner)
+		admin.DELETE("/:id", h.DeleteBanner)
+	}
+}
+
+// GetActiveBanners godoc
+// @Summary Get active banners
+// @Description Get the banners currently scheduled at a display location, highest priority first. Pass restaurant_id for a restaurant's own banners.
+// @Tags banners
+// @Produce json
+// @Param location query string true "Display location (home, offers, restaurant)"
+// @Param restaurant_id query string false "Restaurant ID"
+// @Success 200 {array} models.Banner
+// @Failure 400 {object} ErrorResponse
+// @Router /banners [get]
+func (h *BannerHandler) GetActiveBanners(c *gin.Context) {
+	banners, err := h.bannerService.GetActiveBanners(c.Request.Context(), c.DefaultQuery("location", "home"), c.Query("restaurant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to get banners",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, banners)
+}
+
+// CreateBanner godoc
+// @Summary Create a banner
+// @Description Create a platform-wide or restaurant-scoped banner with a display schedule (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.CreateBannerRequest true "Banner"
+// @Success 201 {object} models.Banner
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/banners [post]
+func (h *BannerHandler) CreateBanner(c *gin.Context) {
+	var req services.CreateBannerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	banner, err := h.bannerService.CreateBanner(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to create banner",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, banner)
+}
+
+// ListBanners godoc
+// @Summary List banners
+// @Description List all banners including inactive and expired ones (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param location query string false "Display location"
+// @Param restaurant_id query string false "Restaurant ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {array} models.Banner
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/banners [get]
+func (h *BannerHandler) ListBanners(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	banners, err := h.bannerService.ListBanners(c.Request.Context(), c.Query("location"), c.Query("restaurant_id"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to list banners",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, banners)
+}
+
+// GetBanner godoc
+// @Summary Get a banner
+// @Description Get a banner by ID (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Banner ID"
+// @Success 200 {object} models.Banner
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/banners/{id} [get]
+func (h *BannerHandler) GetBanner(c *gin.Context) {
+	banner, err := h.bannerService.GetBanner(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Banner not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, banner)
+}
+
+// UpdateBanner godoc
+// @Summary Update a banner
+// @Description Update a banner's content, placement, schedule or priority (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Banner ID"
+// @Param request body services.UpdateBannerRequest true "Banner changes"
+// @Success 200 {object} models.Banner
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/banners/{id} [put]
+func (h *BannerHandler) UpdateBanner(c *gin.Context) {
+	var req services.UpdateBannerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	banner, err := h.bannerService.UpdateBanner(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to update banner",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, banner)
+}
+
+// DeleteBanner godoc
+// @Summary Delete a banner
+// @Description Delete a banner (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Banner ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/banners/{id} [delete]
+func (h *BannerHandler) DeleteBanner(c *gin.Context) {
+	if err := h.bannerService.DeleteBanner(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to delete banner",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Banner deleted successfully"})
+}
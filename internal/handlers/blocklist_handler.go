@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BlocklistHandler struct {
+	blocklistService *services.BlocklistService
+}
+
+func NewBlocklistHandler(blocklistService *services.BlocklistService) *BlocklistHandler {
+	return &BlocklistHandler{
+		blocklistService: blocklistService,
+	}
+}
+
+// RegisterRoutes registers the admin blocklist routes
+func (h *BlocklistHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := router.Group("/admin/blocklist",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionRisk),
+	)
+	{
+		admin.GET("", h.ListEntries)
+		admin.POST("", h.Block)
+		admin.GET("/:id", h.GetEntry)
+		admin.DELETE("/:id", h.Lift)
+	}
+}
+
+// ListEntries godoc
+// @Summary List the blocklist
+// @Description List blocked users, phone numbers and devices, newest first (admin with risk.manage)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param kind query string false "user, phone or device"
+// @Param value query string false "User ID, phone number or device ID; needs kind"
+// @Param source query string false "admin or risk"
+// @Param active query bool false "Only entries neither lifted nor expired"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} services.BlocklistResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/blocklist [get]
+func (h *BlocklistHandler) ListEntries(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	active, _ := strconv.ParseBool(c.Query("active"))
+
+	entries, err := h.blocklistService.List(c.Request.Context(), services.BlocklistQuery{
+		Kind:       c.Query("kind"),
+		Value:      c.Query("value"),
+		Source:     c.Query("source"),
+		ActiveOnly: active,
+		Page:       page,
+		Limit:      limit,
+	})
+	if err != nil {
+		abortWithError(c, "Failed to list blocklist", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// Block godoc
+// @Summary Block a user, phone number or device
+// @Description Bar a subject from OTPs, logins and checkout until expires_at, or for good without one. Blocking what is already blocked replaces its reason and expiry; a blocked user is signed out everywhere.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.BlockRequest true "Block"
+// @Success 201 {object} models.BlocklistEntry
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/blocklist [post]
+func (h *BlocklistHandler) Block(c *gin.Context) {
+	var req services.BlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	entry, err := h.blocklistService.Block(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to block", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// GetEntry godoc
+// @Summary Get a blocklist entry
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Entry ID"
+// @Success 200 {object} models.BlocklistEntry
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/blocklist/{id} [get]
+func (h *BlocklistHandler) GetEntry(c *gin.Context) {
+	entry, err := h.blocklistService.GetEntry(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get blocklist entry", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// Lift godoc
+// @Summary Lift a block
+// @Description End a block now; the entry is kept, with who lifted it and when
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Entry ID"
+// @Success 200 {object} models.BlocklistEntry
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/blocklist/{id} [delete]
+func (h *BlocklistHandler) Lift(c *gin.Context) {
+	entry, err := h.blocklistService.Lift(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to lift block", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
@@ -1,108 +1,243 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
+	"strings"
 
 	"golang-food-backend/internal/middleware"
 	"golang-food-backend/internal/services"
+	"golang-food-backend/pkg/apperr"
 
 	"github.com/gin-gonic/gin"
 )
 
 type CartHandler struct {
-	cartService CartServiceInterface
+	cartService     CartServiceInterface
+	activityTracker *services.ActivityTracker
 }
 
-func NewCartHandler(cartService CartServiceInterface) *CartHandler {
+func NewCartHandler(cartService CartServiceInterface, activityTracker *services.ActivityTracker) *CartHandler {
 	return &CartHandler{
-		cartService: cartService,
+		cartService:     cartService,
+		activityTracker: activityTracker,
 	}
 }
 
-// RegisterRoutes registers the routes for cart management
+// cartRestaurantID returns the restaurant a cart belongs to, for activity tracking
+func cartRestaurantID(cart *services.CartResponse) string {
+	if cart == nil || cart.Cart == nil {
+		return ""
+	}
+	return cart.Cart.RestaurantID.String()
+}
+
+// cartOwner identifies whose carts a request works on: the logged-in user or, before login,
+// the guest token in the X-Guest-Token header. It answers 401 when there is neither.
+func cartOwner(c *gin.Context) (userID, guestToken string, ok bool) {
+	if id := middleware.GetUserID(c); id != "" {
+		return id, "", true
+	}
+	if token := c.GetHeader(services.GuestTokenHeader); token != "" {
+		return "", token, true
+	}
+	c.JSON(http.StatusUnauthorized, ErrorResponse{
+		Error:   "Unauthorized",
+		Message: "Log in or send a guest token in the " + services.GuestTokenHeader + " header",
+	})
+	return "", "", false
+}
+
+// RegisterRoutes registers the routes for cart management. Carts can be filled before login
+// with a guest token; coupons, the bill summary and checkout need a logged-in user. Carts are
+// read right after they are changed, so they never come from the read replica.
 func (h *CartHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
-	// All cart routes require authentication
-	cart := router.Group("/cart", authMiddleware.AuthRequired())
+	cart := router.Group("/cart", middleware.ForcePrimary())
+
+	// Issue a guest token for carts filled before login
+	cart.POST("/guest", h.CreateGuestToken)
+
+	guest := cart.Group("", authMiddleware.OptionalAuth())
 	{
 		// Get the user's cart
-		cart.GET("", h.GetCart)
+		guest.GET("", h.GetCart)
+		// List the user's carts, one per restaurant
+		guest.GET("/all", h.ListCarts)
+		// Make a restaurant's cart the current one
+		guest.POST("/switch", h.SwitchCart)
 		// Add item to cart
-		cart.POST("/items", h.AddToCart)
+		guest.POST("/items", h.AddToCart)
 		// Update cart item
-		cart.PUT("/items/:item_id", h.UpdateCartItem)
+		guest.PUT("/items/:item_id", h.UpdateCartItem)
 		// Remove item from cart
-		cart.DELETE("/items/:item_id", h.RemoveFromCart)
+		guest.DELETE("/items/:item_id", h.RemoveFromCart)
 		// Clear cart
-		cart.DELETE("", h.ClearCart)
+		guest.DELETE("", h.ClearCart)
+	}
+
+	user := cart.Group("", authMiddleware.AuthRequired())
+	{
 		// Apply coupon
-		cart.POST("/coupons", h.ApplyCoupon)
+		user.POST("/coupons", h.ApplyCoupon)
 		// Remove coupon
-		cart.DELETE("/coupons", h.RemoveCoupon)
+		user.DELETE("/coupons", h.RemoveCoupon)
+		// Coupons that apply to the cart, best offer first
+		user.GET("/applicable-coupons", h.GetApplicableCoupons)
 		// Get bill summary
-		cart.GET("/bill-summary", h.GetBillSummary)
+		user.GET("/bill-summary", h.GetBillSummary)
 		// Checkout cart
-		cart.POST("/checkout", h.Checkout)
+		user.POST("/checkout", h.Checkout)
+	}
+
+	// Order again: rebuild a cart from a past order
+	router.POST("/orders/:id/reorder", middleware.ForcePrimary(), authMiddleware.AuthRequired(), h.Reorder)
+}
+
+// CreateGuestToken godoc
+// @Summary Start a guest cart
+// @Description Issue a guest token so carts can be filled before login. Send it in the X-Guest-Token header on cart requests, and as guest_token when verifying the OTP to move the carts into the account. Guest carts expire a week after their last change.
+// @Tags cart
+// @Produce json
+// @Success 201 {object} services.GuestTokenResponse
+// @Router /cart/guest [post]
+func (h *CartHandler) CreateGuestToken(c *gin.Context) {
+	token, err := h.cartService.NewGuestToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to create guest token",
+			Message: err.Error(),
+		})
+		return
 	}
+
+	c.JSON(http.StatusCreated, token)
 }
 
 // GetCart godoc
 // @Summary Get user's cart
-// @Description Get current user's active cart
+// @Description Get the user's cart for a restaurant, creating an empty one if needed, or the current cart when no restaurant is given
 // @Tags cart
 // @Accept json
 // @Produce json
+// @Param restaurant_id query string false "Restaurant ID"
+// @Param X-Guest-Token header string false "Guest token, for carts filled before login"
 // @Success 200 {object} services.CartResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /cart [get]
 func (h *CartHandler) GetCart(c *gin.Context) {
-	// Get user ID from context
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "User ID not found",
-		})
+	uid, guestToken, ok := cartOwner(c)
+	if !ok {
 		return
 	}
+	ctx := c.Request.Context()
 
-	uid := userID.(string)
-	ctx := context.Background()
-
-	// For getting cart, we need to know the restaurant ID
-	// Let's assume we get it from query parameter for now
+	var cart *services.CartResponse
+	var err error
 	restaurantID := c.Query("restaurant_id")
-	if restaurantID == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Restaurant ID is required",
-			Message: "Please provide restaurant_id parameter",
-		})
+	switch {
+	case guestToken != "":
+		cart, err = h.cartService.GetGuestCart(ctx, guestToken, restaurantID)
+	case restaurantID != "":
+		cart, err = h.cartService.GetOrCreateCart(ctx, uid, restaurantID)
+	default:
+		cart, err = h.cartService.GetCart(ctx, uid)
+	}
+	if err != nil {
+		abortWithError(c, "Failed to get cart", err)
 		return
 	}
 
-	cart, err := h.cartService.GetOrCreateCart(ctx, uid, restaurantID)
+	c.JSON(http.StatusOK, cart)
+}
+
+// ListCarts godoc
+// @Summary List user's carts
+// @Description List the user's active carts, one per restaurant, with the current cart first
+// @Tags cart
+// @Produce json
+// @Param X-Guest-Token header string false "Guest token, for carts filled before login"
+// @Success 200 {object} services.CartListResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /cart/all [get]
+func (h *CartHandler) ListCarts(c *gin.Context) {
+	uid, guestToken, ok := cartOwner(c)
+	if !ok {
+		return
+	}
+
+	var carts *services.CartListResponse
+	var err error
+	if guestToken != "" {
+		carts, err = h.cartService.ListGuestCarts(c.Request.Context(), guestToken)
+	} else {
+		carts, err = h.cartService.ListCarts(c.Request.Context(), uid)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to get cart",
+			Error:   "Failed to list carts",
 			Message: err.Error(),
 		})
 		return
 	}
 
+	c.JSON(http.StatusOK, carts)
+}
+
+// SwitchCart godoc
+// @Summary Switch the current cart
+// @Description Make the user's cart for a restaurant the current one, creating it if needed. Item, coupon and clear requests without a restaurant_id act on the current cart.
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param request body SwitchCartRequest true "Restaurant to switch to"
+// @Param X-Guest-Token header string false "Guest token, for carts filled before login"
+// @Success 200 {object} services.CartResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /cart/switch [post]
+func (h *CartHandler) SwitchCart(c *gin.Context) {
+	var req SwitchCartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	uid, guestToken, ok := cartOwner(c)
+	if !ok {
+		return
+	}
+
+	var cart *services.CartResponse
+	var err error
+	if guestToken != "" {
+		cart, err = h.cartService.SwitchGuestCart(c.Request.Context(), guestToken, req.RestaurantID)
+	} else {
+		cart, err = h.cartService.SwitchCart(c.Request.Context(), uid, req.RestaurantID)
+	}
+	if err != nil {
+		abortWithError(c, "Failed to switch cart", apperr.Classify(err, apperr.KindValidation))
+		return
+	}
+
 	c.JSON(http.StatusOK, cart)
 }
 
 // AddToCart godoc
 // @Summary Add item to cart
-// @Description Add or update item in user's cart
+// @Description Add an item to the user's cart for the product's restaurant, which becomes the current cart. restaurant_id may be omitted; one that does not match the product is rejected.
 // @Tags cart
 // @Accept json
 // @Produce json
 // @Param item body AddToCartRequest true "Cart item data"
+// @Param X-Guest-Token header string false "Guest token, for carts filled before login"
 // @Success 200 {object} services.CartResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /cart/add [post]
 func (h *CartHandler) AddToCart(c *gin.Context) {
 	var req AddToCartRequest
@@ -114,34 +249,40 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 		return
 	}
 
-	// Get user ID from context
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "User ID not found",
-		})
+	uid, guestToken, ok := cartOwner(c)
+	if !ok {
 		return
 	}
-
-	uid := userID.(string)
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	// Create service request
 	serviceReq := &services.AddToCartRequest{
 		ProductID: req.ProductID,
 		Quantity:  req.Quantity,
+		VariantID: req.VariantID,
+		AddonIDs:  req.AddonIDs,
+		OptionIDs: req.OptionIDs,
 	}
 
-	cart, err := h.cartService.AddToCart(ctx, uid, req.RestaurantID, serviceReq)
+	var cart *services.CartResponse
+	var err error
+	if guestToken != "" {
+		cart, err = h.cartService.AddToGuestCart(ctx, guestToken, req.RestaurantID, serviceReq)
+	} else {
+		cart, err = h.cartService.AddToCart(ctx, uid, req.RestaurantID, serviceReq)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to add item to cart",
-			Message: err.Error(),
-		})
+		abortWithError(c, "Failed to add item to cart", err)
 		return
 	}
 
+	h.activityTracker.TrackActivity(activityContextFrom(c), services.ActivityAddToCart, req.RestaurantID, req.ProductID, map[string]interface{}{
+		"quantity":   req.Quantity,
+		"variant_id": req.VariantID,
+		"addon_ids":  req.AddonIDs,
+		"option_ids": req.OptionIDs,
+	})
+
 	c.JSON(http.StatusOK, cart)
 }
 
@@ -152,9 +293,11 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param item body UpdateCartItemRequest true "Update item data"
+// @Param X-Guest-Token header string false "Guest token, for carts filled before login"
 // @Success 200 {object} services.CartResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /cart/update [put]
 func (h *CartHandler) UpdateCartItem(c *gin.Context) {
 	var req UpdateCartItemRequest
@@ -166,34 +309,45 @@ func (h *CartHandler) UpdateCartItem(c *gin.Context) {
 		return
 	}
 
-	// Get user ID from context
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "User ID not found",
-		})
+	uid, guestToken, ok := cartOwner(c)
+	if !ok {
 		return
 	}
+	ctx := c.Request.Context()
 
-	uid := userID.(string)
-	ctx := context.Background()
+	// A plain product ID in the path is resolved with the selection in the body
+	itemKey := c.Param("item_id")
+	if !strings.Contains(itemKey, ":") {
+		itemKey = ""
+	}
 
 	// Create service request
 	serviceReq := &services.UpdateCartItemRequest{
-		ProductID: req.ProductID,
-		Quantity:  req.Quantity,
+		RestaurantID: req.RestaurantID,
+		ProductID:    req.ProductID,
+		Quantity:     req.Quantity,
+		ItemKey:      itemKey,
+		VariantID:    req.VariantID,
+		AddonIDs:     req.AddonIDs,
+		OptionIDs:    req.OptionIDs,
 	}
 
-	cart, err := h.cartService.UpdateCartItem(ctx, uid, serviceReq)
+	var cart *services.CartResponse
+	var err error
+	if guestToken != "" {
+		cart, err = h.cartService.UpdateGuestCartItem(ctx, guestToken, serviceReq)
+	} else {
+		cart, err = h.cartService.UpdateCartItem(ctx, uid, serviceReq)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to update cart item",
-			Message: err.Error(),
-		})
+		abortWithError(c, "Failed to update cart item", err)
 		return
 	}
 
+	h.activityTracker.TrackActivity(activityContextFrom(c), services.ActivityUpdateCartItem, cartRestaurantID(cart), req.ProductID, map[string]interface{}{
+		"quantity": req.Quantity,
+	})
+
 	c.JSON(http.StatusOK, cart)
 }
 
@@ -203,14 +357,17 @@ func (h *CartHandler) UpdateCartItem(c *gin.Context) {
 // @Tags cart
 // @Accept json
 // @Produce json
-// @Param productId path string true "Product ID"
+// @Param item_id path string true "Item key from the cart response, or a product ID for a line without variant or addons"
+// @Param restaurant_id query string false "Restaurant whose cart to change; defaults to the current cart"
+// @Param X-Guest-Token header string false "Guest token, for carts filled before login"
 // @Success 200 {object} services.CartResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /cart/remove/{productId} [delete]
 func (h *CartHandler) RemoveFromCart(c *gin.Context) {
-	productID := c.Param("productId")
-	if productID == "" {
+	itemKey := c.Param("item_id")
+	if itemKey == "" {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "Product ID is required",
 			Message: "Please provide a valid product ID",
@@ -218,63 +375,63 @@ func (h *CartHandler) RemoveFromCart(c *gin.Context) {
 		return
 	}
 
-	// Get user ID from context
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "User ID not found",
-		})
+	uid, guestToken, ok := cartOwner(c)
+	if !ok {
 		return
 	}
-
-	uid := userID.(string)
-	ctx := context.Background()
-
-	cart, err := h.cartService.RemoveFromCart(ctx, uid, productID)
+	ctx := c.Request.Context()
+
+	var cart *services.CartResponse
+	var err error
+	if guestToken != "" {
+		cart, err = h.cartService.RemoveFromGuestCart(ctx, guestToken, c.Query("restaurant_id"), itemKey)
+	} else {
+		cart, err = h.cartService.RemoveFromCart(ctx, uid, c.Query("restaurant_id"), itemKey)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to remove item from cart",
-			Message: err.Error(),
-		})
+		abortWithError(c, "Failed to remove item from cart", err)
 		return
 	}
 
+	productID := strings.SplitN(itemKey, ":", 2)[0]
+	h.activityTracker.TrackActivity(activityContextFrom(c), services.ActivityRemoveFromCart, cartRestaurantID(cart), productID, nil)
+
 	c.JSON(http.StatusOK, cart)
 }
 
 // ClearCart godoc
 // @Summary Clear user's cart
-// @Description Remove all items from user's cart
+// @Description Remove all items from the user's cart for a restaurant, or from the current cart
 // @Tags cart
 // @Accept json
 // @Produce json
+// @Param restaurant_id query string false "Restaurant ID"
+// @Param X-Guest-Token header string false "Guest token, for carts filled before login"
 // @Success 204 "No Content"
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /cart/clear [delete]
 func (h *CartHandler) ClearCart(c *gin.Context) {
-	// Get user ID from context
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "User ID not found",
-		})
+	uid, guestToken, ok := cartOwner(c)
+	if !ok {
 		return
 	}
+	ctx := c.Request.Context()
 
-	uid := userID.(string)
-	ctx := context.Background()
-
-	if err := h.cartService.ClearCart(ctx, uid); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to clear cart",
-			Message: err.Error(),
-		})
+	var err error
+	if guestToken != "" {
+		err = h.cartService.ClearGuestCart(ctx, guestToken, c.Query("restaurant_id"))
+	} else {
+		err = h.cartService.ClearCart(ctx, uid, c.Query("restaurant_id"))
+	}
+	if err != nil {
+		abortWithError(c, "Failed to clear cart", err)
 		return
 	}
 
+	h.activityTracker.TrackActivity(activityContextFrom(c), services.ActivityClearCart, "", "", nil)
+
 	c.Status(http.StatusNoContent)
 }
 
@@ -288,6 +445,7 @@ func (h *CartHandler) ClearCart(c *gin.Context) {
 // @Success 200 {object} services.CartResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /cart/coupon [post]
 func (h *CartHandler) ApplyCoupon(c *gin.Context) {
 	var req ApplyCouponRequest
@@ -310,14 +468,11 @@ func (h *CartHandler) ApplyCoupon(c *gin.Context) {
 	}
 
 	uid := userID.(string)
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
-	cart, err := h.cartService.ApplyCoupon(ctx, uid, req.CouponCode)
+	cart, err := h.cartService.ApplyCoupon(ctx, uid, req.RestaurantID, req.CouponCode)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to apply coupon",
-			Message: err.Error(),
-		})
+		abortWithError(c, "Failed to apply coupon", apperr.Classify(err, apperr.KindValidation))
 		return
 	}
 
@@ -326,13 +481,15 @@ func (h *CartHandler) ApplyCoupon(c *gin.Context) {
 
 // RemoveCoupon godoc
 // @Summary Remove coupon from cart
-// @Description Remove applied coupon from user's cart
+// @Description Remove the applied coupon from the user's cart for a restaurant, or from the current cart
 // @Tags cart
 // @Accept json
 // @Produce json
+// @Param restaurant_id query string false "Restaurant ID"
 // @Success 200 {object} services.CartResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /cart/coupon [delete]
 func (h *CartHandler) RemoveCoupon(c *gin.Context) {
 	// Get user ID from context
@@ -346,31 +503,76 @@ func (h *CartHandler) RemoveCoupon(c *gin.Context) {
 	}
 
 	uid := userID.(string)
-	ctx := context.Background()
+	ctx := c.Request.Context()
+
+	cart, err := h.cartService.RemoveCoupon(ctx, uid, c.Query("restaurant_id"))
+	if err != nil {
+		abortWithError(c, "Failed to remove coupon", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+// GetApplicableCoupons godoc
+// @Summary List coupons applicable to the cart
+// @Description Evaluate all active platform and restaurant coupons against the cart, sorted by the discount each gives
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param restaurant_id query string true "Restaurant ID"
+// @Success 200 {object} services.ApplicableCouponsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /cart/applicable-coupons [get]
+func (h *CartHandler) GetApplicableCoupons(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "Unauthorized",
+			Message: "User ID not found",
+		})
+		return
+	}
+
+	uid := userID.(string)
+	restaurantID := c.Query("restaurant_id")
+	if restaurantID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Restaurant ID is required",
+			Message: "Please provide restaurant_id parameter",
+		})
+		return
+	}
 
-	cart, err := h.cartService.RemoveCoupon(ctx, uid)
+	coupons, err := h.cartService.GetApplicableCoupons(c.Request.Context(), uid, restaurantID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to remove coupon",
+			Error:   "Failed to get applicable coupons",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, cart)
+	c.JSON(http.StatusOK, coupons)
 }
 
 // GetBillSummary godoc
 // @Summary Get bill summary for cart
-// @Description Get detailed bill summary including taxes, delivery charges, and coupons
+// @Description Get detailed bill summary including taxes, delivery charges, and coupons. min_order_shortfall is what has to be added before checkout when the delivery area has a minimum order value.
 // @Tags cart
 // @Accept json
 // @Produce json
 // @Param restaurant_id query string true "Restaurant ID"
-// @Param address_id query string true "Delivery Address ID"
+// @Param order_type query string false "delivery (default), pickup or dine_in"
+// @Param address_id query string false "Delivery Address ID; required for delivery"
+// @Param auto_apply_coupon query bool false "Apply the best available coupon when none is applied"
 // @Success 200 {object} services.BillSummaryResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse "Address outside the delivery area"
 // @Router /cart/bill-summary [get]
 func (h *CartHandler) GetBillSummary(c *gin.Context) {
 	// Get user ID from context
@@ -385,6 +587,7 @@ func (h *CartHandler) GetBillSummary(c *gin.Context) {
 
 	uid := userID.(string)
 	restaurantID := c.Query("restaurant_id")
+	orderType := c.DefaultQuery("order_type", services.OrderTypeDelivery)
 	addressID := c.Query("address_id")
 
 	if restaurantID == "" {
@@ -395,7 +598,7 @@ func (h *CartHandler) GetBillSummary(c *gin.Context) {
 		return
 	}
 
-	if addressID == "" {
+	if addressID == "" && orderType == services.OrderTypeDelivery {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "Address ID is required",
 			Message: "Please provide address_id parameter",
@@ -403,13 +606,12 @@ func (h *CartHandler) GetBillSummary(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
-	billSummary, err := h.cartService.GetBillSummary(ctx, uid, restaurantID, addressID)
+	// The request context carries the customer's language
+	ctx := c.Request.Context()
+	autoApplyCoupon := c.Query("auto_apply_coupon") == "true"
+	billSummary, err := h.cartService.GetBillSummary(ctx, uid, restaurantID, orderType, addressID, autoApplyCoupon)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to get bill summary",
-			Message: err.Error(),
-		})
+		abortWithError(c, "Failed to get bill summary", err)
 		return
 	}
 
@@ -418,7 +620,7 @@ func (h *CartHandler) GetBillSummary(c *gin.Context) {
 
 // Checkout godoc
 // @Summary Checkout cart
-// @Description Create order and payment records for cart checkout
+// @Description Create order and payment records for cart checkout. Pickup and dine-in orders need no address_id and pay no delivery fee. Dine-in orders need the table_token from the table's QR code and go on the table's running tab, paid when staff close it.
 // @Tags cart
 // @Accept json
 // @Produce json
@@ -426,6 +628,8 @@ func (h *CartHandler) GetBillSummary(c *gin.Context) {
 // @Success 200 {object} services.CheckoutResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse "Below the minimum order value or outside the delivery area"
 // @Router /cart/checkout [post]
 func (h *CartHandler) Checkout(c *gin.Context) {
 	var req CheckoutRequest
@@ -447,40 +651,100 @@ func (h *CartHandler) Checkout(c *gin.Context) {
 		return
 	}
 
+	if req.AddressID == "" && (req.OrderType == "" || req.OrderType == services.OrderTypeDelivery) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Address ID is required",
+			Message: "Delivery orders need an address_id",
+		})
+		return
+	}
+
 	uid := userID.(string)
-	ctx := context.Background()
+	// The request context carries the customer's language
+	ctx := c.Request.Context()
 
-	checkoutResponse, err := h.cartService.Checkout(ctx, uid, req.RestaurantID, req.AddressID)
+	checkoutResponse, err := h.cartService.Checkout(ctx, uid, req.RestaurantID, req.OrderType, req.AddressID, req.TableToken, req.CustomerNotes)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to checkout",
-			Message: err.Error(),
-		})
+		abortWithError(c, "Failed to checkout", err)
 		return
 	}
 
+	h.activityTracker.TrackActivity(activityContextFrom(c), services.ActivityCheckout, req.RestaurantID, checkoutResponse.OrderID, map[string]interface{}{
+		"total_amount":   checkoutResponse.TotalAmount,
+		"payment_method": checkoutResponse.PaymentMethod,
+		"order_type":     checkoutResponse.OrderType,
+	})
+
 	c.JSON(http.StatusOK, checkoutResponse)
 }
 
+// Reorder godoc
+// @Summary Order again
+// @Description Rebuild the user's cart for a past order's restaurant from the order's items at current prices, replacing what the cart held. Items that can no longer be added are listed with the reason, as are items whose price changed. The cart is ready for the bill summary.
+// @Tags cart
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} services.ReorderResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders/{id}/reorder [post]
+func (h *CartHandler) Reorder(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "Unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	response, err := h.cartService.Reorder(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to reorder", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // Request and Response structs
 type AddToCartRequest struct {
-	ProductID    string `json:"product_id" binding:"required"`
-	RestaurantID string `json:"restaurant_id" binding:"required"`
-	Quantity     int    `json:"quantity" binding:"required,min=1"`
+	ProductID    string   `json:"product_id" binding:"required"`
+	RestaurantID string   `json:"restaurant_id,omitempty"` // defaults to the product's restaurant
+	Quantity     int      `json:"quantity" binding:"required,min=1"`
+	VariantID    string   `json:"variant_id,omitempty"`
+	AddonIDs     []string `json:"addon_ids,omitempty"`
+	OptionIDs    []string `json:"option_ids,omitempty"` // options chosen from the product's option groups
 }
 
+// UpdateCartItemRequest updates the line in the path; variant_id, addon_ids and option_ids
+// identify the line when the path holds a product ID
 type UpdateCartItemRequest struct {
-	ProductID string `json:"product_id" binding:"required"`
-	Quantity  int    `json:"quantity" binding:"required,min=0"`
+	RestaurantID string   `json:"restaurant_id,omitempty"` // defaults to the current cart
+	ProductID    string   `json:"product_id" binding:"required"`
+	Quantity     int      `json:"quantity" binding:"required,min=0"`
+	VariantID    string   `json:"variant_id,omitempty"`
+	AddonIDs     []string `json:"addon_ids,omitempty"`
+	OptionIDs    []string `json:"option_ids,omitempty"`
 }
 
 type ApplyCouponRequest struct {
-	CouponCode string `json:"coupon_code" binding:"required"`
+	RestaurantID string `json:"restaurant_id,omitempty"` // defaults to the current cart
+	CouponCode   string `json:"coupon_code" binding:"required"`
 }
 
-type CheckoutRequest struct {
+type SwitchCartRequest struct {
 	RestaurantID string `json:"restaurant_id" binding:"required"`
-	AddressID    string `json:"address_id" binding:"required"`
+}
+
+type CheckoutRequest struct {
+	RestaurantID  string `json:"restaurant_id" binding:"required"`
+	OrderType     string `json:"order_type" binding:"omitempty,oneof=delivery pickup dine_in"` // defaults to delivery
+	AddressID     string `json:"address_id"`                                                   // required for delivery
+	TableToken    string `json:"table_token"`                                                  // from the table's QR code; required for dine_in
+	CustomerNotes string `json:"customer_notes" binding:"max=250"`                             // cooking or delivery instructions, printed on the kitchen ticket
 }
 
 // ErrorResponse is defined in restaurant_handler.go
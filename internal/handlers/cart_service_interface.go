@@ -8,12 +8,25 @@ import (
 // CartServiceInterface defines the contract for cart service
 type CartServiceInterface interface {
 	GetOrCreateCart(ctx context.Context, userID, restaurantID string) (*services.CartResponse, error)
+	GetCart(ctx context.Context, userID string) (*services.CartResponse, error)
+	ListCarts(ctx context.Context, userID string) (*services.CartListResponse, error)
+	SwitchCart(ctx context.Context, userID, restaurantID string) (*services.CartResponse, error)
 	AddToCart(ctx context.Context, userID, restaurantID string, req *services.AddToCartRequest) (*services.CartResponse, error)
 	UpdateCartItem(ctx context.Context, userID string, req *services.UpdateCartItemRequest) (*services.CartResponse, error)
-	RemoveFromCart(ctx context.Context, userID, productID string) (*services.CartResponse, error)
-	ClearCart(ctx context.Context, userID string) error
-	ApplyCoupon(ctx context.Context, userID, couponCode string) (*services.CartResponse, error)
-	RemoveCoupon(ctx context.Context, userID string) (*services.CartResponse, error)
-	GetBillSummary(ctx context.Context, userID, restaurantID, addressID string) (*services.BillSummaryResponse, error)
-	Checkout(ctx context.Context, userID, restaurantID, addressID string) (*services.CheckoutResponse, error)
+	RemoveFromCart(ctx context.Context, userID, restaurantID, productID string) (*services.CartResponse, error)
+	ClearCart(ctx context.Context, userID, restaurantID string) error
+	ApplyCoupon(ctx context.Context, userID, restaurantID, couponCode string) (*services.CartResponse, error)
+	RemoveCoupon(ctx context.Context, userID, restaurantID string) (*services.CartResponse, error)
+	NewGuestToken() (*services.GuestTokenResponse, error)
+	GetGuestCart(ctx context.Context, token, restaurantID string) (*services.CartResponse, error)
+	ListGuestCarts(ctx context.Context, token string) (*services.CartListResponse, error)
+	SwitchGuestCart(ctx context.Context, token, restaurantID string) (*services.CartResponse, error)
+	AddToGuestCart(ctx context.Context, token, restaurantID string, req *services.AddToCartRequest) (*services.CartResponse, error)
+	UpdateGuestCartItem(ctx context.Context, token string, req *services.UpdateCartItemRequest) (*services.CartResponse, error)
+	RemoveFromGuestCart(ctx context.Context, token, restaurantID, itemKey string) (*services.CartResponse, error)
+	ClearGuestCart(ctx context.Context, token, restaurantID string) error
+	GetApplicableCoupons(ctx context.Context, userID, restaurantID string) (*services.ApplicableCouponsResponse, error)
+	GetBillSummary(ctx context.Context, userID, restaurantID, orderType, addressID string, autoApplyCoupon bool) (*services.BillSummaryResponse, error)
+	Checkout(ctx context.Context, userID, restaurantID, orderType, addressID, tableToken, customerNotes string) (*services.CheckoutResponse, error)
+	Reorder(ctx context.Context, userID, orderID string) (*services.ReorderResponse, error)
 }
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CateringHandler struct {
+	cateringService *services.CateringService
+}
+
+func NewCateringHandler(cateringService *services.CateringService) *CateringHandler {
+	return &CateringHandler{
+		cateringService: cateringService,
+	}
+}
+
+// RegisterRoutes registers the catering quote routes for customers and restaurant staff
+func (h *CateringHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	customer := router.Group("/catering/quotes", authMiddleware.AuthRequired())
+	{
+		customer.POST("", h.RequestQuote)
+		customer.GET("", h.ListMyQuotes)
+		customer.GET("/:id", h.GetMyQuote)
+		customer.POST("/:id/accept", h.AcceptQuote)
+		customer.POST("/:id/cancel", h.CancelQuote)
+	}
+
+	restaurant := router.Group("/restaurant/catering/quotes",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantRequired(),
+		authMiddleware.RestaurantStaffRequired(),
+		authMiddleware.RestaurantPermissionRequired(services.StaffPermissionOrders),
+	)
+	{
+		restaurant.GET("", h.ListRestaurantQuotes)
+		restaurant.GET("/:id", h.GetRestaurantQuote)
+		restaurant.POST("/:id/quote", h.SendQuote)
+		restaurant.POST("/:id/decline", h.DeclineQuote)
+	}
+}
+
+// RequestQuote godoc
+// @Summary Request a catering quote
+// @Description Ask a restaurant to quote for a large order: the items with quantities, the headcount and when the food is wanted. Requests need the platform's minimum headcount and notice; the items are priced at menu prices as a guide.
+// @Tags catering
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.CateringQuoteRequest true "Catering request"
+// @Success 201 {object} services.CateringQuoteDetails
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /catering/quotes [post]
+func (h *CateringHandler) RequestQuote(c *gin.Context) {
+	var req services.CateringQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	quote, err := h.cateringService.RequestQuote(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to request catering quote", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, quote)
+}
+
+// ListMyQuotes godoc
+// @Summary List my catering quotes
+// @Description Your catering requests and quotes, newest first
+// @Tags catering
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {array} services.CateringQuoteDetails
+// @Router /catering/quotes [get]
+func (h *CateringHandler) ListMyQuotes(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	quotes, err := h.cateringService.ListUserQuotes(c.Request.Context(), middleware.GetUserID(c), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list catering quotes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quotes)
+}
+
+// GetMyQuote godoc
+// @Summary Get a catering quote
+// @Description One of your catering quotes; once accepted, with the order's status, the payment link and the amount paid
+// @Tags catering
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Quote ID"
+// @Success 200 {object} services.CateringQuoteDetails
+// @Failure 404 {object} ErrorResponse
+// @Router /catering/quotes/{id} [get]
+func (h *CateringHandler) GetMyQuote(c *gin.Context) {
+	quote, err := h.cateringService.GetUserQuote(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get catering quote", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// AcceptQuote godoc
+// @Summary Accept a catering quote
+// @Description Accept the restaurant's quote before it expires. It becomes an order scheduled for the event, paid through a payment link for the quoted amount that takes the advance first; paying the advance confirms the order.
+// @Tags catering
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Quote ID"
+// @Success 200 {object} services.CateringQuoteDetails
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /catering/quotes/{id}/accept [post]
+func (h *CateringHandler) AcceptQuote(c *gin.Context) {
+	quote, err := h.cateringService.AcceptQuote(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to accept catering quote", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// CancelQuote godoc
+// @Summary Cancel a catering request
+// @Description Withdraw a catering request, or turn down the restaurant's quote
+// @Tags catering
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Quote ID"
+// @Success 200 {object} services.CateringQuoteDetails
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /catering/quotes/{id}/cancel [post]
+func (h *CateringHandler) CancelQuote(c *gin.Context) {
+	quote, err := h.cateringService.CancelQuote(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to cancel catering request", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// ListRestaurantQuotes godoc
+// @Summary List catering quotes
+// @Description The restaurant's catering requests and quotes by event date (staff with orders.manage)
+// @Tags catering
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "requested, quoted, accepted, declined, cancelled or expired"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {array} services.CateringQuoteDetails
+// @Failure 403 {object} ErrorResponse
+// @Router /restaurant/catering/quotes [get]
+func (h *CateringHandler) ListRestaurantQuotes(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	quotes, err := h.cateringService.ListRestaurantQuotes(c.Request.Context(), middleware.GetRestaurantID(c), c.Query("status"), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list catering quotes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quotes)
+}
+
+// GetRestaurantQuote godoc
+// @Summary Get a catering quote
+// @Description One of the restaurant's catering quotes with its order's payment (staff with orders.manage)
+// @Tags catering
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Quote ID"
+// @Success 200 {object} services.CateringQuoteDetails
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurant/catering/quotes/{id} [get]
+func (h *CateringHandler) GetRestaurantQuote(c *gin.Context) {
+	quote, err := h.cateringService.GetRestaurantQuote(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get catering quote", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// SendQuote godoc
+// @Summary Quote for a catering request
+// @Description Send the customer a price for the request and the share of it to pay up front, or revise a quote they have yet to accept. The quote stays open until valid_until, by default the platform's quote validity, and never past the event (staff with orders.manage).
+// @Tags catering
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Quote ID"
+// @Param request body services.CateringQuoteOffer true "Quote"
+// @Success 200 {object} services.CateringQuoteDetails
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /restaurant/catering/quotes/{id}/quote [post]
+func (h *CateringHandler) SendQuote(c *gin.Context) {
+	var req services.CateringQuoteOffer
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	quote, err := h.cateringService.SendQuote(c.Request.Context(), middleware.GetUserID(c), middleware.GetRestaurantID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to send catering quote", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// DeclineQuote godoc
+// @Summary Decline a catering request
+// @Description Turn down a catering request, or withdraw a quote the customer has yet to accept, telling them why (staff with orders.manage)
+// @Tags catering
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Quote ID"
+// @Param request body services.DeclineCateringQuoteRequest true "Reason"
+// @Success 200 {object} services.CateringQuoteDetails
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /restaurant/catering/quotes/{id}/decline [post]
+func (h *CateringHandler) DeclineQuote(c *gin.Context) {
+	var req services.DeclineCateringQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	quote, err := h.cateringService.DeclineQuote(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to decline catering request", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommissionHandler struct {
+	commissionService *services.CommissionService
+}
+
+func NewCommissionHandler(commissionService *services.CommissionService) *CommissionHandler {
+	return &CommissionHandler{
+		commissionService: commissionService,
+	}
+}
+
+// RegisterRoutes registers the admin commission routes
+func (h *CommissionHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := router.Group("/admin/restaurants/:id/commissions",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionRestaurants),
+	)
+	{
+		admin.GET("", h.GetHistory)
+		admin.POST("", h.SetCommission)
+		admin.DELETE("/:commission_id", h.DeleteScheduled)
+	}
+}
+
+// GetHistory godoc
+// @Summary Get a restaurant's commission history
+// @Description Get every commission version for a restaurant, including scheduled ones, and the one in effect now
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Success 200 {object} services.CommissionHistoryResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/restaurants/{id}/commissions [get]
+func (h *CommissionHandler) GetHistory(c *gin.Context) {
+	history, err := h.commissionService.GetHistory(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get commission history", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// SetCommission godoc
+// @Summary Set a restaurant's commission
+// @Description Add a percentage or flat commission starting now or at a future date. The previous terms end when it starts; orders placed before keep their terms.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param request body services.SetCommissionRequest true "Commission terms"
+// @Success 201 {object} models.CommissionToRestaurant
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/restaurants/{id}/commissions [post]
+func (h *CommissionHandler) SetCommission(c *gin.Context) {
+	var req services.SetCommissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	commission, err := h.commissionService.SetCommission(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to set commission", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, commission)
+}
+
+// DeleteScheduled godoc
+// @Summary Delete a scheduled commission
+// @Description Delete a commission version that has not started yet; the terms before it stay in effect
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param commission_id path string true "Commission ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/restaurants/{id}/commissions/{commission_id} [delete]
+func (h *CommissionHandler) DeleteScheduled(c *gin.Context) {
+	err := h.commissionService.DeleteScheduled(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), c.Param("commission_id"))
+	if err != nil {
+		abortWithError(c, "Failed to delete commission", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 
@@ -28,7 +27,7 @@ func (h *CouponHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *
 	// Public routes
 	coupons.GET("", h.GetCoupons)
 	coupons.GET("/:id", h.GetCouponByID)
-	coupons.POST("/validate", h.ValidateCoupon)
+	coupons.POST("/validate", authMiddleware.AuthRequired(), h.ValidateCoupon)
 
 	// Protected routes (admin/restaurant owner only)
 	admin := coupons.Group("/", authMiddleware.AuthRequired(), authMiddleware.AdminRequired())
@@ -36,6 +35,7 @@ func (h *CouponHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *
 		admin.POST("", h.CreateCoupon)
 		admin.PUT("/:id", h.UpdateCoupon)
 		admin.DELETE("/:id", h.DeleteCoupon)
+		admin.GET("/:id/redemptions", h.GetRedemptions)
 	}
 }
 
@@ -70,7 +70,7 @@ func (h *CouponHandler) CreateCoupon(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	coupon, err := h.couponService.CreateCoupon(ctx, userID.(string), &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -110,7 +110,7 @@ func (h *CouponHandler) GetCoupons(c *gin.Context) {
 		}
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	response, err := h.couponService.GetCoupons(ctx, page, limit, restaurantID, active)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -144,7 +144,7 @@ func (h *CouponHandler) GetCouponByID(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	coupon, err := h.couponService.GetCouponByID(ctx, couponID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{
@@ -159,8 +159,9 @@ func (h *CouponHandler) GetCouponByID(c *gin.Context) {
 
 // ValidateCoupon godoc
 // @Summary Validate coupon code
-// @Description Validate a coupon code for a specific user and restaurant
+// @Description Validate a coupon code for the signed-in user and a restaurant, including per-user limits and targeting
 // @Tags coupon
+// @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param validation body services.ValidateCouponRequest true "Validation data"
@@ -188,7 +189,7 @@ func (h *CouponHandler) ValidateCoupon(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	validation, err := h.couponService.ValidateCoupon(ctx, userID.(string), &req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
@@ -201,6 +202,40 @@ func (h *CouponHandler) ValidateCoupon(c *gin.Context) {
 	c.JSON(http.StatusOK, validation)
 }
 
+// GetRedemptions godoc
+// @Summary Get coupon redemptions
+// @Description List the orders a coupon was used on, newest first (admin only)
+// @Tags coupon
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Coupon ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} services.CouponRedemptionListResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /coupons/{id}/redemptions [get]
+func (h *CouponHandler) GetRedemptions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	response, err := h.couponService.GetRedemptions(c.Request.Context(), c.Param("id"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to get redemptions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // UpdateCoupon godoc
 // @Summary Update coupon
 // @Description Update an existing coupon
@@ -243,7 +278,7 @@ func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	coupon, err := h.couponService.UpdateCoupon(ctx, userID.(string), couponID, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -288,7 +323,7 @@ func (h *CouponHandler) DeleteCoupon(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	if err := h.couponService.DeleteCoupon(ctx, userID.(string), couponID); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to delete coupon",
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeliveryHandler struct {
+	deliveryPartnerService *services.DeliveryPartnerService
+	deliveryFeeService     *services.DeliveryFeeService
+}
+
+func NewDeliveryHandler(deliveryPartnerService *services.DeliveryPartnerService, deliveryFeeService *services.DeliveryFeeService) *DeliveryHandler {
+	return &DeliveryHandler{
+		deliveryPartnerService: deliveryPartnerService,
+		deliveryFeeService:     deliveryFeeService,
+	}
+}
+
+// RegisterRoutes registers provider-agnostic delivery routes
+func (h *DeliveryHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	// Webhooks from delivery providers (no auth, verified by the provider adapter)
+	router.POST("/delivery/webhooks/:provider", h.ProviderWebhook)
+
+	admin := router.Group("/admin/delivery", authMiddleware.AuthRequired(), authMiddleware.AdminRequired())
+	{
+		admin.PUT("/surge", h.SetSurge)
+		admin.DELETE("/surge", h.ClearSurge)
+	}
+
+	router.GET("/delivery/partners", authMiddleware.AuthRequired(), authMiddleware.RestaurantOwnerRequired(), h.ListPartnerCompanies)
+
+	owner := router.Group("/restaurants/:id/delivery-partners",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantOwnerRequired(),
+	)
+	{
+		owner.GET("", h.ListLinks)
+		owner.POST("", h.RequestLink)
+		owner.PUT("/:link_id", h.UpdateLink)
+		owner.DELETE("/:link_id", h.RemoveLink)
+	}
+}
+
+// SetSurge godoc
+// @Summary Set delivery surge
+// @Description Apply a temporary delivery fee multiplier to a restaurant, or platform-wide when restaurant_id is empty (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.SetSurgeRequest true "Surge settings"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/delivery/surge [put]
+func (h *DeliveryHandler) SetSurge(c *gin.Context) {
+	var req services.SetSurgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.deliveryFeeService.SetSurge(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to set surge",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Surge applied"})
+}
+
+// ClearSurge godoc
+// @Summary Clear delivery surge
+// @Description Remove the surge for a restaurant, or the platform-wide surge when restaurant_id is omitted (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param restaurant_id query string false "Restaurant ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/delivery/surge [delete]
+func (h *DeliveryHandler) ClearSurge(c *gin.Context) {
+	if err := h.deliveryFeeService.ClearSurge(c.Request.Context(), c.Query("restaurant_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to clear surge",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Surge cleared"})
+}
+
+// ProviderWebhook godoc
+// @Summary Delivery provider webhook
+// @Description Receive status updates from any enabled delivery provider
+// @Tags delivery
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider key (e.g. porter)"
+// @Success 200 {object} services.DeliveryWebhookEvent
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /delivery/webhooks/{provider} [post]
+func (h *DeliveryHandler) ProviderWebhook(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid webhook payload",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	event, err := h.deliveryPartnerService.HandleProviderWebhook(c.Request.Context(), c.Param("provider"), body)
+	if err != nil {
+		status := http.StatusBadRequest
+		if event != nil {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, ErrorResponse{
+			Error:   "Failed to process webhook",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
+
+// ListPartnerCompanies godoc
+// @Summary List delivery partners
+// @Description List the delivery partner companies a restaurant can ask to deliver its orders (restaurant owners)
+// @Tags delivery
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} services.DeliveryPartnerCompanySummary
+// @Failure 500 {object} ErrorResponse
+// @Router /delivery/partners [get]
+func (h *DeliveryHandler) ListPartnerCompanies(c *gin.Context) {
+	companies, err := h.deliveryPartnerService.ListPartnerCompanies(c.Request.Context())
+	if err != nil {
+		abortWithError(c, "Failed to list delivery partners", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, companies)
+}
+
+// ListLinks godoc
+// @Summary List a restaurant's delivery partners
+// @Description List the delivery partners linked to a restaurant you own and the requests they have yet to answer, lowest priority first, with each link's dispatch settings
+// @Tags delivery
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Success 200 {array} services.DeliveryPartnerLink
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurants/{id}/delivery-partners [get]
+func (h *DeliveryHandler) ListLinks(c *gin.Context) {
+	links, err := h.deliveryPartnerService.ListLinks(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to list delivery partners", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// RequestLink godoc
+// @Summary Request a delivery partner
+// @Description Ask a delivery partner to deliver a restaurant's orders, with the link's priority (lower is tried first), weight (share of orders among links of the same priority) and an optional fee agreed per delivery that replaces the partner's quotes. Deliveries are booked through the link once the partner accepts it; a declined request can be made again.
+// @Tags delivery
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param request body services.DeliveryPartnerLinkRequest true "Delivery partner and dispatch settings"
+// @Success 201 {object} services.DeliveryPartnerLink
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /restaurants/{id}/delivery-partners [post]
+func (h *DeliveryHandler) RequestLink(c *gin.Context) {
+	var req services.DeliveryPartnerLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	link, err := h.deliveryPartnerService.RequestLink(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to request delivery partner", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+// UpdateLink godoc
+// @Summary Update a delivery partner link
+// @Description Replace the dispatch settings of a restaurant's link to a delivery partner: priority, weight, agreed fee (null to use the partner's quotes) and whether it is switched on
+// @Tags delivery
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param link_id path string true "Link ID"
+// @Param request body services.DeliveryPartnerLinkSettings true "Dispatch settings"
+// @Success 200 {object} services.DeliveryPartnerLink
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurants/{id}/delivery-partners/{link_id} [put]
+func (h *DeliveryHandler) UpdateLink(c *gin.Context) {
+	var req services.DeliveryPartnerLinkSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	link, err := h.deliveryPartnerService.UpdateLink(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"), c.Param("link_id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to update delivery partner", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, link)
+}
+
+// RemoveLink godoc
+// @Summary Remove a delivery partner
+// @Description Unlink a delivery partner from a restaurant, or withdraw a request it has yet to answer. Deliveries already booked are not affected.
+// @Tags delivery
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param link_id path string true "Link ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurants/{id}/delivery-partners/{link_id} [delete]
+func (h *DeliveryHandler) RemoveLink(c *gin.Context) {
+	if err := h.deliveryPartnerService.RemoveLink(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"), c.Param("link_id")); err != nil {
+		abortWithError(c, "Failed to remove delivery partner", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery partner removed"})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeviceHandler struct {
+	sessionService *services.SessionService
+}
+
+func NewDeviceHandler(sessionService *services.SessionService) *DeviceHandler {
+	return &DeviceHandler{
+		sessionService: sessionService,
+	}
+}
+
+// RegisterRoutes registers the signed-in user's device management routes
+func (h *DeviceHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	devices := router.Group("/users/me/devices", authMiddleware.AuthRequired())
+	{
+		devices.GET("", h.ListDevices)
+		devices.DELETE("/:id", h.SignOutDevice)
+	}
+}
+
+// ListDevices godoc
+// @Summary List signed-in devices
+// @Description List the devices the user is currently signed in on. The device making the request is marked current.
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} services.DeviceResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /users/me/devices [get]
+func (h *DeviceHandler) ListDevices(c *gin.Context) {
+	devices, err := h.sessionService.ListDevices(c.Request.Context(), middleware.GetUserID(c), middleware.GetAuthSessionID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to list devices",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, devices)
+}
+
+// SignOutDevice godoc
+// @Summary Sign out a device
+// @Description Revoke the session of one signed-in device. Its refresh token stops working and its access token is rejected.
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Device session ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /users/me/devices/{id} [delete]
+func (h *DeviceHandler) SignOutDevice(c *gin.Context) {
+	if err := h.sessionService.RevokeSession(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), "device_signed_out"); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Failed to sign out device",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Device signed out successfully"})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DietaryPreferenceHandler struct {
+	dietaryPreferenceService *services.DietaryPreferenceService
+}
+
+func NewDietaryPreferenceHandler(dietaryPreferenceService *services.DietaryPreferenceService) *DietaryPreferenceHandler {
+	return &DietaryPreferenceHandler{
+		dietaryPreferenceService: dietaryPreferenceService,
+	}
+}
+
+// RegisterRoutes registers the signed-in user's dietary preference routes
+func (h *DietaryPreferenceHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	preferences := router.Group("/users/me/dietary-preferences", authMiddleware.AuthRequired())
+	{
+		preferences.GET("", h.GetPreferences)
+		preferences.PUT("", h.UpdatePreferences)
+	}
+}
+
+// GetPreferences godoc
+// @Summary Get my dietary preferences
+// @Description Your diet, the allergens you avoid, the hottest food you want and whether menus are filtered by them automatically
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.DietaryPreference
+// @Router /users/me/dietary-preferences [get]
+func (h *DietaryPreferenceHandler) GetPreferences(c *gin.Context) {
+	preference, err := h.dietaryPreferenceService.GetPreferences(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		abortWithError(c, "Failed to get dietary preferences", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// UpdatePreferences godoc
+// @Summary Update my dietary preferences
+// @Description Replace your dietary preferences. With auto_filter on, restaurant menus and searches hide dishes that do not fit your diet, contain an allergen you avoid or are hotter than your limit, unless the request sets its own diet, exclude_allergens or max_spice, or passes preferences=off.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.UpdateDietaryPreferencesRequest true "Dietary preferences"
+// @Success 200 {object} models.DietaryPreference
+// @Failure 400 {object} ErrorResponse
+// @Router /users/me/dietary-preferences [put]
+func (h *DietaryPreferenceHandler) UpdatePreferences(c *gin.Context) {
+	var req services.UpdateDietaryPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	preference, err := h.dietaryPreferenceService.UpdatePreferences(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to update dietary preferences", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// menuDietaryFilter reads the diet, exclude_allergens and max_spice query parameters and,
+// unless preferences=off, fills in what they leave unset from the signed-in user's dietary
+// preferences. It aborts the request when a parameter is invalid.
+func menuDietaryFilter(c *gin.Context, dietaryPreferenceService *services.DietaryPreferenceService) (models.DietaryFilter, bool) {
+	filter, err := services.ParseDietaryFilter(c.Query("diet"), c.Query("exclude_allergens"), c.Query("max_spice"))
+	if err != nil {
+		abortWithError(c, "Invalid dietary filter", err)
+		return filter, false
+	}
+	if dietaryPreferenceService != nil && c.Query("preferences") != "off" {
+		filter = dietaryPreferenceService.WithPreferences(c.Request.Context(), middleware.GetUserID(c), filter)
+	}
+	return filter, true
+}
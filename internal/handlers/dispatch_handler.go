@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DispatchHandler struct {
+	dispatchService *services.DispatchService
+}
+
+func NewDispatchHandler(dispatchService *services.DispatchService) *DispatchHandler {
+	return &DispatchHandler{
+		dispatchService: dispatchService,
+	}
+}
+
+// RegisterRoutes registers the restaurant and admin dispatch routes
+func (h *DispatchHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	restaurant := router.Group("/restaurant", authMiddleware.AuthRequired(), authMiddleware.RestaurantRequired())
+	{
+		restaurant.GET("/orders/:id/dispatch", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionOrders), h.GetRestaurantDispatch)
+		restaurant.POST("/orders/:id/dispatch", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionOrders), h.RetryRestaurantDispatch)
+	}
+
+	admin := router.Group("/admin/dispatches",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionDelivery),
+	)
+	{
+		admin.GET("/:order_id", h.GetDispatch)
+		admin.POST("/:order_id/retry", h.RetryDispatch)
+	}
+}
+
+// GetRestaurantDispatch godoc
+// @Summary Get an order's delivery dispatch
+// @Description List the automatic dispatch attempts for one of your restaurant's orders: the provider tried, its quote and why a booking failed
+// @Tags delivery
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} services.DispatchAttemptsResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurant/orders/{id}/dispatch [get]
+func (h *DispatchHandler) GetRestaurantDispatch(c *gin.Context) {
+	h.getAttempts(c, c.Param("id"), middleware.GetRestaurantID(c))
+}
+
+// RetryRestaurantDispatch godoc
+// @Summary Retry an order's delivery dispatch
+// @Description Run dispatch again for one of your restaurant's orders after every provider failed
+// @Tags delivery
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} models.DeliveryDispatch
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Router /restaurant/orders/{id}/dispatch [post]
+func (h *DispatchHandler) RetryRestaurantDispatch(c *gin.Context) {
+	h.retry(c, c.Param("id"), middleware.GetRestaurantID(c))
+}
+
+// GetDispatch godoc
+// @Summary Get an order's delivery dispatch
+// @Description List the automatic dispatch attempts for any order (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param order_id path string true "Order ID"
+// @Success 200 {object} services.DispatchAttemptsResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/dispatches/{order_id} [get]
+func (h *DispatchHandler) GetDispatch(c *gin.Context) {
+	h.getAttempts(c, c.Param("order_id"), "")
+}
+
+// RetryDispatch godoc
+// @Summary Retry an order's delivery dispatch
+// @Description Run dispatch again for any order after every provider failed (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param order_id path string true "Order ID"
+// @Success 200 {object} models.DeliveryDispatch
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Router /admin/dispatches/{order_id}/retry [post]
+func (h *DispatchHandler) RetryDispatch(c *gin.Context) {
+	h.retry(c, c.Param("order_id"), "")
+}
+
+func (h *DispatchHandler) getAttempts(c *gin.Context, orderID, restaurantID string) {
+	attempts, err := h.dispatchService.GetAttempts(c.Request.Context(), orderID, restaurantID)
+	if err != nil {
+		abortWithError(c, "Failed to get dispatch", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
+
+func (h *DispatchHandler) retry(c *gin.Context, orderID, restaurantID string) {
+	dispatch, err := h.dispatchService.Redispatch(c.Request.Context(), orderID, restaurantID)
+	if err != nil {
+		abortWithError(c, "Failed to dispatch order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dispatch)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FeatureFlagHandler struct {
+	featureFlagService *services.FeatureFlagService
+}
+
+func NewFeatureFlagHandler(featureFlagService *services.FeatureFlagService) *FeatureFlagHandler {
+	return &FeatureFlagHandler{
+		featureFlagService: featureFlagService,
+	}
+}
+
+// RegisterRoutes registers the client feature lookup and the admin flag routes
+func (h *FeatureFlagHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	router.GET("/features", authMiddleware.OptionalAuth(), h.GetFeatures)
+
+	admin := router.Group("/admin/feature-flags",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionFeatures),
+	)
+	{
+		admin.GET("", h.ListFlags)
+		admin.PUT("/:key", h.SetFlag)
+		admin.DELETE("/:key", h.DeleteFlag)
+		admin.GET("/:key/evaluate", h.EvaluateFlag)
+	}
+}
+
+// GetFeatures godoc
+// @Summary Get feature states
+// @Description Get which features are on for the caller and, optionally, a restaurant, so apps can show or hide them
+// @Tags features
+// @Produce json
+// @Param restaurant_id query string false "Restaurant ID"
+// @Success 200 {object} map[string]bool
+// @Router /features [get]
+func (h *FeatureFlagHandler) GetFeatures(c *gin.Context) {
+	restaurantID := c.Query("restaurant_id")
+	if restaurantID == "" {
+		restaurantID = middleware.GetRestaurantID(c)
+	}
+
+	c.JSON(http.StatusOK, h.featureFlagService.EvaluateAll(c.Request.Context(), services.FeatureSubject{
+		RestaurantID: restaurantID,
+		UserID:       middleware.GetUserID(c),
+	}))
+}
+
+// ListFlags godoc
+// @Summary List feature flags
+// @Description List the features the API checks with their defaults, and every stored flag with its rollout
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} services.FeatureFlagStatus
+// @Router /admin/feature-flags [get]
+func (h *FeatureFlagHandler) ListFlags(c *gin.Context) {
+	flags, err := h.featureFlagService.ListFlags(c.Request.Context())
+	if err != nil {
+		abortWithError(c, "Failed to list feature flags", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, flags)
+}
+
+// SetFlag godoc
+// @Summary Set a feature flag
+// @Description Turn a feature on or off, for listed restaurants and a percentage of the rest. Excluded restaurants never get the feature. Changes reach every instance within 15 seconds.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param key path string true "Flag key"
+// @Param request body services.FeatureFlagRequest true "Rollout"
+// @Success 200 {object} models.FeatureFlag
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/feature-flags/{key} [put]
+func (h *FeatureFlagHandler) SetFlag(c *gin.Context) {
+	var req services.FeatureFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	flag, err := h.featureFlagService.SetFlag(c.Request.Context(), middleware.GetUserID(c), c.Param("key"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to set feature flag", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, flag)
+}
+
+// DeleteFlag godoc
+// @Summary Delete a feature flag
+// @Description Delete a stored flag; a feature the API checks returns to its default
+// @Tags admin
+// @Security BearerAuth
+// @Param key path string true "Flag key"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/feature-flags/{key} [delete]
+func (h *FeatureFlagHandler) DeleteFlag(c *gin.Context) {
+	if err := h.featureFlagService.DeleteFlag(c.Request.Context(), c.Param("key")); err != nil {
+		abortWithError(c, "Failed to delete feature flag", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// EvaluateFlag godoc
+// @Summary Evaluate a feature flag
+// @Description Check whether a feature is on for a restaurant or user, e.g. to confirm a rollout
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param key path string true "Flag key"
+// @Param restaurant_id query string false "Restaurant ID"
+// @Param user_id query string false "User ID"
+// @Success 200 {object} services.FeatureFlagEvaluation
+// @Router /admin/feature-flags/{key}/evaluate [get]
+func (h *FeatureFlagHandler) EvaluateFlag(c *gin.Context) {
+	c.JSON(http.StatusOK, h.featureFlagService.Evaluate(c.Request.Context(), c.Param("key"), c.Query("restaurant_id"), c.Query("user_id")))
+}
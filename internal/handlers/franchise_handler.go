@@ -0,0 +1,437 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FranchiseHandler struct {
+	franchiseService *services.FranchiseService
+}
+
+func NewFranchiseHandler(franchiseService *services.FranchiseService) *FranchiseHandler {
+	return &FranchiseHandler{
+		franchiseService: franchiseService,
+	}
+}
+
+// RegisterRoutes registers the brand routes
+func (h *FranchiseHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	brands := router.Group("/brands", authMiddleware.AuthRequired())
+	{
+		brands.POST("", h.CreateBrand)
+		brands.GET("/:id", h.GetBrand)
+		brands.POST("/:id/outlets", h.AttachOutlet)
+		brands.DELETE("/:id/outlets/:outlet_id", h.DetachOutlet)
+		brands.PUT("/:id/outlets/:outlet_id/products/:product_id/price", h.SetOutletPrice)
+		brands.DELETE("/:id/outlets/:outlet_id/products/:product_id/price", h.ResetOutletPrice)
+		brands.GET("/:id/menu", h.GetMenu)
+		brands.POST("/:id/menu/sync", h.SyncMenu)
+		brands.POST("/:id/categories", h.CreateCategory)
+		brands.POST("/:id/products", h.CreateProduct)
+		brands.PUT("/:id/products/:product_id", h.UpdateProduct)
+		brands.DELETE("/:id/products/:product_id", h.DeleteProduct)
+		brands.GET("/:id/analytics", h.GetAnalytics)
+		brands.GET("/:id/orders", h.ListOrders)
+	}
+}
+
+// CreateBrand godoc
+// @Summary Create a brand
+// @Description Create a parent brand that holds the master menu for its outlets. Brands do not take orders.
+// @Tags brands
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.CreateBrandRequest true "Brand details"
+// @Success 201 {object} models.Restaurant
+// @Failure 400 {object} ErrorResponse
+// @Router /brands [post]
+func (h *FranchiseHandler) CreateBrand(c *gin.Context) {
+	var req services.CreateBrandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	brand, err := h.franchiseService.CreateBrand(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to create brand", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, brand)
+}
+
+// GetBrand godoc
+// @Summary Get a brand
+// @Description Get a brand you own with its outlets
+// @Tags brands
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Brand ID"
+// @Success 200 {object} services.BrandResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /brands/{id} [get]
+func (h *FranchiseHandler) GetBrand(c *gin.Context) {
+	brand, err := h.franchiseService.GetBrand(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get brand", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, brand)
+}
+
+// AttachOutlet godoc
+// @Summary Attach an outlet to a brand
+// @Description Make a restaurant you own an outlet of the brand and copy the brand menu to it
+// @Tags brands
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Brand ID"
+// @Param request body services.AttachOutletRequest true "Restaurant to attach"
+// @Success 200 {object} services.MenuSyncResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /brands/{id}/outlets [post]
+func (h *FranchiseHandler) AttachOutlet(c *gin.Context) {
+	var req services.AttachOutletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.franchiseService.AttachOutlet(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to attach outlet", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// DetachOutlet godoc
+// @Summary Detach an outlet from a brand
+// @Description Remove a restaurant from the brand. It keeps its current menu, which stops following the brand.
+// @Tags brands
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Brand ID"
+// @Param outlet_id path string true "Outlet restaurant ID"
+// @Success 204
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /brands/{id}/outlets/{outlet_id} [delete]
+func (h *FranchiseHandler) DetachOutlet(c *gin.Context) {
+	err := h.franchiseService.DetachOutlet(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"), c.Param("outlet_id"))
+	if err != nil {
+		abortWithError(c, "Failed to detach outlet", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SetOutletPrice godoc
+// @Summary Override an outlet's price
+// @Description Give an outlet its own price for a brand menu item; menu syncs keep it until it is reset
+// @Tags brands
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Brand ID"
+// @Param outlet_id path string true "Outlet restaurant ID"
+// @Param product_id path string true "Outlet product ID"
+// @Param request body services.OutletPriceRequest true "Outlet prices"
+// @Success 200 {object} models.Product
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /brands/{id}/outlets/{outlet_id}/products/{product_id}/price [put]
+func (h *FranchiseHandler) SetOutletPrice(c *gin.Context) {
+	var req services.OutletPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	product, err := h.franchiseService.SetOutletPrice(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c),
+		c.Param("id"), c.Param("outlet_id"), c.Param("product_id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to set outlet price", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+// ResetOutletPrice godoc
+// @Summary Reset an outlet's price
+// @Description Drop an outlet's price override and go back to the brand price
+// @Tags brands
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Brand ID"
+// @Param outlet_id path string true "Outlet restaurant ID"
+// @Param product_id path string true "Outlet product ID"
+// @Success 200 {object} models.Product
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /brands/{id}/outlets/{outlet_id}/products/{product_id}/price [delete]
+func (h *FranchiseHandler) ResetOutletPrice(c *gin.Context) {
+	product, err := h.franchiseService.ResetOutletPrice(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c),
+		c.Param("id"), c.Param("outlet_id"), c.Param("product_id"))
+	if err != nil {
+		abortWithError(c, "Failed to reset outlet price", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+// GetMenu godoc
+// @Summary Get a brand menu
+// @Description Get the brand's master categories and products, including unavailable ones
+// @Tags brands
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Brand ID"
+// @Success 200 {object} services.BrandMenuResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /brands/{id}/menu [get]
+func (h *FranchiseHandler) GetMenu(c *gin.Context) {
+	menu, err := h.franchiseService.GetMenu(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get brand menu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, menu)
+}
+
+// SyncMenu godoc
+// @Summary Sync a brand menu to outlets
+// @Description Copy the brand's categories and products to the given outlets, or to all of them. Outlet availability and price overrides are kept; items the brand removed are dropped.
+// @Tags brands
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Brand ID"
+// @Param request body services.SyncMenuRequest false "Outlets to sync"
+// @Success 200 {array} services.MenuSyncResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /brands/{id}/menu/sync [post]
+func (h *FranchiseHandler) SyncMenu(c *gin.Context) {
+	var req services.SyncMenuRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid request",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	results, err := h.franchiseService.SyncMenu(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to sync brand menu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// CreateCategory godoc
+// @Summary Create a brand category
+// @Description Add a category to the brand menu; outlets get it on the next sync
+// @Tags brands
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Brand ID"
+// @Param request body services.CreateCategoryRequest true "Category"
+// @Success 201 {object} models.ProductCategory
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /brands/{id}/categories [post]
+func (h *FranchiseHandler) CreateCategory(c *gin.Context) {
+	var req services.CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	category, err := h.franchiseService.CreateCategory(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to create category", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// CreateProduct godoc
+// @Summary Create a brand product
+// @Description Add an item to the brand menu; outlets get it on the next sync
+// @Tags brands
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Brand ID"
+// @Param request body services.CreateProductRequest true "Product"
+// @Success 201 {object} models.Product
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /brands/{id}/products [post]
+func (h *FranchiseHandler) CreateProduct(c *gin.Context) {
+	var req services.CreateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	product, err := h.franchiseService.CreateProduct(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to create product", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, product)
+}
+
+// UpdateProduct godoc
+// @Summary Update a brand product
+// @Description Change an item on the brand menu; outlets get the change on the next sync
+// @Tags brands
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Brand ID"
+// @Param product_id path string true "Brand product ID"
+// @Param request body map[string]interface{} true "Fields to update"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /brands/{id}/products/{product_id} [put]
+func (h *FranchiseHandler) UpdateProduct(c *gin.Context) {
+	var updates map[string]interface{}
+	if err := c.ShouldBindJSON(&updates); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	err := h.franchiseService.UpdateProduct(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"), c.Param("product_id"), updates)
+	if err != nil {
+		abortWithError(c, "Failed to update product", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Product updated successfully"})
+}
+
+// DeleteProduct godoc
+// @Summary Delete a brand product
+// @Description Remove an item from the brand menu; outlets drop it on the next sync
+// @Tags brands
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Brand ID"
+// @Param product_id path string true "Brand product ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /brands/{id}/products/{product_id} [delete]
+func (h *FranchiseHandler) DeleteProduct(c *gin.Context) {
+	err := h.franchiseService.DeleteProduct(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"), c.Param("product_id"))
+	if err != nil {
+		abortWithError(c, "Failed to delete product", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetAnalytics godoc
+// @Summary Get brand analytics
+// @Description Get orders, revenue, popular items and peak hours combined across the brand's outlets, with a per-outlet breakdown
+// @Tags brands
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Brand ID"
+// @Param from query string false "Start date (YYYY-MM-DD), defaults to 30 days ago"
+// @Param to query string false "End date (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} services.BrandAnalyticsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /brands/{id}/analytics [get]
+func (h *FranchiseHandler) GetAnalytics(c *gin.Context) {
+	analytics, err := h.franchiseService.GetAnalytics(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c),
+		c.Param("id"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		abortWithError(c, "Failed to get brand analytics", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+// ListOrders godoc
+// @Summary List brand orders
+// @Description List orders across all of the brand's outlets, newest first
+// @Tags brands
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Brand ID"
+// @Param status query string false "Order status"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} services.BrandOrdersResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /brands/{id}/orders [get]
+func (h *FranchiseHandler) ListOrders(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	orders, err := h.franchiseService.ListOrders(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c),
+		c.Param("id"), c.Query("status"), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list brand orders", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+type GroupOrderHandler struct {
+	groupOrderService *services.GroupOrderService
+}
+
+func NewGroupOrderHandler(groupOrderService *services.GroupOrderService) *GroupOrderHandler {
+	return &GroupOrderHandler{
+		groupOrderService: groupOrderService,
+	}
+}
+
+type CreateGroupOrderRequest struct {
+	RestaurantID string `json:"restaurant_id" binding:"required"`
+	Name         string `json:"name,omitempty"` // shown to other members; defaults to the account name
+}
+
+type JoinGroupOrderRequest struct {
+	Code string `json:"code" binding:"required"`
+	Name string `json:"name,omitempty"` // shown to other members; defaults to the account name
+}
+
+type GroupOrderItemRequest struct {
+	ProductID string   `json:"product_id" binding:"required"`
+	Quantity  int      `json:"quantity" binding:"required,min=1"`
+	VariantID string   `json:"variant_id,omitempty"`
+	AddonIDs  []string `json:"addon_ids,omitempty"`
+	OptionIDs []string `json:"option_ids,omitempty"`
+}
+
+type UpdateGroupOrderItemRequest struct {
+	Quantity int `json:"quantity" binding:"gte=0"`
+}
+
+type GroupOrderCheckoutRequest struct {
+	AddressID string `json:"address_id" binding:"required"`
+}
+
+// RegisterRoutes registers the group ordering routes. Members follow changes over a WebSocket,
+// which browsers authenticate with the access_token query parameter.
+func (h *GroupOrderHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	groups := router.Group("/group-orders", authMiddleware.AuthRequired())
+	{
+		groups.POST("", h.CreateGroupOrder)
+		groups.POST("/join", h.JoinGroupOrder)
+		groups.GET("/:id", h.GetGroupOrder)
+		groups.DELETE("/:id", h.CancelGroupOrder)
+		groups.POST("/:id/leave", h.LeaveGroupOrder)
+		groups.POST("/:id/items", h.AddGroupOrderItem)
+		groups.PUT("/:id/items/:item_key", h.UpdateGroupOrderItem)
+		groups.DELETE("/:id/items/:item_key", h.RemoveGroupOrderItem)
+		groups.GET("/:id/bill-summary", h.GetGroupOrderBillSummary)
+		groups.POST("/:id/checkout", h.CheckoutGroupOrder)
+	}
+
+	router.GET("/ws/group-orders/:id", authMiddleware.StreamAuthRequired(), h.StreamGroupOrder)
+}
+
+// CreateGroupOrder godoc
+// @Summary Start a group order
+// @Description Start a shared cart for a restaurant with the user as host. Others join with the returned code and add items under their names.
+// @Tags group-orders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body CreateGroupOrderRequest true "Restaurant"
+// @Success 201 {object} services.GroupOrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /group-orders [post]
+func (h *GroupOrderHandler) CreateGroupOrder(c *gin.Context) {
+	var req CreateGroupOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	group, err := h.groupOrderService.Create(c.Request.Context(), middleware.GetUserID(c), req.RestaurantID, req.Name)
+	if err != nil {
+		abortWithError(c, "Failed to start group order", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// JoinGroupOrder godoc
+// @Summary Join a group order
+// @Description Join an open group order with its code. Joining again is harmless.
+// @Tags group-orders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body JoinGroupOrderRequest true "Join code"
+// @Success 200 {object} services.GroupOrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /group-orders/join [post]
+func (h *GroupOrderHandler) JoinGroupOrder(c *gin.Context) {
+	var req JoinGroupOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	group, err := h.groupOrderService.Join(c.Request.Context(), middleware.GetUserID(c), req.Code, req.Name)
+	if err != nil {
+		abortWithError(c, "Failed to join group order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// GetGroupOrder godoc
+// @Summary Get a group order
+// @Description Get a group order with each member's items and subtotal at current prices (members only)
+// @Tags group-orders
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Group order ID"
+// @Success 200 {object} services.GroupOrderResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /group-orders/{id} [get]
+func (h *GroupOrderHandler) GetGroupOrder(c *gin.Context) {
+	group, err := h.groupOrderService.Get(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get group order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// CancelGroupOrder godoc
+// @Summary Cancel a group order
+// @Description End an open group order without ordering (host only)
+// @Tags group-orders
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Group order ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /group-orders/{id} [delete]
+func (h *GroupOrderHandler) CancelGroupOrder(c *gin.Context) {
+	if err := h.groupOrderService.Cancel(c.Request.Context(), middleware.GetUserID(c), c.Param("id")); err != nil {
+		abortWithError(c, "Failed to cancel group order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Group order cancelled"})
+}
+
+// LeaveGroupOrder godoc
+// @Summary Leave a group order
+// @Description Leave an open group order, dropping the member's items. The host cancels instead.
+// @Tags group-orders
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Group order ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /group-orders/{id}/leave [post]
+func (h *GroupOrderHandler) LeaveGroupOrder(c *gin.Context) {
+	if err := h.groupOrderService.Leave(c.Request.Context(), middleware.GetUserID(c), c.Param("id")); err != nil {
+		abortWithError(c, "Failed to leave group order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left group order"})
+}
+
+// AddGroupOrderItem godoc
+// @Summary Add an item to a group order
+// @Description Add a product from the group order's restaurant under the member's name
+// @Tags group-orders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Group order ID"
+// @Param item body GroupOrderItemRequest true "Item"
+// @Success 200 {object} services.GroupOrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /group-orders/{id}/items [post]
+func (h *GroupOrderHandler) AddGroupOrderItem(c *gin.Context) {
+	var req GroupOrderItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	group, err := h.groupOrderService.AddItem(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), &services.AddToCartRequest{
+		ProductID: req.ProductID,
+		Quantity:  req.Quantity,
+		VariantID: req.VariantID,
+		AddonIDs:  req.AddonIDs,
+		OptionIDs: req.OptionIDs,
+	})
+	if err != nil {
+		abortWithError(c, "Failed to add item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// UpdateGroupOrderItem godoc
+// @Summary Update a group order item
+// @Description Set the quantity of one of the member's lines by its item key; zero removes it
+// @Tags group-orders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Group order ID"
+// @Param item_key path string true "Item key"
+// @Param request body UpdateGroupOrderItemRequest true "Quantity"
+// @Success 200 {object} services.GroupOrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /group-orders/{id}/items/{item_key} [put]
+func (h *GroupOrderHandler) UpdateGroupOrderItem(c *gin.Context) {
+	var req UpdateGroupOrderItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	group, err := h.groupOrderService.UpdateItem(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), c.Param("item_key"), req.Quantity)
+	if err != nil {
+		abortWithError(c, "Failed to update item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// RemoveGroupOrderItem godoc
+// @Summary Remove a group order item
+// @Description Remove one of the member's lines by its item key
+// @Tags group-orders
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Group order ID"
+// @Param item_key path string true "Item key"
+// @Success 200 {object} services.GroupOrderResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /group-orders/{id}/items/{item_key} [delete]
+func (h *GroupOrderHandler) RemoveGroupOrderItem(c *gin.Context) {
+	group, err := h.groupOrderService.UpdateItem(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), c.Param("item_key"), 0)
+	if err != nil {
+		abortWithError(c, "Failed to remove item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// GetGroupOrderBillSummary godoc
+// @Summary Get the group order bill summary
+// @Description Price the combined items for the delivery address and split the total between members in proportion to their items. The summary is also sent to members over the socket (host only).
+// @Tags group-orders
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Group order ID"
+// @Param address_id query string true "Delivery address ID"
+// @Success 200 {object} services.GroupBillSummaryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /group-orders/{id}/bill-summary [get]
+func (h *GroupOrderHandler) GetGroupOrderBillSummary(c *gin.Context) {
+	addressID := c.Query("address_id")
+	if addressID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: "address_id is required",
+		})
+		return
+	}
+
+	bill, err := h.groupOrderService.GetBillSummary(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), addressID)
+	if err != nil {
+		abortWithError(c, "Failed to get bill summary", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bill)
+}
+
+// CheckoutGroupOrder godoc
+// @Summary Check out a group order
+// @Description Place one order for the group's combined items with a single payment by the host (host only)
+// @Tags group-orders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Group order ID"
+// @Param request body GroupOrderCheckoutRequest true "Delivery address"
+// @Success 200 {object} services.CheckoutResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /group-orders/{id}/checkout [post]
+func (h *GroupOrderHandler) CheckoutGroupOrder(c *gin.Context) {
+	var req GroupOrderCheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	checkout, err := h.groupOrderService.Checkout(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), req.AddressID)
+	if err != nil {
+		abortWithError(c, "Failed to check out group order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, checkout)
+}
+
+// StreamGroupOrder godoc
+// @Summary Follow a group order
+// @Description WebSocket of group order events (members joining and leaving, item changes, bill summaries, checkout). The current state is sent first. Browsers may pass the token as access_token.
+// @Tags group-orders
+// @Security BearerAuth
+// @Param id path string true "Group order ID"
+// @Param access_token query string false "JWT access token (for browser WebSocket clients)"
+// @Success 101 {object} services.GroupOrderEvent
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /ws/group-orders/{id} [get]
+func (h *GroupOrderHandler) StreamGroupOrder(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	groupID := c.Param("id")
+
+	// Membership is checked before upgrading so a stranger gets a plain 404
+	group, err := h.groupOrderService.Get(c.Request.Context(), userID, groupID)
+	if err != nil {
+		abortWithError(c, "Failed to follow group order", err)
+		return
+	}
+
+	server := websocket.Server{Handler: func(conn *websocket.Conn) {
+		defer conn.Close()
+		ctx, cancel := context.WithCancel(conn.Request().Context())
+		defer cancel()
+
+		events, err := h.groupOrderService.Subscribe(ctx, userID, groupID)
+		if err != nil {
+			return
+		}
+
+		// Clients only listen; reading notices when they go away
+		go func() {
+			var discard string
+			for websocket.Message.Receive(conn, &discard) == nil {
+			}
+			cancel()
+		}()
+
+		if websocket.JSON.Send(conn, services.GroupOrderEvent{Type: "state", Group: group, Timestamp: time.Now()}) != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(25 * time.Second)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if websocket.JSON.Send(conn, event) != nil {
+					return
+				}
+				if event.Group != nil && services.IsFinalGroupOrderStatus(event.Group.Status) {
+					return
+				}
+			case <-heartbeat.C:
+				if websocket.JSON.Send(conn, services.GroupOrderEvent{Type: "ping", Timestamp: time.Now()}) != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HighlightHandler struct {
+	highlightService *services.HighlightService
+}
+
+func NewHighlightHandler(highlightService *services.HighlightService) *HighlightHandler {
+	return &HighlightHandler{
+		highlightService: highlightService,
+	}
+}
+
+// RegisterRoutes registers the public highlighted products route and highlight management for restaurant staff
+func (h *HighlightHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	router.GET("/restaurants/:id/products/highlighted", h.GetHighlightedProducts)
+
+	highlights := router.Group("/highlights",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantRequired(),
+		authMiddleware.RestaurantStaffRequired(),
+		authMiddleware.RestaurantPermissionRequired(services.StaffPermissionMenuEdit),
+	)
+	{
+		highlights.POST("", h.CreateHighlight)
+		highlights.GET("", h.ListHighlights)
+		highlights.GET("/:id", h.GetHighlight)
+		highlights.PUT("/:id", h.UpdateHighlight)
+		highlights.DELETE("/:id", h.DeleteHighlight)
+	}
+}
+
+// GetHighlightedProducts godoc
+// @Summary Get highlighted products
+// @Description Get a restaurant's available products currently scheduled as featured, bestseller or recommended, in display order
+// @Tags products
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param type query string false "Highlight type (featured, bestseller, recommended)" default(featured)
+// @Success 200 {array} models.Product
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurants/{id}/products/highlighted [get]
+func (h *HighlightHandler) GetHighlightedProducts(c *gin.Context) {
+	products, err := h.highlightService.GetHighlightedProducts(c.Request.Context(), c.Param("id"), c.DefaultQuery("type", "featured"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to get highlighted products",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
+
+// CreateHighlight godoc
+// @Summary Create a highlight
+// @Description Schedule one of the restaurant's products as featured, bestseller or recommended
+// @Tags highlights
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.CreateHighlightRequest true "Highlight"
+// @Success 201 {object} models.HighlightProduct
+// @Failure 400 {object} ErrorResponse
+// @Router /highlights [post]
+func (h *HighlightHandler) CreateHighlight(c *gin.Context) {
+	var req services.CreateHighlightRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	highlight, err := h.highlightService.CreateHighlight(c.Request.Context(), middleware.GetRestaurantID(c), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to create highlight",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, highlight)
+}
+
+// ListHighlights godoc
+// @Summary List highlights
+// @Description List the restaurant's highlights including inactive and expired ones
+// @Tags highlights
+// @Security BearerAuth
+// @Produce json
+// @Param type query string false "Highlight type"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {array} models.HighlightProduct
+// @Failure 400 {object} ErrorResponse
+// @Router /highlights [get]
+func (h *HighlightHandler) ListHighlights(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	highlights, err := h.highlightService.ListHighlights(c.Request.Context(), middleware.GetRestaurantID(c), c.Query("type"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to list highlights",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, highlights)
+}
+
+// GetHighlight godoc
+// @Summary Get a highlight
+// @Description Get one of the restaurant's highlights by ID
+// @Tags highlights
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Highlight ID"
+// @Success 200 {object} models.HighlightProduct
+// @Failure 404 {object} ErrorResponse
+// @Router /highlights/{id} [get]
+func (h *HighlightHandler) GetHighlight(c *gin.Context) {
+	highlight, err := h.highlightService.GetHighlight(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Highlight not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, highlight)
+}
+
+// UpdateHighlight godoc
+// @Summary Update a highlight
+// @Description Update a highlight's type, schedule, display order or active flag
+// @Tags highlights
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Highlight ID"
+// @Param request body services.UpdateHighlightRequest true "Highlight changes"
+// @Success 200 {object} models.HighlightProduct
+// @Failure 400 {object} ErrorResponse
+// @Router /highlights/{id} [put]
+func (h *HighlightHandler) UpdateHighlight(c *gin.Context) {
+	var req services.UpdateHighlightRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	highlight, err := h.highlightService.UpdateHighlight(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to update highlight",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, highlight)
+}
+
+// DeleteHighlight godoc
+// @Summary Delete a highlight
+// @Description Remove a product highlight
+// @Tags highlights
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Highlight ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /highlights/{id} [delete]
+func (h *HighlightHandler) DeleteHighlight(c *gin.Context) {
+	if err := h.highlightService.DeleteHighlight(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to delete highlight",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Highlight deleted successfully"})
+}
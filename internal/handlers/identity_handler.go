@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IdentityHandler struct {
+	identityService *services.IdentityService
+}
+
+func NewIdentityHandler(identityService *services.IdentityService) *IdentityHandler {
+	return &IdentityHandler{
+		identityService: identityService,
+	}
+}
+
+// RegisterRoutes registers social sign-in and the signed-in user's linked account routes
+func (h *IdentityHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	router.POST("/auth/social/:provider", h.SocialLogin)
+
+	identities := router.Group("/users/me/identities", authMiddleware.AuthRequired())
+	{
+		identities.GET("", h.ListIdentities)
+		identities.POST("/:provider", h.LinkIdentity)
+		identities.DELETE("/:id", h.UnlinkIdentity)
+	}
+}
+
+// SocialLogin godoc
+// @Summary Sign in with Google, Apple or Truecaller
+// @Description Verify a Google or Apple ID token, or a Truecaller authorization code, and sign in the linked user.
+// @Description On first use the account is linked to the user with the same verified phone or email, or a new customer is created.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider (google, apple, truecaller)"
+// @Param request body services.SocialLoginRequest true "Provider credential"
+// @Success 200 {object} services.AuthResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /auth/social/{provider} [post]
+func (h *IdentityHandler) SocialLogin(c *gin.Context) {
+	var req services.SocialLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	response, err := h.identityService.Login(c.Request.Context(), c.Param("provider"), &req)
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, services.ErrUnknownIdentityProvider) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, ErrorResponse{
+			Error:   "Sign-in failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListIdentities godoc
+// @Summary List linked sign-in accounts
+// @Description List the Google, Apple and Truecaller accounts linked to the user
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.UserIdentity
+// @Failure 400 {object} ErrorResponse
+// @Router /users/me/identities [get]
+func (h *IdentityHandler) ListIdentities(c *gin.Context) {
+	identities, err := h.identityService.ListIdentities(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to list linked accounts",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, identities)
+}
+
+// LinkIdentity godoc
+// @Summary Link a sign-in account
+// @Description Link a Google, Apple or Truecaller account to the user so it can be used to sign in
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider (google, apple, truecaller)"
+// @Param request body services.LinkIdentityRequest true "Provider credential"
+// @Success 201 {object} models.UserIdentity
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users/me/identities/{provider} [post]
+func (h *IdentityHandler) LinkIdentity(c *gin.Context) {
+	var req services.LinkIdentityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	identity, err := h.identityService.LinkIdentity(c.Request.Context(), middleware.GetUserID(c), c.Param("provider"), &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrIdentityLinkedElsewhere) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, ErrorResponse{
+			Error:   "Failed to link account",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, identity)
+}
+
+// UnlinkIdentity godoc
+// @Summary Unlink a sign-in account
+// @Description Remove a linked account. The user's only remaining sign-in method cannot be removed.
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Linked account ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /users/me/identities/{id} [delete]
+func (h *IdentityHandler) UnlinkIdentity(c *gin.Context) {
+	if err := h.identityService.UnlinkIdentity(c.Request.Context(), middleware.GetUserID(c), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to unlink account",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlinked successfully"})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InventoryHandler struct {
+	inventoryService *services.InventoryService
+}
+
+func NewInventoryHandler(inventoryService *services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryService: inventoryService,
+	}
+}
+
+// RegisterRoutes registers product stock routes for restaurant staff
+func (h *InventoryHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	inventory := router.Group("/products/:id/inventory",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantRequired(),
+		authMiddleware.RestaurantStaffRequired(),
+		authMiddleware.RestaurantPermissionRequired(services.StaffPermissionMenuEdit),
+	)
+	{
+		inventory.GET("", h.GetInventory)
+		inventory.POST("/adjustments", h.AdjustStock)
+	}
+}
+
+// GetInventory godoc
+// @Summary Get product stock
+// @Description Get a product's stock, reserved quantity, stock history and automatic availability changes
+// @Tags inventory
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} services.InventoryResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /products/{id}/inventory [get]
+func (h *InventoryHandler) GetInventory(c *gin.Context) {
+	inventory, err := h.inventoryService.GetInventory(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to get inventory",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, inventory)
+}
+
+// AdjustStock godoc
+// @Summary Adjust product stock
+// @Description Restock (addition), deduct or set the stock count. The product is marked unavailable when no unreserved stock is left and available again after a restock.
+// @Tags inventory
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param request body services.StockAdjustmentRequest true "Stock adjustment"
+// @Success 200 {object} services.InventoryResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /products/{id}/inventory/adjustments [post]
+func (h *InventoryHandler) AdjustStock(c *gin.Context) {
+	var req services.StockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	inventory, err := h.inventoryService.AdjustStock(c.Request.Context(), middleware.GetRestaurantID(c), middleware.GetUserID(c), c.Param("id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to adjust stock",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, inventory)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvoiceHandler struct {
+	invoiceService *services.InvoiceService
+}
+
+func NewInvoiceHandler(invoiceService *services.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{
+		invoiceService: invoiceService,
+	}
+}
+
+// RegisterRoutes registers the customer invoice download route
+func (h *InvoiceHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	router.GET("/orders/:id/invoice", authMiddleware.AuthRequired(), h.GetOrderInvoice)
+}
+
+// GetOrderInvoice godoc
+// @Summary Download an order invoice
+// @Description Download the GST invoice of a delivered order as a PDF. The invoice is issued when the order is delivered and also emailed to the customer.
+// @Tags orders
+// @Security BearerAuth
+// @Produce application/pdf
+// @Param id path string true "Order ID"
+// @Success 200 {file} file
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders/{id}/invoice [get]
+func (h *InvoiceHandler) GetOrderInvoice(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "Unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	fileName, data, err := h.invoiceService.GetInvoicePDF(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		abortWithError(c, "Failed to get invoice", err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
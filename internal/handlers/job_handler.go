@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+	"golang-food-backend/pkg/jobs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobHandler struct {
+	queue *jobs.Queue
+}
+
+func NewJobHandler(queue *jobs.Queue) *JobHandler {
+	return &JobHandler{
+		queue: queue,
+	}
+}
+
+type JobsResponse struct {
+	Jobs       []jobs.Job              `json:"jobs"`
+	Counts     map[string]int64        `json:"counts"` // jobs in each status
+	Pagination services.PaginationInfo `json:"pagination"`
+}
+
+// RegisterRoutes registers the admin routes for inspecting and retrying background jobs
+func (h *JobHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := router.Group("/admin/jobs",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionJobs),
+	)
+	{
+		admin.GET("", h.ListJobs)
+		admin.GET("/:id", h.GetJob)
+		admin.POST("/:id/retry", h.RetryJob)
+	}
+}
+
+// ListJobs godoc
+// @Summary List background jobs
+// @Description List background jobs in a status, most recently updated first, with the number of jobs in every status. Defaults to dead jobs, which ran out of attempts (admin only).
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "pending, scheduled, running, completed or dead" default(dead)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} JobsResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/jobs [get]
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	ctx := c.Request.Context()
+	list, total, err := h.queue.List(ctx, c.DefaultQuery("status", jobs.StatusDead), (page-1)*limit, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list jobs", err)
+		return
+	}
+	counts, err := h.queue.Counts(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to count jobs",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, JobsResponse{
+		Jobs:   list,
+		Counts: counts,
+		Pagination: services.PaginationInfo{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		},
+	})
+}
+
+// GetJob godoc
+// @Summary Get a background job
+// @Description Get a background job with its payload, attempts and last error (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} jobs.Job
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/jobs/{id} [get]
+func (h *JobHandler) GetJob(c *gin.Context) {
+	job, err := h.queue.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get job", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// RetryJob godoc
+// @Summary Retry a dead job
+// @Description Give a job that ran out of attempts a fresh set of attempts (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} jobs.Job
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/jobs/{id}/retry [post]
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	job, err := h.queue.Retry(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to retry job", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KitchenTicketHandler struct {
+	ticketService *services.KitchenTicketService
+}
+
+func NewKitchenTicketHandler(ticketService *services.KitchenTicketService) *KitchenTicketHandler {
+	return &KitchenTicketHandler{
+		ticketService: ticketService,
+	}
+}
+
+// RegisterRoutes registers the kitchen ticket routes for restaurant staff
+func (h *KitchenTicketHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	restaurant := router.Group("/restaurant/orders/:id/ticket",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantRequired(),
+		authMiddleware.RestaurantStaffRequired(),
+		authMiddleware.RestaurantPermissionRequired(services.StaffPermissionOrders),
+	)
+	{
+		restaurant.GET("", h.GetTicket)
+		restaurant.POST("/print", h.PrintTicket)
+	}
+}
+
+// GetTicket godoc
+// @Summary Download a kitchen ticket
+// @Description Download the order's kitchen order ticket (KOT) with its items, variants, addons and customer notes, as plain text or as ESC/POS bytes to send to a thermal printer
+// @Tags orders
+// @Security BearerAuth
+// @Produce plain
+// @Produce application/octet-stream
+// @Param id path string true "Order ID"
+// @Param format query string false "text or escpos" default(text)
+// @Param width query int false "Characters per line, 24 to 64 (32 for 58mm paper, 48 for 80mm)" default(48)
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurant/orders/{id}/ticket [get]
+func (h *KitchenTicketHandler) GetTicket(c *gin.Context) {
+	format, err := services.ParseTicketFormat(c.Query("format"))
+	if err != nil {
+		abortWithError(c, "Failed to get kitchen ticket", err)
+		return
+	}
+	width, _ := strconv.Atoi(c.DefaultQuery("width", "0"))
+
+	ticket, err := h.ticketService.GetTicket(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"), width)
+	if err != nil {
+		abortWithError(c, "Failed to get kitchen ticket", err)
+		return
+	}
+
+	if format == services.TicketFormatESCPOS {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "kot-"+ticket.OrderID[:8]+".bin"))
+		c.Data(http.StatusOK, "application/octet-stream", ticket.ESCPOS)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "kot-"+ticket.OrderID[:8]+".txt"))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(ticket.Text))
+}
+
+// PrintTicket godoc
+// @Summary Reprint a kitchen ticket
+// @Description Send the order's kitchen ticket, marked as a reprint, to the restaurant's printer agents: its webhook subscriptions to ticket.print. Tickets are printed automatically when an order is confirmed.
+// @Tags orders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body services.PrintTicketRequest false "Ticket width"
+// @Success 202 {object} services.PrintTicketResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /restaurant/orders/{id}/ticket/print [post]
+func (h *KitchenTicketHandler) PrintTicket(c *gin.Context) {
+	var req services.PrintTicketRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid request body",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	printed, err := h.ticketService.PrintTicket(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to print kitchen ticket", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, printed)
+}
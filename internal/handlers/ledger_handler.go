@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LedgerHandler struct {
+	ledgerService *services.LedgerService
+}
+
+func NewLedgerHandler(ledgerService *services.LedgerService) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerService: ledgerService,
+	}
+}
+
+// RegisterRoutes registers the admin ledger routes for finance
+func (h *LedgerHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := router.Group("/admin/ledger",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionReports),
+	)
+	{
+		admin.GET("/accounts", h.ListAccounts)
+		admin.GET("/accounts/:id", h.GetAccountBalance)
+		admin.GET("/accounts/:id/lines", h.GetAccountLines)
+		admin.GET("/entries/:id", h.GetEntry)
+		admin.GET("/trial-balance", middleware.Timeout(longRequestTimeout), h.GetTrialBalance)
+	}
+}
+
+// ListAccounts godoc
+// @Summary List ledger accounts
+// @Description Ledger accounts by code with their debits, credits and balance at the end of a day: customer_wallet and restaurant_payable accounts per customer and restaurant, and the platform_revenue and gateway_clearing accounts
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param kind query string false "Only accounts of this kind"
+// @Param as_of query string false "Balances at the end of this day (YYYY-MM-DD); defaults to now"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} services.LedgerAccountListResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/ledger/accounts [get]
+func (h *LedgerHandler) ListAccounts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	accounts, err := h.ledgerService.ListAccounts(c.Request.Context(), c.Query("kind"), c.Query("as_of"), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list ledger accounts", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+// GetAccountBalance godoc
+// @Summary Get a ledger account's balance
+// @Description A ledger account with its debits, credits and balance at the end of a day. Gateway clearing carries a debit balance; the other accounts carry credit balances.
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Ledger account ID"
+// @Param as_of query string false "Balance at the end of this day (YYYY-MM-DD); defaults to now"
+// @Success 200 {object} services.LedgerAccountBalance
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/ledger/accounts/{id} [get]
+func (h *LedgerHandler) GetAccountBalance(c *gin.Context) {
+	balance, err := h.ledgerService.GetAccountBalance(c.Request.Context(), c.Param("id"), c.Query("as_of"))
+	if err != nil {
+		abortWithError(c, "Failed to get ledger account", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
+
+// GetAccountLines godoc
+// @Summary List a ledger account's postings
+// @Description The debits and credits posted to a ledger account with their journal entries, newest first
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Ledger account ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} services.JournalLineListResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/ledger/accounts/{id}/lines [get]
+func (h *LedgerHandler) GetAccountLines(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	lines, err := h.ledgerService.GetAccountLines(c.Request.Context(), c.Param("id"), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list ledger postings", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, lines)
+}
+
+// GetEntry godoc
+// @Summary Get a journal entry
+// @Description A journal entry with its debit and credit lines and their accounts. Its reference names the payment, refund, payout or meal plan bill it records.
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Journal entry ID"
+// @Success 200 {object} models.JournalEntry
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/ledger/entries/{id} [get]
+func (h *LedgerHandler) GetEntry(c *gin.Context) {
+	entry, err := h.ledgerService.GetEntry(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get journal entry", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetTrialBalance godoc
+// @Summary Get the trial balance
+// @Description Every ledger account in a currency with its net debit or credit balance at the end of a day, and the column totals, which match while the ledger balances
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param currency query string false "Currency code" default(INR)
+// @Param as_of query string false "Balances at the end of this day (YYYY-MM-DD); defaults to now"
+// @Success 200 {object} services.TrialBalance
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/ledger/trial-balance [get]
+func (h *LedgerHandler) GetTrialBalance(c *gin.Context) {
+	trial, err := h.ledgerService.GetTrialBalance(c.Request.Context(), c.Query("currency"), c.Query("as_of"))
+	if err != nil {
+		abortWithError(c, "Failed to get trial balance", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, trial)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MaintenanceHandler struct {
+	maintenanceService *services.MaintenanceService
+}
+
+func NewMaintenanceHandler(maintenanceService *services.MaintenanceService) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		maintenanceService: maintenanceService,
+	}
+}
+
+// RegisterRoutes registers the status and maintenance administration routes
+func (h *MaintenanceHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	// Public status endpoint clients can poll
+	router.GET("/status", h.GetStatus)
+
+	admin := router.Group("/admin/maintenance", authMiddleware.AuthRequired(), authMiddleware.AdminRequired())
+	{
+		admin.POST("", h.ScheduleWindow)
+		admin.DELETE("/:id", h.CancelWindow)
+	}
+}
+
+// GetStatus godoc
+// @Summary Get platform status
+// @Description Get the current operational status and any running or upcoming maintenance windows
+// @Tags status
+// @Produce json
+// @Success 200 {object} services.MaintenanceStatusResponse
+// @Router /status [get]
+func (h *MaintenanceHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.maintenanceService.GetStatus(c.Request.Context()))
+}
+
+// ScheduleWindow godoc
+// @Summary Schedule a maintenance window
+// @Description Schedule a window during which the API is read-only (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.ScheduleMaintenanceRequest true "Maintenance window"
+// @Success 201 {object} models.MaintenanceWindow
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/maintenance [post]
+func (h *MaintenanceHandler) ScheduleWindow(c *gin.Context) {
+	var req services.ScheduleMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	window, err := h.maintenanceService.ScheduleWindow(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to schedule maintenance",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, window)
+}
+
+// CancelWindow godoc
+// @Summary Cancel a maintenance window
+// @Description Cancel a scheduled or running maintenance window (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Maintenance window ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/maintenance/{id} [delete]
+func (h *MaintenanceHandler) CancelWindow(c *gin.Context) {
+	if err := h.maintenanceService.CancelWindow(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to cancel maintenance",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Maintenance window cancelled"})
+}
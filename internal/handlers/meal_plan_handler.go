@@ -0,0 +1,406 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MealPlanHandler struct {
+	mealPlanService *services.MealPlanService
+}
+
+func NewMealPlanHandler(mealPlanService *services.MealPlanService) *MealPlanHandler {
+	return &MealPlanHandler{
+		mealPlanService: mealPlanService,
+	}
+}
+
+// RegisterRoutes registers the meal plan routes: the public plan list, customer subscriptions
+// and plan management for restaurant staff
+func (h *MealPlanHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	router.GET("/restaurants/:id/meal-plans", h.ListAvailablePlans)
+
+	customer := router.Group("/meal-plans/subscriptions", authMiddleware.AuthRequired())
+	{
+		customer.POST("", h.Subscribe)
+		customer.GET("", h.ListMySubscriptions)
+		customer.GET("/:id", h.GetMySubscription)
+		customer.POST("/:id/skip", h.SkipDelivery)
+		customer.POST("/:id/pause", h.PauseSubscription)
+		customer.POST("/:id/resume", h.ResumeSubscription)
+		customer.POST("/:id/cancel", h.CancelSubscription)
+	}
+
+	restaurant := router.Group("/restaurant/meal-plans",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantRequired(),
+		authMiddleware.RestaurantStaffRequired(),
+		authMiddleware.RestaurantPermissionRequired(services.StaffPermissionMenuEdit),
+	)
+	{
+		restaurant.GET("", h.ListPlans)
+		restaurant.POST("", h.CreatePlan)
+		restaurant.PUT("/:id", h.UpdatePlan)
+		restaurant.DELETE("/:id", h.DeletePlan)
+		restaurant.GET("/subscriptions", h.ListRestaurantSubscriptions)
+		restaurant.GET("/analytics", h.GetAnalytics)
+	}
+}
+
+// ListAvailablePlans godoc
+// @Summary List a restaurant's meal plans
+// @Description The restaurant's meal plans taking subscribers, with their items and price per delivery
+// @Tags meal-plans
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Success 200 {array} services.MealPlanResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurants/{id}/meal-plans [get]
+func (h *MealPlanHandler) ListAvailablePlans(c *gin.Context) {
+	plans, err := h.mealPlanService.ListAvailablePlans(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to list meal plans", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+// Subscribe godoc
+// @Summary Subscribe to a meal plan
+// @Description Subscribe to a meal plan, delivered or picked up at a set time on chosen weekdays (every day by default) from the start date. Deliveries are billed a week at a time ahead from your wallet or a Razorpay mandate; the first week is billed now and has to be paid. Each delivery is ordered at the plan's cutoff before it.
+// @Tags meal-plans
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.SubscribeMealPlanRequest true "Subscription"
+// @Success 201 {object} services.MealPlanSubscriptionDetails
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /meal-plans/subscriptions [post]
+func (h *MealPlanHandler) Subscribe(c *gin.Context) {
+	var req services.SubscribeMealPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	subscription, err := h.mealPlanService.Subscribe(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to subscribe to meal plan", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+// ListMySubscriptions godoc
+// @Summary List my meal plan subscriptions
+// @Description Your meal plan subscriptions, newest first
+// @Tags meal-plans
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {array} services.MealPlanSubscriptionDetails
+// @Router /meal-plans/subscriptions [get]
+func (h *MealPlanHandler) ListMySubscriptions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	subscriptions, err := h.mealPlanService.ListUserSubscriptions(c.Request.Context(), middleware.GetUserID(c), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list meal plan subscriptions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// GetMySubscription godoc
+// @Summary Get a meal plan subscription
+// @Description One of your subscriptions with the coming week's deliveries, until when each can be skipped, and recent bills and credits
+// @Tags meal-plans
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} services.MealPlanSubscriptionDetails
+// @Failure 404 {object} ErrorResponse
+// @Router /meal-plans/subscriptions/{id} [get]
+func (h *MealPlanHandler) GetMySubscription(c *gin.Context) {
+	subscription, err := h.mealPlanService.GetUserSubscription(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get meal plan subscription", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// SkipDelivery godoc
+// @Summary Skip a meal plan delivery
+// @Description Skip one delivery before the plan's cutoff; if it was already billed, its price goes back to your wallet
+// @Tags meal-plans
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param request body services.SkipMealPlanDeliveryRequest true "Delivery date"
+// @Success 200 {object} services.MealPlanSubscriptionDetails
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /meal-plans/subscriptions/{id}/skip [post]
+func (h *MealPlanHandler) SkipDelivery(c *gin.Context) {
+	var req services.SkipMealPlanDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	subscription, err := h.mealPlanService.SkipDelivery(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to skip meal plan delivery", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// PauseSubscription godoc
+// @Summary Pause a meal plan subscription
+// @Description Pause deliveries until a date, or until you resume. Deliveries past their cutoff still come; later ones already billed go back to your wallet.
+// @Tags meal-plans
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param request body services.PauseMealPlanRequest false "Resume date"
+// @Success 200 {object} services.MealPlanSubscriptionDetails
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /meal-plans/subscriptions/{id}/pause [post]
+func (h *MealPlanHandler) PauseSubscription(c *gin.Context) {
+	var req services.PauseMealPlanRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid request body",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	subscription, err := h.mealPlanService.PauseSubscription(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to pause meal plan subscription", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// ResumeSubscription godoc
+// @Summary Resume a meal plan subscription
+// @Description Resume a paused subscription, billing its next week, or pay the bill of one waiting for payment
+// @Tags meal-plans
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} services.MealPlanSubscriptionDetails
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /meal-plans/subscriptions/{id}/resume [post]
+func (h *MealPlanHandler) ResumeSubscription(c *gin.Context) {
+	subscription, err := h.mealPlanService.ResumeSubscription(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to resume meal plan subscription", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// CancelSubscription godoc
+// @Summary Cancel a meal plan subscription
+// @Description End the subscription. Deliveries past their cutoff still come; later ones already billed go back to your wallet.
+// @Tags meal-plans
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} services.MealPlanSubscriptionDetails
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /meal-plans/subscriptions/{id}/cancel [post]
+func (h *MealPlanHandler) CancelSubscription(c *gin.Context) {
+	subscription, err := h.mealPlanService.CancelSubscription(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to cancel meal plan subscription", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// ListPlans godoc
+// @Summary List meal plans
+// @Description The restaurant's meal plans, active or not, with their items at today's menu prices (staff with menu.edit)
+// @Tags meal-plans
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} services.MealPlanResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /restaurant/meal-plans [get]
+func (h *MealPlanHandler) ListPlans(c *gin.Context) {
+	plans, err := h.mealPlanService.ListPlans(c.Request.Context(), middleware.GetRestaurantID(c))
+	if err != nil {
+		abortWithError(c, "Failed to list meal plans", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+// CreatePlan godoc
+// @Summary Add a meal plan
+// @Description Add a meal plan of the restaurant's available products, with its price per delivery and how many hours before a delivery it is ordered (staff with menu.edit)
+// @Tags meal-plans
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.MealPlanRequest true "Meal plan"
+// @Success 201 {object} services.MealPlanResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurant/meal-plans [post]
+func (h *MealPlanHandler) CreatePlan(c *gin.Context) {
+	var req services.MealPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	plan, err := h.mealPlanService.CreatePlan(c.Request.Context(), middleware.GetRestaurantID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to create meal plan", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+// UpdatePlan godoc
+// @Summary Update a meal plan
+// @Description Replace a meal plan. Subscribers keep the price they subscribed at; new items apply to deliveries not yet ordered. Inactive plans take no new subscribers (staff with menu.edit).
+// @Tags meal-plans
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Meal plan ID"
+// @Param request body services.MealPlanRequest true "Meal plan"
+// @Success 200 {object} services.MealPlanResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurant/meal-plans/{id} [put]
+func (h *MealPlanHandler) UpdatePlan(c *gin.Context) {
+	var req services.MealPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	plan, err := h.mealPlanService.UpdatePlan(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to update meal plan", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// DeletePlan godoc
+// @Summary Remove a meal plan
+// @Description Remove a meal plan nobody subscribed to; deactivate plans with subscriptions instead (staff with menu.edit)
+// @Tags meal-plans
+// @Security BearerAuth
+// @Param id path string true "Meal plan ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /restaurant/meal-plans/{id} [delete]
+func (h *MealPlanHandler) DeletePlan(c *gin.Context) {
+	if err := h.mealPlanService.DeletePlan(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id")); err != nil {
+		abortWithError(c, "Failed to remove meal plan", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListRestaurantSubscriptions godoc
+// @Summary List meal plan subscriptions
+// @Description The restaurant's meal plan subscriptions, newest first (staff with menu.edit)
+// @Tags meal-plans
+// @Security BearerAuth
+// @Produce json
+// @Param plan_id query string false "Meal plan ID"
+// @Param status query string false "active, paused, payment_due, cancelled or ended"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {array} services.MealPlanSubscriptionDetails
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurant/meal-plans/subscriptions [get]
+func (h *MealPlanHandler) ListRestaurantSubscriptions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	subscriptions, err := h.mealPlanService.ListRestaurantSubscriptions(c.Request.Context(), middleware.GetRestaurantID(c), c.Query("plan_id"), c.Query("status"), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list meal plan subscriptions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// GetAnalytics godoc
+// @Summary Meal plan analytics
+// @Description Per plan: subscribers by status, and the deliveries ordered and skipped, the skip rate, charges, credits and failed bills dated in the range (by default the last 30 days) (staff with menu.edit)
+// @Tags meal-plans
+// @Security BearerAuth
+// @Produce json
+// @Param from query string false "First date, YYYY-MM-DD"
+// @Param to query string false "Last date, YYYY-MM-DD"
+// @Success 200 {object} services.MealPlanAnalytics
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurant/meal-plans/analytics [get]
+func (h *MealPlanHandler) GetAnalytics(c *gin.Context) {
+	analytics, err := h.mealPlanService.GetAnalytics(c.Request.Context(), middleware.GetRestaurantID(c), c.Query("from"), c.Query("to"))
+	if err != nil {
+		abortWithError(c, "Failed to get meal plan analytics", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MediaHandler struct {
+	mediaService *services.MediaService
+}
+
+func NewMediaHandler(mediaService *services.MediaService) *MediaHandler {
+	return &MediaHandler{
+		mediaService: mediaService,
+	}
+}
+
+// RegisterRoutes registers media upload routes
+func (h *MediaHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	media := router.Group("/media",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantRequired(),
+		authMiddleware.RestaurantStaffRequired(),
+		authMiddleware.RestaurantPermissionRequired(services.StaffPermissionMenuEdit),
+	)
+	{
+		media.POST("/uploads", h.CreateUpload)
+		media.POST("/:id/confirm", h.ConfirmUpload)
+		media.GET("", h.ListMedia)
+		media.DELETE("/:id", h.DeleteMedia)
+	}
+}
+
+// CreateUpload godoc
+// @Summary Start an image upload
+// @Description Get a pre-signed URL to PUT a JPEG or PNG image to, for a product, category or the restaurant logo. Send the returned headers with the upload, then confirm it.
+// @Tags media
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.CreateUploadRequest true "Upload details"
+// @Success 201 {object} services.UploadResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /media/uploads [post]
+func (h *MediaHandler) CreateUpload(c *gin.Context) {
+	var req services.CreateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	upload, err := h.mediaService.CreateUpload(c.Request.Context(), middleware.GetUserID(c), middleware.GetRestaurantID(c), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to create upload",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, upload)
+}
+
+// ConfirmUpload godoc
+// @Summary Confirm an image upload
+// @Description Validate the uploaded image, generate thumbnails and attach it to its product, category or restaurant
+// @Tags media
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Media ID"
+// @Success 200 {object} models.Media
+// @Failure 400 {object} ErrorResponse
+// @Router /media/{id}/confirm [post]
+func (h *MediaHandler) ConfirmUpload(c *gin.Context) {
+	media, err := h.mediaService.ConfirmUpload(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to confirm upload",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+// ListMedia godoc
+// @Summary List media
+// @Description List the images uploaded for a product, category or the restaurant
+// @Tags media
+// @Security BearerAuth
+// @Produce json
+// @Param owner_type query string true "product, category or restaurant"
+// @Param owner_id query string true "Owner ID"
+// @Success 200 {array} models.Media
+// @Failure 400 {object} ErrorResponse
+// @Router /media [get]
+func (h *MediaHandler) ListMedia(c *gin.Context) {
+	media, err := h.mediaService.ListMedia(c.Request.Context(), middleware.GetRestaurantID(c), c.Query("owner_type"), c.Query("owner_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to list media",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+// DeleteMedia godoc
+// @Summary Delete media
+// @Description Detach an image from its owner and delete it and its thumbnails
+// @Tags media
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Media ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Router /media/{id} [delete]
+func (h *MediaHandler) DeleteMedia(c *gin.Context) {
+	if err := h.mediaService.DeleteMedia(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to delete media",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Media deleted successfully"})
+}
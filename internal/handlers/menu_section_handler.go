@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MenuSectionHandler struct {
+	menuSectionService *services.MenuSectionService
+}
+
+func NewMenuSectionHandler(menuSectionService *services.MenuSectionService) *MenuSectionHandler {
+	return &MenuSectionHandler{
+		menuSectionService: menuSectionService,
+	}
+}
+
+// RegisterRoutes registers the public menu route and menu section management for restaurant staff
+func (h *MenuSectionHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	router.GET("/restaurants/:id/menu", h.GetMenu)
+
+	sections := router.Group("/menu-sections",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantRequired(),
+		authMiddleware.RestaurantStaffRequired(),
+		authMiddleware.RestaurantPermissionRequired(services.StaffPermissionMenuEdit),
+	)
+	{
+		sections.POST("", h.CreateSection)
+		sections.GET("", h.ListSections)
+		sections.PUT("/order", h.ReorderSections)
+		sections.GET("/:id", h.GetSection)
+		sections.PUT("/:id", h.UpdateSection)
+		sections.DELETE("/:id", h.DeleteSection)
+		sections.PUT("/:id/products", h.SetSectionProducts)
+	}
+}
+
+// GetMenu godoc
+// @Summary Get restaurant menu
+// @Description Get the restaurant's full menu organized into the sections valid right now, each with its available products in display order
+// @Tags products
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Success 200 {object} services.MenuResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurants/{id}/menu [get]
+func (h *MenuSectionHandler) GetMenu(c *gin.Context) {
+	menu, err := h.menuSectionService.GetMenu(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to get menu",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, menu)
+}
+
+// CreateSection godoc
+// @Summary Create a menu section
+// @Description Create a menu section, optionally limited to certain hours and days
+// @Tags menu-sections
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.CreateMenuSectionRequest true "Menu section"
+// @Success 201 {object} models.MenuSection
+// @Failure 400 {object} ErrorResponse
+// @Router /menu-sections [post]
+func (h *MenuSectionHandler) CreateSection(c *gin.Context) {
+	var req services.CreateMenuSectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	section, err := h.menuSectionService.CreateSection(c.Request.Context(), middleware.GetRestaurantID(c), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to create menu section",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, section)
+}
+
+// ListSections godoc
+// @Summary List menu sections
+// @Description List all of the restaurant's menu sections, including inactive ones, in display order
+// @Tags menu-sections
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.MenuSection
+// @Failure 500 {object} ErrorResponse
+// @Router /menu-sections [get]
+func (h *MenuSectionHandler) ListSections(c *gin.Context) {
+	sections, err := h.menuSectionService.ListSections(c.Request.Context(), middleware.GetRestaurantID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to list menu sections",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, sections)
+}
+
+// ReorderSections godoc
+// @Summary Reorder menu sections
+// @Description Set the display order of menu sections to the order of the given IDs
+// @Tags menu-sections
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.ReorderMenuSectionsRequest true "Section IDs in display order"
+// @Success 200 {array} models.MenuSection
+// @Failure 400 {object} ErrorResponse
+// @Router /menu-sections/order [put]
+func (h *MenuSectionHandler) ReorderSections(c *gin.Context) {
+	var req services.ReorderMenuSectionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	sections, err := h.menuSectionService.ReorderSections(c.Request.Context(), middleware.GetRestaurantID(c), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to reorder menu sections",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, sections)
+}
+
+// GetSection godoc
+// @Summary Get a menu section
+// @Description Get one of the restaurant's menu sections by ID
+// @Tags menu-sections
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Section ID"
+// @Success 200 {object} models.MenuSection
+// @Failure 404 {object} ErrorResponse
+// @Router /menu-sections/{id} [get]
+func (h *MenuSectionHandler) GetSection(c *gin.Context) {
+	section, err := h.menuSectionService.GetSection(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Menu section not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, section)
+}
+
+// UpdateSection godoc
+// @Summary Update a menu section
+// @Description Update a menu section's name, description, sort order, active flag or time restriction
+// @Tags menu-sections
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Section ID"
+// @Param request body services.UpdateMenuSectionRequest true "Section changes"
+// @Success 200 {object} models.MenuSection
+// @Failure 400 {object} ErrorResponse
+// @Router /menu-sections/{id} [put]
+func (h *MenuSectionHandler) UpdateSection(c *gin.Context) {
+	var req services.UpdateMenuSectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	section, err := h.menuSectionService.UpdateSection(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to update menu section",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, section)
+}
+
+// DeleteSection godoc
+// @Summary Delete a menu section
+// @Description Delete a menu section. Its products are not deleted.
+// @Tags menu-sections
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Section ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /menu-sections/{id} [delete]
+func (h *MenuSectionHandler) DeleteSection(c *gin.Context) {
+	if err := h.menuSectionService.DeleteSection(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to delete menu section",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Menu section deleted successfully"})
+}
+
+// SetSectionProducts godoc
+// @Summary Assign products to a menu section
+// @Description Replace the products listed in a menu section. The order of the IDs is the display order.
+// @Tags menu-sections
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Section ID"
+// @Param request body services.SetSectionProductsRequest true "Product IDs in display order"
+// @Success 200 {object} models.MenuSection
+// @Failure 400 {object} ErrorResponse
+// @Router /menu-sections/{id}/products [put]
+func (h *MenuSectionHandler) SetSectionProducts(c *gin.Context) {
+	var req services.SetSectionProductsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	section, err := h.menuSectionService.SetSectionProducts(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to assign products",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, section)
+}
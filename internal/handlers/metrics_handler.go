@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+	"golang-food-backend/pkg/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MetricsHandler struct {
+	db *database.Database
+}
+
+func NewMetricsHandler(db *database.Database) *MetricsHandler {
+	return &MetricsHandler{
+		db: db,
+	}
+}
+
+type MetricsResponse struct {
+	Database database.PoolStats `json:"database"`
+}
+
+// RegisterRoutes registers the admin runtime metrics route
+func (h *MetricsHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := router.Group("/admin",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionReports),
+	)
+	{
+		admin.GET("/metrics", h.GetMetrics)
+	}
+}
+
+// GetMetrics godoc
+// @Summary Runtime metrics
+// @Description PostgreSQL and MongoDB connection pool utilization and slow-query counts since the server started (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} MetricsResponse
+// @Router /admin/metrics [get]
+func (h *MetricsHandler) GetMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, MetricsResponse{
+		Database: h.db.Stats(),
+	})
+}
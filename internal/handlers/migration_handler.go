@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/migrations"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MigrationHandler struct {
+	runner *migrations.Runner
+}
+
+func NewMigrationHandler(runner *migrations.Runner) *MigrationHandler {
+	return &MigrationHandler{
+		runner: runner,
+	}
+}
+
+type MigrationStatusResponse struct {
+	Migrations []migrations.Status `json:"migrations"`
+	Pending    int                 `json:"pending"`
+}
+
+// RegisterRoutes registers the admin route for schema migration status
+func (h *MigrationHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := router.Group("/admin",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionMaintenance),
+	)
+	{
+		admin.GET("/migrations", h.GetMigrationStatus)
+	}
+}
+
+// GetMigrationStatus godoc
+// @Summary Schema migration status
+// @Description Every versioned database migration in the order it runs and whether it has been applied. Migrations applied to the database but missing from this build are listed last and marked unknown (admin only).
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} MigrationStatusResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/migrations [get]
+func (h *MigrationHandler) GetMigrationStatus(c *gin.Context) {
+	statuses, err := h.runner.Status(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to get migration status",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if !status.Applied {
+			pending++
+		}
+	}
+	c.JSON(http.StatusOK, MigrationStatusResponse{
+		Migrations: statuses,
+		Pending:    pending,
+	})
+}
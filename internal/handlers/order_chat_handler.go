@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+type OrderChatHandler struct {
+	chatService *services.OrderChatService
+}
+
+func NewOrderChatHandler(chatService *services.OrderChatService) *OrderChatHandler {
+	return &OrderChatHandler{
+		chatService: chatService,
+	}
+}
+
+// RegisterRoutes registers the order chat routes for customers, restaurant staff, riders and
+// support agents
+func (h *OrderChatHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	chat := router.Group("/orders/:id/chat", authMiddleware.AuthRequired())
+	{
+		chat.GET("", h.GetChat)
+		chat.POST("/messages", h.SendMessage)
+		chat.POST("/read", h.MarkRead)
+	}
+	router.GET("/ws/orders/:id/chat", authMiddleware.StreamAuthRequired(), h.StreamChat)
+
+	router.GET("/admin/orders/:id/chat/export",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionSupport),
+		middleware.Timeout(longRequestTimeout),
+		h.ExportChat,
+	)
+}
+
+// GetChat godoc
+// @Summary Get an order's chat
+// @Description Get the chat between the order's customer, its restaurant and the in-house rider delivering it, with the caller's unread count and quick replies. Admins can read any chat.
+// @Tags orders
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} services.OrderChatResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /orders/{id}/chat [get]
+func (h *OrderChatHandler) GetChat(c *gin.Context) {
+	chat, err := h.chatService.GetChat(c.Request.Context(), c.Param("id"), middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetRestaurantID(c))
+	if err != nil {
+		abortWithError(c, "Failed to get chat", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, chat)
+}
+
+// SendMessage godoc
+// @Summary Send a chat message
+// @Description Send a message, or a quick reply by its key, to the order's chat. Chats take messages only while the order is active; listed words are masked.
+// @Tags orders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param message body services.SendChatMessageRequest true "Message"
+// @Success 201 {object} models.OrderChatMessage
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders/{id}/chat/messages [post]
+func (h *OrderChatHandler) SendMessage(c *gin.Context) {
+	var req services.SendChatMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	message, err := h.chatService.SendMessage(c.Request.Context(), c.Param("id"), middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetRestaurantID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to send message", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, message)
+}
+
+// MarkRead godoc
+// @Summary Mark a chat read
+// @Description Mark the messages others sent so far as read; they get a read receipt
+// @Tags orders
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} services.ChatReadResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /orders/{id}/chat/read [post]
+func (h *OrderChatHandler) MarkRead(c *gin.Context) {
+	read, err := h.chatService.MarkRead(c.Request.Context(), c.Param("id"), middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetRestaurantID(c))
+	if err != nil {
+		abortWithError(c, "Failed to mark chat read", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, read)
+}
+
+// StreamChat godoc
+// @Summary Follow an order's chat
+// @Description WebSocket of chat events: the whole chat first (state), then new messages, read receipts and closed when the order ends. Messages are sent with POST /orders/{id}/chat/messages. Browsers may pass the token as access_token.
+// @Tags orders
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param access_token query string false "JWT access token (for browser WebSocket clients)"
+// @Success 101 {object} services.OrderChatEvent
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /ws/orders/{id}/chat [get]
+func (h *OrderChatHandler) StreamChat(c *gin.Context) {
+	// Access is checked before upgrading so a stranger gets a plain 404
+	chat, err := h.chatService.GetChat(c.Request.Context(), c.Param("id"), middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetRestaurantID(c))
+	if err != nil {
+		abortWithError(c, "Failed to follow chat", err)
+		return
+	}
+
+	server := websocket.Server{Handler: func(conn *websocket.Conn) {
+		defer conn.Close()
+		ctx, cancel := context.WithCancel(conn.Request().Context())
+		defer cancel()
+
+		events := h.chatService.Subscribe(ctx, chat.OrderID)
+
+		// Clients only listen; reading notices when they go away
+		go func() {
+			var discard string
+			for websocket.Message.Receive(conn, &discard) == nil {
+			}
+			cancel()
+		}()
+
+		state := services.OrderChatEvent{Type: "state", OrderID: chat.OrderID, Messages: chat.Messages, Timestamp: time.Now()}
+		if websocket.JSON.Send(conn, state) != nil || !chat.Active {
+			return
+		}
+
+		heartbeat := time.NewTicker(25 * time.Second)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if websocket.JSON.Send(conn, event) != nil || event.Type == "closed" {
+					return
+				}
+			case <-heartbeat.C:
+				if websocket.JSON.Send(conn, services.OrderChatEvent{Type: "ping", Timestamp: time.Now()}) != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// ExportChat godoc
+// @Summary Export an order's chat
+// @Description Export the order's whole chat for dispute handling, with the text of filtered messages as sent and read receipts (admin with support.manage)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Produce text/csv
+// @Param id path string true "Order ID"
+// @Param format query string false "json or csv" default(json)
+// @Success 200 {object} services.OrderChatExport
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/orders/{id}/chat/export [get]
+func (h *OrderChatHandler) ExportChat(c *gin.Context) {
+	export, err := h.chatService.Export(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to export chat", err)
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, export)
+		return
+	}
+	data, err := export.CSV()
+	if err != nil {
+		abortWithError(c, "Failed to export chat", err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "chat-"+export.OrderID[:8]+".csv"))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
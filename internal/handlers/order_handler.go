@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"golang-food-backend/internal/middleware"
 	"golang-food-backend/internal/services"
+	"golang-food-backend/pkg/database"
 	"net/http"
 	"strconv"
 
@@ -110,16 +112,24 @@ func (h *OrderHandler) GetUserOrders(c *gin.Context) {
 }
 
 // @Summary Get restaurant orders
-// @Description Get all orders for a restaurant (restaurant staff/owner only)
+// @Description Get the restaurant's orders, newest first, optionally filtered by status, date range, amount range, payment method and delivery provider (restaurant staff/owner only). The number of matching orders is in the X-Total-Count header.
 // @Tags orders
 // @Security BearerAuth
 // @Produce json
+// @Param status query string false "Order status, or a comma-separated list"
+// @Param from query string false "Placed on or after (YYYY-MM-DD)"
+// @Param to query string false "Placed on or before (YYYY-MM-DD)"
+// @Param min_amount query number false "Minimum order total"
+// @Param max_amount query number false "Maximum order total"
+// @Param payment_method query string false "Payment method (UPI, card, wallet, cash)"
+// @Param delivery_provider query string false "Delivery provider the order was booked with, e.g. porter"
 // @Param limit query int false "Limit number of results" default(20)
 // @Param offset query int false "Offset for pagination" default(0)
 // @Success 200 {array} models.Order
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /api/v1/restaurant/orders [get]
 func (h *OrderHandler) GetRestaurantOrders(c *gin.Context) {
 	restaurantID := middleware.GetRestaurantID(c)
@@ -128,20 +138,102 @@ func (h *OrderHandler) GetRestaurantOrders(c *gin.Context) {
 		return
 	}
 
+	var query services.OrderListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	orders, err := h.orderService.GetRestaurantOrders(c.Request.Context(), restaurantID, limit, offset)
+	orders, total, err := h.orderService.GetRestaurantOrders(c.Request.Context(), restaurantID, &query, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, "Failed to list orders", err)
 		return
 	}
 
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
 	c.JSON(http.StatusOK, orders)
 }
 
+// @Summary Count restaurant orders by status
+// @Description Count the restaurant's orders by status, optionally filtered by date range, amount range, payment method and delivery provider (restaurant staff/owner only)
+// @Tags orders
+// @Security BearerAuth
+// @Produce json
+// @Param from query string false "Placed on or after (YYYY-MM-DD)"
+// @Param to query string false "Placed on or before (YYYY-MM-DD)"
+// @Param min_amount query number false "Minimum order total"
+// @Param max_amount query number false "Maximum order total"
+// @Param payment_method query string false "Payment method (UPI, card, wallet, cash)"
+// @Param delivery_provider query string false "Delivery provider the order was booked with"
+// @Success 200 {object} services.OrderStatusCountsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /api/v1/restaurant/orders/stats/status [get]
+func (h *OrderHandler) GetRestaurantOrderStatusCounts(c *gin.Context) {
+	restaurantID := middleware.GetRestaurantID(c)
+	if restaurantID == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Restaurant access required"})
+		return
+	}
+
+	var query services.OrderListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	counts, err := h.orderService.CountRestaurantOrdersByStatus(c.Request.Context(), restaurantID, &query)
+	if err != nil {
+		abortWithError(c, "Failed to count orders", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, counts)
+}
+
+// @Summary Get restaurant revenue per day
+// @Description Get the restaurant's order count and revenue per day, excluding unpaid, failed and cancelled orders. Defaults to the last 30 days (restaurant staff/owner only).
+// @Tags orders
+// @Security BearerAuth
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Param status query string false "Order status, or a comma-separated list"
+// @Param min_amount query number false "Minimum order total"
+// @Param max_amount query number false "Maximum order total"
+// @Param payment_method query string false "Payment method (UPI, card, wallet, cash)"
+// @Param delivery_provider query string false "Delivery provider the order was booked with"
+// @Success 200 {object} services.DailyRevenueResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /api/v1/restaurant/orders/stats/revenue [get]
+func (h *OrderHandler) GetRestaurantDailyRevenue(c *gin.Context) {
+	restaurantID := middleware.GetRestaurantID(c)
+	if restaurantID == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Restaurant access required"})
+		return
+	}
+
+	var query services.OrderListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	revenue, err := h.orderService.GetRestaurantDailyRevenue(c.Request.Context(), restaurantID, &query)
+	if err != nil {
+		abortWithError(c, "Failed to get revenue", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revenue)
+}
+
 // @Summary Update order status
-// @Description Update the status of an order (restaurant staff/owner only)
+// @Description Update the status of an order (restaurant staff/owner only). Delivery orders go preparing, dispatched, delivered; pickup orders preparing, ready_for_pickup, delivered (collected); dine-in orders preparing, delivered (served).
 // @Tags orders
 // @Security BearerAuth
 // @Accept json
@@ -152,6 +244,7 @@ func (h *OrderHandler) GetRestaurantOrders(c *gin.Context) {
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /api/v1/restaurant/orders/{id}/status [put]
 func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	restaurantID := middleware.GetRestaurantID(c)
@@ -171,26 +264,147 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	}
 
 	if err := h.orderService.UpdateOrderStatus(c.Request.Context(), orderID, req.Status, restaurantID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrConcurrentUpdate) || errors.Is(err, services.ErrDeliveryCodeRequired) {
+			status = http.StatusConflict
+		} else if errors.Is(err, database.ErrCrossTenant) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Order status updated successfully"})
 }
 
+// @Summary Get delivery code
+// @Description Get the code to share with the rider or delivery partner when the order arrives, or to show at the counter to collect a pickup order
+// @Tags orders
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} services.DeliveryCodeResponse
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/orders/{id}/delivery-code [get]
+func (h *OrderHandler) GetDeliveryCode(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	code, err := h.orderService.GetDeliveryCode(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, code)
+}
+
+// @Summary Verify delivery code
+// @Description Verify the code a delivery partner's rider collected from the customer and mark the order delivered, or the collection code of a pickup order and mark it collected (restaurant staff/owner only)
+// @Tags orders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body map[string]string true "Delivery code"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /api/v1/restaurant/orders/{id}/delivery-code/verify [post]
+func (h *OrderHandler) VerifyDeliveryCode(c *gin.Context) {
+	restaurantID := middleware.GetRestaurantID(c)
+	if restaurantID == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Restaurant access required"})
+		return
+	}
+
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.orderService.VerifyDeliveryCode(c.Request.Context(), c.Param("id"), restaurantID, req.Code)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, services.ErrDeliveryCodeInvalid):
+			status = http.StatusUnprocessableEntity
+		case errors.Is(err, services.ErrConcurrentUpdate):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// @Summary Delay an order
+// @Description Move the order's promised delivery time later by up to 120 minutes when the kitchen runs behind. The customer is notified of the new time and tracking subscribers get a promise event (restaurant staff/owner only).
+// @Tags orders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body services.DelayOrderRequest true "Delay"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/restaurant/orders/{id}/delay [post]
+func (h *OrderHandler) DelayOrder(c *gin.Context) {
+	restaurantID := middleware.GetRestaurantID(c)
+	if restaurantID == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Restaurant access required"})
+		return
+	}
+
+	var req services.DelayOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	order, err := h.orderService.DelayOrder(c.Request.Context(), c.Param("id"), restaurantID, &req)
+	if err != nil {
+		abortWithError(c, "Failed to delay order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
 func (h *OrderHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
 	// Customer routes
 	customer := router.Group("/", authMiddleware.AuthRequired())
 	{
 		customer.POST("/orders", h.CreateOrder)
-		customer.GET("/orders", h.GetUserOrders)
-		customer.GET("/orders/:id", h.GetOrderByID)
+		// Orders are shown right after checkout, before a replica may have caught up
+		customer.GET("/orders", middleware.ForcePrimary(), h.GetUserOrders)
+		customer.GET("/orders/:id", middleware.ForcePrimary(), h.GetOrderByID)
+		customer.GET("/orders/:id/delivery-code", middleware.ForcePrimary(), h.GetDeliveryCode)
 	}
 
 	// Restaurant routes
 	restaurant := router.Group("/restaurant", authMiddleware.AuthRequired(), authMiddleware.RestaurantRequired())
 	{
-		restaurant.GET("/orders", authMiddleware.RestaurantStaffRequired(), h.GetRestaurantOrders)
-		restaurant.PUT("/orders/:id/status", authMiddleware.RestaurantStaffRequired(), h.UpdateOrderStatus)
+		restaurant.GET("/orders", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionOrders), h.GetRestaurantOrders)
+		restaurant.GET("/orders/stats/status", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionOrders), h.GetRestaurantOrderStatusCounts)
+		restaurant.GET("/orders/stats/revenue", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionOrders), h.GetRestaurantDailyRevenue)
+		restaurant.PUT("/orders/:id/status", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionOrders), h.UpdateOrderStatus)
+		restaurant.POST("/orders/:id/delivery-code/verify", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionOrders), h.VerifyDeliveryCode)
+		restaurant.POST("/orders/:id/delay", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionOrders), h.DelayOrder)
 	}
 }
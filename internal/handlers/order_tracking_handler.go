@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrderTrackingHandler struct {
+	trackingService *services.OrderTrackingService
+}
+
+func NewOrderTrackingHandler(trackingService *services.OrderTrackingService) *OrderTrackingHandler {
+	return &OrderTrackingHandler{
+		trackingService: trackingService,
+	}
+}
+
+// RegisterRoutes registers the realtime order tracking routes
+func (h *OrderTrackingHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	// The event stream lasts as long as the client listens, so it has no deadline
+	router.GET("/ws/orders/:id", authMiddleware.StreamAuthRequired(), middleware.Timeout(0), h.StreamOrder)
+	router.GET("/orders/:id/delivery/track", authMiddleware.AuthRequired(), h.GetDeliveryTrack)
+}
+
+// StreamOrder godoc
+// @Summary Stream live order updates
+// @Description Server-sent event stream of order status changes and delivery partner locations. Browsers may pass the token as access_token.
+// @Tags orders
+// @Security BearerAuth
+// @Produce text/event-stream
+// @Param id path string true "Order ID"
+// @Param access_token query string false "JWT access token (for EventSource clients)"
+// @Success 200 {object} services.TrackingEvent
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /ws/orders/{id} [get]
+func (h *OrderTrackingHandler) StreamOrder(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	order, err := h.trackingService.GetTrackableOrder(ctx, c.Param("id"), middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetRestaurantID(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Order not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	events := h.trackingService.Subscribe(ctx, order.ID.String())
+	heartbeat := time.NewTicker(25 * time.Second)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	// Send the current state first so clients don't wait for the next change
+	c.SSEvent("status", services.TrackingEvent{
+		Type:      "status",
+		OrderID:   order.ID.String(),
+		Status:    order.OrderStatus,
+		Timestamp: time.Now(),
+	})
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return event.Type != "status" || !services.IsFinalOrderStatus(event.Status)
+		case <-heartbeat.C:
+			c.SSEvent("ping", gin.H{"timestamp": time.Now()})
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
+
+// GetDeliveryTrack godoc
+// @Summary Get delivery tracking details
+// @Description Get the delivery partner's latest location, path history and estimated arrival
+// @Tags orders
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} services.DeliveryTrackResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/delivery/track [get]
+func (h *OrderTrackingHandler) GetDeliveryTrack(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	order, err := h.trackingService.GetTrackableOrder(ctx, c.Param("id"), middleware.GetUserID(c), middleware.GetUserRole(c), middleware.GetRestaurantID(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Order not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	track, err := h.trackingService.GetDeliveryTrack(ctx, order)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to get delivery tracking",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, track)
+}
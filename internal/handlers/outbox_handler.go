@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OutboxHandler struct {
+	outboxService *services.OutboxService
+}
+
+func NewOutboxHandler(outboxService *services.OutboxService) *OutboxHandler {
+	return &OutboxHandler{
+		outboxService: outboxService,
+	}
+}
+
+// RegisterRoutes registers the admin routes for inspecting and requeueing outbox events
+func (h *OutboxHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := router.Group("/admin/events",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionEvents),
+	)
+	{
+		admin.GET("", h.ListEvents)
+		admin.GET("/:id", h.GetEvent)
+		admin.POST("/:id/requeue", h.RequeueEvent)
+	}
+}
+
+// ListEvents godoc
+// @Summary List outbox events
+// @Description List Kafka events from the outbox, newest first. Defaults to failed events, which ran out of retries and were sent to the dead-letter topic (admin only).
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "pending, published or failed" default(failed)
+// @Param topic query string false "Kafka topic"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} services.OutboxEventsResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/events [get]
+func (h *OutboxHandler) ListEvents(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	events, err := h.outboxService.ListEvents(c.Request.Context(), c.Query("status"), c.Query("topic"), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list events", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// GetEvent godoc
+// @Summary Get an outbox event
+// @Description Get an outbox event with its payload and last publish error (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Event ID"
+// @Success 200 {object} models.OutboxEvent
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/events/{id} [get]
+func (h *OutboxHandler) GetEvent(c *gin.Context) {
+	event, err := h.outboxService.GetEvent(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get event", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
+
+// RequeueEvent godoc
+// @Summary Requeue a failed event
+// @Description Give a failed outbox event a fresh set of publish attempts (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Event ID"
+// @Success 200 {object} models.OutboxEvent
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/events/{id}/requeue [post]
+func (h *OutboxHandler) RequeueEvent(c *gin.Context) {
+	event, err := h.outboxService.Requeue(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to requeue event", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PartnerAPIHandler struct {
+	partnerAPIService *services.PartnerAPIService
+}
+
+func NewPartnerAPIHandler(partnerAPIService *services.PartnerAPIService) *PartnerAPIHandler {
+	return &PartnerAPIHandler{
+		partnerAPIService: partnerAPIService,
+	}
+}
+
+// RegisterRoutes registers the admin routes that issue and revoke partner API keys
+func (h *PartnerAPIHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := router.Group("/admin/delivery-partners/:id/api-keys",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionDelivery),
+	)
+	{
+		admin.GET("", h.ListKeys)
+		admin.POST("", h.CreateKey)
+		admin.DELETE("/:key_id", h.RevokeKey)
+	}
+}
+
+// RegisterPartnerRoutes registers the API-key authenticated partner API
+func (h *PartnerAPIHandler) RegisterPartnerRoutes(router *gin.RouterGroup, partnerAuth *middleware.PartnerAuthMiddleware) {
+	partner := router.Group("", partnerAuth.APIKeyRequired())
+	{
+		partner.GET("/orders", partnerAuth.ScopeRequired(services.PartnerScopeOrdersRead), h.ListOrders)
+		partner.GET("/orders/:id", partnerAuth.ScopeRequired(services.PartnerScopeOrdersRead), h.GetOrder)
+		partner.POST("/orders/:id/status", partnerAuth.ScopeRequired(services.PartnerScopeOrdersWrite), h.UpdateDeliveryStatus)
+		partner.GET("/settlements", partnerAuth.ScopeRequired(services.PartnerScopeSettlementsRead), h.GetSettlement)
+		partner.GET("/performance", partnerAuth.ScopeRequired(services.PartnerScopeOrdersRead), h.GetPerformance)
+		partner.GET("/restaurants", partnerAuth.ScopeRequired(services.PartnerScopeRestaurantsRead), h.ListRestaurants)
+		partner.POST("/restaurants/links/:id/accept", partnerAuth.ScopeRequired(services.PartnerScopeRestaurantsWrite), h.AcceptLink)
+		partner.POST("/restaurants/links/:id/decline", partnerAuth.ScopeRequired(services.PartnerScopeRestaurantsWrite), h.DeclineLink)
+		partner.POST("/api-key/rotate", h.RotateKey)
+	}
+}
+
+// ListKeys godoc
+// @Summary List partner API keys
+// @Description List a delivery partner company's API keys, newest first. Keys themselves are never shown again after they are issued.
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Delivery partner company ID"
+// @Success 200 {array} models.PartnerAPIKey
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/delivery-partners/{id}/api-keys [get]
+func (h *PartnerAPIHandler) ListKeys(c *gin.Context) {
+	keys, err := h.partnerAPIService.ListKeys(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to list API keys", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateKey godoc
+// @Summary Issue a partner API key
+// @Description Issue an API key for the partner API with orders:read, orders:write, settlements:read, restaurants:read and/or restaurants:write scopes and a per-minute rate limit. The key is returned only in this response.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Delivery partner company ID"
+// @Param request body services.PartnerAPIKeyRequest true "API key settings"
+// @Success 201 {object} services.PartnerAPIKeyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/delivery-partners/{id}/api-keys [post]
+func (h *PartnerAPIHandler) CreateKey(c *gin.Context) {
+	var req services.PartnerAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	key, err := h.partnerAPIService.CreateKey(c.Request.Context(), c.Param("id"), middleware.GetUserID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to create API key", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// RevokeKey godoc
+// @Summary Revoke a partner API key
+// @Description Revoke an API key; requests made with it are rejected from then on
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Delivery partner company ID"
+// @Param key_id path string true "API key ID"
+// @Success 200 {object} models.PartnerAPIKey
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/delivery-partners/{id}/api-keys/{key_id} [delete]
+func (h *PartnerAPIHandler) RevokeKey(c *gin.Context) {
+	key, err := h.partnerAPIService.RevokeKey(c.Request.Context(), c.Param("id"), c.Param("key_id"))
+	if err != nil {
+		abortWithError(c, "Failed to revoke API key", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// ListOrders godoc
+// @Summary List assigned orders
+// @Description List the orders booked with your company, newest first. Without a status, open orders (confirmed, preparing, dispatched) are listed. Requires the orders:read scope.
+// @Tags partner
+// @Security ApiKeyAuth
+// @Produce json
+// @Param status query string false "Order status"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} services.PartnerOrdersResponse
+// @Failure 401 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Router /partner/v1/orders [get]
+func (h *PartnerAPIHandler) ListOrders(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	orders, err := h.partnerAPIService.ListOrders(c.Request.Context(), middleware.GetPartnerCompanyID(c), c.Query("status"), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list orders", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetOrder godoc
+// @Summary Get an assigned order
+// @Description Get an order booked with your company, with pickup and delivery addresses. Requires the orders:read scope.
+// @Tags partner
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} services.PartnerOrder
+// @Failure 404 {object} ErrorResponse
+// @Router /partner/v1/orders/{id} [get]
+func (h *PartnerAPIHandler) GetOrder(c *gin.Context) {
+	order, err := h.partnerAPIService.GetOrder(c.Request.Context(), middleware.GetPartnerCompanyID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// UpdateDeliveryStatus godoc
+// @Summary Report delivery status
+// @Description Report picked_up (the order is out for delivery), in_transit (a progress note) or delivered for an order booked with your company. Send the customer's delivery_code with delivered; restaurants that require it refuse delivery without it. Requires the orders:write scope.
+// @Tags partner
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body services.PartnerDeliveryStatusRequest true "Delivery status"
+// @Success 200 {object} services.PartnerOrder
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /partner/v1/orders/{id}/status [post]
+func (h *PartnerAPIHandler) UpdateDeliveryStatus(c *gin.Context) {
+	var req services.PartnerDeliveryStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	order, err := h.partnerAPIService.UpdateDeliveryStatus(c.Request.Context(), middleware.GetPartnerCompanyID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to update delivery status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// GetSettlement godoc
+// @Summary Get settlement data
+// @Description Orders delivered by your company and their delivery fees per day the orders were placed, for a period of up to 92 days (the last 7 days by default). Requires the settlements:read scope.
+// @Tags partner
+// @Security ApiKeyAuth
+// @Produce json
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD"
+// @Success 200 {object} services.PartnerSettlementResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /partner/v1/settlements [get]
+func (h *PartnerAPIHandler) GetSettlement(c *gin.Context) {
+	settlement, err := h.partnerAPIService.GetSettlement(c.Request.Context(), middleware.GetPartnerCompanyID(c), c.Query("from"), c.Query("to"))
+	if err != nil {
+		abortWithError(c, "Failed to get settlement", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settlement)
+}
+
+// GetPerformance godoc
+// @Summary Get delivery performance
+// @Description Outcomes of the orders booked with your company and placed in a period of up to 92 days (the last 7 days by default): delivered, cancelled, average minutes from pickup to delivery and the share delivered by the promised time, with the number of restaurants you currently deliver for. Requires the orders:read scope.
+// @Tags partner
+// @Security ApiKeyAuth
+// @Produce json
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD"
+// @Success 200 {object} services.PartnerPerformanceResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /partner/v1/performance [get]
+func (h *PartnerAPIHandler) GetPerformance(c *gin.Context) {
+	performance, err := h.partnerAPIService.GetPerformance(c.Request.Context(), middleware.GetPartnerCompanyID(c), c.Query("from"), c.Query("to"))
+	if err != nil {
+		abortWithError(c, "Failed to get performance", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, performance)
+}
+
+// ListRestaurants godoc
+// @Summary List linked restaurants
+// @Description List the restaurants linked to your company and those asking to be, with each link's status. Deliveries are only booked through active links. Requires the restaurants:read scope.
+// @Tags partner
+// @Security ApiKeyAuth
+// @Produce json
+// @Param status query string false "Link status: pending, active or declined"
+// @Success 200 {array} services.PartnerRestaurantLink
+// @Failure 400 {object} ErrorResponse
+// @Router /partner/v1/restaurants [get]
+func (h *PartnerAPIHandler) ListRestaurants(c *gin.Context) {
+	links, err := h.partnerAPIService.ListRestaurants(c.Request.Context(), middleware.GetPartnerCompanyID(c), c.Query("status"))
+	if err != nil {
+		abortWithError(c, "Failed to list restaurants", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// AcceptLink godoc
+// @Summary Accept a link request
+// @Description Accept a restaurant's pending request to deliver its orders; the restaurant's deliveries can be booked with your company from then on. Requires the restaurants:write scope.
+// @Tags partner
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Link ID"
+// @Success 200 {object} services.PartnerRestaurantLink
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /partner/v1/restaurants/links/{id}/accept [post]
+func (h *PartnerAPIHandler) AcceptLink(c *gin.Context) {
+	link, err := h.partnerAPIService.RespondToLink(c.Request.Context(), middleware.GetPartnerCompanyID(c), c.Param("id"), true, "")
+	if err != nil {
+		abortWithError(c, "Failed to accept link request", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, link)
+}
+
+// DeclineLink godoc
+// @Summary Decline a link request
+// @Description Decline a restaurant's pending request to deliver its orders, optionally saying why. Requires the restaurants:write scope.
+// @Tags partner
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Link ID"
+// @Param request body services.PartnerLinkDeclineRequest false "Reason"
+// @Success 200 {object} services.PartnerRestaurantLink
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /partner/v1/restaurants/links/{id}/decline [post]
+func (h *PartnerAPIHandler) DeclineLink(c *gin.Context) {
+	var req services.PartnerLinkDeclineRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid request",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	link, err := h.partnerAPIService.RespondToLink(c.Request.Context(), middleware.GetPartnerCompanyID(c), c.Param("id"), false, req.Reason)
+	if err != nil {
+		abortWithError(c, "Failed to decline link request", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, link)
+}
+
+// RotateKey godoc
+// @Summary Rotate your API key
+// @Description Issue a new API key with the same name, scopes and rate limit as the key this request is made with. The new key is returned only in this response; the current key keeps working for the grace period (60 minutes by default, 0 to revoke it at once).
+// @Tags partner
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body services.PartnerKeyRotateRequest false "Grace period"
+// @Success 201 {object} services.PartnerKeyRotationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /partner/v1/api-key/rotate [post]
+func (h *PartnerAPIHandler) RotateKey(c *gin.Context) {
+	var req services.PartnerKeyRotateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid request",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	key, err := h.partnerAPIService.RotateKey(c.Request.Context(), middleware.GetPartnerCompanyID(c), middleware.GetPartnerKeyID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to rotate API key", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+	"golang-food-backend/pkg/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordHandler struct {
+	passwordService *services.PasswordService
+}
+
+func NewPasswordHandler(passwordService *services.PasswordService) *PasswordHandler {
+	return &PasswordHandler{
+		passwordService: passwordService,
+	}
+}
+
+// RegisterRoutes registers the password recovery and change routes
+func (h *PasswordHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	auth := router.Group("/auth")
+	{
+		auth.POST("/forgot-password", h.ForgotPassword)
+		auth.POST("/reset-password", h.ResetPassword)
+		auth.POST("/change-password", authMiddleware.AuthRequired(), h.ChangePassword)
+	}
+}
+
+// ForgotPassword godoc
+// @Summary Start a password reset
+// @Description Send a reset link to the account's email, or an OTP to its phone number. The response is the same whether or not an account matched.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.ForgotPasswordRequest true "Account"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /auth/forgot-password [post]
+func (h *PasswordHandler) ForgotPassword(c *gin.Context) {
+	var req services.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.passwordService.ForgotPassword(c.Request.Context(), &req); err != nil {
+		abortWithError(c, "Failed to start password reset", apperr.Classify(err, apperr.KindValidation))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an account matches, reset instructions have been sent"})
+}
+
+// ResetPassword godoc
+// @Summary Reset a password
+// @Description Set a new password with the token from the reset email, or with the phone number and the OTP sent to it. The account is signed out on every device.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.ResetPasswordRequest true "Reset"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /auth/reset-password [post]
+func (h *PasswordHandler) ResetPassword(c *gin.Context) {
+	var req services.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.passwordService.ResetPassword(c.Request.Context(), &req); err != nil {
+		abortWithError(c, "Failed to reset password", apperr.Classify(err, apperr.KindValidation))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset; please log in again"})
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Replace the password after confirming the current one. Other devices are signed out; this one stays signed in.
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.ChangePasswordRequest true "Passwords"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /auth/change-password [post]
+func (h *PasswordHandler) ChangePassword(c *gin.Context) {
+	var req services.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	err := h.passwordService.ChangePassword(c.Request.Context(), middleware.GetUserID(c), middleware.GetAuthSessionID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to change password", apperr.Classify(err, apperr.KindValidation))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+}
@@ -79,7 +79,7 @@ func (h *PaymentHandler) CreatePayment(c *gin.Context) {
 		TransactionID: req.TransactionID,
 	}
 
-	if err := h.paymentService.CreatePayment(payment); err != nil {
+	if err := h.paymentService.CreatePayment(c.Request.Context(), payment); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to create payment",
 			Message: err.Error(),
@@ -112,7 +112,7 @@ func (h *PaymentHandler) GetPaymentByID(c *gin.Context) {
 		return
 	}
 
-	payment, err := h.paymentService.GetPaymentByID(id)
+	payment, err := h.paymentService.GetPaymentByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Error:   "Payment not found",
@@ -146,7 +146,7 @@ func (h *PaymentHandler) GetPaymentByOrderID(c *gin.Context) {
 		return
 	}
 
-	payment, err := h.paymentService.GetPaymentByOrderID(orderID)
+	payment, err := h.paymentService.GetPaymentByOrderID(c.Request.Context(), orderID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Error:   "Payment not found",
@@ -190,7 +190,7 @@ func (h *PaymentHandler) UpdatePaymentStatus(c *gin.Context) {
 		return
 	}
 
-	payment, err := h.paymentService.UpdatePaymentStatus(id, req.Status)
+	payment, err := h.paymentService.UpdatePaymentStatus(c.Request.Context(), id, req.Status)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to update payment status",
@@ -235,7 +235,7 @@ func (h *PaymentHandler) GetPaymentsByStatus(c *gin.Context) {
 		limit = 10
 	}
 
-	payments, total, err := h.paymentService.GetPaymentsByStatus(status, page, limit)
+	payments, total, err := h.paymentService.GetPaymentsByStatus(c.Request.Context(), status, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to fetch payments",
@@ -276,7 +276,7 @@ func (h *PaymentHandler) ProcessWebhook(c *gin.Context) {
 		return
 	}
 
-	if err := h.paymentService.ProcessWebhook(&req); err != nil {
+	if err := h.paymentService.ProcessWebhook(c.Request.Context(), &req); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to process webhook",
 			Message: err.Error(),
@@ -292,10 +292,10 @@ func (h *PaymentHandler) ProcessWebhook(c *gin.Context) {
 
 // Request/Response models
 type CreatePaymentRequest struct {
-	OrderID       string  `json:"order_id" binding:"required"`
-	Amount        float64 `json:"amount" binding:"required"`
-	Method        string  `json:"method" binding:"required"`
-	TransactionID string  `json:"transaction_id" binding:"required"`
+	OrderID       string       `json:"order_id" binding:"required"`
+	Amount        models.Money `json:"amount" binding:"required"`
+	Method        string       `json:"method" binding:"required"`
+	TransactionID string       `json:"transaction_id" binding:"required"`
 }
 
 type UpdatePaymentStatusRequest struct {
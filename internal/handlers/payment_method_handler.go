@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentMethodHandler struct {
+	paymentMethodService *services.PaymentMethodService
+}
+
+func NewPaymentMethodHandler(paymentMethodService *services.PaymentMethodService) *PaymentMethodHandler {
+	return &PaymentMethodHandler{
+		paymentMethodService: paymentMethodService,
+	}
+}
+
+// RegisterRoutes registers the customer's saved payment method routes
+func (h *PaymentMethodHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	methods := router.Group("/users/me/payment-methods", authMiddleware.AuthRequired())
+	{
+		methods.GET("", h.ListMethods)
+		methods.POST("/:id/default", h.SetDefault)
+		methods.DELETE("/:id", h.DeleteMethod)
+	}
+}
+
+// ListMethods godoc
+// @Summary List saved payment methods
+// @Description Your saved cards and UPI IDs, the default first. Only Razorpay's tokens are stored; cards show their network and last four digits and UPI IDs are masked.
+// @Tags payments
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.SavedPaymentMethod
+// @Router /users/me/payment-methods [get]
+func (h *PaymentMethodHandler) ListMethods(c *gin.Context) {
+	methods, err := h.paymentMethodService.ListMethods(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		abortWithError(c, "Failed to list saved payment methods", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, methods)
+}
+
+// SetDefault godoc
+// @Summary Set the default payment method
+// @Description Make a saved card or UPI ID the one checkout offers first
+// @Tags payments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Saved payment method ID"
+// @Success 200 {object} models.SavedPaymentMethod
+// @Failure 404 {object} ErrorResponse
+// @Router /users/me/payment-methods/{id}/default [post]
+func (h *PaymentMethodHandler) SetDefault(c *gin.Context) {
+	method, err := h.paymentMethodService.SetDefault(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to set default payment method", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, method)
+}
+
+// DeleteMethod godoc
+// @Summary Delete a saved payment method
+// @Description Forget a saved card or UPI ID, deleting its token at Razorpay. If it was the default, the most recently used method becomes the default.
+// @Tags payments
+// @Security BearerAuth
+// @Param id path string true "Saved payment method ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} ErrorResponse
+// @Router /users/me/payment-methods/{id} [delete]
+func (h *PaymentMethodHandler) DeleteMethod(c *gin.Context) {
+	if err := h.paymentMethodService.DeleteMethod(c.Request.Context(), middleware.GetUserID(c), c.Param("id")); err != nil {
+		abortWithError(c, "Failed to delete saved payment method", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
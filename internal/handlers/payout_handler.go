@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+	"golang-food-backend/pkg/spreadsheet"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayoutHandler struct {
+	payoutService *services.PayoutService
+}
+
+func NewPayoutHandler(payoutService *services.PayoutService) *PayoutHandler {
+	return &PayoutHandler{
+		payoutService: payoutService,
+	}
+}
+
+type GenerateBatchRequest struct {
+	WeekStart string `json:"week_start"` // Monday, YYYY-MM-DD; defaults to last week
+}
+
+// RegisterRoutes registers the owner payout routes and the admin settlement routes
+func (h *PayoutHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	owner := router.Group("/restaurants/:id/payouts", authMiddleware.AuthRequired())
+	{
+		owner.GET("", h.ListRestaurantPayouts)
+		owner.GET("/:payout_id", h.GetRestaurantPayout)
+		owner.GET("/:payout_id/statement", h.ExportStatement)
+	}
+
+	admin := router.Group("/admin/payouts",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionRestaurants),
+	)
+	{
+		admin.GET("", h.ListBatches)
+		admin.POST("", middleware.Timeout(longRequestTimeout), h.GenerateBatch)
+		admin.GET("/batches/:id", h.GetBatch)
+		admin.GET("/batches/:id/export", middleware.Timeout(longRequestTimeout), h.ExportBatch)
+		admin.PATCH("/:id/status", h.UpdatePayoutStatus)
+	}
+}
+
+// ListRestaurantPayouts godoc
+// @Summary List restaurant payouts
+// @Description List weekly payouts for a restaurant you own, newest first
+// @Tags payouts
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} services.PayoutListResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurants/{id}/payouts [get]
+func (h *PayoutHandler) ListRestaurantPayouts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	payouts, err := h.payoutService.ListRestaurantPayouts(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list payouts", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payouts)
+}
+
+// GetRestaurantPayout godoc
+// @Summary Get a restaurant payout
+// @Description Get a payout for a restaurant you own with its per-order earnings and refund deductions
+// @Tags payouts
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param payout_id path string true "Payout ID"
+// @Success 200 {object} services.PayoutDetailResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurants/{id}/payouts/{payout_id} [get]
+func (h *PayoutHandler) GetRestaurantPayout(c *gin.Context) {
+	payout, err := h.payoutService.GetRestaurantPayout(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), c.Param("payout_id"))
+	if err != nil {
+		abortWithError(c, "Failed to get payout", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payout)
+}
+
+// ExportStatement godoc
+// @Summary Download a settlement statement
+// @Description Download a CSV statement for a payout, with a line per order or refund and the payout totals
+// @Tags payouts
+// @Security BearerAuth
+// @Produce text/csv
+// @Param id path string true "Restaurant ID"
+// @Param payout_id path string true "Payout ID"
+// @Success 200 {file} file
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurants/{id}/payouts/{payout_id}/statement [get]
+func (h *PayoutHandler) ExportStatement(c *gin.Context) {
+	data, err := h.payoutService.ExportStatement(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), c.Param("payout_id"))
+	if err != nil {
+		abortWithError(c, "Failed to export statement", err)
+		return
+	}
+
+	fileName := fmt.Sprintf("statement-%s.%s", c.Param("payout_id"), spreadsheet.FormatCSV)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Data(http.StatusOK, spreadsheet.ContentType(spreadsheet.FormatCSV), data)
+}
+
+// ListBatches godoc
+// @Summary List payout batches
+// @Description List weekly payout batches, newest week first
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} services.PayoutBatchListResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/payouts [get]
+func (h *PayoutHandler) ListBatches(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	batches, err := h.payoutService.ListBatches(c.Request.Context(), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to list payout batches",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, batches)
+}
+
+// GenerateBatch godoc
+// @Summary Generate a payout batch
+// @Description Settle delivered orders and processed refunds for a finished week. Batches are generated automatically every Monday; generating a week that already has a batch returns it.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body GenerateBatchRequest false "Week to settle"
+// @Success 200 {object} models.PayoutBatch
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/payouts [post]
+func (h *PayoutHandler) GenerateBatch(c *gin.Context) {
+	var req GenerateBatchRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid request",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	batch, err := h.payoutService.GenerateBatch(c.Request.Context(), req.WeekStart)
+	if err != nil {
+		abortWithError(c, "Failed to generate payout batch", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
+
+// GetBatch godoc
+// @Summary Get a payout batch
+// @Description Get a payout batch with every restaurant's payout
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Batch ID"
+// @Success 200 {object} services.PayoutBatchResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/payouts/batches/{id} [get]
+func (h *PayoutHandler) GetBatch(c *gin.Context) {
+	batch, err := h.payoutService.GetBatch(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get payout batch", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
+
+// ExportBatch godoc
+// @Summary Download a bank transfer sheet
+// @Description Download the batch's pending payouts as CSV with each restaurant's verified bank account
+// @Tags admin
+// @Security BearerAuth
+// @Produce text/csv
+// @Param id path string true "Batch ID"
+// @Success 200 {file} file
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/payouts/batches/{id}/export [get]
+func (h *PayoutHandler) ExportBatch(c *gin.Context) {
+	data, err := h.payoutService.ExportBatch(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to export payout batch", err)
+		return
+	}
+
+	fileName := fmt.Sprintf("payouts-%s.%s", c.Param("id"), spreadsheet.FormatCSV)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Data(http.StatusOK, spreadsheet.ContentType(spreadsheet.FormatCSV), data)
+}
+
+// UpdatePayoutStatus godoc
+// @Summary Update a payout's status
+// @Description Mark a payout paid with its bank reference, failed, on hold, or release it back to pending
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Payout ID"
+// @Param request body services.UpdatePayoutStatusRequest true "New status"
+// @Success 200 {object} models.RestaurantPayout
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/payouts/{id}/status [patch]
+func (h *PayoutHandler) UpdatePayoutStatus(c *gin.Context) {
+	var req services.UpdatePayoutStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	payout, err := h.payoutService.UpdatePayoutStatus(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to update payout", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payout)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PIIHandler struct {
+	piiService *services.PIIService
+}
+
+func NewPIIHandler(piiService *services.PIIService) *PIIHandler {
+	return &PIIHandler{
+		piiService: piiService,
+	}
+}
+
+// RegisterRoutes registers the admin routes for field encryption status and re-encryption
+func (h *PIIHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := router.Group("/admin/pii",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionMaintenance),
+	)
+	{
+		admin.GET("/status", h.GetStatus)
+		admin.POST("/reencrypt", h.Reencrypt)
+	}
+}
+
+// GetStatus godoc
+// @Summary Field encryption status
+// @Description Whether personal data is encrypted at rest, and how many users and addresses are still in plaintext or under a rotated-out key (admin with maintenance.manage)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} services.PIIStatus
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/pii/status [get]
+func (h *PIIHandler) GetStatus(c *gin.Context) {
+	status, err := h.piiService.Status(c.Request.Context())
+	if err != nil {
+		abortWithError(c, "Failed to get encryption status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Reencrypt godoc
+// @Summary Re-encrypt personal data
+// @Description Queue a job that rewrites every user and address not yet under the active key: plaintext from before encryption was turned on, and values under rotated-out keys. Follow it on /admin/jobs/{id} (admin with maintenance.manage).
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 202 {object} jobs.Job
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/pii/reencrypt [post]
+func (h *PIIHandler) Reencrypt(c *gin.Context) {
+	job, err := h.piiService.StartReencryption(c.Request.Context())
+	if err != nil {
+		abortWithError(c, "Failed to start re-encryption", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
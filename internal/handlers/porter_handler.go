@@ -1,11 +1,11 @@
 package handlers
 
 import (
-	"context"
+	"golang-food-backend/internal/middleware"
 	"golang-food-backend/internal/repositories"
 	"golang-food-backend/internal/services"
+	"log"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -101,6 +101,11 @@ func (h *PorterHandler) TrackOrder(c *gin.Context) {
 		return
 	}
 
+	if !h.ownsPorterOrder(c, orderID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Porter order not found"})
+		return
+	}
+
 	trackingInfo, err := h.porterService.TrackOrder(c.Request.Context(), orderID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to track order: " + err.Error()})
@@ -118,6 +123,11 @@ func (h *PorterHandler) CancelOrder(c *gin.Context) {
 		return
 	}
 
+	if !h.ownsPorterOrder(c, orderID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Porter order not found"})
+		return
+	}
+
 	result, err := h.porterService.CancelOrder(c.Request.Context(), orderID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel order: " + err.Error()})
@@ -127,6 +137,19 @@ func (h *PorterHandler) CancelOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// ownsPorterOrder reports whether the Porter order delivers a food order of the restaurant the
+// request is scoped to. Porter orders not linked to a food order belong to no restaurant.
+func (h *PorterHandler) ownsPorterOrder(c *gin.Context, porterOrderID string) bool {
+	delivery, err := h.porterDeliveryRepo.GetByPorterOrderID(c.Request.Context(), porterOrderID)
+	if err != nil {
+		return false
+	}
+
+	// The order lookup is tenant-scoped, so another restaurant's order is not found
+	_, err = h.orderRepo.GetByID(c.Request.Context(), delivery.OrderID)
+	return err == nil
+}
+
 // Webhook handles Porter webhook notifications
 func (h *PorterHandler) Webhook(c *gin.Context) {
 	var payload services.PorterWebhookPayload
@@ -182,20 +205,10 @@ func (h *PorterHandler) Webhook(c *gin.Context) {
 		// Check if this was a voluntary cancellation by Porter (not customer-initiated)
 		// We can identify this by checking if the order status was not already "cancelled"
 		if originalOrderStatus != "cancelled" {
-			// Porter voluntarily cancelled - attempt reassignment
-			go func() {
-				// Create a background context with timeout for reassignment
-				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-				defer cancel()
-
-				// Run reassignment in background to avoid blocking webhook response
-				reassignErr := h.deliveryPartnerService.ReassignDeliveryPartner(ctx, order.ID, "porter")
-				if reassignErr != nil {
-					// Log the error - in production, you'd use a proper logger
-					// logger.Error("Failed to reassign Porter delivery after voluntary cancellation",
-					//   "order_id", order.ID, "porter_order_id", payload.OrderID, "error", reassignErr)
-				}
-			}()
+			// Porter voluntarily cancelled - queue reassignment so the webhook isn't blocked
+			if err := h.deliveryPartnerService.QueueReassignment(c.Request.Context(), order.ID, "porter"); err != nil {
+				log.Printf("Failed to queue reassignment for order %s after Porter cancellation: %v", order.ID, err)
+			}
 		}
 	}
 
@@ -290,9 +303,58 @@ func (h *PorterHandler) ReassignPorterDelivery(c *gin.Context) {
 	})
 }
 
+// ListDeliveriesNeedingAttention lists Porter deliveries flagged by reconciliation, e.g. no rider assigned
+func (h *PorterHandler) ListDeliveriesNeedingAttention(c *gin.Context) {
+	deliveries, err := h.porterService.ListNeedingAttention(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list deliveries: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// ResolveDeliveryAttention clears the ops flag on a Porter delivery
+func (h *PorterHandler) ResolveDeliveryAttention(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID format"})
+		return
+	}
+
+	delivery, err := h.porterService.ResolveAttention(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// ReconcileDeliveries polls Porter for stale deliveries now instead of waiting for the next cron run
+func (h *PorterHandler) ReconcileDeliveries(c *gin.Context) {
+	synced, flagged, err := h.porterService.ReconcileDeliveries(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile deliveries: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"synced":  synced,
+		"flagged": flagged,
+	})
+}
+
 // RegisterRoutes registers all Porter-related routes
-func (h *PorterHandler) RegisterRoutes(router *gin.RouterGroup) {
-	porter := router.Group("/porter")
+func (h *PorterHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	// Porter calls the webhook without a user token
+	router.POST("/porter/webhook", h.Webhook)
+
+	porter := router.Group("/porter",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantRequired(),
+		authMiddleware.RestaurantStaffRequired(),
+	)
 	{
 		// Quote management
 		porter.POST("/quote", h.GetQuote)
@@ -302,15 +364,23 @@ func (h *PorterHandler) RegisterRoutes(router *gin.RouterGroup) {
 		porter.GET("/orders/:order_id", h.TrackOrder)
 		porter.POST("/orders/:order_id/cancel", h.CancelOrder)
 
-		// Webhook
-		porter.POST("/webhook", h.Webhook)
-
 		// Helper endpoints
 		porter.POST("/create-delivery/:order_id", h.CreateDeliveryOrderForFoodOrder)
 
 		// Reassignment endpoint
 		porter.POST("/reassign/:order_id", h.ReassignPorterDelivery)
 	}
+
+	// Reconciliation for missed webhooks (admin only)
+	admin := router.Group("/admin/porter",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionDelivery),
+	)
+	{
+		admin.GET("/deliveries/attention", h.ListDeliveriesNeedingAttention)
+		admin.POST("/deliveries/:id/resolve", h.ResolveDeliveryAttention)
+		admin.POST("/reconcile", h.ReconcileDeliveries)
+	}
 }
 
 // Sample request/response structures for documentation
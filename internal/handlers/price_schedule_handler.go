@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PriceScheduleHandler struct {
+	priceScheduleService *services.PriceScheduleService
+}
+
+func NewPriceScheduleHandler(priceScheduleService *services.PriceScheduleService) *PriceScheduleHandler {
+	return &PriceScheduleHandler{
+		priceScheduleService: priceScheduleService,
+	}
+}
+
+// RegisterRoutes registers the restaurant price history and scheduled price change routes
+func (h *PriceScheduleHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	restaurant := router.Group("/restaurant/prices",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantRequired(),
+		authMiddleware.RestaurantStaffRequired(),
+		authMiddleware.RestaurantPermissionRequired(services.StaffPermissionMenuEdit),
+	)
+	{
+		restaurant.GET("/products/:id/history", h.GetPriceHistory)
+		restaurant.GET("/schedules", h.ListSchedules)
+		restaurant.POST("/schedules", h.SchedulePriceChange)
+		restaurant.POST("/schedules/:id/cancel", h.CancelSchedule)
+	}
+}
+
+// GetPriceHistory godoc
+// @Summary Get a product's price history
+// @Description Every change to a product's prices, newest first, with the prices before and after, who made it and its source: manual, scheduled, brand_sync or outlet_override
+// @Tags restaurant
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} services.PriceHistoryListResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurant/prices/products/{id}/history [get]
+func (h *PriceScheduleHandler) GetPriceHistory(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	history, err := h.priceScheduleService.GetPriceHistory(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to get price history", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// ListSchedules godoc
+// @Summary List scheduled price changes
+// @Description The restaurant's scheduled price changes by start time, with the prices they replaced once applied
+// @Tags restaurant
+// @Security BearerAuth
+// @Produce json
+// @Param product_id query string false "Only changes to this product"
+// @Param status query string false "Only changes in this status (scheduled, applied, reverted, superseded, cancelled, failed)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} services.PriceScheduleListResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurant/prices/schedules [get]
+func (h *PriceScheduleHandler) ListSchedules(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	schedules, err := h.priceScheduleService.ListSchedules(c.Request.Context(), middleware.GetRestaurantID(c), c.Query("product_id"), c.Query("status"), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list scheduled price changes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// SchedulePriceChange godoc
+// @Summary Schedule a price change
+// @Description Schedule a product's prices to change at a future time, such as for weekend pricing. With an end time the prices in effect before come back then, unless they were changed by hand meanwhile. Customers see the earlier price struck through while the new one is lower.
+// @Tags restaurant
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.SchedulePriceRequest true "Price change"
+// @Success 201 {object} models.ScheduledPriceChange
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /restaurant/prices/schedules [post]
+func (h *PriceScheduleHandler) SchedulePriceChange(c *gin.Context) {
+	var req services.SchedulePriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	change, err := h.priceScheduleService.SchedulePriceChange(c.Request.Context(), middleware.GetRestaurantID(c), middleware.GetUserID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to schedule price change", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, change)
+}
+
+// CancelSchedule godoc
+// @Summary Cancel a scheduled price change
+// @Description Cancel a price change that has not started, or end one in effect now, putting the earlier prices back
+// @Tags restaurant
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Scheduled price change ID"
+// @Success 200 {object} models.ScheduledPriceChange
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /restaurant/prices/schedules/{id}/cancel [post]
+func (h *PriceScheduleHandler) CancelSchedule(c *gin.Context) {
+	change, err := h.priceScheduleService.CancelSchedule(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to cancel scheduled price change", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PricingHandler struct {
+	pricingService *services.PricingService
+}
+
+func NewPricingHandler(pricingService *services.PricingService) *PricingHandler {
+	return &PricingHandler{
+		pricingService: pricingService,
+	}
+}
+
+// RegisterRoutes registers the admin pricing rule routes
+func (h *PricingHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := router.Group("/admin/pricing",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionPricing),
+	)
+	{
+		admin.GET("/rules", h.ListRules)
+		admin.POST("/rules", h.CreateRule)
+		admin.PUT("/rules/:id", h.UpdateRule)
+		admin.DELETE("/rules/:id", h.DeleteRule)
+		admin.PUT("/rain", h.SetRainMode)
+		admin.DELETE("/rain", h.ClearRainMode)
+	}
+}
+
+// ListRules godoc
+// @Summary List pricing rules
+// @Description List fee and surge rules, optionally for one restaurant or of one type
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param restaurant_id query string false "Restaurant ID"
+// @Param rule_type query string false "platform_fee, small_order_fee, delivery_surge or late_night_fee"
+// @Success 200 {array} models.PricingRule
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/pricing/rules [get]
+func (h *PricingHandler) ListRules(c *gin.Context) {
+	rules, err := h.pricingService.ListRules(c.Request.Context(), c.Query("restaurant_id"), c.Query("rule_type"))
+	if err != nil {
+		abortWithError(c, "Failed to list pricing rules", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateRule godoc
+// @Summary Create a pricing rule
+// @Description Add a platform, small-order or late-night fee or a delivery surge, platform-wide or for a restaurant, with optional validity dates, days and daily window
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.PricingRuleRequest true "Pricing rule"
+// @Success 201 {object} models.PricingRule
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/pricing/rules [post]
+func (h *PricingHandler) CreateRule(c *gin.Context) {
+	var req services.PricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	rule, err := h.pricingService.CreateRule(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to create pricing rule", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule godoc
+// @Summary Update a pricing rule
+// @Description Replace a pricing rule's settings
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Param request body services.PricingRuleRequest true "Pricing rule"
+// @Success 200 {object} models.PricingRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/pricing/rules/{id} [put]
+func (h *PricingHandler) UpdateRule(c *gin.Context) {
+	var req services.PricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	rule, err := h.pricingService.UpdateRule(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to update pricing rule", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule godoc
+// @Summary Delete a pricing rule
+// @Description Delete a pricing rule; orders already placed keep the charges they were priced with
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/pricing/rules/{id} [delete]
+func (h *PricingHandler) DeleteRule(c *gin.Context) {
+	if err := h.pricingService.DeleteRule(c.Request.Context(), c.Param("id")); err != nil {
+		abortWithError(c, "Failed to delete pricing rule", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SetRainMode godoc
+// @Summary Turn on rain mode
+// @Description Apply rain delivery surges to a restaurant, or platform-wide when restaurant_id is empty, for a while
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.RainModeRequest true "Rain mode settings"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/pricing/rain [put]
+func (h *PricingHandler) SetRainMode(c *gin.Context) {
+	var req services.RainModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.pricingService.SetRainMode(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to set rain mode",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rain mode on"})
+}
+
+// ClearRainMode godoc
+// @Summary Turn off rain mode
+// @Description Turn off rain mode for a restaurant, or platform-wide when restaurant_id is omitted
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param restaurant_id query string false "Restaurant ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/pricing/rain [delete]
+func (h *PricingHandler) ClearRainMode(c *gin.Context) {
+	if err := h.pricingService.ClearRainMode(c.Request.Context(), c.Query("restaurant_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to clear rain mode",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rain mode off"})
+}
@@ -10,14 +10,18 @@ import (
 )
 
 type ProductHandler struct {
-	productService  ProductServiceInterface
-	categoryService CategoryServiceInterface
+	productService           ProductServiceInterface
+	categoryService          CategoryServiceInterface
+	activityTracker          *services.ActivityTracker
+	dietaryPreferenceService *services.DietaryPreferenceService
 }
 
-func NewProductHandler(productService ProductServiceInterface, categoryService CategoryServiceInterface) *ProductHandler {
+func NewProductHandler(productService ProductServiceInterface, categoryService CategoryServiceInterface, activityTracker *services.ActivityTracker, dietaryPreferenceService *services.DietaryPreferenceService) *ProductHandler {
 	return &ProductHandler{
-		productService:  productService,
-		categoryService: categoryService,
+		productService:           productService,
+		categoryService:          categoryService,
+		activityTracker:          activityTracker,
+		dietaryPreferenceService: dietaryPreferenceService,
 	}
 }
 
@@ -62,6 +66,10 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 // @Param id path string true "Restaurant ID"
 // @Param page query int false "Page number (default: 1)"
 // @Param limit query int false "Items per page (default: 10)"
+// @Param diet query string false "Only dishes of this diet: veg (which includes vegan), vegan or non_veg"
+// @Param exclude_allergens query string false "Comma separated allergens the dishes must not contain, e.g. peanuts,dairy"
+// @Param max_spice query int false "Hottest spice level to show, 0 to 3"
+// @Param preferences query string false "off to ignore the signed-in user's dietary preferences"
 // @Success 200 {object} PaginatedProductsResponse
 // @Failure 400 {object} ErrorResponse
 // @Router /api/v1/restaurants/{id}/products [get]
@@ -70,8 +78,12 @@ func (h *ProductHandler) GetProductsByRestaurant(c *gin.Context) {
 
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	diet, ok := menuDietaryFilter(c, h.dietaryPreferenceService)
+	if !ok {
+		return
+	}
 
-	products, err := h.productService.GetProductsByRestaurant(c.Request.Context(), restaurantID, limit, offset)
+	products, err := h.productService.GetProductsByRestaurant(c.Request.Context(), restaurantID, diet, limit, offset)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -89,6 +101,10 @@ func (h *ProductHandler) GetProductsByRestaurant(c *gin.Context) {
 // @Param category query string false "Category filter"
 // @Param page query int false "Page number (default: 1)"
 // @Param limit query int false "Items per page (default: 10)"
+// @Param diet query string false "Only dishes of this diet: veg (which includes vegan), vegan or non_veg"
+// @Param exclude_allergens query string false "Comma separated allergens the dishes must not contain, e.g. peanuts,dairy"
+// @Param max_spice query int false "Hottest spice level to show, 0 to 3"
+// @Param preferences query string false "off to ignore the signed-in user's dietary preferences"
 // @Success 200 {object} PaginatedProductsResponse
 // @Failure 400 {object} ErrorResponse
 // @Router /api/v1/restaurants/{id}/products/search [get]
@@ -103,13 +119,19 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	diet, ok := menuDietaryFilter(c, h.dietaryPreferenceService)
+	if !ok {
+		return
+	}
 
-	products, err := h.productService.SearchProducts(c.Request.Context(), restaurantID, query, limit, offset)
+	products, err := h.productService.SearchProducts(c.Request.Context(), restaurantID, query, diet, limit, offset)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	h.activityTracker.TrackSearch(activityContextFrom(c), restaurantID, query, len(products))
+
 	c.JSON(http.StatusOK, products)
 }
 
@@ -145,6 +167,10 @@ func (h *ProductHandler) GetProductByID(c *gin.Context) {
 		return
 	}
 
+	h.activityTracker.TrackActivity(activityContextFrom(c), services.ActivityViewProduct, product.RestaurantID, productID, map[string]interface{}{
+		"category_id": product.CategoryID.Hex(),
+	})
+
 	c.JSON(http.StatusOK, product)
 }
 
@@ -157,6 +183,10 @@ func (h *ProductHandler) GetProductByID(c *gin.Context) {
 // @Param available_only query bool false "Show only available products (default: false)"
 // @Param page query int false "Page number (default: 1)"
 // @Param limit query int false "Items per page (default: 20, max: 100)"
+// @Param diet query string false "Only dishes of this diet: veg (which includes vegan), vegan or non_veg"
+// @Param exclude_allergens query string false "Comma separated allergens the dishes must not contain, e.g. peanuts,dairy"
+// @Param max_spice query int false "Hottest spice level to show, 0 to 3"
+// @Param preferences query string false "off to ignore the signed-in user's dietary preferences"
 // @Success 200 {object} services.GetProductsResponse
 // @Failure 400 {object} map[string]string
 // @Router /api/v1/restaurants/{restaurant_id}/products/filtered [get]
@@ -168,6 +198,10 @@ func (h *ProductHandler) GetProductsByRestaurantCategoryAndTime(c *gin.Context)
 	availableOnly, _ := strconv.ParseBool(c.DefaultQuery("available_only", "false"))
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	diet, ok := menuDietaryFilter(c, h.dietaryPreferenceService)
+	if !ok {
+		return
+	}
 
 	// Build request
 	req := &services.GetProductsRequest{
@@ -176,6 +210,7 @@ func (h *ProductHandler) GetProductsByRestaurantCategoryAndTime(c *gin.Context)
 		AvailableOnly: availableOnly,
 		Page:          page,
 		Limit:         limit,
+		Dietary:       diet,
 	}
 
 	// Get products from service
@@ -220,7 +255,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	if err := h.productService.UpdateProduct(c.Request.Context(), productID, restaurantID, updates); err != nil {
+	if err := h.productService.UpdateProduct(c.Request.Context(), productID, restaurantID, middleware.GetUserID(c), updates); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -256,6 +291,89 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
 
+// GetCombo godoc
+// @Summary Get a combo
+// @Description A combo product with its items, what they cost on their own and what the combo saves. Combos are available while all their items are and have the stock for one combo.
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} services.ComboResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/products/{id}/combo [get]
+func (h *ProductHandler) GetCombo(c *gin.Context) {
+	combo, err := h.productService.GetCombo(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get combo", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, combo)
+}
+
+// SetComboComponents godoc
+// @Summary Set a combo's items
+// @Description Make a product a combo of other menu items of the restaurant, or change a combo's items. The combo sells at the product's price as one unit; orders take its items from stock and list them on the kitchen ticket.
+// @Tags products
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param request body services.SetComboComponentsRequest true "Combo items"
+// @Success 200 {object} services.ComboResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/products/{id}/components [put]
+func (h *ProductHandler) SetComboComponents(c *gin.Context) {
+	var req services.SetComboComponentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	combo, err := h.productService.SetComboComponents(c.Request.Context(), c.Param("id"), middleware.GetRestaurantID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to set combo items", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, combo)
+}
+
+// SetOptionGroups godoc
+// @Summary Set a product's option groups
+// @Description Replace a product's option groups, such as "choose exactly 1 base" or "up to 3 toppings". Each group has min_select and max_select (all its options by default) and options with a price_delta. Groups and options sent with their ID keep it. The response adds rendering hints to each group: display (radio, checkbox or dropdown), required and selection_hint.
+// @Tags products
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param request body services.SetOptionGroupsRequest true "Option groups"
+// @Success 200 {object} models.Product
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/products/{id}/option-groups [put]
+func (h *ProductHandler) SetOptionGroups(c *gin.Context) {
+	var req services.SetOptionGroupsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	product, err := h.productService.SetOptionGroups(c.Request.Context(), c.Param("id"), middleware.GetRestaurantID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to set option groups", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
 // Category handlers
 
 // @Summary Create a new category
@@ -442,23 +560,26 @@ func (h *ProductHandler) MoveProductsAndDeleteCategory(c *gin.Context) {
 
 func (h *ProductHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
 	// Public routes
-	router.GET("/restaurants/:id/products", h.GetProductsByRestaurant)
-	router.GET("/restaurants/:id/products/filtered", h.GetProductsByRestaurantCategoryAndTime)
-	router.GET("/restaurants/:id/products/search", h.SearchProducts)
+	router.GET("/restaurants/:id/products", authMiddleware.OptionalAuth(), h.GetProductsByRestaurant)
+	router.GET("/restaurants/:id/products/filtered", authMiddleware.OptionalAuth(), h.GetProductsByRestaurantCategoryAndTime)
+	router.GET("/restaurants/:id/products/search", authMiddleware.OptionalAuth(), h.SearchProducts)
 	router.GET("/restaurants/:id/categories", h.GetCategoriesByRestaurant)
-	router.GET("/products/:id", h.GetProductByID)
+	router.GET("/products/:id", authMiddleware.OptionalAuth(), h.GetProductByID)
+	router.GET("/products/:id/combo", h.GetCombo)
 
 	// Protected routes (restaurant staff/owner only)
 	protected := router.Group("/", authMiddleware.AuthRequired(), authMiddleware.RestaurantRequired())
 	{
 		// Product management
-		protected.POST("/products", authMiddleware.RestaurantStaffRequired(), h.CreateProduct)
-		protected.PUT("/products/:id", authMiddleware.RestaurantStaffRequired(), h.UpdateProduct)
+		protected.POST("/products", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionMenuEdit), h.CreateProduct)
+		protected.PUT("/products/:id", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionMenuEdit), h.UpdateProduct)
 		protected.DELETE("/products/:id", authMiddleware.RestaurantOwnerRequired(), h.DeleteProduct)
+		protected.PUT("/products/:id/components", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionMenuEdit), h.SetComboComponents)
+		protected.PUT("/products/:id/option-groups", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionMenuEdit), h.SetOptionGroups)
 
 		// Category management
-		protected.POST("/categories", authMiddleware.RestaurantStaffRequired(), h.CreateCategory)
-		protected.PUT("/categories/:id", authMiddleware.RestaurantStaffRequired(), h.UpdateCategory)
+		protected.POST("/categories", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionMenuEdit), h.CreateCategory)
+		protected.PUT("/categories/:id", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionMenuEdit), h.UpdateCategory)
 		protected.DELETE("/categories/:id", authMiddleware.RestaurantOwnerRequired(), h.DeleteCategory)
 		protected.DELETE("/categories/:id/with-products", authMiddleware.RestaurantOwnerRequired(), h.DeleteCategoryWithProducts)
 		protected.DELETE("/categories/:id/move-products", authMiddleware.RestaurantOwnerRequired(), h.MoveProductsAndDeleteCategory)
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+	"golang-food-backend/pkg/spreadsheet"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxProductImportFileSize = 5 << 20 // 5 MB
+
+type ProductImportHandler struct {
+	importService *services.ProductImportService
+}
+
+func NewProductImportHandler(importService *services.ProductImportService) *ProductImportHandler {
+	return &ProductImportHandler{
+		importService: importService,
+	}
+}
+
+// RegisterRoutes registers bulk product import and export routes
+func (h *ProductImportHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	imports := router.Group("/restaurants/:id/products/import",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantStaffRequired(),
+		authMiddleware.RestaurantPermissionRequired(services.StaffPermissionMenuEdit),
+	)
+	{
+		imports.POST("", middleware.Timeout(longRequestTimeout), h.ImportProducts)
+		imports.GET("/:job_id", h.GetImportJob)
+	}
+
+	router.GET("/products/export",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantRequired(),
+		authMiddleware.RestaurantStaffRequired(),
+		authMiddleware.RestaurantPermissionRequired(services.StaffPermissionMenuEdit),
+		middleware.Timeout(longRequestTimeout),
+		h.ExportProducts,
+	)
+}
+
+// ownsRestaurant checks the restaurant in the path against the caller's token, writing a 403 if it differs
+func (h *ProductImportHandler) ownsRestaurant(c *gin.Context) bool {
+	if middleware.GetUserRole(c) == "admin" || middleware.GetRestaurantID(c) == c.Param("id") {
+		return true
+	}
+	c.JSON(http.StatusForbidden, ErrorResponse{
+		Error:   "Access denied",
+		Message: "You can only manage products of your own restaurant",
+	})
+	return false
+}
+
+// ImportProducts godoc
+// @Summary Bulk import products
+// @Description Upload a CSV or XLSX file of products. Rows are validated immediately and valid rows are created in the background; missing categories are created and inventory is seeded. Columns: name, category, price (required), description, discount_price, preparation_time, tags, image_urls (semicolon separated), video_url, is_available, initial_stock, min_stock_level.
+// @Tags products
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param file formData file true "CSV or XLSX file (max 5 MB)"
+// @Success 202 {object} services.ProductImportJob
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /restaurants/{id}/products/import [post]
+func (h *ProductImportHandler) ImportProducts(c *gin.Context) {
+	if !h.ownsRestaurant(c) {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "File is required",
+			Message: err.Error(),
+		})
+		return
+	}
+	if fileHeader.Size > maxProductImportFileSize {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "File too large",
+			Message: "Import files must be 5 MB or smaller",
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to read file",
+			Message: err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to read file",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	job, err := h.importService.StartImport(c.Request.Context(), c.Param("id"), fileHeader.Filename, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Import failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetImportJob godoc
+// @Summary Get product import progress
+// @Description Poll the progress and row-level errors of a bulk product import
+// @Tags products
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param job_id path string true "Import job ID"
+// @Success 200 {object} services.ProductImportJob
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurants/{id}/products/import/{job_id} [get]
+func (h *ProductImportHandler) GetImportJob(c *gin.Context) {
+	if !h.ownsRestaurant(c) {
+		return
+	}
+
+	job, err := h.importService.GetImportJob(c.Request.Context(), c.Param("id"), c.Param("job_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Import job not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// ExportProducts godoc
+// @Summary Export products
+// @Description Download the current restaurant's products, with category names and stock, as CSV or XLSX in the import layout
+// @Tags products
+// @Security BearerAuth
+// @Produce octet-stream
+// @Param format query string false "csv or xlsx" default(csv)
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Router /products/export [get]
+func (h *ProductImportHandler) ExportProducts(c *gin.Context) {
+	format := c.DefaultQuery("format", spreadsheet.FormatCSV)
+
+	data, err := h.importService.ExportProducts(c.Request.Context(), middleware.GetRestaurantID(c), format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Export failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	fileName := fmt.Sprintf("products-%s.%s", time.Now().Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Data(http.StatusOK, spreadsheet.ContentType(format), data)
+}
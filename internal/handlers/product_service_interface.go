@@ -9,12 +9,15 @@ import (
 // ProductServiceInterface defines the contract for product service
 type ProductServiceInterface interface {
 	CreateProduct(ctx context.Context, restaurantID string, req *services.CreateProductRequest) (*models.Product, error)
-	GetProductsByRestaurant(ctx context.Context, restaurantID string, limit, offset int) ([]models.Product, error)
-	SearchProducts(ctx context.Context, restaurantID, query string, limit, offset int) ([]models.Product, error)
+	GetProductsByRestaurant(ctx context.Context, restaurantID string, diet models.DietaryFilter, limit, offset int) ([]models.Product, error)
+	SearchProducts(ctx context.Context, restaurantID, query string, diet models.DietaryFilter, limit, offset int) ([]models.Product, error)
 	GetProductByID(ctx context.Context, productID string) (*models.Product, error)
-	UpdateProduct(ctx context.Context, productID, restaurantID string, updates map[string]interface{}) error
+	UpdateProduct(ctx context.Context, productID, restaurantID, userID string, updates map[string]interface{}) error
 	DeleteProduct(ctx context.Context, restaurantID, productID string) error
 	GetProductsByRestaurantCategoryAndTime(ctx context.Context, req *services.GetProductsRequest) (*services.GetProductsResponse, error)
+	GetCombo(ctx context.Context, productID string) (*services.ComboResponse, error)
+	SetComboComponents(ctx context.Context, productID, restaurantID string, req *services.SetComboComponentsRequest) (*services.ComboResponse, error)
+	SetOptionGroups(ctx context.Context, productID, restaurantID string, req *services.SetOptionGroupsRequest) (*models.Product, error)
 }
 
 // CategoryServiceInterface defines the contract for category service
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+	"golang-food-backend/pkg/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProfileHandler struct {
+	profileService *services.ProfileService
+}
+
+func NewProfileHandler(profileService *services.ProfileService) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: profileService,
+	}
+}
+
+// RegisterRoutes registers the signed-in user's profile routes
+func (h *ProfileHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	profile := router.Group("/users/me", authMiddleware.AuthRequired())
+	{
+		profile.GET("", h.GetProfile)
+		profile.PUT("", h.UpdateProfile)
+		profile.POST("/contact-change", h.RequestContactChange)
+		profile.POST("/contact-change/confirm", h.ConfirmContactChange)
+	}
+}
+
+// GetProfile godoc
+// @Summary Get my profile
+// @Description Get the signed-in user's profile
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.User
+// @Failure 404 {object} ErrorResponse
+// @Router /users/me [get]
+func (h *ProfileHandler) GetProfile(c *gin.Context) {
+	user, err := h.profileService.GetProfile(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Profile not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateProfile godoc
+// @Summary Update my profile
+// @Description Update the signed-in user's name and avatar. A new email is not applied right away: a code is sent to it and the change completes at /users/me/contact-change/confirm.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.UpdateProfileRequest true "Profile changes"
+// @Success 200 {object} services.ProfileResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users/me [put]
+func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
+	var req services.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response, err := h.profileService.UpdateProfile(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to update profile", apperr.Classify(err, apperr.KindValidation))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RequestContactChange godoc
+// @Summary Start a phone or email change
+// @Description Send a verification code to the new phone number or email. The account keeps the old one until the code is confirmed.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.ContactChangeRequest true "New phone number or email"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users/me/contact-change [post]
+func (h *ProfileHandler) RequestContactChange(c *gin.Context) {
+	var req services.ContactChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.profileService.RequestContactChange(c.Request.Context(), middleware.GetUserID(c), &req); err != nil {
+		abortWithError(c, "Failed to start "+req.Type+" change", apperr.Classify(err, apperr.KindValidation))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification code sent"})
+}
+
+// ConfirmContactChange godoc
+// @Summary Confirm a phone or email change
+// @Description Switch to the new phone number or email with the code that was sent to it
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.ConfirmContactChangeRequest true "Verification code"
+// @Success 200 {object} models.User
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users/me/contact-change/confirm [post]
+func (h *ProfileHandler) ConfirmContactChange(c *gin.Context) {
+	var req services.ConfirmContactChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	user, err := h.profileService.ConfirmContactChange(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to confirm "+req.Type+" change", apperr.Classify(err, apperr.KindValidation))
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
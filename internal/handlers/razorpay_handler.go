@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 
+	"golang-food-backend/internal/middleware"
 	"golang-food-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -24,6 +25,7 @@ func NewRazorpayHandler(razorpayService *services.RazorpayService) *RazorpayHand
 // @Summary Place a new order with Razorpay integration
 // @Description Create order and Razorpay payment order, returns razorpay_order_id for frontend payment
 // @Tags orders
+// @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param order body services.PlaceOrderRequest true "Order placement request"
@@ -41,13 +43,12 @@ func (h *RazorpayHandler) PlaceOrder(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
-	response, err := h.razorpayService.CreateRazorpayOrder(ctx, &req)
+	// Orders are always placed for the authenticated customer
+	req.UserID = middleware.GetUserID(c)
+
+	response, err := h.razorpayService.CreateRazorpayOrder(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to create order",
-			Message: err.Error(),
-		})
+		abortWithError(c, "Failed to create order", err)
 		return
 	}
 
@@ -85,7 +86,8 @@ func (h *RazorpayHandler) PaymentWebhook(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	// The webhook is applied in full even if Razorpay hangs up first; a retry would find it half done
+	ctx := context.WithoutCancel(c.Request.Context())
 	if err := h.razorpayService.HandlePaymentWebhook(ctx, body, signature); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to process webhook",
@@ -100,10 +102,226 @@ func (h *RazorpayHandler) PaymentWebhook(c *gin.Context) {
 	})
 }
 
+// RetryPayment godoc
+// @Summary Retry payment for an order
+// @Description Create a new Razorpay order for a food order whose payment failed or was abandoned
+// @Tags payments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 201 {object} services.PlaceOrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/orders/{id}/payment/retry [post]
+func (h *RazorpayHandler) RetryPayment(c *gin.Context) {
+	response, err := h.razorpayService.RetryPayment(c.Request.Context(), c.Param("id"), middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to retry payment",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// StartUPIPayment godoc
+// @Summary Pay for an order by UPI
+// @Description Start a UPI payment for your order awaiting payment: an intent link that opens your UPI app, a collect request sent to your UPI ID, or a QR code to scan. It replaces the order's unpaid attempt and stays open for 15 minutes.
+// @Tags payments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body services.UPIPaymentRequest true "UPI flow"
+// @Success 201 {object} services.OrderPaymentStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/orders/{id}/payment/upi [post]
+func (h *RazorpayHandler) StartUPIPayment(c *gin.Context) {
+	var req services.UPIPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	status, err := h.razorpayService.StartUPIPayment(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to start UPI payment", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, status)
+}
+
+// GetOrderPayment godoc
+// @Summary Get an order's payment
+// @Description The current payment attempt of one of the restaurant's orders: its method and status, the amount paid, and the link, UPI intent or QR code the customer pays through (staff with orders.manage)
+// @Tags payments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} services.OrderPaymentStatus
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/restaurant/orders/{id}/payment [get]
+func (h *RazorpayHandler) GetOrderPayment(c *gin.Context) {
+	status, err := h.razorpayService.GetOrderPayment(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get order payment", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// SendPaymentLink godoc
+// @Summary Send a payment link for an order
+// @Description Create a Razorpay payment link for an order awaiting payment, such as one taken by phone or WhatsApp, and send it to the customer by SMS (the default), email or WhatsApp. It replaces the order's unpaid attempt; the order is cancelled if the link expires unpaid (staff with orders.manage).
+// @Tags payments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body services.OrderPaymentLinkRequest false "Link options"
+// @Success 201 {object} services.OrderPaymentStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/restaurant/orders/{id}/payment/link [post]
+func (h *RazorpayHandler) SendPaymentLink(c *gin.Context) {
+	var req services.OrderPaymentLinkRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid request body",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	status, err := h.razorpayService.SendOrderPaymentLink(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to send payment link", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, status)
+}
+
+// ResendPaymentLink godoc
+// @Summary Resend an order's payment link
+// @Description Send the order's open payment link to the customer again by SMS, email or WhatsApp (staff with orders.manage)
+// @Tags payments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body services.ResendPaymentLinkRequest true "Channel"
+// @Success 200 {object} services.OrderPaymentStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/restaurant/orders/{id}/payment/link/resend [post]
+func (h *RazorpayHandler) ResendPaymentLink(c *gin.Context) {
+	var req services.ResendPaymentLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	status, err := h.razorpayService.ResendOrderPaymentLink(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to resend payment link", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// CancelPaymentLink godoc
+// @Summary Cancel an order's payment link
+// @Description Cancel the order's payment link before anything is paid through it. The order keeps waiting for payment until it expires, so a new link or a UPI payment can follow (staff with orders.manage).
+// @Tags payments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} services.OrderPaymentStatus
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/restaurant/orders/{id}/payment/link/cancel [post]
+func (h *RazorpayHandler) CancelPaymentLink(c *gin.Context) {
+	status, err := h.razorpayService.CancelOrderPaymentLink(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to cancel payment link", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// StartRestaurantUPIPayment godoc
+// @Summary Take a UPI payment for an order
+// @Description Start a UPI payment for an order awaiting payment: a QR code to show the customer, a collect request to their UPI ID, or an intent link. It replaces the order's unpaid attempt and stays open for 15 minutes (staff with orders.manage).
+// @Tags payments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body services.UPIPaymentRequest true "UPI flow"
+// @Success 201 {object} services.OrderPaymentStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/restaurant/orders/{id}/payment/upi [post]
+func (h *RazorpayHandler) StartRestaurantUPIPayment(c *gin.Context) {
+	var req services.UPIPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	status, err := h.razorpayService.StartRestaurantUPIPayment(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to start UPI payment", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, status)
+}
+
 // RegisterRoutes registers all Razorpay-related routes
-func (h *RazorpayHandler) RegisterRoutes(router *gin.RouterGroup) {
-	// Order placement endpoint (without auth for now, as it's called from frontend)
-	router.POST("/orders/place", h.PlaceOrder)
+func (h *RazorpayHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	router.POST("/orders/place", authMiddleware.AuthRequired(), h.PlaceOrder)
+
+	// Payment retry for failed or abandoned payments
+	router.POST("/orders/:id/payment/retry", authMiddleware.AuthRequired(), h.RetryPayment)
+	router.POST("/orders/:id/payment/upi", authMiddleware.AuthRequired(), h.StartUPIPayment)
+
+	// Payment links and UPI payments staff start for orders taken by phone or at the counter
+	restaurant := router.Group("/restaurant/orders/:id/payment",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantRequired(),
+		authMiddleware.RestaurantStaffRequired(),
+		authMiddleware.RestaurantPermissionRequired(services.StaffPermissionOrders),
+	)
+	{
+		restaurant.GET("", h.GetOrderPayment)
+		restaurant.POST("/link", h.SendPaymentLink)
+		restaurant.POST("/link/resend", h.ResendPaymentLink)
+		restaurant.POST("/link/cancel", h.CancelPaymentLink)
+		restaurant.POST("/upi", h.StartRestaurantUPIPayment)
+	}
 
 	// Webhook endpoint (no auth needed for webhooks from Razorpay)
 	router.POST("/webhooks/razorpay", h.PaymentWebhook)
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RecommendationHandler struct {
+	recommendationService *services.RecommendationService
+}
+
+func NewRecommendationHandler(recommendationService *services.RecommendationService) *RecommendationHandler {
+	return &RecommendationHandler{
+		recommendationService: recommendationService,
+	}
+}
+
+// RegisterRoutes registers product and personal recommendation routes
+func (h *RecommendationHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	router.GET("/products/:id/recommendations", h.GetProductRecommendations)
+	router.GET("/users/me/recommendations", authMiddleware.AuthRequired(), h.GetMyRecommendations)
+}
+
+// GetProductRecommendations godoc
+// @Summary Get "customers also ordered" suggestions
+// @Description Get available products that are frequently ordered or viewed together with a product
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param limit query int false "Number of suggestions (max 20)" default(10)
+// @Success 200 {object} services.RecommendationsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/{id}/recommendations [get]
+func (h *RecommendationHandler) GetProductRecommendations(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	recommendations, err := h.recommendationService.GetProductRecommendations(c.Request.Context(), c.Param("id"), limit)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "product not found" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, ErrorResponse{
+			Error:   "Failed to get recommendations",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, recommendations)
+}
+
+// GetMyRecommendations godoc
+// @Summary Get personal recommendations
+// @Description Get products suggested from the current user's recent orders and views
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Number of suggestions (max 20)" default(10)
+// @Success 200 {object} services.RecommendationsResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /users/me/recommendations [get]
+func (h *RecommendationHandler) GetMyRecommendations(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	recommendations, err := h.recommendationService.GetUserRecommendations(c.Request.Context(), middleware.GetUserID(c), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to get recommendations",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, recommendations)
+}
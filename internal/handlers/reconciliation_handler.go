@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+	"golang-food-backend/pkg/spreadsheet"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationHandler struct {
+	reconciliationService *services.ReconciliationService
+}
+
+func NewReconciliationHandler(reconciliationService *services.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		reconciliationService: reconciliationService,
+	}
+}
+
+type RunReconciliationRequest struct {
+	Date string `json:"date"` // YYYY-MM-DD; defaults to yesterday
+}
+
+// RegisterRoutes registers the admin payment reconciliation routes
+func (h *ReconciliationHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := router.Group("/admin/reconciliations",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionReports),
+	)
+	{
+		admin.GET("", h.ListReconciliations)
+		admin.POST("", middleware.Timeout(longRequestTimeout), h.RunReconciliation)
+		admin.GET("/:date", h.GetReport)
+		admin.GET("/:date/export", middleware.Timeout(longRequestTimeout), h.ExportReport)
+	}
+}
+
+// ListReconciliations godoc
+// @Summary List payment reconciliations
+// @Description Daily reconciliations of Razorpay payments and settlements against ours, newest date first, with the captured, settled and fee totals and the number of mismatches
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} services.ReconciliationListResponse
+// @Router /admin/reconciliations [get]
+func (h *ReconciliationHandler) ListReconciliations(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	runs, err := h.reconciliationService.ListReconciliations(c.Request.Context(), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list reconciliations", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// RunReconciliation godoc
+// @Summary Reconcile a day's payments
+// @Description Reconcile a finished day's payments now, redoing it if it was already reconciled. Days are reconciled automatically every morning.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body RunReconciliationRequest false "Day to reconcile"
+// @Success 200 {object} models.PaymentReconciliation
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/reconciliations [post]
+func (h *ReconciliationHandler) RunReconciliation(c *gin.Context) {
+	var req RunReconciliationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid request body",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	run, err := h.reconciliationService.Reconcile(c.Request.Context(), req.Date, true)
+	if err != nil {
+		abortWithError(c, "Failed to reconcile payments", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// GetReport godoc
+// @Summary Get a reconciliation report
+// @Description A day's reconciliation with its mismatches: missing_capture (captured at Razorpay, unpaid here), amount_mismatch, orphan_payment (captured at Razorpay with no payment here) and unconfirmed_payment (paid here, not captured at Razorpay)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param date path string true "Date (YYYY-MM-DD)"
+// @Param kind query string false "Only mismatches of this kind"
+// @Success 200 {object} services.ReconciliationReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/reconciliations/{date} [get]
+func (h *ReconciliationHandler) GetReport(c *gin.Context) {
+	report, err := h.reconciliationService.GetReport(c.Request.Context(), c.Param("date"), c.Query("kind"))
+	if err != nil {
+		abortWithError(c, "Failed to get reconciliation report", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportReport godoc
+// @Summary Download a reconciliation report
+// @Description Download a day's reconciliation mismatches as CSV
+// @Tags admin
+// @Security BearerAuth
+// @Produce text/csv
+// @Param date path string true "Date (YYYY-MM-DD)"
+// @Param kind query string false "Only mismatches of this kind"
+// @Success 200 {file} file
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/reconciliations/{date}/export [get]
+func (h *ReconciliationHandler) ExportReport(c *gin.Context) {
+	data, err := h.reconciliationService.ExportReport(c.Request.Context(), c.Param("date"), c.Query("kind"))
+	if err != nil {
+		abortWithError(c, "Failed to export reconciliation report", err)
+		return
+	}
+
+	fileName := fmt.Sprintf("reconciliation-%s.%s", c.Param("date"), spreadsheet.FormatCSV)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Data(http.StatusOK, spreadsheet.ContentType(spreadsheet.FormatCSV), data)
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 
@@ -46,7 +45,7 @@ func (h *RefundHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *
 	}
 
 	// Restaurant routes
-	restaurantRoutes := refunds.Group("/restaurant", authMiddleware.AuthRequired(), authMiddleware.RestaurantOwnerRequired())
+	restaurantRoutes := refunds.Group("/restaurant", authMiddleware.AuthRequired(), authMiddleware.RestaurantRequired(), authMiddleware.RestaurantOwnerRequired())
 	{
 		// Update refund status for restaurant
 		restaurantRoutes.PUT("/:id/status", h.UpdateRefundStatus)
@@ -84,7 +83,7 @@ func (h *RefundHandler) CreateRefund(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	refund, err := h.refundService.CreateRefund(ctx, userID.(string), &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -125,7 +124,7 @@ func (h *RefundHandler) GetRefunds(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	response, err := h.refundService.GetRefunds(ctx, userID.(string), page, limit, status)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -169,7 +168,7 @@ func (h *RefundHandler) GetRefundByID(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	refund, err := h.refundService.GetRefundByID(ctx, userID.(string), refundID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{
@@ -224,8 +223,8 @@ func (h *RefundHandler) UpdateRefundStatus(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
-	refund, err := h.refundService.UpdateRefundStatus(ctx, adminID.(string), refundID, &req)
+	// The request context carries the restaurant scope on the restaurant route
+	refund, err := h.refundService.UpdateRefundStatus(c.Request.Context(), adminID.(string), refundID, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to update refund status",
@@ -269,7 +268,7 @@ func (h *RefundHandler) ProcessRefund(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	refund, err := h.refundService.ProcessRefund(ctx, adminID.(string), refundID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RestaurantAnalyticsHandler struct {
+	analyticsService *services.RestaurantAnalyticsService
+}
+
+func NewRestaurantAnalyticsHandler(analyticsService *services.RestaurantAnalyticsService) *RestaurantAnalyticsHandler {
+	return &RestaurantAnalyticsHandler{
+		analyticsService: analyticsService,
+	}
+}
+
+// RegisterRoutes registers restaurant analytics routes
+func (h *RestaurantAnalyticsHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	router.GET("/restaurants/:id/analytics",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantOwnerRequired(),
+		h.GetAnalytics,
+	)
+}
+
+// GetAnalytics godoc
+// @Summary Get restaurant analytics
+// @Description Get orders, revenue, popular items, peak hours and new vs returning customers for a date range (owner or admin)
+// @Tags restaurants
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param from query string false "Start date (YYYY-MM-DD), defaults to 7 days ago"
+// @Param to query string false "End date (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} services.RestaurantAnalyticsResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurants/{id}/analytics [get]
+func (h *RestaurantAnalyticsHandler) GetAnalytics(c *gin.Context) {
+	analytics, err := h.analyticsService.GetAnalytics(
+		c.Request.Context(),
+		middleware.GetUserID(c),
+		middleware.GetUserRole(c),
+		c.Param("id"),
+		c.Query("from"),
+		c.Query("to"),
+	)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to get analytics",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"golang-food-backend/internal/middleware"
 	"golang-food-backend/internal/models"
@@ -71,13 +73,11 @@ func (h *RestaurantHandler) CreateRestaurant(c *gin.Context) {
 		OwnerID:       ownerID,
 		GSTNumber:     req.GSTNumber,
 		ContactNumber: req.ContactNumber,
+		Currency:      req.Currency,
 	}
 
-	if err := h.restaurantService.CreateRestaurant(restaurant); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to create restaurant",
-			Message: err.Error(),
-		})
+	if err := h.restaurantService.CreateRestaurant(c.Request.Context(), restaurant); err != nil {
+		abortWithError(c, "Failed to create restaurant", err)
 		return
 	}
 
@@ -110,7 +110,7 @@ func (h *RestaurantHandler) GetRestaurants(c *gin.Context) {
 		limit = 10
 	}
 
-	restaurants, total, err := h.restaurantService.GetRestaurants(page, limit, cuisine, search)
+	restaurants, total, err := h.restaurantService.GetRestaurants(c.Request.Context(), page, limit, cuisine, search)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to fetch restaurants",
@@ -152,7 +152,7 @@ func (h *RestaurantHandler) GetRestaurantByID(c *gin.Context) {
 		return
 	}
 
-	restaurant, err := h.restaurantService.GetRestaurantByID(id)
+	restaurant, err := h.restaurantService.GetRestaurantByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Error:   "Restaurant not found",
@@ -218,7 +218,7 @@ func (h *RestaurantHandler) UpdateRestaurant(c *gin.Context) {
 	}
 
 	// Check if user owns the restaurant
-	restaurant, err := h.restaurantService.GetRestaurantByID(id)
+	restaurant, err := h.restaurantService.GetRestaurantByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Error:   "Restaurant not found",
@@ -252,14 +252,41 @@ func (h *RestaurantHandler) UpdateRestaurant(c *gin.Context) {
 		restaurant.ContactNumber = req.ContactNumber
 	}
 	if req.Status != "" {
+		if restaurant.OnboardingStatus != services.OnboardingApproved {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "Onboarding not approved",
+				Message: "The restaurant's status can be changed once its onboarding is approved",
+			})
+			return
+		}
 		restaurant.Status = req.Status
 	}
+	if req.RequireDeliveryCode != nil {
+		restaurant.RequireDeliveryCode = *req.RequireDeliveryCode
+	}
+	if req.DeliveryEnabled != nil {
+		restaurant.DeliveryEnabled = *req.DeliveryEnabled
+	}
+	if req.PickupEnabled != nil {
+		restaurant.PickupEnabled = *req.PickupEnabled
+	}
+	if req.DineInEnabled != nil {
+		restaurant.DineInEnabled = *req.DineInEnabled
+	}
+	if req.Currency != "" && !strings.EqualFold(req.Currency, restaurant.Currency) {
+		// Orders already placed are charged in the old currency
+		if restaurant.OnboardingStatus == services.OnboardingApproved {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "Currency locked",
+				Message: "The currency can only be changed before the restaurant's onboarding is approved",
+			})
+			return
+		}
+		restaurant.Currency = req.Currency
+	}
 
-	if err := h.restaurantService.UpdateRestaurant(restaurant); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to update restaurant",
-			Message: err.Error(),
-		})
+	if err := h.restaurantService.UpdateRestaurant(c.Request.Context(), restaurant); err != nil {
+		abortWithError(c, "Failed to update restaurant", err)
 		return
 	}
 
@@ -310,7 +337,7 @@ func (h *RestaurantHandler) DeleteRestaurant(c *gin.Context) {
 	}
 
 	// Check if user owns the restaurant
-	restaurant, err := h.restaurantService.GetRestaurantByID(id)
+	restaurant, err := h.restaurantService.GetRestaurantByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Error:   "Restaurant not found",
@@ -327,7 +354,7 @@ func (h *RestaurantHandler) DeleteRestaurant(c *gin.Context) {
 		return
 	}
 
-	if err := h.restaurantService.DeleteRestaurant(id); err != nil {
+	if err := h.restaurantService.DeleteRestaurant(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to delete restaurant",
 			Message: err.Error(),
@@ -368,7 +395,7 @@ func (h *RestaurantHandler) GetMyRestaurants(c *gin.Context) {
 		return
 	}
 
-	restaurants, err := h.restaurantService.GetRestaurantsByOwner(ownerID)
+	restaurants, err := h.restaurantService.GetRestaurantsByOwner(c.Request.Context(), ownerID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to fetch restaurants",
@@ -404,6 +431,7 @@ type CreateRestaurantRequest struct {
 	CuisineTypes  []string `json:"cuisine_types" binding:"required"`
 	GSTNumber     string   `json:"gst_number" binding:"required"`
 	ContactNumber string   `json:"contact_number" binding:"required"`
+	Currency      string   `json:"currency" example:"INR"` // ISO 4217 code; defaults to INR
 }
 
 type UpdateRestaurantRequest struct {
@@ -413,6 +441,15 @@ type UpdateRestaurantRequest struct {
 	CuisineTypes  []string `json:"cuisine_types"`
 	ContactNumber string   `json:"contact_number"`
 	Status        string   `json:"status"`
+	// Hold orders at out for delivery until the rider or delivery partner verifies the
+	// customer's delivery code
+	RequireDeliveryCode *bool `json:"require_delivery_code"`
+	// Order types taken; at least one has to stay enabled
+	DeliveryEnabled *bool `json:"delivery_enabled"`
+	PickupEnabled   *bool `json:"pickup_enabled"`
+	DineInEnabled   *bool `json:"dine_in_enabled"`
+	// ISO 4217 code; can only change before onboarding is approved
+	Currency string `json:"currency"`
 }
 
 type RestaurantsResponse struct {
@@ -430,4 +467,16 @@ type PaginationResponse struct {
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message"`
+	Code    string `json:"code,omitempty"` // machine-readable, such as group_order_full
 }
+
+// abortWithError hands err to the error middleware, which answers with the status and code of
+// the error's kind. The summary is the short error shown to users.
+func abortWithError(c *gin.Context, summary string, err error) {
+	c.Error(err).SetMeta(summary)
+	c.Abort()
+}
+
+// longRequestTimeout replaces the default request deadline on imports and exports, which read
+// or write a whole menu, chat or payout batch
+const longRequestTimeout = 2 * time.Minute
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RestaurantOnboardingHandler struct {
+	onboardingService *services.RestaurantOnboardingService
+}
+
+func NewRestaurantOnboardingHandler(onboardingService *services.RestaurantOnboardingService) *RestaurantOnboardingHandler {
+	return &RestaurantOnboardingHandler{
+		onboardingService: onboardingService,
+	}
+}
+
+// RegisterRoutes registers the owner onboarding routes and the admin review routes
+func (h *RestaurantOnboardingHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	owner := router.Group("/restaurants/:id/onboarding", authMiddleware.AuthRequired())
+	{
+		owner.GET("", h.GetOnboarding)
+		owner.POST("/documents", h.UploadDocument)
+		owner.POST("/documents/:document_id/confirm", h.ConfirmDocument)
+		owner.POST("/submit", h.Submit)
+	}
+
+	admin := router.Group("/admin/onboarding",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionRestaurants),
+	)
+	{
+		admin.GET("", h.ListQueue)
+		admin.GET("/:id", h.GetReview)
+		admin.POST("/:id/documents/:document_id/review", h.ReviewDocument)
+		admin.POST("/:id/status", h.Transition)
+	}
+}
+
+// GetOnboarding godoc
+// @Summary Get restaurant onboarding status
+// @Description Get the onboarding status, uploaded documents and the documents still missing for a restaurant you own
+// @Tags restaurant-onboarding
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Success 200 {object} services.OnboardingOverview
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurants/{id}/onboarding [get]
+func (h *RestaurantOnboardingHandler) GetOnboarding(c *gin.Context) {
+	overview, err := h.onboardingService.GetOverview(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get onboarding status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
+
+// UploadDocument godoc
+// @Summary Upload an onboarding document
+// @Description Save an FSSAI licence, GST certificate or bank details and get a pre-signed URL to PUT the file (PDF, JPEG or PNG) to. Confirm the upload afterwards.
+// @Tags restaurant-onboarding
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param request body services.OnboardingDocumentRequest true "Document details"
+// @Success 201 {object} services.DocumentUploadResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /restaurants/{id}/onboarding/documents [post]
+func (h *RestaurantOnboardingHandler) UploadDocument(c *gin.Context) {
+	var req services.OnboardingDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	upload, err := h.onboardingService.UploadDocument(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to upload document", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, upload)
+}
+
+// ConfirmDocument godoc
+// @Summary Confirm an onboarding document upload
+// @Description Check the uploaded file and attach it to the document, replacing any earlier file
+// @Tags restaurant-onboarding
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param document_id path string true "Document ID"
+// @Param request body services.ConfirmDocumentRequest true "Uploaded media"
+// @Success 200 {object} models.RestaurantDocument
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurants/{id}/onboarding/documents/{document_id}/confirm [post]
+func (h *RestaurantOnboardingHandler) ConfirmDocument(c *gin.Context) {
+	var req services.ConfirmDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	document, err := h.onboardingService.ConfirmDocument(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), c.Param("document_id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to confirm document", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, document)
+}
+
+// Submit godoc
+// @Summary Submit a restaurant for review
+// @Description Send a draft or rejected restaurant to admins for review once the FSSAI licence, GST certificate and bank details are uploaded
+// @Tags restaurant-onboarding
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Success 200 {object} services.OnboardingOverview
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /restaurants/{id}/onboarding/submit [post]
+func (h *RestaurantOnboardingHandler) Submit(c *gin.Context) {
+	overview, err := h.onboardingService.Submit(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to submit restaurant", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
+
+// ListQueue godoc
+// @Summary List restaurants by onboarding status
+// @Description List restaurants in an onboarding status, oldest submission first
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "draft, documents_uploaded, under_review, approved or rejected"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} services.OnboardingQueueResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/onboarding [get]
+func (h *RestaurantOnboardingHandler) ListQueue(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	queue, err := h.onboardingService.ListQueue(c.Request.Context(), c.Query("status"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to list restaurants",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+// GetReview godoc
+// @Summary Get a restaurant's onboarding for review
+// @Description Get a restaurant's onboarding status, documents with signed file links and status history
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Success 200 {object} services.OnboardingOverview
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/onboarding/{id} [get]
+func (h *RestaurantOnboardingHandler) GetReview(c *gin.Context) {
+	overview, err := h.onboardingService.GetReview(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get onboarding", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
+
+// ReviewDocument godoc
+// @Summary Verify or reject an onboarding document
+// @Description Mark a document verified or rejected while the restaurant is under review. Rejections need a note.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param document_id path string true "Document ID"
+// @Param request body services.DocumentReviewRequest true "Review decision"
+// @Success 200 {object} models.RestaurantDocument
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/onboarding/{id}/documents/{document_id}/review [post]
+func (h *RestaurantOnboardingHandler) ReviewDocument(c *gin.Context) {
+	var req services.DocumentReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	document, err := h.onboardingService.ReviewDocument(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), c.Param("document_id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to review document", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, document)
+}
+
+// Transition godoc
+// @Summary Change a restaurant's onboarding status
+// @Description Start the review, approve or reject a restaurant. Approval needs every document verified and activates the restaurant; rejection needs a note.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param request body services.OnboardingTransitionRequest true "New status"
+// @Success 200 {object} services.OnboardingOverview
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/onboarding/{id}/status [post]
+func (h *RestaurantOnboardingHandler) Transition(c *gin.Context) {
+	var req services.OnboardingTransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	overview, err := h.onboardingService.Transition(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to change onboarding status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
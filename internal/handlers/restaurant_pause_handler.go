@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RestaurantPauseHandler struct {
+	pauseService *services.RestaurantPauseService
+}
+
+func NewRestaurantPauseHandler(pauseService *services.RestaurantPauseService) *RestaurantPauseHandler {
+	return &RestaurantPauseHandler{
+		pauseService: pauseService,
+	}
+}
+
+// RegisterRoutes registers the busy mode routes for restaurant owners and staff
+func (h *RestaurantPauseHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	restaurant := router.Group("/restaurants/:id",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantStaffRequired(),
+	)
+	{
+		restaurant.GET("/pause", h.GetPauseStatus)
+		restaurant.POST("/pause", h.Pause)
+		restaurant.DELETE("/pause", h.Resume)
+		restaurant.GET("/pauses", h.ListPauses)
+	}
+}
+
+// Pause godoc
+// @Summary Pause new orders
+// @Description Put the restaurant in busy mode for 15, 30 or 60 minutes. Checkouts are refused with 409 restaurant_paused, naming the resume time, until the pause ends; orders scheduled for after it are still taken. Pausing again restarts the pause with the new duration (owner, staff with orders.manage, or admin).
+// @Tags restaurants
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param pause body services.PauseRestaurantRequest true "Pause duration"
+// @Success 201 {object} models.RestaurantPause
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /restaurants/{id}/pause [post]
+func (h *RestaurantPauseHandler) Pause(c *gin.Context) {
+	var req services.PauseRestaurantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	pause, err := h.pauseService.Pause(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to pause restaurant", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, pause)
+}
+
+// Resume godoc
+// @Summary Resume taking orders
+// @Description End the restaurant's pause before it runs out
+// @Tags restaurants
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Success 200 {object} models.RestaurantPause
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /restaurants/{id}/pause [delete]
+func (h *RestaurantPauseHandler) Resume(c *gin.Context) {
+	pause, err := h.pauseService.Resume(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to resume restaurant", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pause)
+}
+
+// GetPauseStatus godoc
+// @Summary Get the pause status
+// @Description Get whether the restaurant is paused and when it resumes
+// @Tags restaurants
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Success 200 {object} services.RestaurantPauseStatus
+// @Failure 403 {object} ErrorResponse
+// @Router /restaurants/{id}/pause [get]
+func (h *RestaurantPauseHandler) GetPauseStatus(c *gin.Context) {
+	status, err := h.pauseService.GetPauseStatus(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get pause status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// ListPauses godoc
+// @Summary List pause history
+// @Description List the restaurant's pauses started in a date range, newest first, with how often and for how many minutes it was paused
+// @Tags restaurants
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param from query string false "Start date (YYYY-MM-DD), defaults to 30 days before to"
+// @Param to query string false "End date (YYYY-MM-DD), defaults to today"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} services.RestaurantPausesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /restaurants/{id}/pauses [get]
+func (h *RestaurantPauseHandler) ListPauses(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	pauses, err := h.pauseService.ListPauses(
+		c.Request.Context(),
+		middleware.GetUserID(c),
+		middleware.GetUserRole(c),
+		c.Param("id"),
+		c.Query("from"),
+		c.Query("to"),
+		page,
+		limit,
+	)
+	if err != nil {
+		abortWithError(c, "Failed to list pauses", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pauses)
+}
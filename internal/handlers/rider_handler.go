@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RiderHandler struct {
+	riderService *services.RiderService
+}
+
+func NewRiderHandler(riderService *services.RiderService) *RiderHandler {
+	return &RiderHandler{
+		riderService: riderService,
+	}
+}
+
+// RegisterRoutes registers restaurant fleet management and rider app routes
+func (h *RiderHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	// Restaurant fleet management
+	restaurant := router.Group("/restaurant", authMiddleware.AuthRequired(), authMiddleware.RestaurantRequired())
+	{
+		restaurant.POST("/riders", authMiddleware.RestaurantOwnerRequired(), h.CreateRider)
+		restaurant.GET("/riders", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionOrders), h.ListRiders)
+		restaurant.PUT("/riders/:id", authMiddleware.RestaurantOwnerRequired(), h.UpdateRider)
+		restaurant.POST("/orders/:id/rider", authMiddleware.RestaurantStaffRequired(), authMiddleware.RestaurantPermissionRequired(services.StaffPermissionOrders), h.AssignRider)
+	}
+
+	// Rider app (riders log in through /auth/send-otp and /auth/verify-otp with role "rider")
+	rider := router.Group("/rider", authMiddleware.AuthRequired(), authMiddleware.RiderRequired())
+	{
+		rider.GET("/me", h.GetMe)
+		rider.PUT("/availability", h.SetAvailability)
+		rider.POST("/location", h.UpdateLocation)
+		rider.GET("/assignments", h.GetMyAssignments)
+		rider.POST("/assignments/:id/accept", h.AcceptAssignment)
+		rider.POST("/assignments/:id/reject", h.RejectAssignment)
+		rider.POST("/assignments/:id/pickup", h.PickUpOrder)
+		rider.POST("/assignments/:id/deliver", h.CompleteDelivery)
+	}
+}
+
+// CreateRider godoc
+// @Summary Add a delivery rider
+// @Description Register one of the restaurant's own riders for self-delivery
+// @Tags riders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.CreateRiderRequest true "Rider details"
+// @Success 201 {object} models.Rider
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurant/riders [post]
+func (h *RiderHandler) CreateRider(c *gin.Context) {
+	var req services.CreateRiderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	rider, err := h.riderService.CreateRider(c.Request.Context(), middleware.GetRestaurantID(c), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to create rider",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rider)
+}
+
+// ListRiders godoc
+// @Summary List delivery riders
+// @Description List the restaurant's riders with their current status
+// @Tags riders
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Rider
+// @Failure 500 {object} ErrorResponse
+// @Router /restaurant/riders [get]
+func (h *RiderHandler) ListRiders(c *gin.Context) {
+	riders, err := h.riderService.ListRiders(c.Request.Context(), middleware.GetRestaurantID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to get riders",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, riders)
+}
+
+// UpdateRider godoc
+// @Summary Update a delivery rider
+// @Description Update a rider's details or deactivate them
+// @Tags riders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Rider ID"
+// @Param request body services.UpdateRiderRequest true "Rider updates"
+// @Success 200 {object} models.Rider
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurant/riders/{id} [put]
+func (h *RiderHandler) UpdateRider(c *gin.Context) {
+	var req services.UpdateRiderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	rider, err := h.riderService.UpdateRider(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to update rider",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, rider)
+}
+
+// AssignRider godoc
+// @Summary Assign a rider to an order
+// @Description Manually assign or reassign one of the restaurant's riders to an order
+// @Tags riders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body services.AssignRiderRequest true "Rider to assign"
+// @Success 200 {object} models.RiderAssignment
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurant/orders/{id}/rider [post]
+func (h *RiderHandler) AssignRider(c *gin.Context) {
+	var req services.AssignRiderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	assignment, err := h.riderService.AssignRider(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to assign rider",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+// GetMe godoc
+// @Summary Get rider profile
+// @Description Get the logged-in rider's profile and status
+// @Tags rider
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.Rider
+// @Failure 404 {object} ErrorResponse
+// @Router /rider/me [get]
+func (h *RiderHandler) GetMe(c *gin.Context) {
+	rider, err := h.riderService.GetMe(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Rider not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, rider)
+}
+
+// SetAvailability godoc
+// @Summary Go online or offline
+// @Description Toggle whether the rider can receive new assignments
+// @Tags rider
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.RiderAvailabilityRequest true "Availability"
+// @Success 200 {object} models.Rider
+// @Failure 400 {object} ErrorResponse
+// @Router /rider/availability [put]
+func (h *RiderHandler) SetAvailability(c *gin.Context) {
+	var req services.RiderAvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	rider, err := h.riderService.SetAvailability(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to update availability",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, rider)
+}
+
+// UpdateLocation godoc
+// @Summary Report rider location
+// @Description Report the rider's current position; streamed to customers of orders in progress
+// @Tags rider
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.RiderLocationRequest true "Current position"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /rider/location [post]
+func (h *RiderHandler) UpdateLocation(c *gin.Context) {
+	var req services.RiderLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.riderService.UpdateLocation(c.Request.Context(), middleware.GetUserID(c), &req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to update location",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Location updated"})
+}
+
+// GetMyAssignments godoc
+// @Summary List rider assignments
+// @Description List the rider's open assignments, or recent history with history=true
+// @Tags rider
+// @Security BearerAuth
+// @Produce json
+// @Param history query bool false "Return recent history instead of open jobs"
+// @Success 200 {array} models.RiderAssignment
+// @Failure 400 {object} ErrorResponse
+// @Router /rider/assignments [get]
+func (h *RiderHandler) GetMyAssignments(c *gin.Context) {
+	history := c.Query("history") == "true"
+
+	assignments, err := h.riderService.GetMyAssignments(c.Request.Context(), middleware.GetUserID(c), history)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to get assignments",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignments)
+}
+
+// AcceptAssignment godoc
+// @Summary Accept an assignment
+// @Tags rider
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Assignment ID"
+// @Success 200 {object} models.RiderAssignment
+// @Failure 400 {object} ErrorResponse
+// @Router /rider/assignments/{id}/accept [post]
+func (h *RiderHandler) AcceptAssignment(c *gin.Context) {
+	assignment, err := h.riderService.AcceptAssignment(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to accept assignment",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+// RejectAssignment godoc
+// @Summary Reject an assignment
+// @Description Hand the assignment back to the restaurant for reassignment
+// @Tags rider
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Assignment ID"
+// @Success 200 {object} models.RiderAssignment
+// @Failure 400 {object} ErrorResponse
+// @Router /rider/assignments/{id}/reject [post]
+func (h *RiderHandler) RejectAssignment(c *gin.Context) {
+	assignment, err := h.riderService.RejectAssignment(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to reject assignment",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+// PickUpOrder godoc
+// @Summary Pick up an order
+// @Description Mark the order as collected; the customer receives a handover OTP
+// @Tags rider
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Assignment ID"
+// @Success 200 {object} models.RiderAssignment
+// @Failure 400 {object} ErrorResponse
+// @Router /rider/assignments/{id}/pickup [post]
+func (h *RiderHandler) PickUpOrder(c *gin.Context) {
+	assignment, err := h.riderService.PickUpOrder(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to pick up order",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+// CompleteDelivery godoc
+// @Summary Complete a delivery
+// @Description Hand over the order after verifying the customer's delivery code
+// @Tags rider
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Assignment ID"
+// @Param request body services.CompleteDeliveryRequest true "Customer's delivery code"
+// @Success 200 {object} models.RiderAssignment
+// @Failure 400 {object} ErrorResponse
+// @Router /rider/assignments/{id}/deliver [post]
+func (h *RiderHandler) CompleteDelivery(c *gin.Context) {
+	var req services.CompleteDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	assignment, err := h.riderService.CompleteDelivery(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to complete delivery",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
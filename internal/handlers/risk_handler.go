@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RiskHandler struct {
+	riskService *services.RiskService
+}
+
+func NewRiskHandler(riskService *services.RiskService) *RiskHandler {
+	return &RiskHandler{
+		riskService: riskService,
+	}
+}
+
+// RegisterRoutes registers the admin fraud rule and review queue routes
+func (h *RiskHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := router.Group("/admin/risk",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionRisk),
+	)
+	{
+		admin.GET("/rules", h.ListRules)
+		admin.PUT("/rules/:key", h.UpdateRule)
+		admin.GET("/reviews", h.ListReviews)
+		admin.GET("/assessments", h.ListAssessments)
+		admin.GET("/assessments/:id", h.GetAssessment)
+		admin.POST("/assessments/:id/approve", h.ApproveAssessment)
+		admin.POST("/assessments/:id/reject", h.RejectAssessment)
+	}
+}
+
+// ListRules godoc
+// @Summary List fraud rules
+// @Description List the fraud rules checked at checkout and refund request with the settings in effect (admin with risk.manage)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} services.RiskRuleStatus
+// @Router /admin/risk/rules [get]
+func (h *RiskHandler) ListRules(c *gin.Context) {
+	rules, err := h.riskService.ListRules(c.Request.Context())
+	if err != nil {
+		abortWithError(c, "Failed to list risk rules", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// UpdateRule godoc
+// @Summary Configure a fraud rule
+// @Description Turn a rule on or off and set its threshold, look-back window and action: review lets the request through into the review queue, block refuses it
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param key path string true "Rule key"
+// @Param request body services.RiskRuleRequest true "Rule settings"
+// @Success 200 {object} services.RiskRuleStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/risk/rules/{key} [put]
+func (h *RiskHandler) UpdateRule(c *gin.Context) {
+	var req services.RiskRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	rule, err := h.riskService.UpdateRule(c.Request.Context(), middleware.GetUserID(c), c.Param("key"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to update risk rule", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// ListReviews godoc
+// @Summary List the fraud review queue
+// @Description List checkouts and refund requests the rules flagged for review, newest first
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "pending, approved or rejected" default(pending)
+// @Param event query string false "checkout or refund"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} services.RiskAssessmentsResponse
+// @Router /admin/risk/reviews [get]
+func (h *RiskHandler) ListReviews(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	reviews, err := h.riskService.ListAssessments(c.Request.Context(), services.RiskAssessmentQuery{
+		Event:        c.Query("event"),
+		Decision:     services.RiskDecisionReview,
+		ReviewStatus: c.DefaultQuery("status", services.RiskReviewPending),
+		Page:         page,
+		Limit:        limit,
+	})
+	if err != nil {
+		abortWithError(c, "Failed to list risk reviews", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// ListAssessments godoc
+// @Summary List fraud decisions
+// @Description List recorded decisions, newest first, including blocked attempts
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param decision query string false "allow, review or block"
+// @Param event query string false "checkout or refund"
+// @Param user_id query string false "Customer ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} services.RiskAssessmentsResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/risk/assessments [get]
+func (h *RiskHandler) ListAssessments(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	assessments, err := h.riskService.ListAssessments(c.Request.Context(), services.RiskAssessmentQuery{
+		Event:    c.Query("event"),
+		Decision: c.Query("decision"),
+		UserID:   c.Query("user_id"),
+		Page:     page,
+		Limit:    limit,
+	})
+	if err != nil {
+		abortWithError(c, "Failed to list risk assessments", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, assessments)
+}
+
+// GetAssessment godoc
+// @Summary Get a fraud decision
+// @Description Get a decision with the signals that triggered it
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Assessment ID"
+// @Success 200 {object} models.RiskAssessment
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/risk/assessments/{id} [get]
+func (h *RiskHandler) GetAssessment(c *gin.Context) {
+	assessment, err := h.riskService.GetAssessment(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get risk assessment", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, assessment)
+}
+
+// ApproveAssessment godoc
+// @Summary Approve a flagged request
+// @Description Clear a checkout or refund request from the review queue
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Assessment ID"
+// @Param request body services.RiskReviewRequest false "Review note"
+// @Success 200 {object} models.RiskAssessment
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/risk/assessments/{id}/approve [post]
+func (h *RiskHandler) ApproveAssessment(c *gin.Context) {
+	h.review(c, true)
+}
+
+// RejectAssessment godoc
+// @Summary Reject a flagged request
+// @Description Reject a request in the review queue: its order is cancelled, or its refund rejected while still pending
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Assessment ID"
+// @Param request body services.RiskReviewRequest false "Review note"
+// @Success 200 {object} models.RiskAssessment
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/risk/assessments/{id}/reject [post]
+func (h *RiskHandler) RejectAssessment(c *gin.Context) {
+	h.review(c, false)
+}
+
+func (h *RiskHandler) review(c *gin.Context, approve bool) {
+	var req services.RiskReviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid request body",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	assessment, err := h.riskService.ReviewAssessment(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), approve, &req)
+	if err != nil {
+		abortWithError(c, "Failed to review risk assessment", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, assessment)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	searchService            *services.SearchService
+	activityTracker          *services.ActivityTracker
+	dietaryPreferenceService *services.DietaryPreferenceService
+}
+
+func NewSearchHandler(searchService *services.SearchService, activityTracker *services.ActivityTracker, dietaryPreferenceService *services.DietaryPreferenceService) *SearchHandler {
+	return &SearchHandler{
+		searchService:            searchService,
+		activityTracker:          activityTracker,
+		dietaryPreferenceService: dietaryPreferenceService,
+	}
+}
+
+// RegisterRoutes registers the platform search routes
+func (h *SearchHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	router.GET("/search", authMiddleware.OptionalAuth(), h.Search)
+}
+
+// activityContextFrom describes the caller for activity tracking. The user ID is only
+// present on authenticated routes or when OptionalAuth found a valid token.
+func activityContextFrom(c *gin.Context) services.ActivityContext {
+	return services.ActivityContext{
+		UserID:    middleware.GetUserID(c),
+		SessionID: middleware.GetSessionID(c),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// Search godoc
+// @Summary Search across restaurants
+// @Description Search products and restaurants across all restaurants that deliver to the customer's location, grouped by restaurant
+// @Tags search
+// @Produce json
+// @Param q query string true "Search query"
+// @Param lat query number true "Customer latitude"
+// @Param lng query number true "Customer longitude"
+// @Param limit query int false "Maximum number of products" default(50)
+// @Param diet query string false "Only dishes of this diet: veg (which includes vegan), vegan or non_veg"
+// @Param exclude_allergens query string false "Comma separated allergens the dishes must not contain, e.g. peanuts,dairy"
+// @Param max_spice query int false "Hottest spice level to show, 0 to 3"
+// @Param preferences query string false "off to ignore the signed-in user's dietary preferences"
+// @Param X-Session-ID header string false "Client session ID for activity tracking"
+// @Success 200 {object} services.PlatformSearchResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /search [get]
+func (h *SearchHandler) Search(c *gin.Context) {
+	var req services.PlatformSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid search parameters",
+			Message: err.Error(),
+		})
+		return
+	}
+	diet, ok := menuDietaryFilter(c, h.dietaryPreferenceService)
+	if !ok {
+		return
+	}
+	req.Dietary = diet
+
+	response, err := h.searchService.Search(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Search failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resultsCount := 0
+	for _, result := range response.Results {
+		resultsCount += len(result.Products)
+	}
+	h.activityTracker.TrackSearch(activityContextFrom(c), "", req.Query, resultsCount)
+
+	c.JSON(http.StatusOK, response)
+}
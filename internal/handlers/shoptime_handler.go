@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 
 	"golang-food-backend/internal/middleware"
@@ -24,6 +23,9 @@ func NewShopTimeHandler(shopTimeService *services.ShopTimeService) *ShopTimeHand
 func (h *ShopTimeHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
 	// Public routes
 	router.GET("/shop-timing/:restaurant_id", h.GetShopTiming)
+	router.GET("/shop-timing/:restaurant_id/slots", h.GetScheduleSlots)
+	router.GET("/shop-timing/:restaurant_id/status", h.GetShopStatus)
+	router.GET("/shop-timing/:restaurant_id/special-hours", h.ListSpecialHours)
 	router.GET("/time-groups/:group_id/products", h.GetProductsByTime)
 
 	// Protected routes
@@ -33,6 +35,11 @@ func (h *ShopTimeHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware
 		restaurant.PUT("", h.UpdateShopTiming)
 		restaurant.PUT("/status", h.UpdateShopStatus)
 
+		// Holidays and special hours
+		restaurant.POST("/special-hours", h.CreateSpecialHours)
+		restaurant.PUT("/special-hours/:special_hours_id", h.UpdateSpecialHours)
+		restaurant.DELETE("/special-hours/:special_hours_id", h.DeleteSpecialHours)
+
 		// Time group routes
 		timeGroups := restaurant.Group("/time-groups")
 		{
@@ -78,7 +85,7 @@ func (h *ShopTimeHandler) UpdateShopTiming(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	restaurant, err := h.shopTimeService.UpdateShopTiming(ctx, restaurantID, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -112,7 +119,7 @@ func (h *ShopTimeHandler) GetShopTiming(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	restaurant, err := h.shopTimeService.GetShopTiming(ctx, restaurantID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{
@@ -125,6 +132,39 @@ func (h *ShopTimeHandler) GetShopTiming(c *gin.Context) {
 	c.JSON(http.StatusOK, restaurant)
 }
 
+// GetScheduleSlots godoc
+// @Summary Get order-ahead slots
+// @Description Get the time slots a customer can schedule an order for on a given day
+// @Tags shop-timing
+// @Accept json
+// @Produce json
+// @Param restaurant_id path string true "Restaurant ID"
+// @Param date query string false "Date (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} services.ScheduleSlotsResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /shop-timing/{restaurant_id}/slots [get]
+func (h *ShopTimeHandler) GetScheduleSlots(c *gin.Context) {
+	restaurantID := c.Param("restaurant_id")
+	if restaurantID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Restaurant ID is required",
+			Message: "Please provide a valid restaurant ID",
+		})
+		return
+	}
+
+	response, err := h.shopTimeService.GetScheduleSlots(c.Request.Context(), restaurantID, c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to get schedule slots",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // UpdateShopStatus godoc
 // @Summary Update restaurant open/close status
 // @Description Manually update restaurant open/close status
@@ -157,7 +197,7 @@ func (h *ShopTimeHandler) UpdateShopStatus(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	restaurant, err := h.shopTimeService.UpdateShopStatus(ctx, restaurantID, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -170,6 +210,131 @@ func (h *ShopTimeHandler) UpdateShopStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, restaurant)
 }
 
+// GetShopStatus godoc
+// @Summary Get whether a restaurant is open now
+// @Description Get whether a restaurant is open now and why (manual, opening_hours, special_hours or holiday), with its holidays and special hours of the next 30 days
+// @Tags shop-timing
+// @Produce json
+// @Param restaurant_id path string true "Restaurant ID"
+// @Success 200 {object} services.ShopStatusResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /shop-timing/{restaurant_id}/status [get]
+func (h *ShopTimeHandler) GetShopStatus(c *gin.Context) {
+	status, err := h.shopTimeService.GetShopStatus(c.Request.Context(), c.Param("restaurant_id"))
+	if err != nil {
+		abortWithError(c, "Failed to get shop status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// ListSpecialHours godoc
+// @Summary List holidays and special hours
+// @Description List a restaurant's closures and changed hours ending on or after a date
+// @Tags shop-timing
+// @Produce json
+// @Param restaurant_id path string true "Restaurant ID"
+// @Param from query string false "Date (YYYY-MM-DD), defaults to today"
+// @Success 200 {array} models.RestaurantSpecialHours
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /shop-timing/{restaurant_id}/special-hours [get]
+func (h *ShopTimeHandler) ListSpecialHours(c *gin.Context) {
+	entries, err := h.shopTimeService.ListSpecialHours(c.Request.Context(), c.Param("restaurant_id"), c.Query("from"))
+	if err != nil {
+		abortWithError(c, "Failed to list special hours", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// CreateSpecialHours godoc
+// @Summary Add a holiday or special hours
+// @Description Close a restaurant, or set different opening hours, on a range of dates. Special hours replace the weekly opening hours on those dates, for ordering, order-ahead slots and auto open/close.
+// @Tags shop-timing
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param restaurant_id path string true "Restaurant ID"
+// @Param special_hours body services.SpecialHoursRequest true "Special hours"
+// @Success 201 {object} models.RestaurantSpecialHours
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /shop-timing/{restaurant_id}/special-hours [post]
+func (h *ShopTimeHandler) CreateSpecialHours(c *gin.Context) {
+	var req services.SpecialHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	entry, err := h.shopTimeService.CreateSpecialHours(c.Request.Context(), c.Param("restaurant_id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to create special hours", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// UpdateSpecialHours godoc
+// @Summary Update a holiday or special hours
+// @Description Replace the dates and hours of a restaurant's special hours entry
+// @Tags shop-timing
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param restaurant_id path string true "Restaurant ID"
+// @Param special_hours_id path string true "Special hours ID"
+// @Param special_hours body services.SpecialHoursRequest true "Special hours"
+// @Success 200 {object} models.RestaurantSpecialHours
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /shop-timing/{restaurant_id}/special-hours/{special_hours_id} [put]
+func (h *ShopTimeHandler) UpdateSpecialHours(c *gin.Context) {
+	var req services.SpecialHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	entry, err := h.shopTimeService.UpdateSpecialHours(c.Request.Context(), c.Param("restaurant_id"), c.Param("special_hours_id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to update special hours", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// DeleteSpecialHours godoc
+// @Summary Delete a holiday or special hours
+// @Description Remove a special hours entry, so the weekly opening hours apply again on its dates
+// @Tags shop-timing
+// @Security BearerAuth
+// @Produce json
+// @Param restaurant_id path string true "Restaurant ID"
+// @Param special_hours_id path string true "Special hours ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /shop-timing/{restaurant_id}/special-hours/{special_hours_id} [delete]
+func (h *ShopTimeHandler) DeleteSpecialHours(c *gin.Context) {
+	if err := h.shopTimeService.DeleteSpecialHours(c.Request.Context(), c.Param("restaurant_id"), c.Param("special_hours_id")); err != nil {
+		abortWithError(c, "Failed to delete special hours", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Special hours deleted successfully"})
+}
+
 // CreateTimeGroup godoc
 // @Summary Create time-based product group
 // @Description Create a time range group for products (e.g., breakfast, lunch, dinner)
@@ -191,7 +356,7 @@ func (h *ShopTimeHandler) CreateTimeGroup(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	group, err := h.shopTimeService.CreateTimeGroup(ctx, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -224,7 +389,7 @@ func (h *ShopTimeHandler) GetTimeGroups(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	groups, err := h.shopTimeService.GetTimeGroups(ctx, restaurantID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -269,7 +434,7 @@ func (h *ShopTimeHandler) UpdateTimeGroup(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	group, err := h.shopTimeService.UpdateTimeGroup(ctx, groupID, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -304,7 +469,7 @@ func (h *ShopTimeHandler) DeleteTimeGroup(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	if err := h.shopTimeService.DeleteTimeGroup(ctx, groupID); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to delete time group",
@@ -347,7 +512,7 @@ func (h *ShopTimeHandler) AddProductToTimeGroup(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	if err := h.shopTimeService.AddProductToTimeGroup(ctx, groupID, &req); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to add product to time group",
@@ -385,7 +550,7 @@ func (h *ShopTimeHandler) RemoveProductFromTimeGroup(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	if err := h.shopTimeService.RemoveProductFromTimeGroup(ctx, groupID, productID); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to remove product from time group",
@@ -426,7 +591,7 @@ func (h *ShopTimeHandler) GetProductsByTime(c *gin.Context) {
 		targetTime = &timeParam
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	response, err := h.shopTimeService.GetProductsByTime(ctx, restaurantID, targetTime)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+	"golang-food-backend/pkg/sms"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SMSHandler struct {
+	smsService *sms.SMSService
+}
+
+func NewSMSHandler(smsService *sms.SMSService) *SMSHandler {
+	return &SMSHandler{
+		smsService: smsService,
+	}
+}
+
+// RegisterRoutes registers SMS delivery report webhooks and provider metrics
+func (h *SMSHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	// Delivery reports from SMS providers (no auth, verified by the provider adapter)
+	router.POST("/webhooks/sms/:provider", h.DeliveryReport)
+
+	admin := router.Group("/admin/sms",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionReports),
+	)
+	{
+		admin.GET("/metrics", h.GetMetrics)
+	}
+}
+
+// DeliveryReport godoc
+// @Summary SMS delivery report webhook
+// @Description Receive delivery status callbacks from an SMS provider
+// @Tags webhooks
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param provider path string true "Provider key (msg91, twilio)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /webhooks/sms/{provider} [post]
+func (h *SMSHandler) DeliveryReport(c *gin.Context) {
+	processed, err := h.smsService.HandleDeliveryReport(c.Request.Context(), c.Param("provider"), c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to process delivery report",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"processed": processed})
+}
+
+// GetMetrics godoc
+// @Summary SMS provider metrics
+// @Description Per-provider send, failure and delivery counts since the server started (admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/sms/metrics [get]
+func (h *SMSHandler) GetMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.smsService.Metrics()})
+}
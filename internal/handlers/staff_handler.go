@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StaffHandler struct {
+	staffService *services.StaffService
+}
+
+func NewStaffHandler(staffService *services.StaffService) *StaffHandler {
+	return &StaffHandler{
+		staffService: staffService,
+	}
+}
+
+// RegisterRoutes registers the owner staff management routes and the public invitation routes
+func (h *StaffHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	owner := router.Group("/restaurants/:id/staff",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantOwnerRequired(),
+	)
+	{
+		owner.GET("", h.ListStaff)
+		owner.POST("", h.InviteStaff)
+		owner.PUT("/:staff_id/permissions", h.UpdatePermissions)
+		owner.DELETE("/:staff_id", h.RevokeStaff)
+	}
+
+	invitations := router.Group("/staff/invitations")
+	{
+		invitations.POST("/otp", h.SendInviteOTP)
+		invitations.POST("/accept", h.AcceptInvite)
+	}
+}
+
+// ListStaff godoc
+// @Summary List restaurant staff
+// @Description List the staff and open invitations of a restaurant you own, newest first
+// @Tags staff
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Success 200 {array} models.RestaurantStaff
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurants/{id}/staff [get]
+func (h *StaffHandler) ListStaff(c *gin.Context) {
+	staff, err := h.staffService.ListStaff(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to list staff", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, staff)
+}
+
+// InviteStaff godoc
+// @Summary Invite a staff member
+// @Description Invite a phone number to a restaurant you own with menu.edit, orders.manage and/or payouts.view. The invitee gets an OTP to accept with; inviting the same number again updates and resends the invitation.
+// @Tags staff
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param request body services.InviteStaffRequest true "Invitation"
+// @Success 201 {object} models.RestaurantStaff
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurants/{id}/staff [post]
+func (h *StaffHandler) InviteStaff(c *gin.Context) {
+	var req services.InviteStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	staff, err := h.staffService.InviteStaff(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to invite staff member", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, staff)
+}
+
+// UpdatePermissions godoc
+// @Summary Update a staff member's permissions
+// @Description Replace a staff member's permissions. Signed-in staff are signed out so their next sign-in carries the new permissions.
+// @Tags staff
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param staff_id path string true "Staff ID"
+// @Param request body services.UpdateStaffPermissionsRequest true "Permissions"
+// @Success 200 {object} models.RestaurantStaff
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurants/{id}/staff/{staff_id}/permissions [put]
+func (h *StaffHandler) UpdatePermissions(c *gin.Context) {
+	var req services.UpdateStaffPermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	staff, err := h.staffService.UpdatePermissions(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c),
+		c.Param("id"), c.Param("staff_id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to update permissions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, staff)
+}
+
+// RevokeStaff godoc
+// @Summary Remove a staff member
+// @Description Remove a staff member, signing them out everywhere, or cancel an open invitation
+// @Tags staff
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Param staff_id path string true "Staff ID"
+// @Success 204
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurants/{id}/staff/{staff_id} [delete]
+func (h *StaffHandler) RevokeStaff(c *gin.Context) {
+	err := h.staffService.RevokeStaff(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserRole(c), c.Param("id"), c.Param("staff_id"))
+	if err != nil {
+		abortWithError(c, "Failed to remove staff member", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SendInviteOTP godoc
+// @Summary Resend a staff invitation OTP
+// @Description Send a new OTP for an open staff invitation to the invited phone number
+// @Tags staff
+// @Accept json
+// @Produce json
+// @Param request body services.StaffInviteOTPRequest true "Invitation"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} ErrorResponse
+// @Router /staff/invitations/otp [post]
+func (h *StaffHandler) SendInviteOTP(c *gin.Context) {
+	var req services.StaffInviteOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.staffService.SendInviteOTP(c.Request.Context(), &req); err != nil {
+		abortWithError(c, "Failed to send OTP", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "OTP sent"})
+}
+
+// AcceptInvite godoc
+// @Summary Accept a staff invitation
+// @Description Accept a staff invitation with the OTP sent to the invited phone number. Creates a staff account if the number has none and signs it in.
+// @Tags staff
+// @Accept json
+// @Produce json
+// @Param request body services.AcceptStaffInviteRequest true "Invitation and OTP"
+// @Success 200 {object} services.AuthResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /staff/invitations/accept [post]
+func (h *StaffHandler) AcceptInvite(c *gin.Context) {
+	var req services.AcceptStaffInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	response, err := h.staffService.AcceptInvite(c.Request.Context(), &req)
+	if err != nil {
+		abortWithError(c, "Failed to accept invitation", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
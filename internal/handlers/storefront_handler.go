@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StorefrontHandler struct {
+	storefrontService *services.StorefrontService
+}
+
+func NewStorefrontHandler(storefrontService *services.StorefrontService) *StorefrontHandler {
+	return &StorefrontHandler{
+		storefrontService: storefrontService,
+	}
+}
+
+// RegisterRoutes registers the public storefront route
+func (h *StorefrontHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	router.GET("/restaurants/:id/storefront", h.GetStorefront)
+}
+
+// GetStorefront godoc
+// @Summary Get restaurant storefront
+// @Description Get the restaurant, its open status, categories, featured products, banners and currently active time groups in one response
+// @Tags restaurants
+// @Produce json
+// @Param id path string true "Restaurant ID"
+// @Success 200 {object} services.StorefrontResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurants/{id}/storefront [get]
+func (h *StorefrontHandler) GetStorefront(c *gin.Context) {
+	storefront, err := h.storefrontService.GetStorefront(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to get storefront",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, storefront)
+}
@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SupportHandler struct {
+	supportService *services.SupportService
+}
+
+func NewSupportHandler(supportService *services.SupportService) *SupportHandler {
+	return &SupportHandler{
+		supportService: supportService,
+	}
+}
+
+// RegisterRoutes registers the support ticket routes for customers and support agents
+func (h *SupportHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	support := router.Group("/support", authMiddleware.AuthRequired())
+	{
+		support.POST("/tickets", h.CreateTicket)
+		support.GET("/tickets", h.ListMyTickets)
+		support.GET("/tickets/:id", h.GetMyTicket)
+		support.POST("/tickets/:id/messages", h.Reply)
+		support.POST("/tickets/:id/close", h.CloseTicket)
+		support.GET("/tickets/:id/attachments/:media_id", h.GetMyAttachment)
+		support.POST("/attachments", h.CreateUpload)
+	}
+
+	admin := router.Group("/admin/support",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionSupport),
+	)
+	{
+		admin.GET("/tickets", h.ListTickets)
+		admin.GET("/tickets/:id", h.GetTicket)
+		admin.POST("/tickets/:id/assign", h.AssignTicket)
+		admin.POST("/tickets/:id/messages", h.Respond)
+		admin.POST("/tickets/:id/resolve", h.ResolveTicket)
+		admin.GET("/tickets/:id/attachments/:media_id", h.GetAttachment)
+		admin.POST("/attachments", h.CreateUpload)
+	}
+}
+
+// CreateTicket godoc
+// @Summary Raise a support ticket
+// @Description Raise a ticket, usually about one of your orders, with photos or PDFs uploaded through POST /support/attachments. Payment and refund issues get high priority and a one hour first response deadline.
+// @Tags support
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param ticket body services.CreateSupportTicketRequest true "Ticket"
+// @Success 201 {object} services.SupportTicketResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /support/tickets [post]
+func (h *SupportHandler) CreateTicket(c *gin.Context) {
+	var req services.CreateSupportTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ticket, err := h.supportService.CreateTicket(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to create ticket", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, ticket)
+}
+
+// ListMyTickets godoc
+// @Summary List my support tickets
+// @Description List your tickets, most recently active first
+// @Tags support
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "open, awaiting_customer, resolved or closed"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} services.SupportTicketsResponse
+// @Router /support/tickets [get]
+func (h *SupportHandler) ListMyTickets(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	tickets, err := h.supportService.ListMyTickets(c.Request.Context(), middleware.GetUserID(c), c.Query("status"), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list tickets", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tickets)
+}
+
+// GetMyTicket godoc
+// @Summary Get a support ticket
+// @Description Get one of your tickets with its messages
+// @Tags support
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Success 200 {object} services.SupportTicketResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /support/tickets/{id} [get]
+func (h *SupportHandler) GetMyTicket(c *gin.Context) {
+	ticket, err := h.supportService.GetMyTicket(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get ticket", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// Reply godoc
+// @Summary Reply to a support ticket
+// @Description Add a message to your ticket. Replying to a resolved ticket reopens it.
+// @Tags support
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param message body services.SupportMessageRequest true "Message"
+// @Success 200 {object} services.SupportTicketResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /support/tickets/{id}/messages [post]
+func (h *SupportHandler) Reply(c *gin.Context) {
+	var req services.SupportMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ticket, err := h.supportService.Reply(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to reply to ticket", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// CloseTicket godoc
+// @Summary Close a support ticket
+// @Description Close your ticket for good; closed tickets take no more replies
+// @Tags support
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Success 200 {object} services.SupportTicketResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /support/tickets/{id}/close [post]
+func (h *SupportHandler) CloseTicket(c *gin.Context) {
+	ticket, err := h.supportService.CloseTicket(c.Request.Context(), middleware.GetUserID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to close ticket", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// GetMyAttachment godoc
+// @Summary Get a ticket attachment
+// @Description Get a short-lived link to an attachment on your ticket
+// @Tags support
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param media_id path string true "Attachment media ID"
+// @Success 200 {object} services.SupportAttachmentResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /support/tickets/{id}/attachments/{media_id} [get]
+func (h *SupportHandler) GetMyAttachment(c *gin.Context) {
+	attachment, err := h.supportService.MyAttachmentURL(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), c.Param("media_id"))
+	if err != nil {
+		abortWithError(c, "Failed to get attachment", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+// CreateUpload godoc
+// @Summary Upload a ticket attachment
+// @Description Get a presigned URL to PUT a photo or PDF to, then pass its media ID in attachment_ids of a ticket or message. Customers and support agents use the same flow.
+// @Tags support
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param upload body services.SupportUploadRequest true "File to upload"
+// @Success 201 {object} services.UploadResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /support/attachments [post]
+func (h *SupportHandler) CreateUpload(c *gin.Context) {
+	var req services.SupportUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	upload, err := h.supportService.CreateUpload(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to create upload", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, upload)
+}
+
+// ListTickets godoc
+// @Summary List the support queue
+// @Description List tickets, most recently active first, with their SLA status (admin with support.manage)
+// @Tags support
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "open, awaiting_customer, resolved or closed"
+// @Param priority query string false "low, normal, high or urgent"
+// @Param issue_type query string false "Issue type"
+// @Param assigned_to query string false "Admin ID, me or unassigned"
+// @Param sla_breached query bool false "Only unresolved tickets past a deadline"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} services.SupportTicketsResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/support/tickets [get]
+func (h *SupportHandler) ListTickets(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	breached, _ := strconv.ParseBool(c.Query("sla_breached"))
+
+	assignedTo := c.Query("assigned_to")
+	if assignedTo == "me" {
+		assignedTo = middleware.GetUserID(c)
+	}
+
+	tickets, err := h.supportService.ListTickets(c.Request.Context(), services.SupportTicketQuery{
+		Status:      c.Query("status"),
+		Priority:    c.Query("priority"),
+		IssueType:   c.Query("issue_type"),
+		AssignedTo:  assignedTo,
+		SLABreached: breached,
+		Page:        page,
+		Limit:       limit,
+	})
+	if err != nil {
+		abortWithError(c, "Failed to list tickets", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tickets)
+}
+
+// GetTicket godoc
+// @Summary Get a support ticket (admin)
+// @Description Get a ticket with its whole thread, internal notes included
+// @Tags support
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Success 200 {object} services.SupportTicketResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/support/tickets/{id} [get]
+func (h *SupportHandler) GetTicket(c *gin.Context) {
+	ticket, err := h.supportService.GetTicket(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get ticket", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// AssignTicket godoc
+// @Summary Assign a support ticket
+// @Description Assign the ticket to an active admin with support.manage, or to yourself
+// @Tags support
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param assignment body services.AssignSupportTicketRequest false "Agent"
+// @Success 200 {object} services.SupportTicketResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/support/tickets/{id}/assign [post]
+func (h *SupportHandler) AssignTicket(c *gin.Context) {
+	var req services.AssignSupportTicketRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid request body",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	ticket, err := h.supportService.AssignTicket(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to assign ticket", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// Respond godoc
+// @Summary Respond to a support ticket
+// @Description Reply to the customer, who is notified, or add an internal note they do not see. The first reply meets the first response deadline.
+// @Tags support
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param message body services.SupportMessageRequest true "Message"
+// @Success 200 {object} services.SupportTicketResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/support/tickets/{id}/messages [post]
+func (h *SupportHandler) Respond(c *gin.Context) {
+	var req services.SupportMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ticket, err := h.supportService.Respond(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to respond to ticket", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// ResolveTicket godoc
+// @Summary Resolve a support ticket
+// @Description Mark the ticket resolved with what was done about it; the customer is notified and can reopen it by replying
+// @Tags support
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param resolution body services.ResolveSupportTicketRequest true "Resolution"
+// @Success 200 {object} services.SupportTicketResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/support/tickets/{id}/resolve [post]
+func (h *SupportHandler) ResolveTicket(c *gin.Context) {
+	var req services.ResolveSupportTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ticket, err := h.supportService.ResolveTicket(c.Request.Context(), middleware.GetUserID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to resolve ticket", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// GetAttachment godoc
+// @Summary Get a ticket attachment (admin)
+// @Description Get a short-lived link to any attachment on the ticket
+// @Tags support
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param media_id path string true "Attachment media ID"
+// @Success 200 {object} services.SupportAttachmentResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/support/tickets/{id}/attachments/{media_id} [get]
+func (h *SupportHandler) GetAttachment(c *gin.Context) {
+	attachment, err := h.supportService.AttachmentURL(c.Request.Context(), c.Param("id"), c.Param("media_id"))
+	if err != nil {
+		abortWithError(c, "Failed to get attachment", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TableHandler struct {
+	tableService *services.TableService
+}
+
+func NewTableHandler(tableService *services.TableService) *TableHandler {
+	return &TableHandler{
+		tableService: tableService,
+	}
+}
+
+// RegisterRoutes registers the dine-in table routes: the QR code lookup for customers and table
+// and tab management for restaurant staff
+func (h *TableHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	router.GET("/tables/:token", h.GetTableInfo)
+	router.GET("/tables/:token/tab", authMiddleware.AuthRequired(), h.GetTableTab)
+
+	restaurant := router.Group("/restaurant/tables",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantRequired(),
+		authMiddleware.RestaurantStaffRequired(),
+		authMiddleware.RestaurantPermissionRequired(services.StaffPermissionOrders),
+	)
+	{
+		restaurant.GET("", h.ListTables)
+		restaurant.POST("", h.CreateTable)
+		restaurant.PUT("/:id", h.UpdateTable)
+		restaurant.DELETE("/:id", h.DeleteTable)
+		restaurant.POST("/:id/qr/rotate", h.RotateQRCode)
+		restaurant.GET("/:id/tab", h.GetOpenTab)
+		restaurant.POST("/:id/tab/close", h.CloseTab)
+	}
+}
+
+// GetTableInfo godoc
+// @Summary Look up a table QR code
+// @Description The restaurant and table a scanned QR code belongs to, and whether dine-in orders can be placed at it. Dine-in checkout takes the token as table_token.
+// @Tags tables
+// @Produce json
+// @Param token path string true "Token from the table's QR code"
+// @Success 200 {object} services.TableInfo
+// @Failure 404 {object} ErrorResponse
+// @Router /tables/{token} [get]
+func (h *TableHandler) GetTableInfo(c *gin.Context) {
+	info, err := h.tableService.GetTableInfo(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		abortWithError(c, "Failed to get table", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// GetTableTab godoc
+// @Summary Running tab of a table
+// @Description The open tab of the table whose QR code was scanned, with every round ordered at the table so far and the running total
+// @Tags tables
+// @Security BearerAuth
+// @Produce json
+// @Param token path string true "Token from the table's QR code"
+// @Success 200 {object} services.TabResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /tables/{token}/tab [get]
+func (h *TableHandler) GetTableTab(c *gin.Context) {
+	tab, err := h.tableService.GetTableTab(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		abortWithError(c, "Failed to get tab", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tab)
+}
+
+// ListTables godoc
+// @Summary List tables
+// @Description The restaurant's tables with their QR code links and open tabs (staff with orders.manage)
+// @Tags tables
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} services.TableResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /restaurant/tables [get]
+func (h *TableHandler) ListTables(c *gin.Context) {
+	tables, err := h.tableService.ListTables(c.Request.Context(), middleware.GetRestaurantID(c))
+	if err != nil {
+		abortWithError(c, "Failed to list tables", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tables)
+}
+
+// CreateTable godoc
+// @Summary Add a table
+// @Description Add a table with a new QR code; table numbers are unique per restaurant (staff with orders.manage)
+// @Tags tables
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.TableRequest true "Table"
+// @Success 201 {object} services.TableResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /restaurant/tables [post]
+func (h *TableHandler) CreateTable(c *gin.Context) {
+	var req services.TableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	table, err := h.tableService.CreateTable(c.Request.Context(), middleware.GetRestaurantID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to create table", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, table)
+}
+
+// UpdateTable godoc
+// @Summary Update a table
+// @Description Replace a table's number, seats and active flag; inactive tables take no orders (staff with orders.manage)
+// @Tags tables
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Table ID"
+// @Param request body services.TableRequest true "Table"
+// @Success 200 {object} services.TableResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /restaurant/tables/{id} [put]
+func (h *TableHandler) UpdateTable(c *gin.Context) {
+	var req services.TableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	table, err := h.tableService.UpdateTable(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to update table", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, table)
+}
+
+// DeleteTable godoc
+// @Summary Remove a table
+// @Description Remove a table whose tab is closed; past orders keep its number (staff with orders.manage)
+// @Tags tables
+// @Security BearerAuth
+// @Param id path string true "Table ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /restaurant/tables/{id} [delete]
+func (h *TableHandler) DeleteTable(c *gin.Context) {
+	if err := h.tableService.DeleteTable(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id")); err != nil {
+		abortWithError(c, "Failed to remove table", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RotateQRCode godoc
+// @Summary Replace a table's QR code
+// @Description Give the table a new QR token; codes printed with the old one stop working (staff with orders.manage)
+// @Tags tables
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Table ID"
+// @Success 200 {object} services.TableResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurant/tables/{id}/qr/rotate [post]
+func (h *TableHandler) RotateQRCode(c *gin.Context) {
+	table, err := h.tableService.RotateQRCode(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to replace QR code", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, table)
+}
+
+// GetOpenTab godoc
+// @Summary Table's open tab
+// @Description The rounds ordered at the table since its tab was opened, with their statuses and the running total (staff with orders.manage)
+// @Tags tables
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Table ID"
+// @Success 200 {object} services.TabResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurant/tables/{id}/tab [get]
+func (h *TableHandler) GetOpenTab(c *gin.Context) {
+	tab, err := h.tableService.GetOpenTab(c.Request.Context(), middleware.GetRestaurantID(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, "Failed to get tab", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tab)
+}
+
+// CloseTab godoc
+// @Summary Close a table's tab
+// @Description Settle the table's running tab with the payment taken (cash, card or upi) once every round is served or cancelled. The rounds' payments are marked paid and the next order at the table opens a new tab (staff with orders.manage).
+// @Tags tables
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Table ID"
+// @Param request body services.CloseTabRequest true "Payment taken"
+// @Success 200 {object} services.TabResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /restaurant/tables/{id}/tab/close [post]
+func (h *TableHandler) CloseTab(c *gin.Context) {
+	var req services.CloseTabRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	tab, err := h.tableService.CloseTab(c.Request.Context(), middleware.GetUserID(c), middleware.GetRestaurantID(c), c.Param("id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to close tab", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tab)
+}
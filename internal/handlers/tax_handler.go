@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TaxHandler struct {
+	taxService *services.TaxService
+}
+
+func NewTaxHandler(taxService *services.TaxService) *TaxHandler {
+	return &TaxHandler{
+		taxService: taxService,
+	}
+}
+
+// RegisterRoutes registers restaurant tax configuration routes
+func (h *TaxHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	restaurant := router.Group("/restaurant", authMiddleware.AuthRequired(), authMiddleware.RestaurantRequired())
+	{
+		restaurant.GET("/tax-config", authMiddleware.RestaurantStaffRequired(), h.GetTaxConfig)
+		restaurant.PUT("/tax-config", authMiddleware.RestaurantOwnerRequired(), h.UpdateTaxConfig)
+	}
+}
+
+// GetTaxConfig godoc
+// @Summary Get restaurant tax configuration
+// @Description Get the GST rates, pricing mode and fees applied to the restaurant's bills
+// @Tags restaurants
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.RestaurantTaxConfig
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurant/tax-config [get]
+func (h *TaxHandler) GetTaxConfig(c *gin.Context) {
+	restaurantID, err := uuid.Parse(middleware.GetRestaurantID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid restaurant ID",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.taxService.GetConfig(c.Request.Context(), restaurantID))
+}
+
+// UpdateTaxConfig godoc
+// @Summary Update restaurant tax configuration
+// @Description Set the default and per-category GST rates, tax-inclusive pricing, packaging and platform fees
+// @Tags restaurants
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body services.UpdateTaxConfigRequest true "Tax settings"
+// @Success 200 {object} models.RestaurantTaxConfig
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurant/tax-config [put]
+func (h *TaxHandler) UpdateTaxConfig(c *gin.Context) {
+	var req services.UpdateTaxConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	config, err := h.taxService.UpdateConfig(c.Request.Context(), middleware.GetRestaurantID(c), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to update tax configuration",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
@@ -1,11 +1,11 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 
 	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/models"
 	"golang-food-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -41,6 +41,9 @@ func NewTimeBasedProductHandler(
 // @Param price_min query number false "Minimum price filter"
 // @Param price_max query number false "Maximum price filter"
 // @Param availability query boolean false "Filter by availability"
+// @Param diet query string false "Only dishes of this diet: veg (which includes vegan), vegan or non_veg"
+// @Param exclude_allergens query string false "Comma separated allergens the dishes must not contain, e.g. peanuts,dairy"
+// @Param max_spice query int false "Hottest spice level to show, 0 to 3"
 // @Param page query int false "Page number (default: 1)"
 // @Param limit query int false "Items per page (default: 20)"
 // @Success 200 {object} services.TimeBasedProductsResponse
@@ -85,7 +88,7 @@ func (h *TimeBasedProductHandler) GetProductsByTimeAdvanced(c *gin.Context) {
 
 	// Parse price range
 	if priceMinStr := c.Query("price_min"); priceMinStr != "" {
-		if priceMin, err := strconv.ParseFloat(priceMinStr, 64); err == nil {
+		if priceMin, err := models.ParseMoney(priceMinStr); err == nil {
 			if req.PriceRange == nil {
 				req.PriceRange = &services.PriceFilter{}
 			}
@@ -94,7 +97,7 @@ func (h *TimeBasedProductHandler) GetProductsByTimeAdvanced(c *gin.Context) {
 	}
 
 	if priceMaxStr := c.Query("price_max"); priceMaxStr != "" {
-		if priceMax, err := strconv.ParseFloat(priceMaxStr, 64); err == nil {
+		if priceMax, err := models.ParseMoney(priceMaxStr); err == nil {
 			if req.PriceRange == nil {
 				req.PriceRange = &services.PriceFilter{}
 			}
@@ -109,6 +112,13 @@ func (h *TimeBasedProductHandler) GetProductsByTimeAdvanced(c *gin.Context) {
 		}
 	}
 
+	// Parse dietary filters; this listing does not apply saved preferences
+	diet, ok := menuDietaryFilter(c, nil)
+	if !ok {
+		return
+	}
+	req.Dietary = diet
+
 	// Parse pagination
 	req.Page = 1
 	req.Limit = 20
@@ -126,7 +136,7 @@ func (h *TimeBasedProductHandler) GetProductsByTimeAdvanced(c *gin.Context) {
 	}
 
 	// Call service
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	response, err := h.timeBasedProductService.GetProductsByTimeAdvanced(ctx, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -161,7 +171,7 @@ func (h *TimeBasedProductHandler) ForceRestaurantStatusUpdate(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	if err := h.enhancedCronService.ForceStatusUpdate(ctx, restaurantID); err != nil {
 		if err.Error() == "restaurant not found" {
 			c.JSON(http.StatusNotFound, ErrorResponse{
@@ -215,7 +225,7 @@ func (h *TimeBasedProductHandler) GetRestaurantStatusHistory(c *gin.Context) {
 		}
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	history, err := h.enhancedCronService.GetRestaurantStatusHistory(ctx, restaurantID, days)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
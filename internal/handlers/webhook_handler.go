@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang-food-backend/internal/middleware"
+	"golang-food-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService     *services.WebhookService
+	featureFlagService *services.FeatureFlagService
+}
+
+func NewWebhookHandler(webhookService *services.WebhookService, featureFlagService *services.FeatureFlagService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService:     webhookService,
+		featureFlagService: featureFlagService,
+	}
+}
+
+// webhookRestaurantID is the restaurant in the admin route's path, or the owner's restaurant
+func webhookRestaurantID(c *gin.Context) string {
+	if restaurantID := c.Param("id"); restaurantID != "" {
+		return restaurantID
+	}
+	return middleware.GetRestaurantID(c)
+}
+
+// RegisterRoutes registers the owner webhook routes, available to restaurants with the
+// restaurant_webhooks feature, and their admin equivalents for any restaurant
+func (h *WebhookHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	owner := router.Group("/restaurant/webhooks",
+		authMiddleware.AuthRequired(),
+		authMiddleware.RestaurantRequired(),
+		authMiddleware.RestaurantOwnerRequired(),
+		middleware.FeatureRequired(h.featureFlagService, services.FeatureRestaurantWebhooks),
+	)
+	h.registerSubscriptionRoutes(owner)
+
+	admin := router.Group("/admin/restaurants/:id/webhooks",
+		authMiddleware.AuthRequired(),
+		authMiddleware.AdminPermissionRequired(services.AdminPermissionRestaurants),
+	)
+	h.registerSubscriptionRoutes(admin)
+}
+
+func (h *WebhookHandler) registerSubscriptionRoutes(group *gin.RouterGroup) {
+	group.GET("", h.ListSubscriptions)
+	group.POST("", h.CreateSubscription)
+	group.PUT("/:webhook_id", h.UpdateSubscription)
+	group.DELETE("/:webhook_id", h.DeleteSubscription)
+	group.POST("/:webhook_id/ping", h.Ping)
+	group.POST("/:webhook_id/rotate-secret", h.RotateSecret)
+	group.GET("/:webhook_id/deliveries", h.ListDeliveries)
+}
+
+// ListSubscriptions godoc
+// @Summary List webhook subscriptions
+// @Description List the restaurant's POS webhook subscriptions. Secrets are not returned.
+// @Tags webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param id path string false "Restaurant ID (admin route)"
+// @Success 200 {array} models.WebhookSubscription
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurant/webhooks [get]
+// @Router /admin/restaurants/{id}/webhooks [get]
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	subscriptions, err := h.webhookService.ListSubscriptions(c.Request.Context(), webhookRestaurantID(c))
+	if err != nil {
+		abortWithError(c, "Failed to list webhooks", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// CreateSubscription godoc
+// @Summary Register a webhook
+// @Description Register an HTTPS callback URL for order.created, order.cancelled and payment.captured events. Each delivery is signed in the X-Webhook-Signature header as t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>">, keyed with the secret returned here only once.
+// @Tags webhooks
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string false "Restaurant ID (admin route)"
+// @Param request body services.WebhookSubscriptionRequest true "Webhook subscription"
+// @Success 201 {object} services.WebhookSecretResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /restaurant/webhooks [post]
+// @Router /admin/restaurants/{id}/webhooks [post]
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var req services.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	subscription, err := h.webhookService.CreateSubscription(c.Request.Context(), webhookRestaurantID(c), middleware.GetUserID(c), &req)
+	if err != nil {
+		abortWithError(c, "Failed to create webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+// UpdateSubscription godoc
+// @Summary Update a webhook
+// @Description Change a webhook's URL, event types or description, or pause and resume it with is_active
+// @Tags webhooks
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string false "Restaurant ID (admin route)"
+// @Param webhook_id path string true "Webhook ID"
+// @Param request body services.WebhookSubscriptionRequest true "Webhook subscription"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurant/webhooks/{webhook_id} [put]
+// @Router /admin/restaurants/{id}/webhooks/{webhook_id} [put]
+func (h *WebhookHandler) UpdateSubscription(c *gin.Context) {
+	var req services.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	subscription, err := h.webhookService.UpdateSubscription(c.Request.Context(), webhookRestaurantID(c), c.Param("webhook_id"), &req)
+	if err != nil {
+		abortWithError(c, "Failed to update webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// DeleteSubscription godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook and its delivery log; pending deliveries are dropped
+// @Tags webhooks
+// @Security BearerAuth
+// @Param id path string false "Restaurant ID (admin route)"
+// @Param webhook_id path string true "Webhook ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurant/webhooks/{webhook_id} [delete]
+// @Router /admin/restaurants/{id}/webhooks/{webhook_id} [delete]
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	if err := h.webhookService.DeleteSubscription(c.Request.Context(), webhookRestaurantID(c), c.Param("webhook_id")); err != nil {
+		abortWithError(c, "Failed to delete webhook", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Ping godoc
+// @Summary Send a test ping
+// @Description Send a signed ping event to the webhook now, once, and return the logged delivery with the endpoint's response
+// @Tags webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param id path string false "Restaurant ID (admin route)"
+// @Param webhook_id path string true "Webhook ID"
+// @Success 200 {object} models.WebhookDelivery
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurant/webhooks/{webhook_id}/ping [post]
+// @Router /admin/restaurants/{id}/webhooks/{webhook_id}/ping [post]
+func (h *WebhookHandler) Ping(c *gin.Context) {
+	delivery, err := h.webhookService.Ping(c.Request.Context(), webhookRestaurantID(c), c.Param("webhook_id"))
+	if err != nil {
+		abortWithError(c, "Failed to ping webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// RotateSecret godoc
+// @Summary Rotate a webhook's secret
+// @Description Replace the webhook's signing secret; the new secret is returned only here
+// @Tags webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param id path string false "Restaurant ID (admin route)"
+// @Param webhook_id path string true "Webhook ID"
+// @Success 200 {object} services.WebhookSecretResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurant/webhooks/{webhook_id}/rotate-secret [post]
+// @Router /admin/restaurants/{id}/webhooks/{webhook_id}/rotate-secret [post]
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	subscription, err := h.webhookService.RotateSecret(c.Request.Context(), webhookRestaurantID(c), c.Param("webhook_id"))
+	if err != nil {
+		abortWithError(c, "Failed to rotate webhook secret", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// ListDeliveries godoc
+// @Summary List webhook deliveries
+// @Description List a webhook's delivery log, newest first, with attempts, response status and last error
+// @Tags webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param id path string false "Restaurant ID (admin route)"
+// @Param webhook_id path string true "Webhook ID"
+// @Param status query string false "pending, delivered or failed"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} services.WebhookDeliveriesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /restaurant/webhooks/{webhook_id}/deliveries [get]
+// @Router /admin/restaurants/{id}/webhooks/{webhook_id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), webhookRestaurantID(c), c.Param("webhook_id"), c.Query("status"), page, limit)
+	if err != nil {
+		abortWithError(c, "Failed to list webhook deliveries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"golang-food-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// auditBodyLimit is how much of a create response is kept to read the new entity's ID
+const auditBodyLimit = 64 << 10
+
+// AuditRecorder loads entity snapshots and stores audit entries
+type AuditRecorder interface {
+	// Snapshot returns the current state of the entity, or nil when it cannot be loaded
+	Snapshot(ctx context.Context, entityType, entityID string) interface{}
+	// Record stores the entry with the changes between the snapshots
+	Record(ctx context.Context, entry *models.AuditLog, before, after interface{})
+}
+
+// AuditRoute marks the routes under Prefix (a route pattern, such as /api/v1/coupons) as
+// changing entities of EntityType, identified by the IDParam path parameter. Routes without the
+// parameter create an entity, whose ID is read from the "id" of the response. An empty
+// EntityType leaves the routes under Prefix unaudited.
+type AuditRoute struct {
+	Prefix     string
+	EntityType string
+	IDParam    string
+}
+
+// Audit records the successful POST, PUT, PATCH and DELETE requests on the routes with the
+// entity's state before and after the request. The first route whose prefix matches applies.
+// The actor is read after the handlers run, so the middleware can sit before the auth middleware.
+func Audit(recorder AuditRecorder, routes ...AuditRoute) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		route, ok := matchAuditRoute(c.FullPath(), routes)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		entityID := ""
+		if route.IDParam != "" {
+			entityID = c.Param(route.IDParam)
+		}
+
+		var before interface{}
+		writer := &auditWriter{ResponseWriter: c.Writer}
+		if entityID != "" {
+			before = recorder.Snapshot(c.Request.Context(), route.EntityType, entityID)
+		} else {
+			writer.capture = true
+		}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		status := c.Writer.Status()
+		if len(c.Errors) > 0 || status < http.StatusOK || status >= http.StatusMultipleChoices {
+			return
+		}
+		if entityID == "" {
+			entityID = writer.createdID()
+			if entityID == "" {
+				return
+			}
+		}
+
+		entry := &models.AuditLog{
+			EntityType: route.EntityType,
+			EntityID:   entityID,
+			Action:     auditAction(c.Request.Method, strings.TrimPrefix(c.FullPath(), route.Prefix)),
+			Metadata: models.JSONB{
+				"method":     c.Request.Method,
+				"route":      c.FullPath(),
+				"status":     status,
+				"request_id": GetRequestID(c),
+			},
+		}
+		if actorID, err := uuid.Parse(GetUserID(c)); err == nil {
+			entry.PerformedBy = &actorID
+		}
+		if role := GetUserRole(c); role != "" {
+			entry.Metadata["actor_role"] = role
+		}
+		recorder.Record(c.Request.Context(), entry, before, recorder.Snapshot(c.Request.Context(), route.EntityType, entityID))
+	}
+}
+
+func matchAuditRoute(fullPath string, routes []AuditRoute) (AuditRoute, bool) {
+	for _, route := range routes {
+		if fullPath == route.Prefix || strings.HasPrefix(fullPath, strings.TrimSuffix(route.Prefix, "/")+"/") {
+			return route, route.EntityType != ""
+		}
+	}
+	return AuditRoute{}, false
+}
+
+// auditAction names the action of a request from its method and the route after the entity:
+// create, update or delete on the entity itself, and the path on sub-routes, such as restore,
+// status.update or staff.delete
+func auditAction(method, rest string) string {
+	var segments []string
+	for _, segment := range strings.Split(rest, "/") {
+		if segment != "" && !strings.HasPrefix(segment, ":") {
+			segments = append(segments, segment)
+		}
+	}
+
+	verb := "create"
+	switch method {
+	case http.MethodPut, http.MethodPatch:
+		verb = "update"
+	case http.MethodDelete:
+		verb = "delete"
+	}
+	if len(segments) == 0 {
+		return verb
+	}
+	if verb != "create" {
+		segments = append(segments, verb)
+	}
+	return strings.Join(segments, ".")
+}
+
+// auditWriter keeps the start of a create response to find the new entity's ID
+type auditWriter struct {
+	gin.ResponseWriter
+	capture bool
+	body    bytes.Buffer
+}
+
+func (w *auditWriter) Write(data []byte) (int, error) {
+	if w.capture && w.body.Len() < auditBodyLimit {
+		w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *auditWriter) createdID() string {
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(w.body.Bytes(), &created); err != nil {
+		return ""
+	}
+	return created.ID
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
@@ -9,12 +10,21 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// SessionChecker reports whether a login session was revoked before its tokens expired
+type SessionChecker interface {
+	IsSessionRevoked(ctx context.Context, sessionID string) bool
+}
+
 type AuthMiddleware struct {
 	jwtManager *auth.JWTManager
+	sessions   SessionChecker
 }
 
-func NewAuthMiddleware(jwtManager *auth.JWTManager) *AuthMiddleware {
-	return &AuthMiddleware{jwtManager: jwtManager}
+func NewAuthMiddleware(jwtManager *auth.JWTManager, sessions SessionChecker) *AuthMiddleware {
+	return &AuthMiddleware{
+		jwtManager: jwtManager,
+		sessions:   sessions,
+	}
 }
 
 // AuthRequired middleware validates JWT token
@@ -22,21 +32,27 @@ func (a *AuthMiddleware) AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": Translate(c, "Authorization header required")})
 			c.Abort()
 			return
 		}
 
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": Translate(c, "Invalid authorization header format")})
 			c.Abort()
 			return
 		}
 
 		claims, err := a.jwtManager.ValidateToken(tokenParts[1])
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": Translate(c, "Invalid token")})
+			c.Abort()
+			return
+		}
+
+		if a.sessionRevoked(c, claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": Translate(c, "Session has been revoked")})
 			c.Abort()
 			return
 		}
@@ -46,29 +62,58 @@ func (a *AuthMiddleware) AuthRequired() gin.HandlerFunc {
 		c.Set("restaurant_id", claims.RestaurantID)
 		c.Set("role", claims.Role)
 		c.Set("email", claims.Email)
+		c.Set("scope", claims.Scope)
+		c.Set("permissions", claims.Permissions)
+		c.Set("auth_session_id", claims.SessionID)
 		c.Next()
 	}
 }
 
-// RestaurantRequired middleware ensures the user belongs to a restaurant
-func (a *AuthMiddleware) RestaurantRequired() gin.HandlerFunc {
+// OptionalAuth sets user information in context when a valid bearer token is present,
+// but lets anonymous requests through on public routes
+func (a *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		restaurantID, exists := c.Get("restaurant_id")
-		if !exists || restaurantID == "" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Restaurant access required"})
-			c.Abort()
-			return
+		tokenParts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(tokenParts) == 2 && tokenParts[0] == "Bearer" {
+			if claims, err := a.jwtManager.ValidateToken(tokenParts[1]); err == nil && !a.sessionRevoked(c, claims) {
+				c.Set("user_id", claims.UserID)
+				c.Set("restaurant_id", claims.RestaurantID)
+				c.Set("role", claims.Role)
+				c.Set("email", claims.Email)
+				c.Set("scope", claims.Scope)
+				c.Set("permissions", claims.Permissions)
+				c.Set("auth_session_id", claims.SessionID)
+			}
 		}
 		c.Next()
 	}
 }
 
+func (a *AuthMiddleware) sessionRevoked(c *gin.Context, claims *auth.Claims) bool {
+	return claims.SessionID != "" && a.sessions != nil && a.sessions.IsSessionRevoked(c.Request.Context(), claims.SessionID)
+}
+
+// StreamAuthRequired behaves like AuthRequired but also accepts the token as an
+// access_token query parameter, since browser EventSource and WebSocket clients cannot set
+// headers
+func (a *AuthMiddleware) StreamAuthRequired() gin.HandlerFunc {
+	authRequired := a.AuthRequired()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		authRequired(c)
+	}
+}
+
 // RoleRequired middleware checks if user has required role
 func (a *AuthMiddleware) RoleRequired(requiredRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
 		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Role information missing"})
+			c.JSON(http.StatusForbidden, gin.H{"error": Translate(c, "Role information missing")})
 			c.Abort()
 			return
 		}
@@ -81,7 +126,7 @@ func (a *AuthMiddleware) RoleRequired(requiredRoles ...string) gin.HandlerFunc {
 			}
 		}
 
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		c.JSON(http.StatusForbidden, gin.H{"error": Translate(c, "Insufficient permissions")})
 		c.Abort()
 	}
 }
@@ -91,6 +136,41 @@ func (a *AuthMiddleware) AdminRequired() gin.HandlerFunc {
 	return a.RoleRequired("admin")
 }
 
+// AdminLoginRequired middleware ensures the token was issued by admin login rather
+// than to an app user with the admin role
+func (a *AuthMiddleware) AdminLoginRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scope, _ := c.Get("scope"); scope != auth.AdminScope {
+			c.JSON(http.StatusForbidden, gin.H{"error": Translate(c, "Admin login required")})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// AdminPermissionRequired middleware ensures the admin-scoped token grants the
+// permission, either directly or through the "*" wildcard
+func (a *AuthMiddleware) AdminPermissionRequired(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scope, _ := c.Get("scope"); scope != auth.AdminScope {
+			c.JSON(http.StatusForbidden, gin.H{"error": Translate(c, "Admin login required")})
+			c.Abort()
+			return
+		}
+
+		for _, granted := range GetPermissions(c) {
+			if granted == permission || granted == "*" {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": Translate(c, "Insufficient permissions")})
+		c.Abort()
+	}
+}
+
 // RestaurantOwnerRequired middleware ensures user is a restaurant owner
 func (a *AuthMiddleware) RestaurantOwnerRequired() gin.HandlerFunc {
 	return a.RoleRequired("restaurant_owner", "admin")
@@ -101,6 +181,32 @@ func (a *AuthMiddleware) RestaurantStaffRequired() gin.HandlerFunc {
 	return a.RoleRequired("restaurant_staff", "restaurant_owner", "admin")
 }
 
+// RestaurantPermissionRequired middleware lets restaurant owners and admins through and
+// requires restaurant staff to hold the permission their owner granted them
+func (a *AuthMiddleware) RestaurantPermissionRequired(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if GetUserRole(c) != "restaurant_staff" {
+			c.Next()
+			return
+		}
+
+		for _, granted := range GetPermissions(c) {
+			if granted == permission {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": Translate(c, "Insufficient permissions")})
+		c.Abort()
+	}
+}
+
+// RiderRequired middleware ensures user is a restaurant delivery rider
+func (a *AuthMiddleware) RiderRequired() gin.HandlerFunc {
+	return a.RoleRequired("rider")
+}
+
 // GetUserID helper function to extract user ID from context
 func GetUserID(c *gin.Context) string {
 	if userID, exists := c.Get("user_id"); exists {
@@ -109,6 +215,14 @@ func GetUserID(c *gin.Context) string {
 	return ""
 }
 
+// GetAuthSessionID helper function to extract the login session ID from context
+func GetAuthSessionID(c *gin.Context) string {
+	if sessionID, exists := c.Get("auth_session_id"); exists {
+		return sessionID.(string)
+	}
+	return ""
+}
+
 // GetRestaurantID helper function to extract restaurant ID from context
 func GetRestaurantID(c *gin.Context) string {
 	if restaurantID, exists := c.Get("restaurant_id"); exists {
@@ -117,6 +231,16 @@ func GetRestaurantID(c *gin.Context) string {
 	return ""
 }
 
+// GetPermissions helper function to extract admin permissions from context
+func GetPermissions(c *gin.Context) []string {
+	if permissions, exists := c.Get("permissions"); exists {
+		if list, ok := permissions.([]string); ok {
+			return list
+		}
+	}
+	return nil
+}
+
 // GetUserRole helper function to extract user role from context
 func GetUserRole(c *gin.Context) string {
 	if role, exists := c.Get("role"); exists {
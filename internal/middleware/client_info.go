@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"golang-food-backend/pkg/clientinfo"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DeviceIDHeader       = "X-Device-ID"
+	ClientLocationHeader = "X-Client-Location" // "lat,lng" from the device's location services
+	maxDeviceIDLen       = 128
+)
+
+// ClientInfo middleware puts the client's device ID, location and IP address in the request
+// context, where the fraud rules read them at checkout and refund request
+func ClientInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.GetHeader(DeviceIDHeader)
+		if len(deviceID) > maxDeviceIDLen {
+			deviceID = deviceID[:maxDeviceIDLen]
+		}
+		c.Request = c.Request.WithContext(clientinfo.WithInfo(c.Request.Context(), clientinfo.Info{
+			DeviceID:  deviceID,
+			IPAddress: c.ClientIP(),
+			Location:  clientinfo.ParseLocation(c.GetHeader(ClientLocationHeader)),
+		}))
+		c.Next()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -16,7 +17,7 @@ func CORSMiddleware() gin.HandlerFunc {
 		AllowOrigins:     []string{"*"}, // Configure based on your frontend domains
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"*"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "X-Total-Count"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	})
@@ -45,20 +46,41 @@ func RecoveryMiddleware() gin.HandlerFunc {
 		if err, ok := recovered.(string); ok {
 			log.Printf("Panic recovered: %s", err)
 		}
-		c.AbortWithStatusJSON(500, gin.H{"error": "Internal server error"})
+		c.AbortWithStatusJSON(500, gin.H{"error": Translate(c, "Internal server error")})
 	})
 }
 
-// RequestIDMiddleware adds a unique request ID to each request
+// RequestIDMiddleware adds a unique request ID to each request. An X-Request-ID sent by a
+// gateway or client is kept so a request can be followed across services.
 func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestID := generateRequestID()
+		requestID := c.GetHeader("X-Request-ID")
+		if !validRequestID(requestID) {
+			requestID = generateRequestID()
+		}
 		c.Set("request_id", requestID)
 		c.Header("X-Request-ID", requestID)
 		c.Next()
 	}
 }
 
+// GetRequestID helper function to extract the request ID from context
+func GetRequestID(c *gin.Context) string {
+	return c.GetString("request_id")
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+			return false
+		}
+	}
+	return true
+}
+
 func generateRequestID() string {
 	return fmt.Sprintf("%d-%d", time.Now().UnixNano(), rand.Intn(1000))
 }
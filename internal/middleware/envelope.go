@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"golang-food-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Envelope wraps the JSON responses of the handlers after it in the v2 envelope, so the v1
+// handlers serve /api/v2 unchanged. Responses that are not JSON (invoices, exports, streams)
+// pass through as they are.
+func Envelope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &envelopeWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.passthrough {
+			return
+		}
+		if !writer.buffered {
+			// Nothing but a status, such as 204
+			if writer.statusSet {
+				c.Writer.WriteHeader(writer.status)
+				c.Writer.WriteHeaderNow()
+			}
+			return
+		}
+
+		envelope := response.Adapt(writer.status, writer.body.Bytes())
+		envelope.RequestID = GetRequestID(c)
+		if envelope.Error != nil {
+			envelope.Error.Message = Translate(c, envelope.Error.Message)
+		}
+		body, err := json.Marshal(envelope)
+		if err != nil {
+			log.Printf("Failed to encode v2 response for %s: %v", c.FullPath(), err)
+			body = writer.body.Bytes()
+		}
+		c.Writer.Header().Del("Content-Length")
+		c.Writer.WriteHeader(writer.status)
+		c.Writer.Write(body)
+	}
+}
+
+// envelopeWriter holds back a JSON body until the handler chain is done
+type envelopeWriter struct {
+	gin.ResponseWriter
+	body        bytes.Buffer
+	status      int
+	statusSet   bool
+	buffered    bool
+	passthrough bool
+}
+
+func (w *envelopeWriter) WriteHeader(status int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if status > 0 {
+		w.status = status
+		w.statusSet = true
+	}
+}
+
+func (w *envelopeWriter) WriteHeaderNow() {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *envelopeWriter) Write(data []byte) (int, error) {
+	if !w.buffered && !w.passthrough {
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			w.buffered = true
+		} else {
+			w.passthrough = true
+			w.ResponseWriter.WriteHeader(w.status)
+		}
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *envelopeWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *envelopeWriter) Status() int {
+	if w.passthrough {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *envelopeWriter) Size() int {
+	if w.passthrough {
+		return w.ResponseWriter.Size()
+	}
+	return w.body.Len()
+}
+
+func (w *envelopeWriter) Written() bool {
+	if w.passthrough {
+		return w.ResponseWriter.Written()
+	}
+	return w.buffered
+}
+
+func (w *envelopeWriter) Flush() {
+	if w.passthrough {
+		w.ResponseWriter.Flush()
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"golang-food-backend/pkg/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler writes the error response for handlers that end with c.Error(err). The status
+// comes from the error's kind and the body names its code, so every handler reports the same
+// failure the same way. A string set as the error's meta is used as the error summary.
+// Internal errors are logged and their text is kept out of the response.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		failure := c.Errors.Last()
+		kind, code := apperr.Of(failure.Err)
+		status := kind.Status()
+
+		summary, _ := failure.Meta.(string)
+		if summary == "" {
+			summary = http.StatusText(status)
+		}
+		message := failure.Err.Error()
+		if kind == apperr.KindInternal {
+			log.Printf("Request %s %s (%s) failed: %v", c.Request.Method, c.FullPath(), GetRequestID(c), failure.Err)
+			message = Translate(c, "Internal server error")
+		}
+
+		c.AbortWithStatusJSON(status, gin.H{
+			"error":   Translate(c, summary),
+			"message": message,
+			"code":    code,
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureChecker evaluates feature flags for a restaurant and user
+type FeatureChecker interface {
+	FeatureEnabled(ctx context.Context, key, restaurantID, userID string) bool
+}
+
+// FeatureRequired middleware responds 404 when the feature is off for the request. The flag is
+// evaluated for the restaurant in the restaurant_id path parameter or the staff member's
+// restaurant, and for the authenticated user, so it belongs after the auth middleware.
+func FeatureRequired(checker FeatureChecker, key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		restaurantID := c.Param("restaurant_id")
+		if restaurantID == "" {
+			restaurantID = GetRestaurantID(c)
+		}
+
+		if !checker.FeatureEnabled(c.Request.Context(), key, restaurantID, GetUserID(c)) {
+			c.JSON(http.StatusNotFound, gin.H{"error": Translate(c, "This feature is not available")})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
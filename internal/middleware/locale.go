@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"golang-food-backend/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale middleware picks the response language from Accept-Language and puts it in the request
+// context, so services translate messages and record it on orders for later notifications
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		c.Set("language", lang)
+		c.Request = c.Request.WithContext(i18n.WithLanguage(c.Request.Context(), lang))
+		c.Header("Content-Language", lang)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
+
+// GetLanguage helper function to extract the response language from context
+func GetLanguage(c *gin.Context) string {
+	if lang, exists := c.Get("language"); exists {
+		return lang.(string)
+	}
+	return i18n.DefaultLanguage
+}
+
+// Translate translates an English message to the response language
+func Translate(c *gin.Context, message string, args ...interface{}) string {
+	return i18n.T(GetLanguage(c), message, args...)
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceChecker reports the currently running maintenance window
+type MaintenanceChecker interface {
+	ActiveWindow(ctx context.Context) *models.MaintenanceWindow
+}
+
+// ReadOnlyDuringMaintenance rejects mutating requests with 503 while a maintenance
+// window is active. Reads keep working, as do paths with an exempt prefix (webhooks,
+// maintenance administration).
+func ReadOnlyDuringMaintenance(checker MaintenanceChecker, exemptPrefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		path := c.Request.URL.Path
+		for _, prefix := range exemptPrefixes {
+			if strings.HasPrefix(path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		window := checker.ActiveWindow(c.Request.Context())
+		if window == nil {
+			c.Next()
+			return
+		}
+
+		retryAfter := int(time.Until(window.EndsAt).Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error":               "Service under maintenance",
+			"message":             window.Message,
+			"maintenance_ends_at": window.EndsAt,
+			"retry_after_seconds": retryAfter,
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang-food-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PartnerKeyChecker authenticates partner API keys and meters their requests
+type PartnerKeyChecker interface {
+	AuthenticatePartnerKey(ctx context.Context, rawKey string) (*models.PartnerAPIKey, error)
+	// AllowPartnerRequest returns the requests left in the key's window, or how long to wait
+	// once they are used up
+	AllowPartnerRequest(ctx context.Context, key *models.PartnerAPIKey) (int, time.Duration)
+}
+
+type PartnerAuthMiddleware struct {
+	keys PartnerKeyChecker
+}
+
+func NewPartnerAuthMiddleware(keys PartnerKeyChecker) *PartnerAuthMiddleware {
+	return &PartnerAuthMiddleware{
+		keys: keys,
+	}
+}
+
+// APIKeyRequired middleware authenticates the X-API-Key header and applies the key's
+// per-minute rate limit
+func (p *PartnerAuthMiddleware) APIKeyRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader("X-API-Key")
+		if rawKey == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "X-API-Key header required"})
+			c.Abort()
+			return
+		}
+
+		key, err := p.keys.AuthenticatePartnerKey(c.Request.Context(), rawKey)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			c.Abort()
+			return
+		}
+
+		remaining, retryAfter := p.keys.AllowPartnerRequest(c.Request.Context(), key)
+		c.Header("X-RateLimit-Limit", strconv.Itoa(key.RateLimitPerMinute))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if retryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+			c.Abort()
+			return
+		}
+
+		c.Set("partner_company_id", key.DeliveryPartnerCompanyID.String())
+		c.Set("partner_key_id", key.ID.String())
+		c.Set("partner_scopes", []string(key.Scopes))
+		c.Next()
+	}
+}
+
+// ScopeRequired middleware ensures the API key was granted the scope
+func (p *PartnerAuthMiddleware) ScopeRequired(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scopes, exists := c.Get("partner_scopes"); exists {
+			for _, granted := range scopes.([]string) {
+				if granted == scope {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key lacks the " + scope + " scope"})
+		c.Abort()
+	}
+}
+
+// GetPartnerCompanyID helper function to extract the API key's delivery partner company from context
+func GetPartnerCompanyID(c *gin.Context) string {
+	if companyID, exists := c.Get("partner_company_id"); exists {
+		return companyID.(string)
+	}
+	return ""
+}
+
+// GetPartnerKeyID helper function to extract the ID of the API key a partner request was made with
+func GetPartnerKeyID(c *gin.Context) string {
+	if keyID, exists := c.Get("partner_key_id"); exists {
+		return keyID.(string)
+	}
+	return ""
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"golang-food-backend/pkg/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadReplica lets read-only requests query the Postgres read replica. Mutating requests, and
+// the reads they make before writing, stay on the primary.
+func ReadReplica() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead:
+			c.Request = c.Request.WithContext(database.WithReplica(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
+
+// ForcePrimary keeps a route on the primary even for reads, for screens that must show a
+// write made just before (an order right after checkout, a cart being edited)
+func ForcePrimary() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(database.ForcePrimary(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	SessionIDHeader = "X-Session-ID"
+	sessionIDQuery  = "session_id"
+	maxSessionIDLen = 128
+)
+
+// SessionMiddleware reads the client's session ID from the X-Session-ID header or the
+// session_id query parameter so activity from anonymous browsing can be stitched together
+func SessionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID := c.GetHeader(SessionIDHeader)
+		if sessionID == "" {
+			sessionID = c.Query(sessionIDQuery)
+		}
+		if len(sessionID) > maxSessionIDLen {
+			sessionID = sessionID[:maxSessionIDLen]
+		}
+		if sessionID != "" {
+			c.Set("session_id", sessionID)
+		}
+		c.Next()
+	}
+}
+
+// GetSessionID helper function to extract the client session ID from context
+func GetSessionID(c *gin.Context) string {
+	if sessionID, exists := c.Get("session_id"); exists {
+		return sessionID.(string)
+	}
+	return ""
+}
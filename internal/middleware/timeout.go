@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestBaseContextKey holds the request's context from before RequestTimeout gave it a
+// deadline, so a route's own Timeout can replace the default rather than only shorten it
+const requestBaseContextKey = "request_base_context"
+
+// RequestTimeout gives every request a deadline. Postgres, Mongo and Redis calls made with the
+// request's context fail with context.DeadlineExceeded once it passes, which handlers report as
+// 504 request_timeout. WebSocket upgrades get none, as they last as long as their connection.
+func RequestTimeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d <= 0 || isWebSocketUpgrade(c.Request) {
+			c.Next()
+			return
+		}
+		c.Set(requestBaseContextKey, c.Request.Context())
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Timeout replaces the default deadline for routes that need another, such as uploads, imports
+// and exports. It counts from when the route is reached; zero lifts the deadline. Values added
+// to the request's context by earlier middleware are kept.
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current := c.Request.Context()
+		parent := current
+		if base, ok := c.Get(requestBaseContextKey); ok {
+			parent = base.(context.Context)
+		}
+		if d <= 0 || isWebSocketUpgrade(c.Request) {
+			c.Request = c.Request.WithContext(valuesFrom{Context: parent, values: current})
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(parent, d)
+		defer cancel()
+		c.Request = c.Request.WithContext(valuesFrom{Context: ctx, values: current})
+		c.Next()
+	}
+}
+
+// valuesFrom takes its deadline and cancellation from the embedded context and its values
+// from another
+type valuesFrom struct {
+	context.Context
+	values context.Context
+}
+
+func (v valuesFrom) Value(key interface{}) interface{} {
+	return v.values.Value(key)
+}
+
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
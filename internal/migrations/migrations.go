@@ -0,0 +1,341 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+
+	schema "golang-food-backend/migrations"
+	"golang-food-backend/pkg/database"
+)
+
+// advisoryLockKey serializes migrations when several instances or the CLI run at once
+const advisoryLockKey = 727361
+
+var (
+	ErrMigrationInvalid = errors.New("invalid migration")
+	ErrMongoUnavailable = errors.New("mongodb is not connected")
+)
+
+// Migration is one versioned schema change. SQL comes from the <ID>.up.sql and <ID>.down.sql
+// files in the top-level migrations directory; Mongo changes are registered in mongoMigrations.
+type Migration struct {
+	ID          string
+	Description string
+	Up          string
+	Down        string
+	MongoUp     func(ctx context.Context, db *mongo.Database) error // must be idempotent
+	MongoDown   func(ctx context.Context, db *mongo.Database) error
+}
+
+// SchemaMigration records an applied migration
+type SchemaMigration struct {
+	ID          string    `gorm:"primaryKey" json:"id"`
+	Description string    `json:"description"`
+	AppliedAt   time.Time `json:"applied_at"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status reports whether a migration has been applied
+type Status struct {
+	ID          string     `json:"id"`
+	Description string     `json:"description"`
+	Applied     bool       `json:"applied"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+	Unknown     bool       `json:"unknown,omitempty"` // applied but missing from this build
+}
+
+type Runner struct {
+	db         *database.Database
+	migrations []Migration
+}
+
+// NewRunner loads the embedded migrations
+func NewRunner(db *database.Database) (*Runner, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{db: db, migrations: migrations}, nil
+}
+
+// Load reads the SQL migrations and merges in the Mongo ones, sorted by ID. Every up file
+// needs a matching down file.
+func Load() ([]Migration, error) {
+	byID := make(map[string]*Migration)
+	get := func(id string) *Migration {
+		if m, ok := byID[id]; ok {
+			return m
+		}
+		m := &Migration{ID: id}
+		byID[id] = m
+		return m
+	}
+
+	files, err := fs.Glob(schema.Files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range files {
+		content, err := fs.ReadFile(schema.Files, name)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			m := get(strings.TrimSuffix(name, ".up.sql"))
+			m.Up = string(content)
+			m.Description = describe(m.Up)
+		case strings.HasSuffix(name, ".down.sql"):
+			get(strings.TrimSuffix(name, ".down.sql")).Down = string(content)
+		default:
+			return nil, fmt.Errorf("%w: %s is neither an up nor a down file", ErrMigrationInvalid, name)
+		}
+	}
+
+	for _, mongoMigration := range mongoMigrations {
+		m := get(mongoMigration.ID)
+		m.MongoUp = mongoMigration.MongoUp
+		m.MongoDown = mongoMigration.MongoDown
+		if m.Description == "" {
+			m.Description = mongoMigration.Description
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byID))
+	for _, m := range byID {
+		if m.Up == "" && m.MongoUp == nil {
+			return nil, fmt.Errorf("%w: %s has no up migration", ErrMigrationInvalid, m.ID)
+		}
+		if m.Up != "" && m.Down == "" {
+			return nil, fmt.Errorf("%w: %s has no down file", ErrMigrationInvalid, m.ID)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].ID < migrations[j].ID })
+	return migrations, nil
+}
+
+// describe uses the first comment line of an up file as its description
+func describe(sql string) string {
+	for _, line := range strings.Split(sql, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "--") {
+			return strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(line, "--")), ".")
+		}
+		if line != "" {
+			break
+		}
+	}
+	return ""
+}
+
+// hasStatements reports whether sql contains more than comments and whitespace
+func hasStatements(sql string) bool {
+	for _, line := range strings.Split(sql, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return true
+		}
+	}
+	return false
+}
+
+// Migrations returns every known migration in the order it runs
+func (r *Runner) Migrations() []Migration {
+	return r.migrations
+}
+
+func (r *Runner) ensureTable(ctx context.Context) error {
+	if err := r.db.Postgres.WithContext(ctx).AutoMigrate(&SchemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+	return nil
+}
+
+func (r *Runner) applied(ctx context.Context) (map[string]SchemaMigration, error) {
+	if err := r.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	var records []SchemaMigration
+	if err := r.db.Postgres.WithContext(ctx).Order("id").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[string]SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.ID] = record
+	}
+	return applied, nil
+}
+
+// Status lists every known migration, followed by any applied migration this build does not know
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		status := Status{ID: m.ID, Description: m.Description}
+		if record, ok := applied[m.ID]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			delete(applied, m.ID)
+		}
+		statuses = append(statuses, status)
+	}
+
+	unknown := make([]Status, 0, len(applied))
+	for _, record := range applied {
+		appliedAt := record.AppliedAt
+		unknown = append(unknown, Status{
+			ID:          record.ID,
+			Description: record.Description,
+			Applied:     true,
+			AppliedAt:   &appliedAt,
+			Unknown:     true,
+		})
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].ID < unknown[j].ID })
+	return append(statuses, unknown...), nil
+}
+
+// Pending returns the IDs of migrations that have not been applied
+func (r *Runner) Pending(ctx context.Context) ([]string, error) {
+	statuses, err := r.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []string
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status.ID)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in order. A migration that needs Mongo is left pending
+// while Mongo is unavailable, and later ones still run.
+func (r *Runner) Up(ctx context.Context) ([]string, error) {
+	if err := r.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var done []string
+	for _, m := range r.migrations {
+		if m.MongoUp != nil && r.db.MongoDB == nil {
+			log.Printf("Skipping migration %s until MongoDB is available", m.ID)
+			continue
+		}
+		applied, err := r.up(ctx, m)
+		if err != nil {
+			return done, fmt.Errorf("migration %s failed: %v", m.ID, err)
+		}
+		if applied {
+			log.Printf("Applied migration %s", m.ID)
+			done = append(done, m.ID)
+		}
+	}
+	return done, nil
+}
+
+func (r *Runner) up(ctx context.Context, m Migration) (bool, error) {
+	applied := false
+	err := r.db.Postgres.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", advisoryLockKey).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&SchemaMigration{}).Where("id = ?", m.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		if hasStatements(m.Up) {
+			if err := tx.Exec(m.Up).Error; err != nil {
+				return err
+			}
+		}
+		if m.MongoUp != nil {
+			if err := m.MongoUp(ctx, r.db.MongoDB); err != nil {
+				return err
+			}
+		}
+
+		applied = true
+		return tx.Create(&SchemaMigration{
+			ID:          m.ID,
+			Description: m.Description,
+			AppliedAt:   time.Now(),
+		}).Error
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first
+func (r *Runner) Down(ctx context.Context, steps int) ([]string, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []string
+	for i := len(r.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := r.migrations[i]
+		if _, ok := applied[m.ID]; !ok {
+			continue
+		}
+		if m.MongoDown != nil && r.db.MongoDB == nil {
+			return done, fmt.Errorf("migration %s: %w", m.ID, ErrMongoUnavailable)
+		}
+		if err := r.down(ctx, m); err != nil {
+			return done, fmt.Errorf("reverting migration %s failed: %v", m.ID, err)
+		}
+		log.Printf("Reverted migration %s", m.ID)
+		done = append(done, m.ID)
+	}
+	return done, nil
+}
+
+func (r *Runner) down(ctx context.Context, m Migration) error {
+	return r.db.Postgres.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", advisoryLockKey).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("id = ?", m.ID).Delete(&SchemaMigration{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil // reverted by another runner meanwhile
+		}
+
+		if hasStatements(m.Down) {
+			if err := tx.Exec(m.Down).Error; err != nil {
+				return err
+			}
+		}
+		if m.MongoDown != nil {
+			return m.MongoDown(ctx, r.db.MongoDB)
+		}
+		return nil
+	})
+}
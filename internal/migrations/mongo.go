@@ -0,0 +1,145 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// mongoMigrations are the MongoDB changes, which have no SQL file. They share IDs and ordering
+// with the SQL migrations; never reorder or edit one that has shipped.
+var mongoMigrations = []Migration{
+	{
+		ID:          "20261016_04_mongo_menu_indexes",
+		Description: "Product and time-range group indexes",
+		MongoUp:     createIndexes(menuIndexes),
+		MongoDown:   dropIndexes(menuIndexes),
+	},
+	{
+		ID:          "20261016_24_mongo_order_chat_indexes",
+		Description: "Order chat message indexes",
+		MongoUp:     createIndexes(orderChatIndexes),
+		MongoDown:   dropIndexes(orderChatIndexes),
+	},
+	{
+		ID:          "20261016_39_mongo_price_schedule_indexes",
+		Description: "Price history and scheduled price change indexes",
+		MongoUp:     createIndexes(priceIndexes),
+		MongoDown:   dropIndexes(priceIndexes),
+	},
+	{
+		ID:          "20261016_40_mongo_combo_indexes",
+		Description: "Combo component index",
+		MongoUp:     createIndexes(comboIndexes),
+		MongoDown:   dropIndexes(comboIndexes),
+	},
+	{
+		ID:          "20261016_42_mongo_dietary_indexes",
+		Description: "Product diet, allergen and spice level indexes",
+		MongoUp:     createIndexes(dietaryIndexes),
+		MongoDown:   dropIndexes(dietaryIndexes),
+	},
+}
+
+// menuIndexes back the menu queries, which all filter by restaurant
+var menuIndexes = map[string][]mongo.IndexModel{
+	"products": {
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "is_available", Value: 1}}},
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "category_id", Value: 1}}},
+	},
+	"time_range_products_groups": {
+		{Keys: bson.D{
+			{Key: "restaurant_id", Value: 1},
+			{Key: "is_active", Value: 1},
+			{Key: "start_time", Value: 1},
+		}},
+	},
+	"time_range_products_group_items": {
+		{Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "product_id", Value: 1}}},
+		{Keys: bson.D{{Key: "product_id", Value: 1}}}, // menu $lookup from products
+	},
+}
+
+// orderChatIndexes back reading an order's chat and the nightly retention purge
+var orderChatIndexes = map[string][]mongo.IndexModel{
+	"order_chat_messages": {
+		{Keys: bson.D{{Key: "order_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: 1}}},
+	},
+}
+
+// priceIndexes back a product's price history, the restaurant's schedule list and the job
+// that applies and reverts scheduled prices
+var priceIndexes = map[string][]mongo.IndexModel{
+	"price_history": {
+		{Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "changed_at", Value: -1}}},
+	},
+	"scheduled_price_changes": {
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "starts_at", Value: 1}}},
+		{Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "starts_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "ends_at", Value: 1}}},
+	},
+	"products": {
+		{Keys: bson.D{{Key: "was_price_until", Value: 1}}},
+	},
+}
+
+// comboIndexes find the combos a product is part of when its availability or stock changes
+var comboIndexes = map[string][]mongo.IndexModel{
+	"products": {
+		{Keys: bson.D{{Key: "components.product_id", Value: 1}}},
+	},
+}
+
+// dietaryIndexes back the diet, allergen and spice level filters on menu listings
+var dietaryIndexes = map[string][]mongo.IndexModel{
+	"products": {
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "diet_type", Value: 1}}},
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "allergens", Value: 1}}},
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "spice_level", Value: 1}}},
+	},
+}
+
+// createIndexes is idempotent: creating an index that already exists with the same keys and
+// options is a no-op
+func createIndexes(indexes map[string][]mongo.IndexModel) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		for collection, models := range indexes {
+			if _, err := db.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func dropIndexes(indexes map[string][]mongo.IndexModel) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		for collection, models := range indexes {
+			for _, model := range models {
+				_, err := db.Collection(collection).Indexes().DropOne(ctx, indexName(model.Keys.(bson.D)))
+				var commandErr mongo.CommandError
+				if err != nil && !(errors.As(err, &commandErr) && commandErr.Name == "IndexNotFound") {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// indexName is the name the driver gives an index created without one, e.g. restaurant_id_1
+func indexName(keys bson.D) string {
+	name := ""
+	for i, key := range keys {
+		if i > 0 {
+			name += "_"
+		}
+		name += key.Key + "_" + fmt.Sprint(key.Value)
+	}
+	return name
+}
@@ -0,0 +1,208 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Money is an amount in hundredths of the currency unit (paise for rupees). Sums of Money are
+// exact, unlike float64 amounts. It is stored as numeric(12,2) in PostgreSQL and as a double in
+// MongoDB, and marshals to JSON as a number with two decimals, as amounts always were.
+type Money int64
+
+// NewMoney converts a decimal amount, rounding half away from zero to the nearest hundredth
+func NewMoney(amount float64) Money {
+	return Money(math.Round(amount * 100))
+}
+
+// ParseMoney parses a decimal amount such as 120, 99.5 or -0.125 exactly, rounding digits past
+// the second decimal half away from zero
+func ParseMoney(text string) (Money, error) {
+	text = strings.TrimSpace(text)
+	if strings.ContainsAny(text, "eE") {
+		amount, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount %q", text)
+		}
+		return NewMoney(amount), nil
+	}
+
+	negative := strings.HasPrefix(text, "-")
+	whole, fraction, _ := strings.Cut(strings.TrimLeft(text, "+-"), ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("invalid amount %q", text)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	for _, part := range []string{whole, fraction} {
+		if strings.Trim(part, "0123456789") != "" {
+			return 0, fmt.Errorf("invalid amount %q", text)
+		}
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", text)
+	}
+	cents := units * 100
+	digits := (fraction + "00")[:2]
+	hundredths, _ := strconv.ParseInt(digits, 10, 64)
+	cents += hundredths
+	if len(fraction) > 2 && fraction[2] >= '5' {
+		cents++
+	}
+	if negative {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+// MoneyOf reads an amount from a decoded JSONB value: a Money set in this process, or the number
+// or string it was stored as. Anything else is zero.
+func MoneyOf(value interface{}) Money {
+	switch v := value.(type) {
+	case Money:
+		return v
+	case float64:
+		return NewMoney(v)
+	case string:
+		amount, _ := ParseMoney(v)
+		return amount
+	}
+	return 0
+}
+
+// Float64 returns the amount as a decimal, for rates and display
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// Scale multiplies the amount by a factor such as a surge multiplier, rounding to the hundredth
+func (m Money) Scale(factor float64) Money {
+	return Money(math.Round(float64(m) * factor))
+}
+
+// Percent returns percent of the amount, rounded to the hundredth
+func (m Money) Percent(percent float64) Money {
+	return m.Scale(percent / 100)
+}
+
+// Split divides the amount into n shares that add up to it exactly; the first shares get the
+// hundredths left over
+func (m Money) Split(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	shares := make([]Money, n)
+	share, rest := m/Money(n), m%Money(n)
+	for i := range shares {
+		shares[i] = share
+		if Money(i) < rest {
+			shares[i]++
+		} else if Money(i) < -rest {
+			shares[i]--
+		}
+	}
+	return shares
+}
+
+// String formats the amount with two decimals, as 1250.50 or -0.05
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts numbers and numeric strings
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "null" || text == "" {
+		*m = 0
+		return nil
+	}
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// GormDataType keeps migrated columns exact
+func (Money) GormDataType() string {
+	return "numeric(12,2)"
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+	case []byte:
+		return m.scanText(string(v))
+	case string:
+		return m.scanText(v)
+	case float64:
+		*m = NewMoney(v)
+	case float32:
+		*m = NewMoney(float64(v))
+	case int64:
+		*m = Money(v * 100)
+	default:
+		return fmt.Errorf("cannot scan %T into Money", value)
+	}
+	return nil
+}
+
+func (m *Money) scanText(text string) error {
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// MarshalBSONValue stores the amount as a double so existing documents and range queries on
+// prices keep working
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bsontype.Double, bsoncore.AppendDouble(nil, m.Float64()), nil
+}
+
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bsontype.Double:
+		*m = NewMoney(raw.Double())
+	case bsontype.Int32:
+		*m = Money(int64(raw.Int32()) * 100)
+	case bsontype.Int64:
+		*m = Money(raw.Int64() * 100)
+	case bsontype.Decimal128:
+		return m.scanText(raw.Decimal128().String())
+	case bsontype.Null, bsontype.Undefined:
+		*m = 0
+	default:
+		return fmt.Errorf("cannot decode BSON %s into Money", t)
+	}
+	return nil
+}
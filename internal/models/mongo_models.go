@@ -1,6 +1,8 @@
 package models
 
 import (
+	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,8 +15,8 @@ type Product struct {
 	CategoryID      primitive.ObjectID     `bson:"category_id" json:"category_id"`
 	Name            string                 `bson:"name" json:"name"`
 	Description     string                 `bson:"description" json:"description"`
-	Price           float64                `bson:"price" json:"price"`
-	DiscountPrice   *float64               `bson:"discount_price,omitempty" json:"discount_price"`
+	Price           Money                  `bson:"price" json:"price"`
+	DiscountPrice   *Money                 `bson:"discount_price,omitempty" json:"discount_price"`
 	ImageUrls       []string               `bson:"image_urls" json:"image_urls"`
 	IsAvailable     bool                   `bson:"is_available" json:"is_available"`
 	PreparationTime int                    `bson:"preparation_time" json:"preparation_time"` // in minutes
@@ -25,20 +27,148 @@ type Product struct {
 	NutritionalInfo map[string]interface{} `bson:"nutritional_info,omitempty" json:"nutritional_info"`
 	Variants        []ProductVariant       `bson:"variants,omitempty" json:"variants"`
 	Addons          []ProductAddon         `bson:"addons,omitempty" json:"addons"`
+	Badges          []string               `bson:"badges,omitempty" json:"badges"` // computed nightly: bestseller, frequently_reordered
+	BadgesUpdatedAt *time.Time             `bson:"badges_updated_at,omitempty" json:"badges_updated_at,omitempty"`
+	OutOfStock      bool                   `bson:"out_of_stock,omitempty" json:"out_of_stock"`                   // set when IsAvailable was turned off because stock ran out, or a combo's components did
+	BrandProductID  *primitive.ObjectID    `bson:"brand_product_id,omitempty" json:"brand_product_id,omitempty"` // brand menu item this outlet copy follows
+	PriceOverridden bool                   `bson:"price_overridden,omitempty" json:"price_overridden,omitempty"` // outlet sets its own prices; brand syncs leave them alone
+	DeletedAt       *time.Time             `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`             // soft delete; hidden everywhere until restored or purged
+	WasPrice        *Money                 `bson:"was_price" json:"was_price,omitempty"`                         // selling price before the latest cut, shown struck through
+	WasPriceUntil   *time.Time             `bson:"was_price_until" json:"was_price_until,omitempty"`             // stop showing the was price after this
+	Components      []ComboComponent       `bson:"components" json:"components,omitempty"`                       // set on combos: the items bundled at the product's price
+	OptionGroups    []ProductOptionGroup   `bson:"option_groups" json:"option_groups,omitempty"`                 // choices with selection rules; addons stay free extras
+	DietType        string                 `bson:"diet_type" json:"diet_type,omitempty"`                         // veg, non_veg or vegan; empty when not stated
+	Allergens       []string               `bson:"allergens" json:"allergens,omitempty"`                         // from the allergens the menu recognises
+	SpiceLevel      int                    `bson:"spice_level" json:"spice_level"`                               // 0 (not spicy) to 3 (hot)
+}
+
+// Diet types of a product
+const (
+	DietVeg    = "veg"
+	DietNonVeg = "non_veg"
+	DietVegan  = "vegan"
+)
+
+// DietaryFilter narrows a menu listing by diet, allergens and spice level. The zero value
+// lets everything through.
+type DietaryFilter struct {
+	DietTypes        []string // products of any of these diets; vegan dishes count as veg
+	ExcludeAllergens []string // products containing none of these
+	MaxSpiceLevel    *int
+}
+
+// IsZero reports whether the filter lets everything through
+func (f DietaryFilter) IsZero() bool {
+	return len(f.DietTypes) == 0 && len(f.ExcludeAllergens) == 0 && f.MaxSpiceLevel == nil
+}
+
+// Key identifies the filter in cache keys
+func (f DietaryFilter) Key() string {
+	if f.IsZero() {
+		return "all"
+	}
+	spice := "any"
+	if f.MaxSpiceLevel != nil {
+		spice = strconv.Itoa(*f.MaxSpiceLevel)
+	}
+	return strings.Join(f.DietTypes, ",") + "|" + strings.Join(f.ExcludeAllergens, ",") + "|" + spice
+}
+
+// IsCombo reports whether the product is a combo of other menu items
+func (p *Product) IsCombo() bool {
+	return len(p.Components) > 0
+}
+
+// PriceSnapshot returns the product's current prices
+func (p *Product) PriceSnapshot() PriceSnapshot {
+	snapshot := PriceSnapshot{Price: p.Price}
+	if p.DiscountPrice != nil {
+		discount := *p.DiscountPrice
+		snapshot.DiscountPrice = &discount
+	}
+	if len(p.Variants) > 0 {
+		snapshot.Variants = make(map[string]Money, len(p.Variants))
+		for _, variant := range p.Variants {
+			snapshot.Variants[variant.ID.Hex()] = variant.Price
+		}
+	}
+	return snapshot
+}
+
+// PriceSnapshot is a product's prices at one time
+type PriceSnapshot struct {
+	Price         Money            `bson:"price" json:"price"`
+	DiscountPrice *Money           `bson:"discount_price,omitempty" json:"discount_price,omitempty"`
+	Variants      map[string]Money `bson:"variants,omitempty" json:"variants,omitempty"` // variant ID -> price
+}
+
+// SellingPrice is what the product sells for: its discount price when it has one
+func (s PriceSnapshot) SellingPrice() Money {
+	if s.DiscountPrice != nil {
+		return *s.DiscountPrice
+	}
+	return s.Price
+}
+
+// Equal reports whether two snapshots hold the same prices
+func (s PriceSnapshot) Equal(other PriceSnapshot) bool {
+	if s.Price != other.Price || (s.DiscountPrice == nil) != (other.DiscountPrice == nil) ||
+		(s.DiscountPrice != nil && *s.DiscountPrice != *other.DiscountPrice) || len(s.Variants) != len(other.Variants) {
+		return false
+	}
+	for id, price := range s.Variants {
+		if otherPrice, ok := other.Variants[id]; !ok || otherPrice != price {
+			return false
+		}
+	}
+	return true
 }
 
 // ProductVariant for size/type variations
 type ProductVariant struct {
 	ID    primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Name  string             `bson:"name" json:"name"`
-	Price float64            `bson:"price" json:"price"`
+	Price Money              `bson:"price" json:"price"`
 }
 
 // ProductAddon for extra items
 type ProductAddon struct {
 	ID    primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Name  string             `bson:"name" json:"name"`
-	Price float64            `bson:"price" json:"price"`
+	Price Money              `bson:"price" json:"price"`
+}
+
+// ProductOptionGroup is a set of choices for a product with selection rules, such as "choose
+// exactly 1 base" or "up to 3 toppings"
+type ProductOptionGroup struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	MinSelect int                `bson:"min_select" json:"min_select"`
+	MaxSelect int                `bson:"max_select" json:"max_select"`
+	Options   []ProductOption    `bson:"options" json:"options"`
+	// Rendering hints for clients, derived from the rules when the groups are saved
+	Display       string `bson:"display" json:"display"` // radio, checkbox or dropdown
+	Required      bool   `bson:"required" json:"required"`
+	SelectionHint string `bson:"selection_hint" json:"selection_hint"` // e.g. "Choose up to 3"
+}
+
+// ProductOption is a choice in an option group, changing the unit price by its delta
+type ProductOption struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name       string             `bson:"name" json:"name"`
+	PriceDelta Money              `bson:"price_delta" json:"price_delta"`
+	IsDefault  bool               `bson:"is_default,omitempty" json:"is_default"` // preselected by clients
+	SoldOut    bool               `bson:"sold_out,omitempty" json:"sold_out"`
+}
+
+// ComboComponent is a menu item in a combo, with the names it had when last synced for
+// tickets and bills
+type ComboComponent struct {
+	ProductID   primitive.ObjectID `bson:"product_id" json:"product_id"`
+	ProductName string             `bson:"product_name" json:"product_name"`
+	VariantID   string             `bson:"variant_id,omitempty" json:"variant_id,omitempty"`
+	VariantName string             `bson:"variant_name,omitempty" json:"variant_name,omitempty"`
+	Quantity    int                `bson:"quantity" json:"quantity"` // per combo
 }
 
 // ProductCategory model - MongoDB
@@ -52,6 +182,7 @@ type ProductCategory struct {
 	ImgUrl           string              `bson:"img_url,omitempty" json:"img_url"`
 	VideoUrl         string              `bson:"video_url,omitempty" json:"video_url"`
 	IsActive         bool                `bson:"is_active" json:"is_active"`
+	BrandCategoryID  *primitive.ObjectID `bson:"brand_category_id,omitempty" json:"brand_category_id,omitempty"` // brand category this outlet copy follows
 	CreatedAt        time.Time           `bson:"created_at" json:"created_at"`
 	UpdatedAt        time.Time           `bson:"updated_at" json:"updated_at"`
 }
@@ -64,8 +195,10 @@ type HighlightProduct struct {
 	HighlightType string             `bson:"highlight_type" json:"highlight_type"` // featured, bestseller, recommended
 	StartDate     time.Time          `bson:"start_date" json:"start_date"`
 	EndDate       time.Time          `bson:"end_date" json:"end_date"`
+	SortOrder     int                `bson:"sort_order" json:"sort_order"`
 	IsActive      bool               `bson:"is_active" json:"is_active"`
 	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
 // TimeRangeProductsGroup model - MongoDB
@@ -149,18 +282,22 @@ type SearchLog struct {
 	ClickedItems []string           `bson:"clicked_items,omitempty" json:"clicked_items"`
 	Timestamp    time.Time          `bson:"timestamp" json:"timestamp"`
 	IP           string             `bson:"ip,omitempty" json:"ip"`
+	SessionID    string             `bson:"session_id,omitempty" json:"session_id"`
+	UserAgent    string             `bson:"user_agent,omitempty" json:"user_agent"`
 }
 
 // UserActivity model - MongoDB (user behavior tracking)
 type UserActivity struct {
 	ID           primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
-	UserID       string                 `bson:"user_id" json:"user_id"`
+	UserID       string                 `bson:"user_id,omitempty" json:"user_id"`
 	RestaurantID string                 `bson:"restaurant_id,omitempty" json:"restaurant_id"`
 	ActivityType string                 `bson:"activity_type" json:"activity_type"` // view_product, add_to_cart, place_order
 	EntityID     string                 `bson:"entity_id,omitempty" json:"entity_id"`
 	Metadata     map[string]interface{} `bson:"metadata,omitempty" json:"metadata"`
 	Timestamp    time.Time              `bson:"timestamp" json:"timestamp"`
 	SessionID    string                 `bson:"session_id,omitempty" json:"session_id"`
+	IP           string                 `bson:"ip,omitempty" json:"ip"`
+	UserAgent    string                 `bson:"user_agent,omitempty" json:"user_agent"`
 }
 
 // Inventory model - MongoDB (flexible inventory tracking)
@@ -170,6 +307,7 @@ type Inventory struct {
 	RestaurantID     string                 `bson:"restaurant_id" json:"restaurant_id"`
 	Quantity         int                    `bson:"quantity" json:"quantity"`
 	ReservedQuantity int                    `bson:"reserved_quantity" json:"reserved_quantity"`
+	TrackStock       bool                   `bson:"track_stock" json:"track_stock"` // availability follows stock only for tracked products
 	MinStockLevel    int                    `bson:"min_stock_level" json:"min_stock_level"`
 	MaxStockLevel    int                    `bson:"max_stock_level" json:"max_stock_level"`
 	LastRestocked    time.Time              `bson:"last_restocked" json:"last_restocked"`
@@ -188,15 +326,16 @@ type StockTransaction struct {
 
 // MenuSection model - MongoDB (for organizing menu items)
 type MenuSection struct {
-	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	RestaurantID    string             `bson:"restaurant_id" json:"restaurant_id"`
-	Name            string             `bson:"name" json:"name"`
-	Description     string             `bson:"description,omitempty" json:"description"`
-	SortOrder       int                `bson:"sort_order" json:"sort_order"`
-	IsActive        bool               `bson:"is_active" json:"is_active"`
-	TimeRestriction *TimeRestriction   `bson:"time_restriction,omitempty" json:"time_restriction"`
-	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
+	ID              primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	RestaurantID    string               `bson:"restaurant_id" json:"restaurant_id"`
+	Name            string               `bson:"name" json:"name"`
+	Description     string               `bson:"description,omitempty" json:"description"`
+	SortOrder       int                  `bson:"sort_order" json:"sort_order"`
+	IsActive        bool                 `bson:"is_active" json:"is_active"`
+	TimeRestriction *TimeRestriction     `bson:"time_restriction,omitempty" json:"time_restriction"`
+	ProductIDs      []primitive.ObjectID `bson:"product_ids" json:"product_ids"` // in display order
+	CreatedAt       time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time            `bson:"updated_at" json:"updated_at"`
 }
 
 type TimeRestriction struct {
@@ -231,3 +370,85 @@ type CustomerStats struct {
 	ReturnCustomers int `bson:"return_customers" json:"return_customers"`
 	TotalCustomers  int `bson:"total_customers" json:"total_customers"`
 }
+
+// DeliveryLocationPing model - MongoDB (delivery partner location history)
+type DeliveryLocationPing struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrderID         string             `bson:"order_id" json:"order_id"`
+	Provider        string             `bson:"provider" json:"provider"` // porter, self
+	ProviderOrderID string             `bson:"provider_order_id,omitempty" json:"provider_order_id"`
+	Latitude        float64            `bson:"latitude" json:"latitude"`
+	Longitude       float64            `bson:"longitude" json:"longitude"`
+	Status          string             `bson:"status,omitempty" json:"status"`
+	RecordedAt      time.Time          `bson:"recorded_at" json:"recorded_at"`
+}
+
+// OrderChatMessage model - MongoDB (a message in an order's chat between the customer, the
+// restaurant and the rider)
+type OrderChatMessage struct {
+	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	OrderID      string               `bson:"order_id" json:"order_id"`
+	SenderID     string               `bson:"sender_id" json:"sender_id"`
+	SenderRole   string               `bson:"sender_role" json:"sender_role"` // customer, restaurant, rider
+	Body         string               `bson:"body" json:"body"`
+	OriginalBody string               `bson:"original_body,omitempty" json:"-"` // before filtering, kept for disputes
+	QuickReply   string               `bson:"quick_reply,omitempty" json:"quick_reply,omitempty"`
+	Filtered     bool                 `bson:"filtered,omitempty" json:"filtered"`
+	ReadBy       map[string]time.Time `bson:"read_by,omitempty" json:"read_by"` // participant role -> read time
+	CreatedAt    time.Time            `bson:"created_at" json:"created_at"`
+}
+
+// Media model - MongoDB (uploaded images and their thumbnails in object storage)
+type Media struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RestaurantID string             `bson:"restaurant_id" json:"restaurant_id"`
+	OwnerType    string             `bson:"owner_type" json:"owner_type"` // product, category, restaurant
+	OwnerID      string             `bson:"owner_id" json:"owner_id"`
+	UploadedBy   string             `bson:"uploaded_by" json:"uploaded_by"`
+	FileName     string             `bson:"file_name,omitempty" json:"file_name"`
+	ContentType  string             `bson:"content_type" json:"content_type"`
+	Size         int64              `bson:"size" json:"size"`
+	Width        int                `bson:"width,omitempty" json:"width"`
+	Height       int                `bson:"height,omitempty" json:"height"`
+	Key          string             `bson:"key" json:"key"`
+	URL          string             `bson:"url,omitempty" json:"url"`
+	Thumbnails   map[string]string  `bson:"thumbnails,omitempty" json:"thumbnails"`         // size name -> object key
+	ThumbURLs    map[string]string  `bson:"thumbnail_urls,omitempty" json:"thumbnail_urls"` // size name -> public URL
+	Status       string             `bson:"status" json:"status"`                           // pending, ready
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// PriceHistory model - MongoDB (a change to a product's prices, kept for audit and for the
+// struck-through was price)
+type PriceHistory struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ProductID    primitive.ObjectID  `bson:"product_id" json:"product_id"`
+	RestaurantID string              `bson:"restaurant_id" json:"restaurant_id"`
+	Before       PriceSnapshot       `bson:"before" json:"before"`
+	After        PriceSnapshot       `bson:"after" json:"after"`
+	Source       string              `bson:"source" json:"source"`                             // manual, scheduled, brand_sync, outlet_override
+	ChangedBy    string              `bson:"changed_by,omitempty" json:"changed_by,omitempty"` // user ID; empty for changes the system made
+	ScheduleID   *primitive.ObjectID `bson:"schedule_id,omitempty" json:"schedule_id,omitempty"`
+	ChangedAt    time.Time           `bson:"changed_at" json:"changed_at"`
+}
+
+// ScheduledPriceChange model - MongoDB (prices a product switches to at a set time, and back
+// from at an optional end, such as weekend pricing)
+type ScheduledPriceChange struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID     primitive.ObjectID `bson:"product_id" json:"product_id"`
+	RestaurantID  string             `bson:"restaurant_id" json:"restaurant_id"`
+	Prices        PriceSnapshot      `bson:"prices" json:"prices"`                         // no discount price clears it; variants left out keep theirs
+	Previous      *PriceSnapshot     `bson:"previous,omitempty" json:"previous,omitempty"` // prices before it applied, restored at the end
+	StartsAt      time.Time          `bson:"starts_at" json:"starts_at"`
+	EndsAt        *time.Time         `bson:"ends_at,omitempty" json:"ends_at,omitempty"`
+	Status        string             `bson:"status" json:"status"` // scheduled, applied, reverted, superseded, cancelled, failed
+	Note          string             `bson:"note,omitempty" json:"note,omitempty"`
+	FailureReason string             `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+	CreatedBy     string             `bson:"created_by" json:"created_by"`
+	AppliedAt     *time.Time         `bson:"applied_at,omitempty" json:"applied_at,omitempty"`
+	EndedAt       *time.Time         `bson:"ended_at,omitempty" json:"ended_at,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
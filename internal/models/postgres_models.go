@@ -4,9 +4,14 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"sort"
+	"strings"
 	"time"
 
+	"golang-food-backend/pkg/fieldcrypt"
+
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // JSONB type for PostgreSQL
@@ -61,23 +66,34 @@ func (s *StringArray) Scan(value interface{}) error {
 type User struct {
 	ID               uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	Name             string     `gorm:"not null" json:"name"`
-	Email            string     `gorm:"not null" json:"email"`
-	Phone            string     `gorm:"not null" json:"phone"`
+	Email            string     `gorm:"not null;serializer:pii" json:"email"` // encrypted at rest
+	Phone            string     `gorm:"not null;serializer:pii" json:"phone"` // encrypted at rest
+	EmailHash        string     `gorm:"not null;default:''" json:"-"`         // blind index for lookups by email
+	PhoneHash        string     `gorm:"not null;default:''" json:"-"`         // blind index for lookups by phone
+	AvatarURL        string     `json:"avatar_url"`
 	PasswordHash     string     `gorm:"not null" json:"-"`
 	CreatedAt        time.Time  `json:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at"`
 	DefaultAddressID *uuid.UUID `gorm:"type:uuid" json:"default_address_id"`
-	WalletBalance    float64    `gorm:"default:0" json:"wallet_balance"`
+	WalletBalance    Money      `gorm:"default:0" json:"wallet_balance"`
 	IsVerified       bool       `gorm:"default:false" json:"is_verified"`
-	Status           string     `gorm:"default:active" json:"status"`   // active, inactive, suspended
+	Status           string     `gorm:"default:active" json:"status"`   // active, inactive, suspended, deleted
 	RestaurantID     *uuid.UUID `gorm:"type:uuid" json:"restaurant_id"` // Required for customers/restaurant staff, optional for admins
-	Role             string     `gorm:"default:customer" json:"role"`   // customer, restaurant_owner, restaurant_staff, admin
+	Role             string     `gorm:"default:customer" json:"role"`   // customer, restaurant_owner, restaurant_staff, admin, rider
 
-	// Database constraints will be:
+	// Database constraints, created by internal/migrations:
 	// 1. Unique index on (email, restaurant_id) for customers
 	// 2. Unique index on (phone, restaurant_id) for customers
 	// 3. Unique index on email for non-customer roles
 	// 4. Unique index on phone for non-customer roles
+	// The same four on email_hash and phone_hash cover encrypted contacts.
+}
+
+// BeforeSave keeps the blind indexes in step with the email and phone they are made from
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	u.EmailHash = fieldcrypt.BlindIndex(u.Email)
+	u.PhoneHash = fieldcrypt.BlindIndex(u.Phone)
+	return nil
 }
 
 // Restaurant model - PostgreSQL
@@ -99,8 +115,64 @@ type Restaurant struct {
 	PreparationTime   int         `gorm:"default:15" json:"preparation_time_minutes"` // average prep time
 	CreatedAt         time.Time   `json:"created_at"`
 	PickupLocationID  *uuid.UUID  `gorm:"type:uuid" json:"pickup_location_id"`
-	FranchiseParentID *uuid.UUID  `gorm:"type:uuid" json:"franchise_parent_id"`
+	FranchiseParentID *uuid.UUID  `gorm:"type:uuid;index" json:"franchise_parent_id"` // brand this outlet belongs to
 	ContactNumber     string      `json:"contact_number"`
+	IsBrand           bool        `gorm:"default:false;index" json:"is_brand"` // parent brand that owns the menu; takes no orders itself
+	// Orders are only marked delivered once the rider or delivery partner has verified the
+	// code the customer was given
+	RequireDeliveryCode bool `gorm:"default:false" json:"require_delivery_code"`
+	// Order types the restaurant takes; at least one stays enabled
+	DeliveryEnabled bool `gorm:"not null;default:true" json:"delivery_enabled"`
+	PickupEnabled   bool `gorm:"not null;default:false" json:"pickup_enabled"`
+	DineInEnabled   bool `gorm:"not null;default:false" json:"dine_in_enabled"`
+	// ISO 4217 code of the currency menu prices are in and orders are charged in
+	Currency string `gorm:"size:3;not null;default:'INR'" json:"currency"`
+
+	// Onboarding; restaurants take no orders until an admin approves their KYC documents.
+	// Rows that predate onboarding default to approved.
+	OnboardingStatus string     `gorm:"default:approved;index" json:"onboarding_status"` // draft, documents_uploaded, under_review, approved, rejected
+	OnboardingNote   string     `json:"onboarding_note,omitempty"`                       // reason given with the last rejection
+	SubmittedAt      *time.Time `json:"submitted_at,omitempty"`
+	ApprovedAt       *time.Time `json:"approved_at,omitempty"`
+
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"` // soft delete; admins can restore
+}
+
+// RestaurantDocument model - PostgreSQL (one KYC document of each type per restaurant)
+type RestaurantDocument struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RestaurantID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_restaurant_document_type" json:"restaurant_id"`
+	Type         string     `gorm:"not null;uniqueIndex:idx_restaurant_document_type" json:"type"` // fssai, gst, bank_details
+	Number       string     `gorm:"not null" json:"number"`                                        // FSSAI licence number, GSTIN or bank account number
+	Details      JSONB      `gorm:"type:jsonb" json:"details"`                                     // bank_details: account_holder, ifsc
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`                                          // FSSAI licence expiry
+	MediaID      string     `json:"media_id"`                                                      // uploaded file in the media store
+	FileName     string     `json:"file_name"`
+	Status       string     `gorm:"default:pending_upload" json:"status"` // pending_upload, uploaded, verified, rejected
+	ReviewNote   string     `json:"review_note,omitempty"`
+	ReviewedBy   *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// RestaurantStaff model - PostgreSQL (a staff member of a restaurant; invitations go to a phone
+// number and become active when the invitee accepts with the OTP sent to it)
+type RestaurantStaff struct {
+	ID              uuid.UUID   `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RestaurantID    uuid.UUID   `gorm:"type:uuid;not null;index" json:"restaurant_id"`
+	UserID          *uuid.UUID  `gorm:"type:uuid;index" json:"user_id,omitempty"` // set when the invitation is accepted
+	User            *User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Name            string      `json:"name"`
+	Phone           string      `gorm:"not null;index" json:"phone"`
+	Permissions     StringArray `gorm:"type:jsonb" json:"permissions"`       // menu.edit, orders.manage, payouts.view
+	Status          string      `gorm:"default:invited;index" json:"status"` // invited, active, revoked
+	InvitedBy       uuid.UUID   `gorm:"type:uuid;not null" json:"invited_by"`
+	InviteExpiresAt time.Time   `json:"invite_expires_at"`
+	AcceptedAt      *time.Time  `json:"accepted_at,omitempty"`
+	RevokedAt       *time.Time  `json:"revoked_at,omitempty"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
 }
 
 // RestaurantDeliveryPartners model - PostgreSQL
@@ -110,34 +182,270 @@ type RestaurantDeliveryPartners struct {
 	Restaurant               Restaurant             `gorm:"foreignKey:RestaurantID" json:"restaurant,omitempty"`
 	DeliveryPartnerCompanyID uuid.UUID              `gorm:"type:uuid;not null" json:"delivery_partner_company_id"`
 	DeliveryPartnerCompany   DeliveryPartnerCompany `gorm:"foreignKey:DeliveryPartnerCompanyID" json:"delivery_partner_company,omitempty"`
+	Priority                 int                    `gorm:"default:0" json:"priority"`        // lower is tried first
+	Weight                   int                    `gorm:"not null;default:1" json:"weight"` // share of orders among links of the same priority; 0 only as a fallback
+	FeeOverride              *Money                 `json:"fee_override,omitempty"`           // fee agreed with the partner per delivery, used instead of its quotes
+	IsActive                 bool                   `gorm:"default:true" json:"is_active"`
+	Status                   string                 `gorm:"type:varchar(20);not null;default:'active'" json:"status"` // pending until the partner accepts, then active; declined
+	RequestedBy              *uuid.UUID             `gorm:"type:uuid" json:"requested_by,omitempty"`
+	RequestedAt              *time.Time             `json:"requested_at,omitempty"`
+	RespondedAt              *time.Time             `json:"responded_at,omitempty"` // when the partner accepted or declined
+	DeclineReason            string                 `json:"decline_reason,omitempty"`
+}
+
+// DeliveryDispatch model - PostgreSQL (one attempt by the dispatch engine to book an order's
+// delivery with a provider)
+type DeliveryDispatch struct {
+	ID                uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	OrderID           uuid.UUID  `gorm:"type:uuid;not null;index" json:"order_id"`
+	Attempt           int        `gorm:"not null" json:"attempt"`
+	Strategy          string     `gorm:"not null" json:"strategy"` // cheapest, fastest, priority
+	Provider          string     `gorm:"not null" json:"provider"`
+	DeliveryPartnerID *uuid.UUID `gorm:"type:uuid" json:"delivery_partner_id,omitempty"` // restaurant's link to the provider; nil for the platform default
+	QuotedFee         Money      `json:"quoted_fee"`
+	EstimatedMinutes  int        `json:"estimated_minutes"`
+	Status            string     `gorm:"not null" json:"status"` // booked, failed
+	ProviderOrderID   string     `json:"provider_order_id,omitempty"`
+	TrackingURL       string     `json:"tracking_url,omitempty"`
+	Error             string     `json:"error,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
 }
 
 // RestaurantDeliveryLocationBoundary model - PostgreSQL
 type RestaurantDeliveryLocationBoundary struct {
-	ID               uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	RestaurantID     uuid.UUID  `gorm:"type:uuid;not null" json:"restaurant_id"`
-	Restaurant       Restaurant `gorm:"foreignKey:RestaurantID" json:"restaurant,omitempty"`
-	GeoPolygon       JSONB      `gorm:"type:jsonb" json:"geo_polygon"`
-	DeliveryRadiusKm float64    `json:"delivery_radius_km"`
-	MinOrderValue    float64    `json:"min_order_value"`
-	DeliveryFee      float64    `json:"delivery_fee"`
+	ID                uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RestaurantID      uuid.UUID  `gorm:"type:uuid;not null" json:"restaurant_id"`
+	Restaurant        Restaurant `gorm:"foreignKey:RestaurantID" json:"restaurant,omitempty"`
+	GeoPolygon        JSONB      `gorm:"type:jsonb" json:"geo_polygon"`
+	DeliveryRadiusKm  float64    `json:"delivery_radius_km"`
+	MinOrderValue     Money      `json:"min_order_value"`
+	DeliveryFee       Money      `json:"delivery_fee"`
+	FreeDeliveryAbove Money      `json:"free_delivery_above"` // order subtotal for free delivery; 0 uses the platform threshold
+}
+
+// RestaurantSpecialHours model - PostgreSQL (a holiday closure or different hours on a range of
+// dates, overriding the weekly opening hours)
+type RestaurantSpecialHours struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RestaurantID uuid.UUID `gorm:"type:uuid;not null;index:idx_special_hours_restaurant,priority:1" json:"restaurant_id"`
+	StartDate    string    `gorm:"type:varchar(10);not null;index:idx_special_hours_restaurant,priority:2" json:"start_date"` // YYYY-MM-DD in the restaurant's time zone
+	EndDate      string    `gorm:"type:varchar(10);not null" json:"end_date"`                                                 // inclusive
+	IsClosed     bool      `gorm:"default:false" json:"is_closed"`
+	OpenTime     string    `json:"open_time,omitempty"`  // HH:MM, when not closed
+	CloseTime    string    `json:"close_time,omitempty"` // HH:MM; before OpenTime for hours past midnight
+	Reason       string    `json:"reason"`               // shown to customers, e.g. "Diwali"
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// RestaurantPause model - PostgreSQL (a spell of busy mode: the restaurant takes no new orders
+// until EndsAt, or until it resumes earlier)
+type RestaurantPause struct {
+	ID              uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RestaurantID    uuid.UUID  `gorm:"type:uuid;not null;index:idx_restaurant_pause_restaurant,priority:1" json:"restaurant_id"`
+	DurationMinutes int        `gorm:"not null" json:"duration_minutes"`
+	Reason          string     `json:"reason,omitempty"`
+	StartedAt       time.Time  `gorm:"not null;index:idx_restaurant_pause_restaurant,priority:2" json:"started_at"`
+	EndsAt          time.Time  `gorm:"not null" json:"ends_at"`
+	ResumedAt       *time.Time `gorm:"index" json:"resumed_at,omitempty"` // set when the pause ends
+	EndReason       string     `json:"end_reason,omitempty"`              // expired, resumed or replaced
+	PausedBy        *uuid.UUID `gorm:"type:uuid" json:"paused_by,omitempty"`
+	ResumedBy       *uuid.UUID `gorm:"type:uuid" json:"resumed_by,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
-// CommissionToRestaurant model - PostgreSQL
+// CommissionToRestaurant model - PostgreSQL (one version of a restaurant's commission terms;
+// versions are never edited, a new one closes the previous at its effective date)
 type CommissionToRestaurant struct {
 	ID              uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	RestaurantID    uuid.UUID  `gorm:"type:uuid;not null" json:"restaurant_id"`
+	RestaurantID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_commission_effective_from" json:"restaurant_id"`
 	Restaurant      Restaurant `gorm:"foreignKey:RestaurantID" json:"restaurant,omitempty"`
 	CommissionType  string     `gorm:"not null" json:"commission_type"` // percentage, flat
 	CommissionValue float64    `gorm:"not null" json:"commission_value"`
+	EffectiveFrom   time.Time  `gorm:"not null;default:'1970-01-01 00:00:00+00';uniqueIndex:idx_commission_effective_from" json:"effective_from"` // rows from before versioning apply from the start
+	EffectiveTo     *time.Time `json:"effective_to,omitempty"`                                                                                    // exclusive; nil while open-ended
+	Note            string     `json:"note,omitempty"`
+	CreatedBy       *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// PayoutBatch model - PostgreSQL (one weekly settlement run across all restaurants)
+type PayoutBatch struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PeriodStart time.Time `gorm:"not null;uniqueIndex:idx_payout_batch_period" json:"period_start"`
+	PeriodEnd   time.Time `gorm:"not null;uniqueIndex:idx_payout_batch_period" json:"period_end"`
+	Status      string    `gorm:"default:generated" json:"status"` // generated, completed (no payout left pending or failed)
+	PayoutCount int       `json:"payout_count"`
+	TotalAmount Money     `json:"total_amount"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RestaurantPayout model - PostgreSQL (a restaurant's earnings in a payout batch)
+type RestaurantPayout struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BatchID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"batch_id"`
+	RestaurantID uuid.UUID  `gorm:"type:uuid;not null;index" json:"restaurant_id"`
+	Restaurant   Restaurant `gorm:"foreignKey:RestaurantID" json:"restaurant,omitempty"`
+	PeriodStart  time.Time  `json:"period_start"`
+	PeriodEnd    time.Time  `json:"period_end"`
+	OrderCount   int        `json:"order_count"`
+	OrderValue   Money      `json:"order_value"` // items and packaging before tax
+	Commission   Money      `json:"commission"`
+	Fees         Money      `json:"fees"` // payment gateway fee and GST on platform charges
+	Refunds      Money      `json:"refunds"`
+	CarriedOver  Money      `json:"carried_over"` // negative balance brought in from an earlier payout
+	NetAmount    Money      `json:"net_amount"`
+	Status       string     `gorm:"default:pending" json:"status"` // pending, paid, failed, on_hold, carried_forward
+	Reference    string     `json:"reference,omitempty"`           // bank transfer UTR
+	Note         string     `json:"note,omitempty"`
+	PaidAt       *time.Time `json:"paid_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// OrderSettlement model - PostgreSQL (a restaurant's earnings from a delivered order, or the
+// deduction for a refund processed after it was settled)
+type OrderSettlement struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	SourceID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"source_id"` // order ID, or refund ID for refund rows
+	Type         string    `gorm:"not null" json:"type"`                            // order, refund
+	OrderID      uuid.UUID `gorm:"type:uuid;not null;index" json:"order_id"`
+	RestaurantID uuid.UUID `gorm:"type:uuid;not null" json:"restaurant_id"`
+	PayoutID     uuid.UUID `gorm:"type:uuid;not null;index" json:"payout_id"`
+	OrderValue   Money     `json:"order_value"`
+	Commission   Money     `json:"commission"`
+	Fees         Money     `json:"fees"`
+	Refunds      Money     `json:"refunds"`
+	NetAmount    Money     `json:"net_amount"`
+	OccurredAt   time.Time `json:"occurred_at"` // order placed, or refund processed
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 // Cart model - PostgreSQL (transactional data)
 type CartItem struct {
-	ProductID string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
+	ProductID string   `json:"product_id"`
+	Quantity  int      `json:"quantity"`
+	VariantID string   `json:"variant_id,omitempty"`
+	AddonIDs  []string `json:"addon_ids,omitempty"`
+	OptionIDs []string `json:"option_ids,omitempty"`
+}
+
+// Key identifies a cart line. A product without a selection is keyed by its ID alone;
+// the same product with a different variant, addons or options is a separate line.
+func (i CartItem) Key() string {
+	if i.VariantID == "" && len(i.AddonIDs) == 0 && len(i.OptionIDs) == 0 {
+		return i.ProductID
+	}
+	addonIDs := append([]string(nil), i.AddonIDs...)
+	sort.Strings(addonIDs)
+	key := i.ProductID + ":" + i.VariantID + ":" + strings.Join(addonIDs, ",")
+	if len(i.OptionIDs) > 0 {
+		optionIDs := append([]string(nil), i.OptionIDs...)
+		sort.Strings(optionIDs)
+		key += ":" + strings.Join(optionIDs, ",")
+	}
+	return key
+}
+
+// DecodeCartItems reads cart lines stored in a JSONB column under "items"
+func DecodeCartItems(data JSONB) []CartItem {
+	var items []CartItem
+	if raw, ok := data["items"]; ok {
+		itemsJson, _ := json.Marshal(raw)
+		json.Unmarshal(itemsJson, &items)
+	}
+	return items
+}
+
+// EncodeCartItems wraps cart lines for a JSONB column, which must hold an object
+func EncodeCartItems(items []CartItem) JSONB {
+	if items == nil {
+		items = []CartItem{}
+	}
+	return JSONB{"items": items}
+}
+
+// OrderLineItem is the priced snapshot of a cart line kept on the order
+type OrderLineItem struct {
+	ProductID   string           `json:"product_id"`
+	ProductName string           `json:"product_name"`
+	Quantity    int              `json:"quantity"`
+	VariantID   string           `json:"variant_id,omitempty"`
+	VariantName string           `json:"variant_name,omitempty"`
+	Addons      []SelectedAddon  `json:"addons,omitempty"`
+	Options     []SelectedOption `json:"options,omitempty"`
+	UnitPrice   Money            `json:"unit_price"`
+	Total       Money            `json:"total"`
+	Components  []LineComponent  `json:"components,omitempty"` // a combo's items, per combo; the combo is billed as one unit
+}
+
+// LineComponent is an item inside a combo line of a cart or order
+type LineComponent struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	VariantID   string `json:"variant_id,omitempty"`
+	VariantName string `json:"variant_name,omitempty"`
+	Quantity    int    `json:"quantity"`
+}
+
+// StockLines is what order lines take from stock: combos take their components instead
+func StockLines(lines []OrderLineItem) []OrderLineItem {
+	expanded := make([]OrderLineItem, 0, len(lines))
+	for _, line := range lines {
+		if len(line.Components) == 0 {
+			expanded = append(expanded, line)
+			continue
+		}
+		for _, component := range line.Components {
+			expanded = append(expanded, OrderLineItem{
+				ProductID:   component.ProductID,
+				ProductName: component.ProductName,
+				VariantID:   component.VariantID,
+				VariantName: component.VariantName,
+				Quantity:    component.Quantity * line.Quantity,
+			})
+		}
+	}
+	return expanded
+}
+
+// SelectedAddon is an addon chosen for a cart or order line, priced when it was chosen
+type SelectedAddon struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Price Money  `json:"price"`
+}
+
+// SelectedOption is an option chosen from a product's option group, priced when it was chosen
+type SelectedOption struct {
+	GroupID    string `json:"group_id"`
+	GroupName  string `json:"group_name"`
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	PriceDelta Money  `json:"price_delta"`
 }
 
+// DecodeOrderLineItems reads the line items stored on an order
+func DecodeOrderLineItems(data JSONB) []OrderLineItem {
+	var items []OrderLineItem
+	if raw, ok := data["items"]; ok {
+		itemsJson, _ := json.Marshal(raw)
+		json.Unmarshal(itemsJson, &items)
+	}
+	return items
+}
+
+// EncodeOrderLineItems wraps order line items for a JSONB column
+func EncodeOrderLineItems(items []OrderLineItem) JSONB {
+	if items == nil {
+		items = []OrderLineItem{}
+	}
+	return JSONB{"items": items}
+}
+
+// Cart holds a user's items for one restaurant. A user has at most one active cart per
+// restaurant (unique index in migrations/); the one changed or switched to last is current.
 type Cart struct {
 	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	UserID       uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
@@ -146,10 +454,12 @@ type Cart struct {
 	RestaurantID uuid.UUID  `gorm:"type:uuid;not null" json:"restaurant_id"`
 	Restaurant   Restaurant `gorm:"foreignKey:RestaurantID" json:"restaurant,omitempty"`
 	CouponID     *uuid.UUID `gorm:"type:uuid" json:"coupon_id"`
-	TotalAmount  float64    `json:"total_amount"`
+	TotalAmount  Money      `json:"total_amount"`
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
-	Status       string     `gorm:"default:active" json:"status"`
+	Status       string     `gorm:"default:active" json:"status"`        // active, abandoned, ordered, completed, cancelled
+	AbandonedAt  *time.Time `gorm:"index" json:"abandoned_at,omitempty"` // kept when an abandoned cart is recovered
+	Version      int        `gorm:"not null;default:1" json:"version"`   // optimistic lock; bumped on every update
 }
 
 // Order model - PostgreSQL (critical transactional data)
@@ -161,20 +471,55 @@ type Order struct {
 	Restaurant                     Restaurant       `gorm:"foreignKey:RestaurantID" json:"restaurant,omitempty"`
 	CartID                         uuid.UUID        `gorm:"type:uuid;not null" json:"cart_id"`
 	Cart                           Cart             `gorm:"foreignKey:CartID" json:"cart,omitempty"`
-	OrderStatus                    string           `gorm:"default:pending" json:"order_status"` // pending, confirmed, preparing, dispatched, delivered, cancelled
+	OrderType                      string           `gorm:"size:16;not null;default:'delivery';index" json:"order_type"` // delivery, pickup, dine_in
+	OrderStatus                    string           `gorm:"default:pending" json:"order_status"`                         // scheduled, pending, confirmed, preparing, dispatched, ready_for_pickup, delivered, cancelled
+	ScheduledFor                   *time.Time       `gorm:"index" json:"scheduled_for,omitempty"`                        // order-ahead slot; nil for ASAP orders
 	DeliveryPartnerID              *uuid.UUID       `gorm:"type:uuid" json:"delivery_partner_id"`
 	PaymentID                      *uuid.UUID       `gorm:"type:uuid" json:"payment_id"`
 	AddressID                      *uuid.UUID       `gorm:"type:uuid" json:"address_id"`
 	OrderLogs                      JSONB            `gorm:"type:jsonb" json:"order_logs"`
-	TotalAmount                    float64          `json:"total_amount"`
+	TotalAmount                    Money            `json:"total_amount"`
 	CreatedAt                      time.Time        `json:"created_at"`
 	DiscountDetails                JSONB            `gorm:"type:jsonb" json:"discount_details"`
 	PickupFullAddressWithLatLong   JSONB            `gorm:"type:jsonb" json:"pickup_full_address_with_lat_long"`
 	DeliveryFullAddressWithLatLong JSONB            `gorm:"type:jsonb" json:"delivery_full_address_with_lat_long"`
 	CustomerName                   string           `json:"customer_name"`
 	CustomerContact                string           `json:"customer_contact"`
+	CustomerNotes                  string           `gorm:"size:250" json:"customer_notes,omitempty"` // cooking or delivery instructions, printed on the kitchen ticket
 	PorterDeliveries               []PorterDelivery `gorm:"foreignKey:OrderID" json:"porter_deliveries,omitempty"`
 	ActivePorterDeliveryID         *uuid.UUID       `gorm:"type:uuid" json:"active_porter_delivery_id"`
+	TaxDetails                     JSONB            `gorm:"type:jsonb" json:"tax_details"`          // itemized GST and fee breakdown at checkout
+	DeliveryFee                    Money            `json:"delivery_fee"`                           // fee charged to the customer at checkout
+	DeliveryFeeDetails             JSONB            `gorm:"type:jsonb" json:"delivery_fee_details"` // quote source, surge and free-delivery info for reconciliation
+	LineItems                      JSONB            `gorm:"type:jsonb" json:"line_items"`           // priced items with variant and addon selections at checkout
+	DeletedAt                      gorm.DeletedAt   `gorm:"index" json:"deleted_at,omitempty"`      // soft delete; admins can restore
+	Version                        int              `gorm:"not null;default:1" json:"version"`      // optimistic lock; bumped on every update
+	DeliveryCode                   string           `gorm:"size:4" json:"-"`                        // 4-digit code the customer gives at handoff; shown only to them
+	DeliveryCodeVerifiedAt         *time.Time       `json:"delivery_code_verified_at,omitempty"`
+	PricingDetails                 JSONB            `gorm:"type:jsonb" json:"pricing_details,omitempty"`   // pricing rules applied at checkout
+	Currency                       string           `gorm:"size:3;not null;default:'INR'" json:"currency"` // restaurant's currency at checkout
+	Language                       string           `gorm:"size:8" json:"language,omitempty"`              // customer's language at checkout; used for their invoice and notifications
+	// Delivery time promised to the customer at checkout, moved later when the order runs late
+	PromisedAt         *time.Time `json:"promised_at,omitempty"`
+	OriginalPromisedAt *time.Time `json:"original_promised_at,omitempty"`
+	PromiseDetails     JSONB      `gorm:"type:jsonb" json:"promise_details,omitempty"` // prep, kitchen load and travel minutes, and delays
+	// Dine-in orders are served at a table and go on its open tab as one round
+	TableID     *uuid.UUID `gorm:"type:uuid" json:"table_id,omitempty"`
+	TableNumber string     `gorm:"size:20" json:"table_number,omitempty"`
+	TabID       *uuid.UUID `gorm:"type:uuid;index" json:"tab_id,omitempty"`
+}
+
+// ArchivedOrder is an old order moved out of the orders table, kept as a JSON snapshot of the
+// order with its logs, payments, refunds and Porter deliveries
+type ArchivedOrder struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	RestaurantID uuid.UUID `gorm:"type:uuid;not null;index" json:"restaurant_id"`
+	OrderStatus  string    `json:"order_status"`
+	TotalAmount  Money     `json:"total_amount"`
+	CreatedAt    time.Time `json:"created_at"` // when the order was placed
+	ArchivedAt   time.Time `gorm:"index" json:"archived_at"`
+	Snapshot     JSONB     `gorm:"type:jsonb" json:"snapshot"`
 }
 
 // PorterDelivery model - PostgreSQL (tracks Porter delivery details for orders)
@@ -193,9 +538,12 @@ type PorterDelivery struct {
 	EstimatedDeliveryTime *time.Time `json:"estimated_delivery_time"`
 	ActualDeliveryTime    *time.Time `json:"actual_delivery_time"`
 	PickupTime            *time.Time `json:"pickup_time"`
-	DeliveryFee           float64    `json:"delivery_fee"`
+	DeliveryFee           Money      `json:"delivery_fee"`
 	Distance              float64    `json:"distance"` // in kilometers
 	IsActive              bool       `gorm:"default:true" json:"is_active"`
+	ReconciledAt          *time.Time `json:"reconciled_at"`                        // last status poll after missed webhooks
+	NeedsAttention        bool       `gorm:"default:false" json:"needs_attention"` // flagged for ops, e.g. no rider assigned
+	AttentionReason       string     `json:"attention_reason,omitempty"`
 	CreatedAt             time.Time  `json:"created_at"`
 	UpdatedAt             time.Time  `json:"updated_at"`
 	PorterResponse        JSONB      `gorm:"type:jsonb" json:"porter_response"` // Full Porter API response
@@ -219,10 +567,10 @@ type Payment struct {
 	Order         Order     `gorm:"foreignKey:OrderID" json:"order,omitempty"`
 	UserID        uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
 	User          User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Amount        float64   `gorm:"not null" json:"amount"`
+	Amount        Money     `gorm:"not null" json:"amount"`
 	Method        string    `gorm:"not null" json:"method"`        // UPI, card, wallet, cash
-	Status        string    `gorm:"default:pending" json:"status"` // pending, success, failed
-	TransactionID string    `gorm:"uniqueIndex" json:"transaction_id"`
+	Status        string    `gorm:"default:pending" json:"status"` // pending, partially_paid, success, failed, abandoned, expired
+	TransactionID string    `json:"transaction_id"`                // unique when set; see internal/migrations
 	CreatedAt     time.Time `json:"created_at"`
 	Metadata      JSONB     `gorm:"type:jsonb" json:"metadata"`
 }
@@ -236,7 +584,7 @@ type Refund struct {
 	Payment      Payment    `gorm:"foreignKey:PaymentID" json:"payment,omitempty"`
 	UserID       uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
 	User         User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Amount       float64    `gorm:"not null" json:"amount"`
+	Amount       Money      `gorm:"not null" json:"amount"`
 	Reason       string     `json:"reason"`
 	Status       string     `gorm:"default:pending" json:"status"` // pending, approved, rejected, processed, failed
 	AdminComment *string    `json:"admin_comment"`
@@ -244,15 +592,98 @@ type Refund struct {
 	CreatedAt    time.Time  `json:"created_at"`
 }
 
+// SupportTicket model - PostgreSQL (a customer issue, usually about an order, worked by support
+// agents against response and resolution deadlines set by its priority)
+type SupportTicket struct {
+	ID                 uuid.UUID              `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID             uuid.UUID              `gorm:"type:uuid;not null;index" json:"user_id"`
+	OrderID            *uuid.UUID             `gorm:"type:uuid;index" json:"order_id,omitempty"`
+	RestaurantID       *uuid.UUID             `gorm:"type:uuid" json:"restaurant_id,omitempty"`
+	IssueType          string                 `gorm:"not null" json:"issue_type"` // missing_items, wrong_items, food_quality, late_delivery, payment, refund, other; refund_failed and delivery_failed are opened by the system
+	Subject            string                 `gorm:"not null" json:"subject"`
+	Status             string                 `gorm:"not null;default:open;index" json:"status"`    // open, awaiting_customer, resolved, closed
+	Priority           string                 `gorm:"not null;default:normal" json:"priority"`      // low, normal, high, urgent
+	Source             string                 `gorm:"not null;default:customer" json:"source"`      // customer, system
+	SourceKey          *string                `gorm:"uniqueIndex" json:"-"`                         // the failure a system ticket was opened for, so it is opened once
+	AssignedTo         *uuid.UUID             `gorm:"type:uuid;index" json:"assigned_to,omitempty"` // admin working the ticket
+	FirstResponseDueAt time.Time              `json:"first_response_due_at"`
+	ResolutionDueAt    time.Time              `json:"resolution_due_at"`
+	FirstRespondedAt   *time.Time             `json:"first_responded_at,omitempty"` // first agent reply the customer could see
+	ResolvedAt         *time.Time             `json:"resolved_at,omitempty"`
+	Resolution         string                 `json:"resolution,omitempty"`
+	LastMessageAt      time.Time              `json:"last_message_at"`
+	Messages           []SupportTicketMessage `gorm:"foreignKey:TicketID" json:"messages,omitempty"`
+	CreatedAt          time.Time              `json:"created_at"`
+	UpdatedAt          time.Time              `json:"updated_at"`
+}
+
+// SupportTicketMessage model - PostgreSQL (one message in a ticket's thread)
+type SupportTicketMessage struct {
+	ID          uuid.UUID   `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TicketID    uuid.UUID   `gorm:"type:uuid;not null;index" json:"ticket_id"`
+	AuthorType  string      `gorm:"not null" json:"author_type"` // customer, agent, system
+	AuthorID    *uuid.UUID  `gorm:"type:uuid" json:"author_id,omitempty"`
+	Body        string      `gorm:"type:text;not null" json:"body"`
+	Attachments StringArray `gorm:"type:jsonb" json:"attachments"` // media IDs of photos and PDFs, kept private
+	Internal    bool        `gorm:"default:false" json:"internal"` // agent note the customer does not see
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// Invoice model - PostgreSQL (GST tax invoice issued once an order is delivered). Numbers run
+// per restaurant and financial year without gaps; the PDF is kept in private media storage.
+type Invoice struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	OrderID       uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"order_id"`
+	RestaurantID  uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_invoices_restaurant_number" json:"restaurant_id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	InvoiceNumber string     `gorm:"not null;uniqueIndex:idx_invoices_restaurant_number" json:"invoice_number"` // e.g. 2627/000042
+	FinancialYear string     `gorm:"not null" json:"financial_year"`                                            // e.g. 2026-27
+	GSTNumber     string     `json:"gst_number"`                                                                // restaurant GSTIN at issue
+	TaxableValue  Money      `json:"taxable_value"`
+	TotalTax      Money      `json:"total_tax"`
+	TotalAmount   Money      `json:"total_amount"`
+	MediaID       string     `gorm:"not null" json:"media_id"` // stored PDF
+	IssuedAt      time.Time  `json:"issued_at"`
+	EmailedAt     *time.Time `json:"emailed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// InvoiceCounter holds the last invoice number used by a restaurant in a financial year
+type InvoiceCounter struct {
+	RestaurantID  uuid.UUID `gorm:"type:uuid;primaryKey" json:"restaurant_id"`
+	FinancialYear string    `gorm:"primaryKey" json:"financial_year"`
+	LastNumber    int64     `gorm:"not null;default:0" json:"last_number"`
+}
+
 // DeliveryPartnerCompany model - PostgreSQL
 type DeliveryPartnerCompany struct {
-	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Name        string    `gorm:"not null" json:"name"`
-	APIKey      string    `gorm:"uniqueIndex;not null" json:"api_key"`
-	ContactInfo JSONB     `gorm:"type:jsonb" json:"contact_info"`
-	GSTNumber   string    `gorm:"uniqueIndex" json:"gst_number"`
-	CreatedAt   time.Time `json:"created_at"`
-	Status      string    `gorm:"default:active" json:"status"`
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Name         string    `gorm:"not null" json:"name"`
+	ProviderCode string    `gorm:"index" json:"provider_code"`    // delivery provider registry key: porter, self, dunzo, shadowfax
+	APIKey       string    `gorm:"uniqueIndex;not null" json:"-"` // legacy plaintext key; partner API access uses PartnerAPIKey
+	ContactInfo  JSONB     `gorm:"type:jsonb" json:"contact_info"`
+	GSTNumber    string    `gorm:"uniqueIndex" json:"gst_number"`
+	CreatedAt    time.Time `json:"created_at"`
+	Status       string    `gorm:"default:active" json:"status"`
+}
+
+// PartnerAPIKey model - PostgreSQL (a delivery partner company's key for the partner API; only
+// its SHA-256 hash is stored)
+type PartnerAPIKey struct {
+	ID                       uuid.UUID   `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	DeliveryPartnerCompanyID uuid.UUID   `gorm:"type:uuid;not null;index" json:"delivery_partner_company_id"`
+	Name                     string      `gorm:"not null" json:"name"`
+	Prefix                   string      `gorm:"not null" json:"prefix"` // start of the key, to tell keys apart
+	KeyHash                  string      `gorm:"uniqueIndex;not null" json:"-"`
+	Scopes                   StringArray `gorm:"type:jsonb" json:"scopes"` // orders:read, orders:write, settlements:read, restaurants:read, restaurants:write
+	RateLimitPerMinute       int         `gorm:"not null" json:"rate_limit_per_minute"`
+	LastUsedAt               *time.Time  `json:"last_used_at,omitempty"`
+	ExpiresAt                *time.Time  `json:"expires_at,omitempty"`
+	RevokedAt                *time.Time  `json:"revoked_at,omitempty"`
+	ReplacedByID             *uuid.UUID  `gorm:"type:uuid" json:"replaced_by_id,omitempty"` // key issued when the partner rotated this one
+	CreatedBy                *uuid.UUID  `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt                time.Time   `json:"created_at"`
+	UpdatedAt                time.Time   `json:"updated_at"`
 }
 
 // Favourite model - PostgreSQL
@@ -273,14 +704,54 @@ type Coupon struct {
 	Description   string     `json:"description"`
 	DiscountType  string     `gorm:"not null" json:"discount_type"` // flat, percentage
 	DiscountValue float64    `gorm:"not null" json:"discount_value"`
-	MaxDiscount   float64    `json:"max_discount"`
-	MinOrderValue float64    `json:"min_order_value"`
+	MaxDiscount   Money      `json:"max_discount"`
+	MinOrderValue Money      `json:"min_order_value"`
 	ValidFrom     time.Time  `json:"valid_from"`
 	ValidTo       time.Time  `json:"valid_to"`
 	UsageLimit    int        `gorm:"default:-1" json:"usage_limit"` // -1 for unlimited
 	UsedCount     int        `gorm:"default:0" json:"used_count"`
 	IsActive      bool       `gorm:"default:true" json:"is_active"`
 	RestaurantID  *uuid.UUID `gorm:"type:uuid" json:"restaurant_id"` // null for platform-wide coupons
+
+	// Per-user limits and targeting; the zero values leave the coupon open to everyone
+	PerUserLimit  int         `gorm:"default:0" json:"per_user_limit"`             // 0 for unlimited
+	NewUsersOnly  bool        `gorm:"default:false" json:"new_users_only"`         // no orders placed yet
+	MinPastOrders int         `gorm:"default:0" json:"min_past_orders"`            // delivered orders required
+	TargetUserIDs StringArray `gorm:"type:jsonb" json:"target_user_ids,omitempty"` // empty for every user
+}
+
+// PricingRule model - PostgreSQL (a fee or delivery surge added to bills while it applies;
+// a restaurant's rules replace the platform-wide rules of the same type)
+type PricingRule struct {
+	ID            uuid.UUID   `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RestaurantID  *uuid.UUID  `gorm:"type:uuid;index" json:"restaurant_id"` // null for platform-wide rules
+	Name          string      `gorm:"not null" json:"name"`
+	RuleType      string      `gorm:"not null;index" json:"rule_type"`  // platform_fee, small_order_fee, delivery_surge, late_night_fee
+	Amount        Money       `json:"amount"`                           // fee added to the bill
+	Multiplier    float64     `json:"multiplier"`                       // delivery fee multiplier for surges
+	BelowSubtotal Money       `json:"below_subtotal"`                   // small-order fees apply to subtotals under this
+	Condition     string      `json:"condition,omitempty"`              // rain: only while rain mode is on
+	StartTime     string      `json:"start_time,omitempty"`             // HH:MM in the restaurant's timezone; may wrap past midnight
+	EndTime       string      `json:"end_time,omitempty"`               // HH:MM
+	Days          StringArray `gorm:"type:jsonb" json:"days,omitempty"` // monday, tuesday, etc; empty for every day
+	ValidFrom     *time.Time  `json:"valid_from,omitempty"`
+	ValidUntil    *time.Time  `json:"valid_until,omitempty"`
+	IsActive      bool        `gorm:"default:true" json:"is_active"`
+	CreatedBy     *uuid.UUID  `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// CouponRedemption model - PostgreSQL (one coupon use on an order)
+type CouponRedemption struct {
+	ID             uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	CouponID       uuid.UUID `gorm:"type:uuid;not null;index:idx_coupon_redemption_user" json:"coupon_id"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;index:idx_coupon_redemption_user" json:"user_id"`
+	OrderID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"order_id"`
+	DiscountAmount Money     `gorm:"not null" json:"discount_amount"`
+	Status         string    `gorm:"not null;default:'redeemed'" json:"status"` // redeemed, released
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 // Notification model - PostgreSQL
@@ -304,19 +775,114 @@ type AdminUser struct {
 	Role         string      `gorm:"not null" json:"role"`
 	Permissions  StringArray `gorm:"type:jsonb" json:"permissions"`
 	PasswordHash string      `gorm:"not null" json:"-"`
+	TOTPSecret   string      `json:"-"`
+	TOTPEnabled  bool        `gorm:"default:false" json:"totp_enabled"`
+	LastLoginAt  *time.Time  `json:"last_login_at"`
+	CreatedBy    *uuid.UUID  `gorm:"type:uuid" json:"created_by"`
 	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
 	IsActive     bool        `gorm:"default:true" json:"is_active"`
 }
 
 // AuditLog model - PostgreSQL
 type AuditLog struct {
-	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	EntityType  string    `gorm:"not null" json:"entity_type"`
-	EntityID    string    `gorm:"not null" json:"entity_id"`
-	Action      string    `gorm:"not null" json:"action"`
-	PerformedBy uuid.UUID `gorm:"type:uuid;not null" json:"performed_by"`
-	Timestamp   time.Time `gorm:"default:now()" json:"timestamp"`
-	Metadata    JSONB     `gorm:"type:jsonb" json:"metadata"`
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityType  string     `gorm:"not null;index:idx_audit_entity,priority:1" json:"entity_type"`
+	EntityID    string     `gorm:"not null;index:idx_audit_entity,priority:2" json:"entity_id"`
+	Action      string     `gorm:"not null" json:"action"`
+	PerformedBy *uuid.UUID `gorm:"type:uuid;index:idx_audit_actor,priority:1" json:"performed_by"` // nil for automatic actions
+	Timestamp   time.Time  `gorm:"default:now();index:idx_audit_entity,priority:3;index:idx_audit_actor,priority:2" json:"timestamp"`
+	Metadata    JSONB      `gorm:"type:jsonb" json:"metadata"` // request audit entries hold the route, actor role and before/after changes
+}
+
+// OutboxEvent model - PostgreSQL (an event written in the same transaction as the change it
+// describes and published to Kafka by the outbox relay)
+type OutboxEvent struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Topic         string     `gorm:"not null" json:"topic"`
+	Key           string     `json:"key"`
+	Payload       string     `gorm:"type:jsonb;not null" json:"payload"`
+	Status        string     `gorm:"not null;default:pending;index:idx_outbox_due,priority:1" json:"status"` // pending, published, failed
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_due,priority:2" json:"next_attempt_at"`
+	PublishedAt   *time.Time `json:"published_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// WebhookSubscription model - PostgreSQL (a restaurant's callback URL for POS integrations;
+// deliveries are signed with its secret)
+type WebhookSubscription struct {
+	ID           uuid.UUID   `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RestaurantID uuid.UUID   `gorm:"type:uuid;not null;index" json:"restaurant_id"`
+	URL          string      `gorm:"not null" json:"url"`
+	EventTypes   StringArray `gorm:"type:jsonb" json:"event_types"` // order.created, order.cancelled, payment.captured, ticket.print
+	Secret       string      `gorm:"not null" json:"-"`             // HMAC-SHA256 signing key, shown once
+	Description  string      `json:"description,omitempty"`
+	IsActive     bool        `gorm:"default:true" json:"is_active"`
+	CreatedBy    *uuid.UUID  `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+// WebhookDelivery model - PostgreSQL (one event sent to a subscription, retried with backoff)
+type WebhookDelivery struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_webhook_delivery_event" json:"subscription_id"`
+	EventType      string     `gorm:"not null" json:"event_type"`
+	EventID        string     `gorm:"not null;uniqueIndex:idx_webhook_delivery_event" json:"event_id"` // the same event is delivered once per subscription
+	Payload        string     `gorm:"type:jsonb;not null" json:"payload"`
+	Status         string     `gorm:"not null;default:pending;index:idx_webhook_delivery_due,priority:1" json:"status"` // pending, delivered, failed
+	Attempts       int        `gorm:"default:0" json:"attempts"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	ResponseBody   string     `json:"response_body,omitempty"` // start of the last response
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time  `gorm:"not null;index:idx_webhook_delivery_due,priority:2" json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// AuthSession model - PostgreSQL (one per login; the refresh token is rotated within it)
+type AuthSession struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID           uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	RefreshTokenHash string     `gorm:"not null" json:"-"` // SHA-256 of the only refresh token still accepted
+	DeviceID         string     `gorm:"index" json:"device_id"`
+	Platform         string     `json:"platform"` // android, ios, web
+	PushToken        string     `json:"-"`
+	IPAddress        string     `json:"ip_address"`
+	UserAgent        string     `json:"user_agent"`
+	ExpiresAt        time.Time  `gorm:"not null" json:"expires_at"`
+	LastRefreshedAt  *time.Time `json:"last_refreshed_at"`
+	RevokedAt        *time.Time `gorm:"index" json:"revoked_at,omitempty"`
+	RevokedReason    string     `json:"revoked_reason,omitempty"` // logout, logout_all, device_signed_out, replaced, refresh_token_reuse
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// PasswordHistory keeps the hashes of a user's recent passwords so they are not reused
+type PasswordHistory struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	PasswordHash string    `gorm:"not null" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UserIdentity links a user to an account at an external identity provider (google, apple,
+// truecaller). Customers are scoped to a restaurant, so one external account can be linked to
+// a different customer in each restaurant.
+type UserIdentity struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider     string     `gorm:"not null;uniqueIndex:idx_user_identity_subject" json:"provider"`
+	Subject      string     `gorm:"not null;uniqueIndex:idx_user_identity_subject" json:"-"` // provider's stable account ID
+	RestaurantID *uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_user_identity_subject" json:"restaurant_id"`
+	Email        string     `json:"email"`
+	LastLoginAt  *time.Time `json:"last_login_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
 // Address model - PostgreSQL (user addresses)
@@ -324,9 +890,9 @@ type Address struct {
 	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	UserID       uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
 	User         User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Type         string    `gorm:"not null" json:"type"` // home, office, other
-	AddressLine1 string    `gorm:"not null" json:"address_line1"`
-	AddressLine2 string    `json:"address_line2"`
+	Type         string    `gorm:"not null" json:"type"`                         // home, office, other
+	AddressLine1 string    `gorm:"not null;serializer:pii" json:"address_line1"` // encrypted at rest
+	AddressLine2 string    `gorm:"serializer:pii" json:"address_line2"`          // encrypted at rest
 	City         string    `gorm:"not null" json:"city"`
 	State        string    `gorm:"not null" json:"state"`
 	Country      string    `gorm:"not null" json:"country"`
@@ -336,17 +902,449 @@ type Address struct {
 	IsDefault    bool      `gorm:"default:false" json:"is_default"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Filled in by the geocoder when the address is saved
+	FormattedAddress string     `gorm:"serializer:pii" json:"formatted_address"` // encrypted at rest
+	PlaceID          string     `json:"place_id,omitempty"`
+	LocationVerified bool       `gorm:"default:false" json:"location_verified"` // pincode, city and map pin agree
+	GeocodedAt       *time.Time `json:"geocoded_at,omitempty"`
+}
+
+// DeliveryDetails is the address in the shape orders and delivery providers store it
+func (a *Address) DeliveryDetails() JSONB {
+	fullAddress := a.FormattedAddress
+	if fullAddress == "" {
+		parts := make([]string, 0, 5)
+		for _, part := range []string{a.AddressLine1, a.AddressLine2, a.City, a.State, a.PinCode} {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		fullAddress = strings.Join(parts, ", ")
+	}
+
+	return JSONB{
+		"address_id":    a.ID.String(),
+		"address_line1": a.AddressLine1,
+		"address_line2": a.AddressLine2,
+		"city":          a.City,
+		"state":         a.State,
+		"country":       a.Country,
+		"pin_code":      a.PinCode,
+		"full_address":  fullAddress,
+		"latitude":      a.Latitude,
+		"longitude":     a.Longitude,
+	}
 }
 
 // OTP model for SMS authentication
 type OTP struct {
 	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	Phone        string     `gorm:"not null" json:"phone"`
-	RestaurantID *uuid.UUID `gorm:"type:uuid" json:"restaurant_id"` // OTP can be restaurant-specific for customers, NULL for admin/staff
-	OTPCode      string     `gorm:"not null" json:"-"`              // Don't expose in JSON
+	RestaurantID *uuid.UUID `gorm:"type:uuid" json:"restaurant_id"`                // OTP can be restaurant-specific for customers, NULL for admin/staff
+	Purpose      string     `gorm:"size:32;not null;default:login" json:"purpose"` // login, password_reset, staff_invite
+	CodeHash     string     `gorm:"column:otp_code;not null" json:"-"`             // HMAC-SHA256 of the salt and code under OTP_HASH_KEY, hex
+	CodeSalt     string     `gorm:"not null;default:''" json:"-"`
 	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
 	IsUsed       bool       `gorm:"default:false" json:"is_used"`
 	AttemptCount int        `gorm:"default:0" json:"attempt_count"`
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
 }
+
+// SMSDelivery model - PostgreSQL (outbound text messages and their delivery reports)
+type SMSDelivery struct {
+	ID                uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Provider          string     `gorm:"not null;index:idx_sms_delivery_message" json:"provider"`
+	ProviderMessageID string     `gorm:"index:idx_sms_delivery_message" json:"provider_message_id"`
+	Phone             string     `gorm:"not null;index" json:"phone"`
+	Status            string     `gorm:"not null;default:'sent'" json:"status"` // sent, delivered, undelivered, failed
+	ErrorCode         string     `json:"error_code"`
+	LastReport        JSONB      `gorm:"type:jsonb" json:"last_report"`
+	SentAt            time.Time  `gorm:"not null" json:"sent_at"`
+	DeliveredAt       *time.Time `json:"delivered_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// MaintenanceWindow model - PostgreSQL (scheduled read-only periods)
+type MaintenanceWindow struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	StartsAt    time.Time  `gorm:"not null;index" json:"starts_at"`
+	EndsAt      time.Time  `gorm:"not null;index" json:"ends_at"`
+	Message     string     `json:"message"`
+	CreatedBy   uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// FeatureFlag model - PostgreSQL (a feature switched on for listed restaurants and a
+// percentage of the rest; a stored flag overrides the feature's built-in default)
+type FeatureFlag struct {
+	ID                    uuid.UUID   `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Key                   string      `gorm:"not null;uniqueIndex" json:"key"`
+	Description           string      `json:"description"`
+	Enabled               bool        `gorm:"default:false" json:"enabled"`              // master switch; off disables the feature everywhere
+	RolloutPercent        int         `gorm:"default:0" json:"rollout_percent"`          // share of restaurants (users without one) enabled, 0-100
+	RestaurantIDs         StringArray `gorm:"type:jsonb" json:"restaurant_ids"`          // always enabled while the flag is on
+	ExcludedRestaurantIDs StringArray `gorm:"type:jsonb" json:"excluded_restaurant_ids"` // never enabled
+	UpdatedBy             *uuid.UUID  `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt             time.Time   `json:"created_at"`
+	UpdatedAt             time.Time   `json:"updated_at"`
+}
+
+// RiskRule model - PostgreSQL (the stored settings of a built-in fraud rule, overriding its
+// default threshold, window and action)
+type RiskRule struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Key         string     `gorm:"not null;uniqueIndex" json:"key"`
+	Enabled     bool       `gorm:"default:true" json:"enabled"`
+	Action      string     `gorm:"not null" json:"action"`        // review, block
+	Threshold   float64    `gorm:"not null" json:"threshold"`     // count that triggers the rule, or kilometres for geo_mismatch
+	WindowHours int        `gorm:"default:0" json:"window_hours"` // look-back for counted rules
+	BlockHours  int        `gorm:"default:0" json:"block_hours"`  // blocklist the offending device, phone or user this long when the rule blocks
+	UpdatedBy   *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// BlocklistEntry model - PostgreSQL (a user, phone number or device barred from OTPs, logins and
+// checkout until it expires or is lifted; lifted entries are kept as history)
+type BlocklistEntry struct {
+	ID               uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Kind             string     `gorm:"not null;index:idx_blocklist_subject,priority:1" json:"kind"`  // user, phone, device
+	Value            string     `gorm:"not null;index:idx_blocklist_subject,priority:2" json:"value"` // user ID, phone digits or device ID
+	Reason           string     `gorm:"not null" json:"reason"`
+	Source           string     `gorm:"not null;default:admin" json:"source"`          // admin, risk
+	RiskAssessmentID *uuid.UUID `gorm:"type:uuid" json:"risk_assessment_id,omitempty"` // the fraud decision that blocked it
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`                          // never, when empty
+	CreatedBy        *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	LiftedAt         *time.Time `gorm:"index" json:"lifted_at,omitempty"`
+	LiftedBy         *uuid.UUID `gorm:"type:uuid" json:"lifted_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// RiskAssessment model - PostgreSQL (the fraud rules' decision on one checkout or refund
+// request; decisions held for review make up the admin review queue)
+type RiskAssessment struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Event         string     `gorm:"not null;index" json:"event"` // checkout, refund
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	OrderID       *uuid.UUID `gorm:"type:uuid;index" json:"order_id,omitempty"`
+	RefundID      *uuid.UUID `gorm:"type:uuid" json:"refund_id,omitempty"`
+	PaymentMethod string     `json:"payment_method,omitempty"`
+	DeviceID      string     `gorm:"index:idx_risk_assessment_device,priority:1" json:"device_id,omitempty"`
+	Phone         string     `gorm:"index:idx_risk_assessment_phone,priority:1" json:"phone,omitempty"`
+	IPAddress     string     `json:"ip_address,omitempty"`
+	Decision      string     `gorm:"not null;index" json:"decision"`       // allow, review, block
+	Triggered     JSONB      `gorm:"type:jsonb" json:"triggered"`          // rule key -> value, threshold and action of each rule that fired
+	ReviewStatus  string     `gorm:"index" json:"review_status,omitempty"` // pending, approved, rejected; only for review decisions
+	ReviewedBy    *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote    string     `json:"review_note,omitempty"`
+	CreatedAt     time.Time  `gorm:"index:idx_risk_assessment_device,priority:2;index:idx_risk_assessment_phone,priority:2" json:"created_at"`
+}
+
+// RestaurantTable model - PostgreSQL (a table of a dine-in restaurant; its QR code opens the
+// menu with the table attached to the customer's dine-in orders)
+type RestaurantTable struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RestaurantID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_restaurant_table_number" json:"restaurant_id"`
+	Number       string    `gorm:"size:20;not null;uniqueIndex:idx_restaurant_table_number" json:"number"` // as shown on the table, e.g. 12 or T4
+	Seats        int       `json:"seats"`
+	QRToken      string    `gorm:"size:64;not null;uniqueIndex" json:"qr_token"` // in the QR code's link; rotating it retires printed codes
+	IsActive     bool      `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableTab model - PostgreSQL (a running bill at a table: every dine-in round ordered at the
+// table while it is open goes on it, and it is settled in one payment when staff close it)
+type TableTab struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RestaurantID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"restaurant_id"`
+	TableID       uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_table_tabs_open,where:status = 'open'" json:"table_id"` // one open tab per table
+	TableNumber   string     `gorm:"size:20" json:"table_number"`
+	Status        string     `gorm:"size:10;not null;default:open;index" json:"status"` // open, closed
+	TotalAmount   Money      `json:"total_amount"`                                      // set when the tab is closed
+	PaymentMethod string     `json:"payment_method,omitempty"`                          // how the bill was settled: cash, card, upi
+	OpenedAt      time.Time  `json:"opened_at"`
+	ClosedAt      *time.Time `json:"closed_at,omitempty"`
+	ClosedBy      *uuid.UUID `gorm:"type:uuid" json:"closed_by,omitempty"`
+	Orders        []Order    `gorm:"foreignKey:TabID" json:"-"` // the rounds
+}
+
+// CateringQuote is a customer's request for a large order and the restaurant's quote for it.
+// An accepted quote becomes a scheduled order paid for through a payment link, starting with
+// the advance.
+type CateringQuote struct {
+	ID              uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	RestaurantID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"restaurant_id"`
+	Status          string     `gorm:"size:16;not null;default:requested;index" json:"status"` // requested, quoted, accepted, declined, cancelled, expired
+	OrderType       string     `gorm:"size:16;not null" json:"order_type"`                     // delivery or pickup
+	Headcount       int        `gorm:"not null" json:"headcount"`
+	EventDate       time.Time  `json:"event_date"`                   // when the food is wanted; the order is scheduled for it
+	Items           JSONB      `gorm:"type:jsonb" json:"-"`          // requested cart lines
+	LineItems       JSONB      `gorm:"type:jsonb" json:"line_items"` // the requested lines at menu prices
+	MenuTotal       Money      `json:"menu_total"`                   // what the lines cost at menu prices
+	AddressID       *uuid.UUID `gorm:"type:uuid" json:"address_id,omitempty"`
+	CustomerName    string     `json:"customer_name"`
+	CustomerContact string     `gorm:"serializer:pii" json:"customer_contact"` // encrypted at rest
+	CustomerNotes   string     `gorm:"size:1000" json:"customer_notes,omitempty"`
+	// The restaurant's quote
+	QuotedAmount   Money      `json:"quoted_amount"`
+	AdvancePercent int        `json:"advance_percent"` // share of the quote paid up front to confirm the order
+	QuoteNotes     string     `gorm:"size:1000" json:"quote_notes,omitempty"`
+	QuotedAt       *time.Time `json:"quoted_at,omitempty"`
+	QuotedBy       *uuid.UUID `gorm:"type:uuid" json:"quoted_by,omitempty"`
+	ValidUntil     *time.Time `json:"valid_until,omitempty"` // the quote can be accepted until then
+	DeclineReason  string     `json:"decline_reason,omitempty"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	OrderID        *uuid.UUID `gorm:"type:uuid" json:"order_id,omitempty"`
+	Currency       string     `gorm:"size:3;not null;default:'INR'" json:"currency"`
+	Version        int        `gorm:"not null;default:1" json:"version"` // optimistic lock; bumped on every update
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// MealPlan is a bundle of a restaurant's products customers subscribe to for recurring
+// deliveries, priced per delivery
+type MealPlan struct {
+	ID               uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RestaurantID     uuid.UUID `gorm:"type:uuid;not null;index" json:"restaurant_id"`
+	Name             string    `gorm:"size:100;not null" json:"name"`
+	Description      string    `gorm:"size:500" json:"description,omitempty"`
+	Items            JSONB     `gorm:"type:jsonb" json:"-"` // cart lines of each delivery
+	PricePerDelivery Money     `gorm:"not null" json:"price_per_delivery"`
+	CutoffHours      int       `gorm:"not null" json:"cutoff_hours"`  // deliveries are ordered, and can no longer be skipped, this long before they are due
+	IsActive         bool      `gorm:"default:true" json:"is_active"` // inactive plans take no new subscribers
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// MealPlanSubscription is a customer's subscription to a meal plan. Dates are YYYY-MM-DD in the
+// restaurant's time zone. Deliveries are billed a week at a time, ahead of time.
+type MealPlanSubscription struct {
+	ID               uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PlanID           uuid.UUID  `gorm:"type:uuid;not null;index" json:"plan_id"`
+	Plan             MealPlan   `gorm:"foreignKey:PlanID" json:"plan,omitempty"`
+	UserID           uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	RestaurantID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"restaurant_id"`
+	Status           string     `gorm:"size:16;not null;default:active;index" json:"status"` // active, paused, payment_due, cancelled, ended
+	Days             string     `gorm:"size:30" json:"-"`                                    // comma-separated weekdays (mon..sun); empty for every day
+	DeliveryTime     string     `gorm:"size:5;not null" json:"delivery_time"`                // HH:MM
+	OrderType        string     `gorm:"size:16;not null" json:"order_type"`                  // delivery or pickup
+	AddressID        *uuid.UUID `gorm:"type:uuid" json:"address_id,omitempty"`
+	CustomerName     string     `json:"customer_name"`
+	CustomerContact  string     `gorm:"serializer:pii" json:"customer_contact"` // encrypted at rest
+	PricePerDelivery Money      `gorm:"not null" json:"price_per_delivery"`     // the plan's price when subscribing
+	PaymentMethod    string     `gorm:"size:16;not null" json:"payment_method"` // wallet or mandate
+	MandateToken     string     `json:"-"`                                      // Razorpay recurring payment token
+	MandateCustomer  string     `gorm:"size:32" json:"-"`                       // Razorpay customer the token belongs to
+	CustomerEmail    string     `gorm:"serializer:pii" json:"customer_email,omitempty"`
+	StartDate        string     `gorm:"size:10;not null" json:"start_date"`
+	EndDate          string     `gorm:"size:10" json:"end_date,omitempty"`     // last delivery date; empty until cancelled
+	PausedUntil      string     `gorm:"size:10" json:"paused_until,omitempty"` // deliveries resume on this date; empty until resumed
+	PaidThrough      string     `gorm:"size:10" json:"paid_through,omitempty"` // deliveries up to this date are billed
+	Currency         string     `gorm:"size:3;not null;default:'INR'" json:"currency"`
+	CancelledAt      *time.Time `json:"cancelled_at,omitempty"`
+	Version          int        `gorm:"not null;default:1" json:"version"` // optimistic lock; bumped on every update
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// MealPlanDelivery records what happened to one delivery date of a subscription: its order was
+// placed or the customer skipped it
+type MealPlanDelivery struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_meal_plan_delivery_date" json:"subscription_id"`
+	PlanID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"plan_id"`
+	RestaurantID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"restaurant_id"`
+	Date           string     `gorm:"size:10;not null;uniqueIndex:idx_meal_plan_delivery_date" json:"date"`
+	Status         string     `gorm:"size:16;not null" json:"status"` // ordered, skipped
+	OrderID        *uuid.UUID `gorm:"type:uuid" json:"order_id,omitempty"`
+	Credited       Money      `json:"credited"` // refunded to the wallet for a skipped delivery already billed
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// MealPlanBill is a charge for a subscription's deliveries, or a credit to the customer's wallet
+// for billed deliveries that were skipped
+type MealPlanBill struct {
+	ID               uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	SubscriptionID   uuid.UUID `gorm:"type:uuid;not null;index" json:"subscription_id"`
+	PlanID           uuid.UUID `gorm:"type:uuid;not null;index" json:"plan_id"`
+	RestaurantID     uuid.UUID `gorm:"type:uuid;not null;index" json:"restaurant_id"`
+	UserID           uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	Kind             string    `gorm:"size:10;not null" json:"kind"` // charge, credit
+	PeriodStart      string    `gorm:"size:10" json:"period_start,omitempty"`
+	PeriodEnd        string    `gorm:"size:10" json:"period_end,omitempty"`
+	Deliveries       int       `json:"deliveries"`
+	Amount           Money     `json:"amount"`
+	Method           string    `gorm:"size:16" json:"method"`          // wallet or mandate; credits always go to the wallet
+	Status           string    `gorm:"size:10;not null" json:"status"` // pending (mandate charge in flight, or charged but neither saved nor refunded), paid, failed, refunded
+	GatewayPaymentID string    `json:"gateway_payment_id,omitempty"`
+	FailureReason    string    `json:"failure_reason,omitempty"`
+	CreatedAt        time.Time `gorm:"index" json:"created_at"`
+}
+
+// PaymentCustomer links a user to their customer record at Razorpay, which holds their saved
+// cards and UPI IDs
+type PaymentCustomer struct {
+	UserID             uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	RazorpayCustomerID string    `gorm:"size:32;not null;uniqueIndex" json:"-"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// SavedPaymentMethod is a card or UPI ID a customer saved at checkout. Only Razorpay's token
+// and what is needed to show the method are kept; card numbers never reach us.
+type SavedPaymentMethod struct {
+	ID                 uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID             uuid.UUID  `gorm:"type:uuid;not null;index" json:"-"`
+	Type               string     `gorm:"size:10;not null" json:"type"`          // card, upi
+	Token              string     `gorm:"size:64;not null;uniqueIndex" json:"-"` // Razorpay token ID
+	RazorpayCustomerID string     `gorm:"size:32;not null" json:"-"`
+	CardNetwork        string     `gorm:"size:32" json:"card_network,omitempty"` // Visa, MasterCard, RuPay...
+	CardIssuer         string     `gorm:"size:32" json:"card_issuer,omitempty"`
+	CardLast4          string     `gorm:"size:4" json:"card_last4,omitempty"`
+	VPA                string     `gorm:"size:100" json:"vpa,omitempty"` // masked UPI ID
+	IsDefault          bool       `gorm:"default:false" json:"is_default"`
+	LastUsedAt         *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// DietaryPreference is what a customer eats. With AutoFilter on, the menus and searches they
+// browse hide what does not fit unless the request asks otherwise.
+type DietaryPreference struct {
+	UserID         uuid.UUID   `gorm:"type:uuid;primaryKey" json:"-"`
+	Diet           string      `gorm:"size:10" json:"diet"`               // veg, vegan or empty for anything
+	AvoidAllergens StringArray `gorm:"type:jsonb" json:"avoid_allergens"` // hide dishes containing any of these
+	MaxSpiceLevel  *int        `json:"max_spice_level"`                   // hide dishes hotter than this
+	AutoFilter     bool        `gorm:"default:false" json:"auto_filter"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// PaymentReconciliation is a day's check of Razorpay's payments and settlements against our
+// payments, in the platform timezone
+type PaymentReconciliation struct {
+	ID              uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Date            string     `gorm:"size:10;not null;uniqueIndex" json:"date"` // YYYY-MM-DD
+	Status          string     `gorm:"size:10;not null" json:"status"`           // running, completed, failed
+	GatewayPayments int        `json:"gateway_payments"`                         // payments Razorpay reported
+	Matched         int        `json:"matched"`
+	Mismatches      int        `json:"mismatches"`
+	CapturedAmount  Money      `json:"captured_amount"` // captured at Razorpay during the day
+	SettledAmount   Money      `json:"settled_amount"`  // paid out by Razorpay during the day, before fees
+	Fees            Money      `json:"fees"`            // Razorpay fees and tax on the day's settlements
+	Error           string     `json:"error,omitempty"`
+	StartedAt       time.Time  `json:"started_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+}
+
+// ReconciliationMismatch is a payment on which Razorpay and our records disagree
+type ReconciliationMismatch struct {
+	ID               uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ReconciliationID uuid.UUID  `gorm:"type:uuid;not null;index" json:"reconciliation_id"`
+	Kind             string     `gorm:"size:24;not null;index" json:"kind"` // missing_capture, amount_mismatch, orphan_payment, unconfirmed_payment
+	PaymentID        *uuid.UUID `gorm:"type:uuid" json:"payment_id,omitempty"`
+	OrderID          *uuid.UUID `gorm:"type:uuid" json:"order_id,omitempty"`
+	TransactionID    string     `json:"transaction_id,omitempty"`
+	GatewayPaymentID string     `gorm:"size:32" json:"gateway_payment_id,omitempty"`
+	LocalStatus      string     `gorm:"size:20" json:"local_status,omitempty"`
+	GatewayStatus    string     `gorm:"size:20" json:"gateway_status,omitempty"`
+	LocalAmount      Money      `json:"local_amount"`
+	GatewayAmount    Money      `json:"gateway_amount"`
+	Currency         string     `gorm:"size:3" json:"currency"`
+	SettlementID     string     `gorm:"size:32" json:"settlement_id,omitempty"`
+	Detail           string     `json:"detail"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// LedgerAccount model - PostgreSQL (an account in the double-entry ledger: a customer's wallet,
+// what the platform owes a restaurant, the platform's revenue, or money collected through the
+// payment gateway and not yet paid out)
+type LedgerAccount struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Code      string     `gorm:"size:96;not null;uniqueIndex" json:"code"`  // kind[:owner ID]:currency
+	Kind      string     `gorm:"size:32;not null;index" json:"kind"`        // customer_wallet, restaurant_payable, platform_revenue, gateway_clearing
+	Type      string     `gorm:"size:16;not null" json:"type"`              // asset, liability, revenue
+	OwnerID   *uuid.UUID `gorm:"type:uuid;index" json:"owner_id,omitempty"` // the customer or restaurant
+	Currency  string     `gorm:"size:3;not null" json:"currency"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// JournalEntry model - PostgreSQL (one money movement posted to the ledger; the debits and
+// credits of its lines are equal)
+type JournalEntry struct {
+	ID          uuid.UUID     `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Reference   string        `gorm:"size:128;not null;uniqueIndex" json:"reference"` // the source event, so it is posted once
+	Kind        string        `gorm:"size:32;not null;index" json:"kind"`             // payment_captured, refund_processed, payout_settled, payout_paid, meal_plan_charge, meal_plan_credit
+	SourceID    uuid.UUID     `gorm:"type:uuid;not null;index" json:"source_id"`      // payment, refund, payout or meal plan bill
+	Description string        `json:"description"`
+	Currency    string        `gorm:"size:3;not null" json:"currency"`
+	PostedAt    time.Time     `gorm:"not null;index" json:"posted_at"`
+	Lines       []JournalLine `gorm:"foreignKey:EntryID" json:"lines,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// JournalLine model - PostgreSQL (a debit or a credit to one account in a journal entry)
+type JournalLine struct {
+	ID        uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntryID   uuid.UUID      `gorm:"type:uuid;not null;index" json:"entry_id"`
+	Entry     *JournalEntry  `gorm:"foreignKey:EntryID" json:"entry,omitempty"`
+	AccountID uuid.UUID      `gorm:"type:uuid;not null;index" json:"account_id"`
+	Account   *LedgerAccount `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+	Debit     Money          `json:"debit"`
+	Credit    Money          `json:"credit"`
+}
+
+// Rider model - PostgreSQL (restaurant-employed delivery riders for self-delivery)
+type Rider struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"user_id"` // login account with role rider
+	RestaurantID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"restaurant_id"`
+	Name          string     `gorm:"not null" json:"name"`
+	Phone         string     `gorm:"not null" json:"phone"`
+	VehicleType   string     `json:"vehicle_type"` // bike, scooter, bicycle
+	VehicleNumber string     `json:"vehicle_number"`
+	Status        string     `gorm:"default:offline;index" json:"status"` // offline, available, on_delivery
+	IsActive      bool       `gorm:"default:true" json:"is_active"`
+	LastLatitude  *float64   `json:"last_latitude,omitempty"`
+	LastLongitude *float64   `json:"last_longitude,omitempty"`
+	LastSeenAt    *time.Time `json:"last_seen_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// RiderAssignment model - PostgreSQL (a self-delivery job for one order)
+type RiderAssignment struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	OrderID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"order_id"`
+	RiderID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"rider_id"`
+	Rider        Rider      `gorm:"foreignKey:RiderID" json:"rider,omitempty"`
+	RestaurantID uuid.UUID  `gorm:"type:uuid;not null;index" json:"restaurant_id"`
+	Status       string     `gorm:"default:assigned;index" json:"status"` // assigned, accepted, picked_up, delivered, rejected, cancelled
+	HandoverOTP  string     `json:"-"`                                    // shared with the customer at pickup
+	AssignedAt   time.Time  `json:"assigned_at"`
+	AcceptedAt   *time.Time `json:"accepted_at,omitempty"`
+	PickedUpAt   *time.Time `json:"picked_up_at,omitempty"`
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// RestaurantTaxConfig model - PostgreSQL (GST and fee settings per restaurant)
+type RestaurantTaxConfig struct {
+	ID               uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RestaurantID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"restaurant_id"`
+	GSTRate          float64   `gorm:"not null" json:"gst_rate"`             // percent, split equally into CGST and SGST
+	CategoryGSTRates JSONB     `gorm:"type:jsonb" json:"category_gst_rates"` // category ID -> GST percent override
+	PricesIncludeTax bool      `gorm:"default:false" json:"prices_include_tax"`
+	PackagingFee     Money     `json:"packaging_fee"`
+	PlatformFee      Money     `json:"platform_fee"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
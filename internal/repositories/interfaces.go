@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"golang-food-backend/internal/models"
+	"time"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -21,13 +22,14 @@ type UserRepository interface {
 	Update(ctx context.Context, user *models.User) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID) ([]models.User, error)
+	// Anonymize erases the user's personal data across Postgres; see the implementation for what is kept
+	Anonymize(ctx context.Context, userID uuid.UUID, pseudonymize func(string) string) error
 }
 
 // OTPRepository interface for PostgreSQL OTP operations
 type OTPRepository interface {
 	Create(ctx context.Context, otp *models.OTP) error
-	GetValidOTP(ctx context.Context, phone string, restaurantID uuid.UUID, otpCode string) (*models.OTP, error)
-	GetValidOTPWithOptionalRestaurant(ctx context.Context, phone string, restaurantID *uuid.UUID, otpCode string) (*models.OTP, error)
+	GetActiveOTP(ctx context.Context, phone string, restaurantID *uuid.UUID, purpose string) (*models.OTP, error) // latest unused, unexpired code for the purpose
 	InvalidateOTP(ctx context.Context, id uuid.UUID) error
 	DeleteExpiredOTPs(ctx context.Context) error
 	IncrementAttempt(ctx context.Context, id uuid.UUID) error
@@ -42,6 +44,56 @@ type RestaurantRepository interface {
 	GetByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]models.Restaurant, error)
 	Search(ctx context.Context, query string, limit, offset int) ([]models.Restaurant, error)
 	GetRestaurantsWithAutoOpenClose() ([]*models.Restaurant, error)
+	GetByOnboardingStatus(ctx context.Context, status string, offset, limit int) ([]models.Restaurant, int64, error)
+	GetOutlets(ctx context.Context, brandID uuid.UUID) ([]models.Restaurant, error)
+	GetDeleted(ctx context.Context, offset, limit int) ([]models.Restaurant, int64, error)
+	Restore(ctx context.Context, id uuid.UUID) error
+}
+
+// RestaurantDocumentRepository interface for PostgreSQL restaurant KYC documents
+type RestaurantDocumentRepository interface {
+	Create(ctx context.Context, document *models.RestaurantDocument) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.RestaurantDocument, error)
+	GetByRestaurantAndType(ctx context.Context, restaurantID uuid.UUID, documentType string) (*models.RestaurantDocument, error)
+	GetByRestaurant(ctx context.Context, restaurantID uuid.UUID) ([]models.RestaurantDocument, error)
+	Update(ctx context.Context, document *models.RestaurantDocument) error
+}
+
+// StaffRepository interface for PostgreSQL restaurant staff and invitations
+type StaffRepository interface {
+	Create(ctx context.Context, staff *models.RestaurantStaff) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.RestaurantStaff, error)
+	GetByRestaurant(ctx context.Context, restaurantID uuid.UUID) ([]models.RestaurantStaff, error)
+	GetPendingInvite(ctx context.Context, restaurantID uuid.UUID, phone string) (*models.RestaurantStaff, error)
+	GetActiveByUser(ctx context.Context, userID uuid.UUID) (*models.RestaurantStaff, error)
+	Update(ctx context.Context, staff *models.RestaurantStaff) error
+}
+
+// CommissionRepository interface for PostgreSQL restaurant commission versions
+type CommissionRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.CommissionToRestaurant, error)
+	GetHistory(ctx context.Context, restaurantID uuid.UUID) ([]models.CommissionToRestaurant, error)
+	GetActiveAt(ctx context.Context, restaurantID uuid.UUID, at time.Time) (*models.CommissionToRestaurant, error)
+	CreateVersion(ctx context.Context, commission *models.CommissionToRestaurant) error
+	DeleteVersion(ctx context.Context, commission *models.CommissionToRestaurant) error
+}
+
+// PayoutRepository interface for PostgreSQL settlement and payout operations
+type PayoutRepository interface {
+	GetUnsettledOrders(ctx context.Context, before time.Time) ([]models.Order, error)
+	GetUnsettledRefunds(ctx context.Context, before time.Time) ([]models.Refund, error)
+	GetSettlementBySource(ctx context.Context, sourceID uuid.UUID) (*models.OrderSettlement, error)
+	GetNegativeHeldPayouts(ctx context.Context) ([]models.RestaurantPayout, error)
+	CreateBatch(ctx context.Context, batch *models.PayoutBatch, payouts []models.RestaurantPayout, settlements []models.OrderSettlement, carriedPayoutIDs []uuid.UUID) error
+	GetBatchByID(ctx context.Context, id uuid.UUID) (*models.PayoutBatch, error)
+	GetBatchByPeriod(ctx context.Context, periodStart time.Time) (*models.PayoutBatch, error)
+	GetBatches(ctx context.Context, offset, limit int) ([]models.PayoutBatch, int64, error)
+	UpdateBatch(ctx context.Context, batch *models.PayoutBatch) error
+	GetPayoutByID(ctx context.Context, id uuid.UUID) (*models.RestaurantPayout, error)
+	GetPayoutsByBatch(ctx context.Context, batchID uuid.UUID) ([]models.RestaurantPayout, error)
+	GetPayoutsByRestaurant(ctx context.Context, restaurantID uuid.UUID, offset, limit int) ([]models.RestaurantPayout, int64, error)
+	UpdatePayout(ctx context.Context, payout *models.RestaurantPayout) error
+	GetSettlementsByPayout(ctx context.Context, payoutID uuid.UUID) ([]models.OrderSettlement, error)
 }
 
 // OrderRepository interface for PostgreSQL order operations
@@ -53,6 +105,63 @@ type OrderRepository interface {
 	GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Order, error)
 	GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID, limit, offset int) ([]models.Order, error)
 	GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.Order, error)
+	GetByRestaurantIDSince(ctx context.Context, restaurantID uuid.UUID, since time.Time) ([]models.Order, error)
+	GetByUserIDSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.Order, error)
+	CountDeliveredByUserAndRestaurant(ctx context.Context, userID, restaurantID uuid.UUID, since, before time.Time) (int64, error)
+	CountByUser(ctx context.Context, userID uuid.UUID, deliveredOnly bool) (int64, error)
+	GetScheduledDue(ctx context.Context, before time.Time, limit int) ([]models.Order, error)
+	GetAwaitingPaymentSince(ctx context.Context, before time.Time, limit int) ([]models.Order, error)
+	GetByRestaurantIDs(ctx context.Context, restaurantIDs []uuid.UUID, status string, offset, limit int) ([]models.Order, int64, error)
+	List(ctx context.Context, filter OrderFilter, offset, limit int) ([]models.Order, int64, error)
+	CountByStatus(ctx context.Context, filter OrderFilter) ([]OrderStatusCount, error)
+	GetDailyRevenue(ctx context.Context, filter OrderFilter) ([]DailyRevenue, error)
+	GetDailyDeliveryFees(ctx context.Context, filter OrderFilter) ([]DailyDeliveryFees, error)
+	GetDeliveryPerformance(ctx context.Context, filter OrderFilter) (*DeliveryPerformance, error)
+	UpdateWithEvents(ctx context.Context, order *models.Order, events []models.OutboxEvent) error
+	UpdateWithPayment(ctx context.Context, order *models.Order, payment *models.Payment, events []models.OutboxEvent) error
+	GetDeleted(ctx context.Context, offset, limit int) ([]models.Order, int64, error)
+	Restore(ctx context.Context, id uuid.UUID) error
+	Archive(ctx context.Context, placedBefore, deletedBefore time.Time, limit int) (int64, error)
+	GetArchived(ctx context.Context, id uuid.UUID) (*models.ArchivedOrder, error)
+}
+
+// OrderFilter narrows order listings and aggregates. Zero fields do not filter; the created_at
+// range is half-open.
+type OrderFilter struct {
+	RestaurantID     uuid.UUID
+	Statuses         []string
+	From             time.Time
+	To               time.Time
+	MinAmount        *float64
+	MaxAmount        *float64
+	PaymentMethod    string // any payment attempt made with the method, case-insensitive
+	DeliveryProvider string // provider the delivery was booked with, e.g. porter
+
+	DeliveryPartnerCompanyID uuid.UUID // orders booked with the company through any restaurant's link to it
+}
+
+type DailyRevenue struct {
+	Day     string  `json:"day"` // YYYY-MM-DD
+	Orders  int64   `json:"orders"`
+	Revenue float64 `json:"revenue"`
+}
+
+type DailyDeliveryFees struct {
+	Day          string  `json:"day"` // YYYY-MM-DD
+	Orders       int64   `json:"orders"`
+	DeliveryFees float64 `json:"delivery_fees"`
+}
+
+// DeliveryPerformance summarizes how the orders matching a filter were delivered. Delivery
+// minutes run from when an order went out for delivery to when it was delivered; on-time
+// deliveries arrived by the time promised at checkout.
+type DeliveryPerformance struct {
+	Orders                 int64    `json:"orders"`
+	Delivered              int64    `json:"delivered"`
+	Cancelled              int64    `json:"cancelled"`
+	OnTime                 int64    `json:"on_time"`
+	Late                   int64    `json:"late"`
+	AverageDeliveryMinutes *float64 `json:"average_delivery_minutes"` // nil without timed deliveries
 }
 
 // PaymentRepository interface for PostgreSQL payment operations
@@ -63,6 +172,10 @@ type PaymentRepository interface {
 	GetByTransactionID(ctx context.Context, transactionID string) (*models.Payment, error)
 	Update(ctx context.Context, payment *models.Payment) error
 	GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.Payment, error)
+	CountByOrderID(ctx context.Context, orderID uuid.UUID) (int64, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Payment, error)
+	// GetByMethodsBetween lists payments by the given methods created in [from, to)
+	GetByMethodsBetween(ctx context.Context, methods []string, from, to time.Time) ([]models.Payment, error)
 }
 
 // CartRepository interface for PostgreSQL cart operations
@@ -70,7 +183,12 @@ type CartRepository interface {
 	Create(ctx context.Context, cart *models.Cart) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Cart, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Cart, error)
+	GetByUserAndRestaurant(ctx context.Context, userID, restaurantID uuid.UUID) (*models.Cart, error)
+	GetActiveByUserID(ctx context.Context, userID uuid.UUID) ([]models.Cart, error)
+	GetAbandoned(ctx context.Context, userID, restaurantID uuid.UUID, since time.Time) (*models.Cart, error)
+	GetInactive(ctx context.Context, before time.Time, limit int) ([]models.Cart, error)
 	Update(ctx context.Context, cart *models.Cart) error
+	UpdateWithEvents(ctx context.Context, cart *models.Cart, events []models.OutboxEvent) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
@@ -80,11 +198,23 @@ type ProductRepository interface {
 	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error)
 	Update(ctx context.Context, product *models.Product) error
 	Delete(ctx context.Context, id primitive.ObjectID) error
-	GetByRestaurantID(ctx context.Context, restaurantID string, limit, offset int) ([]models.Product, error)
+	// GetByRestaurantID, Search, SearchAcrossRestaurants and GetByRestaurantCategoryAndTime leave
+	// out products that do not pass diet
+	GetByRestaurantID(ctx context.Context, restaurantID string, diet models.DietaryFilter, limit, offset int) ([]models.Product, error)
 	GetByCategoryID(ctx context.Context, categoryID primitive.ObjectID, limit, offset int) ([]models.Product, error)
-	Search(ctx context.Context, query string, restaurantID string, limit, offset int) ([]models.Product, error)
-	GetHighlighted(ctx context.Context, restaurantID string, highlightType string) ([]models.Product, error)
-	GetByRestaurantCategoryAndTime(ctx context.Context, restaurantID string, categoryID *primitive.ObjectID, availableOnly bool, currentTime string, limit, offset int) ([]models.Product, int64, error)
+	Search(ctx context.Context, query string, restaurantID string, diet models.DietaryFilter, limit, offset int) ([]models.Product, error)
+	SearchAcrossRestaurants(ctx context.Context, query string, restaurantIDs []string, diet models.DietaryFilter, limit int) ([]models.Product, error)
+	GetByRestaurantCategoryAndTime(ctx context.Context, restaurantID string, categoryID *primitive.ObjectID, availableOnly bool, currentTime string, diet models.DietaryFilter, limit, offset int) ([]models.Product, int64, error)
+	UpdateBadges(ctx context.Context, restaurantID string, badges map[primitive.ObjectID][]string) error
+	GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Product, error)
+	GetAllByRestaurant(ctx context.Context, restaurantID string) ([]models.Product, error)
+	// GetCombosWithComponent returns the combos that include the product
+	GetCombosWithComponent(ctx context.Context, productID primitive.ObjectID) ([]models.Product, error)
+	GetDeleted(ctx context.Context, restaurantID string, offset, limit int) ([]models.Product, int64, error)
+	Restore(ctx context.Context, id primitive.ObjectID) (*models.Product, error)
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	// ClearExpiredWasPrices drops was prices shown until before the given time
+	ClearExpiredWasPrices(ctx context.Context, now time.Time) (int64, error)
 }
 
 // ProductCategoryRepository interface for MongoDB category operations
@@ -94,6 +224,7 @@ type ProductCategoryRepository interface {
 	Update(ctx context.Context, category *models.ProductCategory) error
 	Delete(ctx context.Context, id primitive.ObjectID) error
 	GetByRestaurantID(ctx context.Context, restaurantID string) ([]models.ProductCategory, error)
+	GetAllByRestaurant(ctx context.Context, restaurantID string) ([]models.ProductCategory, error)
 }
 
 // RatingReviewRepository interface for MongoDB review operations
@@ -104,6 +235,7 @@ type RatingReviewRepository interface {
 	Delete(ctx context.Context, id primitive.ObjectID) error
 	GetByEntityID(ctx context.Context, entityID string, reviewType string, limit, offset int) ([]models.RatingReview, error)
 	GetByUserID(ctx context.Context, userID string, limit, offset int) ([]models.RatingReview, error)
+	AnonymizeUser(ctx context.Context, userID, pseudonym string) error
 }
 
 // InventoryRepository interface for MongoDB inventory operations
@@ -115,6 +247,33 @@ type InventoryRepository interface {
 	ReserveStock(ctx context.Context, productID primitive.ObjectID, quantity int) error
 	ReleaseStock(ctx context.Context, productID primitive.ObjectID, quantity int) error
 	GetLowStock(ctx context.Context, restaurantID string) ([]models.Inventory, error)
+	// ApplyTransaction atomically adjusts quantity and reserved quantity, records the
+	// transaction in the stock history and returns the updated inventory
+	ApplyTransaction(ctx context.Context, productID primitive.ObjectID, quantityDelta, reservedDelta int, transaction models.StockTransaction) (*models.Inventory, error)
+}
+
+// PriceHistoryRepository interface for MongoDB product price history operations
+type PriceHistoryRepository interface {
+	Create(ctx context.Context, entry *models.PriceHistory) error
+	// GetByProductID returns a product's price changes, newest first
+	GetByProductID(ctx context.Context, productID primitive.ObjectID, offset, limit int) ([]models.PriceHistory, int64, error)
+}
+
+// PriceScheduleRepository interface for MongoDB scheduled price change operations
+type PriceScheduleRepository interface {
+	Create(ctx context.Context, change *models.ScheduledPriceChange) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.ScheduledPriceChange, error)
+	// Save writes the change if it is still in fromStatus, returning false when another run
+	// moved it on first
+	Save(ctx context.Context, change *models.ScheduledPriceChange, fromStatus string) (bool, error)
+	// GetByRestaurant lists the restaurant's changes by start time, of one product and in one
+	// status when set
+	GetByRestaurant(ctx context.Context, restaurantID string, productID *primitive.ObjectID, status string, offset, limit int) ([]models.ScheduledPriceChange, int64, error)
+	// GetDue returns scheduled changes starting by now and applied changes ending by now
+	GetDue(ctx context.Context, now time.Time) ([]models.ScheduledPriceChange, error)
+	// HasOverlap reports whether another scheduled or applied change of the product overlaps
+	// the window; an open end runs forever
+	HasOverlap(ctx context.Context, productID primitive.ObjectID, startsAt time.Time, endsAt *time.Time) (bool, error)
 }
 
 // CouponRepository interface for PostgreSQL coupon operations
@@ -125,6 +284,22 @@ type CouponRepository interface {
 	Update(ctx context.Context, coupon *models.Coupon) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetCouponsWithFilters(ctx context.Context, offset, limit int, restaurantID *uuid.UUID, active *bool) ([]models.Coupon, int64, error)
+	GetActiveForRestaurant(ctx context.Context, restaurantID uuid.UUID, at time.Time) ([]models.Coupon, error)
+	Redeem(ctx context.Context, redemption *models.CouponRedemption, perUserLimit int) error
+	ReleaseRedemption(ctx context.Context, orderID uuid.UUID) error
+	CountUserRedemptions(ctx context.Context, userID uuid.UUID, couponIDs []uuid.UUID) (map[uuid.UUID]int64, error)
+	GetRedemptions(ctx context.Context, couponID uuid.UUID, offset, limit int) ([]models.CouponRedemption, int64, error)
+}
+
+// PricingRuleRepository interface for PostgreSQL pricing rule operations
+type PricingRuleRepository interface {
+	Create(ctx context.Context, rule *models.PricingRule) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.PricingRule, error)
+	Update(ctx context.Context, rule *models.PricingRule) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, restaurantID *uuid.UUID, ruleType string) ([]models.PricingRule, error)
+	// GetActiveForRestaurant returns the restaurant's and the platform-wide active rules valid at the given time
+	GetActiveForRestaurant(ctx context.Context, restaurantID uuid.UUID, at time.Time) ([]models.PricingRule, error)
 }
 
 // RefundRepository interface for PostgreSQL refund operations
@@ -136,6 +311,40 @@ type RefundRepository interface {
 	GetByUserIDWithFilters(ctx context.Context, userID uuid.UUID, offset, limit int, status string) ([]models.Refund, int64, error)
 }
 
+// SupportTicketRepository interface for PostgreSQL support ticket operations
+type SupportTicketRepository interface {
+	// Create saves a ticket with its first message and the events in one transaction. A ticket
+	// whose source key is already taken is not saved; created reports whether it was.
+	Create(ctx context.Context, ticket *models.SupportTicket, message *models.SupportTicketMessage, events []models.OutboxEvent) (created bool, err error)
+	// GetByID returns the ticket with its messages, oldest first
+	GetByID(ctx context.Context, id uuid.UUID) (*models.SupportTicket, error)
+	// Save updates the ticket, adding the message if there is one, with the events in one transaction
+	Save(ctx context.Context, ticket *models.SupportTicket, message *models.SupportTicketMessage, events []models.OutboxEvent) error
+	List(ctx context.Context, filter SupportTicketFilter, offset, limit int) ([]models.SupportTicket, int64, error)
+	// HasAttachment reports whether any message references the media
+	HasAttachment(ctx context.Context, mediaID string) (bool, error)
+	// GetOpenOrderIDs returns the orders that have unresolved tickets
+	GetOpenOrderIDs(ctx context.Context) ([]uuid.UUID, error)
+}
+
+// SupportTicketFilter narrows ticket listings. Zero fields do not filter.
+type SupportTicketFilter struct {
+	UserID     uuid.UUID
+	Status     string
+	Priority   string
+	IssueType  string
+	AssignedTo uuid.UUID
+	Unassigned bool
+	BreachedAt time.Time // unresolved tickets that missed their response or resolution deadline by this time
+}
+
+// InvoiceRepository interface for PostgreSQL order invoices
+type InvoiceRepository interface {
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error)
+	Issue(ctx context.Context, orderID, restaurantID uuid.UUID, financialYear string, build func(number int64) (*models.Invoice, error)) (*models.Invoice, bool, error)
+	MarkEmailed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
 // AddressRepository interface for PostgreSQL address operations
 type AddressRepository interface {
 	Create(ctx context.Context, address *models.Address) error
@@ -156,15 +365,323 @@ type DeliveryPartnerRepository interface {
 	GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.DeliveryPartnerCompany, error)
 }
 
+// PartnerAPIKeyRepository interface for PostgreSQL partner API key operations
+type PartnerAPIKeyRepository interface {
+	Create(ctx context.Context, key *models.PartnerAPIKey) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.PartnerAPIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*models.PartnerAPIKey, error)
+	ListByCompany(ctx context.Context, companyID uuid.UUID) ([]models.PartnerAPIKey, error)
+	Update(ctx context.Context, key *models.PartnerAPIKey) error
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
 // RestaurantDeliveryPartnerRepository interface for restaurant-delivery partner relationships
 type RestaurantDeliveryPartnerRepository interface {
 	Create(ctx context.Context, relationship *models.RestaurantDeliveryPartners) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.RestaurantDeliveryPartners, error)
 	GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID) ([]models.RestaurantDeliveryPartners, error)
 	GetByDeliveryPartnerID(ctx context.Context, partnerID uuid.UUID) ([]models.RestaurantDeliveryPartners, error)
+	Update(ctx context.Context, relationship *models.RestaurantDeliveryPartners) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// MaintenanceWindowRepository interface for PostgreSQL maintenance window operations
+type MaintenanceWindowRepository interface {
+	Create(ctx context.Context, window *models.MaintenanceWindow) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.MaintenanceWindow, error)
+	Update(ctx context.Context, window *models.MaintenanceWindow) error
+	GetScheduled(ctx context.Context, endingAfter time.Time) ([]models.MaintenanceWindow, error)
+}
+
+// FeatureFlagRepository interface for PostgreSQL feature flag operations
+type FeatureFlagRepository interface {
+	GetByKey(ctx context.Context, key string) (*models.FeatureFlag, error)
+	Save(ctx context.Context, flag *models.FeatureFlag) error
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context) ([]models.FeatureFlag, error)
+}
+
+// BlocklistRepository interface for PostgreSQL blocklist operations
+type BlocklistRepository interface {
+	Create(ctx context.Context, entry *models.BlocklistEntry) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.BlocklistEntry, error)
+	Update(ctx context.Context, entry *models.BlocklistEntry) error
+	// GetActive returns the subject's entry that is neither lifted nor expired at the time
+	GetActive(ctx context.Context, kind, value string, at time.Time) (*models.BlocklistEntry, error)
+	List(ctx context.Context, filter BlocklistFilter, offset, limit int) ([]models.BlocklistEntry, int64, error)
+}
+
+// BlocklistFilter narrows blocklist listings. Zero fields do not filter.
+type BlocklistFilter struct {
+	Kind     string
+	Value    string
+	Source   string
+	ActiveAt time.Time // entries neither lifted nor expired at this time
+}
+
+// RiskRepository interface for PostgreSQL fraud rule settings, decisions and the order history
+// the rules count
+type RiskRepository interface {
+	ListRules(ctx context.Context) ([]models.RiskRule, error)
+	GetRule(ctx context.Context, key string) (*models.RiskRule, error)
+	SaveRule(ctx context.Context, rule *models.RiskRule) error
+	CreateAssessment(ctx context.Context, assessment *models.RiskAssessment) error
+	GetAssessment(ctx context.Context, id uuid.UUID) (*models.RiskAssessment, error)
+	UpdateAssessment(ctx context.Context, assessment *models.RiskAssessment) error
+	ListAssessments(ctx context.Context, filter RiskAssessmentFilter, offset, limit int) ([]models.RiskAssessment, int64, error)
+	CountAssessments(ctx context.Context, filter RiskAssessmentFilter) (int64, error)
+	// CountCancelledOrders counts the user's orders paid with the method that were cancelled since
+	CountCancelledOrders(ctx context.Context, userID uuid.UUID, paymentMethod string, since time.Time) (int64, error)
+	// CountRefunds counts the refunds requested for the user's orders since
+	CountRefunds(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error)
+}
+
+// RiskAssessmentFilter narrows decision listings and counts. Zero fields do not filter.
+type RiskAssessmentFilter struct {
+	Event        string
+	UserID       uuid.UUID
+	DeviceID     string
+	Phone        string
+	Decision     string
+	ReviewStatus string
+	Since        time.Time
+}
+
+// RestaurantTableRepository interface for PostgreSQL dine-in table operations
+type RestaurantTableRepository interface {
+	Create(ctx context.Context, table *models.RestaurantTable) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.RestaurantTable, error)
+	GetByQRToken(ctx context.Context, token string) (*models.RestaurantTable, error)
+	GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID) ([]models.RestaurantTable, error)
+	Update(ctx context.Context, table *models.RestaurantTable) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// TableTabRepository interface for PostgreSQL running tab operations
+type TableTabRepository interface {
+	// GetOrOpen returns the table's open tab, opening one when there is none
+	GetOrOpen(ctx context.Context, table *models.RestaurantTable) (*models.TableTab, error)
+	GetOpenByTableID(ctx context.Context, tableID uuid.UUID) (*models.TableTab, error)
+	GetOpenByRestaurantID(ctx context.Context, restaurantID uuid.UUID) ([]models.TableTab, error)
+	// Close closes the tab and settles the pending payments of its rounds with the method,
+	// returning the payments it settled
+	Close(ctx context.Context, tab *models.TableTab) ([]models.Payment, error)
+}
+
+// CateringQuoteRepository interface for PostgreSQL catering quote operations
+type CateringQuoteRepository interface {
+	// Create saves the quote with the events in one transaction
+	Create(ctx context.Context, quote *models.CateringQuote, events []models.OutboxEvent) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.CateringQuote, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.CateringQuote, error)
+	// GetByRestaurantID lists the restaurant's quotes, all of them when status is empty
+	GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID, status string, limit, offset int) ([]models.CateringQuote, error)
+	// Update saves the quote with the events in one transaction, failing with ErrVersionConflict
+	// if it changed since it was read
+	Update(ctx context.Context, quote *models.CateringQuote, events []models.OutboxEvent) error
+}
+
+// MealPlanRepository interface for PostgreSQL meal plan operations
+type MealPlanRepository interface {
+	Create(ctx context.Context, plan *models.MealPlan) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.MealPlan, error)
+	// GetByRestaurantID lists the restaurant's plans, only those taking subscribers when activeOnly
+	GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID, activeOnly bool) ([]models.MealPlan, error)
+	Update(ctx context.Context, plan *models.MealPlan) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// MealPlanSubscriptionRepository interface for PostgreSQL meal plan subscription operations
+type MealPlanSubscriptionRepository interface {
+	// Create saves the subscription with the changes in one transaction
+	Create(ctx context.Context, subscription *models.MealPlanSubscription, changes MealPlanChanges) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.MealPlanSubscription, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.MealPlanSubscription, error)
+	// GetByRestaurantID lists the restaurant's subscriptions, narrowed to a plan and a status when set
+	GetByRestaurantID(ctx context.Context, restaurantID, planID uuid.UUID, status string, limit, offset int) ([]models.MealPlanSubscription, error)
+	// GetRunning returns every subscription that is active, paused or waiting for payment
+	GetRunning(ctx context.Context) ([]models.MealPlanSubscription, error)
+	// Update saves the subscription with the changes in one transaction, failing with
+	// ErrVersionConflict if it changed since it was read
+	Update(ctx context.Context, subscription *models.MealPlanSubscription, changes MealPlanChanges) error
+	// GetDeliveries returns the subscription's deliveries dated in the inclusive range, by date
+	GetDeliveries(ctx context.Context, subscriptionID uuid.UUID, from, to string) ([]models.MealPlanDelivery, error)
+	GetBills(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]models.MealPlanBill, error)
+	// CreateBill saves a bill on its own, ahead of charging it
+	CreateBill(ctx context.Context, bill *models.MealPlanBill) error
+	UpdateBill(ctx context.Context, bill *models.MealPlanBill) error
+	GetBillByGatewayPaymentID(ctx context.Context, gatewayPaymentID string) (*models.MealPlanBill, error)
+	// ClaimDelivery records the delivery unless its date already has one, reporting whether it did
+	ClaimDelivery(ctx context.Context, delivery *models.MealPlanDelivery) (bool, error)
+	// ReleaseDelivery deletes a claimed delivery whose order could not be placed
+	ReleaseDelivery(ctx context.Context, id uuid.UUID) error
+	SetDeliveryOrder(ctx context.Context, deliveryID, orderID uuid.UUID) error
+	// GetStats summarizes the restaurant's plans, counting deliveries and bills dated in the
+	// inclusive range
+	GetStats(ctx context.Context, restaurantID uuid.UUID, from, to string) ([]MealPlanStats, error)
+}
+
+// MealPlanChanges are written with a subscription. Paid wallet charges are taken from the
+// customer's wallet, failing with ErrInsufficientWalletBalance, and credits are added to it.
+// Bills saved ahead of their charge are updated in place.
+type MealPlanChanges struct {
+	Deliveries []models.MealPlanDelivery
+	Bills      []models.MealPlanBill
+	Events     []models.OutboxEvent
+}
+
+type MealPlanStats struct {
+	PlanID      uuid.UUID    `json:"plan_id"`
+	Name        string       `json:"name"`
+	Active      int64        `json:"active"`
+	Paused      int64        `json:"paused"`
+	PaymentDue  int64        `json:"payment_due"`
+	Cancelled   int64        `json:"cancelled"`
+	Ended       int64        `json:"ended"`
+	Ordered     int64        `json:"ordered"` // deliveries whose orders were placed
+	Skipped     int64        `json:"skipped"`
+	Charged     models.Money `json:"charged"`
+	Credited    models.Money `json:"credited"`
+	FailedBills int64        `json:"failed_bills"`
+}
+
+// SavedPaymentMethodRepository interface for PostgreSQL saved payment method operations
+type SavedPaymentMethodRepository interface {
+	GetCustomer(ctx context.Context, userID uuid.UUID) (*models.PaymentCustomer, error)
+	// CreateCustomer records the user's gateway customer unless one already is
+	CreateCustomer(ctx context.Context, customer *models.PaymentCustomer) error
+	// Save adds a method, or refreshes it when its token is already saved. A user's first
+	// method becomes their default.
+	Save(ctx context.Context, method *models.SavedPaymentMethod) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.SavedPaymentMethod, error)
+	// GetByUserID lists the user's methods, the default first, then the most recently used
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.SavedPaymentMethod, error)
+	SetDefault(ctx context.Context, userID, id uuid.UUID) error
+	MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+	// Delete removes a method, making the user's most recently used one the default if it was
+	Delete(ctx context.Context, method *models.SavedPaymentMethod) error
+}
+
+// DietaryPreferenceRepository interface for PostgreSQL dietary preference operations
+type DietaryPreferenceRepository interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*models.DietaryPreference, error)
+	// Save creates or replaces the user's preferences
+	Save(ctx context.Context, preference *models.DietaryPreference) error
+}
+
+// ReconciliationRepository interface for PostgreSQL payment reconciliation operations
+type ReconciliationRepository interface {
+	// Claim starts the run for run.Date, filling run in. A completed run is only redone when
+	// force is set, dropping its mismatches; a running one is left alone unless it went stale.
+	Claim(ctx context.Context, run *models.PaymentReconciliation, force bool, staleAfter time.Duration) (bool, error)
+	// Complete saves the finished run with its mismatches
+	Complete(ctx context.Context, run *models.PaymentReconciliation, mismatches []models.ReconciliationMismatch) error
+	GetByDate(ctx context.Context, date string) (*models.PaymentReconciliation, error)
+	// List returns runs, newest date first
+	List(ctx context.Context, offset, limit int) ([]models.PaymentReconciliation, int64, error)
+	// GetMismatches lists a run's mismatches, of one kind when kind is set
+	GetMismatches(ctx context.Context, reconciliationID uuid.UUID, kind string) ([]models.ReconciliationMismatch, error)
+}
+
+// LedgerRepository interface for PostgreSQL double-entry ledger operations
+type LedgerRepository interface {
+	// GetOrCreateAccount fills account in from the account with its code, creating it when
+	// there is none
+	GetOrCreateAccount(ctx context.Context, account *models.LedgerAccount) error
+	GetAccountByID(ctx context.Context, id uuid.UUID) (*models.LedgerAccount, error)
+	GetAccountsByIDs(ctx context.Context, ids []uuid.UUID) ([]models.LedgerAccount, error)
+	// ListAccounts returns accounts by code, of one kind when kind is set
+	ListAccounts(ctx context.Context, kind string, offset, limit int) ([]models.LedgerAccount, int64, error)
+	// Post writes an entry with its lines, returning false when an entry with the same
+	// reference was already posted. Entries whose lines do not balance are refused.
+	Post(ctx context.Context, entry *models.JournalEntry) (bool, error)
+	GetEntryByID(ctx context.Context, id uuid.UUID) (*models.JournalEntry, error)
+	// GetLines returns an account's lines with their entries, newest first
+	GetLines(ctx context.Context, accountID uuid.UUID, offset, limit int) ([]models.JournalLine, int64, error)
+	// GetTotals sums the debits and credits posted before asOf to each of the accounts, or to
+	// every account posted to when accountIDs is empty
+	GetTotals(ctx context.Context, accountIDs []uuid.UUID, asOf time.Time) ([]LedgerTotals, error)
+}
+
+type LedgerTotals struct {
+	AccountID uuid.UUID
+	Debits    models.Money
+	Credits   models.Money
+}
+
+// RiderRepository interface for PostgreSQL self-delivery rider operations
+type RiderRepository interface {
+	Create(ctx context.Context, rider *models.Rider) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Rider, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Rider, error)
+	GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID) ([]models.Rider, error)
+	GetAvailableByRestaurantID(ctx context.Context, restaurantID uuid.UUID) ([]models.Rider, error)
+	Update(ctx context.Context, rider *models.Rider) error
+}
+
+// RiderAssignmentRepository interface for PostgreSQL self-delivery job operations
+type RiderAssignmentRepository interface {
+	Create(ctx context.Context, assignment *models.RiderAssignment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.RiderAssignment, error)
+	GetActiveByOrderID(ctx context.Context, orderID uuid.UUID) (*models.RiderAssignment, error)
+	GetActiveByRiderID(ctx context.Context, riderID uuid.UUID) ([]models.RiderAssignment, error)
+	GetByRiderID(ctx context.Context, riderID uuid.UUID, limit int) ([]models.RiderAssignment, error)
+	Update(ctx context.Context, assignment *models.RiderAssignment) error
+}
+
+// TaxConfigRepository interface for PostgreSQL restaurant tax settings
+type TaxConfigRepository interface {
+	Create(ctx context.Context, config *models.RestaurantTaxConfig) error
+	GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID) (*models.RestaurantTaxConfig, error)
+	Update(ctx context.Context, config *models.RestaurantTaxConfig) error
+}
+
+// DeliveryBoundaryRepository interface for restaurant delivery area operations
+type DeliveryBoundaryRepository interface {
+	Create(ctx context.Context, boundary *models.RestaurantDeliveryLocationBoundary) error
+	Update(ctx context.Context, boundary *models.RestaurantDeliveryLocationBoundary) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID) ([]models.RestaurantDeliveryLocationBoundary, error)
+	GetAllActive(ctx context.Context) ([]models.RestaurantDeliveryLocationBoundary, error)
+}
+
+// RestaurantSpecialHoursRepository interface for PostgreSQL holiday and special hours operations
+type RestaurantSpecialHoursRepository interface {
+	Create(ctx context.Context, entry *models.RestaurantSpecialHours) error
+	Update(ctx context.Context, entry *models.RestaurantSpecialHours) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.RestaurantSpecialHours, error)
+	// GetByRestaurant returns the restaurant's entries ending on or after the date (YYYY-MM-DD),
+	// earliest first
+	GetByRestaurant(ctx context.Context, restaurantID uuid.UUID, from string) ([]models.RestaurantSpecialHours, error)
+	// GetCovering returns the entries of every restaurant that cover the date
+	GetCovering(ctx context.Context, date string) ([]models.RestaurantSpecialHours, error)
+}
+
+// RestaurantPauseRepository interface for PostgreSQL busy mode operations
+type RestaurantPauseRepository interface {
+	Create(ctx context.Context, pause *models.RestaurantPause) error
+	Update(ctx context.Context, pause *models.RestaurantPause) error
+	// GetActive returns the restaurant's open pause that runs past the time
+	GetActive(ctx context.Context, restaurantID uuid.UUID, at time.Time) (*models.RestaurantPause, error)
+	// GetExpired returns open pauses of every restaurant that ended at or before the time
+	GetExpired(ctx context.Context, at time.Time, limit int) ([]models.RestaurantPause, error)
+	List(ctx context.Context, filter RestaurantPauseFilter, offset, limit int) ([]models.RestaurantPause, int64, error)
+	Summarize(ctx context.Context, filter RestaurantPauseFilter) (*RestaurantPauseTotals, error)
+}
+
+// RestaurantPauseFilter narrows pause listings to pauses started in the half-open range
+type RestaurantPauseFilter struct {
+	RestaurantID uuid.UUID
+	From         time.Time
+	To           time.Time
+}
+
+type RestaurantPauseTotals struct {
+	Pauses        int64   `json:"pauses"`
+	PausedMinutes float64 `json:"paused_minutes"` // until each pause ended, or was due to end
+}
+
 // TimeRangeProductRepository interface for MongoDB time-based product operations
 type TimeRangeProductRepository interface {
 	CreateTimeGroup(ctx context.Context, group *models.TimeRangeProductsGroup) error
@@ -179,6 +696,248 @@ type TimeRangeProductRepository interface {
 	GetActiveProductsByTime(ctx context.Context, restaurantID string, currentTime string) ([]primitive.ObjectID, error)
 }
 
+// DeliveryLocationRepository interface for MongoDB delivery partner location history
+type DeliveryLocationRepository interface {
+	Create(ctx context.Context, ping *models.DeliveryLocationPing) error
+	GetByOrderID(ctx context.Context, orderID string, limit int) ([]models.DeliveryLocationPing, error)
+	GetLatestByOrderID(ctx context.Context, orderID string) (*models.DeliveryLocationPing, error)
+}
+
+// OrderChatRepository interface for MongoDB order chat messages
+type OrderChatRepository interface {
+	Create(ctx context.Context, message *models.OrderChatMessage) error
+	// GetByOrderID returns the order's messages, oldest first
+	GetByOrderID(ctx context.Context, orderID string) ([]models.OrderChatMessage, error)
+	// MarkRead records that the role read the other participants' messages sent until at
+	MarkRead(ctx context.Context, orderID, role string, at time.Time) (int64, error)
+	// DeleteBefore removes messages sent before the time, except those of the given orders
+	DeleteBefore(ctx context.Context, before time.Time, keepOrderIDs []string) (int64, error)
+}
+
+// AdminRepository interface for PostgreSQL admin user operations
+type AdminRepository interface {
+	Create(ctx context.Context, admin *models.AdminUser) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.AdminUser, error)
+	GetByEmail(ctx context.Context, email string) (*models.AdminUser, error)
+	Update(ctx context.Context, admin *models.AdminUser) error
+	List(ctx context.Context, limit, offset int) ([]models.AdminUser, error)
+	Count(ctx context.Context) (int64, error)
+}
+
+// DashboardRepository interface for PostgreSQL platform metrics aggregation.
+// Ranges are half-open: from inclusive, to exclusive.
+type DashboardRepository interface {
+	CountOrdersByStatus(ctx context.Context, from, to time.Time) ([]OrderStatusCount, error)
+	GetDailyGMV(ctx context.Context, from, to time.Time) ([]DailyGMV, error)
+	GetTopRestaurants(ctx context.Context, from, to time.Time, limit int, defaultCommissionPercent float64) ([]RestaurantSales, error)
+	GetRefundTotals(ctx context.Context, from, to time.Time) (*RefundTotals, error)
+	GetUserActivity(ctx context.Context, from, to time.Time) (*UserActivityCounts, error)
+	GetCartActivity(ctx context.Context, from, to time.Time) (*CartActivityCounts, error)
+}
+
+type OrderStatusCount struct {
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+type DailyGMV struct {
+	Day    string  `json:"day"` // YYYY-MM-DD
+	Orders int64   `json:"orders"`
+	GMV    float64 `json:"gmv"`
+}
+
+type RestaurantSales struct {
+	RestaurantID   string  `json:"restaurant_id"`
+	RestaurantName string  `json:"restaurant_name"`
+	Orders         int64   `json:"orders"`
+	GMV            float64 `json:"gmv"`
+	Commission     float64 `json:"commission"` // at the commission terms active when each order was placed
+}
+
+type RefundTotals struct {
+	GMVOrders      int64   `json:"gmv_orders"`
+	GMV            float64 `json:"gmv"`
+	RefundedOrders int64   `json:"refunded_orders"`
+	RefundedAmount float64 `json:"refunded_amount"`
+}
+
+type UserActivityCounts struct {
+	OrderingUsers int64 `json:"ordering_users"` // distinct users who placed an order
+	NewUsers      int64 `json:"new_users"`
+	ActiveUsers   int64 `json:"active_users"` // accounts with active status, not limited to the range
+}
+
+// CartActivityCounts follows the carts created in a range
+type CartActivityCounts struct {
+	Created   int64 `json:"created"`
+	Abandoned int64 `json:"abandoned"` // went inactive and were marked abandoned, recovered or not
+	Recovered int64 `json:"recovered"` // abandoned, then used again
+	Ordered   int64 `json:"ordered"`
+}
+
+// RestaurantAnalyticsRepository interface for MongoDB daily restaurant analytics
+type RestaurantAnalyticsRepository interface {
+	GetByRestaurantAndDate(ctx context.Context, restaurantID, date string) (*models.RestaurantAnalytics, error)
+	Upsert(ctx context.Context, analytics *models.RestaurantAnalytics) error
+	GetRange(ctx context.Context, restaurantID, fromDate, toDate string) ([]models.RestaurantAnalytics, error)
+}
+
+// SystemLogRepository interface for MongoDB system logs
+type SystemLogRepository interface {
+	Create(ctx context.Context, entry *models.SystemLog) error
+}
+
+// SearchLogRepository interface for MongoDB search logs
+type SearchLogRepository interface {
+	InsertMany(ctx context.Context, logs []models.SearchLog) error
+	AnonymizeUser(ctx context.Context, userID, pseudonym string) error
+}
+
+// UserActivityRepository interface for MongoDB user activity events
+type UserActivityRepository interface {
+	InsertMany(ctx context.Context, activities []models.UserActivity) error
+	GetByRestaurantSince(ctx context.Context, restaurantID, activityType string, since time.Time) ([]models.UserActivity, error)
+	GetRecentByUser(ctx context.Context, userID, activityType string, limit int) ([]models.UserActivity, error)
+	AnonymizeUser(ctx context.Context, userID, pseudonym string) error
+}
+
+// MediaRepository interface for MongoDB uploaded media
+type MediaRepository interface {
+	Create(ctx context.Context, media *models.Media) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Media, error)
+	Update(ctx context.Context, media *models.Media) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	GetByOwner(ctx context.Context, ownerType, ownerID string) ([]models.Media, error)
+	GetByStatusBefore(ctx context.Context, status string, before time.Time, afterID primitive.ObjectID, limit int) ([]models.Media, error)
+}
+
+// HighlightProductRepository interface for MongoDB highlighted product operations
+type HighlightProductRepository interface {
+	Create(ctx context.Context, highlight *models.HighlightProduct) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.HighlightProduct, error)
+	Update(ctx context.Context, highlight *models.HighlightProduct) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	List(ctx context.Context, restaurantID, highlightType string, limit, offset int) ([]models.HighlightProduct, error)
+	GetActiveProducts(ctx context.Context, restaurantID, highlightType string, at time.Time) ([]models.Product, error)
+}
+
+// MenuSectionRepository interface for MongoDB menu section operations
+type MenuSectionRepository interface {
+	Create(ctx context.Context, section *models.MenuSection) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.MenuSection, error)
+	Update(ctx context.Context, section *models.MenuSection) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	GetByRestaurantID(ctx context.Context, restaurantID string) ([]models.MenuSection, error)
+	UpdateSortOrder(ctx context.Context, restaurantID string, sortOrder map[primitive.ObjectID]int) error
+}
+
+// BannerRepository interface for MongoDB banner operations
+type BannerRepository interface {
+	Create(ctx context.Context, banner *models.Banner) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Banner, error)
+	Update(ctx context.Context, banner *models.Banner) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	List(ctx context.Context, displayLocation, restaurantID string, limit, offset int) ([]models.Banner, error)
+	GetActive(ctx context.Context, displayLocation, restaurantID string, at time.Time) ([]models.Banner, error)
+}
+
+// AuditLogRepository interface for PostgreSQL audit entries
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *models.AuditLog) error
+	GetByEntity(ctx context.Context, entityType, entityID string, limit int) ([]models.AuditLog, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.AuditLog, error)
+	List(ctx context.Context, filter AuditLogFilter, offset, limit int) ([]models.AuditLog, int64, error)
+}
+
+// AuditLogFilter narrows audit listings. Zero fields do not filter; the timestamp range is
+// half-open.
+type AuditLogFilter struct {
+	EntityType  string
+	EntityID    string
+	Action      string
+	PerformedBy uuid.UUID
+	From        time.Time
+	To          time.Time
+}
+
+// WebhookRepository interface for PostgreSQL webhook subscription and delivery operations
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	// DeleteSubscription removes the subscription and its delivery log
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+	ListSubscriptions(ctx context.Context, restaurantID uuid.UUID) ([]models.WebhookSubscription, error)
+	GetActiveSubscriptions(ctx context.Context, restaurantID uuid.UUID, eventType string) ([]models.WebhookSubscription, error)
+	// CreateDeliveries skips deliveries of an event already recorded for the subscription
+	CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error
+	// ClaimDueDeliveries returns pending deliveries that are due and hides them from other
+	// relays for the lease
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, status string, limit, offset int) ([]models.WebhookDelivery, int64, error)
+	DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// OutboxRepository interface for PostgreSQL transactional outbox operations
+type OutboxRepository interface {
+	Create(ctx context.Context, event *models.OutboxEvent) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.OutboxEvent, error)
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error)
+	Update(ctx context.Context, event *models.OutboxEvent) error
+	List(ctx context.Context, status, topic string, limit, offset int) ([]models.OutboxEvent, int64, error)
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AuthSessionRepository interface for PostgreSQL login session operations
+type AuthSessionRepository interface {
+	Create(ctx context.Context, session *models.AuthSession) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.AuthSession, error)
+	Rotate(ctx context.Context, id uuid.UUID, oldTokenHash, newTokenHash string, expiresAt time.Time) (bool, error)
+	Revoke(ctx context.Context, id uuid.UUID, reason string) error
+	RevokeAllByUser(ctx context.Context, userID uuid.UUID, reason string) ([]uuid.UUID, error)
+	RevokeByDevice(ctx context.Context, userID uuid.UUID, deviceID, reason string) ([]uuid.UUID, error)
+	GetActiveByUser(ctx context.Context, userID uuid.UUID) ([]models.AuthSession, error)
+}
+
+// PasswordHistoryRepository interface for PostgreSQL password history operations
+type PasswordHistoryRepository interface {
+	Create(ctx context.Context, entry *models.PasswordHistory) error
+	GetRecent(ctx context.Context, userID uuid.UUID, limit int) ([]models.PasswordHistory, error)
+	DeleteAllButRecent(ctx context.Context, userID uuid.UUID, keep int) error
+}
+
+// PIIRepository finds and rewrites rows whose personal data is in plaintext or sealed with a
+// key other than the active one, whose values do not start with activePrefix
+type PIIRepository interface {
+	CountStale(ctx context.Context, activePrefix string) (users int64, addresses int64, err error)
+	ReencryptUsers(ctx context.Context, activePrefix string, limit int) (int, error)
+	ReencryptAddresses(ctx context.Context, activePrefix string, limit int) (int, error)
+}
+
+// UserIdentityRepository interface for PostgreSQL external identity links
+type UserIdentityRepository interface {
+	Create(ctx context.Context, identity *models.UserIdentity) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.UserIdentity, error)
+	GetByProviderSubject(ctx context.Context, provider, subject string, restaurantID *uuid.UUID) (*models.UserIdentity, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.UserIdentity, error)
+	TouchLastLogin(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// SMSDeliveryRepository interface for PostgreSQL SMS delivery tracking
+type SMSDeliveryRepository interface {
+	Create(ctx context.Context, delivery *models.SMSDelivery) error
+	UpdateStatus(ctx context.Context, provider, messageID, status, errorCode string, report models.JSONB, reportedAt time.Time) error
+}
+
+// DispatchRepository interface for PostgreSQL delivery dispatch attempts
+type DispatchRepository interface {
+	Create(ctx context.Context, dispatch *models.DeliveryDispatch) error
+	GetByOrder(ctx context.Context, orderID uuid.UUID) ([]models.DeliveryDispatch, error)
+	HasBooking(ctx context.Context, orderID uuid.UUID) (bool, error)
+}
+
 // PorterDeliveryRepository interface for PostgreSQL Porter delivery operations
 type PorterDeliveryRepository interface {
 	Create(ctx context.Context, delivery *models.PorterDelivery) error
@@ -189,4 +948,6 @@ type PorterDeliveryRepository interface {
 	Update(ctx context.Context, delivery *models.PorterDelivery) error
 	UpdateStatus(ctx context.Context, porterOrderID string, status string, metadata map[string]interface{}) error
 	DeactivateOldDeliveries(ctx context.Context, orderID uuid.UUID) error
+	GetStale(ctx context.Context, updatedBefore time.Time, limit int) ([]models.PorterDelivery, error)
+	GetNeedingAttention(ctx context.Context) ([]models.PorterDelivery, error)
 }
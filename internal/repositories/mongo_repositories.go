@@ -3,6 +3,8 @@ package repositories
 import (
 	"context"
 	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/database"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -11,6 +13,27 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// inventoryHistoryLimit caps the stock transactions kept on each inventory record
+const inventoryHistoryLimit = 200
+
+// productDeletedAt marks soft-deleted products. Product reads match it against nil so deleted
+// products stay hidden.
+const productDeletedAt = "deleted_at"
+
+// productTenantFilter limits a product filter to the restaurant ctx is scoped to; a filter
+// naming another restaurant fails with database.ErrCrossTenant
+func productTenantFilter(ctx context.Context, filter bson.M) (bson.M, error) {
+	tenant := database.TenantFromContext(ctx)
+	if tenant == "" {
+		return filter, nil
+	}
+	if restaurantID, ok := filter["restaurant_id"].(string); ok && restaurantID != tenant {
+		return nil, database.ErrCrossTenant
+	}
+	filter["restaurant_id"] = tenant
+	return filter, nil
+}
+
 // Product Repository
 type productRepository struct {
 	collection *mongo.Collection
@@ -23,6 +46,9 @@ func NewProductRepository(db *mongo.Database) ProductRepository {
 }
 
 func (r *productRepository) Create(ctx context.Context, product *models.Product) error {
+	if _, err := productTenantFilter(ctx, bson.M{"restaurant_id": product.RestaurantID}); err != nil {
+		return err
+	}
 	product.CreatedAt = time.Now()
 	product.UpdatedAt = time.Now()
 
@@ -35,8 +61,12 @@ func (r *productRepository) Create(ctx context.Context, product *models.Product)
 }
 
 func (r *productRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error) {
+	filter, err := productTenantFilter(ctx, bson.M{"_id": id, productDeletedAt: nil})
+	if err != nil {
+		return nil, err
+	}
 	var product models.Product
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&product)
+	err = r.collection.FindOne(ctx, filter).Decode(&product)
 	if err != nil {
 		return nil, err
 	}
@@ -46,23 +76,104 @@ func (r *productRepository) GetByID(ctx context.Context, id primitive.ObjectID)
 func (r *productRepository) Update(ctx context.Context, product *models.Product) error {
 	product.UpdatedAt = time.Now()
 
-	filter := bson.M{"_id": product.ID}
+	filter, err := productTenantFilter(ctx, bson.M{"_id": product.ID})
+	if err != nil {
+		return err
+	}
+	if tenant := database.TenantFromContext(ctx); tenant != "" && product.RestaurantID != tenant {
+		return database.ErrCrossTenant
+	}
 	update := bson.M{"$set": product}
 
-	_, err := r.collection.UpdateOne(ctx, filter, update)
+	_, err = r.collection.UpdateOne(ctx, filter, update)
 	return err
 }
 
+// Delete soft-deletes the product; PurgeDeleted removes it for good later
 func (r *productRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
-	filter := bson.M{"_id": id}
-	_, err := r.collection.DeleteOne(ctx, filter)
+	now := time.Now()
+	filter, err := productTenantFilter(ctx, bson.M{"_id": id, productDeletedAt: nil})
+	if err != nil {
+		return err
+	}
+	_, err = r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now}})
 	return err
 }
 
-func (r *productRepository) GetByRestaurantID(ctx context.Context, restaurantID string, limit, offset int) ([]models.Product, error) {
+// GetDeleted returns a restaurant's soft-deleted products, most recently deleted first
+func (r *productRepository) GetDeleted(ctx context.Context, restaurantID string, offset, limit int) ([]models.Product, int64, error) {
+	filter, err := productTenantFilter(ctx, bson.M{"restaurant_id": restaurantID, productDeletedAt: bson.M{"$ne": nil}})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}}).SetSkip(int64(offset)).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	products := []models.Product{}
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+// Restore undeletes a soft-deleted product. It returns mongo.ErrNoDocuments when the product
+// is not deleted or has been purged.
+func (r *productRepository) Restore(ctx context.Context, id primitive.ObjectID) (*models.Product, error) {
+	filter, err := productTenantFilter(ctx, bson.M{"_id": id, productDeletedAt: bson.M{"$ne": nil}})
+	if err != nil {
+		return nil, err
+	}
+	var product models.Product
+	err = r.collection.FindOneAndUpdate(ctx,
+		filter,
+		bson.M{"$unset": bson.M{"deleted_at": ""}, "$set": bson.M{"updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&product)
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// PurgeDeleted permanently removes products soft-deleted before the cutoff
+func (r *productRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{productDeletedAt: bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *productRepository) ClearExpiredWasPrices(ctx context.Context, now time.Time) (int64, error) {
+	filter := bson.M{"was_price_until": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"was_price": nil, "was_price_until": nil}}
+
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *productRepository) GetByRestaurantID(ctx context.Context, restaurantID string, diet models.DietaryFilter, limit, offset int) ([]models.Product, error) {
 	var products []models.Product
 
-	filter := bson.M{"restaurant_id": restaurantID, "is_available": true}
+	filter := bson.M{"restaurant_id": restaurantID, "is_available": true, productDeletedAt: nil}
+	applyDietaryFilter(filter, diet)
+	filter, err := productTenantFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
 	opts := options.Find().SetLimit(int64(limit)).SetSkip(int64(offset))
 
 	cursor, err := r.collection.Find(ctx, filter, opts)
@@ -78,10 +189,27 @@ func (r *productRepository) GetByRestaurantID(ctx context.Context, restaurantID
 	return products, nil
 }
 
+// applyDietaryFilter adds diet's conditions to a product filter. Products that do not state
+// their allergens or spice level pass those conditions; only a stated diet passes a diet one.
+func applyDietaryFilter(filter bson.M, diet models.DietaryFilter) {
+	if len(diet.DietTypes) > 0 {
+		filter["diet_type"] = bson.M{"$in": diet.DietTypes}
+	}
+	if len(diet.ExcludeAllergens) > 0 {
+		filter["allergens"] = bson.M{"$nin": diet.ExcludeAllergens}
+	}
+	if diet.MaxSpiceLevel != nil {
+		filter["spice_level"] = bson.M{"$not": bson.M{"$gt": *diet.MaxSpiceLevel}}
+	}
+}
+
 func (r *productRepository) GetByCategoryID(ctx context.Context, categoryID primitive.ObjectID, limit, offset int) ([]models.Product, error) {
 	var products []models.Product
 
-	filter := bson.M{"category_id": categoryID, "is_available": true}
+	filter, err := productTenantFilter(ctx, bson.M{"category_id": categoryID, "is_available": true, productDeletedAt: nil})
+	if err != nil {
+		return nil, err
+	}
 	opts := options.Find().SetLimit(int64(limit)).SetSkip(int64(offset))
 
 	cursor, err := r.collection.Find(ctx, filter, opts)
@@ -97,18 +225,24 @@ func (r *productRepository) GetByCategoryID(ctx context.Context, categoryID prim
 	return products, nil
 }
 
-func (r *productRepository) Search(ctx context.Context, query string, restaurantID string, limit, offset int) ([]models.Product, error) {
+func (r *productRepository) Search(ctx context.Context, query string, restaurantID string, diet models.DietaryFilter, limit, offset int) ([]models.Product, error) {
 	var products []models.Product
 
 	filter := bson.M{
-		"restaurant_id": restaurantID,
-		"is_available":  true,
+		"restaurant_id":  restaurantID,
+		"is_available":   true,
+		productDeletedAt: nil,
 		"$or": []bson.M{
 			{"name": bson.M{"$regex": query, "$options": "i"}},
 			{"description": bson.M{"$regex": query, "$options": "i"}},
 			{"tags": bson.M{"$in": []string{query}}},
 		},
 	}
+	applyDietaryFilter(filter, diet)
+	filter, err := productTenantFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
 
 	opts := options.Find().SetLimit(int64(limit)).SetSkip(int64(offset))
 
@@ -125,18 +259,28 @@ func (r *productRepository) Search(ctx context.Context, query string, restaurant
 	return products, nil
 }
 
-func (r *productRepository) GetHighlighted(ctx context.Context, restaurantID string, highlightType string) ([]models.Product, error) {
-	// This would typically involve aggregation with the HighlightProduct collection
-	// For now, we'll return products based on tags
+func (r *productRepository) SearchAcrossRestaurants(ctx context.Context, query string, restaurantIDs []string, diet models.DietaryFilter, limit int) ([]models.Product, error) {
 	var products []models.Product
 
 	filter := bson.M{
-		"restaurant_id": restaurantID,
-		"is_available":  true,
-		"tags":          bson.M{"$in": []string{highlightType}},
+		"restaurant_id":  bson.M{"$in": restaurantIDs},
+		"is_available":   true,
+		productDeletedAt: nil,
+		"$or": []bson.M{
+			{"name": bson.M{"$regex": regexp.QuoteMeta(query), "$options": "i"}},
+			{"description": bson.M{"$regex": regexp.QuoteMeta(query), "$options": "i"}},
+			{"tags": bson.M{"$in": []string{query}}},
+		},
+	}
+	applyDietaryFilter(filter, diet)
+	filter, err := productTenantFilter(ctx, filter)
+	if err != nil {
+		return nil, err
 	}
 
-	cursor, err := r.collection.Find(ctx, filter)
+	opts := options.Find().SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -149,9 +293,9 @@ func (r *productRepository) GetHighlighted(ctx context.Context, restaurantID str
 	return products, nil
 }
 
-func (r *productRepository) GetByRestaurantCategoryAndTime(ctx context.Context, restaurantID string, categoryID *primitive.ObjectID, availableOnly bool, currentTime string, limit, offset int) ([]models.Product, int64, error) {
+func (r *productRepository) GetByRestaurantCategoryAndTime(ctx context.Context, restaurantID string, categoryID *primitive.ObjectID, availableOnly bool, currentTime string, diet models.DietaryFilter, limit, offset int) ([]models.Product, int64, error) {
 	// Build base filter
-	filter := bson.M{"restaurant_id": restaurantID}
+	filter := bson.M{"restaurant_id": restaurantID, productDeletedAt: nil}
 
 	// Add category filter if provided
 	if categoryID != nil {
@@ -162,6 +306,12 @@ func (r *productRepository) GetByRestaurantCategoryAndTime(ctx context.Context,
 	if availableOnly {
 		filter["is_available"] = true
 	}
+	applyDietaryFilter(filter, diet)
+
+	filter, err := productTenantFilter(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	// Get products with time-based filtering
 	pipeline := mongo.Pipeline{
@@ -266,6 +416,100 @@ func (r *productRepository) GetByRestaurantCategoryAndTime(ctx context.Context,
 	return products, total, nil
 }
 
+func (r *productRepository) UpdateBadges(ctx context.Context, restaurantID string, badges map[primitive.ObjectID][]string) error {
+	now := time.Now()
+	if _, err := productTenantFilter(ctx, bson.M{"restaurant_id": restaurantID}); err != nil {
+		return err
+	}
+
+	// One bulk write sets each badged product's badges and clears only the others', instead of
+	// clearing every badge first and leaving listings without them while the new ones are set
+	badged := make([]primitive.ObjectID, 0, len(badges))
+	writes := make([]mongo.WriteModel, 0, len(badges)+1)
+	for productID, productBadges := range badges {
+		if len(productBadges) == 0 {
+			continue
+		}
+		badged = append(badged, productID)
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": productID, "restaurant_id": restaurantID}).
+			SetUpdate(bson.M{"$set": bson.M{"badges": productBadges, "badges_updated_at": now}}))
+	}
+	writes = append(writes, mongo.NewUpdateManyModel().
+		SetFilter(bson.M{"restaurant_id": restaurantID, "_id": bson.M{"$nin": badged}}).
+		SetUpdate(bson.M{"$unset": bson.M{"badges": ""}, "$set": bson.M{"badges_updated_at": now}}))
+
+	_, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// GetByIDs returns the products with the given IDs in no particular order, including unavailable
+// ones but not deleted ones
+func (r *productRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Product, error) {
+	var products []models.Product
+	if len(ids) == 0 {
+		return products, nil
+	}
+
+	filter, err := productTenantFilter(ctx, bson.M{"_id": bson.M{"$in": ids}, productDeletedAt: nil})
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// GetAllByRestaurant returns every product of a restaurant, including unavailable ones but not
+// deleted ones
+func (r *productRepository) GetAllByRestaurant(ctx context.Context, restaurantID string) ([]models.Product, error) {
+	var products []models.Product
+
+	filter, err := productTenantFilter(ctx, bson.M{"restaurant_id": restaurantID, productDeletedAt: nil})
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (r *productRepository) GetCombosWithComponent(ctx context.Context, productID primitive.ObjectID) ([]models.Product, error) {
+	var products []models.Product
+
+	filter, err := productTenantFilter(ctx, bson.M{"components.product_id": productID, productDeletedAt: nil})
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
 // ProductCategory Repository
 type productCategoryRepository struct {
 	collection *mongo.Collection
@@ -333,6 +577,24 @@ func (r *productCategoryRepository) GetByRestaurantID(ctx context.Context, resta
 	return categories, nil
 }
 
+// GetAllByRestaurant returns every category of a restaurant, including inactive ones
+func (r *productCategoryRepository) GetAllByRestaurant(ctx context.Context, restaurantID string) ([]models.ProductCategory, error) {
+	var categories []models.ProductCategory
+
+	opts := options.Find().SetSort(bson.D{{"sort_order", 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"restaurant_id": restaurantID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
 // RatingReview Repository
 type ratingReviewRepository struct {
 	collection *mongo.Collection
@@ -429,6 +691,15 @@ func (r *ratingReviewRepository) GetByUserID(ctx context.Context, userID string,
 	return reviews, nil
 }
 
+// AnonymizeUser detaches the user's reviews from their account; ratings and text stay published
+func (r *ratingReviewRepository) AnonymizeUser(ctx context.Context, userID, pseudonym string) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"user_id": userID},
+		bson.M{"$set": bson.M{"user_id": pseudonym, "updated_at": time.Now()}},
+	)
+	return err
+}
+
 // Inventory Repository
 type inventoryRepository struct {
 	collection *mongo.Collection
@@ -505,6 +776,36 @@ func (r *inventoryRepository) ReleaseStock(ctx context.Context, productID primit
 	return err
 }
 
+func (r *inventoryRepository) ApplyTransaction(ctx context.Context, productID primitive.ObjectID, quantityDelta, reservedDelta int, transaction models.StockTransaction) (*models.Inventory, error) {
+	set := bson.M{"updated_at": time.Now()}
+	if transaction.Type == "addition" {
+		set["last_restocked"] = transaction.Timestamp
+	}
+
+	filter := bson.M{"product_id": productID}
+	update := bson.M{
+		"$inc": bson.M{
+			"quantity":          quantityDelta,
+			"reserved_quantity": reservedDelta,
+		},
+		"$set": set,
+		// Keep the most recent transactions only
+		"$push": bson.M{
+			"stock_history": bson.M{
+				"$each":  []models.StockTransaction{transaction},
+				"$slice": -inventoryHistoryLimit,
+			},
+		},
+	}
+
+	var inventory models.Inventory
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&inventory); err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
+
 func (r *inventoryRepository) GetLowStock(ctx context.Context, restaurantID string) ([]models.Inventory, error) {
 	var inventories []models.Inventory
 
@@ -533,76 +834,233 @@ func (r *inventoryRepository) GetLowStock(ctx context.Context, restaurantID stri
 	return inventories, nil
 }
 
-// TimeRangeProduct Repository
-type timeRangeProductRepository struct {
-	groupCollection *mongo.Collection
-	itemCollection  *mongo.Collection
+// Price History Repository
+type priceHistoryRepository struct {
+	collection *mongo.Collection
 }
 
-func NewTimeRangeProductRepository(db *mongo.Database) TimeRangeProductRepository {
-	return &timeRangeProductRepository{
-		groupCollection: db.Collection("time_range_products_groups"),
-		itemCollection:  db.Collection("time_range_products_group_items"),
+func NewPriceHistoryRepository(db *mongo.Database) PriceHistoryRepository {
+	return &priceHistoryRepository{
+		collection: db.Collection("price_history"),
 	}
 }
 
-func (r *timeRangeProductRepository) CreateTimeGroup(ctx context.Context, group *models.TimeRangeProductsGroup) error {
-	group.ID = primitive.NewObjectID()
-	group.CreatedAt = time.Now()
-	group.UpdatedAt = time.Now()
+func (r *priceHistoryRepository) Create(ctx context.Context, entry *models.PriceHistory) error {
+	if entry.ChangedAt.IsZero() {
+		entry.ChangedAt = time.Now()
+	}
 
-	_, err := r.groupCollection.InsertOne(ctx, group)
-	return err
+	result, err := r.collection.InsertOne(ctx, entry)
+	if err != nil {
+		return err
+	}
+	entry.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
 }
 
-func (r *timeRangeProductRepository) GetTimeGroupByID(ctx context.Context, id primitive.ObjectID) (*models.TimeRangeProductsGroup, error) {
-	var group models.TimeRangeProductsGroup
-	filter := bson.M{"_id": id}
+func (r *priceHistoryRepository) GetByProductID(ctx context.Context, productID primitive.ObjectID, offset, limit int) ([]models.PriceHistory, int64, error) {
+	var entries []models.PriceHistory
+	filter := bson.M{"product_id": productID}
 
-	err := r.groupCollection.FindOne(ctx, filter).Decode(&group)
+	total, err := r.collection.CountDocuments(ctx, filter)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return &group, nil
+	opts := options.Find().SetSort(bson.D{{"changed_at", -1}}).SetSkip(int64(offset)).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
 }
 
-func (r *timeRangeProductRepository) GetTimeGroupsByRestaurant(ctx context.Context, restaurantID string) ([]models.TimeRangeProductsGroup, error) {
-	var groups []models.TimeRangeProductsGroup
+// Price Schedule Repository
+type priceScheduleRepository struct {
+	collection *mongo.Collection
+}
 
-	filter := bson.M{
-		"restaurant_id": restaurantID,
-		"is_active":     true,
+func NewPriceScheduleRepository(db *mongo.Database) PriceScheduleRepository {
+	return &priceScheduleRepository{
+		collection: db.Collection("scheduled_price_changes"),
 	}
+}
 
-	opts := options.Find().SetSort(bson.D{{"start_time", 1}})
+func (r *priceScheduleRepository) Create(ctx context.Context, change *models.ScheduledPriceChange) error {
+	change.CreatedAt = time.Now()
+	change.UpdatedAt = time.Now()
 
-	cursor, err := r.groupCollection.Find(ctx, filter, opts)
+	result, err := r.collection.InsertOne(ctx, change)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer cursor.Close(ctx)
+	change.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
 
-	if err = cursor.All(ctx, &groups); err != nil {
+func (r *priceScheduleRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.ScheduledPriceChange, error) {
+	var change models.ScheduledPriceChange
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&change)
+	if err != nil {
 		return nil, err
 	}
-
-	return groups, nil
+	return &change, nil
 }
 
-func (r *timeRangeProductRepository) UpdateTimeGroup(ctx context.Context, group *models.TimeRangeProductsGroup) error {
-	group.UpdatedAt = time.Now()
-
-	filter := bson.M{"_id": group.ID}
-	update := bson.M{"$set": group}
+func (r *priceScheduleRepository) Save(ctx context.Context, change *models.ScheduledPriceChange, fromStatus string) (bool, error) {
+	change.UpdatedAt = time.Now()
 
-	_, err := r.groupCollection.UpdateOne(ctx, filter, update)
-	return err
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": change.ID, "status": fromStatus}, change)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
 }
 
-func (r *timeRangeProductRepository) DeleteTimeGroup(ctx context.Context, id primitive.ObjectID) error {
-	filter := bson.M{"_id": id}
-	_, err := r.groupCollection.DeleteOne(ctx, filter)
+func (r *priceScheduleRepository) GetByRestaurant(ctx context.Context, restaurantID string, productID *primitive.ObjectID, status string, offset, limit int) ([]models.ScheduledPriceChange, int64, error) {
+	var changes []models.ScheduledPriceChange
+	filter := bson.M{"restaurant_id": restaurantID}
+	if productID != nil {
+		filter["product_id"] = *productID
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{"starts_at", 1}, {"_id", 1}}).SetSkip(int64(offset)).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &changes); err != nil {
+		return nil, 0, err
+	}
+	return changes, total, nil
+}
+
+func (r *priceScheduleRepository) GetDue(ctx context.Context, now time.Time) ([]models.ScheduledPriceChange, error) {
+	var changes []models.ScheduledPriceChange
+	// Statuses as in services.PriceChangeScheduled and PriceChangeApplied
+	filter := bson.M{"$or": []bson.M{
+		{"status": "scheduled", "starts_at": bson.M{"$lte": now}},
+		{"status": "applied", "ends_at": bson.M{"$lte": now}},
+	}}
+
+	opts := options.Find().SetSort(bson.D{{"starts_at", 1}, {"_id", 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *priceScheduleRepository) HasOverlap(ctx context.Context, productID primitive.ObjectID, startsAt time.Time, endsAt *time.Time) (bool, error) {
+	filter := bson.M{
+		"product_id": productID,
+		"status":     bson.M{"$in": []string{"scheduled", "applied"}},
+		"$or": []bson.M{
+			{"ends_at": nil},
+			{"ends_at": bson.M{"$gt": startsAt}},
+		},
+	}
+	if endsAt != nil {
+		filter["starts_at"] = bson.M{"$lt": *endsAt}
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// TimeRangeProduct Repository
+type timeRangeProductRepository struct {
+	groupCollection *mongo.Collection
+	itemCollection  *mongo.Collection
+}
+
+func NewTimeRangeProductRepository(db *mongo.Database) TimeRangeProductRepository {
+	return &timeRangeProductRepository{
+		groupCollection: db.Collection("time_range_products_groups"),
+		itemCollection:  db.Collection("time_range_products_group_items"),
+	}
+}
+
+func (r *timeRangeProductRepository) CreateTimeGroup(ctx context.Context, group *models.TimeRangeProductsGroup) error {
+	group.ID = primitive.NewObjectID()
+	group.CreatedAt = time.Now()
+	group.UpdatedAt = time.Now()
+
+	_, err := r.groupCollection.InsertOne(ctx, group)
+	return err
+}
+
+func (r *timeRangeProductRepository) GetTimeGroupByID(ctx context.Context, id primitive.ObjectID) (*models.TimeRangeProductsGroup, error) {
+	var group models.TimeRangeProductsGroup
+	filter := bson.M{"_id": id}
+
+	err := r.groupCollection.FindOne(ctx, filter).Decode(&group)
+	if err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (r *timeRangeProductRepository) GetTimeGroupsByRestaurant(ctx context.Context, restaurantID string) ([]models.TimeRangeProductsGroup, error) {
+	var groups []models.TimeRangeProductsGroup
+
+	filter := bson.M{
+		"restaurant_id": restaurantID,
+		"is_active":     true,
+	}
+
+	opts := options.Find().SetSort(bson.D{{"start_time", 1}})
+
+	cursor, err := r.groupCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (r *timeRangeProductRepository) UpdateTimeGroup(ctx context.Context, group *models.TimeRangeProductsGroup) error {
+	group.UpdatedAt = time.Now()
+
+	filter := bson.M{"_id": group.ID}
+	update := bson.M{"$set": group}
+
+	_, err := r.groupCollection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *timeRangeProductRepository) DeleteTimeGroup(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id}
+	_, err := r.groupCollection.DeleteOne(ctx, filter)
 	return err
 }
 
@@ -691,3 +1149,732 @@ func (r *timeRangeProductRepository) GetActiveProductsByTime(ctx context.Context
 
 	return productIDs, nil
 }
+
+// Delivery Location Repository
+type deliveryLocationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewDeliveryLocationRepository(db *mongo.Database) DeliveryLocationRepository {
+	return &deliveryLocationRepository{
+		collection: db.Collection("delivery_location_pings"),
+	}
+}
+
+func (r *deliveryLocationRepository) Create(ctx context.Context, ping *models.DeliveryLocationPing) error {
+	if ping.RecordedAt.IsZero() {
+		ping.RecordedAt = time.Now()
+	}
+
+	result, err := r.collection.InsertOne(ctx, ping)
+	if err != nil {
+		return err
+	}
+	ping.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByOrderID returns the most recent pings for an order in chronological order
+func (r *deliveryLocationRepository) GetByOrderID(ctx context.Context, orderID string, limit int) ([]models.DeliveryLocationPing, error) {
+	var pings []models.DeliveryLocationPing
+
+	opts := options.Find().
+		SetSort(bson.D{{"recorded_at", -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, bson.M{"order_id": orderID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &pings); err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(pings)-1; i < j; i, j = i+1, j-1 {
+		pings[i], pings[j] = pings[j], pings[i]
+	}
+
+	return pings, nil
+}
+
+func (r *deliveryLocationRepository) GetLatestByOrderID(ctx context.Context, orderID string) (*models.DeliveryLocationPing, error) {
+	var ping models.DeliveryLocationPing
+
+	opts := options.FindOne().SetSort(bson.D{{"recorded_at", -1}})
+
+	err := r.collection.FindOne(ctx, bson.M{"order_id": orderID}, opts).Decode(&ping)
+	if err != nil {
+		return nil, err
+	}
+	return &ping, nil
+}
+
+// Order Chat Repository
+type orderChatRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOrderChatRepository(db *mongo.Database) OrderChatRepository {
+	return &orderChatRepository{
+		collection: db.Collection("order_chat_messages"),
+	}
+}
+
+func (r *orderChatRepository) Create(ctx context.Context, message *models.OrderChatMessage) error {
+	message.CreatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, message)
+	if err != nil {
+		return err
+	}
+	message.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *orderChatRepository) GetByOrderID(ctx context.Context, orderID string) ([]models.OrderChatMessage, error) {
+	var messages []models.OrderChatMessage
+
+	opts := options.Find().SetSort(bson.D{{"created_at", 1}, {"_id", 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"order_id": orderID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *orderChatRepository) MarkRead(ctx context.Context, orderID, role string, at time.Time) (int64, error) {
+	filter := bson.M{
+		"order_id":        orderID,
+		"sender_role":     bson.M{"$ne": role},
+		"created_at":      bson.M{"$lte": at},
+		"read_by." + role: bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"read_by." + role: at}}
+
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *orderChatRepository) DeleteBefore(ctx context.Context, before time.Time, keepOrderIDs []string) (int64, error) {
+	filter := bson.M{"created_at": bson.M{"$lt": before}}
+	if len(keepOrderIDs) > 0 {
+		filter["order_id"] = bson.M{"$nin": keepOrderIDs}
+	}
+
+	result, err := r.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// Highlight Product Repository
+type highlightProductRepository struct {
+	collection *mongo.Collection
+}
+
+func NewHighlightProductRepository(db *mongo.Database) HighlightProductRepository {
+	return &highlightProductRepository{
+		collection: db.Collection("highlight_products"),
+	}
+}
+
+func (r *highlightProductRepository) Create(ctx context.Context, highlight *models.HighlightProduct) error {
+	highlight.CreatedAt = time.Now()
+	highlight.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, highlight)
+	if err != nil {
+		return err
+	}
+	highlight.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *highlightProductRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.HighlightProduct, error) {
+	var highlight models.HighlightProduct
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&highlight)
+	if err != nil {
+		return nil, err
+	}
+	return &highlight, nil
+}
+
+func (r *highlightProductRepository) Update(ctx context.Context, highlight *models.HighlightProduct) error {
+	highlight.UpdatedAt = time.Now()
+
+	filter := bson.M{"_id": highlight.ID}
+	update := bson.M{"$set": highlight}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *highlightProductRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id}
+	_, err := r.collection.DeleteOne(ctx, filter)
+	return err
+}
+
+// List returns a restaurant's highlights for management, including inactive and expired ones.
+// An empty type matches all types.
+func (r *highlightProductRepository) List(ctx context.Context, restaurantID, highlightType string, limit, offset int) ([]models.HighlightProduct, error) {
+	var highlights []models.HighlightProduct
+
+	filter := bson.M{"restaurant_id": restaurantID}
+	if highlightType != "" {
+		filter["highlight_type"] = highlightType
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{"highlight_type", 1}, {"sort_order", 1}, {"start_date", -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &highlights); err != nil {
+		return nil, err
+	}
+
+	return highlights, nil
+}
+
+// GetActiveProducts joins the highlights live at the given time to their products, in
+// highlight order. Products that are unavailable are left out.
+func (r *highlightProductRepository) GetActiveProducts(ctx context.Context, restaurantID, highlightType string, at time.Time) ([]models.Product, error) {
+	var products []models.Product
+
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"restaurant_id":  restaurantID,
+				"highlight_type": highlightType,
+				"is_active":      true,
+				"start_date":     bson.M{"$lte": at},
+				"end_date":       bson.M{"$gte": at},
+			},
+		},
+		{"$sort": bson.D{{"sort_order", 1}, {"start_date", -1}}},
+		{
+			"$lookup": bson.M{
+				"from":         "products",
+				"localField":   "product_id",
+				"foreignField": "_id",
+				"as":           "product",
+			},
+		},
+		{"$unwind": "$product"},
+		{"$match": bson.M{"product.is_available": true, "product.deleted_at": nil}},
+		{"$replaceRoot": bson.M{"newRoot": "$product"}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// Menu Section Repository
+type menuSectionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMenuSectionRepository(db *mongo.Database) MenuSectionRepository {
+	return &menuSectionRepository{
+		collection: db.Collection("menu_sections"),
+	}
+}
+
+func (r *menuSectionRepository) Create(ctx context.Context, section *models.MenuSection) error {
+	section.CreatedAt = time.Now()
+	section.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, section)
+	if err != nil {
+		return err
+	}
+	section.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *menuSectionRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.MenuSection, error) {
+	var section models.MenuSection
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&section)
+	if err != nil {
+		return nil, err
+	}
+	return &section, nil
+}
+
+func (r *menuSectionRepository) Update(ctx context.Context, section *models.MenuSection) error {
+	section.UpdatedAt = time.Now()
+
+	filter := bson.M{"_id": section.ID}
+	update := bson.M{"$set": section}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *menuSectionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id}
+	_, err := r.collection.DeleteOne(ctx, filter)
+	return err
+}
+
+// GetByRestaurantID returns all of a restaurant's sections, including inactive ones, in display order
+func (r *menuSectionRepository) GetByRestaurantID(ctx context.Context, restaurantID string) ([]models.MenuSection, error) {
+	var sections []models.MenuSection
+
+	opts := options.Find().SetSort(bson.D{{"sort_order", 1}, {"created_at", 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"restaurant_id": restaurantID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &sections); err != nil {
+		return nil, err
+	}
+
+	return sections, nil
+}
+
+func (r *menuSectionRepository) UpdateSortOrder(ctx context.Context, restaurantID string, sortOrder map[primitive.ObjectID]int) error {
+	now := time.Now()
+	for sectionID, order := range sortOrder {
+		filter := bson.M{"_id": sectionID, "restaurant_id": restaurantID}
+		update := bson.M{"$set": bson.M{"sort_order": order, "updated_at": now}}
+		if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Banner Repository
+type bannerRepository struct {
+	collection *mongo.Collection
+}
+
+func NewBannerRepository(db *mongo.Database) BannerRepository {
+	return &bannerRepository{
+		collection: db.Collection("banners"),
+	}
+}
+
+func (r *bannerRepository) Create(ctx context.Context, banner *models.Banner) error {
+	banner.CreatedAt = time.Now()
+	banner.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, banner)
+	if err != nil {
+		return err
+	}
+	banner.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *bannerRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Banner, error) {
+	var banner models.Banner
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&banner)
+	if err != nil {
+		return nil, err
+	}
+	return &banner, nil
+}
+
+func (r *bannerRepository) Update(ctx context.Context, banner *models.Banner) error {
+	banner.UpdatedAt = time.Now()
+
+	filter := bson.M{"_id": banner.ID}
+	update := bson.M{"$set": banner}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *bannerRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id}
+	_, err := r.collection.DeleteOne(ctx, filter)
+	return err
+}
+
+// List returns banners for administration, including inactive and expired ones.
+// Empty filters match any location or restaurant.
+func (r *bannerRepository) List(ctx context.Context, displayLocation, restaurantID string, limit, offset int) ([]models.Banner, error) {
+	var banners []models.Banner
+
+	filter := bson.M{}
+	if displayLocation != "" {
+		filter["display_location"] = displayLocation
+	}
+	if restaurantID != "" {
+		filter["restaurant_id"] = restaurantID
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{"priority", -1}, {"start_date", -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &banners); err != nil {
+		return nil, err
+	}
+
+	return banners, nil
+}
+
+// GetActive returns enabled banners whose schedule covers the given time, highest priority first.
+// An empty restaurant ID returns platform banners only.
+func (r *bannerRepository) GetActive(ctx context.Context, displayLocation, restaurantID string, at time.Time) ([]models.Banner, error) {
+	var banners []models.Banner
+
+	filter := bson.M{
+		"display_location": displayLocation,
+		"is_active":        true,
+		"start_date":       bson.M{"$lte": at},
+		"end_date":         bson.M{"$gte": at},
+	}
+	if restaurantID != "" {
+		filter["restaurant_id"] = restaurantID
+	} else {
+		// Platform banners are stored without a restaurant ID
+		filter["restaurant_id"] = bson.M{"$in": bson.A{"", nil}}
+	}
+
+	opts := options.Find().SetSort(bson.D{{"priority", -1}, {"start_date", -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &banners); err != nil {
+		return nil, err
+	}
+
+	return banners, nil
+}
+
+// Restaurant Analytics Repository
+type restaurantAnalyticsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRestaurantAnalyticsRepository(db *mongo.Database) RestaurantAnalyticsRepository {
+	return &restaurantAnalyticsRepository{
+		collection: db.Collection("restaurant_analytics"),
+	}
+}
+
+func (r *restaurantAnalyticsRepository) GetByRestaurantAndDate(ctx context.Context, restaurantID, date string) (*models.RestaurantAnalytics, error) {
+	var analytics models.RestaurantAnalytics
+	err := r.collection.FindOne(ctx, bson.M{"restaurant_id": restaurantID, "date": date}).Decode(&analytics)
+	if err != nil {
+		return nil, err
+	}
+	return &analytics, nil
+}
+
+// Upsert replaces the restaurant's aggregate for the day, creating it if missing
+func (r *restaurantAnalyticsRepository) Upsert(ctx context.Context, analytics *models.RestaurantAnalytics) error {
+	analytics.UpdatedAt = time.Now()
+
+	filter := bson.M{"restaurant_id": analytics.RestaurantID, "date": analytics.Date}
+	opts := options.Replace().SetUpsert(true)
+
+	result, err := r.collection.ReplaceOne(ctx, filter, analytics, opts)
+	if err != nil {
+		return err
+	}
+	if id, ok := result.UpsertedID.(primitive.ObjectID); ok {
+		analytics.ID = id
+	}
+	return nil
+}
+
+// GetRange returns daily aggregates between two YYYY-MM-DD dates, inclusive
+func (r *restaurantAnalyticsRepository) GetRange(ctx context.Context, restaurantID, fromDate, toDate string) ([]models.RestaurantAnalytics, error) {
+	var days []models.RestaurantAnalytics
+
+	filter := bson.M{
+		"restaurant_id": restaurantID,
+		"date":          bson.M{"$gte": fromDate, "$lte": toDate},
+	}
+	opts := options.Find().SetSort(bson.D{{"date", 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &days); err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+type systemLogRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSystemLogRepository(db *mongo.Database) SystemLogRepository {
+	return &systemLogRepository{
+		collection: db.Collection("system_logs"),
+	}
+}
+
+func (r *systemLogRepository) Create(ctx context.Context, entry *models.SystemLog) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	result, err := r.collection.InsertOne(ctx, entry)
+	if err != nil {
+		return err
+	}
+	entry.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+type searchLogRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSearchLogRepository(db *mongo.Database) SearchLogRepository {
+	return &searchLogRepository{
+		collection: db.Collection("search_logs"),
+	}
+}
+
+func (r *searchLogRepository) InsertMany(ctx context.Context, logs []models.SearchLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(logs))
+	for i := range logs {
+		docs[i] = logs[i]
+	}
+
+	// Unordered so one bad document does not drop the rest of the batch
+	_, err := r.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	return err
+}
+
+// AnonymizeUser replaces the user ID and drops the network details of the user's search logs
+func (r *searchLogRepository) AnonymizeUser(ctx context.Context, userID, pseudonym string) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"user_id": userID},
+		bson.M{
+			"$set":   bson.M{"user_id": pseudonym},
+			"$unset": bson.M{"ip": "", "user_agent": "", "session_id": ""},
+		},
+	)
+	return err
+}
+
+type userActivityRepository struct {
+	collection *mongo.Collection
+}
+
+func NewUserActivityRepository(db *mongo.Database) UserActivityRepository {
+	return &userActivityRepository{
+		collection: db.Collection("user_activities"),
+	}
+}
+
+func (r *userActivityRepository) InsertMany(ctx context.Context, activities []models.UserActivity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(activities))
+	for i := range activities {
+		docs[i] = activities[i]
+	}
+
+	_, err := r.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	return err
+}
+
+func (r *userActivityRepository) GetByRestaurantSince(ctx context.Context, restaurantID, activityType string, since time.Time) ([]models.UserActivity, error) {
+	var activities []models.UserActivity
+
+	filter := bson.M{
+		"restaurant_id": restaurantID,
+		"activity_type": activityType,
+		"timestamp":     bson.M{"$gte": since},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &activities); err != nil {
+		return nil, err
+	}
+
+	return activities, nil
+}
+
+func (r *userActivityRepository) GetRecentByUser(ctx context.Context, userID, activityType string, limit int) ([]models.UserActivity, error) {
+	var activities []models.UserActivity
+
+	filter := bson.M{"user_id": userID, "activity_type": activityType}
+	opts := options.Find().
+		SetSort(bson.D{{"timestamp", -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &activities); err != nil {
+		return nil, err
+	}
+
+	return activities, nil
+}
+
+// AnonymizeUser replaces the user ID and drops the network details of the user's activity events
+func (r *userActivityRepository) AnonymizeUser(ctx context.Context, userID, pseudonym string) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"user_id": userID},
+		bson.M{
+			"$set":   bson.M{"user_id": pseudonym},
+			"$unset": bson.M{"ip": "", "user_agent": "", "session_id": ""},
+		},
+	)
+	return err
+}
+
+type mediaRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMediaRepository(db *mongo.Database) MediaRepository {
+	return &mediaRepository{
+		collection: db.Collection("media"),
+	}
+}
+
+func (r *mediaRepository) Create(ctx context.Context, media *models.Media) error {
+	media.CreatedAt = time.Now()
+	media.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, media)
+	if err != nil {
+		return err
+	}
+	media.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *mediaRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Media, error) {
+	var media models.Media
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&media)
+	if err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
+
+func (r *mediaRepository) Update(ctx context.Context, media *models.Media) error {
+	media.UpdatedAt = time.Now()
+
+	filter := bson.M{"_id": media.ID}
+	update := bson.M{"$set": media}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *mediaRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id}
+	_, err := r.collection.DeleteOne(ctx, filter)
+	return err
+}
+
+func (r *mediaRepository) GetByOwner(ctx context.Context, ownerType, ownerID string) ([]models.Media, error) {
+	var media []models.Media
+
+	filter := bson.M{"owner_type": ownerType, "owner_id": ownerID}
+	opts := options.Find().SetSort(bson.D{{"created_at", 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &media); err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
+
+// GetByStatusBefore pages through media in a status last updated before a time, in ID order.
+// Pass the last ID of the previous page as afterID, or a zero ID for the first page.
+func (r *mediaRepository) GetByStatusBefore(ctx context.Context, status string, before time.Time, afterID primitive.ObjectID, limit int) ([]models.Media, error) {
+	var media []models.Media
+
+	filter := bson.M{
+		"status":     status,
+		"updated_at": bson.M{"$lt": before},
+	}
+	if !afterID.IsZero() {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{"_id", 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &media); err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
@@ -2,11 +2,16 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/fieldcrypt"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type userRepository struct {
@@ -30,9 +35,19 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 	return &user, nil
 }
 
+// whereContact matches users by email or phone through its blind index. Rows written before
+// encryption was turned on, and not yet rewritten by the re-encryption job, still hold the
+// plaintext, so the column itself is matched too.
+func whereContact(db *gorm.DB, column, value string) *gorm.DB {
+	if index := fieldcrypt.BlindIndex(value); index != "" {
+		return db.Where("("+column+"_hash = ? OR "+column+" = ?)", index, value)
+	}
+	return db.Where(column+" = ?", value)
+}
+
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	err := whereContact(r.db.WithContext(ctx), "email", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +56,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 
 func (r *userRepository) GetByPhone(ctx context.Context, phone string) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).Where("phone = ?", phone).First(&user).Error
+	err := whereContact(r.db.WithContext(ctx), "phone", phone).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +66,7 @@ func (r *userRepository) GetByPhone(ctx context.Context, phone string) (*models.
 // Multi-restaurant support: get user by email within specific restaurant
 func (r *userRepository) GetByEmailAndRestaurant(ctx context.Context, email string, restaurantID uuid.UUID) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).Where("email = ? AND restaurant_id = ?", email, restaurantID).First(&user).Error
+	err := whereContact(r.db.WithContext(ctx), "email", email).Where("restaurant_id = ?", restaurantID).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +76,7 @@ func (r *userRepository) GetByEmailAndRestaurant(ctx context.Context, email stri
 // Multi-restaurant support: get user by phone within specific restaurant
 func (r *userRepository) GetByPhoneAndRestaurant(ctx context.Context, phone string, restaurantID uuid.UUID) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).Where("phone = ? AND restaurant_id = ?", phone, restaurantID).First(&user).Error
+	err := whereContact(r.db.WithContext(ctx), "phone", phone).Where("restaurant_id = ?", restaurantID).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -82,6 +97,87 @@ func (r *userRepository) GetByRestaurantID(ctx context.Context, restaurantID uui
 	return users, err
 }
 
+// Anonymize erases a user's personal data in one transaction. The user row stays as a tombstone
+// so orders, payments and refunds keep their references for accounting; orders keep only a
+// pseudonymized customer contact. Addresses, linked sign-in accounts and OTPs are deleted and
+// active carts are cancelled.
+func (r *userRepository) Anonymize(ctx context.Context, userID uuid.UUID, pseudonymize func(string) string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+
+		var contacts []string
+		if err := tx.Model(&models.Order{}).Where("user_id = ?", userID).Distinct().Pluck("customer_contact", &contacts).Error; err != nil {
+			return err
+		}
+		for _, contact := range contacts {
+			if contact == "" {
+				continue
+			}
+			if err := tx.Model(&models.Order{}).
+				Where("user_id = ? AND customer_contact = ?", userID, contact).
+				Updates(map[string]interface{}{
+					"customer_contact": pseudonymize(contact),
+					"version":          gorm.Expr("version + 1"),
+				}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.Order{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"customer_name":                       "Deleted User",
+			"delivery_full_address_with_lat_long": models.JSONB{"redacted": true},
+			"version":                             gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Cart{}).
+			Where("user_id = ? AND status = ?", userID, "active").
+			Updates(map[string]interface{}{"status": "cancelled", "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Address{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.PasswordHistory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.SavedPaymentMethod{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.DietaryPreference{}).Error; err != nil {
+			return err
+		}
+		if user.Phone != "" {
+			if err := tx.Where("phone = ?", user.Phone).Delete(&models.OTP{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("phone = ?", user.Phone).Delete(&models.SMSDelivery{}).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"name":               "Deleted User",
+			"email":              "",
+			"phone":              "",
+			"email_hash":         "",
+			"phone_hash":         "",
+			"avatar_url":         "",
+			"password_hash":      "",
+			"default_address_id": nil,
+			"is_verified":        false,
+			"status":             "deleted",
+			"updated_at":         time.Now(),
+		}).Error
+	})
+}
+
 // Restaurant Repository
 type restaurantRepository struct {
 	db *gorm.DB
@@ -108,10 +204,39 @@ func (r *restaurantRepository) Update(ctx context.Context, restaurant *models.Re
 	return r.db.WithContext(ctx).Save(restaurant).Error
 }
 
+// Delete soft-deletes the restaurant; its orders and payouts keep referring to it
 func (r *restaurantRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&models.Restaurant{}, id).Error
 }
 
+// GetDeleted returns soft-deleted restaurants, most recently deleted first
+func (r *restaurantRepository) GetDeleted(ctx context.Context, offset, limit int) ([]models.Restaurant, int64, error) {
+	var restaurants []models.Restaurant
+	var total int64
+
+	query := r.db.WithContext(ctx).Unscoped().Model(&models.Restaurant{}).Where("deleted_at IS NOT NULL")
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("deleted_at DESC").Offset(offset).Limit(limit).Find(&restaurants).Error
+	return restaurants, total, err
+}
+
+// Restore undeletes a soft-deleted restaurant, returning gorm.ErrRecordNotFound when it is not deleted
+func (r *restaurantRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&models.Restaurant{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (r *restaurantRepository) GetByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]models.Restaurant, error) {
 	var restaurants []models.Restaurant
 	err := r.db.WithContext(ctx).Where("owner_id = ?", ownerID).Find(&restaurants).Error
@@ -121,6 +246,7 @@ func (r *restaurantRepository) GetByOwnerID(ctx context.Context, ownerID uuid.UU
 func (r *restaurantRepository) Search(ctx context.Context, query string, limit, offset int) ([]models.Restaurant, error) {
 	var restaurants []models.Restaurant
 	err := r.db.WithContext(ctx).
+		Where("is_brand = ?", false).
 		Where("name ILIKE ? OR description ILIKE ?", "%"+query+"%", "%"+query+"%").
 		Limit(limit).Offset(offset).Find(&restaurants).Error
 	return restaurants, err
@@ -132,616 +258,4001 @@ func (r *restaurantRepository) GetRestaurantsWithAutoOpenClose() ([]*models.Rest
 	return restaurants, err
 }
 
-// Order Repository
-type orderRepository struct {
-	db *gorm.DB
+func (r *restaurantRepository) GetByOnboardingStatus(ctx context.Context, status string, offset, limit int) ([]models.Restaurant, int64, error) {
+	var restaurants []models.Restaurant
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Restaurant{})
+	if status != "" {
+		query = query.Where("onboarding_status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("submitted_at ASC NULLS LAST, created_at ASC").Offset(offset).Limit(limit).Find(&restaurants).Error
+	return restaurants, total, err
 }
 
-func NewOrderRepository(db *gorm.DB) OrderRepository {
-	return &orderRepository{db: db}
+// GetOutlets returns the restaurants attached to a brand
+func (r *restaurantRepository) GetOutlets(ctx context.Context, brandID uuid.UUID) ([]models.Restaurant, error) {
+	var restaurants []models.Restaurant
+	err := r.db.WithContext(ctx).Where("franchise_parent_id = ?", brandID).Order("name ASC").Find(&restaurants).Error
+	return restaurants, err
 }
 
-func (r *orderRepository) Create(ctx context.Context, order *models.Order) error {
-	return r.db.WithContext(ctx).Create(order).Error
+// Restaurant Document Repository
+type restaurantDocumentRepository struct {
+	db *gorm.DB
 }
 
-func (r *orderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
-	var order models.Order
-	err := r.db.WithContext(ctx).
-		Preload("User").
-		Preload("Restaurant").
-		Preload("Cart").
-		Preload("PorterDeliveries", "is_active = ?", true).
-		Where("id = ?", id).First(&order).Error
-	if err != nil {
-		return nil, err
-	}
-	return &order, nil
+func NewRestaurantDocumentRepository(db *gorm.DB) RestaurantDocumentRepository {
+	return &restaurantDocumentRepository{db: db}
 }
 
-func (r *orderRepository) Update(ctx context.Context, order *models.Order) error {
-	return r.db.WithContext(ctx).Save(order).Error
+func (r *restaurantDocumentRepository) Create(ctx context.Context, document *models.RestaurantDocument) error {
+	return r.db.WithContext(ctx).Create(document).Error
 }
 
-func (r *orderRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.Order{}, id).Error
+func (r *restaurantDocumentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.RestaurantDocument, error) {
+	var document models.RestaurantDocument
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&document).Error
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
 }
 
-func (r *orderRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Order, error) {
-	var orders []models.Order
-	err := r.db.WithContext(ctx).
-		Preload("Restaurant").
-		Preload("PorterDeliveries", "is_active = ?", true).
-		Where("user_id = ?", userID).
-		Order("created_at DESC").
-		Limit(limit).Offset(offset).Find(&orders).Error
-	return orders, err
+func (r *restaurantDocumentRepository) GetByRestaurantAndType(ctx context.Context, restaurantID uuid.UUID, documentType string) (*models.RestaurantDocument, error) {
+	var document models.RestaurantDocument
+	err := r.db.WithContext(ctx).Where("restaurant_id = ? AND type = ?", restaurantID, documentType).First(&document).Error
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
 }
 
-func (r *orderRepository) GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID, limit, offset int) ([]models.Order, error) {
-	var orders []models.Order
-	err := r.db.WithContext(ctx).
-		Preload("User").
-		Preload("PorterDeliveries", "is_active = ?", true).
-		Where("restaurant_id = ?", restaurantID).
-		Order("created_at DESC").
-		Limit(limit).Offset(offset).Find(&orders).Error
-	return orders, err
+func (r *restaurantDocumentRepository) GetByRestaurant(ctx context.Context, restaurantID uuid.UUID) ([]models.RestaurantDocument, error) {
+	var documents []models.RestaurantDocument
+	err := r.db.WithContext(ctx).Where("restaurant_id = ?", restaurantID).Order("type").Find(&documents).Error
+	return documents, err
 }
 
-func (r *orderRepository) GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.Order, error) {
-	var orders []models.Order
-	err := r.db.WithContext(ctx).
-		Preload("User").
-		Preload("Restaurant").
-		Where("order_status = ?", status).
-		Order("created_at DESC").
-		Limit(limit).Offset(offset).Find(&orders).Error
-	return orders, err
+func (r *restaurantDocumentRepository) Update(ctx context.Context, document *models.RestaurantDocument) error {
+	return r.db.WithContext(ctx).Save(document).Error
 }
 
-// Payment Repository
-type paymentRepository struct {
+// Staff Repository
+type staffRepository struct {
 	db *gorm.DB
 }
 
-func NewPaymentRepository(db *gorm.DB) PaymentRepository {
-	return &paymentRepository{db: db}
+func NewStaffRepository(db *gorm.DB) StaffRepository {
+	return &staffRepository{db: db}
 }
 
-func (r *paymentRepository) Create(ctx context.Context, payment *models.Payment) error {
-	return r.db.WithContext(ctx).Create(payment).Error
+func (r *staffRepository) Create(ctx context.Context, staff *models.RestaurantStaff) error {
+	return r.db.WithContext(ctx).Create(staff).Error
 }
 
-func (r *paymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
-	var payment models.Payment
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&payment).Error
+func (r *staffRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.RestaurantStaff, error) {
+	var staff models.RestaurantStaff
+	err := r.db.WithContext(ctx).Preload("User").Where("id = ?", id).First(&staff).Error
 	if err != nil {
 		return nil, err
 	}
-	return &payment, nil
+	return &staff, nil
 }
 
-func (r *paymentRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Payment, error) {
-	var payment models.Payment
-	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).First(&payment).Error
+func (r *staffRepository) GetByRestaurant(ctx context.Context, restaurantID uuid.UUID) ([]models.RestaurantStaff, error) {
+	var staff []models.RestaurantStaff
+	err := r.db.WithContext(ctx).Preload("User").
+		Where("restaurant_id = ?", restaurantID).
+		Order("created_at DESC").
+		Find(&staff).Error
+	return staff, err
+}
+
+func (r *staffRepository) GetPendingInvite(ctx context.Context, restaurantID uuid.UUID, phone string) (*models.RestaurantStaff, error) {
+	var staff models.RestaurantStaff
+	err := r.db.WithContext(ctx).
+		Where("restaurant_id = ? AND phone = ? AND status = ?", restaurantID, phone, "invited").
+		Order("created_at DESC").
+		First(&staff).Error
 	if err != nil {
 		return nil, err
 	}
-	return &payment, nil
+	return &staff, nil
 }
 
-func (r *paymentRepository) GetByTransactionID(ctx context.Context, transactionID string) (*models.Payment, error) {
-	var payment models.Payment
-	err := r.db.WithContext(ctx).Where("transaction_id = ?", transactionID).First(&payment).Error
+func (r *staffRepository) GetActiveByUser(ctx context.Context, userID uuid.UUID) (*models.RestaurantStaff, error) {
+	var staff models.RestaurantStaff
+	err := r.db.WithContext(ctx).Where("user_id = ? AND status = ?", userID, "active").First(&staff).Error
 	if err != nil {
 		return nil, err
 	}
-	return &payment, nil
-}
-
-func (r *paymentRepository) Update(ctx context.Context, payment *models.Payment) error {
-	return r.db.WithContext(ctx).Save(payment).Error
+	return &staff, nil
 }
 
-func (r *paymentRepository) GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.Payment, error) {
-	var payments []models.Payment
-	err := r.db.WithContext(ctx).
-		Preload("Order").
-		Preload("User").
-		Where("status = ?", status).
-		Order("created_at DESC").
-		Limit(limit).Offset(offset).Find(&payments).Error
-	return payments, err
+func (r *staffRepository) Update(ctx context.Context, staff *models.RestaurantStaff) error {
+	return r.db.WithContext(ctx).Omit("User").Save(staff).Error
 }
 
-// Cart Repository
-type cartRepository struct {
+// Commission Repository
+type commissionRepository struct {
 	db *gorm.DB
 }
 
-func NewCartRepository(db *gorm.DB) CartRepository {
-	return &cartRepository{db: db}
-}
-
-func (r *cartRepository) Create(ctx context.Context, cart *models.Cart) error {
-	return r.db.WithContext(ctx).Create(cart).Error
+func NewCommissionRepository(db *gorm.DB) CommissionRepository {
+	return &commissionRepository{db: db}
 }
 
-func (r *cartRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Cart, error) {
-	var cart models.Cart
-	err := r.db.WithContext(ctx).
-		Preload("User").
-		Preload("Restaurant").
-		Where("id = ?", id).First(&cart).Error
+func (r *commissionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.CommissionToRestaurant, error) {
+	var commission models.CommissionToRestaurant
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&commission).Error
 	if err != nil {
 		return nil, err
 	}
-	return &cart, nil
+	return &commission, nil
 }
 
-func (r *cartRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Cart, error) {
-	var cart models.Cart
+// GetHistory returns every commission version of a restaurant, latest first
+func (r *commissionRepository) GetHistory(ctx context.Context, restaurantID uuid.UUID) ([]models.CommissionToRestaurant, error) {
+	var commissions []models.CommissionToRestaurant
 	err := r.db.WithContext(ctx).
-		Preload("Restaurant").
-		Where("user_id = ? AND status = ?", userID, "active").First(&cart).Error
+		Where("restaurant_id = ?", restaurantID).
+		Order("effective_from DESC").
+		Find(&commissions).Error
+	return commissions, err
+}
+
+func (r *commissionRepository) GetActiveAt(ctx context.Context, restaurantID uuid.UUID, at time.Time) (*models.CommissionToRestaurant, error) {
+	var commission models.CommissionToRestaurant
+	err := r.db.WithContext(ctx).
+		Where("restaurant_id = ? AND effective_from <= ?", restaurantID, at).
+		Where("effective_to IS NULL OR effective_to > ?", at).
+		Order("effective_from DESC").
+		First(&commission).Error
 	if err != nil {
 		return nil, err
 	}
-	return &cart, nil
+	return &commission, nil
 }
 
-func (r *cartRepository) Update(ctx context.Context, cart *models.Cart) error {
-	return r.db.WithContext(ctx).Save(cart).Error
+// CreateVersion inserts a commission version, ending the version it takes over from at its
+// effective date. A version scheduled to start later caps the new one.
+func (r *commissionRepository) CreateVersion(ctx context.Context, commission *models.CommissionToRestaurant) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var next models.CommissionToRestaurant
+		err := tx.Where("restaurant_id = ? AND effective_from > ?", commission.RestaurantID, commission.EffectiveFrom).
+			Order("effective_from ASC").
+			First(&next).Error
+		if err == nil {
+			commission.EffectiveTo = &next.EffectiveFrom
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		err = tx.Model(&models.CommissionToRestaurant{}).
+			Where("restaurant_id = ? AND effective_from < ?", commission.RestaurantID, commission.EffectiveFrom).
+			Where("effective_to IS NULL OR effective_to > ?", commission.EffectiveFrom).
+			Update("effective_to", commission.EffectiveFrom).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Omit("Restaurant").Create(commission).Error
+	})
 }
 
-func (r *cartRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.Cart{}, id).Error
+// DeleteVersion removes a commission version and hands its period back to the version
+// before it
+func (r *commissionRepository) DeleteVersion(ctx context.Context, commission *models.CommissionToRestaurant) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.CommissionToRestaurant{}).
+			Where("restaurant_id = ? AND effective_to = ?", commission.RestaurantID, commission.EffectiveFrom).
+			Update("effective_to", commission.EffectiveTo).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&models.CommissionToRestaurant{}, "id = ?", commission.ID).Error
+	})
 }
 
-// Coupon repository implementation
-type couponRepository struct {
+// Payout Repository
+type payoutRepository struct {
 	db *gorm.DB
 }
 
-func NewCouponRepository(db *gorm.DB) CouponRepository {
-	return &couponRepository{db: db}
+func NewPayoutRepository(db *gorm.DB) PayoutRepository {
+	return &payoutRepository{db: db}
 }
 
-func (r *couponRepository) Create(ctx context.Context, coupon *models.Coupon) error {
-	return r.db.WithContext(ctx).Create(coupon).Error
+// GetUnsettledOrders returns delivered orders placed before the cutoff that no batch has
+// settled yet, so orders delivered after a batch ran are picked up by the next one
+func (r *payoutRepository) GetUnsettledOrders(ctx context.Context, before time.Time) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.db.WithContext(ctx).
+		Where("order_status = ? AND created_at < ?", "delivered", before).
+		Where("NOT EXISTS (SELECT 1 FROM order_settlements WHERE order_settlements.source_id = orders.id)").
+		Order("created_at ASC").
+		Find(&orders).Error
+	return orders, err
 }
 
-func (r *couponRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Coupon, error) {
-	var coupon models.Coupon
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&coupon).Error
-	if err != nil {
-		return nil, err
-	}
-	return &coupon, nil
+// GetUnsettledRefunds returns refunds processed before the cutoff on delivered orders that
+// no batch has deducted yet, with their orders
+func (r *payoutRepository) GetUnsettledRefunds(ctx context.Context, before time.Time) ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := r.db.WithContext(ctx).Preload("Order").
+		Joins("JOIN orders ON orders.id = refunds.order_id AND orders.order_status = ?", "delivered").
+		Where("refunds.status = ? AND refunds.processed_at < ?", "processed", before).
+		Where("NOT EXISTS (SELECT 1 FROM order_settlements WHERE order_settlements.source_id = refunds.id)").
+		Order("refunds.processed_at ASC").
+		Find(&refunds).Error
+	return refunds, err
 }
 
-func (r *couponRepository) GetByCode(ctx context.Context, code string) (*models.Coupon, error) {
-	var coupon models.Coupon
-	err := r.db.WithContext(ctx).Where("code = ?", code).First(&coupon).Error
+func (r *payoutRepository) GetSettlementBySource(ctx context.Context, sourceID uuid.UUID) (*models.OrderSettlement, error) {
+	var settlement models.OrderSettlement
+	err := r.db.WithContext(ctx).Where("source_id = ?", sourceID).First(&settlement).Error
 	if err != nil {
 		return nil, err
 	}
-	return &coupon, nil
+	return &settlement, nil
 }
 
-func (r *couponRepository) Update(ctx context.Context, coupon *models.Coupon) error {
-	return r.db.WithContext(ctx).Save(coupon).Error
+// GetNegativeHeldPayouts returns held payouts that ended below zero, which are carried into
+// the restaurant's next payout
+func (r *payoutRepository) GetNegativeHeldPayouts(ctx context.Context) ([]models.RestaurantPayout, error) {
+	var payouts []models.RestaurantPayout
+	err := r.db.WithContext(ctx).Where("status = ? AND net_amount < 0", "on_hold").Find(&payouts).Error
+	return payouts, err
 }
 
-func (r *couponRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.Coupon{}, id).Error
+// CreateBatch writes a batch with its payouts and settlement lines in one transaction, and
+// marks the held payouts whose balance was carried into it. The unique settlement source
+// keeps an order from being paid out twice.
+func (r *payoutRepository) CreateBatch(ctx context.Context, batch *models.PayoutBatch, payouts []models.RestaurantPayout, settlements []models.OrderSettlement, carriedPayoutIDs []uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		if len(payouts) == 0 {
+			return nil
+		}
+		for i := range payouts {
+			payouts[i].BatchID = batch.ID
+		}
+		if err := tx.Omit("Restaurant").Create(&payouts).Error; err != nil {
+			return err
+		}
+		if len(settlements) > 0 {
+			if err := tx.CreateInBatches(settlements, 500).Error; err != nil {
+				return err
+			}
+		}
+		if len(carriedPayoutIDs) > 0 {
+			return tx.Model(&models.RestaurantPayout{}).
+				Where("id IN ? AND status = ?", carriedPayoutIDs, "on_hold").
+				Updates(map[string]interface{}{"status": "carried_forward", "updated_at": time.Now()}).Error
+		}
+		return nil
+	})
 }
 
-func (r *couponRepository) GetCouponsWithFilters(ctx context.Context, offset, limit int, restaurantID *uuid.UUID, active *bool) ([]models.Coupon, int64, error) {
-	var coupons []models.Coupon
-	var total int64
-
-	query := r.db.WithContext(ctx).Model(&models.Coupon{})
-
-	// Apply filters
-	if restaurantID != nil {
-		query = query.Where("restaurant_id = ?", *restaurantID)
+func (r *payoutRepository) GetBatchByID(ctx context.Context, id uuid.UUID) (*models.PayoutBatch, error) {
+	var batch models.PayoutBatch
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&batch).Error
+	if err != nil {
+		return nil, err
 	}
-	if active != nil {
-		query = query.Where("is_active = ?", *active)
+	return &batch, nil
+}
+
+func (r *payoutRepository) GetBatchByPeriod(ctx context.Context, periodStart time.Time) (*models.PayoutBatch, error) {
+	var batch models.PayoutBatch
+	err := r.db.WithContext(ctx).Where("period_start = ?", periodStart).First(&batch).Error
+	if err != nil {
+		return nil, err
 	}
+	return &batch, nil
+}
 
-	// Get total count
+func (r *payoutRepository) GetBatches(ctx context.Context, offset, limit int) ([]models.PayoutBatch, int64, error) {
+	var batches []models.PayoutBatch
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.PayoutBatch{})
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// Get paginated results
-	if err := query.Offset(offset).Limit(limit).Find(&coupons).Error; err != nil {
+	err := query.Order("period_start DESC").Offset(offset).Limit(limit).Find(&batches).Error
+	return batches, total, err
+}
+
+func (r *payoutRepository) UpdateBatch(ctx context.Context, batch *models.PayoutBatch) error {
+	return r.db.WithContext(ctx).Save(batch).Error
+}
+
+func (r *payoutRepository) GetPayoutByID(ctx context.Context, id uuid.UUID) (*models.RestaurantPayout, error) {
+	var payout models.RestaurantPayout
+	err := r.db.WithContext(ctx).Preload("Restaurant", includeDeleted).Where("id = ?", id).First(&payout).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payout, nil
+}
+
+func (r *payoutRepository) GetPayoutsByBatch(ctx context.Context, batchID uuid.UUID) ([]models.RestaurantPayout, error) {
+	var payouts []models.RestaurantPayout
+	err := r.db.WithContext(ctx).Preload("Restaurant", includeDeleted).
+		Where("batch_id = ?", batchID).
+		Order("net_amount DESC").
+		Find(&payouts).Error
+	return payouts, err
+}
+
+func (r *payoutRepository) GetPayoutsByRestaurant(ctx context.Context, restaurantID uuid.UUID, offset, limit int) ([]models.RestaurantPayout, int64, error) {
+	var payouts []models.RestaurantPayout
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.RestaurantPayout{}).Where("restaurant_id = ?", restaurantID)
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	return coupons, total, nil
+	err := query.Order("period_start DESC").Offset(offset).Limit(limit).Find(&payouts).Error
+	return payouts, total, err
 }
 
-// Refund repository implementation
-type refundRepository struct {
-	db *gorm.DB
+func (r *payoutRepository) UpdatePayout(ctx context.Context, payout *models.RestaurantPayout) error {
+	return r.db.WithContext(ctx).Omit("Restaurant").Save(payout).Error
 }
 
-func NewRefundRepository(db *gorm.DB) RefundRepository {
-	return &refundRepository{db: db}
+func (r *payoutRepository) GetSettlementsByPayout(ctx context.Context, payoutID uuid.UUID) ([]models.OrderSettlement, error) {
+	var settlements []models.OrderSettlement
+	err := r.db.WithContext(ctx).Where("payout_id = ?", payoutID).Order("occurred_at ASC").Find(&settlements).Error
+	return settlements, err
 }
 
-func (r *refundRepository) Create(ctx context.Context, refund *models.Refund) error {
-	return r.db.WithContext(ctx).Create(refund).Error
+// includeDeleted lets a preload load soft-deleted rows, so order and payout history keeps
+// showing a restaurant after it was deleted
+func includeDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
 }
 
-func (r *refundRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Refund, error) {
-	var refund models.Refund
-	err := r.db.WithContext(ctx).
-		Preload("Order").
-		Preload("Payment").
-		Preload("User").
-		Where("id = ?", id).First(&refund).Error
-	if err != nil {
-		return nil, err
+// ErrVersionConflict is returned when saving a cart, order or quote that another request changed
+// since it was read; the caller should read it again and reapply its change
+var ErrVersionConflict = errors.New("record was modified concurrently")
+
+// saveVersioned writes every column of a versioned record only if its version is still the one
+// that was read, and bumps the version. Associations are not saved.
+func saveVersioned(tx *gorm.DB, model interface{}, version *int) error {
+	read := *version
+	*version = read + 1
+	result := tx.Model(model).Where("version = ?", read).Select("*").Omit(clause.Associations).Updates(model)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrVersionConflict
 	}
-	return &refund, nil
+	if result.Error != nil {
+		*version = read
+	}
+	return result.Error
 }
 
-func (r *refundRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Refund, error) {
+// Order Repository
+type orderRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderRepository(db *gorm.DB) OrderRepository {
+	return &orderRepository{db: db}
+}
+
+func (r *orderRepository) Create(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).Create(order).Error
+}
+
+func (r *orderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	var order models.Order
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Restaurant", includeDeleted).
+		Preload("Cart").
+		Preload("PorterDeliveries", "is_active = ?", true).
+		Where("id = ?", id).First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// Update saves the order, failing with ErrVersionConflict if it changed since it was read
+func (r *orderRepository) Update(ctx context.Context, order *models.Order) error {
+	return saveVersioned(r.db.WithContext(ctx), order, &order.Version)
+}
+
+// UpdateWithEvents saves the order and writes its outbox events in one transaction, so the
+// events are published if and only if the change is committed
+func (r *orderRepository) UpdateWithEvents(ctx context.Context, order *models.Order, events []models.OutboxEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := saveVersioned(tx, order, &order.Version); err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return tx.Create(&events).Error
+	})
+}
+
+// UpdateWithPayment saves the order together with the payment that changed it and writes its
+// outbox events, all in one transaction; a version conflict on the order saves none of them
+func (r *orderRepository) UpdateWithPayment(ctx context.Context, order *models.Order, payment *models.Payment, events []models.OutboxEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := saveVersioned(tx, order, &order.Version); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Save(payment).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return tx.Create(&events).Error
+	})
+}
+
+// Delete soft-deletes the order
+func (r *orderRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Order{}, id).Error
+}
+
+// GetDeleted returns soft-deleted orders, most recently deleted first
+func (r *orderRepository) GetDeleted(ctx context.Context, offset, limit int) ([]models.Order, int64, error) {
+	var orders []models.Order
+	var total int64
+
+	query := r.db.WithContext(ctx).Unscoped().Model(&models.Order{}).Where("deleted_at IS NOT NULL")
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Restaurant", includeDeleted).
+		Order("deleted_at DESC").Offset(offset).Limit(limit).Find(&orders).Error
+	return orders, total, err
+}
+
+// Restore undeletes a soft-deleted order, returning gorm.ErrRecordNotFound when it is not deleted
+func (r *orderRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&models.Order{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// archivableOrders selects orders that can leave the orders table: cancelled orders and
+// delivered orders a payout batch has settled, placed before the first cutoff, and any order
+// soft-deleted before the second
+const archivableOrders = `SELECT id FROM orders
+	WHERE (created_at < @placed_before AND (order_status = 'cancelled' OR (order_status = 'delivered'
+			AND EXISTS (SELECT 1 FROM order_settlements WHERE order_settlements.source_id = orders.id))))
+		OR deleted_at < @deleted_before
+	ORDER BY created_at
+	LIMIT @limit
+	FOR UPDATE SKIP LOCKED`
+
+// Archive moves up to limit archivable orders into archived_orders, each with a snapshot of its
+// logs, payments, refunds and Porter deliveries, and deletes those rows. It returns how many
+// orders were archived; callers repeat until it returns fewer than limit.
+func (r *orderRepository) Archive(ctx context.Context, placedBefore, deletedBefore time.Time, limit int) (int64, error) {
+	var archived int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uuid.UUID
+		err := tx.Raw(archivableOrders, map[string]interface{}{
+			"placed_before":  placedBefore,
+			"deleted_before": deletedBefore,
+			"limit":          limit,
+		}).Scan(&ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		err = tx.Exec(`INSERT INTO archived_orders (id, user_id, restaurant_id, order_status, total_amount, created_at, archived_at, snapshot)
+			SELECT o.id, o.user_id, o.restaurant_id, o.order_status, o.total_amount, o.created_at, now(),
+				jsonb_build_object(
+					'order', to_jsonb(o),
+					'order_logs', COALESCE((SELECT jsonb_agg(l) FROM order_logs l WHERE l.order_id = o.id), '[]'::jsonb),
+					'payments', COALESCE((SELECT jsonb_agg(p) FROM payments p WHERE p.order_id = o.id), '[]'::jsonb),
+					'refunds', COALESCE((SELECT jsonb_agg(rf) FROM refunds rf WHERE rf.order_id = o.id), '[]'::jsonb),
+					'porter_deliveries', COALESCE((SELECT jsonb_agg(pd) FROM porter_deliveries pd WHERE pd.order_id = o.id), '[]'::jsonb))
+			FROM orders o WHERE o.id IN ?
+			ON CONFLICT (id) DO NOTHING`, ids).Error
+		if err != nil {
+			return err
+		}
+
+		// Children first: refunds reference payments, and these tables reference orders
+		for _, table := range []string{"refunds", "payments", "order_logs", "porter_deliveries"} {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE order_id IN ?", ids).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Exec("DELETE FROM orders WHERE id IN ?", ids)
+		archived = result.RowsAffected
+		return result.Error
+	})
+	return archived, err
+}
+
+func (r *orderRepository) GetArchived(ctx context.Context, id uuid.UUID) (*models.ArchivedOrder, error) {
+	var order models.ArchivedOrder
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *orderRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.db.WithContext(ctx).
+		Preload("Restaurant", includeDeleted).
+		Preload("PorterDeliveries", "is_active = ?", true).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).Find(&orders).Error
+	return orders, err
+}
+
+func (r *orderRepository) GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID, limit, offset int) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("PorterDeliveries", "is_active = ?", true).
+		Where("restaurant_id = ?", restaurantID).
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).Find(&orders).Error
+	return orders, err
+}
+
+func (r *orderRepository) GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Restaurant", includeDeleted).
+		Where("order_status = ?", status).
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).Find(&orders).Error
+	return orders, err
+}
+
+func (r *orderRepository) GetByRestaurantIDSince(ctx context.Context, restaurantID uuid.UUID, since time.Time) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.db.WithContext(ctx).
+		Where("restaurant_id = ? AND created_at >= ?", restaurantID, since).
+		Order("created_at DESC").
+		Find(&orders).Error
+	return orders, err
+}
+
+func (r *orderRepository) GetByUserIDSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Order("created_at DESC").
+		Find(&orders).Error
+	return orders, err
+}
+
+// CountDeliveredByUserAndRestaurant counts a customer's delivered orders at a restaurant placed in [since, before)
+func (r *orderRepository) CountDeliveredByUserAndRestaurant(ctx context.Context, userID, restaurantID uuid.UUID, since, before time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Where("user_id = ? AND restaurant_id = ? AND order_status = ?", userID, restaurantID, "delivered").
+		Where("created_at >= ? AND created_at < ?", since, before).
+		Count(&count).Error
+	return count, err
+}
+
+// CountByUser counts a user's delivered orders, or every order they placed that was not
+// cancelled when deliveredOnly is false
+func (r *orderRepository) CountByUser(ctx context.Context, userID uuid.UUID, deliveredOnly bool) (int64, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&models.Order{}).Where("user_id = ?", userID)
+	if deliveredOnly {
+		query = query.Where("order_status = ?", "delivered")
+	} else {
+		query = query.Where("order_status <> ?", "cancelled")
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
+func (r *orderRepository) GetScheduledDue(ctx context.Context, before time.Time, limit int) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.db.WithContext(ctx).
+		Preload("Restaurant", includeDeleted).
+		Where("order_status = ? AND scheduled_for <= ?", "scheduled", before).
+		Order("scheduled_for ASC").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
+
+// GetAwaitingPaymentSince returns orders still waiting for payment that were created before the cutoff
+func (r *orderRepository) GetAwaitingPaymentSince(ctx context.Context, before time.Time, limit int) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.db.WithContext(ctx).
+		Where("order_status = ? AND created_at <= ?", "pending_payment", before).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
+
+// GetByRestaurantIDs lists orders across several restaurants, newest first
+func (r *orderRepository) GetByRestaurantIDs(ctx context.Context, restaurantIDs []uuid.UUID, status string, offset, limit int) ([]models.Order, int64, error) {
+	var orders []models.Order
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Order{}).Where("restaurant_id IN ?", restaurantIDs)
+	if status != "" {
+		query = query.Where("order_status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&orders).Error
+	return orders, total, err
+}
+
+// apply adds the filter's conditions to a query on orders
+func (f OrderFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.RestaurantID != uuid.Nil {
+		query = query.Where("orders.restaurant_id = ?", f.RestaurantID)
+	}
+	if len(f.Statuses) > 0 {
+		query = query.Where("orders.order_status IN ?", f.Statuses)
+	}
+	if !f.From.IsZero() {
+		query = query.Where("orders.created_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		query = query.Where("orders.created_at < ?", f.To)
+	}
+	if f.MinAmount != nil {
+		query = query.Where("orders.total_amount >= ?", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		query = query.Where("orders.total_amount <= ?", *f.MaxAmount)
+	}
+	if f.PaymentMethod != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM payments WHERE payments.order_id = orders.id AND LOWER(payments.method) = LOWER(?))", f.PaymentMethod)
+	}
+	if f.DeliveryProvider != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM delivery_dispatches WHERE delivery_dispatches.order_id = orders.id AND delivery_dispatches.provider = ? AND delivery_dispatches.status = ?)",
+			f.DeliveryProvider, "booked")
+	}
+	if f.DeliveryPartnerCompanyID != uuid.Nil {
+		query = query.Where("orders.delivery_partner_id IN (SELECT id FROM restaurant_delivery_partners WHERE delivery_partner_company_id = ?)", f.DeliveryPartnerCompanyID)
+	}
+	return query
+}
+
+// List returns the orders matching the filter, newest first, with the total number of matches
+func (r *orderRepository) List(ctx context.Context, filter OrderFilter, offset, limit int) ([]models.Order, int64, error) {
+	var orders []models.Order
+	var total int64
+
+	query := filter.apply(r.db.WithContext(ctx).Model(&models.Order{}))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Preload("User").
+		Preload("PorterDeliveries", "is_active = ?", true).
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&orders).Error
+	return orders, total, err
+}
+
+// CountByStatus counts the orders matching the filter by status, largest first
+func (r *orderRepository) CountByStatus(ctx context.Context, filter OrderFilter) ([]OrderStatusCount, error) {
+	var counts []OrderStatusCount
+	err := filter.apply(r.db.WithContext(ctx).Model(&models.Order{})).
+		Select("order_status AS status, COUNT(*) AS count").
+		Group("order_status").
+		Order("count DESC").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// GetDailyRevenue sums the sale orders matching the filter per day. Unpaid, failed and
+// cancelled orders never count as revenue.
+func (r *orderRepository) GetDailyRevenue(ctx context.Context, filter OrderFilter) ([]DailyRevenue, error) {
+	var days []DailyRevenue
+	err := filter.apply(r.db.WithContext(ctx).Model(&models.Order{})).
+		Select("TO_CHAR(DATE(created_at), 'YYYY-MM-DD') AS day, COUNT(*) AS orders, COALESCE(SUM(total_amount), 0) AS revenue").
+		Where("order_status NOT IN ?", nonSaleOrderStatuses).
+		Group("DATE(created_at)").
+		Order("DATE(created_at) ASC").
+		Scan(&days).Error
+	return days, err
+}
+
+// GetDailyDeliveryFees sums the delivery fees of the orders matching the filter per day
+func (r *orderRepository) GetDailyDeliveryFees(ctx context.Context, filter OrderFilter) ([]DailyDeliveryFees, error) {
+	var days []DailyDeliveryFees
+	err := filter.apply(r.db.WithContext(ctx).Model(&models.Order{})).
+		Select("TO_CHAR(DATE(created_at), 'YYYY-MM-DD') AS day, COUNT(*) AS orders, COALESCE(SUM(delivery_fee), 0) AS delivery_fees").
+		Group("DATE(created_at)").
+		Order("DATE(created_at) ASC").
+		Scan(&days).Error
+	return days, err
+}
+
+// orderStatusReachedAt is when an order's log first records the status, the rest of the
+// query joining it laterally as alias.at
+const orderStatusReachedAt = `LEFT JOIN LATERAL (
+	SELECT MIN((entry->>'timestamp')::timestamptz) AS at
+	FROM jsonb_array_elements(COALESCE(orders.order_logs->'logs', '[]'::jsonb)) AS entry
+	WHERE entry->>'status' = ?
+) AS %s ON TRUE`
+
+// GetDeliveryPerformance counts the orders matching the filter by outcome, timing deliveries
+// from the order logs
+func (r *orderRepository) GetDeliveryPerformance(ctx context.Context, filter OrderFilter) (*DeliveryPerformance, error) {
+	var performance DeliveryPerformance
+	err := filter.apply(r.db.WithContext(ctx).Model(&models.Order{})).
+		Joins(fmt.Sprintf(orderStatusReachedAt, "dispatched"), "dispatched").
+		Joins(fmt.Sprintf(orderStatusReachedAt, "delivered"), "delivered").
+		Select(`COUNT(*) AS orders,
+			COUNT(*) FILTER (WHERE orders.order_status IN ('delivered', 'completed')) AS delivered,
+			COUNT(*) FILTER (WHERE orders.order_status = 'cancelled') AS cancelled,
+			COUNT(*) FILTER (WHERE delivered.at IS NOT NULL AND orders.promised_at IS NOT NULL AND delivered.at <= orders.promised_at) AS on_time,
+			COUNT(*) FILTER (WHERE delivered.at IS NOT NULL AND orders.promised_at IS NOT NULL AND delivered.at > orders.promised_at) AS late,
+			AVG(EXTRACT(EPOCH FROM delivered.at - dispatched.at) / 60) FILTER (WHERE delivered.at >= dispatched.at) AS average_delivery_minutes`).
+		Scan(&performance).Error
+	if err != nil {
+		return nil, err
+	}
+	return &performance, nil
+}
+
+// Payment Repository
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	return r.db.WithContext(ctx).Create(payment).Error
+}
+
+func (r *paymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// CountByOrderID returns how many payment attempts were made for an order
+func (r *paymentRepository) CountByOrderID(ctx context.Context, orderID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Payment{}).Where("order_id = ?", orderID).Count(&count).Error
+	return count, err
+}
+
+func (r *paymentRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).Find(&payments).Error
+	return payments, err
+}
+
+func (r *paymentRepository) GetByMethodsBetween(ctx context.Context, methods []string, from, to time.Time) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.db.WithContext(ctx).
+		Where("method IN ? AND created_at >= ? AND created_at < ?", methods, from, to).
+		Order("created_at").
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *paymentRepository) GetByTransactionID(ctx context.Context, transactionID string) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.WithContext(ctx).Where("transaction_id = ?", transactionID).First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) Update(ctx context.Context, payment *models.Payment) error {
+	return r.db.WithContext(ctx).Save(payment).Error
+}
+
+func (r *paymentRepository) GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.db.WithContext(ctx).
+		Preload("Order").
+		Preload("User").
+		Where("status = ?", status).
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).Find(&payments).Error
+	return payments, err
+}
+
+// Cart Repository
+type cartRepository struct {
+	db *gorm.DB
+}
+
+func NewCartRepository(db *gorm.DB) CartRepository {
+	return &cartRepository{db: db}
+}
+
+func (r *cartRepository) Create(ctx context.Context, cart *models.Cart) error {
+	return r.db.WithContext(ctx).Create(cart).Error
+}
+
+func (r *cartRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Cart, error) {
+	var cart models.Cart
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Restaurant").
+		Where("id = ?", id).First(&cart).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// GetByUserID returns the user's current cart: the active cart changed or switched to last
+func (r *cartRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Cart, error) {
+	var cart models.Cart
+	err := r.db.WithContext(ctx).
+		Preload("Restaurant").
+		Where("user_id = ? AND status = ?", userID, "active").
+		Order("updated_at DESC").
+		First(&cart).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// GetByUserAndRestaurant returns the user's active cart for a restaurant
+func (r *cartRepository) GetByUserAndRestaurant(ctx context.Context, userID, restaurantID uuid.UUID) (*models.Cart, error) {
+	var cart models.Cart
+	err := r.db.WithContext(ctx).
+		Preload("Restaurant").
+		Where("user_id = ? AND restaurant_id = ? AND status = ?", userID, restaurantID, "active").
+		First(&cart).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// GetActiveByUserID returns every active cart of the user, the current one first
+func (r *cartRepository) GetActiveByUserID(ctx context.Context, userID uuid.UUID) ([]models.Cart, error) {
+	var carts []models.Cart
+	err := r.db.WithContext(ctx).
+		Preload("Restaurant").
+		Where("user_id = ? AND status = ?", userID, "active").
+		Order("updated_at DESC").
+		Find(&carts).Error
+	return carts, err
+}
+
+// GetAbandoned returns the user's cart for the restaurant abandoned most recently, if that was
+// after since
+func (r *cartRepository) GetAbandoned(ctx context.Context, userID, restaurantID uuid.UUID, since time.Time) (*models.Cart, error) {
+	var cart models.Cart
+	err := r.db.WithContext(ctx).
+		Preload("Restaurant").
+		Where("user_id = ? AND restaurant_id = ? AND status = ?", userID, restaurantID, "abandoned").
+		Where("abandoned_at >= ?", since).
+		Order("abandoned_at DESC").
+		First(&cart).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// GetInactive returns active carts last changed before the given time, least recent first
+func (r *cartRepository) GetInactive(ctx context.Context, before time.Time, limit int) ([]models.Cart, error) {
+	var carts []models.Cart
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND updated_at < ?", "active", before).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&carts).Error
+	return carts, err
+}
+
+// Update saves the cart, failing with ErrVersionConflict if it changed since it was read
+func (r *cartRepository) Update(ctx context.Context, cart *models.Cart) error {
+	return saveVersioned(r.db.WithContext(ctx), cart, &cart.Version)
+}
+
+// UpdateWithEvents saves the cart and writes its outbox events in one transaction
+func (r *cartRepository) UpdateWithEvents(ctx context.Context, cart *models.Cart, events []models.OutboxEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := saveVersioned(tx, cart, &cart.Version); err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return tx.Create(&events).Error
+	})
+}
+
+func (r *cartRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Cart{}, id).Error
+}
+
+// ErrCouponLimitReached is returned when redeeming a coupon whose global or per-user limit is used up
+var ErrCouponLimitReached = errors.New("coupon usage limit reached")
+
+// Coupon repository implementation
+type couponRepository struct {
+	db *gorm.DB
+}
+
+func NewCouponRepository(db *gorm.DB) CouponRepository {
+	return &couponRepository{db: db}
+}
+
+func (r *couponRepository) Create(ctx context.Context, coupon *models.Coupon) error {
+	return r.db.WithContext(ctx).Create(coupon).Error
+}
+
+func (r *couponRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&coupon).Error
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *couponRepository) GetByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.db.WithContext(ctx).Where("code = ?", code).First(&coupon).Error
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *couponRepository) Update(ctx context.Context, coupon *models.Coupon) error {
+	return r.db.WithContext(ctx).Save(coupon).Error
+}
+
+func (r *couponRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Coupon{}, id).Error
+}
+
+func (r *couponRepository) GetCouponsWithFilters(ctx context.Context, offset, limit int, restaurantID *uuid.UUID, active *bool) ([]models.Coupon, int64, error) {
+	var coupons []models.Coupon
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Coupon{})
+
+	// Apply filters
+	if restaurantID != nil {
+		query = query.Where("restaurant_id = ?", *restaurantID)
+	}
+	if active != nil {
+		query = query.Where("is_active = ?", *active)
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated results
+	if err := query.Offset(offset).Limit(limit).Find(&coupons).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return coupons, total, nil
+}
+
+// GetActiveForRestaurant returns active coupons valid at the given time, both platform-wide
+// and scoped to the restaurant
+func (r *couponRepository) GetActiveForRestaurant(ctx context.Context, restaurantID uuid.UUID, at time.Time) ([]models.Coupon, error) {
+	var coupons []models.Coupon
+	err := r.db.WithContext(ctx).
+		Where("is_active = ? AND valid_from <= ? AND valid_to >= ?", true, at, at).
+		Where("restaurant_id IS NULL OR restaurant_id = ?", restaurantID).
+		Find(&coupons).Error
+	return coupons, err
+}
+
+// Redeem records a coupon use and takes it from the global limit in one transaction. It fails
+// with ErrCouponLimitReached when the coupon or the user's allowance is used up.
+func (r *couponRepository) Redeem(ctx context.Context, redemption *models.CouponRedemption, perUserLimit int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if perUserLimit > 0 {
+			var used int64
+			if err := tx.Model(&models.CouponRedemption{}).
+				Where("coupon_id = ? AND user_id = ? AND status = ?", redemption.CouponID, redemption.UserID, "redeemed").
+				Count(&used).Error; err != nil {
+				return err
+			}
+			if used >= int64(perUserLimit) {
+				return ErrCouponLimitReached
+			}
+		}
+
+		result := tx.Model(&models.Coupon{}).
+			Where("id = ? AND (usage_limit <= 0 OR used_count < usage_limit)", redemption.CouponID).
+			Update("used_count", gorm.Expr("used_count + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCouponLimitReached
+		}
+
+		return tx.Create(redemption).Error
+	})
+}
+
+// ReleaseRedemption gives back the coupon use of a cancelled order. Orders without a
+// redemption, or already released, are left alone.
+func (r *couponRepository) ReleaseRedemption(ctx context.Context, orderID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var redemption models.CouponRedemption
+		err := tx.Where("order_id = ? AND status = ?", orderID, "redeemed").First(&redemption).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&redemption).Updates(map[string]interface{}{
+			"status":     "released",
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Coupon{}).
+			Where("id = ? AND used_count > 0", redemption.CouponID).
+			Update("used_count", gorm.Expr("used_count - 1")).Error
+	})
+}
+
+// CountUserRedemptions returns how many times the user has redeemed each of the coupons
+func (r *couponRepository) CountUserRedemptions(ctx context.Context, userID uuid.UUID, couponIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	counts := make(map[uuid.UUID]int64, len(couponIDs))
+	if len(couponIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		CouponID uuid.UUID
+		Count    int64
+	}
+	err := r.db.WithContext(ctx).Model(&models.CouponRedemption{}).
+		Select("coupon_id, COUNT(*) AS count").
+		Where("user_id = ? AND status = ? AND coupon_id IN ?", userID, "redeemed", couponIDs).
+		Group("coupon_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.CouponID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *couponRepository) GetRedemptions(ctx context.Context, couponID uuid.UUID, offset, limit int) ([]models.CouponRedemption, int64, error) {
+	var redemptions []models.CouponRedemption
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.CouponRedemption{}).Where("coupon_id = ?", couponID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&redemptions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return redemptions, total, nil
+}
+
+// Pricing Rule Repository
+type pricingRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewPricingRuleRepository(db *gorm.DB) PricingRuleRepository {
+	return &pricingRuleRepository{db: db}
+}
+
+func (r *pricingRuleRepository) Create(ctx context.Context, rule *models.PricingRule) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+func (r *pricingRuleRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.PricingRule, error) {
+	var rule models.PricingRule
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&rule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *pricingRuleRepository) Update(ctx context.Context, rule *models.PricingRule) error {
+	return r.db.WithContext(ctx).Save(rule).Error
+}
+
+func (r *pricingRuleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.PricingRule{}, id).Error
+}
+
+func (r *pricingRuleRepository) List(ctx context.Context, restaurantID *uuid.UUID, ruleType string) ([]models.PricingRule, error) {
+	var rules []models.PricingRule
+	query := r.db.WithContext(ctx)
+	if restaurantID != nil {
+		query = query.Where("restaurant_id = ?", *restaurantID)
+	}
+	if ruleType != "" {
+		query = query.Where("rule_type = ?", ruleType)
+	}
+	err := query.Order("created_at DESC").Find(&rules).Error
+	return rules, err
+}
+
+func (r *pricingRuleRepository) GetActiveForRestaurant(ctx context.Context, restaurantID uuid.UUID, at time.Time) ([]models.PricingRule, error) {
+	var rules []models.PricingRule
+	err := r.db.WithContext(ctx).
+		Where("is_active = ?", true).
+		Where("valid_from IS NULL OR valid_from <= ?", at).
+		Where("valid_until IS NULL OR valid_until > ?", at).
+		Where("restaurant_id IS NULL OR restaurant_id = ?", restaurantID).
+		Order("created_at").
+		Find(&rules).Error
+	return rules, err
+}
+
+// Invoice Repository
+type invoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository(db *gorm.DB) InvoiceRepository {
+	return &invoiceRepository{db: db}
+}
+
+func (r *invoiceRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).First(&invoice).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// Issue takes the restaurant's next invoice number for the financial year, has build produce
+// the invoice with it and saves it, all in one transaction so numbers are never skipped. The
+// order row stays locked meanwhile, so an order gets a single invoice however many requests
+// race; an existing invoice is returned with false.
+func (r *invoiceRepository) Issue(ctx context.Context, orderID, restaurantID uuid.UUID, financialYear string, build func(number int64) (*models.Invoice, error)) (*models.Invoice, bool, error) {
+	var invoice *models.Invoice
+	issued := false
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", orderID).First(&order).Error; err != nil {
+			return err
+		}
+
+		var existing models.Invoice
+		err := tx.Where("order_id = ?", orderID).First(&existing).Error
+		if err == nil {
+			invoice = &existing
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var counter models.InvoiceCounter
+		err = tx.Raw(`INSERT INTO invoice_counters (restaurant_id, financial_year, last_number) VALUES (?, ?, 1)
+			ON CONFLICT (restaurant_id, financial_year) DO UPDATE SET last_number = invoice_counters.last_number + 1
+			RETURNING last_number`, restaurantID, financialYear).Scan(&counter).Error
+		if err != nil {
+			return err
+		}
+
+		invoice, err = build(counter.LastNumber)
+		if err != nil {
+			return err
+		}
+		issued = true
+		return tx.Create(invoice).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return invoice, issued, nil
+}
+
+func (r *invoiceRepository) MarkEmailed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Invoice{}).Where("id = ?", id).Update("emailed_at", at).Error
+}
+
+// Refund repository implementation
+type refundRepository struct {
+	db *gorm.DB
+}
+
+func NewRefundRepository(db *gorm.DB) RefundRepository {
+	return &refundRepository{db: db}
+}
+
+func (r *refundRepository) Create(ctx context.Context, refund *models.Refund) error {
+	return r.db.WithContext(ctx).Create(refund).Error
+}
+
+func (r *refundRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.WithContext(ctx).
+		Preload("Order").
+		Preload("Payment").
+		Preload("User").
+		Where("id = ?", id).First(&refund).Error
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *refundRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Refund, error) {
 	var refund models.Refund
 	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).First(&refund).Error
 	if err != nil {
 		return nil, err
 	}
-	return &refund, nil
+	return &refund, nil
+}
+
+func (r *refundRepository) Update(ctx context.Context, refund *models.Refund) error {
+	return r.db.WithContext(ctx).Save(refund).Error
+}
+
+func (r *refundRepository) GetByUserIDWithFilters(ctx context.Context, userID uuid.UUID, offset, limit int, status string) ([]models.Refund, int64, error) {
+	var refunds []models.Refund
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Refund{}).Where("user_id = ?", userID)
+
+	// Apply status filter
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated results with preloaded relations
+	if err := query.Preload("Order").Preload("Payment").
+		Offset(offset).Limit(limit).Find(&refunds).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return refunds, total, nil
+}
+
+// Support ticket repository implementation
+type supportTicketRepository struct {
+	db *gorm.DB
+}
+
+func NewSupportTicketRepository(db *gorm.DB) SupportTicketRepository {
+	return &supportTicketRepository{db: db}
+}
+
+func (r *supportTicketRepository) Create(ctx context.Context, ticket *models.SupportTicket, message *models.SupportTicketMessage, events []models.OutboxEvent) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(ticket)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		created = true
+
+		message.TicketID = ticket.ID
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return tx.Create(&events).Error
+	})
+	return created, err
+}
+
+func (r *supportTicketRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.SupportTicket, error) {
+	var ticket models.SupportTicket
+	err := r.db.WithContext(ctx).
+		Preload("Messages", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Where("id = ?", id).First(&ticket).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+func (r *supportTicketRepository) Save(ctx context.Context, ticket *models.SupportTicket, message *models.SupportTicketMessage, events []models.OutboxEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(ticket).Error; err != nil {
+			return err
+		}
+		if message != nil {
+			message.TicketID = ticket.ID
+			if err := tx.Create(message).Error; err != nil {
+				return err
+			}
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return tx.Create(&events).Error
+	})
+}
+
+func (r *supportTicketRepository) List(ctx context.Context, filter SupportTicketFilter, offset, limit int) ([]models.SupportTicket, int64, error) {
+	var tickets []models.SupportTicket
+	var total int64
+
+	query := filter.apply(r.db.WithContext(ctx).Model(&models.SupportTicket{}))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("last_message_at DESC").Offset(offset).Limit(limit).Find(&tickets).Error
+	return tickets, total, err
+}
+
+func (r *supportTicketRepository) HasAttachment(ctx context.Context, mediaID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.SupportTicketMessage{}).
+		Where("attachments @> jsonb_build_array(?::text)", mediaID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *supportTicketRepository) GetOpenOrderIDs(ctx context.Context) ([]uuid.UUID, error) {
+	var orderIDs []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&models.SupportTicket{}).
+		Where("order_id IS NOT NULL AND status IN ?", []string{"open", "awaiting_customer"}).
+		Distinct().Pluck("order_id", &orderIDs).Error
+	return orderIDs, err
+}
+
+func (f SupportTicketFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", f.UserID)
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.Priority != "" {
+		query = query.Where("priority = ?", f.Priority)
+	}
+	if f.IssueType != "" {
+		query = query.Where("issue_type = ?", f.IssueType)
+	}
+	if f.AssignedTo != uuid.Nil {
+		query = query.Where("assigned_to = ?", f.AssignedTo)
+	}
+	if f.Unassigned {
+		query = query.Where("assigned_to IS NULL")
+	}
+	if !f.BreachedAt.IsZero() {
+		query = query.Where("resolved_at IS NULL AND status IN ?", []string{"open", "awaiting_customer"}).
+			Where("(first_responded_at IS NULL AND first_response_due_at < ?) OR resolution_due_at < ?", f.BreachedAt, f.BreachedAt)
+	}
+	return query
+}
+
+// Address repository implementation
+type addressRepository struct {
+	db *gorm.DB
+}
+
+func NewAddressRepository(db *gorm.DB) AddressRepository {
+	return &addressRepository{db: db}
+}
+
+func (r *addressRepository) Create(ctx context.Context, address *models.Address) error {
+	return r.db.WithContext(ctx).Create(address).Error
+}
+
+func (r *addressRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Address, error) {
+	var address models.Address
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&address).Error
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (r *addressRepository) GetByUserID(ctx context.Context, userID uuid.UUID, offset, limit int) ([]models.Address, int64, error) {
+	var addresses []models.Address
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Address{}).Where("user_id = ?", userID)
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated results ordered by is_default desc, created_at desc
+	if err := query.Order("is_default DESC, created_at DESC").
+		Offset(offset).Limit(limit).Find(&addresses).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return addresses, total, nil
+}
+
+func (r *addressRepository) Update(ctx context.Context, address *models.Address) error {
+	return r.db.WithContext(ctx).Save(address).Error
+}
+
+func (r *addressRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Address{}, id).Error
+}
+
+func (r *addressRepository) UnsetDefaultAddresses(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.Address{}).
+		Where("user_id = ?", userID).
+		Update("is_default", false).Error
+}
+
+// Delivery Partner Repository
+type deliveryPartnerRepository struct {
+	db *gorm.DB
+}
+
+func NewDeliveryPartnerRepository(db *gorm.DB) DeliveryPartnerRepository {
+	return &deliveryPartnerRepository{db: db}
+}
+
+func (r *deliveryPartnerRepository) Create(ctx context.Context, partner *models.DeliveryPartnerCompany) error {
+	return r.db.WithContext(ctx).Create(partner).Error
+}
+
+func (r *deliveryPartnerRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DeliveryPartnerCompany, error) {
+	var partner models.DeliveryPartnerCompany
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&partner).Error
+	if err != nil {
+		return nil, err
+	}
+	return &partner, nil
+}
+
+func (r *deliveryPartnerRepository) Update(ctx context.Context, partner *models.DeliveryPartnerCompany) error {
+	return r.db.WithContext(ctx).Save(partner).Error
+}
+
+func (r *deliveryPartnerRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.DeliveryPartnerCompany{}, id).Error
+}
+
+func (r *deliveryPartnerRepository) GetAll(ctx context.Context, limit, offset int) ([]models.DeliveryPartnerCompany, error) {
+	var partners []models.DeliveryPartnerCompany
+	err := r.db.WithContext(ctx).
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).Find(&partners).Error
+	return partners, err
+}
+
+func (r *deliveryPartnerRepository) GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.DeliveryPartnerCompany, error) {
+	var partners []models.DeliveryPartnerCompany
+	err := r.db.WithContext(ctx).
+		Where("status = ?", status).
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).Find(&partners).Error
+	return partners, err
+}
+
+// Delivery Boundary Repository
+type deliveryBoundaryRepository struct {
+	db *gorm.DB
+}
+
+func NewDeliveryBoundaryRepository(db *gorm.DB) DeliveryBoundaryRepository {
+	return &deliveryBoundaryRepository{db: db}
+}
+
+func (r *deliveryBoundaryRepository) Create(ctx context.Context, boundary *models.RestaurantDeliveryLocationBoundary) error {
+	return r.db.WithContext(ctx).Create(boundary).Error
+}
+
+func (r *deliveryBoundaryRepository) Update(ctx context.Context, boundary *models.RestaurantDeliveryLocationBoundary) error {
+	return r.db.WithContext(ctx).Save(boundary).Error
+}
+
+func (r *deliveryBoundaryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.RestaurantDeliveryLocationBoundary{}, id).Error
+}
+
+func (r *deliveryBoundaryRepository) GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID) ([]models.RestaurantDeliveryLocationBoundary, error) {
+	var boundaries []models.RestaurantDeliveryLocationBoundary
+	err := r.db.WithContext(ctx).
+		Where("restaurant_id = ?", restaurantID).Find(&boundaries).Error
+	return boundaries, err
+}
+
+// GetAllActive returns the boundaries of active restaurants with the restaurant preloaded
+func (r *deliveryBoundaryRepository) GetAllActive(ctx context.Context) ([]models.RestaurantDeliveryLocationBoundary, error) {
+	var boundaries []models.RestaurantDeliveryLocationBoundary
+	err := r.db.WithContext(ctx).
+		Preload("Restaurant").
+		Joins("JOIN restaurants ON restaurants.id = restaurant_delivery_location_boundaries.restaurant_id").
+		Where("restaurants.status = ? AND restaurants.deleted_at IS NULL", "active").
+		Find(&boundaries).Error
+	return boundaries, err
+}
+
+// Restaurant Special Hours Repository
+type restaurantSpecialHoursRepository struct {
+	db *gorm.DB
+}
+
+func NewRestaurantSpecialHoursRepository(db *gorm.DB) RestaurantSpecialHoursRepository {
+	return &restaurantSpecialHoursRepository{db: db}
+}
+
+func (r *restaurantSpecialHoursRepository) Create(ctx context.Context, entry *models.RestaurantSpecialHours) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *restaurantSpecialHoursRepository) Update(ctx context.Context, entry *models.RestaurantSpecialHours) error {
+	return r.db.WithContext(ctx).Save(entry).Error
+}
+
+func (r *restaurantSpecialHoursRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.RestaurantSpecialHours{}, id).Error
+}
+
+func (r *restaurantSpecialHoursRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.RestaurantSpecialHours, error) {
+	var entry models.RestaurantSpecialHours
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *restaurantSpecialHoursRepository) GetByRestaurant(ctx context.Context, restaurantID uuid.UUID, from string) ([]models.RestaurantSpecialHours, error) {
+	var entries []models.RestaurantSpecialHours
+	err := r.db.WithContext(ctx).
+		Where("restaurant_id = ? AND end_date >= ?", restaurantID, from).
+		Order("start_date ASC, created_at ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *restaurantSpecialHoursRepository) GetCovering(ctx context.Context, date string) ([]models.RestaurantSpecialHours, error) {
+	var entries []models.RestaurantSpecialHours
+	err := r.db.WithContext(ctx).
+		Where("start_date <= ? AND end_date >= ?", date, date).
+		Find(&entries).Error
+	return entries, err
+}
+
+// Restaurant Pause Repository
+type restaurantPauseRepository struct {
+	db *gorm.DB
+}
+
+func NewRestaurantPauseRepository(db *gorm.DB) RestaurantPauseRepository {
+	return &restaurantPauseRepository{db: db}
+}
+
+func (r *restaurantPauseRepository) Create(ctx context.Context, pause *models.RestaurantPause) error {
+	return r.db.WithContext(ctx).Create(pause).Error
+}
+
+func (r *restaurantPauseRepository) Update(ctx context.Context, pause *models.RestaurantPause) error {
+	return r.db.WithContext(ctx).Save(pause).Error
+}
+
+func (r *restaurantPauseRepository) GetActive(ctx context.Context, restaurantID uuid.UUID, at time.Time) (*models.RestaurantPause, error) {
+	var pause models.RestaurantPause
+	err := r.db.WithContext(ctx).
+		Where("restaurant_id = ? AND resumed_at IS NULL AND ends_at > ?", restaurantID, at).
+		Order("started_at DESC").
+		First(&pause).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pause, nil
+}
+
+func (r *restaurantPauseRepository) GetExpired(ctx context.Context, at time.Time, limit int) ([]models.RestaurantPause, error) {
+	var pauses []models.RestaurantPause
+	err := r.db.WithContext(ctx).
+		Where("resumed_at IS NULL AND ends_at <= ?", at).
+		Order("ends_at ASC").
+		Limit(limit).
+		Find(&pauses).Error
+	return pauses, err
+}
+
+func (r *restaurantPauseRepository) List(ctx context.Context, filter RestaurantPauseFilter, offset, limit int) ([]models.RestaurantPause, int64, error) {
+	var pauses []models.RestaurantPause
+	var total int64
+
+	query := filter.apply(r.db.WithContext(ctx).Model(&models.RestaurantPause{}))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("started_at DESC").Offset(offset).Limit(limit).Find(&pauses).Error
+	return pauses, total, err
+}
+
+func (r *restaurantPauseRepository) Summarize(ctx context.Context, filter RestaurantPauseFilter) (*RestaurantPauseTotals, error) {
+	var totals RestaurantPauseTotals
+	err := filter.apply(r.db.WithContext(ctx).Model(&models.RestaurantPause{})).
+		Select("COUNT(*) AS pauses, " +
+			"COALESCE(SUM(EXTRACT(EPOCH FROM (COALESCE(resumed_at, ends_at) - started_at))), 0) / 60 AS paused_minutes").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return &totals, nil
+}
+
+func (f RestaurantPauseFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.RestaurantID != uuid.Nil {
+		query = query.Where("restaurant_id = ?", f.RestaurantID)
+	}
+	if !f.From.IsZero() {
+		query = query.Where("started_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		query = query.Where("started_at < ?", f.To)
+	}
+	return query
+}
+
+// Restaurant Delivery Partner Repository
+type restaurantDeliveryPartnerRepository struct {
+	db *gorm.DB
+}
+
+func NewRestaurantDeliveryPartnerRepository(db *gorm.DB) RestaurantDeliveryPartnerRepository {
+	return &restaurantDeliveryPartnerRepository{db: db}
+}
+
+// Create inserts a link. A zero weight or a switched-off link is written after the insert, as
+// the insert itself leaves zero values to the column defaults.
+func (r *restaurantDeliveryPartnerRepository) Create(ctx context.Context, relationship *models.RestaurantDeliveryPartners) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		weight, isActive := relationship.Weight, relationship.IsActive
+		if err := tx.Omit(clause.Associations).Create(relationship).Error; err != nil {
+			return err
+		}
+		if relationship.Weight == weight && relationship.IsActive == isActive {
+			return nil
+		}
+		relationship.Weight, relationship.IsActive = weight, isActive
+		return tx.Model(relationship).Updates(map[string]interface{}{"weight": weight, "is_active": isActive}).Error
+	})
+}
+
+func (r *restaurantDeliveryPartnerRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.RestaurantDeliveryPartners, error) {
+	var relationship models.RestaurantDeliveryPartners
+	err := r.db.WithContext(ctx).
+		Preload("Restaurant").
+		Preload("DeliveryPartnerCompany").
+		Where("id = ?", id).First(&relationship).Error
+	if err != nil {
+		return nil, err
+	}
+	return &relationship, nil
+}
+
+func (r *restaurantDeliveryPartnerRepository) GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID) ([]models.RestaurantDeliveryPartners, error) {
+	var relationships []models.RestaurantDeliveryPartners
+	err := r.db.WithContext(ctx).
+		Preload("DeliveryPartnerCompany").
+		Where("restaurant_id = ?", restaurantID).
+		Order("priority ASC").
+		Find(&relationships).Error
+	return relationships, err
+}
+
+func (r *restaurantDeliveryPartnerRepository) GetByDeliveryPartnerID(ctx context.Context, partnerID uuid.UUID) ([]models.RestaurantDeliveryPartners, error) {
+	var relationships []models.RestaurantDeliveryPartners
+	err := r.db.WithContext(ctx).
+		Preload("Restaurant").
+		Where("delivery_partner_company_id = ?", partnerID).Find(&relationships).Error
+	return relationships, err
+}
+
+func (r *restaurantDeliveryPartnerRepository) Update(ctx context.Context, relationship *models.RestaurantDeliveryPartners) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(relationship).Error
+}
+
+func (r *restaurantDeliveryPartnerRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.RestaurantDeliveryPartners{}, id).Error
+}
+
+// PartnerAPIKey Repository
+type partnerAPIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewPartnerAPIKeyRepository(db *gorm.DB) PartnerAPIKeyRepository {
+	return &partnerAPIKeyRepository{db: db}
+}
+
+func (r *partnerAPIKeyRepository) Create(ctx context.Context, key *models.PartnerAPIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *partnerAPIKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.PartnerAPIKey, error) {
+	var key models.PartnerAPIKey
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *partnerAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.PartnerAPIKey, error) {
+	var key models.PartnerAPIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *partnerAPIKeyRepository) ListByCompany(ctx context.Context, companyID uuid.UUID) ([]models.PartnerAPIKey, error) {
+	var keys []models.PartnerAPIKey
+	err := r.db.WithContext(ctx).
+		Where("delivery_partner_company_id = ?", companyID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (r *partnerAPIKeyRepository) Update(ctx context.Context, key *models.PartnerAPIKey) error {
+	return r.db.WithContext(ctx).Save(key).Error
+}
+
+func (r *partnerAPIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.PartnerAPIKey{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
+
+// Dispatch Repository
+type dispatchRepository struct {
+	db *gorm.DB
+}
+
+func NewDispatchRepository(db *gorm.DB) DispatchRepository {
+	return &dispatchRepository{db: db}
+}
+
+func (r *dispatchRepository) Create(ctx context.Context, dispatch *models.DeliveryDispatch) error {
+	return r.db.WithContext(ctx).Create(dispatch).Error
+}
+
+func (r *dispatchRepository) GetByOrder(ctx context.Context, orderID uuid.UUID) ([]models.DeliveryDispatch, error) {
+	var dispatches []models.DeliveryDispatch
+	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Order("created_at, attempt").Find(&dispatches).Error
+	return dispatches, err
+}
+
+func (r *dispatchRepository) HasBooking(ctx context.Context, orderID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.DeliveryDispatch{}).
+		Where("order_id = ? AND status = ?", orderID, "booked").
+		Count(&count).Error
+	return count > 0, err
+}
+
+// PorterDeliveryRepository implementation
+type porterDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewPorterDeliveryRepository(db *gorm.DB) PorterDeliveryRepository {
+	return &porterDeliveryRepository{db: db}
+}
+
+func (r *porterDeliveryRepository) Create(ctx context.Context, delivery *models.PorterDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+func (r *porterDeliveryRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.PorterDelivery, error) {
+	var delivery models.PorterDelivery
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *porterDeliveryRepository) GetByPorterOrderID(ctx context.Context, porterOrderID string) (*models.PorterDelivery, error) {
+	var delivery models.PorterDelivery
+	err := r.db.WithContext(ctx).Where("porter_order_id = ?", porterOrderID).First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *porterDeliveryRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]models.PorterDelivery, error) {
+	var deliveries []models.PorterDelivery
+	err := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("created_at DESC").
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *porterDeliveryRepository) GetActiveByOrderID(ctx context.Context, orderID uuid.UUID) (*models.PorterDelivery, error) {
+	var delivery models.PorterDelivery
+	err := r.db.WithContext(ctx).
+		Where("order_id = ? AND is_active = ?", orderID, true).
+		First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *porterDeliveryRepository) Update(ctx context.Context, delivery *models.PorterDelivery) error {
+	return r.db.WithContext(ctx).Save(delivery).Error
+}
+
+func (r *porterDeliveryRepository) UpdateStatus(ctx context.Context, porterOrderID string, status string, metadata map[string]interface{}) error {
+	updates := map[string]interface{}{
+		"status": status,
+	}
+
+	// Add specific fields based on status
+	if metadata != nil {
+		if partnerName, ok := metadata["partner_name"]; ok {
+			updates["partner_name"] = partnerName
+		}
+		if partnerPhone, ok := metadata["partner_phone"]; ok {
+			updates["partner_phone_number"] = partnerPhone
+		}
+		if partnerPicture, ok := metadata["partner_picture"]; ok {
+			updates["partner_picture"] = partnerPicture
+		}
+		if vehicleType, ok := metadata["vehicle_type"]; ok {
+			updates["vehicle_type"] = vehicleType
+		}
+		if vehicleNumber, ok := metadata["vehicle_number"]; ok {
+			updates["vehicle_number"] = vehicleNumber
+		}
+		if trackingURL, ok := metadata["tracking_url"]; ok {
+			updates["tracking_url"] = trackingURL
+		}
+		if estimatedTime, ok := metadata["estimated_delivery_time"]; ok {
+			updates["estimated_delivery_time"] = estimatedTime
+		}
+		if actualTime, ok := metadata["actual_delivery_time"]; ok {
+			updates["actual_delivery_time"] = actualTime
+		}
+		if pickupTime, ok := metadata["pickup_time"]; ok {
+			updates["pickup_time"] = pickupTime
+		}
+
+		// Update status history
+		updates["status_history"] = gorm.Expr("COALESCE(status_history, '[]'::jsonb) || ?",
+			models.JSONB{
+				"status":    status,
+				"timestamp": "NOW()",
+				"metadata":  metadata,
+			})
+	}
+
+	return r.db.WithContext(ctx).
+		Model(&models.PorterDelivery{}).
+		Where("porter_order_id = ?", porterOrderID).
+		Updates(updates).Error
+}
+
+func (r *porterDeliveryRepository) DeactivateOldDeliveries(ctx context.Context, orderID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Model(&models.PorterDelivery{}).
+		Where("order_id = ? AND is_active = ?", orderID, true).
+		Update("is_active", false).Error
+}
+
+// GetStale returns active deliveries in a non-final status that have not changed since updatedBefore, oldest first
+func (r *porterDeliveryRepository) GetStale(ctx context.Context, updatedBefore time.Time, limit int) ([]models.PorterDelivery, error) {
+	var deliveries []models.PorterDelivery
+	err := r.db.WithContext(ctx).
+		Where("is_active = ? AND updated_at < ?", true, updatedBefore).
+		Where("status NOT IN ?", []string{"order_end_job", "order_cancel", "delivered", "cancelled", "failed"}).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *porterDeliveryRepository) GetNeedingAttention(ctx context.Context) ([]models.PorterDelivery, error) {
+	var deliveries []models.PorterDelivery
+	err := r.db.WithContext(ctx).
+		Where("needs_attention = ?", true).
+		Order("created_at ASC").
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// OTP Repository Implementation
+type otpRepository struct {
+	db *gorm.DB
+}
+
+func NewOTPRepository(db *gorm.DB) OTPRepository {
+	return &otpRepository{db: db}
+}
+
+func (r *otpRepository) Create(ctx context.Context, otp *models.OTP) error {
+	return r.db.WithContext(ctx).Create(otp).Error
+}
+
+func (r *otpRepository) GetActiveOTP(ctx context.Context, phone string, restaurantID *uuid.UUID, purpose string) (*models.OTP, error) {
+	var otp models.OTP
+	query := r.db.WithContext(ctx).Where("phone = ? AND purpose = ? AND expires_at > ? AND is_used = false", phone, purpose, time.Now())
+
+	if restaurantID != nil {
+		query = query.Where("restaurant_id = ?", *restaurantID)
+	} else {
+		query = query.Where("restaurant_id IS NULL")
+	}
+
+	err := query.Order("created_at DESC").First(&otp).Error
+	if err != nil {
+		return nil, err
+	}
+	return &otp, nil
+}
+
+func (r *otpRepository) InvalidateOTP(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.OTP{}).Where("id = ?", id).Update("is_used", true).Error
+}
+
+func (r *otpRepository) DeleteExpiredOTPs(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.OTP{}).Error
+}
+
+func (r *otpRepository) IncrementAttempt(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.OTP{}).Where("id = ?", id).UpdateColumn("attempt_count", gorm.Expr("attempt_count + 1")).Error
+}
+
+// Feature Flag Repository
+type featureFlagRepository struct {
+	db *gorm.DB
+}
+
+func NewFeatureFlagRepository(db *gorm.DB) FeatureFlagRepository {
+	return &featureFlagRepository{db: db}
+}
+
+func (r *featureFlagRepository) GetByKey(ctx context.Context, key string) (*models.FeatureFlag, error) {
+	var flag models.FeatureFlag
+	err := r.db.WithContext(ctx).Where("key = ?", key).First(&flag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+func (r *featureFlagRepository) Save(ctx context.Context, flag *models.FeatureFlag) error {
+	return r.db.WithContext(ctx).Save(flag).Error
+}
+
+func (r *featureFlagRepository) Delete(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&models.FeatureFlag{}).Error
+}
+
+func (r *featureFlagRepository) List(ctx context.Context) ([]models.FeatureFlag, error) {
+	var flags []models.FeatureFlag
+	err := r.db.WithContext(ctx).Order("key").Find(&flags).Error
+	return flags, err
+}
+
+// Blocklist repository implementation
+type blocklistRepository struct {
+	db *gorm.DB
+}
+
+func NewBlocklistRepository(db *gorm.DB) BlocklistRepository {
+	return &blocklistRepository{db: db}
+}
+
+func (r *blocklistRepository) Create(ctx context.Context, entry *models.BlocklistEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *blocklistRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.BlocklistEntry, error) {
+	var entry models.BlocklistEntry
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *blocklistRepository) Update(ctx context.Context, entry *models.BlocklistEntry) error {
+	return r.db.WithContext(ctx).Save(entry).Error
+}
+
+func (r *blocklistRepository) GetActive(ctx context.Context, kind, value string, at time.Time) (*models.BlocklistEntry, error) {
+	var entry models.BlocklistEntry
+	err := BlocklistFilter{Kind: kind, Value: value, ActiveAt: at}.apply(r.db.WithContext(ctx)).
+		Order("created_at DESC").First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *blocklistRepository) List(ctx context.Context, filter BlocklistFilter, offset, limit int) ([]models.BlocklistEntry, int64, error) {
+	var entries []models.BlocklistEntry
+	var total int64
+
+	query := filter.apply(r.db.WithContext(ctx).Model(&models.BlocklistEntry{}))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&entries).Error
+	return entries, total, err
+}
+
+func (f BlocklistFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.Kind != "" {
+		query = query.Where("kind = ?", f.Kind)
+	}
+	if f.Value != "" {
+		query = query.Where("value = ?", f.Value)
+	}
+	if f.Source != "" {
+		query = query.Where("source = ?", f.Source)
+	}
+	if !f.ActiveAt.IsZero() {
+		query = query.Where("lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", f.ActiveAt)
+	}
+	return query
+}
+
+// Risk repository implementation
+type riskRepository struct {
+	db *gorm.DB
+}
+
+func NewRiskRepository(db *gorm.DB) RiskRepository {
+	return &riskRepository{db: db}
+}
+
+func (r *riskRepository) ListRules(ctx context.Context) ([]models.RiskRule, error) {
+	var rules []models.RiskRule
+	err := r.db.WithContext(ctx).Order("key").Find(&rules).Error
+	return rules, err
+}
+
+func (r *riskRepository) GetRule(ctx context.Context, key string) (*models.RiskRule, error) {
+	var rule models.RiskRule
+	err := r.db.WithContext(ctx).Where("key = ?", key).First(&rule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *riskRepository) SaveRule(ctx context.Context, rule *models.RiskRule) error {
+	return r.db.WithContext(ctx).Save(rule).Error
+}
+
+func (r *riskRepository) CreateAssessment(ctx context.Context, assessment *models.RiskAssessment) error {
+	return r.db.WithContext(ctx).Create(assessment).Error
+}
+
+func (r *riskRepository) GetAssessment(ctx context.Context, id uuid.UUID) (*models.RiskAssessment, error) {
+	var assessment models.RiskAssessment
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&assessment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &assessment, nil
+}
+
+func (r *riskRepository) UpdateAssessment(ctx context.Context, assessment *models.RiskAssessment) error {
+	return r.db.WithContext(ctx).Save(assessment).Error
+}
+
+func (r *riskRepository) ListAssessments(ctx context.Context, filter RiskAssessmentFilter, offset, limit int) ([]models.RiskAssessment, int64, error) {
+	var assessments []models.RiskAssessment
+	var total int64
+
+	query := filter.apply(r.db.WithContext(ctx).Model(&models.RiskAssessment{}))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&assessments).Error
+	return assessments, total, err
+}
+
+func (r *riskRepository) CountAssessments(ctx context.Context, filter RiskAssessmentFilter) (int64, error) {
+	var count int64
+	err := filter.apply(r.db.WithContext(ctx).Model(&models.RiskAssessment{})).Count(&count).Error
+	return count, err
+}
+
+func (r *riskRepository) CountCancelledOrders(ctx context.Context, userID uuid.UUID, paymentMethod string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Joins("JOIN payments ON payments.order_id = orders.id").
+		Where("orders.user_id = ? AND orders.order_status = ? AND payments.method = ? AND orders.created_at >= ?", userID, "cancelled", paymentMethod, since).
+		Distinct("orders.id").
+		Count(&count).Error
+	return count, err
+}
+
+func (r *riskRepository) CountRefunds(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	// Refunds are matched through their order; older refund rows were saved without a user
+	err := r.db.WithContext(ctx).Model(&models.Refund{}).
+		Joins("JOIN orders ON orders.id = refunds.order_id").
+		Where("orders.user_id = ? AND refunds.created_at >= ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (f RiskAssessmentFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.Event != "" {
+		query = query.Where("event = ?", f.Event)
+	}
+	if f.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", f.UserID)
+	}
+	if f.DeviceID != "" {
+		query = query.Where("device_id = ?", f.DeviceID)
+	}
+	if f.Phone != "" {
+		query = query.Where("phone = ?", f.Phone)
+	}
+	if f.Decision != "" {
+		query = query.Where("decision = ?", f.Decision)
+	}
+	if f.ReviewStatus != "" {
+		query = query.Where("review_status = ?", f.ReviewStatus)
+	}
+	if !f.Since.IsZero() {
+		query = query.Where("created_at >= ?", f.Since)
+	}
+	return query
+}
+
+// Maintenance Window Repository
+type maintenanceWindowRepository struct {
+	db *gorm.DB
+}
+
+func NewMaintenanceWindowRepository(db *gorm.DB) MaintenanceWindowRepository {
+	return &maintenanceWindowRepository{db: db}
+}
+
+func (r *maintenanceWindowRepository) Create(ctx context.Context, window *models.MaintenanceWindow) error {
+	return r.db.WithContext(ctx).Create(window).Error
+}
+
+func (r *maintenanceWindowRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.MaintenanceWindow, error) {
+	var window models.MaintenanceWindow
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&window).Error
+	if err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+func (r *maintenanceWindowRepository) Update(ctx context.Context, window *models.MaintenanceWindow) error {
+	return r.db.WithContext(ctx).Save(window).Error
+}
+
+// GetScheduled returns non-cancelled windows that have not ended yet, soonest first
+func (r *maintenanceWindowRepository) GetScheduled(ctx context.Context, endingAfter time.Time) ([]models.MaintenanceWindow, error) {
+	var windows []models.MaintenanceWindow
+	err := r.db.WithContext(ctx).
+		Where("ends_at > ? AND cancelled_at IS NULL", endingAfter).
+		Order("starts_at ASC").
+		Find(&windows).Error
+	return windows, err
+}
+
+type restaurantTableRepository struct {
+	db *gorm.DB
+}
+
+func NewRestaurantTableRepository(db *gorm.DB) RestaurantTableRepository {
+	return &restaurantTableRepository{db: db}
+}
+
+func (r *restaurantTableRepository) Create(ctx context.Context, table *models.RestaurantTable) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		isActive := table.IsActive
+		if err := tx.Create(table).Error; err != nil {
+			return err
+		}
+		if table.IsActive == isActive {
+			return nil
+		}
+		table.IsActive = isActive
+		return tx.Model(table).Update("is_active", isActive).Error
+	})
+}
+
+func (r *restaurantTableRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.RestaurantTable, error) {
+	var table models.RestaurantTable
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&table).Error
+	if err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
+
+func (r *restaurantTableRepository) GetByQRToken(ctx context.Context, token string) (*models.RestaurantTable, error) {
+	var table models.RestaurantTable
+	err := r.db.WithContext(ctx).Where("qr_token = ?", token).First(&table).Error
+	if err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
+
+func (r *restaurantTableRepository) GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID) ([]models.RestaurantTable, error) {
+	var tables []models.RestaurantTable
+	err := r.db.WithContext(ctx).Where("restaurant_id = ?", restaurantID).Order("number ASC").Find(&tables).Error
+	return tables, err
+}
+
+func (r *restaurantTableRepository) Update(ctx context.Context, table *models.RestaurantTable) error {
+	return r.db.WithContext(ctx).Save(table).Error
+}
+
+func (r *restaurantTableRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.RestaurantTable{}, "id = ?", id).Error
+}
+
+type tableTabRepository struct {
+	db *gorm.DB
+}
+
+func NewTableTabRepository(db *gorm.DB) TableTabRepository {
+	return &tableTabRepository{db: db}
+}
+
+// preloadTabRounds loads a tab's orders, oldest round first
+func preloadTabRounds(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at ASC")
+}
+
+// GetOrOpen relies on the partial unique index on open tabs: when two rounds are placed at the
+// same table at once, the insert that loses does nothing and both get the same tab
+func (r *tableTabRepository) GetOrOpen(ctx context.Context, table *models.RestaurantTable) (*models.TableTab, error) {
+	tab := &models.TableTab{
+		RestaurantID: table.RestaurantID,
+		TableID:      table.ID,
+		TableNumber:  table.Number,
+		Status:       "open",
+		OpenedAt:     time.Now(),
+	}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(tab).Error; err != nil {
+		return nil, err
+	}
+	return r.GetOpenByTableID(ctx, table.ID)
+}
+
+func (r *tableTabRepository) GetOpenByTableID(ctx context.Context, tableID uuid.UUID) (*models.TableTab, error) {
+	var tab models.TableTab
+	err := r.db.WithContext(ctx).
+		Preload("Orders", preloadTabRounds).
+		Where("table_id = ? AND status = ?", tableID, "open").
+		First(&tab).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tab, nil
+}
+
+func (r *tableTabRepository) GetOpenByRestaurantID(ctx context.Context, restaurantID uuid.UUID) ([]models.TableTab, error) {
+	var tabs []models.TableTab
+	err := r.db.WithContext(ctx).
+		Preload("Orders", preloadTabRounds).
+		Where("restaurant_id = ? AND status = ?", restaurantID, "open").
+		Order("opened_at ASC").
+		Find(&tabs).Error
+	return tabs, err
+}
+
+func (r *tableTabRepository) Close(ctx context.Context, tab *models.TableTab) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.TableTab{}).
+			Where("id = ? AND status = ?", tab.ID, "open").
+			Updates(map[string]interface{}{
+				"status":         "closed",
+				"total_amount":   tab.TotalAmount,
+				"payment_method": tab.PaymentMethod,
+				"closed_at":      tab.ClosedAt,
+				"closed_by":      tab.ClosedBy,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ? AND order_id IN (?)", "pending",
+				tx.Model(&models.Order{}).Select("id").Where("tab_id = ? AND order_status <> ?", tab.ID, "cancelled")).
+			Find(&payments).Error
+		if err != nil || len(payments) == 0 {
+			return err
+		}
+		ids := make([]uuid.UUID, len(payments))
+		for i := range payments {
+			ids[i] = payments[i].ID
+			payments[i].Status = "success"
+			payments[i].Method = tab.PaymentMethod
+		}
+		return tx.Model(&models.Payment{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{"status": "success", "method": tab.PaymentMethod}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+type cateringQuoteRepository struct {
+	db *gorm.DB
+}
+
+func NewCateringQuoteRepository(db *gorm.DB) CateringQuoteRepository {
+	return &cateringQuoteRepository{db: db}
+}
+
+func (r *cateringQuoteRepository) Create(ctx context.Context, quote *models.CateringQuote, events []models.OutboxEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(quote).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return tx.Create(&events).Error
+	})
+}
+
+func (r *cateringQuoteRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.CateringQuote, error) {
+	var quote models.CateringQuote
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&quote).Error
+	if err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
+
+func (r *cateringQuoteRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.CateringQuote, error) {
+	var quotes []models.CateringQuote
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&quotes).Error
+	return quotes, err
+}
+
+func (r *cateringQuoteRepository) GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID, status string, limit, offset int) ([]models.CateringQuote, error) {
+	var quotes []models.CateringQuote
+	query := r.db.WithContext(ctx).Where("restaurant_id = ?", restaurantID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("event_date ASC").Limit(limit).Offset(offset).Find(&quotes).Error
+	return quotes, err
+}
+
+func (r *cateringQuoteRepository) Update(ctx context.Context, quote *models.CateringQuote, events []models.OutboxEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := saveVersioned(tx, quote, &quote.Version); err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return tx.Create(&events).Error
+	})
+}
+
+type mealPlanRepository struct {
+	db *gorm.DB
+}
+
+func NewMealPlanRepository(db *gorm.DB) MealPlanRepository {
+	return &mealPlanRepository{db: db}
+}
+
+func (r *mealPlanRepository) Create(ctx context.Context, plan *models.MealPlan) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		isActive := plan.IsActive
+		if err := tx.Create(plan).Error; err != nil {
+			return err
+		}
+		if plan.IsActive == isActive {
+			return nil
+		}
+		plan.IsActive = isActive
+		return tx.Model(plan).Update("is_active", isActive).Error
+	})
+}
+
+func (r *mealPlanRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.MealPlan, error) {
+	var plan models.MealPlan
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&plan).Error
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *mealPlanRepository) GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID, activeOnly bool) ([]models.MealPlan, error) {
+	var plans []models.MealPlan
+	query := r.db.WithContext(ctx).Where("restaurant_id = ?", restaurantID)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("name ASC").Find(&plans).Error
+	return plans, err
+}
+
+func (r *mealPlanRepository) Update(ctx context.Context, plan *models.MealPlan) error {
+	return r.db.WithContext(ctx).Save(plan).Error
+}
+
+func (r *mealPlanRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.MealPlan{}, "id = ?", id).Error
+}
+
+// ErrInsufficientWalletBalance is returned when a wallet charge is more than the wallet holds
+var ErrInsufficientWalletBalance = errors.New("insufficient wallet balance")
+
+type mealPlanSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewMealPlanSubscriptionRepository(db *gorm.DB) MealPlanSubscriptionRepository {
+	return &mealPlanSubscriptionRepository{db: db}
+}
+
+func (r *mealPlanSubscriptionRepository) Create(ctx context.Context, subscription *models.MealPlanSubscription, changes MealPlanChanges) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Plan").Create(subscription).Error; err != nil {
+			return err
+		}
+		return changes.save(tx)
+	})
+}
+
+func (r *mealPlanSubscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.MealPlanSubscription, error) {
+	var subscription models.MealPlanSubscription
+	err := r.db.WithContext(ctx).Preload("Plan").Where("id = ?", id).First(&subscription).Error
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *mealPlanSubscriptionRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.MealPlanSubscription, error) {
+	var subscriptions []models.MealPlanSubscription
+	err := r.db.WithContext(ctx).
+		Preload("Plan").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *mealPlanSubscriptionRepository) GetByRestaurantID(ctx context.Context, restaurantID, planID uuid.UUID, status string, limit, offset int) ([]models.MealPlanSubscription, error) {
+	var subscriptions []models.MealPlanSubscription
+	query := r.db.WithContext(ctx).Preload("Plan").Where("restaurant_id = ?", restaurantID)
+	if planID != uuid.Nil {
+		query = query.Where("plan_id = ?", planID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *mealPlanSubscriptionRepository) GetRunning(ctx context.Context) ([]models.MealPlanSubscription, error) {
+	var subscriptions []models.MealPlanSubscription
+	err := r.db.WithContext(ctx).
+		Preload("Plan").
+		Where("status IN ?", []string{"active", "paused", "payment_due"}).
+		Order("created_at ASC").
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *mealPlanSubscriptionRepository) Update(ctx context.Context, subscription *models.MealPlanSubscription, changes MealPlanChanges) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The changes go first so a declined wallet charge fails before the version is bumped
+		if err := changes.save(tx); err != nil {
+			return err
+		}
+		return saveVersioned(tx, subscription, &subscription.Version)
+	})
+}
+
+// save writes the deliveries, bills and events, moving paid bills' money in or out of the
+// customer's wallet
+func (c MealPlanChanges) save(tx *gorm.DB) error {
+	for _, bill := range c.Bills {
+		if bill.Status != "paid" {
+			continue
+		}
+		switch {
+		case bill.Kind == "credit":
+			if err := tx.Model(&models.User{}).Where("id = ?", bill.UserID).
+				Update("wallet_balance", gorm.Expr("wallet_balance + ?", bill.Amount)).Error; err != nil {
+				return err
+			}
+		case bill.Method == "wallet":
+			result := tx.Model(&models.User{}).Where("id = ? AND wallet_balance >= ?", bill.UserID, bill.Amount).
+				Update("wallet_balance", gorm.Expr("wallet_balance - ?", bill.Amount))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrInsufficientWalletBalance
+			}
+		}
+	}
+	if len(c.Deliveries) > 0 {
+		if err := tx.Create(&c.Deliveries).Error; err != nil {
+			return err
+		}
+	}
+	if len(c.Bills) > 0 {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			UpdateAll: true,
+		}).Create(&c.Bills).Error; err != nil {
+			return err
+		}
+	}
+	if len(c.Events) > 0 {
+		return tx.Create(&c.Events).Error
+	}
+	return nil
+}
+
+func (r *mealPlanSubscriptionRepository) GetDeliveries(ctx context.Context, subscriptionID uuid.UUID, from, to string) ([]models.MealPlanDelivery, error) {
+	var deliveries []models.MealPlanDelivery
+	err := r.db.WithContext(ctx).
+		Where("subscription_id = ? AND date >= ? AND date <= ?", subscriptionID, from, to).
+		Order("date ASC").
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *mealPlanSubscriptionRepository) GetBills(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]models.MealPlanBill, error) {
+	var bills []models.MealPlanBill
+	err := r.db.WithContext(ctx).
+		Where("subscription_id = ?", subscriptionID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&bills).Error
+	return bills, err
+}
+
+func (r *mealPlanSubscriptionRepository) CreateBill(ctx context.Context, bill *models.MealPlanBill) error {
+	return r.db.WithContext(ctx).Create(bill).Error
+}
+
+func (r *mealPlanSubscriptionRepository) UpdateBill(ctx context.Context, bill *models.MealPlanBill) error {
+	return r.db.WithContext(ctx).Save(bill).Error
+}
+
+func (r *mealPlanSubscriptionRepository) GetBillByGatewayPaymentID(ctx context.Context, gatewayPaymentID string) (*models.MealPlanBill, error) {
+	var bill models.MealPlanBill
+	err := r.db.WithContext(ctx).Where("gateway_payment_id = ?", gatewayPaymentID).First(&bill).Error
+	if err != nil {
+		return nil, err
+	}
+	return &bill, nil
+}
+
+// ClaimDelivery relies on the unique index on the subscription and date: when two generator runs
+// reach the same delivery, the insert that loses does nothing
+func (r *mealPlanSubscriptionRepository) ClaimDelivery(ctx context.Context, delivery *models.MealPlanDelivery) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(delivery)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *mealPlanSubscriptionRepository) ReleaseDelivery(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.MealPlanDelivery{}, "id = ?", id).Error
+}
+
+func (r *mealPlanSubscriptionRepository) SetDeliveryOrder(ctx context.Context, deliveryID, orderID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.MealPlanDelivery{}).Where("id = ?", deliveryID).Update("order_id", orderID).Error
+}
+
+func (r *mealPlanSubscriptionRepository) GetStats(ctx context.Context, restaurantID uuid.UUID, from, to string) ([]MealPlanStats, error) {
+	var plans []models.MealPlan
+	if err := r.db.WithContext(ctx).Where("restaurant_id = ?", restaurantID).Order("name ASC").Find(&plans).Error; err != nil {
+		return nil, err
+	}
+	stats := make([]MealPlanStats, len(plans))
+	byPlan := make(map[uuid.UUID]*MealPlanStats, len(plans))
+	for i, plan := range plans {
+		stats[i].PlanID = plan.ID
+		stats[i].Name = plan.Name
+		byPlan[plan.ID] = &stats[i]
+	}
+
+	var subscribers []struct {
+		PlanID uuid.UUID
+		Status string
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Model(&models.MealPlanSubscription{}).
+		Select("plan_id, status, COUNT(*) AS count").
+		Where("restaurant_id = ?", restaurantID).
+		Group("plan_id, status").
+		Scan(&subscribers).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range subscribers {
+		plan, ok := byPlan[row.PlanID]
+		if !ok {
+			continue
+		}
+		switch row.Status {
+		case "active":
+			plan.Active = row.Count
+		case "paused":
+			plan.Paused = row.Count
+		case "payment_due":
+			plan.PaymentDue = row.Count
+		case "cancelled":
+			plan.Cancelled = row.Count
+		case "ended":
+			plan.Ended = row.Count
+		}
+	}
+
+	var deliveries []struct {
+		PlanID uuid.UUID
+		Status string
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Model(&models.MealPlanDelivery{}).
+		Select("plan_id, status, COUNT(*) AS count").
+		Where("restaurant_id = ? AND date >= ? AND date <= ?", restaurantID, from, to).
+		Group("plan_id, status").
+		Scan(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range deliveries {
+		plan, ok := byPlan[row.PlanID]
+		if !ok {
+			continue
+		}
+		switch row.Status {
+		case "ordered":
+			plan.Ordered = row.Count
+		case "skipped":
+			plan.Skipped = row.Count
+		}
+	}
+
+	var bills []struct {
+		PlanID uuid.UUID
+		Kind   string
+		Status string
+		Amount models.Money
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Model(&models.MealPlanBill{}).
+		Select("plan_id, kind, status, COALESCE(SUM(amount), 0) AS amount, COUNT(*) AS count").
+		Where("restaurant_id = ? AND created_at::date >= ? AND created_at::date <= ?", restaurantID, from, to).
+		Group("plan_id, kind, status").
+		Scan(&bills).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range bills {
+		plan, ok := byPlan[row.PlanID]
+		if !ok {
+			continue
+		}
+		switch {
+		case row.Status == "failed":
+			plan.FailedBills += row.Count
+		case row.Status != "paid":
+			// pending and refunded mandate charges are not takings
+		case row.Kind == "charge":
+			plan.Charged += row.Amount
+		case row.Kind == "credit":
+			plan.Credited += row.Amount
+		}
+	}
+	return stats, nil
+}
+
+type savedPaymentMethodRepository struct {
+	db *gorm.DB
+}
+
+func NewSavedPaymentMethodRepository(db *gorm.DB) SavedPaymentMethodRepository {
+	return &savedPaymentMethodRepository{db: db}
+}
+
+func (r *savedPaymentMethodRepository) GetCustomer(ctx context.Context, userID uuid.UUID) (*models.PaymentCustomer, error) {
+	var customer models.PaymentCustomer
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&customer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *savedPaymentMethodRepository) CreateCustomer(ctx context.Context, customer *models.PaymentCustomer) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(customer).Error
+}
+
+func (r *savedPaymentMethodRepository) Save(ctx context.Context, method *models.SavedPaymentMethod) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing models.SavedPaymentMethod
+		err := tx.Where("token = ?", method.Token).First(&existing).Error
+		if err == nil {
+			// Razorpay hands out the same token when a saved card is used again
+			*method = existing
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.SavedPaymentMethod{}).Where("user_id = ?", method.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		method.IsDefault = count == 0
+		return tx.Create(method).Error
+	})
+}
+
+func (r *savedPaymentMethodRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.SavedPaymentMethod, error) {
+	var method models.SavedPaymentMethod
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&method).Error
+	if err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
+
+func (r *savedPaymentMethodRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.SavedPaymentMethod, error) {
+	var methods []models.SavedPaymentMethod
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("is_default DESC, last_used_at DESC NULLS LAST, created_at DESC").
+		Find(&methods).Error
+	return methods, err
+}
+
+func (r *savedPaymentMethodRepository) SetDefault(ctx context.Context, userID, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.SavedPaymentMethod{}).
+			Where("user_id = ? AND is_default = ?", userID, true).
+			Update("is_default", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.SavedPaymentMethod{}).
+			Where("id = ? AND user_id = ?", id, userID).
+			Update("is_default", true).Error
+	})
+}
+
+func (r *savedPaymentMethodRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.SavedPaymentMethod{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
+
+func (r *savedPaymentMethodRepository) Delete(ctx context.Context, method *models.SavedPaymentMethod) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.SavedPaymentMethod{}, "id = ?", method.ID).Error; err != nil {
+			return err
+		}
+		if !method.IsDefault {
+			return nil
+		}
+
+		var next models.SavedPaymentMethod
+		err := tx.Where("user_id = ?", method.UserID).
+			Order("last_used_at DESC NULLS LAST, created_at DESC").
+			First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+}
+
+type reconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) ReconciliationRepository {
+	return &reconciliationRepository{db: db}
+}
+
+func (r *reconciliationRepository) Claim(ctx context.Context, run *models.PaymentReconciliation, force bool, staleAfter time.Duration) (bool, error) {
+	claimed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(run)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			claimed = true
+			return nil
+		}
+
+		var existing models.PaymentReconciliation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("date = ?", run.Date).First(&existing).Error; err != nil {
+			return err
+		}
+		// Statuses as in services.ReconciliationRunning and ReconciliationCompleted
+		if existing.Status == "running" && time.Since(existing.StartedAt) < staleAfter {
+			return nil
+		}
+		if existing.Status == "completed" && !force {
+			return nil
+		}
+
+		if err := tx.Where("reconciliation_id = ?", existing.ID).Delete(&models.ReconciliationMismatch{}).Error; err != nil {
+			return err
+		}
+		run.ID = existing.ID
+		if err := tx.Save(run).Error; err != nil {
+			return err
+		}
+		claimed = true
+		return nil
+	})
+	return claimed, err
+}
+
+func (r *reconciliationRepository) Complete(ctx context.Context, run *models.PaymentReconciliation, mismatches []models.ReconciliationMismatch) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(mismatches) > 0 {
+			if err := tx.CreateInBatches(mismatches, 500).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(run).Error
+	})
+}
+
+func (r *reconciliationRepository) GetByDate(ctx context.Context, date string) (*models.PaymentReconciliation, error) {
+	var run models.PaymentReconciliation
+	err := r.db.WithContext(ctx).Where("date = ?", date).First(&run).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *reconciliationRepository) List(ctx context.Context, offset, limit int) ([]models.PaymentReconciliation, int64, error) {
+	var runs []models.PaymentReconciliation
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.PaymentReconciliation{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("date DESC").Offset(offset).Limit(limit).Find(&runs).Error
+	return runs, total, err
+}
+
+func (r *reconciliationRepository) GetMismatches(ctx context.Context, reconciliationID uuid.UUID, kind string) ([]models.ReconciliationMismatch, error) {
+	var mismatches []models.ReconciliationMismatch
+	db := r.db.WithContext(ctx).Where("reconciliation_id = ?", reconciliationID)
+	if kind != "" {
+		db = db.Where("kind = ?", kind)
+	}
+	err := db.Order("kind, created_at").Find(&mismatches).Error
+	return mismatches, err
+}
+
+var ErrUnbalancedEntry = errors.New("journal entry debits and credits do not balance")
+
+type ledgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+func (r *ledgerRepository) GetOrCreateAccount(ctx context.Context, account *models.LedgerAccount) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoNothing: true}).Create(account).Error; err != nil {
+		return err
+	}
+	return db.Where("code = ?", account.Code).First(account).Error
+}
+
+func (r *ledgerRepository) GetAccountByID(ctx context.Context, id uuid.UUID) (*models.LedgerAccount, error) {
+	var account models.LedgerAccount
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *ledgerRepository) GetAccountsByIDs(ctx context.Context, ids []uuid.UUID) ([]models.LedgerAccount, error) {
+	var accounts []models.LedgerAccount
+	if len(ids) == 0 {
+		return accounts, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("code ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *ledgerRepository) ListAccounts(ctx context.Context, kind string, offset, limit int) ([]models.LedgerAccount, int64, error) {
+	var accounts []models.LedgerAccount
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.LedgerAccount{})
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("code ASC").Offset(offset).Limit(limit).Find(&accounts).Error
+	return accounts, total, err
+}
+
+func (r *ledgerRepository) Post(ctx context.Context, entry *models.JournalEntry) (bool, error) {
+	var debits, credits models.Money
+	for _, line := range entry.Lines {
+		if line.Debit < 0 || line.Credit < 0 {
+			return false, ErrUnbalancedEntry
+		}
+		debits += line.Debit
+		credits += line.Credit
+	}
+	if len(entry.Lines) < 2 || debits != credits || debits <= 0 {
+		return false, ErrUnbalancedEntry
+	}
+
+	posted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		lines := entry.Lines
+		result := tx.Omit("Lines").Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "reference"}}, DoNothing: true}).Create(entry)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		for i := range lines {
+			lines[i].EntryID = entry.ID
+		}
+		if err := tx.Omit("Entry", "Account").Create(&lines).Error; err != nil {
+			return err
+		}
+		posted = true
+		return nil
+	})
+	return posted, err
+}
+
+func (r *ledgerRepository) GetEntryByID(ctx context.Context, id uuid.UUID) (*models.JournalEntry, error) {
+	var entry models.JournalEntry
+	err := r.db.WithContext(ctx).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("debit DESC") }).
+		Preload("Lines.Account").
+		Where("id = ?", id).First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
 }
 
-func (r *refundRepository) Update(ctx context.Context, refund *models.Refund) error {
-	return r.db.WithContext(ctx).Save(refund).Error
+func (r *ledgerRepository) GetLines(ctx context.Context, accountID uuid.UUID, offset, limit int) ([]models.JournalLine, int64, error) {
+	var lines []models.JournalLine
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.JournalLine{}).Where("journal_lines.account_id = ?", accountID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Joins("JOIN journal_entries ON journal_entries.id = journal_lines.entry_id").
+		Preload("Entry").
+		Order("journal_entries.posted_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&lines).Error
+	return lines, total, err
+}
+
+func (r *ledgerRepository) GetTotals(ctx context.Context, accountIDs []uuid.UUID, asOf time.Time) ([]LedgerTotals, error) {
+	var totals []LedgerTotals
+	query := r.db.WithContext(ctx).Model(&models.JournalLine{}).
+		Select("journal_lines.account_id, COALESCE(SUM(journal_lines.debit), 0) AS debits, COALESCE(SUM(journal_lines.credit), 0) AS credits").
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.entry_id").
+		Where("journal_entries.posted_at < ?", asOf)
+	if len(accountIDs) > 0 {
+		query = query.Where("journal_lines.account_id IN ?", accountIDs)
+	}
+	err := query.Group("journal_lines.account_id").Scan(&totals).Error
+	return totals, err
+}
+
+type riderRepository struct {
+	db *gorm.DB
+}
+
+func NewRiderRepository(db *gorm.DB) RiderRepository {
+	return &riderRepository{db: db}
+}
+
+func (r *riderRepository) Create(ctx context.Context, rider *models.Rider) error {
+	return r.db.WithContext(ctx).Create(rider).Error
+}
+
+func (r *riderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Rider, error) {
+	var rider models.Rider
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&rider).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rider, nil
+}
+
+func (r *riderRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Rider, error) {
+	var rider models.Rider
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&rider).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rider, nil
+}
+
+func (r *riderRepository) GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID) ([]models.Rider, error) {
+	var riders []models.Rider
+	err := r.db.WithContext(ctx).Where("restaurant_id = ?", restaurantID).Order("name ASC").Find(&riders).Error
+	return riders, err
+}
+
+// GetAvailableByRestaurantID returns idle riders, longest idle first so jobs are spread evenly
+func (r *riderRepository) GetAvailableByRestaurantID(ctx context.Context, restaurantID uuid.UUID) ([]models.Rider, error) {
+	var riders []models.Rider
+	err := r.db.WithContext(ctx).
+		Where("restaurant_id = ? AND status = ? AND is_active = ?", restaurantID, "available", true).
+		Order("updated_at ASC").
+		Find(&riders).Error
+	return riders, err
+}
+
+func (r *riderRepository) Update(ctx context.Context, rider *models.Rider) error {
+	return r.db.WithContext(ctx).Save(rider).Error
+}
+
+type riderAssignmentRepository struct {
+	db *gorm.DB
+}
+
+func NewRiderAssignmentRepository(db *gorm.DB) RiderAssignmentRepository {
+	return &riderAssignmentRepository{db: db}
+}
+
+// Assignment statuses that still need rider action
+var activeRiderAssignmentStatuses = []string{"assigned", "accepted", "picked_up"}
+
+func (r *riderAssignmentRepository) Create(ctx context.Context, assignment *models.RiderAssignment) error {
+	return r.db.WithContext(ctx).Create(assignment).Error
+}
+
+func (r *riderAssignmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.RiderAssignment, error) {
+	var assignment models.RiderAssignment
+	err := r.db.WithContext(ctx).Preload("Rider").Where("id = ?", id).First(&assignment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+func (r *riderAssignmentRepository) GetActiveByOrderID(ctx context.Context, orderID uuid.UUID) (*models.RiderAssignment, error) {
+	var assignment models.RiderAssignment
+	err := r.db.WithContext(ctx).
+		Preload("Rider").
+		Where("order_id = ? AND status IN ?", orderID, activeRiderAssignmentStatuses).
+		Order("created_at DESC").
+		First(&assignment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+func (r *riderAssignmentRepository) GetActiveByRiderID(ctx context.Context, riderID uuid.UUID) ([]models.RiderAssignment, error) {
+	var assignments []models.RiderAssignment
+	err := r.db.WithContext(ctx).
+		Where("rider_id = ? AND status IN ?", riderID, activeRiderAssignmentStatuses).
+		Order("assigned_at ASC").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+func (r *riderAssignmentRepository) GetByRiderID(ctx context.Context, riderID uuid.UUID, limit int) ([]models.RiderAssignment, error) {
+	var assignments []models.RiderAssignment
+	err := r.db.WithContext(ctx).
+		Where("rider_id = ?", riderID).
+		Order("assigned_at DESC").
+		Limit(limit).
+		Find(&assignments).Error
+	return assignments, err
+}
+
+func (r *riderAssignmentRepository) Update(ctx context.Context, assignment *models.RiderAssignment) error {
+	return r.db.WithContext(ctx).Omit("Rider").Save(assignment).Error
+}
+
+type taxConfigRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxConfigRepository(db *gorm.DB) TaxConfigRepository {
+	return &taxConfigRepository{db: db}
+}
+
+func (r *taxConfigRepository) Create(ctx context.Context, config *models.RestaurantTaxConfig) error {
+	return r.db.WithContext(ctx).Create(config).Error
+}
+
+func (r *taxConfigRepository) GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID) (*models.RestaurantTaxConfig, error) {
+	var config models.RestaurantTaxConfig
+	err := r.db.WithContext(ctx).Where("restaurant_id = ?", restaurantID).First(&config).Error
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (r *taxConfigRepository) Update(ctx context.Context, config *models.RestaurantTaxConfig) error {
+	return r.db.WithContext(ctx).Save(config).Error
+}
+
+// Admin Repository
+type adminRepository struct {
+	db *gorm.DB
+}
+
+func NewAdminRepository(db *gorm.DB) AdminRepository {
+	return &adminRepository{db: db}
+}
+
+func (r *adminRepository) Create(ctx context.Context, admin *models.AdminUser) error {
+	return r.db.WithContext(ctx).Create(admin).Error
+}
+
+func (r *adminRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AdminUser, error) {
+	var admin models.AdminUser
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&admin).Error
+	if err != nil {
+		return nil, err
+	}
+	return &admin, nil
+}
+
+func (r *adminRepository) GetByEmail(ctx context.Context, email string) (*models.AdminUser, error) {
+	var admin models.AdminUser
+	err := r.db.WithContext(ctx).Where("LOWER(email) = LOWER(?)", email).First(&admin).Error
+	if err != nil {
+		return nil, err
+	}
+	return &admin, nil
+}
+
+func (r *adminRepository) Update(ctx context.Context, admin *models.AdminUser) error {
+	return r.db.WithContext(ctx).Save(admin).Error
+}
+
+func (r *adminRepository) List(ctx context.Context, limit, offset int) ([]models.AdminUser, error) {
+	var admins []models.AdminUser
+	err := r.db.WithContext(ctx).
+		Order("created_at ASC").
+		Limit(limit).Offset(offset).Find(&admins).Error
+	return admins, err
+}
+
+func (r *adminRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.AdminUser{}).Count(&count).Error
+	return count, err
+}
+
+// Dashboard Repository
+
+// Orders in these statuses never turned into sales and are left out of GMV
+var nonSaleOrderStatuses = []string{"pending_payment", "payment_failed", "cancelled"}
+
+type dashboardRepository struct {
+	db *gorm.DB
+}
+
+func NewDashboardRepository(db *gorm.DB) DashboardRepository {
+	return &dashboardRepository{db: db}
+}
+
+func (r *dashboardRepository) CountOrdersByStatus(ctx context.Context, from, to time.Time) ([]OrderStatusCount, error) {
+	var counts []OrderStatusCount
+	err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Select("order_status AS status, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("order_status").
+		Order("count DESC").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *dashboardRepository) GetDailyGMV(ctx context.Context, from, to time.Time) ([]DailyGMV, error) {
+	var days []DailyGMV
+	err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Select("TO_CHAR(DATE(created_at), 'YYYY-MM-DD') AS day, COUNT(*) AS orders, COALESCE(SUM(total_amount), 0) AS gmv").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Where("order_status NOT IN ?", nonSaleOrderStatuses).
+		Group("DATE(created_at)").
+		Order("DATE(created_at) ASC").
+		Scan(&days).Error
+	return days, err
+}
+
+// GetTopRestaurants ranks restaurants by GMV. Commission is worked out per order from the
+// commission version active when it was placed, on the pre-tax item and packaging value.
+func (r *dashboardRepository) GetTopRestaurants(ctx context.Context, from, to time.Time, limit int, defaultCommissionPercent float64) ([]RestaurantSales, error) {
+	var restaurants []RestaurantSales
+	err := r.db.WithContext(ctx).Table("orders").
+		Select(`orders.restaurant_id AS restaurant_id, restaurants.name AS restaurant_name, COUNT(*) AS orders,
+			COALESCE(SUM(orders.total_amount), 0) AS gmv,
+			ROUND(COALESCE(SUM(CASE WHEN commission.commission_type = 'flat' THEN commission.commission_value
+				ELSE COALESCE((orders.tax_details->>'items_taxable_value')::numeric + COALESCE((orders.tax_details->>'packaging_fee')::numeric, 0),
+					(orders.total_amount - orders.delivery_fee)::numeric) * COALESCE(commission.commission_value, ?) / 100 END), 0), 2) AS commission`,
+			defaultCommissionPercent).
+		Joins("JOIN restaurants ON restaurants.id = orders.restaurant_id").
+		Joins(`LEFT JOIN LATERAL (
+			SELECT commission_type, commission_value FROM commission_to_restaurants
+			WHERE commission_to_restaurants.restaurant_id = orders.restaurant_id
+				AND commission_to_restaurants.effective_from <= orders.created_at
+				AND (commission_to_restaurants.effective_to IS NULL OR commission_to_restaurants.effective_to > orders.created_at)
+			ORDER BY commission_to_restaurants.effective_from DESC LIMIT 1
+		) commission ON true`).
+		Where("orders.created_at >= ? AND orders.created_at < ?", from, to).
+		Where("orders.order_status NOT IN ? AND orders.deleted_at IS NULL", nonSaleOrderStatuses).
+		Group("orders.restaurant_id, restaurants.name").
+		Order("gmv DESC").
+		Limit(limit).
+		Scan(&restaurants).Error
+	return restaurants, err
+}
+
+func (r *dashboardRepository) GetRefundTotals(ctx context.Context, from, to time.Time) (*RefundTotals, error) {
+	var totals RefundTotals
+
+	err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Select("COUNT(*) AS gmv_orders, COALESCE(SUM(total_amount), 0) AS gmv").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Where("order_status NOT IN ?", nonSaleOrderStatuses).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Refunds are attributed to the range in which the order was placed
+	var refunds RefundTotals
+	err = r.db.WithContext(ctx).Table("refunds").
+		Select("COUNT(DISTINCT refunds.order_id) AS refunded_orders, COALESCE(SUM(refunds.amount), 0) AS refunded_amount").
+		Joins("JOIN orders ON orders.id = refunds.order_id AND orders.deleted_at IS NULL").
+		Where("orders.created_at >= ? AND orders.created_at < ?", from, to).
+		Where("refunds.status IN ?", []string{"approved", "processed"}).
+		Scan(&refunds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	totals.RefundedOrders = refunds.RefundedOrders
+	totals.RefundedAmount = refunds.RefundedAmount
+	return &totals, nil
+}
+
+func (r *dashboardRepository) GetUserActivity(ctx context.Context, from, to time.Time) (*UserActivityCounts, error) {
+	var counts UserActivityCounts
+
+	err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Distinct("user_id").
+		Count(&counts.OrderingUsers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.WithContext(ctx).Model(&models.User{}).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Count(&counts.NewUsers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.WithContext(ctx).Model(&models.User{}).
+		Where("status = ?", "active").
+		Count(&counts.ActiveUsers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &counts, nil
+}
+
+// GetCartActivity counts the carts created in the range by what became of them. A recovered
+// cart keeps its abandoned_at, so it counts as both abandoned and recovered.
+func (r *dashboardRepository) GetCartActivity(ctx context.Context, from, to time.Time) (*CartActivityCounts, error) {
+	var counts CartActivityCounts
+
+	err := r.db.WithContext(ctx).Model(&models.Cart{}).
+		Select(`COUNT(*) AS created,
+			COUNT(*) FILTER (WHERE abandoned_at IS NOT NULL) AS abandoned,
+			COUNT(*) FILTER (WHERE abandoned_at IS NOT NULL AND status <> 'abandoned') AS recovered,
+			COUNT(*) FILTER (WHERE status IN ('ordered', 'completed')) AS ordered`).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return &counts, nil
+}
+
+// Audit Log Repository
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *auditLogRepository) GetByEntity(ctx context.Context, entityType, entityID string, limit int) ([]models.AuditLog, error) {
+	var entries []models.AuditLog
+	err := r.db.WithContext(ctx).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Order("timestamp DESC").
+		Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+func (r *auditLogRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AuditLog, error) {
+	var entry models.AuditLog
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *auditLogRepository) List(ctx context.Context, filter AuditLogFilter, offset, limit int) ([]models.AuditLog, int64, error) {
+	var entries []models.AuditLog
+	var total int64
+
+	query := filter.apply(r.db.WithContext(ctx).Model(&models.AuditLog{}))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("timestamp DESC").Offset(offset).Limit(limit).Find(&entries).Error
+	return entries, total, err
+}
+
+func (f AuditLogFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.EntityType != "" {
+		query = query.Where("entity_type = ?", f.EntityType)
+	}
+	if f.EntityID != "" {
+		query = query.Where("entity_id = ?", f.EntityID)
+	}
+	if f.Action != "" {
+		query = query.Where("action = ?", f.Action)
+	}
+	if f.PerformedBy != uuid.Nil {
+		query = query.Where("performed_by = ?", f.PerformedBy)
+	}
+	if !f.From.IsZero() {
+		query = query.Where("timestamp >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		query = query.Where("timestamp < ?", f.To)
+	}
+	return query
+}
+
+// Outbox Repository
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Create(ctx context.Context, event *models.OutboxEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *outboxRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.OutboxEvent, error) {
+	var event models.OutboxEvent
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&event).Error
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// ClaimDue locks pending events that are due, oldest first, and pushes their next attempt out by
+// the lease so relays on other instances skip them while this one publishes
+func (r *outboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", "pending", now).
+			Order("created_at ASC").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(events))
+		for i := range events {
+			ids[i] = events[i].ID
+			events[i].NextAttemptAt = now.Add(lease)
+		}
+		return tx.Model(&models.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return events, err
+}
+
+func (r *outboxRepository) Update(ctx context.Context, event *models.OutboxEvent) error {
+	return r.db.WithContext(ctx).Save(event).Error
+}
+
+func (r *outboxRepository) List(ctx context.Context, status, topic string, limit, offset int) ([]models.OutboxEvent, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.OutboxEvent{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if topic != "" {
+		query = query.Where("topic = ?", topic)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.OutboxEvent
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&events).Error
+	return events, total, err
+}
+
+func (r *outboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status = ? AND published_at < ?", "published", before).
+		Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// Webhook Repository
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(subscription).Error
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&subscription).Error
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Save(subscription).Error
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.WebhookSubscription{}).Error
+	})
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context, restaurantID uuid.UUID) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := r.db.WithContext(ctx).
+		Where("restaurant_id = ?", restaurantID).
+		Order("created_at ASC").
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *webhookRepository) GetActiveSubscriptions(ctx context.Context, restaurantID uuid.UUID, eventType string) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := r.db.WithContext(ctx).
+		Where("restaurant_id = ? AND is_active = ? AND event_types @> jsonb_build_array(?::text)", restaurantID, true, eventType).
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+// ClaimDueDeliveries locks pending deliveries that are due, oldest first, and pushes their next
+// attempt out by the lease so relays on other instances skip them while this one sends
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", "pending", now).
+			Order("created_at ASC").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(deliveries))
+		for i := range deliveries {
+			ids[i] = deliveries[i].ID
+			deliveries[i].NextAttemptAt = now.Add(lease)
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return deliveries, err
 }
 
-func (r *refundRepository) GetByUserIDWithFilters(ctx context.Context, userID uuid.UUID, offset, limit int, status string) ([]models.Refund, int64, error) {
-	var refunds []models.Refund
-	var total int64
-
-	query := r.db.WithContext(ctx).Model(&models.Refund{}).Where("user_id = ?", userID)
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Save(delivery).Error
+}
 
-	// Apply status filter
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, status string, limit, offset int) ([]models.WebhookDelivery, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
 
-	// Get total count
+	var total int64
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// Get paginated results with preloaded relations
-	if err := query.Preload("Order").Preload("Payment").
-		Offset(offset).Limit(limit).Find(&refunds).Error; err != nil {
-		return nil, 0, err
-	}
+	var deliveries []models.WebhookDelivery
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&deliveries).Error
+	return deliveries, total, err
+}
 
-	return refunds, total, nil
+func (r *webhookRepository) DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status <> ? AND created_at < ?", "pending", before).
+		Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, result.Error
 }
 
-// Address repository implementation
-type addressRepository struct {
+// AuthSession Repository
+type authSessionRepository struct {
 	db *gorm.DB
 }
 
-func NewAddressRepository(db *gorm.DB) AddressRepository {
-	return &addressRepository{db: db}
+func NewAuthSessionRepository(db *gorm.DB) AuthSessionRepository {
+	return &authSessionRepository{db: db}
 }
 
-func (r *addressRepository) Create(ctx context.Context, address *models.Address) error {
-	return r.db.WithContext(ctx).Create(address).Error
+func (r *authSessionRepository) Create(ctx context.Context, session *models.AuthSession) error {
+	return r.db.WithContext(ctx).Create(session).Error
 }
 
-func (r *addressRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Address, error) {
-	var address models.Address
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&address).Error
+func (r *authSessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AuthSession, error) {
+	var session models.AuthSession
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error
 	if err != nil {
 		return nil, err
 	}
-	return &address, nil
+	return &session, nil
 }
 
-func (r *addressRepository) GetByUserID(ctx context.Context, userID uuid.UUID, offset, limit int) ([]models.Address, int64, error) {
-	var addresses []models.Address
-	var total int64
-
-	query := r.db.WithContext(ctx).Model(&models.Address{}).Where("user_id = ?", userID)
-
-	// Get total count
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// Get paginated results ordered by is_default desc, created_at desc
-	if err := query.Order("is_default DESC, created_at DESC").
-		Offset(offset).Limit(limit).Find(&addresses).Error; err != nil {
-		return nil, 0, err
+// Rotate swaps the accepted refresh token only if the presented one is still current, so two
+// concurrent refreshes with the same token cannot both succeed. It reports whether the swap happened.
+func (r *authSessionRepository) Rotate(ctx context.Context, id uuid.UUID, oldTokenHash, newTokenHash string, expiresAt time.Time) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", id, oldTokenHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": newTokenHash,
+			"expires_at":         expiresAt,
+			"last_refreshed_at":  now,
+			"updated_at":         now,
+		})
+	if result.Error != nil {
+		return false, result.Error
 	}
-
-	return addresses, total, nil
+	return result.RowsAffected == 1, nil
 }
 
-func (r *addressRepository) Update(ctx context.Context, address *models.Address) error {
-	return r.db.WithContext(ctx).Save(address).Error
+func (r *authSessionRepository) Revoke(ctx context.Context, id uuid.UUID, reason string) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at":     now,
+			"revoked_reason": reason,
+			"updated_at":     now,
+		}).Error
 }
 
-func (r *addressRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.Address{}, id).Error
+// RevokeAllByUser revokes every live session of a user and returns their IDs
+func (r *authSessionRepository) RevokeAllByUser(ctx context.Context, userID uuid.UUID, reason string) ([]uuid.UUID, error) {
+	return r.revokeWhere(ctx, reason, "user_id = ?", userID)
 }
 
-func (r *addressRepository) UnsetDefaultAddresses(ctx context.Context, userID uuid.UUID) error {
-	return r.db.WithContext(ctx).Model(&models.Address{}).
-		Where("user_id = ?", userID).
-		Update("is_default", false).Error
+// RevokeByDevice revokes the user's live sessions on one device and returns their IDs
+func (r *authSessionRepository) RevokeByDevice(ctx context.Context, userID uuid.UUID, deviceID, reason string) ([]uuid.UUID, error) {
+	return r.revokeWhere(ctx, reason, "user_id = ? AND device_id = ?", userID, deviceID)
 }
 
-// Delivery Partner Repository
-type deliveryPartnerRepository struct {
-	db *gorm.DB
+// GetActiveByUser returns the user's sessions that are neither revoked nor expired, most recent first
+func (r *authSessionRepository) GetActiveByUser(ctx context.Context, userID uuid.UUID) ([]models.AuthSession, error) {
+	var sessions []models.AuthSession
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("created_at DESC").
+		Find(&sessions).Error
+	return sessions, err
 }
 
-func NewDeliveryPartnerRepository(db *gorm.DB) DeliveryPartnerRepository {
-	return &deliveryPartnerRepository{db: db}
-}
+func (r *authSessionRepository) revokeWhere(ctx context.Context, reason, query string, args ...interface{}) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where(query, args...).
+		Where("revoked_at IS NULL AND expires_at > ?", time.Now()).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return ids, err
+	}
 
-func (r *deliveryPartnerRepository) Create(ctx context.Context, partner *models.DeliveryPartnerCompany) error {
-	return r.db.WithContext(ctx).Create(partner).Error
+	now := time.Now()
+	err = r.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"revoked_at":     now,
+			"revoked_reason": reason,
+			"updated_at":     now,
+		}).Error
+	return ids, err
 }
 
-func (r *deliveryPartnerRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DeliveryPartnerCompany, error) {
-	var partner models.DeliveryPartnerCompany
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&partner).Error
-	if err != nil {
-		return nil, err
-	}
-	return &partner, nil
+// PasswordHistory Repository
+type passwordHistoryRepository struct {
+	db *gorm.DB
 }
 
-func (r *deliveryPartnerRepository) Update(ctx context.Context, partner *models.DeliveryPartnerCompany) error {
-	return r.db.WithContext(ctx).Save(partner).Error
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepository{db: db}
 }
 
-func (r *deliveryPartnerRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.DeliveryPartnerCompany{}, id).Error
+func (r *passwordHistoryRepository) Create(ctx context.Context, entry *models.PasswordHistory) error {
+	return r.db.WithContext(ctx).Create(entry).Error
 }
 
-func (r *deliveryPartnerRepository) GetAll(ctx context.Context, limit, offset int) ([]models.DeliveryPartnerCompany, error) {
-	var partners []models.DeliveryPartnerCompany
+// GetRecent returns the user's latest password hashes, newest first
+func (r *passwordHistoryRepository) GetRecent(ctx context.Context, userID uuid.UUID, limit int) ([]models.PasswordHistory, error) {
+	var entries []models.PasswordHistory
 	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
 		Order("created_at DESC").
-		Limit(limit).Offset(offset).Find(&partners).Error
-	return partners, err
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
 }
 
-func (r *deliveryPartnerRepository) GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.DeliveryPartnerCompany, error) {
-	var partners []models.DeliveryPartnerCompany
-	err := r.db.WithContext(ctx).
-		Where("status = ?", status).
+func (r *passwordHistoryRepository) DeleteAllButRecent(ctx context.Context, userID uuid.UUID, keep int) error {
+	recent := r.db.Model(&models.PasswordHistory{}).
+		Select("id").
+		Where("user_id = ?", userID).
 		Order("created_at DESC").
-		Limit(limit).Offset(offset).Find(&partners).Error
-	return partners, err
+		Limit(keep)
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND id NOT IN (?)", userID, recent).
+		Delete(&models.PasswordHistory{}).Error
 }
 
-// Restaurant Delivery Partner Repository
-type restaurantDeliveryPartnerRepository struct {
+// PII Repository
+type piiRepository struct {
 	db *gorm.DB
 }
 
-func NewRestaurantDeliveryPartnerRepository(db *gorm.DB) RestaurantDeliveryPartnerRepository {
-	return &restaurantDeliveryPartnerRepository{db: db}
+func NewPIIRepository(db *gorm.DB) PIIRepository {
+	return &piiRepository{db: db}
 }
 
-func (r *restaurantDeliveryPartnerRepository) Create(ctx context.Context, relationship *models.RestaurantDeliveryPartners) error {
-	return r.db.WithContext(ctx).Create(relationship).Error
-}
+const (
+	staleUsers     = "(email <> '' AND email NOT LIKE @pattern) OR (phone <> '' AND phone NOT LIKE @pattern)"
+	staleAddresses = "(address_line1 <> '' AND address_line1 NOT LIKE @pattern)" +
+		" OR (COALESCE(address_line2, '') <> '' AND address_line2 NOT LIKE @pattern)" +
+		" OR (COALESCE(formatted_address, '') <> '' AND formatted_address NOT LIKE @pattern)"
+)
 
-func (r *restaurantDeliveryPartnerRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.RestaurantDeliveryPartners, error) {
-	var relationship models.RestaurantDeliveryPartners
-	err := r.db.WithContext(ctx).
-		Preload("Restaurant").
-		Preload("DeliveryPartnerCompany").
-		Where("id = ?", id).First(&relationship).Error
-	if err != nil {
-		return nil, err
+func (r *piiRepository) CountStale(ctx context.Context, activePrefix string) (int64, int64, error) {
+	pattern := sql.Named("pattern", activePrefix+"%")
+	var users, addresses int64
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Where(staleUsers, pattern).Count(&users).Error; err != nil {
+		return 0, 0, err
 	}
-	return &relationship, nil
+	if err := r.db.WithContext(ctx).Model(&models.Address{}).Where(staleAddresses, pattern).Count(&addresses).Error; err != nil {
+		return 0, 0, err
+	}
+	return users, addresses, nil
 }
 
-func (r *restaurantDeliveryPartnerRepository) GetByRestaurantID(ctx context.Context, restaurantID uuid.UUID) ([]models.RestaurantDeliveryPartners, error) {
-	var relationships []models.RestaurantDeliveryPartners
+// ReencryptUsers saves up to limit stale users back, which seals their email and phone with the
+// active key and recomputes the blind indexes. It returns how many it rewrote.
+func (r *piiRepository) ReencryptUsers(ctx context.Context, activePrefix string, limit int) (int, error) {
+	var users []models.User
 	err := r.db.WithContext(ctx).
-		Preload("DeliveryPartnerCompany").
-		Where("restaurant_id = ?", restaurantID).Find(&relationships).Error
-	return relationships, err
+		Where(staleUsers, sql.Named("pattern", activePrefix+"%")).
+		Order("id").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return 0, err
+	}
+	for i := range users {
+		err := r.db.WithContext(ctx).Model(&users[i]).
+			Select("email", "phone", "email_hash", "phone_hash").
+			Updates(&users[i]).Error
+		if err != nil {
+			return i, fmt.Errorf("user %s: %v", users[i].ID, err)
+		}
+	}
+	return len(users), nil
 }
 
-func (r *restaurantDeliveryPartnerRepository) GetByDeliveryPartnerID(ctx context.Context, partnerID uuid.UUID) ([]models.RestaurantDeliveryPartners, error) {
-	var relationships []models.RestaurantDeliveryPartners
+// ReencryptAddresses saves up to limit stale addresses back under the active key
+func (r *piiRepository) ReencryptAddresses(ctx context.Context, activePrefix string, limit int) (int, error) {
+	var addresses []models.Address
 	err := r.db.WithContext(ctx).
-		Preload("Restaurant").
-		Where("delivery_partner_company_id = ?", partnerID).Find(&relationships).Error
-	return relationships, err
-}
-
-func (r *restaurantDeliveryPartnerRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.RestaurantDeliveryPartners{}, id).Error
+		Where(staleAddresses, sql.Named("pattern", activePrefix+"%")).
+		Order("id").
+		Limit(limit).
+		Find(&addresses).Error
+	if err != nil {
+		return 0, err
+	}
+	for i := range addresses {
+		err := r.db.WithContext(ctx).Model(&addresses[i]).
+			Select("address_line1", "address_line2", "formatted_address").
+			Updates(&addresses[i]).Error
+		if err != nil {
+			return i, fmt.Errorf("address %s: %v", addresses[i].ID, err)
+		}
+	}
+	return len(addresses), nil
 }
 
-// PorterDeliveryRepository implementation
-type porterDeliveryRepository struct {
+// UserIdentity Repository
+type userIdentityRepository struct {
 	db *gorm.DB
 }
 
-func NewPorterDeliveryRepository(db *gorm.DB) PorterDeliveryRepository {
-	return &porterDeliveryRepository{db: db}
+func NewUserIdentityRepository(db *gorm.DB) UserIdentityRepository {
+	return &userIdentityRepository{db: db}
 }
 
-func (r *porterDeliveryRepository) Create(ctx context.Context, delivery *models.PorterDelivery) error {
-	return r.db.WithContext(ctx).Create(delivery).Error
+func (r *userIdentityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
 }
 
-func (r *porterDeliveryRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.PorterDelivery, error) {
-	var delivery models.PorterDelivery
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&delivery).Error
+func (r *userIdentityRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&identity).Error
 	if err != nil {
 		return nil, err
 	}
-	return &delivery, nil
+	return &identity, nil
 }
 
-func (r *porterDeliveryRepository) GetByPorterOrderID(ctx context.Context, porterOrderID string) (*models.PorterDelivery, error) {
-	var delivery models.PorterDelivery
-	err := r.db.WithContext(ctx).Where("porter_order_id = ?", porterOrderID).First(&delivery).Error
-	if err != nil {
-		return nil, err
+// GetByProviderSubject finds the link for an external account within a restaurant, or among
+// links without a restaurant when restaurantID is nil
+func (r *userIdentityRepository) GetByProviderSubject(ctx context.Context, provider, subject string, restaurantID *uuid.UUID) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	query := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject)
+	if restaurantID != nil {
+		query = query.Where("restaurant_id = ?", *restaurantID)
+	} else {
+		query = query.Where("restaurant_id IS NULL")
 	}
-	return &delivery, nil
-}
-
-func (r *porterDeliveryRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]models.PorterDelivery, error) {
-	var deliveries []models.PorterDelivery
-	err := r.db.WithContext(ctx).
-		Where("order_id = ?", orderID).
-		Order("created_at DESC").
-		Find(&deliveries).Error
-	return deliveries, err
-}
-
-func (r *porterDeliveryRepository) GetActiveByOrderID(ctx context.Context, orderID uuid.UUID) (*models.PorterDelivery, error) {
-	var delivery models.PorterDelivery
-	err := r.db.WithContext(ctx).
-		Where("order_id = ? AND is_active = ?", orderID, true).
-		First(&delivery).Error
-	if err != nil {
+	if err := query.First(&identity).Error; err != nil {
 		return nil, err
 	}
-	return &delivery, nil
+	return &identity, nil
 }
 
-func (r *porterDeliveryRepository) Update(ctx context.Context, delivery *models.PorterDelivery) error {
-	return r.db.WithContext(ctx).Save(delivery).Error
+func (r *userIdentityRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error
+	return identities, err
 }
 
-func (r *porterDeliveryRepository) UpdateStatus(ctx context.Context, porterOrderID string, status string, metadata map[string]interface{}) error {
-	updates := map[string]interface{}{
-		"status": status,
-	}
-
-	// Add specific fields based on status
-	if metadata != nil {
-		if partnerName, ok := metadata["partner_name"]; ok {
-			updates["partner_name"] = partnerName
-		}
-		if partnerPhone, ok := metadata["partner_phone"]; ok {
-			updates["partner_phone_number"] = partnerPhone
-		}
-		if partnerPicture, ok := metadata["partner_picture"]; ok {
-			updates["partner_picture"] = partnerPicture
-		}
-		if vehicleType, ok := metadata["vehicle_type"]; ok {
-			updates["vehicle_type"] = vehicleType
-		}
-		if vehicleNumber, ok := metadata["vehicle_number"]; ok {
-			updates["vehicle_number"] = vehicleNumber
-		}
-		if trackingURL, ok := metadata["tracking_url"]; ok {
-			updates["tracking_url"] = trackingURL
-		}
-		if estimatedTime, ok := metadata["estimated_delivery_time"]; ok {
-			updates["estimated_delivery_time"] = estimatedTime
-		}
-		if actualTime, ok := metadata["actual_delivery_time"]; ok {
-			updates["actual_delivery_time"] = actualTime
-		}
-		if pickupTime, ok := metadata["pickup_time"]; ok {
-			updates["pickup_time"] = pickupTime
-		}
-
-		// Update status history
-		updates["status_history"] = gorm.Expr("COALESCE(status_history, '[]'::jsonb) || ?",
-			models.JSONB{
-				"status":    status,
-				"timestamp": "NOW()",
-				"metadata":  metadata,
-			})
-	}
-
-	return r.db.WithContext(ctx).
-		Model(&models.PorterDelivery{}).
-		Where("porter_order_id = ?", porterOrderID).
-		Updates(updates).Error
+func (r *userIdentityRepository) TouchLastLogin(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&models.UserIdentity{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_login_at": now,
+			"updated_at":    now,
+		}).Error
 }
 
-func (r *porterDeliveryRepository) DeactivateOldDeliveries(ctx context.Context, orderID uuid.UUID) error {
-	return r.db.WithContext(ctx).
-		Model(&models.PorterDelivery{}).
-		Where("order_id = ? AND is_active = ?", orderID, true).
-		Update("is_active", false).Error
+func (r *userIdentityRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.UserIdentity{}, "id = ?", id).Error
 }
 
-// OTP Repository Implementation
-type otpRepository struct {
+// SMSDelivery Repository
+type smsDeliveryRepository struct {
 	db *gorm.DB
 }
 
-func NewOTPRepository(db *gorm.DB) OTPRepository {
-	return &otpRepository{db: db}
+func NewSMSDeliveryRepository(db *gorm.DB) SMSDeliveryRepository {
+	return &smsDeliveryRepository{db: db}
 }
 
-func (r *otpRepository) Create(ctx context.Context, otp *models.OTP) error {
-	return r.db.WithContext(ctx).Create(otp).Error
+func (r *smsDeliveryRepository) Create(ctx context.Context, delivery *models.SMSDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
 }
 
-func (r *otpRepository) GetValidOTP(ctx context.Context, phone string, restaurantID uuid.UUID, otpCode string) (*models.OTP, error) {
-	var otp models.OTP
-	err := r.db.WithContext(ctx).Where(
-		"phone = ? AND restaurant_id = ? AND otp_code = ? AND expires_at > ? AND is_used = false AND attempt_count < ?",
-		phone, restaurantID, otpCode, time.Now(), 5, // Max 5 attempts
-	).First(&otp).Error
-	if err != nil {
-		return nil, err
+// UpdateStatus applies a delivery report. Once delivered, a message keeps that status even if
+// a stale intermediate report arrives afterwards.
+func (r *smsDeliveryRepository) UpdateStatus(ctx context.Context, provider, messageID, status, errorCode string, report models.JSONB, reportedAt time.Time) error {
+	updates := map[string]interface{}{
+		"status":      status,
+		"error_code":  errorCode,
+		"last_report": report,
+		"updated_at":  time.Now(),
 	}
-	return &otp, nil
+	if status == "delivered" {
+		updates["delivered_at"] = reportedAt
+	}
+	return r.db.WithContext(ctx).Model(&models.SMSDelivery{}).
+		Where("provider = ? AND provider_message_id = ? AND status <> ?", provider, messageID, "delivered").
+		Updates(updates).Error
 }
 
-func (r *otpRepository) GetValidOTPWithOptionalRestaurant(ctx context.Context, phone string, restaurantID *uuid.UUID, otpCode string) (*models.OTP, error) {
-	var otp models.OTP
-	query := r.db.WithContext(ctx).Where(
-		"phone = ? AND otp_code = ? AND expires_at > ? AND is_used = false AND attempt_count < ?",
-		phone, otpCode, time.Now(), 5, // Max 5 attempts
-	)
+type dietaryPreferenceRepository struct {
+	db *gorm.DB
+}
 
-	if restaurantID != nil {
-		query = query.Where("restaurant_id = ?", *restaurantID)
-	} else {
-		query = query.Where("restaurant_id IS NULL")
-	}
+func NewDietaryPreferenceRepository(db *gorm.DB) DietaryPreferenceRepository {
+	return &dietaryPreferenceRepository{db: db}
+}
 
-	err := query.First(&otp).Error
+func (r *dietaryPreferenceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.DietaryPreference, error) {
+	var preference models.DietaryPreference
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&preference).Error
 	if err != nil {
 		return nil, err
 	}
-	return &otp, nil
-}
-
-func (r *otpRepository) InvalidateOTP(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Model(&models.OTP{}).Where("id = ?", id).Update("is_used", true).Error
-}
-
-func (r *otpRepository) DeleteExpiredOTPs(ctx context.Context) error {
-	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.OTP{}).Error
+	return &preference, nil
 }
 
-func (r *otpRepository) IncrementAttempt(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Model(&models.OTP{}).Where("id = ?", id).UpdateColumn("attempt_count", gorm.Expr("attempt_count + 1")).Error
+func (r *dietaryPreferenceRepository) Save(ctx context.Context, preference *models.DietaryPreference) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		UpdateAll: true,
+	}).Create(preference).Error
 }
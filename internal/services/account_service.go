@@ -0,0 +1,424 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/cache"
+	"golang-food-backend/pkg/jobs"
+	"golang-food-backend/pkg/storage"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DataExportProcessing = "processing"
+	DataExportCompleted  = "completed"
+	DataExportFailed     = "failed"
+
+	// Archives are served for a day; the exports/ prefix of the bucket should carry a lifecycle
+	// rule that deletes objects after the same period
+	dataExportTTL     = 24 * time.Hour
+	dataExportTimeout = 5 * time.Minute
+	dataExportBatch   = 100
+
+	// JobDataExport builds a user's data archive in the background
+	JobDataExport = "account:data_export"
+)
+
+var ErrActiveOrders = apperr.Conflict("active_orders", "account has orders in progress. Please wait until they are delivered or cancelled")
+
+// AccountService implements the data subject rights of DPDP and GDPR: exporting everything
+// stored about a user, and erasing it. Erasure anonymizes rather than deletes records that
+// must be kept for accounting, such as orders and payments.
+type AccountService struct {
+	userRepo         repositories.UserRepository
+	addressRepo      repositories.AddressRepository
+	orderRepo        repositories.OrderRepository
+	paymentRepo      repositories.PaymentRepository
+	refundRepo       repositories.RefundRepository
+	reviewRepo       repositories.RatingReviewRepository
+	identityRepo     repositories.UserIdentityRepository
+	searchLogRepo    repositories.SearchLogRepository
+	userActivityRepo repositories.UserActivityRepository
+	auditRepo        repositories.AuditLogRepository
+	sessionService   *SessionService
+	storage          *storage.ObjectStorage
+	cache            *cache.RedisCache
+	queue            *jobs.Queue
+	policy           AccountDataPolicy
+}
+
+// AccountDataPolicy configures erasure and export. HashKey keys the pseudonyms that replace
+// user IDs and contacts, so they cannot be reversed by hashing known phone numbers.
+type AccountDataPolicy struct {
+	HashKey      string
+	ExportURLTTL time.Duration
+}
+
+func NewAccountService(
+	userRepo repositories.UserRepository,
+	addressRepo repositories.AddressRepository,
+	orderRepo repositories.OrderRepository,
+	paymentRepo repositories.PaymentRepository,
+	refundRepo repositories.RefundRepository,
+	reviewRepo repositories.RatingReviewRepository,
+	identityRepo repositories.UserIdentityRepository,
+	searchLogRepo repositories.SearchLogRepository,
+	userActivityRepo repositories.UserActivityRepository,
+	auditRepo repositories.AuditLogRepository,
+	sessionService *SessionService,
+	storage *storage.ObjectStorage,
+	cache *cache.RedisCache,
+	policy AccountDataPolicy,
+) *AccountService {
+	return &AccountService{
+		userRepo:         userRepo,
+		addressRepo:      addressRepo,
+		orderRepo:        orderRepo,
+		paymentRepo:      paymentRepo,
+		refundRepo:       refundRepo,
+		reviewRepo:       reviewRepo,
+		identityRepo:     identityRepo,
+		searchLogRepo:    searchLogRepo,
+		userActivityRepo: userActivityRepo,
+		auditRepo:        auditRepo,
+		sessionService:   sessionService,
+		storage:          storage,
+		cache:            cache,
+		policy:           policy,
+	}
+}
+
+type DeleteAccountRequest struct {
+	Reason string `json:"reason"`
+}
+
+// DataExportJob tracks the background generation of a user's data archive; clients poll it
+// until DownloadURL is set
+type DataExportJob struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	ObjectKey   string     `json:"object_key,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+	RequestedAt time.Time  `json:"requested_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+}
+
+// UserDataArchive is the JSON document handed to the user
+type UserDataArchive struct {
+	GeneratedAt    time.Time             `json:"generated_at"`
+	Profile        models.User           `json:"profile"`
+	Addresses      []models.Address      `json:"addresses"`
+	Orders         []models.Order        `json:"orders"`
+	Payments       []models.Payment      `json:"payments"`
+	Refunds        []models.Refund       `json:"refunds"`
+	Reviews        []models.RatingReview `json:"reviews"`
+	LinkedAccounts []models.UserIdentity `json:"linked_accounts"`
+	Devices        []DeviceResponse      `json:"devices"`
+}
+
+func dataExportKey(userID string) string {
+	return "data_export:" + userID
+}
+
+// pseudonymize replaces an identifier with a keyed hash: stable, so retained records of the
+// same user still group together, but not reversible
+func (s *AccountService) pseudonymize(value string) string {
+	mac := hmac.New(sha256.New, []byte(s.policy.HashKey))
+	mac.Write([]byte(value))
+	return "anon_" + hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// DeleteAccount erases the user's personal data and signs them out everywhere. Only customers
+// can delete themselves; staff accounts are tied to restaurants and are removed by the owner.
+func (s *AccountService) DeleteAccount(ctx context.Context, userID string, req *DeleteAccountRequest) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil || user.Status == "deleted" {
+		return errors.New("user not found")
+	}
+	if user.Role != "customer" {
+		return errors.New("only customer accounts can be deleted from the app. Please contact support")
+	}
+
+	hasActive, err := s.hasActiveOrders(ctx, id)
+	if err != nil {
+		return err
+	}
+	if hasActive {
+		return ErrActiveOrders
+	}
+
+	if err := s.userRepo.Anonymize(ctx, id, s.pseudonymize); err != nil {
+		return fmt.Errorf("failed to delete account: %v", err)
+	}
+
+	// The account is already gone from Postgres; Mongo failures are recorded for a manual rerun
+	// instead of failing the request
+	pseudonym := s.pseudonymize(userID)
+	var failures []string
+	if err := s.reviewRepo.AnonymizeUser(ctx, userID, pseudonym); err != nil {
+		log.Printf("Failed to anonymize reviews of user %s: %v", userID, err)
+		failures = append(failures, "reviews")
+	}
+	if err := s.searchLogRepo.AnonymizeUser(ctx, userID, pseudonym); err != nil {
+		log.Printf("Failed to anonymize search logs of user %s: %v", userID, err)
+		failures = append(failures, "search_logs")
+	}
+	if err := s.userActivityRepo.AnonymizeUser(ctx, userID, pseudonym); err != nil {
+		log.Printf("Failed to anonymize activity of user %s: %v", userID, err)
+		failures = append(failures, "user_activities")
+	}
+
+	if err := s.sessionService.RevokeAllSessions(ctx, userID); err != nil {
+		log.Printf("Failed to revoke sessions of deleted user %s: %v", userID, err)
+	}
+	s.cache.Delete(ctx, userSessionKey(userID))
+	s.discardExport(ctx, userID)
+
+	entry := &models.AuditLog{
+		EntityType:  "user",
+		EntityID:    userID,
+		Action:      "account_deleted",
+		PerformedBy: &id,
+		Metadata: models.JSONB{
+			"reason":           req.Reason,
+			"pseudonym":        pseudonym,
+			"pending_cleanups": failures,
+		},
+	}
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write audit entry for user %s: %v", userID, err)
+	}
+
+	return nil
+}
+
+func (s *AccountService) hasActiveOrders(ctx context.Context, userID uuid.UUID) (bool, error) {
+	for offset := 0; ; offset += dataExportBatch {
+		orders, err := s.orderRepo.GetByUserID(ctx, userID, dataExportBatch, offset)
+		if err != nil {
+			return false, fmt.Errorf("failed to check orders: %v", err)
+		}
+		for _, order := range orders {
+			if order.OrderStatus != "delivered" && order.OrderStatus != "cancelled" {
+				return true, nil
+			}
+		}
+		if len(orders) < dataExportBatch {
+			return false, nil
+		}
+	}
+}
+
+// GetDataExport returns the user's export, starting one in the background when there is none.
+// A finished export carries a short-lived download URL.
+func (s *AccountService) GetDataExport(ctx context.Context, userID string) (*DataExportJob, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	var job DataExportJob
+	if err := s.cache.Get(ctx, dataExportKey(userID), &job); err == nil && job.Status != DataExportFailed {
+		if job.Status == DataExportCompleted {
+			urlTTL := s.policy.ExportURLTTL
+			if remaining := time.Until(job.ExpiresAt); remaining < urlTTL {
+				urlTTL = remaining
+			}
+			job.DownloadURL = s.storage.PresignGet(job.ObjectKey, urlTTL)
+		}
+		job.ObjectKey = ""
+		return &job, nil
+	}
+
+	now := time.Now()
+	job = DataExportJob{
+		ID:          uuid.NewString(),
+		UserID:      userID,
+		Status:      DataExportProcessing,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(dataExportTTL),
+	}
+	if err := s.saveExportJob(ctx, &job); err != nil {
+		return nil, errors.New("failed to start export")
+	}
+
+	if _, err := s.queue.Enqueue(ctx, JobDataExport, job, jobs.Timeout(dataExportTimeout)); err != nil {
+		s.cache.Delete(ctx, dataExportKey(userID))
+		return nil, errors.New("failed to start export")
+	}
+
+	return &job, nil
+}
+
+// RegisterJobs registers the data export job handler and the queue exports are started on
+func (s *AccountService) RegisterJobs(queue *jobs.Queue) {
+	s.queue = queue
+	queue.Handle(JobDataExport, s.runExport)
+}
+
+// runExport builds and uploads the archive. Failures are retried by the queue; the export is
+// only marked failed once the last attempt fails.
+func (s *AccountService) runExport(ctx context.Context, queued *jobs.Job) error {
+	var job DataExportJob
+	if err := queued.Decode(&job); err != nil {
+		return err
+	}
+
+	archive, err := s.buildArchive(ctx, job.UserID)
+	if err == nil {
+		var data []byte
+		data, err = json.MarshalIndent(archive, "", "  ")
+		if err == nil {
+			// The random job ID keeps the key unguessable even if the bucket allows public reads
+			job.ObjectKey = fmt.Sprintf("exports/%s/%s.json", job.UserID, job.ID)
+			err = s.storage.Put(ctx, job.ObjectKey, "application/json", data)
+		}
+	}
+	if err != nil && queued.Attempts < queued.MaxAttempts {
+		log.Printf("Data export %s for user %s failed on attempt %d: %v", job.ID, job.UserID, queued.Attempts, err)
+		return err
+	}
+
+	now := time.Now()
+	job.CompletedAt = &now
+	job.Status = DataExportCompleted
+	if err != nil {
+		log.Printf("Data export %s for user %s failed: %v", job.ID, job.UserID, err)
+		job.Status = DataExportFailed
+		job.Error = "failed to generate export, please try again"
+		job.ObjectKey = ""
+	}
+	if saveErr := s.saveExportJob(ctx, &job); saveErr != nil {
+		log.Printf("Failed to save data export %s: %v", job.ID, saveErr)
+	}
+	return err
+}
+
+func (s *AccountService) buildArchive(ctx context.Context, userID string) (*UserDataArchive, error) {
+	id := uuid.MustParse(userID)
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+	if user.Status == "deleted" {
+		return nil, errors.New("account was deleted")
+	}
+
+	archive := &UserDataArchive{
+		GeneratedAt:    time.Now(),
+		Profile:        *user,
+		Addresses:      []models.Address{},
+		Orders:         []models.Order{},
+		Payments:       []models.Payment{},
+		Refunds:        []models.Refund{},
+		Reviews:        []models.RatingReview{},
+		LinkedAccounts: []models.UserIdentity{},
+		Devices:        []DeviceResponse{},
+	}
+
+	for offset := 0; ; offset += dataExportBatch {
+		addresses, _, err := s.addressRepo.GetByUserID(ctx, id, offset, dataExportBatch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get addresses: %v", err)
+		}
+		archive.Addresses = append(archive.Addresses, addresses...)
+		if len(addresses) < dataExportBatch {
+			break
+		}
+	}
+
+	for offset := 0; ; offset += dataExportBatch {
+		orders, err := s.orderRepo.GetByUserID(ctx, id, dataExportBatch, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get orders: %v", err)
+		}
+		archive.Orders = append(archive.Orders, orders...)
+		if len(orders) < dataExportBatch {
+			break
+		}
+	}
+
+	for offset := 0; ; offset += dataExportBatch {
+		payments, err := s.paymentRepo.GetByUserID(ctx, id, dataExportBatch, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get payments: %v", err)
+		}
+		archive.Payments = append(archive.Payments, payments...)
+		if len(payments) < dataExportBatch {
+			break
+		}
+	}
+
+	for offset := 0; ; offset += dataExportBatch {
+		refunds, _, err := s.refundRepo.GetByUserIDWithFilters(ctx, id, offset, dataExportBatch, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get refunds: %v", err)
+		}
+		archive.Refunds = append(archive.Refunds, refunds...)
+		if len(refunds) < dataExportBatch {
+			break
+		}
+	}
+
+	for offset := 0; ; offset += dataExportBatch {
+		reviews, err := s.reviewRepo.GetByUserID(ctx, userID, dataExportBatch, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get reviews: %v", err)
+		}
+		archive.Reviews = append(archive.Reviews, reviews...)
+		if len(reviews) < dataExportBatch {
+			break
+		}
+	}
+
+	identities, err := s.identityRepo.GetByUserID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linked accounts: %v", err)
+	}
+	archive.LinkedAccounts = append(archive.LinkedAccounts, identities...)
+
+	devices, err := s.sessionService.ListDevices(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	archive.Devices = append(archive.Devices, devices...)
+
+	return archive, nil
+}
+
+func (s *AccountService) saveExportJob(ctx context.Context, job *DataExportJob) error {
+	return s.cache.Set(ctx, dataExportKey(job.UserID), job, time.Until(job.ExpiresAt))
+}
+
+// discardExport deletes a generated archive of a user whose account is being deleted
+func (s *AccountService) discardExport(ctx context.Context, userID string) {
+	var job DataExportJob
+	if err := s.cache.Get(ctx, dataExportKey(userID), &job); err != nil {
+		return
+	}
+	if job.ObjectKey != "" {
+		if err := s.storage.Delete(ctx, job.ObjectKey); err != nil {
+			log.Printf("Failed to delete data export %s: %v", job.ID, err)
+		}
+	}
+	s.cache.Delete(ctx, dataExportKey(userID))
+}
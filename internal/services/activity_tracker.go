@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	ActivityViewProduct    = "view_product"
+	ActivityAddToCart      = "add_to_cart"
+	ActivityUpdateCartItem = "update_cart_item"
+	ActivityRemoveFromCart = "remove_from_cart"
+	ActivityClearCart      = "clear_cart"
+	ActivityCheckout       = "checkout"
+
+	activityBufferSize    = 10000
+	activityBatchSize     = 500
+	activityFlushInterval = 5 * time.Second
+	activityWriteTimeout  = 10 * time.Second
+)
+
+// ActivityContext describes who performed a tracked action. UserID is empty for anonymous requests.
+type ActivityContext struct {
+	UserID    string
+	SessionID string
+	IP        string
+	UserAgent string
+}
+
+// activityEvent carries exactly one of a search log or a user activity through the buffer
+type activityEvent struct {
+	search   *models.SearchLog
+	activity *models.UserActivity
+}
+
+// ActivityTracker records search logs and user activity without blocking requests.
+// Events are buffered in a channel and written to MongoDB in batches; when the buffer is
+// full, events are dropped rather than slowing down the caller.
+type ActivityTracker struct {
+	searchLogRepo    repositories.SearchLogRepository
+	userActivityRepo repositories.UserActivityRepository
+	events           chan activityEvent
+	stopChan         chan bool
+	done             sync.WaitGroup
+	isRunning        bool
+}
+
+func NewActivityTracker(
+	searchLogRepo repositories.SearchLogRepository,
+	userActivityRepo repositories.UserActivityRepository,
+) *ActivityTracker {
+	return &ActivityTracker{
+		searchLogRepo:    searchLogRepo,
+		userActivityRepo: userActivityRepo,
+		events:           make(chan activityEvent, activityBufferSize),
+		stopChan:         make(chan bool),
+	}
+}
+
+// Start begins writing buffered events in the background
+func (t *ActivityTracker) Start() {
+	if t.isRunning {
+		return
+	}
+
+	t.isRunning = true
+	t.done.Add(1)
+	go t.run()
+
+	log.Println("📈 Activity tracker started")
+}
+
+// Stop flushes any buffered events and stops the background writer
+func (t *ActivityTracker) Stop() {
+	if !t.isRunning {
+		return
+	}
+
+	close(t.stopChan)
+	t.done.Wait()
+	t.isRunning = false
+}
+
+// TrackSearch records a search query and how many results it returned
+func (t *ActivityTracker) TrackSearch(actx ActivityContext, restaurantID, query string, resultsCount int) {
+	if query == "" {
+		return
+	}
+
+	t.enqueue(activityEvent{search: &models.SearchLog{
+		UserID:       actx.UserID,
+		RestaurantID: restaurantID,
+		Query:        query,
+		ResultsCount: resultsCount,
+		Timestamp:    time.Now(),
+		IP:           actx.IP,
+		SessionID:    actx.SessionID,
+		UserAgent:    actx.UserAgent,
+	}})
+}
+
+// TrackActivity records a user action such as viewing a product or changing the cart
+func (t *ActivityTracker) TrackActivity(actx ActivityContext, activityType, restaurantID, entityID string, metadata map[string]interface{}) {
+	// Nothing links an event without a user or session back to a visitor
+	if actx.UserID == "" && actx.SessionID == "" {
+		return
+	}
+
+	t.enqueue(activityEvent{activity: &models.UserActivity{
+		UserID:       actx.UserID,
+		RestaurantID: restaurantID,
+		ActivityType: activityType,
+		EntityID:     entityID,
+		Metadata:     metadata,
+		Timestamp:    time.Now(),
+		SessionID:    actx.SessionID,
+		IP:           actx.IP,
+		UserAgent:    actx.UserAgent,
+	}})
+}
+
+func (t *ActivityTracker) enqueue(event activityEvent) {
+	select {
+	case t.events <- event:
+	default:
+		log.Println("Activity tracker buffer full, dropping event")
+	}
+}
+
+func (t *ActivityTracker) run() {
+	defer t.done.Done()
+
+	ticker := time.NewTicker(activityFlushInterval)
+	defer ticker.Stop()
+
+	var searches []models.SearchLog
+	var activities []models.UserActivity
+
+	flush := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), activityWriteTimeout)
+		defer cancel()
+
+		if len(searches) > 0 {
+			if err := t.searchLogRepo.InsertMany(ctx, searches); err != nil {
+				log.Printf("Failed to write %d search logs: %v", len(searches), err)
+			}
+			searches = nil
+		}
+		if len(activities) > 0 {
+			if err := t.userActivityRepo.InsertMany(ctx, activities); err != nil {
+				log.Printf("Failed to write %d user activities: %v", len(activities), err)
+			}
+			activities = nil
+		}
+	}
+
+	add := func(event activityEvent) {
+		if event.search != nil {
+			searches = append(searches, *event.search)
+		}
+		if event.activity != nil {
+			activities = append(activities, *event.activity)
+		}
+		if len(searches) >= activityBatchSize || len(activities) >= activityBatchSize {
+			flush()
+		}
+	}
+
+	for {
+		select {
+		case event := <-t.events:
+			add(event)
+		case <-ticker.C:
+			flush()
+		case <-t.stopChan:
+			// Drain whatever is already buffered before exiting
+			for {
+				select {
+				case event := <-t.events:
+					add(event)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
@@ -12,11 +12,13 @@ import (
 
 type AddressService struct {
 	addressRepo repositories.AddressRepository
+	geocode     *GeocodeService
 }
 
-func NewAddressService(addressRepo repositories.AddressRepository) *AddressService {
+func NewAddressService(addressRepo repositories.AddressRepository, geocode *GeocodeService) *AddressService {
 	return &AddressService{
 		addressRepo: addressRepo,
+		geocode:     geocode,
 	}
 }
 
@@ -60,13 +62,6 @@ func (s *AddressService) CreateAddress(ctx context.Context, userID string, req *
 		return nil, errors.New("invalid user ID")
 	}
 
-	// If this is set as default, unset other default addresses
-	if req.IsDefault {
-		if err := s.addressRepo.UnsetDefaultAddresses(ctx, userUUID); err != nil {
-			return nil, err
-		}
-	}
-
 	// Create address
 	address := &models.Address{
 		UserID:       userUUID,
@@ -82,6 +77,17 @@ func (s *AddressService) CreateAddress(ctx context.Context, userID string, req *
 		IsDefault:    req.IsDefault,
 	}
 
+	if err := s.geocode.ValidateAddress(ctx, address); err != nil {
+		return nil, err
+	}
+
+	// If this is set as default, unset other default addresses
+	if req.IsDefault {
+		if err := s.addressRepo.UnsetDefaultAddresses(ctx, userUUID); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.addressRepo.Create(ctx, address); err != nil {
 		return nil, err
 	}
@@ -158,6 +164,8 @@ func (s *AddressService) UpdateAddress(ctx context.Context, userID, addressID st
 	}
 
 	// Update fields
+	locationChanged := req.AddressLine != "" || req.City != "" || req.State != "" || req.PostalCode != "" ||
+		req.Country != "" || req.Latitude != 0 || req.Longitude != 0
 	if req.Type != "" {
 		address.Type = req.Type
 	}
@@ -185,6 +193,14 @@ func (s *AddressService) UpdateAddress(ctx context.Context, userID, addressID st
 	if req.Longitude != 0 {
 		address.Longitude = req.Longitude
 	}
+	if locationChanged {
+		// A moved pin or edited address gets a fresh geocode rather than the old one's
+		address.FormattedAddress = ""
+		address.PlaceID = ""
+		if err := s.geocode.ValidateAddress(ctx, address); err != nil {
+			return nil, err
+		}
+	}
 	if req.IsDefault != nil {
 		// If setting as default, unset other default addresses
 		if *req.IsDefault {
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/cache"
+	"time"
+)
+
+const (
+	dashboardCacheTTL      = 5 * time.Minute
+	dashboardDefaultDays   = 30
+	dashboardMaxRangeDays  = 366
+	dashboardDateLayout    = "2006-01-02"
+	dashboardTopRestaurant = 10
+)
+
+type AdminDashboardService struct {
+	dashboardRepo            repositories.DashboardRepository
+	cache                    *cache.RedisCache
+	defaultCommissionPercent float64
+}
+
+func NewAdminDashboardService(dashboardRepo repositories.DashboardRepository, cache *cache.RedisCache, defaultCommissionPercent float64) *AdminDashboardService {
+	return &AdminDashboardService{
+		dashboardRepo:            dashboardRepo,
+		cache:                    cache,
+		defaultCommissionPercent: defaultCommissionPercent,
+	}
+}
+
+// DashboardRange is an inclusive range of calendar days
+type DashboardRange struct {
+	From string `json:"from"` // YYYY-MM-DD
+	To   string `json:"to"`   // YYYY-MM-DD
+	from time.Time
+	to   time.Time // exclusive: midnight after the last day
+}
+
+type OrderStatusSummary struct {
+	Range       DashboardRange                  `json:"range"`
+	TotalOrders int64                           `json:"total_orders"`
+	ByStatus    []repositories.OrderStatusCount `json:"by_status"`
+}
+
+type GMVSummary struct {
+	Range    DashboardRange          `json:"range"`
+	TotalGMV float64                 `json:"total_gmv"`
+	Orders   int64                   `json:"orders"`
+	AOV      float64                 `json:"average_order_value"`
+	Daily    []repositories.DailyGMV `json:"daily"`
+}
+
+type TopRestaurantsSummary struct {
+	Range       DashboardRange                 `json:"range"`
+	Restaurants []repositories.RestaurantSales `json:"restaurants"`
+}
+
+type RefundSummary struct {
+	Range DashboardRange `json:"range"`
+	repositories.RefundTotals
+	RefundRate       float64 `json:"refund_rate"`        // percentage of sale orders refunded
+	RefundAmountRate float64 `json:"refund_amount_rate"` // refunded amount as a percentage of GMV
+}
+
+type UserSummary struct {
+	Range DashboardRange `json:"range"`
+	repositories.UserActivityCounts
+}
+
+type CartSummary struct {
+	Range DashboardRange `json:"range"`
+	repositories.CartActivityCounts
+	AbandonmentRate float64 `json:"abandonment_rate"` // percentage of carts created that were abandoned
+	RecoveryRate    float64 `json:"recovery_rate"`    // percentage of abandoned carts used again
+	ConversionRate  float64 `json:"conversion_rate"`  // percentage of carts created that were ordered
+}
+
+type DashboardOverview struct {
+	Range          DashboardRange                 `json:"range"`
+	Orders         *OrderStatusSummary            `json:"orders"`
+	GMV            *GMVSummary                    `json:"gmv"`
+	TopRestaurants []repositories.RestaurantSales `json:"top_restaurants"`
+	Refunds        *RefundSummary                 `json:"refunds"`
+	Users          *UserSummary                   `json:"users"`
+	Carts          *CartSummary                   `json:"carts"`
+}
+
+// ParseDashboardRange parses from/to dates (YYYY-MM-DD), defaulting to the last 30 days
+func ParseDashboardRange(from, to string) (DashboardRange, error) {
+	var r DashboardRange
+
+	today := time.Now().Truncate(24 * time.Hour)
+	end := today
+	if to != "" {
+		parsed, err := time.Parse(dashboardDateLayout, to)
+		if err != nil {
+			return r, errors.New("invalid 'to' date, expected YYYY-MM-DD")
+		}
+		end = parsed
+	}
+
+	start := end.AddDate(0, 0, -(dashboardDefaultDays - 1))
+	if from != "" {
+		parsed, err := time.Parse(dashboardDateLayout, from)
+		if err != nil {
+			return r, errors.New("invalid 'from' date, expected YYYY-MM-DD")
+		}
+		start = parsed
+	}
+
+	if start.After(end) {
+		return r, errors.New("'from' date must not be after 'to' date")
+	}
+	if end.Sub(start) > dashboardMaxRangeDays*24*time.Hour {
+		return r, fmt.Errorf("date range cannot exceed %d days", dashboardMaxRangeDays)
+	}
+
+	return DashboardRange{
+		From: start.Format(dashboardDateLayout),
+		To:   end.Format(dashboardDateLayout),
+		from: start,
+		to:   end.AddDate(0, 0, 1),
+	}, nil
+}
+
+func dashboardCacheKey(metric string, r DashboardRange, extra ...interface{}) string {
+	key := fmt.Sprintf("admin_dashboard:%s:%s:%s", metric, r.From, r.To)
+	for _, e := range extra {
+		key += fmt.Sprintf(":%v", e)
+	}
+	return key
+}
+
+func (s *AdminDashboardService) GetOrderStatusSummary(ctx context.Context, r DashboardRange) (*OrderStatusSummary, error) {
+	cacheKey := dashboardCacheKey("orders", r)
+	var cached OrderStatusSummary
+	if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+		return &cached, nil
+	}
+
+	counts, err := s.dashboardRepo.CountOrdersByStatus(ctx, r.from, r.to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count orders: %v", err)
+	}
+
+	summary := &OrderStatusSummary{Range: r, ByStatus: counts}
+	for _, count := range counts {
+		summary.TotalOrders += count.Count
+	}
+
+	s.cache.Set(ctx, cacheKey, summary, dashboardCacheTTL)
+	return summary, nil
+}
+
+func (s *AdminDashboardService) GetGMVSummary(ctx context.Context, r DashboardRange) (*GMVSummary, error) {
+	cacheKey := dashboardCacheKey("gmv", r)
+	var cached GMVSummary
+	if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+		return &cached, nil
+	}
+
+	days, err := s.dashboardRepo.GetDailyGMV(ctx, r.from, r.to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate GMV: %v", err)
+	}
+
+	summary := &GMVSummary{Range: r, Daily: days}
+	for _, day := range days {
+		summary.TotalGMV += day.GMV
+		summary.Orders += day.Orders
+	}
+	summary.TotalGMV = roundMoney(summary.TotalGMV)
+	if summary.Orders > 0 {
+		summary.AOV = roundMoney(summary.TotalGMV / float64(summary.Orders))
+	}
+
+	s.cache.Set(ctx, cacheKey, summary, dashboardCacheTTL)
+	return summary, nil
+}
+
+func (s *AdminDashboardService) GetTopRestaurants(ctx context.Context, r DashboardRange, limit int) (*TopRestaurantsSummary, error) {
+	if limit < 1 || limit > 100 {
+		limit = dashboardTopRestaurant
+	}
+
+	cacheKey := dashboardCacheKey("top_restaurants", r, limit)
+	var cached TopRestaurantsSummary
+	if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+		return &cached, nil
+	}
+
+	restaurants, err := s.dashboardRepo.GetTopRestaurants(ctx, r.from, r.to, limit, s.defaultCommissionPercent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank restaurants: %v", err)
+	}
+
+	summary := &TopRestaurantsSummary{Range: r, Restaurants: restaurants}
+	s.cache.Set(ctx, cacheKey, summary, dashboardCacheTTL)
+	return summary, nil
+}
+
+func (s *AdminDashboardService) GetRefundSummary(ctx context.Context, r DashboardRange) (*RefundSummary, error) {
+	cacheKey := dashboardCacheKey("refunds", r)
+	var cached RefundSummary
+	if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+		return &cached, nil
+	}
+
+	totals, err := s.dashboardRepo.GetRefundTotals(ctx, r.from, r.to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate refunds: %v", err)
+	}
+
+	summary := &RefundSummary{Range: r, RefundTotals: *totals}
+	if totals.GMVOrders > 0 {
+		summary.RefundRate = roundMoney(float64(totals.RefundedOrders) / float64(totals.GMVOrders) * 100)
+	}
+	if totals.GMV > 0 {
+		summary.RefundAmountRate = roundMoney(totals.RefundedAmount / totals.GMV * 100)
+	}
+
+	s.cache.Set(ctx, cacheKey, summary, dashboardCacheTTL)
+	return summary, nil
+}
+
+func (s *AdminDashboardService) GetUserSummary(ctx context.Context, r DashboardRange) (*UserSummary, error) {
+	cacheKey := dashboardCacheKey("users", r)
+	var cached UserSummary
+	if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+		return &cached, nil
+	}
+
+	counts, err := s.dashboardRepo.GetUserActivity(ctx, r.from, r.to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count users: %v", err)
+	}
+
+	summary := &UserSummary{Range: r, UserActivityCounts: *counts}
+	s.cache.Set(ctx, cacheKey, summary, dashboardCacheTTL)
+	return summary, nil
+}
+
+func (s *AdminDashboardService) GetCartSummary(ctx context.Context, r DashboardRange) (*CartSummary, error) {
+	cacheKey := dashboardCacheKey("carts", r)
+	var cached CartSummary
+	if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+		return &cached, nil
+	}
+
+	counts, err := s.dashboardRepo.GetCartActivity(ctx, r.from, r.to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count carts: %v", err)
+	}
+
+	summary := &CartSummary{Range: r, CartActivityCounts: *counts}
+	if counts.Created > 0 {
+		summary.AbandonmentRate = roundMoney(float64(counts.Abandoned) / float64(counts.Created) * 100)
+		summary.ConversionRate = roundMoney(float64(counts.Ordered) / float64(counts.Created) * 100)
+	}
+	if counts.Abandoned > 0 {
+		summary.RecoveryRate = roundMoney(float64(counts.Recovered) / float64(counts.Abandoned) * 100)
+	}
+
+	s.cache.Set(ctx, cacheKey, summary, dashboardCacheTTL)
+	return summary, nil
+}
+
+// GetOverview combines all dashboard metrics for the range
+func (s *AdminDashboardService) GetOverview(ctx context.Context, r DashboardRange) (*DashboardOverview, error) {
+	orders, err := s.GetOrderStatusSummary(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	gmv, err := s.GetGMVSummary(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	topRestaurants, err := s.GetTopRestaurants(ctx, r, 5)
+	if err != nil {
+		return nil, err
+	}
+
+	refunds, err := s.GetRefundSummary(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.GetUserSummary(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	carts, err := s.GetCartSummary(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DashboardOverview{
+		Range:          r,
+		Orders:         orders,
+		GMV:            gmv,
+		TopRestaurants: topRestaurants.Restaurants,
+		Refunds:        refunds,
+		Users:          users,
+		Carts:          carts,
+	}, nil
+}
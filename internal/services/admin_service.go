@@ -0,0 +1,452 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/auth"
+	"golang-food-backend/pkg/cache"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Admin permissions checked by middleware.AdminPermissionRequired
+const (
+	AdminPermissionAll         = "*"
+	AdminPermissionAdmins      = "admins.manage"
+	AdminPermissionRestaurants = "restaurants.manage"
+	AdminPermissionUsers       = "users.manage"
+	AdminPermissionOrders      = "orders.manage"
+	AdminPermissionBanners     = "banners.manage"
+	AdminPermissionDelivery    = "delivery.manage"
+	AdminPermissionMaintenance = "maintenance.manage"
+	AdminPermissionReports     = "reports.view"
+	AdminPermissionEvents      = "events.manage"
+	AdminPermissionJobs        = "jobs.manage"
+	AdminPermissionPricing     = "pricing.manage"
+	AdminPermissionFeatures    = "features.manage"
+	AdminPermissionAudit       = "audit.view"
+	AdminPermissionSupport     = "support.manage"
+	AdminPermissionRisk        = "risk.manage"
+)
+
+var adminPermissions = map[string]bool{
+	AdminPermissionAll:         true,
+	AdminPermissionAdmins:      true,
+	AdminPermissionRestaurants: true,
+	AdminPermissionUsers:       true,
+	AdminPermissionOrders:      true,
+	AdminPermissionBanners:     true,
+	AdminPermissionDelivery:    true,
+	AdminPermissionMaintenance: true,
+	AdminPermissionReports:     true,
+	AdminPermissionEvents:      true,
+	AdminPermissionJobs:        true,
+	AdminPermissionPricing:     true,
+	AdminPermissionFeatures:    true,
+	AdminPermissionAudit:       true,
+	AdminPermissionSupport:     true,
+	AdminPermissionRisk:        true,
+}
+
+// Failed logins allowed per email before the account is temporarily locked
+const (
+	adminMaxLoginAttempts = 5
+	adminLoginLockout     = 15 * time.Minute
+	adminRefreshTokenDays = 30
+)
+
+// ErrTOTPRequired is returned by Login when the admin has TOTP enabled and no code was given
+var ErrTOTPRequired = apperr.Unauthorized("totp_required", "TOTP code required")
+
+type AdminService struct {
+	adminRepo  repositories.AdminRepository
+	jwtManager *auth.JWTManager
+	cache      *cache.RedisCache
+	totpIssuer string
+}
+
+func NewAdminService(adminRepo repositories.AdminRepository, jwtManager *auth.JWTManager, cache *cache.RedisCache, totpIssuer string) *AdminService {
+	return &AdminService{
+		adminRepo:  adminRepo,
+		jwtManager: jwtManager,
+		cache:      cache,
+		totpIssuer: totpIssuer,
+	}
+}
+
+type AdminLoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+	TOTPCode string `json:"totp_code"`
+}
+
+type AdminAuthResponse struct {
+	AccessToken  string           `json:"access_token"`
+	RefreshToken string           `json:"refresh_token"`
+	TokenType    string           `json:"token_type"`
+	ExpiresIn    int              `json:"expires_in"` // seconds until access token expires
+	Admin        models.AdminUser `json:"admin"`
+}
+
+type CreateAdminRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Email       string   `json:"email" binding:"required,email"`
+	Password    string   `json:"password" binding:"required,min=8"`
+	Role        string   `json:"role" binding:"required"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateAdminRequest struct {
+	Name        *string  `json:"name"`
+	Role        *string  `json:"role"`
+	Permissions []string `json:"permissions"`
+	Password    *string  `json:"password" binding:"omitempty,min=8"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required,len=6"`
+}
+
+type TOTPSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+func adminRefreshTokenKey(adminID string) string {
+	return "admin_refresh_token:" + adminID
+}
+
+func adminLoginAttemptsKey(email string) string {
+	return "admin_login_attempts:" + strings.ToLower(email)
+}
+
+// EnsureBootstrapAdmin creates a super admin from configuration when no admins exist yet
+func (s *AdminService) EnsureBootstrapAdmin(ctx context.Context, email, password string) error {
+	if email == "" || password == "" {
+		return nil
+	}
+
+	count, err := s.adminRepo.Count(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count admins: %v", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	admin := &models.AdminUser{
+		Name:         "Super Admin",
+		Email:        strings.ToLower(email),
+		Role:         "super_admin",
+		Permissions:  models.StringArray{AdminPermissionAll},
+		PasswordHash: string(hashedPassword),
+		IsActive:     true,
+	}
+	if err := s.adminRepo.Create(ctx, admin); err != nil {
+		return fmt.Errorf("failed to create bootstrap admin: %v", err)
+	}
+
+	log.Printf("Created bootstrap super admin %s", admin.Email)
+	return nil
+}
+
+// Login authenticates an admin by password and, when enabled, a TOTP code
+func (s *AdminService) Login(ctx context.Context, req *AdminLoginRequest) (*AdminAuthResponse, error) {
+	attemptsKey := adminLoginAttemptsKey(req.Email)
+	var attempts int
+	s.cache.Get(ctx, attemptsKey, &attempts)
+	if attempts >= adminMaxLoginAttempts {
+		return nil, errors.New("too many failed login attempts, try again later")
+	}
+
+	admin, err := s.adminRepo.GetByEmail(ctx, req.Email)
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte(req.Password)) != nil {
+		s.cache.Set(ctx, attemptsKey, attempts+1, adminLoginLockout)
+		return nil, errors.New("invalid email or password")
+	}
+
+	if !admin.IsActive {
+		return nil, errors.New("account is not active")
+	}
+
+	if admin.TOTPEnabled {
+		if req.TOTPCode == "" {
+			return nil, ErrTOTPRequired
+		}
+		if !auth.ValidateTOTP(admin.TOTPSecret, req.TOTPCode, time.Now()) {
+			s.cache.Set(ctx, attemptsKey, attempts+1, adminLoginLockout)
+			return nil, errors.New("invalid TOTP code")
+		}
+	}
+
+	s.cache.Delete(ctx, attemptsKey)
+
+	now := time.Now()
+	admin.LastLoginAt = &now
+	if err := s.adminRepo.Update(ctx, admin); err != nil {
+		log.Printf("Failed to record login for admin %s: %v", admin.ID, err)
+	}
+
+	return s.issueTokens(ctx, admin)
+}
+
+// RefreshToken issues a new access token with the admin's current permissions
+func (s *AdminService) RefreshToken(ctx context.Context, refreshToken string) (*AdminAuthResponse, error) {
+	claims, err := s.jwtManager.ValidateToken(refreshToken)
+	if err != nil || claims.TokenType != auth.RefreshToken || claims.Scope != auth.AdminScope {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	var storedToken string
+	if err := s.cache.Get(ctx, adminRefreshTokenKey(claims.UserID), &storedToken); err != nil || storedToken != refreshToken {
+		return nil, errors.New("refresh token not found or invalid")
+	}
+
+	admin, err := s.GetAdmin(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !admin.IsActive {
+		return nil, errors.New("account is not active")
+	}
+
+	return s.issueTokens(ctx, admin)
+}
+
+// Logout invalidates the admin's refresh token
+func (s *AdminService) Logout(ctx context.Context, adminID string) error {
+	return s.cache.Delete(ctx, adminRefreshTokenKey(adminID))
+}
+
+func (s *AdminService) issueTokens(ctx context.Context, admin *models.AdminUser) (*AdminAuthResponse, error) {
+	tokenPair, err := s.jwtManager.GenerateAdminTokenPair(admin.ID.String(), admin.Email, admin.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cache.Set(ctx, adminRefreshTokenKey(admin.ID.String()), tokenPair.RefreshToken, time.Hour*24*adminRefreshTokenDays); err != nil {
+		return nil, err
+	}
+
+	return &AdminAuthResponse{
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    3600, // 1 hour in seconds
+		Admin:        *admin,
+	}, nil
+}
+
+func (s *AdminService) GetAdmin(ctx context.Context, adminID string) (*models.AdminUser, error) {
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		return nil, errors.New("invalid admin ID")
+	}
+
+	admin, err := s.adminRepo.GetByID(ctx, adminUUID)
+	if err != nil {
+		return nil, errors.New("admin not found")
+	}
+
+	return admin, nil
+}
+
+func (s *AdminService) ListAdmins(ctx context.Context, page, limit int) ([]models.AdminUser, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	admins, err := s.adminRepo.List(ctx, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admins: %v", err)
+	}
+
+	return admins, nil
+}
+
+func (s *AdminService) CreateAdmin(ctx context.Context, creatorID string, req *CreateAdminRequest) (*models.AdminUser, error) {
+	if err := validateAdminPermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.adminRepo.GetByEmail(ctx, req.Email); err == nil {
+		return nil, errors.New("admin with this email already exists")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	admin := &models.AdminUser{
+		Name:         req.Name,
+		Email:        strings.ToLower(req.Email),
+		Role:         req.Role,
+		Permissions:  models.StringArray(req.Permissions),
+		PasswordHash: string(hashedPassword),
+		IsActive:     true,
+	}
+	if creatorUUID, err := uuid.Parse(creatorID); err == nil {
+		admin.CreatedBy = &creatorUUID
+	}
+
+	if err := s.adminRepo.Create(ctx, admin); err != nil {
+		return nil, fmt.Errorf("failed to create admin: %v", err)
+	}
+
+	return admin, nil
+}
+
+func (s *AdminService) UpdateAdmin(ctx context.Context, actorID, adminID string, req *UpdateAdminRequest) (*models.AdminUser, error) {
+	admin, err := s.GetAdmin(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		admin.Name = *req.Name
+	}
+	if req.Role != nil {
+		admin.Role = *req.Role
+	}
+	if req.Permissions != nil {
+		if err := validateAdminPermissions(req.Permissions); err != nil {
+			return nil, err
+		}
+		// Prevent admins from locking themselves out of admin management
+		if actorID == adminID && !hasAdminPermission(req.Permissions, AdminPermissionAdmins) {
+			return nil, errors.New("cannot remove your own admin management permission")
+		}
+		admin.Permissions = models.StringArray(req.Permissions)
+	}
+	if req.Password != nil {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %v", err)
+		}
+		admin.PasswordHash = string(hashedPassword)
+	}
+	if req.IsActive != nil {
+		if actorID == adminID && !*req.IsActive {
+			return nil, errors.New("cannot deactivate your own account")
+		}
+		admin.IsActive = *req.IsActive
+	}
+
+	admin.UpdatedAt = time.Now()
+	if err := s.adminRepo.Update(ctx, admin); err != nil {
+		return nil, fmt.Errorf("failed to update admin: %v", err)
+	}
+
+	// Force a fresh login so tokens pick up the new permissions or status
+	if req.Permissions != nil || req.Password != nil || !admin.IsActive {
+		s.Logout(ctx, adminID)
+	}
+
+	return admin, nil
+}
+
+// DeactivateAdmin disables an admin account and revokes its refresh token
+func (s *AdminService) DeactivateAdmin(ctx context.Context, actorID, adminID string) error {
+	isActive := false
+	_, err := s.UpdateAdmin(ctx, actorID, adminID, &UpdateAdminRequest{IsActive: &isActive})
+	return err
+}
+
+// SetupTOTP generates a new TOTP secret for the admin. It takes effect once confirmed with EnableTOTP.
+func (s *AdminService) SetupTOTP(ctx context.Context, adminID string) (*TOTPSetupResponse, error) {
+	admin, err := s.GetAdmin(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if admin.TOTPEnabled {
+		return nil, errors.New("TOTP is already enabled")
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %v", err)
+	}
+
+	admin.TOTPSecret = secret
+	admin.UpdatedAt = time.Now()
+	if err := s.adminRepo.Update(ctx, admin); err != nil {
+		return nil, fmt.Errorf("failed to save TOTP secret: %v", err)
+	}
+
+	return &TOTPSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(s.totpIssuer, admin.Email, secret),
+	}, nil
+}
+
+// EnableTOTP turns on TOTP after the admin proves their authenticator is set up
+func (s *AdminService) EnableTOTP(ctx context.Context, adminID, code string) error {
+	admin, err := s.GetAdmin(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if admin.TOTPSecret == "" {
+		return errors.New("TOTP setup has not been started")
+	}
+	if !auth.ValidateTOTP(admin.TOTPSecret, code, time.Now()) {
+		return errors.New("invalid TOTP code")
+	}
+
+	admin.TOTPEnabled = true
+	admin.UpdatedAt = time.Now()
+	return s.adminRepo.Update(ctx, admin)
+}
+
+func (s *AdminService) DisableTOTP(ctx context.Context, adminID, code string) error {
+	admin, err := s.GetAdmin(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if !admin.TOTPEnabled {
+		return errors.New("TOTP is not enabled")
+	}
+	if !auth.ValidateTOTP(admin.TOTPSecret, code, time.Now()) {
+		return errors.New("invalid TOTP code")
+	}
+
+	admin.TOTPEnabled = false
+	admin.TOTPSecret = ""
+	admin.UpdatedAt = time.Now()
+	return s.adminRepo.Update(ctx, admin)
+}
+
+func validateAdminPermissions(permissions []string) error {
+	for _, permission := range permissions {
+		if !adminPermissions[permission] {
+			return fmt.Errorf("unknown permission: %s", permission)
+		}
+	}
+	return nil
+}
+
+func hasAdminPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission || p == AdminPermissionAll {
+			return true
+		}
+	}
+	return false
+}
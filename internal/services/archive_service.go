@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/jobs"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+)
+
+const (
+	JobOrderArchive = "orders:archive"
+	JobPurgeDeleted = "trash:purge"
+
+	archiveBatchSize = 200
+)
+
+var (
+	ErrArchiveNotFound   = apperr.NotFound("record_not_found", "record not found")
+	ErrArchiveInvalid    = apperr.Validation("archive_invalid", "invalid request")
+	ErrOrderNotDeletable = apperr.Conflict("order_not_deletable", "only unpaid, failed or cancelled orders can be deleted")
+)
+
+// deletableOrderStatuses are the statuses of orders no money was collected for, which
+// admins may delete without affecting payouts
+var deletableOrderStatuses = map[string]bool{
+	"pending":         true,
+	"pending_payment": true,
+	"payment_failed":  true,
+	"cancelled":       true,
+}
+
+// RetentionPolicy sets how long settled orders stay in the orders table and how long
+// soft-deleted records can be restored
+type RetentionPolicy struct {
+	OrderArchiveAfter time.Duration
+	PurgeDeletedAfter time.Duration
+}
+
+type DeletedOrdersResponse struct {
+	Orders     []models.Order `json:"orders"`
+	Pagination PaginationInfo `json:"pagination"`
+}
+
+type DeletedRestaurantsResponse struct {
+	Restaurants []models.Restaurant `json:"restaurants"`
+	Pagination  PaginationInfo      `json:"pagination"`
+}
+
+type DeletedProductsResponse struct {
+	Products   []models.Product `json:"products"`
+	Pagination PaginationInfo   `json:"pagination"`
+}
+
+// ArchiveService handles soft-deleted orders, restaurants and products, and the nightly jobs
+// that archive old orders and purge records deleted long ago
+type ArchiveService struct {
+	orderRepo      repositories.OrderRepository
+	restaurantRepo repositories.RestaurantRepository
+	productRepo    repositories.ProductRepository
+	productService *ProductService
+	policy         RetentionPolicy
+	queue          *jobs.Queue
+	stopChan       chan bool
+	isRunning      bool
+	timezone       *time.Location
+}
+
+func NewArchiveService(
+	orderRepo repositories.OrderRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	productRepo repositories.ProductRepository,
+	productService *ProductService,
+	policy RetentionPolicy,
+) *ArchiveService {
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		loc = time.UTC
+		log.Printf("Failed to load timezone, using UTC: %v", err)
+	}
+
+	return &ArchiveService{
+		orderRepo:      orderRepo,
+		restaurantRepo: restaurantRepo,
+		productRepo:    productRepo,
+		productService: productService,
+		policy:         policy,
+		stopChan:       make(chan bool),
+		timezone:       loc,
+	}
+}
+
+// RegisterJobs registers the archive and purge job handlers and the queue the nightly run uses
+func (s *ArchiveService) RegisterJobs(queue *jobs.Queue) {
+	s.queue = queue
+	queue.Handle(JobOrderArchive, func(ctx context.Context, job *jobs.Job) error {
+		_, err := s.ArchiveOrders(ctx)
+		return err
+	})
+	queue.Handle(JobPurgeDeleted, func(ctx context.Context, job *jobs.Job) error {
+		_, err := s.PurgeDeleted(ctx)
+		return err
+	})
+}
+
+// Start queues the archive, purge and chat retention jobs every night at 03:00
+func (s *ArchiveService) Start() error {
+	if s.isRunning {
+		return fmt.Errorf("archive service is already running")
+	}
+
+	s.isRunning = true
+	go s.runNightlyTicker()
+
+	log.Println("🗄️ Order archival and trash purge: Every day at 03:00")
+	return nil
+}
+
+// Stop stops the nightly runs
+func (s *ArchiveService) Stop() {
+	if !s.isRunning {
+		return
+	}
+
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+func (s *ArchiveService) runNightlyTicker() {
+	now := time.Now().In(s.timezone)
+	next := time.Date(now.Year(), now.Month(), now.Day(), 3, 0, 0, 0, s.timezone)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	timer := time.NewTimer(next.Sub(now))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			ctx := context.Background()
+			// The jobs work in batches and skip rows another run holds, or delete by date, so
+			// a run queued by every instance is harmless. Chat retention is handled by the
+			// order chat service.
+			for _, jobType := range []string{JobOrderArchive, JobPurgeDeleted, JobChatPurge} {
+				if _, err := s.queue.Enqueue(ctx, jobType, nil, jobs.Timeout(time.Hour)); err != nil {
+					log.Printf("Failed to queue %s: %v", jobType, err)
+				}
+			}
+			timer.Reset(24 * time.Hour)
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// ArchiveOrders moves old cancelled and settled orders, and orders deleted longer ago than the
+// purge period, to the order archive
+func (s *ArchiveService) ArchiveOrders(ctx context.Context) (int64, error) {
+	now := time.Now()
+	placedBefore := now.Add(-s.policy.OrderArchiveAfter)
+	deletedBefore := now.Add(-s.policy.PurgeDeletedAfter)
+
+	var total int64
+	for {
+		archived, err := s.orderRepo.Archive(ctx, placedBefore, deletedBefore, archiveBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to archive orders: %v", err)
+		}
+		total += archived
+		if archived < archiveBatchSize {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("Archived %d orders", total)
+	}
+	return total, nil
+}
+
+// PurgeDeleted permanently removes products deleted longer ago than the purge period.
+// Deleted restaurants are kept because orders and payouts still refer to them.
+func (s *ArchiveService) PurgeDeleted(ctx context.Context) (int64, error) {
+	purged, err := s.productRepo.PurgeDeleted(ctx, time.Now().Add(-s.policy.PurgeDeletedAfter))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted products: %v", err)
+	}
+	if purged > 0 {
+		log.Printf("Purged %d deleted products", purged)
+	}
+	return purged, nil
+}
+
+// DeleteOrder soft-deletes an order that took no money
+func (s *ArchiveService) DeleteOrder(ctx context.Context, orderID uuid.UUID) error {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: order %s", ErrArchiveNotFound, orderID)
+	}
+	if err != nil {
+		return err
+	}
+	if !deletableOrderStatuses[order.OrderStatus] {
+		return fmt.Errorf("%w: order is %s", ErrOrderNotDeletable, order.OrderStatus)
+	}
+	return s.orderRepo.Delete(ctx, orderID)
+}
+
+func (s *ArchiveService) GetDeletedOrders(ctx context.Context, page, limit int) (*DeletedOrdersResponse, error) {
+	orders, total, err := s.orderRepo.GetDeleted(ctx, (page-1)*limit, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &DeletedOrdersResponse{Orders: orders, Pagination: archivePagination(page, limit, total)}, nil
+}
+
+func (s *ArchiveService) RestoreOrder(ctx context.Context, orderID uuid.UUID) error {
+	err := s.orderRepo.Restore(ctx, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: order %s is not deleted", ErrArchiveNotFound, orderID)
+	}
+	return err
+}
+
+// GetArchivedOrder returns an archived order with its snapshot
+func (s *ArchiveService) GetArchivedOrder(ctx context.Context, orderID uuid.UUID) (*models.ArchivedOrder, error) {
+	order, err := s.orderRepo.GetArchived(ctx, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: order %s is not archived", ErrArchiveNotFound, orderID)
+	}
+	return order, err
+}
+
+func (s *ArchiveService) GetDeletedRestaurants(ctx context.Context, page, limit int) (*DeletedRestaurantsResponse, error) {
+	restaurants, total, err := s.restaurantRepo.GetDeleted(ctx, (page-1)*limit, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &DeletedRestaurantsResponse{Restaurants: restaurants, Pagination: archivePagination(page, limit, total)}, nil
+}
+
+func (s *ArchiveService) RestoreRestaurant(ctx context.Context, restaurantID uuid.UUID) error {
+	err := s.restaurantRepo.Restore(ctx, restaurantID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: restaurant %s is not deleted", ErrArchiveNotFound, restaurantID)
+	}
+	return err
+}
+
+func (s *ArchiveService) GetDeletedProducts(ctx context.Context, restaurantID string, page, limit int) (*DeletedProductsResponse, error) {
+	if restaurantID == "" {
+		return nil, fmt.Errorf("%w: restaurant_id is required", ErrArchiveInvalid)
+	}
+	products, total, err := s.productRepo.GetDeleted(ctx, restaurantID, (page-1)*limit, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &DeletedProductsResponse{Products: products, Pagination: archivePagination(page, limit, total)}, nil
+}
+
+// RestoreProduct undeletes a product that has not been purged yet
+func (s *ArchiveService) RestoreProduct(ctx context.Context, productID string) (*models.Product, error) {
+	objectID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid product ID", ErrArchiveInvalid)
+	}
+
+	product, err := s.productRepo.Restore(ctx, objectID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: product %s is not deleted", ErrArchiveNotFound, productID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	syncCombos(ctx, s.productService.productRepo, s.productService.inventoryRepo, s.productService.cache, product.ID)
+	s.productService.clearProductCache(product.RestaurantID)
+	s.productService.cache.InvalidateTags(ctx, productCartsTag(productID))
+	return product, nil
+}
+
+func archivePagination(page, limit int, total int64) PaginationInfo {
+	return PaginationInfo{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Entity types of audited requests
+const (
+	AuditEntityRestaurant  = "restaurant"
+	AuditEntityProduct     = "product"
+	AuditEntityCoupon      = "coupon"
+	AuditEntityRefund      = "refund"
+	AuditEntityPayout      = "payout"
+	AuditEntityPayoutBatch = "payout_batch"
+)
+
+var (
+	ErrAuditInvalid  = apperr.Validation("audit_invalid", "invalid audit log request")
+	ErrAuditNotFound = apperr.NotFound("audit_entry_not_found", "audit entry not found")
+)
+
+// auditIgnoredFields change on every write and are left out of the recorded changes
+var auditIgnoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+// AuditService records who changed restaurants, products, coupons, refunds and payouts, and
+// how, for the audit middleware, and lets admins search the audit log
+type AuditService struct {
+	auditRepo      repositories.AuditLogRepository
+	restaurantRepo repositories.RestaurantRepository
+	productRepo    repositories.ProductRepository
+	couponRepo     repositories.CouponRepository
+	refundRepo     repositories.RefundRepository
+	payoutRepo     repositories.PayoutRepository
+}
+
+func NewAuditService(
+	auditRepo repositories.AuditLogRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	productRepo repositories.ProductRepository,
+	couponRepo repositories.CouponRepository,
+	refundRepo repositories.RefundRepository,
+	payoutRepo repositories.PayoutRepository,
+) *AuditService {
+	return &AuditService{
+		auditRepo:      auditRepo,
+		restaurantRepo: restaurantRepo,
+		productRepo:    productRepo,
+		couponRepo:     couponRepo,
+		refundRepo:     refundRepo,
+		payoutRepo:     payoutRepo,
+	}
+}
+
+type AuditLogsResponse struct {
+	Entries    []models.AuditLog `json:"entries"`
+	Pagination PaginationInfo    `json:"pagination"`
+}
+
+// AuditLogQuery filters the audit log. From and To are RFC 3339 times or YYYY-MM-DD dates;
+// a date in To includes the whole day.
+type AuditLogQuery struct {
+	EntityType string
+	EntityID   string
+	Action     string
+	ActorID    string
+	From       string
+	To         string
+	Page       int
+	Limit      int
+}
+
+// Snapshot returns the entity as the API shows it, or nil when it does not exist (any more)
+func (s *AuditService) Snapshot(ctx context.Context, entityType, entityID string) interface{} {
+	var (
+		snapshot interface{}
+		err      error
+	)
+	switch entityType {
+	case AuditEntityProduct:
+		var id primitive.ObjectID
+		if id, err = primitive.ObjectIDFromHex(entityID); err == nil {
+			snapshot, err = s.productRepo.GetByID(ctx, id)
+		}
+	default:
+		var id uuid.UUID
+		if id, err = uuid.Parse(entityID); err != nil {
+			return nil
+		}
+		switch entityType {
+		case AuditEntityRestaurant:
+			snapshot, err = s.restaurantRepo.GetByID(ctx, id)
+		case AuditEntityCoupon:
+			snapshot, err = s.couponRepo.GetByID(ctx, id)
+		case AuditEntityRefund:
+			snapshot, err = s.refundRepo.GetByID(ctx, id)
+		case AuditEntityPayout:
+			snapshot, err = s.payoutRepo.GetPayoutByID(ctx, id)
+		case AuditEntityPayoutBatch:
+			snapshot, err = s.payoutRepo.GetBatchByID(ctx, id)
+		default:
+			return nil
+		}
+	}
+	if err != nil {
+		return nil
+	}
+	return snapshot
+}
+
+// Record writes an audit entry with the changes between the entity's snapshots: the changed
+// fields on updates, the new entity on creates and the removed one on deletes. Failures are
+// logged; they never fail the request, which has already succeeded.
+func (s *AuditService) Record(ctx context.Context, entry *models.AuditLog, before, after interface{}) {
+	if entry.Metadata == nil {
+		entry.Metadata = models.JSONB{}
+	}
+	beforeFields, afterFields := auditFields(before), auditFields(after)
+	switch {
+	case beforeFields != nil && afterFields != nil:
+		entry.Metadata["changes"] = auditChanges(beforeFields, afterFields)
+	case afterFields != nil:
+		entry.Metadata["after"] = afterFields
+	case beforeFields != nil:
+		entry.Metadata["before"] = beforeFields
+	}
+
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write audit entry for %s %s: %v", entry.EntityType, entry.EntityID, err)
+	}
+}
+
+// ListEntries returns audit entries matching the query, newest first
+func (s *AuditService) ListEntries(ctx context.Context, query AuditLogQuery) (*AuditLogsResponse, error) {
+	filter := repositories.AuditLogFilter{
+		EntityType: query.EntityType,
+		EntityID:   query.EntityID,
+		Action:     query.Action,
+	}
+	if query.ActorID != "" {
+		actorID, err := uuid.Parse(query.ActorID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid actor ID", ErrAuditInvalid)
+		}
+		filter.PerformedBy = actorID
+	}
+
+	var err error
+	if filter.From, err = parseAuditTime(query.From, false); err != nil {
+		return nil, fmt.Errorf("%w: invalid from: %v", ErrAuditInvalid, err)
+	}
+	if filter.To, err = parseAuditTime(query.To, true); err != nil {
+		return nil, fmt.Errorf("%w: invalid to: %v", ErrAuditInvalid, err)
+	}
+
+	page, limit := query.Page, query.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	entries, total, err := s.auditRepo.List(ctx, filter, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %v", err)
+	}
+
+	return &AuditLogsResponse{
+		Entries: entries,
+		Pagination: PaginationInfo{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		},
+	}, nil
+}
+
+func (s *AuditService) GetEntry(ctx context.Context, id string) (*models.AuditLog, error) {
+	entryID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid audit entry ID", ErrAuditInvalid)
+	}
+	entry, err := s.auditRepo.GetByID(ctx, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrAuditNotFound, id)
+	}
+	return entry, nil
+}
+
+// parseAuditTime reads an RFC 3339 time or a date; endOfDay moves a date to the next midnight
+func parseAuditTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("use RFC 3339 or YYYY-MM-DD")
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// auditFields returns the JSON fields of an entity, so the log holds what the API showed
+func auditFields(entity interface{}) map[string]interface{} {
+	if entity == nil {
+		return nil
+	}
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// auditChanges returns the fields that differ between two snapshots with their old and new values
+func auditChanges(before, after map[string]interface{}) map[string]interface{} {
+	changes := make(map[string]interface{})
+	for field, old := range before {
+		if auditIgnoredFields[field] {
+			continue
+		}
+		if value := after[field]; !reflect.DeepEqual(old, value) {
+			changes[field] = map[string]interface{}{"before": old, "after": value}
+		}
+	}
+	for field, value := range after {
+		if _, seen := before[field]; !seen && !auditIgnoredFields[field] {
+			changes[field] = map[string]interface{}{"before": nil, "after": value}
+		}
+	}
+	return changes
+}
@@ -3,57 +3,38 @@ package services
 import (
 	"context"
 	"errors"
-	"fmt"
 	"golang-food-backend/internal/models"
 	"golang-food-backend/internal/repositories"
-	"golang-food-backend/pkg/auth"
 	"golang-food-backend/pkg/cache"
 	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService struct {
-	userRepo   repositories.UserRepository
-	jwtManager *auth.JWTManager
-	cache      *cache.RedisCache
+	userRepo       repositories.UserRepository
+	sessionService *SessionService
+	passwords      *PasswordService
+	cache          *cache.RedisCache
 }
 
-func NewAuthService(userRepo repositories.UserRepository, jwtManager *auth.JWTManager, cache *cache.RedisCache) *AuthService {
+func NewAuthService(userRepo repositories.UserRepository, sessionService *SessionService, passwords *PasswordService, cache *cache.RedisCache) *AuthService {
 	return &AuthService{
-		userRepo:   userRepo,
-		jwtManager: jwtManager,
-		cache:      cache,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		passwords:      passwords,
+		cache:          cache,
 	}
 }
 
-// Refresh token storage methods
-func (s *AuthService) storeRefreshToken(ctx context.Context, userID, refreshToken string, expiryDays int) error {
-	key := fmt.Sprintf("refresh_token:%s", userID)
-	expiry := time.Hour * 24 * time.Duration(expiryDays)
-	return s.cache.Set(ctx, key, refreshToken, expiry)
-}
-
-func (s *AuthService) getStoredRefreshToken(ctx context.Context, userID string) (string, error) {
-	key := fmt.Sprintf("refresh_token:%s", userID)
-	var token string
-	err := s.cache.Get(ctx, key, &token)
-	return token, err
-}
-
-func (s *AuthService) invalidateRefreshToken(ctx context.Context, userID string) error {
-	key := fmt.Sprintf("refresh_token:%s", userID)
-	return s.cache.Delete(ctx, key)
-}
-
 type RegisterRequest struct {
 	Name         string `json:"name" binding:"required"`
 	Email        string `json:"email" binding:"required,email"`
 	Phone        string `json:"phone" binding:"required"`
-	Password     string `json:"password" binding:"required,min=6"`
+	Password     string `json:"password" binding:"required"` // checked against the password policy
 	Role         string `json:"role"`
-	RestaurantID string `json:"restaurant_id"` // Required only for customers and restaurant staff
+	RestaurantID string `json:"restaurant_id"` // Required only for customers
+	DeviceInfo
 }
 
 type LoginRequest struct {
@@ -61,6 +42,7 @@ type LoginRequest struct {
 	Password     string `json:"password" binding:"required"`
 	Role         string `json:"role" binding:"required"` // Required to determine login flow
 	RestaurantID string `json:"restaurant_id"`           // Required only for customers
+	DeviceInfo
 }
 
 type AuthResponse struct {
@@ -77,6 +59,10 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*Auth
 	if role == "" {
 		role = "customer"
 	}
+	// Staff accounts are created when an owner's invitation is accepted
+	if role == "restaurant_staff" {
+		return nil, errors.New("restaurant staff join by invitation from the restaurant owner")
+	}
 
 	var restaurantID *uuid.UUID
 
@@ -123,18 +109,11 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*Auth
 		}
 	}
 
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return nil, err
-	}
-
 	// Create user
 	user := &models.User{
 		Name:         req.Name,
 		Email:        req.Email,
 		Phone:        req.Phone,
-		PasswordHash: string(hashedPassword),
 		Role:         role,
 		RestaurantID: restaurantID,
 		Status:       "active",
@@ -143,23 +122,24 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*Auth
 		UpdatedAt:    time.Now(),
 	}
 
-	if err := s.userRepo.Create(ctx, user); err != nil {
+	// Check and hash password
+	if err := s.passwords.CheckStrength(req.Password, user); err != nil {
 		return nil, err
 	}
-
-	// Generate token pair
-	restaurantIDStr := ""
-	if restaurantID != nil {
-		restaurantIDStr = restaurantID.String()
+	hashedPassword, err := s.passwords.Hash(req.Password)
+	if err != nil {
+		return nil, err
 	}
+	user.PasswordHash = hashedPassword
 
-	tokenPair, err := s.jwtManager.GenerateTokenPair(user.ID.String(), restaurantIDStr, user.Role, user.Email)
-	if err != nil {
+	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
+	s.passwords.Remember(ctx, user.ID, hashedPassword)
 
-	// Store refresh token in Redis (30 days expiry)
-	if err := s.storeRefreshToken(ctx, user.ID.String(), tokenPair.RefreshToken, 30); err != nil {
+	// Open a login session and issue its tokens
+	tokenPair, err := s.sessionService.StartSession(ctx, user, req.DeviceInfo, "register")
+	if err != nil {
 		return nil, err
 	}
 
@@ -215,27 +195,17 @@ func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (*AuthRespon
 		return nil, errors.New("account is not active")
 	}
 
-	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+	// Verify password, upgrading its hash if the hashing settings changed
+	if !s.passwords.Verify(ctx, user, req.Password) {
 		return nil, errors.New("invalid email or password")
 	}
 
-	// Generate token pair
-	restaurantIDStr := ""
-	if user.RestaurantID != nil {
-		restaurantIDStr = user.RestaurantID.String()
-	}
-
-	tokenPair, err := s.jwtManager.GenerateTokenPair(user.ID.String(), restaurantIDStr, user.Role, user.Email)
+	// Open a login session and issue its tokens
+	tokenPair, err := s.sessionService.StartSession(ctx, user, req.DeviceInfo, "password")
 	if err != nil {
 		return nil, err
 	}
 
-	// Store refresh token in Redis (30 days expiry)
-	if err := s.storeRefreshToken(ctx, user.ID.String(), tokenPair.RefreshToken, 30); err != nil {
-		return nil, err
-	}
-
 	// Cache user session
 	sessionKey := "user_session:" + user.ID.String()
 	s.cache.Set(ctx, sessionKey, user, time.Hour*24)
@@ -292,11 +262,7 @@ func (s *AuthService) UpdateProfile(ctx context.Context, userID string, updates
 		}
 	}
 
-	if phone, ok := updates["phone"]; ok {
-		if phoneStr, ok := phone.(string); ok {
-			user.Phone = phoneStr
-		}
-	}
+	// Phone numbers are changed through the verified contact change flow of the profile service
 
 	user.UpdatedAt = time.Now()
 
@@ -311,23 +277,12 @@ func (s *AuthService) UpdateProfile(ctx context.Context, userID string, updates
 	return nil
 }
 
-// RefreshAccessToken validates refresh token and generates new access token
+// RefreshAccessToken exchanges a refresh token for a new token pair. The presented refresh token
+// stops working; presenting it again revokes the session.
 func (s *AuthService) RefreshAccessToken(ctx context.Context, refreshToken string) (*AuthResponse, error) {
-	// Validate the refresh token
-	claims, err := s.jwtManager.ValidateToken(refreshToken)
+	claims, session, err := s.sessionService.ValidateRefreshToken(ctx, refreshToken)
 	if err != nil {
-		return nil, errors.New("invalid refresh token")
-	}
-
-	// Ensure this is a refresh token
-	if claims.TokenType != auth.RefreshToken {
-		return nil, errors.New("invalid token type: expected refresh token")
-	}
-
-	// Check if refresh token exists in storage
-	storedToken, err := s.getStoredRefreshToken(ctx, claims.UserID)
-	if err != nil || storedToken != refreshToken {
-		return nil, errors.New("refresh token not found or invalid")
+		return nil, err
 	}
 
 	// Get user details
@@ -345,29 +300,39 @@ func (s *AuthService) RefreshAccessToken(ctx context.Context, refreshToken strin
 		return nil, errors.New("account is not active")
 	}
 
-	// Generate new access token
-	newAccessToken, err := s.jwtManager.RefreshAccessToken(refreshToken)
+	tokenPair, err := s.sessionService.RotateSession(ctx, session, refreshToken, user)
 	if err != nil {
 		return nil, err
 	}
 
 	return &AuthResponse{
-		AccessToken:  newAccessToken,
-		RefreshToken: refreshToken, // Keep the same refresh token
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
 		TokenType:    "Bearer",
 		ExpiresIn:    3600, // 1 hour in seconds
 		User:         *user,
 	}, nil
 }
 
-// Logout invalidates the refresh token
-func (s *AuthService) Logout(ctx context.Context, userID string) error {
-	// Invalidate refresh token
-	if err := s.invalidateRefreshToken(ctx, userID); err != nil {
-		return err
+// Logout revokes the login session the request was made with
+func (s *AuthService) Logout(ctx context.Context, userID, sessionID string) error {
+	if sessionID != "" {
+		if err := s.sessionService.RevokeSession(ctx, userID, sessionID, "logout"); err != nil {
+			return err
+		}
 	}
 
 	// Remove user session from cache
 	sessionKey := "user_session:" + userID
 	return s.cache.Delete(ctx, sessionKey)
 }
+
+// LogoutAll revokes every login session of the user
+func (s *AuthService) LogoutAll(ctx context.Context, userID string) error {
+	if err := s.sessionService.RevokeAllSessions(ctx, userID); err != nil {
+		return err
+	}
+
+	sessionKey := "user_session:" + userID
+	return s.cache.Delete(ctx, sessionKey)
+}
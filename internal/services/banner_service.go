@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/cache"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Longest time a public banner list is served from cache
+const bannerCacheTTL = 5 * time.Minute
+
+var bannerDisplayLocations = map[string]bool{
+	"home":       true,
+	"offers":     true,
+	"restaurant": true,
+}
+
+type BannerService struct {
+	bannerRepo     repositories.BannerRepository
+	restaurantRepo repositories.RestaurantRepository
+	cache          *cache.RedisCache
+}
+
+func NewBannerService(
+	bannerRepo repositories.BannerRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	cache *cache.RedisCache,
+) *BannerService {
+	return &BannerService{
+		bannerRepo:     bannerRepo,
+		restaurantRepo: restaurantRepo,
+		cache:          cache,
+	}
+}
+
+type CreateBannerRequest struct {
+	Title           string    `json:"title" binding:"required"`
+	ImageUrl        string    `json:"image_url" binding:"required,url"`
+	TargetUrl       string    `json:"target_url"`
+	DisplayLocation string    `json:"display_location" binding:"required"` // home, offers, restaurant
+	RestaurantID    string    `json:"restaurant_id"`                       // empty for platform banners
+	StartDate       time.Time `json:"start_date" binding:"required"`
+	EndDate         time.Time `json:"end_date" binding:"required"`
+	Priority        int       `json:"priority"`
+	IsActive        *bool     `json:"is_active"`
+}
+
+type UpdateBannerRequest struct {
+	Title           *string    `json:"title"`
+	ImageUrl        *string    `json:"image_url" binding:"omitempty,url"`
+	TargetUrl       *string    `json:"target_url"`
+	DisplayLocation *string    `json:"display_location"`
+	StartDate       *time.Time `json:"start_date"`
+	EndDate         *time.Time `json:"end_date"`
+	Priority        *int       `json:"priority"`
+	IsActive        *bool      `json:"is_active"`
+}
+
+func bannerCacheKey(displayLocation, restaurantID string) string {
+	return fmt.Sprintf("banners:%s:%s", displayLocation, restaurantID)
+}
+
+// CreateBanner creates a platform-wide or restaurant-scoped banner
+func (s *BannerService) CreateBanner(ctx context.Context, req *CreateBannerRequest) (*models.Banner, error) {
+	if req.RestaurantID != "" {
+		if err := s.validateRestaurant(ctx, req.RestaurantID); err != nil {
+			return nil, err
+		}
+	}
+
+	banner := &models.Banner{
+		Title:           req.Title,
+		ImageUrl:        req.ImageUrl,
+		TargetUrl:       req.TargetUrl,
+		DisplayLocation: req.DisplayLocation,
+		RestaurantID:    req.RestaurantID,
+		StartDate:       req.StartDate,
+		EndDate:         req.EndDate,
+		Priority:        req.Priority,
+		IsActive:        true,
+	}
+	if req.IsActive != nil {
+		banner.IsActive = *req.IsActive
+	}
+
+	if err := validateBanner(banner); err != nil {
+		return nil, err
+	}
+
+	if err := s.bannerRepo.Create(ctx, banner); err != nil {
+		return nil, fmt.Errorf("failed to create banner: %v", err)
+	}
+
+	s.clearBannerCache(ctx, banner)
+	return banner, nil
+}
+
+func (s *BannerService) GetBanner(ctx context.Context, bannerID string) (*models.Banner, error) {
+	objectID, err := primitive.ObjectIDFromHex(bannerID)
+	if err != nil {
+		return nil, errors.New("invalid banner ID")
+	}
+
+	banner, err := s.bannerRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, errors.New("banner not found")
+	}
+
+	return banner, nil
+}
+
+// ListBanners returns banners for administration, optionally filtered by location and restaurant
+func (s *BannerService) ListBanners(ctx context.Context, displayLocation, restaurantID string, page, limit int) ([]models.Banner, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	banners, err := s.bannerRepo.List(ctx, displayLocation, restaurantID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list banners: %v", err)
+	}
+
+	return banners, nil
+}
+
+func (s *BannerService) UpdateBanner(ctx context.Context, bannerID string, req *UpdateBannerRequest) (*models.Banner, error) {
+	banner, err := s.GetBanner(ctx, bannerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The old placement must be evicted as well if the banner moves
+	s.clearBannerCache(ctx, banner)
+
+	if req.Title != nil {
+		banner.Title = *req.Title
+	}
+	if req.ImageUrl != nil {
+		banner.ImageUrl = *req.ImageUrl
+	}
+	if req.TargetUrl != nil {
+		banner.TargetUrl = *req.TargetUrl
+	}
+	if req.DisplayLocation != nil {
+		banner.DisplayLocation = *req.DisplayLocation
+	}
+	if req.StartDate != nil {
+		banner.StartDate = *req.StartDate
+	}
+	if req.EndDate != nil {
+		banner.EndDate = *req.EndDate
+	}
+	if req.Priority != nil {
+		banner.Priority = *req.Priority
+	}
+	if req.IsActive != nil {
+		banner.IsActive = *req.IsActive
+	}
+
+	if err := validateBanner(banner); err != nil {
+		return nil, err
+	}
+
+	if err := s.bannerRepo.Update(ctx, banner); err != nil {
+		return nil, fmt.Errorf("failed to update banner: %v", err)
+	}
+
+	s.clearBannerCache(ctx, banner)
+	return banner, nil
+}
+
+func (s *BannerService) DeleteBanner(ctx context.Context, bannerID string) error {
+	banner, err := s.GetBanner(ctx, bannerID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.bannerRepo.Delete(ctx, banner.ID); err != nil {
+		return fmt.Errorf("failed to delete banner: %v", err)
+	}
+
+	s.clearBannerCache(ctx, banner)
+	return nil
+}
+
+// GetActiveBanners returns the banners currently live at a display location, highest priority first.
+// Without a restaurant ID only platform banners are returned.
+func (s *BannerService) GetActiveBanners(ctx context.Context, displayLocation, restaurantID string) ([]models.Banner, error) {
+	if !bannerDisplayLocations[displayLocation] {
+		return nil, fmt.Errorf("invalid display location: %s", displayLocation)
+	}
+
+	cacheKey := bannerCacheKey(displayLocation, restaurantID)
+	var cached []models.Banner
+	if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+		return cached, nil
+	}
+
+	now := time.Now()
+	banners, err := s.bannerRepo.GetActive(ctx, displayLocation, restaurantID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get banners: %v", err)
+	}
+	if banners == nil {
+		banners = []models.Banner{}
+	}
+
+	// Expire the cache no later than the first banner goes off air
+	ttl := bannerCacheTTL
+	for _, banner := range banners {
+		if remaining := banner.EndDate.Sub(now); remaining < ttl {
+			ttl = remaining
+		}
+	}
+	if ttl > 0 {
+		s.cache.Set(ctx, cacheKey, banners, ttl)
+	}
+
+	return banners, nil
+}
+
+func (s *BannerService) validateRestaurant(ctx context.Context, restaurantID string) error {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return errors.New("invalid restaurant ID")
+	}
+
+	if _, err := s.restaurantRepo.GetByID(ctx, restaurantUUID); err != nil {
+		return errors.New("restaurant not found")
+	}
+
+	return nil
+}
+
+func (s *BannerService) clearBannerCache(ctx context.Context, banner *models.Banner) {
+	s.cache.Delete(ctx, bannerCacheKey(banner.DisplayLocation, banner.RestaurantID))
+}
+
+func validateBanner(banner *models.Banner) error {
+	if !bannerDisplayLocations[banner.DisplayLocation] {
+		return fmt.Errorf("invalid display location: %s", banner.DisplayLocation)
+	}
+	if banner.DisplayLocation == "restaurant" && banner.RestaurantID == "" {
+		return errors.New("restaurant banners require a restaurant ID")
+	}
+	if !banner.EndDate.After(banner.StartDate) {
+		return errors.New("end date must be after start date")
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/cache"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// What a blocklist entry bars
+const (
+	BlockKindUser   = "user"
+	BlockKindPhone  = "phone"
+	BlockKindDevice = "device"
+)
+
+const (
+	BlockSourceAdmin = "admin"
+	BlockSourceRisk  = "risk"
+)
+
+// How long a lookup, blocked or not, is cached; blocks and lifts clear it at once
+const blocklistCacheTTL = 5 * time.Minute
+
+var (
+	ErrBlocklistInvalid  = apperr.Validation("blocklist_invalid", "invalid blocklist entry")
+	ErrBlocklistNotFound = apperr.NotFound("blocklist_entry_not_found", "blocklist entry not found")
+	ErrBlocked           = apperr.Forbidden("blocked", "access has been blocked; please contact support")
+)
+
+// BlocklistService keeps the users, phone numbers and devices barred from OTPs, logins and
+// checkout, with lookups cached in Redis
+type BlocklistService struct {
+	repo     repositories.BlocklistRepository
+	sessions *SessionService
+	cache    *cache.RedisCache
+}
+
+func NewBlocklistService(repo repositories.BlocklistRepository, sessions *SessionService, cache *cache.RedisCache) *BlocklistService {
+	return &BlocklistService{
+		repo:     repo,
+		sessions: sessions,
+		cache:    cache,
+	}
+}
+
+// BlockSubject is who a request comes from. Empty fields are not checked.
+type BlockSubject struct {
+	UserID   string
+	Phone    string
+	DeviceID string
+}
+
+type BlockRequest struct {
+	Kind      string     `json:"kind" binding:"required,oneof=user phone device"`
+	Value     string     `json:"value" binding:"required"` // user ID, phone number with country code, or device ID
+	Reason    string     `json:"reason" binding:"required,max=500"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // RFC3339; the block is permanent without one
+}
+
+// BlocklistQuery filters blocklist listings
+type BlocklistQuery struct {
+	Kind       string
+	Value      string
+	Source     string
+	ActiveOnly bool
+	Page       int
+	Limit      int
+}
+
+type BlocklistResponse struct {
+	Entries    []models.BlocklistEntry `json:"entries"`
+	Pagination PaginationInfo          `json:"pagination"`
+}
+
+// cachedBlock is a cached lookup; Entry is nil when the subject is not blocked
+type cachedBlock struct {
+	Entry *models.BlocklistEntry `json:"entry"`
+}
+
+// Check returns ErrBlocked when the user, phone number or device is blocked. Lookup failures
+// are logged and let the request through.
+func (s *BlocklistService) Check(ctx context.Context, subject BlockSubject) error {
+	lookups := []struct{ kind, value string }{
+		{BlockKindUser, subject.UserID},
+		{BlockKindPhone, normalizeBlockedPhone(subject.Phone)},
+		{BlockKindDevice, strings.TrimSpace(subject.DeviceID)},
+	}
+	for _, lookup := range lookups {
+		if lookup.value == "" {
+			continue
+		}
+		entry, err := s.active(ctx, lookup.kind, lookup.value)
+		if err != nil {
+			log.Printf("Failed to check blocklist for %s %s: %v", lookup.kind, lookup.value, err)
+			continue
+		}
+		if entry != nil {
+			return ErrBlocked
+		}
+	}
+	return nil
+}
+
+// Block bars the subject. An active entry for it is extended with the new reason and expiry
+// instead of adding another. Blocking a user signs them out everywhere.
+func (s *BlocklistService) Block(ctx context.Context, adminID string, req *BlockRequest) (*models.BlocklistEntry, error) {
+	entry := &models.BlocklistEntry{
+		Kind:      req.Kind,
+		Value:     req.Value,
+		Reason:    strings.TrimSpace(req.Reason),
+		Source:    BlockSourceAdmin,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if adminUUID, err := uuid.Parse(adminID); err == nil {
+		entry.CreatedBy = &adminUUID
+	}
+	return s.block(ctx, entry)
+}
+
+// BlockAutomatically bars a subject the fraud rules caught, for the given time
+func (s *BlocklistService) BlockAutomatically(ctx context.Context, kind, value, reason string, duration time.Duration, assessmentID uuid.UUID) (*models.BlocklistEntry, error) {
+	expiresAt := time.Now().Add(duration)
+	return s.block(ctx, &models.BlocklistEntry{
+		Kind:             kind,
+		Value:            value,
+		Reason:           reason,
+		Source:           BlockSourceRisk,
+		RiskAssessmentID: &assessmentID,
+		ExpiresAt:        &expiresAt,
+	})
+}
+
+func (s *BlocklistService) block(ctx context.Context, entry *models.BlocklistEntry) (*models.BlocklistEntry, error) {
+	value, err := normalizeBlockValue(entry.Kind, entry.Value)
+	if err != nil {
+		return nil, err
+	}
+	entry.Value = value
+	if entry.Reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrBlocklistInvalid)
+	}
+	now := time.Now()
+	if entry.ExpiresAt != nil && !entry.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrBlocklistInvalid)
+	}
+
+	existing, err := s.repo.GetActive(ctx, entry.Kind, entry.Value, now)
+	switch {
+	case err == nil:
+		// A shorter automatic block never cuts a longer one short
+		if entry.Source == BlockSourceRisk && (existing.ExpiresAt == nil || existing.ExpiresAt.After(*entry.ExpiresAt)) {
+			return existing, nil
+		}
+		existing.Reason = entry.Reason
+		existing.Source = entry.Source
+		existing.RiskAssessmentID = entry.RiskAssessmentID
+		existing.ExpiresAt = entry.ExpiresAt
+		if entry.CreatedBy != nil {
+			existing.CreatedBy = entry.CreatedBy
+		}
+		if err := s.repo.Update(ctx, existing); err != nil {
+			return nil, fmt.Errorf("failed to update blocklist entry: %v", err)
+		}
+		entry = existing
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if err := s.repo.Create(ctx, entry); err != nil {
+			return nil, fmt.Errorf("failed to create blocklist entry: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("failed to load blocklist entry: %v", err)
+	}
+
+	s.forget(ctx, entry.Kind, entry.Value)
+	if entry.Kind == BlockKindUser {
+		userUUID, _ := uuid.Parse(entry.Value)
+		if err := s.sessions.SignOutUser(ctx, userUUID, "blocked"); err != nil {
+			log.Printf("Failed to sign out blocked user %s: %v", entry.Value, err)
+		}
+	}
+	return entry, nil
+}
+
+// Lift ends a block early; the entry is kept as history
+func (s *BlocklistService) Lift(ctx context.Context, adminID, entryID string) (*models.BlocklistEntry, error) {
+	entry, err := s.GetEntry(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry.LiftedAt != nil {
+		return entry, nil
+	}
+
+	now := time.Now()
+	entry.LiftedAt = &now
+	if adminUUID, err := uuid.Parse(adminID); err == nil {
+		entry.LiftedBy = &adminUUID
+	}
+	if err := s.repo.Update(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to lift blocklist entry: %v", err)
+	}
+	s.forget(ctx, entry.Kind, entry.Value)
+	return entry, nil
+}
+
+// GetEntry returns one blocklist entry
+func (s *BlocklistService) GetEntry(ctx context.Context, entryID string) (*models.BlocklistEntry, error) {
+	entryUUID, err := uuid.Parse(entryID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid entry ID", ErrBlocklistInvalid)
+	}
+	entry, err := s.repo.GetByID(ctx, entryUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBlocklistNotFound
+		}
+		return nil, fmt.Errorf("failed to load blocklist entry: %v", err)
+	}
+	return entry, nil
+}
+
+// List lists blocklist entries, newest first
+func (s *BlocklistService) List(ctx context.Context, query BlocklistQuery) (*BlocklistResponse, error) {
+	filter := repositories.BlocklistFilter{Kind: query.Kind, Source: query.Source}
+	if query.Value != "" {
+		if query.Kind == "" {
+			return nil, fmt.Errorf("%w: filtering by value needs a kind", ErrBlocklistInvalid)
+		}
+		value, err := normalizeBlockValue(query.Kind, query.Value)
+		if err != nil {
+			return nil, err
+		}
+		filter.Value = value
+	}
+	if query.ActiveOnly {
+		filter.ActiveAt = time.Now()
+	}
+
+	page, limit := normalizePage(query.Page, query.Limit)
+	entries, total, err := s.repo.List(ctx, filter, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocklist: %v", err)
+	}
+	return &BlocklistResponse{
+		Entries: entries,
+		Pagination: PaginationInfo{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		},
+	}, nil
+}
+
+// active returns the subject's active entry, or nil, reading through the cache
+func (s *BlocklistService) active(ctx context.Context, kind, value string) (*models.BlocklistEntry, error) {
+	key := blocklistCacheKey(kind, value)
+	var cached cachedBlock
+	if err := s.cache.Get(ctx, key, &cached); err == nil {
+		if cached.Entry != nil && cached.Entry.ExpiresAt != nil && !cached.Entry.ExpiresAt.After(time.Now()) {
+			return nil, nil
+		}
+		return cached.Entry, nil
+	}
+
+	entry, err := s.repo.GetActive(ctx, kind, value, time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		entry = nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Set(ctx, key, cachedBlock{Entry: entry}, blocklistCacheTTL); err != nil {
+		log.Printf("Failed to cache blocklist lookup: %v", err)
+	}
+	return entry, nil
+}
+
+func (s *BlocklistService) forget(ctx context.Context, kind, value string) {
+	if err := s.cache.Delete(ctx, blocklistCacheKey(kind, value)); err != nil {
+		log.Printf("Failed to clear cached blocklist lookup: %v", err)
+	}
+}
+
+func blocklistCacheKey(kind, value string) string {
+	return "blocklist:" + kind + ":" + value
+}
+
+func normalizeBlockValue(kind, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch kind {
+	case BlockKindUser:
+		userUUID, err := uuid.Parse(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: value must be a user ID", ErrBlocklistInvalid)
+		}
+		return userUUID.String(), nil
+	case BlockKindPhone:
+		phone := normalizeBlockedPhone(value)
+		if len(phone) < 6 {
+			return "", fmt.Errorf("%w: value must be a phone number", ErrBlocklistInvalid)
+		}
+		return phone, nil
+	case BlockKindDevice:
+		if value == "" || len(value) > 128 {
+			return "", fmt.Errorf("%w: value must be a device ID of up to 128 characters", ErrBlocklistInvalid)
+		}
+		return value, nil
+	}
+	return "", fmt.Errorf("%w: kind must be user, phone or device", ErrBlocklistInvalid)
+}
+
+// normalizeBlockedPhone keeps only the digits, so formatting differences do not dodge a block
+func normalizeBlockedPhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/messaging"
+
+	"github.com/google/uuid"
+)
+
+const (
+	cartAbandonBatchSize = 200
+
+	// cartRecoveryWindow is how long an abandoned cart is brought back, items and all, when
+	// its user returns to the restaurant
+	cartRecoveryWindow = 30 * 24 * time.Hour
+)
+
+// CartAbandonmentPolicy sets when an unused cart counts as abandoned and the coupon offered
+// in the recovery campaign. A zero After turns abandonment off.
+type CartAbandonmentPolicy struct {
+	After              time.Duration
+	RecoveryCouponCode string
+}
+
+// SetAbandonmentPolicy configures cart abandonment; carts never expire without it
+func (s *CartService) SetAbandonmentPolicy(policy CartAbandonmentPolicy) {
+	s.abandonment = policy
+}
+
+// AbandonInactiveCarts marks active carts unused for the abandonment period as abandoned. Carts
+// with items get a cart_abandoned event for notification campaigns, written with the change.
+func (s *CartService) AbandonInactiveCarts(ctx context.Context) (int, error) {
+	if s.abandonment.After <= 0 {
+		return 0, nil
+	}
+
+	before := time.Now().Add(-s.abandonment.After)
+	abandoned := 0
+	for {
+		carts, err := s.cartRepo.GetInactive(ctx, before, cartAbandonBatchSize)
+		if err != nil {
+			return abandoned, fmt.Errorf("failed to load inactive carts: %v", err)
+		}
+
+		for i := range carts {
+			err := s.abandonCart(ctx, &carts[i])
+			if errors.Is(err, repositories.ErrVersionConflict) {
+				continue // used again while we looked at it
+			}
+			if err != nil {
+				return abandoned, fmt.Errorf("failed to abandon cart %s: %v", carts[i].ID, err)
+			}
+			abandoned++
+		}
+
+		if len(carts) < cartAbandonBatchSize {
+			return abandoned, nil
+		}
+	}
+}
+
+func (s *CartService) abandonCart(ctx context.Context, cart *models.Cart) error {
+	lastActivity := cart.UpdatedAt
+	now := time.Now()
+	cart.Status = "abandoned"
+	cart.AbandonedAt = &now
+
+	var events []models.OutboxEvent
+	items := models.DecodeCartItems(cart.Items)
+	if len(items) > 0 {
+		// Price at current prices for the campaign; the stored total is good enough if a
+		// product has gone since
+		if _, total, err := priceCartItems(ctx, s.productRepo, items); err == nil {
+			cart.TotalAmount = total
+		}
+		event, err := cartAbandonedEvent(cart, items, lastActivity, s.abandonment.RecoveryCouponCode)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+
+	if err := s.cartRepo.UpdateWithEvents(ctx, cart, events); err != nil {
+		return err
+	}
+	s.clearCartCache(ctx, cart.UserID.String())
+	return nil
+}
+
+// cartAbandonedEvent is the CartAbandoned contract event for a cart that was just abandoned
+func cartAbandonedEvent(cart *models.Cart, items []models.CartItem, lastActivity time.Time, couponCode string) (models.OutboxEvent, error) {
+	abandoned := messaging.CartAbandoned{
+		CartID:         cart.ID.String(),
+		UserID:         cart.UserID.String(),
+		RestaurantID:   cart.RestaurantID.String(),
+		TotalAmount:    cart.TotalAmount.Float64(),
+		Items:          make([]messaging.OrderEventItem, 0, len(items)),
+		LastActivityAt: lastActivity,
+		AbandonedAt:    *cart.AbandonedAt,
+		CouponCode:     couponCode,
+	}
+	for _, item := range items {
+		abandoned.ItemCount += item.Quantity
+		abandoned.Items = append(abandoned.Items, messaging.OrderEventItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+
+	envelope, err := messaging.Events.NewEnvelope(messaging.EventCartAbandoned, cart.RestaurantID.String(), abandoned)
+	if err != nil {
+		return models.OutboxEvent{}, err
+	}
+	return NewOutboxEvent("cart_events", cart.UserID.String(), envelope)
+}
+
+// recoverAbandonedCart makes the user's recently abandoned cart for the restaurant active
+// again. Its abandoned_at is kept so the recovery shows in the cart metrics.
+func (s *CartService) recoverAbandonedCart(ctx context.Context, userID, restaurantID uuid.UUID) (*models.Cart, error) {
+	cart, err := s.cartRepo.GetAbandoned(ctx, userID, restaurantID, time.Now().Add(-cartRecoveryWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	cart.Status = "active"
+	cart.UpdatedAt = time.Now()
+	if err := s.cartRepo.Update(ctx, cart); err != nil {
+		return nil, err
+	}
+	log.Printf("Recovered abandoned cart %s", cart.ID)
+	return cart, nil
+}
@@ -2,23 +2,46 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"golang-food-backend/internal/models"
 	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
 	"golang-food-backend/pkg/cache"
+	"golang-food-backend/pkg/i18n"
+	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var (
+	ErrCartNotFound = apperr.NotFound("cart_not_found", "cart not found")
+	// ErrCartRestaurantMismatch is returned when adding a product to the cart of another restaurant
+	ErrCartRestaurantMismatch = apperr.Validation("cart_restaurant_mismatch", "product belongs to a different restaurant")
+	// ErrBelowMinimumOrder is returned at checkout when the subtotal is below the minimum order
+	// value of the delivery boundary covering the address
+	ErrBelowMinimumOrder = apperr.Unprocessable("below_minimum_order", "order is below the minimum order value")
+)
+
 type CartService struct {
 	cartRepo    repositories.CartRepository
 	productRepo repositories.ProductRepository
 	orderRepo   repositories.OrderRepository
 	paymentRepo repositories.PaymentRepository
+	restaurants *RestaurantService
+	coupons     *CouponService
+	taxService  *TaxService
+	deliveryFee *DeliveryFeeService
+	pricing     *PricingService
+	tracking    *OrderTrackingService
+	risk        *RiskService
+	tables      *TableService
 	cache       *cache.RedisCache
+	abandonment CartAbandonmentPolicy
 }
 
 func NewCartService(
@@ -26,6 +49,14 @@ func NewCartService(
 	productRepo repositories.ProductRepository,
 	orderRepo repositories.OrderRepository,
 	paymentRepo repositories.PaymentRepository,
+	restaurants *RestaurantService,
+	coupons *CouponService,
+	taxService *TaxService,
+	deliveryFee *DeliveryFeeService,
+	pricing *PricingService,
+	tracking *OrderTrackingService,
+	risk *RiskService,
+	tables *TableService,
 	cache *cache.RedisCache,
 ) *CartService {
 	return &CartService{
@@ -33,18 +64,34 @@ func NewCartService(
 		productRepo: productRepo,
 		orderRepo:   orderRepo,
 		paymentRepo: paymentRepo,
+		restaurants: restaurants,
+		coupons:     coupons,
+		taxService:  taxService,
+		deliveryFee: deliveryFee,
+		pricing:     pricing,
+		tracking:    tracking,
+		risk:        risk,
+		tables:      tables,
 		cache:       cache,
 	}
 }
 
 type AddToCartRequest struct {
-	ProductID string `json:"product_id" binding:"required"`
-	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+	ProductID string   `json:"product_id" binding:"required"`
+	Quantity  int      `json:"quantity" binding:"required,gt=0"`
+	VariantID string   `json:"variant_id,omitempty"`
+	AddonIDs  []string `json:"addon_ids,omitempty"`
+	OptionIDs []string `json:"option_ids,omitempty"` // options chosen from the product's option groups
 }
 
 type UpdateCartItemRequest struct {
-	ProductID string `json:"product_id" binding:"required"`
-	Quantity  int    `json:"quantity" binding:"required,gte=0"`
+	RestaurantID string   `json:"restaurant_id,omitempty"` // cart to update; defaults to the current cart
+	ProductID    string   `json:"product_id" binding:"required"`
+	Quantity     int      `json:"quantity" binding:"required,gte=0"`
+	ItemKey      string   `json:"item_key,omitempty"` // line to update; defaults to the line matching the product and selection
+	VariantID    string   `json:"variant_id,omitempty"`
+	AddonIDs     []string `json:"addon_ids,omitempty"`
+	OptionIDs    []string `json:"option_ids,omitempty"`
 }
 
 type CartResponse struct {
@@ -52,12 +99,25 @@ type CartResponse struct {
 	Items []CartItemResponse `json:"items"`
 }
 
+// CartListResponse lists the user's active carts, the current one first
+type CartListResponse struct {
+	CurrentRestaurantID *uuid.UUID     `json:"current_restaurant_id,omitempty"`
+	Carts               []CartResponse `json:"carts"`
+}
+
 type CartItemResponse struct {
-	ProductID   string  `json:"product_id"`
-	ProductName string  `json:"product_name,omitempty"`
-	Quantity    int     `json:"quantity"`
-	Price       float64 `json:"price"`
-	Total       float64 `json:"total"`
+	ItemKey     string                  `json:"item_key"`
+	ProductID   string                  `json:"product_id"`
+	ProductName string                  `json:"product_name,omitempty"`
+	CategoryID  string                  `json:"category_id,omitempty"`
+	VariantID   string                  `json:"variant_id,omitempty"`
+	VariantName string                  `json:"variant_name,omitempty"`
+	Addons      []models.SelectedAddon  `json:"addons,omitempty"`
+	Options     []models.SelectedOption `json:"options,omitempty"`
+	Quantity    int                     `json:"quantity"`
+	Price       models.Money            `json:"price"` // unit price including variant, addons and options
+	Total       models.Money            `json:"total"`
+	Components  []models.LineComponent  `json:"components,omitempty"` // a combo's items, per combo
 }
 
 type BillSummaryRequest struct {
@@ -67,21 +127,52 @@ type BillSummaryRequest struct {
 }
 
 type BillSummaryResponse struct {
-	SubTotal       float64            `json:"sub_total"`
-	CouponDetails  *CouponDetails     `json:"coupon_details,omitempty"`
-	DeliveryCharge float64            `json:"delivery_charge"`
-	TaxAmount      float64            `json:"tax_amount"`
-	PackagingFee   float64            `json:"packaging_fee"`
-	TotalAmount    float64            `json:"total_amount"`
-	Items          []CartItemResponse `json:"items"`
+	OrderType         string             `json:"order_type"` // delivery, pickup, dine_in
+	SubTotal          models.Money       `json:"sub_total"`
+	CouponDetails     *CouponDetails     `json:"coupon_details,omitempty"`
+	CouponMessage     string             `json:"coupon_message,omitempty"` // why the cart's coupon was not applied
+	DeliveryCharge    models.Money       `json:"delivery_charge"`
+	DeliveryQuote     *DeliveryFeeQuote  `json:"delivery_quote"` // null for pickup and dine-in
+	TaxAmount         models.Money       `json:"tax_amount"`
+	PackagingFee      models.Money       `json:"packaging_fee"`
+	PlatformFee       models.Money       `json:"platform_fee"`
+	SmallOrderFee     models.Money       `json:"small_order_fee"`
+	LateNightFee      models.Money       `json:"late_night_fee"`
+	TotalAmount       models.Money       `json:"total_amount"`
+	MinOrderValue     models.Money       `json:"min_order_value"`     // minimum subtotal for the address; 0 when there is none
+	MinOrderShortfall models.Money       `json:"min_order_shortfall"` // what has to be added to the cart before checkout
+	TaxBreakdown      *TaxBreakdown      `json:"tax_breakdown"`
+	Pricing           *PricingResult     `json:"pricing"` // fee and surge rules applied
+	Items             []CartItemResponse `json:"items"`
+	Currency          string             `json:"currency"` // ISO 4217 code of the amounts
+	Language          string             `json:"language"` // language of the line labels
+	Lines             []BillLine         `json:"lines"`    // the bill as shown to the customer, labelled and formatted
+}
+
+// BillLine is one labelled line of a bill with its amount formatted in the bill's currency
+type BillLine struct {
+	Key       string       `json:"key"` // item_total, coupon_discount, delivery_fee, taxes, packaging_fee, platform_fee, small_order_fee, late_night_fee, total
+	Label     string       `json:"label"`
+	Amount    models.Money `json:"amount"`
+	Formatted string       `json:"formatted"`
 }
 
 type CouponDetails struct {
-	CouponCode     string  `json:"coupon_code"`
-	DiscountType   string  `json:"discount_type"` // percentage, fixed
-	DiscountValue  float64 `json:"discount_value"`
-	MaxDiscount    float64 `json:"max_discount,omitempty"`
-	DiscountAmount float64 `json:"discount_amount"`
+	CouponID       string       `json:"coupon_id"`
+	CouponCode     string       `json:"coupon_code"`
+	DiscountType   string       `json:"discount_type"` // percentage, fixed
+	DiscountValue  float64      `json:"discount_value"`
+	MaxDiscount    models.Money `json:"max_discount,omitempty"`
+	DiscountAmount models.Money `json:"discount_amount"`
+	AutoApplied    bool         `json:"auto_applied,omitempty"` // picked as the best offer rather than entered
+}
+
+// ApplicableCouponsResponse lists the coupons that apply to the cart, largest discount first
+type ApplicableCouponsResponse struct {
+	SubTotal        models.Money    `json:"sub_total"`
+	AppliedCouponID *uuid.UUID      `json:"applied_coupon_id,omitempty"`
+	Best            *AppliedCoupon  `json:"best,omitempty"`
+	Coupons         []AppliedCoupon `json:"coupons"`
 }
 
 type CheckoutRequest struct {
@@ -91,14 +182,29 @@ type CheckoutRequest struct {
 }
 
 type CheckoutResponse struct {
-	OrderID       string  `json:"order_id"`
-	PaymentID     string  `json:"payment_id"`
-	TotalAmount   float64 `json:"total_amount"`
-	PaymentMethod string  `json:"payment_method"`
-	Status        string  `json:"status"`
+	OrderID       string       `json:"order_id"`
+	OrderType     string       `json:"order_type"`
+	TableNumber   string       `json:"table_number,omitempty"` // dine-in
+	TabID         string       `json:"tab_id,omitempty"`       // running tab the dine-in order was added to
+	PaymentID     string       `json:"payment_id"`
+	TotalAmount   models.Money `json:"total_amount"`
+	PaymentMethod string       `json:"payment_method"`
+	Status        string       `json:"status"`
+	PromisedAt    *time.Time   `json:"promised_at,omitempty"` // when the order should arrive, or be ready to collect
 }
 
 func (s *CartService) GetOrCreateCart(ctx context.Context, userID, restaurantID string) (*CartResponse, error) {
+	cart, err := s.getOrCreateCart(ctx, userID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildCartResponse(ctx, cart)
+}
+
+// getOrCreateCart returns the user's active cart for the restaurant, recovering a recently
+// abandoned one or creating an empty one if there is none. Carts for other restaurants are
+// left as they are.
+func (s *CartService) getOrCreateCart(ctx context.Context, userID, restaurantID string) (*models.Cart, error) {
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
 		return nil, errors.New("invalid user ID")
@@ -110,13 +216,17 @@ func (s *CartService) GetOrCreateCart(ctx context.Context, userID, restaurantID
 	}
 
 	// Try to get existing active cart
-	cart, err := s.cartRepo.GetByUserID(ctx, userUUID)
-	if err != nil || cart.RestaurantID != restUUID {
+	cart, err := s.cartRepo.GetByUserAndRestaurant(ctx, userUUID, restUUID)
+	if err != nil {
+		if recovered, err := s.recoverAbandonedCart(ctx, userUUID, restUUID); err == nil {
+			return recovered, nil
+		}
+
 		// Create new cart
 		cart = &models.Cart{
 			UserID:       userUUID,
 			RestaurantID: restUUID,
-			Items:        models.JSONB{},
+			Items:        models.EncodeCartItems(nil),
 			TotalAmount:  0,
 			Status:       "active",
 			CreatedAt:    time.Now(),
@@ -124,69 +234,146 @@ func (s *CartService) GetOrCreateCart(ctx context.Context, userID, restaurantID
 		}
 
 		if err := s.cartRepo.Create(ctx, cart); err != nil {
+			// A concurrent request may have created it first; the unique index keeps one
+			if existing, getErr := s.cartRepo.GetByUserAndRestaurant(ctx, userUUID, restUUID); getErr == nil {
+				return existing, nil
+			}
 			return nil, err
 		}
 	}
 
-	return s.buildCartResponse(ctx, cart)
+	return cart, nil
 }
 
-func (s *CartService) AddToCart(ctx context.Context, userID, restaurantID string, req *AddToCartRequest) (*CartResponse, error) {
-	// Get or create cart
-	cartResponse, err := s.GetOrCreateCart(ctx, userID, restaurantID)
+// cartFor returns the user's active cart for the restaurant, or the current cart when no
+// restaurant is given
+func (s *CartService) cartFor(ctx context.Context, userID uuid.UUID, restaurantID string) (*models.Cart, error) {
+	var cart *models.Cart
+	var err error
+	if restaurantID == "" {
+		cart, err = s.cartRepo.GetByUserID(ctx, userID)
+	} else {
+		restUUID, parseErr := uuid.Parse(restaurantID)
+		if parseErr != nil {
+			return nil, errors.New("invalid restaurant ID")
+		}
+		cart, err = s.cartRepo.GetByUserAndRestaurant(ctx, userID, restUUID)
+	}
 	if err != nil {
-		return nil, err
+		return nil, ErrCartNotFound
 	}
+	return cart, nil
+}
 
-	cart := cartResponse.Cart
-
-	// Verify product belongs to the same restaurant
-	// This would involve getting product from MongoDB and checking restaurant_id
-	// For now, we'll assume it's valid
+// ListCarts returns every active cart of the user, priced at current prices, the current one first
+func (s *CartService) ListCarts(ctx context.Context, userID string) (*CartListResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
 
-	// Parse existing items
-	var items []models.CartItem
-	if cart.Items != nil {
-		itemsJson, _ := json.Marshal(cart.Items)
-		json.Unmarshal(itemsJson, &items)
+	carts, err := s.cartRepo.GetActiveByUserID(ctx, userUUID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check if item already exists
-	found := false
-	for i, item := range items {
-		if item.ProductID == req.ProductID {
-			items[i].Quantity += req.Quantity
-			found = true
-			break
+	response := &CartListResponse{Carts: make([]CartResponse, 0, len(carts))}
+	for i := range carts {
+		cartResponse, err := s.buildCartResponse(ctx, &carts[i])
+		if err != nil {
+			return nil, err
 		}
+		response.Carts = append(response.Carts, *cartResponse)
 	}
+	if len(carts) > 0 {
+		response.CurrentRestaurantID = &carts[0].RestaurantID
+	}
+	return response, nil
+}
 
-	if !found {
-		// Add new item
-		newItem := models.CartItem{
-			ProductID: req.ProductID,
-			Quantity:  req.Quantity,
+// SwitchCart makes the user's cart for the restaurant the current one, creating it if needed.
+// Item, coupon and clear requests without a restaurant act on the current cart.
+func (s *CartService) SwitchCart(ctx context.Context, userID, restaurantID string) (*CartResponse, error) {
+	var response *CartResponse
+	err := retryOnConflict(ctx, func() error {
+		cart, err := s.getOrCreateCart(ctx, userID, restaurantID)
+		if err != nil {
+			return err
 		}
-		items = append(items, newItem)
+		response, err = s.saveCart(ctx, cart)
+		return err
+	})
+	return response, err
+}
+
+// AddToCart adds the product to the user's cart for its restaurant, which becomes the current
+// cart. Without a restaurant ID the product's restaurant is used.
+func (s *CartService) AddToCart(ctx context.Context, userID, restaurantID string, req *AddToCartRequest) (*CartResponse, error) {
+	newItem, restaurantID, err := s.newCartItem(ctx, restaurantID, req)
+	if err != nil {
+		return nil, err
 	}
 
-	// Update cart
-	itemsMap := make(map[string]interface{})
-	itemsJson, _ := json.Marshal(items)
-	json.Unmarshal(itemsJson, &itemsMap)
+	var response *CartResponse
+	err = retryOnConflict(ctx, func() error {
+		cart, err := s.getOrCreateCart(ctx, userID, restaurantID)
+		if err != nil {
+			return err
+		}
 
-	cart.Items = itemsMap
-	// TotalAmount will be calculated in buildCartResponse with current prices
-	cart.UpdatedAt = time.Now()
+		cart.Items = models.EncodeCartItems(addCartItem(models.DecodeCartItems(cart.Items), newItem))
+		response, err = s.saveCart(ctx, cart)
+		return err
+	})
+	return response, err
+}
 
-	if err := s.cartRepo.Update(ctx, cart); err != nil {
-		return nil, err
-	}
+// replaceCartItems sets the lines of the user's cart for the restaurant, which becomes the
+// current cart
+func (s *CartService) replaceCartItems(ctx context.Context, userID, restaurantID string, items []models.CartItem) (*CartResponse, error) {
+	var response *CartResponse
+	err := retryOnConflict(ctx, func() error {
+		cart, err := s.getOrCreateCart(ctx, userID, restaurantID)
+		if err != nil {
+			return err
+		}
 
-	// Clear cache
-	s.clearCartCache(userID)
+		cart.Items = models.EncodeCartItems(items)
+		response, err = s.saveCart(ctx, cart)
+		return err
+	})
+	return response, err
+}
 
-	return s.buildCartResponse(ctx, cart)
+// newCartItem checks the product exists, belongs to the restaurant (the product's restaurant
+// when none is given) and offers the selection, and returns the cart line with its restaurant
+func (s *CartService) newCartItem(ctx context.Context, restaurantID string, req *AddToCartRequest) (models.CartItem, string, error) {
+	newItem := models.CartItem{
+		ProductID: req.ProductID,
+		Quantity:  req.Quantity,
+		VariantID: req.VariantID,
+		AddonIDs:  normalizeAddonIDs(req.AddonIDs),
+		OptionIDs: normalizeAddonIDs(req.OptionIDs),
+	}
+
+	productID, err := primitive.ObjectIDFromHex(req.ProductID)
+	if err != nil {
+		return newItem, "", errors.New("invalid product ID")
+	}
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return newItem, "", errors.New("product not found")
+	}
+	if restaurantID == "" {
+		restaurantID = product.RestaurantID
+	}
+	if product.RestaurantID != restaurantID {
+		return newItem, "", fmt.Errorf("%w: add it to the cart for restaurant %s instead", ErrCartRestaurantMismatch, product.RestaurantID)
+	}
+	if _, err := priceCartItem(product, newItem); err != nil {
+		return newItem, "", err
+	}
+	return newItem, restaurantID, nil
 }
 
 func (s *CartService) UpdateCartItem(ctx context.Context, userID string, req *UpdateCartItemRequest) (*CartResponse, error) {
@@ -195,84 +382,94 @@ func (s *CartService) UpdateCartItem(ctx context.Context, userID string, req *Up
 		return nil, errors.New("invalid user ID")
 	}
 
-	cart, err := s.cartRepo.GetByUserID(ctx, userUUID)
-	if err != nil {
-		return nil, errors.New("cart not found")
-	}
-
-	// Parse existing items
-	var items []models.CartItem
-	if cart.Items != nil {
-		itemsJson, _ := json.Marshal(cart.Items)
-		json.Unmarshal(itemsJson, &items)
+	key := req.ItemKey
+	if key == "" {
+		key = models.CartItem{ProductID: req.ProductID, VariantID: req.VariantID, AddonIDs: normalizeAddonIDs(req.AddonIDs), OptionIDs: normalizeAddonIDs(req.OptionIDs)}.Key()
 	}
 
-	// Update or remove item
-	for i, item := range items {
-		if item.ProductID == req.ProductID {
-			if req.Quantity == 0 {
-				// Remove item
-				items = append(items[:i], items[i+1:]...)
-			} else {
-				// Update quantity
-				items[i].Quantity = req.Quantity
-			}
-			break
+	var response *CartResponse
+	err = retryOnConflict(ctx, func() error {
+		cart, err := s.cartFor(ctx, userUUID, req.RestaurantID)
+		if err != nil {
+			return err
 		}
-	}
 
-	// Update cart
-	itemsMap := make(map[string]interface{})
-	itemsJson, _ := json.Marshal(items)
-	json.Unmarshal(itemsJson, &itemsMap)
+		cart.Items = models.EncodeCartItems(setCartItemQuantity(models.DecodeCartItems(cart.Items), key, req.Quantity))
+		response, err = s.saveCart(ctx, cart)
+		return err
+	})
+	return response, err
+}
 
-	cart.Items = itemsMap
-	// TotalAmount will be calculated in buildCartResponse with current prices
-	cart.UpdatedAt = time.Now()
+// RemoveFromCart removes a cart line by its item key; a plain product ID removes the
+// product's line without a variant or addons
+func (s *CartService) RemoveFromCart(ctx context.Context, userID, restaurantID, itemKey string) (*CartResponse, error) {
+	return s.UpdateCartItem(ctx, userID, removeCartItemRequest(restaurantID, itemKey))
+}
 
-	if err := s.cartRepo.Update(ctx, cart); err != nil {
-		return nil, err
+func removeCartItemRequest(restaurantID, itemKey string) *UpdateCartItemRequest {
+	return &UpdateCartItemRequest{
+		RestaurantID: restaurantID,
+		ProductID:    strings.SplitN(itemKey, ":", 2)[0],
+		ItemKey:      itemKey,
+		Quantity:     0,
 	}
+}
 
-	// Clear cache
-	s.clearCartCache(userID)
-
-	return s.buildCartResponse(ctx, cart)
+// addCartItem adds an item to the cart lines; the same product with the same selection is one line
+func addCartItem(items []models.CartItem, newItem models.CartItem) []models.CartItem {
+	for i, item := range items {
+		if item.Key() == newItem.Key() {
+			items[i].Quantity += newItem.Quantity
+			return items
+		}
+	}
+	return append(items, newItem)
 }
 
-func (s *CartService) RemoveFromCart(ctx context.Context, userID, productID string) (*CartResponse, error) {
-	req := &UpdateCartItemRequest{
-		ProductID: productID,
-		Quantity:  0,
+// setCartItemQuantity updates the quantity of the line with the key, removing it at zero
+func setCartItemQuantity(items []models.CartItem, key string, quantity int) []models.CartItem {
+	for i, item := range items {
+		if item.Key() == key {
+			if quantity == 0 {
+				return append(items[:i], items[i+1:]...)
+			}
+			items[i].Quantity = quantity
+			break
+		}
 	}
-	return s.UpdateCartItem(ctx, userID, req)
+	return items
 }
 
-func (s *CartService) ClearCart(ctx context.Context, userID string) error {
+// ClearCart empties the user's cart for the restaurant, or the current cart
+func (s *CartService) ClearCart(ctx context.Context, userID, restaurantID string) error {
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
 		return errors.New("invalid user ID")
 	}
 
-	cart, err := s.cartRepo.GetByUserID(ctx, userUUID)
-	if err != nil {
-		return errors.New("cart not found")
-	}
-
-	cart.Items = models.JSONB{}
-	cart.TotalAmount = 0
-	cart.UpdatedAt = time.Now()
+	err = retryOnConflict(ctx, func() error {
+		cart, err := s.cartFor(ctx, userUUID, restaurantID)
+		if err != nil {
+			return err
+		}
 
-	if err := s.cartRepo.Update(ctx, cart); err != nil {
+		cart.Items = models.EncodeCartItems(nil)
+		cart.TotalAmount = 0
+		cart.UpdatedAt = time.Now()
+		return s.cartRepo.Update(ctx, cart)
+	})
+	if err != nil {
 		return err
 	}
 
 	// Clear cache
-	s.clearCartCache(userID)
+	s.clearCartCache(ctx, userID)
 
 	return nil
 }
 
+// GetCart returns the user's current cart
 func (s *CartService) GetCart(ctx context.Context, userID string) (*CartResponse, error) {
 	// Try cache first
 	cacheKey := "cart:" + userID
@@ -288,7 +485,7 @@ func (s *CartService) GetCart(ctx context.Context, userID string) (*CartResponse
 
 	cart, err := s.cartRepo.GetByUserID(ctx, userUUID)
 	if err != nil {
-		return nil, errors.New("cart not found")
+		return nil, ErrCartNotFound
 	}
 
 	response, err := s.buildCartResponse(ctx, cart)
@@ -296,54 +493,24 @@ func (s *CartService) GetCart(ctx context.Context, userID string) (*CartResponse
 		return nil, err
 	}
 
-	// Cache for 10 minutes
-	s.cache.Set(ctx, cacheKey, response, time.Minute*10)
+	// Cache for 10 minutes; the cart embeds product prices, so tag it with each product
+	tags := make([]string, 0, len(response.Items))
+	for _, item := range response.Items {
+		tags = append(tags, productCartsTag(item.ProductID))
+	}
+	s.cache.SetWithTags(ctx, cacheKey, response, time.Minute*10, tags...)
 
 	return response, nil
 }
 
+// buildCartResponse prices the cart at current prices. It only reads: the total is reported
+// at current prices and persisted by saveCart when the cart next changes.
 func (s *CartService) buildCartResponse(ctx context.Context, cart *models.Cart) (*CartResponse, error) {
-	var items []models.CartItem
-	if cart.Items != nil {
-		itemsJson, _ := json.Marshal(cart.Items)
-		json.Unmarshal(itemsJson, &items)
-	}
-
-	var itemResponses []CartItemResponse
-	var total float64
-
-	for _, item := range items {
-		// Fetch current product data from MongoDB to get current price
-		productID, err := primitive.ObjectIDFromHex(item.ProductID)
-		if err != nil {
-			continue // Invalid product ID, skip
-		}
-
-		product, err := s.productRepo.GetByID(ctx, productID)
-		if err != nil {
-			// If product not found, skip this item
-			continue
-		}
-
-		currentPrice := product.Price
-		if product.DiscountPrice != nil && *product.DiscountPrice > 0 {
-			currentPrice = *product.DiscountPrice
-		}
-
-		itemResponse := CartItemResponse{
-			ProductID:   item.ProductID,
-			ProductName: product.Name,
-			Quantity:    item.Quantity,
-			Price:       currentPrice,
-			Total:       currentPrice * float64(item.Quantity),
-		}
-		itemResponses = append(itemResponses, itemResponse)
-		total += currentPrice * float64(item.Quantity)
+	itemResponses, total, err := priceCartItems(ctx, s.productRepo, models.DecodeCartItems(cart.Items))
+	if err != nil {
+		return nil, err
 	}
-
-	// Update cart total amount with current prices
 	cart.TotalAmount = total
-	s.cartRepo.Update(ctx, cart)
 
 	return &CartResponse{
 		Cart:  cart,
@@ -351,147 +518,346 @@ func (s *CartService) buildCartResponse(ctx context.Context, cart *models.Cart)
 	}, nil
 }
 
-func (s *CartService) clearCartCache(userID string) {
-	ctx := context.Background()
+// saveCart persists a changed cart with its total at current prices and drops the cached copy
+func (s *CartService) saveCart(ctx context.Context, cart *models.Cart) (*CartResponse, error) {
+	response, err := s.buildCartResponse(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+
+	cart.UpdatedAt = time.Now()
+	if err := s.cartRepo.Update(ctx, cart); err != nil {
+		return nil, err
+	}
+
+	s.clearCartCache(ctx, cart.UserID.String())
+	return response, nil
+}
+
+func (s *CartService) clearCartCache(ctx context.Context, userID string) {
 	cacheKey := "cart:" + userID
 	s.cache.Delete(ctx, cacheKey)
 }
 
-// ApplyCoupon applies a coupon to the user's cart
-func (s *CartService) ApplyCoupon(ctx context.Context, userID, couponCode string) (*CartResponse, error) {
+// ApplyCoupon applies a coupon to the user's cart for the restaurant, or the current cart
+func (s *CartService) ApplyCoupon(ctx context.Context, userID, restaurantID, couponCode string) (*CartResponse, error) {
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
 		return nil, errors.New("invalid user ID")
 	}
 
-	cart, err := s.cartRepo.GetByUserID(ctx, userUUID)
-	if err != nil {
-		return nil, errors.New("cart not found")
-	}
-
-	// TODO: Validate coupon code and get coupon ID from coupon service
-	// For now, we'll generate a mock coupon ID
-	couponID := uuid.New()
-	cart.CouponID = &couponID
-	cart.UpdatedAt = time.Now()
+	var response *CartResponse
+	err = retryOnConflict(ctx, func() error {
+		cart, err := s.cartFor(ctx, userUUID, restaurantID)
+		if err != nil {
+			return err
+		}
 
-	if err := s.cartRepo.Update(ctx, cart); err != nil {
-		return nil, err
-	}
+		lines, subTotal, err := priceCartItems(ctx, s.productRepo, models.DecodeCartItems(cart.Items))
+		if err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return errors.New("cart is empty")
+		}
 
-	// Clear cache
-	s.clearCartCache(userID)
+		applied, err := s.coupons.ApplyCode(ctx, strings.TrimSpace(couponCode), userUUID, cart.RestaurantID, subTotal)
+		if err != nil {
+			return err
+		}
 
-	return s.buildCartResponse(ctx, cart)
+		cart.CouponID = &applied.Coupon.ID
+		response, err = s.saveCart(ctx, cart)
+		return err
+	})
+	return response, err
 }
 
-// RemoveCoupon removes the applied coupon from the user's cart
-func (s *CartService) RemoveCoupon(ctx context.Context, userID string) (*CartResponse, error) {
+// RemoveCoupon removes the applied coupon from the user's cart for the restaurant, or the current cart
+func (s *CartService) RemoveCoupon(ctx context.Context, userID, restaurantID string) (*CartResponse, error) {
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
 		return nil, errors.New("invalid user ID")
 	}
 
-	cart, err := s.cartRepo.GetByUserID(ctx, userUUID)
+	var response *CartResponse
+	err = retryOnConflict(ctx, func() error {
+		cart, err := s.cartFor(ctx, userUUID, restaurantID)
+		if err != nil {
+			return err
+		}
+
+		cart.CouponID = nil
+		response, err = s.saveCart(ctx, cart)
+		return err
+	})
+	return response, err
+}
+
+// GetApplicableCoupons evaluates every active coupon for the cart's restaurant against the cart
+func (s *CartService) GetApplicableCoupons(ctx context.Context, userID, restaurantID string) (*ApplicableCouponsResponse, error) {
+	cartResponse, err := s.GetOrCreateCart(ctx, userID, restaurantID)
 	if err != nil {
-		return nil, errors.New("cart not found")
+		return nil, err
 	}
 
-	cart.CouponID = nil
-	cart.UpdatedAt = time.Now()
+	var subTotal models.Money
+	for _, item := range cartResponse.Items {
+		subTotal += item.Total
+	}
 
-	if err := s.cartRepo.Update(ctx, cart); err != nil {
-		return nil, err
+	response := &ApplicableCouponsResponse{
+		SubTotal:        subTotal,
+		AppliedCouponID: cartResponse.Cart.CouponID,
+		Coupons:         []AppliedCoupon{},
+	}
+	if len(cartResponse.Items) == 0 {
+		return response, nil
 	}
 
-	// Clear cache
-	s.clearCartCache(userID)
+	coupons, err := s.coupons.ApplicableCoupons(ctx, cartResponse.Cart.UserID, cartResponse.Cart.RestaurantID, subTotal)
+	if err != nil {
+		return nil, err
+	}
+	response.Coupons = coupons
+	if len(coupons) > 0 {
+		response.Best = &coupons[0]
+	}
 
-	return s.buildCartResponse(ctx, cart)
+	return response, nil
 }
 
 // GetBillSummary calculates the complete bill summary including taxes, delivery charges, etc.
-func (s *CartService) GetBillSummary(ctx context.Context, userID, restaurantID, addressID string) (*BillSummaryResponse, error) {
-	// Get user's cart
+// With autoApplyCoupon set and no usable coupon on the cart, the best available offer is
+// applied to the cart so checkout charges the same total. Pickup and dine-in orders need no
+// address and pay no delivery fee.
+func (s *CartService) GetBillSummary(ctx context.Context, userID, restaurantID, orderType, addressID string, autoApplyCoupon bool) (*BillSummaryResponse, error) {
 	cartResponse, err := s.GetOrCreateCart(ctx, userID, restaurantID)
 	if err != nil {
 		return nil, err
 	}
+	return s.summarize(ctx, cartResponse, orderType, addressID, autoApplyCoupon)
+}
+
+// billSummaryFor prices a cart that need not be its user's current one, such as a group
+// order's combined items. Coupons are not applied automatically.
+func (s *CartService) billSummaryFor(ctx context.Context, cart *models.Cart, orderType, addressID string) (*BillSummaryResponse, error) {
+	cartResponse, err := s.buildCartResponse(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+	return s.summarize(ctx, cartResponse, orderType, addressID, false)
+}
 
+// summarize works out the bill for a priced cart
+func (s *CartService) summarize(ctx context.Context, cartResponse *CartResponse, orderType, addressID string, autoApplyCoupon bool) (*BillSummaryResponse, error) {
+	orderType, err := normalizeOrderType(orderType)
+	if err != nil {
+		return nil, err
+	}
 	if len(cartResponse.Items) == 0 {
 		return nil, errors.New("cart is empty")
 	}
+	userID := cartResponse.Cart.UserID.String()
+	restUUID := cartResponse.Cart.RestaurantID
 
 	// Calculate subtotal from cart items
-	var subTotal float64
+	var subTotal models.Money
+	taxableItems := make([]TaxableItem, 0, len(cartResponse.Items))
 	for _, item := range cartResponse.Items {
 		subTotal += item.Total
+		taxableItems = append(taxableItems, TaxableItem{
+			ProductID:  item.ProductID,
+			CategoryID: item.CategoryID,
+			Amount:     item.Total,
+		})
 	}
 
-	// TODO: Get restaurant details to calculate packaging fee and tax rate
-	// For now using default values
-	packagingFee := 10.0 // ₹10 packaging fee
-	taxRate := 0.18      // 18% GST
-	taxAmount := subTotal * taxRate
+	// Platform, small-order and late-night fees and delivery surges from pricing rules
+	pricing := s.pricing.Evaluate(ctx, restUUID, subTotal)
 
-	// TODO: Calculate delivery charge using Porter API or restaurant's delivery partner
-	deliveryCharge := 30.0 // Default ₹30 delivery charge
+	// GST, packaging and platform fees from the restaurant's tax configuration
+	taxBreakdown := s.taxService.Calculate(ctx, restUUID, taxableItems, pricing)
 
-	// TODO: Get and apply coupon if exists
-	var couponDetails *CouponDetails
-	var couponDiscount float64 = 0
+	// Delivery charge from the provider quote, boundary fee or platform default
+	var deliveryQuote *DeliveryFeeQuote
+	var deliveryCharge, minOrderValue models.Money
+	if orderType == OrderTypeDelivery {
+		deliveryQuote, err = s.deliveryFee.Quote(ctx, restUUID, addressID, subTotal)
+		if err != nil {
+			return nil, err
+		}
+		s.deliveryFee.ApplySurge(deliveryQuote, pricing.SurgeMultiplier)
+		deliveryCharge = deliveryQuote.Fee
+		minOrderValue = deliveryQuote.MinOrderValue
+	}
 
+	// Coupons are re-checked against the current subtotal; one that no longer applies stays
+	// on the cart (it may apply again once items are added) but gives no discount
+	var applied *AppliedCoupon
+	var couponMessage string
 	if cartResponse.Cart.CouponID != nil {
-		// TODO: Fetch coupon details and calculate discount
+		applied, err = s.coupons.ApplyByID(ctx, *cartResponse.Cart.CouponID, cartResponse.Cart.UserID, restUUID, subTotal)
+		if err != nil {
+			couponMessage = err.Error()
+		}
+	}
+
+	autoApplied := false
+	if applied == nil && autoApplyCoupon {
+		best, err := s.coupons.BestCoupon(ctx, cartResponse.Cart.UserID, restUUID, subTotal)
+		if err != nil {
+			return nil, err
+		}
+		if best != nil {
+			// The coupon is only stored when the cart is unchanged since it was priced;
+			// otherwise the summary is out of date and the client has to ask again
+			cart := cartResponse.Cart
+			cart.CouponID = &best.Coupon.ID
+			cart.UpdatedAt = time.Now()
+			if err := s.cartRepo.Update(ctx, cart); err != nil {
+				if errors.Is(err, repositories.ErrVersionConflict) {
+					return nil, fmt.Errorf("%w: %v", ErrConcurrentUpdate, err)
+				}
+				return nil, err
+			}
+			s.clearCartCache(ctx, userID)
+			applied = best
+			autoApplied = true
+			couponMessage = ""
+		}
+	}
+
+	var couponDetails *CouponDetails
+	var couponDiscount models.Money
+	if applied != nil {
 		couponDetails = &CouponDetails{
-			CouponCode:     "SAVE20",
-			DiscountType:   "percentage",
-			DiscountValue:  20,
-			DiscountAmount: subTotal * 0.2,
+			CouponID:       applied.Coupon.ID.String(),
+			CouponCode:     applied.Coupon.Code,
+			DiscountType:   applied.Coupon.DiscountType,
+			DiscountValue:  applied.Coupon.DiscountValue,
+			MaxDiscount:    applied.Coupon.MaxDiscount,
+			DiscountAmount: applied.DiscountAmount,
+			AutoApplied:    autoApplied,
 		}
-		couponDiscount = couponDetails.DiscountAmount
+		couponDiscount = applied.DiscountAmount
 	}
 
-	totalAmount := subTotal + taxAmount + packagingFee + deliveryCharge - couponDiscount
+	// Tax-inclusive menus already carry GST in item prices and fees
+	totalAmount := subTotal + taxBreakdown.ChargedTax + taxBreakdown.PackagingFee + taxBreakdown.PlatformFee +
+		taxBreakdown.SmallOrderFee + taxBreakdown.LateNightFee + deliveryCharge - couponDiscount
 
-	return &BillSummaryResponse{
+	summary := &BillSummaryResponse{
+		OrderType:      orderType,
 		SubTotal:       subTotal,
 		CouponDetails:  couponDetails,
+		CouponMessage:  couponMessage,
 		DeliveryCharge: deliveryCharge,
-		TaxAmount:      taxAmount,
-		PackagingFee:   packagingFee,
+		DeliveryQuote:  deliveryQuote,
+		TaxAmount:      taxBreakdown.TotalTax,
+		PackagingFee:   taxBreakdown.PackagingFee,
+		PlatformFee:    taxBreakdown.PlatformFee,
+		SmallOrderFee:  taxBreakdown.SmallOrderFee,
+		LateNightFee:   taxBreakdown.LateNightFee,
 		TotalAmount:    totalAmount,
+		MinOrderValue:  minOrderValue,
+		TaxBreakdown:   taxBreakdown,
+		Pricing:        pricing,
 		Items:          cartResponse.Items,
-	}, nil
+		Currency:       s.restaurants.Currency(ctx, restUUID),
+		Language:       i18n.LanguageFromContext(ctx),
+	}
+	if subTotal < summary.MinOrderValue {
+		summary.MinOrderShortfall = summary.MinOrderValue - subTotal
+	}
+	summary.Lines = billLines(summary)
+	return summary, nil
 }
 
-// Checkout processes the cart and creates order and payment records
-func (s *CartService) Checkout(ctx context.Context, userID, restaurantID, addressID string) (*CheckoutResponse, error) {
-	// Get bill summary first to calculate total amount
-	billSummary, err := s.GetBillSummary(ctx, userID, restaurantID, addressID)
+// billLines lays out the bill in the summary's language and currency. Taxes already included in
+// menu prices are not a line of their own.
+func billLines(summary *BillSummaryResponse) []BillLine {
+	lines := []BillLine{}
+	add := func(key, label string, amount models.Money, always bool) {
+		if amount == 0 && !always {
+			return
+		}
+		lines = append(lines, BillLine{
+			Key:       key,
+			Label:     i18n.T(summary.Language, label),
+			Amount:    amount,
+			Formatted: i18n.FormatMoney(amount.Float64(), summary.Currency),
+		})
+	}
+
+	add("item_total", "Item total", summary.SubTotal, true)
+	if summary.CouponDetails != nil {
+		add("coupon_discount", "Coupon discount", -summary.CouponDetails.DiscountAmount, false)
+	}
+	add("delivery_fee", "Delivery fee", summary.DeliveryCharge, summary.OrderType == OrderTypeDelivery)
+	if summary.TaxBreakdown != nil {
+		add("taxes", "Taxes", summary.TaxBreakdown.ChargedTax, false)
+	}
+	add("packaging_fee", "Packaging charges", summary.PackagingFee, false)
+	add("platform_fee", "Platform fee", summary.PlatformFee, false)
+	add("small_order_fee", "Small order fee", summary.SmallOrderFee, false)
+	add("late_night_fee", "Late night fee", summary.LateNightFee, false)
+	add("total", "To pay", summary.TotalAmount, true)
+	return lines
+}
+
+// Checkout processes the cart and creates order and payment records. Pickup and dine-in orders
+// are placed without an address. Dine-in orders are placed at the table whose QR token is given
+// and go on its running tab, paid when staff close the tab.
+func (s *CartService) Checkout(ctx context.Context, userID, restaurantID, orderType, addressID, tableToken, customerNotes string) (*CheckoutResponse, error) {
+	cart, err := s.getOrCreateCart(ctx, userID, restaurantID)
 	if err != nil {
 		return nil, err
 	}
+	return s.checkoutCart(ctx, cart, orderType, addressID, tableToken, customerNotes)
+}
 
-	userUUID, err := uuid.Parse(userID)
+// checkoutCart places an order for a cart, which need not be its user's current one
+func (s *CartService) checkoutCart(ctx context.Context, cart *models.Cart, orderType, addressID, tableToken, customerNotes string) (*CheckoutResponse, error) {
+	// Get bill summary first to calculate total amount
+	billSummary, err := s.billSummaryFor(ctx, cart, orderType, addressID)
 	if err != nil {
-		return nil, errors.New("invalid user ID")
+		return nil, err
+	}
+	if billSummary.MinOrderShortfall > 0 {
+		return nil, fmt.Errorf("%w: add %s more to order (minimum %s)", ErrBelowMinimumOrder,
+			i18n.FormatMoney(billSummary.MinOrderShortfall.Float64(), billSummary.Currency),
+			i18n.FormatMoney(billSummary.MinOrderValue.Float64(), billSummary.Currency))
 	}
 
-	restUUID, err := uuid.Parse(restaurantID)
-	if err != nil {
-		return nil, errors.New("invalid restaurant ID")
+	userUUID := cart.UserID
+	restUUID := cart.RestaurantID
+	if err := s.restaurants.EnsureAcceptingOrders(ctx, restUUID); err != nil {
+		return nil, err
+	}
+	if err := s.restaurants.EnsureOrderType(ctx, restUUID, billSummary.OrderType); err != nil {
+		return nil, err
+	}
+	if err := s.restaurants.EnsureNotPaused(ctx, restUUID, time.Now()); err != nil {
+		return nil, err
 	}
 
-	addressUUID, err := uuid.Parse(addressID)
-	if err != nil {
-		return nil, errors.New("invalid address ID")
+	var addressUUID *uuid.UUID
+	if billSummary.OrderType == OrderTypeDelivery {
+		parsed, err := uuid.Parse(addressID)
+		if err != nil {
+			return nil, errors.New("invalid address ID")
+		}
+		addressUUID = &parsed
 	}
 
-	// Get user's cart
-	cart, err := s.cartRepo.GetByUserID(ctx, userUUID)
+	paymentMethod := "razorpay" // Default to Razorpay
+	if billSummary.OrderType == OrderTypeDineIn {
+		paymentMethod = PaymentMethodTab
+	}
+	assessment, err := s.risk.Assess(ctx, RiskCheck{Event: RiskEventCheckout, UserID: userUUID, PaymentMethod: paymentMethod, AddressID: addressUUID})
 	if err != nil {
-		return nil, errors.New("cart not found")
+		return nil, err
 	}
 
 	// Create order
@@ -499,17 +865,28 @@ func (s *CartService) Checkout(ctx context.Context, userID, restaurantID, addres
 		UserID:          userUUID,
 		RestaurantID:    restUUID,
 		CartID:          cart.ID,
+		OrderType:       billSummary.OrderType,
 		OrderStatus:     "pending",
-		AddressID:       &addressUUID,
+		AddressID:       addressUUID,
+		CustomerNotes:   strings.TrimSpace(customerNotes),
 		TotalAmount:     billSummary.TotalAmount,
 		CreatedAt:       time.Now(),
 		DiscountDetails: models.JSONB{},
 		OrderLogs:       models.JSONB{},
+		TaxDetails:      billSummary.TaxBreakdown.ToJSONB(),
+		DeliveryFee:     billSummary.DeliveryCharge,
+		// Quoted fee is kept for reconciliation against the provider's actual fare
+		DeliveryFeeDetails: billSummary.DeliveryQuote.ToJSONB(),
+		LineItems:          models.EncodeOrderLineItems(orderLineItems(billSummary.Items)),
+		PricingDetails:     billSummary.Pricing.ToJSONB(),
+		Currency:           billSummary.Currency,
+		Language:           billSummary.Language,
 	}
 
 	// Add discount details if coupon was applied
 	if billSummary.CouponDetails != nil {
 		discountData := map[string]interface{}{
+			"coupon_id":       billSummary.CouponDetails.CouponID,
 			"coupon_code":     billSummary.CouponDetails.CouponCode,
 			"discount_type":   billSummary.CouponDetails.DiscountType,
 			"discount_value":  billSummary.CouponDetails.DiscountValue,
@@ -517,8 +894,44 @@ func (s *CartService) Checkout(ctx context.Context, userID, restaurantID, addres
 		}
 		order.DiscountDetails = discountData
 	}
+	if usesCollectionCode(order.OrderType) {
+		if err := assignDeliveryCode(order); err != nil {
+			return nil, err
+		}
+	}
+	if order.OrderType == OrderTypeDineIn {
+		if err := s.tables.attach(ctx, order, tableToken); err != nil {
+			return nil, err
+		}
+	}
+
+	if billSummary.DeliveryQuote != nil {
+		s.restaurants.PromiseDelivery(ctx, restUUID, billSummary.DeliveryQuote.TravelMinutes, nil).apply(order)
+	} else {
+		s.restaurants.PromiseReady(ctx, restUUID, nil).apply(order)
+	}
+
+	// The coupon use is claimed before the order is written so a coupon that ran out since the
+	// bill summary fails checkout instead of giving an unlimited discount
+	var redeemed bool
+	if billSummary.CouponDetails != nil {
+		couponID, err := uuid.Parse(billSummary.CouponDetails.CouponID)
+		if err != nil {
+			return nil, errors.New("invalid coupon ID")
+		}
+		order.ID = uuid.New()
+		if err := s.coupons.Redeem(ctx, couponID, userUUID, order.ID, billSummary.CouponDetails.DiscountAmount); err != nil {
+			return nil, err
+		}
+		redeemed = true
+	}
 
 	if err := s.orderRepo.Create(ctx, order); err != nil {
+		if redeemed {
+			if releaseErr := s.coupons.ReleaseRedemption(ctx, order.ID); releaseErr != nil {
+				log.Printf("Failed to release coupon for unplaced order %s: %v", order.ID, releaseErr)
+			}
+		}
 		return nil, err
 	}
 
@@ -527,7 +940,7 @@ func (s *CartService) Checkout(ctx context.Context, userID, restaurantID, addres
 		OrderID:   order.ID,
 		UserID:    userUUID,
 		Amount:    billSummary.TotalAmount,
-		Method:    "razorpay", // Default to Razorpay
+		Method:    paymentMethod,
 		Status:    "pending",
 		CreatedAt: time.Now(),
 		Metadata:  models.JSONB{},
@@ -537,17 +950,226 @@ func (s *CartService) Checkout(ctx context.Context, userID, restaurantID, addres
 		return nil, err
 	}
 
-	// Update order with payment ID
+	// Update order with payment ID; the order_created event is written with it
 	order.PaymentID = &payment.ID
-	if err := s.orderRepo.Update(ctx, order); err != nil {
+	created, err := orderCreatedEvent(order)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.orderRepo.UpdateWithEvents(ctx, order, []models.OutboxEvent{created}); err != nil {
 		return nil, err
 	}
 
-	return &CheckoutResponse{
+	// Mark cart as used so it is not swept up as abandoned. A cart changed while the order was
+	// placed keeps the newer items for a later order instead of failing the one already placed.
+	cart.Status = "ordered"
+	if err := s.cartRepo.Update(ctx, cart); err != nil {
+		if !errors.Is(err, repositories.ErrVersionConflict) {
+			return nil, err
+		}
+		log.Printf("Cart %s changed while order %s was placed, leaving it active", cart.ID, order.ID)
+	}
+	s.clearCartCache(ctx, cart.UserID.String())
+	s.risk.Record(ctx, assessment, &order.ID, nil)
+
+	response := &CheckoutResponse{
 		OrderID:       order.ID.String(),
+		OrderType:     order.OrderType,
+		TableNumber:   order.TableNumber,
 		PaymentID:     payment.ID.String(),
 		TotalAmount:   billSummary.TotalAmount,
-		PaymentMethod: "razorpay",
+		PaymentMethod: paymentMethod,
 		Status:        "pending",
-	}, nil
+		PromisedAt:    order.PromisedAt,
+	}
+	if order.TabID != nil {
+		response.TabID = order.TabID.String()
+	}
+	return response, nil
+}
+
+// priceCartItems prices cart lines at current menu prices, loading every product in one
+// query. Lines whose product was removed or whose variant or addons no longer exist are skipped.
+func priceCartItems(ctx context.Context, productRepo repositories.ProductRepository, items []models.CartItem) ([]CartItemResponse, models.Money, error) {
+	var lines []CartItemResponse
+	var total models.Money
+
+	ids := make([]primitive.ObjectID, 0, len(items))
+	for _, item := range items {
+		if productID, err := primitive.ObjectIDFromHex(item.ProductID); err == nil {
+			ids = append(ids, productID)
+		}
+	}
+	products, err := productRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load cart products: %v", err)
+	}
+	productsByID := make(map[string]*models.Product, len(products))
+	for i := range products {
+		productsByID[products[i].ID.Hex()] = &products[i]
+	}
+
+	for _, item := range items {
+		product, ok := productsByID[item.ProductID]
+		if !ok {
+			continue
+		}
+
+		line, err := priceCartItem(product, item)
+		if err != nil {
+			continue
+		}
+		lines = append(lines, line)
+		total += line.Total
+	}
+
+	return lines, total, nil
+}
+
+// priceRestaurantItems prices the lines at menu prices, failing with the invalid error unless
+// every item is an available product of the restaurant
+func priceRestaurantItems(ctx context.Context, productRepo repositories.ProductRepository, restaurantID uuid.UUID, items []models.CartItem, invalid error) ([]CartItemResponse, models.Money, error) {
+	ids := make([]primitive.ObjectID, 0, len(items))
+	for _, item := range items {
+		productID, err := primitive.ObjectIDFromHex(item.ProductID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: invalid product ID %s", invalid, item.ProductID)
+		}
+		if item.Quantity < 1 {
+			return nil, 0, fmt.Errorf("%w: quantity must be at least 1", invalid)
+		}
+		ids = append(ids, productID)
+	}
+	products, err := productRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load products: %v", err)
+	}
+	productsByID := make(map[string]*models.Product, len(products))
+	for i := range products {
+		productsByID[products[i].ID.Hex()] = &products[i]
+	}
+
+	lines := make([]CartItemResponse, 0, len(items))
+	var total models.Money
+	for _, item := range items {
+		product, ok := productsByID[item.ProductID]
+		if !ok || product.RestaurantID != restaurantID.String() || !product.IsAvailable {
+			return nil, 0, fmt.Errorf("%w: product %s is not available", invalid, item.ProductID)
+		}
+		line, err := priceCartItem(product, item)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", invalid, err)
+		}
+		lines = append(lines, line)
+		total += line.Total
+	}
+	return lines, total, nil
+}
+
+// priceCartItem validates a line's selection against the product and prices it. A variant's
+// price replaces the product price and its discount; addon prices and option price deltas are
+// added per unit. Products with variants require one to be chosen, and options must fit the
+// product's option groups.
+func priceCartItem(product *models.Product, item models.CartItem) (CartItemResponse, error) {
+	line := CartItemResponse{
+		ItemKey:     item.Key(),
+		ProductID:   item.ProductID,
+		ProductName: product.Name,
+		CategoryID:  product.CategoryID.Hex(),
+		Quantity:    item.Quantity,
+		Components:  comboLineComponents(product),
+	}
+
+	unitPrice := product.Price
+	if product.DiscountPrice != nil && *product.DiscountPrice > 0 {
+		unitPrice = *product.DiscountPrice
+	}
+
+	if item.VariantID != "" {
+		found := false
+		for _, variant := range product.Variants {
+			if variant.ID.Hex() == item.VariantID {
+				unitPrice = variant.Price
+				line.VariantID = item.VariantID
+				line.VariantName = variant.Name
+				found = true
+				break
+			}
+		}
+		if !found {
+			return line, fmt.Errorf("variant %s is not available for %s", item.VariantID, product.Name)
+		}
+	} else if len(product.Variants) > 0 {
+		return line, fmt.Errorf("please choose a variant for %s", product.Name)
+	}
+
+	for _, addonID := range item.AddonIDs {
+		found := false
+		for _, addon := range product.Addons {
+			if addon.ID.Hex() == addonID {
+				unitPrice += addon.Price
+				line.Addons = append(line.Addons, models.SelectedAddon{
+					ID:    addonID,
+					Name:  addon.Name,
+					Price: addon.Price,
+				})
+				found = true
+				break
+			}
+		}
+		if !found {
+			return line, fmt.Errorf("addon %s is not available for %s", addonID, product.Name)
+		}
+	}
+
+	options, delta, err := selectOptions(product, item.OptionIDs)
+	if err != nil {
+		return line, err
+	}
+	line.Options = options
+	unitPrice += delta
+	if unitPrice < 0 {
+		unitPrice = 0
+	}
+
+	line.Price = unitPrice
+	line.Total = unitPrice * models.Money(item.Quantity)
+	return line, nil
+}
+
+// orderLineItems snapshots priced cart lines for an order
+func orderLineItems(lines []CartItemResponse) []models.OrderLineItem {
+	items := make([]models.OrderLineItem, 0, len(lines))
+	for _, line := range lines {
+		items = append(items, models.OrderLineItem{
+			ProductID:   line.ProductID,
+			ProductName: line.ProductName,
+			Quantity:    line.Quantity,
+			VariantID:   line.VariantID,
+			VariantName: line.VariantName,
+			Addons:      line.Addons,
+			Options:     line.Options,
+			UnitPrice:   line.Price,
+			Total:       line.Total,
+			Components:  line.Components,
+		})
+	}
+	return items
+}
+
+// normalizeAddonIDs drops blanks and duplicates so the same addons or options always have the
+// same key
+func normalizeAddonIDs(addonIDs []string) []string {
+	seen := make(map[string]bool, len(addonIDs))
+	var normalized []string
+	for _, id := range addonIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		normalized = append(normalized, id)
+	}
+	sort.Strings(normalized)
+	return normalized
 }
@@ -0,0 +1,594 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/i18n"
+	"golang-food-backend/pkg/messaging"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	QuoteRequested = "requested"
+	QuoteQuoted    = "quoted"
+	QuoteAccepted  = "accepted"
+	QuoteDeclined  = "declined"
+	QuoteCancelled = "cancelled"
+	QuoteExpired   = "expired"
+
+	cateringMaxDaysAhead = 180
+)
+
+var (
+	ErrQuoteInvalid  = apperr.Validation("catering_quote_invalid", "invalid catering quote")
+	ErrQuoteNotFound = apperr.NotFound("catering_quote_not_found", "catering quote not found")
+	ErrQuoteState    = apperr.Conflict("catering_quote_state", "the catering quote cannot be changed in its current state")
+	ErrQuoteExpired  = apperr.Conflict("catering_quote_expired", "the catering quote has expired")
+)
+
+// CateringPolicy sets the smallest catering order, how far ahead it has to be requested and
+// how long a quote stays open
+type CateringPolicy struct {
+	MinHeadcount  int
+	MinNotice     time.Duration
+	QuoteValidity time.Duration
+}
+
+// CateringQuoteRequest asks a restaurant to quote for a large order
+type CateringQuoteRequest struct {
+	RestaurantID    string            `json:"restaurant_id" binding:"required"`
+	Items           []models.CartItem `json:"items" binding:"required,min=1"`
+	Headcount       int               `json:"headcount" binding:"required,min=1"`
+	EventDate       time.Time         `json:"event_date" binding:"required"` // when the food is wanted, RFC3339
+	OrderType       string            `json:"order_type,omitempty"`          // delivery (default) or pickup
+	AddressID       string            `json:"address_id,omitempty"`          // required for delivery
+	CustomerName    string            `json:"customer_name" binding:"required"`
+	CustomerContact string            `json:"customer_contact" binding:"required"`
+	CustomerNotes   string            `json:"customer_notes,omitempty" binding:"max=1000"`
+}
+
+// CateringQuoteOffer is the restaurant's price for a catering request
+type CateringQuoteOffer struct {
+	Amount         models.Money `json:"amount" binding:"required,gte=100"`
+	AdvancePercent int          `json:"advance_percent" binding:"required,min=1,max=100"` // paid up front to confirm the order
+	Notes          string       `json:"notes,omitempty" binding:"max=1000"`
+	ValidUntil     *time.Time   `json:"valid_until,omitempty"` // defaults to the platform's quote validity
+}
+
+// DeclineCateringQuoteRequest turns a catering request down
+type DeclineCateringQuoteRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// CateringQuoteDetails is a catering quote with the advance due and, once accepted, its order
+// and payment link
+type CateringQuoteDetails struct {
+	models.CateringQuote
+	AdvanceAmount models.Money `json:"advance_amount"`
+	OrderStatus   string       `json:"order_status,omitempty"`
+	PaymentURL    string       `json:"payment_url,omitempty"`
+	AmountPaid    models.Money `json:"amount_paid"`
+}
+
+// CateringService runs catering quotes: customers request them, restaurants price them and an
+// accepted quote becomes a scheduled order paid for through a payment link
+type CateringService struct {
+	quoteRepo      repositories.CateringQuoteRepository
+	cartRepo       repositories.CartRepository
+	orderRepo      repositories.OrderRepository
+	paymentRepo    repositories.PaymentRepository
+	productRepo    repositories.ProductRepository
+	addressRepo    repositories.AddressRepository
+	restaurantRepo repositories.RestaurantRepository
+	restaurants    *RestaurantService
+	razorpay       *RazorpayService
+	policy         CateringPolicy
+}
+
+func NewCateringService(
+	quoteRepo repositories.CateringQuoteRepository,
+	cartRepo repositories.CartRepository,
+	orderRepo repositories.OrderRepository,
+	paymentRepo repositories.PaymentRepository,
+	productRepo repositories.ProductRepository,
+	addressRepo repositories.AddressRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	restaurants *RestaurantService,
+	razorpay *RazorpayService,
+	policy CateringPolicy,
+) *CateringService {
+	return &CateringService{
+		quoteRepo:      quoteRepo,
+		cartRepo:       cartRepo,
+		orderRepo:      orderRepo,
+		paymentRepo:    paymentRepo,
+		productRepo:    productRepo,
+		addressRepo:    addressRepo,
+		restaurantRepo: restaurantRepo,
+		restaurants:    restaurants,
+		razorpay:       razorpay,
+		policy:         policy,
+	}
+}
+
+// RequestQuote sends a catering request to the restaurant. The items are priced at menu prices
+// to guide the restaurant's quote.
+func (s *CateringService) RequestQuote(ctx context.Context, userID string, req *CateringQuoteRequest) (*CateringQuoteDetails, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user ID", ErrQuoteInvalid)
+	}
+	restaurantUUID, err := uuid.Parse(req.RestaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrQuoteInvalid)
+	}
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantUUID)
+	if err != nil {
+		return nil, errors.New("restaurant not found")
+	}
+	if err := s.restaurants.EnsureAcceptingOrders(ctx, restaurant.ID); err != nil {
+		return nil, err
+	}
+
+	orderType, err := normalizeOrderType(req.OrderType)
+	if err != nil {
+		return nil, err
+	}
+	if orderType == OrderTypeDineIn {
+		return nil, fmt.Errorf("%w: catering orders are delivered or picked up", ErrQuoteInvalid)
+	}
+	if !orderTypeEnabled(restaurant, orderType) {
+		return nil, fmt.Errorf("%w: %s", ErrOrderTypeUnavailable, orderType)
+	}
+
+	if req.Headcount < s.policy.MinHeadcount {
+		return nil, fmt.Errorf("%w: catering is for at least %d guests", ErrQuoteInvalid, s.policy.MinHeadcount)
+	}
+	now := time.Now()
+	if req.EventDate.Before(now.Add(s.policy.MinNotice)) {
+		return nil, fmt.Errorf("%w: catering has to be requested at least %s ahead", ErrQuoteInvalid, s.policy.MinNotice)
+	}
+	if req.EventDate.After(now.AddDate(0, 0, cateringMaxDaysAhead)) {
+		return nil, fmt.Errorf("%w: catering can be requested up to %d days ahead", ErrQuoteInvalid, cateringMaxDaysAhead)
+	}
+
+	var addressUUID *uuid.UUID
+	if orderType == OrderTypeDelivery {
+		parsed, err := uuid.Parse(req.AddressID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: address_id is required for delivery", ErrQuoteInvalid)
+		}
+		address, err := s.addressRepo.GetByID(ctx, parsed)
+		if err != nil || address.UserID != userUUID {
+			return nil, errors.New("address not found")
+		}
+		addressUUID = &parsed
+	}
+
+	lines, menuTotal, err := priceRestaurantItems(ctx, s.productRepo, restaurant.ID, req.Items, ErrQuoteInvalid)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &models.CateringQuote{
+		ID:              uuid.New(),
+		UserID:          userUUID,
+		RestaurantID:    restaurant.ID,
+		Status:          QuoteRequested,
+		OrderType:       orderType,
+		Headcount:       req.Headcount,
+		EventDate:       req.EventDate,
+		Items:           models.EncodeCartItems(req.Items),
+		LineItems:       models.EncodeOrderLineItems(orderLineItems(lines)),
+		MenuTotal:       menuTotal,
+		AddressID:       addressUUID,
+		CustomerName:    strings.TrimSpace(req.CustomerName),
+		CustomerContact: strings.TrimSpace(req.CustomerContact),
+		CustomerNotes:   strings.TrimSpace(req.CustomerNotes),
+		Currency:        i18n.CurrencyOf(restaurant.Currency).Code,
+	}
+	notification, err := cateringNotification(restaurant.OwnerID, quote, "catering_quote_requested", "New Catering Request",
+		fmt.Sprintf("Catering for %d guests on %s is waiting for your quote.", quote.Headcount, quote.EventDate.Format("02 Jan 15:04")))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.quoteRepo.Create(ctx, quote, []models.OutboxEvent{notification}); err != nil {
+		return nil, fmt.Errorf("failed to save catering request: %v", err)
+	}
+	return newCateringQuoteDetails(quote), nil
+}
+
+// ListUserQuotes returns the customer's catering quotes, newest first
+func (s *CateringService) ListUserQuotes(ctx context.Context, userID string, page, limit int) ([]CateringQuoteDetails, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user ID", ErrQuoteInvalid)
+	}
+	page, limit = normalizePage(page, limit)
+	quotes, err := s.quoteRepo.GetByUserID(ctx, userUUID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list catering quotes: %v", err)
+	}
+	return s.quoteList(ctx, quotes), nil
+}
+
+// GetUserQuote returns one of the customer's catering quotes with its order's payment
+func (s *CateringService) GetUserQuote(ctx context.Context, userID, quoteID string) (*CateringQuoteDetails, error) {
+	quote, err := s.userQuote(ctx, userID, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	return s.quoteDetails(ctx, quote), nil
+}
+
+// CancelQuote withdraws a catering request or turns down the restaurant's quote
+func (s *CateringService) CancelQuote(ctx context.Context, userID, quoteID string) (*CateringQuoteDetails, error) {
+	quote, err := s.userQuote(ctx, userID, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Status != QuoteRequested && quote.Status != QuoteQuoted {
+		return nil, fmt.Errorf("%w: the quote is %s", ErrQuoteState, quote.Status)
+	}
+	quote.Status = QuoteCancelled
+	if err := s.quoteRepo.Update(ctx, quote, nil); err != nil {
+		return nil, s.saveError(err)
+	}
+	return newCateringQuoteDetails(quote), nil
+}
+
+// AcceptQuote turns the restaurant's quote into an order scheduled for the event. The order waits
+// for payment through a payment link for the quoted amount, which the customer can pay in parts
+// starting with the advance; paying the advance schedules the order.
+func (s *CateringService) AcceptQuote(ctx context.Context, userID, quoteID string) (*CateringQuoteDetails, error) {
+	quote, err := s.userQuote(ctx, userID, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Status == QuoteExpired {
+		return nil, ErrQuoteExpired
+	}
+	if quote.Status != QuoteQuoted {
+		return nil, fmt.Errorf("%w: the quote is %s", ErrQuoteState, quote.Status)
+	}
+	if err := s.restaurants.EnsureAcceptingOrders(ctx, quote.RestaurantID); err != nil {
+		return nil, err
+	}
+	if err := s.restaurants.EnsureOrderType(ctx, quote.RestaurantID, quote.OrderType); err != nil {
+		return nil, err
+	}
+
+	// Claim the quote first so accepting it twice at once places one order
+	now := time.Now()
+	quote.Status = QuoteAccepted
+	quote.AcceptedAt = &now
+	if err := s.quoteRepo.Update(ctx, quote, nil); err != nil {
+		return nil, s.saveError(err)
+	}
+
+	order, err := s.placeOrder(ctx, quote)
+	if err != nil {
+		quote.Status = QuoteQuoted
+		quote.AcceptedAt = nil
+		if revertErr := s.quoteRepo.Update(ctx, quote, nil); revertErr != nil {
+			log.Printf("Failed to reopen catering quote %s after a failed acceptance: %v", quote.ID, revertErr)
+		}
+		return nil, err
+	}
+
+	quote.OrderID = &order.ID
+	var events []models.OutboxEvent
+	if restaurant, err := s.restaurantRepo.GetByID(ctx, quote.RestaurantID); err == nil {
+		notification, err := cateringNotification(restaurant.OwnerID, quote, "catering_quote_accepted", "Catering Quote Accepted",
+			fmt.Sprintf("Your quote for %d guests on %s was accepted. The order is confirmed once the advance is paid.", quote.Headcount, quote.EventDate.Format("02 Jan 15:04")))
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, notification)
+	}
+	if err := s.quoteRepo.Update(ctx, quote, events); err != nil {
+		return nil, fmt.Errorf("failed to save catering quote: %v", err)
+	}
+	return s.quoteDetails(ctx, quote), nil
+}
+
+// ListRestaurantQuotes returns the restaurant's catering quotes by event date, all of them when
+// status is empty
+func (s *CateringService) ListRestaurantQuotes(ctx context.Context, restaurantID, status string, page, limit int) ([]CateringQuoteDetails, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrQuoteInvalid)
+	}
+	page, limit = normalizePage(page, limit)
+	quotes, err := s.quoteRepo.GetByRestaurantID(ctx, restaurantUUID, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list catering quotes: %v", err)
+	}
+	return s.quoteList(ctx, quotes), nil
+}
+
+// GetRestaurantQuote returns one of the restaurant's catering quotes with its order's payment
+func (s *CateringService) GetRestaurantQuote(ctx context.Context, restaurantID, quoteID string) (*CateringQuoteDetails, error) {
+	quote, err := s.restaurantQuote(ctx, restaurantID, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	return s.quoteDetails(ctx, quote), nil
+}
+
+// SendQuote prices a catering request, or revises a quote the customer has yet to accept
+func (s *CateringService) SendQuote(ctx context.Context, staffID, restaurantID, quoteID string, offer *CateringQuoteOffer) (*CateringQuoteDetails, error) {
+	quote, err := s.restaurantQuote(ctx, restaurantID, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Status != QuoteRequested && quote.Status != QuoteQuoted {
+		return nil, fmt.Errorf("%w: the quote is %s", ErrQuoteState, quote.Status)
+	}
+	if offer.AdvancePercent < 1 || offer.AdvancePercent > 100 {
+		return nil, fmt.Errorf("%w: advance_percent must be between 1 and 100", ErrQuoteInvalid)
+	}
+
+	now := time.Now()
+	validUntil := now.Add(s.policy.QuoteValidity)
+	if offer.ValidUntil != nil {
+		validUntil = *offer.ValidUntil
+	}
+	if !validUntil.After(now) {
+		return nil, fmt.Errorf("%w: valid_until must be in the future", ErrQuoteInvalid)
+	}
+	if validUntil.After(quote.EventDate) {
+		validUntil = quote.EventDate
+	}
+
+	quote.Status = QuoteQuoted
+	quote.QuotedAmount = offer.Amount
+	quote.AdvancePercent = offer.AdvancePercent
+	quote.QuoteNotes = strings.TrimSpace(offer.Notes)
+	quote.QuotedAt = &now
+	quote.ValidUntil = &validUntil
+	if staffUUID, err := uuid.Parse(staffID); err == nil {
+		quote.QuotedBy = &staffUUID
+	}
+
+	notification, err := cateringNotification(quote.UserID, quote, "catering_quote_received", "Your Catering Quote",
+		fmt.Sprintf("The restaurant quoted %s for %d guests, with %s to pay up front. Accept it by %s.",
+			i18n.FormatMoney(quote.QuotedAmount.Float64(), quote.Currency), quote.Headcount,
+			i18n.FormatMoney(cateringAdvance(quote).Float64(), quote.Currency), validUntil.Format("02 Jan 15:04")))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.quoteRepo.Update(ctx, quote, []models.OutboxEvent{notification}); err != nil {
+		return nil, s.saveError(err)
+	}
+	return newCateringQuoteDetails(quote), nil
+}
+
+// DeclineQuote turns down a catering request, or withdraws a quote the customer has yet to accept
+func (s *CateringService) DeclineQuote(ctx context.Context, restaurantID, quoteID string, req *DeclineCateringQuoteRequest) (*CateringQuoteDetails, error) {
+	quote, err := s.restaurantQuote(ctx, restaurantID, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Status != QuoteRequested && quote.Status != QuoteQuoted {
+		return nil, fmt.Errorf("%w: the quote is %s", ErrQuoteState, quote.Status)
+	}
+	quote.Status = QuoteDeclined
+	quote.DeclineReason = strings.TrimSpace(req.Reason)
+
+	notification, err := cateringNotification(quote.UserID, quote, "catering_quote_declined", "Catering Request Declined",
+		fmt.Sprintf("The restaurant cannot cater for %d guests on %s: %s", quote.Headcount, quote.EventDate.Format("02 Jan 15:04"), quote.DeclineReason))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.quoteRepo.Update(ctx, quote, []models.OutboxEvent{notification}); err != nil {
+		return nil, s.saveError(err)
+	}
+	return newCateringQuoteDetails(quote), nil
+}
+
+// placeOrder creates the accepted quote's order, with a cart holding its items, and the
+// payment link it is paid through
+func (s *CateringService) placeOrder(ctx context.Context, quote *models.CateringQuote) (*models.Order, error) {
+	cart := &models.Cart{
+		UserID:       quote.UserID,
+		RestaurantID: quote.RestaurantID,
+		Items:        quote.Items,
+		TotalAmount:  quote.QuotedAmount,
+		Status:       "ordered",
+	}
+	if err := s.cartRepo.Create(ctx, cart); err != nil {
+		return nil, fmt.Errorf("failed to create catering cart: %v", err)
+	}
+
+	notes := fmt.Sprintf("Catering for %d guests", quote.Headcount)
+	if quote.CustomerNotes != "" {
+		notes += ". " + quote.CustomerNotes
+	}
+	if runes := []rune(notes); len(runes) > 250 {
+		notes = string(runes[:250])
+	}
+	eventDate := quote.EventDate
+	order := &models.Order{
+		UserID:          quote.UserID,
+		RestaurantID:    quote.RestaurantID,
+		CartID:          cart.ID,
+		OrderType:       quote.OrderType,
+		OrderStatus:     "pending_payment",
+		ScheduledFor:    &eventDate,
+		AddressID:       quote.AddressID,
+		TotalAmount:     quote.QuotedAmount,
+		CustomerName:    quote.CustomerName,
+		CustomerContact: quote.CustomerContact,
+		CustomerNotes:   notes,
+		LineItems:       quote.LineItems,
+		PricingDetails: models.JSONB{
+			"catering_quote_id": quote.ID.String(),
+			"menu_total":        quote.MenuTotal,
+			"quoted_amount":     quote.QuotedAmount,
+			"advance_percent":   quote.AdvancePercent,
+		},
+		Currency:  quote.Currency,
+		Language:  i18n.LanguageFromContext(ctx),
+		CreatedAt: time.Now(),
+	}
+	if err := assignDeliveryCode(order); err != nil {
+		return nil, err
+	}
+	if order.OrderType == OrderTypeDelivery {
+		s.restaurants.PromiseDelivery(ctx, quote.RestaurantID, 0, order.ScheduledFor).apply(order)
+	} else {
+		s.restaurants.PromiseReady(ctx, quote.RestaurantID, order.ScheduledFor).apply(order)
+	}
+	appendOrderLog(order, order.OrderStatus, "Catering quote accepted, waiting for the advance")
+	if err := s.orderRepo.Create(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to create catering order: %v", err)
+	}
+
+	// The link stays open as long as the quote was, but closes before the event
+	expiresAt := time.Now().Add(s.policy.QuoteValidity)
+	if expiresAt.After(quote.EventDate) {
+		expiresAt = quote.EventDate
+	}
+	description := fmt.Sprintf("Catering for %d guests on %s", quote.Headcount, quote.EventDate.Format("02 Jan 15:04"))
+	if _, err := s.razorpay.CreatePaymentLink(ctx, order, cateringAdvance(quote), expiresAt, description, nil); err != nil {
+		return nil, err
+	}
+
+	created, err := orderCreatedEvent(order)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.orderRepo.UpdateWithEvents(ctx, order, []models.OutboxEvent{created}); err != nil {
+		return nil, fmt.Errorf("failed to update catering order: %v", err)
+	}
+	return order, nil
+}
+
+// userQuote loads one of the customer's quotes
+func (s *CateringService) userQuote(ctx context.Context, userID, quoteID string) (*models.CateringQuote, error) {
+	quote, err := s.getQuote(ctx, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.UserID.String() != userID {
+		return nil, fmt.Errorf("%w: %s", ErrQuoteNotFound, quoteID)
+	}
+	return quote, nil
+}
+
+// restaurantQuote loads one of the restaurant's quotes
+func (s *CateringService) restaurantQuote(ctx context.Context, restaurantID, quoteID string) (*models.CateringQuote, error) {
+	quote, err := s.getQuote(ctx, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.RestaurantID.String() != restaurantID {
+		return nil, fmt.Errorf("%w: %s", ErrQuoteNotFound, quoteID)
+	}
+	return quote, nil
+}
+
+// getQuote loads a quote, expiring it first when it ran out of time
+func (s *CateringService) getQuote(ctx context.Context, quoteID string) (*models.CateringQuote, error) {
+	id, err := uuid.Parse(quoteID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrQuoteNotFound, quoteID)
+	}
+	quote, err := s.quoteRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrQuoteNotFound, quoteID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.expire(ctx, quote, time.Now())
+	return quote, nil
+}
+
+// expire marks a quote expired once it can no longer be accepted: an offer past its validity,
+// or a request or offer whose event has passed
+func (s *CateringService) expire(ctx context.Context, quote *models.CateringQuote, now time.Time) {
+	switch {
+	case quote.Status == QuoteQuoted && quote.ValidUntil != nil && now.After(*quote.ValidUntil):
+	case (quote.Status == QuoteRequested || quote.Status == QuoteQuoted) && now.After(quote.EventDate):
+	default:
+		return
+	}
+	quote.Status = QuoteExpired
+	if err := s.quoteRepo.Update(ctx, quote, nil); err != nil {
+		log.Printf("Failed to expire catering quote %s: %v", quote.ID, err)
+	}
+}
+
+// quoteList expires the quotes that ran out of time and describes them
+func (s *CateringService) quoteList(ctx context.Context, quotes []models.CateringQuote) []CateringQuoteDetails {
+	now := time.Now()
+	response := make([]CateringQuoteDetails, 0, len(quotes))
+	for i := range quotes {
+		s.expire(ctx, &quotes[i], now)
+		response = append(response, *newCateringQuoteDetails(&quotes[i]))
+	}
+	return response
+}
+
+// quoteDetails describes the quote with its order's status and payment link
+func (s *CateringService) quoteDetails(ctx context.Context, quote *models.CateringQuote) *CateringQuoteDetails {
+	details := newCateringQuoteDetails(quote)
+	if quote.OrderID == nil {
+		return details
+	}
+	order, err := s.orderRepo.GetByID(ctx, *quote.OrderID)
+	if err != nil {
+		return details
+	}
+	details.OrderStatus = order.OrderStatus
+	if order.PaymentID == nil {
+		return details
+	}
+	if payment, err := s.paymentRepo.GetByID(ctx, *order.PaymentID); err == nil {
+		details.PaymentURL, _ = payment.Metadata["short_url"].(string)
+		details.AmountPaid = models.MoneyOf(payment.Metadata["amount_paid"])
+	}
+	return details
+}
+
+// saveError reports a quote changed by another request as a conflict
+func (s *CateringService) saveError(err error) error {
+	if errors.Is(err, repositories.ErrVersionConflict) {
+		return fmt.Errorf("%w: the quote was changed meanwhile", ErrQuoteState)
+	}
+	return fmt.Errorf("failed to save catering quote: %v", err)
+}
+
+func newCateringQuoteDetails(quote *models.CateringQuote) *CateringQuoteDetails {
+	return &CateringQuoteDetails{CateringQuote: *quote, AdvanceAmount: cateringAdvance(quote)}
+}
+
+// cateringAdvance is the part of the quote paid up front
+func cateringAdvance(quote *models.CateringQuote) models.Money {
+	return quote.QuotedAmount.Percent(float64(quote.AdvancePercent))
+}
+
+func cateringNotification(userID uuid.UUID, quote *models.CateringQuote, eventType, title, message string) (models.OutboxEvent, error) {
+	return NewOutboxEvent("notification_events", userID.String(), messaging.NotificationEvent{
+		Type:    eventType,
+		UserID:  userID.String(),
+		Title:   title,
+		Message: message,
+		Metadata: map[string]interface{}{
+			"catering_quote_id": quote.ID.String(),
+			"restaurant_id":     quote.RestaurantID.String(),
+		},
+	})
+}
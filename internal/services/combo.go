@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/cache"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrComboInvalid  = apperr.Validation("combo_invalid", "invalid combo")
+	ErrComboNotFound = apperr.NotFound("combo_not_found", "combo not found")
+)
+
+// ComboComponentRequest is a menu item to put in a combo
+type ComboComponentRequest struct {
+	ProductID string `json:"product_id" binding:"required"`
+	VariantID string `json:"variant_id,omitempty"` // required when the item has variants
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+}
+
+type SetComboComponentsRequest struct {
+	Components []ComboComponentRequest `json:"components" binding:"required,min=1,dive"`
+}
+
+// ComboComponentDetail is a combo item with its own menu price and availability
+type ComboComponentDetail struct {
+	models.ComboComponent
+	UnitPrice   models.Money `json:"unit_price"`
+	IsAvailable bool         `json:"is_available"`
+}
+
+// ComboResponse is a combo with what its items cost on their own
+type ComboResponse struct {
+	Product         *models.Product        `json:"product"`
+	Components      []ComboComponentDetail `json:"components"`
+	ComponentsTotal models.Money           `json:"components_total"` // the items at their menu prices
+	Savings         models.Money           `json:"savings"`          // what the combo price saves on the items, if anything
+}
+
+// GetCombo returns a combo with its items priced on their own
+func (s *ProductService) GetCombo(ctx context.Context, productID string) (*ComboResponse, error) {
+	objectID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, ErrComboNotFound
+	}
+	product, err := s.productRepo.GetByID(ctx, objectID)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && !product.IsCombo()) {
+		return nil, ErrComboNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get combo: %v", err)
+	}
+	return s.comboResponse(ctx, product)
+}
+
+// SetComboComponents makes a product a combo of other menu items of the restaurant, or
+// changes the items of a combo. The combo sells at its own price.
+func (s *ProductService) SetComboComponents(ctx context.Context, productID, restaurantID string, req *SetComboComponentsRequest) (*ComboResponse, error) {
+	objectID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid product ID", ErrComboInvalid)
+	}
+	product, err := s.productRepo.GetByID(ctx, objectID)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && product.RestaurantID != restaurantID) {
+		return nil, fmt.Errorf("%w: product %s not found", ErrComboNotFound, productID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %v", err)
+	}
+	if len(product.Variants) > 0 {
+		return nil, fmt.Errorf("%w: a product with variants cannot be a combo", ErrComboInvalid)
+	}
+	if !product.IsCombo() {
+		combos, err := s.productRepo.GetCombosWithComponent(ctx, product.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check combos: %v", err)
+		}
+		if len(combos) > 0 {
+			return nil, fmt.Errorf("%w: %s is in the combo %s", ErrComboInvalid, product.Name, combos[0].Name)
+		}
+	}
+
+	components, err := comboComponents(ctx, s.productRepo, restaurantID, product.ID, req.Components)
+	if err != nil {
+		return nil, err
+	}
+	product.Components = components
+	if err := s.productRepo.Update(ctx, product); err != nil {
+		return nil, err
+	}
+	if err := syncCombo(ctx, s.productRepo, s.inventoryRepo, s.cache, product); err != nil {
+		log.Printf("Failed to sync combo %s: %v", product.ID.Hex(), err)
+	}
+
+	s.clearProductCache(restaurantID)
+	s.cache.InvalidateTags(ctx, productCartsTag(productID))
+
+	return s.comboResponse(ctx, product)
+}
+
+func (s *ProductService) comboResponse(ctx context.Context, combo *models.Product) (*ComboResponse, error) {
+	products, err := s.productRepo.GetByIDs(ctx, comboProductIDs(combo))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load combo items: %v", err)
+	}
+	productsByID := make(map[primitive.ObjectID]*models.Product, len(products))
+	for i := range products {
+		productsByID[products[i].ID] = &products[i]
+	}
+
+	response := &ComboResponse{
+		Product:    combo,
+		Components: make([]ComboComponentDetail, 0, len(combo.Components)),
+	}
+	for _, component := range combo.Components {
+		detail := ComboComponentDetail{ComboComponent: component}
+		if product, ok := productsByID[component.ProductID]; ok {
+			detail.IsAvailable = product.IsAvailable
+			if line, err := priceCartItem(product, models.CartItem{ProductID: product.ID.Hex(), Quantity: component.Quantity, VariantID: component.VariantID}); err == nil {
+				detail.UnitPrice = line.Price
+				response.ComponentsTotal += line.Total
+			}
+		}
+		response.Components = append(response.Components, detail)
+	}
+
+	price := combo.PriceSnapshot().SellingPrice()
+	if response.ComponentsTotal > price {
+		response.Savings = response.ComponentsTotal - price
+	}
+	return response, nil
+}
+
+// comboComponents checks a combo's items are products of the restaurant that are not combos
+// themselves, with a variant chosen where they have them, and merges repeats. A combo holds at
+// least two items.
+func comboComponents(ctx context.Context, productRepo repositories.ProductRepository, restaurantID string, comboID primitive.ObjectID, requested []ComboComponentRequest) ([]models.ComboComponent, error) {
+	ids := make([]primitive.ObjectID, 0, len(requested))
+	for _, item := range requested {
+		productID, err := primitive.ObjectIDFromHex(item.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid product ID %s", ErrComboInvalid, item.ProductID)
+		}
+		if productID == comboID {
+			return nil, fmt.Errorf("%w: a combo cannot include itself", ErrComboInvalid)
+		}
+		ids = append(ids, productID)
+	}
+	products, err := productRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load combo items: %v", err)
+	}
+	productsByID := make(map[primitive.ObjectID]*models.Product, len(products))
+	for i := range products {
+		productsByID[products[i].ID] = &products[i]
+	}
+
+	var components []models.ComboComponent
+	positions := make(map[string]int, len(requested))
+	total := 0
+	for i, item := range requested {
+		product, ok := productsByID[ids[i]]
+		if !ok || product.RestaurantID != restaurantID {
+			return nil, fmt.Errorf("%w: product %s not found", ErrComboInvalid, item.ProductID)
+		}
+		if product.IsCombo() {
+			return nil, fmt.Errorf("%w: %s is a combo itself", ErrComboInvalid, product.Name)
+		}
+		if hasRequiredOptions(product.OptionGroups) {
+			return nil, fmt.Errorf("%w: %s needs options chosen", ErrComboInvalid, product.Name)
+		}
+
+		component := models.ComboComponent{
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			Quantity:    item.Quantity,
+		}
+		if item.VariantID != "" || len(product.Variants) > 0 {
+			variant := findVariant(product, item.VariantID)
+			if variant == nil {
+				return nil, fmt.Errorf("%w: choose a variant of %s", ErrComboInvalid, product.Name)
+			}
+			component.VariantID, component.VariantName = item.VariantID, variant.Name
+		}
+		total += item.Quantity
+
+		key := component.ProductID.Hex() + ":" + component.VariantID
+		if position, ok := positions[key]; ok {
+			components[position].Quantity += item.Quantity
+			continue
+		}
+		positions[key] = len(components)
+		components = append(components, component)
+	}
+	if total < 2 {
+		return nil, fmt.Errorf("%w: a combo needs at least two items", ErrComboInvalid)
+	}
+	return components, nil
+}
+
+// syncCombos brings the combos that include a product in line with it after its
+// availability, stock or details change
+func syncCombos(ctx context.Context, productRepo repositories.ProductRepository, inventoryRepo repositories.InventoryRepository, cache *cache.RedisCache, productID primitive.ObjectID) {
+	combos, err := productRepo.GetCombosWithComponent(ctx, productID)
+	if err != nil {
+		log.Printf("Failed to load combos with product %s: %v", productID.Hex(), err)
+		return
+	}
+	for i := range combos {
+		if err := syncCombo(ctx, productRepo, inventoryRepo, cache, &combos[i]); err != nil {
+			log.Printf("Failed to sync combo %s: %v", combos[i].ID.Hex(), err)
+		}
+	}
+}
+
+// syncCombo turns a combo off while one of its items is unavailable, deleted or without the
+// unreserved stock for one combo, and back on once they all are. Like the stock watcher it
+// leaves combos turned off by hand alone. The items' names are refreshed for tickets and bills.
+func syncCombo(ctx context.Context, productRepo repositories.ProductRepository, inventoryRepo repositories.InventoryRepository, cache *cache.RedisCache, combo *models.Product) error {
+	products, err := productRepo.GetByIDs(ctx, comboProductIDs(combo))
+	if err != nil {
+		return err
+	}
+	productsByID := make(map[primitive.ObjectID]*models.Product, len(products))
+	for i := range products {
+		productsByID[products[i].ID] = &products[i]
+	}
+
+	changed := false
+	available := true
+	needed := make(map[primitive.ObjectID]int, len(combo.Components))
+	for i := range combo.Components {
+		component := &combo.Components[i]
+		needed[component.ProductID] += component.Quantity
+
+		product, ok := productsByID[component.ProductID]
+		if !ok || !product.IsAvailable {
+			available = false
+			continue
+		}
+		if product.Name != component.ProductName {
+			component.ProductName = product.Name
+			changed = true
+		}
+		if component.VariantID != "" {
+			variant := findVariant(product, component.VariantID)
+			if variant == nil {
+				available = false
+				continue
+			}
+			if variant.Name != component.VariantName {
+				component.VariantName = variant.Name
+				changed = true
+			}
+		}
+	}
+
+	for productID, quantity := range needed {
+		if !available {
+			break
+		}
+		inventory, err := inventoryRepo.GetByProductID(ctx, productID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if inventory.TrackStock && inventory.Quantity-inventory.ReservedQuantity < quantity {
+			available = false
+		}
+	}
+
+	switch {
+	case !available && combo.IsAvailable:
+		combo.IsAvailable = false
+		combo.OutOfStock = true
+		changed = true
+	case available && combo.OutOfStock:
+		combo.IsAvailable = true
+		combo.OutOfStock = false
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	if err := productRepo.Update(ctx, combo); err != nil {
+		return err
+	}
+	cache.InvalidateTags(ctx, restaurantProductsTag(combo.RestaurantID), productCartsTag(combo.ID.Hex()))
+	return nil
+}
+
+func comboProductIDs(combo *models.Product) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(combo.Components))
+	for _, component := range combo.Components {
+		ids = append(ids, component.ProductID)
+	}
+	return ids
+}
+
+func findVariant(product *models.Product, variantID string) *models.ProductVariant {
+	for i := range product.Variants {
+		if product.Variants[i].ID.Hex() == variantID {
+			return &product.Variants[i]
+		}
+	}
+	return nil
+}
+
+// comboLineComponents lists a combo's items for a cart or order line
+func comboLineComponents(product *models.Product) []models.LineComponent {
+	if !product.IsCombo() {
+		return nil
+	}
+	components := make([]models.LineComponent, 0, len(product.Components))
+	for _, component := range product.Components {
+		components = append(components, models.LineComponent{
+			ProductID:   component.ProductID.Hex(),
+			ProductName: component.ProductName,
+			VariantID:   component.VariantID,
+			VariantName: component.VariantName,
+			Quantity:    component.Quantity,
+		})
+	}
+	return components
+}
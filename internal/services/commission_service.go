@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CommissionPercentage = "percentage"
+	CommissionFlat       = "flat"
+)
+
+var (
+	ErrCommissionInvalid  = apperr.Validation("commission_invalid", "invalid commission")
+	ErrCommissionNotFound = apperr.NotFound("commission_not_found", "commission not found")
+)
+
+type SetCommissionRequest struct {
+	CommissionType  string  `json:"commission_type" binding:"required,oneof=percentage flat"`
+	CommissionValue float64 `json:"commission_value" binding:"gte=0"`
+	EffectiveFrom   string  `json:"effective_from"` // RFC 3339; defaults to now
+	Note            string  `json:"note"`
+}
+
+type CommissionHistoryResponse struct {
+	RestaurantID uuid.UUID                       `json:"restaurant_id"`
+	Active       *models.CommissionToRestaurant  `json:"active"`   // nil while the platform default applies
+	Default      float64                         `json:"default"`  // platform default percentage
+	Versions     []models.CommissionToRestaurant `json:"versions"` // latest first, including scheduled ones
+}
+
+// CommissionService manages restaurants' commission terms as dated versions, so an order is
+// always charged the commission that applied when it was placed
+type CommissionService struct {
+	commissionRepo repositories.CommissionRepository
+	restaurantRepo repositories.RestaurantRepository
+	auditRepo      repositories.AuditLogRepository
+	defaultPercent float64
+}
+
+func NewCommissionService(
+	commissionRepo repositories.CommissionRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	auditRepo repositories.AuditLogRepository,
+	defaultPercent float64,
+) *CommissionService {
+	return &CommissionService{
+		commissionRepo: commissionRepo,
+		restaurantRepo: restaurantRepo,
+		auditRepo:      auditRepo,
+		defaultPercent: defaultPercent,
+	}
+}
+
+// GetHistory returns a restaurant's commission versions and the one active now
+func (s *CommissionService) GetHistory(ctx context.Context, restaurantID string) (*CommissionHistoryResponse, error) {
+	restaurant, err := s.restaurant(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.commissionRepo.GetHistory(ctx, restaurant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load commission history: %v", err)
+	}
+
+	return &CommissionHistoryResponse{
+		RestaurantID: restaurant.ID,
+		Active:       commissionAt(versions, time.Now()),
+		Default:      s.defaultPercent,
+		Versions:     versions,
+	}, nil
+}
+
+// SetCommission adds a commission version starting now or at a future date. Past dates are
+// rejected so orders that were already placed keep the terms they were placed under.
+func (s *CommissionService) SetCommission(ctx context.Context, adminID, restaurantID string, req *SetCommissionRequest) (*models.CommissionToRestaurant, error) {
+	restaurant, err := s.restaurant(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CommissionType == CommissionPercentage && req.CommissionValue > 100 {
+		return nil, fmt.Errorf("%w: a percentage commission cannot exceed 100", ErrCommissionInvalid)
+	}
+
+	now := time.Now()
+	effectiveFrom := now
+	if req.EffectiveFrom != "" {
+		effectiveFrom, err = time.Parse(time.RFC3339, req.EffectiveFrom)
+		if err != nil {
+			return nil, fmt.Errorf("%w: effective_from must be an RFC 3339 timestamp", ErrCommissionInvalid)
+		}
+		if effectiveFrom.Before(now.Add(-time.Minute)) {
+			return nil, fmt.Errorf("%w: effective_from cannot be in the past", ErrCommissionInvalid)
+		}
+	}
+
+	versions, err := s.commissionRepo.GetHistory(ctx, restaurant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load commission history: %v", err)
+	}
+	for _, version := range versions {
+		if version.EffectiveFrom.Equal(effectiveFrom) {
+			return nil, fmt.Errorf("%w: a commission already starts at %s", ErrCommissionInvalid, effectiveFrom.Format(time.RFC3339))
+		}
+	}
+
+	commission := &models.CommissionToRestaurant{
+		RestaurantID:    restaurant.ID,
+		CommissionType:  req.CommissionType,
+		CommissionValue: req.CommissionValue,
+		EffectiveFrom:   effectiveFrom,
+		Note:            strings.TrimSpace(req.Note),
+		CreatedAt:       now,
+	}
+	if adminUUID, err := uuid.Parse(adminID); err == nil {
+		commission.CreatedBy = &adminUUID
+	}
+
+	if err := s.commissionRepo.CreateVersion(ctx, commission); err != nil {
+		return nil, fmt.Errorf("failed to save commission: %v", err)
+	}
+
+	s.audit(ctx, commission, adminID, "commission_scheduled")
+	return commission, nil
+}
+
+// DeleteScheduled removes a commission version that has not started yet
+func (s *CommissionService) DeleteScheduled(ctx context.Context, adminID, restaurantID, commissionID string) error {
+	restaurant, err := s.restaurant(ctx, restaurantID)
+	if err != nil {
+		return err
+	}
+
+	commissionUUID, err := uuid.Parse(commissionID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid commission ID", ErrCommissionInvalid)
+	}
+	commission, err := s.commissionRepo.GetByID(ctx, commissionUUID)
+	if err != nil || commission.RestaurantID != restaurant.ID {
+		return fmt.Errorf("%w: commission %s", ErrCommissionNotFound, commissionID)
+	}
+	if !commission.EffectiveFrom.After(time.Now()) {
+		return fmt.Errorf("%w: only commissions that have not started can be deleted", ErrCommissionInvalid)
+	}
+
+	if err := s.commissionRepo.DeleteVersion(ctx, commission); err != nil {
+		return fmt.Errorf("failed to delete commission: %v", err)
+	}
+
+	s.audit(ctx, commission, adminID, "commission_deleted")
+	return nil
+}
+
+func (s *CommissionService) audit(ctx context.Context, commission *models.CommissionToRestaurant, adminID, action string) {
+	entry := &models.AuditLog{
+		EntityType: "restaurant",
+		EntityID:   commission.RestaurantID.String(),
+		Action:     action,
+		Metadata: models.JSONB{
+			"commission_id":    commission.ID.String(),
+			"commission_type":  commission.CommissionType,
+			"commission_value": commission.CommissionValue,
+			"effective_from":   commission.EffectiveFrom,
+		},
+	}
+	if adminUUID, err := uuid.Parse(adminID); err == nil {
+		entry.PerformedBy = &adminUUID
+	}
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write commission audit entry for restaurant %s: %v", commission.RestaurantID, err)
+	}
+}
+
+func (s *CommissionService) restaurant(ctx context.Context, restaurantID string) (*models.Restaurant, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrCommissionInvalid)
+	}
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: restaurant %s", ErrCommissionNotFound, restaurantID)
+	}
+	return restaurant, nil
+}
+
+// commissionAt picks the version in effect at a moment from a restaurant's history, or nil
+// when none was
+func commissionAt(versions []models.CommissionToRestaurant, at time.Time) *models.CommissionToRestaurant {
+	for i := range versions {
+		version := &versions[i]
+		if version.EffectiveFrom.After(at) {
+			continue
+		}
+		if version.EffectiveTo != nil && !version.EffectiveTo.After(at) {
+			continue
+		}
+		return version
+	}
+	return nil
+}
+
+// commissionAmount charges a commission version, or the default percentage without one, on
+// an order's value
+func commissionAmount(commission *models.CommissionToRestaurant, defaultPercent float64, value models.Money) models.Money {
+	if commission == nil {
+		return value.Percent(defaultPercent)
+	}
+	if commission.CommissionType == CommissionFlat {
+		return models.NewMoney(commission.CommissionValue)
+	}
+	return value.Percent(commission.CommissionValue)
+}
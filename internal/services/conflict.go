@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+)
+
+// maxConflictRetries is how many times a cart or order change is read and applied again after
+// losing a race with another request
+const maxConflictRetries = 3
+
+// ErrConcurrentUpdate is returned when a cart or order kept changing underneath a request
+// until its retries ran out; the client should reload and try again
+var ErrConcurrentUpdate = apperr.Conflict("concurrent_update", "the record was changed by another request, please retry")
+
+// retryOnConflict runs apply, which must read the record, change it and save it, until the save
+// no longer hits a version conflict. Retries back off for a few jittered milliseconds.
+func retryOnConflict(ctx context.Context, apply func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := apply()
+		if !errors.Is(err, repositories.ErrVersionConflict) {
+			return err
+		}
+		if attempt == maxConflictRetries {
+			return fmt.Errorf("%w: %v", ErrConcurrentUpdate, err)
+		}
+
+		backoff := time.Duration(10*(attempt+1)+rand.Intn(10)) * time.Millisecond
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// updateOrder applies change to an order the caller has already read and saves it, with the
+// cancellation or completion event when change moves it into either status. When the save
+// loses a race the order is read again and change applied to the fresh copy, so change must
+// only depend on the order it is given. order is left holding what was saved.
+func updateOrder(ctx context.Context, orders repositories.OrderRepository, order *models.Order, change func(*models.Order) error) error {
+	current := order
+	return retryOnConflict(ctx, func() error {
+		if current == nil {
+			fresh, err := orders.GetByID(ctx, order.ID)
+			if err != nil {
+				return err
+			}
+			current = fresh
+		}
+		previous := current.OrderStatus
+		if err := change(current); err != nil {
+			return err
+		}
+		events, err := orderStatusEvents(current, previous)
+		if err != nil {
+			return err
+		}
+		if err := orders.UpdateWithEvents(ctx, current, events); err != nil {
+			if errors.Is(err, repositories.ErrVersionConflict) {
+				current = nil
+			}
+			return err
+		}
+		if current != order {
+			*order = *current
+		}
+		return nil
+	})
+}
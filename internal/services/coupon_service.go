@@ -2,56 +2,71 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"golang-food-backend/internal/models"
 	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/messaging"
 
 	"github.com/google/uuid"
 )
 
 type CouponService struct {
 	couponRepo repositories.CouponRepository
+	orderRepo  repositories.OrderRepository
 }
 
-func NewCouponService(couponRepo repositories.CouponRepository) *CouponService {
+func NewCouponService(couponRepo repositories.CouponRepository, orderRepo repositories.OrderRepository) *CouponService {
 	return &CouponService{
 		couponRepo: couponRepo,
+		orderRepo:  orderRepo,
 	}
 }
 
 // Request and Response types
 type CreateCouponRequest struct {
-	Code                  string   `json:"code" binding:"required"`
-	Description           string   `json:"description" binding:"required"`
-	DiscountType          string   `json:"discount_type" binding:"required,oneof=percentage fixed"`
-	DiscountValue         float64  `json:"discount_value" binding:"required,min=0"`
-	MinimumOrderAmount    *float64 `json:"minimum_order_amount"`
-	MaximumDiscountAmount *float64 `json:"maximum_discount_amount"`
-	UsageLimit            *int     `json:"usage_limit"`
-	ValidFrom             string   `json:"valid_from" binding:"required"`
-	ValidUntil            string   `json:"valid_until" binding:"required"`
-	RestaurantID          *string  `json:"restaurant_id"`
-	IsActive              bool     `json:"is_active"`
+	Code                  string        `json:"code" binding:"required"`
+	Description           string        `json:"description" binding:"required"`
+	DiscountType          string        `json:"discount_type" binding:"required,oneof=percentage fixed"`
+	DiscountValue         float64       `json:"discount_value" binding:"required,min=0"`
+	MinimumOrderAmount    *models.Money `json:"minimum_order_amount"`
+	MaximumDiscountAmount *models.Money `json:"maximum_discount_amount"`
+	UsageLimit            *int          `json:"usage_limit"`
+	ValidFrom             string        `json:"valid_from" binding:"required"`
+	ValidUntil            string        `json:"valid_until" binding:"required"`
+	RestaurantID          *string       `json:"restaurant_id"`
+	IsActive              bool          `json:"is_active"`
+	PerUserLimit          *int          `json:"per_user_limit" binding:"omitempty,min=0"`
+	NewUsersOnly          bool          `json:"new_users_only"`
+	MinPastOrders         *int          `json:"min_past_orders" binding:"omitempty,min=0"`
+	TargetUserIDs         []string      `json:"target_user_ids"`
 }
 
 type UpdateCouponRequest struct {
-	Description           string   `json:"description"`
-	DiscountType          string   `json:"discount_type" binding:"omitempty,oneof=percentage fixed"`
-	DiscountValue         *float64 `json:"discount_value" binding:"omitempty,min=0"`
-	MinimumOrderAmount    *float64 `json:"minimum_order_amount"`
-	MaximumDiscountAmount *float64 `json:"maximum_discount_amount"`
-	UsageLimit            *int     `json:"usage_limit"`
-	ValidFrom             string   `json:"valid_from"`
-	ValidUntil            string   `json:"valid_until"`
-	IsActive              *bool    `json:"is_active"`
+	Description           string        `json:"description"`
+	DiscountType          string        `json:"discount_type" binding:"omitempty,oneof=percentage fixed"`
+	DiscountValue         *float64      `json:"discount_value" binding:"omitempty,min=0"`
+	MinimumOrderAmount    *models.Money `json:"minimum_order_amount"`
+	MaximumDiscountAmount *models.Money `json:"maximum_discount_amount"`
+	UsageLimit            *int          `json:"usage_limit"`
+	ValidFrom             string        `json:"valid_from"`
+	ValidUntil            string        `json:"valid_until"`
+	IsActive              *bool         `json:"is_active"`
+	PerUserLimit          *int          `json:"per_user_limit" binding:"omitempty,min=0"`
+	NewUsersOnly          *bool         `json:"new_users_only"`
+	MinPastOrders         *int          `json:"min_past_orders" binding:"omitempty,min=0"`
+	TargetUserIDs         []string      `json:"target_user_ids"` // replaces the list when present; [] clears it
 }
 
 type ValidateCouponRequest struct {
-	Code         string  `json:"code" binding:"required"`
-	RestaurantID string  `json:"restaurant_id" binding:"required"`
-	OrderAmount  float64 `json:"order_amount" binding:"required,min=0"`
+	Code         string       `json:"code" binding:"required"`
+	RestaurantID string       `json:"restaurant_id" binding:"required"`
+	OrderAmount  models.Money `json:"order_amount" binding:"required,min=0"`
 }
 
 type CouponListResponse struct {
@@ -61,11 +76,24 @@ type CouponListResponse struct {
 	TotalPages int             `json:"total_pages"`
 }
 
+type CouponRedemptionListResponse struct {
+	Redemptions []models.CouponRedemption `json:"redemptions"`
+	Total       int64                     `json:"total"`
+	Page        int                       `json:"page"`
+	TotalPages  int                       `json:"total_pages"`
+}
+
+// AppliedCoupon is a coupon that applies to an order and the discount it realizes
+type AppliedCoupon struct {
+	Coupon         *models.Coupon `json:"coupon"`
+	DiscountAmount models.Money   `json:"discount_amount"`
+}
+
 type CouponValidationResponse struct {
 	Valid          bool           `json:"valid"`
 	DiscountType   string         `json:"discount_type,omitempty"`
 	DiscountValue  float64        `json:"discount_value,omitempty"`
-	DiscountAmount float64        `json:"discount_amount,omitempty"`
+	DiscountAmount models.Money   `json:"discount_amount,omitempty"`
 	Message        string         `json:"message,omitempty"`
 	Coupon         *models.Coupon `json:"coupon,omitempty"`
 }
@@ -109,6 +137,21 @@ func (s *CouponService) CreateCoupon(ctx context.Context, userID string, req *Cr
 	} else {
 		coupon.UsageLimit = -1 // unlimited
 	}
+	if req.PerUserLimit != nil {
+		coupon.PerUserLimit = *req.PerUserLimit
+	}
+	if req.MinPastOrders != nil {
+		coupon.MinPastOrders = *req.MinPastOrders
+	}
+	coupon.NewUsersOnly = req.NewUsersOnly
+	if coupon.NewUsersOnly && coupon.MinPastOrders > 0 {
+		return nil, errors.New("new_users_only cannot be combined with min_past_orders")
+	}
+	targets, err := parseTargetUserIDs(req.TargetUserIDs)
+	if err != nil {
+		return nil, err
+	}
+	coupon.TargetUserIDs = targets
 
 	// Set restaurant ID if provided
 	if req.RestaurantID != nil {
@@ -163,6 +206,11 @@ func (s *CouponService) GetCouponByID(ctx context.Context, couponID string) (*mo
 }
 
 func (s *CouponService) ValidateCoupon(ctx context.Context, userID string, req *ValidateCouponRequest) (*CouponValidationResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
 	var restID *uuid.UUID
 	if req.RestaurantID != "" {
 		parsed, err := uuid.Parse(req.RestaurantID)
@@ -184,66 +232,334 @@ func (s *CouponService) ValidateCoupon(ctx context.Context, userID string, req *
 		}, nil
 	}
 
-	// Check if coupon is active
-	if !coupon.IsActive {
-		return &CouponValidationResponse{
-			Valid:   false,
-			Message: "Coupon is not active",
-		}, nil
+	discountAmount, reason := evaluateCoupon(coupon, restID, req.OrderAmount, time.Now())
+	if reason == "" {
+		reason, err = s.newAudience(userUUID).check(ctx, coupon)
+		if err != nil {
+			return nil, err
+		}
 	}
-
-	// Check if coupon is valid for this restaurant
-	if coupon.RestaurantID != nil && restID != nil && *coupon.RestaurantID != *restID {
+	if reason != "" {
 		return &CouponValidationResponse{
 			Valid:   false,
-			Message: "Coupon is not valid for this restaurant",
+			Message: reason,
 		}, nil
 	}
 
-	// Check date validity
+	return &CouponValidationResponse{
+		Valid:          true,
+		DiscountType:   coupon.DiscountType,
+		DiscountValue:  coupon.DiscountValue,
+		DiscountAmount: discountAmount,
+		Message:        "Coupon is valid",
+		Coupon:         coupon,
+	}, nil
+}
+
+// ApplicableCoupons evaluates every active platform and restaurant coupon against the user's
+// order and returns those that apply, largest discount first
+func (s *CouponService) ApplicableCoupons(ctx context.Context, userID, restaurantID uuid.UUID, orderAmount models.Money) ([]AppliedCoupon, error) {
 	now := time.Now()
+	coupons, err := s.couponRepo.GetActiveForRestaurant(ctx, restaurantID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	audience := s.newAudience(userID)
+	if err := audience.loadRedemptions(ctx, coupons); err != nil {
+		return nil, err
+	}
+
+	applicable := make([]AppliedCoupon, 0, len(coupons))
+	for i := range coupons {
+		discountAmount, reason := evaluateCoupon(&coupons[i], &restaurantID, orderAmount, now)
+		if reason != "" || discountAmount <= 0 {
+			continue
+		}
+		reason, err := audience.check(ctx, &coupons[i])
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			continue
+		}
+		applicable = append(applicable, AppliedCoupon{
+			Coupon:         &coupons[i],
+			DiscountAmount: discountAmount,
+		})
+	}
+
+	sort.SliceStable(applicable, func(i, j int) bool {
+		if applicable[i].DiscountAmount != applicable[j].DiscountAmount {
+			return applicable[i].DiscountAmount > applicable[j].DiscountAmount
+		}
+		return applicable[i].Coupon.Code < applicable[j].Coupon.Code
+	})
+
+	return applicable, nil
+}
+
+// BestCoupon returns the coupon giving the largest discount on the order, or nil when none apply
+func (s *CouponService) BestCoupon(ctx context.Context, userID, restaurantID uuid.UUID, orderAmount models.Money) (*AppliedCoupon, error) {
+	applicable, err := s.ApplicableCoupons(ctx, userID, restaurantID, orderAmount)
+	if err != nil || len(applicable) == 0 {
+		return nil, err
+	}
+	return &applicable[0], nil
+}
+
+// ApplyCode checks a coupon code against the user's order and returns the discount it gives
+func (s *CouponService) ApplyCode(ctx context.Context, code string, userID, restaurantID uuid.UUID, orderAmount models.Money) (*AppliedCoupon, error) {
+	coupon, err := s.couponRepo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, errors.New("coupon not found")
+	}
+	return s.apply(ctx, coupon, userID, restaurantID, orderAmount)
+}
+
+// ApplyByID re-checks a coupon already attached to a cart against the current order amount
+func (s *CouponService) ApplyByID(ctx context.Context, couponID, userID, restaurantID uuid.UUID, orderAmount models.Money) (*AppliedCoupon, error) {
+	coupon, err := s.couponRepo.GetByID(ctx, couponID)
+	if err != nil {
+		return nil, errors.New("coupon not found")
+	}
+	return s.apply(ctx, coupon, userID, restaurantID, orderAmount)
+}
+
+func (s *CouponService) apply(ctx context.Context, coupon *models.Coupon, userID, restaurantID uuid.UUID, orderAmount models.Money) (*AppliedCoupon, error) {
+	discountAmount, reason := evaluateCoupon(coupon, &restaurantID, orderAmount, time.Now())
+	if reason == "" {
+		var err error
+		if reason, err = s.newAudience(userID).check(ctx, coupon); err != nil {
+			return nil, err
+		}
+	}
+	if reason != "" {
+		return nil, errors.New(reason)
+	}
+	return &AppliedCoupon{Coupon: coupon, DiscountAmount: discountAmount}, nil
+}
+
+// Redeem records the coupon against a placed order. The global and per-user limits are
+// enforced again here since other orders may have used the coupon since it was applied.
+func (s *CouponService) Redeem(ctx context.Context, couponID, userID, orderID uuid.UUID, discountAmount models.Money) error {
+	coupon, err := s.couponRepo.GetByID(ctx, couponID)
+	if err != nil {
+		return errors.New("coupon not found")
+	}
+
+	return s.couponRepo.Redeem(ctx, &models.CouponRedemption{
+		CouponID:       couponID,
+		UserID:         userID,
+		OrderID:        orderID,
+		DiscountAmount: discountAmount,
+		Status:         "redeemed",
+	}, coupon.PerUserLimit)
+}
+
+// ReleaseRedemption returns the coupon use of an order that was cancelled or never placed
+func (s *CouponService) ReleaseRedemption(ctx context.Context, orderID uuid.UUID) error {
+	return s.couponRepo.ReleaseRedemption(ctx, orderID)
+}
+
+// GetRedemptions lists a coupon's uses, newest first
+func (s *CouponService) GetRedemptions(ctx context.Context, couponID string, page, limit int) (*CouponRedemptionListResponse, error) {
+	id, err := uuid.Parse(couponID)
+	if err != nil {
+		return nil, errors.New("invalid coupon ID")
+	}
+
+	redemptions, total, err := s.couponRepo.GetRedemptions(ctx, id, (page-1)*limit, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CouponRedemptionListResponse{
+		Redemptions: redemptions,
+		Total:       total,
+		Page:        page,
+		TotalPages:  int((total + int64(limit) - 1) / int64(limit)),
+	}, nil
+}
+
+// HandleOrderEvent is the Kafka handler for the order_events topic. A cancelled order gives
+// its coupon use back to the coupon and to the customer.
+func (s *CouponService) HandleOrderEvent(payload []byte) error {
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("invalid order event: %v", err)
+	}
+	if event.Type != messaging.EventOrderCancelled {
+		return nil
+	}
+
+	var cancelled messaging.OrderCancelled
+	if _, err := messaging.Events.Decode(payload, &cancelled); err != nil {
+		return err
+	}
+	orderID, err := uuid.Parse(cancelled.OrderID)
+	if err != nil {
+		return fmt.Errorf("invalid order ID in event: %v", err)
+	}
+	return s.couponRepo.ReleaseRedemption(context.Background(), orderID)
+}
+
+// Subscribe consumes order events to give back coupon uses of cancelled orders
+func (s *CouponService) Subscribe(consumer *messaging.KafkaConsumer, groupID string) {
+	consumer.Subscribe("order_events", groupID, s.HandleOrderEvent, messaging.SubscribeOptions{})
+}
+
+// evaluateCoupon applies the coupon rules to an order. It returns the discount, or a reason
+// the coupon cannot be used. The discount never exceeds the order amount.
+func evaluateCoupon(coupon *models.Coupon, restaurantID *uuid.UUID, orderAmount models.Money, now time.Time) (models.Money, string) {
+	if !coupon.IsActive {
+		return 0, "Coupon is not active"
+	}
+
+	if coupon.RestaurantID != nil && restaurantID != nil && *coupon.RestaurantID != *restaurantID {
+		return 0, "Coupon is not valid for this restaurant"
+	}
+
 	if now.Before(coupon.ValidFrom) || now.After(coupon.ValidTo) {
-		return &CouponValidationResponse{
-			Valid:   false,
-			Message: "Coupon has expired or is not yet valid",
-		}, nil
+		return 0, "Coupon has expired or is not yet valid"
 	}
 
-	// Check usage limit
 	if coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit {
-		return &CouponValidationResponse{
-			Valid:   false,
-			Message: "Coupon usage limit exceeded",
-		}, nil
+		return 0, "Coupon usage limit exceeded"
 	}
 
-	// Check minimum order amount
-	if coupon.MinOrderValue > 0 && req.OrderAmount < coupon.MinOrderValue {
-		return &CouponValidationResponse{
-			Valid:   false,
-			Message: "Order amount is below minimum required",
-		}, nil
+	if coupon.MinOrderValue > 0 && orderAmount < coupon.MinOrderValue {
+		return 0, "Order amount is below minimum required"
 	}
 
-	// Calculate discount amount
-	var discountAmount float64
+	var discountAmount models.Money
 	if coupon.DiscountType == "percentage" {
-		discountAmount = req.OrderAmount * (coupon.DiscountValue / 100)
+		discountAmount = orderAmount.Percent(coupon.DiscountValue)
 		if coupon.MaxDiscount > 0 && discountAmount > coupon.MaxDiscount {
 			discountAmount = coupon.MaxDiscount
 		}
 	} else {
-		discountAmount = coupon.DiscountValue
+		discountAmount = models.NewMoney(coupon.DiscountValue)
+	}
+	if discountAmount > orderAmount {
+		discountAmount = orderAmount
 	}
 
-	return &CouponValidationResponse{
-		Valid:          true,
-		DiscountType:   coupon.DiscountType,
-		DiscountValue:  coupon.DiscountValue,
-		DiscountAmount: discountAmount,
-		Message:        "Coupon is valid",
-		Coupon:         coupon,
-	}, nil
+	return discountAmount, ""
+}
+
+// couponAudience answers the targeting rules for one user. Order counts are loaded on first
+// use, so checking many coupons costs at most one query of each kind.
+type couponAudience struct {
+	couponRepo  repositories.CouponRepository
+	orderRepo   repositories.OrderRepository
+	userID      uuid.UUID
+	placed      *int64
+	delivered   *int64
+	redemptions map[uuid.UUID]int64
+}
+
+func (s *CouponService) newAudience(userID uuid.UUID) *couponAudience {
+	return &couponAudience{
+		couponRepo: s.couponRepo,
+		orderRepo:  s.orderRepo,
+		userID:     userID,
+	}
+}
+
+// loadRedemptions fetches the user's redemption counts for every coupon with a per-user limit
+func (a *couponAudience) loadRedemptions(ctx context.Context, coupons []models.Coupon) error {
+	var limited []uuid.UUID
+	for _, coupon := range coupons {
+		if coupon.PerUserLimit > 0 {
+			limited = append(limited, coupon.ID)
+		}
+	}
+	counts, err := a.couponRepo.CountUserRedemptions(ctx, a.userID, limited)
+	if err != nil {
+		return err
+	}
+	a.redemptions = counts
+	return nil
+}
+
+// check returns why the user cannot use the coupon, or "" when they can
+func (a *couponAudience) check(ctx context.Context, coupon *models.Coupon) (string, error) {
+	if len(coupon.TargetUserIDs) > 0 {
+		targeted := false
+		for _, id := range coupon.TargetUserIDs {
+			if id == a.userID.String() {
+				targeted = true
+				break
+			}
+		}
+		if !targeted {
+			return "Coupon is not available for your account", nil
+		}
+	}
+
+	if coupon.NewUsersOnly {
+		placed, err := a.orderCount(ctx, false)
+		if err != nil {
+			return "", err
+		}
+		if placed > 0 {
+			return "Coupon is only valid on your first order", nil
+		}
+	}
+
+	if coupon.MinPastOrders > 0 {
+		delivered, err := a.orderCount(ctx, true)
+		if err != nil {
+			return "", err
+		}
+		if delivered < int64(coupon.MinPastOrders) {
+			return fmt.Sprintf("Coupon requires at least %d completed orders", coupon.MinPastOrders), nil
+		}
+	}
+
+	if coupon.PerUserLimit > 0 {
+		if a.redemptions == nil {
+			if err := a.loadRedemptions(ctx, []models.Coupon{*coupon}); err != nil {
+				return "", err
+			}
+		}
+		if a.redemptions[coupon.ID] >= int64(coupon.PerUserLimit) {
+			return "You have already used this coupon the maximum number of times", nil
+		}
+	}
+
+	return "", nil
+}
+
+func (a *couponAudience) orderCount(ctx context.Context, deliveredOnly bool) (int64, error) {
+	cached := &a.placed
+	if deliveredOnly {
+		cached = &a.delivered
+	}
+	if *cached == nil {
+		count, err := a.orderRepo.CountByUser(ctx, a.userID, deliveredOnly)
+		if err != nil {
+			return 0, err
+		}
+		*cached = &count
+	}
+	return **cached, nil
+}
+
+// parseTargetUserIDs validates a coupon's target user list
+func parseTargetUserIDs(ids []string) (models.StringArray, error) {
+	targets := make(models.StringArray, 0, len(ids))
+	for _, id := range ids {
+		parsed, err := uuid.Parse(strings.TrimSpace(id))
+		if err != nil {
+			return nil, fmt.Errorf("invalid target user ID: %s", id)
+		}
+		targets = append(targets, parsed.String())
+	}
+	return targets, nil
 }
 
 func (s *CouponService) UpdateCoupon(ctx context.Context, userID, couponID string, req *UpdateCouponRequest) (*models.Coupon, error) {
@@ -293,6 +609,25 @@ func (s *CouponService) UpdateCoupon(ctx context.Context, userID, couponID strin
 	if req.IsActive != nil {
 		coupon.IsActive = *req.IsActive
 	}
+	if req.PerUserLimit != nil {
+		coupon.PerUserLimit = *req.PerUserLimit
+	}
+	if req.NewUsersOnly != nil {
+		coupon.NewUsersOnly = *req.NewUsersOnly
+	}
+	if req.MinPastOrders != nil {
+		coupon.MinPastOrders = *req.MinPastOrders
+	}
+	if coupon.NewUsersOnly && coupon.MinPastOrders > 0 {
+		return nil, errors.New("new_users_only cannot be combined with min_past_orders")
+	}
+	if req.TargetUserIDs != nil {
+		targets, err := parseTargetUserIDs(req.TargetUserIDs)
+		if err != nil {
+			return nil, err
+		}
+		coupon.TargetUserIDs = targets
+	}
 
 	if err := s.couponRepo.Update(ctx, coupon); err != nil {
 		return nil, err
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/apperr"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrDeliveryCodeRequired is returned when an order of a restaurant that requires delivery
+	// codes is marked delivered before its code was verified
+	ErrDeliveryCodeRequired = apperr.Conflict("delivery_code_required", "delivery code must be verified before the order is marked delivered")
+	ErrDeliveryCodeInvalid  = apperr.Unprocessable("delivery_code_invalid", "invalid delivery code")
+)
+
+type DeliveryCodeResponse struct {
+	OrderID      string     `json:"order_id"`
+	DeliveryCode string     `json:"delivery_code"`
+	Required     bool       `json:"required"` // the order is only marked delivered once the code is verified
+	VerifiedAt   *time.Time `json:"verified_at,omitempty"`
+}
+
+// assignDeliveryCode gives a new order the code its customer shares with the rider at handoff
+func assignDeliveryCode(order *models.Order) error {
+	code, err := generateHandoverOTP()
+	if err != nil {
+		return fmt.Errorf("failed to generate delivery code: %v", err)
+	}
+	order.DeliveryCode = code
+	return nil
+}
+
+// deliveryCodePending reports whether the order cannot be marked delivered yet because its
+// restaurant requires a verified delivery code. Orders placed before codes were issued have
+// none and are not held.
+func deliveryCodePending(order *models.Order) bool {
+	return order.Restaurant.RequireDeliveryCode && order.DeliveryCode != "" && order.DeliveryCodeVerifiedAt == nil
+}
+
+// verifyDeliveryCode checks the code given at handoff and records the verification on the order
+func verifyDeliveryCode(order *models.Order, code string) error {
+	if order.DeliveryCode == "" || subtle.ConstantTimeCompare([]byte(order.DeliveryCode), []byte(code)) != 1 {
+		return ErrDeliveryCodeInvalid
+	}
+	if order.DeliveryCodeVerifiedAt == nil {
+		now := time.Now()
+		order.DeliveryCodeVerifiedAt = &now
+	}
+	return nil
+}
+
+// GetDeliveryCode returns the code the customer shares with the rider or delivery partner, or
+// shows at the counter to collect a pickup order
+func (s *OrderService) GetDeliveryCode(ctx context.Context, orderID, userID string) (*DeliveryCodeResponse, error) {
+	order, err := s.GetOrderByID(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if order.DeliveryCode == "" {
+		return nil, errors.New("order has no delivery code")
+	}
+
+	return &DeliveryCodeResponse{
+		OrderID:      order.ID.String(),
+		DeliveryCode: order.DeliveryCode,
+		Required:     order.Restaurant.RequireDeliveryCode,
+		VerifiedAt:   order.DeliveryCodeVerifiedAt,
+	}, nil
+}
+
+// VerifyDeliveryCode checks the code a delivery partner's rider collected at the door, relayed
+// by restaurant staff, and marks the out-for-delivery order delivered. For pickup orders it is
+// the collection code the customer shows at the counter, and the order is marked collected.
+func (s *OrderService) VerifyDeliveryCode(ctx context.Context, orderID, restaurantID, code string) (*models.Order, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, errors.New("invalid order ID")
+	}
+
+	var order *models.Order
+	err = retryOnConflict(ctx, func() error {
+		order, err = s.orderRepo.GetByID(ctx, orderUUID)
+		if err != nil {
+			return err
+		}
+		if order.RestaurantID.String() != restaurantID {
+			return errors.New("order does not belong to this restaurant")
+		}
+		if order.OrderType == OrderTypePickup && order.OrderStatus != "ready_for_pickup" {
+			return errors.New("order is not ready for pickup")
+		}
+		if order.OrderType != OrderTypePickup && order.OrderStatus != "dispatched" {
+			return errors.New("order is not out for delivery")
+		}
+		if err := verifyDeliveryCode(order, code); err != nil {
+			return err
+		}
+		return s.applyOrderStatus(ctx, order, "delivered")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.tracking.PublishStatus(ctx, order)
+
+	return order, nil
+}
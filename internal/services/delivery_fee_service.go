@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/cache"
+	"golang-food-backend/pkg/geo"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Geohash length used to share cached quotes between nearby drop points (~150m cells)
+const deliveryQuoteGeohashPrecision = 7
+
+// ErrOutsideDeliveryArea is returned when the restaurant has delivery boundaries and none of
+// them covers the address
+var ErrOutsideDeliveryArea = apperr.Unprocessable("outside_delivery_area", "address is outside the restaurant's delivery area")
+
+// DeliveryFeePolicy holds the platform-wide delivery fee settings
+type DeliveryFeePolicy struct {
+	DefaultFee         models.Money  // used when neither a provider quote nor a boundary fee is available
+	FreeDeliveryAbove  models.Money  // platform free-delivery threshold on the subtotal; 0 disables
+	QuoteTTL           time.Duration // how long a base fare is reused for the same restaurant and geohash
+	MaxSurgeMultiplier float64
+}
+
+type DeliveryFeeService struct {
+	deliveryPartnerService *DeliveryPartnerService
+	restaurantRepo         repositories.RestaurantRepository
+	boundaryRepo           repositories.DeliveryBoundaryRepository
+	addressRepo            repositories.AddressRepository
+	cache                  *cache.RedisCache
+	policy                 DeliveryFeePolicy
+}
+
+func NewDeliveryFeeService(
+	deliveryPartnerService *DeliveryPartnerService,
+	restaurantRepo repositories.RestaurantRepository,
+	boundaryRepo repositories.DeliveryBoundaryRepository,
+	addressRepo repositories.AddressRepository,
+	cache *cache.RedisCache,
+	policy DeliveryFeePolicy,
+) *DeliveryFeeService {
+	return &DeliveryFeeService{
+		deliveryPartnerService: deliveryPartnerService,
+		restaurantRepo:         restaurantRepo,
+		boundaryRepo:           boundaryRepo,
+		addressRepo:            addressRepo,
+		cache:                  cache,
+		policy:                 policy,
+	}
+}
+
+// DeliveryFeeQuote is the delivery charge for a cart, with how it was derived
+type DeliveryFeeQuote struct {
+	Fee               models.Money `json:"fee"`
+	BaseFee           models.Money `json:"base_fee"`
+	Source            string       `json:"source"` // provider, boundary, default
+	Provider          string       `json:"provider,omitempty"`
+	SurgeMultiplier   float64      `json:"surge_multiplier"`
+	FreeDelivery      bool         `json:"free_delivery"`
+	FreeDeliveryAbove models.Money `json:"free_delivery_above,omitempty"`
+	Geohash           string       `json:"geohash,omitempty"`
+	BoundaryID        *uuid.UUID   `json:"boundary_id,omitempty"`     // delivery boundary covering the address
+	MinOrderValue     models.Money `json:"min_order_value,omitempty"` // the boundary's minimum subtotal
+	TravelMinutes     int          `json:"travel_minutes,omitempty"`  // pickup to drop; 0 when unknown
+	QuotedAt          time.Time    `json:"quoted_at"`
+}
+
+type SetSurgeRequest struct {
+	RestaurantID    string  `json:"restaurant_id"` // empty applies platform-wide
+	Multiplier      float64 `json:"multiplier" binding:"required,gte=1"`
+	DurationMinutes int     `json:"duration_minutes" binding:"required,gt=0"`
+}
+
+// baseFare is the cached, pre-surge fare for a restaurant and drop area
+type baseFare struct {
+	Fee           models.Money `json:"fee"`
+	Source        string       `json:"source"`
+	Provider      string       `json:"provider,omitempty"`
+	TravelMinutes int          `json:"travel_minutes,omitempty"`
+}
+
+func deliveryQuoteKey(restaurantID uuid.UUID, geohash string) string {
+	return fmt.Sprintf("delivery_quote:%s:%s", restaurantID, geohash)
+}
+
+func deliverySurgeKey(restaurantID string) string {
+	if restaurantID == "" {
+		return "delivery_surge:global"
+	}
+	return "delivery_surge:" + restaurantID
+}
+
+// Quote returns the delivery fee for delivering a cart subtotal from a restaurant to an address
+func (s *DeliveryFeeService) Quote(ctx context.Context, restaurantID uuid.UUID, addressID string, subTotal models.Money) (*DeliveryFeeQuote, error) {
+	addressUUID, err := uuid.Parse(addressID)
+	if err != nil {
+		return nil, errors.New("invalid address ID")
+	}
+
+	address, err := s.addressRepo.GetByID(ctx, addressUUID)
+	if err != nil {
+		return nil, errors.New("address not found")
+	}
+
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantID)
+	if err != nil {
+		return nil, errors.New("restaurant not found")
+	}
+
+	drop := geo.Point{Lat: address.Latitude, Lng: address.Longitude}
+	pickup := s.restaurantPickup(ctx, restaurant)
+	boundary, outside := s.coveringBoundary(ctx, restaurant.ID, drop, pickup)
+	if outside {
+		return nil, ErrOutsideDeliveryArea
+	}
+
+	quote := &DeliveryFeeQuote{
+		SurgeMultiplier: 1,
+		QuotedAt:        time.Now(),
+	}
+	if boundary != nil {
+		quote.BoundaryID = &boundary.ID
+		quote.MinOrderValue = boundary.MinOrderValue
+	}
+
+	fare := s.baseFare(ctx, restaurant, address, pickup, boundary, quote)
+	quote.BaseFee = fare.Fee
+	quote.Source = fare.Source
+	quote.Provider = fare.Provider
+	quote.TravelMinutes = fare.TravelMinutes
+	if quote.TravelMinutes == 0 && pickup != nil && (drop.Lat != 0 || drop.Lng != 0) {
+		location := geo.Point{Lat: pickup["latitude"].(float64), Lng: pickup["longitude"].(float64)}
+		quote.TravelMinutes = estimateTravelMinutes(geo.DistanceKm(location, drop))
+	}
+
+	// Free delivery overrides surge
+	quote.FreeDeliveryAbove = s.policy.FreeDeliveryAbove
+	if boundary != nil && boundary.FreeDeliveryAbove > 0 {
+		quote.FreeDeliveryAbove = boundary.FreeDeliveryAbove
+	}
+	if quote.FreeDeliveryAbove > 0 && subTotal >= quote.FreeDeliveryAbove {
+		quote.FreeDelivery = true
+		return quote, nil
+	}
+
+	quote.SurgeMultiplier = s.surgeMultiplier(ctx, restaurant.ID.String())
+	quote.Fee = quote.BaseFee.Scale(quote.SurgeMultiplier)
+
+	return quote, nil
+}
+
+// baseFare resolves the pre-surge fare: provider quote, then boundary fee, then platform default
+func (s *DeliveryFeeService) baseFare(ctx context.Context, restaurant *models.Restaurant, address *models.Address, pickup models.JSONB, boundary *models.RestaurantDeliveryLocationBoundary, quote *DeliveryFeeQuote) baseFare {
+	hasLocation := address.Latitude != 0 || address.Longitude != 0
+	if hasLocation {
+		quote.Geohash = geo.Geohash(geo.Point{Lat: address.Latitude, Lng: address.Longitude}, deliveryQuoteGeohashPrecision)
+
+		var cached baseFare
+		if err := s.cache.Get(ctx, deliveryQuoteKey(restaurant.ID, quote.Geohash), &cached); err == nil {
+			return cached
+		}
+	}
+
+	fare := baseFare{Fee: s.policy.DefaultFee, Source: "default"}
+
+	if hasLocation {
+		order := &models.Order{
+			RestaurantID:                   restaurant.ID,
+			PickupFullAddressWithLatLong:   pickup,
+			DeliveryFullAddressWithLatLong: address.DeliveryDetails(),
+		}
+
+		// Providers without a customer-facing fare (e.g. self delivery) fall through
+		providerQuote, err := s.deliveryPartnerService.QuoteDelivery(ctx, order, restaurant)
+		if err == nil && providerQuote.Fee > 0 {
+			fare = baseFare{
+				Fee:           models.NewMoney(providerQuote.Fee),
+				Source:        "provider",
+				Provider:      providerQuote.Provider,
+				TravelMinutes: providerQuote.EstimatedMinutes,
+			}
+		} else if err != nil {
+			log.Printf("Delivery quote failed for restaurant %s: %v", restaurant.ID, err)
+		}
+	}
+
+	if fare.Source == "default" && boundary != nil && boundary.DeliveryFee > 0 {
+		fare = baseFare{Fee: boundary.DeliveryFee, Source: "boundary"}
+	}
+
+	if quote.Geohash != "" && s.policy.QuoteTTL > 0 {
+		s.cache.Set(ctx, deliveryQuoteKey(restaurant.ID, quote.Geohash), fare, s.policy.QuoteTTL)
+	}
+
+	return fare
+}
+
+// coveringBoundary returns the restaurant's delivery boundary containing the drop point.
+// outside reports that the restaurant has boundaries and none covers the point; a drop point
+// without a location, or boundaries that cannot be checked (a radius without a pickup
+// location), never count as outside.
+func (s *DeliveryFeeService) coveringBoundary(ctx context.Context, restaurantID uuid.UUID, drop geo.Point, pickup models.JSONB) (boundary *models.RestaurantDeliveryLocationBoundary, outside bool) {
+	boundaries, err := s.boundaryRepo.GetByRestaurantID(ctx, restaurantID)
+	if err != nil || len(boundaries) == 0 {
+		return nil, false
+	}
+
+	var location *geo.Point
+	var distance float64
+	if pickup != nil {
+		location = &geo.Point{Lat: pickup["latitude"].(float64), Lng: pickup["longitude"].(float64)}
+		distance = geo.DistanceKm(*location, drop)
+	}
+
+	checked := false
+	for i := range boundaries {
+		if boundaryCovers(&boundaries[i], drop, location, distance) {
+			return &boundaries[i], false
+		}
+		checked = checked || boundaryCheckable(&boundaries[i], location)
+	}
+
+	hasLocation := drop.Lat != 0 || drop.Lng != 0
+	return nil, hasLocation && checked
+}
+
+func (s *DeliveryFeeService) restaurantPickup(ctx context.Context, restaurant *models.Restaurant) models.JSONB {
+	if restaurant.PickupLocationID == nil {
+		return nil
+	}
+
+	address, err := s.addressRepo.GetByID(ctx, *restaurant.PickupLocationID)
+	if err != nil || (address.Latitude == 0 && address.Longitude == 0) {
+		return nil
+	}
+
+	return models.JSONB{
+		"latitude":  address.Latitude,
+		"longitude": address.Longitude,
+	}
+}
+
+// surgeMultiplier returns the active restaurant surge, falling back to the platform-wide surge
+func (s *DeliveryFeeService) surgeMultiplier(ctx context.Context, restaurantID string) float64 {
+	var multiplier float64
+	if err := s.cache.Get(ctx, deliverySurgeKey(restaurantID), &multiplier); err != nil {
+		if err := s.cache.Get(ctx, deliverySurgeKey(""), &multiplier); err != nil {
+			return 1
+		}
+	}
+
+	if multiplier < 1 {
+		return 1
+	}
+	if s.policy.MaxSurgeMultiplier > 0 && multiplier > s.policy.MaxSurgeMultiplier {
+		return s.policy.MaxSurgeMultiplier
+	}
+	return multiplier
+}
+
+// ApplySurge raises a quote's surge to the multiplier from pricing rules, such as a rain or
+// peak-hour surge. Free delivery still overrides surge and the platform cap applies.
+func (s *DeliveryFeeService) ApplySurge(quote *DeliveryFeeQuote, multiplier float64) {
+	if quote.FreeDelivery || multiplier <= quote.SurgeMultiplier {
+		return
+	}
+	if s.policy.MaxSurgeMultiplier > 0 && multiplier > s.policy.MaxSurgeMultiplier {
+		multiplier = s.policy.MaxSurgeMultiplier
+	}
+	quote.SurgeMultiplier = multiplier
+	quote.Fee = quote.BaseFee.Scale(multiplier)
+}
+
+// SetSurge applies a temporary surge multiplier to a restaurant or the whole platform
+func (s *DeliveryFeeService) SetSurge(ctx context.Context, req *SetSurgeRequest) error {
+	if req.RestaurantID != "" {
+		if _, err := uuid.Parse(req.RestaurantID); err != nil {
+			return errors.New("invalid restaurant ID")
+		}
+	}
+	if s.policy.MaxSurgeMultiplier > 0 && req.Multiplier > s.policy.MaxSurgeMultiplier {
+		return fmt.Errorf("surge multiplier cannot exceed %.2f", s.policy.MaxSurgeMultiplier)
+	}
+
+	return s.cache.Set(ctx, deliverySurgeKey(req.RestaurantID), req.Multiplier, time.Duration(req.DurationMinutes)*time.Minute)
+}
+
+// ClearSurge removes a restaurant or platform-wide surge
+func (s *DeliveryFeeService) ClearSurge(ctx context.Context, restaurantID string) error {
+	return s.cache.Delete(ctx, deliverySurgeKey(restaurantID))
+}
+
+// ToJSONB converts the quote for storage on the order; orders without delivery have none
+func (q *DeliveryFeeQuote) ToJSONB() models.JSONB {
+	if q == nil {
+		return nil
+	}
+	details := models.JSONB{
+		"fee":              q.Fee,
+		"base_fee":         q.BaseFee,
+		"source":           q.Source,
+		"surge_multiplier": q.SurgeMultiplier,
+		"free_delivery":    q.FreeDelivery,
+		"quoted_at":        q.QuotedAt,
+	}
+	if q.Provider != "" {
+		details["provider"] = q.Provider
+	}
+	if q.FreeDeliveryAbove > 0 {
+		details["free_delivery_above"] = q.FreeDeliveryAbove
+	}
+	if q.Geohash != "" {
+		details["geohash"] = q.Geohash
+	}
+	return details
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/apperr"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	deliveryLinkMaxPriority = 100
+	deliveryLinkMaxWeight   = 100
+)
+
+var (
+	ErrDeliveryLinkInvalid   = apperr.Validation("delivery_link_invalid", "invalid delivery partner link")
+	ErrDeliveryLinkNotFound  = apperr.NotFound("delivery_link_not_found", "delivery partner link not found")
+	ErrDeliveryLinkForbidden = apperr.Forbidden("delivery_link_forbidden", "you do not manage this restaurant")
+	ErrDeliveryLinkExists    = apperr.Conflict("delivery_link_exists", "the restaurant is already linked to this delivery partner or waiting for it to accept")
+)
+
+// DeliveryPartnerLinkSettings are the dispatch settings of a restaurant's link to a delivery
+// partner
+type DeliveryPartnerLinkSettings struct {
+	Priority    int           `json:"priority"`     // lower is tried first, 0 to 100
+	Weight      *int          `json:"weight"`       // share of orders among links of the same priority, 0 to 100; defaults to 1
+	FeeOverride *models.Money `json:"fee_override"` // fee agreed with the partner per delivery; null uses its quotes
+	IsActive    *bool         `json:"is_active"`    // defaults to true; switched-off links are skipped
+}
+
+// DeliveryPartnerLinkRequest asks a delivery partner to deliver a restaurant's orders
+type DeliveryPartnerLinkRequest struct {
+	DeliveryPartnerCompanyID string `json:"delivery_partner_company_id" binding:"required"`
+	DeliveryPartnerLinkSettings
+}
+
+// DeliveryPartnerLink is a restaurant's link to a delivery partner as shown to the restaurant
+type DeliveryPartnerLink struct {
+	ID                       uuid.UUID     `json:"id"`
+	DeliveryPartnerCompanyID uuid.UUID     `json:"delivery_partner_company_id"`
+	CompanyName              string        `json:"company_name"`
+	ProviderCode             string        `json:"provider_code"`
+	Status                   string        `json:"status"` // pending, active, declined
+	Priority                 int           `json:"priority"`
+	Weight                   int           `json:"weight"`
+	FeeOverride              *models.Money `json:"fee_override,omitempty"`
+	IsActive                 bool          `json:"is_active"`
+	Dispatchable             bool          `json:"dispatchable"` // deliveries can be booked through it now
+	RequestedAt              *time.Time    `json:"requested_at,omitempty"`
+	RespondedAt              *time.Time    `json:"responded_at,omitempty"`
+	DeclineReason            string        `json:"decline_reason,omitempty"`
+}
+
+// DeliveryPartnerCompanySummary is a delivery partner restaurants can ask to link with
+type DeliveryPartnerCompanySummary struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	ProviderCode string    `json:"provider_code"`
+}
+
+func newDeliveryPartnerLink(link *models.RestaurantDeliveryPartners) DeliveryPartnerLink {
+	return DeliveryPartnerLink{
+		ID:                       link.ID,
+		DeliveryPartnerCompanyID: link.DeliveryPartnerCompanyID,
+		CompanyName:              link.DeliveryPartnerCompany.Name,
+		ProviderCode:             link.DeliveryPartnerCompany.ProviderCode,
+		Status:                   link.Status,
+		Priority:                 link.Priority,
+		Weight:                   link.Weight,
+		FeeOverride:              link.FeeOverride,
+		IsActive:                 link.IsActive,
+		Dispatchable:             linkDispatchable(link),
+		RequestedAt:              link.RequestedAt,
+		RespondedAt:              link.RespondedAt,
+		DeclineReason:            link.DeclineReason,
+	}
+}
+
+// apply validates the settings and sets them on the link
+func (settings *DeliveryPartnerLinkSettings) apply(link *models.RestaurantDeliveryPartners) error {
+	if settings.Priority < 0 || settings.Priority > deliveryLinkMaxPriority {
+		return fmt.Errorf("%w: priority must be between 0 and %d", ErrDeliveryLinkInvalid, deliveryLinkMaxPriority)
+	}
+	weight := 1
+	if settings.Weight != nil {
+		weight = *settings.Weight
+	}
+	if weight < 0 || weight > deliveryLinkMaxWeight {
+		return fmt.Errorf("%w: weight must be between 0 and %d", ErrDeliveryLinkInvalid, deliveryLinkMaxWeight)
+	}
+	if settings.FeeOverride != nil && *settings.FeeOverride < 0 {
+		return fmt.Errorf("%w: fee_override cannot be negative", ErrDeliveryLinkInvalid)
+	}
+
+	link.Priority = settings.Priority
+	link.Weight = weight
+	link.FeeOverride = settings.FeeOverride
+	link.IsActive = settings.IsActive == nil || *settings.IsActive
+	return nil
+}
+
+// ListPartnerCompanies returns the active delivery partners restaurants can ask to link with
+func (s *DeliveryPartnerService) ListPartnerCompanies(ctx context.Context) ([]DeliveryPartnerCompanySummary, error) {
+	companies, err := s.deliveryPartnerRepo.GetByStatus(ctx, "active", 500, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list delivery partners: %v", err)
+	}
+	summaries := make([]DeliveryPartnerCompanySummary, 0, len(companies))
+	for _, company := range companies {
+		summaries = append(summaries, DeliveryPartnerCompanySummary{
+			ID:           company.ID,
+			Name:         company.Name,
+			ProviderCode: company.ProviderCode,
+		})
+	}
+	return summaries, nil
+}
+
+// ListLinks returns a restaurant's links to delivery partners, lowest priority first
+func (s *DeliveryPartnerService) ListLinks(ctx context.Context, userID, role, restaurantID string) ([]DeliveryPartnerLink, error) {
+	restaurant, err := s.manageRestaurant(ctx, userID, role, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.restaurantDeliveryPartnerRepo.GetByRestaurantID(ctx, restaurant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery partners: %v", err)
+	}
+	response := make([]DeliveryPartnerLink, 0, len(links))
+	for i := range links {
+		response = append(response, newDeliveryPartnerLink(&links[i]))
+	}
+	return response, nil
+}
+
+// RequestLink asks a delivery partner to deliver the restaurant's orders. The link stays pending
+// until the partner accepts it through the partner API; a declined request can be made again.
+func (s *DeliveryPartnerService) RequestLink(ctx context.Context, userID, role, restaurantID string, req *DeliveryPartnerLinkRequest) (*DeliveryPartnerLink, error) {
+	restaurant, err := s.manageRestaurant(ctx, userID, role, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	companyID, err := uuid.Parse(req.DeliveryPartnerCompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid delivery_partner_company_id", ErrDeliveryLinkInvalid)
+	}
+	company, err := s.deliveryPartnerRepo.GetByID(ctx, companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && company.Status != "active") {
+		return nil, fmt.Errorf("%w: delivery partner %s", ErrDeliveryLinkNotFound, req.DeliveryPartnerCompanyID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := s.restaurantDeliveryPartnerRepo.GetByRestaurantID(ctx, restaurant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery partners: %v", err)
+	}
+	var link *models.RestaurantDeliveryPartners
+	for i := range links {
+		if links[i].DeliveryPartnerCompanyID != company.ID {
+			continue
+		}
+		if links[i].Status != PartnerLinkDeclined {
+			return nil, ErrDeliveryLinkExists
+		}
+		link = &links[i]
+	}
+	if link == nil {
+		link = &models.RestaurantDeliveryPartners{RestaurantID: restaurant.ID, DeliveryPartnerCompanyID: company.ID}
+	}
+
+	if err := req.DeliveryPartnerLinkSettings.apply(link); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	link.Status = PartnerLinkPending
+	link.RequestedAt = &now
+	link.RespondedAt = nil
+	link.DeclineReason = ""
+	if requestedBy, err := uuid.Parse(userID); err == nil {
+		link.RequestedBy = &requestedBy
+	}
+
+	if link.ID == uuid.Nil {
+		err = s.restaurantDeliveryPartnerRepo.Create(ctx, link)
+	} else {
+		err = s.restaurantDeliveryPartnerRepo.Update(ctx, link)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to request delivery partner: %v", err)
+	}
+
+	link.DeliveryPartnerCompany = *company
+	response := newDeliveryPartnerLink(link)
+	return &response, nil
+}
+
+// UpdateLink replaces the dispatch settings of one of the restaurant's links. Settings can be
+// changed while the partner has yet to answer.
+func (s *DeliveryPartnerService) UpdateLink(ctx context.Context, userID, role, restaurantID, linkID string, settings *DeliveryPartnerLinkSettings) (*DeliveryPartnerLink, error) {
+	link, err := s.manageLink(ctx, userID, role, restaurantID, linkID)
+	if err != nil {
+		return nil, err
+	}
+	if err := settings.apply(link); err != nil {
+		return nil, err
+	}
+	if err := s.restaurantDeliveryPartnerRepo.Update(ctx, link); err != nil {
+		return nil, fmt.Errorf("failed to update delivery partner: %v", err)
+	}
+
+	response := newDeliveryPartnerLink(link)
+	return &response, nil
+}
+
+// RemoveLink unlinks a delivery partner from the restaurant, or withdraws a pending request.
+// Deliveries already booked with the partner are not affected.
+func (s *DeliveryPartnerService) RemoveLink(ctx context.Context, userID, role, restaurantID, linkID string) error {
+	link, err := s.manageLink(ctx, userID, role, restaurantID, linkID)
+	if err != nil {
+		return err
+	}
+	if err := s.restaurantDeliveryPartnerRepo.Delete(ctx, link.ID); err != nil {
+		return fmt.Errorf("failed to remove delivery partner: %v", err)
+	}
+	return nil
+}
+
+// manageRestaurant loads a restaurant the user owns, or any restaurant for admins
+func (s *DeliveryPartnerService) manageRestaurant(ctx context.Context, userID, role, restaurantID string) (*models.Restaurant, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrDeliveryLinkInvalid)
+	}
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: restaurant %s", ErrDeliveryLinkNotFound, restaurantID)
+	}
+	if role != "admin" && restaurant.OwnerID.String() != userID {
+		return nil, ErrDeliveryLinkForbidden
+	}
+	return restaurant, nil
+}
+
+// manageLink loads one of the links of a restaurant the user manages
+func (s *DeliveryPartnerService) manageLink(ctx context.Context, userID, role, restaurantID, linkID string) (*models.RestaurantDeliveryPartners, error) {
+	restaurant, err := s.manageRestaurant(ctx, userID, role, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(linkID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDeliveryLinkNotFound, linkID)
+	}
+	link, err := s.restaurantDeliveryPartnerRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && link.RestaurantID != restaurant.ID) {
+		return nil, fmt.Errorf("%w: %s", ErrDeliveryLinkNotFound, linkID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
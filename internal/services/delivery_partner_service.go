@@ -5,6 +5,11 @@ import (
 	"fmt"
 	"golang-food-backend/internal/models"
 	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/jobs"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,9 +19,50 @@ type DeliveryPartnerService struct {
 	restaurantRepo                repositories.RestaurantRepository
 	deliveryPartnerRepo           repositories.DeliveryPartnerRepository
 	restaurantDeliveryPartnerRepo repositories.RestaurantDeliveryPartnerRepository
-	porterService                 *PorterService
+	providers                     *DeliveryProviderRegistry
 	orderRepo                     repositories.OrderRepository
 	porterDeliveryRepo            repositories.PorterDeliveryRepository
+	queue                         *jobs.Queue
+}
+
+// JobDeliveryReassign hands an order whose delivery was dropped to another partner
+const JobDeliveryReassign = "delivery:reassign"
+
+// Statuses of a restaurant's link to a delivery partner company
+const (
+	PartnerLinkPending  = "pending" // waiting for the partner to accept
+	PartnerLinkActive   = "active"
+	PartnerLinkDeclined = "declined"
+)
+
+// orderLinks puts a restaurant's links in the order they are tried: lowest priority first, and
+// links of the same priority in a random order weighted by their weights, so each is tried
+// first for its share of orders. Zero-weight links come after the rest of their priority.
+func orderLinks(links []models.RestaurantDeliveryPartners) {
+	keys := make(map[uuid.UUID]float64, len(links))
+	for _, link := range links {
+		keys[link.ID] = math.Inf(1)
+		if link.Weight > 0 {
+			keys[link.ID] = -math.Log(1-rand.Float64()) / float64(link.Weight)
+		}
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].Priority != links[j].Priority {
+			return links[i].Priority < links[j].Priority
+		}
+		return keys[links[i].ID] < keys[links[j].ID]
+	})
+}
+
+// linkDispatchable reports whether deliveries may be booked through a restaurant's link to a
+// partner: the partner has accepted it and the restaurant has not switched it off
+func linkDispatchable(link *models.RestaurantDeliveryPartners) bool {
+	return link.IsActive && link.Status == PartnerLinkActive
+}
+
+type deliveryReassignPayload struct {
+	OrderID          uuid.UUID `json:"order_id"`
+	PreferredPartner string    `json:"preferred_partner"`
 }
 
 func NewDeliveryPartnerService(
@@ -25,12 +71,13 @@ func NewDeliveryPartnerService(
 	restaurantDeliveryPartnerRepo repositories.RestaurantDeliveryPartnerRepository,
 	orderRepo repositories.OrderRepository,
 	porterDeliveryRepo repositories.PorterDeliveryRepository,
+	providers *DeliveryProviderRegistry,
 ) *DeliveryPartnerService {
 	return &DeliveryPartnerService{
 		restaurantRepo:                restaurantRepo,
 		deliveryPartnerRepo:           deliveryPartnerRepo,
 		restaurantDeliveryPartnerRepo: restaurantDeliveryPartnerRepo,
-		porterService:                 NewPorterService(orderRepo, porterDeliveryRepo),
+		providers:                     providers,
 		orderRepo:                     orderRepo,
 		porterDeliveryRepo:            porterDeliveryRepo,
 	}
@@ -44,49 +91,195 @@ func (s *DeliveryPartnerService) CreateDeliveryOrder(ctx context.Context, order
 		return fmt.Errorf("failed to get restaurant: %v", err)
 	}
 
-	// Get restaurant's delivery partners
-	deliveryPartners, err := s.restaurantDeliveryPartnerRepo.GetByRestaurantID(ctx, order.RestaurantID)
+	// Pick the provider configured for the restaurant
+	deliveryPartner, partnerCompany, provider, err := s.selectProvider(ctx, order.RestaurantID)
 	if err != nil {
-		return fmt.Errorf("failed to get delivery partners: %v", err)
+		return err
 	}
 
-	if len(deliveryPartners) == 0 {
-		return fmt.Errorf("no delivery partners found for restaurant %s", restaurant.Name)
+	// Partner companies without an integration use the legacy mock implementation
+	if provider == nil {
+		return s.createLegacyDeliveryOrder(ctx, order, restaurant, partnerCompany, *deliveryPartner)
 	}
 
-	// For now, use the first delivery partner (Porter)
-	deliveryPartner := deliveryPartners[0]
+	return s.bookWithProvider(ctx, order, restaurant, provider, deliveryPartner)
+}
 
-	// Get delivery partner company details
-	partnerCompany, err := s.deliveryPartnerRepo.GetByID(ctx, deliveryPartner.DeliveryPartnerCompanyID)
+// bookWithProvider creates the delivery with a specific provider
+func (s *DeliveryPartnerService) bookWithProvider(ctx context.Context, order *models.Order, restaurant *models.Restaurant, provider DeliveryProvider, deliveryPartner *models.RestaurantDeliveryPartners) error {
+	booking, err := provider.CreateOrder(ctx, order, restaurant)
 	if err != nil {
-		return fmt.Errorf("failed to get delivery partner company: %v", err)
+		return fmt.Errorf("failed to create %s delivery order: %v", provider.Name(), err)
+	}
+
+	// Update order with delivery partner info
+	if deliveryPartner != nil {
+		order.DeliveryPartnerID = &deliveryPartner.ID
 	}
 
-	// Use Porter service for Porter delivery partners
-	if partnerCompany.Name == "Porter" {
-		porterOrder, err := s.porterService.CreateDeliveryOrder(ctx, order, restaurant)
+	// Log successful delivery order creation
+	fmt.Printf("Delivery order created successfully:\n")
+	fmt.Printf("- Order ID: %s\n", order.ID.String())
+	fmt.Printf("- Provider: %s\n", booking.Provider)
+	fmt.Printf("- Provider Order ID: %s\n", booking.ProviderOrderID)
+	fmt.Printf("- Tracking URL: %s\n", booking.TrackingURL)
+	fmt.Printf("- Estimated Pickup Time: %d\n", booking.EstimatedPickupTime)
+	fmt.Printf("- Estimated Fare: ₹%.2f\n", booking.EstimatedFee)
+
+	return nil
+}
+
+// selectProvider resolves the delivery provider for a restaurant from its active
+// RestaurantDeliveryPartners (lowest priority first). Restaurants without partners
+// fall back to the platform default provider. A nil provider with a partner means
+// the partner company has no registered integration.
+func (s *DeliveryPartnerService) selectProvider(ctx context.Context, restaurantID uuid.UUID) (*models.RestaurantDeliveryPartners, *models.DeliveryPartnerCompany, DeliveryProvider, error) {
+	deliveryPartners, err := s.restaurantDeliveryPartnerRepo.GetByRestaurantID(ctx, restaurantID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get delivery partners: %v", err)
+	}
+
+	orderLinks(deliveryPartners)
+
+	var fallbackPartner *models.RestaurantDeliveryPartners
+	var fallbackCompany *models.DeliveryPartnerCompany
+	for i := range deliveryPartners {
+		deliveryPartner := &deliveryPartners[i]
+		if !linkDispatchable(deliveryPartner) {
+			continue
+		}
+
+		partnerCompany, err := s.deliveryPartnerRepo.GetByID(ctx, deliveryPartner.DeliveryPartnerCompanyID)
 		if err != nil {
-			return fmt.Errorf("failed to create Porter delivery order: %v", err)
+			return nil, nil, nil, fmt.Errorf("failed to get delivery partner company: %v", err)
 		}
 
-		// Update order with delivery partner info
-		order.DeliveryPartnerID = &deliveryPartner.ID
+		if provider, err := s.providers.Get(providerCode(partnerCompany)); err == nil {
+			return deliveryPartner, partnerCompany, provider, nil
+		}
 
-		// Log successful delivery order creation
-		fmt.Printf("Porter delivery order created successfully:\n")
-		fmt.Printf("- Order ID: %s\n", order.ID.String())
-		fmt.Printf("- Porter Order ID: %s\n", porterOrder.OrderID)
-		fmt.Printf("- Delivery Partner: %s\n", partnerCompany.Name)
-		fmt.Printf("- Tracking URL: %s\n", porterOrder.TrackingURL)
-		fmt.Printf("- Estimated Pickup Time: %d\n", porterOrder.EstimatedPickupTime)
-		fmt.Printf("- Estimated Fare: ₹%.2f\n", float64(porterOrder.EstimatedFareDetails.MinorAmount)/100)
+		if fallbackPartner == nil {
+			fallbackPartner, fallbackCompany = deliveryPartner, partnerCompany
+		}
+	}
+
+	if fallbackPartner != nil {
+		return fallbackPartner, fallbackCompany, nil, nil
+	}
 
-		return nil
+	provider, err := s.providers.Default()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("no delivery partners configured for restaurant %s", restaurantID)
 	}
+	return nil, nil, provider, nil
+}
 
-	// For other delivery partners, use the legacy mock implementation
-	return s.createLegacyDeliveryOrder(ctx, order, restaurant, partnerCompany, deliveryPartner)
+// dispatchCandidate is a provider the dispatch engine may book a delivery with
+type dispatchCandidate struct {
+	partner  *models.RestaurantDeliveryPartners // nil for the platform default
+	provider DeliveryProvider
+}
+
+// dispatchCandidates returns every active provider linked to the restaurant, lowest priority
+// first, or the platform default when none are linked. Partner companies without a registered
+// integration are left out, so the result is empty when the restaurant only has those.
+func (s *DeliveryPartnerService) dispatchCandidates(ctx context.Context, restaurantID uuid.UUID) ([]dispatchCandidate, error) {
+	deliveryPartners, err := s.restaurantDeliveryPartnerRepo.GetByRestaurantID(ctx, restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery partners: %v", err)
+	}
+
+	orderLinks(deliveryPartners)
+
+	candidates := []dispatchCandidate{}
+	linked := false
+	for i := range deliveryPartners {
+		deliveryPartner := &deliveryPartners[i]
+		if !linkDispatchable(deliveryPartner) {
+			continue
+		}
+		linked = true
+
+		partnerCompany, err := s.deliveryPartnerRepo.GetByID(ctx, deliveryPartner.DeliveryPartnerCompanyID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get delivery partner company: %v", err)
+		}
+		if provider, err := s.providers.Get(providerCode(partnerCompany)); err == nil {
+			candidates = append(candidates, dispatchCandidate{partner: deliveryPartner, provider: provider})
+		}
+	}
+
+	if !linked {
+		provider, err := s.providers.Default()
+		if err != nil {
+			return nil, fmt.Errorf("no delivery partners configured for restaurant %s", restaurantID)
+		}
+		candidates = append(candidates, dispatchCandidate{provider: provider})
+	}
+	return candidates, nil
+}
+
+// QuoteDelivery asks the restaurant's delivery provider for a fare quote. A fee agreed on the
+// restaurant's link to the provider replaces the quoted one, and stands in for a quote from
+// partners without an integration.
+func (s *DeliveryPartnerService) QuoteDelivery(ctx context.Context, order *models.Order, restaurant *models.Restaurant) (*DeliveryQuote, error) {
+	deliveryPartner, partnerCompany, provider, err := s.selectProvider(ctx, restaurant.ID)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		if deliveryPartner != nil && deliveryPartner.FeeOverride != nil {
+			return &DeliveryQuote{
+				Provider: providerCode(partnerCompany),
+				Fee:      deliveryPartner.FeeOverride.Float64(),
+				Currency: restaurant.Currency,
+			}, nil
+		}
+		return nil, fmt.Errorf("restaurant %s has no delivery provider integration", restaurant.ID)
+	}
+
+	quote, err := provider.GetQuote(ctx, order, restaurant)
+	if err != nil {
+		return nil, err
+	}
+	return withFeeOverride(quote, deliveryPartner), nil
+}
+
+// withFeeOverride returns the quote with the fee agreed on the link in place of the quoted one
+func withFeeOverride(quote *DeliveryQuote, link *models.RestaurantDeliveryPartners) *DeliveryQuote {
+	if quote == nil || link == nil || link.FeeOverride == nil {
+		return quote
+	}
+	overridden := *quote
+	overridden.Fee = link.FeeOverride.Float64()
+	return &overridden
+}
+
+// providerCode returns the registry key for a delivery partner company
+func providerCode(company *models.DeliveryPartnerCompany) string {
+	if company.ProviderCode != "" {
+		return company.ProviderCode
+	}
+	return strings.ToLower(company.Name)
+}
+
+// HandleProviderWebhook parses and processes a webhook for the named provider
+func (s *DeliveryPartnerService) HandleProviderWebhook(ctx context.Context, providerName string, body []byte) (*DeliveryWebhookEvent, error) {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := provider.ParseWebhook(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := provider.HandleWebhook(ctx, event); err != nil {
+		return event, fmt.Errorf("failed to process webhook: %v", err)
+	}
+
+	return event, nil
 }
 
 // createLegacyDeliveryOrder handles non-Porter delivery partners (mock implementation)
@@ -126,6 +319,27 @@ func (s *DeliveryPartnerService) createLegacyDeliveryOrder(ctx context.Context,
 	return nil
 }
 
+// RegisterJobs registers the reassignment job handler and the queue reassignments are queued on
+func (s *DeliveryPartnerService) RegisterJobs(queue *jobs.Queue) {
+	s.queue = queue
+	queue.Handle(JobDeliveryReassign, func(ctx context.Context, job *jobs.Job) error {
+		var payload deliveryReassignPayload
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		return s.ReassignDeliveryPartner(ctx, payload.OrderID, payload.PreferredPartner)
+	})
+}
+
+// QueueReassignment reassigns the order's delivery in the background, retrying on failure
+func (s *DeliveryPartnerService) QueueReassignment(ctx context.Context, orderID uuid.UUID, preferredPartner string) error {
+	_, err := s.queue.Enqueue(ctx, JobDeliveryReassign, deliveryReassignPayload{
+		OrderID:          orderID,
+		PreferredPartner: preferredPartner,
+	}, jobs.Timeout(30*time.Second))
+	return err
+}
+
 // ReassignDeliveryPartner reassigns delivery partner for an order
 func (s *DeliveryPartnerService) ReassignDeliveryPartner(ctx context.Context, orderID uuid.UUID, preferredPartner string) error {
 	// Get the order
@@ -134,8 +348,13 @@ func (s *DeliveryPartnerService) ReassignDeliveryPartner(ctx context.Context, or
 		return fmt.Errorf("failed to get order: %v", err)
 	}
 
-	// If requesting Porter reassignment
-	if preferredPartner == "porter" {
+	provider, err := s.providers.Get(preferredPartner)
+	if err != nil {
+		return fmt.Errorf("reassignment for partner %s not yet implemented", preferredPartner)
+	}
+
+	// Porter deliveries are tracked locally and must be cancelled before rebooking
+	if provider.Name() == "porter" {
 		// Deactivate old Porter deliveries
 		if err := s.porterDeliveryRepo.DeactivateOldDeliveries(ctx, orderID); err != nil {
 			return fmt.Errorf("failed to deactivate old deliveries: %v", err)
@@ -145,17 +364,18 @@ func (s *DeliveryPartnerService) ReassignDeliveryPartner(ctx context.Context, or
 		activeDelivery, err := s.porterDeliveryRepo.GetActiveByOrderID(ctx, orderID)
 		if err == nil && activeDelivery != nil {
 			// Try to cancel the old Porter order
-			_, cancelErr := s.porterService.CancelOrder(ctx, activeDelivery.PorterOrderID)
-			if cancelErr != nil {
+			if cancelErr := provider.Cancel(ctx, activeDelivery.PorterOrderID); cancelErr != nil {
 				// Log error but continue - the order might already be in a non-cancellable state
 				fmt.Printf("Warning: Failed to cancel old Porter order %s: %v\n", activeDelivery.PorterOrderID, cancelErr)
 			}
 		}
+	}
 
-		// Create new Porter delivery
-		return s.CreateDeliveryOrder(ctx, order)
+	restaurant, err := s.restaurantRepo.GetByID(ctx, order.RestaurantID)
+	if err != nil {
+		return fmt.Errorf("failed to get restaurant: %v", err)
 	}
 
-	// For other delivery partners, implement similar logic
-	return fmt.Errorf("reassignment for partner %s not yet implemented", preferredPartner)
+	// Create new delivery with the requested provider
+	return s.bookWithProvider(ctx, order, restaurant, provider, nil)
 }
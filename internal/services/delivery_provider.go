@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"sort"
+	"strings"
+)
+
+// DeliveryProvider is implemented by every last-mile delivery integration
+// (Porter, restaurant riders, and future aggregators like Dunzo or Shadowfax)
+type DeliveryProvider interface {
+	// Name is the registry key stored on DeliveryPartnerCompany.ProviderCode
+	Name() string
+	GetQuote(ctx context.Context, order *models.Order, restaurant *models.Restaurant) (*DeliveryQuote, error)
+	CreateOrder(ctx context.Context, order *models.Order, restaurant *models.Restaurant) (*DeliveryBooking, error)
+	Track(ctx context.Context, providerOrderID string) (*DeliveryTrackingInfo, error)
+	Cancel(ctx context.Context, providerOrderID string) error
+	ParseWebhook(body []byte) (*DeliveryWebhookEvent, error)
+	HandleWebhook(ctx context.Context, event *DeliveryWebhookEvent) error
+}
+
+type DeliveryQuote struct {
+	Provider         string  `json:"provider"`
+	Fee              float64 `json:"fee"`
+	Currency         string  `json:"currency"`
+	EstimatedMinutes int     `json:"estimated_minutes"`
+	VehicleType      string  `json:"vehicle_type,omitempty"`
+	Distance         string  `json:"distance,omitempty"`
+}
+
+type DeliveryBooking struct {
+	Provider            string  `json:"provider"`
+	ProviderOrderID     string  `json:"provider_order_id"`
+	TrackingURL         string  `json:"tracking_url,omitempty"`
+	EstimatedFee        float64 `json:"estimated_fee"`
+	EstimatedPickupTime int64   `json:"estimated_pickup_time,omitempty"`
+}
+
+type DeliveryTrackingInfo struct {
+	Provider        string   `json:"provider"`
+	ProviderOrderID string   `json:"provider_order_id"`
+	Status          string   `json:"status"`
+	PartnerName     string   `json:"partner_name,omitempty"`
+	PartnerPhone    string   `json:"partner_phone,omitempty"`
+	VehicleNumber   string   `json:"vehicle_number,omitempty"`
+	Latitude        *float64 `json:"latitude,omitempty"`
+	Longitude       *float64 `json:"longitude,omitempty"`
+}
+
+// DeliveryWebhookEvent is a provider webhook normalised to our order statuses
+type DeliveryWebhookEvent struct {
+	Provider        string      `json:"provider"`
+	ProviderOrderID string      `json:"provider_order_id"`
+	ProviderStatus  string      `json:"provider_status"`
+	OrderStatus     string      `json:"order_status,omitempty"` // dispatched, delivered, cancelled
+	Payload         interface{} `json:"-"`                      // provider-specific payload
+}
+
+// DeliveryProviderRegistry holds the delivery providers enabled in configuration
+type DeliveryProviderRegistry struct {
+	providers       map[string]DeliveryProvider
+	defaultProvider string
+}
+
+func NewDeliveryProviderRegistry(defaultProvider string, providers ...DeliveryProvider) *DeliveryProviderRegistry {
+	registry := &DeliveryProviderRegistry{
+		providers:       make(map[string]DeliveryProvider),
+		defaultProvider: strings.ToLower(defaultProvider),
+	}
+	for _, provider := range providers {
+		registry.Register(provider)
+	}
+	return registry
+}
+
+func (r *DeliveryProviderRegistry) Register(provider DeliveryProvider) {
+	r.providers[strings.ToLower(provider.Name())] = provider
+}
+
+func (r *DeliveryProviderRegistry) Get(name string) (DeliveryProvider, error) {
+	provider, ok := r.providers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("delivery provider %s is not enabled", name)
+	}
+	return provider, nil
+}
+
+// Default returns the platform-wide fallback provider
+func (r *DeliveryProviderRegistry) Default() (DeliveryProvider, error) {
+	return r.Get(r.defaultProvider)
+}
+
+// Names returns the enabled provider keys
+func (r *DeliveryProviderRegistry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
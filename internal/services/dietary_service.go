@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/cache"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxSpiceLevel is the hottest a dish can be marked, from 0 for not spicy
+const MaxSpiceLevel = 3
+
+// productAllergens are the allergens a product can list, after the fourteen major allergens
+// food labelling asks for
+var productAllergens = map[string]bool{
+	"gluten": true, "crustaceans": true, "eggs": true, "fish": true, "peanuts": true,
+	"soy": true, "dairy": true, "tree_nuts": true, "celery": true, "mustard": true,
+	"sesame": true, "sulphites": true, "lupin": true, "molluscs": true,
+}
+
+var ErrDietaryInvalid = apperr.Validation("dietary_invalid", "invalid dietary details")
+
+// normalizeDietType checks a product's diet type; empty means not stated
+func normalizeDietType(diet string) (string, error) {
+	diet = strings.ToLower(strings.TrimSpace(diet))
+	switch diet {
+	case "", models.DietVeg, models.DietNonVeg, models.DietVegan:
+		return diet, nil
+	}
+	return "", fmt.Errorf("%w: diet_type must be veg, non_veg or vegan", ErrDietaryInvalid)
+}
+
+// normalizeAllergens lowercases, dedupes and sorts allergens, rejecting ones the menu does not
+// recognise
+func normalizeAllergens(allergens []string) ([]string, error) {
+	seen := make(map[string]bool, len(allergens))
+	normalized := make([]string, 0, len(allergens))
+	for _, allergen := range allergens {
+		allergen = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(allergen)), " ", "_")
+		if allergen == "" || seen[allergen] {
+			continue
+		}
+		if !productAllergens[allergen] {
+			return nil, fmt.Errorf("%w: unknown allergen %s", ErrDietaryInvalid, allergen)
+		}
+		seen[allergen] = true
+		normalized = append(normalized, allergen)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+func validateSpiceLevel(level int) error {
+	if level < 0 || level > MaxSpiceLevel {
+		return fmt.Errorf("%w: spice_level must be between 0 and %d", ErrDietaryInvalid, MaxSpiceLevel)
+	}
+	return nil
+}
+
+// applyDietaryUpdates checks and applies diet_type, allergens and spice_level from a product
+// update; null or empty clears the first two
+func applyDietaryUpdates(product *models.Product, updates map[string]interface{}) error {
+	if value, ok := updates["diet_type"]; ok {
+		diet, isString := value.(string)
+		if value != nil && !isString {
+			return fmt.Errorf("%w: diet_type must be veg, non_veg or vegan", ErrDietaryInvalid)
+		}
+		diet, err := normalizeDietType(diet)
+		if err != nil {
+			return err
+		}
+		product.DietType = diet
+	}
+	if value, ok := updates["allergens"]; ok {
+		items, isList := value.([]interface{})
+		if value != nil && !isList {
+			return fmt.Errorf("%w: allergens must be a list", ErrDietaryInvalid)
+		}
+		allergens := make([]string, 0, len(items))
+		for _, item := range items {
+			allergen, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("%w: allergens must be a list of names", ErrDietaryInvalid)
+			}
+			allergens = append(allergens, allergen)
+		}
+		normalized, err := normalizeAllergens(allergens)
+		if err != nil {
+			return err
+		}
+		product.Allergens = normalized
+	}
+	if value, ok := updates["spice_level"]; ok {
+		level, isNumber := value.(float64)
+		if !isNumber || level != float64(int(level)) {
+			return fmt.Errorf("%w: spice_level must be a whole number", ErrDietaryInvalid)
+		}
+		if err := validateSpiceLevel(int(level)); err != nil {
+			return err
+		}
+		product.SpiceLevel = int(level)
+	}
+	return nil
+}
+
+// dietTypesFor lists the product diet types that suit a diet: vegan dishes suit vegetarians
+func dietTypesFor(diet string) []string {
+	if diet == models.DietVeg {
+		return []string{models.DietVeg, models.DietVegan}
+	}
+	return []string{diet}
+}
+
+// ParseDietaryFilter reads a menu filter from query values: a diet of veg, vegan or non_veg,
+// a comma separated list of allergens to exclude and the highest spice level. Empty values
+// leave that part of the filter unset.
+func ParseDietaryFilter(diet, excludeAllergens, maxSpice string) (models.DietaryFilter, error) {
+	var filter models.DietaryFilter
+
+	diet, err := normalizeDietType(diet)
+	if err != nil {
+		return filter, fmt.Errorf("%w: diet must be veg, non_veg or vegan", ErrDietaryInvalid)
+	}
+	if diet != "" {
+		filter.DietTypes = dietTypesFor(diet)
+	}
+
+	if excludeAllergens != "" {
+		allergens, err := normalizeAllergens(strings.Split(excludeAllergens, ","))
+		if err != nil {
+			return filter, err
+		}
+		if len(allergens) > 0 {
+			filter.ExcludeAllergens = allergens
+		}
+	}
+
+	if maxSpice != "" {
+		level, err := strconv.Atoi(maxSpice)
+		if err != nil || validateSpiceLevel(level) != nil {
+			return filter, fmt.Errorf("%w: max_spice must be between 0 and %d", ErrDietaryInvalid, MaxSpiceLevel)
+		}
+		filter.MaxSpiceLevel = &level
+	}
+	return filter, nil
+}
+
+// Cached per user because every menu listing a signed-in customer opens reads them
+const dietaryPreferenceCacheTTL = time.Hour
+
+// DietaryPreferenceService keeps each customer's dietary preferences and applies them to the
+// menus they browse
+type DietaryPreferenceService struct {
+	preferenceRepo repositories.DietaryPreferenceRepository
+	cache          *cache.RedisCache
+}
+
+func NewDietaryPreferenceService(preferenceRepo repositories.DietaryPreferenceRepository, cache *cache.RedisCache) *DietaryPreferenceService {
+	return &DietaryPreferenceService{
+		preferenceRepo: preferenceRepo,
+		cache:          cache,
+	}
+}
+
+type UpdateDietaryPreferencesRequest struct {
+	Diet           string   `json:"diet" binding:"omitempty,oneof=veg vegan"` // empty for anything
+	AvoidAllergens []string `json:"avoid_allergens"`
+	MaxSpiceLevel  *int     `json:"max_spice_level" binding:"omitempty,min=0,max=3"`
+	AutoFilter     bool     `json:"auto_filter"` // apply these to menus and searches without being asked
+}
+
+func dietaryPreferenceKey(userID string) string {
+	return "dietary_preferences:" + userID
+}
+
+// GetPreferences returns the user's dietary preferences, blank ones if they have not set any
+func (s *DietaryPreferenceService) GetPreferences(ctx context.Context, userID string) (*models.DietaryPreference, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	var cached models.DietaryPreference
+	if err := s.cache.Get(ctx, dietaryPreferenceKey(userID), &cached); err == nil {
+		return &cached, nil
+	}
+
+	preference, err := s.preferenceRepo.GetByUserID(ctx, userUUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		preference = &models.DietaryPreference{UserID: userUUID, AvoidAllergens: models.StringArray{}}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get dietary preferences: %v", err)
+	}
+
+	s.cache.Set(ctx, dietaryPreferenceKey(userID), preference, dietaryPreferenceCacheTTL)
+	return preference, nil
+}
+
+// UpdatePreferences replaces the user's dietary preferences
+func (s *DietaryPreferenceService) UpdatePreferences(ctx context.Context, userID string, req *UpdateDietaryPreferencesRequest) (*models.DietaryPreference, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	allergens, err := normalizeAllergens(req.AvoidAllergens)
+	if err != nil {
+		return nil, err
+	}
+
+	preference := &models.DietaryPreference{
+		UserID:         userUUID,
+		Diet:           req.Diet,
+		AvoidAllergens: models.StringArray(allergens),
+		MaxSpiceLevel:  req.MaxSpiceLevel,
+		AutoFilter:     req.AutoFilter,
+		UpdatedAt:      time.Now(),
+	}
+	if err := s.preferenceRepo.Save(ctx, preference); err != nil {
+		return nil, fmt.Errorf("failed to save dietary preferences: %v", err)
+	}
+
+	s.cache.Delete(ctx, dietaryPreferenceKey(userID))
+	return preference, nil
+}
+
+// WithPreferences fills in the parts of a menu filter the request left unset from the user's
+// preferences, when they asked for them to apply automatically. Anonymous callers and failures
+// to load preferences get the filter back as it was.
+func (s *DietaryPreferenceService) WithPreferences(ctx context.Context, userID string, filter models.DietaryFilter) models.DietaryFilter {
+	if userID == "" {
+		return filter
+	}
+	preference, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to load dietary preferences of user %s: %v", userID, err)
+		return filter
+	}
+	if !preference.AutoFilter {
+		return filter
+	}
+
+	if len(filter.DietTypes) == 0 && preference.Diet != "" {
+		filter.DietTypes = dietTypesFor(preference.Diet)
+	}
+	if len(filter.ExcludeAllergens) == 0 && len(preference.AvoidAllergens) > 0 {
+		filter.ExcludeAllergens = preference.AvoidAllergens
+	}
+	if filter.MaxSpiceLevel == nil && preference.MaxSpiceLevel != nil {
+		level := *preference.MaxSpiceLevel
+		filter.MaxSpiceLevel = &level
+	}
+	return filter
+}
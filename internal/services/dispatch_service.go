@@ -0,0 +1,368 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/messaging"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Dispatch strategies for choosing between provider quotes
+const (
+	DispatchCheapest = "cheapest"
+	DispatchFastest  = "fastest"
+	DispatchPriority = "priority" // the restaurant's partner priority order
+)
+
+var (
+	ErrDispatchInvalid  = apperr.Conflict("dispatch_invalid", "order cannot be dispatched")
+	ErrDispatchFailed   = apperr.Upstream("dispatch_failed", "no delivery provider accepted the order")
+	ErrDispatchNotFound = apperr.NotFound("order_not_found", "order not found")
+)
+
+// DispatchPolicy configures the dispatch engine. TriggerStatus is the order status that books a
+// delivery; MaxAttempts caps the providers tried per dispatch, 0 tries them all.
+type DispatchPolicy struct {
+	Strategy      string
+	TriggerStatus string
+	MaxAttempts   int
+}
+
+// DispatchService books deliveries automatically. When an order reaches the trigger status it
+// asks every provider linked to the restaurant for a quote, ranks them by the strategy and books
+// the best one, moving on to the next when a booking fails. Fees agreed on a restaurant's links
+// replace the providers' quotes, and links of the same priority take turns by weight. Every
+// attempt is recorded.
+type DispatchService struct {
+	dispatchRepo   repositories.DispatchRepository
+	orderRepo      repositories.OrderRepository
+	restaurantRepo repositories.RestaurantRepository
+	partners       *DeliveryPartnerService
+	support        *SupportService
+	policy         DispatchPolicy
+	inFlight       sync.Map // order IDs being dispatched by this instance
+}
+
+func NewDispatchService(
+	dispatchRepo repositories.DispatchRepository,
+	orderRepo repositories.OrderRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	partners *DeliveryPartnerService,
+	support *SupportService,
+	policy DispatchPolicy,
+) *DispatchService {
+	switch policy.Strategy {
+	case DispatchCheapest, DispatchFastest, DispatchPriority:
+	default:
+		log.Printf("Unknown dispatch strategy %q, using %s", policy.Strategy, DispatchCheapest)
+		policy.Strategy = DispatchCheapest
+	}
+
+	return &DispatchService{
+		dispatchRepo:   dispatchRepo,
+		orderRepo:      orderRepo,
+		restaurantRepo: restaurantRepo,
+		partners:       partners,
+		support:        support,
+		policy:         policy,
+	}
+}
+
+type DispatchAttemptsResponse struct {
+	OrderID  uuid.UUID                 `json:"order_id"`
+	Strategy string                    `json:"strategy"`
+	Booked   bool                      `json:"booked"`
+	Attempts []models.DeliveryDispatch `json:"attempts"`
+}
+
+// dispatchOption is a candidate provider with its quote, if it gave one
+type dispatchOption struct {
+	candidate dispatchCandidate
+	quote     *DeliveryQuote
+	quoteErr  error
+}
+
+// Subscribe dispatches orders as their status updates reach the trigger status
+func (s *DispatchService) Subscribe(consumer *messaging.KafkaConsumer, groupID string) {
+	log.Printf("🚚 Dispatch engine subscribed (strategy %s, on %s)", s.policy.Strategy, s.policy.TriggerStatus)
+	consumer.Subscribe("order_events", groupID, s.HandleOrderEvent, messaging.SubscribeOptions{Concurrency: 2})
+}
+
+// HandleOrderEvent processes an order_events message
+func (s *DispatchService) HandleOrderEvent(payload []byte) error {
+	var event struct {
+		Type    string `json:"type"`
+		OrderID string `json:"order_id"`
+		Data    struct {
+			NewStatus string `json:"new_status"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("invalid order event: %v", err)
+	}
+	if event.Type != "order_status_updated" || event.Data.NewStatus != s.policy.TriggerStatus {
+		return nil
+	}
+
+	orderID, err := uuid.Parse(event.OrderID)
+	if err != nil {
+		return fmt.Errorf("invalid order ID in event: %s", event.OrderID)
+	}
+	order, err := s.orderRepo.GetByID(context.Background(), orderID)
+	if err != nil {
+		return fmt.Errorf("failed to load order %s: %v", orderID, err)
+	}
+	if !isDeliveryOrder(order) {
+		return nil
+	}
+
+	_, err = s.Dispatch(context.Background(), order)
+	return err
+}
+
+// OrderConfirmed dispatches an order confirmed by payment capture, which does not go through
+// order_status_updated, when confirmation is the trigger
+func (s *DispatchService) OrderConfirmed(order *models.Order) {
+	if s.policy.TriggerStatus != "confirmed" || !isDeliveryOrder(order) {
+		return
+	}
+	go func() {
+		if _, err := s.Dispatch(context.Background(), order); err != nil {
+			log.Printf("Failed to dispatch order %s: %v", order.ID, err)
+		}
+	}()
+}
+
+// Dispatch books the order's delivery with the best provider that accepts it
+func (s *DispatchService) Dispatch(ctx context.Context, order *models.Order) (*models.DeliveryDispatch, error) {
+	if _, busy := s.inFlight.LoadOrStore(order.ID, true); busy {
+		return nil, fmt.Errorf("%w: a dispatch is already running", ErrDispatchInvalid)
+	}
+	defer s.inFlight.Delete(order.ID)
+
+	if order.OrderStatus == "cancelled" || order.OrderStatus == "delivered" {
+		return nil, fmt.Errorf("%w: order is %s", ErrDispatchInvalid, order.OrderStatus)
+	}
+	if !isDeliveryOrder(order) {
+		return nil, fmt.Errorf("%w: %s orders are not delivered", ErrDispatchInvalid, order.OrderType)
+	}
+	booked, err := s.dispatchRepo.HasBooking(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check dispatches: %v", err)
+	}
+	if booked {
+		return nil, fmt.Errorf("%w: delivery is already booked", ErrDispatchInvalid)
+	}
+
+	restaurant, err := s.restaurantRepo.GetByID(ctx, order.RestaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get restaurant: %v", err)
+	}
+	candidates, err := s.partners.dispatchCandidates(ctx, order.RestaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.dispatchRepo.GetByOrder(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dispatches: %v", err)
+	}
+	attempt := len(previous)
+
+	// Partner companies without an integration are booked through the legacy path
+	if len(candidates) == 0 {
+		attempt++
+		record := &models.DeliveryDispatch{OrderID: order.ID, Attempt: attempt, Strategy: s.policy.Strategy, Provider: "legacy"}
+		if err := s.partners.CreateDeliveryOrder(ctx, order); err != nil {
+			err = s.recordFailure(ctx, record, err)
+			s.support.DeliveryFailed(ctx, order, attempt, err)
+			return nil, err
+		}
+		record.DeliveryPartnerID = order.DeliveryPartnerID
+		return record, s.recordBooking(ctx, order, record)
+	}
+
+	options := s.rank(s.quote(ctx, order, restaurant, candidates))
+	if s.policy.MaxAttempts > 0 && len(options) > s.policy.MaxAttempts {
+		options = options[:s.policy.MaxAttempts]
+	}
+
+	var lastErr error
+	for _, option := range options {
+		attempt++
+		record := &models.DeliveryDispatch{
+			OrderID:  order.ID,
+			Attempt:  attempt,
+			Strategy: s.policy.Strategy,
+			Provider: option.candidate.provider.Name(),
+		}
+		if option.candidate.partner != nil {
+			record.DeliveryPartnerID = &option.candidate.partner.ID
+		}
+		if option.quote != nil {
+			record.QuotedFee = models.NewMoney(option.quote.Fee)
+			record.EstimatedMinutes = option.quote.EstimatedMinutes
+		} else if option.candidate.partner != nil && option.candidate.partner.FeeOverride != nil {
+			record.QuotedFee = *option.candidate.partner.FeeOverride
+		}
+
+		booking, err := option.candidate.provider.CreateOrder(ctx, order, restaurant)
+		if err != nil {
+			lastErr = s.recordFailure(ctx, record, err)
+			log.Printf("Dispatch attempt %d for order %s with %s failed: %v", attempt, order.ID, record.Provider, err)
+			continue
+		}
+
+		record.ProviderOrderID = booking.ProviderOrderID
+		record.TrackingURL = booking.TrackingURL
+		if record.QuotedFee == 0 {
+			record.QuotedFee = models.NewMoney(booking.EstimatedFee)
+		}
+		order.DeliveryPartnerID = record.DeliveryPartnerID
+		return record, s.recordBooking(ctx, order, record)
+	}
+
+	// Attempts count across dispatches of the order: after enough of them support steps in
+	s.support.DeliveryFailed(ctx, order, attempt, lastErr)
+	return nil, fmt.Errorf("%w: %v", ErrDispatchFailed, lastErr)
+}
+
+// Redispatch retries an order whose automatic dispatch failed. Staff pass their restaurant ID;
+// admins pass an empty one.
+func (s *DispatchService) Redispatch(ctx context.Context, orderID, restaurantID string) (*models.DeliveryDispatch, error) {
+	order, err := s.order(ctx, orderID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Dispatch(ctx, order)
+}
+
+// GetAttempts returns an order's dispatch attempts, oldest first
+func (s *DispatchService) GetAttempts(ctx context.Context, orderID, restaurantID string) (*DispatchAttemptsResponse, error) {
+	order, err := s.order(ctx, orderID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.dispatchRepo.GetByOrder(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dispatches: %v", err)
+	}
+
+	response := &DispatchAttemptsResponse{
+		OrderID:  order.ID,
+		Strategy: s.policy.Strategy,
+		Attempts: attempts,
+	}
+	for _, attempt := range attempts {
+		if attempt.Status == "booked" {
+			response.Booked = true
+		}
+	}
+	return response, nil
+}
+
+// quote asks every candidate for a quote at once
+func (s *DispatchService) quote(ctx context.Context, order *models.Order, restaurant *models.Restaurant, candidates []dispatchCandidate) []dispatchOption {
+	options := make([]dispatchOption, len(candidates))
+	var wg sync.WaitGroup
+	for i, candidate := range candidates {
+		options[i].candidate = candidate
+		wg.Add(1)
+		go func(option *dispatchOption) {
+			defer wg.Done()
+			quote, err := option.candidate.provider.GetQuote(ctx, order, restaurant)
+			option.quote, option.quoteErr = withFeeOverride(quote, option.candidate.partner), err
+		}(&options[i])
+	}
+	wg.Wait()
+	return options
+}
+
+// rank orders options by the strategy. Providers that failed to quote are still tried, after
+// the ones that quoted, in priority order.
+func (s *DispatchService) rank(options []dispatchOption) []dispatchOption {
+	for _, option := range options {
+		if option.quoteErr != nil {
+			log.Printf("Delivery quote from %s failed: %v", option.candidate.provider.Name(), option.quoteErr)
+		}
+	}
+
+	sort.SliceStable(options, func(i, j int) bool {
+		a, b := options[i].quote, options[j].quote
+		if (a == nil) != (b == nil) {
+			return a != nil
+		}
+		if a == nil {
+			return false
+		}
+
+		switch s.policy.Strategy {
+		case DispatchCheapest:
+			if a.Fee != b.Fee {
+				return a.Fee < b.Fee
+			}
+			return quoteMinutes(a) < quoteMinutes(b)
+		case DispatchFastest:
+			if quoteMinutes(a) != quoteMinutes(b) {
+				return quoteMinutes(a) < quoteMinutes(b)
+			}
+			return a.Fee < b.Fee
+		}
+		return false
+	})
+	return options
+}
+
+// quoteMinutes treats quotes without an estimate as the slowest
+func quoteMinutes(quote *DeliveryQuote) int {
+	if quote.EstimatedMinutes <= 0 {
+		return int(^uint(0) >> 1)
+	}
+	return quote.EstimatedMinutes
+}
+
+func (s *DispatchService) recordFailure(ctx context.Context, record *models.DeliveryDispatch, cause error) error {
+	record.Status = "failed"
+	record.Error = cause.Error()
+	if err := s.dispatchRepo.Create(ctx, record); err != nil {
+		log.Printf("Failed to record dispatch attempt for order %s: %v", record.OrderID, err)
+	}
+	return cause
+}
+
+func (s *DispatchService) recordBooking(ctx context.Context, order *models.Order, record *models.DeliveryDispatch) error {
+	record.Status = "booked"
+	if err := s.dispatchRepo.Create(ctx, record); err != nil {
+		log.Printf("Failed to record dispatch for order %s: %v", order.ID, err)
+	}
+
+	err := updateOrder(ctx, s.orderRepo, order, func(order *models.Order) error {
+		appendOrderLog(order, order.OrderStatus, fmt.Sprintf("Delivery booked with %s", record.Provider))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("delivery booked with %s but the order could not be updated: %v", record.Provider, err)
+	}
+	return nil
+}
+
+func (s *DispatchService) order(ctx context.Context, orderID, restaurantID string) (*models.Order, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid order ID", ErrDispatchInvalid)
+	}
+	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	if err != nil || (restaurantID != "" && order.RestaurantID.String() != restaurantID) {
+		return nil, fmt.Errorf("%w: %s", ErrDispatchNotFound, orderID)
+	}
+	return order, nil
+}
@@ -15,6 +15,12 @@ import (
 
 type EnhancedCronService struct {
 	restaurantRepo    repositories.RestaurantRepository
+	specialHoursRepo  repositories.RestaurantSpecialHoursRepository
+	orderService      *OrderService
+	razorpayService   *RazorpayService
+	porterService     *PorterService
+	cartService       *CartService
+	pauseService      *RestaurantPauseService
 	stopChan          chan bool
 	timezone          *time.Location
 	isRunning         bool
@@ -23,7 +29,7 @@ type EnhancedCronService struct {
 	mutex             sync.RWMutex
 }
 
-func NewEnhancedCronService(restaurantRepo repositories.RestaurantRepository) *EnhancedCronService {
+func NewEnhancedCronService(restaurantRepo repositories.RestaurantRepository, specialHoursRepo repositories.RestaurantSpecialHoursRepository, orderService *OrderService, razorpayService *RazorpayService, porterService *PorterService, cartService *CartService, pauseService *RestaurantPauseService) *EnhancedCronService {
 	// Default to Asia/Kolkata timezone
 	loc, err := time.LoadLocation("Asia/Kolkata")
 	if err != nil {
@@ -32,10 +38,16 @@ func NewEnhancedCronService(restaurantRepo repositories.RestaurantRepository) *E
 	}
 
 	return &EnhancedCronService{
-		restaurantRepo: restaurantRepo,
-		stopChan:       make(chan bool),
-		timezone:       loc,
-		isRunning:      false,
+		restaurantRepo:   restaurantRepo,
+		specialHoursRepo: specialHoursRepo,
+		orderService:     orderService,
+		razorpayService:  razorpayService,
+		porterService:    porterService,
+		cartService:      cartService,
+		pauseService:     pauseService,
+		stopChan:         make(chan bool),
+		timezone:         loc,
+		isRunning:        false,
 	}
 }
 
@@ -50,6 +62,18 @@ func (s *EnhancedCronService) StartAutomaticStatusManagement() error {
 	// Start the status update ticker (every minute)
 	go s.runStatusUpdateTicker()
 
+	// Start the scheduled order ticker (every minute)
+	go s.runScheduledOrderTicker()
+
+	// Start the unpaid order recovery ticker (every minute)
+	go s.runPaymentRecoveryTicker()
+
+	// Start the Porter reconciliation ticker (every 5 minutes)
+	go s.runPorterReconcileTicker()
+
+	// Start the cart abandonment ticker (every 15 minutes)
+	go s.runCartAbandonmentTicker()
+
 	// Start the maintenance ticker (every hour)
 	go s.runMaintenanceTicker()
 
@@ -58,6 +82,11 @@ func (s *EnhancedCronService) StartAutomaticStatusManagement() error {
 
 	log.Println("✅ Enhanced cron service started successfully")
 	log.Println("📅 Restaurant status updates: Every minute")
+	log.Println("▶️ Busy mode auto-resume: Every minute")
+	log.Println("⏰ Scheduled order release: Every minute")
+	log.Println("💳 Unpaid order reminders and expiry: Every minute")
+	log.Println("🚚 Porter delivery reconciliation: Every 5 minutes")
+	log.Println("🛒 Abandoned cart sweep: Every 15 minutes")
 	log.Println("🔧 Maintenance tasks: Every hour")
 	log.Println("📊 Daily reports: Every day at midnight")
 
@@ -75,7 +104,7 @@ func (s *EnhancedCronService) StopAutomaticStatusManagement() {
 	log.Println("🛑 Enhanced cron service stopped")
 }
 
-// runStatusUpdateTicker runs status updates every minute
+// runStatusUpdateTicker resumes paused restaurants and runs status updates every minute
 func (s *EnhancedCronService) runStatusUpdateTicker() {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
@@ -83,6 +112,7 @@ func (s *EnhancedCronService) runStatusUpdateTicker() {
 	for {
 		select {
 		case <-ticker.C:
+			s.resumeExpiredPauses()
 			s.updateAllRestaurantStatuses()
 		case <-s.stopChan:
 			return
@@ -90,6 +120,142 @@ func (s *EnhancedCronService) runStatusUpdateTicker() {
 	}
 }
 
+// resumeExpiredPauses ends busy mode for restaurants whose pause ran out
+func (s *EnhancedCronService) resumeExpiredPauses() {
+	if s.pauseService == nil {
+		return
+	}
+
+	resumed, err := s.pauseService.ResumeExpired(context.Background())
+	if err != nil {
+		log.Printf("❌ Error resuming paused restaurants: %v", err)
+	}
+	if resumed > 0 {
+		log.Printf("▶️ Resumed %d paused restaurants", resumed)
+	}
+}
+
+// runScheduledOrderTicker releases due scheduled orders every minute
+func (s *EnhancedCronService) runScheduledOrderTicker() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.releaseScheduledOrders()
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// releaseScheduledOrders moves scheduled orders into the normal order pipeline
+func (s *EnhancedCronService) releaseScheduledOrders() {
+	if s.orderService == nil {
+		return
+	}
+
+	released, err := s.orderService.ReleaseDueScheduledOrders(context.Background())
+	if err != nil {
+		log.Printf("❌ Error releasing scheduled orders: %v", err)
+	}
+	if released > 0 {
+		log.Printf("⏰ Released %d scheduled orders", released)
+	}
+}
+
+// runPaymentRecoveryTicker chases and expires unpaid orders every minute
+func (s *EnhancedCronService) runPaymentRecoveryTicker() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.recoverUnpaidOrders()
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// recoverUnpaidOrders sends payment reminders and cancels orders whose payment window expired
+func (s *EnhancedCronService) recoverUnpaidOrders() {
+	if s.razorpayService == nil {
+		return
+	}
+
+	reminded, expired, err := s.razorpayService.RecoverUnpaidOrders(context.Background())
+	if err != nil {
+		log.Printf("❌ Error recovering unpaid orders: %v", err)
+	}
+	if reminded > 0 || expired > 0 {
+		log.Printf("💳 Unpaid orders: %d reminders sent, %d expired", reminded, expired)
+	}
+}
+
+// runPorterReconcileTicker polls Porter for deliveries with missed webhooks every 5 minutes
+func (s *EnhancedCronService) runPorterReconcileTicker() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.reconcilePorterDeliveries()
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// reconcilePorterDeliveries syncs stale Porter deliveries and flags stuck ones
+func (s *EnhancedCronService) reconcilePorterDeliveries() {
+	if s.porterService == nil {
+		return
+	}
+
+	synced, flagged, err := s.porterService.ReconcileDeliveries(context.Background())
+	if err != nil {
+		log.Printf("❌ Error reconciling Porter deliveries: %v", err)
+	}
+	if synced > 0 || flagged > 0 {
+		log.Printf("🚚 Porter deliveries: %d synced, %d flagged as stuck", synced, flagged)
+	}
+}
+
+// runCartAbandonmentTicker marks inactive carts abandoned every 15 minutes
+func (s *EnhancedCronService) runCartAbandonmentTicker() {
+	ticker := time.NewTicker(15 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.abandonInactiveCarts()
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// abandonInactiveCarts marks carts unused for the abandonment period and queues their
+// recovery events
+func (s *EnhancedCronService) abandonInactiveCarts() {
+	if s.cartService == nil {
+		return
+	}
+
+	abandoned, err := s.cartService.AbandonInactiveCarts(context.Background())
+	if err != nil {
+		log.Printf("❌ Error abandoning inactive carts: %v", err)
+	}
+	if abandoned > 0 {
+		log.Printf("🛒 Marked %d inactive carts as abandoned", abandoned)
+	}
+}
+
 // runMaintenanceTicker runs maintenance tasks every hour
 func (s *EnhancedCronService) runMaintenanceTicker() {
 	ticker := time.NewTicker(1 * time.Hour)
@@ -140,6 +306,16 @@ func (s *EnhancedCronService) updateAllRestaurantStatuses() {
 	totalUpdated := 0
 	totalErrors := 0
 
+	// Holidays and special hours of today, by restaurant
+	specialHours := make(map[uuid.UUID][]models.RestaurantSpecialHours)
+	todays, err := s.specialHoursRepo.GetCovering(ctx, currentTime.Format(specialHoursDateLayout))
+	if err != nil {
+		log.Printf("❌ Error fetching special hours, using weekly hours: %v", err)
+	}
+	for _, entry := range todays {
+		specialHours[entry.RestaurantID] = append(specialHours[entry.RestaurantID], entry)
+	}
+
 	for {
 		restaurants, err := s.getRestaurantsWithAutoOpenClose(ctx, limit, offset)
 		if err != nil {
@@ -152,7 +328,7 @@ func (s *EnhancedCronService) updateAllRestaurantStatuses() {
 		}
 
 		for _, restaurant := range restaurants {
-			if err := s.updateRestaurantStatus(ctx, &restaurant, currentTime); err != nil {
+			if err := s.updateRestaurantStatus(ctx, &restaurant, specialHours[restaurant.ID], currentTime); err != nil {
 				log.Printf("❌ Error updating restaurant %s (%s): %v", restaurant.Name, restaurant.ID, err)
 				totalErrors++
 			} else {
@@ -194,10 +370,11 @@ func (s *EnhancedCronService) getRestaurantsWithAutoOpenClose(ctx context.Contex
 	return filteredRestaurants, nil
 }
 
-// updateRestaurantStatus updates a single restaurant's status based on its opening hours
-func (s *EnhancedCronService) updateRestaurantStatus(ctx context.Context, restaurant *models.Restaurant, checkTime time.Time) error {
+// updateRestaurantStatus updates a single restaurant's status based on its opening hours and
+// special hours
+func (s *EnhancedCronService) updateRestaurantStatus(ctx context.Context, restaurant *models.Restaurant, specialHours []models.RestaurantSpecialHours, checkTime time.Time) error {
 	// Calculate if restaurant should be open
-	shouldBeOpen := s.shouldRestaurantBeOpenAtTime(restaurant, checkTime)
+	shouldBeOpen := s.shouldRestaurantBeOpenAtTime(restaurant, specialHours, checkTime)
 	statusChanged := restaurant.IsOpen != shouldBeOpen
 
 	// Update status if it has changed
@@ -225,12 +402,17 @@ func (s *EnhancedCronService) updateRestaurantStatus(ctx context.Context, restau
 	return nil
 }
 
-// shouldRestaurantBeOpenAtTime determines if a restaurant should be open at a specific time
-func (s *EnhancedCronService) shouldRestaurantBeOpenAtTime(restaurant *models.Restaurant, checkTime time.Time) bool {
+// shouldRestaurantBeOpenAtTime determines if a restaurant should be open at a specific time.
+// A holiday or special hours covering the date replace the weekly opening hours.
+func (s *EnhancedCronService) shouldRestaurantBeOpenAtTime(restaurant *models.Restaurant, specialHours []models.RestaurantSpecialHours, checkTime time.Time) bool {
 	if !restaurant.AutoOpenClose {
 		return restaurant.IsOpen // Return current status if auto management is disabled
 	}
 
+	if open, _, ok := specialHoursAt(specialHours, checkTime); ok {
+		return open
+	}
+
 	if restaurant.OpeningHours == nil {
 		return false // No opening hours defined
 	}
@@ -437,7 +619,7 @@ func (s *EnhancedCronService) ForceStatusUpdate(ctx context.Context, restaurantI
 	}
 
 	// Update status immediately
-	newStatus := s.determineRestaurantStatus(restaurant)
+	newStatus := s.determineRestaurantStatus(ctx, restaurant)
 	if restaurant.Status != newStatus {
 		restaurant.Status = newStatus
 		now := time.Now()
@@ -454,7 +636,7 @@ func (s *EnhancedCronService) ForceStatusUpdate(ctx context.Context, restaurantI
 }
 
 // determineRestaurantStatus determines the current status of a restaurant based on opening hours
-func (s *EnhancedCronService) determineRestaurantStatus(restaurant *models.Restaurant) string {
+func (s *EnhancedCronService) determineRestaurantStatus(ctx context.Context, restaurant *models.Restaurant) string {
 	if restaurant == nil {
 		return "closed"
 	}
@@ -469,7 +651,11 @@ func (s *EnhancedCronService) determineRestaurantStatus(restaurant *models.Resta
 
 	// Use existing logic from shouldRestaurantBeOpenAtTime method
 	currentTime := time.Now().In(s.timezone)
-	if s.shouldRestaurantBeOpenAtTime(restaurant, currentTime) {
+	specialHours, err := s.specialHoursRepo.GetByRestaurant(ctx, restaurant.ID, currentTime.Format(specialHoursDateLayout))
+	if err != nil {
+		log.Printf("Failed to get special hours of restaurant %s: %v", restaurant.ID, err)
+	}
+	if s.shouldRestaurantBeOpenAtTime(restaurant, specialHours, currentTime) {
 		return "open"
 	}
 
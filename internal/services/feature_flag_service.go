@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"hash/fnv"
+	"log"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Features checked with FeatureFlagService.IsEnabled or middleware.FeatureRequired
+const (
+	FeatureGroupOrders        = "group_orders"
+	FeatureRestaurantWebhooks = "restaurant_webhooks"
+)
+
+// knownFeature is a feature the code checks and its state while no flag is stored for it
+type knownFeature struct {
+	description string
+	enabled     bool
+}
+
+var knownFeatures = map[string]knownFeature{
+	FeatureGroupOrders:        {description: "Customers can start group orders at the restaurant", enabled: true},
+	FeatureRestaurantWebhooks: {description: "Restaurant owners can register POS webhooks", enabled: true},
+}
+
+// How long the in-memory flags are trusted before reloading; changes made on another
+// instance take effect within this interval
+const featureFlagRefreshInterval = 15 * time.Second
+
+var featureFlagKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+var (
+	ErrFeatureFlagInvalid  = apperr.Validation("feature_flag_invalid", "invalid feature flag")
+	ErrFeatureFlagNotFound = apperr.NotFound("feature_flag_not_found", "feature flag not found")
+	ErrFeatureDisabled     = apperr.Forbidden("feature_disabled", "this feature is not available")
+)
+
+type FeatureFlagService struct {
+	repo        repositories.FeatureFlagRepository
+	flags       map[string]models.FeatureFlag
+	lastRefresh time.Time
+	mutex       sync.RWMutex
+}
+
+func NewFeatureFlagService(repo repositories.FeatureFlagRepository) *FeatureFlagService {
+	return &FeatureFlagService{repo: repo}
+}
+
+// FeatureSubject is who a flag is evaluated for. Percentage rollouts bucket by restaurant, or
+// by user when there is no restaurant.
+type FeatureSubject struct {
+	RestaurantID string
+	UserID       string
+}
+
+type FeatureFlagRequest struct {
+	Description           string   `json:"description"`
+	Enabled               *bool    `json:"enabled" binding:"required"`
+	RolloutPercent        int      `json:"rollout_percent" binding:"min=0,max=100"`
+	RestaurantIDs         []string `json:"restaurant_ids"`
+	ExcludedRestaurantIDs []string `json:"excluded_restaurant_ids"`
+}
+
+// FeatureFlagStatus describes a flag for the admin API: a feature the code checks, a stored
+// flag, or both
+type FeatureFlagStatus struct {
+	Key         string              `json:"key"`
+	Description string              `json:"description"`
+	Known       bool                `json:"known"`          // checked by the code
+	Default     bool                `json:"default"`        // state while no flag is stored
+	Flag        *models.FeatureFlag `json:"flag,omitempty"` // stored rollout, overriding the default
+}
+
+// FeatureFlagEvaluation is a flag's state for one subject
+type FeatureFlagEvaluation struct {
+	Key          string `json:"key"`
+	RestaurantID string `json:"restaurant_id,omitempty"`
+	UserID       string `json:"user_id,omitempty"`
+	Enabled      bool   `json:"enabled"`
+}
+
+// IsEnabled reports whether the feature is on for the subject. Unknown features without a
+// stored flag are off.
+func (s *FeatureFlagService) IsEnabled(ctx context.Context, key string, subject FeatureSubject) bool {
+	if flag, ok := s.cachedFlags(ctx)[key]; ok {
+		return evaluateFeatureFlag(&flag, subject)
+	}
+	return knownFeatures[key].enabled
+}
+
+// FeatureEnabled satisfies middleware.FeatureChecker
+func (s *FeatureFlagService) FeatureEnabled(ctx context.Context, key, restaurantID, userID string) bool {
+	return s.IsEnabled(ctx, key, FeatureSubject{RestaurantID: restaurantID, UserID: userID})
+}
+
+// Require returns ErrFeatureDisabled when the feature is off for the subject
+func (s *FeatureFlagService) Require(ctx context.Context, key string, subject FeatureSubject) error {
+	if !s.IsEnabled(ctx, key, subject) {
+		return ErrFeatureDisabled
+	}
+	return nil
+}
+
+// EvaluateAll returns the state of every known or stored feature for the subject, for clients
+// that show or hide features
+func (s *FeatureFlagService) EvaluateAll(ctx context.Context, subject FeatureSubject) map[string]bool {
+	states := make(map[string]bool, len(knownFeatures))
+	for key, feature := range knownFeatures {
+		states[key] = feature.enabled
+	}
+	for key, flag := range s.cachedFlags(ctx) {
+		flag := flag
+		states[key] = evaluateFeatureFlag(&flag, subject)
+	}
+	return states
+}
+
+// Evaluate returns one flag's state for a restaurant or user, to check a rollout
+func (s *FeatureFlagService) Evaluate(ctx context.Context, key, restaurantID, userID string) *FeatureFlagEvaluation {
+	return &FeatureFlagEvaluation{
+		Key:          key,
+		RestaurantID: restaurantID,
+		UserID:       userID,
+		Enabled:      s.IsEnabled(ctx, key, FeatureSubject{RestaurantID: restaurantID, UserID: userID}),
+	}
+}
+
+// ListFlags lists the known features and stored flags by key
+func (s *FeatureFlagService) ListFlags(ctx context.Context) ([]FeatureFlagStatus, error) {
+	flags, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flags: %v", err)
+	}
+
+	statuses := map[string]*FeatureFlagStatus{}
+	for key, feature := range knownFeatures {
+		statuses[key] = &FeatureFlagStatus{Key: key, Description: feature.description, Known: true, Default: feature.enabled}
+	}
+	for i := range flags {
+		status, ok := statuses[flags[i].Key]
+		if !ok {
+			status = &FeatureFlagStatus{Key: flags[i].Key}
+			statuses[flags[i].Key] = status
+		}
+		if flags[i].Description != "" {
+			status.Description = flags[i].Description
+		}
+		status.Flag = &flags[i]
+	}
+
+	result := make([]FeatureFlagStatus, 0, len(statuses))
+	for _, status := range statuses {
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+// SetFlag creates or replaces the flag's rollout
+func (s *FeatureFlagService) SetFlag(ctx context.Context, adminID, key string, req *FeatureFlagRequest) (*models.FeatureFlag, error) {
+	if !featureFlagKeyPattern.MatchString(key) {
+		return nil, fmt.Errorf("%w: key must be lowercase letters, digits, _, . or -", ErrFeatureFlagInvalid)
+	}
+	if req.RolloutPercent < 0 || req.RolloutPercent > 100 {
+		return nil, fmt.Errorf("%w: rollout_percent must be between 0 and 100", ErrFeatureFlagInvalid)
+	}
+	included, err := featureFlagRestaurants(req.RestaurantIDs)
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := featureFlagRestaurants(req.ExcludedRestaurantIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range included {
+		if containsFeatureRestaurant(excluded, id) {
+			return nil, fmt.Errorf("%w: restaurant %s is both included and excluded", ErrFeatureFlagInvalid, id)
+		}
+	}
+
+	flag, err := s.repo.GetByKey(ctx, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		flag = &models.FeatureFlag{Key: key}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load feature flag: %v", err)
+	}
+
+	flag.Description = req.Description
+	flag.Enabled = *req.Enabled
+	flag.RolloutPercent = req.RolloutPercent
+	flag.RestaurantIDs = included
+	flag.ExcludedRestaurantIDs = excluded
+	if adminUUID, err := uuid.Parse(adminID); err == nil {
+		flag.UpdatedBy = &adminUUID
+	}
+
+	if err := s.repo.Save(ctx, flag); err != nil {
+		return nil, fmt.Errorf("failed to save feature flag: %v", err)
+	}
+
+	s.refresh(ctx)
+	return flag, nil
+}
+
+// DeleteFlag removes a stored flag; a known feature returns to its default
+func (s *FeatureFlagService) DeleteFlag(ctx context.Context, key string) error {
+	if _, err := s.repo.GetByKey(ctx, key); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrFeatureFlagNotFound
+		}
+		return fmt.Errorf("failed to load feature flag: %v", err)
+	}
+	if err := s.repo.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete feature flag: %v", err)
+	}
+
+	s.refresh(ctx)
+	return nil
+}
+
+func (s *FeatureFlagService) cachedFlags(ctx context.Context) map[string]models.FeatureFlag {
+	s.mutex.RLock()
+	stale := time.Since(s.lastRefresh) > featureFlagRefreshInterval
+	flags := s.flags
+	s.mutex.RUnlock()
+
+	if stale {
+		return s.refresh(ctx)
+	}
+	return flags
+}
+
+func (s *FeatureFlagService) refresh(ctx context.Context) map[string]models.FeatureFlag {
+	flags, err := s.repo.List(ctx)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err != nil {
+		// Keep evaluating the last known flags rather than flipping features to their defaults
+		log.Printf("Failed to load feature flags: %v", err)
+	} else {
+		s.flags = make(map[string]models.FeatureFlag, len(flags))
+		for _, flag := range flags {
+			s.flags[flag.Key] = flag
+		}
+	}
+	s.lastRefresh = time.Now()
+
+	return s.flags
+}
+
+// evaluateFeatureFlag applies the master switch, then the restaurant exclusions and inclusions,
+// then the percentage rollout. The same subject always lands in the same bucket for a flag.
+func evaluateFeatureFlag(flag *models.FeatureFlag, subject FeatureSubject) bool {
+	if !flag.Enabled {
+		return false
+	}
+	if subject.RestaurantID != "" {
+		if containsFeatureRestaurant(flag.ExcludedRestaurantIDs, subject.RestaurantID) {
+			return false
+		}
+		if containsFeatureRestaurant(flag.RestaurantIDs, subject.RestaurantID) {
+			return true
+		}
+	}
+	if flag.RolloutPercent >= 100 {
+		return true
+	}
+
+	unit := subject.RestaurantID
+	if unit == "" {
+		unit = subject.UserID
+	}
+	if unit == "" || flag.RolloutPercent <= 0 {
+		return false
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(flag.Key + ":" + unit))
+	return int(hash.Sum32()%100) < flag.RolloutPercent
+}
+
+func featureFlagRestaurants(ids []string) (models.StringArray, error) {
+	restaurants := models.StringArray{}
+	for _, id := range ids {
+		restaurantUUID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid restaurant ID %q", ErrFeatureFlagInvalid, id)
+		}
+		if !containsFeatureRestaurant(restaurants, restaurantUUID.String()) {
+			restaurants = append(restaurants, restaurantUUID.String())
+		}
+	}
+	return restaurants, nil
+}
+
+func containsFeatureRestaurant(ids []string, restaurantID string) bool {
+	for _, id := range ids {
+		if id == restaurantID {
+			return true
+		}
+	}
+	return false
+}
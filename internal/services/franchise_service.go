@@ -0,0 +1,827 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrFranchiseForbidden = apperr.Forbidden("franchise_forbidden", "you do not manage this brand")
+	ErrFranchiseInvalid   = apperr.Validation("franchise_invalid", "invalid franchise request")
+	ErrFranchiseNotFound  = apperr.NotFound("brand_not_found", "brand not found")
+)
+
+type CreateBrandRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Description   string   `json:"description"`
+	Logo          string   `json:"logo"`
+	CuisineTypes  []string `json:"cuisine_types"`
+	GSTNumber     string   `json:"gst_number" binding:"required"`
+	ContactNumber string   `json:"contact_number"`
+}
+
+type AttachOutletRequest struct {
+	RestaurantID string `json:"restaurant_id" binding:"required"`
+}
+
+type SyncMenuRequest struct {
+	OutletIDs []string `json:"outlet_ids"` // defaults to every outlet
+}
+
+// OutletPriceRequest sets an outlet's own price for a brand menu item. Variant prices are
+// keyed by variant ID; variants left out keep the brand price.
+type OutletPriceRequest struct {
+	Price         models.Money            `json:"price" binding:"required,gt=0"`
+	DiscountPrice *models.Money           `json:"discount_price,omitempty"`
+	VariantPrices map[string]models.Money `json:"variant_prices,omitempty"`
+}
+
+type BrandResponse struct {
+	Brand   *models.Restaurant  `json:"brand"`
+	Outlets []models.Restaurant `json:"outlets"`
+}
+
+type BrandMenuResponse struct {
+	BrandID    uuid.UUID                `json:"brand_id"`
+	Categories []models.ProductCategory `json:"categories"`
+	Products   []models.Product         `json:"products"`
+}
+
+// MenuSyncResult counts what a brand menu sync changed at one outlet
+type MenuSyncResult struct {
+	OutletID          uuid.UUID `json:"outlet_id"`
+	OutletName        string    `json:"outlet_name"`
+	CategoriesCreated int       `json:"categories_created"`
+	CategoriesUpdated int       `json:"categories_updated"`
+	ProductsCreated   int       `json:"products_created"`
+	ProductsUpdated   int       `json:"products_updated"`
+	ProductsRemoved   int       `json:"products_removed"`
+	Error             string    `json:"error,omitempty"`
+}
+
+type OutletAnalytics struct {
+	RestaurantID string  `json:"restaurant_id"`
+	Name         string  `json:"name"`
+	TotalOrders  int     `json:"total_orders"`
+	TotalRevenue float64 `json:"total_revenue"`
+	AOV          float64 `json:"average_order_value"`
+}
+
+type BrandAnalyticsResponse struct {
+	BrandID      uuid.UUID            `json:"brand_id"`
+	From         string               `json:"from"`
+	To           string               `json:"to"`
+	TotalOrders  int                  `json:"total_orders"`
+	TotalRevenue float64              `json:"total_revenue"`
+	AOV          float64              `json:"average_order_value"`
+	PopularItems []models.PopularItem `json:"popular_items"` // combined across outlets by item name
+	PeakHours    map[string]int       `json:"peak_hours"`
+	Outlets      []OutletAnalytics    `json:"outlets"`
+}
+
+type BrandOrdersResponse struct {
+	Orders []models.Order `json:"orders"`
+	Total  int64          `json:"total"`
+	Page   int            `json:"page"`
+	Limit  int            `json:"limit"`
+}
+
+// FranchiseService manages multi-outlet brands. A brand is a restaurant row that owns the
+// master menu and takes no orders; outlets point at it through FranchiseParentID and get
+// copies of its categories and products, linked back by BrandCategoryID and BrandProductID.
+type FranchiseService struct {
+	restaurantRepo  repositories.RestaurantRepository
+	productRepo     repositories.ProductRepository
+	categoryRepo    repositories.ProductCategoryRepository
+	orderRepo       repositories.OrderRepository
+	productService  *ProductService
+	categoryService *CategoryService
+	analytics       *RestaurantAnalyticsService
+}
+
+func NewFranchiseService(
+	restaurantRepo repositories.RestaurantRepository,
+	productRepo repositories.ProductRepository,
+	categoryRepo repositories.ProductCategoryRepository,
+	orderRepo repositories.OrderRepository,
+	productService *ProductService,
+	categoryService *CategoryService,
+	analytics *RestaurantAnalyticsService,
+) *FranchiseService {
+	return &FranchiseService{
+		restaurantRepo:  restaurantRepo,
+		productRepo:     productRepo,
+		categoryRepo:    categoryRepo,
+		orderRepo:       orderRepo,
+		productService:  productService,
+		categoryService: categoryService,
+		analytics:       analytics,
+	}
+}
+
+// CreateBrand creates a parent brand owned by the user. Brands stay inactive and never go
+// through onboarding; their outlets do.
+func (s *FranchiseService) CreateBrand(ctx context.Context, userID string, req *CreateBrandRequest) (*models.Restaurant, error) {
+	ownerID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user ID", ErrFranchiseInvalid)
+	}
+
+	brand := &models.Restaurant{
+		Name:             strings.TrimSpace(req.Name),
+		Description:      req.Description,
+		Logo:             req.Logo,
+		CuisineTypes:     req.CuisineTypes,
+		OwnerID:          ownerID,
+		GSTNumber:        strings.ToUpper(strings.TrimSpace(req.GSTNumber)),
+		ContactNumber:    req.ContactNumber,
+		IsBrand:          true,
+		Status:           "inactive",
+		OnboardingStatus: OnboardingDraft,
+	}
+	if err := s.restaurantRepo.Create(ctx, brand); err != nil {
+		return nil, fmt.Errorf("failed to create brand: %v", err)
+	}
+	return brand, nil
+}
+
+// GetBrand returns a brand with its outlets
+func (s *FranchiseService) GetBrand(ctx context.Context, userID, role, brandID string) (*BrandResponse, error) {
+	brand, err := s.manageBrand(ctx, userID, role, brandID)
+	if err != nil {
+		return nil, err
+	}
+
+	outlets, err := s.restaurantRepo.GetOutlets(ctx, brand.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load outlets: %v", err)
+	}
+	return &BrandResponse{Brand: brand, Outlets: outlets}, nil
+}
+
+// AttachOutlet adds a restaurant to a brand and copies the brand menu to it. The caller must
+// own both the brand and the restaurant, or be an admin.
+func (s *FranchiseService) AttachOutlet(ctx context.Context, userID, role, brandID string, req *AttachOutletRequest) (*MenuSyncResult, error) {
+	brand, err := s.manageBrand(ctx, userID, role, brandID)
+	if err != nil {
+		return nil, err
+	}
+
+	outlet, err := s.restaurant(ctx, req.RestaurantID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && outlet.OwnerID.String() != userID {
+		return nil, ErrFranchiseForbidden
+	}
+	if outlet.IsBrand {
+		return nil, fmt.Errorf("%w: a brand cannot be an outlet of another brand", ErrFranchiseInvalid)
+	}
+	if outlet.FranchiseParentID != nil && *outlet.FranchiseParentID != brand.ID {
+		return nil, fmt.Errorf("%w: restaurant already belongs to another brand", ErrFranchiseInvalid)
+	}
+
+	outlet.FranchiseParentID = &brand.ID
+	if err := s.restaurantRepo.Update(ctx, outlet); err != nil {
+		return nil, fmt.Errorf("failed to attach outlet: %v", err)
+	}
+
+	result := s.syncOutlet(ctx, brand, outlet)
+	return &result, nil
+}
+
+// DetachOutlet removes a restaurant from its brand. The outlet keeps its menu copies, which
+// stop following the brand. The brand owner, the outlet owner or an admin may detach it.
+func (s *FranchiseService) DetachOutlet(ctx context.Context, userID, role, brandID, outletID string) error {
+	brand, err := s.brand(ctx, brandID)
+	if err != nil {
+		return err
+	}
+	outlet, err := s.outlet(ctx, brand, outletID)
+	if err != nil {
+		return err
+	}
+	if role != "admin" && brand.OwnerID.String() != userID && outlet.OwnerID.String() != userID {
+		return ErrFranchiseForbidden
+	}
+
+	outlet.FranchiseParentID = nil
+	if err := s.restaurantRepo.Update(ctx, outlet); err != nil {
+		return fmt.Errorf("failed to detach outlet: %v", err)
+	}
+
+	if err := s.unlinkMenu(ctx, outlet.ID.String()); err != nil {
+		log.Printf("Failed to unlink menu of detached outlet %s: %v", outlet.ID, err)
+	}
+	return nil
+}
+
+// GetMenu returns the brand's master menu, including unavailable items
+func (s *FranchiseService) GetMenu(ctx context.Context, userID, role, brandID string) (*BrandMenuResponse, error) {
+	brand, err := s.manageBrand(ctx, userID, role, brandID)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categoryRepo.GetAllByRestaurant(ctx, brand.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %v", err)
+	}
+	products, err := s.productRepo.GetAllByRestaurant(ctx, brand.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load products: %v", err)
+	}
+
+	return &BrandMenuResponse{
+		BrandID:    brand.ID,
+		Categories: categories,
+		Products:   products,
+	}, nil
+}
+
+// CreateCategory adds a category to the brand menu
+func (s *FranchiseService) CreateCategory(ctx context.Context, userID, role, brandID string, req *CreateCategoryRequest) (*models.ProductCategory, error) {
+	brand, err := s.manageBrand(ctx, userID, role, brandID)
+	if err != nil {
+		return nil, err
+	}
+	category, err := s.categoryService.CreateCategory(ctx, brand.ID.String(), req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFranchiseInvalid, err)
+	}
+	return category, nil
+}
+
+// CreateProduct adds an item to the brand menu
+func (s *FranchiseService) CreateProduct(ctx context.Context, userID, role, brandID string, req *CreateProductRequest) (*models.Product, error) {
+	brand, err := s.manageBrand(ctx, userID, role, brandID)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.productService.CreateProduct(ctx, brand.ID.String(), req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFranchiseInvalid, err)
+	}
+	return product, nil
+}
+
+// UpdateProduct changes an item on the brand menu
+func (s *FranchiseService) UpdateProduct(ctx context.Context, userID, role, brandID, productID string, updates map[string]interface{}) error {
+	brand, err := s.manageBrand(ctx, userID, role, brandID)
+	if err != nil {
+		return err
+	}
+	if err := s.productService.UpdateProduct(ctx, productID, brand.ID.String(), userID, updates); err != nil {
+		return fmt.Errorf("%w: %v", ErrFranchiseInvalid, err)
+	}
+	return nil
+}
+
+// DeleteProduct removes an item from the brand menu; outlets drop it on the next sync
+func (s *FranchiseService) DeleteProduct(ctx context.Context, userID, role, brandID, productID string) error {
+	brand, err := s.manageBrand(ctx, userID, role, brandID)
+	if err != nil {
+		return err
+	}
+	if err := s.productService.DeleteProduct(ctx, productID, brand.ID.String()); err != nil {
+		return fmt.Errorf("%w: %v", ErrFranchiseInvalid, err)
+	}
+	return nil
+}
+
+// SyncMenu copies the brand menu to the given outlets, or to all of them
+func (s *FranchiseService) SyncMenu(ctx context.Context, userID, role, brandID string, req *SyncMenuRequest) ([]MenuSyncResult, error) {
+	brand, err := s.manageBrand(ctx, userID, role, brandID)
+	if err != nil {
+		return nil, err
+	}
+
+	outlets, err := s.restaurantRepo.GetOutlets(ctx, brand.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load outlets: %v", err)
+	}
+
+	if len(req.OutletIDs) > 0 {
+		wanted := make(map[string]bool, len(req.OutletIDs))
+		for _, id := range req.OutletIDs {
+			wanted[id] = true
+		}
+		selected := outlets[:0]
+		for _, outlet := range outlets {
+			if wanted[outlet.ID.String()] {
+				selected = append(selected, outlet)
+				delete(wanted, outlet.ID.String())
+			}
+		}
+		for id := range wanted {
+			return nil, fmt.Errorf("%w: %s is not an outlet of this brand", ErrFranchiseInvalid, id)
+		}
+		outlets = selected
+	}
+
+	results := make([]MenuSyncResult, 0, len(outlets))
+	for i := range outlets {
+		results = append(results, s.syncOutlet(ctx, brand, &outlets[i]))
+	}
+	return results, nil
+}
+
+// SetOutletPrice gives an outlet its own price for a brand menu item. Later syncs keep it
+// until it is reset. The brand owner or the outlet owner may set it.
+func (s *FranchiseService) SetOutletPrice(ctx context.Context, userID, role, brandID, outletID, productID string, req *OutletPriceRequest) (*models.Product, error) {
+	product, err := s.outletProduct(ctx, userID, role, brandID, outletID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DiscountPrice != nil && *req.DiscountPrice >= req.Price {
+		return nil, fmt.Errorf("%w: discount_price must be below price", ErrFranchiseInvalid)
+	}
+	before := product.PriceSnapshot()
+	for variantID, price := range req.VariantPrices {
+		if price <= 0 {
+			return nil, fmt.Errorf("%w: variant %s price must be positive", ErrFranchiseInvalid, variantID)
+		}
+		found := false
+		for i := range product.Variants {
+			if product.Variants[i].ID.Hex() == variantID {
+				product.Variants[i].Price = price
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: variant %s not found", ErrFranchiseInvalid, variantID)
+		}
+	}
+
+	product.Price = req.Price
+	product.DiscountPrice = req.DiscountPrice
+	product.PriceOverridden = true
+	if err := s.productService.updatePricedProduct(ctx, product, before, PriceSourceOutletOverride, userID); err != nil {
+		return nil, fmt.Errorf("failed to update price: %v", err)
+	}
+
+	s.clearOutletCache(ctx, product)
+	return product, nil
+}
+
+// ResetOutletPrice drops an outlet's price override and restores the brand prices
+func (s *FranchiseService) ResetOutletPrice(ctx context.Context, userID, role, brandID, outletID, productID string) (*models.Product, error) {
+	product, err := s.outletProduct(ctx, userID, role, brandID, outletID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := s.productRepo.GetByID(ctx, *product.BrandProductID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the brand no longer sells this item", ErrFranchiseInvalid)
+	}
+
+	before := product.PriceSnapshot()
+	product.PriceOverridden = false
+	copyPrices(product, source)
+	if err := s.productService.updatePricedProduct(ctx, product, before, PriceSourceOutletOverride, userID); err != nil {
+		return nil, fmt.Errorf("failed to reset price: %v", err)
+	}
+
+	s.clearOutletCache(ctx, product)
+	return product, nil
+}
+
+// GetAnalytics combines the outlets' analytics between two dates (YYYY-MM-DD, inclusive)
+func (s *FranchiseService) GetAnalytics(ctx context.Context, userID, role, brandID, from, to string) (*BrandAnalyticsResponse, error) {
+	brand, err := s.manageBrand(ctx, userID, role, brandID)
+	if err != nil {
+		return nil, err
+	}
+
+	outlets, err := s.restaurantRepo.GetOutlets(ctx, brand.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load outlets: %v", err)
+	}
+
+	response := &BrandAnalyticsResponse{
+		BrandID:      brand.ID,
+		PeakHours:    map[string]int{},
+		PopularItems: []models.PopularItem{},
+		Outlets:      []OutletAnalytics{},
+	}
+	// Outlet copies of a menu item have their own IDs, so items are combined by name
+	items := make(map[string]*models.PopularItem)
+	for _, outlet := range outlets {
+		summary, err := s.analytics.Summarize(ctx, outlet.ID.String(), from, to)
+		if err != nil {
+			return nil, err
+		}
+		response.From = summary.From
+		response.To = summary.To
+
+		response.Outlets = append(response.Outlets, OutletAnalytics{
+			RestaurantID: outlet.ID.String(),
+			Name:         outlet.Name,
+			TotalOrders:  summary.TotalOrders,
+			TotalRevenue: summary.TotalRevenue,
+			AOV:          summary.AOV,
+		})
+		response.TotalOrders += summary.TotalOrders
+		response.TotalRevenue += summary.TotalRevenue
+		for hour, count := range summary.PeakHours {
+			response.PeakHours[hour] += count
+		}
+		for _, item := range summary.PopularItems {
+			total, ok := items[item.Name]
+			if !ok {
+				total = &models.PopularItem{ProductID: item.ProductID, Name: item.Name}
+				items[item.Name] = total
+			}
+			total.OrderCount += item.OrderCount
+			total.Revenue = roundMoney(total.Revenue + item.Revenue)
+		}
+	}
+
+	response.TotalRevenue = roundMoney(response.TotalRevenue)
+	if response.TotalOrders > 0 {
+		response.AOV = roundMoney(response.TotalRevenue / float64(response.TotalOrders))
+	}
+	for _, item := range items {
+		response.PopularItems = append(response.PopularItems, *item)
+	}
+	sort.Slice(response.PopularItems, func(i, j int) bool {
+		return response.PopularItems[i].OrderCount > response.PopularItems[j].OrderCount
+	})
+	if len(response.PopularItems) > analyticsTopItems {
+		response.PopularItems = response.PopularItems[:analyticsTopItems]
+	}
+	sort.Slice(response.Outlets, func(i, j int) bool {
+		return response.Outlets[i].TotalRevenue > response.Outlets[j].TotalRevenue
+	})
+
+	return response, nil
+}
+
+// ListOrders lists orders across all of the brand's outlets, newest first
+func (s *FranchiseService) ListOrders(ctx context.Context, userID, role, brandID, status string, page, limit int) (*BrandOrdersResponse, error) {
+	brand, err := s.manageBrand(ctx, userID, role, brandID)
+	if err != nil {
+		return nil, err
+	}
+	page, limit = normalizePage(page, limit)
+
+	outlets, err := s.restaurantRepo.GetOutlets(ctx, brand.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load outlets: %v", err)
+	}
+	response := &BrandOrdersResponse{Orders: []models.Order{}, Page: page, Limit: limit}
+	if len(outlets) == 0 {
+		return response, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(outlets))
+	for _, outlet := range outlets {
+		ids = append(ids, outlet.ID)
+	}
+	orders, total, err := s.orderRepo.GetByRestaurantIDs(ctx, ids, status, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %v", err)
+	}
+	response.Orders = orders
+	response.Total = total
+	return response, nil
+}
+
+// syncOutlet copies the brand's categories and products to an outlet. Outlet availability
+// and price overrides are kept; items the brand no longer sells are removed, and brand
+// categories that were removed are deactivated so outlet-only items in them survive.
+func (s *FranchiseService) syncOutlet(ctx context.Context, brand, outlet *models.Restaurant) MenuSyncResult {
+	result := MenuSyncResult{OutletID: outlet.ID, OutletName: outlet.Name}
+	if err := s.copyMenu(ctx, brand.ID.String(), outlet.ID.String(), &result); err != nil {
+		log.Printf("Failed to sync brand %s menu to outlet %s: %v", brand.ID, outlet.ID, err)
+		result.Error = err.Error()
+	}
+	s.productService.clearProductCache(outlet.ID.String())
+	s.categoryService.cache.Delete(ctx, "categories:"+outlet.ID.String())
+	return result
+}
+
+func (s *FranchiseService) copyMenu(ctx context.Context, brandID, outletID string, result *MenuSyncResult) error {
+	brandCategories, err := s.categoryRepo.GetAllByRestaurant(ctx, brandID)
+	if err != nil {
+		return fmt.Errorf("failed to load brand categories: %v", err)
+	}
+	outletCategories, err := s.categoryRepo.GetAllByRestaurant(ctx, outletID)
+	if err != nil {
+		return fmt.Errorf("failed to load outlet categories: %v", err)
+	}
+
+	linked := make(map[primitive.ObjectID]*models.ProductCategory)
+	for i := range outletCategories {
+		if outletCategories[i].BrandCategoryID != nil {
+			linked[*outletCategories[i].BrandCategoryID] = &outletCategories[i]
+		}
+	}
+
+	// Categories first, so products and sub-categories can point at the outlet copies
+	categoryIDs := make(map[primitive.ObjectID]primitive.ObjectID, len(brandCategories))
+	for i := range brandCategories {
+		source := &brandCategories[i]
+		target, ok := linked[source.ID]
+		if !ok {
+			brandCategoryID := source.ID
+			target = &models.ProductCategory{RestaurantID: outletID, BrandCategoryID: &brandCategoryID}
+		}
+		target.Name = source.Name
+		target.Description = source.Description
+		target.SortOrder = source.SortOrder
+		target.ImgUrl = source.ImgUrl
+		target.VideoUrl = source.VideoUrl
+		target.IsActive = source.IsActive
+
+		if ok {
+			err = s.categoryRepo.Update(ctx, target)
+			result.CategoriesUpdated++
+		} else {
+			err = s.categoryRepo.Create(ctx, target)
+			linked[source.ID] = target
+			result.CategoriesCreated++
+		}
+		if err != nil {
+			return fmt.Errorf("failed to copy category %s: %v", source.Name, err)
+		}
+		categoryIDs[source.ID] = target.ID
+	}
+
+	kept := make(map[primitive.ObjectID]bool, len(brandCategories))
+	for i := range brandCategories {
+		source := &brandCategories[i]
+		kept[source.ID] = true
+		if source.ParentCategoryID == nil {
+			continue
+		}
+		parentID, ok := categoryIDs[*source.ParentCategoryID]
+		target := linked[source.ID]
+		if !ok || (target.ParentCategoryID != nil && *target.ParentCategoryID == parentID) {
+			continue
+		}
+		target.ParentCategoryID = &parentID
+		if err := s.categoryRepo.Update(ctx, target); err != nil {
+			return fmt.Errorf("failed to nest category %s: %v", source.Name, err)
+		}
+	}
+	for brandCategoryID, target := range linked {
+		if !kept[brandCategoryID] && target.IsActive {
+			target.IsActive = false
+			if err := s.categoryRepo.Update(ctx, target); err != nil {
+				return fmt.Errorf("failed to deactivate category %s: %v", target.Name, err)
+			}
+		}
+	}
+
+	brandProducts, err := s.productRepo.GetAllByRestaurant(ctx, brandID)
+	if err != nil {
+		return fmt.Errorf("failed to load brand products: %v", err)
+	}
+	outletProducts, err := s.productRepo.GetAllByRestaurant(ctx, outletID)
+	if err != nil {
+		return fmt.Errorf("failed to load outlet products: %v", err)
+	}
+
+	copies := make(map[primitive.ObjectID]*models.Product)
+	for i := range outletProducts {
+		if outletProducts[i].BrandProductID != nil {
+			copies[*outletProducts[i].BrandProductID] = &outletProducts[i]
+		}
+	}
+
+	selling := make(map[primitive.ObjectID]bool, len(brandProducts))
+	for i := range brandProducts {
+		source := &brandProducts[i]
+		selling[source.ID] = true
+
+		target, ok := copies[source.ID]
+		var before models.PriceSnapshot
+		if ok {
+			before = target.PriceSnapshot()
+		} else {
+			brandProductID := source.ID
+			target = &models.Product{
+				RestaurantID:   outletID,
+				BrandProductID: &brandProductID,
+				IsAvailable:    source.IsAvailable,
+			}
+		}
+		if !target.PriceOverridden {
+			copyPrices(target, source)
+		} else {
+			target.Variants = keepVariantPrices(target.Variants, source.Variants)
+		}
+		target.CategoryID = categoryIDs[source.CategoryID]
+		target.Name = source.Name
+		target.Description = source.Description
+		target.ImageUrls = source.ImageUrls
+		target.PreparationTime = source.PreparationTime
+		target.Tags = source.Tags
+		target.VideoUrl = source.VideoUrl
+		target.NutritionalInfo = source.NutritionalInfo
+		target.Addons = source.Addons
+		target.OptionGroups = source.OptionGroups
+		target.DietType = source.DietType
+		target.Allergens = source.Allergens
+		target.SpiceLevel = source.SpiceLevel
+
+		if ok {
+			err = s.productService.updatePricedProduct(ctx, target, before, PriceSourceBrandSync, "")
+			result.ProductsUpdated++
+		} else {
+			err = s.productRepo.Create(ctx, target)
+			result.ProductsCreated++
+		}
+		if err != nil {
+			return fmt.Errorf("failed to copy product %s: %v", source.Name, err)
+		}
+		copies[source.ID] = target
+	}
+
+	for brandProductID, target := range copies {
+		if selling[brandProductID] {
+			continue
+		}
+		if err := s.productRepo.Delete(ctx, target.ID); err != nil {
+			return fmt.Errorf("failed to remove product %s: %v", target.Name, err)
+		}
+		s.productService.cache.InvalidateTags(ctx, productCartsTag(target.ID.Hex()))
+		result.ProductsRemoved++
+	}
+
+	// Combos are pointed at the outlet's copies of their items once every copy exists
+	for i := range brandProducts {
+		source := &brandProducts[i]
+		target := copies[source.ID]
+		if !source.IsCombo() && !target.IsCombo() {
+			continue
+		}
+		target.Components = outletComponents(source.Components, copies)
+		if err := s.productRepo.Update(ctx, target); err != nil {
+			return fmt.Errorf("failed to link combo %s: %v", source.Name, err)
+		}
+		if err := syncCombo(ctx, s.productRepo, s.productService.inventoryRepo, s.productService.cache, target); err != nil {
+			log.Printf("Failed to sync combo %s: %v", target.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// outletComponents swaps a brand combo's items for the outlet's copies of them
+func outletComponents(components []models.ComboComponent, copies map[primitive.ObjectID]*models.Product) []models.ComboComponent {
+	var linked []models.ComboComponent
+	for _, component := range components {
+		if target, ok := copies[component.ProductID]; ok {
+			component.ProductID = target.ID
+			linked = append(linked, component)
+		}
+	}
+	return linked
+}
+
+// unlinkMenu turns a detached outlet's menu copies into its own items
+func (s *FranchiseService) unlinkMenu(ctx context.Context, outletID string) error {
+	categories, err := s.categoryRepo.GetAllByRestaurant(ctx, outletID)
+	if err != nil {
+		return err
+	}
+	for i := range categories {
+		if categories[i].BrandCategoryID == nil {
+			continue
+		}
+		categories[i].BrandCategoryID = nil
+		if err := s.categoryRepo.Update(ctx, &categories[i]); err != nil {
+			return err
+		}
+	}
+
+	products, err := s.productRepo.GetAllByRestaurant(ctx, outletID)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		if products[i].BrandProductID == nil {
+			continue
+		}
+		products[i].BrandProductID = nil
+		products[i].PriceOverridden = false
+		if err := s.productRepo.Update(ctx, &products[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FranchiseService) clearOutletCache(ctx context.Context, product *models.Product) {
+	s.productService.clearProductCache(product.RestaurantID)
+	s.productService.cache.InvalidateTags(ctx, productCartsTag(product.ID.Hex()))
+}
+
+// outletProduct loads an outlet's copy of a brand menu item for a price change
+func (s *FranchiseService) outletProduct(ctx context.Context, userID, role, brandID, outletID, productID string) (*models.Product, error) {
+	brand, err := s.brand(ctx, brandID)
+	if err != nil {
+		return nil, err
+	}
+	outlet, err := s.outlet(ctx, brand, outletID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && brand.OwnerID.String() != userID && outlet.OwnerID.String() != userID {
+		return nil, ErrFranchiseForbidden
+	}
+
+	productObjectID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid product ID", ErrFranchiseInvalid)
+	}
+	product, err := s.productRepo.GetByID(ctx, productObjectID)
+	if err != nil || product.RestaurantID != outlet.ID.String() {
+		return nil, fmt.Errorf("%w: product %s", ErrFranchiseNotFound, productID)
+	}
+	if product.BrandProductID == nil {
+		return nil, fmt.Errorf("%w: product is the outlet's own item, not a brand menu item", ErrFranchiseInvalid)
+	}
+	return product, nil
+}
+
+// manageBrand loads a brand the user owns, or any brand for admins
+func (s *FranchiseService) manageBrand(ctx context.Context, userID, role, brandID string) (*models.Restaurant, error) {
+	brand, err := s.brand(ctx, brandID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && brand.OwnerID.String() != userID {
+		return nil, ErrFranchiseForbidden
+	}
+	return brand, nil
+}
+
+func (s *FranchiseService) brand(ctx context.Context, brandID string) (*models.Restaurant, error) {
+	brand, err := s.restaurant(ctx, brandID)
+	if err != nil {
+		return nil, err
+	}
+	if !brand.IsBrand {
+		return nil, fmt.Errorf("%w: restaurant %s is not a brand", ErrFranchiseNotFound, brandID)
+	}
+	return brand, nil
+}
+
+func (s *FranchiseService) outlet(ctx context.Context, brand *models.Restaurant, outletID string) (*models.Restaurant, error) {
+	outlet, err := s.restaurant(ctx, outletID)
+	if err != nil {
+		return nil, err
+	}
+	if outlet.FranchiseParentID == nil || *outlet.FranchiseParentID != brand.ID {
+		return nil, fmt.Errorf("%w: restaurant %s is not an outlet of this brand", ErrFranchiseNotFound, outletID)
+	}
+	return outlet, nil
+}
+
+func (s *FranchiseService) restaurant(ctx context.Context, restaurantID string) (*models.Restaurant, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrFranchiseInvalid)
+	}
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: restaurant %s", ErrFranchiseNotFound, restaurantID)
+	}
+	return restaurant, nil
+}
+
+// copyPrices sets an outlet copy's prices, variants included, from the brand item
+func copyPrices(target, source *models.Product) {
+	target.Price = source.Price
+	target.DiscountPrice = source.DiscountPrice
+	target.Variants = append([]models.ProductVariant(nil), source.Variants...)
+}
+
+// keepVariantPrices takes the brand's variants but keeps the outlet's price for variants it
+// already had
+func keepVariantPrices(current, source []models.ProductVariant) []models.ProductVariant {
+	prices := make(map[primitive.ObjectID]models.Money, len(current))
+	for _, variant := range current {
+		prices[variant.ID] = variant.Price
+	}
+	variants := append([]models.ProductVariant(nil), source...)
+	for i := range variants {
+		if price, ok := prices[variants[i].ID]; ok {
+			variants[i].Price = price
+		}
+	}
+	return variants
+}
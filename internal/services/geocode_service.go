@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/cache"
+	"golang-food-backend/pkg/geo"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrGeocodingDisabled = apperr.Unavailable("geocoding_disabled", "address lookup is not configured")
+	ErrInvalidAddress    = apperr.Validation("address_invalid", "invalid address")
+)
+
+// A map pin further than this from its pincode's centre is treated as a mistake
+const maxPinToPincodeKm = 25
+
+var indianPincodePattern = regexp.MustCompile(`^[1-9][0-9]{5}$`)
+
+// GeocodePolicy configures address validation. With Strict set, addresses the geocoder
+// cannot place are rejected; otherwise they are saved unverified.
+type GeocodePolicy struct {
+	CacheTTL time.Duration
+	Strict   bool
+}
+
+// GeocodeService validates and normalizes addresses and serves address search. The geocoder
+// may be nil, in which case only format checks run and lookups return ErrGeocodingDisabled.
+type GeocodeService struct {
+	geocoder geo.Geocoder
+	cache    *cache.RedisCache
+	policy   GeocodePolicy
+}
+
+func NewGeocodeService(geocoder geo.Geocoder, cache *cache.RedisCache, policy GeocodePolicy) *GeocodeService {
+	return &GeocodeService{
+		geocoder: geocoder,
+		cache:    cache,
+		policy:   policy,
+	}
+}
+
+// Autocomplete suggests addresses for partial input, biased towards near when given
+func (s *GeocodeService) Autocomplete(ctx context.Context, input string, near *geo.Point) ([]geo.Suggestion, error) {
+	if s.geocoder == nil {
+		return nil, ErrGeocodingDisabled
+	}
+
+	input = strings.TrimSpace(input)
+	if len(input) < 3 {
+		return []geo.Suggestion{}, nil
+	}
+
+	key := strings.ToLower(input)
+	if near != nil {
+		// Nearby users share cached suggestions
+		key += "|" + geo.Geohash(*near, 5)
+	}
+	cacheKey := "geocode:autocomplete:" + hashKey(key)
+
+	var suggestions []geo.Suggestion
+	if err := s.cache.Get(ctx, cacheKey, &suggestions); err == nil {
+		return suggestions, nil
+	}
+
+	suggestions, err := s.geocoder.Autocomplete(ctx, input, near)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(ctx, cacheKey, suggestions, s.policy.CacheTTL)
+	return suggestions, nil
+}
+
+// ReverseGeocode returns the address at a map pin
+func (s *GeocodeService) ReverseGeocode(ctx context.Context, lat, lng float64) (*geo.Place, error) {
+	if s.geocoder == nil {
+		return nil, ErrGeocodingDisabled
+	}
+	if err := validateCoordinates(lat, lng); err != nil {
+		return nil, err
+	}
+
+	point := geo.Point{Lat: lat, Lng: lng}
+	cacheKey := "geocode:reverse:" + geo.Geohash(point, 9)
+
+	var place geo.Place
+	if err := s.cache.Get(ctx, cacheKey, &place); err == nil {
+		return &place, nil
+	}
+
+	result, err := s.geocoder.Reverse(ctx, point)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(ctx, cacheKey, result, s.policy.CacheTTL)
+	return result, nil
+}
+
+// LookupPlace resolves an autocomplete suggestion to a full address
+func (s *GeocodeService) LookupPlace(ctx context.Context, placeID string) (*geo.Place, error) {
+	if s.geocoder == nil {
+		return nil, ErrGeocodingDisabled
+	}
+
+	cacheKey := "geocode:place:" + hashKey(placeID)
+	var place geo.Place
+	if err := s.cache.Get(ctx, cacheKey, &place); err == nil {
+		return &place, nil
+	}
+
+	result, err := s.geocoder.Lookup(ctx, placeID)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(ctx, cacheKey, result, s.policy.CacheTTL)
+	return result, nil
+}
+
+// ValidateAddress checks an address before it is saved and normalizes it in place. The
+// pincode must resolve to the address's state, the city and state are taken from the
+// pincode, and the map pin must lie near the pincode; addresses without a pin get one from
+// the geocoder. Provider outages don't block saving, the address just stays unverified.
+// Validation failures wrap ErrInvalidAddress.
+func (s *GeocodeService) ValidateAddress(ctx context.Context, address *models.Address) error {
+	address.AddressLine1 = strings.TrimSpace(address.AddressLine1)
+	address.AddressLine2 = strings.TrimSpace(address.AddressLine2)
+	address.City = strings.TrimSpace(address.City)
+	address.State = strings.TrimSpace(address.State)
+	address.Country = strings.TrimSpace(address.Country)
+	address.PinCode = strings.ReplaceAll(strings.TrimSpace(address.PinCode), " ", "")
+	address.LocationVerified = false
+	address.GeocodedAt = nil
+
+	if isIndia(address.Country) && !indianPincodePattern.MatchString(address.PinCode) {
+		return fmt.Errorf("%w: pincode must be 6 digits", ErrInvalidAddress)
+	}
+	hasPin := address.Latitude != 0 || address.Longitude != 0
+	if hasPin {
+		if err := validateCoordinates(address.Latitude, address.Longitude); err != nil {
+			return err
+		}
+	}
+
+	if s.geocoder == nil {
+		return nil
+	}
+
+	area, err := s.pincodeArea(ctx, address.PinCode, address.Country)
+	if err != nil {
+		return s.unverified(address, "pincode", err)
+	}
+	if area.State != "" && address.State != "" && !sameName(area.State, address.State) {
+		return fmt.Errorf("%w: pincode %s is in %s, not %s", ErrInvalidAddress, address.PinCode, area.State, address.State)
+	}
+	if area.City != "" {
+		address.City = area.City
+	}
+	if area.State != "" {
+		address.State = area.State
+	}
+
+	var place *geo.Place
+	if hasPin {
+		place, err = s.ReverseGeocode(ctx, address.Latitude, address.Longitude)
+		if err != nil {
+			return s.unverified(address, "map pin", err)
+		}
+		pin := geo.Point{Lat: address.Latitude, Lng: address.Longitude}
+		if place.PostalCode != address.PinCode && geo.DistanceKm(pin, area.Location) > maxPinToPincodeKm {
+			return fmt.Errorf("%w: the map pin is not near pincode %s", ErrInvalidAddress, address.PinCode)
+		}
+	} else {
+		query := strings.Join([]string{address.AddressLine1, address.City, address.PinCode, address.Country}, ", ")
+		places, err := s.geocoder.Geocode(ctx, query)
+		if err != nil {
+			return s.unverified(address, "address", err)
+		}
+		place = &places[0]
+		address.Latitude = place.Location.Lat
+		address.Longitude = place.Location.Lng
+	}
+
+	now := time.Now()
+	address.FormattedAddress = place.FormattedAddress
+	address.PlaceID = place.PlaceID
+	address.LocationVerified = true
+	address.GeocodedAt = &now
+	return nil
+}
+
+// pincodeArea geocodes a pincode on its own to find the city, state and centre it covers
+func (s *GeocodeService) pincodeArea(ctx context.Context, pincode, country string) (*geo.Place, error) {
+	cacheKey := "geocode:pincode:" + strings.ToLower(country) + ":" + pincode
+	var area geo.Place
+	if err := s.cache.Get(ctx, cacheKey, &area); err == nil {
+		return &area, nil
+	}
+
+	places, err := s.geocoder.Geocode(ctx, pincode+", "+country)
+	if err != nil {
+		return nil, err
+	}
+	for i := range places {
+		if places[i].PostalCode == pincode {
+			s.cache.Set(ctx, cacheKey, places[i], s.policy.CacheTTL)
+			return &places[i], nil
+		}
+	}
+	return nil, geo.ErrNoResults
+}
+
+// unverified decides what a geocoder failure means for the address being saved
+func (s *GeocodeService) unverified(address *models.Address, what string, err error) error {
+	if errors.Is(err, geo.ErrNoResults) {
+		if s.policy.Strict {
+			return fmt.Errorf("%w: could not find the %s on the map", ErrInvalidAddress, what)
+		}
+		return nil
+	}
+	log.Printf("Geocoding %s for address failed: %v", what, err)
+	return nil
+}
+
+func validateCoordinates(lat, lng float64) error {
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return fmt.Errorf("%w: coordinates out of range", ErrInvalidAddress)
+	}
+	return nil
+}
+
+func isIndia(country string) bool {
+	country = strings.ToLower(strings.TrimSpace(country))
+	return country == "india" || country == "in" || country == "ind"
+}
+
+// sameName compares place names loosely, ignoring case, spaces and punctuation
+func sameName(a, b string) bool {
+	normalize := func(s string) string {
+		var b strings.Builder
+		for _, r := range strings.ToLower(s) {
+			if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+				b.WriteRune(r)
+			}
+		}
+		return b.String()
+	}
+	return normalize(a) == normalize(b)
+}
+
+func hashKey(value string) string {
+	sum := sha1.Sum([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,671 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/cache"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+const (
+	GroupOrderOpen        = "open"
+	GroupOrderCheckingOut = "checking_out"
+	GroupOrderCheckedOut  = "checked_out"
+	GroupOrderCancelled   = "cancelled"
+
+	// groupOrderTTL is how long a group order is kept after its last change
+	groupOrderTTL        = 6 * time.Hour
+	groupOrderMaxMembers = 20
+	groupOrderCodeLength = 6
+	// Join codes leave out characters that are easily confused when read aloud
+	groupOrderCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+var (
+	ErrGroupOrderNotFound = apperr.NotFound("group_order_not_found", "group order not found")
+	ErrGroupOrderClosed   = apperr.Conflict("group_order_closed", "group order is no longer open")
+	ErrGroupOrderHostOnly = apperr.Forbidden("group_order_host_only", "only the host can do this")
+	ErrGroupOrderFull     = apperr.Conflict("group_order_full", "group order is full")
+	ErrGroupOrderInvalid  = apperr.Validation("group_order_invalid", "invalid group order request")
+)
+
+// GroupOrder is a shared cart session kept in Redis. Members add items under their own names;
+// the host checks out the combined cart with a single payment.
+type GroupOrder struct {
+	ID           string              `json:"id"`
+	Code         string              `json:"code"`
+	HostUserID   string              `json:"host_user_id"`
+	RestaurantID string              `json:"restaurant_id"`
+	Status       string              `json:"status"` // open, checking_out, checked_out, cancelled
+	OrderID      string              `json:"order_id,omitempty"`
+	Members      []*GroupOrderMember `json:"members"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+type GroupOrderMember struct {
+	UserID   string            `json:"user_id"`
+	Name     string            `json:"name"`
+	Items    []models.CartItem `json:"items"`
+	JoinedAt time.Time         `json:"joined_at"`
+}
+
+func (g *GroupOrder) member(userID string) *GroupOrderMember {
+	for _, member := range g.Members {
+		if member.UserID == userID {
+			return member
+		}
+	}
+	return nil
+}
+
+type GroupOrderResponse struct {
+	ID           string                     `json:"id"`
+	Code         string                     `json:"code"`
+	HostUserID   string                     `json:"host_user_id"`
+	RestaurantID string                     `json:"restaurant_id"`
+	Status       string                     `json:"status"`
+	OrderID      string                     `json:"order_id,omitempty"`
+	Members      []GroupOrderMemberResponse `json:"members"`
+	SubTotal     models.Money               `json:"sub_total"`
+	ExpiresAt    time.Time                  `json:"expires_at"`
+}
+
+type GroupOrderMemberResponse struct {
+	UserID   string             `json:"user_id"`
+	Name     string             `json:"name"`
+	IsHost   bool               `json:"is_host"`
+	Items    []CartItemResponse `json:"items"`
+	SubTotal models.Money       `json:"sub_total"`
+}
+
+// GroupMemberShare is a member's part of the bill: their items plus a share of taxes, fees,
+// delivery and discount in proportion to their items
+type GroupMemberShare struct {
+	UserID   string       `json:"user_id"`
+	Name     string       `json:"name"`
+	SubTotal models.Money `json:"sub_total"`
+	Share    models.Money `json:"share"`
+}
+
+type GroupBillSummaryResponse struct {
+	*BillSummaryResponse
+	Members []GroupMemberShare `json:"members"`
+}
+
+// GroupOrderEvent is sent to the members' WebSocket connections when the group order changes
+type GroupOrderEvent struct {
+	Type      string                    `json:"type"` // member_joined, member_left, items_updated, bill_summary, checkout_started, checked_out, cancelled
+	UserID    string                    `json:"user_id,omitempty"`
+	Group     *GroupOrderResponse       `json:"group,omitempty"`
+	Bill      *GroupBillSummaryResponse `json:"bill,omitempty"`
+	Timestamp time.Time                 `json:"timestamp"`
+}
+
+type GroupOrderService struct {
+	cartService *CartService
+	userRepo    repositories.UserRepository
+	features    *FeatureFlagService
+	cache       *cache.RedisCache
+}
+
+func NewGroupOrderService(cartService *CartService, userRepo repositories.UserRepository, features *FeatureFlagService, cache *cache.RedisCache) *GroupOrderService {
+	return &GroupOrderService{
+		cartService: cartService,
+		userRepo:    userRepo,
+		features:    features,
+		cache:       cache,
+	}
+}
+
+func groupOrderKey(id string) string {
+	return "group_order:" + id
+}
+
+func groupOrderCodeKey(code string) string {
+	return "group_order_code:" + code
+}
+
+func groupOrderChannel(id string) string {
+	return "group_order_events:" + id
+}
+
+// Create starts a group order for the restaurant with the user as host
+func (s *GroupOrderService) Create(ctx context.Context, userID, restaurantID, name string) (*GroupOrderResponse, error) {
+	restUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrGroupOrderInvalid)
+	}
+	if err := s.features.Require(ctx, FeatureGroupOrders, FeatureSubject{RestaurantID: restUUID.String(), UserID: userID}); err != nil {
+		return nil, err
+	}
+	if err := s.cartService.restaurants.EnsureAcceptingOrders(ctx, restUUID); err != nil {
+		return nil, err
+	}
+	member, err := s.newMember(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	group := &GroupOrder{
+		ID:           uuid.New().String(),
+		HostUserID:   userID,
+		RestaurantID: restaurantID,
+		Status:       GroupOrderOpen,
+		Members:      []*GroupOrderMember{member},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	client := s.cache.Client()
+	for attempt := 0; group.Code == ""; attempt++ {
+		if attempt == 5 {
+			return nil, errors.New("failed to allocate a join code")
+		}
+		code, err := newGroupOrderCode()
+		if err != nil {
+			return nil, err
+		}
+		claimed, err := client.SetNX(ctx, groupOrderCodeKey(code), group.ID, groupOrderTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			group.Code = code
+		}
+	}
+
+	data, err := json.Marshal(group)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Set(ctx, groupOrderKey(group.ID), data, groupOrderTTL).Err(); err != nil {
+		return nil, err
+	}
+	return s.response(ctx, group)
+}
+
+func newGroupOrderCode() (string, error) {
+	code := make([]byte, groupOrderCodeLength)
+	max := big.NewInt(int64(len(groupOrderCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate join code: %v", err)
+		}
+		code[i] = groupOrderCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// newMember names a member after their account unless they chose a name for the group
+func (s *GroupOrderService) newMember(ctx context.Context, userID, name string) (*GroupOrderMember, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	if name == "" {
+		user, err := s.userRepo.GetByID(ctx, userUUID)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		name = user.Name
+	}
+	return &GroupOrderMember{
+		UserID:   userID,
+		Name:     name,
+		Items:    []models.CartItem{},
+		JoinedAt: time.Now(),
+	}, nil
+}
+
+func (s *GroupOrderService) load(ctx context.Context, client redis.Cmdable, id string) (*GroupOrder, error) {
+	data, err := client.Get(ctx, groupOrderKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrGroupOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var group GroupOrder
+	if err := json.Unmarshal(data, &group); err != nil {
+		return nil, fmt.Errorf("failed to decode group order: %v", err)
+	}
+	return &group, nil
+}
+
+// update applies change to the group order and stores it, retrying when another member
+// changed it in between. The session and its join code live on for the TTL after each change.
+func (s *GroupOrderService) update(ctx context.Context, id string, change func(group *GroupOrder) error) (*GroupOrder, error) {
+	client := s.cache.Client()
+	key := groupOrderKey(id)
+
+	var group *GroupOrder
+	for attempt := 0; attempt <= maxConflictRetries; attempt++ {
+		err := client.Watch(ctx, func(tx *redis.Tx) error {
+			var err error
+			group, err = s.load(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if err := change(group); err != nil {
+				return err
+			}
+			group.UpdatedAt = time.Now()
+			data, err := json.Marshal(group)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, data, groupOrderTTL)
+				if group.Status == GroupOrderOpen {
+					pipe.Expire(ctx, groupOrderCodeKey(group.Code), groupOrderTTL)
+				} else {
+					pipe.Del(ctx, groupOrderCodeKey(group.Code))
+				}
+				return nil
+			})
+			return err
+		}, key)
+		if err != redis.TxFailedErr {
+			return group, err
+		}
+	}
+	return nil, ErrConcurrentUpdate
+}
+
+// updateOpen changes an open group order on behalf of one of its members
+func (s *GroupOrderService) updateOpen(ctx context.Context, userID, id string, change func(group *GroupOrder, member *GroupOrderMember) error) (*GroupOrder, error) {
+	return s.update(ctx, id, func(group *GroupOrder) error {
+		member := group.member(userID)
+		if member == nil {
+			return ErrGroupOrderNotFound
+		}
+		if group.Status != GroupOrderOpen {
+			return fmt.Errorf("%w: it is %s", ErrGroupOrderClosed, group.Status)
+		}
+		return change(group, member)
+	})
+}
+
+// Join adds the user to the group order with the join code
+func (s *GroupOrderService) Join(ctx context.Context, userID, code, name string) (*GroupOrderResponse, error) {
+	id, err := s.cache.Client().Get(ctx, groupOrderCodeKey(code)).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("%w: unknown join code", ErrGroupOrderNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	member, err := s.newMember(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	joined := false
+	group, err := s.update(ctx, id, func(group *GroupOrder) error {
+		if group.member(userID) != nil {
+			return nil
+		}
+		if group.Status != GroupOrderOpen {
+			return fmt.Errorf("%w: it is %s", ErrGroupOrderClosed, group.Status)
+		}
+		if len(group.Members) >= groupOrderMaxMembers {
+			return ErrGroupOrderFull
+		}
+		group.Members = append(group.Members, member)
+		joined = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.respondAndPublish(ctx, group, "member_joined", userID, joined)
+}
+
+// Get returns the group order to one of its members
+func (s *GroupOrderService) Get(ctx context.Context, userID, id string) (*GroupOrderResponse, error) {
+	group, err := s.load(ctx, s.cache.Client(), id)
+	if err != nil {
+		return nil, err
+	}
+	if group.member(userID) == nil {
+		return nil, ErrGroupOrderNotFound
+	}
+	return s.response(ctx, group)
+}
+
+// AddItem adds a product to the member's items, validated like a cart item
+func (s *GroupOrderService) AddItem(ctx context.Context, userID, id string, req *AddToCartRequest) (*GroupOrderResponse, error) {
+	group, err := s.load(ctx, s.cache.Client(), id)
+	if err != nil {
+		return nil, err
+	}
+	newItem, _, err := s.cartService.newCartItem(ctx, group.RestaurantID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err = s.updateOpen(ctx, userID, id, func(group *GroupOrder, member *GroupOrderMember) error {
+		member.Items = addCartItem(member.Items, newItem)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.respondAndPublish(ctx, group, "items_updated", userID, true)
+}
+
+// UpdateItem sets the quantity of one of the member's lines, removing it at zero
+func (s *GroupOrderService) UpdateItem(ctx context.Context, userID, id, itemKey string, quantity int) (*GroupOrderResponse, error) {
+	if quantity < 0 {
+		return nil, fmt.Errorf("%w: quantity cannot be negative", ErrGroupOrderInvalid)
+	}
+	group, err := s.updateOpen(ctx, userID, id, func(group *GroupOrder, member *GroupOrderMember) error {
+		member.Items = setCartItemQuantity(member.Items, itemKey, quantity)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.respondAndPublish(ctx, group, "items_updated", userID, true)
+}
+
+// Leave removes a member and their items. The host cannot leave; they cancel instead.
+func (s *GroupOrderService) Leave(ctx context.Context, userID, id string) error {
+	group, err := s.updateOpen(ctx, userID, id, func(group *GroupOrder, member *GroupOrderMember) error {
+		if userID == group.HostUserID {
+			return fmt.Errorf("%w: the host cancels the group order instead of leaving", ErrGroupOrderInvalid)
+		}
+		for i, m := range group.Members {
+			if m == member {
+				group.Members = append(group.Members[:i], group.Members[i+1:]...)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.respondAndPublish(ctx, group, "member_left", userID, true)
+	return err
+}
+
+// Cancel ends an open group order without ordering
+func (s *GroupOrderService) Cancel(ctx context.Context, userID, id string) error {
+	group, err := s.updateOpen(ctx, userID, id, func(group *GroupOrder, member *GroupOrderMember) error {
+		if userID != group.HostUserID {
+			return ErrGroupOrderHostOnly
+		}
+		group.Status = GroupOrderCancelled
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.respondAndPublish(ctx, group, "cancelled", userID, true)
+	return err
+}
+
+// GetBillSummary prices the combined items for the host's delivery address and splits the
+// total between members. The host's own cart is left as it is. Members receive the summary over
+// the socket.
+func (s *GroupOrderService) GetBillSummary(ctx context.Context, userID, id, addressID string) (*GroupBillSummaryResponse, error) {
+	group, err := s.load(ctx, s.cache.Client(), id)
+	if err != nil {
+		return nil, err
+	}
+	if group.member(userID) == nil {
+		return nil, ErrGroupOrderNotFound
+	}
+	if userID != group.HostUserID {
+		return nil, ErrGroupOrderHostOnly
+	}
+	if group.Status != GroupOrderOpen {
+		return nil, fmt.Errorf("%w: it is %s", ErrGroupOrderClosed, group.Status)
+	}
+
+	bill, err := s.billSummary(ctx, group, addressID)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(ctx, group.ID, GroupOrderEvent{Type: "bill_summary", UserID: userID, Bill: bill, Timestamp: time.Now()})
+	return bill, nil
+}
+
+// groupCart is a cart holding the members' combined items, kept apart from the host's own cart
+// for the restaurant
+func groupCart(group *GroupOrder) (*models.Cart, error) {
+	hostID, err := uuid.Parse(group.HostUserID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	restaurantID, err := uuid.Parse(group.RestaurantID)
+	if err != nil {
+		return nil, errors.New("invalid restaurant ID")
+	}
+
+	var items []models.CartItem
+	for _, member := range group.Members {
+		for _, item := range member.Items {
+			items = addCartItem(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return nil, errors.New("cart is empty")
+	}
+
+	now := time.Now()
+	return &models.Cart{
+		UserID:       hostID,
+		RestaurantID: restaurantID,
+		Items:        models.EncodeCartItems(items),
+		Status:       "ordered",
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}, nil
+}
+
+func (s *GroupOrderService) billSummary(ctx context.Context, group *GroupOrder, addressID string) (*GroupBillSummaryResponse, error) {
+	cart, err := groupCart(group)
+	if err != nil {
+		return nil, err
+	}
+	bill, err := s.cartService.billSummaryFor(ctx, cart, OrderTypeDelivery, addressID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GroupBillSummaryResponse{BillSummaryResponse: bill}
+	var allocated models.Money
+	for i, member := range group.Members {
+		_, subTotal, err := priceCartItems(ctx, s.cartService.productRepo, member.Items)
+		if err != nil {
+			return nil, err
+		}
+		share := GroupMemberShare{UserID: member.UserID, Name: member.Name, SubTotal: subTotal}
+		if bill.SubTotal > 0 {
+			share.Share = bill.TotalAmount.Scale(float64(subTotal) / float64(bill.SubTotal))
+		}
+		// The last member takes the rounding difference so shares add up to the total
+		if i == len(group.Members)-1 && bill.SubTotal > 0 {
+			share.Share = bill.TotalAmount - allocated
+		}
+		allocated += share.Share
+		response.Members = append(response.Members, share)
+	}
+	return response, nil
+}
+
+// Checkout places the group's order for the host with a single payment. Members cannot change
+// items while it runs; if it fails the group order opens again.
+func (s *GroupOrderService) Checkout(ctx context.Context, userID, id, addressID string) (*CheckoutResponse, error) {
+	group, err := s.updateOpen(ctx, userID, id, func(group *GroupOrder, member *GroupOrderMember) error {
+		if userID != group.HostUserID {
+			return ErrGroupOrderHostOnly
+		}
+		group.Status = GroupOrderCheckingOut
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.respondAndPublish(ctx, group, "checkout_started", userID, true)
+
+	checkout, err := s.checkout(ctx, group, addressID)
+	if err != nil {
+		reopened, updateErr := s.update(ctx, id, func(group *GroupOrder) error {
+			group.Status = GroupOrderOpen
+			return nil
+		})
+		if updateErr != nil {
+			log.Printf("Failed to reopen group order %s: %v", id, updateErr)
+		} else {
+			s.respondAndPublish(ctx, reopened, "items_updated", userID, true)
+		}
+		return nil, err
+	}
+
+	group, err = s.update(ctx, id, func(group *GroupOrder) error {
+		group.Status = GroupOrderCheckedOut
+		group.OrderID = checkout.OrderID
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to mark group order %s checked out: %v", id, err)
+		return checkout, nil
+	}
+	s.respondAndPublish(ctx, group, "checked_out", userID, true)
+	return checkout, nil
+}
+
+// checkout orders the combined items from a cart of their own, which the order keeps, rather
+// than the host's cart for the restaurant
+func (s *GroupOrderService) checkout(ctx context.Context, group *GroupOrder, addressID string) (*CheckoutResponse, error) {
+	cart, err := groupCart(group)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cartService.cartRepo.Create(ctx, cart); err != nil {
+		return nil, fmt.Errorf("failed to create group order cart: %v", err)
+	}
+
+	checkout, err := s.cartService.checkoutCart(ctx, cart, OrderTypeDelivery, addressID, "", "")
+	if err != nil {
+		if deleteErr := s.cartService.cartRepo.Delete(ctx, cart.ID); deleteErr != nil {
+			log.Printf("Failed to delete cart %s of group order %s: %v", cart.ID, group.ID, deleteErr)
+		}
+		return nil, err
+	}
+	return checkout, nil
+}
+
+// response prices each member's items at current prices
+func (s *GroupOrderService) response(ctx context.Context, group *GroupOrder) (*GroupOrderResponse, error) {
+	response := &GroupOrderResponse{
+		ID:           group.ID,
+		Code:         group.Code,
+		HostUserID:   group.HostUserID,
+		RestaurantID: group.RestaurantID,
+		Status:       group.Status,
+		OrderID:      group.OrderID,
+		Members:      make([]GroupOrderMemberResponse, 0, len(group.Members)),
+		ExpiresAt:    group.UpdatedAt.Add(groupOrderTTL),
+	}
+	for _, member := range group.Members {
+		lines, subTotal, err := priceCartItems(ctx, s.cartService.productRepo, member.Items)
+		if err != nil {
+			return nil, err
+		}
+		if lines == nil {
+			lines = []CartItemResponse{}
+		}
+		response.Members = append(response.Members, GroupOrderMemberResponse{
+			UserID:   member.UserID,
+			Name:     member.Name,
+			IsHost:   member.UserID == group.HostUserID,
+			Items:    lines,
+			SubTotal: subTotal,
+		})
+		response.SubTotal += subTotal
+	}
+	return response, nil
+}
+
+// respondAndPublish builds the group order response and, when something changed, sends it to
+// the members' sockets
+func (s *GroupOrderService) respondAndPublish(ctx context.Context, group *GroupOrder, eventType, userID string, changed bool) (*GroupOrderResponse, error) {
+	response, err := s.response(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		s.publish(ctx, group.ID, GroupOrderEvent{Type: eventType, UserID: userID, Group: response, Timestamp: time.Now()})
+	}
+	return response, nil
+}
+
+func (s *GroupOrderService) publish(ctx context.Context, id string, event GroupOrderEvent) {
+	if err := s.cache.Publish(ctx, groupOrderChannel(id), event); err != nil {
+		log.Printf("Failed to publish group order event for %s: %v", id, err)
+	}
+}
+
+// Subscribe streams a group order's events to one of its members until ctx is cancelled
+func (s *GroupOrderService) Subscribe(ctx context.Context, userID, id string) (<-chan GroupOrderEvent, error) {
+	if _, err := s.Get(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	events := make(chan GroupOrderEvent)
+	pubsub := s.cache.Subscribe(ctx, groupOrderChannel(id))
+
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var event GroupOrderEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// IsFinalGroupOrderStatus reports whether a group order can no longer change
+func IsFinalGroupOrderStatus(status string) bool {
+	return status == GroupOrderCheckedOut || status == GroupOrderCancelled
+}
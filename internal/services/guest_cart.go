@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/apperr"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+const (
+	// GuestTokenHeader carries the guest token on cart requests made before login
+	GuestTokenHeader = "X-Guest-Token"
+
+	// guestCartTTL is how long a guest cart is kept after its last change
+	guestCartTTL = 7 * 24 * time.Hour
+)
+
+var ErrGuestTokenInvalid = apperr.Unauthorized("guest_token_invalid", "invalid guest token")
+
+var guestTokenPattern = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+// guestCart holds a signed-out visitor's carts in Redis, keyed by restaurant ID. As for users,
+// the cart changed or switched to last is the current one.
+type guestCart struct {
+	Carts map[string]*guestRestaurantCart `json:"carts"`
+}
+
+type guestRestaurantCart struct {
+	Items     []models.CartItem `json:"items"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+type GuestTokenResponse struct {
+	GuestToken string `json:"guest_token"`
+	ExpiresIn  int    `json:"expires_in"` // seconds without a cart change before the guest cart is dropped
+}
+
+func guestCartKey(token string) string {
+	return "guest_cart:" + token
+}
+
+// NewGuestToken issues a token identifying a signed-out visitor's carts until they log in
+func (s *CartService) NewGuestToken() (*GuestTokenResponse, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate guest token: %v", err)
+	}
+	return &GuestTokenResponse{
+		GuestToken: hex.EncodeToString(raw),
+		ExpiresIn:  int(guestCartTTL.Seconds()),
+	}, nil
+}
+
+func (s *CartService) loadGuestCart(ctx context.Context, client redis.Cmdable, token string) (*guestCart, error) {
+	if !guestTokenPattern.MatchString(token) {
+		return nil, ErrGuestTokenInvalid
+	}
+
+	cart := &guestCart{Carts: make(map[string]*guestRestaurantCart)}
+	data, err := client.Get(ctx, guestCartKey(token)).Bytes()
+	if err == redis.Nil {
+		return cart, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cart); err != nil {
+		return nil, fmt.Errorf("failed to decode guest cart: %v", err)
+	}
+	if cart.Carts == nil {
+		cart.Carts = make(map[string]*guestRestaurantCart)
+	}
+	return cart, nil
+}
+
+// updateGuestCart applies change to the guest's carts and stores them, retrying when another
+// request changed them in between
+func (s *CartService) updateGuestCart(ctx context.Context, token string, change func(cart *guestCart) error) error {
+	client := s.cache.Client()
+	key := guestCartKey(token)
+
+	for attempt := 0; attempt <= maxConflictRetries; attempt++ {
+		err := client.Watch(ctx, func(tx *redis.Tx) error {
+			cart, err := s.loadGuestCart(ctx, tx, token)
+			if err != nil {
+				return err
+			}
+			if err := change(cart); err != nil {
+				return err
+			}
+			data, err := json.Marshal(cart)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, data, guestCartTTL)
+				return nil
+			})
+			return err
+		}, key)
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+	return ErrConcurrentUpdate
+}
+
+// guestCartResponse prices a guest's cart for a restaurant like a user cart. The cart has no ID
+// or user until it is merged on login.
+func (s *CartService) guestCartResponse(ctx context.Context, restaurantID string, entry *guestRestaurantCart) (*CartResponse, error) {
+	restUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, errors.New("invalid restaurant ID")
+	}
+
+	cart := &models.Cart{
+		RestaurantID: restUUID,
+		Items:        models.EncodeCartItems(nil),
+		Status:       "guest",
+	}
+	if entry != nil {
+		cart.Items = models.EncodeCartItems(entry.Items)
+		cart.CreatedAt = entry.UpdatedAt
+		cart.UpdatedAt = entry.UpdatedAt
+	}
+	return s.buildCartResponse(ctx, cart)
+}
+
+// current returns the guest's current cart and its restaurant ID
+func (c *guestCart) current() (string, *guestRestaurantCart) {
+	var currentID string
+	var current *guestRestaurantCart
+	for restaurantID, entry := range c.Carts {
+		if current == nil || entry.UpdatedAt.After(current.UpdatedAt) {
+			currentID, current = restaurantID, entry
+		}
+	}
+	return currentID, current
+}
+
+// GetGuestCart returns the guest's cart for the restaurant, empty if there is none, or the
+// current cart when no restaurant is given
+func (s *CartService) GetGuestCart(ctx context.Context, token, restaurantID string) (*CartResponse, error) {
+	cart, err := s.loadGuestCart(ctx, s.cache.Client(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	if restaurantID == "" {
+		currentID, current := cart.current()
+		if current == nil {
+			return nil, ErrCartNotFound
+		}
+		return s.guestCartResponse(ctx, currentID, current)
+	}
+	return s.guestCartResponse(ctx, restaurantID, cart.Carts[restaurantID])
+}
+
+// ListGuestCarts returns every cart of the guest, the current one first
+func (s *CartService) ListGuestCarts(ctx context.Context, token string) (*CartListResponse, error) {
+	cart, err := s.loadGuestCart(ctx, s.cache.Client(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	restaurantIDs := make([]string, 0, len(cart.Carts))
+	for restaurantID := range cart.Carts {
+		restaurantIDs = append(restaurantIDs, restaurantID)
+	}
+	sort.Slice(restaurantIDs, func(i, j int) bool {
+		return cart.Carts[restaurantIDs[i]].UpdatedAt.After(cart.Carts[restaurantIDs[j]].UpdatedAt)
+	})
+
+	response := &CartListResponse{Carts: make([]CartResponse, 0, len(restaurantIDs))}
+	for _, restaurantID := range restaurantIDs {
+		cartResponse, err := s.guestCartResponse(ctx, restaurantID, cart.Carts[restaurantID])
+		if err != nil {
+			return nil, err
+		}
+		response.Carts = append(response.Carts, *cartResponse)
+	}
+	if len(response.Carts) > 0 {
+		response.CurrentRestaurantID = &response.Carts[0].Cart.RestaurantID
+	}
+	return response, nil
+}
+
+// SwitchGuestCart makes the guest's cart for the restaurant the current one
+func (s *CartService) SwitchGuestCart(ctx context.Context, token, restaurantID string) (*CartResponse, error) {
+	if _, err := uuid.Parse(restaurantID); err != nil {
+		return nil, errors.New("invalid restaurant ID")
+	}
+
+	var entry *guestRestaurantCart
+	err := s.updateGuestCart(ctx, token, func(cart *guestCart) error {
+		entry = cart.Carts[restaurantID]
+		if entry == nil {
+			entry = &guestRestaurantCart{}
+			cart.Carts[restaurantID] = entry
+		}
+		entry.UpdatedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.guestCartResponse(ctx, restaurantID, entry)
+}
+
+// AddToGuestCart adds the product to the guest's cart for its restaurant, which becomes the
+// current cart
+func (s *CartService) AddToGuestCart(ctx context.Context, token, restaurantID string, req *AddToCartRequest) (*CartResponse, error) {
+	newItem, restaurantID, err := s.newCartItem(ctx, restaurantID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *guestRestaurantCart
+	err = s.updateGuestCart(ctx, token, func(cart *guestCart) error {
+		entry = cart.Carts[restaurantID]
+		if entry == nil {
+			entry = &guestRestaurantCart{}
+			cart.Carts[restaurantID] = entry
+		}
+		entry.Items = addCartItem(entry.Items, newItem)
+		entry.UpdatedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.guestCartResponse(ctx, restaurantID, entry)
+}
+
+// UpdateGuestCartItem sets the quantity of a line in the guest's cart, removing it at zero
+func (s *CartService) UpdateGuestCartItem(ctx context.Context, token string, req *UpdateCartItemRequest) (*CartResponse, error) {
+	key := req.ItemKey
+	if key == "" {
+		key = models.CartItem{ProductID: req.ProductID, VariantID: req.VariantID, AddonIDs: normalizeAddonIDs(req.AddonIDs), OptionIDs: normalizeAddonIDs(req.OptionIDs)}.Key()
+	}
+
+	restaurantID := req.RestaurantID
+	var entry *guestRestaurantCart
+	err := s.updateGuestCart(ctx, token, func(cart *guestCart) error {
+		if restaurantID == "" {
+			restaurantID, entry = cart.current()
+		} else {
+			entry = cart.Carts[restaurantID]
+		}
+		if entry == nil {
+			return ErrCartNotFound
+		}
+		entry.Items = setCartItemQuantity(entry.Items, key, req.Quantity)
+		entry.UpdatedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.guestCartResponse(ctx, restaurantID, entry)
+}
+
+// RemoveFromGuestCart removes a line from the guest's cart by its item key or product ID
+func (s *CartService) RemoveFromGuestCart(ctx context.Context, token, restaurantID, itemKey string) (*CartResponse, error) {
+	return s.UpdateGuestCartItem(ctx, token, removeCartItemRequest(restaurantID, itemKey))
+}
+
+// ClearGuestCart drops the guest's cart for the restaurant, or the current cart
+func (s *CartService) ClearGuestCart(ctx context.Context, token, restaurantID string) error {
+	return s.updateGuestCart(ctx, token, func(cart *guestCart) error {
+		if restaurantID == "" {
+			restaurantID, _ = cart.current()
+		}
+		if _, ok := cart.Carts[restaurantID]; !ok {
+			return ErrCartNotFound
+		}
+		delete(cart.Carts, restaurantID)
+		return nil
+	})
+}
+
+// MergeGuestCart moves a guest's carts into the user's carts after login. Lines already in the
+// user's cart for a restaurant get the guest quantity added, and the guest's current cart
+// becomes the user's current cart.
+func (s *CartService) MergeGuestCart(ctx context.Context, token string, userID uuid.UUID) error {
+	cart, err := s.loadGuestCart(ctx, s.cache.Client(), token)
+	if err != nil {
+		return err
+	}
+
+	// Oldest first, so the guest's current cart is saved last and stays current
+	restaurantIDs := make([]string, 0, len(cart.Carts))
+	for restaurantID := range cart.Carts {
+		restaurantIDs = append(restaurantIDs, restaurantID)
+	}
+	sort.Slice(restaurantIDs, func(i, j int) bool {
+		return cart.Carts[restaurantIDs[i]].UpdatedAt.Before(cart.Carts[restaurantIDs[j]].UpdatedAt)
+	})
+
+	for _, restaurantID := range restaurantIDs {
+		guestItems := cart.Carts[restaurantID].Items
+		err := retryOnConflict(ctx, func() error {
+			userCart, err := s.getOrCreateCart(ctx, userID.String(), restaurantID)
+			if err != nil {
+				return err
+			}
+			items := models.DecodeCartItems(userCart.Items)
+			for _, item := range guestItems {
+				items = addCartItem(items, item)
+			}
+			userCart.Items = models.EncodeCartItems(items)
+			_, err = s.saveCart(ctx, userCart)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to merge guest cart for restaurant %s: %w", restaurantID, err)
+		}
+	}
+
+	if err := s.cache.Delete(ctx, guestCartKey(token)); err != nil {
+		log.Printf("Failed to delete merged guest cart: %v", err)
+	}
+	return nil
+}
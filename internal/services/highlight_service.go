@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/cache"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Longest time a public highlighted product list is served from cache
+const highlightCacheTTL = 5 * time.Minute
+
+var highlightTypes = map[string]bool{
+	"featured":    true,
+	"bestseller":  true,
+	"recommended": true,
+}
+
+type HighlightService struct {
+	highlightRepo repositories.HighlightProductRepository
+	productRepo   repositories.ProductRepository
+	cache         *cache.RedisCache
+}
+
+func NewHighlightService(
+	highlightRepo repositories.HighlightProductRepository,
+	productRepo repositories.ProductRepository,
+	cache *cache.RedisCache,
+) *HighlightService {
+	return &HighlightService{
+		highlightRepo: highlightRepo,
+		productRepo:   productRepo,
+		cache:         cache,
+	}
+}
+
+type CreateHighlightRequest struct {
+	ProductID     string    `json:"product_id" binding:"required"`
+	HighlightType string    `json:"highlight_type" binding:"required"` // featured, bestseller, recommended
+	StartDate     time.Time `json:"start_date" binding:"required"`
+	EndDate       time.Time `json:"end_date" binding:"required"`
+	SortOrder     int       `json:"sort_order"`
+	IsActive      *bool     `json:"is_active"`
+}
+
+type UpdateHighlightRequest struct {
+	HighlightType *string    `json:"highlight_type"`
+	StartDate     *time.Time `json:"start_date"`
+	EndDate       *time.Time `json:"end_date"`
+	SortOrder     *int       `json:"sort_order"`
+	IsActive      *bool      `json:"is_active"`
+}
+
+func highlightCacheKey(restaurantID, highlightType string) string {
+	return fmt.Sprintf("highlights:%s:%s", restaurantID, highlightType)
+}
+
+// CreateHighlight schedules one of the restaurant's products as featured, bestseller or recommended
+func (s *HighlightService) CreateHighlight(ctx context.Context, restaurantID string, req *CreateHighlightRequest) (*models.HighlightProduct, error) {
+	productID, err := primitive.ObjectIDFromHex(req.ProductID)
+	if err != nil {
+		return nil, errors.New("invalid product ID")
+	}
+
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil || product.RestaurantID != restaurantID {
+		return nil, errors.New("product not found")
+	}
+
+	highlight := &models.HighlightProduct{
+		ProductID:     productID,
+		RestaurantID:  restaurantID,
+		HighlightType: req.HighlightType,
+		StartDate:     req.StartDate,
+		EndDate:       req.EndDate,
+		SortOrder:     req.SortOrder,
+		IsActive:      true,
+	}
+	if req.IsActive != nil {
+		highlight.IsActive = *req.IsActive
+	}
+
+	if err := validateHighlight(highlight); err != nil {
+		return nil, err
+	}
+
+	if err := s.highlightRepo.Create(ctx, highlight); err != nil {
+		return nil, fmt.Errorf("failed to create highlight: %v", err)
+	}
+
+	s.clearHighlightCache(ctx, highlight)
+	return highlight, nil
+}
+
+// GetHighlight returns a highlight owned by the restaurant
+func (s *HighlightService) GetHighlight(ctx context.Context, restaurantID, highlightID string) (*models.HighlightProduct, error) {
+	objectID, err := primitive.ObjectIDFromHex(highlightID)
+	if err != nil {
+		return nil, errors.New("invalid highlight ID")
+	}
+
+	highlight, err := s.highlightRepo.GetByID(ctx, objectID)
+	if err != nil || highlight.RestaurantID != restaurantID {
+		return nil, errors.New("highlight not found")
+	}
+
+	return highlight, nil
+}
+
+// ListHighlights returns the restaurant's highlights for management, optionally filtered by type
+func (s *HighlightService) ListHighlights(ctx context.Context, restaurantID, highlightType string, page, limit int) ([]models.HighlightProduct, error) {
+	if highlightType != "" && !highlightTypes[highlightType] {
+		return nil, fmt.Errorf("invalid highlight type: %s", highlightType)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	highlights, err := s.highlightRepo.List(ctx, restaurantID, highlightType, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list highlights: %v", err)
+	}
+
+	return highlights, nil
+}
+
+func (s *HighlightService) UpdateHighlight(ctx context.Context, restaurantID, highlightID string, req *UpdateHighlightRequest) (*models.HighlightProduct, error) {
+	highlight, err := s.GetHighlight(ctx, restaurantID, highlightID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The old list must be evicted as well if the highlight changes type
+	s.clearHighlightCache(ctx, highlight)
+
+	if req.HighlightType != nil {
+		highlight.HighlightType = *req.HighlightType
+	}
+	if req.StartDate != nil {
+		highlight.StartDate = *req.StartDate
+	}
+	if req.EndDate != nil {
+		highlight.EndDate = *req.EndDate
+	}
+	if req.SortOrder != nil {
+		highlight.SortOrder = *req.SortOrder
+	}
+	if req.IsActive != nil {
+		highlight.IsActive = *req.IsActive
+	}
+
+	if err := validateHighlight(highlight); err != nil {
+		return nil, err
+	}
+
+	if err := s.highlightRepo.Update(ctx, highlight); err != nil {
+		return nil, fmt.Errorf("failed to update highlight: %v", err)
+	}
+
+	s.clearHighlightCache(ctx, highlight)
+	return highlight, nil
+}
+
+func (s *HighlightService) DeleteHighlight(ctx context.Context, restaurantID, highlightID string) error {
+	highlight, err := s.GetHighlight(ctx, restaurantID, highlightID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.highlightRepo.Delete(ctx, highlight.ID); err != nil {
+		return fmt.Errorf("failed to delete highlight: %v", err)
+	}
+
+	s.clearHighlightCache(ctx, highlight)
+	return nil
+}
+
+// GetHighlightedProducts returns the available products currently highlighted with the given type,
+// in highlight order. A product highlighted more than once is listed once.
+func (s *HighlightService) GetHighlightedProducts(ctx context.Context, restaurantID, highlightType string) ([]models.Product, error) {
+	if !highlightTypes[highlightType] {
+		return nil, fmt.Errorf("invalid highlight type: %s", highlightType)
+	}
+
+	cacheKey := highlightCacheKey(restaurantID, highlightType)
+	var cached []models.Product
+	if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+		return cached, nil
+	}
+
+	now := time.Now()
+	highlighted, err := s.highlightRepo.GetActiveProducts(ctx, restaurantID, highlightType, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get highlighted products: %v", err)
+	}
+
+	products := []models.Product{}
+	seen := make(map[primitive.ObjectID]bool)
+	for _, product := range highlighted {
+		if seen[product.ID] {
+			continue
+		}
+		seen[product.ID] = true
+		products = append(products, product)
+	}
+
+	// Expire the cache no later than the next highlight ends, checked against the
+	// management list since the joined products don't carry highlight dates
+	ttl := highlightCacheTTL
+	if active, err := s.highlightRepo.List(ctx, restaurantID, highlightType, 100, 0); err == nil {
+		for _, highlight := range active {
+			if !highlight.IsActive {
+				continue
+			}
+			if remaining := highlight.EndDate.Sub(now); remaining > 0 && remaining < ttl {
+				ttl = remaining
+			}
+			if upcoming := highlight.StartDate.Sub(now); upcoming > 0 && upcoming < ttl {
+				ttl = upcoming
+			}
+		}
+	}
+
+	// Tagged with the restaurant's products so price and availability changes show up
+	s.cache.SetWithTags(ctx, cacheKey, products, ttl, restaurantProductsTag(restaurantID))
+
+	return products, nil
+}
+
+func (s *HighlightService) clearHighlightCache(ctx context.Context, highlight *models.HighlightProduct) {
+	s.cache.Delete(ctx, highlightCacheKey(highlight.RestaurantID, highlight.HighlightType))
+}
+
+func validateHighlight(highlight *models.HighlightProduct) error {
+	if !highlightTypes[highlight.HighlightType] {
+		return fmt.Errorf("invalid highlight type: %s", highlight.HighlightType)
+	}
+	if !highlight.EndDate.After(highlight.StartDate) {
+		return errors.New("end date must be after start date")
+	}
+	return nil
+}
@@ -0,0 +1,381 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/auth"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownIdentityProvider = apperr.Validation("identity_provider_unsupported", "unsupported sign-in provider")
+	ErrIdentityLinkedElsewhere = apperr.Conflict("identity_linked_elsewhere", "this account is already linked to another user")
+)
+
+// IdentityService signs users in through external identity providers. An external account
+// is linked to one user; on its first sign-in it is matched to an existing user by verified
+// email or phone, so social login never creates a duplicate of an OTP or password account.
+type IdentityService struct {
+	identityRepo   repositories.UserIdentityRepository
+	userRepo       repositories.UserRepository
+	auditRepo      repositories.AuditLogRepository
+	sessionService *SessionService
+	providers      map[string]auth.IdentityProvider
+}
+
+func NewIdentityService(
+	identityRepo repositories.UserIdentityRepository,
+	userRepo repositories.UserRepository,
+	auditRepo repositories.AuditLogRepository,
+	sessionService *SessionService,
+	providers []auth.IdentityProvider,
+) *IdentityService {
+	byName := make(map[string]auth.IdentityProvider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+	return &IdentityService{
+		identityRepo:   identityRepo,
+		userRepo:       userRepo,
+		auditRepo:      auditRepo,
+		sessionService: sessionService,
+		providers:      byName,
+	}
+}
+
+type SocialLoginRequest struct {
+	Token        string `json:"token" binding:"required"` // ID token, or the authorization code for truecaller
+	CodeVerifier string `json:"code_verifier"`            // PKCE verifier, truecaller only
+	Role         string `json:"role" binding:"required"`
+	RestaurantID string `json:"restaurant_id"` // Required only for customers
+	Name         string `json:"name"`          // Apple hands the name to the app on first sign-in only, not in the token
+	DeviceInfo
+}
+
+type LinkIdentityRequest struct {
+	Token        string `json:"token" binding:"required"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// Login verifies the provider credential and signs in the linked user, linking or creating one on first use
+func (s *IdentityService) Login(ctx context.Context, providerName string, req *SocialLoginRequest) (*AuthResponse, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownIdentityProvider
+	}
+
+	var restaurantID *uuid.UUID
+	if req.Role == "customer" && req.RestaurantID == "" {
+		return nil, errors.New("restaurant ID is required for customer login")
+	}
+	// Only customers are scoped to a restaurant; staff and admin identities are global
+	if req.Role == "customer" {
+		parsedRestaurantID, err := uuid.Parse(req.RestaurantID)
+		if err != nil {
+			return nil, errors.New("invalid restaurant ID")
+		}
+		restaurantID = &parsedRestaurantID
+	}
+
+	identity, err := provider.Verify(ctx, auth.IdentityCredential{Token: req.Token, CodeVerifier: req.CodeVerifier})
+	if err != nil {
+		return nil, err
+	}
+	if identity.Name == "" {
+		identity.Name = strings.TrimSpace(req.Name)
+	}
+
+	var user *models.User
+	link, err := s.identityRepo.GetByProviderSubject(ctx, identity.Provider, identity.Subject, restaurantID)
+	if err == nil {
+		user, err = s.userRepo.GetByID(ctx, link.UserID)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		if err := s.identityRepo.TouchLastLogin(ctx, link.ID); err != nil {
+			log.Printf("Failed to update identity %s last login: %v", link.ID, err)
+		}
+	} else {
+		user, err = s.findOrCreateUser(ctx, identity, req.Role, restaurantID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if user.Role != req.Role {
+		return nil, errors.New("invalid role for this login")
+	}
+	if user.Status != "active" {
+		return nil, errors.New("account is not active")
+	}
+
+	tokenPair, err := s.sessionService.StartSession(ctx, user, req.DeviceInfo, identity.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResponse{
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    3600, // 1 hour in seconds
+		User:         *user,
+	}, nil
+}
+
+// findOrCreateUser resolves the user for an external account seen for the first time. A
+// verified phone or email that already belongs to a user merges the account into that user;
+// otherwise customers get a new account and other roles must register first.
+func (s *IdentityService) findOrCreateUser(ctx context.Context, identity *auth.Identity, role string, restaurantID *uuid.UUID) (*models.User, error) {
+	user, matchedBy := s.matchExistingUser(ctx, identity, restaurantID)
+
+	if user == nil && identity.Email != "" && !identity.EmailVerified {
+		// Linking on an unverified email would hand the account to whoever typed it in
+		if existing, _ := s.lookupUser(ctx, "email", identity.Email, restaurantID); existing != nil {
+			return nil, errors.New("an account with this email already exists. Please log in with OTP and link this sign-in method from your profile")
+		}
+	}
+
+	if user == nil {
+		if role != "customer" {
+			return nil, errors.New("user not found. Please register first for non-customer roles")
+		}
+
+		email := ""
+		if identity.EmailVerified {
+			email = identity.Email
+		}
+		name := identity.Name
+		if name == "" {
+			name = "User"
+		}
+		user = &models.User{
+			Name:         name,
+			Phone:        identity.Phone,
+			Email:        email,
+			PasswordHash: "", // No password for social login users
+			RestaurantID: restaurantID,
+			Role:         "customer",
+			Status:       "active",
+			IsVerified:   true, // The provider verified the account
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		}
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return nil, errors.New("failed to create user")
+		}
+		matchedBy = "new_account"
+	} else if user.Role == role {
+		s.mergeProfile(ctx, user, identity)
+	}
+
+	if user.Role != role {
+		return nil, errors.New("invalid role for this login")
+	}
+
+	if err := s.createLink(ctx, user, identity, matchedBy); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// matchExistingUser looks for a user owning the identity's verified phone, then its verified email
+func (s *IdentityService) matchExistingUser(ctx context.Context, identity *auth.Identity, restaurantID *uuid.UUID) (*models.User, string) {
+	if identity.Phone != "" {
+		if user, _ := s.lookupUser(ctx, "phone", identity.Phone, restaurantID); user != nil {
+			return user, "phone"
+		}
+	}
+	if identity.EmailVerified {
+		if user, _ := s.lookupUser(ctx, "email", identity.Email, restaurantID); user != nil {
+			return user, "email"
+		}
+	}
+	return nil, ""
+}
+
+func (s *IdentityService) lookupUser(ctx context.Context, field, value string, restaurantID *uuid.UUID) (*models.User, error) {
+	switch {
+	case field == "phone" && restaurantID != nil:
+		return s.userRepo.GetByPhoneAndRestaurant(ctx, value, *restaurantID)
+	case field == "phone":
+		return s.userRepo.GetByPhone(ctx, value)
+	case restaurantID != nil:
+		return s.userRepo.GetByEmailAndRestaurant(ctx, value, *restaurantID)
+	default:
+		return s.userRepo.GetByEmail(ctx, value)
+	}
+}
+
+// mergeProfile fills details the existing account is missing from the provider's verified data.
+// Values the user already has are never overwritten.
+func (s *IdentityService) mergeProfile(ctx context.Context, user *models.User, identity *auth.Identity) {
+	changed := false
+	if user.Email == "" && identity.EmailVerified {
+		user.Email = identity.Email
+		changed = true
+	}
+	if user.Phone == "" && identity.Phone != "" {
+		user.Phone = identity.Phone
+		changed = true
+	}
+	// OTP sign-up names customers after their phone number until they pick a name
+	if identity.Name != "" && (user.Name == "" || strings.HasPrefix(user.Name, "User-")) {
+		user.Name = identity.Name
+		changed = true
+	}
+	if !user.IsVerified {
+		user.IsVerified = true
+		changed = true
+	}
+	if !changed {
+		return
+	}
+
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		log.Printf("Failed to merge %s profile into user %s: %v", identity.Provider, user.ID, err)
+	}
+}
+
+func (s *IdentityService) createLink(ctx context.Context, user *models.User, identity *auth.Identity, matchedBy string) error {
+	now := time.Now()
+	link := &models.UserIdentity{
+		UserID:       user.ID,
+		Provider:     identity.Provider,
+		Subject:      identity.Subject,
+		RestaurantID: user.RestaurantID,
+		Email:        identity.Email,
+		LastLoginAt:  &now,
+	}
+	if user.Role != "customer" {
+		link.RestaurantID = nil
+	}
+	if err := s.identityRepo.Create(ctx, link); err != nil {
+		return fmt.Errorf("failed to link %s account: %v", identity.Provider, err)
+	}
+
+	userID := user.ID
+	entry := &models.AuditLog{
+		EntityType:  "user",
+		EntityID:    userID.String(),
+		Action:      "identity_linked",
+		PerformedBy: &userID,
+		Metadata: models.JSONB{
+			"provider":   identity.Provider,
+			"matched_by": matchedBy,
+		},
+	}
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write audit entry for user %s: %v", userID, err)
+	}
+	return nil
+}
+
+// LinkIdentity adds a sign-in method to the signed-in user's account
+func (s *IdentityService) LinkIdentity(ctx context.Context, userID, providerName string, req *LinkIdentityRequest) (*models.UserIdentity, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownIdentityProvider
+	}
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	identity, err := provider.Verify(ctx, auth.IdentityCredential{Token: req.Token, CodeVerifier: req.CodeVerifier})
+	if err != nil {
+		return nil, err
+	}
+
+	scope := user.RestaurantID
+	if user.Role != "customer" {
+		scope = nil
+	}
+	if existing, err := s.identityRepo.GetByProviderSubject(ctx, identity.Provider, identity.Subject, scope); err == nil {
+		if existing.UserID != user.ID {
+			return nil, ErrIdentityLinkedElsewhere
+		}
+		return existing, nil
+	}
+
+	s.mergeProfile(ctx, user, identity)
+	if err := s.createLink(ctx, user, identity, "manual"); err != nil {
+		return nil, err
+	}
+	return s.identityRepo.GetByProviderSubject(ctx, identity.Provider, identity.Subject, scope)
+}
+
+// ListIdentities returns the external accounts linked to the user
+func (s *IdentityService) ListIdentities(ctx context.Context, userID string) ([]models.UserIdentity, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	identities, err := s.identityRepo.GetByUserID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linked accounts: %v", err)
+	}
+	return identities, nil
+}
+
+// UnlinkIdentity removes a linked external account, unless it is the user's only way to sign in
+func (s *IdentityService) UnlinkIdentity(ctx context.Context, userID, identityID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+	id, err := uuid.Parse(identityID)
+	if err != nil {
+		return errors.New("invalid identity ID")
+	}
+
+	link, err := s.identityRepo.GetByID(ctx, id)
+	if err != nil || link.UserID != uid {
+		return errors.New("linked account not found")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, uid)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.Phone == "" && user.PasswordHash == "" {
+		identities, err := s.identityRepo.GetByUserID(ctx, uid)
+		if err != nil {
+			return fmt.Errorf("failed to get linked accounts: %v", err)
+		}
+		if len(identities) <= 1 {
+			return errors.New("cannot remove the only sign-in method. Add a phone number first")
+		}
+	}
+
+	if err := s.identityRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to unlink account: %v", err)
+	}
+
+	entry := &models.AuditLog{
+		EntityType:  "user",
+		EntityID:    uid.String(),
+		Action:      "identity_unlinked",
+		PerformedBy: &uid,
+		Metadata:    models.JSONB{"provider": link.Provider},
+	}
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write audit entry for user %s: %v", uid, err)
+	}
+	return nil
+}
@@ -0,0 +1,464 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/cache"
+	"golang-food-backend/pkg/messaging"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	StockAddition  = "addition"
+	StockDeduction = "deduction"
+	StockSet       = "set"
+	StockReserved  = "reserved"
+	StockReleased  = "released"
+
+	AuditProductAutoUnavailable = "auto_unavailable"
+	AuditProductAutoAvailable   = "auto_available"
+
+	// Reservations are remembered this long so cancellations and deliveries only release
+	// stock that was actually reserved, and redelivered events are applied once
+	inventoryReservationTTL = 30 * 24 * time.Hour
+	inventoryAuditLimit     = 50
+)
+
+type InventoryService struct {
+	inventoryRepo  repositories.InventoryRepository
+	productRepo    repositories.ProductRepository
+	orderRepo      repositories.OrderRepository
+	restaurantRepo repositories.RestaurantRepository
+	auditRepo      repositories.AuditLogRepository
+	cache          *cache.RedisCache
+	kafkaProducer  *messaging.KafkaProducer
+	kafkaBrokers   []string
+}
+
+func NewInventoryService(
+	inventoryRepo repositories.InventoryRepository,
+	productRepo repositories.ProductRepository,
+	orderRepo repositories.OrderRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	auditRepo repositories.AuditLogRepository,
+	cache *cache.RedisCache,
+	kafkaProducer *messaging.KafkaProducer,
+	kafkaBrokers []string,
+) *InventoryService {
+	return &InventoryService{
+		inventoryRepo:  inventoryRepo,
+		productRepo:    productRepo,
+		orderRepo:      orderRepo,
+		restaurantRepo: restaurantRepo,
+		auditRepo:      auditRepo,
+		cache:          cache,
+		kafkaProducer:  kafkaProducer,
+		kafkaBrokers:   kafkaBrokers,
+	}
+}
+
+type StockAdjustmentRequest struct {
+	Type     string `json:"type" binding:"required,oneof=addition deduction set"`
+	Quantity int    `json:"quantity" binding:"gte=0"`
+	Reason   string `json:"reason"`
+}
+
+type InventoryResponse struct {
+	Inventory   *models.Inventory `json:"inventory"`
+	Available   int               `json:"available"` // quantity not reserved by open orders
+	IsAvailable bool              `json:"is_available"`
+	OutOfStock  bool              `json:"out_of_stock"`
+	AuditLog    []models.AuditLog `json:"audit_log"`
+}
+
+// GetInventory returns a product's stock along with its availability changes
+func (s *InventoryService) GetInventory(ctx context.Context, restaurantID, productID string) (*InventoryResponse, error) {
+	product, err := s.getOwnProduct(ctx, restaurantID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory, err := s.inventoryRepo.GetByProductID(ctx, product.ID)
+	if err != nil {
+		return nil, errors.New("inventory not found")
+	}
+
+	auditLog, err := s.auditRepo.GetByEntity(ctx, "product", productID, inventoryAuditLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log: %v", err)
+	}
+
+	return &InventoryResponse{
+		Inventory:   inventory,
+		Available:   inventory.Quantity - inventory.ReservedQuantity,
+		IsAvailable: product.IsAvailable,
+		OutOfStock:  product.OutOfStock,
+		AuditLog:    auditLog,
+	}, nil
+}
+
+// AdjustStock records a restock, a deduction (wastage, manual sale) or a stock count
+func (s *InventoryService) AdjustStock(ctx context.Context, restaurantID, userID, productID string, req *StockAdjustmentRequest) (*InventoryResponse, error) {
+	product, err := s.getOwnProduct(ctx, restaurantID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.IsCombo() {
+		return nil, errors.New("a combo's stock is the stock of its items")
+	}
+
+	current, err := s.inventoryRepo.GetByProductID(ctx, product.ID)
+	if err != nil {
+		return nil, errors.New("inventory not found")
+	}
+
+	delta := req.Quantity
+	transactionType := req.Type
+	switch req.Type {
+	case StockDeduction:
+		if req.Quantity > current.Quantity {
+			return nil, fmt.Errorf("cannot deduct %d, only %d in stock", req.Quantity, current.Quantity)
+		}
+		delta = -req.Quantity
+	case StockSet:
+		delta = req.Quantity - current.Quantity
+		transactionType = StockAddition
+		if delta < 0 {
+			transactionType = StockDeduction
+		}
+	}
+
+	// Adjusting stock by hand opts the product into stock tracking
+	if !current.TrackStock {
+		current.TrackStock = true
+		if err := s.inventoryRepo.Update(ctx, current); err != nil {
+			return nil, fmt.Errorf("failed to enable stock tracking: %v", err)
+		}
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "manual " + req.Type
+	}
+
+	var performedBy *uuid.UUID
+	if userUUID, err := uuid.Parse(userID); err == nil {
+		performedBy = &userUUID
+	}
+
+	inventory, err := s.applyTransaction(ctx, product.ID, delta, 0, models.StockTransaction{
+		Type:      transactionType,
+		Quantity:  abs(delta),
+		Reason:    reason,
+		Reference: "manual",
+	}, performedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err = s.productRepo.GetByID(ctx, product.ID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	return &InventoryResponse{
+		Inventory:   inventory,
+		Available:   inventory.Quantity - inventory.ReservedQuantity,
+		IsAvailable: product.IsAvailable,
+		OutOfStock:  product.OutOfStock,
+	}, nil
+}
+
+func (s *InventoryService) getOwnProduct(ctx context.Context, restaurantID, productID string) (*models.Product, error) {
+	objectID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, errors.New("invalid product ID")
+	}
+
+	product, err := s.productRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	if product.RestaurantID != restaurantID {
+		return nil, errors.New("product does not belong to this restaurant")
+	}
+	return product, nil
+}
+
+// applyTransaction changes stock and then brings the availability of the product and the
+// combos that include it in line with it
+func (s *InventoryService) applyTransaction(ctx context.Context, productID primitive.ObjectID, quantityDelta, reservedDelta int, transaction models.StockTransaction, performedBy *uuid.UUID) (*models.Inventory, error) {
+	transaction.Timestamp = time.Now()
+
+	inventory, err := s.inventoryRepo.ApplyTransaction(ctx, productID, quantityDelta, reservedDelta, transaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update stock: %v", err)
+	}
+
+	if err := s.syncAvailability(ctx, inventory, performedBy); err != nil {
+		log.Printf("Failed to sync availability for product %s: %v", productID.Hex(), err)
+	}
+	syncCombos(ctx, s.productRepo, s.inventoryRepo, s.cache, productID)
+	return inventory, nil
+}
+
+// syncAvailability turns a product off when nothing unreserved is left and back on after a
+// restock. Products that were already turned off by hand are left alone, and only products
+// this watcher turned off are turned back on. Untracked products are never changed.
+func (s *InventoryService) syncAvailability(ctx context.Context, inventory *models.Inventory, performedBy *uuid.UUID) error {
+	if !inventory.TrackStock {
+		return nil
+	}
+
+	product, err := s.productRepo.GetByID(ctx, inventory.ProductID)
+	if err != nil {
+		return err
+	}
+
+	available := inventory.Quantity - inventory.ReservedQuantity
+	var action, eventType string
+	switch {
+	case available <= 0 && product.IsAvailable:
+		product.IsAvailable = false
+		product.OutOfStock = true
+		action, eventType = AuditProductAutoUnavailable, "out_of_stock"
+	case available > 0 && product.OutOfStock:
+		product.IsAvailable = true
+		product.OutOfStock = false
+		action, eventType = AuditProductAutoAvailable, "back_in_stock"
+	default:
+		return nil
+	}
+
+	if err := s.productRepo.Update(ctx, product); err != nil {
+		return err
+	}
+
+	productID := product.ID.Hex()
+	s.cache.InvalidateTags(ctx, restaurantProductsTag(product.RestaurantID), productCartsTag(productID))
+
+	entry := &models.AuditLog{
+		EntityType:  "product",
+		EntityID:    productID,
+		Action:      action,
+		PerformedBy: performedBy,
+		Metadata: models.JSONB{
+			"restaurant_id":     product.RestaurantID,
+			"quantity":          inventory.Quantity,
+			"reserved_quantity": inventory.ReservedQuantity,
+		},
+	}
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write audit entry for product %s: %v", productID, err)
+	}
+
+	envelope, err := messaging.Events.NewEnvelope(messaging.EventInventoryChanged, product.RestaurantID, messaging.InventoryChanged{
+		ProductID:    productID,
+		RestaurantID: product.RestaurantID,
+		Change:       eventType,
+		Available:    available,
+	})
+	if err == nil {
+		err = s.kafkaProducer.PublishEvent(ctx, "inventory_events", s.kafkaBrokers, productID, envelope)
+	}
+	if err != nil {
+		log.Printf("Failed to publish %s for product %s: %v", eventType, productID, err)
+	}
+
+	return nil
+}
+
+func inventoryReservationKey(orderID string) string {
+	return "inventory_reserved:" + orderID
+}
+
+// HandleOrderEvent is the Kafka handler for the order_events topic. Stock is reserved when
+// an order is created, released if it is cancelled and deducted once it is delivered.
+func (s *InventoryService) HandleOrderEvent(payload []byte) error {
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("invalid order event: %v", err)
+	}
+
+	ctx := context.Background()
+	switch event.Type {
+	case messaging.EventOrderCreated:
+		var created messaging.OrderCreated
+		if _, err := messaging.Events.Decode(payload, &created); err != nil {
+			return err
+		}
+		return s.reserveOrder(ctx, created.OrderID)
+	case messaging.EventOrderCancelled:
+		var cancelled messaging.OrderCancelled
+		if _, err := messaging.Events.Decode(payload, &cancelled); err != nil {
+			return err
+		}
+		return s.settleOrder(ctx, cancelled.OrderID, false)
+	case messaging.EventOrderCompleted:
+		var completed messaging.OrderCompleted
+		if _, err := messaging.Events.Decode(payload, &completed); err != nil {
+			return err
+		}
+		return s.settleOrder(ctx, completed.OrderID, true)
+	}
+	return nil
+}
+
+// HandleInventoryEvent is the Kafka handler for the inventory_events topic. The restaurant owner
+// is notified when a product sells out and when it is back in stock.
+func (s *InventoryService) HandleInventoryEvent(payload []byte) error {
+	var event messaging.InventoryChanged
+	if _, err := messaging.Events.Decode(payload, &event); err != nil {
+		return err
+	}
+
+	var title, message string
+	switch event.Change {
+	case "out_of_stock":
+		title, message = "Product sold out", "%s is out of stock and hidden from the menu until it is restocked"
+	case "back_in_stock":
+		title, message = "Product back in stock", "%s is back in stock and available to order again"
+	default:
+		return nil
+	}
+
+	ctx := context.Background()
+	productID, err := primitive.ObjectIDFromHex(event.ProductID)
+	if err != nil {
+		return nil // not a product we can look up; nothing to retry
+	}
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("failed to get product %s: %v", event.ProductID, err)
+	}
+	restaurantID, err := uuid.Parse(event.RestaurantID)
+	if err != nil {
+		return nil
+	}
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantID)
+	if err != nil {
+		return fmt.Errorf("failed to get restaurant %s: %v", event.RestaurantID, err)
+	}
+
+	notification := messaging.NotificationEvent{
+		Type:    "stock_alert",
+		UserID:  restaurant.OwnerID.String(),
+		Title:   title,
+		Message: fmt.Sprintf(message, product.Name),
+		Metadata: map[string]interface{}{
+			"restaurant_id": event.RestaurantID,
+			"product_id":    event.ProductID,
+			"available":     event.Available,
+		},
+	}
+	return s.kafkaProducer.SendMessage("notification_events", s.kafkaBrokers, restaurant.OwnerID.String(), notification)
+}
+
+// reserveOrder reserves stock for each line of a new order, and for each item of its combos
+func (s *InventoryService) reserveOrder(ctx context.Context, orderID string) error {
+	key := inventoryReservationKey(orderID)
+	if exists, _ := s.cache.Exists(ctx, key); exists {
+		return nil
+	}
+
+	lines, err := s.orderLines(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		productID, err := primitive.ObjectIDFromHex(line.ProductID)
+		if err != nil {
+			continue
+		}
+		_, err = s.applyTransaction(ctx, productID, 0, line.Quantity, models.StockTransaction{
+			Type:      StockReserved,
+			Quantity:  line.Quantity,
+			Reason:    "order placed",
+			Reference: orderID,
+		}, nil)
+		if err != nil {
+			log.Printf("Failed to reserve stock for product %s on order %s: %v", line.ProductID, orderID, err)
+		}
+	}
+
+	return s.cache.Set(ctx, key, true, inventoryReservationTTL)
+}
+
+// settleOrder releases an order's reservation; delivered orders also take the stock out
+func (s *InventoryService) settleOrder(ctx context.Context, orderID string, delivered bool) error {
+	key := inventoryReservationKey(orderID)
+	if exists, _ := s.cache.Exists(ctx, key); !exists {
+		return nil
+	}
+
+	lines, err := s.orderLines(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	transactionType, reason := StockReleased, "order cancelled"
+	if delivered {
+		transactionType, reason = StockDeduction, "order delivered"
+	}
+
+	for _, line := range lines {
+		productID, err := primitive.ObjectIDFromHex(line.ProductID)
+		if err != nil {
+			continue
+		}
+
+		quantityDelta := 0
+		if delivered {
+			quantityDelta = -line.Quantity
+		}
+		_, err = s.applyTransaction(ctx, productID, quantityDelta, -line.Quantity, models.StockTransaction{
+			Type:      transactionType,
+			Quantity:  line.Quantity,
+			Reason:    reason,
+			Reference: orderID,
+		}, nil)
+		if err != nil {
+			log.Printf("Failed to settle stock for product %s on order %s: %v", line.ProductID, orderID, err)
+		}
+	}
+
+	return s.cache.Delete(ctx, key)
+}
+
+func (s *InventoryService) orderLines(ctx context.Context, orderID string) ([]models.OrderLineItem, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, errors.New("invalid order ID")
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order %s: %v", orderID, err)
+	}
+	return models.StockLines(models.DecodeOrderLineItems(order.LineItems)), nil
+}
+
+// Subscribe consumes order events to keep stock and availability in sync, and inventory events
+// to alert restaurants when products sell out or come back
+func (s *InventoryService) Subscribe(consumer *messaging.KafkaConsumer, groupID string) {
+	consumer.Subscribe("order_events", groupID, s.HandleOrderEvent, messaging.SubscribeOptions{Concurrency: 2})
+	consumer.Subscribe("inventory_events", groupID+"-alerts", s.HandleInventoryEvent, messaging.SubscribeOptions{})
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,457 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/i18n"
+	"golang-food-backend/pkg/messaging"
+	"golang-food-backend/pkg/notify"
+	"golang-food-backend/pkg/pdf"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// invoiceSAC is the GST services accounting code for restaurant services
+const invoiceSAC = "996331"
+
+var (
+	ErrInvoiceNotFound     = apperr.NotFound("invoice_not_found", "invoice not found")
+	ErrInvoiceNotAvailable = apperr.Conflict("invoice_not_available", "invoices are issued once an order is delivered")
+)
+
+// InvoiceService issues GST invoices for delivered orders. Each invoice is rendered to PDF once,
+// kept in private media storage and emailed to the customer.
+type InvoiceService struct {
+	invoiceRepo    repositories.InvoiceRepository
+	orderRepo      repositories.OrderRepository
+	restaurantRepo repositories.RestaurantRepository
+	userRepo       repositories.UserRepository
+	mediaService   *MediaService
+	email          notify.Sender
+	timezone       *time.Location
+}
+
+func NewInvoiceService(
+	invoiceRepo repositories.InvoiceRepository,
+	orderRepo repositories.OrderRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	userRepo repositories.UserRepository,
+	mediaService *MediaService,
+	email notify.Sender,
+) *InvoiceService {
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		loc = time.UTC
+		log.Printf("Failed to load timezone, using UTC: %v", err)
+	}
+
+	return &InvoiceService{
+		invoiceRepo:    invoiceRepo,
+		orderRepo:      orderRepo,
+		restaurantRepo: restaurantRepo,
+		userRepo:       userRepo,
+		mediaService:   mediaService,
+		email:          email,
+		timezone:       loc,
+	}
+}
+
+// Subscribe issues invoices as orders complete
+func (s *InvoiceService) Subscribe(consumer *messaging.KafkaConsumer, groupID string) {
+	consumer.Subscribe("order_events", groupID, s.HandleOrderEvent, messaging.SubscribeOptions{})
+}
+
+// HandleOrderEvent processes an order_events message
+func (s *InvoiceService) HandleOrderEvent(payload []byte) error {
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("invalid order event: %v", err)
+	}
+	if event.Type != messaging.EventOrderCompleted {
+		return nil
+	}
+
+	var completed messaging.OrderCompleted
+	if _, err := messaging.Events.Decode(payload, &completed); err != nil {
+		return err
+	}
+	orderID, err := uuid.Parse(completed.OrderID)
+	if err != nil {
+		return fmt.Errorf("invalid order ID in event: %s", completed.OrderID)
+	}
+	_, err = s.IssueInvoice(context.Background(), orderID)
+	return err
+}
+
+// IssueInvoice issues the invoice of a delivered order, or returns the one already issued. A
+// newly issued invoice is emailed to the customer.
+func (s *InvoiceService) IssueInvoice(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: order %s", ErrInvoiceNotFound, orderID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.OrderStatus != "delivered" && order.OrderStatus != "completed" {
+		return nil, fmt.Errorf("%w: order is %s", ErrInvoiceNotAvailable, order.OrderStatus)
+	}
+
+	restaurant, err := s.restaurantRepo.GetByID(ctx, order.RestaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load restaurant: %v", err)
+	}
+
+	issuedAt := time.Now().In(s.timezone)
+	financialYear, prefix := financialYearOf(issuedAt)
+	var data []byte
+	invoice, issued, err := s.invoiceRepo.Issue(ctx, order.ID, order.RestaurantID, financialYear, func(number int64) (*models.Invoice, error) {
+		taxes := orderTaxBreakdown(order)
+		invoice := &models.Invoice{
+			OrderID:       order.ID,
+			RestaurantID:  order.RestaurantID,
+			UserID:        order.UserID,
+			InvoiceNumber: fmt.Sprintf("%s/%06d", prefix, number),
+			FinancialYear: financialYear,
+			GSTNumber:     restaurant.GSTNumber,
+			TaxableValue:  invoiceTaxableValue(taxes),
+			TotalTax:      taxes.TotalTax,
+			TotalAmount:   order.TotalAmount,
+			IssuedAt:      issuedAt,
+		}
+
+		data = renderInvoice(invoice, order, restaurant, taxes, s.timezone)
+		media, err := s.mediaService.StoreInvoice(ctx, order.RestaurantID.String(), order.ID.String(), invoiceFileName(invoice), data)
+		if err != nil {
+			return nil, err
+		}
+		invoice.MediaID = media.ID.Hex()
+		return invoice, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue invoice: %v", err)
+	}
+
+	if issued {
+		log.Printf("🧾 Issued invoice %s for order %s", invoice.InvoiceNumber, order.ID)
+		s.emailInvoice(ctx, invoice, order, restaurant, data)
+	}
+	return invoice, nil
+}
+
+// emailInvoice sends the invoice PDF to the customer. A failed email is logged; the invoice can
+// still be downloaded.
+func (s *InvoiceService) emailInvoice(ctx context.Context, invoice *models.Invoice, order *models.Order, restaurant *models.Restaurant, data []byte) {
+	user, err := s.userRepo.GetByID(ctx, order.UserID)
+	if err != nil || user.Email == "" {
+		return
+	}
+
+	// In the language the customer ordered in
+	err = s.email.Send(ctx, notify.Message{
+		To:      user.Email,
+		Subject: i18n.T(order.Language, "Your invoice %s from %s", invoice.InvoiceNumber, restaurant.Name),
+		Body: i18n.T(order.Language, "Hi %s,\n\nThank you for ordering from %s. Your invoice for order %s is attached.\n",
+			user.Name, restaurant.Name, order.ID),
+		Attachments: []notify.Attachment{{
+			FileName:    invoiceFileName(invoice),
+			ContentType: "application/pdf",
+			Data:        data,
+		}},
+	})
+	if err != nil {
+		log.Printf("Failed to email invoice %s: %v", invoice.InvoiceNumber, err)
+		return
+	}
+	if err := s.invoiceRepo.MarkEmailed(ctx, invoice.ID, time.Now()); err != nil {
+		log.Printf("Failed to mark invoice %s emailed: %v", invoice.InvoiceNumber, err)
+	}
+}
+
+// GetInvoicePDF returns the file name and PDF of the invoice of a customer's order, issuing it
+// first if the order was delivered before invoicing caught up
+func (s *InvoiceService) GetInvoicePDF(ctx context.Context, orderID, userID string) (string, []byte, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: invalid order ID", ErrInvoiceNotFound)
+	}
+	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	if err != nil || order.UserID.String() != userID {
+		return "", nil, fmt.Errorf("%w: order %s", ErrInvoiceNotFound, orderID)
+	}
+
+	invoice, err := s.invoiceRepo.GetByOrderID(ctx, orderUUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		invoice, err = s.IssueInvoice(ctx, orderUUID)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	data, err := s.mediaService.ReadInvoice(ctx, invoice.MediaID)
+	if err != nil {
+		return "", nil, err
+	}
+	return invoiceFileName(invoice), data, nil
+}
+
+// financialYearOf returns the Indian financial year (April to March) of t, as 2026-27, and the
+// short form used in invoice numbers, as 2627
+func financialYearOf(t time.Time) (string, string) {
+	start := t.Year()
+	if t.Month() < time.April {
+		start--
+	}
+	return fmt.Sprintf("%d-%02d", start, (start+1)%100), fmt.Sprintf("%02d%02d", start%100, (start+1)%100)
+}
+
+func invoiceFileName(invoice *models.Invoice) string {
+	return "invoice-" + strings.ReplaceAll(invoice.InvoiceNumber, "/", "-") + ".pdf"
+}
+
+// orderTaxBreakdown reads the tax breakdown stored on an order at checkout
+func orderTaxBreakdown(order *models.Order) TaxBreakdown {
+	var taxes TaxBreakdown
+	if raw, err := json.Marshal(order.TaxDetails); err == nil {
+		json.Unmarshal(raw, &taxes)
+	}
+	return taxes
+}
+
+func invoiceTaxableValue(taxes TaxBreakdown) models.Money {
+	var total models.Money
+	for _, line := range taxes.Lines {
+		total += line.TaxableValue
+	}
+	return total
+}
+
+var invoiceComponentLabels = map[string]string{
+	"items":           "Food and beverages",
+	"packaging":       "Packaging charges",
+	"platform_fee":    "Platform fee",
+	"small_order_fee": "Small order fee",
+	"late_night_fee":  "Late night fee",
+}
+
+// renderInvoice lays out the invoice on A4 pages: supplier and customer details, the items
+// ordered, GST by component and rate with its CGST and SGST halves, and the amount paid. Amounts
+// are grouped for the order's currency; labels stay in English as the PDF fonts only cover Latin
+// text.
+func renderInvoice(invoice *models.Invoice, order *models.Order, restaurant *models.Restaurant, taxes TaxBreakdown, loc *time.Location) []byte {
+	const (
+		left   = 40.0
+		right  = pdf.PageWidth - 40
+		bottom = pdf.PageHeight - 60
+		size   = 9.0
+	)
+
+	formatAmount := func(amount models.Money) string {
+		return i18n.FormatAmount(amount.Float64(), order.Currency)
+	}
+
+	doc := pdf.New()
+	page := doc.AddPage()
+	y := 50.0
+	// ensure starts a new page when fewer than height points are left
+	ensure := func(height float64) {
+		if y+height > bottom {
+			page = doc.AddPage()
+			y = 50
+		}
+	}
+	row := func(label, value string, bold bool) {
+		ensure(14)
+		page.Text(right-200, y, size, bold, label)
+		page.TextRight(right, y, size, bold, value)
+		y += 14
+	}
+
+	title := "TAX INVOICE"
+	if restaurant.GSTNumber == "" {
+		title = "BILL OF SUPPLY"
+	}
+	page.Text(left, y, 18, true, title)
+	page.TextRight(right, y-8, size, false, "Invoice No: "+invoice.InvoiceNumber)
+	page.TextRight(right, y+4, size, false, "Date: "+invoice.IssuedAt.In(loc).Format("02 Jan 2006"))
+	page.TextRight(right, y+16, size, false, "Order: "+order.ID.String())
+	y += 40
+
+	// Supplier and recipient
+	page.Text(left, y, 11, true, restaurant.Name)
+	page.Text(pdf.PageWidth/2, y, 11, true, "Bill to")
+	y += 14
+	gstin := restaurant.GSTNumber
+	if gstin == "" {
+		gstin = "Unregistered"
+	}
+	supplier := []string{"GSTIN: " + gstin}
+	if restaurant.ContactNumber != "" {
+		supplier = append(supplier, "Phone: "+restaurant.ContactNumber)
+	}
+	recipient := []string{order.CustomerName}
+	if order.CustomerContact != "" {
+		recipient = append(recipient, "Phone: "+order.CustomerContact)
+	}
+	if address, _ := order.DeliveryFullAddressWithLatLong["full_address"].(string); address != "" {
+		recipient = append(recipient, pdf.Wrap(address, size, false, right-pdf.PageWidth/2)...)
+	}
+	for i := 0; i < len(supplier) || i < len(recipient); i++ {
+		if i < len(supplier) {
+			page.Text(left, y, size, false, supplier[i])
+		}
+		if i < len(recipient) {
+			page.Text(pdf.PageWidth/2, y, size, false, recipient[i])
+		}
+		y += 12
+	}
+	y += 12
+
+	// Items
+	columns := []float64{left + 300, left + 340, left + 420, right}
+	header := func() {
+		page.Line(left, y-10, right, y-10)
+		page.Text(left, y, size, true, "Item")
+		page.TextRight(columns[0], y, size, true, "SAC")
+		page.TextRight(columns[1], y, size, true, "Qty")
+		page.TextRight(columns[2], y, size, true, "Rate")
+		page.TextRight(columns[3], y, size, true, "Amount")
+		page.Line(left, y+5, right, y+5)
+		y += 18
+	}
+	header()
+	var itemTotal models.Money
+	for _, item := range models.DecodeOrderLineItems(order.LineItems) {
+		name := item.ProductName
+		if item.VariantName != "" {
+			name += " (" + item.VariantName + ")"
+		}
+		if len(item.Options) > 0 {
+			options := make([]string, len(item.Options))
+			for i, option := range item.Options {
+				options[i] = option.Name
+			}
+			name += ", " + strings.Join(options, ", ")
+		}
+		if len(item.Components) > 0 {
+			components := make([]string, len(item.Components))
+			for i, component := range item.Components {
+				components[i] = fmt.Sprintf("%d x %s", component.Quantity, component.ProductName)
+			}
+			name += " (" + strings.Join(components, ", ") + ")"
+		}
+		if len(item.Addons) > 0 {
+			addons := make([]string, len(item.Addons))
+			for i, addon := range item.Addons {
+				addons[i] = addon.Name
+			}
+			name += " + " + strings.Join(addons, ", ")
+		}
+		lines := pdf.Wrap(name, size, false, 250)
+		if len(lines) == 0 {
+			lines = []string{item.ProductID}
+		}
+		if y+float64(len(lines))*12 > bottom {
+			page = doc.AddPage()
+			y = 50
+			header()
+		}
+		page.TextRight(columns[0], y, size, false, invoiceSAC)
+		page.TextRight(columns[1], y, size, false, fmt.Sprintf("%d", item.Quantity))
+		page.TextRight(columns[2], y, size, false, formatAmount(item.UnitPrice))
+		page.TextRight(columns[3], y, size, false, formatAmount(item.Total))
+		for _, line := range lines {
+			page.Text(left, y, size, false, line)
+			y += 12
+		}
+		itemTotal += item.Total
+	}
+	page.Line(left, y-6, right, y-6)
+	y += 8
+
+	// Tax breakup
+	if len(taxes.Lines) > 0 {
+		ensure(30 + float64(len(taxes.Lines))*12)
+		page.Text(left, y, size, true, "Tax breakup")
+		y += 14
+		page.Text(left, y, size, true, "Component")
+		page.TextRight(left+230, y, size, true, "Taxable value")
+		page.TextRight(left+290, y, size, true, "GST %")
+		page.TextRight(left+370, y, size, true, "CGST")
+		page.TextRight(left+450, y, size, true, "SGST")
+		y += 12
+		lines := append([]TaxLine(nil), taxes.Lines...)
+		sort.SliceStable(lines, func(i, j int) bool { return lines[i].Component < lines[j].Component })
+		for _, line := range lines {
+			label := invoiceComponentLabels[line.Component]
+			if label == "" {
+				label = line.Component
+			}
+			page.Text(left, y, size, false, label)
+			page.TextRight(left+230, y, size, false, formatAmount(line.TaxableValue))
+			page.TextRight(left+290, y, size, false, fmt.Sprintf("%g", line.GSTRate))
+			page.TextRight(left+370, y, size, false, formatAmount(line.CGST))
+			page.TextRight(left+450, y, size, false, formatAmount(line.SGST))
+			y += 12
+		}
+		y += 12
+	}
+
+	// Amounts
+	row("Item total", formatAmount(itemTotal), false)
+	if taxes.PackagingFee > 0 {
+		row("Packaging charges", formatAmount(taxes.PackagingFee), false)
+	}
+	if taxes.PlatformFee > 0 {
+		row("Platform fee", formatAmount(taxes.PlatformFee), false)
+	}
+	if taxes.SmallOrderFee > 0 {
+		row("Small order fee", formatAmount(taxes.SmallOrderFee), false)
+	}
+	if taxes.LateNightFee > 0 {
+		row("Late night fee", formatAmount(taxes.LateNightFee), false)
+	}
+	if order.DeliveryFee > 0 {
+		row("Delivery fee", formatAmount(order.DeliveryFee), false)
+	}
+	if taxes.CGST > 0 || taxes.SGST > 0 {
+		row("CGST", formatAmount(taxes.CGST), false)
+		row("SGST", formatAmount(taxes.SGST), false)
+	}
+	if discount := models.MoneyOf(order.DiscountDetails["discount_amount"]); discount > 0 {
+		label := "Discount"
+		if code, _ := order.DiscountDetails["coupon_code"].(string); code != "" {
+			label += " (" + code + ")"
+		}
+		row(label, "-"+formatAmount(discount), false)
+	}
+	ensure(30)
+	page.Line(right-200, y-8, right, y-8)
+	y += 4
+	row("Total ("+i18n.CurrencyOf(order.Currency).Code+")", formatAmount(order.TotalAmount), true)
+	y += 16
+
+	ensure(40)
+	if taxes.PricesIncludeTax {
+		page.Text(left, y, 8, false, "Menu prices are inclusive of GST.")
+		y += 11
+	}
+	page.Text(left, y, 8, false, "Place of supply as per the delivery address. Tax is not payable on reverse charge basis.")
+	y += 11
+	page.Text(left, y, 8, false, "This is a computer generated invoice and needs no signature.")
+
+	return doc.Bytes()
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/escpos"
+	"golang-food-backend/pkg/messaging"
+
+	"github.com/google/uuid"
+)
+
+// Kitchen ticket formats
+const (
+	TicketFormatText   = "text"
+	TicketFormatESCPOS = "escpos"
+)
+
+// Characters per line: 48 fits 80mm paper, 32 fits 58mm
+const (
+	ticketDefaultWidth = 48
+	ticketMinWidth     = 24
+	ticketMaxWidth     = 64
+)
+
+var ticketFormats = map[string]bool{TicketFormatText: true, TicketFormatESCPOS: true}
+
+var (
+	ErrTicketInvalid   = apperr.Validation("ticket_invalid", "invalid kitchen ticket request")
+	ErrTicketNotFound  = apperr.NotFound("order_not_found", "order not found")
+	ErrNoPrinterAgents = apperr.Conflict("no_printer_agents", "the restaurant has no printer agents; subscribe a webhook to ticket.print")
+)
+
+// KitchenTicketService renders orders into kitchen order tickets (KOT) for thermal printers.
+// Tickets are downloaded by restaurant staff or pushed to printer agents, which are webhook
+// subscriptions to ticket.print: each order is printed once when it is confirmed, and again
+// whenever staff ask for a reprint.
+type KitchenTicketService struct {
+	orderRepo repositories.OrderRepository
+	webhooks  *WebhookService
+}
+
+func NewKitchenTicketService(orderRepo repositories.OrderRepository, webhooks *WebhookService) *KitchenTicketService {
+	return &KitchenTicketService{
+		orderRepo: orderRepo,
+		webhooks:  webhooks,
+	}
+}
+
+// KitchenTicket is an order rendered for a thermal printer, as plain text and as ESC/POS bytes
+// (base64 in JSON)
+type KitchenTicket struct {
+	OrderID string `json:"order_id"`
+	Width   int    `json:"width"` // characters per line
+	Reprint bool   `json:"reprint"`
+	Text    string `json:"text"`
+	ESCPOS  []byte `json:"escpos"`
+}
+
+type PrintTicketRequest struct {
+	Width int `json:"width" example:"48"` // characters per line, 24 to 64; 48 by default
+}
+
+type PrintTicketResponse struct {
+	Ticket *KitchenTicket `json:"ticket"`
+	Queued int            `json:"queued"` // printer agents the ticket was sent to
+}
+
+// ParseTicketFormat checks a requested ticket format, text by default
+func ParseTicketFormat(format string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		return TicketFormatText, nil
+	}
+	if !ticketFormats[format] {
+		return "", fmt.Errorf("%w: format must be text or escpos", ErrTicketInvalid)
+	}
+	return format, nil
+}
+
+// GetTicket renders the restaurant's order at the given width (0 for the default)
+func (s *KitchenTicketService) GetTicket(ctx context.Context, restaurantID, orderID string, width int) (*KitchenTicket, error) {
+	width, err := ticketWidth(width)
+	if err != nil {
+		return nil, err
+	}
+	order, err := s.getOrder(ctx, restaurantID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return RenderKitchenTicket(order, width, false), nil
+}
+
+// PrintTicket sends a reprint of the order's ticket to the restaurant's printer agents
+func (s *KitchenTicketService) PrintTicket(ctx context.Context, restaurantID, orderID string, req *PrintTicketRequest) (*PrintTicketResponse, error) {
+	width, err := ticketWidth(req.Width)
+	if err != nil {
+		return nil, err
+	}
+	order, err := s.getOrder(ctx, restaurantID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket := RenderKitchenTicket(order, width, true)
+	queued, err := s.webhooks.QueuePrintJob(ctx, restaurantID, "ticket:"+order.ID.String()+":"+uuid.New().String(), ticket)
+	if err != nil {
+		return nil, err
+	}
+	if queued == 0 {
+		return nil, ErrNoPrinterAgents
+	}
+	return &PrintTicketResponse{Ticket: ticket, Queued: queued}, nil
+}
+
+// Subscribe prints the tickets of orders as they are confirmed, by the restaurant or by
+// payment capture
+func (s *KitchenTicketService) Subscribe(consumer *messaging.KafkaConsumer, groupID string) {
+	consumer.Subscribe("order_events", groupID, s.HandleOrderEvent, messaging.SubscribeOptions{})
+	consumer.Subscribe("payment_events", groupID, s.HandlePaymentEvent, messaging.SubscribeOptions{})
+}
+
+// HandleOrderEvent is the Kafka handler for the order_events topic
+func (s *KitchenTicketService) HandleOrderEvent(payload []byte) error {
+	var event struct {
+		Type    string `json:"type"`
+		OrderID string `json:"order_id"`
+		Data    struct {
+			NewStatus string `json:"new_status"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("invalid order event: %v", err)
+	}
+	if event.Type != "order_status_updated" || event.Data.NewStatus != "confirmed" {
+		return nil
+	}
+	return s.printConfirmed(context.Background(), event.OrderID)
+}
+
+// HandlePaymentEvent is the Kafka handler for the payment_events topic
+func (s *KitchenTicketService) HandlePaymentEvent(payload []byte) error {
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("invalid payment event: %v", err)
+	}
+	if event.Type != messaging.EventPaymentCaptured {
+		return nil
+	}
+
+	var captured messaging.PaymentCaptured
+	if _, err := messaging.Events.Decode(payload, &captured); err != nil {
+		return err
+	}
+	return s.printConfirmed(context.Background(), captured.OrderID)
+}
+
+// printConfirmed queues the first ticket of a confirmed order. The job ID is the order's, so
+// an order confirmed twice over, e.g. paid and then accepted, prints once.
+func (s *KitchenTicketService) printConfirmed(ctx context.Context, orderID string) error {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil // nothing to retry
+	}
+	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	if err != nil {
+		return fmt.Errorf("failed to load order %s: %v", orderID, err)
+	}
+
+	queued, err := s.webhooks.QueuePrintJob(ctx, order.RestaurantID.String(), "ticket:"+order.ID.String(), RenderKitchenTicket(order, ticketDefaultWidth, false))
+	if err != nil {
+		return err
+	}
+	if queued > 0 {
+		log.Printf("🖨️ Kitchen ticket for order %s sent to %d printer agents", order.ID, queued)
+	}
+	return nil
+}
+
+func (s *KitchenTicketService) getOrder(ctx context.Context, restaurantID, orderID string) (*models.Order, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTicketNotFound, orderID)
+	}
+	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	if err != nil || order.RestaurantID.String() != restaurantID {
+		return nil, fmt.Errorf("%w: %s", ErrTicketNotFound, orderID)
+	}
+	return order, nil
+}
+
+func ticketWidth(width int) (int, error) {
+	if width == 0 {
+		return ticketDefaultWidth, nil
+	}
+	if width < ticketMinWidth || width > ticketMaxWidth {
+		return 0, fmt.Errorf("%w: width must be between %d and %d", ErrTicketInvalid, ticketMinWidth, ticketMaxWidth)
+	}
+	return width, nil
+}
+
+// RenderKitchenTicket lays out the order for the kitchen: whether it is for pickup or dine-in,
+// when it is due, the items with their variants and addons, and the customer's notes. Prices are left off. Times are on the
+// restaurant's clock.
+func RenderKitchenTicket(order *models.Order, width int, reprint bool) *KitchenTicket {
+	loc, err := time.LoadLocation(order.Restaurant.TimeZone)
+	if err != nil {
+		loc = time.Local
+	}
+
+	ticket := escpos.New(width)
+	ticket.Title("KOT")
+	if order.Restaurant.Name != "" {
+		ticket.Center(order.Restaurant.Name)
+	}
+	if reprint {
+		ticket.Center("** REPRINT **")
+	}
+	switch order.OrderType {
+	case OrderTypePickup:
+		ticket.Center("** PICKUP **")
+	case OrderTypeDineIn:
+		ticket.Center("** DINE IN **")
+		if order.TableNumber != "" {
+			ticket.Bold("Table " + order.TableNumber)
+		}
+	}
+	ticket.Rule()
+	ticket.Columns("Order #"+strings.ToUpper(order.ID.String()[:8]), order.CreatedAt.In(loc).Format("02 Jan 15:04"))
+	if order.ScheduledFor != nil {
+		ticket.Bold("Scheduled for " + order.ScheduledFor.In(loc).Format("02 Jan 15:04"))
+	}
+	if order.PromisedAt != nil {
+		label := "Deliver by "
+		if !isDeliveryOrder(order) {
+			label = "Ready by "
+		}
+		ticket.Line(label + order.PromisedAt.In(loc).Format("15:04"))
+	}
+	if order.CustomerName != "" {
+		ticket.Line("Customer: " + order.CustomerName)
+	}
+	ticket.Rule()
+
+	items := 0
+	for _, line := range models.DecodeOrderLineItems(order.LineItems) {
+		// Combos are made item by item, so the kitchen gets each of their items
+		if len(line.Components) > 0 {
+			ticket.Line(fmt.Sprintf("%d x %s (combo)", line.Quantity, line.ProductName))
+			for _, component := range line.Components {
+				quantity := component.Quantity * line.Quantity
+				items += quantity
+				ticket.Bold(fmt.Sprintf("  %d x %s", quantity, component.ProductName))
+				if component.VariantName != "" {
+					ticket.Indent("      ", component.VariantName)
+				}
+			}
+			for _, addon := range line.Addons {
+				ticket.Indent("    + ", addon.Name)
+			}
+			continue
+		}
+
+		items += line.Quantity
+		ticket.Bold(fmt.Sprintf("%d x %s", line.Quantity, line.ProductName))
+		if line.VariantName != "" {
+			ticket.Indent("    ", line.VariantName)
+		}
+		for _, option := range line.Options {
+			ticket.Indent("    ", option.GroupName+": "+option.Name)
+		}
+		for _, addon := range line.Addons {
+			ticket.Indent("    + ", addon.Name)
+		}
+	}
+	ticket.Rule()
+	ticket.Columns("Items", fmt.Sprintf("%d", items))
+
+	if order.CustomerNotes != "" {
+		ticket.Rule()
+		ticket.Bold("NOTES")
+		ticket.Line(order.CustomerNotes)
+	}
+	ticket.Feed(2)
+
+	return &KitchenTicket{
+		OrderID: order.ID.String(),
+		Width:   width,
+		Reprint: reprint,
+		Text:    ticket.Text(),
+		ESCPOS:  ticket.Bytes(),
+	}
+}
@@ -0,0 +1,522 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/messaging"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	LedgerCustomerWallet    = "customer_wallet"    // what the platform holds for a customer
+	LedgerRestaurantPayable = "restaurant_payable" // what the platform owes a restaurant
+	LedgerPlatformRevenue   = "platform_revenue"   // commission, fees and the platform's share of orders
+	LedgerGatewayClearing   = "gateway_clearing"   // money collected through Razorpay and not yet paid out
+
+	LedgerTypeAsset     = "asset"
+	LedgerTypeLiability = "liability"
+	LedgerTypeRevenue   = "revenue"
+
+	JournalPaymentCaptured = "payment_captured"
+	JournalRefundProcessed = "refund_processed"
+	JournalPayoutSettled   = "payout_settled"
+	JournalPayoutPaid      = "payout_paid"
+	JournalMealPlanCharge  = "meal_plan_charge"
+	JournalMealPlanCredit  = "meal_plan_credit"
+
+	ledgerDateLayout = "2006-01-02"
+)
+
+var (
+	ErrLedgerInvalid         = apperr.Validation("ledger_invalid", "invalid ledger request")
+	ErrLedgerAccountNotFound = apperr.NotFound("ledger_account_not_found", "ledger account not found")
+	ErrJournalEntryNotFound  = apperr.NotFound("journal_entry_not_found", "journal entry not found")
+)
+
+// ledgerAccountTypes gives each kind of account its type. Assets carry debit balances; the
+// others carry credit balances.
+var ledgerAccountTypes = map[string]string{
+	LedgerCustomerWallet:    LedgerTypeLiability,
+	LedgerRestaurantPayable: LedgerTypeLiability,
+	LedgerPlatformRevenue:   LedgerTypeRevenue,
+	LedgerGatewayClearing:   LedgerTypeAsset,
+}
+
+// ledgerAccountRef names an account to post to; customer wallets and restaurant payables
+// have an owner
+type ledgerAccountRef struct {
+	Kind    string     `json:"kind"`
+	OwnerID *uuid.UUID `json:"owner_id,omitempty"`
+}
+
+func platformAccount(kind string) ledgerAccountRef {
+	return ledgerAccountRef{Kind: kind}
+}
+
+func ownedAccount(kind string, ownerID uuid.UUID) ledgerAccountRef {
+	return ledgerAccountRef{Kind: kind, OwnerID: &ownerID}
+}
+
+type LedgerAccountBalance struct {
+	models.LedgerAccount
+	Debits  models.Money `json:"debits"`
+	Credits models.Money `json:"credits"`
+	// Balance is on the account's usual side: debits less credits for assets, credits less
+	// debits for the others
+	Balance models.Money `json:"balance"`
+}
+
+type LedgerAccountListResponse struct {
+	Accounts []LedgerAccountBalance `json:"accounts"`
+	AsOf     string                 `json:"as_of"`
+	Total    int64                  `json:"total"`
+	Page     int                    `json:"page"`
+	Limit    int                    `json:"limit"`
+}
+
+type JournalLineListResponse struct {
+	Lines []models.JournalLine `json:"lines"`
+	Total int64                `json:"total"`
+	Page  int                  `json:"page"`
+	Limit int                  `json:"limit"`
+}
+
+// TrialBalanceLine is an account's net balance, in the debit column for a debit balance and
+// the credit column for a credit balance
+type TrialBalanceLine struct {
+	AccountID uuid.UUID    `json:"account_id"`
+	Code      string       `json:"code"`
+	Kind      string       `json:"kind"`
+	Type      string       `json:"type"`
+	Debit     models.Money `json:"debit"`
+	Credit    models.Money `json:"credit"`
+}
+
+type TrialBalance struct {
+	Currency    string             `json:"currency"`
+	AsOf        string             `json:"as_of"`
+	Lines       []TrialBalanceLine `json:"lines"`
+	TotalDebit  models.Money       `json:"total_debit"`
+	TotalCredit models.Money       `json:"total_credit"`
+	Balanced    bool               `json:"balanced"`
+}
+
+// LedgerService keeps the double-entry ledger. Payments, refunds, payouts and meal plan bills
+// post their money movements to it once each; finance reads balances and the trial balance.
+type LedgerService struct {
+	ledgerRepo repositories.LedgerRepository
+	outboxRepo repositories.OutboxRepository
+	timezone   *time.Location
+}
+
+func NewLedgerService(ledgerRepo repositories.LedgerRepository, outboxRepo repositories.OutboxRepository) *LedgerService {
+	// Default to Asia/Kolkata timezone
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		loc = time.UTC
+		log.Printf("Failed to load timezone, using UTC: %v", err)
+	}
+
+	return &LedgerService{
+		ledgerRepo: ledgerRepo,
+		outboxRepo: outboxRepo,
+		timezone:   loc,
+	}
+}
+
+// PaymentCaptured posts money a customer paid through Razorpay for an order, owed to the
+// order's restaurant until the order is settled. Each part of a part-paid link is posted
+// under its own reference.
+func (s *LedgerService) PaymentCaptured(ctx context.Context, order *models.Order, paymentID uuid.UUID, reference string, amount models.Money) {
+	s.transfer(ctx, &models.JournalEntry{
+		Reference:   reference,
+		Kind:        JournalPaymentCaptured,
+		SourceID:    paymentID,
+		Description: fmt.Sprintf("Payment for order %s", order.ID),
+		Currency:    order.Currency,
+	}, platformAccount(LedgerGatewayClearing), ownedAccount(LedgerRestaurantPayable, order.RestaurantID), amount)
+}
+
+// RefundProcessed posts a refund returned to the customer through Razorpay, taken from what
+// the restaurant is owed. The platform's share is given back when the refund is settled.
+func (s *LedgerService) RefundProcessed(ctx context.Context, refund *models.Refund) {
+	s.transfer(ctx, &models.JournalEntry{
+		Reference:   "refund:" + refund.ID.String(),
+		Kind:        JournalRefundProcessed,
+		SourceID:    refund.ID,
+		Description: fmt.Sprintf("Refund for order %s", refund.OrderID),
+		Currency:    refund.Order.Currency,
+	}, ownedAccount(LedgerRestaurantPayable, refund.Order.RestaurantID), platformAccount(LedgerGatewayClearing), refund.Amount)
+}
+
+// PayoutSettled posts the platform's share of the orders and refunds settled into a payout:
+// what was collected for them less what the restaurant earns. A negative share, when refunds
+// the platform absorbs outweigh its commission, goes back to the restaurant.
+func (s *LedgerService) PayoutSettled(ctx context.Context, payout *models.RestaurantPayout, currency string, platformShare models.Money) {
+	s.transfer(ctx, &models.JournalEntry{
+		Reference:   fmt.Sprintf("payout:%s:settled", payout.ID),
+		Kind:        JournalPayoutSettled,
+		SourceID:    payout.ID,
+		Description: fmt.Sprintf("Settlement for the week of %s", payout.PeriodStart.Format(payoutDateLayout)),
+		Currency:    currency,
+	}, ownedAccount(LedgerRestaurantPayable, payout.RestaurantID), platformAccount(LedgerPlatformRevenue), platformShare)
+}
+
+// PayoutPaid posts a payout transferred to the restaurant's bank account
+func (s *LedgerService) PayoutPaid(ctx context.Context, payout *models.RestaurantPayout, currency string) {
+	s.transfer(ctx, &models.JournalEntry{
+		Reference:   fmt.Sprintf("payout:%s:paid", payout.ID),
+		Kind:        JournalPayoutPaid,
+		SourceID:    payout.ID,
+		Description: fmt.Sprintf("Payout %s", payout.Reference),
+		Currency:    currency,
+	}, ownedAccount(LedgerRestaurantPayable, payout.RestaurantID), platformAccount(LedgerGatewayClearing), payout.NetAmount)
+}
+
+// MealPlanBills posts a subscription's paid bills. Charges prepay the restaurant from the
+// mandate or the customer's wallet; credits for skipped deliveries move that prepayment back
+// to the wallet.
+func (s *LedgerService) MealPlanBills(ctx context.Context, subscription *models.MealPlanSubscription, bills []models.MealPlanBill) {
+	for i := range bills {
+		bill := &bills[i]
+		if bill.Status != "paid" || bill.ID == uuid.Nil {
+			continue
+		}
+		entry := &models.JournalEntry{
+			Reference: "meal_plan_bill:" + bill.ID.String(),
+			Kind:      JournalMealPlanCharge,
+			SourceID:  bill.ID,
+			Currency:  subscription.Currency,
+		}
+		restaurant := ownedAccount(LedgerRestaurantPayable, bill.RestaurantID)
+		wallet := ownedAccount(LedgerCustomerWallet, bill.UserID)
+		switch {
+		case bill.Kind == "credit":
+			entry.Kind = JournalMealPlanCredit
+			entry.Description = fmt.Sprintf("Meal plan credit for %s to %s", bill.PeriodStart, bill.PeriodEnd)
+			s.transfer(ctx, entry, restaurant, wallet, bill.Amount)
+		case bill.Method == "wallet":
+			entry.Description = fmt.Sprintf("Meal plan charge for %s to %s from the wallet", bill.PeriodStart, bill.PeriodEnd)
+			s.transfer(ctx, entry, wallet, restaurant, bill.Amount)
+		default:
+			entry.Description = fmt.Sprintf("Meal plan charge for %s to %s", bill.PeriodStart, bill.PeriodEnd)
+			s.transfer(ctx, entry, platformAccount(LedgerGatewayClearing), restaurant, bill.Amount)
+		}
+	}
+}
+
+// ledgerPosting is a transfer waiting to be posted, queued on the ledger_postings topic when
+// posting it straight away fails
+type ledgerPosting struct {
+	Entry  models.JournalEntry `json:"entry"`
+	Debit  ledgerAccountRef    `json:"debit"`
+	Credit ledgerAccountRef    `json:"credit"`
+	Amount models.Money        `json:"amount"`
+}
+
+// transfer posts amount from the credited account to the debited one, the other way round
+// when it is negative. The business change has already been saved, so a posting that fails is
+// queued through the outbox under its reference and retried by HandlePosting rather than
+// undoing it.
+func (s *LedgerService) transfer(ctx context.Context, entry *models.JournalEntry, debit, credit ledgerAccountRef, amount models.Money) {
+	if amount == 0 {
+		return
+	}
+	if amount < 0 {
+		debit, credit, amount = credit, debit, -amount
+	}
+	if entry.Currency == "" {
+		entry.Currency = "INR"
+	}
+	entry.PostedAt = time.Now()
+
+	posting := ledgerPosting{Entry: *entry, Debit: debit, Credit: credit, Amount: amount}
+	err := s.post(ctx, &posting)
+	if err == nil {
+		return
+	}
+	log.Printf("Failed to post ledger entry %s, queueing it for retry: %v", entry.Reference, err)
+
+	event, err := NewOutboxEvent("ledger_postings", entry.Reference, posting)
+	if err == nil {
+		err = s.outboxRepo.Create(ctx, &event)
+	}
+	if err != nil {
+		log.Printf("Failed to queue ledger entry %s for retry: %v", entry.Reference, err)
+	}
+}
+
+// post writes a posting's entry. Posting one that was already posted does nothing.
+func (s *LedgerService) post(ctx context.Context, posting *ledgerPosting) error {
+	debitAccount, err := s.account(ctx, posting.Debit, posting.Entry.Currency)
+	if err != nil {
+		return err
+	}
+	creditAccount, err := s.account(ctx, posting.Credit, posting.Entry.Currency)
+	if err != nil {
+		return err
+	}
+
+	entry := posting.Entry
+	entry.Lines = []models.JournalLine{
+		{AccountID: debitAccount.ID, Debit: posting.Amount},
+		{AccountID: creditAccount.ID, Credit: posting.Amount},
+	}
+	_, err = s.ledgerRepo.Post(ctx, &entry)
+	return err
+}
+
+// Subscribe retries the postings that failed when their business change was saved
+func (s *LedgerService) Subscribe(consumer *messaging.KafkaConsumer, groupID string) {
+	// Postings usually fail while the database is unavailable, so they are retried for longer
+	// than other messages before being dead-lettered
+	consumer.Subscribe("ledger_postings", groupID, s.HandlePosting, messaging.SubscribeOptions{
+		Retry: messaging.RetryPolicy{MaxAttempts: 8, InitialBackoff: 2 * time.Second, MaxBackoff: time.Minute},
+	})
+}
+
+// HandlePosting processes a ledger_postings message
+func (s *LedgerService) HandlePosting(payload []byte) error {
+	var posting ledgerPosting
+	if err := json.Unmarshal(payload, &posting); err != nil {
+		return fmt.Errorf("invalid ledger posting: %v", err)
+	}
+	if err := s.post(context.Background(), &posting); err != nil {
+		return fmt.Errorf("failed to post ledger entry %s: %v", posting.Entry.Reference, err)
+	}
+	return nil
+}
+
+// account returns the ledger account, opening it on its first posting
+func (s *LedgerService) account(ctx context.Context, ref ledgerAccountRef, currency string) (*models.LedgerAccount, error) {
+	code := ref.Kind
+	if ref.OwnerID != nil {
+		code += ":" + ref.OwnerID.String()
+	}
+	account := &models.LedgerAccount{
+		Code:     code + ":" + strings.ToUpper(currency),
+		Kind:     ref.Kind,
+		Type:     ledgerAccountTypes[ref.Kind],
+		OwnerID:  ref.OwnerID,
+		Currency: strings.ToUpper(currency),
+	}
+	if err := s.ledgerRepo.GetOrCreateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// ListAccounts lists ledger accounts by code, of one kind when kind is set, with their
+// balances at the end of asOf (YYYY-MM-DD, today when empty)
+func (s *LedgerService) ListAccounts(ctx context.Context, kind, asOf string, page, limit int) (*LedgerAccountListResponse, error) {
+	if _, ok := ledgerAccountTypes[kind]; kind != "" && !ok {
+		return nil, fmt.Errorf("%w: unknown account kind %q", ErrLedgerInvalid, kind)
+	}
+	until, asOf, err := s.asOf(asOf)
+	if err != nil {
+		return nil, err
+	}
+	page, limit = normalizePage(page, limit)
+
+	accounts, total, err := s.ledgerRepo.ListAccounts(ctx, kind, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger accounts: %v", err)
+	}
+	balances, err := s.balances(ctx, accounts, until)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LedgerAccountListResponse{
+		Accounts: balances,
+		AsOf:     asOf,
+		Total:    total,
+		Page:     page,
+		Limit:    limit,
+	}, nil
+}
+
+// GetAccountBalance returns an account with its balance at the end of asOf
+func (s *LedgerService) GetAccountBalance(ctx context.Context, accountID, asOf string) (*LedgerAccountBalance, error) {
+	account, err := s.ledgerAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	until, _, err := s.asOf(asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := s.balances(ctx, []models.LedgerAccount{*account}, until)
+	if err != nil {
+		return nil, err
+	}
+	return &balances[0], nil
+}
+
+// GetAccountLines lists an account's postings with their entries, newest first
+func (s *LedgerService) GetAccountLines(ctx context.Context, accountID string, page, limit int) (*JournalLineListResponse, error) {
+	account, err := s.ledgerAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	page, limit = normalizePage(page, limit)
+
+	lines, total, err := s.ledgerRepo.GetLines(ctx, account.ID, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger postings: %v", err)
+	}
+
+	return &JournalLineListResponse{
+		Lines: lines,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	}, nil
+}
+
+// GetEntry returns a journal entry with its lines and their accounts
+func (s *LedgerService) GetEntry(ctx context.Context, entryID string) (*models.JournalEntry, error) {
+	id, err := uuid.Parse(entryID)
+	if err != nil {
+		return nil, ErrJournalEntryNotFound
+	}
+	entry, err := s.ledgerRepo.GetEntryByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJournalEntryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal entry: %v", err)
+	}
+	return entry, nil
+}
+
+// GetTrialBalance lists every account in the currency posted to by the end of asOf with its
+// net balance. The debit and credit columns total the same unless the ledger is broken.
+func (s *LedgerService) GetTrialBalance(ctx context.Context, currency, asOf string) (*TrialBalance, error) {
+	until, asOf, err := s.asOf(asOf)
+	if err != nil {
+		return nil, err
+	}
+	currency = strings.ToUpper(currency)
+	if currency == "" {
+		currency = "INR"
+	}
+
+	totals, err := s.ledgerRepo.GetTotals(ctx, nil, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to total ledger postings: %v", err)
+	}
+	byAccount := make(map[uuid.UUID]repositories.LedgerTotals, len(totals))
+	ids := make([]uuid.UUID, 0, len(totals))
+	for _, total := range totals {
+		byAccount[total.AccountID] = total
+		ids = append(ids, total.AccountID)
+	}
+	accounts, err := s.ledgerRepo.GetAccountsByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ledger accounts: %v", err)
+	}
+
+	trial := &TrialBalance{
+		Currency: currency,
+		AsOf:     asOf,
+		Lines:    []TrialBalanceLine{},
+	}
+	for _, account := range accounts {
+		if account.Currency != currency {
+			continue
+		}
+		total := byAccount[account.ID]
+		line := TrialBalanceLine{
+			AccountID: account.ID,
+			Code:      account.Code,
+			Kind:      account.Kind,
+			Type:      account.Type,
+		}
+		if net := total.Debits - total.Credits; net >= 0 {
+			line.Debit = net
+		} else {
+			line.Credit = -net
+		}
+		trial.TotalDebit += line.Debit
+		trial.TotalCredit += line.Credit
+		trial.Lines = append(trial.Lines, line)
+	}
+	trial.Balanced = trial.TotalDebit == trial.TotalCredit
+	return trial, nil
+}
+
+// balances adds the postings before until to each account
+func (s *LedgerService) balances(ctx context.Context, accounts []models.LedgerAccount, until time.Time) ([]LedgerAccountBalance, error) {
+	balances := make([]LedgerAccountBalance, 0, len(accounts))
+	if len(accounts) == 0 {
+		return balances, nil
+	}
+	ids := make([]uuid.UUID, len(accounts))
+	for i, account := range accounts {
+		ids[i] = account.ID
+	}
+	totals, err := s.ledgerRepo.GetTotals(ctx, ids, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to total ledger postings: %v", err)
+	}
+	byAccount := make(map[uuid.UUID]repositories.LedgerTotals, len(totals))
+	for _, total := range totals {
+		byAccount[total.AccountID] = total
+	}
+
+	for _, account := range accounts {
+		total := byAccount[account.ID]
+		balance := LedgerAccountBalance{
+			LedgerAccount: account,
+			Debits:        total.Debits,
+			Credits:       total.Credits,
+			Balance:       total.Credits - total.Debits,
+		}
+		if account.Type == LedgerTypeAsset {
+			balance.Balance = total.Debits - total.Credits
+		}
+		balances = append(balances, balance)
+	}
+	return balances, nil
+}
+
+func (s *LedgerService) ledgerAccount(ctx context.Context, accountID string) (*models.LedgerAccount, error) {
+	id, err := uuid.Parse(accountID)
+	if err != nil {
+		return nil, ErrLedgerAccountNotFound
+	}
+	account, err := s.ledgerRepo.GetAccountByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLedgerAccountNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger account: %v", err)
+	}
+	return account, nil
+}
+
+// asOf turns a date into the end of that day in the platform timezone; an empty date is now
+func (s *LedgerService) asOf(date string) (time.Time, string, error) {
+	if date == "" {
+		now := time.Now().In(s.timezone)
+		return now, now.Format(ledgerDateLayout), nil
+	}
+	day, err := time.ParseInLocation(ledgerDateLayout, date, s.timezone)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("%w: as_of must be a date in YYYY-MM-DD format", ErrLedgerInvalid)
+	}
+	return day.AddDate(0, 0, 1), date, nil
+}
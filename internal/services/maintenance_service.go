@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How long the in-memory maintenance schedule is trusted before reloading
+const maintenanceRefreshInterval = 15 * time.Second
+
+type MaintenanceService struct {
+	repo        repositories.MaintenanceWindowRepository
+	windows     []models.MaintenanceWindow
+	lastRefresh time.Time
+	mutex       sync.RWMutex
+}
+
+func NewMaintenanceService(repo repositories.MaintenanceWindowRepository) *MaintenanceService {
+	return &MaintenanceService{repo: repo}
+}
+
+type ScheduleMaintenanceRequest struct {
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Message  string    `json:"message"`
+}
+
+type MaintenanceStatusResponse struct {
+	Status            string                     `json:"status"` // operational, maintenance
+	Current           *models.MaintenanceWindow  `json:"current,omitempty"`
+	Upcoming          []models.MaintenanceWindow `json:"upcoming"`
+	ServerTime        time.Time                  `json:"server_time"`
+	RetryAfterSeconds int                        `json:"retry_after_seconds,omitempty"`
+}
+
+// ScheduleWindow creates a new maintenance window
+func (s *MaintenanceService) ScheduleWindow(ctx context.Context, adminID string, req *ScheduleMaintenanceRequest) (*models.MaintenanceWindow, error) {
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	if !req.EndsAt.After(req.StartsAt) {
+		return nil, errors.New("maintenance window must end after it starts")
+	}
+	if req.EndsAt.Before(time.Now()) {
+		return nil, errors.New("maintenance window is already over")
+	}
+
+	message := req.Message
+	if message == "" {
+		message = "We are performing scheduled maintenance. Please try again shortly."
+	}
+
+	window := &models.MaintenanceWindow{
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Message:   message,
+		CreatedBy: adminUUID,
+	}
+
+	if err := s.repo.Create(ctx, window); err != nil {
+		return nil, fmt.Errorf("failed to schedule maintenance: %v", err)
+	}
+
+	s.refresh(ctx)
+	return window, nil
+}
+
+// CancelWindow cancels a scheduled or running maintenance window
+func (s *MaintenanceService) CancelWindow(ctx context.Context, windowID string) error {
+	windowUUID, err := uuid.Parse(windowID)
+	if err != nil {
+		return errors.New("invalid maintenance window ID")
+	}
+
+	window, err := s.repo.GetByID(ctx, windowUUID)
+	if err != nil {
+		return errors.New("maintenance window not found")
+	}
+
+	now := time.Now()
+	window.CancelledAt = &now
+	if err := s.repo.Update(ctx, window); err != nil {
+		return fmt.Errorf("failed to cancel maintenance: %v", err)
+	}
+
+	s.refresh(ctx)
+	return nil
+}
+
+// GetStatus returns the current and upcoming maintenance windows
+func (s *MaintenanceService) GetStatus(ctx context.Context) *MaintenanceStatusResponse {
+	now := time.Now()
+	response := &MaintenanceStatusResponse{
+		Status:     "operational",
+		Upcoming:   []models.MaintenanceWindow{},
+		ServerTime: now,
+	}
+
+	for _, window := range s.scheduledWindows(ctx) {
+		switch {
+		case !now.Before(window.StartsAt) && now.Before(window.EndsAt):
+			w := window
+			response.Status = "maintenance"
+			response.Current = &w
+			response.RetryAfterSeconds = int(window.EndsAt.Sub(now).Seconds()) + 1
+		case now.Before(window.StartsAt):
+			response.Upcoming = append(response.Upcoming, window)
+		}
+	}
+
+	return response
+}
+
+// ActiveWindow returns the running maintenance window, if any
+func (s *MaintenanceService) ActiveWindow(ctx context.Context) *models.MaintenanceWindow {
+	return s.GetStatus(ctx).Current
+}
+
+func (s *MaintenanceService) scheduledWindows(ctx context.Context) []models.MaintenanceWindow {
+	s.mutex.RLock()
+	stale := time.Since(s.lastRefresh) > maintenanceRefreshInterval
+	windows := s.windows
+	s.mutex.RUnlock()
+
+	if stale {
+		return s.refresh(ctx)
+	}
+	return windows
+}
+
+func (s *MaintenanceService) refresh(ctx context.Context) []models.MaintenanceWindow {
+	windows, err := s.repo.GetScheduled(ctx, time.Now())
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err != nil {
+		// Keep serving the last known schedule rather than failing every request
+		log.Printf("Failed to load maintenance windows: %v", err)
+	} else {
+		s.windows = windows
+	}
+	s.lastRefresh = time.Now()
+
+	return s.windows
+}
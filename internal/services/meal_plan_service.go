@@ -0,0 +1,1360 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/i18n"
+	"golang-food-backend/pkg/jobs"
+	"golang-food-backend/pkg/messaging"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	MealPlanActive     = "active"
+	MealPlanPaused     = "paused"
+	MealPlanPaymentDue = "payment_due"
+	MealPlanCancelled  = "cancelled"
+	MealPlanEnded      = "ended"
+
+	MealPlanPayWallet  = "wallet"
+	MealPlanPayMandate = "mandate"
+
+	// PaymentMethodMealPlan marks an order paid for by its subscription's bill
+	PaymentMethodMealPlan = "meal_plan"
+
+	JobMealPlanOrders = "meal_plans:orders"
+
+	mealPlanDateLayout   = "2006-01-02"
+	mealPlanBillingDays  = 7
+	mealPlanMaxDaysAhead = 60
+	mealPlanMaxPauseDays = 90
+	mealPlanRunInterval  = 15 * time.Minute
+)
+
+// mealPlanWeekdays are the delivery days a subscription can choose, in week order
+var mealPlanWeekdays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+var (
+	ErrMealPlanInvalid              = apperr.Validation("meal_plan_invalid", "invalid meal plan")
+	ErrMealPlanNotFound             = apperr.NotFound("meal_plan_not_found", "meal plan not found")
+	ErrMealPlanUnavailable          = apperr.Conflict("meal_plan_unavailable", "the meal plan is not taking subscribers")
+	ErrMealPlanInUse                = apperr.Conflict("meal_plan_in_use", "the meal plan has subscriptions; deactivate it instead")
+	ErrMealPlanSubscriptionNotFound = apperr.NotFound("meal_plan_subscription_not_found", "meal plan subscription not found")
+	ErrMealPlanSubscriptionState    = apperr.Conflict("meal_plan_subscription_state", "the subscription cannot be changed in its current state")
+	ErrMealPlanCutoff               = apperr.Conflict("meal_plan_cutoff_passed", "the delivery is past its cutoff")
+	ErrMealPlanPayment              = apperr.Unprocessable("meal_plan_payment_failed", "the meal plan could not be paid for")
+)
+
+// MealPlanRequest creates or replaces a meal plan
+type MealPlanRequest struct {
+	Name             string            `json:"name" binding:"required,max=100"`
+	Description      string            `json:"description,omitempty" binding:"max=500"`
+	Items            []models.CartItem `json:"items" binding:"required,min=1"`
+	PricePerDelivery models.Money      `json:"price_per_delivery" binding:"required,gte=100"`
+	CutoffHours      int               `json:"cutoff_hours" binding:"required,min=1,max=72"` // how long before a delivery it is ordered and can no longer be skipped
+	IsActive         *bool             `json:"is_active,omitempty"`                          // defaults to true
+}
+
+// MealPlanResponse is a meal plan with its items at today's menu prices
+type MealPlanResponse struct {
+	models.MealPlan
+	Items     []CartItemResponse `json:"items"`
+	MenuPrice models.Money       `json:"menu_price"`
+}
+
+// SubscribeMealPlanRequest subscribes the customer to a meal plan
+type SubscribeMealPlanRequest struct {
+	PlanID          string   `json:"plan_id" binding:"required"`
+	Days            []string `json:"days,omitempty"`                   // mon to sun; every day when empty
+	DeliveryTime    string   `json:"delivery_time" binding:"required"` // HH:MM in the restaurant's time zone
+	StartDate       string   `json:"start_date" binding:"required"`    // YYYY-MM-DD
+	EndDate         string   `json:"end_date,omitempty"`               // last delivery date; open-ended when empty
+	OrderType       string   `json:"order_type,omitempty"`             // delivery (default) or pickup
+	AddressID       string   `json:"address_id,omitempty"`             // required for delivery
+	CustomerName    string   `json:"customer_name" binding:"required"`
+	CustomerContact string   `json:"customer_contact" binding:"required"`
+	PaymentMethod   string   `json:"payment_method" binding:"required,oneof=wallet mandate"`
+	MandateToken    string   `json:"mandate_token,omitempty"`       // Razorpay recurring payment token, required for mandate
+	MandateCustomer string   `json:"mandate_customer_id,omitempty"` // Razorpay customer the token was issued to, required for mandate
+	CustomerEmail   string   `json:"customer_email,omitempty"`      // required for mandate; Razorpay sends charge receipts to it
+}
+
+// SkipMealPlanDeliveryRequest skips one delivery date
+type SkipMealPlanDeliveryRequest struct {
+	Date string `json:"date" binding:"required"` // YYYY-MM-DD
+}
+
+// PauseMealPlanRequest pauses deliveries
+type PauseMealPlanRequest struct {
+	Until string `json:"until,omitempty"` // deliveries resume on this date; paused until resumed when empty
+}
+
+// MealPlanDeliveryDate is one upcoming delivery of a subscription
+type MealPlanDeliveryDate struct {
+	Date      string     `json:"date"`
+	DeliverAt time.Time  `json:"deliver_at"`
+	Status    string     `json:"status"` // ordered, skipped, scheduled (paid for) or unbilled (paid for with the next bill)
+	SkipBy    *time.Time `json:"skip_by,omitempty"`
+	OrderID   *uuid.UUID `json:"order_id,omitempty"`
+}
+
+// MealPlanSubscriptionDetails is a subscription with its delivery days and, when read on its
+// own, the week's deliveries and recent bills
+type MealPlanSubscriptionDetails struct {
+	models.MealPlanSubscription
+	Days     []string               `json:"days"`
+	Upcoming []MealPlanDeliveryDate `json:"upcoming,omitempty"`
+	Bills    []models.MealPlanBill  `json:"bills,omitempty"`
+}
+
+// MealPlanAnalytics summarizes a restaurant's meal plans over a date range
+type MealPlanAnalytics struct {
+	From  string              `json:"from"`
+	To    string              `json:"to"`
+	Plans []MealPlanPlanStats `json:"plans"`
+}
+
+type MealPlanPlanStats struct {
+	repositories.MealPlanStats
+	Subscribers int64        `json:"subscribers"` // active, paused or waiting for payment
+	SkipRate    float64      `json:"skip_rate"`   // percentage of the range's deliveries that were skipped
+	NetRevenue  models.Money `json:"net_revenue"` // charges less credits
+}
+
+// MealPlanService runs meal plan subscriptions. Deliveries are billed a week at a time ahead,
+// per delivery, from the customer's wallet or Razorpay mandate; each delivery's order is placed
+// once its cutoff passes. Skipped, paused and cancelled deliveries already billed are credited
+// to the wallet.
+type MealPlanService struct {
+	planRepo         repositories.MealPlanRepository
+	subscriptionRepo repositories.MealPlanSubscriptionRepository
+	cartRepo         repositories.CartRepository
+	orderRepo        repositories.OrderRepository
+	paymentRepo      repositories.PaymentRepository
+	productRepo      repositories.ProductRepository
+	addressRepo      repositories.AddressRepository
+	restaurantRepo   repositories.RestaurantRepository
+	restaurants      *RestaurantService
+	razorpay         *RazorpayService
+	ledger           *LedgerService
+	queue            *jobs.Queue
+	stopChan         chan bool
+	isRunning        bool
+}
+
+func NewMealPlanService(
+	planRepo repositories.MealPlanRepository,
+	subscriptionRepo repositories.MealPlanSubscriptionRepository,
+	cartRepo repositories.CartRepository,
+	orderRepo repositories.OrderRepository,
+	paymentRepo repositories.PaymentRepository,
+	productRepo repositories.ProductRepository,
+	addressRepo repositories.AddressRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	restaurants *RestaurantService,
+	razorpay *RazorpayService,
+	ledger *LedgerService,
+) *MealPlanService {
+	return &MealPlanService{
+		planRepo:         planRepo,
+		subscriptionRepo: subscriptionRepo,
+		cartRepo:         cartRepo,
+		orderRepo:        orderRepo,
+		paymentRepo:      paymentRepo,
+		productRepo:      productRepo,
+		addressRepo:      addressRepo,
+		restaurantRepo:   restaurantRepo,
+		restaurants:      restaurants,
+		razorpay:         razorpay,
+		ledger:           ledger,
+		stopChan:         make(chan bool),
+	}
+}
+
+// RegisterJobs registers the order generation job handler and the queue the ticker uses
+func (s *MealPlanService) RegisterJobs(queue *jobs.Queue) {
+	s.queue = queue
+	queue.Handle(JobMealPlanOrders, func(ctx context.Context, job *jobs.Job) error {
+		_, err := s.GenerateOrders(ctx)
+		return err
+	})
+}
+
+// Start queues order generation every 15 minutes
+func (s *MealPlanService) Start() error {
+	if s.isRunning {
+		return fmt.Errorf("meal plan service is already running")
+	}
+
+	s.isRunning = true
+	go s.runTicker()
+
+	log.Println("🍱 Meal plan orders and renewals: Every 15 minutes")
+	return nil
+}
+
+// Stop stops queueing order generation
+func (s *MealPlanService) Stop() {
+	if !s.isRunning {
+		return
+	}
+
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+func (s *MealPlanService) runTicker() {
+	ticker := time.NewTicker(mealPlanRunInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Deliveries are claimed per date and subscriptions saved with their version, so a
+			// run queued by every instance places each order once
+			if _, err := s.queue.Enqueue(context.Background(), JobMealPlanOrders, nil, jobs.Timeout(10*time.Minute)); err != nil {
+				log.Printf("Failed to queue %s: %v", JobMealPlanOrders, err)
+			}
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// ListPlans returns the restaurant's meal plans, active or not
+func (s *MealPlanService) ListPlans(ctx context.Context, restaurantID string) ([]MealPlanResponse, error) {
+	return s.listPlans(ctx, restaurantID, false)
+}
+
+// ListAvailablePlans returns the restaurant's meal plans taking subscribers
+func (s *MealPlanService) ListAvailablePlans(ctx context.Context, restaurantID string) ([]MealPlanResponse, error) {
+	return s.listPlans(ctx, restaurantID, true)
+}
+
+func (s *MealPlanService) listPlans(ctx context.Context, restaurantID string, activeOnly bool) ([]MealPlanResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrMealPlanInvalid)
+	}
+	plans, err := s.planRepo.GetByRestaurantID(ctx, restaurantUUID, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list meal plans: %v", err)
+	}
+	response := make([]MealPlanResponse, 0, len(plans))
+	for i := range plans {
+		response = append(response, *s.planResponse(ctx, &plans[i]))
+	}
+	return response, nil
+}
+
+// CreatePlan adds a meal plan of the restaurant's available products
+func (s *MealPlanService) CreatePlan(ctx context.Context, restaurantID string, req *MealPlanRequest) (*MealPlanResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrMealPlanInvalid)
+	}
+	plan := &models.MealPlan{RestaurantID: restaurantUUID, IsActive: true}
+	if err := s.applyPlan(ctx, plan, req); err != nil {
+		return nil, err
+	}
+	if err := s.planRepo.Create(ctx, plan); err != nil {
+		return nil, fmt.Errorf("failed to create meal plan: %v", err)
+	}
+	return s.planResponse(ctx, plan), nil
+}
+
+// UpdatePlan replaces a meal plan. Subscribers keep the price they subscribed at; item changes
+// apply to deliveries ordered from now on.
+func (s *MealPlanService) UpdatePlan(ctx context.Context, restaurantID, planID string, req *MealPlanRequest) (*MealPlanResponse, error) {
+	plan, err := s.restaurantPlan(ctx, restaurantID, planID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyPlan(ctx, plan, req); err != nil {
+		return nil, err
+	}
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		return nil, fmt.Errorf("failed to update meal plan: %v", err)
+	}
+	return s.planResponse(ctx, plan), nil
+}
+
+// DeletePlan removes a meal plan nobody ever subscribed to
+func (s *MealPlanService) DeletePlan(ctx context.Context, restaurantID, planID string) error {
+	plan, err := s.restaurantPlan(ctx, restaurantID, planID)
+	if err != nil {
+		return err
+	}
+	subscriptions, err := s.subscriptionRepo.GetByRestaurantID(ctx, plan.RestaurantID, plan.ID, "", 1, 0)
+	if err != nil {
+		return fmt.Errorf("failed to check meal plan subscriptions: %v", err)
+	}
+	if len(subscriptions) > 0 {
+		return ErrMealPlanInUse
+	}
+	if err := s.planRepo.Delete(ctx, plan.ID); err != nil {
+		return fmt.Errorf("failed to delete meal plan: %v", err)
+	}
+	return nil
+}
+
+// Subscribe subscribes the customer to a meal plan and bills the first week's deliveries, which
+// has to succeed
+func (s *MealPlanService) Subscribe(ctx context.Context, userID string, req *SubscribeMealPlanRequest) (*MealPlanSubscriptionDetails, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user ID", ErrMealPlanInvalid)
+	}
+	planUUID, err := uuid.Parse(req.PlanID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMealPlanNotFound, req.PlanID)
+	}
+	plan, err := s.planRepo.GetByID(ctx, planUUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrMealPlanNotFound, req.PlanID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !plan.IsActive {
+		return nil, ErrMealPlanUnavailable
+	}
+	restaurant, err := s.restaurantRepo.GetByID(ctx, plan.RestaurantID)
+	if err != nil {
+		return nil, errors.New("restaurant not found")
+	}
+	if err := s.restaurants.EnsureAcceptingOrders(ctx, restaurant.ID); err != nil {
+		return nil, err
+	}
+
+	orderType, err := normalizeOrderType(req.OrderType)
+	if err != nil {
+		return nil, err
+	}
+	if orderType == OrderTypeDineIn {
+		return nil, fmt.Errorf("%w: meal plans are delivered or picked up", ErrMealPlanInvalid)
+	}
+	if !orderTypeEnabled(restaurant, orderType) {
+		return nil, fmt.Errorf("%w: %s", ErrOrderTypeUnavailable, orderType)
+	}
+	var addressUUID *uuid.UUID
+	if orderType == OrderTypeDelivery {
+		parsed, err := uuid.Parse(req.AddressID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: address_id is required for delivery", ErrMealPlanInvalid)
+		}
+		address, err := s.addressRepo.GetByID(ctx, parsed)
+		if err != nil || address.UserID != userUUID {
+			return nil, errors.New("address not found")
+		}
+		addressUUID = &parsed
+	}
+
+	days, err := parseMealPlanDays(req.Days)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := time.Parse("15:04", req.DeliveryTime); err != nil {
+		return nil, fmt.Errorf("%w: delivery_time must be HH:MM", ErrMealPlanInvalid)
+	}
+	loc := restaurantTimeZone(restaurant)
+	now := time.Now()
+	today := now.In(loc).Format(mealPlanDateLayout)
+	if !validMealPlanDate(req.StartDate) || req.StartDate < today {
+		return nil, fmt.Errorf("%w: start_date must be today or later", ErrMealPlanInvalid)
+	}
+	if req.StartDate > now.In(loc).AddDate(0, 0, mealPlanMaxDaysAhead).Format(mealPlanDateLayout) {
+		return nil, fmt.Errorf("%w: subscriptions can start up to %d days ahead", ErrMealPlanInvalid, mealPlanMaxDaysAhead)
+	}
+	if req.EndDate != "" && (!validMealPlanDate(req.EndDate) || req.EndDate < req.StartDate) {
+		return nil, fmt.Errorf("%w: end_date must be on or after start_date", ErrMealPlanInvalid)
+	}
+	if req.PaymentMethod == MealPlanPayMandate && (req.MandateToken == "" || req.MandateCustomer == "" || strings.TrimSpace(req.CustomerEmail) == "") {
+		return nil, fmt.Errorf("%w: mandate_token, mandate_customer_id and customer_email are required to pay by mandate", ErrMealPlanInvalid)
+	}
+
+	subscription := &models.MealPlanSubscription{
+		ID:               uuid.New(),
+		PlanID:           plan.ID,
+		Plan:             *plan,
+		UserID:           userUUID,
+		RestaurantID:     restaurant.ID,
+		Status:           MealPlanActive,
+		Days:             days,
+		DeliveryTime:     req.DeliveryTime,
+		OrderType:        orderType,
+		AddressID:        addressUUID,
+		CustomerName:     strings.TrimSpace(req.CustomerName),
+		CustomerContact:  strings.TrimSpace(req.CustomerContact),
+		PricePerDelivery: plan.PricePerDelivery,
+		PaymentMethod:    req.PaymentMethod,
+		MandateToken:     req.MandateToken,
+		MandateCustomer:  req.MandateCustomer,
+		CustomerEmail:    strings.TrimSpace(req.CustomerEmail),
+		StartDate:        req.StartDate,
+		EndDate:          req.EndDate,
+		Currency:         i18n.CurrencyOf(restaurant.Currency).Code,
+	}
+	if first := s.firstOpenDate(subscription, loc, now); first < subscription.StartDate {
+		subscription.PaidThrough = addMealPlanDays(subscription.StartDate, -1)
+	} else {
+		subscription.PaidThrough = addMealPlanDays(first, -1)
+	}
+	if !s.hasDeliveries(subscription, addMealPlanDays(subscription.PaidThrough, 1), mealPlanBillingDays) {
+		return nil, fmt.Errorf("%w: the schedule has no deliveries in its first week", ErrMealPlanInvalid)
+	}
+
+	bill, err := s.charge(ctx, subscription, loc, now)
+	if err != nil {
+		return nil, err
+	}
+	if bill.Status != "paid" {
+		return nil, fmt.Errorf("%w: %s", ErrMealPlanPayment, bill.FailureReason)
+	}
+	notification, err := mealPlanNotification(subscription, "meal_plan_subscribed", "Meal Plan Started",
+		fmt.Sprintf("You're subscribed to %s. We charged %s for %d deliveries through %s.",
+			plan.Name, i18n.FormatMoney(bill.Amount.Float64(), subscription.Currency), bill.Deliveries, bill.PeriodEnd))
+	if err != nil {
+		return nil, err
+	}
+	changes := repositories.MealPlanChanges{
+		Bills:  []models.MealPlanBill{*bill},
+		Events: []models.OutboxEvent{notification},
+	}
+	err = s.subscriptionRepo.Create(ctx, subscription, changes)
+	if err != nil {
+		s.refundUnsavedCharge(ctx, subscription, bill, err)
+	}
+	if errors.Is(err, repositories.ErrInsufficientWalletBalance) {
+		return nil, fmt.Errorf("%w: wallet balance is below %s", ErrMealPlanPayment, i18n.FormatMoney(bill.Amount.Float64(), subscription.Currency))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save meal plan subscription: %v", err)
+	}
+	s.ledger.MealPlanBills(ctx, subscription, changes.Bills)
+	return s.subscriptionDetails(ctx, subscription), nil
+}
+
+// ListUserSubscriptions returns the customer's subscriptions, newest first
+func (s *MealPlanService) ListUserSubscriptions(ctx context.Context, userID string, page, limit int) ([]MealPlanSubscriptionDetails, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user ID", ErrMealPlanInvalid)
+	}
+	page, limit = normalizePage(page, limit)
+	subscriptions, err := s.subscriptionRepo.GetByUserID(ctx, userUUID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list meal plan subscriptions: %v", err)
+	}
+	return newMealPlanSubscriptionList(subscriptions), nil
+}
+
+// GetUserSubscription returns one of the customer's subscriptions with the week's deliveries
+func (s *MealPlanService) GetUserSubscription(ctx context.Context, userID, subscriptionID string) (*MealPlanSubscriptionDetails, error) {
+	subscription, err := s.userSubscription(ctx, userID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.subscriptionDetails(ctx, subscription), nil
+}
+
+// SkipDelivery skips one delivery before its cutoff. A delivery already billed is credited to the
+// customer's wallet.
+func (s *MealPlanService) SkipDelivery(ctx context.Context, userID, subscriptionID string, req *SkipMealPlanDeliveryRequest) (*MealPlanSubscriptionDetails, error) {
+	subscription, err := s.userSubscription(ctx, userID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Status != MealPlanActive && subscription.Status != MealPlanPaymentDue {
+		return nil, fmt.Errorf("%w: the subscription is %s", ErrMealPlanSubscriptionState, subscription.Status)
+	}
+	if !validMealPlanDate(req.Date) || !s.deliversOn(subscription, req.Date) {
+		return nil, fmt.Errorf("%w: there is no delivery on %s", ErrMealPlanInvalid, req.Date)
+	}
+	loc := s.location(ctx, subscription.RestaurantID)
+	now := time.Now()
+	if req.Date < s.firstOpenDate(subscription, loc, now) {
+		return nil, fmt.Errorf("%w: %s", ErrMealPlanCutoff, req.Date)
+	}
+	existing, err := s.subscriptionRepo.GetDeliveries(ctx, subscription.ID, req.Date, req.Date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load meal plan deliveries: %v", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("%w: the delivery on %s is already %s", ErrMealPlanSubscriptionState, req.Date, existing[0].Status)
+	}
+
+	delivery := models.MealPlanDelivery{
+		SubscriptionID: subscription.ID,
+		PlanID:         subscription.PlanID,
+		RestaurantID:   subscription.RestaurantID,
+		Date:           req.Date,
+		Status:         "skipped",
+	}
+	changes := repositories.MealPlanChanges{}
+	if req.Date <= subscription.PaidThrough {
+		delivery.Credited = subscription.PricePerDelivery
+		credit := mealPlanCredit(subscription, req.Date, req.Date, 1)
+		notification, err := mealPlanNotification(subscription, "meal_plan_delivery_skipped", "Delivery Skipped",
+			fmt.Sprintf("Your %s delivery on %s is skipped and %s is back in your wallet.",
+				subscription.Plan.Name, req.Date, i18n.FormatMoney(credit.Amount.Float64(), subscription.Currency)))
+		if err != nil {
+			return nil, err
+		}
+		changes.Bills = []models.MealPlanBill{credit}
+		changes.Events = []models.OutboxEvent{notification}
+	}
+	changes.Deliveries = []models.MealPlanDelivery{delivery}
+	if err := s.update(ctx, subscription, changes); err != nil {
+		return nil, s.saveError(err)
+	}
+	return s.subscriptionDetails(ctx, subscription), nil
+}
+
+// PauseSubscription pauses deliveries until a date, or until resumed. Deliveries past their
+// cutoff are still made; later ones already billed are credited to the customer's wallet.
+func (s *MealPlanService) PauseSubscription(ctx context.Context, userID, subscriptionID string, req *PauseMealPlanRequest) (*MealPlanSubscriptionDetails, error) {
+	subscription, err := s.userSubscription(ctx, userID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Status != MealPlanActive && subscription.Status != MealPlanPaymentDue {
+		return nil, fmt.Errorf("%w: the subscription is %s", ErrMealPlanSubscriptionState, subscription.Status)
+	}
+	loc := s.location(ctx, subscription.RestaurantID)
+	now := time.Now()
+	today := now.In(loc).Format(mealPlanDateLayout)
+	if req.Until != "" {
+		if !validMealPlanDate(req.Until) || req.Until <= today {
+			return nil, fmt.Errorf("%w: until must be a later date", ErrMealPlanInvalid)
+		}
+		if req.Until > now.In(loc).AddDate(0, 0, mealPlanMaxPauseDays).Format(mealPlanDateLayout) {
+			return nil, fmt.Errorf("%w: subscriptions can be paused for up to %d days", ErrMealPlanInvalid, mealPlanMaxPauseDays)
+		}
+	}
+
+	if _, err := s.placeDueOrders(ctx, subscription, loc, now); err != nil {
+		return nil, err
+	}
+	changes, err := s.refundOpenDates(ctx, subscription, loc, now)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Status = MealPlanPaused
+	subscription.PausedUntil = req.Until
+	if err := s.update(ctx, subscription, changes); err != nil {
+		return nil, s.saveError(err)
+	}
+	return s.subscriptionDetails(ctx, subscription), nil
+}
+
+// ResumeSubscription resumes a paused subscription, or retries the bill of one waiting for
+// payment. A paused subscription resumes even if its next bill fails; it then waits for payment.
+func (s *MealPlanService) ResumeSubscription(ctx context.Context, userID, subscriptionID string) (*MealPlanSubscriptionDetails, error) {
+	subscription, err := s.userSubscription(ctx, userID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	loc := s.location(ctx, subscription.RestaurantID)
+	now := time.Now()
+
+	switch subscription.Status {
+	case MealPlanPaused:
+		// Dates billed under the pause were billed without the paused ones, so credit them and
+		// bill the schedule afresh
+		changes, err := s.refundOpenDates(ctx, subscription, loc, now)
+		if err != nil {
+			return nil, err
+		}
+		subscription.Status = MealPlanActive
+		subscription.PausedUntil = ""
+		if err := s.update(ctx, subscription, changes); err != nil {
+			return nil, s.saveError(err)
+		}
+		if err := s.renew(ctx, subscription, loc, now); err != nil {
+			return nil, s.saveError(err)
+		}
+	case MealPlanPaymentDue:
+		if err := s.renew(ctx, subscription, loc, now); err != nil {
+			return nil, s.saveError(err)
+		}
+		if subscription.Status == MealPlanPaymentDue {
+			return nil, ErrMealPlanPayment
+		}
+	default:
+		return nil, fmt.Errorf("%w: the subscription is %s", ErrMealPlanSubscriptionState, subscription.Status)
+	}
+	return s.subscriptionDetails(ctx, subscription), nil
+}
+
+// CancelSubscription ends the subscription. Deliveries past their cutoff are still made; later
+// ones already billed are credited to the customer's wallet.
+func (s *MealPlanService) CancelSubscription(ctx context.Context, userID, subscriptionID string) (*MealPlanSubscriptionDetails, error) {
+	subscription, err := s.userSubscription(ctx, userID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Status == MealPlanCancelled || subscription.Status == MealPlanEnded {
+		return nil, fmt.Errorf("%w: the subscription is %s", ErrMealPlanSubscriptionState, subscription.Status)
+	}
+	loc := s.location(ctx, subscription.RestaurantID)
+	now := time.Now()
+
+	if _, err := s.placeDueOrders(ctx, subscription, loc, now); err != nil {
+		return nil, err
+	}
+	changes, err := s.refundOpenDates(ctx, subscription, loc, now)
+	if err != nil {
+		return nil, err
+	}
+	lastDate := addMealPlanDays(s.firstOpenDate(subscription, loc, now), -1)
+	if subscription.EndDate == "" || subscription.EndDate > lastDate {
+		subscription.EndDate = lastDate
+	}
+	subscription.Status = MealPlanCancelled
+	subscription.PausedUntil = ""
+	subscription.CancelledAt = &now
+	if err := s.update(ctx, subscription, changes); err != nil {
+		return nil, s.saveError(err)
+	}
+	return s.subscriptionDetails(ctx, subscription), nil
+}
+
+// ListRestaurantSubscriptions returns the restaurant's subscriptions, narrowed to a plan and a
+// status when set
+func (s *MealPlanService) ListRestaurantSubscriptions(ctx context.Context, restaurantID, planID, status string, page, limit int) ([]MealPlanSubscriptionDetails, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrMealPlanInvalid)
+	}
+	var planUUID uuid.UUID
+	if planID != "" {
+		if planUUID, err = uuid.Parse(planID); err != nil {
+			return nil, fmt.Errorf("%w: invalid plan ID", ErrMealPlanInvalid)
+		}
+	}
+	page, limit = normalizePage(page, limit)
+	subscriptions, err := s.subscriptionRepo.GetByRestaurantID(ctx, restaurantUUID, planUUID, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list meal plan subscriptions: %v", err)
+	}
+	return newMealPlanSubscriptionList(subscriptions), nil
+}
+
+// GetAnalytics summarizes the restaurant's plans: subscribers by status, and the deliveries and
+// bills dated in the range, by default the last 30 days
+func (s *MealPlanService) GetAnalytics(ctx context.Context, restaurantID, from, to string) (*MealPlanAnalytics, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrMealPlanInvalid)
+	}
+	today := time.Now().In(s.location(ctx, restaurantUUID))
+	if to == "" {
+		to = today.Format(mealPlanDateLayout)
+	}
+	if from == "" {
+		from = today.AddDate(0, 0, -29).Format(mealPlanDateLayout)
+	}
+	if !validMealPlanDate(from) || !validMealPlanDate(to) || from > to {
+		return nil, fmt.Errorf("%w: from and to must be dates, from no later than to", ErrMealPlanInvalid)
+	}
+
+	stats, err := s.subscriptionRepo.GetStats(ctx, restaurantUUID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize meal plans: %v", err)
+	}
+	analytics := &MealPlanAnalytics{From: from, To: to, Plans: make([]MealPlanPlanStats, 0, len(stats))}
+	for _, plan := range stats {
+		planStats := MealPlanPlanStats{
+			MealPlanStats: plan,
+			Subscribers:   plan.Active + plan.Paused + plan.PaymentDue,
+			NetRevenue:    plan.Charged - plan.Credited,
+		}
+		if deliveries := plan.Ordered + plan.Skipped; deliveries > 0 {
+			planStats.SkipRate = float64(plan.Skipped) * 100 / float64(deliveries)
+		}
+		analytics.Plans = append(analytics.Plans, planStats)
+	}
+	return analytics, nil
+}
+
+// GenerateOrders places the orders of deliveries whose cutoff has passed, bills subscriptions
+// whose paid deliveries are running out, and resumes and ends subscriptions on their dates. It
+// returns how many orders it placed.
+func (s *MealPlanService) GenerateOrders(ctx context.Context) (int, error) {
+	subscriptions, err := s.subscriptionRepo.GetRunning(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get meal plan subscriptions: %v", err)
+	}
+
+	now := time.Now()
+	locations := make(map[uuid.UUID]*time.Location)
+	placed := 0
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		loc, ok := locations[subscription.RestaurantID]
+		if !ok {
+			loc = s.location(ctx, subscription.RestaurantID)
+			locations[subscription.RestaurantID] = loc
+		}
+		count, err := s.runSubscription(ctx, subscription, loc, now)
+		placed += count
+		if err != nil && !errors.Is(err, repositories.ErrVersionConflict) {
+			log.Printf("Failed to run meal plan subscription %s: %v", subscription.ID, err)
+		}
+	}
+	if placed > 0 {
+		log.Printf("🍱 Placed %d meal plan orders", placed)
+	}
+	return placed, nil
+}
+
+// runSubscription moves one subscription along: resuming or ending it on its date, billing its
+// next week and placing its due orders
+func (s *MealPlanService) runSubscription(ctx context.Context, subscription *models.MealPlanSubscription, loc *time.Location, now time.Time) (int, error) {
+	today := now.In(loc).Format(mealPlanDateLayout)
+	if subscription.Status == MealPlanPaused && subscription.PausedUntil != "" && subscription.PausedUntil <= today {
+		subscription.Status = MealPlanActive
+		subscription.PausedUntil = ""
+		if err := s.update(ctx, subscription, repositories.MealPlanChanges{}); err != nil {
+			return 0, err
+		}
+	}
+	if subscription.EndDate != "" && subscription.EndDate < today {
+		subscription.Status = MealPlanEnded
+		return 0, s.update(ctx, subscription, repositories.MealPlanChanges{})
+	}
+
+	// Bill the next week once the first unbilled delivery is still open but next to close.
+	// Subscriptions waiting for payment are billed again when the customer resumes them.
+	renewable := subscription.Status == MealPlanActive || (subscription.Status == MealPlanPaused && subscription.PausedUntil != "")
+	if renewable && subscription.PaidThrough < s.firstOpenDate(subscription, loc, now) &&
+		(subscription.EndDate == "" || subscription.PaidThrough < subscription.EndDate) {
+		if err := s.renew(ctx, subscription, loc, now); err != nil {
+			return 0, err
+		}
+	}
+	return s.placeDueOrders(ctx, subscription, loc, now)
+}
+
+// renew bills the subscription's next week and saves it. A declined payment leaves it waiting
+// for payment; deliveries already paid for are still made.
+func (s *MealPlanService) renew(ctx context.Context, subscription *models.MealPlanSubscription, loc *time.Location, now time.Time) error {
+	status, paidThrough := subscription.Status, subscription.PaidThrough
+	bill, err := s.charge(ctx, subscription, loc, now)
+	if err != nil {
+		return err
+	}
+	if bill == nil {
+		return s.update(ctx, subscription, repositories.MealPlanChanges{})
+	}
+
+	if bill.Status == "paid" {
+		if subscription.Status == MealPlanPaymentDue {
+			subscription.Status = MealPlanActive
+		}
+		notification, err := mealPlanNotification(subscription, "meal_plan_renewed", "Meal Plan Renewed",
+			fmt.Sprintf("We charged %s for %d %s deliveries through %s.",
+				i18n.FormatMoney(bill.Amount.Float64(), subscription.Currency), bill.Deliveries, subscription.Plan.Name, bill.PeriodEnd))
+		if err != nil {
+			return err
+		}
+		err = s.update(ctx, subscription, repositories.MealPlanChanges{
+			Bills:  []models.MealPlanBill{*bill},
+			Events: []models.OutboxEvent{notification},
+		})
+		if !errors.Is(err, repositories.ErrInsufficientWalletBalance) {
+			if err != nil {
+				s.refundUnsavedCharge(ctx, subscription, bill, err)
+			}
+			return err
+		}
+		subscription.Status, subscription.PaidThrough = status, paidThrough
+		bill.Status = "failed"
+		bill.FailureReason = repositories.ErrInsufficientWalletBalance.Error()
+	}
+
+	subscription.Status = MealPlanPaymentDue
+	notification, err := mealPlanNotification(subscription, "meal_plan_payment_failed", "Meal Plan Payment Failed",
+		fmt.Sprintf("We couldn't charge %s for your %s deliveries from %s (%s). Resume the plan to pay and keep your deliveries coming.",
+			i18n.FormatMoney(bill.Amount.Float64(), subscription.Currency), subscription.Plan.Name, bill.PeriodStart, bill.FailureReason))
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, subscription, repositories.MealPlanChanges{
+		Bills:  []models.MealPlanBill{*bill},
+		Events: []models.OutboxEvent{notification},
+	})
+}
+
+// charge bills the week after the subscription's paid deliveries, from the first delivery still
+// open, per delivery date left after skips. A mandate is charged here, its bill saved as pending
+// first so the charge is never unrecorded; the subscription save then marks it paid. A wallet
+// bill is marked paid and taken from the wallet when saved. PaidThrough moves to the end of the
+// week unless the mandate was declined. There is no bill when the week has no deliveries.
+func (s *MealPlanService) charge(ctx context.Context, subscription *models.MealPlanSubscription, loc *time.Location, now time.Time) (*models.MealPlanBill, error) {
+	from := addMealPlanDays(subscription.PaidThrough, 1)
+	if open := s.firstOpenDate(subscription, loc, now); from < open {
+		from = open
+	}
+	if from < subscription.StartDate {
+		from = subscription.StartDate
+	}
+	to := addMealPlanDays(from, mealPlanBillingDays-1)
+	if subscription.EndDate != "" && to > subscription.EndDate {
+		to = subscription.EndDate
+	}
+
+	skipped, err := s.subscriptionRepo.GetDeliveries(ctx, subscription.ID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load meal plan deliveries: %v", err)
+	}
+	taken := make(map[string]bool, len(skipped))
+	for _, delivery := range skipped {
+		taken[delivery.Date] = true
+	}
+	deliveries := 0
+	for date := from; date <= to; date = addMealPlanDays(date, 1) {
+		if s.deliversOn(subscription, date) && !taken[date] {
+			deliveries++
+		}
+	}
+	if deliveries == 0 {
+		if to > subscription.PaidThrough {
+			subscription.PaidThrough = to
+		}
+		return nil, nil
+	}
+
+	bill := &models.MealPlanBill{
+		SubscriptionID: subscription.ID,
+		PlanID:         subscription.PlanID,
+		RestaurantID:   subscription.RestaurantID,
+		UserID:         subscription.UserID,
+		Kind:           "charge",
+		PeriodStart:    from,
+		PeriodEnd:      to,
+		Deliveries:     deliveries,
+		Amount:         subscription.PricePerDelivery * models.Money(deliveries),
+		Method:         subscription.PaymentMethod,
+		Status:         "paid",
+	}
+	if subscription.PaymentMethod == MealPlanPayMandate {
+		bill.ID = uuid.New()
+		bill.Status = "pending"
+		if err := s.subscriptionRepo.CreateBill(ctx, bill); err != nil {
+			return nil, fmt.Errorf("failed to save meal plan bill: %v", err)
+		}
+
+		description := fmt.Sprintf("%s, %d deliveries from %s", subscription.Plan.Name, deliveries, from)
+		mandate := RecurringMandate{
+			CustomerID: subscription.MandateCustomer,
+			Token:      subscription.MandateToken,
+			Email:      subscription.CustomerEmail,
+			Contact:    subscription.CustomerContact,
+		}
+		paymentID, err := s.razorpay.ChargeRecurring(ctx, mandate, bill.Amount, subscription.Currency, bill.ID.String(), description)
+		if err != nil {
+			bill.Status = "failed"
+			bill.FailureReason = err.Error()
+			if err := s.subscriptionRepo.UpdateBill(ctx, bill); err != nil {
+				log.Printf("Failed to mark meal plan bill %s failed: %v", bill.ID, err)
+			}
+			return bill, nil
+		}
+		bill.Status = "paid"
+		bill.GatewayPaymentID = paymentID
+	}
+	subscription.PaidThrough = to
+	return bill, nil
+}
+
+// refundUnsavedCharge refunds a mandate charge whose subscription could not be saved, so the
+// customer does not pay for deliveries that were never booked. A charge that cannot be refunded
+// stays pending with its gateway payment for finance to settle by hand.
+func (s *MealPlanService) refundUnsavedCharge(ctx context.Context, subscription *models.MealPlanSubscription, bill *models.MealPlanBill, cause error) {
+	if bill.Method != MealPlanPayMandate || bill.Status != "paid" {
+		return
+	}
+	bill.FailureReason = fmt.Sprintf("subscription not saved: %v", cause)
+	if _, err := s.razorpay.RefundRecurring(ctx, bill.GatewayPaymentID, bill.Amount, subscription.Currency, bill.ID.String()); err != nil {
+		log.Printf("Failed to refund meal plan bill %s (payment %s): %v", bill.ID, bill.GatewayPaymentID, err)
+		bill.Status = "pending"
+	} else {
+		bill.Status = "refunded"
+	}
+	if err := s.subscriptionRepo.UpdateBill(ctx, bill); err != nil {
+		log.Printf("Failed to save meal plan bill %s as %s: %v", bill.ID, bill.Status, err)
+	}
+}
+
+// refundOpenDates credits the customer's wallet for billed deliveries still open to changes and
+// moves PaidThrough back before them
+func (s *MealPlanService) refundOpenDates(ctx context.Context, subscription *models.MealPlanSubscription, loc *time.Location, now time.Time) (repositories.MealPlanChanges, error) {
+	from := s.firstOpenDate(subscription, loc, now)
+	if from < subscription.StartDate {
+		from = subscription.StartDate
+	}
+	if subscription.PaidThrough < from {
+		return repositories.MealPlanChanges{}, nil
+	}
+	to := subscription.PaidThrough
+
+	existing, err := s.subscriptionRepo.GetDeliveries(ctx, subscription.ID, from, to)
+	if err != nil {
+		return repositories.MealPlanChanges{}, fmt.Errorf("failed to load meal plan deliveries: %v", err)
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, delivery := range existing {
+		taken[delivery.Date] = true
+	}
+	deliveries := 0
+	for date := from; date <= to; date = addMealPlanDays(date, 1) {
+		if s.deliversOn(subscription, date) && !taken[date] {
+			deliveries++
+		}
+	}
+	subscription.PaidThrough = addMealPlanDays(from, -1)
+	if deliveries == 0 {
+		return repositories.MealPlanChanges{}, nil
+	}
+
+	credit := mealPlanCredit(subscription, from, to, deliveries)
+	notification, err := mealPlanNotification(subscription, "meal_plan_credited", "Meal Plan Credit",
+		fmt.Sprintf("%s for %d %s deliveries is back in your wallet.",
+			i18n.FormatMoney(credit.Amount.Float64(), subscription.Currency), deliveries, subscription.Plan.Name))
+	if err != nil {
+		return repositories.MealPlanChanges{}, err
+	}
+	return repositories.MealPlanChanges{
+		Bills:  []models.MealPlanBill{credit},
+		Events: []models.OutboxEvent{notification},
+	}, nil
+}
+
+// placeDueOrders places the orders of paid deliveries whose cutoff has passed
+func (s *MealPlanService) placeDueOrders(ctx context.Context, subscription *models.MealPlanSubscription, loc *time.Location, now time.Time) (int, error) {
+	placed := 0
+	open := s.firstOpenDate(subscription, loc, now)
+	for date := now.In(loc).Format(mealPlanDateLayout); date < open; date = addMealPlanDays(date, 1) {
+		if date > subscription.PaidThrough || !s.deliversOn(subscription, date) {
+			continue
+		}
+		deliverAt := mealPlanDeliveryTime(subscription, date, loc)
+		if !deliverAt.After(now) {
+			continue
+		}
+
+		delivery := &models.MealPlanDelivery{
+			ID:             uuid.New(),
+			SubscriptionID: subscription.ID,
+			PlanID:         subscription.PlanID,
+			RestaurantID:   subscription.RestaurantID,
+			Date:           date,
+			Status:         "ordered",
+		}
+		claimed, err := s.subscriptionRepo.ClaimDelivery(ctx, delivery)
+		if err != nil {
+			return placed, fmt.Errorf("failed to claim meal plan delivery: %v", err)
+		}
+		if !claimed {
+			continue // ordered or skipped already
+		}
+		order, err := s.placeOrder(ctx, subscription, date, deliverAt)
+		if err != nil {
+			if releaseErr := s.subscriptionRepo.ReleaseDelivery(ctx, delivery.ID); releaseErr != nil {
+				log.Printf("Failed to release meal plan delivery %s after a failed order: %v", delivery.ID, releaseErr)
+			}
+			return placed, err
+		}
+		if err := s.subscriptionRepo.SetDeliveryOrder(ctx, delivery.ID, order.ID); err != nil {
+			log.Printf("Failed to link meal plan delivery %s to order %s: %v", delivery.ID, order.ID, err)
+		}
+		placed++
+	}
+	return placed, nil
+}
+
+// placeOrder creates a delivery's order, scheduled for the delivery time and paid for by the
+// subscription's bill
+func (s *MealPlanService) placeOrder(ctx context.Context, subscription *models.MealPlanSubscription, date string, deliverAt time.Time) (*models.Order, error) {
+	items := models.DecodeCartItems(subscription.Plan.Items)
+	lines, menuTotal, err := priceCartItems(ctx, s.productRepo, items)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("meal plan %s has no available items", subscription.PlanID)
+	}
+
+	cart := &models.Cart{
+		UserID:       subscription.UserID,
+		RestaurantID: subscription.RestaurantID,
+		Items:        subscription.Plan.Items,
+		TotalAmount:  subscription.PricePerDelivery,
+		Status:       "ordered",
+	}
+	if err := s.cartRepo.Create(ctx, cart); err != nil {
+		return nil, fmt.Errorf("failed to create meal plan cart: %v", err)
+	}
+
+	order := &models.Order{
+		UserID:          subscription.UserID,
+		RestaurantID:    subscription.RestaurantID,
+		CartID:          cart.ID,
+		OrderType:       subscription.OrderType,
+		OrderStatus:     "scheduled",
+		ScheduledFor:    &deliverAt,
+		AddressID:       subscription.AddressID,
+		TotalAmount:     subscription.PricePerDelivery,
+		CustomerName:    subscription.CustomerName,
+		CustomerContact: subscription.CustomerContact,
+		CustomerNotes:   fmt.Sprintf("%s meal plan", subscription.Plan.Name),
+		LineItems:       models.EncodeOrderLineItems(orderLineItems(lines)),
+		PricingDetails: models.JSONB{
+			"meal_plan_id":              subscription.PlanID.String(),
+			"meal_plan_subscription_id": subscription.ID.String(),
+			"delivery_date":             date,
+			"menu_total":                menuTotal,
+			"price_per_delivery":        subscription.PricePerDelivery,
+		},
+		Currency:  subscription.Currency,
+		Language:  i18n.LanguageFromContext(ctx),
+		CreatedAt: time.Now(),
+	}
+	if err := assignDeliveryCode(order); err != nil {
+		return nil, err
+	}
+	if order.OrderType == OrderTypeDelivery {
+		s.restaurants.PromiseDelivery(ctx, subscription.RestaurantID, 0, order.ScheduledFor).apply(order)
+	} else {
+		s.restaurants.PromiseReady(ctx, subscription.RestaurantID, order.ScheduledFor).apply(order)
+	}
+	appendOrderLog(order, order.OrderStatus, fmt.Sprintf("Meal plan delivery for %s", date))
+	if err := s.orderRepo.Create(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to create meal plan order: %v", err)
+	}
+
+	payment := &models.Payment{
+		ID:        uuid.New(),
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		Amount:    order.TotalAmount,
+		Method:    PaymentMethodMealPlan,
+		Status:    "success",
+		CreatedAt: time.Now(),
+		Metadata: models.JSONB{
+			"meal_plan_subscription_id": subscription.ID.String(),
+			"delivery_date":             date,
+		},
+	}
+	if err := s.paymentRepo.Create(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to create payment record: %v", err)
+	}
+	order.PaymentID = &payment.ID
+
+	created, err := orderCreatedEvent(order)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.orderRepo.UpdateWithEvents(ctx, order, []models.OutboxEvent{created}); err != nil {
+		return nil, fmt.Errorf("failed to update meal plan order: %v", err)
+	}
+	return order, nil
+}
+
+// deliversOn reports whether the subscription's schedule has a delivery on the date
+func (s *MealPlanService) deliversOn(subscription *models.MealPlanSubscription, date string) bool {
+	if date < subscription.StartDate || (subscription.EndDate != "" && date > subscription.EndDate) {
+		return false
+	}
+	if subscription.Status == MealPlanPaused && (subscription.PausedUntil == "" || date < subscription.PausedUntil) {
+		return false
+	}
+	if subscription.Days == "" {
+		return true
+	}
+	day, err := time.Parse(mealPlanDateLayout, date)
+	if err != nil {
+		return false
+	}
+	weekday := mealPlanWeekdays[(int(day.Weekday())+6)%7]
+	return strings.Contains(subscription.Days, weekday)
+}
+
+// hasDeliveries reports whether the schedule has a delivery in the days from the date
+func (s *MealPlanService) hasDeliveries(subscription *models.MealPlanSubscription, from string, days int) bool {
+	for i := 0; i < days; i++ {
+		if s.deliversOn(subscription, addMealPlanDays(from, i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstOpenDate returns the first date, from today, whose delivery cutoff is still ahead; dates
+// before it are ordered and can no longer be skipped
+func (s *MealPlanService) firstOpenDate(subscription *models.MealPlanSubscription, loc *time.Location, now time.Time) string {
+	cutoff := time.Duration(subscription.Plan.CutoffHours) * time.Hour
+	date := now.In(loc).Format(mealPlanDateLayout)
+	for !mealPlanDeliveryTime(subscription, date, loc).Add(-cutoff).After(now) {
+		date = addMealPlanDays(date, 1)
+	}
+	return date
+}
+
+// upcoming describes the subscription's deliveries in the next week
+func (s *MealPlanService) upcoming(ctx context.Context, subscription *models.MealPlanSubscription, loc *time.Location, now time.Time) []MealPlanDeliveryDate {
+	from := now.In(loc).Format(mealPlanDateLayout)
+	to := addMealPlanDays(from, mealPlanBillingDays-1)
+	existing, err := s.subscriptionRepo.GetDeliveries(ctx, subscription.ID, from, to)
+	if err != nil {
+		return nil
+	}
+	byDate := make(map[string]models.MealPlanDelivery, len(existing))
+	for _, delivery := range existing {
+		byDate[delivery.Date] = delivery
+	}
+
+	cutoff := time.Duration(subscription.Plan.CutoffHours) * time.Hour
+	running := subscription.Status != MealPlanCancelled && subscription.Status != MealPlanEnded
+	var dates []MealPlanDeliveryDate
+	for date := from; date <= to; date = addMealPlanDays(date, 1) {
+		deliverAt := mealPlanDeliveryTime(subscription, date, loc)
+		entry := MealPlanDeliveryDate{Date: date, DeliverAt: deliverAt}
+		if delivery, ok := byDate[date]; ok {
+			entry.Status = delivery.Status
+			entry.OrderID = delivery.OrderID
+		} else if running && s.deliversOn(subscription, date) && deliverAt.After(now) {
+			entry.Status = "unbilled"
+			if date <= subscription.PaidThrough {
+				entry.Status = "scheduled"
+			}
+			if skipBy := deliverAt.Add(-cutoff); skipBy.After(now) {
+				entry.SkipBy = &skipBy
+			}
+		} else {
+			continue
+		}
+		dates = append(dates, entry)
+	}
+	return dates
+}
+
+// applyPlan validates the request against the restaurant's menu and copies it onto the plan
+func (s *MealPlanService) applyPlan(ctx context.Context, plan *models.MealPlan, req *MealPlanRequest) error {
+	if _, _, err := priceRestaurantItems(ctx, s.productRepo, plan.RestaurantID, req.Items, ErrMealPlanInvalid); err != nil {
+		return err
+	}
+	if req.CutoffHours < 1 || req.CutoffHours > 72 {
+		return fmt.Errorf("%w: cutoff_hours must be between 1 and 72", ErrMealPlanInvalid)
+	}
+	plan.Name = strings.TrimSpace(req.Name)
+	plan.Description = strings.TrimSpace(req.Description)
+	plan.Items = models.EncodeCartItems(req.Items)
+	plan.PricePerDelivery = req.PricePerDelivery
+	plan.CutoffHours = req.CutoffHours
+	if req.IsActive != nil {
+		plan.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// planResponse prices the plan's items at today's menu prices
+func (s *MealPlanService) planResponse(ctx context.Context, plan *models.MealPlan) *MealPlanResponse {
+	response := &MealPlanResponse{MealPlan: *plan, Items: []CartItemResponse{}}
+	lines, menuPrice, err := priceCartItems(ctx, s.productRepo, models.DecodeCartItems(plan.Items))
+	if err == nil && lines != nil {
+		response.Items = lines
+		response.MenuPrice = menuPrice
+	}
+	return response
+}
+
+// restaurantPlan loads one of the restaurant's plans
+func (s *MealPlanService) restaurantPlan(ctx context.Context, restaurantID, planID string) (*models.MealPlan, error) {
+	id, err := uuid.Parse(planID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMealPlanNotFound, planID)
+	}
+	plan, err := s.planRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && plan.RestaurantID.String() != restaurantID) {
+		return nil, fmt.Errorf("%w: %s", ErrMealPlanNotFound, planID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// userSubscription loads one of the customer's subscriptions
+func (s *MealPlanService) userSubscription(ctx context.Context, userID, subscriptionID string) (*models.MealPlanSubscription, error) {
+	id, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMealPlanSubscriptionNotFound, subscriptionID)
+	}
+	subscription, err := s.subscriptionRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && subscription.UserID.String() != userID) {
+		return nil, fmt.Errorf("%w: %s", ErrMealPlanSubscriptionNotFound, subscriptionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// subscriptionDetails describes the subscription with the week's deliveries and recent bills
+func (s *MealPlanService) subscriptionDetails(ctx context.Context, subscription *models.MealPlanSubscription) *MealPlanSubscriptionDetails {
+	details := newMealPlanSubscriptionDetails(subscription)
+	details.Upcoming = s.upcoming(ctx, subscription, s.location(ctx, subscription.RestaurantID), time.Now())
+	if bills, err := s.subscriptionRepo.GetBills(ctx, subscription.ID, 10); err == nil {
+		details.Bills = bills
+	}
+	return details
+}
+
+// location returns the restaurant's time zone, which delivery dates and times are in
+func (s *MealPlanService) location(ctx context.Context, restaurantID uuid.UUID) *time.Location {
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantID)
+	if err != nil {
+		return time.Local
+	}
+	return restaurantTimeZone(restaurant)
+}
+
+// update saves the subscription with its changes and posts its paid bills to the ledger
+func (s *MealPlanService) update(ctx context.Context, subscription *models.MealPlanSubscription, changes repositories.MealPlanChanges) error {
+	if err := s.subscriptionRepo.Update(ctx, subscription, changes); err != nil {
+		return err
+	}
+	s.ledger.MealPlanBills(ctx, subscription, changes.Bills)
+	return nil
+}
+
+// saveError reports a subscription changed by another request as a conflict
+func (s *MealPlanService) saveError(err error) error {
+	if errors.Is(err, repositories.ErrVersionConflict) {
+		return fmt.Errorf("%w: the subscription was changed meanwhile", ErrMealPlanSubscriptionState)
+	}
+	return fmt.Errorf("failed to save meal plan subscription: %v", err)
+}
+
+func newMealPlanSubscriptionDetails(subscription *models.MealPlanSubscription) *MealPlanSubscriptionDetails {
+	days := mealPlanWeekdays
+	if subscription.Days != "" {
+		days = strings.Split(subscription.Days, ",")
+	}
+	return &MealPlanSubscriptionDetails{MealPlanSubscription: *subscription, Days: days}
+}
+
+func newMealPlanSubscriptionList(subscriptions []models.MealPlanSubscription) []MealPlanSubscriptionDetails {
+	response := make([]MealPlanSubscriptionDetails, 0, len(subscriptions))
+	for i := range subscriptions {
+		response = append(response, *newMealPlanSubscriptionDetails(&subscriptions[i]))
+	}
+	return response
+}
+
+// parseMealPlanDays normalizes delivery days to comma-separated weekdays in week order, empty
+// for every day
+func parseMealPlanDays(days []string) (string, error) {
+	chosen := make(map[string]bool, len(days))
+	for _, day := range days {
+		day = strings.ToLower(strings.TrimSpace(day))
+		if len(day) > 3 {
+			day = day[:3]
+		}
+		known := false
+		for _, weekday := range mealPlanWeekdays {
+			known = known || weekday == day
+		}
+		if !known {
+			return "", fmt.Errorf("%w: unknown delivery day %q", ErrMealPlanInvalid, day)
+		}
+		chosen[day] = true
+	}
+	if len(chosen) == 0 || len(chosen) == len(mealPlanWeekdays) {
+		return "", nil
+	}
+	var ordered []string
+	for _, weekday := range mealPlanWeekdays {
+		if chosen[weekday] {
+			ordered = append(ordered, weekday)
+		}
+	}
+	return strings.Join(ordered, ","), nil
+}
+
+// mealPlanDeliveryTime is when the subscription delivers on the date, in the restaurant's time zone
+func mealPlanDeliveryTime(subscription *models.MealPlanSubscription, date string, loc *time.Location) time.Time {
+	at, err := time.ParseInLocation(mealPlanDateLayout+" 15:04", date+" "+subscription.DeliveryTime, loc)
+	if err != nil {
+		day, _ := time.ParseInLocation(mealPlanDateLayout, date, loc)
+		return day
+	}
+	return at
+}
+
+func validMealPlanDate(date string) bool {
+	_, err := time.Parse(mealPlanDateLayout, date)
+	return err == nil
+}
+
+// addMealPlanDays moves a YYYY-MM-DD date by whole days; an empty or invalid date is returned as is
+func addMealPlanDays(date string, days int) string {
+	day, err := time.Parse(mealPlanDateLayout, date)
+	if err != nil {
+		return date
+	}
+	return day.AddDate(0, 0, days).Format(mealPlanDateLayout)
+}
+
+// restaurantTimeZone returns the restaurant's time zone, or the server's when it has none
+func restaurantTimeZone(restaurant *models.Restaurant) *time.Location {
+	if restaurant.TimeZone != "" {
+		if loc, err := time.LoadLocation(restaurant.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// mealPlanCredit is a wallet credit for billed deliveries of the subscription that will not be made
+func mealPlanCredit(subscription *models.MealPlanSubscription, from, to string, deliveries int) models.MealPlanBill {
+	return models.MealPlanBill{
+		SubscriptionID: subscription.ID,
+		PlanID:         subscription.PlanID,
+		RestaurantID:   subscription.RestaurantID,
+		UserID:         subscription.UserID,
+		Kind:           "credit",
+		PeriodStart:    from,
+		PeriodEnd:      to,
+		Deliveries:     deliveries,
+		Amount:         subscription.PricePerDelivery * models.Money(deliveries),
+		Method:         MealPlanPayWallet,
+		Status:         "paid",
+	}
+}
+
+func mealPlanNotification(subscription *models.MealPlanSubscription, eventType, title, message string) (models.OutboxEvent, error) {
+	return NewOutboxEvent("notification_events", subscription.UserID.String(), messaging.NotificationEvent{
+		Type:    eventType,
+		UserID:  subscription.UserID.String(),
+		Title:   title,
+		Message: message,
+		Metadata: map[string]interface{}{
+			"meal_plan_subscription_id": subscription.ID.String(),
+			"restaurant_id":             subscription.RestaurantID.String(),
+		},
+	})
+}
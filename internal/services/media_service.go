@@ -0,0 +1,867 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/cache"
+	"golang-food-backend/pkg/storage"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MediaOwnerProduct    = "product"
+	MediaOwnerCategory   = "category"
+	MediaOwnerRestaurant = "restaurant"
+	MediaOwnerDocument   = "restaurant_document" // onboarding KYC files, kept private
+	MediaOwnerInvoice    = "order_invoice"       // generated invoice PDFs, kept private
+	MediaOwnerSupport    = "support_attachment"  // photos and PDFs on support tickets, kept private
+
+	MediaStatusPending = "pending"
+	MediaStatusReady   = "ready"
+
+	mediaMinDimension    = 100
+	mediaMaxDimension    = 6000
+	mediaPendingTTL      = 24 * time.Hour
+	mediaOrphanGrace     = time.Hour
+	mediaCleanupInterval = 6 * time.Hour
+	mediaCleanupBatch    = 100
+	mediaJPEGQuality     = 85
+)
+
+// mediaThumbnailWidths are the generated sizes; images narrower than a size skip it
+var mediaThumbnailWidths = map[string]int{
+	"thumb":  150,
+	"small":  320,
+	"medium": 640,
+	"large":  1024,
+}
+
+var mediaContentTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+var documentContentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+// MediaPolicy holds upload limits from configuration
+type MediaPolicy struct {
+	UploadURLTTL   time.Duration
+	MaxUploadBytes int64
+}
+
+type MediaService struct {
+	mediaRepo      repositories.MediaRepository
+	productRepo    repositories.ProductRepository
+	categoryRepo   repositories.ProductCategoryRepository
+	restaurantRepo repositories.RestaurantRepository
+	documentRepo   repositories.RestaurantDocumentRepository
+	invoiceRepo    repositories.InvoiceRepository
+	supportRepo    repositories.SupportTicketRepository
+	storage        *storage.ObjectStorage
+	cache          *cache.RedisCache
+	policy         MediaPolicy
+	stopChan       chan bool
+	isRunning      bool
+}
+
+func NewMediaService(
+	mediaRepo repositories.MediaRepository,
+	productRepo repositories.ProductRepository,
+	categoryRepo repositories.ProductCategoryRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	documentRepo repositories.RestaurantDocumentRepository,
+	invoiceRepo repositories.InvoiceRepository,
+	supportRepo repositories.SupportTicketRepository,
+	storage *storage.ObjectStorage,
+	cache *cache.RedisCache,
+	policy MediaPolicy,
+) *MediaService {
+	return &MediaService{
+		mediaRepo:      mediaRepo,
+		productRepo:    productRepo,
+		categoryRepo:   categoryRepo,
+		restaurantRepo: restaurantRepo,
+		documentRepo:   documentRepo,
+		invoiceRepo:    invoiceRepo,
+		supportRepo:    supportRepo,
+		storage:        storage,
+		cache:          cache,
+		policy:         policy,
+		stopChan:       make(chan bool),
+	}
+}
+
+type CreateUploadRequest struct {
+	OwnerType   string `json:"owner_type" binding:"required,oneof=product category restaurant"`
+	OwnerID     string `json:"owner_id" binding:"required"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type" binding:"required"`
+	Size        int64  `json:"size" binding:"required,gt=0"`
+}
+
+type UploadResponse struct {
+	Media     *models.Media     `json:"media"`
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// CreateUpload registers a pending upload for a product, category or the restaurant itself
+// and returns a pre-signed URL the client uploads the file to directly
+func (s *MediaService) CreateUpload(ctx context.Context, userID, restaurantID string, req *CreateUploadRequest) (*UploadResponse, error) {
+	ext, ok := mediaContentTypes[req.ContentType]
+	if !ok {
+		return nil, errors.New("unsupported content type, expected image/jpeg or image/png")
+	}
+	if req.Size > s.policy.MaxUploadBytes {
+		return nil, fmt.Errorf("file is larger than %d MB", s.policy.MaxUploadBytes>>20)
+	}
+	if err := s.checkOwner(ctx, restaurantID, req.OwnerType, req.OwnerID); err != nil {
+		return nil, err
+	}
+
+	media := &models.Media{
+		RestaurantID: restaurantID,
+		OwnerType:    req.OwnerType,
+		OwnerID:      req.OwnerID,
+		UploadedBy:   userID,
+		FileName:     path.Base(req.FileName),
+		ContentType:  req.ContentType,
+		Size:         req.Size,
+		Key:          fmt.Sprintf("media/%s/%s/%s%s", restaurantID, req.OwnerType, uuid.New().String(), ext),
+		Status:       MediaStatusPending,
+	}
+	if err := s.mediaRepo.Create(ctx, media); err != nil {
+		return nil, fmt.Errorf("failed to create upload: %v", err)
+	}
+
+	return &UploadResponse{
+		Media:     media,
+		UploadURL: s.storage.PresignPut(media.Key, s.policy.UploadURLTTL),
+		Method:    "PUT",
+		Headers:   map[string]string{"Content-Type": media.ContentType},
+		ExpiresAt: time.Now().Add(s.policy.UploadURLTTL),
+	}, nil
+}
+
+// ConfirmUpload validates an uploaded image, generates thumbnails and attaches it to its owner
+func (s *MediaService) ConfirmUpload(ctx context.Context, restaurantID, mediaID string) (*models.Media, error) {
+	media, err := s.getOwnMedia(ctx, restaurantID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if media.OwnerType == MediaOwnerDocument {
+		return nil, errors.New("onboarding documents are confirmed through restaurant onboarding")
+	}
+	if media.Status == MediaStatusReady {
+		return media, nil
+	}
+
+	data, err := s.storage.Get(ctx, media.Key, s.policy.MaxUploadBytes)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, errors.New("file has not been uploaded yet")
+		}
+		if errors.Is(err, storage.ErrObjectTooLarge) {
+			s.discard(ctx, media)
+			return nil, fmt.Errorf("file is larger than %d MB", s.policy.MaxUploadBytes>>20)
+		}
+		return nil, fmt.Errorf("failed to read upload: %v", err)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil || mediaContentTypes["image/"+format] == "" {
+		s.discard(ctx, media)
+		return nil, errors.New("file is not a valid JPEG or PNG image")
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() < mediaMinDimension || bounds.Dy() < mediaMinDimension {
+		s.discard(ctx, media)
+		return nil, fmt.Errorf("image must be at least %dx%d pixels", mediaMinDimension, mediaMinDimension)
+	}
+	if bounds.Dx() > mediaMaxDimension || bounds.Dy() > mediaMaxDimension {
+		s.discard(ctx, media)
+		return nil, fmt.Errorf("image must be at most %dx%d pixels", mediaMaxDimension, mediaMaxDimension)
+	}
+
+	media.ContentType = "image/" + format
+	media.Size = int64(len(data))
+	media.Width = bounds.Dx()
+	media.Height = bounds.Dy()
+	media.URL = s.storage.PublicURL(media.Key)
+	media.Thumbnails = map[string]string{}
+	media.ThumbURLs = map[string]string{}
+
+	base := strings.TrimSuffix(media.Key, path.Ext(media.Key))
+	for name, width := range mediaThumbnailWidths {
+		if width >= bounds.Dx() {
+			continue
+		}
+
+		var buf bytes.Buffer
+		thumbnail := resizeToWidth(img, width)
+		if format == "png" {
+			err = png.Encode(&buf, thumbnail)
+		} else {
+			err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: mediaJPEGQuality})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode thumbnail: %v", err)
+		}
+
+		key := base + "_" + name + path.Ext(media.Key)
+		if err := s.storage.Put(ctx, key, media.ContentType, buf.Bytes()); err != nil {
+			return nil, fmt.Errorf("failed to store thumbnail: %v", err)
+		}
+		media.Thumbnails[name] = key
+		media.ThumbURLs[name] = s.storage.PublicURL(key)
+	}
+
+	if err := s.attach(ctx, media); err != nil {
+		return nil, err
+	}
+
+	media.Status = MediaStatusReady
+	if err := s.mediaRepo.Update(ctx, media); err != nil {
+		return nil, fmt.Errorf("failed to save media: %v", err)
+	}
+
+	return media, nil
+}
+
+// ListMedia returns the uploads attached to an owner of the restaurant
+func (s *MediaService) ListMedia(ctx context.Context, restaurantID, ownerType, ownerID string) ([]models.Media, error) {
+	if err := s.checkOwner(ctx, restaurantID, ownerType, ownerID); err != nil {
+		return nil, err
+	}
+
+	media, err := s.mediaRepo.GetByOwner(ctx, ownerType, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list media: %v", err)
+	}
+	if media == nil {
+		media = []models.Media{}
+	}
+	return media, nil
+}
+
+// DeleteMedia detaches an image from its owner and removes it and its thumbnails from storage
+func (s *MediaService) DeleteMedia(ctx context.Context, restaurantID, mediaID string) error {
+	media, err := s.getOwnMedia(ctx, restaurantID, mediaID)
+	if err != nil {
+		return err
+	}
+	if media.OwnerType == MediaOwnerDocument {
+		return errors.New("onboarding documents are replaced through restaurant onboarding")
+	}
+
+	if media.Status == MediaStatusReady {
+		if err := s.detach(ctx, media); err != nil {
+			return err
+		}
+	}
+
+	return s.remove(ctx, media)
+}
+
+// CreateDocumentUpload registers a pending upload of an onboarding document. Documents are
+// stored privately and only ever served through short-lived signed URLs.
+func (s *MediaService) CreateDocumentUpload(ctx context.Context, userID, restaurantID, documentID, fileName, contentType string, size int64) (*UploadResponse, error) {
+	ext, ok := documentContentTypes[contentType]
+	if !ok {
+		return nil, errors.New("unsupported content type, expected application/pdf, image/jpeg or image/png")
+	}
+	if size > s.policy.MaxUploadBytes {
+		return nil, fmt.Errorf("file is larger than %d MB", s.policy.MaxUploadBytes>>20)
+	}
+
+	media := &models.Media{
+		RestaurantID: restaurantID,
+		OwnerType:    MediaOwnerDocument,
+		OwnerID:      documentID,
+		UploadedBy:   userID,
+		FileName:     path.Base(fileName),
+		ContentType:  contentType,
+		Size:         size,
+		Key:          fmt.Sprintf("documents/%s/%s%s", restaurantID, uuid.New().String(), ext),
+		Status:       MediaStatusPending,
+	}
+	if err := s.mediaRepo.Create(ctx, media); err != nil {
+		return nil, fmt.Errorf("failed to create upload: %v", err)
+	}
+
+	return &UploadResponse{
+		Media:     media,
+		UploadURL: s.storage.PresignPut(media.Key, s.policy.UploadURLTTL),
+		Method:    "PUT",
+		Headers:   map[string]string{"Content-Type": media.ContentType},
+		ExpiresAt: time.Now().Add(s.policy.UploadURLTTL),
+	}, nil
+}
+
+// ConfirmDocumentUpload checks an uploaded onboarding document is really a PDF or image
+func (s *MediaService) ConfirmDocumentUpload(ctx context.Context, restaurantID, documentID, mediaID string) (*models.Media, error) {
+	media, err := s.getOwnMedia(ctx, restaurantID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if media.OwnerType != MediaOwnerDocument || media.OwnerID != documentID {
+		return nil, errors.New("media is not an upload for this document")
+	}
+	if err := s.verifyFile(ctx, media); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// verifyFile checks a pending private upload is really a PDF or image and marks it ready
+func (s *MediaService) verifyFile(ctx context.Context, media *models.Media) error {
+	if media.Status == MediaStatusReady {
+		return nil
+	}
+
+	data, err := s.storage.Get(ctx, media.Key, s.policy.MaxUploadBytes)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return errors.New("file has not been uploaded yet")
+		}
+		if errors.Is(err, storage.ErrObjectTooLarge) {
+			s.discard(ctx, media)
+			return fmt.Errorf("file is larger than %d MB", s.policy.MaxUploadBytes>>20)
+		}
+		return fmt.Errorf("failed to read upload: %v", err)
+	}
+
+	contentType := http.DetectContentType(data)
+	if documentContentTypes[contentType] == "" {
+		s.discard(ctx, media)
+		return errors.New("file is not a valid PDF, JPEG or PNG")
+	}
+
+	media.ContentType = contentType
+	media.Size = int64(len(data))
+	media.Status = MediaStatusReady
+	if err := s.mediaRepo.Update(ctx, media); err != nil {
+		return fmt.Errorf("failed to save media: %v", err)
+	}
+	return nil
+}
+
+// CreateSupportUpload registers a pending upload of a support ticket attachment. Like
+// documents, attachments are private and only served through short-lived signed URLs.
+func (s *MediaService) CreateSupportUpload(ctx context.Context, uploaderID, fileName, contentType string, size int64) (*UploadResponse, error) {
+	ext, ok := documentContentTypes[contentType]
+	if !ok {
+		return nil, errors.New("unsupported content type, expected application/pdf, image/jpeg or image/png")
+	}
+	if size > s.policy.MaxUploadBytes {
+		return nil, fmt.Errorf("file is larger than %d MB", s.policy.MaxUploadBytes>>20)
+	}
+
+	media := &models.Media{
+		OwnerType:   MediaOwnerSupport,
+		OwnerID:     uploaderID,
+		UploadedBy:  uploaderID,
+		FileName:    path.Base(fileName),
+		ContentType: contentType,
+		Size:        size,
+		Key:         fmt.Sprintf("support/%s/%s%s", uploaderID, uuid.New().String(), ext),
+		Status:      MediaStatusPending,
+	}
+	if err := s.mediaRepo.Create(ctx, media); err != nil {
+		return nil, fmt.Errorf("failed to create upload: %v", err)
+	}
+
+	return &UploadResponse{
+		Media:     media,
+		UploadURL: s.storage.PresignPut(media.Key, s.policy.UploadURLTTL),
+		Method:    "PUT",
+		Headers:   map[string]string{"Content-Type": media.ContentType},
+		ExpiresAt: time.Now().Add(s.policy.UploadURLTTL),
+	}, nil
+}
+
+// ConfirmSupportUpload checks an attachment the uploader is adding to a ticket message
+func (s *MediaService) ConfirmSupportUpload(ctx context.Context, uploaderID, mediaID string) (*models.Media, error) {
+	media, err := s.getSupportMedia(ctx, mediaID)
+	if err != nil || media.UploadedBy != uploaderID {
+		return nil, fmt.Errorf("attachment %s not found", mediaID)
+	}
+	if err := s.verifyFile(ctx, media); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// SupportAttachmentURL returns a short-lived link to view a support ticket attachment
+func (s *MediaService) SupportAttachmentURL(ctx context.Context, mediaID string) (string, error) {
+	media, err := s.getSupportMedia(ctx, mediaID)
+	if err != nil {
+		return "", err
+	}
+	return s.storage.PresignGet(media.Key, s.policy.UploadURLTTL), nil
+}
+
+func (s *MediaService) getSupportMedia(ctx context.Context, mediaID string) (*models.Media, error) {
+	objectID, err := primitive.ObjectIDFromHex(mediaID)
+	if err != nil {
+		return nil, errors.New("invalid media ID")
+	}
+	media, err := s.mediaRepo.GetByID(ctx, objectID)
+	if err != nil || media.OwnerType != MediaOwnerSupport {
+		return nil, errors.New("attachment not found")
+	}
+	return media, nil
+}
+
+// DocumentURL returns a short-lived link to view an onboarding document
+func (s *MediaService) DocumentURL(ctx context.Context, mediaID string) (string, error) {
+	objectID, err := primitive.ObjectIDFromHex(mediaID)
+	if err != nil {
+		return "", errors.New("invalid media ID")
+	}
+	media, err := s.mediaRepo.GetByID(ctx, objectID)
+	if err != nil || media.OwnerType != MediaOwnerDocument {
+		return "", errors.New("document file not found")
+	}
+	return s.storage.PresignGet(media.Key, s.policy.UploadURLTTL), nil
+}
+
+// RemoveDocument deletes an onboarding document file that has been replaced
+func (s *MediaService) RemoveDocument(ctx context.Context, mediaID string) {
+	objectID, err := primitive.ObjectIDFromHex(mediaID)
+	if err != nil {
+		return
+	}
+	media, err := s.mediaRepo.GetByID(ctx, objectID)
+	if err != nil || media.OwnerType != MediaOwnerDocument {
+		return
+	}
+	s.discard(ctx, media)
+}
+
+// StoreInvoice saves a generated invoice PDF for an order in private storage
+func (s *MediaService) StoreInvoice(ctx context.Context, restaurantID, orderID, fileName string, data []byte) (*models.Media, error) {
+	media := &models.Media{
+		RestaurantID: restaurantID,
+		OwnerType:    MediaOwnerInvoice,
+		OwnerID:      orderID,
+		UploadedBy:   "system",
+		FileName:     fileName,
+		ContentType:  "application/pdf",
+		Size:         int64(len(data)),
+		Key:          fmt.Sprintf("invoices/%s/%s.pdf", restaurantID, uuid.New().String()),
+		Status:       MediaStatusReady,
+	}
+	if err := s.storage.Put(ctx, media.Key, media.ContentType, data); err != nil {
+		return nil, fmt.Errorf("failed to store invoice: %v", err)
+	}
+	if err := s.mediaRepo.Create(ctx, media); err != nil {
+		s.storage.Delete(ctx, media.Key)
+		return nil, fmt.Errorf("failed to save invoice media: %v", err)
+	}
+	return media, nil
+}
+
+// ReadInvoice returns a stored invoice PDF
+func (s *MediaService) ReadInvoice(ctx context.Context, mediaID string) ([]byte, error) {
+	objectID, err := primitive.ObjectIDFromHex(mediaID)
+	if err != nil {
+		return nil, errors.New("invalid media ID")
+	}
+	media, err := s.mediaRepo.GetByID(ctx, objectID)
+	if err != nil || media.OwnerType != MediaOwnerInvoice {
+		return nil, errors.New("invoice file not found")
+	}
+	return s.storage.Get(ctx, media.Key, s.policy.MaxUploadBytes)
+}
+
+func (s *MediaService) getOwnMedia(ctx context.Context, restaurantID, mediaID string) (*models.Media, error) {
+	objectID, err := primitive.ObjectIDFromHex(mediaID)
+	if err != nil {
+		return nil, errors.New("invalid media ID")
+	}
+
+	media, err := s.mediaRepo.GetByID(ctx, objectID)
+	if err != nil || media.RestaurantID != restaurantID {
+		return nil, errors.New("media not found")
+	}
+	return media, nil
+}
+
+// checkOwner verifies the owner exists and belongs to the restaurant
+func (s *MediaService) checkOwner(ctx context.Context, restaurantID, ownerType, ownerID string) error {
+	switch ownerType {
+	case MediaOwnerProduct:
+		objectID, err := primitive.ObjectIDFromHex(ownerID)
+		if err != nil {
+			return errors.New("invalid product ID")
+		}
+		product, err := s.productRepo.GetByID(ctx, objectID)
+		if err != nil {
+			return errors.New("product not found")
+		}
+		if product.RestaurantID != restaurantID {
+			return errors.New("product does not belong to this restaurant")
+		}
+	case MediaOwnerCategory:
+		objectID, err := primitive.ObjectIDFromHex(ownerID)
+		if err != nil {
+			return errors.New("invalid category ID")
+		}
+		category, err := s.categoryRepo.GetByID(ctx, objectID)
+		if err != nil {
+			return errors.New("category not found")
+		}
+		if category.RestaurantID != restaurantID {
+			return errors.New("category does not belong to this restaurant")
+		}
+	case MediaOwnerRestaurant:
+		if ownerID != restaurantID {
+			return errors.New("you can only upload media for your own restaurant")
+		}
+	default:
+		return fmt.Errorf("invalid owner type: %s", ownerType)
+	}
+	return nil
+}
+
+// attach adds the image to its owner: products gain an image, categories and restaurants
+// have their image or logo replaced
+func (s *MediaService) attach(ctx context.Context, media *models.Media) error {
+	switch media.OwnerType {
+	case MediaOwnerProduct:
+		objectID, _ := primitive.ObjectIDFromHex(media.OwnerID)
+		product, err := s.productRepo.GetByID(ctx, objectID)
+		if err != nil {
+			return errors.New("product not found")
+		}
+		product.ImageUrls = append(product.ImageUrls, media.URL)
+		if err := s.productRepo.Update(ctx, product); err != nil {
+			return fmt.Errorf("failed to attach image: %v", err)
+		}
+		s.cache.InvalidateTags(ctx, restaurantProductsTag(media.RestaurantID))
+	case MediaOwnerCategory:
+		objectID, _ := primitive.ObjectIDFromHex(media.OwnerID)
+		category, err := s.categoryRepo.GetByID(ctx, objectID)
+		if err != nil {
+			return errors.New("category not found")
+		}
+		category.ImgUrl = media.URL
+		if err := s.categoryRepo.Update(ctx, category); err != nil {
+			return fmt.Errorf("failed to attach image: %v", err)
+		}
+		s.cache.Delete(ctx, "categories:"+media.RestaurantID)
+	case MediaOwnerRestaurant:
+		restaurantUUID, err := uuid.Parse(media.OwnerID)
+		if err != nil {
+			return errors.New("invalid restaurant ID")
+		}
+		restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantUUID)
+		if err != nil {
+			return errors.New("restaurant not found")
+		}
+		restaurant.Logo = media.URL
+		if err := s.restaurantRepo.Update(ctx, restaurant); err != nil {
+			return fmt.Errorf("failed to attach logo: %v", err)
+		}
+		s.cache.DeleteWithPrefix(ctx, "restaurant", media.OwnerID)
+	}
+	return nil
+}
+
+// detach removes the image URL from its owner if the owner still uses it
+func (s *MediaService) detach(ctx context.Context, media *models.Media) error {
+	inUse, err := s.isAttached(ctx, media)
+	if err != nil || !inUse {
+		return nil
+	}
+
+	switch media.OwnerType {
+	case MediaOwnerProduct:
+		objectID, _ := primitive.ObjectIDFromHex(media.OwnerID)
+		product, err := s.productRepo.GetByID(ctx, objectID)
+		if err != nil {
+			return nil
+		}
+		var urls []string
+		for _, url := range product.ImageUrls {
+			if url != media.URL {
+				urls = append(urls, url)
+			}
+		}
+		product.ImageUrls = urls
+		if err := s.productRepo.Update(ctx, product); err != nil {
+			return fmt.Errorf("failed to detach image: %v", err)
+		}
+		s.cache.InvalidateTags(ctx, restaurantProductsTag(media.RestaurantID))
+	case MediaOwnerCategory:
+		objectID, _ := primitive.ObjectIDFromHex(media.OwnerID)
+		category, err := s.categoryRepo.GetByID(ctx, objectID)
+		if err != nil {
+			return nil
+		}
+		category.ImgUrl = ""
+		if err := s.categoryRepo.Update(ctx, category); err != nil {
+			return fmt.Errorf("failed to detach image: %v", err)
+		}
+		s.cache.Delete(ctx, "categories:"+media.RestaurantID)
+	case MediaOwnerRestaurant:
+		restaurantUUID, _ := uuid.Parse(media.OwnerID)
+		restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantUUID)
+		if err != nil {
+			return nil
+		}
+		restaurant.Logo = ""
+		if err := s.restaurantRepo.Update(ctx, restaurant); err != nil {
+			return fmt.Errorf("failed to detach logo: %v", err)
+		}
+		s.cache.DeleteWithPrefix(ctx, "restaurant", media.OwnerID)
+	}
+	return nil
+}
+
+// isAttached reports whether the owner still references the image. A missing owner
+// means the image is orphaned; lookup failures return an error so nothing is deleted by mistake.
+func (s *MediaService) isAttached(ctx context.Context, media *models.Media) (bool, error) {
+	switch media.OwnerType {
+	case MediaOwnerProduct:
+		objectID, err := primitive.ObjectIDFromHex(media.OwnerID)
+		if err != nil {
+			return false, nil
+		}
+		product, err := s.productRepo.GetByID(ctx, objectID)
+		if err != nil {
+			return false, ownerLookupError(err)
+		}
+		for _, url := range product.ImageUrls {
+			if url == media.URL {
+				return true, nil
+			}
+		}
+	case MediaOwnerCategory:
+		objectID, err := primitive.ObjectIDFromHex(media.OwnerID)
+		if err != nil {
+			return false, nil
+		}
+		category, err := s.categoryRepo.GetByID(ctx, objectID)
+		if err != nil {
+			return false, ownerLookupError(err)
+		}
+		return category.ImgUrl == media.URL, nil
+	case MediaOwnerRestaurant:
+		restaurantUUID, err := uuid.Parse(media.OwnerID)
+		if err != nil {
+			return false, nil
+		}
+		restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantUUID)
+		if err != nil {
+			return false, ownerLookupError(err)
+		}
+		return restaurant.Logo == media.URL, nil
+	case MediaOwnerDocument:
+		documentUUID, err := uuid.Parse(media.OwnerID)
+		if err != nil {
+			return false, nil
+		}
+		document, err := s.documentRepo.GetByID(ctx, documentUUID)
+		if err != nil {
+			return false, ownerLookupError(err)
+		}
+		return document.MediaID == media.ID.Hex(), nil
+	case MediaOwnerInvoice:
+		orderUUID, err := uuid.Parse(media.OwnerID)
+		if err != nil {
+			return false, nil
+		}
+		invoice, err := s.invoiceRepo.GetByOrderID(ctx, orderUUID)
+		if err != nil {
+			return false, ownerLookupError(err)
+		}
+		return invoice.MediaID == media.ID.Hex(), nil
+	case MediaOwnerSupport:
+		return s.supportRepo.HasAttachment(ctx, media.ID.Hex())
+	}
+	return false, nil
+}
+
+// ownerLookupError treats "not found" as a missing owner and anything else as a real failure
+func ownerLookupError(err error) error {
+	message := err.Error()
+	if strings.Contains(message, "no documents") || strings.Contains(message, "record not found") {
+		return nil
+	}
+	return err
+}
+
+// discard drops an upload that failed validation
+func (s *MediaService) discard(ctx context.Context, media *models.Media) {
+	if err := s.remove(ctx, media); err != nil {
+		log.Printf("Failed to discard media %s: %v", media.ID.Hex(), err)
+	}
+}
+
+// remove deletes the original, its thumbnails and the record
+func (s *MediaService) remove(ctx context.Context, media *models.Media) error {
+	keys := []string{media.Key}
+	for _, key := range media.Thumbnails {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			return fmt.Errorf("failed to delete %s: %v", key, err)
+		}
+	}
+
+	if err := s.mediaRepo.Delete(ctx, media.ID); err != nil {
+		return fmt.Errorf("failed to delete media: %v", err)
+	}
+	return nil
+}
+
+// Start runs orphan cleanup every six hours
+func (s *MediaService) Start() error {
+	if s.isRunning {
+		return fmt.Errorf("media cleanup is already running")
+	}
+
+	s.isRunning = true
+	go s.runCleanupTicker()
+
+	log.Println("🧹 Media cleanup: Every 6 hours")
+	return nil
+}
+
+// Stop stops the orphan cleanup
+func (s *MediaService) Stop() {
+	if !s.isRunning {
+		return
+	}
+
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+func (s *MediaService) runCleanupTicker() {
+	ticker := time.NewTicker(mediaCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			removed, err := s.CleanupOrphans(context.Background())
+			if err != nil {
+				log.Printf("Media cleanup failed: %v", err)
+			} else if removed > 0 {
+				log.Printf("Media cleanup removed %d orphaned uploads", removed)
+			}
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// CleanupOrphans removes uploads that were never confirmed and images whose owner was
+// deleted or no longer references them
+func (s *MediaService) CleanupOrphans(ctx context.Context) (int, error) {
+	removed := 0
+
+	stale := func(status string, before time.Time, isOrphan func(*models.Media) bool) error {
+		var afterID primitive.ObjectID
+		for {
+			batch, err := s.mediaRepo.GetByStatusBefore(ctx, status, before, afterID, mediaCleanupBatch)
+			if err != nil {
+				return fmt.Errorf("failed to list %s media: %v", status, err)
+			}
+
+			for i := range batch {
+				media := &batch[i]
+				afterID = media.ID
+				if !isOrphan(media) {
+					continue
+				}
+				if err := s.remove(ctx, media); err != nil {
+					log.Printf("Failed to remove orphaned media %s: %v", media.ID.Hex(), err)
+					continue
+				}
+				removed++
+			}
+
+			if len(batch) < mediaCleanupBatch {
+				return nil
+			}
+		}
+	}
+
+	now := time.Now()
+	if err := stale(MediaStatusPending, now.Add(-mediaPendingTTL), func(*models.Media) bool {
+		return true
+	}); err != nil {
+		return removed, err
+	}
+
+	err := stale(MediaStatusReady, now.Add(-mediaOrphanGrace), func(media *models.Media) bool {
+		attached, err := s.isAttached(ctx, media)
+		return err == nil && !attached
+	})
+	return removed, err
+}
+
+// resizeToWidth scales an image to the given width, keeping its aspect ratio, by averaging
+// the source pixels that fall into each destination pixel
+func resizeToWidth(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,463 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/cache"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Longest time a restaurant's public menu is served from cache
+const menuCacheTTL = 10 * time.Minute
+
+var weekdays = map[string]bool{
+	"monday":    true,
+	"tuesday":   true,
+	"wednesday": true,
+	"thursday":  true,
+	"friday":    true,
+	"saturday":  true,
+	"sunday":    true,
+}
+
+type MenuSectionService struct {
+	menuSectionRepo repositories.MenuSectionRepository
+	productRepo     repositories.ProductRepository
+	restaurantRepo  repositories.RestaurantRepository
+	cache           *cache.RedisCache
+}
+
+func NewMenuSectionService(
+	menuSectionRepo repositories.MenuSectionRepository,
+	productRepo repositories.ProductRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	cache *cache.RedisCache,
+) *MenuSectionService {
+	return &MenuSectionService{
+		menuSectionRepo: menuSectionRepo,
+		productRepo:     productRepo,
+		restaurantRepo:  restaurantRepo,
+		cache:           cache,
+	}
+}
+
+type CreateMenuSectionRequest struct {
+	Name            string                  `json:"name" binding:"required"`
+	Description     string                  `json:"description"`
+	SortOrder       int                     `json:"sort_order"`
+	IsActive        *bool                   `json:"is_active"`
+	TimeRestriction *models.TimeRestriction `json:"time_restriction"`
+	ProductIDs      []string                `json:"product_ids"`
+}
+
+type UpdateMenuSectionRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	SortOrder   *int    `json:"sort_order"`
+	IsActive    *bool   `json:"is_active"`
+	// Replaces the restriction when set; set ClearTimeRestriction to make the section available all day
+	TimeRestriction      *models.TimeRestriction `json:"time_restriction"`
+	ClearTimeRestriction bool                    `json:"clear_time_restriction"`
+}
+
+type SetSectionProductsRequest struct {
+	ProductIDs []string `json:"product_ids"` // in display order
+}
+
+type ReorderMenuSectionsRequest struct {
+	SectionIDs []string `json:"section_ids" binding:"required,min=1"` // in display order
+}
+
+type MenuResponse struct {
+	RestaurantID string                `json:"restaurant_id"`
+	Sections     []MenuSectionResponse `json:"sections"`
+	GeneratedAt  time.Time             `json:"generated_at"`
+}
+
+type MenuSectionResponse struct {
+	ID              primitive.ObjectID      `json:"id"`
+	Name            string                  `json:"name"`
+	Description     string                  `json:"description"`
+	TimeRestriction *models.TimeRestriction `json:"time_restriction,omitempty"`
+	Products        []models.Product        `json:"products"`
+}
+
+func menuCacheKey(restaurantID string) string {
+	return "menu:" + restaurantID
+}
+
+// CreateSection adds a section to the restaurant's menu
+func (s *MenuSectionService) CreateSection(ctx context.Context, restaurantID string, req *CreateMenuSectionRequest) (*models.MenuSection, error) {
+	section := &models.MenuSection{
+		RestaurantID:    restaurantID,
+		Name:            req.Name,
+		Description:     req.Description,
+		SortOrder:       req.SortOrder,
+		IsActive:        true,
+		TimeRestriction: req.TimeRestriction,
+		ProductIDs:      []primitive.ObjectID{},
+	}
+	if req.IsActive != nil {
+		section.IsActive = *req.IsActive
+	}
+
+	if err := validateTimeRestriction(section.TimeRestriction); err != nil {
+		return nil, err
+	}
+
+	if len(req.ProductIDs) > 0 {
+		productIDs, err := s.restaurantProductIDs(ctx, restaurantID, req.ProductIDs)
+		if err != nil {
+			return nil, err
+		}
+		section.ProductIDs = productIDs
+	}
+
+	if err := s.menuSectionRepo.Create(ctx, section); err != nil {
+		return nil, fmt.Errorf("failed to create menu section: %v", err)
+	}
+
+	s.clearMenuCache(ctx, restaurantID)
+	return section, nil
+}
+
+// GetSection returns a section owned by the restaurant
+func (s *MenuSectionService) GetSection(ctx context.Context, restaurantID, sectionID string) (*models.MenuSection, error) {
+	objectID, err := primitive.ObjectIDFromHex(sectionID)
+	if err != nil {
+		return nil, errors.New("invalid section ID")
+	}
+
+	section, err := s.menuSectionRepo.GetByID(ctx, objectID)
+	if err != nil || section.RestaurantID != restaurantID {
+		return nil, errors.New("menu section not found")
+	}
+
+	return section, nil
+}
+
+// ListSections returns all of the restaurant's sections, including inactive ones, in display order
+func (s *MenuSectionService) ListSections(ctx context.Context, restaurantID string) ([]models.MenuSection, error) {
+	sections, err := s.menuSectionRepo.GetByRestaurantID(ctx, restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list menu sections: %v", err)
+	}
+	if sections == nil {
+		sections = []models.MenuSection{}
+	}
+
+	return sections, nil
+}
+
+func (s *MenuSectionService) UpdateSection(ctx context.Context, restaurantID, sectionID string, req *UpdateMenuSectionRequest) (*models.MenuSection, error) {
+	section, err := s.GetSection(ctx, restaurantID, sectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		section.Name = *req.Name
+	}
+	if req.Description != nil {
+		section.Description = *req.Description
+	}
+	if req.SortOrder != nil {
+		section.SortOrder = *req.SortOrder
+	}
+	if req.IsActive != nil {
+		section.IsActive = *req.IsActive
+	}
+	if req.ClearTimeRestriction {
+		section.TimeRestriction = nil
+	} else if req.TimeRestriction != nil {
+		section.TimeRestriction = req.TimeRestriction
+	}
+
+	if err := validateTimeRestriction(section.TimeRestriction); err != nil {
+		return nil, err
+	}
+
+	if err := s.menuSectionRepo.Update(ctx, section); err != nil {
+		return nil, fmt.Errorf("failed to update menu section: %v", err)
+	}
+
+	s.clearMenuCache(ctx, restaurantID)
+	return section, nil
+}
+
+func (s *MenuSectionService) DeleteSection(ctx context.Context, restaurantID, sectionID string) error {
+	section, err := s.GetSection(ctx, restaurantID, sectionID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.menuSectionRepo.Delete(ctx, section.ID); err != nil {
+		return fmt.Errorf("failed to delete menu section: %v", err)
+	}
+
+	s.clearMenuCache(ctx, restaurantID)
+	return nil
+}
+
+// SetSectionProducts replaces the products listed in a section. The order of the IDs is the display order.
+func (s *MenuSectionService) SetSectionProducts(ctx context.Context, restaurantID, sectionID string, req *SetSectionProductsRequest) (*models.MenuSection, error) {
+	section, err := s.GetSection(ctx, restaurantID, sectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs, err := s.restaurantProductIDs(ctx, restaurantID, req.ProductIDs)
+	if err != nil {
+		return nil, err
+	}
+	section.ProductIDs = productIDs
+
+	if err := s.menuSectionRepo.Update(ctx, section); err != nil {
+		return nil, fmt.Errorf("failed to update menu section: %v", err)
+	}
+
+	s.clearMenuCache(ctx, restaurantID)
+	return section, nil
+}
+
+// ReorderSections sets the display order of the listed sections to their position in the request
+func (s *MenuSectionService) ReorderSections(ctx context.Context, restaurantID string, req *ReorderMenuSectionsRequest) ([]models.MenuSection, error) {
+	sections, err := s.menuSectionRepo.GetByRestaurantID(ctx, restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list menu sections: %v", err)
+	}
+
+	owned := make(map[primitive.ObjectID]bool, len(sections))
+	for _, section := range sections {
+		owned[section.ID] = true
+	}
+
+	sortOrder := make(map[primitive.ObjectID]int, len(req.SectionIDs))
+	for i, id := range req.SectionIDs {
+		sectionID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid section ID: %s", id)
+		}
+		if !owned[sectionID] {
+			return nil, fmt.Errorf("menu section not found: %s", id)
+		}
+		sortOrder[sectionID] = i
+	}
+
+	if err := s.menuSectionRepo.UpdateSortOrder(ctx, restaurantID, sortOrder); err != nil {
+		return nil, fmt.Errorf("failed to reorder menu sections: %v", err)
+	}
+
+	s.clearMenuCache(ctx, restaurantID)
+	return s.ListSections(ctx, restaurantID)
+}
+
+// GetMenu returns the restaurant's menu organized into the sections that are active and valid right now,
+// in the restaurant's timezone. Unavailable products and empty sections are left out.
+func (s *MenuSectionService) GetMenu(ctx context.Context, restaurantID string) (*MenuResponse, error) {
+	cacheKey := menuCacheKey(restaurantID)
+	var cached MenuResponse
+	if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+		return &cached, nil
+	}
+
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, errors.New("invalid restaurant ID")
+	}
+
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantUUID)
+	if err != nil {
+		return nil, errors.New("restaurant not found")
+	}
+
+	sections, err := s.menuSectionRepo.GetByRestaurantID(ctx, restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get menu sections: %v", err)
+	}
+
+	loc := time.Local
+	if restaurant.TimeZone != "" {
+		if tz, err := time.LoadLocation(restaurant.TimeZone); err == nil {
+			loc = tz
+		}
+	}
+	now := time.Now().In(loc)
+
+	// Expire the cache no later than the next time a section comes into or goes out of its hours
+	ttl := menuCacheTTL
+	var valid []models.MenuSection
+	var productIDs []primitive.ObjectID
+	for _, section := range sections {
+		if !section.IsActive {
+			continue
+		}
+		if restriction := section.TimeRestriction; restriction != nil {
+			if next := nextRestrictionChange(restriction, now); next < ttl {
+				ttl = next
+			}
+			if !isWithinTimeRestriction(restriction, now) {
+				continue
+			}
+		}
+		valid = append(valid, section)
+		productIDs = append(productIDs, section.ProductIDs...)
+	}
+
+	products, err := s.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get menu products: %v", err)
+	}
+
+	productsByID := make(map[primitive.ObjectID]models.Product, len(products))
+	for _, product := range products {
+		if product.IsAvailable && product.RestaurantID == restaurantID {
+			productsByID[product.ID] = product
+		}
+	}
+
+	menu := &MenuResponse{
+		RestaurantID: restaurantID,
+		Sections:     []MenuSectionResponse{},
+		GeneratedAt:  time.Now(),
+	}
+	for _, section := range valid {
+		sectionResponse := MenuSectionResponse{
+			ID:              section.ID,
+			Name:            section.Name,
+			Description:     section.Description,
+			TimeRestriction: section.TimeRestriction,
+			Products:        []models.Product{},
+		}
+		for _, productID := range section.ProductIDs {
+			if product, ok := productsByID[productID]; ok {
+				sectionResponse.Products = append(sectionResponse.Products, product)
+			}
+		}
+		if len(sectionResponse.Products) > 0 {
+			menu.Sections = append(menu.Sections, sectionResponse)
+		}
+	}
+
+	// Tagged with the restaurant's products so price and availability changes show up
+	s.cache.SetWithTags(ctx, cacheKey, menu, ttl, restaurantProductsTag(restaurantID))
+
+	return menu, nil
+}
+
+// restaurantProductIDs parses product IDs, dropping duplicates, and checks they all belong to the restaurant
+func (s *MenuSectionService) restaurantProductIDs(ctx context.Context, restaurantID string, ids []string) ([]primitive.ObjectID, error) {
+	productIDs := make([]primitive.ObjectID, 0, len(ids))
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		productID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid product ID: %s", id)
+		}
+		if seen[productID] {
+			continue
+		}
+		seen[productID] = true
+		productIDs = append(productIDs, productID)
+	}
+
+	products, err := s.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %v", err)
+	}
+
+	found := make(map[primitive.ObjectID]bool, len(products))
+	for _, product := range products {
+		if product.RestaurantID == restaurantID {
+			found[product.ID] = true
+		}
+	}
+	for _, productID := range productIDs {
+		if !found[productID] {
+			return nil, fmt.Errorf("product not found: %s", productID.Hex())
+		}
+	}
+
+	return productIDs, nil
+}
+
+func (s *MenuSectionService) clearMenuCache(ctx context.Context, restaurantID string) {
+	s.cache.Delete(ctx, menuCacheKey(restaurantID))
+}
+
+func validateTimeRestriction(restriction *models.TimeRestriction) error {
+	if restriction == nil {
+		return nil
+	}
+	if _, err := time.Parse("15:04", restriction.StartTime); err != nil {
+		return errors.New("start time must be in HH:MM format")
+	}
+	if _, err := time.Parse("15:04", restriction.EndTime); err != nil {
+		return errors.New("end time must be in HH:MM format")
+	}
+	for i, day := range restriction.Days {
+		day = strings.ToLower(day)
+		if !weekdays[day] {
+			return fmt.Errorf("invalid day: %s", restriction.Days[i])
+		}
+		restriction.Days[i] = day
+	}
+	return nil
+}
+
+// isWithinTimeRestriction checks the day and time window. No days means every day.
+func isWithinTimeRestriction(restriction *models.TimeRestriction, now time.Time) bool {
+	if len(restriction.Days) > 0 {
+		today := strings.ToLower(now.Weekday().String())
+		onDay := false
+		for _, day := range restriction.Days {
+			if strings.ToLower(day) == today {
+				onDay = true
+				break
+			}
+		}
+		if !onDay {
+			return false
+		}
+	}
+
+	return isTimeInRange(now.Format("15:04"), restriction.StartTime, restriction.EndTime)
+}
+
+// nextRestrictionChange returns how long until the restriction could next flip: its start time,
+// the minute after its end time (the end is inclusive) or midnight when it is limited to some days
+func nextRestrictionChange(restriction *models.TimeRestriction, now time.Time) time.Duration {
+	boundaries := []time.Duration{untilClock(restriction.StartTime, 0, now), untilClock(restriction.EndTime, time.Minute, now)}
+	if len(restriction.Days) > 0 {
+		boundaries = append(boundaries, untilClock("00:00", 0, now))
+	}
+
+	next := 24 * time.Hour
+	for _, boundary := range boundaries {
+		if boundary > 0 && boundary < next {
+			next = boundary
+		}
+	}
+	return next
+}
+
+// untilClock returns the time until the next occurrence of an HH:MM clock time plus an offset
+func untilClock(clock string, offset time.Duration, now time.Time) time.Duration {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0
+	}
+
+	at := time.Date(now.Year(), now.Month(), now.Day(), parsed.Hour(), parsed.Minute(), 0, 0, now.Location()).Add(offset)
+	if !at.After(now) {
+		at = at.Add(24 * time.Hour)
+	}
+	return at.Sub(now)
+}
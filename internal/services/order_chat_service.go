@@ -0,0 +1,531 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/cache"
+	"golang-food-backend/pkg/jobs"
+	"golang-food-backend/pkg/messaging"
+	"golang-food-backend/pkg/spreadsheet"
+
+	"github.com/google/uuid"
+)
+
+// Order chat participants
+const (
+	ChatRoleCustomer   = "customer"
+	ChatRoleRestaurant = "restaurant"
+	ChatRoleRider      = "rider"
+	ChatRoleSupport    = "support" // admins, who read chats but do not take part
+)
+
+const (
+	JobChatPurge = "chat:purge"
+
+	chatMaxMessageLength = 1000
+)
+
+var (
+	ErrChatInvalid   = apperr.Validation("chat_invalid", "invalid chat message")
+	ErrChatRejected  = apperr.Validation("chat_message_rejected", "message was rejected by the chat filter")
+	ErrChatNotFound  = apperr.NotFound("order_not_found", "order not found")
+	ErrChatForbidden = apperr.Forbidden("chat_forbidden", "you cannot send messages in this chat")
+	ErrChatClosed    = apperr.Conflict("chat_closed", "chat is only open while the order is active")
+)
+
+// ChatQuickReply is a canned message a participant can send with one tap
+type ChatQuickReply struct {
+	Key  string `json:"key"`
+	Text string `json:"text"`
+}
+
+// chatQuickReplies are the canned messages offered to each participant
+var chatQuickReplies = map[string][]ChatQuickReply{
+	ChatRoleCustomer: {
+		{Key: "where_are_you", Text: "Where are you?"},
+		{Key: "call_me", Text: "Please call me when you arrive."},
+		{Key: "leave_at_door", Text: "Please leave the order at the door."},
+		{Key: "thanks", Text: "Thank you!"},
+	},
+	ChatRoleRestaurant: {
+		{Key: "preparing", Text: "Your order is being prepared."},
+		{Key: "running_late", Text: "We are running a few minutes late, sorry!"},
+		{Key: "item_unavailable", Text: "An item is out of stock. We will call you about a replacement."},
+		{Key: "on_the_way", Text: "Your order is on its way."},
+	},
+	ChatRoleRider: {
+		{Key: "on_my_way", Text: "I am on my way."},
+		{Key: "arrived", Text: "I have arrived at your location."},
+		{Key: "cant_find_address", Text: "I cannot find your address. Please call me."},
+		{Key: "in_traffic", Text: "I am stuck in traffic and will be there soon."},
+	},
+}
+
+// ChatFilter screens a chat message before it is stored. It returns the text to store, as is
+// or masked, or an error wrapping ErrChatRejected to refuse the message. Filters run in the
+// order they were given; each sees the previous one's output.
+type ChatFilter interface {
+	Filter(ctx context.Context, text string) (string, error)
+}
+
+// ChatFilterFunc adapts a function to ChatFilter, e.g. to call a moderation API
+type ChatFilterFunc func(ctx context.Context, text string) (string, error)
+
+func (f ChatFilterFunc) Filter(ctx context.Context, text string) (string, error) {
+	return f(ctx, text)
+}
+
+// wordFilter masks listed words, whole words only and ignoring case
+type wordFilter struct {
+	pattern *regexp.Regexp
+}
+
+// NewWordFilter returns a filter that replaces each listed word with asterisks. An empty list
+// returns nil.
+func NewWordFilter(words []string) ChatFilter {
+	var quoted []string
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	return &wordFilter{pattern: regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)}
+}
+
+func (f *wordFilter) Filter(ctx context.Context, text string) (string, error) {
+	return f.pattern.ReplaceAllStringFunc(text, func(word string) string {
+		return strings.Repeat("*", len([]rune(word)))
+	}), nil
+}
+
+// OrderChatService runs the chat of each order between the customer, the restaurant and the
+// in-house rider delivering it. Messages are kept in MongoDB and pushed to WebSocket clients
+// through Redis. A chat takes messages only while its order is active; it stays readable
+// afterwards until the retention period ends, unless the order is under a support dispute.
+type OrderChatService struct {
+	chatRepo       repositories.OrderChatRepository
+	orderRepo      repositories.OrderRepository
+	riderRepo      repositories.RiderRepository
+	assignmentRepo repositories.RiderAssignmentRepository
+	supportRepo    repositories.SupportTicketRepository
+	cache          *cache.RedisCache
+	retention      time.Duration
+	filters        []ChatFilter
+}
+
+func NewOrderChatService(
+	chatRepo repositories.OrderChatRepository,
+	orderRepo repositories.OrderRepository,
+	riderRepo repositories.RiderRepository,
+	assignmentRepo repositories.RiderAssignmentRepository,
+	supportRepo repositories.SupportTicketRepository,
+	cache *cache.RedisCache,
+	retention time.Duration,
+	filters ...ChatFilter,
+) *OrderChatService {
+	service := &OrderChatService{
+		chatRepo:       chatRepo,
+		orderRepo:      orderRepo,
+		riderRepo:      riderRepo,
+		assignmentRepo: assignmentRepo,
+		supportRepo:    supportRepo,
+		cache:          cache,
+		retention:      retention,
+	}
+	for _, filter := range filters {
+		if filter != nil {
+			service.filters = append(service.filters, filter)
+		}
+	}
+	return service
+}
+
+type SendChatMessageRequest struct {
+	Message    string `json:"message" example:"Please ring the bell"` // up to 1000 characters
+	QuickReply string `json:"quick_reply" example:"leave_at_door"`    // a quick reply key, sent instead of message
+}
+
+// OrderChatResponse is an order's chat as one participant sees it
+type OrderChatResponse struct {
+	OrderID      string                    `json:"order_id"`
+	Role         string                    `json:"role"`   // the caller's part in the chat
+	Active       bool                      `json:"active"` // whether messages can be sent
+	Unread       int                       `json:"unread"` // messages from others the caller has not read
+	Messages     []models.OrderChatMessage `json:"messages"`
+	QuickReplies []ChatQuickReply          `json:"quick_replies"`
+}
+
+type ChatReadResponse struct {
+	Read   int64     `json:"read"` // messages newly marked read
+	ReadAt time.Time `json:"read_at"`
+}
+
+// OrderChatEvent is pushed to WebSocket clients following an order's chat
+type OrderChatEvent struct {
+	Type      string                    `json:"type"` // state, message, read, closed or ping
+	OrderID   string                    `json:"order_id,omitempty"`
+	Message   *models.OrderChatMessage  `json:"message,omitempty"`
+	Messages  []models.OrderChatMessage `json:"messages,omitempty"` // the whole chat, in state events
+	Role      string                    `json:"role,omitempty"`     // who read, in read events
+	Timestamp time.Time                 `json:"timestamp"`
+}
+
+// OrderChatExport is an order's whole chat for dispute handling, including the text of
+// messages as sent before filtering
+type OrderChatExport struct {
+	OrderID      string              `json:"order_id"`
+	UserID       string              `json:"user_id"`
+	RestaurantID string              `json:"restaurant_id"`
+	OrderStatus  string              `json:"order_status"`
+	ExportedAt   time.Time           `json:"exported_at"`
+	Messages     []ChatExportMessage `json:"messages"`
+}
+
+type ChatExportMessage struct {
+	models.OrderChatMessage
+	OriginalBody string `json:"original_body,omitempty"`
+}
+
+// Participant returns the order and the caller's role in its chat: its customer, the
+// restaurant's owner and staff, the in-house rider delivering it, or an admin reading it
+func (s *OrderChatService) Participant(ctx context.Context, orderID, userID, role, restaurantID string) (*models.Order, string, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrChatNotFound, orderID)
+	}
+	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrChatNotFound, orderID)
+	}
+
+	switch role {
+	case "admin":
+		return order, ChatRoleSupport, nil
+	case "restaurant_owner", "restaurant_staff":
+		if order.RestaurantID.String() == restaurantID {
+			return order, ChatRoleRestaurant, nil
+		}
+	case "rider":
+		if s.isDeliveringRider(ctx, order.ID, userID) {
+			return order, ChatRoleRider, nil
+		}
+	default:
+		if order.UserID.String() == userID {
+			return order, ChatRoleCustomer, nil
+		}
+	}
+	return nil, "", fmt.Errorf("%w: %s", ErrChatNotFound, orderID)
+}
+
+func (s *OrderChatService) isDeliveringRider(ctx context.Context, orderID uuid.UUID, userID string) bool {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return false
+	}
+	rider, err := s.riderRepo.GetByUserID(ctx, userUUID)
+	if err != nil {
+		return false
+	}
+	assignment, err := s.assignmentRepo.GetActiveByOrderID(ctx, orderID)
+	return err == nil && assignment.RiderID == rider.ID
+}
+
+// GetChat returns the order's chat with the caller's unread count and quick replies
+func (s *OrderChatService) GetChat(ctx context.Context, orderID, userID, role, restaurantID string) (*OrderChatResponse, error) {
+	order, chatRole, err := s.Participant(ctx, orderID, userID, role, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.chatRepo.GetByOrderID(ctx, order.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chat: %v", err)
+	}
+
+	unread := 0
+	for _, message := range messages {
+		if _, read := message.ReadBy[chatRole]; !read && message.SenderRole != chatRole {
+			unread++
+		}
+	}
+	if messages == nil {
+		messages = []models.OrderChatMessage{}
+	}
+
+	return &OrderChatResponse{
+		OrderID:      order.ID.String(),
+		Role:         chatRole,
+		Active:       !IsFinalOrderStatus(order.OrderStatus),
+		Unread:       unread,
+		Messages:     messages,
+		QuickReplies: QuickReplies(chatRole),
+	}, nil
+}
+
+// SendMessage posts the caller's message, or the text of a quick reply, after running it
+// through the chat filters
+func (s *OrderChatService) SendMessage(ctx context.Context, orderID, userID, role, restaurantID string, req *SendChatMessageRequest) (*models.OrderChatMessage, error) {
+	order, chatRole, err := s.Participant(ctx, orderID, userID, role, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	if chatRole == ChatRoleSupport {
+		return nil, ErrChatForbidden
+	}
+	if IsFinalOrderStatus(order.OrderStatus) {
+		return nil, ErrChatClosed
+	}
+
+	message := &models.OrderChatMessage{
+		OrderID:    order.ID.String(),
+		SenderID:   userID,
+		SenderRole: chatRole,
+	}
+	if req.QuickReply != "" {
+		text, ok := quickReplyText(chatRole, req.QuickReply)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown quick reply %q", ErrChatInvalid, req.QuickReply)
+		}
+		message.Body = text
+		message.QuickReply = req.QuickReply
+	} else {
+		body := strings.TrimSpace(req.Message)
+		if body == "" {
+			return nil, fmt.Errorf("%w: message or quick_reply is required", ErrChatInvalid)
+		}
+		if len([]rune(body)) > chatMaxMessageLength {
+			return nil, fmt.Errorf("%w: message must be at most %d characters", ErrChatInvalid, chatMaxMessageLength)
+		}
+
+		filtered := body
+		for _, filter := range s.filters {
+			if filtered, err = filter.Filter(ctx, filtered); err != nil {
+				return nil, err
+			}
+		}
+		message.Body = filtered
+		if filtered != body {
+			message.Filtered = true
+			message.OriginalBody = body
+		}
+	}
+
+	if err := s.chatRepo.Create(ctx, message); err != nil {
+		return nil, fmt.Errorf("failed to send message: %v", err)
+	}
+	s.publish(ctx, OrderChatEvent{Type: "message", OrderID: message.OrderID, Message: message, Timestamp: message.CreatedAt})
+
+	return message, nil
+}
+
+// MarkRead marks the messages others sent so far as read by the caller's side and lets them
+// know
+func (s *OrderChatService) MarkRead(ctx context.Context, orderID, userID, role, restaurantID string) (*ChatReadResponse, error) {
+	order, chatRole, err := s.Participant(ctx, orderID, userID, role, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	if chatRole == ChatRoleSupport {
+		return nil, ErrChatForbidden
+	}
+
+	now := time.Now()
+	read, err := s.chatRepo.MarkRead(ctx, order.ID.String(), chatRole, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark messages read: %v", err)
+	}
+	if read > 0 {
+		s.publish(ctx, OrderChatEvent{Type: "read", OrderID: order.ID.String(), Role: chatRole, Timestamp: now})
+	}
+	return &ChatReadResponse{Read: read, ReadAt: now}, nil
+}
+
+// QuickReplies returns the canned messages offered to a chat role
+func QuickReplies(chatRole string) []ChatQuickReply {
+	replies := chatQuickReplies[chatRole]
+	if replies == nil {
+		return []ChatQuickReply{}
+	}
+	return replies
+}
+
+func quickReplyText(chatRole, key string) (string, bool) {
+	for _, reply := range chatQuickReplies[chatRole] {
+		if reply.Key == key {
+			return reply.Text, true
+		}
+	}
+	return "", false
+}
+
+// Export returns the order's whole chat for support agents handling a dispute
+func (s *OrderChatService) Export(ctx context.Context, orderID string) (*OrderChatExport, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrChatNotFound, orderID)
+	}
+	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrChatNotFound, orderID)
+	}
+	messages, err := s.chatRepo.GetByOrderID(ctx, order.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chat: %v", err)
+	}
+
+	export := &OrderChatExport{
+		OrderID:      order.ID.String(),
+		UserID:       order.UserID.String(),
+		RestaurantID: order.RestaurantID.String(),
+		OrderStatus:  order.OrderStatus,
+		ExportedAt:   time.Now(),
+		Messages:     make([]ChatExportMessage, 0, len(messages)),
+	}
+	for _, message := range messages {
+		export.Messages = append(export.Messages, ChatExportMessage{OrderChatMessage: message, OriginalBody: message.OriginalBody})
+	}
+	return export, nil
+}
+
+// CSV lays the export out with a row per message, read receipts as role=time pairs
+func (e *OrderChatExport) CSV() ([]byte, error) {
+	rows := [][]string{{"sent_at", "sender_role", "sender_id", "message", "original_message", "quick_reply", "read_by"}}
+	for _, message := range e.Messages {
+		var readBy []string
+		for _, role := range []string{ChatRoleCustomer, ChatRoleRestaurant, ChatRoleRider} {
+			if at, ok := message.ReadBy[role]; ok {
+				readBy = append(readBy, role+"="+at.UTC().Format(time.RFC3339))
+			}
+		}
+		rows = append(rows, []string{
+			message.CreatedAt.UTC().Format(time.RFC3339),
+			message.SenderRole,
+			message.SenderID,
+			message.Body,
+			message.OriginalBody,
+			message.QuickReply,
+			strings.Join(readBy, ";"),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := spreadsheet.Write(spreadsheet.FormatCSV, &buf, "Chat", rows); err != nil {
+		return nil, fmt.Errorf("failed to write chat export: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Subscribe streams the order's chat events until ctx is cancelled
+func (s *OrderChatService) Subscribe(ctx context.Context, orderID string) <-chan OrderChatEvent {
+	events := make(chan OrderChatEvent)
+	pubsub := s.cache.Subscribe(ctx, chatChannel(orderID))
+
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var event OrderChatEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events
+}
+
+// SubscribeOrders closes chats as their orders are delivered or cancelled
+func (s *OrderChatService) SubscribeOrders(consumer *messaging.KafkaConsumer, groupID string) {
+	consumer.Subscribe("order_events", groupID, s.HandleOrderEvent, messaging.SubscribeOptions{})
+}
+
+// HandleOrderEvent is the Kafka handler for the order_events topic
+func (s *OrderChatService) HandleOrderEvent(payload []byte) error {
+	var event struct {
+		Type    string `json:"type"`
+		OrderID string `json:"order_id"`
+		Data    struct {
+			NewStatus string `json:"new_status"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("invalid order event: %v", err)
+	}
+	if event.Type != "order_status_updated" || !IsFinalOrderStatus(event.Data.NewStatus) {
+		return nil
+	}
+	s.publish(context.Background(), OrderChatEvent{Type: "closed", OrderID: event.OrderID, Timestamp: time.Now()})
+	return nil
+}
+
+// RegisterJobs registers the chat retention job, queued nightly with the archive jobs
+func (s *OrderChatService) RegisterJobs(queue *jobs.Queue) {
+	queue.Handle(JobChatPurge, func(ctx context.Context, job *jobs.Job) error {
+		_, err := s.PurgeExpired(ctx)
+		return err
+	})
+}
+
+// PurgeExpired deletes chat messages older than the retention period. Chats of orders with
+// unresolved support tickets are kept until the dispute is settled.
+func (s *OrderChatService) PurgeExpired(ctx context.Context) (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	disputed, err := s.supportRepo.GetOpenOrderIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load disputed orders: %v", err)
+	}
+	keep := make([]string, 0, len(disputed))
+	for _, orderID := range disputed {
+		keep = append(keep, orderID.String())
+	}
+
+	purged, err := s.chatRepo.DeleteBefore(ctx, time.Now().Add(-s.retention), keep)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge chat messages: %v", err)
+	}
+	if purged > 0 {
+		log.Printf("Purged %d order chat messages", purged)
+	}
+	return purged, nil
+}
+
+func (s *OrderChatService) publish(ctx context.Context, event OrderChatEvent) {
+	if err := s.cache.Publish(ctx, chatChannel(event.OrderID), event); err != nil {
+		log.Printf("Failed to publish chat %s event for order %s: %v", event.Type, event.OrderID, err)
+	}
+}
+
+func chatChannel(orderID string) string {
+	return "order_chat:" + orderID
+}
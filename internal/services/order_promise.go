@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/messaging"
+
+	"github.com/google/uuid"
+)
+
+const (
+	promiseLoadWindow           = 2 * time.Hour // older open orders are treated as stuck, not as kitchen load
+	promiseKitchenCapacity      = 3             // orders a kitchen prepares at the same time
+	promiseDefaultTravelMinutes = 20            // when the delivery quote has no travel time
+	promiseMaxDelayMinutes      = 120
+)
+
+// promiseKitchenStatuses are the statuses of orders the kitchen is still working on
+var promiseKitchenStatuses = []string{"pending", "confirmed", "preparing"}
+
+var (
+	ErrOrderDelayInvalid  = apperr.Validation("order_delay_invalid", "invalid order delay")
+	ErrOrderDelayNotFound = apperr.NotFound("order_not_found", "order not found")
+)
+
+// OrderPromise is the delivery time promised at checkout and what it was computed from
+type OrderPromise struct {
+	PromisedAt    time.Time `json:"promised_at"`
+	PrepMinutes   int       `json:"prep_minutes"`
+	ActiveOrders  int       `json:"active_orders"` // orders in the kitchen at checkout
+	LoadMinutes   int       `json:"load_minutes"`  // added for the orders ahead
+	TravelMinutes int       `json:"travel_minutes"`
+}
+
+// DelayOrderRequest reports that an order will arrive later than promised
+type DelayOrderRequest struct {
+	Minutes int    `json:"minutes" binding:"required,gt=0" example:"10"` // at most 120
+	Reason  string `json:"reason"`                                       // shown to the customer, e.g. "rush hour"
+}
+
+// PromiseDelivery computes when an order placed now will arrive: the restaurant's preparation
+// time, another preparation time for every full batch of orders already in its kitchen, and the
+// delivery quote's travel time. Scheduled orders are ready at their slot. Lookups that fail
+// leave their part out, so checkout never fails over a promise.
+func (s *RestaurantService) PromiseDelivery(ctx context.Context, restaurantID uuid.UUID, travelMinutes int, scheduledFor *time.Time) *OrderPromise {
+	if travelMinutes <= 0 {
+		travelMinutes = promiseDefaultTravelMinutes
+	}
+	return s.promise(ctx, restaurantID, travelMinutes, scheduledFor)
+}
+
+// PromiseReady computes when a pickup or dine-in order placed now will be ready: the delivery
+// promise without travel
+func (s *RestaurantService) PromiseReady(ctx context.Context, restaurantID uuid.UUID, scheduledFor *time.Time) *OrderPromise {
+	return s.promise(ctx, restaurantID, 0, scheduledFor)
+}
+
+func (s *RestaurantService) promise(ctx context.Context, restaurantID uuid.UUID, travelMinutes int, scheduledFor *time.Time) *OrderPromise {
+	promise := &OrderPromise{TravelMinutes: travelMinutes}
+	travel := time.Duration(promise.TravelMinutes) * time.Minute
+
+	if scheduledFor != nil {
+		promise.PromisedAt = scheduledFor.Add(travel)
+		return promise
+	}
+
+	if restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantID); err == nil {
+		promise.PrepMinutes = restaurant.PreparationTime
+	}
+
+	now := time.Now()
+	counts, err := s.orderRepo.CountByStatus(ctx, repositories.OrderFilter{
+		RestaurantID: restaurantID,
+		Statuses:     promiseKitchenStatuses,
+		From:         now.Add(-promiseLoadWindow),
+	})
+	if err == nil {
+		for _, count := range counts {
+			promise.ActiveOrders += int(count.Count)
+		}
+	}
+	promise.LoadMinutes = promise.ActiveOrders / promiseKitchenCapacity * promise.PrepMinutes
+
+	promise.PromisedAt = now.Add(time.Duration(promise.PrepMinutes+promise.LoadMinutes)*time.Minute + travel)
+	return promise
+}
+
+// apply stores the promise on a new order
+func (p *OrderPromise) apply(order *models.Order) {
+	promisedAt, original := p.PromisedAt, p.PromisedAt
+	order.PromisedAt = &promisedAt
+	order.OriginalPromisedAt = &original
+	order.PromiseDetails = models.JSONB{
+		"prep_minutes":   p.PrepMinutes,
+		"active_orders":  p.ActiveOrders,
+		"load_minutes":   p.LoadMinutes,
+		"travel_minutes": p.TravelMinutes,
+		"delays":         []interface{}{},
+	}
+}
+
+// delayPromise moves the order's promised time to at when that is later, recording the delay,
+// and reports whether it moved. Promises are never brought forward.
+func delayPromise(order *models.Order, at time.Time, reason string) bool {
+	if order.PromisedAt == nil || !at.After(*order.PromisedAt) {
+		return false
+	}
+
+	if order.PromiseDetails == nil {
+		order.PromiseDetails = models.JSONB{}
+	}
+	delays, _ := order.PromiseDetails["delays"].([]interface{})
+	order.PromiseDetails["delays"] = append(delays, map[string]interface{}{
+		"minutes": int(math.Ceil(at.Sub(*order.PromisedAt).Minutes())),
+		"reason":  reason,
+		"at":      time.Now(),
+	})
+	order.PromisedAt = &at
+	return true
+}
+
+// promiseTravelMinutes returns the travel time the order's promise was computed with
+func promiseTravelMinutes(order *models.Order) int {
+	switch minutes := order.PromiseDetails["travel_minutes"].(type) {
+	case float64:
+		return int(minutes)
+	case int:
+		return minutes
+	}
+	return promiseDefaultTravelMinutes
+}
+
+// promiseDelayMinutes is how far the order's promise moved since checkout
+func promiseDelayMinutes(order *models.Order) int {
+	if order.PromisedAt == nil || order.OriginalPromisedAt == nil {
+		return 0
+	}
+	return int(math.Ceil(order.PromisedAt.Sub(*order.OriginalPromisedAt).Minutes()))
+}
+
+// promiseClock formats the promised time on the restaurant's clock for customer messages
+func promiseClock(order *models.Order) string {
+	loc, err := time.LoadLocation(order.Restaurant.TimeZone)
+	if err != nil {
+		loc = time.Local
+	}
+	return order.PromisedAt.In(loc).Format("15:04")
+}
+
+// DelayOrder moves the order's promised time later when the restaurant runs behind, and tells
+// the customer the new time
+func (s *OrderService) DelayOrder(ctx context.Context, orderID, restaurantID string, req *DelayOrderRequest) (*models.Order, error) {
+	if req.Minutes > promiseMaxDelayMinutes {
+		return nil, fmt.Errorf("%w: minutes must be at most %d", ErrOrderDelayInvalid, promiseMaxDelayMinutes)
+	}
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid order ID", ErrOrderDelayInvalid)
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "restaurant_delay"
+	}
+
+	var order *models.Order
+	err = retryOnConflict(ctx, func() error {
+		order, err = s.orderRepo.GetByID(ctx, orderUUID)
+		if err != nil || order.RestaurantID.String() != restaurantID {
+			return fmt.Errorf("%w: %s", ErrOrderDelayNotFound, orderID)
+		}
+		if IsFinalOrderStatus(order.OrderStatus) {
+			return fmt.Errorf("%w: order is %s", ErrOrderDelayInvalid, order.OrderStatus)
+		}
+
+		// Orders placed before promises were kept are delayed from now
+		if order.PromisedAt == nil {
+			now := time.Now()
+			order.PromisedAt = &now
+		}
+		delayPromise(order, order.PromisedAt.Add(time.Duration(req.Minutes)*time.Minute), reason)
+		appendOrderLog(order, order.OrderStatus, fmt.Sprintf("Delayed by %d minutes: %s", req.Minutes, reason))
+
+		message := fmt.Sprintf("Your order #%s is running late. It should now arrive by %s.", order.ID.String()[:8], promiseClock(order))
+		if req.Reason != "" {
+			message = fmt.Sprintf("Your order #%s is running late (%s). It should now arrive by %s.", order.ID.String()[:8], req.Reason, promiseClock(order))
+		}
+		notification, err := NewOutboxEvent("notification_events", order.UserID.String(), messaging.NotificationEvent{
+			Type:    "order_delayed",
+			UserID:  order.UserID.String(),
+			Title:   "Order Delayed",
+			Message: message,
+			Metadata: map[string]interface{}{
+				"order_id":    order.ID.String(),
+				"promised_at": order.PromisedAt,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		return s.orderRepo.UpdateWithEvents(ctx, order, []models.OutboxEvent{notification})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.tracking.PublishPromise(ctx, order)
+
+	return order, nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidOrderFilter is returned for order filters that cannot be applied
+var ErrInvalidOrderFilter = apperr.Validation("order_filter_invalid", "invalid order filter")
+
+// OrderListQuery filters a restaurant's orders. Dates are YYYY-MM-DD and both ends are inclusive.
+type OrderListQuery struct {
+	Status           string   `form:"status"` // one status or a comma-separated list
+	From             string   `form:"from"`
+	To               string   `form:"to"`
+	MinAmount        *float64 `form:"min_amount"`
+	MaxAmount        *float64 `form:"max_amount"`
+	PaymentMethod    string   `form:"payment_method"`
+	DeliveryProvider string   `form:"delivery_provider"`
+}
+
+type OrderStatusCountsResponse struct {
+	From   string                          `json:"from,omitempty"`
+	To     string                          `json:"to,omitempty"`
+	Total  int64                           `json:"total"`
+	Counts []repositories.OrderStatusCount `json:"counts"`
+}
+
+type DailyRevenueResponse struct {
+	From    string                      `json:"from"`
+	To      string                      `json:"to"`
+	Orders  int64                       `json:"orders"`
+	Revenue float64                     `json:"revenue"`
+	Days    []repositories.DailyRevenue `json:"days"`
+}
+
+// orderFilter turns a restaurant's list query into a repository filter
+func orderFilter(restaurantID string, q *OrderListQuery) (repositories.OrderFilter, error) {
+	var filter repositories.OrderFilter
+
+	restUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return filter, fmt.Errorf("%w: invalid restaurant ID", ErrInvalidOrderFilter)
+	}
+	filter.RestaurantID = restUUID
+	if q == nil {
+		return filter, nil
+	}
+
+	for _, status := range strings.Split(q.Status, ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	if q.From != "" {
+		from, err := time.Parse(dashboardDateLayout, q.From)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid 'from' date, expected YYYY-MM-DD", ErrInvalidOrderFilter)
+		}
+		filter.From = from
+	}
+	if q.To != "" {
+		to, err := time.Parse(dashboardDateLayout, q.To)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid 'to' date, expected YYYY-MM-DD", ErrInvalidOrderFilter)
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, fmt.Errorf("%w: 'from' date must not be after 'to' date", ErrInvalidOrderFilter)
+	}
+
+	if q.MinAmount != nil && q.MaxAmount != nil && *q.MinAmount > *q.MaxAmount {
+		return filter, fmt.Errorf("%w: min_amount must not be above max_amount", ErrInvalidOrderFilter)
+	}
+	filter.MinAmount = q.MinAmount
+	filter.MaxAmount = q.MaxAmount
+	filter.PaymentMethod = strings.TrimSpace(q.PaymentMethod)
+	filter.DeliveryProvider = strings.ToLower(strings.TrimSpace(q.DeliveryProvider))
+	return filter, nil
+}
+
+// GetRestaurantOrders returns a page of the restaurant's orders matching the query, newest
+// first, with the number of matching orders
+func (s *OrderService) GetRestaurantOrders(ctx context.Context, restaurantID string, q *OrderListQuery, limit, offset int) ([]models.Order, int64, error) {
+	filter, err := orderFilter(restaurantID, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.orderRepo.List(ctx, filter, offset, limit)
+}
+
+// CountRestaurantOrdersByStatus counts the restaurant's orders matching the query by status.
+// The status filter is ignored so every status is counted.
+func (s *OrderService) CountRestaurantOrdersByStatus(ctx context.Context, restaurantID string, q *OrderListQuery) (*OrderStatusCountsResponse, error) {
+	filter, err := orderFilter(restaurantID, q)
+	if err != nil {
+		return nil, err
+	}
+	filter.Statuses = nil
+
+	counts, err := s.orderRepo.CountByStatus(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count orders: %v", err)
+	}
+
+	response := &OrderStatusCountsResponse{Counts: counts}
+	if q != nil {
+		response.From, response.To = q.From, q.To
+	}
+	for _, count := range counts {
+		response.Total += count.Count
+	}
+	return response, nil
+}
+
+// GetRestaurantDailyRevenue sums the restaurant's sale orders matching the query per day. The
+// range defaults to the last 30 days and is capped like the admin dashboard's.
+func (s *OrderService) GetRestaurantDailyRevenue(ctx context.Context, restaurantID string, q *OrderListQuery) (*DailyRevenueResponse, error) {
+	if q == nil {
+		q = &OrderListQuery{}
+	}
+	dateRange, err := ParseDashboardRange(q.From, q.To)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOrderFilter, err)
+	}
+
+	filter, err := orderFilter(restaurantID, q)
+	if err != nil {
+		return nil, err
+	}
+	filter.From, filter.To = dateRange.from, dateRange.to
+
+	days, err := s.orderRepo.GetDailyRevenue(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate revenue: %v", err)
+	}
+
+	response := &DailyRevenueResponse{From: dateRange.From, To: dateRange.To, Days: days}
+	for _, day := range days {
+		response.Orders += day.Orders
+		response.Revenue += day.Revenue
+	}
+	response.Revenue = roundMoney(response.Revenue)
+	return response, nil
+}
@@ -2,13 +2,15 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"golang-food-backend/internal/models"
 	"golang-food-backend/internal/repositories"
 	"golang-food-backend/pkg/cache"
+	"golang-food-backend/pkg/i18n"
 	"golang-food-backend/pkg/messaging"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,9 +22,13 @@ type OrderService struct {
 	paymentRepo   repositories.PaymentRepository
 	userRepo      repositories.UserRepository
 	inventoryRepo repositories.InventoryRepository
+	productRepo   repositories.ProductRepository
+	restaurants   *RestaurantService
+	shopTime      *ShopTimeService
+	tracking      *OrderTrackingService
+	risk          *RiskService
+	tables        *TableService
 	cache         *cache.RedisCache
-	kafkaProducer *messaging.KafkaProducer
-	kafkaBrokers  []string
 }
 
 func NewOrderService(
@@ -31,9 +37,13 @@ func NewOrderService(
 	paymentRepo repositories.PaymentRepository,
 	userRepo repositories.UserRepository,
 	inventoryRepo repositories.InventoryRepository,
+	productRepo repositories.ProductRepository,
+	restaurants *RestaurantService,
+	shopTime *ShopTimeService,
+	tracking *OrderTrackingService,
+	risk *RiskService,
+	tables *TableService,
 	cache *cache.RedisCache,
-	kafkaProducer *messaging.KafkaProducer,
-	kafkaBrokers []string,
 ) *OrderService {
 	return &OrderService{
 		orderRepo:     orderRepo,
@@ -41,9 +51,13 @@ func NewOrderService(
 		paymentRepo:   paymentRepo,
 		userRepo:      userRepo,
 		inventoryRepo: inventoryRepo,
+		productRepo:   productRepo,
+		restaurants:   restaurants,
+		shopTime:      shopTime,
+		tracking:      tracking,
+		risk:          risk,
+		tables:        tables,
 		cache:         cache,
-		kafkaProducer: kafkaProducer,
-		kafkaBrokers:  kafkaBrokers,
 	}
 }
 
@@ -55,6 +69,10 @@ type CreateOrderRequest struct {
 	CustomerName                   string                 `json:"customer_name" binding:"required"`
 	CustomerContact                string                 `json:"customer_contact" binding:"required"`
 	CouponCode                     *string                `json:"coupon_code,omitempty"`
+	ScheduledFor                   *time.Time             `json:"scheduled_for,omitempty"` // order-ahead slot, RFC3339
+	CustomerNotes                  string                 `json:"customer_notes,omitempty" binding:"max=250"`
+	OrderType                      string                 `json:"order_type,omitempty"`  // delivery (default), pickup or dine_in
+	TableToken                     string                 `json:"table_token,omitempty"` // from the table's QR code; required for dine_in
 }
 
 type OrderResponse struct {
@@ -85,16 +103,51 @@ func (s *OrderService) CreateOrder(ctx context.Context, userID string, req *Crea
 	if cart.Status != "active" {
 		return nil, errors.New("cart is not active")
 	}
-
-	// Parse cart items
-	var cartItems []models.CartItem
-	if err := json.Unmarshal([]byte(fmt.Sprintf("%v", cart.Items)), &cartItems); err != nil {
-		return nil, errors.New("invalid cart items")
+	if err := s.restaurants.EnsureAcceptingOrders(ctx, cart.RestaurantID); err != nil {
+		return nil, err
+	}
+	orderType, err := normalizeOrderType(req.OrderType)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.restaurants.EnsureOrderType(ctx, cart.RestaurantID, orderType); err != nil {
+		return nil, err
 	}
 
+	// Price the cart lines, including variant and addon selections
+	cartItems := models.DecodeCartItems(cart.Items)
 	if len(cartItems) == 0 {
 		return nil, errors.New("cart is empty")
 	}
+	lineItems, _, err := priceCartItems(ctx, s.productRepo, cartItems)
+	if err != nil {
+		return nil, err
+	}
+	if len(lineItems) == 0 {
+		return nil, errors.New("none of the items in the cart are available")
+	}
+
+	// Busy mode holds back orders due in the kitchen before the pause ends
+	readyBy := time.Now()
+	if req.ScheduledFor != nil {
+		readyBy = *req.ScheduledFor
+	}
+	if err := s.restaurants.EnsureNotPaused(ctx, cart.RestaurantID, readyBy); err != nil {
+		return nil, err
+	}
+
+	// Validate the order-ahead slot
+	orderStatus := "pending"
+	if req.ScheduledFor != nil {
+		productIDs := make([]string, 0, len(cartItems))
+		for _, item := range cartItems {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		if err := s.shopTime.ValidateScheduleSlot(ctx, cart.RestaurantID.String(), productIDs, *req.ScheduledFor); err != nil {
+			return nil, err
+		}
+		orderStatus = "scheduled"
+	}
 
 	// Reserve inventory for each item
 	for range cartItems {
@@ -104,12 +157,34 @@ func (s *OrderService) CreateOrder(ctx context.Context, userID string, req *Crea
 
 	// Parse user and address IDs
 	userUUID, _ := uuid.Parse(userID)
+	// Pickup and dine-in orders are not delivered anywhere
 	var addressUUID *uuid.UUID
-	if req.AddressID != nil {
-		addr, err := uuid.Parse(*req.AddressID)
-		if err == nil {
-			addressUUID = &addr
+	var deliveryAddress models.JSONB
+	if orderType == OrderTypeDelivery {
+		if req.AddressID != nil {
+			addr, err := uuid.Parse(*req.AddressID)
+			if err == nil {
+				addressUUID = &addr
+			}
 		}
+		deliveryAddress = req.DeliveryFullAddressWithLatLong
+	}
+
+	// Dine-in rounds are paid when the table's tab is closed
+	paymentMethod := req.PaymentMethod
+	if orderType == OrderTypeDineIn {
+		paymentMethod = PaymentMethodTab
+	}
+
+	assessment, err := s.risk.Assess(ctx, RiskCheck{
+		Event:           RiskEventCheckout,
+		UserID:          userUUID,
+		PaymentMethod:   paymentMethod,
+		AddressID:       addressUUID,
+		DeliveryAddress: deliveryAddress,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Create order
@@ -117,14 +192,35 @@ func (s *OrderService) CreateOrder(ctx context.Context, userID string, req *Crea
 		UserID:                         userUUID,
 		RestaurantID:                   cart.RestaurantID,
 		CartID:                         cartUUID,
-		OrderStatus:                    "pending",
+		OrderType:                      orderType,
+		OrderStatus:                    orderStatus,
+		ScheduledFor:                   req.ScheduledFor,
 		TotalAmount:                    cart.TotalAmount,
 		CustomerName:                   req.CustomerName,
 		CustomerContact:                req.CustomerContact,
+		CustomerNotes:                  strings.TrimSpace(req.CustomerNotes),
 		AddressID:                      addressUUID,
-		DeliveryFullAddressWithLatLong: req.DeliveryFullAddressWithLatLong,
+		DeliveryFullAddressWithLatLong: deliveryAddress,
+		LineItems:                      models.EncodeOrderLineItems(orderLineItems(lineItems)),
+		Currency:                       s.restaurants.Currency(ctx, cart.RestaurantID),
+		Language:                       i18n.LanguageFromContext(ctx),
 		CreatedAt:                      time.Now(),
 	}
+	if usesCollectionCode(orderType) {
+		if err := assignDeliveryCode(order); err != nil {
+			return nil, err
+		}
+	}
+	if orderType == OrderTypeDineIn {
+		if err := s.tables.attach(ctx, order, req.TableToken); err != nil {
+			return nil, err
+		}
+	}
+	if orderType == OrderTypeDelivery {
+		s.restaurants.PromiseDelivery(ctx, cart.RestaurantID, 0, req.ScheduledFor).apply(order)
+	} else {
+		s.restaurants.PromiseReady(ctx, cart.RestaurantID, req.ScheduledFor).apply(order)
+	}
 
 	if err := s.orderRepo.Create(ctx, order); err != nil {
 		return nil, err
@@ -135,7 +231,7 @@ func (s *OrderService) CreateOrder(ctx context.Context, userID string, req *Crea
 		OrderID:   order.ID,
 		UserID:    userUUID,
 		Amount:    order.TotalAmount,
-		Method:    req.PaymentMethod,
+		Method:    paymentMethod,
 		Status:    "pending",
 		CreatedAt: time.Now(),
 	}
@@ -144,39 +240,44 @@ func (s *OrderService) CreateOrder(ctx context.Context, userID string, req *Crea
 		return nil, err
 	}
 
-	// Update order with payment ID
+	// Update order with payment ID. The order event (inventory reserves stock from it) and the
+	// confirmation go through the outbox with this update.
 	order.PaymentID = &payment.ID
-	if err := s.orderRepo.Update(ctx, order); err != nil {
-		return nil, err
+	message := fmt.Sprintf("Your order #%s has been confirmed", order.ID.String()[:8])
+	if order.ScheduledFor != nil {
+		message = fmt.Sprintf("Your order #%s has been scheduled for %s", order.ID.String()[:8], order.ScheduledFor.Format("02 Jan 15:04"))
 	}
-
-	// Mark cart as used
-	cart.Status = "ordered"
-	if err := s.cartRepo.Update(ctx, cart); err != nil {
+	created, err := orderCreatedEvent(order)
+	if err != nil {
 		return nil, err
 	}
-
-	// Send order event to Kafka
-	orderEvent := messaging.OrderEvent{
-		Type:    "order_created",
-		OrderID: order.ID.String(),
-		UserID:  userID,
-		Data:    order,
-	}
-	s.kafkaProducer.SendMessage("order_events", s.kafkaBrokers, order.ID.String(), orderEvent)
-
-	// Send notification event
-	notificationEvent := messaging.NotificationEvent{
+	notification, err := NewOutboxEvent("notification_events", userID, messaging.NotificationEvent{
 		Type:    "order_confirmation",
 		UserID:  userID,
 		Title:   "Order Confirmed",
-		Message: fmt.Sprintf("Your order #%s has been confirmed", order.ID.String()[:8]),
+		Message: message,
 		Metadata: map[string]interface{}{
 			"order_id": order.ID.String(),
 			"amount":   order.TotalAmount,
 		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.orderRepo.UpdateWithEvents(ctx, order, []models.OutboxEvent{created, notification}); err != nil {
+		return nil, err
 	}
-	s.kafkaProducer.SendMessage("notification_events", s.kafkaBrokers, userID, notificationEvent)
+
+	// Mark cart as used. A cart changed while the order was placed keeps the newer items
+	// for a later order instead of failing the one already placed.
+	cart.Status = "ordered"
+	if err := s.cartRepo.Update(ctx, cart); err != nil {
+		if !errors.Is(err, repositories.ErrVersionConflict) {
+			return nil, err
+		}
+		log.Printf("Cart %s changed while order %s was placed, leaving it active", cart.ID, order.ID)
+	}
+	s.risk.Record(ctx, assessment, &order.ID, nil)
 
 	response := &OrderResponse{
 		Order:   order,
@@ -184,7 +285,7 @@ func (s *OrderService) CreateOrder(ctx context.Context, userID string, req *Crea
 	}
 
 	// For non-cash payments, generate payment URL
-	if req.PaymentMethod != "cash" {
+	if paymentMethod != "cash" && paymentMethod != PaymentMethodTab {
 		response.PaymentURL = s.generatePaymentURL(payment)
 	}
 
@@ -220,44 +321,223 @@ func (s *OrderService) GetUserOrders(ctx context.Context, userID string, limit,
 	return s.orderRepo.GetByUserID(ctx, userUUID, limit, offset)
 }
 
-func (s *OrderService) GetRestaurantOrders(ctx context.Context, restaurantID string, limit, offset int) ([]models.Order, error) {
-	restUUID, err := uuid.Parse(restaurantID)
+func (s *OrderService) UpdateOrderStatus(ctx context.Context, orderID string, newStatus string, restaurantID string) error {
+	orderUUID, err := uuid.Parse(orderID)
 	if err != nil {
-		return nil, errors.New("invalid restaurant ID")
+		return errors.New("invalid order ID")
 	}
 
-	return s.orderRepo.GetByRestaurantID(ctx, restUUID, limit, offset)
+	var order *models.Order
+	err = retryOnConflict(ctx, func() error {
+		order, err = s.updateOrderStatus(ctx, orderUUID, newStatus, restaurantID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.tracking.PublishStatus(ctx, order)
+
+	return nil
 }
 
-func (s *OrderService) UpdateOrderStatus(ctx context.Context, orderID string, newStatus string, restaurantID string) error {
-	orderUUID, err := uuid.Parse(orderID)
+// CancelOrder cancels an order on the platform's behalf, such as one rejected in fraud review.
+// Orders already delivered or cancelled are left as they are.
+func (s *OrderService) CancelOrder(ctx context.Context, orderID uuid.UUID) error {
+	var order *models.Order
+	err := retryOnConflict(ctx, func() error {
+		var err error
+		order, err = s.orderRepo.GetByID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if IsFinalOrderStatus(order.OrderStatus) {
+			return nil
+		}
+		return s.applyOrderStatus(ctx, order, "cancelled")
+	})
 	if err != nil {
-		return errors.New("invalid order ID")
+		return err
 	}
 
-	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	s.tracking.PublishStatus(ctx, order)
+	return nil
+}
+
+// ExpireUnpaidOrder cancels an order still awaiting payment once its payment window has passed.
+// It reports false, changing nothing, when the order was paid or moved on meanwhile.
+func (s *OrderService) ExpireUnpaidOrder(ctx context.Context, orderID uuid.UUID) (bool, error) {
+	var order *models.Order
+	expired := false
+	err := retryOnConflict(ctx, func() error {
+		var err error
+		order, err = s.orderRepo.GetByID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		expired = order.OrderStatus == "pending_payment"
+		if !expired {
+			return nil
+		}
+		return s.saveOrderStatus(ctx, order, "cancelled", "Cancelled because payment was not completed in time")
+	})
+	if err != nil || !expired {
+		return false, err
+	}
+
+	s.tracking.PublishStatus(ctx, order)
+	return true, nil
+}
+
+// updateOrderStatus applies a status change to the order as currently stored
+func (s *OrderService) updateOrderStatus(ctx context.Context, orderID uuid.UUID, newStatus string, restaurantID string) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Verify order belongs to restaurant
 	if order.RestaurantID.String() != restaurantID {
-		return errors.New("order does not belong to this restaurant")
+		return nil, errors.New("order does not belong to this restaurant")
+	}
+
+	if err := s.applyOrderStatus(ctx, order, newStatus); err != nil {
+		return nil, err
 	}
+	return order, nil
+}
 
+// applyOrderStatus moves the order to newStatus, writing the status event and the customer
+// notification with the update
+func (s *OrderService) applyOrderStatus(ctx context.Context, order *models.Order, newStatus string) error {
 	// Validate status transition
-	if !s.isValidStatusTransition(order.OrderStatus, newStatus) {
+	if !s.isValidStatusTransition(order.OrderType, order.OrderStatus, newStatus) {
 		return errors.New("invalid status transition")
 	}
+	if newStatus == "delivered" && deliveryCodePending(order) {
+		return ErrDeliveryCodeRequired
+	}
+	return s.saveOrderStatus(ctx, order, newStatus, fmt.Sprintf("Status updated to %s", newStatus))
+}
 
-	// Update order status
+// saveOrderStatus moves the order to newStatus without checking the transition, logging why
+func (s *OrderService) saveOrderStatus(ctx context.Context, order *models.Order, newStatus, reason string) error {
+	oldStatus := order.OrderStatus
 	order.OrderStatus = newStatus
+	appendOrderLog(order, newStatus, reason)
+
+	message := s.getStatusUpdateMessage(order.OrderType, newStatus)
+	// An order leaving the kitchen late can no longer make its promise; move it to when the
+	// ride actually gets there
+	if newStatus == "dispatched" {
+		travel := time.Duration(promiseTravelMinutes(order)) * time.Minute
+		if delayPromise(order, time.Now().Add(travel), "late_dispatch") {
+			message += fmt.Sprintf(". It should now arrive by %s", promiseClock(order))
+		}
+	}
+	if newStatus == "dispatched" && order.DeliveryCode != "" {
+		message += fmt.Sprintf(". Share delivery code %s with the rider to receive it.", order.DeliveryCode)
+	}
+	if newStatus == "ready_for_pickup" && order.DeliveryCode != "" {
+		message += fmt.Sprintf(". Show collection code %s at the counter to collect it.", order.DeliveryCode)
+	}
+
+	statusData := map[string]interface{}{
+		"order_id":   order.ID.String(),
+		"new_status": newStatus,
+		"old_status": oldStatus,
+	}
+	if order.PromisedAt != nil {
+		statusData["promised_at"] = order.PromisedAt
+	}
+	statusEvent, err := NewOutboxEvent("order_events", order.ID.String(), messaging.OrderEvent{
+		Type:    "order_status_updated",
+		OrderID: order.ID.String(),
+		UserID:  order.UserID.String(),
+		Data:    statusData,
+	})
+	if err != nil {
+		return err
+	}
+	notification, err := NewOutboxEvent("notification_events", order.UserID.String(), messaging.NotificationEvent{
+		Type:    "order_status_update",
+		UserID:  order.UserID.String(),
+		Title:   "Order Update",
+		Message: message,
+		Metadata: map[string]interface{}{
+			"order_id": order.ID.String(),
+			"status":   newStatus,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	lifecycle, err := orderStatusEvents(order, oldStatus)
+	if err != nil {
+		return err
+	}
+	return s.orderRepo.UpdateWithEvents(ctx, order, append([]models.OutboxEvent{statusEvent, notification}, lifecycle...))
+}
+
+// ReleaseDueScheduledOrders moves scheduled orders into the normal pipeline once
+// the restaurant needs to start preparing them
+func (s *OrderService) ReleaseDueScheduledOrders(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	// Look ahead far enough to cover the longest preparation times
+	orders, err := s.orderRepo.GetScheduledDue(ctx, now.Add(2*time.Hour), 100)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get scheduled orders: %v", err)
+	}
 
-	// Add to order logs
+	released := 0
+	for i := range orders {
+		order := &orders[i]
+		if order.ScheduledFor == nil {
+			continue
+		}
+
+		prepTime := time.Duration(order.Restaurant.PreparationTime) * time.Minute
+		if now.Before(order.ScheduledFor.Add(-prepTime)) {
+			continue
+		}
+
+		order.OrderStatus = "pending"
+		appendOrderLog(order, "pending", "Scheduled order released for preparation")
+
+		releasedEvent, err := NewOutboxEvent("order_events", order.ID.String(), messaging.OrderEvent{
+			Type:    "order_released",
+			OrderID: order.ID.String(),
+			UserID:  order.UserID.String(),
+			Data: map[string]interface{}{
+				"order_id":      order.ID.String(),
+				"restaurant_id": order.RestaurantID.String(),
+				"scheduled_for": order.ScheduledFor,
+			},
+		})
+		if err != nil {
+			return released, err
+		}
+		if err := s.orderRepo.UpdateWithEvents(ctx, order, []models.OutboxEvent{releasedEvent}); err != nil {
+			if errors.Is(err, repositories.ErrVersionConflict) {
+				continue // changed meanwhile; the next run looks at it again
+			}
+			return released, fmt.Errorf("failed to release order %s: %v", order.ID, err)
+		}
+		released++
+		s.tracking.PublishStatus(ctx, order)
+	}
+
+	return released, nil
+}
+
+// appendOrderLog adds an entry to the order's JSONB log history
+func appendOrderLog(order *models.Order, status, note string) {
 	logEntry := map[string]interface{}{
 		"timestamp": time.Now(),
-		"status":    newStatus,
-		"note":      fmt.Sprintf("Status updated to %s", newStatus),
+		"status":    status,
+		"note":      note,
 	}
 
 	// Handle OrderLogs as JSONB (map[string]interface{})
@@ -274,48 +554,22 @@ func (s *OrderService) UpdateOrderStatus(ctx context.Context, orderID string, ne
 					logs = append(logs, logMap)
 				}
 			}
+		} else if logSlice, ok := existingLogs.([]map[string]interface{}); ok {
+			logs = logSlice
 		}
 	}
 
 	// Append new log entry
 	logs = append(logs, logEntry)
 	order.OrderLogs["logs"] = logs
-
-	if err := s.orderRepo.Update(ctx, order); err != nil {
-		return err
-	}
-
-	// Send status update event
-	orderEvent := messaging.OrderEvent{
-		Type:    "order_status_updated",
-		OrderID: order.ID.String(),
-		UserID:  order.UserID.String(),
-		Data: map[string]interface{}{
-			"order_id":   order.ID.String(),
-			"new_status": newStatus,
-			"old_status": order.OrderStatus,
-		},
-	}
-	s.kafkaProducer.SendMessage("order_events", s.kafkaBrokers, order.ID.String(), orderEvent)
-
-	// Send notification to user
-	notificationEvent := messaging.NotificationEvent{
-		Type:    "order_status_update",
-		UserID:  order.UserID.String(),
-		Title:   "Order Update",
-		Message: s.getStatusUpdateMessage(newStatus),
-		Metadata: map[string]interface{}{
-			"order_id": order.ID.String(),
-			"status":   newStatus,
-		},
-	}
-	s.kafkaProducer.SendMessage("notification_events", s.kafkaBrokers, order.UserID.String(), notificationEvent)
-
-	return nil
 }
 
-func (s *OrderService) isValidStatusTransition(currentStatus, newStatus string) bool {
+// isValidStatusTransition checks the status change against the order type's flow. Pickup
+// orders wait at the counter as ready_for_pickup and dine-in orders are served straight from
+// the kitchen; delivered marks both as handed over.
+func (s *OrderService) isValidStatusTransition(orderType, currentStatus, newStatus string) bool {
 	validTransitions := map[string][]string{
+		"scheduled":  {"pending", "cancelled"},
 		"pending":    {"confirmed", "cancelled"},
 		"confirmed":  {"preparing", "cancelled"},
 		"preparing":  {"dispatched", "cancelled"},
@@ -323,6 +577,15 @@ func (s *OrderService) isValidStatusTransition(currentStatus, newStatus string)
 		"delivered":  {}, // terminal state
 		"cancelled":  {}, // terminal state
 	}
+	switch orderType {
+	case OrderTypePickup:
+		validTransitions["preparing"] = []string{"ready_for_pickup", "cancelled"}
+		validTransitions["ready_for_pickup"] = []string{"delivered", "cancelled"}
+		delete(validTransitions, "dispatched")
+	case OrderTypeDineIn:
+		validTransitions["preparing"] = []string{"delivered", "cancelled"}
+		delete(validTransitions, "dispatched")
+	}
 
 	allowedStatuses, exists := validTransitions[currentStatus]
 	if !exists {
@@ -338,13 +601,20 @@ func (s *OrderService) isValidStatusTransition(currentStatus, newStatus string)
 	return false
 }
 
-func (s *OrderService) getStatusUpdateMessage(status string) string {
+func (s *OrderService) getStatusUpdateMessage(orderType, status string) string {
 	messages := map[string]string{
-		"confirmed":  "Your order has been confirmed by the restaurant",
-		"preparing":  "Your order is being prepared",
-		"dispatched": "Your order is on the way",
-		"delivered":  "Your order has been delivered",
-		"cancelled":  "Your order has been cancelled",
+		"confirmed":        "Your order has been confirmed by the restaurant",
+		"preparing":        "Your order is being prepared",
+		"dispatched":       "Your order is on the way",
+		"ready_for_pickup": "Your order is ready for pickup",
+		"delivered":        "Your order has been delivered",
+		"cancelled":        "Your order has been cancelled",
+	}
+	switch orderType {
+	case OrderTypePickup:
+		messages["delivered"] = "Your order has been collected"
+	case OrderTypeDineIn:
+		messages["delivered"] = "Your order has been served"
 	}
 
 	if message, exists := messages[status]; exists {
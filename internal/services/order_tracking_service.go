@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/cache"
+	"golang-food-backend/pkg/geo"
+	"golang-food-backend/pkg/messaging"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Number of location pings returned in the delivery polyline
+const deliveryTrackHistoryLimit = 200
+
+type OrderTrackingService struct {
+	orderRepo     repositories.OrderRepository
+	locationRepo  repositories.DeliveryLocationRepository
+	cache         *cache.RedisCache
+	kafkaProducer *messaging.KafkaProducer
+	kafkaBrokers  []string
+}
+
+func NewOrderTrackingService(
+	orderRepo repositories.OrderRepository,
+	locationRepo repositories.DeliveryLocationRepository,
+	cache *cache.RedisCache,
+	kafkaProducer *messaging.KafkaProducer,
+	kafkaBrokers []string,
+) *OrderTrackingService {
+	return &OrderTrackingService{
+		orderRepo:     orderRepo,
+		locationRepo:  locationRepo,
+		cache:         cache,
+		kafkaProducer: kafkaProducer,
+		kafkaBrokers:  kafkaBrokers,
+	}
+}
+
+// TrackingEvent is pushed to clients following an order in realtime
+type TrackingEvent struct {
+	Type      string                 `json:"type"` // status, promise, partner_location
+	OrderID   string                 `json:"order_id"`
+	Status    string                 `json:"status,omitempty"`
+	Latitude  float64                `json:"latitude,omitempty"`
+	Longitude float64                `json:"longitude,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+type DeliveryTrackResponse struct {
+	OrderID          string                        `json:"order_id"`
+	OrderStatus      string                        `json:"order_status"`
+	LatestLocation   *models.DeliveryLocationPing  `json:"latest_location"`
+	Path             []models.DeliveryLocationPing `json:"path"`
+	DistanceKm       *float64                      `json:"remaining_distance_km,omitempty"`
+	EstimatedArrival *time.Time                    `json:"estimated_arrival,omitempty"`
+	PromisedAt       *time.Time                    `json:"promised_at,omitempty"`   // delivery time promised to the customer
+	DelayMinutes     int                           `json:"delay_minutes,omitempty"` // how far the promise moved since checkout
+}
+
+func trackingChannel(orderID string) string {
+	return "order_tracking:" + orderID
+}
+
+func latestLocationKey(orderID string) string {
+	return "delivery_location:" + orderID
+}
+
+// PublishStatus broadcasts an order status change to tracking subscribers
+func (s *OrderTrackingService) PublishStatus(ctx context.Context, order *models.Order) {
+	event := TrackingEvent{
+		Type:      "status",
+		OrderID:   order.ID.String(),
+		Status:    order.OrderStatus,
+		Timestamp: time.Now(),
+	}
+	if order.PromisedAt != nil {
+		event.Data = map[string]interface{}{"promised_at": order.PromisedAt}
+	}
+	if err := s.cache.Publish(ctx, trackingChannel(event.OrderID), event); err != nil {
+		log.Printf("Failed to publish tracking status for order %s: %v", event.OrderID, err)
+	}
+}
+
+// PublishPromise tells tracking subscribers that the order's promised delivery time moved
+func (s *OrderTrackingService) PublishPromise(ctx context.Context, order *models.Order) {
+	if order.PromisedAt == nil {
+		return
+	}
+	event := TrackingEvent{
+		Type:    "promise",
+		OrderID: order.ID.String(),
+		Status:  order.OrderStatus,
+		Data: map[string]interface{}{
+			"promised_at":   order.PromisedAt,
+			"delay_minutes": promiseDelayMinutes(order),
+		},
+		Timestamp: time.Now(),
+	}
+	if err := s.cache.Publish(ctx, trackingChannel(event.OrderID), event); err != nil {
+		log.Printf("Failed to publish promise for order %s: %v", event.OrderID, err)
+	}
+}
+
+// orderCreatedEvent is the OrderCreated contract event for a newly placed order, written to
+// the outbox with the order's payment link
+func orderCreatedEvent(order *models.Order) (models.OutboxEvent, error) {
+	created := messaging.OrderCreated{
+		OrderID:      order.ID.String(),
+		UserID:       order.UserID.String(),
+		RestaurantID: order.RestaurantID.String(),
+		Status:       order.OrderStatus,
+		TotalAmount:  order.TotalAmount.Float64(),
+		ScheduledFor: order.ScheduledFor,
+		Items:        orderEventItems(order),
+	}
+	if order.PaymentID != nil {
+		created.PaymentID = order.PaymentID.String()
+	}
+
+	envelope, err := messaging.Events.NewEnvelope(messaging.EventOrderCreated, order.RestaurantID.String(), created)
+	if err != nil {
+		return models.OutboxEvent{}, err
+	}
+	return NewOutboxEvent("order_events", order.ID.String(), envelope)
+}
+
+// orderStatusEvents are the contract events of an order that moved out of previous into its
+// current status, written to the outbox with the change: OrderCancelled when it was cancelled
+// and OrderCompleted when it was delivered, collected or served
+func orderStatusEvents(order *models.Order, previous string) ([]models.OutboxEvent, error) {
+	if order.OrderStatus == previous {
+		return nil, nil
+	}
+
+	var (
+		envelope messaging.Envelope
+		err      error
+	)
+	switch order.OrderStatus {
+	case "cancelled":
+		envelope, err = messaging.Events.NewEnvelope(messaging.EventOrderCancelled, order.RestaurantID.String(), messaging.OrderCancelled{
+			OrderID:      order.ID.String(),
+			UserID:       order.UserID.String(),
+			RestaurantID: order.RestaurantID.String(),
+			Items:        orderEventItems(order),
+			CancelledAt:  time.Now().UTC(),
+		})
+	case "delivered":
+		envelope, err = messaging.Events.NewEnvelope(messaging.EventOrderCompleted, order.RestaurantID.String(), messaging.OrderCompleted{
+			OrderID:      order.ID.String(),
+			UserID:       order.UserID.String(),
+			RestaurantID: order.RestaurantID.String(),
+			TotalAmount:  order.TotalAmount.Float64(),
+			Items:        orderEventItems(order),
+			PlacedAt:     order.CreatedAt.UTC(),
+			DeliveredAt:  time.Now().UTC(),
+		})
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := NewOutboxEvent("order_events", order.ID.String(), envelope)
+	if err != nil {
+		return nil, err
+	}
+	return []models.OutboxEvent{event}, nil
+}
+
+// orderEventItems lists what was ordered, from the order's line items
+func orderEventItems(order *models.Order) []messaging.OrderEventItem {
+	items := []messaging.OrderEventItem{}
+	for _, line := range models.DecodeOrderLineItems(order.LineItems) {
+		items = append(items, messaging.OrderEventItem{ProductID: line.ProductID, Quantity: line.Quantity})
+	}
+	return items
+}
+
+// PublishDeliveryStatus emits a DeliveryStatusChanged contract event for a delivery update
+func (s *OrderTrackingService) PublishDeliveryStatus(ctx context.Context, orderID, restaurantID uuid.UUID, provider, providerOrderID, status, orderStatus string) {
+	envelope, err := messaging.Events.NewEnvelope(messaging.EventDeliveryStatusChanged, restaurantID.String(), messaging.DeliveryStatusChanged{
+		OrderID:         orderID.String(),
+		Provider:        provider,
+		ProviderOrderID: providerOrderID,
+		Status:          status,
+		OrderStatus:     orderStatus,
+	})
+	if err == nil {
+		err = s.kafkaProducer.PublishEvent(ctx, "delivery_events", s.kafkaBrokers, orderID.String(), envelope)
+	}
+	if err != nil {
+		log.Printf("Failed to publish delivery status %s for order %s: %v", status, orderID, err)
+	}
+}
+
+// PublishPartnerLocation broadcasts a delivery partner location ping to tracking subscribers
+func (s *OrderTrackingService) PublishPartnerLocation(ctx context.Context, orderID uuid.UUID, lat, lng float64, data map[string]interface{}) {
+	event := TrackingEvent{
+		Type:      "partner_location",
+		OrderID:   orderID.String(),
+		Latitude:  lat,
+		Longitude: lng,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+	if err := s.cache.Publish(ctx, trackingChannel(event.OrderID), event); err != nil {
+		log.Printf("Failed to publish partner location for order %s: %v", event.OrderID, err)
+	}
+}
+
+// RecordPartnerLocation stores a location ping, caches it as the latest point and streams it to subscribers
+func (s *OrderTrackingService) RecordPartnerLocation(ctx context.Context, orderID uuid.UUID, provider, providerOrderID, status string, lat, lng float64) error {
+	ping := &models.DeliveryLocationPing{
+		OrderID:         orderID.String(),
+		Provider:        provider,
+		ProviderOrderID: providerOrderID,
+		Latitude:        lat,
+		Longitude:       lng,
+		Status:          status,
+		RecordedAt:      time.Now(),
+	}
+
+	if err := s.locationRepo.Create(ctx, ping); err != nil {
+		return fmt.Errorf("failed to save location ping: %v", err)
+	}
+
+	s.cache.Set(ctx, latestLocationKey(ping.OrderID), ping, time.Hour)
+
+	s.PublishPartnerLocation(ctx, orderID, lat, lng, map[string]interface{}{
+		"provider": provider,
+		"status":   status,
+	})
+
+	return nil
+}
+
+// GetDeliveryTrack returns the latest partner location, the path so far and an ETA to the drop point
+func (s *OrderTrackingService) GetDeliveryTrack(ctx context.Context, order *models.Order) (*DeliveryTrackResponse, error) {
+	orderID := order.ID.String()
+
+	response := &DeliveryTrackResponse{
+		OrderID:     orderID,
+		OrderStatus: order.OrderStatus,
+		Path:        []models.DeliveryLocationPing{},
+		PromisedAt:  order.PromisedAt,
+	}
+	response.DelayMinutes = promiseDelayMinutes(order)
+
+	// Latest point from cache, falling back to MongoDB
+	var latest models.DeliveryLocationPing
+	if err := s.cache.Get(ctx, latestLocationKey(orderID), &latest); err == nil {
+		response.LatestLocation = &latest
+	} else if ping, err := s.locationRepo.GetLatestByOrderID(ctx, orderID); err == nil {
+		response.LatestLocation = ping
+	}
+
+	path, err := s.locationRepo.GetByOrderID(ctx, orderID, deliveryTrackHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location history: %v", err)
+	}
+	response.Path = path
+
+	if response.LatestLocation == nil || IsFinalOrderStatus(order.OrderStatus) {
+		return response, nil
+	}
+
+	dropLat, latOK := order.DeliveryFullAddressWithLatLong["latitude"].(float64)
+	dropLng, lngOK := order.DeliveryFullAddressWithLatLong["longitude"].(float64)
+	if !latOK || !lngOK {
+		return response, nil
+	}
+
+	distance := geo.DistanceKm(
+		geo.Point{Lat: response.LatestLocation.Latitude, Lng: response.LatestLocation.Longitude},
+		geo.Point{Lat: dropLat, Lng: dropLng},
+	)
+	distance = math.Round(distance*100) / 100
+	eta := time.Now().Add(time.Duration(distance / averageDeliverySpeedKmph * float64(time.Hour)))
+
+	response.DistanceKm = &distance
+	response.EstimatedArrival = &eta
+
+	return response, nil
+}
+
+// IsFinalOrderStatus reports whether an order can no longer change
+func IsFinalOrderStatus(status string) bool {
+	return status == "delivered" || status == "cancelled"
+}
+
+// GetTrackableOrder returns the order if the caller is allowed to follow it
+func (s *OrderTrackingService) GetTrackableOrder(ctx context.Context, orderID, userID, role, restaurantID string) (*models.Order, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, errors.New("invalid order ID")
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+
+	switch role {
+	case "admin":
+	case "restaurant_owner", "restaurant_staff":
+		if order.RestaurantID.String() != restaurantID {
+			return nil, errors.New("order does not belong to this restaurant")
+		}
+	default:
+		if order.UserID.String() != userID {
+			return nil, errors.New("order does not belong to user")
+		}
+	}
+
+	return order, nil
+}
+
+// Subscribe streams tracking events for an order until ctx is cancelled
+func (s *OrderTrackingService) Subscribe(ctx context.Context, orderID string) <-chan TrackingEvent {
+	events := make(chan TrackingEvent)
+	pubsub := s.cache.Subscribe(ctx, trackingChannel(orderID))
+
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var event TrackingEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events
+}
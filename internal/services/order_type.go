@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/apperr"
+
+	"github.com/google/uuid"
+)
+
+// Order types. Pickup orders are collected at the counter with their collection code and
+// dine-in orders are served at the restaurant; neither is delivered or pays a delivery fee.
+const (
+	OrderTypeDelivery = "delivery"
+	OrderTypePickup   = "pickup"
+	OrderTypeDineIn   = "dine_in"
+)
+
+var (
+	ErrOrderTypeInvalid     = apperr.Validation("order_type_invalid", "order_type must be delivery, pickup or dine_in")
+	ErrOrderTypeUnavailable = apperr.Conflict("order_type_unavailable", "the restaurant does not take this type of order")
+	ErrOrderTypesDisabled   = apperr.Validation("order_types_disabled", "at least one of delivery, pickup and dine-in must stay enabled")
+	ErrNotDeliveryOrder     = apperr.Conflict("not_delivery_order", "the order is not for delivery")
+)
+
+// normalizeOrderType defaults an empty order type to delivery and rejects unknown ones
+func normalizeOrderType(orderType string) (string, error) {
+	switch orderType = strings.ToLower(strings.TrimSpace(orderType)); orderType {
+	case "":
+		return OrderTypeDelivery, nil
+	case OrderTypeDelivery, OrderTypePickup, OrderTypeDineIn:
+		return orderType, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrOrderTypeInvalid, orderType)
+}
+
+// isDeliveryOrder reports whether the order goes out with a rider or delivery partner. Orders
+// placed before order types were introduced are all deliveries.
+func isDeliveryOrder(order *models.Order) bool {
+	return order.OrderType == "" || order.OrderType == OrderTypeDelivery
+}
+
+// usesCollectionCode reports whether the order is handed over against its delivery code: at
+// the door for deliveries and at the counter for pickups
+func usesCollectionCode(orderType string) bool {
+	return orderType != OrderTypeDineIn
+}
+
+// orderTypeEnabled reports whether the restaurant takes orders of the type
+func orderTypeEnabled(restaurant *models.Restaurant, orderType string) bool {
+	switch orderType {
+	case OrderTypeDelivery:
+		return restaurant.DeliveryEnabled
+	case OrderTypePickup:
+		return restaurant.PickupEnabled
+	case OrderTypeDineIn:
+		return restaurant.DineInEnabled
+	}
+	return false
+}
+
+// EnsureOrderType fails with ErrOrderTypeUnavailable when the restaurant has switched the order
+// type off
+func (s *RestaurantService) EnsureOrderType(ctx context.Context, restaurantID uuid.UUID, orderType string) error {
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantID)
+	if err != nil {
+		return errors.New("restaurant not found")
+	}
+	if !orderTypeEnabled(restaurant, orderType) {
+		return fmt.Errorf("%w: %s", ErrOrderTypeUnavailable, orderType)
+	}
+	return nil
+}
+
+// validateOrderTypes keeps at least one order type enabled on the restaurant
+func validateOrderTypes(restaurant *models.Restaurant) error {
+	if !restaurant.DeliveryEnabled && !restaurant.PickupEnabled && !restaurant.DineInEnabled {
+		return ErrOrderTypesDisabled
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/clientinfo"
+	"golang-food-backend/pkg/messaging"
+
+	"github.com/google/uuid"
+)
+
+const (
+	otpThrottleWindow = time.Hour
+	otpMaxLockout     = 24 * time.Hour
+	otpFailureMemory  = 24 * time.Hour     // wrong codes older than this no longer count toward a lockout
+	otpLockoutMemory  = 7 * 24 * time.Hour // past lockouts within this lengthen the next one
+)
+
+// What a code was sent for; a code only verifies for the purpose it was issued for
+const (
+	OTPPurposeLogin         = "login"
+	OTPPurposePasswordReset = "password_reset"
+	OTPPurposeStaffInvite   = "staff_invite"
+)
+
+var (
+	ErrOTPInvalid   = apperr.Validation("otp_invalid", "invalid or expired OTP")
+	ErrOTPThrottled = apperr.RateLimited("otp_throttled", "too many OTP requests; please try again later")
+	ErrOTPLocked    = apperr.RateLimited("otp_locked", "too many wrong OTPs")
+)
+
+// OTPSecurityPolicy limits how often codes are sent and checked. Limits are per hour; 0 turns
+// one off.
+type OTPSecurityPolicy struct {
+	MaxAttempts         int // wrong guesses one code takes before it is spent
+	SendLimitPerPhone   int
+	SendLimitPerIP      int
+	VerifyLimitPerPhone int
+	VerifyLimitPerIP    int
+	LockoutFailures     int           // wrong codes in a row that lock a phone number
+	LockoutDuration     time.Duration // the first lockout; each one after doubles, up to a day
+	HashKey             string        // keys the HMAC stored for each code
+}
+
+// issueCode creates a code for the phone number and purpose and returns it. Only a salted HMAC
+// is stored.
+func (s *OTPService) issueCode(ctx context.Context, phone string, restaurantID *uuid.UUID, purpose string) (string, error) {
+	if err := s.checkLockout(ctx, phone); err != nil {
+		return "", err
+	}
+	if s.overLimit(ctx, "send:phone:"+otpPhoneKey(phone), s.security.SendLimitPerPhone) > 0 {
+		return "", ErrOTPThrottled
+	}
+	if ip := clientinfo.FromContext(ctx).IPAddress; ip != "" && s.overLimit(ctx, "send:ip:"+ip, s.security.SendLimitPerIP) > 0 {
+		return "", ErrOTPThrottled
+	}
+
+	code, err := s.generateOTP()
+	if err != nil {
+		return "", errors.New("failed to generate OTP")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.New("failed to generate OTP")
+	}
+
+	now := time.Now()
+	otp := &models.OTP{
+		Phone:        phone,
+		RestaurantID: restaurantID,
+		Purpose:      purpose,
+		CodeSalt:     hex.EncodeToString(salt),
+		ExpiresAt:    now.Add(otpExpiry),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	otp.CodeHash = s.hashOTP(otp.CodeSalt, code)
+	if err := s.otpRepo.Create(ctx, otp); err != nil {
+		return "", errors.New("failed to save OTP")
+	}
+	return code, nil
+}
+
+// verifyCode checks a code against the latest one sent to the phone number for the purpose and
+// spends it
+func (s *OTPService) verifyCode(ctx context.Context, phone string, restaurantID *uuid.UUID, purpose, code string) error {
+	otp, err := s.checkCode(ctx, phone, restaurantID, purpose, code)
+	if err != nil {
+		return err
+	}
+	return s.spendCode(ctx, otp)
+}
+
+// checkCode checks a code against the latest one sent to the phone number for the purpose
+// without spending it, for callers that spend it only once what the code unlocks is done. A
+// wrong code counts toward that code's attempt limit and the phone number's lockout.
+func (s *OTPService) checkCode(ctx context.Context, phone string, restaurantID *uuid.UUID, purpose, code string) (*models.OTP, error) {
+	if err := s.checkLockout(ctx, phone); err != nil {
+		return nil, err
+	}
+	ip := clientinfo.FromContext(ctx).IPAddress
+	if s.overLimit(ctx, "verify:phone:"+otpPhoneKey(phone), s.security.VerifyLimitPerPhone) > 0 {
+		return nil, ErrOTPThrottled
+	}
+	if ip != "" {
+		// One address trying codes for many numbers is brute force too; it is reported once an hour
+		if over := s.overLimit(ctx, "verify:ip:"+ip, s.security.VerifyLimitPerIP); over > 0 {
+			if over == 1 {
+				log.Printf("OTP brute force: IP %s went over %d verifications an hour", ip, s.security.VerifyLimitPerIP)
+				s.raiseBruteForceAlert(ctx, messaging.OTPBruteForce{
+					Subject:    "ip",
+					IPAddress:  ip,
+					Attempts:   s.security.VerifyLimitPerIP + 1,
+					DetectedAt: time.Now(),
+				})
+			}
+			return nil, ErrOTPThrottled
+		}
+	}
+
+	otp, err := s.otpRepo.GetActiveOTP(ctx, phone, restaurantID, purpose)
+	if err != nil {
+		return nil, ErrOTPInvalid
+	}
+	if s.security.MaxAttempts > 0 && otp.AttemptCount >= s.security.MaxAttempts {
+		return nil, ErrOTPInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(s.hashOTP(otp.CodeSalt, code)), []byte(otp.CodeHash)) != 1 {
+		if err := s.otpRepo.IncrementAttempt(ctx, otp.ID); err != nil {
+			log.Printf("Failed to count OTP attempt %s: %v", otp.ID, err)
+		}
+		return nil, s.recordFailure(ctx, phone, ip)
+	}
+	return otp, nil
+}
+
+// spendCode marks a checked code used and clears the phone number's run of wrong codes
+func (s *OTPService) spendCode(ctx context.Context, otp *models.OTP) error {
+	if err := s.otpRepo.InvalidateOTP(ctx, otp.ID); err != nil {
+		return errors.New("failed to verify OTP")
+	}
+	if err := s.cache.Client().Del(ctx, otpFailuresKey(otp.Phone), otpLockoutsKey(otp.Phone)).Err(); err != nil {
+		log.Printf("Failed to reset OTP failures for %s: %v", otp.Phone, err)
+	}
+	return nil
+}
+
+// recordFailure counts a wrong code for the phone number and locks it once there are too many
+// in a row. Each lockout within a week doubles the next one.
+func (s *OTPService) recordFailure(ctx context.Context, phone, ip string) error {
+	failures := s.increment(ctx, otpFailuresKey(phone), otpFailureMemory)
+	if s.security.LockoutFailures <= 0 || failures < int64(s.security.LockoutFailures) {
+		return ErrOTPInvalid
+	}
+
+	lockouts := s.increment(ctx, otpLockoutsKey(phone), otpLockoutMemory)
+	duration := otpLockoutDuration(s.security.LockoutDuration, lockouts)
+	client := s.cache.Client()
+	if err := client.Set(ctx, otpLockKey(phone), lockouts, duration).Err(); err != nil {
+		log.Printf("Failed to lock OTPs for %s: %v", phone, err)
+	}
+	if err := client.Del(ctx, otpFailuresKey(phone)).Err(); err != nil {
+		log.Printf("Failed to reset OTP failures for %s: %v", phone, err)
+	}
+
+	lockedUntil := time.Now().Add(duration)
+	log.Printf("OTP brute force: %s locked until %s after %d wrong codes", phone, lockedUntil.Format(time.RFC3339), failures)
+	s.raiseBruteForceAlert(ctx, messaging.OTPBruteForce{
+		Subject:     "phone",
+		Phone:       phone,
+		IPAddress:   ip,
+		Attempts:    int(failures),
+		LockedUntil: &lockedUntil,
+		DetectedAt:  time.Now(),
+	})
+	return otpLockedError(duration)
+}
+
+// checkLockout returns ErrOTPLocked while the phone number is locked out. Redis failures let the
+// request through.
+func (s *OTPService) checkLockout(ctx context.Context, phone string) error {
+	ttl, err := s.cache.Client().TTL(ctx, otpLockKey(phone)).Result()
+	if err != nil {
+		log.Printf("Failed to check OTP lockout for %s: %v", phone, err)
+		return nil
+	}
+	if ttl > 0 {
+		return otpLockedError(ttl)
+	}
+	return nil
+}
+
+// overLimit counts a request in the current hour and returns how far over the limit it is, or 0
+func (s *OTPService) overLimit(ctx context.Context, key string, limit int) int64 {
+	if limit <= 0 {
+		return 0
+	}
+	window := time.Now().Unix() / int64(otpThrottleWindow/time.Second)
+	count := s.increment(ctx, "otp:"+key+":"+strconv.FormatInt(window, 10), 2*otpThrottleWindow)
+	if count <= int64(limit) {
+		return 0
+	}
+	return count - int64(limit)
+}
+
+// increment bumps a Redis counter and returns it, or 0 when Redis fails
+func (s *OTPService) increment(ctx context.Context, key string, ttl time.Duration) int64 {
+	pipe := s.cache.Client().TxPipeline()
+	count := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to count %s: %v", key, err)
+		return 0
+	}
+	return count.Val()
+}
+
+// raiseBruteForceAlert publishes an otp_brute_force event on security_events through the outbox
+func (s *OTPService) raiseBruteForceAlert(ctx context.Context, alert messaging.OTPBruteForce) {
+	key := alert.Phone
+	if alert.Subject == "ip" {
+		key = alert.IPAddress
+	}
+	envelope, err := messaging.Events.NewEnvelope(messaging.EventOTPBruteForce, "", alert)
+	if err == nil {
+		var event models.OutboxEvent
+		if event, err = NewOutboxEvent("security_events", key, envelope); err == nil {
+			err = s.outboxRepo.Create(ctx, &event)
+		}
+	}
+	if err != nil {
+		log.Printf("Failed to raise OTP brute force alert for %s: %v", key, err)
+	}
+}
+
+// otpLockoutDuration doubles the base for each earlier lockout, up to a day
+func otpLockoutDuration(base time.Duration, lockouts int64) time.Duration {
+	if base <= 0 {
+		base = 15 * time.Minute
+	}
+	duration := base
+	for i := int64(1); i < lockouts && duration < otpMaxLockout; i++ {
+		duration *= 2
+	}
+	if duration > otpMaxLockout {
+		duration = otpMaxLockout
+	}
+	return duration
+}
+
+func otpLockedError(remaining time.Duration) error {
+	minutes := int((remaining + time.Minute - 1) / time.Minute)
+	return fmt.Errorf("%w; try again in %d minutes", ErrOTPLocked, minutes)
+}
+
+// hashOTP is the HMAC-SHA256 of the salt and code under the configured key, so codes cannot be
+// recovered from a copy of the table by trying every six digits
+func (s *OTPService) hashOTP(salt, code string) string {
+	mac := hmac.New(sha256.New, []byte(s.security.HashKey))
+	mac.Write([]byte(salt + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// otpPhoneKey keys counters by the digits alone, so formatting differences share a limit
+func otpPhoneKey(phone string) string {
+	return normalizeBlockedPhone(phone)
+}
+
+func otpLockKey(phone string) string     { return "otp:locked:" + otpPhoneKey(phone) }
+func otpFailuresKey(phone string) string { return "otp:failures:" + otpPhoneKey(phone) }
+func otpLockoutsKey(phone string) string { return "otp:lockouts:" + otpPhoneKey(phone) }
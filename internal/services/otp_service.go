@@ -7,27 +7,64 @@ import (
 	"fmt"
 	"golang-food-backend/internal/models"
 	"golang-food-backend/internal/repositories"
-	"golang-food-backend/pkg/auth"
 	"golang-food-backend/pkg/cache"
-	"golang-food-backend/pkg/sms"
+	"golang-food-backend/pkg/clientinfo"
+	"golang-food-backend/pkg/i18n"
+	"golang-food-backend/pkg/notify"
+	"log"
 	"math/big"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const otpExpiry = 5 * time.Minute
+
 type OTPService struct {
-	otpRepo    repositories.OTPRepository
-	userRepo   repositories.UserRepository
-	jwtManager *auth.JWTManager
-	cache      *cache.RedisCache
-	smsService *sms.SMSService
+	otpRepo        repositories.OTPRepository
+	userRepo       repositories.UserRepository
+	outboxRepo     repositories.OutboxRepository
+	sessionService *SessionService
+	blocklist      *BlocklistService
+	cache          *cache.RedisCache
+	senders        map[string]notify.Sender
+	policy         OTPDeliveryPolicy
+	security       OTPSecurityPolicy
+	guestCarts     GuestCartMerger
+}
+
+// GuestCartMerger moves the carts a customer filled before logging in into their account
+type GuestCartMerger interface {
+	MergeGuestCart(ctx context.Context, token string, userID uuid.UUID) error
+}
+
+// OTPDeliveryPolicy picks the channel an OTP goes out on and the one tried when delivery fails
+type OTPDeliveryPolicy struct {
+	DefaultChannel  string
+	FallbackChannel string
+	Templates       map[string]OTPTemplate // keyed by channel
+}
+
+// OTPTemplate is a text/template for one channel, rendered with {{.Code}} and {{.ExpiresInMinutes}}.
+// Subject is only used for email.
+type OTPTemplate struct {
+	Subject string
+	Body    string
+}
+
+type otpTemplateData struct {
+	Code             string
+	ExpiresInMinutes int
 }
 
 type SendOTPRequest struct {
 	Phone        string `json:"phone" binding:"required"`
 	Role         string `json:"role" binding:"required"` // Required to determine OTP flow
 	RestaurantID string `json:"restaurant_id"`           // Required only for customers
+	Channel      string `json:"channel"`                 // sms, whatsapp or email; defaults to the configured channel
+	Email        string `json:"email"`                   // Email channel address; defaults to the account's email
 }
 
 type VerifyOTPRequest struct {
@@ -35,23 +72,35 @@ type VerifyOTPRequest struct {
 	Role         string `json:"role" binding:"required"` // Required to determine login flow
 	RestaurantID string `json:"restaurant_id"`           // Required only for customers
 	OTPCode      string `json:"otp_code" binding:"required"`
+	GuestToken   string `json:"guest_token"` // guest carts to merge into the customer's carts
+	DeviceInfo
 }
 
 type OTPResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
+	Channel string `json:"channel,omitempty"`
 }
 
-func NewOTPService(otpRepo repositories.OTPRepository, userRepo repositories.UserRepository, jwtManager *auth.JWTManager, cache *cache.RedisCache, smsService *sms.SMSService) *OTPService {
+func NewOTPService(otpRepo repositories.OTPRepository, userRepo repositories.UserRepository, outboxRepo repositories.OutboxRepository, sessionService *SessionService, blocklist *BlocklistService, cache *cache.RedisCache, senders map[string]notify.Sender, policy OTPDeliveryPolicy, security OTPSecurityPolicy) *OTPService {
 	return &OTPService{
-		otpRepo:    otpRepo,
-		userRepo:   userRepo,
-		jwtManager: jwtManager,
-		cache:      cache,
-		smsService: smsService,
+		otpRepo:        otpRepo,
+		userRepo:       userRepo,
+		outboxRepo:     outboxRepo,
+		sessionService: sessionService,
+		blocklist:      blocklist,
+		cache:          cache,
+		senders:        senders,
+		policy:         policy,
+		security:       security,
 	}
 }
 
+// SetGuestCartMerger sets where guest carts are merged on customer login
+func (s *OTPService) SetGuestCartMerger(merger GuestCartMerger) {
+	s.guestCarts = merger
+}
+
 // generateOTP generates a 6-digit OTP
 func (s *OTPService) generateOTP() (string, error) {
 	max := big.NewInt(999999)
@@ -89,42 +138,147 @@ func (s *OTPService) SendOTP(ctx context.Context, req *SendOTPRequest) (*OTPResp
 		}
 	}
 
-	// Generate OTP
-	otpCode, err := s.generateOTP()
-	if err != nil {
-		return nil, errors.New("failed to generate OTP")
+	// Blocked numbers and devices get no codes to log in with
+	if err := s.blocklist.Check(ctx, BlockSubject{Phone: req.Phone, DeviceID: clientinfo.FromContext(ctx).DeviceID}); err != nil {
+		return nil, err
 	}
 
-	// Create OTP record
-	otp := &models.OTP{
-		Phone:        req.Phone,
-		RestaurantID: restaurantID,
-		OTPCode:      otpCode,
-		ExpiresAt:    time.Now().Add(5 * time.Minute), // 5 minutes expiry
-		IsUsed:       false,
-		AttemptCount: 0,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+	channel := req.Channel
+	if channel == "" {
+		channel = s.policy.DefaultChannel
+	}
+	if _, ok := s.policy.Templates[channel]; !ok {
+		return nil, fmt.Errorf("unsupported OTP channel: %s", channel)
 	}
 
-	// Save OTP to database
-	if err := s.otpRepo.Create(ctx, otp); err != nil {
-		return nil, errors.New("failed to save OTP")
+	// Generate and save the OTP, subject to the send limits
+	otpCode, err := s.issueCode(ctx, req.Phone, restaurantID, OTPPurposeLogin)
+	if err != nil {
+		return nil, err
 	}
 
-	// Send SMS
-	if err := s.smsService.SendOTP(req.Phone, otpCode); err != nil {
-		// Log error but don't fail the request
-		// In production, you might want to handle this differently
-		fmt.Printf("Failed to send SMS: %v\n", err)
+	sentVia, err := s.deliverOTP(ctx, req, restaurantID, channel, otpCode)
+	if err != nil {
+		return nil, err
 	}
 
 	return &OTPResponse{
 		Success: true,
-		Message: "OTP sent successfully",
+		Message: fmt.Sprintf("OTP sent successfully via %s", sentVia),
+		Channel: sentVia,
 	}, nil
 }
 
+// deliverOTP sends the code on the chosen channel and, if that fails, on the fallback channel.
+// It returns the channel that delivered it.
+func (s *OTPService) deliverOTP(ctx context.Context, req *SendOTPRequest, restaurantID *uuid.UUID, channel, otpCode string) (string, error) {
+	channels := []string{channel}
+	if fallback := s.policy.FallbackChannel; fallback != "" && fallback != channel {
+		channels = append(channels, fallback)
+	}
+
+	for _, ch := range channels {
+		to := req.Phone
+		if ch == notify.ChannelEmail {
+			to = s.otpEmailAddress(ctx, req, restaurantID)
+		}
+		err := s.sendOTPOnChannel(ctx, ch, to, otpCode)
+		if err == nil {
+			return ch, nil
+		}
+		log.Printf("Failed to send OTP via %s to %s: %v", ch, req.Phone, err)
+	}
+
+	return "", errors.New("failed to deliver OTP")
+}
+
+// SendVerificationCode sends a code proving ownership of a new phone number or email address.
+// Email addresses get it by email; phone numbers on the default channel, or SMS when that is email.
+func (s *OTPService) SendVerificationCode(ctx context.Context, contactType, to, code string) error {
+	channel := notify.ChannelEmail
+	if contactType == "phone" {
+		channel = s.policy.DefaultChannel
+		if channel == notify.ChannelEmail {
+			channel = notify.ChannelSMS
+		}
+	}
+
+	if err := s.sendOTPOnChannel(ctx, channel, to, code); err != nil {
+		log.Printf("Failed to send verification code via %s to %s: %v", channel, to, err)
+		return errors.New("failed to deliver verification code")
+	}
+	return nil
+}
+
+func (s *OTPService) sendOTPOnChannel(ctx context.Context, channel, to, otpCode string) error {
+	sender, ok := s.senders[channel]
+	if !ok {
+		return fmt.Errorf("no provider configured for %s", channel)
+	}
+	tmpl, ok := s.policy.Templates[channel]
+	if !ok {
+		return fmt.Errorf("no template configured for %s", channel)
+	}
+
+	// Templates left at their English defaults go out in the requester's language, except SMS
+	// whose text has to match the template registered on DLT
+	if channel != notify.ChannelSMS {
+		tmpl = OTPTemplate{Subject: i18n.Translate(ctx, tmpl.Subject), Body: i18n.Translate(ctx, tmpl.Body)}
+	}
+	data := otpTemplateData{Code: otpCode, ExpiresInMinutes: int(otpExpiry / time.Minute)}
+	subject, err := renderOTPTemplate(tmpl.Subject, data)
+	if err != nil {
+		return err
+	}
+	body, err := renderOTPTemplate(tmpl.Body, data)
+	if err != nil {
+		return err
+	}
+
+	return sender.Send(ctx, notify.Message{
+		To:      to,
+		Subject: subject,
+		Body:    body,
+		Code:    data.Code,
+	})
+}
+
+// otpEmailAddress uses the address in the request, or the email of the account the phone belongs to
+func (s *OTPService) otpEmailAddress(ctx context.Context, req *SendOTPRequest, restaurantID *uuid.UUID) string {
+	if req.Email != "" {
+		return req.Email
+	}
+
+	var user *models.User
+	var err error
+	if req.Role == "customer" && restaurantID != nil {
+		user, err = s.userRepo.GetByPhoneAndRestaurant(ctx, req.Phone, *restaurantID)
+	} else {
+		user, err = s.userRepo.GetByPhone(ctx, req.Phone)
+	}
+	if err != nil {
+		return ""
+	}
+	return user.Email
+}
+
+func renderOTPTemplate(text string, data otpTemplateData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	tmpl, err := template.New("otp").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid OTP template: %v", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render OTP template: %v", err)
+	}
+	return out.String(), nil
+}
+
 func (s *OTPService) VerifyOTPAndLogin(ctx context.Context, req *VerifyOTPRequest) (*AuthResponse, error) {
 	var restaurantID *uuid.UUID
 	var user *models.User
@@ -151,10 +305,9 @@ func (s *OTPService) VerifyOTPAndLogin(ctx context.Context, req *VerifyOTPReques
 		}
 	}
 
-	// Get valid OTP
-	otp, err := s.otpRepo.GetValidOTPWithOptionalRestaurant(ctx, req.Phone, restaurantID, req.OTPCode)
-	if err != nil {
-		return nil, errors.New("invalid or expired OTP")
+	// Check and spend the OTP
+	if err := s.verifyCode(ctx, req.Phone, restaurantID, OTPPurposeLogin, req.OTPCode); err != nil {
+		return nil, err
 	}
 
 	// Role-based user lookup and creation logic
@@ -200,26 +353,17 @@ func (s *OTPService) VerifyOTPAndLogin(ctx context.Context, req *VerifyOTPReques
 		}
 	}
 
-	// Invalidate the OTP
-	if err := s.otpRepo.InvalidateOTP(ctx, otp.ID); err != nil {
-		// Log error but continue
-		fmt.Printf("Failed to invalidate OTP: %v\n", err)
-	}
-
-	// Generate tokens
-	restaurantIDStr := ""
-	if user.RestaurantID != nil {
-		restaurantIDStr = user.RestaurantID.String()
-	}
-
-	tokenPair, err := s.jwtManager.GenerateTokenPair(user.ID.String(), restaurantIDStr, user.Role, user.Email)
+	// Open a login session and issue its tokens
+	tokenPair, err := s.sessionService.StartSession(ctx, user, req.DeviceInfo, "otp")
 	if err != nil {
 		return nil, err
 	}
 
-	// Store refresh token in Redis (30 days expiry)
-	if err := s.storeRefreshToken(ctx, user.ID.String(), tokenPair.RefreshToken, 30); err != nil {
-		return nil, err
+	// Login succeeds even if the guest carts cannot be merged; they stay under the guest token
+	if req.Role == "customer" && req.GuestToken != "" && s.guestCarts != nil {
+		if err := s.guestCarts.MergeGuestCart(ctx, req.GuestToken, user.ID); err != nil {
+			log.Printf("Failed to merge guest cart into user %s: %v", user.ID, err)
+		}
 	}
 
 	return &AuthResponse{
@@ -231,13 +375,6 @@ func (s *OTPService) VerifyOTPAndLogin(ctx context.Context, req *VerifyOTPReques
 	}, nil
 }
 
-// Helper method to store refresh token (same as in AuthService)
-func (s *OTPService) storeRefreshToken(ctx context.Context, userID, refreshToken string, expiryDays int) error {
-	key := fmt.Sprintf("refresh_token:%s", userID)
-	expiry := time.Hour * 24 * time.Duration(expiryDays)
-	return s.cache.Set(ctx, key, refreshToken, expiry)
-}
-
 // CleanupExpiredOTPs removes expired OTPs from database
 func (s *OTPService) CleanupExpiredOTPs(ctx context.Context) error {
 	return s.otpRepo.DeleteExpiredOTPs(ctx)
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/messaging"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Outbox event statuses
+const (
+	OutboxPending   = "pending"
+	OutboxPublished = "published"
+	OutboxFailed    = "failed" // retries exhausted; sent to the dead-letter topic
+)
+
+const (
+	outboxRelayInterval = 2 * time.Second
+	outboxBatchSize     = 100
+	outboxLease         = time.Minute        // how long a claimed event is hidden from other relays
+	outboxRetention     = 7 * 24 * time.Hour // published events are kept this long
+)
+
+var (
+	ErrOutboxInvalid  = apperr.Validation("outbox_invalid", "invalid outbox request")
+	ErrOutboxNotFound = apperr.NotFound("event_not_found", "event not found")
+)
+
+// OutboxService relays events written to the transactional outbox to Kafka. An event that keeps
+// failing is retried with exponential backoff and, once its attempts run out, marked failed and
+// sent to the dead-letter topic, where admins can inspect and requeue it.
+type OutboxService struct {
+	outboxRepo repositories.OutboxRepository
+	producer   *messaging.KafkaProducer
+	brokers    []string
+	retry      messaging.RetryPolicy
+	stopChan   chan struct{}
+}
+
+func NewOutboxService(outboxRepo repositories.OutboxRepository, producer *messaging.KafkaProducer, brokers []string, retry messaging.RetryPolicy) *OutboxService {
+	return &OutboxService{
+		outboxRepo: outboxRepo,
+		producer:   producer,
+		brokers:    brokers,
+		retry:      retry,
+		stopChan:   make(chan struct{}),
+	}
+}
+
+type OutboxEventsResponse struct {
+	Events     []models.OutboxEvent `json:"events"`
+	Pagination PaginationInfo       `json:"pagination"`
+}
+
+// NewOutboxEvent encodes an event for the outbox. Write it in the same transaction as the change
+// it describes.
+func NewOutboxEvent(topic, key string, value interface{}) (models.OutboxEvent, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return models.OutboxEvent{}, fmt.Errorf("failed to encode %s event: %v", topic, err)
+	}
+	return models.OutboxEvent{
+		Topic:         topic,
+		Key:           key,
+		Payload:       string(payload),
+		Status:        OutboxPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// Start runs the relay until Stop is called
+func (s *OutboxService) Start() {
+	go s.run()
+	log.Println("📤 Outbox relay started")
+}
+
+func (s *OutboxService) Stop() {
+	close(s.stopChan)
+}
+
+func (s *OutboxService) run() {
+	relay := time.NewTicker(outboxRelayInterval)
+	defer relay.Stop()
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-relay.C:
+			if _, _, err := s.Relay(context.Background()); err != nil {
+				log.Printf("❌ Error relaying outbox events: %v", err)
+			}
+		case <-cleanup.C:
+			deleted, err := s.outboxRepo.DeletePublishedBefore(context.Background(), time.Now().Add(-outboxRetention))
+			if err != nil {
+				log.Printf("❌ Error cleaning up outbox: %v", err)
+			} else if deleted > 0 {
+				log.Printf("📤 Removed %d published outbox events", deleted)
+			}
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// Relay publishes the events that are due and returns how many were published and how many
+// ran out of attempts
+func (s *OutboxService) Relay(ctx context.Context) (published, failed int, err error) {
+	events, err := s.outboxRepo.ClaimDue(ctx, time.Now(), outboxLease, outboxBatchSize)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to claim outbox events: %v", err)
+	}
+
+	for i := range events {
+		event := &events[i]
+		if s.publish(ctx, event) {
+			published++
+		} else if event.Status == OutboxFailed {
+			failed++
+		}
+	}
+	return published, failed, nil
+}
+
+// publish sends one event and records the outcome
+func (s *OutboxService) publish(ctx context.Context, event *models.OutboxEvent) bool {
+	event.Attempts++
+	err := s.producer.Publish(ctx, event.Topic, s.brokers, event.Key, []byte(event.Payload))
+	now := time.Now()
+
+	if err == nil {
+		event.Status = OutboxPublished
+		event.PublishedAt = &now
+		event.LastError = ""
+	} else {
+		event.LastError = err.Error()
+		if event.Attempts >= s.retry.MaxAttempts {
+			event.Status = OutboxFailed
+			s.deadLetter(ctx, event)
+		} else {
+			event.NextAttemptAt = now.Add(s.retry.Backoff(event.Attempts))
+		}
+	}
+
+	if updateErr := s.outboxRepo.Update(ctx, event); updateErr != nil {
+		log.Printf("Failed to update outbox event %s: %v", event.ID, updateErr)
+	}
+	return err == nil
+}
+
+// deadLetter copies an event that ran out of attempts to the dead-letter topic. The outbox row
+// stays the source of truth, so a failure here is only logged.
+func (s *OutboxService) deadLetter(ctx context.Context, event *models.OutboxEvent) {
+	log.Printf("⚠️ Outbox event %s for %s failed after %d attempts: %s", event.ID, event.Topic, event.Attempts, event.LastError)
+
+	letter := messaging.DeadLetter{
+		EventID:  event.ID.String(),
+		Topic:    event.Topic,
+		Key:      event.Key,
+		Payload:  json.RawMessage(event.Payload),
+		Error:    event.LastError,
+		Attempts: event.Attempts,
+		FailedAt: time.Now(),
+	}
+	payload, err := json.Marshal(letter)
+	if err != nil {
+		log.Printf("Failed to encode dead letter for event %s: %v", event.ID, err)
+		return
+	}
+	if err := s.producer.Publish(ctx, messaging.DeadLetterTopic, s.brokers, event.Key, payload); err != nil {
+		log.Printf("Failed to publish dead letter for event %s: %v", event.ID, err)
+	}
+}
+
+// ListEvents lists outbox events, newest first. Status defaults to failed.
+func (s *OutboxService) ListEvents(ctx context.Context, status, topic string, page, limit int) (*OutboxEventsResponse, error) {
+	if status == "" {
+		status = OutboxFailed
+	}
+	switch status {
+	case OutboxPending, OutboxPublished, OutboxFailed:
+	default:
+		return nil, fmt.Errorf("%w: status must be pending, published or failed", ErrOutboxInvalid)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	events, total, err := s.outboxRepo.List(ctx, status, topic, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox events: %v", err)
+	}
+
+	return &OutboxEventsResponse{
+		Events: events,
+		Pagination: PaginationInfo{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		},
+	}, nil
+}
+
+func (s *OutboxService) GetEvent(ctx context.Context, id string) (*models.OutboxEvent, error) {
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid event ID", ErrOutboxInvalid)
+	}
+	event, err := s.outboxRepo.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrOutboxNotFound, id)
+	}
+	return event, nil
+}
+
+// Requeue gives a failed event a fresh set of attempts on the next relay run
+func (s *OutboxService) Requeue(ctx context.Context, id string) (*models.OutboxEvent, error) {
+	event, err := s.GetEvent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event.Status != OutboxFailed {
+		return nil, fmt.Errorf("%w: only failed events can be requeued, event is %s", ErrOutboxInvalid, event.Status)
+	}
+
+	event.Status = OutboxPending
+	event.Attempts = 0
+	event.NextAttemptAt = time.Now()
+	if err := s.outboxRepo.Update(ctx, event); err != nil {
+		return nil, fmt.Errorf("failed to requeue event: %v", err)
+	}
+	return event, nil
+}
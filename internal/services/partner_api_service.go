@@ -0,0 +1,831 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/cache"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Partner API key scopes
+const (
+	PartnerScopeOrdersRead       = "orders:read"
+	PartnerScopeOrdersWrite      = "orders:write" // delivery status updates
+	PartnerScopeSettlementsRead  = "settlements:read"
+	PartnerScopeRestaurantsRead  = "restaurants:read"
+	PartnerScopeRestaurantsWrite = "restaurants:write" // accepting and declining link requests
+)
+
+// Delivery statuses partners report through the partner API
+const (
+	PartnerStatusPickedUp  = "picked_up"  // the order leaves the restaurant
+	PartnerStatusInTransit = "in_transit" // progress note on an order out for delivery
+	PartnerStatusDelivered = "delivered"
+)
+
+const (
+	partnerKeyPrefixLength    = 11 // "pk_" and 8 hex characters
+	partnerDefaultRateLimit   = 120
+	partnerLastUsedResolution = time.Minute // last_used_at is written at most this often per key
+
+	partnerDefaultRotationGrace = time.Hour
+	partnerMaxRotationGrace     = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrPartnerKeyInvalid is returned for a missing, unknown, revoked or expired API key, or one
+	// of a suspended company
+	ErrPartnerKeyInvalid     = apperr.Unauthorized("api_key_invalid", "invalid API key")
+	ErrPartnerInvalid        = apperr.Validation("partner_request_invalid", "invalid partner request")
+	ErrPartnerNotFound       = apperr.NotFound("not_found", "not found")
+	ErrPartnerLinkNotPending = apperr.Conflict("link_not_pending", "the link request has already been answered")
+	ErrPartnerKeyRotated     = apperr.Conflict("api_key_rotated", "the API key has already been rotated")
+)
+
+var partnerScopes = map[string]bool{
+	PartnerScopeOrdersRead:       true,
+	PartnerScopeOrdersWrite:      true,
+	PartnerScopeSettlementsRead:  true,
+	PartnerScopeRestaurantsRead:  true,
+	PartnerScopeRestaurantsWrite: true,
+}
+
+// partnerOpenStatuses are the order statuses listed when a partner does not ask for one
+var partnerOpenStatuses = []string{"confirmed", "preparing", "dispatched"}
+
+// PartnerAPIService backs the partner API that delivery companies and aggregators call with
+// API keys to pull the orders booked with them, report delivery progress, fetch settlement data
+// and performance stats, and answer restaurants' link requests. Admins issue and revoke the
+// keys; partners rotate their own.
+type PartnerAPIService struct {
+	keyRepo                       repositories.PartnerAPIKeyRepository
+	deliveryPartnerRepo           repositories.DeliveryPartnerRepository
+	restaurantDeliveryPartnerRepo repositories.RestaurantDeliveryPartnerRepository
+	restaurantRepo                repositories.RestaurantRepository
+	orderRepo                     repositories.OrderRepository
+	orders                        *OrderService
+	cache                         *cache.RedisCache
+}
+
+func NewPartnerAPIService(
+	keyRepo repositories.PartnerAPIKeyRepository,
+	deliveryPartnerRepo repositories.DeliveryPartnerRepository,
+	restaurantDeliveryPartnerRepo repositories.RestaurantDeliveryPartnerRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	orderRepo repositories.OrderRepository,
+	orders *OrderService,
+	cache *cache.RedisCache,
+) *PartnerAPIService {
+	return &PartnerAPIService{
+		keyRepo:                       keyRepo,
+		deliveryPartnerRepo:           deliveryPartnerRepo,
+		restaurantDeliveryPartnerRepo: restaurantDeliveryPartnerRepo,
+		restaurantRepo:                restaurantRepo,
+		orderRepo:                     orderRepo,
+		orders:                        orders,
+		cache:                         cache,
+	}
+}
+
+type PartnerAPIKeyRequest struct {
+	Name               string     `json:"name" binding:"required"`
+	Scopes             []string   `json:"scopes" binding:"required,min=1"` // orders:read, orders:write, settlements:read, restaurants:read, restaurants:write
+	RateLimitPerMinute int        `json:"rate_limit_per_minute"`           // defaults to 120
+	ExpiresAt          *time.Time `json:"expires_at"`
+}
+
+// PartnerAPIKeyResponse carries a newly issued key, which is only shown once
+type PartnerAPIKeyResponse struct {
+	models.PartnerAPIKey
+	Key string `json:"key"`
+}
+
+type PartnerKeyRotateRequest struct {
+	// How long the current key keeps working, so deployments can switch over; 0 revokes it at
+	// once. Defaults to 60, at most 10080 (7 days).
+	GracePeriodMinutes *int `json:"grace_period_minutes"`
+}
+
+// PartnerKeyRotationResponse carries the key that replaces the one used to rotate, which is only
+// shown once, and when the old key stops working
+type PartnerKeyRotationResponse struct {
+	PartnerAPIKeyResponse
+	PreviousKeyID        uuid.UUID `json:"previous_key_id"`
+	PreviousKeyExpiresAt time.Time `json:"previous_key_expires_at"`
+}
+
+type PartnerLinkDeclineRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// PartnerRestaurantLink is a restaurant's link to the partner as shown to the partner
+type PartnerRestaurantLink struct {
+	ID                uuid.UUID     `json:"id"`
+	RestaurantID      uuid.UUID     `json:"restaurant_id"`
+	RestaurantName    string        `json:"restaurant_name"`
+	RestaurantContact string        `json:"restaurant_contact"`
+	RestaurantStatus  string        `json:"restaurant_status"`
+	Status            string        `json:"status"`                 // pending, active, declined
+	IsActive          bool          `json:"is_active"`              // false while the restaurant has the link switched off
+	FeeOverride       *models.Money `json:"fee_override,omitempty"` // fee per delivery the restaurant proposed or agreed with you
+	RequestedAt       *time.Time    `json:"requested_at,omitempty"`
+	RespondedAt       *time.Time    `json:"responded_at,omitempty"`
+	DeclineReason     string        `json:"decline_reason,omitempty"`
+}
+
+// PartnerPerformanceResponse summarizes the partner's deliveries of the orders placed in a
+// period. Rates are fractions and are nil when there is nothing to rate.
+type PartnerPerformanceResponse struct {
+	From string `json:"from"`
+	To   string `json:"to"` // exclusive
+	repositories.DeliveryPerformance
+	CompletionRate    *float64 `json:"completion_rate"` // delivered out of delivered and cancelled
+	OnTimeRate        *float64 `json:"on_time_rate"`    // on time out of deliveries with a promised time
+	ActiveRestaurants int      `json:"active_restaurants"`
+}
+
+type PartnerDeliveryStatusRequest struct {
+	Status       string `json:"status" binding:"required"` // picked_up, in_transit, delivered
+	DeliveryCode string `json:"delivery_code"`             // the code the customer gave at handoff
+	Note         string `json:"note"`
+}
+
+// PartnerOrder is an order as shown to the delivery partner it is booked with
+type PartnerOrder struct {
+	ID                     uuid.UUID    `json:"id"`
+	RestaurantID           uuid.UUID    `json:"restaurant_id"`
+	RestaurantName         string       `json:"restaurant_name"`
+	Status                 string       `json:"status"`
+	PickupAddress          models.JSONB `json:"pickup_address"`
+	DeliveryAddress        models.JSONB `json:"delivery_address"`
+	CustomerName           string       `json:"customer_name"`
+	CustomerContact        string       `json:"customer_contact"`
+	TotalAmount            models.Money `json:"total_amount"`
+	DeliveryFee            models.Money `json:"delivery_fee"`
+	DeliveryCodeRequired   bool         `json:"delivery_code_required"`
+	DeliveryCodeVerifiedAt *time.Time   `json:"delivery_code_verified_at,omitempty"`
+	ScheduledFor           *time.Time   `json:"scheduled_for,omitempty"`
+	CreatedAt              time.Time    `json:"created_at"`
+}
+
+type PartnerOrdersResponse struct {
+	Orders     []PartnerOrder `json:"orders"`
+	Pagination PaginationInfo `json:"pagination"`
+}
+
+// PartnerSettlementResponse totals the delivery fees of the orders a partner delivered in a
+// period, by the day the orders were placed
+type PartnerSettlementResponse struct {
+	From         string                           `json:"from"`
+	To           string                           `json:"to"` // exclusive
+	Orders       int64                            `json:"orders"`
+	DeliveryFees float64                          `json:"delivery_fees"`
+	Days         []repositories.DailyDeliveryFees `json:"days"`
+}
+
+func newPartnerOrder(order *models.Order) PartnerOrder {
+	return PartnerOrder{
+		ID:                     order.ID,
+		RestaurantID:           order.RestaurantID,
+		RestaurantName:         order.Restaurant.Name,
+		Status:                 order.OrderStatus,
+		PickupAddress:          order.PickupFullAddressWithLatLong,
+		DeliveryAddress:        order.DeliveryFullAddressWithLatLong,
+		CustomerName:           order.CustomerName,
+		CustomerContact:        order.CustomerContact,
+		TotalAmount:            order.TotalAmount,
+		DeliveryFee:            order.DeliveryFee,
+		DeliveryCodeRequired:   order.Restaurant.RequireDeliveryCode,
+		DeliveryCodeVerifiedAt: order.DeliveryCodeVerifiedAt,
+		ScheduledFor:           order.ScheduledFor,
+		CreatedAt:              order.CreatedAt,
+	}
+}
+
+func newPartnerRestaurantLink(link *models.RestaurantDeliveryPartners) PartnerRestaurantLink {
+	return PartnerRestaurantLink{
+		ID:                link.ID,
+		RestaurantID:      link.RestaurantID,
+		RestaurantName:    link.Restaurant.Name,
+		RestaurantContact: link.Restaurant.ContactNumber,
+		RestaurantStatus:  link.Restaurant.Status,
+		Status:            link.Status,
+		IsActive:          link.IsActive,
+		FeeOverride:       link.FeeOverride,
+		RequestedAt:       link.RequestedAt,
+		RespondedAt:       link.RespondedAt,
+		DeclineReason:     link.DeclineReason,
+	}
+}
+
+// partnerRate is part out of total rounded to four places, or nil when total is zero
+func partnerRate(part, total int64) *float64 {
+	if total == 0 {
+		return nil
+	}
+	rate := math.Round(float64(part)/float64(total)*10000) / 10000
+	return &rate
+}
+
+// generatePartnerKey returns a new raw API key
+func generatePartnerKey() (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %v", err)
+	}
+	return "pk_" + hex.EncodeToString(raw), nil
+}
+
+func hashPartnerKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func partnerRateKey(keyID uuid.UUID, window int64) string {
+	return fmt.Sprintf("partner_rate:%s:%d", keyID, window)
+}
+
+// getCompany loads a delivery partner company by ID
+func (s *PartnerAPIService) getCompany(ctx context.Context, companyID string) (*models.DeliveryPartnerCompany, error) {
+	id, err := uuid.Parse(companyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: delivery partner %s", ErrPartnerNotFound, companyID)
+	}
+	company, err := s.deliveryPartnerRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: delivery partner %s", ErrPartnerNotFound, companyID)
+	}
+	return company, err
+}
+
+// ListKeys returns a delivery partner company's API keys, newest first
+func (s *PartnerAPIService) ListKeys(ctx context.Context, companyID string) ([]models.PartnerAPIKey, error) {
+	company, err := s.getCompany(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.keyRepo.ListByCompany(ctx, company.ID)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = []models.PartnerAPIKey{}
+	}
+	return keys, nil
+}
+
+// CreateKey issues an API key to a delivery partner company. Only its hash is kept, so the key
+// is returned here and never again.
+func (s *PartnerAPIService) CreateKey(ctx context.Context, companyID, userID string, req *PartnerAPIKeyRequest) (*PartnerAPIKeyResponse, error) {
+	company, err := s.getCompany(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := models.StringArray{}
+	seen := make(map[string]bool, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !partnerScopes[scope] {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrPartnerInvalid, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	if req.RateLimitPerMinute < 0 {
+		return nil, fmt.Errorf("%w: rate_limit_per_minute cannot be negative", ErrPartnerInvalid)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrPartnerInvalid)
+	}
+
+	rawKey, err := generatePartnerKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.PartnerAPIKey{
+		DeliveryPartnerCompanyID: company.ID,
+		Name:                     strings.TrimSpace(req.Name),
+		Prefix:                   rawKey[:partnerKeyPrefixLength],
+		KeyHash:                  hashPartnerKey(rawKey),
+		Scopes:                   scopes,
+		RateLimitPerMinute:       req.RateLimitPerMinute,
+		ExpiresAt:                req.ExpiresAt,
+	}
+	if key.RateLimitPerMinute == 0 {
+		key.RateLimitPerMinute = partnerDefaultRateLimit
+	}
+	if createdBy, err := uuid.Parse(userID); err == nil {
+		key.CreatedBy = &createdBy
+	}
+	if err := s.keyRepo.Create(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to create API key: %v", err)
+	}
+
+	return &PartnerAPIKeyResponse{PartnerAPIKey: *key, Key: rawKey}, nil
+}
+
+// RevokeKey stops an API key from authenticating
+func (s *PartnerAPIService) RevokeKey(ctx context.Context, companyID, keyID string) (*models.PartnerAPIKey, error) {
+	id, err := uuid.Parse(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: API key %s", ErrPartnerNotFound, keyID)
+	}
+	key, err := s.keyRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && key.DeliveryPartnerCompanyID.String() != companyID) {
+		return nil, fmt.Errorf("%w: API key %s", ErrPartnerNotFound, keyID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+		if err := s.keyRepo.Update(ctx, key); err != nil {
+			return nil, fmt.Errorf("failed to revoke API key: %v", err)
+		}
+	}
+	return key, nil
+}
+
+// AuthenticatePartnerKey resolves an API key presented to the partner API
+func (s *PartnerAPIService) AuthenticatePartnerKey(ctx context.Context, rawKey string) (*models.PartnerAPIKey, error) {
+	key, err := s.keyRepo.GetByHash(ctx, hashPartnerKey(rawKey))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPartnerKeyInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(now)) {
+		return nil, ErrPartnerKeyInvalid
+	}
+	company, err := s.deliveryPartnerRepo.GetByID(ctx, key.DeliveryPartnerCompanyID)
+	if err != nil || company.Status != "active" {
+		return nil, ErrPartnerKeyInvalid
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= partnerLastUsedResolution {
+		if err := s.keyRepo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			log.Printf("Failed to record use of partner API key %s: %v", key.ID, err)
+		}
+	}
+	return key, nil
+}
+
+// AllowPartnerRequest counts a request against the key's per-minute limit and returns the
+// requests left in the current minute, or how long to wait once the limit is reached. Requests
+// are let through if Redis is unavailable.
+func (s *PartnerAPIService) AllowPartnerRequest(ctx context.Context, key *models.PartnerAPIKey) (int, time.Duration) {
+	now := time.Now()
+	window := now.Unix() / 60
+	rateKey := partnerRateKey(key.ID, window)
+
+	pipe := s.cache.Client().TxPipeline()
+	count := pipe.Incr(ctx, rateKey)
+	pipe.Expire(ctx, rateKey, 2*time.Minute)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to rate limit partner API key %s: %v", key.ID, err)
+		return key.RateLimitPerMinute, 0
+	}
+
+	remaining := key.RateLimitPerMinute - int(count.Val())
+	if remaining < 0 {
+		return 0, time.Unix((window+1)*60, 0).Sub(now)
+	}
+	return remaining, 0
+}
+
+// ListOrders returns the orders booked with the partner, newest first. Without a status only
+// open orders (confirmed, preparing, dispatched) are listed.
+func (s *PartnerAPIService) ListOrders(ctx context.Context, companyID, status string, page, limit int) (*PartnerOrdersResponse, error) {
+	companyUUID, err := uuid.Parse(companyID)
+	if err != nil {
+		return nil, ErrPartnerKeyInvalid
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := repositories.OrderFilter{DeliveryPartnerCompanyID: companyUUID, Statuses: partnerOpenStatuses}
+	if status != "" {
+		filter.Statuses = []string{status}
+	}
+	orders, total, err := s.orderRepo.List(ctx, filter, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %v", err)
+	}
+
+	response := &PartnerOrdersResponse{
+		Orders: make([]PartnerOrder, 0, len(orders)),
+		Pagination: PaginationInfo{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		},
+	}
+	restaurants := make(map[uuid.UUID]*models.Restaurant)
+	for i := range orders {
+		order := &orders[i]
+		restaurant, ok := restaurants[order.RestaurantID]
+		if !ok {
+			restaurant, _ = s.restaurantRepo.GetByID(ctx, order.RestaurantID)
+			restaurants[order.RestaurantID] = restaurant
+		}
+		if restaurant != nil {
+			order.Restaurant = *restaurant
+		}
+		response.Orders = append(response.Orders, newPartnerOrder(order))
+	}
+	return response, nil
+}
+
+// getAssignedOrder loads an order booked with the partner
+func (s *PartnerAPIService) getAssignedOrder(ctx context.Context, companyID, orderID string) (*models.Order, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: order %s", ErrPartnerNotFound, orderID)
+	}
+	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: order %s", ErrPartnerNotFound, orderID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.DeliveryPartnerID == nil {
+		return nil, fmt.Errorf("%w: order %s", ErrPartnerNotFound, orderID)
+	}
+
+	link, err := s.restaurantDeliveryPartnerRepo.GetByID(ctx, *order.DeliveryPartnerID)
+	if err != nil || link.DeliveryPartnerCompanyID.String() != companyID {
+		return nil, fmt.Errorf("%w: order %s", ErrPartnerNotFound, orderID)
+	}
+	return order, nil
+}
+
+// GetOrder returns an order booked with the partner
+func (s *PartnerAPIService) GetOrder(ctx context.Context, companyID, orderID string) (*PartnerOrder, error) {
+	order, err := s.getAssignedOrder(ctx, companyID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	partnerOrder := newPartnerOrder(order)
+	return &partnerOrder, nil
+}
+
+// UpdateDeliveryStatus applies a delivery status the partner reports for an order booked with it
+func (s *PartnerAPIService) UpdateDeliveryStatus(ctx context.Context, companyID, orderID string, req *PartnerDeliveryStatusRequest) (*PartnerOrder, error) {
+	order, err := s.getAssignedOrder(ctx, companyID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	switch req.Status {
+	case PartnerStatusPickedUp, PartnerStatusInTransit, PartnerStatusDelivered:
+	default:
+		return nil, fmt.Errorf("%w: status must be picked_up, in_transit or delivered", ErrPartnerInvalid)
+	}
+
+	order, err = s.orders.ApplyDeliveryPartnerStatus(ctx, order.ID, *order.DeliveryPartnerID, req.Status, strings.TrimSpace(req.DeliveryCode), req.Note)
+	if err != nil {
+		return nil, err
+	}
+	partnerOrder := newPartnerOrder(order)
+	return &partnerOrder, nil
+}
+
+// partnerPeriod parses a from and to day (YYYY-MM-DD, to inclusive) of up to 92 days into a
+// half-open range, defaulting to the last 7 days
+func partnerPeriod(from, to string) (time.Time, time.Time, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	end := today.AddDate(0, 0, 1)
+	if to != "" {
+		day, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrPartnerInvalid)
+		}
+		end = day.AddDate(0, 0, 1)
+	}
+	start := end.AddDate(0, 0, -7)
+	if from != "" {
+		day, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrPartnerInvalid)
+		}
+		start = day
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must not be after to", ErrPartnerInvalid)
+	}
+	if end.Sub(start) > 92*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: periods are limited to 92 days", ErrPartnerInvalid)
+	}
+	return start, end, nil
+}
+
+// GetSettlement totals the delivery fees of the orders the partner delivered between from and
+// to (YYYY-MM-DD, to inclusive), by the day the orders were placed. It defaults to the last 7 days.
+func (s *PartnerAPIService) GetSettlement(ctx context.Context, companyID, from, to string) (*PartnerSettlementResponse, error) {
+	companyUUID, err := uuid.Parse(companyID)
+	if err != nil {
+		return nil, ErrPartnerKeyInvalid
+	}
+	start, end, err := partnerPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := s.orderRepo.GetDailyDeliveryFees(ctx, repositories.OrderFilter{
+		DeliveryPartnerCompanyID: companyUUID,
+		Statuses:                 []string{"delivered", "completed"},
+		From:                     start,
+		To:                       end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute settlement: %v", err)
+	}
+
+	response := &PartnerSettlementResponse{
+		From: start.Format("2006-01-02"),
+		To:   end.Format("2006-01-02"),
+		Days: days,
+	}
+	if response.Days == nil {
+		response.Days = []repositories.DailyDeliveryFees{}
+	}
+	for _, day := range days {
+		response.Orders += day.Orders
+		response.DeliveryFees += day.DeliveryFees
+	}
+	return response, nil
+}
+
+// GetPerformance summarizes the partner's deliveries of the orders placed between from and to
+// (YYYY-MM-DD, to inclusive): outcomes, average minutes out for delivery and on-time share. It
+// defaults to the last 7 days.
+func (s *PartnerAPIService) GetPerformance(ctx context.Context, companyID, from, to string) (*PartnerPerformanceResponse, error) {
+	companyUUID, err := uuid.Parse(companyID)
+	if err != nil {
+		return nil, ErrPartnerKeyInvalid
+	}
+	start, end, err := partnerPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	performance, err := s.orderRepo.GetDeliveryPerformance(ctx, repositories.OrderFilter{
+		DeliveryPartnerCompanyID: companyUUID,
+		From:                     start,
+		To:                       end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute performance: %v", err)
+	}
+	links, err := s.restaurantDeliveryPartnerRepo.GetByDeliveryPartnerID(ctx, companyUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linked restaurants: %v", err)
+	}
+
+	response := &PartnerPerformanceResponse{
+		From:                start.Format("2006-01-02"),
+		To:                  end.Format("2006-01-02"),
+		DeliveryPerformance: *performance,
+		CompletionRate:      partnerRate(performance.Delivered, performance.Delivered+performance.Cancelled),
+		OnTimeRate:          partnerRate(performance.OnTime, performance.OnTime+performance.Late),
+	}
+	if response.AverageDeliveryMinutes != nil {
+		minutes := math.Round(*response.AverageDeliveryMinutes*10) / 10
+		response.AverageDeliveryMinutes = &minutes
+	}
+	for i := range links {
+		if linkDispatchable(&links[i]) {
+			response.ActiveRestaurants++
+		}
+	}
+	return response, nil
+}
+
+// ListRestaurants returns the restaurants linked to the partner, or that asked to be, with the
+// link's status. status narrows them to pending, active or declined links.
+func (s *PartnerAPIService) ListRestaurants(ctx context.Context, companyID, status string) ([]PartnerRestaurantLink, error) {
+	companyUUID, err := uuid.Parse(companyID)
+	if err != nil {
+		return nil, ErrPartnerKeyInvalid
+	}
+	switch status {
+	case "", PartnerLinkPending, PartnerLinkActive, PartnerLinkDeclined:
+	default:
+		return nil, fmt.Errorf("%w: status must be pending, active or declined", ErrPartnerInvalid)
+	}
+
+	links, err := s.restaurantDeliveryPartnerRepo.GetByDeliveryPartnerID(ctx, companyUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linked restaurants: %v", err)
+	}
+	response := make([]PartnerRestaurantLink, 0, len(links))
+	for i := range links {
+		if status != "" && links[i].Status != status {
+			continue
+		}
+		response = append(response, newPartnerRestaurantLink(&links[i]))
+	}
+	return response, nil
+}
+
+// RespondToLink accepts or declines a restaurant's pending link request. Deliveries are only
+// booked with the partner through accepted links. Answering the same way again changes nothing.
+func (s *PartnerAPIService) RespondToLink(ctx context.Context, companyID, linkID string, accept bool, reason string) (*PartnerRestaurantLink, error) {
+	id, err := uuid.Parse(linkID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: link %s", ErrPartnerNotFound, linkID)
+	}
+	link, err := s.restaurantDeliveryPartnerRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && link.DeliveryPartnerCompanyID.String() != companyID) {
+		return nil, fmt.Errorf("%w: link %s", ErrPartnerNotFound, linkID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := PartnerLinkDeclined
+	if accept {
+		status = PartnerLinkActive
+	}
+	if link.Status != status {
+		if link.Status != PartnerLinkPending {
+			return nil, fmt.Errorf("%w: link is %s", ErrPartnerLinkNotPending, link.Status)
+		}
+		now := time.Now()
+		link.Status = status
+		link.RespondedAt = &now
+		if !accept {
+			link.DeclineReason = strings.TrimSpace(reason)
+		}
+		if err := s.restaurantDeliveryPartnerRepo.Update(ctx, link); err != nil {
+			return nil, fmt.Errorf("failed to update link: %v", err)
+		}
+	}
+
+	response := newPartnerRestaurantLink(link)
+	return &response, nil
+}
+
+// RotateKey issues a key with the same name, scopes and rate limit as the one the request was
+// made with, and has the old key stop working after the grace period. A key with an expiry is
+// replaced by one with the same lifetime.
+func (s *PartnerAPIService) RotateKey(ctx context.Context, companyID, keyID string, req *PartnerKeyRotateRequest) (*PartnerKeyRotationResponse, error) {
+	grace := partnerDefaultRotationGrace
+	if req.GracePeriodMinutes != nil {
+		grace = time.Duration(*req.GracePeriodMinutes) * time.Minute
+		if grace < 0 || grace > partnerMaxRotationGrace {
+			return nil, fmt.Errorf("%w: grace_period_minutes must be between 0 and %d", ErrPartnerInvalid, int(partnerMaxRotationGrace.Minutes()))
+		}
+	}
+
+	id, err := uuid.Parse(keyID)
+	if err != nil {
+		return nil, ErrPartnerKeyInvalid
+	}
+	current, err := s.keyRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && current.DeliveryPartnerCompanyID.String() != companyID) {
+		return nil, ErrPartnerKeyInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if current.ReplacedByID != nil {
+		return nil, ErrPartnerKeyRotated
+	}
+
+	rawKey, err := generatePartnerKey()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	key := &models.PartnerAPIKey{
+		DeliveryPartnerCompanyID: current.DeliveryPartnerCompanyID,
+		Name:                     current.Name,
+		Prefix:                   rawKey[:partnerKeyPrefixLength],
+		KeyHash:                  hashPartnerKey(rawKey),
+		Scopes:                   current.Scopes,
+		RateLimitPerMinute:       current.RateLimitPerMinute,
+		CreatedBy:                current.CreatedBy,
+	}
+	if current.ExpiresAt != nil {
+		expiresAt := now.Add(current.ExpiresAt.Sub(current.CreatedAt))
+		key.ExpiresAt = &expiresAt
+	}
+	if err := s.keyRepo.Create(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to create API key: %v", err)
+	}
+
+	// An old key due to expire within the grace period keeps its own expiry
+	retireAt := now.Add(grace)
+	if current.ExpiresAt == nil || current.ExpiresAt.After(retireAt) {
+		current.ExpiresAt = &retireAt
+	}
+	if grace == 0 {
+		current.RevokedAt = &now
+	}
+	current.ReplacedByID = &key.ID
+	if err := s.keyRepo.Update(ctx, current); err != nil {
+		return nil, fmt.Errorf("failed to retire API key: %v", err)
+	}
+
+	return &PartnerKeyRotationResponse{
+		PartnerAPIKeyResponse: PartnerAPIKeyResponse{PartnerAPIKey: *key, Key: rawKey},
+		PreviousKeyID:         current.ID,
+		PreviousKeyExpiresAt:  *current.ExpiresAt,
+	}, nil
+}
+
+// ApplyDeliveryPartnerStatus records a delivery status reported through the partner API by the
+// delivery partner the order is booked with. picked_up sends a preparing order out for delivery,
+// in_transit only logs progress, and delivered completes the order, verifying the delivery code
+// when one is given.
+func (s *OrderService) ApplyDeliveryPartnerStatus(ctx context.Context, orderID, deliveryPartnerID uuid.UUID, status, code, note string) (*models.Order, error) {
+	var order *models.Order
+	var changed bool
+	err := retryOnConflict(ctx, func() error {
+		var err error
+		changed = false
+		order, err = s.orderRepo.GetByID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.DeliveryPartnerID == nil || *order.DeliveryPartnerID != deliveryPartnerID {
+			return fmt.Errorf("%w: order %s", ErrPartnerNotFound, orderID)
+		}
+
+		switch status {
+		case PartnerStatusPickedUp:
+			if order.OrderStatus == "dispatched" {
+				return nil // repeated update
+			}
+			if order.OrderStatus != "preparing" {
+				return fmt.Errorf("%w: order is %s", ErrPartnerInvalid, order.OrderStatus)
+			}
+			if err := s.applyOrderStatus(ctx, order, "dispatched"); err != nil {
+				return err
+			}
+		case PartnerStatusInTransit:
+			if order.OrderStatus != "dispatched" {
+				return fmt.Errorf("%w: order is not out for delivery", ErrPartnerInvalid)
+			}
+			if note == "" {
+				note = "In transit"
+			}
+			appendOrderLog(order, order.OrderStatus, "Delivery partner: "+note)
+			return s.orderRepo.Update(ctx, order)
+		case PartnerStatusDelivered:
+			if order.OrderStatus == "delivered" {
+				return nil // repeated update
+			}
+			if order.OrderStatus != "dispatched" {
+				return fmt.Errorf("%w: order is not out for delivery", ErrPartnerInvalid)
+			}
+			if code != "" {
+				if err := verifyDeliveryCode(order, code); err != nil {
+					return err
+				}
+			}
+			if err := s.applyOrderStatus(ctx, order, "delivered"); err != nil {
+				return err
+			}
+		}
+		changed = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if changed {
+		s.tracking.PublishStatus(ctx, order)
+	}
+	return order, nil
+}
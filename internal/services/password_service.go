@@ -0,0 +1,420 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/auth"
+	"golang-food-backend/pkg/cache"
+	"golang-food-backend/pkg/notify"
+
+	"github.com/google/uuid"
+)
+
+// How long after one reset email another can be sent to the same account
+const passwordResetEmailCooldown = time.Minute
+
+var (
+	ErrWeakPassword       = apperr.Validation("weak_password", "password is too weak")
+	ErrPasswordReused     = apperr.Validation("password_reused", "choose a password you have not used recently")
+	ErrWrongPassword      = apperr.Unauthorized("wrong_password", "current password is incorrect")
+	ErrResetTokenInvalid  = apperr.Validation("reset_token_invalid", "invalid or expired reset link")
+	ErrPasswordResetInput = apperr.Validation("password_reset_invalid", "invalid password reset request")
+)
+
+// PasswordPolicy is how passwords are hashed, how strong they must be, how many recent ones
+// cannot be reused and where reset links point
+type PasswordPolicy struct {
+	Hasher        auth.PasswordHasher
+	Strength      auth.PasswordPolicy
+	HistorySize   int
+	ResetURL      string // reset emails are off without one
+	ResetTokenTTL time.Duration
+}
+
+// PasswordService hashes and checks passwords, and lets users change a password or recover
+// their account with a reset link by email or an OTP to their phone
+type PasswordService struct {
+	userRepo       repositories.UserRepository
+	historyRepo    repositories.PasswordHistoryRepository
+	auditRepo      repositories.AuditLogRepository
+	otpService     *OTPService
+	sessionService *SessionService
+	email          notify.Sender
+	cache          *cache.RedisCache
+	policy         PasswordPolicy
+}
+
+func NewPasswordService(
+	userRepo repositories.UserRepository,
+	historyRepo repositories.PasswordHistoryRepository,
+	auditRepo repositories.AuditLogRepository,
+	otpService *OTPService,
+	sessionService *SessionService,
+	email notify.Sender,
+	cache *cache.RedisCache,
+	policy PasswordPolicy,
+) *PasswordService {
+	return &PasswordService{
+		userRepo:       userRepo,
+		historyRepo:    historyRepo,
+		auditRepo:      auditRepo,
+		otpService:     otpService,
+		sessionService: sessionService,
+		email:          email,
+		cache:          cache,
+		policy:         policy,
+	}
+}
+
+// ForgotPasswordRequest names the account by email, for a reset link, or by phone, for an OTP
+type ForgotPasswordRequest struct {
+	Email        string `json:"email" binding:"omitempty,email"`
+	Phone        string `json:"phone"`
+	Role         string `json:"role" binding:"required"`
+	RestaurantID string `json:"restaurant_id"` // Required only for customers
+}
+
+// ResetPasswordRequest proves the reset with the emailed token, or with the phone number and
+// the OTP sent to it
+type ResetPasswordRequest struct {
+	Token        string `json:"token"`
+	Phone        string `json:"phone"`
+	Role         string `json:"role"`
+	RestaurantID string `json:"restaurant_id"`
+	OTPCode      string `json:"otp_code"`
+	NewPassword  string `json:"new_password" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// CheckStrength returns why a new password is too weak for the user, or nil
+func (s *PasswordService) CheckStrength(password string, user *models.User) error {
+	if err := s.policy.Strength.Check(password, user.Name, user.Email, user.Phone); err != nil {
+		return fmt.Errorf("%w: %v", ErrWeakPassword, err)
+	}
+	return nil
+}
+
+// Hash returns the password's hash with the configured algorithm
+func (s *PasswordService) Hash(password string) (string, error) {
+	hash, err := s.policy.Hasher.Hash(password)
+	if err != nil {
+		return "", errors.New("failed to hash password")
+	}
+	return hash, nil
+}
+
+// Verify checks the user's password. A hash made with older settings is replaced with one
+// made with the current settings; failing to store it does not fail the login.
+func (s *PasswordService) Verify(ctx context.Context, user *models.User, password string) bool {
+	if user.PasswordHash == "" {
+		return false
+	}
+	ok, rehash := s.policy.Hasher.Verify(user.PasswordHash, password)
+	if !ok || !rehash {
+		return ok
+	}
+
+	hash, err := s.policy.Hasher.Hash(password)
+	if err != nil {
+		log.Printf("Failed to rehash password of user %s: %v", user.ID, err)
+		return true
+	}
+	user.PasswordHash = hash
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		log.Printf("Failed to store rehashed password of user %s: %v", user.ID, err)
+	}
+	return true
+}
+
+// Remember adds a password hash to the user's history and drops what falls out of it
+func (s *PasswordService) Remember(ctx context.Context, userID uuid.UUID, hash string) {
+	if s.policy.HistorySize <= 0 {
+		return
+	}
+	if err := s.historyRepo.Create(ctx, &models.PasswordHistory{UserID: userID, PasswordHash: hash}); err != nil {
+		log.Printf("Failed to record password history of user %s: %v", userID, err)
+		return
+	}
+	if err := s.historyRepo.DeleteAllButRecent(ctx, userID, s.policy.HistorySize); err != nil {
+		log.Printf("Failed to trim password history of user %s: %v", userID, err)
+	}
+}
+
+// ChangePassword replaces a signed-in user's password. Their other sessions are signed out.
+func (s *PasswordService) ChangePassword(ctx context.Context, userID, sessionID string, req *ChangePasswordRequest) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+	user, err := s.userRepo.GetByID(ctx, userUUID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if !s.Verify(ctx, user, req.CurrentPassword) {
+		return ErrWrongPassword
+	}
+
+	if err := s.setPassword(ctx, user, req.NewPassword); err != nil {
+		return err
+	}
+	if err := s.sessionService.SignOutOtherSessions(ctx, user.ID, sessionID, "password_changed"); err != nil {
+		log.Printf("Failed to sign out other sessions of user %s: %v", user.ID, err)
+	}
+	s.audit(ctx, user, "password_changed", "")
+	return nil
+}
+
+// ForgotPassword sends a reset link to the account's email, or an OTP to its phone number.
+// Whether an account matched is not revealed.
+func (s *PasswordService) ForgotPassword(ctx context.Context, req *ForgotPasswordRequest) error {
+	if (req.Email == "") == (req.Phone == "") {
+		return fmt.Errorf("%w: give either an email or a phone number", ErrPasswordResetInput)
+	}
+	restaurantID, err := loginRestaurantID(req.Role, req.RestaurantID)
+	if err != nil {
+		return err
+	}
+
+	if req.Phone != "" {
+		user, err := s.findUser(ctx, req.Role, restaurantID, "", req.Phone)
+		if err != nil || user.PasswordHash == "" {
+			return nil
+		}
+		code, err := s.otpService.issueCode(ctx, user.Phone, restaurantID, OTPPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+		return s.otpService.SendVerificationCode(ctx, "phone", user.Phone, code)
+	}
+
+	if s.policy.ResetURL == "" {
+		return fmt.Errorf("%w: reset by email is not available, use your phone number", ErrPasswordResetInput)
+	}
+	user, err := s.findUser(ctx, req.Role, restaurantID, req.Email, "")
+	if err != nil || user.PasswordHash == "" {
+		return nil
+	}
+	cooldownKey := "password_reset_sent:" + user.ID.String()
+	if sent, _ := s.cache.Exists(ctx, cooldownKey); sent {
+		return nil
+	}
+
+	token, err := randomResetToken()
+	if err != nil {
+		return errors.New("failed to create reset link")
+	}
+	if err := s.cache.Set(ctx, passwordResetKey(token), user.ID.String(), s.policy.ResetTokenTTL); err != nil {
+		return errors.New("failed to create reset link")
+	}
+	if err := s.cache.Set(ctx, cooldownKey, true, passwordResetEmailCooldown); err != nil {
+		log.Printf("Failed to set password reset cooldown for user %s: %v", user.ID, err)
+	}
+
+	link, err := url.Parse(s.policy.ResetURL)
+	if err != nil {
+		return errors.New("failed to create reset link")
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	err = s.email.Send(ctx, notify.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Open this link to choose a new password: %s\n\nIt expires in %d minutes. If you did not ask to reset your password, you can ignore this email.",
+			link.String(), int(s.policy.ResetTokenTTL/time.Minute)),
+	})
+	if err != nil {
+		log.Printf("Failed to send password reset email to user %s: %v", user.ID, err)
+		return errors.New("failed to send reset email")
+	}
+	return nil
+}
+
+// ResetPassword sets a new password after a reset link or OTP proved who is asking. The
+// account is signed out everywhere.
+func (s *PasswordService) ResetPassword(ctx context.Context, req *ResetPasswordRequest) error {
+	var user *models.User
+	switch {
+	case req.Token != "":
+		var userID string
+		if err := s.cache.Get(ctx, passwordResetKey(req.Token), &userID); err != nil {
+			return ErrResetTokenInvalid
+		}
+		userUUID, err := uuid.Parse(userID)
+		if err != nil {
+			return ErrResetTokenInvalid
+		}
+		if user, err = s.userRepo.GetByID(ctx, userUUID); err != nil {
+			return ErrResetTokenInvalid
+		}
+		// Checked before the token is spent, so a weak choice can be corrected with the same link
+		if err := s.checkNewPassword(ctx, user, req.NewPassword); err != nil {
+			return err
+		}
+		// Deleting the token claims it; of two requests racing with one link only one gets it
+		if claimed, err := s.cache.Client().Del(ctx, passwordResetKey(req.Token)).Result(); err != nil || claimed == 0 {
+			return ErrResetTokenInvalid
+		}
+	case req.Phone != "" && req.OTPCode != "":
+		restaurantID, err := loginRestaurantID(req.Role, req.RestaurantID)
+		if err != nil {
+			return err
+		}
+		if user, err = s.findUser(ctx, req.Role, restaurantID, "", req.Phone); err != nil {
+			return ErrOTPInvalid
+		}
+		if err := s.checkNewPassword(ctx, user, req.NewPassword); err != nil {
+			return err
+		}
+		if err := s.otpService.verifyCode(ctx, user.Phone, restaurantID, OTPPurposePasswordReset, req.OTPCode); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: give the reset token, or the phone number and its OTP", ErrPasswordResetInput)
+	}
+
+	if err := s.setPassword(ctx, user, req.NewPassword); err != nil {
+		return err
+	}
+	if err := s.sessionService.SignOutUser(ctx, user.ID, "password_reset"); err != nil {
+		log.Printf("Failed to sign out user %s after password reset: %v", user.ID, err)
+	}
+	method := "email"
+	if req.Token == "" {
+		method = "otp"
+	}
+	s.audit(ctx, user, "password_reset", method)
+	return nil
+}
+
+// setPassword checks and stores a new password for the user
+func (s *PasswordService) setPassword(ctx context.Context, user *models.User, password string) error {
+	if err := s.checkNewPassword(ctx, user, password); err != nil {
+		return err
+	}
+	hash, err := s.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = hash
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return errors.New("failed to update password")
+	}
+	s.Remember(ctx, user.ID, hash)
+	if err := s.cache.Delete(ctx, userSessionKey(user.ID.String())); err != nil {
+		log.Printf("Failed to clear cached user %s: %v", user.ID, err)
+	}
+	return nil
+}
+
+// checkNewPassword enforces the strength policy and refuses the current and recent passwords
+func (s *PasswordService) checkNewPassword(ctx context.Context, user *models.User, password string) error {
+	if err := s.CheckStrength(password, user); err != nil {
+		return err
+	}
+	if user.PasswordHash != "" {
+		if ok, _ := s.policy.Hasher.Verify(user.PasswordHash, password); ok {
+			return ErrPasswordReused
+		}
+	}
+	if s.policy.HistorySize <= 0 {
+		return nil
+	}
+	history, err := s.historyRepo.GetRecent(ctx, user.ID, s.policy.HistorySize)
+	if err != nil {
+		return errors.New("failed to check password history")
+	}
+	for _, entry := range history {
+		if ok, _ := s.policy.Hasher.Verify(entry.PasswordHash, password); ok {
+			return ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+// findUser looks the account up the way login does: customers within their restaurant, other
+// roles globally
+func (s *PasswordService) findUser(ctx context.Context, role string, restaurantID *uuid.UUID, email, phone string) (*models.User, error) {
+	var user *models.User
+	var err error
+	switch {
+	case role == "customer" && email != "":
+		user, err = s.userRepo.GetByEmailAndRestaurant(ctx, email, *restaurantID)
+	case role == "customer":
+		user, err = s.userRepo.GetByPhoneAndRestaurant(ctx, phone, *restaurantID)
+	case email != "":
+		user, err = s.userRepo.GetByEmail(ctx, email)
+	default:
+		user, err = s.userRepo.GetByPhone(ctx, phone)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != role || user.Status != "active" {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (s *PasswordService) audit(ctx context.Context, user *models.User, action, method string) {
+	entry := &models.AuditLog{
+		EntityType:  "user",
+		EntityID:    user.ID.String(),
+		Action:      action,
+		PerformedBy: &user.ID,
+	}
+	if method != "" {
+		entry.Metadata = models.JSONB{"method": method}
+	}
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write audit entry for user %s: %v", user.ID, err)
+	}
+}
+
+// loginRestaurantID parses the restaurant a login belongs to; customers must give one
+func loginRestaurantID(role, restaurantID string) (*uuid.UUID, error) {
+	if restaurantID == "" {
+		if role == "customer" {
+			return nil, errors.New("restaurant ID is required for customers")
+		}
+		return nil, nil
+	}
+	parsed, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, errors.New("invalid restaurant ID")
+	}
+	return &parsed, nil
+}
+
+func randomResetToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// passwordResetKey stores reset tokens by their hash, so Redis never holds a usable link
+func passwordResetKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "password_reset:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+
+	"github.com/google/uuid"
+)
+
+var ErrPaymentMethodNotFound = apperr.NotFound("payment_method_not_found", "saved payment method not found")
+
+// PaymentMethodService manages the cards and UPI IDs customers saved at checkout
+type PaymentMethodService struct {
+	savedMethods repositories.SavedPaymentMethodRepository
+	razorpay     *RazorpayService
+}
+
+func NewPaymentMethodService(savedMethods repositories.SavedPaymentMethodRepository, razorpay *RazorpayService) *PaymentMethodService {
+	return &PaymentMethodService{
+		savedMethods: savedMethods,
+		razorpay:     razorpay,
+	}
+}
+
+// ListMethods lists the customer's saved payment methods, the default first
+func (s *PaymentMethodService) ListMethods(ctx context.Context, userID string) ([]models.SavedPaymentMethod, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	methods, err := s.savedMethods.GetByUserID(ctx, userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved payment methods: %v", err)
+	}
+	return methods, nil
+}
+
+// SetDefault makes a saved payment method the one checkout offers first
+func (s *PaymentMethodService) SetDefault(ctx context.Context, userID, methodID string) (*models.SavedPaymentMethod, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	method, err := userSavedMethod(ctx, s.savedMethods, userUUID, methodID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.savedMethods.SetDefault(ctx, userUUID, method.ID); err != nil {
+		return nil, fmt.Errorf("failed to set default payment method: %v", err)
+	}
+	method.IsDefault = true
+	return method, nil
+}
+
+// DeleteMethod forgets a saved payment method, here and at Razorpay
+func (s *PaymentMethodService) DeleteMethod(ctx context.Context, userID, methodID string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+	method, err := userSavedMethod(ctx, s.savedMethods, userUUID, methodID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.razorpay.DeleteSavedMethod(ctx, method); err != nil {
+		return err
+	}
+	if err := s.savedMethods.Delete(ctx, method); err != nil {
+		return fmt.Errorf("failed to delete saved payment method: %v", err)
+	}
+	return nil
+}
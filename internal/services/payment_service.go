@@ -29,24 +29,19 @@ func NewPaymentService(
 	}
 }
 
-func (s *PaymentService) CreatePayment(payment *models.Payment) error {
-	ctx := context.Background()
+func (s *PaymentService) CreatePayment(ctx context.Context, payment *models.Payment) error {
 	return s.paymentRepo.Create(ctx, payment)
 }
 
-func (s *PaymentService) GetPaymentByID(id uuid.UUID) (*models.Payment, error) {
-	ctx := context.Background()
+func (s *PaymentService) GetPaymentByID(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
 	return s.paymentRepo.GetByID(ctx, id)
 }
 
-func (s *PaymentService) GetPaymentByOrderID(orderID uuid.UUID) (*models.Payment, error) {
-	ctx := context.Background()
+func (s *PaymentService) GetPaymentByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Payment, error) {
 	return s.paymentRepo.GetByOrderID(ctx, orderID)
 }
 
-func (s *PaymentService) UpdatePaymentStatus(id uuid.UUID, status string) (*models.Payment, error) {
-	ctx := context.Background()
-
+func (s *PaymentService) UpdatePaymentStatus(ctx context.Context, id uuid.UUID, status string) (*models.Payment, error) {
 	// Get the payment first
 	payment, err := s.paymentRepo.GetByID(ctx, id)
 	if err != nil {
@@ -64,8 +59,7 @@ func (s *PaymentService) UpdatePaymentStatus(id uuid.UUID, status string) (*mode
 	return payment, nil
 }
 
-func (s *PaymentService) GetPaymentsByStatus(status string, page, limit int) ([]models.Payment, int, error) {
-	ctx := context.Background()
+func (s *PaymentService) GetPaymentsByStatus(ctx context.Context, status string, page, limit int) ([]models.Payment, int, error) {
 	offset := (page - 1) * limit
 
 	payments, err := s.paymentRepo.GetByStatus(ctx, status, limit, offset)
@@ -78,9 +72,7 @@ func (s *PaymentService) GetPaymentsByStatus(status string, page, limit int) ([]
 	return payments, total, nil
 }
 
-func (s *PaymentService) ProcessWebhook(webhookData interface{}) error {
-	ctx := context.Background()
-
+func (s *PaymentService) ProcessWebhook(ctx context.Context, webhookData interface{}) error {
 	// Cast webhook data to the expected format
 	webhook, ok := webhookData.(*PaymentWebhookRequest)
 	if !ok {
@@ -121,69 +113,40 @@ func (s *PaymentService) ProcessWebhook(webhookData interface{}) error {
 		payment.TransactionID = webhook.TransactionID
 	}
 
-	if err := s.paymentRepo.Update(ctx, payment); err != nil {
-		return err
+	// Payments that neither complete nor fail leave the order as it is
+	succeeded := webhook.Status == "success" || webhook.Status == "completed"
+	failed := webhook.Status == "failed" || webhook.Status == "cancelled"
+	if !succeeded && !failed {
+		return s.paymentRepo.Update(ctx, payment)
 	}
 
-	// Get the associated order
-	order, err := s.orderRepo.GetByID(ctx, payment.OrderID)
-	if err != nil {
-		return err
-	}
-
-	// Handle payment success
-	if webhook.Status == "success" || webhook.Status == "completed" {
-		// Update order status
-		order.OrderStatus = "confirmed"
+	// The payment is saved with the order's new status; an order changed by another request
+	// in the meantime is read again and the status applied to it
+	var order *models.Order
+	err = retryOnConflict(ctx, func() error {
+		var err error
+		order, err = s.orderRepo.GetByID(ctx, payment.OrderID)
+		if err != nil {
+			return err
+		}
 
-		// Add order log
 		orderLog := map[string]interface{}{
 			"timestamp": time.Now(),
 			"status":    "payment_completed",
 			"message":   "Payment completed successfully",
 			"amount":    webhook.Amount,
 		}
-
-		var logs []interface{}
-		if order.OrderLogs != nil {
-			logsBytes, _ := json.Marshal(order.OrderLogs)
-			json.Unmarshal(logsBytes, &logs)
-		}
-		logs = append(logs, orderLog)
-
-		logsMap := make(map[string]interface{})
-		logsBytes, _ := json.Marshal(logs)
-		json.Unmarshal(logsBytes, &logsMap)
-		order.OrderLogs = logsMap
-
-		if err := s.orderRepo.Update(ctx, order); err != nil {
-			return err
-		}
-
-		// Clear the user's cart
-		cart, err := s.cartRepo.GetByUserID(ctx, order.UserID)
-		if err == nil {
-			// Clear cart items
-			cart.Items = models.JSONB{}
-			cart.TotalAmount = 0
-			cart.Status = "completed"
-			cart.UpdatedAt = time.Now()
-			s.cartRepo.Update(ctx, cart)
-		}
-
-		// TODO: Create delivery partner booking using restaurant's primary delivery partner
-		// This would involve calling Porter API or other delivery partner APIs
-
-	} else if webhook.Status == "failed" || webhook.Status == "cancelled" {
-		// Handle payment failure
-		order.OrderStatus = "payment_failed"
-
-		orderLog := map[string]interface{}{
-			"timestamp": time.Now(),
-			"status":    "payment_failed",
-			"message":   "Payment failed or cancelled",
+		order.OrderStatus = "confirmed"
+		if failed {
+			orderLog = map[string]interface{}{
+				"timestamp": time.Now(),
+				"status":    "payment_failed",
+				"message":   "Payment failed or cancelled",
+			}
+			order.OrderStatus = "payment_failed"
 		}
 
+		// Add order log
 		var logs []interface{}
 		if order.OrderLogs != nil {
 			logsBytes, _ := json.Marshal(order.OrderLogs)
@@ -196,11 +159,26 @@ func (s *PaymentService) ProcessWebhook(webhookData interface{}) error {
 		json.Unmarshal(logsBytes, &logsMap)
 		order.OrderLogs = logsMap
 
-		if err := s.orderRepo.Update(ctx, order); err != nil {
-			return err
-		}
+		return s.orderRepo.UpdateWithPayment(ctx, order, payment, nil)
+	})
+	if err != nil || failed {
+		return err
 	}
 
+	// Clear the cart the order was placed from
+	cart, err := s.cartRepo.GetByID(ctx, order.CartID)
+	if err == nil {
+		// Clear cart items
+		cart.Items = models.JSONB{}
+		cart.TotalAmount = 0
+		cart.Status = "completed"
+		cart.UpdatedAt = time.Now()
+		s.cartRepo.Update(ctx, cart)
+	}
+
+	// TODO: Create delivery partner booking using restaurant's primary delivery partner
+	// This would involve calling Porter API or other delivery partner APIs
+
 	return nil
 }
 
@@ -0,0 +1,741 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/spreadsheet"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PayoutStatusPending        = "pending"
+	PayoutStatusPaid           = "paid"
+	PayoutStatusFailed         = "failed"
+	PayoutStatusOnHold         = "on_hold"
+	PayoutStatusCarriedForward = "carried_forward"
+
+	PayoutBatchGenerated = "generated"
+	PayoutBatchCompleted = "completed"
+
+	SettlementTypeOrder  = "order"
+	SettlementTypeRefund = "refund"
+
+	payoutDateLayout = "2006-01-02"
+)
+
+var (
+	ErrPayoutForbidden = apperr.Forbidden("payout_forbidden", "you do not own this restaurant")
+	ErrPayoutInvalid   = apperr.Validation("payout_invalid", "invalid payout request")
+	ErrPayoutNotFound  = apperr.NotFound("payout_not_found", "payout not found")
+)
+
+// payoutTransitions lists the statuses an admin may move a payout to. Paid payouts are final
+// and carried-forward payouts are settled through a later one.
+var payoutTransitions = map[string][]string{
+	PayoutStatusPending: {PayoutStatusPaid, PayoutStatusFailed, PayoutStatusOnHold},
+	PayoutStatusFailed:  {PayoutStatusPending, PayoutStatusPaid, PayoutStatusOnHold},
+	PayoutStatusOnHold:  {PayoutStatusPending},
+}
+
+// PayoutPolicy holds the platform's settlement terms. The commission applies to restaurants
+// without their own commission agreement; GST is charged on commission and gateway fees.
+type PayoutPolicy struct {
+	DefaultCommissionPercent float64
+	GatewayFeePercent        float64
+	FeeGSTRate               float64
+}
+
+type UpdatePayoutStatusRequest struct {
+	Status    string `json:"status" binding:"required,oneof=pending paid failed on_hold"`
+	Reference string `json:"reference"` // bank transfer UTR, required when marking paid
+	Note      string `json:"note"`
+}
+
+type PayoutListResponse struct {
+	Payouts []models.RestaurantPayout `json:"payouts"`
+	Total   int64                     `json:"total"`
+	Page    int                       `json:"page"`
+	Limit   int                       `json:"limit"`
+}
+
+type PayoutBatchListResponse struct {
+	Batches []models.PayoutBatch `json:"batches"`
+	Total   int64                `json:"total"`
+	Page    int                  `json:"page"`
+	Limit   int                  `json:"limit"`
+}
+
+type PayoutBatchResponse struct {
+	Batch   *models.PayoutBatch       `json:"batch"`
+	Payouts []models.RestaurantPayout `json:"payouts"`
+}
+
+type PayoutDetailResponse struct {
+	Payout      *models.RestaurantPayout `json:"payout"`
+	Settlements []models.OrderSettlement `json:"settlements"`
+}
+
+// PayoutService settles delivered orders into weekly payout batches. Each week runs Monday to
+// Monday in the platform timezone and is generated early on the following Monday.
+type PayoutService struct {
+	payoutRepo     repositories.PayoutRepository
+	commissionRepo repositories.CommissionRepository
+	restaurantRepo repositories.RestaurantRepository
+	couponRepo     repositories.CouponRepository
+	documentRepo   repositories.RestaurantDocumentRepository
+	staffRepo      repositories.StaffRepository
+	ledger         *LedgerService
+	policy         PayoutPolicy
+	stopChan       chan bool
+	timezone       *time.Location
+	isRunning      bool
+}
+
+func NewPayoutService(
+	payoutRepo repositories.PayoutRepository,
+	commissionRepo repositories.CommissionRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	couponRepo repositories.CouponRepository,
+	documentRepo repositories.RestaurantDocumentRepository,
+	staffRepo repositories.StaffRepository,
+	ledger *LedgerService,
+	policy PayoutPolicy,
+) *PayoutService {
+	// Default to Asia/Kolkata timezone
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		loc = time.UTC
+		log.Printf("Failed to load timezone, using UTC: %v", err)
+	}
+
+	return &PayoutService{
+		payoutRepo:     payoutRepo,
+		commissionRepo: commissionRepo,
+		restaurantRepo: restaurantRepo,
+		couponRepo:     couponRepo,
+		documentRepo:   documentRepo,
+		staffRepo:      staffRepo,
+		ledger:         ledger,
+		policy:         policy,
+		stopChan:       make(chan bool),
+		timezone:       loc,
+	}
+}
+
+// Start generates the previous week's payout batch every Monday
+func (s *PayoutService) Start() error {
+	if s.isRunning {
+		return fmt.Errorf("payout service is already running")
+	}
+
+	s.isRunning = true
+	go s.runWeeklyTicker()
+
+	log.Println("💸 Restaurant payouts: Every Monday at 02:00")
+	return nil
+}
+
+// Stop stops the weekly batch generation
+func (s *PayoutService) Stop() {
+	if !s.isRunning {
+		return
+	}
+
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// runWeeklyTicker wakes at 02:00 every day and generates a batch on Mondays. Running a couple
+// of hours after the week closes lets late deliveries and refunds land first.
+func (s *PayoutService) runWeeklyTicker() {
+	now := time.Now().In(s.timezone)
+	next := time.Date(now.Year(), now.Month(), now.Day(), 2, 0, 0, 0, s.timezone)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	timer := time.NewTimer(next.Sub(now))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if time.Now().In(s.timezone).Weekday() == time.Monday {
+				if _, err := s.GenerateBatch(context.Background(), ""); err != nil {
+					log.Printf("Failed to generate payout batch: %v", err)
+				}
+			}
+			timer.Reset(24 * time.Hour)
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// GenerateBatch settles the week starting on weekStart (a Monday, YYYY-MM-DD), or the last
+// completed week when it is empty. A week that already has a batch returns that batch.
+func (s *PayoutService) GenerateBatch(ctx context.Context, weekStart string) (*models.PayoutBatch, error) {
+	var periodStart time.Time
+	if weekStart == "" {
+		periodStart = s.weekStart(time.Now()).AddDate(0, 0, -7)
+	} else {
+		date, err := time.ParseInLocation(payoutDateLayout, weekStart, s.timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: week_start must be a date in YYYY-MM-DD format", ErrPayoutInvalid)
+		}
+		if date.Weekday() != time.Monday {
+			return nil, fmt.Errorf("%w: week_start must be a Monday", ErrPayoutInvalid)
+		}
+		periodStart = date
+	}
+	periodEnd := periodStart.AddDate(0, 0, 7)
+	if periodEnd.After(time.Now()) {
+		return nil, fmt.Errorf("%w: the week starting %s has not ended", ErrPayoutInvalid, periodStart.Format(payoutDateLayout))
+	}
+
+	if existing, err := s.payoutRepo.GetBatchByPeriod(ctx, periodStart); err == nil {
+		return existing, nil
+	}
+
+	orders, err := s.payoutRepo.GetUnsettledOrders(ctx, periodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load delivered orders: %v", err)
+	}
+	refunds, err := s.payoutRepo.GetUnsettledRefunds(ctx, periodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load refunds: %v", err)
+	}
+	held, err := s.payoutRepo.GetNegativeHeldPayouts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load held payouts: %v", err)
+	}
+
+	payouts := make(map[uuid.UUID]*models.RestaurantPayout)
+	payoutFor := func(restaurantID uuid.UUID) *models.RestaurantPayout {
+		payout, ok := payouts[restaurantID]
+		if !ok {
+			payout = &models.RestaurantPayout{
+				ID:           uuid.New(),
+				RestaurantID: restaurantID,
+				PeriodStart:  periodStart,
+				PeriodEnd:    periodEnd,
+			}
+			payouts[restaurantID] = payout
+		}
+		return payout
+	}
+
+	var settlements []models.OrderSettlement
+	orderNet := make(map[uuid.UUID]models.Money, len(orders))
+	// The platform keeps what was collected for an order less the restaurant's earnings, and
+	// absorbs the part of a refund not taken back from the restaurant
+	platformShare := make(map[uuid.UUID]models.Money)
+	currencies := make(map[uuid.UUID]string)
+	histories := make(map[uuid.UUID][]models.CommissionToRestaurant)
+	for i := range orders {
+		order := &orders[i]
+		versions, ok := histories[order.RestaurantID]
+		if !ok {
+			versions, err = s.commissionRepo.GetHistory(ctx, order.RestaurantID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load commission terms: %v", err)
+			}
+			histories[order.RestaurantID] = versions
+		}
+
+		// Orders are charged the commission in effect when they were placed
+		settlement := s.settleOrder(ctx, order, commissionAt(versions, order.CreatedAt))
+		payout := payoutFor(order.RestaurantID)
+		settlement.PayoutID = payout.ID
+		payout.OrderCount++
+		payout.OrderValue += settlement.OrderValue
+		payout.Commission += settlement.Commission
+		payout.Fees += settlement.Fees
+		orderNet[order.ID] = settlement.NetAmount
+		platformShare[payout.ID] += order.TotalAmount - settlement.NetAmount
+		currencies[payout.ID] = order.Currency
+		settlements = append(settlements, settlement)
+	}
+
+	for i := range refunds {
+		refund := &refunds[i]
+		net, ok := orderNet[refund.OrderID]
+		if !ok {
+			original, err := s.payoutRepo.GetSettlementBySource(ctx, refund.OrderID)
+			if err != nil {
+				// The order was placed after this period closed; it is settled next week
+				continue
+			}
+			net = original.NetAmount
+		}
+
+		settlement := settleRefund(refund, net)
+		payout := payoutFor(refund.Order.RestaurantID)
+		settlement.PayoutID = payout.ID
+		payout.Refunds += settlement.Refunds
+		platformShare[payout.ID] -= refund.Amount - settlement.Refunds
+		currencies[payout.ID] = refund.Order.Currency
+		settlements = append(settlements, settlement)
+	}
+
+	var carried []uuid.UUID
+	for _, previous := range held {
+		payout := payoutFor(previous.RestaurantID)
+		payout.CarriedOver += previous.NetAmount
+		carried = append(carried, previous.ID)
+	}
+
+	batch := &models.PayoutBatch{
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Status:      PayoutBatchGenerated,
+	}
+	rows := make([]models.RestaurantPayout, 0, len(payouts))
+	for _, payout := range payouts {
+		payout.NetAmount = payout.OrderValue - payout.Commission - payout.Fees - payout.Refunds + payout.CarriedOver
+		payout.Status = PayoutStatusPending
+		if payout.NetAmount <= 0 {
+			// Nothing to transfer; a negative balance is carried into the next payout
+			payout.Status = PayoutStatusOnHold
+		} else {
+			batch.TotalAmount += payout.NetAmount
+		}
+		rows = append(rows, *payout)
+	}
+	batch.PayoutCount = len(rows)
+	if !hasOutstandingPayouts(rows) {
+		batch.Status = PayoutBatchCompleted
+	}
+
+	if err := s.payoutRepo.CreateBatch(ctx, batch, rows, settlements, carried); err != nil {
+		// Another instance may have generated the same week concurrently
+		if existing, getErr := s.payoutRepo.GetBatchByPeriod(ctx, periodStart); getErr == nil {
+			return existing, nil
+		}
+		return nil, fmt.Errorf("failed to create payout batch: %v", err)
+	}
+	for i := range rows {
+		s.ledger.PayoutSettled(ctx, &rows[i], currencies[rows[i].ID], platformShare[rows[i].ID])
+	}
+
+	log.Printf("Generated payout batch for week of %s: %d payouts, %s total",
+		periodStart.Format(payoutDateLayout), batch.PayoutCount, batch.TotalAmount)
+	return batch, nil
+}
+
+// settleOrder works out the restaurant's earnings from a delivered order. Order value is the
+// pre-tax item and packaging total, less any discount the restaurant funded through its own
+// coupon; GST collected on the order is remitted by the platform and the delivery fee is
+// the platform's.
+func (s *PayoutService) settleOrder(ctx context.Context, order *models.Order, commission *models.CommissionToRestaurant) models.OrderSettlement {
+	items, hasItems := order.TaxDetails["items_taxable_value"]
+	value := models.MoneyOf(items) + models.MoneyOf(order.TaxDetails["packaging_fee"])
+	if !hasItems {
+		// Orders from before itemized tax details only have the bill total
+		value = order.TotalAmount - order.DeliveryFee
+	}
+	value -= s.restaurantFundedDiscount(ctx, order)
+	if value < 0 {
+		value = 0
+	}
+
+	charged := commissionAmount(commission, s.policy.DefaultCommissionPercent, value)
+
+	gatewayFee := order.TotalAmount.Percent(s.policy.GatewayFeePercent)
+	fees := gatewayFee + (charged + gatewayFee).Percent(s.policy.FeeGSTRate)
+
+	return models.OrderSettlement{
+		SourceID:     order.ID,
+		Type:         SettlementTypeOrder,
+		OrderID:      order.ID,
+		RestaurantID: order.RestaurantID,
+		OrderValue:   value,
+		Commission:   charged,
+		Fees:         fees,
+		NetAmount:    value - charged - fees,
+		OccurredAt:   order.CreatedAt,
+	}
+}
+
+// restaurantFundedDiscount returns the coupon discount on an order when the coupon was the
+// restaurant's own; platform-wide coupons are funded by the platform
+func (s *PayoutService) restaurantFundedDiscount(ctx context.Context, order *models.Order) models.Money {
+	couponID, _ := order.DiscountDetails["coupon_id"].(string)
+	discount := models.MoneyOf(order.DiscountDetails["discount_amount"])
+	if couponID == "" || discount <= 0 {
+		return 0
+	}
+
+	couponUUID, err := uuid.Parse(couponID)
+	if err != nil {
+		return 0
+	}
+	coupon, err := s.couponRepo.GetByID(ctx, couponUUID)
+	if err != nil || coupon.RestaurantID == nil {
+		return 0
+	}
+	return discount
+}
+
+// settleRefund deducts the refunded share of the order's earnings, so a full refund takes
+// back everything the restaurant was paid for the order
+func settleRefund(refund *models.Refund, orderNet models.Money) models.OrderSettlement {
+	deduction := orderNet
+	if refund.Order.TotalAmount > 0 && refund.Amount < refund.Order.TotalAmount {
+		deduction = orderNet.Scale(float64(refund.Amount) / float64(refund.Order.TotalAmount))
+	}
+
+	occurredAt := refund.CreatedAt
+	if refund.ProcessedAt != nil {
+		occurredAt = *refund.ProcessedAt
+	}
+
+	return models.OrderSettlement{
+		SourceID:     refund.ID,
+		Type:         SettlementTypeRefund,
+		OrderID:      refund.OrderID,
+		RestaurantID: refund.Order.RestaurantID,
+		Refunds:      deduction,
+		NetAmount:    -deduction,
+		OccurredAt:   occurredAt,
+	}
+}
+
+// ListBatches lists payout batches, newest week first
+func (s *PayoutService) ListBatches(ctx context.Context, page, limit int) (*PayoutBatchListResponse, error) {
+	page, limit = normalizePage(page, limit)
+
+	batches, total, err := s.payoutRepo.GetBatches(ctx, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payout batches: %v", err)
+	}
+
+	return &PayoutBatchListResponse{
+		Batches: batches,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
+
+// GetBatch returns a batch with every restaurant's payout in it
+func (s *PayoutService) GetBatch(ctx context.Context, batchID string) (*PayoutBatchResponse, error) {
+	batch, err := s.batch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	payouts, err := s.payoutRepo.GetPayoutsByBatch(ctx, batch.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payouts: %v", err)
+	}
+
+	return &PayoutBatchResponse{
+		Batch:   batch,
+		Payouts: payouts,
+	}, nil
+}
+
+// ExportBatch writes a bank transfer sheet for the batch's pending payouts, with the account
+// details from each restaurant's verified bank document
+func (s *PayoutService) ExportBatch(ctx context.Context, batchID string) ([]byte, error) {
+	batch, err := s.batch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	payouts, err := s.payoutRepo.GetPayoutsByBatch(ctx, batch.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payouts: %v", err)
+	}
+
+	rows := [][]string{{
+		"payout_id", "restaurant_id", "restaurant_name", "account_holder", "account_number", "ifsc",
+		"amount", "order_count", "period_start", "period_end", "bank_details_status",
+	}}
+	for _, payout := range payouts {
+		if payout.Status != PayoutStatusPending {
+			continue
+		}
+
+		var holder, account, ifsc string
+		bankStatus := "missing"
+		document, err := s.documentRepo.GetByRestaurantAndType(ctx, payout.RestaurantID, DocumentBankDetails)
+		if err == nil {
+			bankStatus = document.Status
+			if document.Status == DocumentStatusVerified {
+				holder, _ = document.Details["account_holder"].(string)
+				ifsc, _ = document.Details["ifsc"].(string)
+				account = document.Number
+			}
+		}
+
+		rows = append(rows, []string{
+			payout.ID.String(),
+			payout.RestaurantID.String(),
+			payout.Restaurant.Name,
+			holder,
+			account,
+			ifsc,
+			payout.NetAmount.String(),
+			fmt.Sprint(payout.OrderCount),
+			payout.PeriodStart.In(s.timezone).Format(payoutDateLayout),
+			payout.PeriodEnd.In(s.timezone).AddDate(0, 0, -1).Format(payoutDateLayout),
+			bankStatus,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := spreadsheet.Write(spreadsheet.FormatCSV, &buf, "Payouts", rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UpdatePayoutStatus records the outcome of a bank transfer, or holds and releases a payout
+func (s *PayoutService) UpdatePayoutStatus(ctx context.Context, payoutID string, req *UpdatePayoutStatusRequest) (*models.RestaurantPayout, error) {
+	payout, err := s.payout(ctx, payoutID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, status := range payoutTransitions[payout.Status] {
+		if status == req.Status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: a %s payout cannot be marked %s", ErrPayoutInvalid, payout.Status, req.Status)
+	}
+
+	reference := strings.TrimSpace(req.Reference)
+	switch req.Status {
+	case PayoutStatusPaid:
+		if reference == "" {
+			return nil, fmt.Errorf("%w: reference is required when marking a payout paid", ErrPayoutInvalid)
+		}
+		if payout.NetAmount <= 0 {
+			return nil, fmt.Errorf("%w: nothing to pay on this payout", ErrPayoutInvalid)
+		}
+		now := time.Now()
+		payout.PaidAt = &now
+		payout.Reference = reference
+	case PayoutStatusPending:
+		if payout.NetAmount <= 0 {
+			return nil, fmt.Errorf("%w: a payout below zero stays on hold until it is carried forward", ErrPayoutInvalid)
+		}
+	}
+
+	payout.Status = req.Status
+	payout.Note = strings.TrimSpace(req.Note)
+	payout.UpdatedAt = time.Now()
+	if err := s.payoutRepo.UpdatePayout(ctx, payout); err != nil {
+		return nil, fmt.Errorf("failed to update payout: %v", err)
+	}
+
+	if payout.Status == PayoutStatusPaid {
+		s.ledger.PayoutPaid(ctx, payout, payout.Restaurant.Currency)
+	}
+	s.refreshBatchStatus(ctx, payout.BatchID)
+	return payout, nil
+}
+
+// refreshBatchStatus completes a batch once none of its payouts is waiting on a transfer
+func (s *PayoutService) refreshBatchStatus(ctx context.Context, batchID uuid.UUID) {
+	batch, err := s.payoutRepo.GetBatchByID(ctx, batchID)
+	if err != nil {
+		return
+	}
+	payouts, err := s.payoutRepo.GetPayoutsByBatch(ctx, batchID)
+	if err != nil {
+		return
+	}
+
+	status := PayoutBatchCompleted
+	if hasOutstandingPayouts(payouts) {
+		status = PayoutBatchGenerated
+	}
+	if batch.Status == status {
+		return
+	}
+	batch.Status = status
+	if err := s.payoutRepo.UpdateBatch(ctx, batch); err != nil {
+		log.Printf("Failed to update payout batch %s: %v", batch.ID, err)
+	}
+}
+
+// ListRestaurantPayouts lists a restaurant's payouts for its owner, newest week first
+func (s *PayoutService) ListRestaurantPayouts(ctx context.Context, userID, restaurantID string, page, limit int) (*PayoutListResponse, error) {
+	restaurant, err := s.ownRestaurant(ctx, userID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	page, limit = normalizePage(page, limit)
+
+	payouts, total, err := s.payoutRepo.GetPayoutsByRestaurant(ctx, restaurant.ID, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payouts: %v", err)
+	}
+
+	return &PayoutListResponse{
+		Payouts: payouts,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
+
+// GetRestaurantPayout returns one of the owner's payouts with its per-order settlement lines
+func (s *PayoutService) GetRestaurantPayout(ctx context.Context, userID, restaurantID, payoutID string) (*PayoutDetailResponse, error) {
+	payout, err := s.ownPayout(ctx, userID, restaurantID, payoutID)
+	if err != nil {
+		return nil, err
+	}
+
+	settlements, err := s.payoutRepo.GetSettlementsByPayout(ctx, payout.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settlements: %v", err)
+	}
+
+	return &PayoutDetailResponse{
+		Payout:      payout,
+		Settlements: settlements,
+	}, nil
+}
+
+// ExportStatement writes the settlement statement for one of the owner's payouts: a line per
+// order or refund followed by the payout totals
+func (s *PayoutService) ExportStatement(ctx context.Context, userID, restaurantID, payoutID string) ([]byte, error) {
+	detail, err := s.GetRestaurantPayout(ctx, userID, restaurantID, payoutID)
+	if err != nil {
+		return nil, err
+	}
+	payout := detail.Payout
+
+	rows := [][]string{{"date", "type", "order_id", "order_value", "commission", "fees", "refunds", "net_amount"}}
+	for _, settlement := range detail.Settlements {
+		rows = append(rows, []string{
+			settlement.OccurredAt.In(s.timezone).Format("2006-01-02 15:04"),
+			settlement.Type,
+			settlement.OrderID.String(),
+			settlement.OrderValue.String(),
+			settlement.Commission.String(),
+			settlement.Fees.String(),
+			settlement.Refunds.String(),
+			settlement.NetAmount.String(),
+		})
+	}
+	if payout.CarriedOver != 0 {
+		rows = append(rows, []string{"", "carried_over", "", "", "", "", "", payout.CarriedOver.String()})
+	}
+	rows = append(rows, []string{
+		payout.PeriodStart.In(s.timezone).Format(payoutDateLayout) + " to " +
+			payout.PeriodEnd.In(s.timezone).AddDate(0, 0, -1).Format(payoutDateLayout),
+		"total",
+		"",
+		payout.OrderValue.String(),
+		payout.Commission.String(),
+		payout.Fees.String(),
+		payout.Refunds.String(),
+		payout.NetAmount.String(),
+	})
+
+	var buf bytes.Buffer
+	if err := spreadsheet.Write(spreadsheet.FormatCSV, &buf, "Statement", rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// weekStart returns midnight on the Monday of t's week in the platform timezone
+func (s *PayoutService) weekStart(t time.Time) time.Time {
+	t = t.In(s.timezone)
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, s.timezone)
+}
+
+func (s *PayoutService) batch(ctx context.Context, batchID string) (*models.PayoutBatch, error) {
+	batchUUID, err := uuid.Parse(batchID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid batch ID", ErrPayoutInvalid)
+	}
+	batch, err := s.payoutRepo.GetBatchByID(ctx, batchUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: batch %s", ErrPayoutNotFound, batchID)
+	}
+	return batch, nil
+}
+
+func (s *PayoutService) payout(ctx context.Context, payoutID string) (*models.RestaurantPayout, error) {
+	payoutUUID, err := uuid.Parse(payoutID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid payout ID", ErrPayoutInvalid)
+	}
+	payout, err := s.payoutRepo.GetPayoutByID(ctx, payoutUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: payout %s", ErrPayoutNotFound, payoutID)
+	}
+	return payout, nil
+}
+
+// ownRestaurant loads a restaurant the user owns, or is staff of with permission to view payouts
+func (s *PayoutService) ownRestaurant(ctx context.Context, userID, restaurantID string) (*models.Restaurant, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrPayoutInvalid)
+	}
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: restaurant %s", ErrPayoutNotFound, restaurantID)
+	}
+	if restaurant.OwnerID.String() != userID && !staffHasPermission(ctx, s.staffRepo, userID, restaurant.ID, StaffPermissionPayoutsView) {
+		return nil, ErrPayoutForbidden
+	}
+	return restaurant, nil
+}
+
+// ownPayout loads a payout of a restaurant the user owns
+func (s *PayoutService) ownPayout(ctx context.Context, userID, restaurantID, payoutID string) (*models.RestaurantPayout, error) {
+	restaurant, err := s.ownRestaurant(ctx, userID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	payout, err := s.payout(ctx, payoutID)
+	if err != nil {
+		return nil, err
+	}
+	if payout.RestaurantID != restaurant.ID {
+		return nil, fmt.Errorf("%w: payout %s", ErrPayoutNotFound, payoutID)
+	}
+	return payout, nil
+}
+
+// hasOutstandingPayouts reports whether any payout still needs a bank transfer
+func hasOutstandingPayouts(payouts []models.RestaurantPayout) bool {
+	for _, payout := range payouts {
+		if payout.Status == PayoutStatusPending || payout.Status == PayoutStatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizePage(page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/fieldcrypt"
+	"golang-food-backend/pkg/jobs"
+)
+
+const (
+	JobPIIReencrypt = "pii:reencrypt"
+
+	piiReencryptBatchSize = 200
+	piiReencryptTimeout   = time.Hour
+)
+
+var ErrPIIEncryptionDisabled = apperr.Conflict("pii_encryption_disabled", "field encryption is not configured")
+
+// PIIService moves stored personal data onto the active encryption key: plaintext written before
+// encryption was turned on, and values sealed with a key that has since been rotated out
+type PIIService struct {
+	repo  repositories.PIIRepository
+	queue *jobs.Queue
+}
+
+func NewPIIService(repo repositories.PIIRepository) *PIIService {
+	return &PIIService{
+		repo: repo,
+	}
+}
+
+// PIIStatus is how much stored personal data is not yet under the active key
+type PIIStatus struct {
+	Enabled          bool  `json:"enabled"`
+	PendingUsers     int64 `json:"pending_users"`
+	PendingAddresses int64 `json:"pending_addresses"`
+}
+
+// PIIReencryptResult counts the rows a re-encryption run rewrote
+type PIIReencryptResult struct {
+	Users     int `json:"users"`
+	Addresses int `json:"addresses"`
+}
+
+// RegisterJobs registers the re-encryption job handler and the queue runs are started on
+func (s *PIIService) RegisterJobs(queue *jobs.Queue) {
+	s.queue = queue
+	queue.Handle(JobPIIReencrypt, func(ctx context.Context, job *jobs.Job) error {
+		result, err := s.Reencrypt(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Re-encrypted personal data of %d users and %d addresses", result.Users, result.Addresses)
+		return nil
+	})
+}
+
+// Status counts the users and addresses the re-encryption job still has to rewrite
+func (s *PIIService) Status(ctx context.Context) (*PIIStatus, error) {
+	if !fieldcrypt.Enabled() {
+		return &PIIStatus{}, nil
+	}
+	users, addresses, err := s.repo.CountStale(ctx, fieldcrypt.ActivePrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unencrypted records: %v", err)
+	}
+	return &PIIStatus{Enabled: true, PendingUsers: users, PendingAddresses: addresses}, nil
+}
+
+// StartReencryption queues a re-encryption run; its progress is on the admin jobs API
+func (s *PIIService) StartReencryption(ctx context.Context) (*jobs.Job, error) {
+	if !fieldcrypt.Enabled() {
+		return nil, ErrPIIEncryptionDisabled
+	}
+	job, err := s.queue.Enqueue(ctx, JobPIIReencrypt, nil, jobs.Timeout(piiReencryptTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue re-encryption: %v", err)
+	}
+	return job, nil
+}
+
+// Reencrypt rewrites stale users and addresses in batches until none are left. Rewritten rows
+// stop matching, so a run cut short by its timeout carries on where it stopped when retried.
+func (s *PIIService) Reencrypt(ctx context.Context) (*PIIReencryptResult, error) {
+	if !fieldcrypt.Enabled() {
+		return nil, ErrPIIEncryptionDisabled
+	}
+	activePrefix := fieldcrypt.ActivePrefix()
+	result := &PIIReencryptResult{}
+
+	for {
+		n, err := s.repo.ReencryptUsers(ctx, activePrefix, piiReencryptBatchSize)
+		result.Users += n
+		if err != nil {
+			return result, fmt.Errorf("failed to re-encrypt users: %v", err)
+		}
+		if n < piiReencryptBatchSize {
+			break
+		}
+	}
+	for {
+		n, err := s.repo.ReencryptAddresses(ctx, activePrefix, piiReencryptBatchSize)
+		result.Addresses += n
+		if err != nil {
+			return result, fmt.Errorf("failed to re-encrypt addresses: %v", err)
+		}
+		if n < piiReencryptBatchSize {
+			break
+		}
+	}
+	return result, nil
+}
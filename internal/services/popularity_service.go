@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/cache"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	BadgeBestseller          = "bestseller"
+	BadgeFrequentlyReordered = "frequently_reordered"
+
+	popularityWindow           = 30 * 24 * time.Hour
+	bestsellerTopN             = 5
+	bestsellerMinOrders        = 10
+	reorderMinRepeatCustomers  = 3
+	popularityRestaurantsBatch = 100
+)
+
+type PopularityService struct {
+	restaurantRepo repositories.RestaurantRepository
+	orderRepo      repositories.OrderRepository
+	productRepo    repositories.ProductRepository
+	cache          *cache.RedisCache
+	stopChan       chan bool
+	timezone       *time.Location
+	isRunning      bool
+}
+
+func NewPopularityService(
+	restaurantRepo repositories.RestaurantRepository,
+	orderRepo repositories.OrderRepository,
+	productRepo repositories.ProductRepository,
+	cache *cache.RedisCache,
+) *PopularityService {
+	// Default to Asia/Kolkata timezone
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		loc = time.UTC
+		log.Printf("Failed to load timezone, using UTC: %v", err)
+	}
+
+	return &PopularityService{
+		restaurantRepo: restaurantRepo,
+		orderRepo:      orderRepo,
+		productRepo:    productRepo,
+		cache:          cache,
+		stopChan:       make(chan bool),
+		timezone:       loc,
+	}
+}
+
+// Start refreshes popularity badges every night at midnight
+func (s *PopularityService) Start() error {
+	if s.isRunning {
+		return fmt.Errorf("popularity service is already running")
+	}
+
+	s.isRunning = true
+	go s.runNightlyTicker()
+
+	log.Println("🏅 Popularity badges: Every day at midnight")
+	return nil
+}
+
+// Stop stops the nightly badge refresh
+func (s *PopularityService) Stop() {
+	if !s.isRunning {
+		return
+	}
+
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+func (s *PopularityService) runNightlyTicker() {
+	now := time.Now().In(s.timezone)
+	next := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, s.timezone)
+
+	timer := time.NewTimer(next.Sub(now))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if err := s.RefreshAllBadges(context.Background()); err != nil {
+				log.Printf("Failed to refresh popularity badges: %v", err)
+			}
+			timer.Reset(24 * time.Hour)
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// RefreshAllBadges recomputes badges for every restaurant
+func (s *PopularityService) RefreshAllBadges(ctx context.Context) error {
+	offset := 0
+	for {
+		restaurants, err := s.restaurantRepo.Search(ctx, "", popularityRestaurantsBatch, offset)
+		if err != nil {
+			return fmt.Errorf("failed to list restaurants: %v", err)
+		}
+
+		for _, restaurant := range restaurants {
+			if err := s.RefreshRestaurantBadges(ctx, restaurant.ID.String()); err != nil {
+				log.Printf("Failed to refresh badges for restaurant %s: %v", restaurant.ID, err)
+			}
+		}
+
+		if len(restaurants) < popularityRestaurantsBatch {
+			return nil
+		}
+		offset += popularityRestaurantsBatch
+	}
+}
+
+// RefreshRestaurantBadges recomputes badges for a single restaurant from the last 30 days of orders
+func (s *PopularityService) RefreshRestaurantBadges(ctx context.Context, restaurantID string) error {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return errors.New("invalid restaurant ID")
+	}
+
+	orders, err := s.orderRepo.GetByRestaurantIDSince(ctx, restaurantUUID, time.Now().Add(-popularityWindow))
+	if err != nil {
+		return fmt.Errorf("failed to get orders: %v", err)
+	}
+
+	orderCounts := make(map[string]int)
+	customerOrders := make(map[string]map[uuid.UUID]int)
+
+	for _, order := range orders {
+		if !countsTowardsPopularity(order.OrderStatus) {
+			continue
+		}
+
+		for _, productID := range uniqueOrderProductIDs(order) {
+			orderCounts[productID]++
+			if customerOrders[productID] == nil {
+				customerOrders[productID] = make(map[uuid.UUID]int)
+			}
+			customerOrders[productID][order.UserID]++
+		}
+	}
+
+	badges := make(map[primitive.ObjectID][]string)
+	addBadge := func(productID, badge string) {
+		objectID, err := primitive.ObjectIDFromHex(productID)
+		if err != nil {
+			return
+		}
+		badges[objectID] = append(badges[objectID], badge)
+	}
+
+	// Bestsellers: top products by number of orders
+	ranked := make([]string, 0, len(orderCounts))
+	for productID, count := range orderCounts {
+		if count >= bestsellerMinOrders {
+			ranked = append(ranked, productID)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		return orderCounts[ranked[i]] > orderCounts[ranked[j]]
+	})
+	if len(ranked) > bestsellerTopN {
+		ranked = ranked[:bestsellerTopN]
+	}
+	for _, productID := range ranked {
+		addBadge(productID, BadgeBestseller)
+	}
+
+	// Frequently reordered: products ordered more than once by enough customers
+	for productID, customers := range customerOrders {
+		repeatCustomers := 0
+		for _, count := range customers {
+			if count > 1 {
+				repeatCustomers++
+			}
+		}
+		if repeatCustomers >= reorderMinRepeatCustomers {
+			addBadge(productID, BadgeFrequentlyReordered)
+		}
+	}
+
+	if err := s.productRepo.UpdateBadges(ctx, restaurantID, badges); err != nil {
+		return fmt.Errorf("failed to update badges: %v", err)
+	}
+
+	// Invalidate cached products so listings pick up the new badges
+	s.cache.InvalidateTags(ctx, restaurantProductsTag(restaurantID))
+
+	return nil
+}
+
+func countsTowardsPopularity(status string) bool {
+	switch status {
+	case "confirmed", "preparing", "dispatched", "ready_for_pickup", "delivered":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+)
+
+// PorterProvider adapts PorterService to the DeliveryProvider interface
+type PorterProvider struct {
+	porterService *PorterService
+}
+
+func NewPorterProvider(porterService *PorterService) *PorterProvider {
+	return &PorterProvider{porterService: porterService}
+}
+
+func (p *PorterProvider) Name() string {
+	return "porter"
+}
+
+func (p *PorterProvider) GetQuote(ctx context.Context, order *models.Order, restaurant *models.Restaurant) (*DeliveryQuote, error) {
+	quote, err := p.porterService.QuoteForOrder(ctx, order, restaurant)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeliveryQuote{
+		Provider:         p.Name(),
+		Fee:              float64(quote.EstimatedFare.MinorAmount) / 100,
+		Currency:         quote.EstimatedFare.Currency,
+		EstimatedMinutes: quote.EstimatedTime,
+		VehicleType:      quote.VehicleType,
+		Distance:         quote.Distance,
+	}, nil
+}
+
+func (p *PorterProvider) CreateOrder(ctx context.Context, order *models.Order, restaurant *models.Restaurant) (*DeliveryBooking, error) {
+	porterOrder, err := p.porterService.CreateDeliveryOrder(ctx, order, restaurant)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeliveryBooking{
+		Provider:            p.Name(),
+		ProviderOrderID:     porterOrder.OrderID,
+		TrackingURL:         porterOrder.TrackingURL,
+		EstimatedFee:        float64(porterOrder.EstimatedFareDetails.MinorAmount) / 100,
+		EstimatedPickupTime: porterOrder.EstimatedPickupTime,
+	}, nil
+}
+
+func (p *PorterProvider) Track(ctx context.Context, providerOrderID string) (*DeliveryTrackingInfo, error) {
+	tracking, err := p.porterService.TrackOrder(ctx, providerOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &DeliveryTrackingInfo{
+		Provider:        p.Name(),
+		ProviderOrderID: tracking.OrderID,
+		Status:          tracking.Status,
+	}
+	if partner := tracking.PartnerInfo; partner != nil {
+		info.PartnerName = partner.Name
+		info.PartnerPhone = partner.Mobile.CountryCode + partner.Mobile.MobileNumber
+		info.VehicleNumber = partner.VehicleNumber
+		if partner.Location != nil {
+			info.Latitude = &partner.Location.Lat
+			info.Longitude = &partner.Location.Long
+		}
+	}
+
+	return info, nil
+}
+
+func (p *PorterProvider) Cancel(ctx context.Context, providerOrderID string) error {
+	_, err := p.porterService.CancelOrder(ctx, providerOrderID)
+	return err
+}
+
+func (p *PorterProvider) ParseWebhook(body []byte) (*DeliveryWebhookEvent, error) {
+	var payload PorterWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid Porter webhook payload: %v", err)
+	}
+	if payload.OrderID == "" || payload.Status == "" {
+		return nil, errors.New("order ID and status are required in webhook payload")
+	}
+
+	return &DeliveryWebhookEvent{
+		Provider:        p.Name(),
+		ProviderOrderID: payload.OrderID,
+		ProviderStatus:  payload.Status,
+		OrderStatus:     porterStatusToOrderStatus(payload.Status),
+		Payload:         &payload,
+	}, nil
+}
+
+func (p *PorterProvider) HandleWebhook(ctx context.Context, event *DeliveryWebhookEvent) error {
+	payload, ok := event.Payload.(*PorterWebhookPayload)
+	if !ok {
+		return errors.New("webhook event was not parsed by the Porter provider")
+	}
+	return p.porterService.HandleWebhook(ctx, payload)
+}
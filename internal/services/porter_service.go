@@ -7,10 +7,14 @@ import (
 	"fmt"
 	"golang-food-backend/internal/models"
 	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
 	"io"
+	"log"
 	"net/http"
-	"os"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type PorterService struct {
@@ -18,20 +22,22 @@ type PorterService struct {
 	baseURL            string
 	httpClient         *http.Client
 	orderRepo          repositories.OrderRepository
+	addressRepo        repositories.AddressRepository
 	porterDeliveryRepo repositories.PorterDeliveryRepository
+	tracking           *OrderTrackingService
+	reconcile          PorterReconcilePolicy
 }
 
-func NewPorterService(orderRepo repositories.OrderRepository, porterDeliveryRepo repositories.PorterDeliveryRepository) *PorterService {
-	apiKey := os.Getenv("PORTER_API_KEY")
-	baseURL := os.Getenv("PORTER_BASE_URL")
+// PorterReconcilePolicy controls polling Porter for deliveries whose webhooks were missed
+type PorterReconcilePolicy struct {
+	StaleAfter time.Duration // time without an update before a delivery is polled
+	StuckAfter time.Duration // time in "created" without a rider before ops are alerted
+}
 
-	if apiKey == "" {
-		apiKey = "O8AJTXXXXXXXXXX-UA1LiA" // Default test key
-	}
-	if baseURL == "" {
-		baseURL = "https://pfe-apigw-uat.porter.in" // Default test URL
-	}
+// porterReconcileBatch caps the deliveries polled per reconciliation run
+const porterReconcileBatch = 100
 
+func NewPorterService(apiKey, baseURL string, orderRepo repositories.OrderRepository, addressRepo repositories.AddressRepository, porterDeliveryRepo repositories.PorterDeliveryRepository, tracking *OrderTrackingService, reconcile PorterReconcilePolicy) *PorterService {
 	return &PorterService{
 		apiKey:  apiKey,
 		baseURL: baseURL,
@@ -39,7 +45,10 @@ func NewPorterService(orderRepo repositories.OrderRepository, porterDeliveryRepo
 			Timeout: 30 * time.Second,
 		},
 		orderRepo:          orderRepo,
+		addressRepo:        addressRepo,
 		porterDeliveryRepo: porterDeliveryRepo,
+		tracking:           tracking,
+		reconcile:          reconcile,
 	}
 }
 
@@ -310,26 +319,41 @@ func (s *PorterService) makePorterRequest(ctx context.Context, method, url strin
 
 // Business logic methods
 
-// CreateDeliveryOrder creates a Porter delivery order for a food order
-func (s *PorterService) CreateDeliveryOrder(ctx context.Context, order *models.Order, restaurant *models.Restaurant) (*PorterCreateOrderResponse, error) {
-	// First get a quote
+// buildQuoteRequest builds a Porter quote request between two resolved stops
+func (s *PorterService) buildQuoteRequest(order *models.Order, pickup, drop *PorterAddress) *PorterQuoteRequest {
 	quoteReq := &PorterQuoteRequest{}
 
-	// Set pickup details (restaurant) - using mock coordinates for now
-	quoteReq.PickupDetails.Lat = 12.935025018880504
-	quoteReq.PickupDetails.Lng = 77.6092605236106
-
-	// Set drop details (customer)
-	quoteReq.DropDetails.Lat = s.extractLatitude(order.DeliveryFullAddressWithLatLong)
-	quoteReq.DropDetails.Lng = s.extractLongitude(order.DeliveryFullAddressWithLatLong)
+	quoteReq.PickupDetails.Lat = pickup.Lat
+	quoteReq.PickupDetails.Lng = pickup.Lng
+	quoteReq.DropDetails.Lat = drop.Lat
+	quoteReq.DropDetails.Lng = drop.Lng
 
 	// Set customer details
 	quoteReq.Customer.Name = order.CustomerName
 	quoteReq.Customer.Mobile.CountryCode = "+91"
 	quoteReq.Customer.Mobile.Number = s.extractPhoneNumber(order.CustomerContact)
 
+	return quoteReq
+}
+
+// QuoteForOrder gets a Porter quote from the restaurant's pickup location to the order's address
+func (s *PorterService) QuoteForOrder(ctx context.Context, order *models.Order, restaurant *models.Restaurant) (*PorterQuoteResponse, error) {
+	pickup, drop, err := s.resolveStops(ctx, order, restaurant)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetQuote(ctx, s.buildQuoteRequest(order, pickup, drop))
+}
+
+// CreateDeliveryOrder creates a Porter delivery order for a food order
+func (s *PorterService) CreateDeliveryOrder(ctx context.Context, order *models.Order, restaurant *models.Restaurant) (*PorterCreateOrderResponse, error) {
+	pickup, drop, err := s.resolveStops(ctx, order, restaurant)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get quote first
-	quote, err := s.GetQuote(ctx, quoteReq)
+	quote, err := s.GetQuote(ctx, s.buildQuoteRequest(order, pickup, drop))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Porter quote: %v", err)
 	}
@@ -345,43 +369,9 @@ func (s *PorterService) CreateDeliveryOrder(ctx context.Context, order *models.O
 				},
 			},
 		},
-		PickupDetails: PorterAddressDetails{
-			Address: PorterAddress{
-				ApartmentAddress: restaurant.Name,
-				StreetAddress1:   "Restaurant Location", // Mock address
-				StreetAddress2:   fmt.Sprintf("Restaurant - %s", restaurant.Name),
-				Landmark:         "Restaurant Area",
-				City:             "Bengaluru",
-				State:            "Karnataka",
-				Pincode:          "560029",
-				Country:          "India",
-				Lat:              12.935025018880504,
-				Lng:              77.6092605236106,
-				ContactDetails: PorterContactDetails{
-					Name:        restaurant.Name,
-					PhoneNumber: restaurant.ContactNumber,
-				},
-			},
-		},
-		DropDetails: PorterAddressDetails{
-			Address: PorterAddress{
-				ApartmentAddress: s.extractApartmentAddress(order.DeliveryFullAddressWithLatLong),
-				StreetAddress1:   s.extractStreetAddress1(order.DeliveryFullAddressWithLatLong),
-				StreetAddress2:   fmt.Sprintf("Order ID: %s", order.ID.String()),
-				Landmark:         s.extractLandmark(order.DeliveryFullAddressWithLatLong),
-				City:             s.extractCity(order.DeliveryFullAddressWithLatLong),
-				State:            s.extractState(order.DeliveryFullAddressWithLatLong),
-				Pincode:          s.extractPincode(order.DeliveryFullAddressWithLatLong),
-				Country:          "India",
-				Lat:              s.extractLatitude(order.DeliveryFullAddressWithLatLong),
-				Lng:              s.extractLongitude(order.DeliveryFullAddressWithLatLong),
-				ContactDetails: PorterContactDetails{
-					Name:        order.CustomerName,
-					PhoneNumber: order.CustomerContact,
-				},
-			},
-		},
-		AdditionalComments: fmt.Sprintf("Food delivery from %s. Order value: ₹%.2f", restaurant.Name, order.TotalAmount),
+		PickupDetails:      PorterAddressDetails{Address: *pickup},
+		DropDetails:        PorterAddressDetails{Address: *drop},
+		AdditionalComments: fmt.Sprintf("Food delivery from %s. Order value: ₹%s", restaurant.Name, order.TotalAmount),
 	}
 
 	// Create the order
@@ -391,10 +381,6 @@ func (s *PorterService) CreateDeliveryOrder(ctx context.Context, order *models.O
 	}
 
 	// Update our order with Porter details
-	if order.OrderLogs == nil {
-		order.OrderLogs = make(models.JSONB)
-	}
-
 	orderLog := map[string]interface{}{
 		"action":           "porter_order_created",
 		"porter_order_id":  porterOrder.OrderID,
@@ -406,16 +392,19 @@ func (s *PorterService) CreateDeliveryOrder(ctx context.Context, order *models.O
 		"timestamp":        time.Now().Unix(),
 	}
 
-	// Add to existing order logs
-	logs, ok := order.OrderLogs["logs"].([]interface{})
-	if !ok {
-		logs = []interface{}{}
-	}
-	logs = append(logs, orderLog)
-	order.OrderLogs["logs"] = logs
-
-	// Save order updates
-	if err := s.orderRepo.Update(ctx, order); err != nil {
+	// Add to existing order logs and save the order
+	err = updateOrder(ctx, s.orderRepo, order, func(order *models.Order) error {
+		if order.OrderLogs == nil {
+			order.OrderLogs = make(models.JSONB)
+		}
+		logs, ok := order.OrderLogs["logs"].([]interface{})
+		if !ok {
+			logs = []interface{}{}
+		}
+		order.OrderLogs["logs"] = append(logs, orderLog)
+		return nil
+	})
+	if err != nil {
 		// Log error but don't fail the order creation
 		fmt.Printf("Failed to update order with Porter details: %v\n", err)
 	}
@@ -431,6 +420,11 @@ func (s *PorterService) HandleWebhook(ctx context.Context, payload *PorterWebhoo
 		return fmt.Errorf("failed to find Porter delivery with order ID %s: %w", payload.OrderID, err)
 	}
 
+	return s.applyUpdate(ctx, porterDelivery, payload)
+}
+
+// applyUpdate applies a webhook, or a status found by reconciliation, to a Porter delivery and its order
+func (s *PorterService) applyUpdate(ctx context.Context, porterDelivery *models.PorterDelivery, payload *PorterWebhookPayload) error {
 	// Update Porter delivery status and details
 	porterDelivery.Status = payload.Status
 	porterDelivery.UpdatedAt = time.Now()
@@ -439,9 +433,13 @@ func (s *PorterService) HandleWebhook(ctx context.Context, payload *PorterWebhoo
 	switch payload.Status {
 	case "order_accepted":
 		// Update partner details when order is accepted
-		porterDelivery.PartnerName = payload.OrderDetails.DriverDetails.DriverName
-		porterDelivery.PartnerPhoneNumber = payload.OrderDetails.DriverDetails.Mobile
-		porterDelivery.VehicleNumber = payload.OrderDetails.DriverDetails.VehicleNumber
+		if driver := payload.OrderDetails.DriverDetails; driver != nil {
+			porterDelivery.PartnerName = driver.DriverName
+			porterDelivery.PartnerPhoneNumber = driver.Mobile
+			porterDelivery.VehicleNumber = driver.VehicleNumber
+		}
+		porterDelivery.NeedsAttention = false
+		porterDelivery.AttentionReason = ""
 
 		// Update estimated delivery time if provided
 		if payload.OrderDetails.EventTs > 0 {
@@ -451,13 +449,17 @@ func (s *PorterService) HandleWebhook(ctx context.Context, payload *PorterWebhoo
 
 	case "order_start_trip":
 		// Update pickup time when trip starts
-		pickupTime := time.Now()
-		porterDelivery.PickupTime = &pickupTime
+		if porterDelivery.PickupTime == nil {
+			pickupTime := time.Now()
+			porterDelivery.PickupTime = &pickupTime
+		}
 
 	case "order_end_job":
 		// Update delivery completion time when job ends
-		deliveryTime := time.Now()
-		porterDelivery.ActualDeliveryTime = &deliveryTime
+		if porterDelivery.ActualDeliveryTime == nil {
+			deliveryTime := time.Now()
+			porterDelivery.ActualDeliveryTime = &deliveryTime
+		}
 
 	case "order_reopen":
 		// Reset delivery time if order is reopened
@@ -473,123 +475,307 @@ func (s *PorterService) HandleWebhook(ctx context.Context, payload *PorterWebhoo
 		return fmt.Errorf("failed to update Porter delivery: %w", err)
 	}
 
+	// Record the partner's position for tracking and ETA
+	if location := payload.OrderDetails.PartnerLocation; location != nil {
+		if err := s.tracking.RecordPartnerLocation(ctx, porterDelivery.OrderID, "porter", payload.OrderID, payload.Status, location.Lat, location.Long); err != nil {
+			log.Printf("Failed to record partner location for order %s: %v", porterDelivery.OrderID, err)
+		}
+	}
+
 	// Update main order status based on Porter status
-	order, err := s.orderRepo.GetByID(ctx, porterDelivery.OrderID)
+	var (
+		order          *models.Order
+		newOrderStatus string
+		changed        bool
+	)
+	err := retryOnConflict(ctx, func() error {
+		var err error
+		order, err = s.orderRepo.GetByID(ctx, porterDelivery.OrderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+
+		// Map Porter status to order status
+		newOrderStatus = porterStatusToOrderStatus(payload.Status)
+		changed = false
+
+		// Restaurants that require delivery codes keep the order out for delivery until staff
+		// verify the code the Porter rider collected
+		if newOrderStatus == "delivered" && order.OrderStatus != "delivered" && deliveryCodePending(order) {
+			appendOrderLog(order, order.OrderStatus, "Porter reported the order delivered; awaiting delivery code verification")
+			newOrderStatus = ""
+			if err := s.orderRepo.Update(ctx, order); err != nil {
+				return fmt.Errorf("failed to update order: %w", err)
+			}
+			return nil
+		}
+
+		if newOrderStatus != "" && order.OrderStatus != newOrderStatus {
+			previous := order.OrderStatus
+			order.OrderStatus = newOrderStatus
+			changed = true
+			events, err := orderStatusEvents(order, previous)
+			if err != nil {
+				return err
+			}
+			if err := s.orderRepo.UpdateWithEvents(ctx, order, events); err != nil {
+				return fmt.Errorf("failed to update order status: %w", err)
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get order: %w", err)
+		return err
+	}
+	if changed {
+		s.tracking.PublishStatus(ctx, order)
 	}
+	s.tracking.PublishDeliveryStatus(ctx, order.ID, order.RestaurantID, "porter", porterDelivery.PorterOrderID, payload.Status, newOrderStatus)
 
-	// Map Porter status to order status
-	var newOrderStatus string
-	switch payload.Status {
-	case "order_accepted":
-		newOrderStatus = "dispatched" // Delivery partner assigned and heading to pickup
-	case "order_start_trip":
-		newOrderStatus = "dispatched" // Package picked up, heading to customer
-	case "order_end_job":
-		newOrderStatus = "delivered" // Successfully delivered
-	case "order_cancel":
-		newOrderStatus = "cancelled" // Delivery cancelled
-	case "order_reopen":
-		newOrderStatus = "dispatched" // Back in transit
+	return nil
+}
+
+// ReconcileDeliveries polls Porter for active deliveries that have gone quiet, applying any
+// status change whose webhook was lost and syncing fare and rider details. Deliveries still
+// waiting for a rider after StuckAfter are flagged for ops.
+func (s *PorterService) ReconcileDeliveries(ctx context.Context) (synced, flagged int, err error) {
+	now := time.Now()
+	deliveries, err := s.porterDeliveryRepo.GetStale(ctx, now.Add(-s.reconcile.StaleAfter), porterReconcileBatch)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get stale Porter deliveries: %v", err)
 	}
 
-	if newOrderStatus != "" && order.OrderStatus != newOrderStatus {
-		order.OrderStatus = newOrderStatus
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		tracked, err := s.TrackOrder(ctx, delivery.PorterOrderID)
+		if err != nil {
+			log.Printf("Failed to track Porter order %s: %v", delivery.PorterOrderID, err)
+			continue
+		}
+
+		syncTrackedDelivery(delivery, tracked)
+		delivery.ReconciledAt = &now
+
+		if delivery.Status == "created" && !delivery.NeedsAttention && now.Sub(delivery.CreatedAt) > s.reconcile.StuckAfter {
+			delivery.NeedsAttention = true
+			delivery.AttentionReason = fmt.Sprintf("no rider assigned %d minutes after booking", int(now.Sub(delivery.CreatedAt).Minutes()))
+			log.Printf("⚠️ Porter delivery %s for order %s is stuck: %s", delivery.PorterOrderID, delivery.OrderID, delivery.AttentionReason)
+			flagged++
+		}
+
+		status := porterTrackStatus(tracked.Status)
+		if status == "" || status == delivery.Status {
+			if err := s.porterDeliveryRepo.Update(ctx, delivery); err != nil {
+				log.Printf("Failed to update Porter delivery %s: %v", delivery.PorterOrderID, err)
+			}
+			continue
+		}
 
-		if err := s.orderRepo.Update(ctx, order); err != nil {
-			return fmt.Errorf("failed to update order status: %w", err)
+		payload := &PorterWebhookPayload{Status: status, OrderID: delivery.PorterOrderID}
+		if partner := tracked.PartnerInfo; partner != nil {
+			payload.OrderDetails.DriverDetails = &PorterDriverDetails{
+				DriverName:    partner.Name,
+				VehicleNumber: partner.VehicleNumber,
+				Mobile:        partner.Mobile.MobileNumber,
+			}
+			if partner.Location != nil {
+				payload.OrderDetails.PartnerLocation = &PorterLocation{Lat: partner.Location.Lat, Long: partner.Location.Long}
+			}
+		}
+		if err := s.applyUpdate(ctx, delivery, payload); err != nil {
+			log.Printf("Failed to apply reconciled status %s to Porter order %s: %v", status, delivery.PorterOrderID, err)
+			continue
 		}
+		log.Printf("Reconciled Porter order %s to %s", delivery.PorterOrderID, status)
+		synced++
 	}
 
-	return nil
+	return synced, flagged, nil
 }
 
-// Helper methods for address extraction
+// ListNeedingAttention returns deliveries flagged by reconciliation
+func (s *PorterService) ListNeedingAttention(ctx context.Context) ([]models.PorterDelivery, error) {
+	return s.porterDeliveryRepo.GetNeedingAttention(ctx)
+}
 
-func (s *PorterService) extractLatitude(addressData models.JSONB) float64 {
-	if addressData == nil {
-		return 12.947146336879577
+// ResolveAttention clears a delivery's ops flag once it has been handled
+func (s *PorterService) ResolveAttention(ctx context.Context, id uuid.UUID) (*models.PorterDelivery, error) {
+	delivery, err := s.porterDeliveryRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	if lat, ok := addressData["latitude"].(float64); ok {
-		return lat
+	delivery.NeedsAttention = false
+	delivery.AttentionReason = ""
+	if err := s.porterDeliveryRepo.Update(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to update Porter delivery: %v", err)
 	}
-	return 12.947146336879577
+	return delivery, nil
 }
 
-func (s *PorterService) extractLongitude(addressData models.JSONB) float64 {
-	if addressData == nil {
-		return 77.62102993895199
+// syncTrackedDelivery copies fare, rider and timing details from a track response
+func syncTrackedDelivery(delivery *models.PorterDelivery, tracked *PorterTrackOrderResponse) {
+	if fare := tracked.FareDetails.ActualFareDetails; fare != nil {
+		delivery.DeliveryFee = models.Money(fare.MinorAmount)
+	} else if fare := tracked.FareDetails.EstimatedFareDetails; fare != nil && delivery.DeliveryFee == 0 {
+		delivery.DeliveryFee = models.Money(fare.MinorAmount)
 	}
-	if lng, ok := addressData["longitude"].(float64); ok {
-		return lng
+
+	if partner := tracked.PartnerInfo; partner != nil {
+		delivery.PartnerName = partner.Name
+		delivery.PartnerPhoneNumber = partner.Mobile.MobileNumber
+		delivery.VehicleType = partner.VehicleType
+		delivery.VehicleNumber = partner.VehicleNumber
 	}
-	return 77.62102993895199
-}
 
-func (s *PorterService) extractPhoneNumber(contact string) string {
-	// Remove country code if present
-	if len(contact) > 10 && contact[:3] == "+91" {
-		return contact[3:]
+	if ts := tracked.OrderTimings.PickupTime; ts != nil && *ts > 0 {
+		pickupTime := time.Unix(*ts, 0)
+		delivery.PickupTime = &pickupTime
 	}
-	if len(contact) > 10 && contact[:2] == "91" {
-		return contact[2:]
+	if ts := tracked.OrderTimings.OrderEndedTime; ts != nil && *ts > 0 {
+		endedTime := time.Unix(*ts, 0)
+		delivery.ActualDeliveryTime = &endedTime
 	}
-	return contact
 }
 
-func (s *PorterService) extractApartmentAddress(addressData models.JSONB) string {
-	if addressData == nil {
-		return ""
+// porterTrackStatus maps a Porter track API status to the matching webhook status.
+// Open orders have no rider yet and map to nothing.
+func porterTrackStatus(status string) string {
+	switch status {
+	case "accepted":
+		return "order_accepted"
+	case "live":
+		return "order_start_trip"
+	case "ended":
+		return "order_end_job"
+	case "cancelled":
+		return "order_cancel"
 	}
-	if apt, ok := addressData["apartment"].(string); ok {
-		return apt
+	return ""
+}
+
+// porterStatusToOrderStatus maps a Porter webhook status to our order status
+func porterStatusToOrderStatus(status string) string {
+	switch status {
+	case "order_accepted":
+		return "dispatched" // Delivery partner assigned and heading to pickup
+	case "order_start_trip":
+		return "dispatched" // Package picked up, heading to customer
+	case "order_end_job":
+		return "delivered" // Successfully delivered
+	case "order_cancel":
+		return "cancelled" // Delivery cancelled
+	case "order_reopen":
+		return "dispatched" // Back in transit
 	}
 	return ""
 }
 
-func (s *PorterService) extractStreetAddress1(addressData models.JSONB) string {
-	if addressData == nil {
-		return "Street Address"
+// Address resolution
+
+// ErrPorterAddressIncomplete is returned when a pickup or drop point lacks what Porter
+// needs to book a rider. The wrapped message names the record and fields to fix.
+var ErrPorterAddressIncomplete = apperr.Validation("address_incomplete", "address is incomplete for Porter delivery")
+
+// resolveStops loads the restaurant's pickup location and the order's delivery address
+func (s *PorterService) resolveStops(ctx context.Context, order *models.Order, restaurant *models.Restaurant) (*PorterAddress, *PorterAddress, error) {
+	if restaurant.PickupLocationID == nil {
+		return nil, nil, fmt.Errorf("%w: restaurant %s has no pickup location, set pickup_location_id on the restaurant", ErrPorterAddressIncomplete, restaurant.ID)
 	}
-	if addr, ok := addressData["line1"].(string); ok {
-		return addr
+	pickupAddress, err := s.addressRepo.GetByID(ctx, *restaurant.PickupLocationID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: pickup location %s of restaurant %s not found", ErrPorterAddressIncomplete, *restaurant.PickupLocationID, restaurant.ID)
+	}
+	pickup := porterAddress(pickupAddress, PorterContactDetails{
+		Name:        restaurant.Name,
+		PhoneNumber: restaurant.ContactNumber,
+	})
+	pickup.StreetAddress2 = fmt.Sprintf("Restaurant - %s", restaurant.Name)
+	if err := checkPorterAddress(pickup, "pickup location of restaurant "+restaurant.ID.String()); err != nil {
+		return nil, nil, err
 	}
-	return "Street Address"
-}
 
-func (s *PorterService) extractLandmark(addressData interface{}) string {
-	if data, ok := addressData.(models.JSONB); ok {
-		if landmark, ok := data["landmark"].(string); ok {
-			return landmark
-		}
+	if order.AddressID == nil {
+		return nil, nil, fmt.Errorf("%w: order %s has no delivery address", ErrPorterAddressIncomplete, order.ID)
 	}
-	return ""
+	dropAddress, err := s.addressRepo.GetByID(ctx, *order.AddressID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: delivery address %s of order %s not found", ErrPorterAddressIncomplete, *order.AddressID, order.ID)
+	}
+	drop := porterAddress(dropAddress, PorterContactDetails{
+		Name:        order.CustomerName,
+		PhoneNumber: order.CustomerContact,
+	})
+	drop.StreetAddress2 = fmt.Sprintf("Order ID: %s", order.ID.String())
+	if err := checkPorterAddress(drop, "delivery address of order "+order.ID.String()); err != nil {
+		return nil, nil, err
+	}
+
+	return &pickup, &drop, nil
 }
 
-func (s *PorterService) extractCity(addressData interface{}) string {
-	if data, ok := addressData.(models.JSONB); ok {
-		if city, ok := data["city"].(string); ok {
-			return city
-		}
+// porterAddress maps a stored address onto Porter's address shape
+func porterAddress(address *models.Address, contact PorterContactDetails) PorterAddress {
+	street := address.AddressLine2
+	if street == "" {
+		street = address.FormattedAddress
+	}
+	country := address.Country
+	if country == "" {
+		country = "India"
+	}
+
+	return PorterAddress{
+		ApartmentAddress: address.AddressLine1,
+		StreetAddress1:   street,
+		City:             address.City,
+		State:            address.State,
+		Pincode:          address.PinCode,
+		Country:          country,
+		Lat:              address.Latitude,
+		Lng:              address.Longitude,
+		ContactDetails:   contact,
 	}
-	return "Bengaluru"
 }
 
-func (s *PorterService) extractState(addressData interface{}) string {
-	if data, ok := addressData.(models.JSONB); ok {
-		if state, ok := data["state"].(string); ok {
-			return state
-		}
+// checkPorterAddress lists every mandatory field missing from an address
+func checkPorterAddress(address PorterAddress, what string) error {
+	var missing []string
+	if address.ApartmentAddress == "" {
+		missing = append(missing, "address_line1")
+	}
+	if address.City == "" {
+		missing = append(missing, "city")
+	}
+	if address.State == "" {
+		missing = append(missing, "state")
+	}
+	if address.Pincode == "" {
+		missing = append(missing, "pin_code")
 	}
-	return "Karnataka"
+	if address.Lat == 0 && address.Lng == 0 {
+		missing = append(missing, "latitude/longitude")
+	}
+	if address.ContactDetails.Name == "" {
+		missing = append(missing, "contact name")
+	}
+	if address.ContactDetails.PhoneNumber == "" {
+		missing = append(missing, "contact number")
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s is missing %s", ErrPorterAddressIncomplete, what, strings.Join(missing, ", "))
+	}
+	return nil
 }
 
-func (s *PorterService) extractPincode(addressData interface{}) string {
-	if data, ok := addressData.(models.JSONB); ok {
-		if pincode, ok := data["pincode"].(string); ok {
-			return pincode
-		}
+func (s *PorterService) extractPhoneNumber(contact string) string {
+	// Remove country code if present
+	if len(contact) > 10 && contact[:3] == "+91" {
+		return contact[3:]
 	}
-	return "560029"
+	if len(contact) > 10 && contact[:2] == "91" {
+		return contact[2:]
+	}
+	return contact
 }
@@ -0,0 +1,461 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/jobs"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	PriceChangeScheduled  = "scheduled"
+	PriceChangeApplied    = "applied"
+	PriceChangeReverted   = "reverted"
+	PriceChangeSuperseded = "superseded" // the price was changed by hand meanwhile, so it was left alone at the end
+	PriceChangeCancelled  = "cancelled"
+	PriceChangeFailed     = "failed"
+
+	JobPriceSchedules = "prices:apply_schedules"
+
+	priceScheduleInterval = time.Minute
+)
+
+var (
+	ErrPriceScheduleInvalid  = apperr.Validation("price_schedule_invalid", "invalid scheduled price change")
+	ErrPriceScheduleNotFound = apperr.NotFound("price_schedule_not_found", "scheduled price change not found")
+	ErrPriceScheduleOverlap  = apperr.Conflict("price_schedule_overlap", "the product already has a price change scheduled then")
+	ErrPriceScheduleState    = apperr.Conflict("price_schedule_state", "the price change can no longer be cancelled")
+)
+
+var priceChangeStatuses = map[string]bool{
+	PriceChangeScheduled:  true,
+	PriceChangeApplied:    true,
+	PriceChangeReverted:   true,
+	PriceChangeSuperseded: true,
+	PriceChangeCancelled:  true,
+	PriceChangeFailed:     true,
+}
+
+type SchedulePriceRequest struct {
+	ProductID     string                  `json:"product_id" binding:"required"`
+	Price         models.Money            `json:"price" binding:"required,gt=0"`
+	DiscountPrice *models.Money           `json:"discount_price"`
+	VariantPrices map[string]models.Money `json:"variant_prices"` // variant ID -> price
+	StartsAt      time.Time               `json:"starts_at" binding:"required"`
+	EndsAt        *time.Time              `json:"ends_at"` // prices go back at this time; omitted to keep them
+	Note          string                  `json:"note"`
+}
+
+type PriceHistoryListResponse struct {
+	History []models.PriceHistory `json:"history"`
+	Total   int64                 `json:"total"`
+	Page    int                   `json:"page"`
+	Limit   int                   `json:"limit"`
+}
+
+type PriceScheduleListResponse struct {
+	Schedules []models.ScheduledPriceChange `json:"schedules"`
+	Total     int64                         `json:"total"`
+	Page      int                           `json:"page"`
+	Limit     int                           `json:"limit"`
+}
+
+// PriceScheduleService keeps products' price history and applies price changes scheduled
+// ahead, such as weekend pricing, putting the old prices back when they end
+type PriceScheduleService struct {
+	scheduleRepo   repositories.PriceScheduleRepository
+	historyRepo    repositories.PriceHistoryRepository
+	productRepo    repositories.ProductRepository
+	productService *ProductService
+	queue          *jobs.Queue
+	stopChan       chan bool
+	isRunning      bool
+}
+
+func NewPriceScheduleService(
+	scheduleRepo repositories.PriceScheduleRepository,
+	historyRepo repositories.PriceHistoryRepository,
+	productRepo repositories.ProductRepository,
+	productService *ProductService,
+) *PriceScheduleService {
+	return &PriceScheduleService{
+		scheduleRepo:   scheduleRepo,
+		historyRepo:    historyRepo,
+		productRepo:    productRepo,
+		productService: productService,
+		stopChan:       make(chan bool),
+	}
+}
+
+// RegisterJobs registers the scheduled price job handler and the queue the ticker uses
+func (s *PriceScheduleService) RegisterJobs(queue *jobs.Queue) {
+	s.queue = queue
+	queue.Handle(JobPriceSchedules, func(ctx context.Context, job *jobs.Job) error {
+		_, err := s.ApplyDue(ctx)
+		return err
+	})
+}
+
+// Start queues the scheduled price job every minute
+func (s *PriceScheduleService) Start() error {
+	if s.isRunning {
+		return fmt.Errorf("price schedule service is already running")
+	}
+
+	s.isRunning = true
+	go s.runTicker()
+
+	log.Println("🏷️ Scheduled price changes: Every minute")
+	return nil
+}
+
+// Stop stops queueing the scheduled price job
+func (s *PriceScheduleService) Stop() {
+	if !s.isRunning {
+		return
+	}
+
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+func (s *PriceScheduleService) runTicker() {
+	ticker := time.NewTicker(priceScheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Changes are claimed by status before the product is touched, so a run queued by
+			// every instance applies each change once
+			if _, err := s.queue.Enqueue(context.Background(), JobPriceSchedules, nil, jobs.Timeout(5*time.Minute)); err != nil {
+				log.Printf("Failed to queue %s: %v", JobPriceSchedules, err)
+			}
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// GetPriceHistory lists a restaurant product's price changes, newest first
+func (s *PriceScheduleService) GetPriceHistory(ctx context.Context, restaurantID, productID string, page, limit int) (*PriceHistoryListResponse, error) {
+	product, err := s.restaurantProduct(ctx, restaurantID, productID)
+	if err != nil {
+		return nil, err
+	}
+	page, limit = normalizePage(page, limit)
+
+	history, total, err := s.historyRepo.GetByProductID(ctx, product.ID, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load price history: %v", err)
+	}
+
+	return &PriceHistoryListResponse{
+		History: history,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
+
+// SchedulePriceChange schedules new prices for a restaurant product from a future time,
+// optionally until an end when the prices in effect at the start come back. A product has one
+// change scheduled at a time.
+func (s *PriceScheduleService) SchedulePriceChange(ctx context.Context, restaurantID, userID string, req *SchedulePriceRequest) (*models.ScheduledPriceChange, error) {
+	product, err := s.restaurantProduct(ctx, restaurantID, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.StartsAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: starts_at must be in the future", ErrPriceScheduleInvalid)
+	}
+	if req.EndsAt != nil && !req.EndsAt.After(req.StartsAt) {
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at", ErrPriceScheduleInvalid)
+	}
+	if req.DiscountPrice != nil && (*req.DiscountPrice <= 0 || *req.DiscountPrice >= req.Price) {
+		return nil, fmt.Errorf("%w: discount_price must be above zero and below price", ErrPriceScheduleInvalid)
+	}
+	current := product.PriceSnapshot()
+	for variantID, price := range req.VariantPrices {
+		if _, ok := current.Variants[variantID]; !ok {
+			return nil, fmt.Errorf("%w: variant %s not found", ErrPriceScheduleInvalid, variantID)
+		}
+		if price <= 0 {
+			return nil, fmt.Errorf("%w: variant %s price must be positive", ErrPriceScheduleInvalid, variantID)
+		}
+	}
+
+	overlaps, err := s.scheduleRepo.HasOverlap(ctx, product.ID, req.StartsAt, req.EndsAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check scheduled price changes: %v", err)
+	}
+	if overlaps {
+		return nil, ErrPriceScheduleOverlap
+	}
+
+	change := &models.ScheduledPriceChange{
+		ProductID:    product.ID,
+		RestaurantID: restaurantID,
+		Prices: models.PriceSnapshot{
+			Price:         req.Price,
+			DiscountPrice: req.DiscountPrice,
+			Variants:      req.VariantPrices,
+		},
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Status:    PriceChangeScheduled,
+		Note:      strings.TrimSpace(req.Note),
+		CreatedBy: userID,
+	}
+	if err := s.scheduleRepo.Create(ctx, change); err != nil {
+		return nil, fmt.Errorf("failed to schedule price change: %v", err)
+	}
+	return change, nil
+}
+
+// ListSchedules lists the restaurant's scheduled price changes by start time, of one product
+// and in one status when set
+func (s *PriceScheduleService) ListSchedules(ctx context.Context, restaurantID, productID, status string, page, limit int) (*PriceScheduleListResponse, error) {
+	if status != "" && !priceChangeStatuses[status] {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrPriceScheduleInvalid, status)
+	}
+	var productObjectID *primitive.ObjectID
+	if productID != "" {
+		id, err := primitive.ObjectIDFromHex(productID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid product ID", ErrPriceScheduleInvalid)
+		}
+		productObjectID = &id
+	}
+	page, limit = normalizePage(page, limit)
+
+	schedules, total, err := s.scheduleRepo.GetByRestaurant(ctx, restaurantID, productObjectID, status, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled price changes: %v", err)
+	}
+
+	return &PriceScheduleListResponse{
+		Schedules: schedules,
+		Total:     total,
+		Page:      page,
+		Limit:     limit,
+	}, nil
+}
+
+// CancelSchedule cancels a change that has not started, or ends one in effect now, putting
+// the earlier prices back
+func (s *PriceScheduleService) CancelSchedule(ctx context.Context, restaurantID, scheduleID string) (*models.ScheduledPriceChange, error) {
+	id, err := primitive.ObjectIDFromHex(scheduleID)
+	if err != nil {
+		return nil, ErrPriceScheduleNotFound
+	}
+	change, err := s.scheduleRepo.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && change.RestaurantID != restaurantID) {
+		return nil, ErrPriceScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled price change: %v", err)
+	}
+
+	now := time.Now()
+	switch change.Status {
+	case PriceChangeScheduled:
+		change.Status = PriceChangeCancelled
+		change.EndedAt = &now
+		saved, err := s.scheduleRepo.Save(ctx, change, PriceChangeScheduled)
+		if err != nil {
+			return nil, fmt.Errorf("failed to cancel scheduled price change: %v", err)
+		}
+		if !saved {
+			return nil, ErrPriceScheduleState
+		}
+	case PriceChangeApplied:
+		ended, err := s.revert(ctx, change, now)
+		if err != nil {
+			return nil, err
+		}
+		if !ended {
+			return nil, ErrPriceScheduleState
+		}
+	default:
+		return nil, ErrPriceScheduleState
+	}
+	return change, nil
+}
+
+// ApplyDue applies the changes whose start has come and reverts those whose end has, and
+// drops was prices past their window. It returns how many changes it moved on.
+func (s *PriceScheduleService) ApplyDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	if _, err := s.productRepo.ClearExpiredWasPrices(ctx, now); err != nil {
+		log.Printf("Failed to clear expired was prices: %v", err)
+	}
+
+	due, err := s.scheduleRepo.GetDue(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load due price changes: %v", err)
+	}
+
+	moved := 0
+	for i := range due {
+		change := &due[i]
+		var done bool
+		if change.Status == PriceChangeScheduled {
+			done, err = s.apply(ctx, change, now)
+		} else {
+			done, err = s.revert(ctx, change, now)
+		}
+		if err != nil {
+			log.Printf("Failed to run scheduled price change %s: %v", change.ID.Hex(), err)
+			continue
+		}
+		if done {
+			moved++
+		}
+	}
+	if moved > 0 {
+		log.Printf("🏷️ Moved on %d scheduled price changes", moved)
+	}
+	return moved, nil
+}
+
+// apply switches the product to the change's prices, keeping the prices it replaces. A change
+// that ended before it could be applied fails.
+func (s *PriceScheduleService) apply(ctx context.Context, change *models.ScheduledPriceChange, now time.Time) (bool, error) {
+	product, err := s.productRepo.GetByID(ctx, change.ProductID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return s.fail(ctx, change, PriceChangeScheduled, "the product was deleted", now)
+	}
+	if err != nil {
+		return false, err
+	}
+	if change.EndsAt != nil && !change.EndsAt.After(now) {
+		return s.fail(ctx, change, PriceChangeScheduled, "the change ended before it could be applied", now)
+	}
+
+	before := product.PriceSnapshot()
+	change.Status = PriceChangeApplied
+	change.Previous = &before
+	change.AppliedAt = &now
+	claimed, err := s.scheduleRepo.Save(ctx, change, PriceChangeScheduled)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	setProductPrices(product, mergePrices(before, change.Prices))
+	if err := s.productService.updatePricedProductFor(ctx, product, before, PriceSourceScheduled, change.CreatedBy, &change.ID); err != nil {
+		return s.fail(ctx, change, PriceChangeApplied, err.Error(), now)
+	}
+	s.clearProductCaches(ctx, product)
+	return true, nil
+}
+
+// revert puts back the prices a change replaced, unless the product's prices were changed by
+// hand meanwhile, which are left alone
+func (s *PriceScheduleService) revert(ctx context.Context, change *models.ScheduledPriceChange, now time.Time) (bool, error) {
+	product, err := s.productRepo.GetByID(ctx, change.ProductID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return s.fail(ctx, change, PriceChangeApplied, "the product was deleted", now)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	current := product.PriceSnapshot()
+	change.Status = PriceChangeReverted
+	if change.Previous == nil || !current.Equal(mergePrices(*change.Previous, change.Prices)) {
+		change.Status = PriceChangeSuperseded
+	}
+	change.EndedAt = &now
+	claimed, err := s.scheduleRepo.Save(ctx, change, PriceChangeApplied)
+	if err != nil || !claimed {
+		return false, err
+	}
+	if change.Status == PriceChangeSuperseded {
+		return true, nil
+	}
+
+	setProductPrices(product, *change.Previous)
+	if err := s.productService.updatePricedProductFor(ctx, product, current, PriceSourceScheduled, change.CreatedBy, &change.ID); err != nil {
+		return s.fail(ctx, change, PriceChangeReverted, err.Error(), now)
+	}
+	s.clearProductCaches(ctx, product)
+	return true, nil
+}
+
+func (s *PriceScheduleService) fail(ctx context.Context, change *models.ScheduledPriceChange, fromStatus, reason string, now time.Time) (bool, error) {
+	change.Status = PriceChangeFailed
+	change.FailureReason = reason
+	change.EndedAt = &now
+	saved, err := s.scheduleRepo.Save(ctx, change, fromStatus)
+	if err != nil {
+		return false, fmt.Errorf("failed to save scheduled price change: %v", err)
+	}
+	return saved, nil
+}
+
+func (s *PriceScheduleService) clearProductCaches(ctx context.Context, product *models.Product) {
+	s.productService.clearProductCache(product.RestaurantID)
+	s.productService.cache.InvalidateTags(ctx, productCartsTag(product.ID.Hex()))
+}
+
+// restaurantProduct loads a product of the restaurant
+func (s *PriceScheduleService) restaurantProduct(ctx context.Context, restaurantID, productID string) (*models.Product, error) {
+	id, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid product ID", ErrPriceScheduleInvalid)
+	}
+	product, err := s.productRepo.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && product.RestaurantID != restaurantID) {
+		return nil, fmt.Errorf("%w: product not found", ErrPriceScheduleInvalid)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %v", err)
+	}
+	return product, nil
+}
+
+// mergePrices is base with a change's prices over it: its price and discount price, and the
+// variant prices it sets
+func mergePrices(base, prices models.PriceSnapshot) models.PriceSnapshot {
+	merged := models.PriceSnapshot{
+		Price:         prices.Price,
+		DiscountPrice: prices.DiscountPrice,
+	}
+	if len(base.Variants) > 0 {
+		merged.Variants = make(map[string]models.Money, len(base.Variants))
+		for id, price := range base.Variants {
+			merged.Variants[id] = price
+			if changed, ok := prices.Variants[id]; ok {
+				merged.Variants[id] = changed
+			}
+		}
+	}
+	return merged
+}
+
+// setProductPrices gives the product the snapshot's prices; variants not in it keep theirs
+func setProductPrices(product *models.Product, prices models.PriceSnapshot) {
+	product.Price = prices.Price
+	product.DiscountPrice = nil
+	if prices.DiscountPrice != nil {
+		discount := *prices.DiscountPrice
+		product.DiscountPrice = &discount
+	}
+	for i := range product.Variants {
+		if price, ok := prices.Variants[product.Variants[i].ID.Hex()]; ok {
+			product.Variants[i].Price = price
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/cache"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Pricing rule types
+const (
+	PricingPlatformFee   = "platform_fee"
+	PricingSmallOrderFee = "small_order_fee"
+	PricingDeliverySurge = "delivery_surge"
+	PricingLateNightFee  = "late_night_fee"
+
+	// PricingConditionRain limits a surge to while rain mode is on
+	PricingConditionRain = "rain"
+)
+
+var (
+	ErrPricingRuleInvalid  = apperr.Validation("pricing_rule_invalid", "invalid pricing rule")
+	ErrPricingRuleNotFound = apperr.NotFound("pricing_rule_not_found", "pricing rule not found")
+)
+
+type PricingRuleRequest struct {
+	RestaurantID  *string      `json:"restaurant_id"` // omitted for a platform-wide rule
+	Name          string       `json:"name" binding:"required"`
+	RuleType      string       `json:"rule_type" binding:"required,oneof=platform_fee small_order_fee delivery_surge late_night_fee"`
+	Amount        models.Money `json:"amount" binding:"gte=0"`
+	Multiplier    float64      `json:"multiplier" binding:"gte=0"`
+	BelowSubtotal models.Money `json:"below_subtotal" binding:"gte=0"`
+	Condition     string       `json:"condition" binding:"omitempty,oneof=rain"`
+	StartTime     string       `json:"start_time"` // HH:MM
+	EndTime       string       `json:"end_time"`   // HH:MM
+	Days          []string     `json:"days"`
+	ValidFrom     *time.Time   `json:"valid_from"`
+	ValidUntil    *time.Time   `json:"valid_until"`
+	IsActive      *bool        `json:"is_active"` // defaults to true
+}
+
+type RainModeRequest struct {
+	RestaurantID    string `json:"restaurant_id"` // empty applies platform-wide
+	DurationMinutes int    `json:"duration_minutes" binding:"required,gt=0"`
+}
+
+// PricingCharge is a pricing rule applied to a bill
+type PricingCharge struct {
+	RuleID     string       `json:"rule_id"`
+	Name       string       `json:"name"`
+	RuleType   string       `json:"rule_type"`
+	Scope      string       `json:"scope"` // restaurant, platform
+	Amount     models.Money `json:"amount,omitempty"`
+	Multiplier float64      `json:"multiplier,omitempty"`
+}
+
+// PricingResult is what the pricing rules add to a bill
+type PricingResult struct {
+	PlatformFee     *models.Money   `json:"platform_fee,omitempty"` // replaces the tax configuration's platform fee
+	SmallOrderFee   models.Money    `json:"small_order_fee"`
+	LateNightFee    models.Money    `json:"late_night_fee"`
+	SurgeMultiplier float64         `json:"surge_multiplier"` // 1 without a surge rule
+	RainMode        bool            `json:"rain_mode"`
+	Charges         []PricingCharge `json:"charges"`
+	EvaluatedAt     time.Time       `json:"evaluated_at"`
+}
+
+// PricingService manages the fee and surge rules added to bills, platform-wide or per restaurant
+type PricingService struct {
+	ruleRepo       repositories.PricingRuleRepository
+	restaurantRepo repositories.RestaurantRepository
+	cache          *cache.RedisCache
+}
+
+func NewPricingService(
+	ruleRepo repositories.PricingRuleRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	cache *cache.RedisCache,
+) *PricingService {
+	return &PricingService{
+		ruleRepo:       ruleRepo,
+		restaurantRepo: restaurantRepo,
+		cache:          cache,
+	}
+}
+
+func rainModeKey(restaurantID string) string {
+	if restaurantID == "" {
+		return "pricing_rain:global"
+	}
+	return "pricing_rain:" + restaurantID
+}
+
+// ListRules returns the rules of a restaurant, or every rule when no restaurant is given
+func (s *PricingService) ListRules(ctx context.Context, restaurantID, ruleType string) ([]models.PricingRule, error) {
+	var restaurantUUID *uuid.UUID
+	if restaurantID != "" {
+		id, err := uuid.Parse(restaurantID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid restaurant ID", ErrPricingRuleInvalid)
+		}
+		restaurantUUID = &id
+	}
+
+	rules, err := s.ruleRepo.List(ctx, restaurantUUID, ruleType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pricing rules: %v", err)
+	}
+	return rules, nil
+}
+
+func (s *PricingService) CreateRule(ctx context.Context, adminID string, req *PricingRuleRequest) (*models.PricingRule, error) {
+	rule := &models.PricingRule{CreatedAt: time.Now()}
+	if err := s.applyRuleRequest(ctx, rule, req); err != nil {
+		return nil, err
+	}
+	if adminUUID, err := uuid.Parse(adminID); err == nil {
+		rule.CreatedBy = &adminUUID
+	}
+
+	if err := s.ruleRepo.Create(ctx, rule); err != nil {
+		return nil, fmt.Errorf("failed to create pricing rule: %v", err)
+	}
+	return rule, nil
+}
+
+// UpdateRule replaces a rule's settings
+func (s *PricingService) UpdateRule(ctx context.Context, ruleID string, req *PricingRuleRequest) (*models.PricingRule, error) {
+	rule, err := s.getRule(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRuleRequest(ctx, rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.Update(ctx, rule); err != nil {
+		return nil, fmt.Errorf("failed to update pricing rule: %v", err)
+	}
+	return rule, nil
+}
+
+func (s *PricingService) DeleteRule(ctx context.Context, ruleID string) error {
+	rule, err := s.getRule(ctx, ruleID)
+	if err != nil {
+		return err
+	}
+	if err := s.ruleRepo.Delete(ctx, rule.ID); err != nil {
+		return fmt.Errorf("failed to delete pricing rule: %v", err)
+	}
+	return nil
+}
+
+// SetRainMode turns on rain surges for a restaurant, or platform-wide, for a while
+func (s *PricingService) SetRainMode(ctx context.Context, req *RainModeRequest) error {
+	if req.RestaurantID != "" {
+		if _, err := uuid.Parse(req.RestaurantID); err != nil {
+			return errors.New("invalid restaurant ID")
+		}
+	}
+	return s.cache.Set(ctx, rainModeKey(req.RestaurantID), true, time.Duration(req.DurationMinutes)*time.Minute)
+}
+
+// ClearRainMode turns off rain mode for a restaurant, or platform-wide
+func (s *PricingService) ClearRainMode(ctx context.Context, restaurantID string) error {
+	return s.cache.Delete(ctx, rainModeKey(restaurantID))
+}
+
+// Evaluate returns the fees and surge the pricing rules add to a bill for a subtotal from the
+// restaurant now. A restaurant's rules of a type replace the platform-wide rules of that type;
+// of several rules of a type that apply, the largest wins. Rules that cannot be loaded add
+// nothing rather than failing the bill.
+func (s *PricingService) Evaluate(ctx context.Context, restaurantID uuid.UUID, subTotal models.Money) *PricingResult {
+	now := time.Now()
+	result := &PricingResult{
+		SurgeMultiplier: 1,
+		Charges:         []PricingCharge{},
+		EvaluatedAt:     now,
+	}
+
+	rules, err := s.ruleRepo.GetActiveForRestaurant(ctx, restaurantID, now)
+	if err != nil {
+		log.Printf("Failed to load pricing rules for restaurant %s: %v", restaurantID, err)
+		return result
+	}
+	if len(rules) == 0 {
+		return result
+	}
+
+	loc := time.Local
+	if restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantID); err == nil && restaurant.TimeZone != "" {
+		if tz, err := time.LoadLocation(restaurant.TimeZone); err == nil {
+			loc = tz
+		}
+	}
+	local := now.In(loc)
+	result.RainMode = s.rainMode(ctx, restaurantID.String())
+
+	ownTypes := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.RestaurantID != nil {
+			ownTypes[rule.RuleType] = true
+		}
+	}
+
+	chosen := make(map[string]*models.PricingRule)
+	for i := range rules {
+		rule := &rules[i]
+		if rule.RestaurantID == nil && ownTypes[rule.RuleType] {
+			continue
+		}
+		if !pricingRuleApplies(rule, local, subTotal, result.RainMode) {
+			continue
+		}
+		if current, ok := chosen[rule.RuleType]; !ok || pricingRuleValue(rule) > pricingRuleValue(current) {
+			chosen[rule.RuleType] = rule
+		}
+	}
+
+	for _, ruleType := range []string{PricingPlatformFee, PricingSmallOrderFee, PricingLateNightFee, PricingDeliverySurge} {
+		rule, ok := chosen[ruleType]
+		if !ok {
+			continue
+		}
+
+		charge := PricingCharge{
+			RuleID:   rule.ID.String(),
+			Name:     rule.Name,
+			RuleType: rule.RuleType,
+			Scope:    "platform",
+		}
+		if rule.RestaurantID != nil {
+			charge.Scope = "restaurant"
+		}
+
+		switch ruleType {
+		case PricingPlatformFee:
+			fee := rule.Amount
+			result.PlatformFee = &fee
+			charge.Amount = fee
+		case PricingSmallOrderFee:
+			result.SmallOrderFee = rule.Amount
+			charge.Amount = rule.Amount
+		case PricingLateNightFee:
+			result.LateNightFee = rule.Amount
+			charge.Amount = rule.Amount
+		case PricingDeliverySurge:
+			result.SurgeMultiplier = rule.Multiplier
+			charge.Multiplier = rule.Multiplier
+		}
+		result.Charges = append(result.Charges, charge)
+	}
+
+	return result
+}
+
+// ToJSONB converts the result for storage on the order
+func (r *PricingResult) ToJSONB() models.JSONB {
+	charges := make([]interface{}, 0, len(r.Charges))
+	for _, charge := range r.Charges {
+		charges = append(charges, map[string]interface{}{
+			"rule_id":    charge.RuleID,
+			"name":       charge.Name,
+			"rule_type":  charge.RuleType,
+			"scope":      charge.Scope,
+			"amount":     charge.Amount,
+			"multiplier": charge.Multiplier,
+		})
+	}
+
+	data := models.JSONB{
+		"small_order_fee":  r.SmallOrderFee,
+		"late_night_fee":   r.LateNightFee,
+		"surge_multiplier": r.SurgeMultiplier,
+		"rain_mode":        r.RainMode,
+		"charges":          charges,
+		"evaluated_at":     r.EvaluatedAt,
+	}
+	if r.PlatformFee != nil {
+		data["platform_fee"] = *r.PlatformFee
+	}
+	return data
+}
+
+// pricingRuleApplies checks a rule's condition, subtotal threshold, days and daily window at
+// the restaurant's local time
+func pricingRuleApplies(rule *models.PricingRule, local time.Time, subTotal models.Money, rainMode bool) bool {
+	if rule.Condition == PricingConditionRain && !rainMode {
+		return false
+	}
+	if rule.RuleType == PricingSmallOrderFee && subTotal >= rule.BelowSubtotal {
+		return false
+	}
+	return isWithinTimeRestriction(pricingRuleWindow(rule), local)
+}
+
+// pricingRuleWindow is the rule's days and daily window; without times it runs all day
+func pricingRuleWindow(rule *models.PricingRule) *models.TimeRestriction {
+	window := &models.TimeRestriction{StartTime: rule.StartTime, EndTime: rule.EndTime, Days: rule.Days}
+	if window.StartTime == "" {
+		window.StartTime, window.EndTime = "00:00", "23:59"
+	}
+	return window
+}
+
+func pricingRuleValue(rule *models.PricingRule) float64 {
+	if rule.RuleType == PricingDeliverySurge {
+		return rule.Multiplier
+	}
+	return rule.Amount.Float64()
+}
+
+func (s *PricingService) rainMode(ctx context.Context, restaurantID string) bool {
+	for _, key := range []string{rainModeKey(restaurantID), rainModeKey("")} {
+		if on, err := s.cache.Exists(ctx, key); err == nil && on {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *PricingService) getRule(ctx context.Context, ruleID string) (*models.PricingRule, error) {
+	ruleUUID, err := uuid.Parse(ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid rule ID", ErrPricingRuleNotFound)
+	}
+	rule, err := s.ruleRepo.GetByID(ctx, ruleUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrPricingRuleNotFound, ruleID)
+	}
+	return rule, nil
+}
+
+// applyRuleRequest validates a request and copies it onto the rule
+func (s *PricingService) applyRuleRequest(ctx context.Context, rule *models.PricingRule, req *PricingRuleRequest) error {
+	switch req.RuleType {
+	case PricingPlatformFee, PricingSmallOrderFee, PricingLateNightFee:
+		if req.Amount <= 0 {
+			return fmt.Errorf("%w: %s needs an amount", ErrPricingRuleInvalid, req.RuleType)
+		}
+	case PricingDeliverySurge:
+		if req.Multiplier <= 1 {
+			return fmt.Errorf("%w: a delivery surge multiplier must be greater than 1", ErrPricingRuleInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: unknown rule type %q", ErrPricingRuleInvalid, req.RuleType)
+	}
+	if req.RuleType == PricingSmallOrderFee && req.BelowSubtotal <= 0 {
+		return fmt.Errorf("%w: a small-order fee needs below_subtotal", ErrPricingRuleInvalid)
+	}
+	if req.Condition == PricingConditionRain && req.RuleType != PricingDeliverySurge {
+		return fmt.Errorf("%w: only delivery surges can depend on rain", ErrPricingRuleInvalid)
+	}
+
+	if (req.StartTime == "") != (req.EndTime == "") {
+		return fmt.Errorf("%w: start_time and end_time go together", ErrPricingRuleInvalid)
+	}
+	if req.StartTime == "" && req.RuleType == PricingLateNightFee {
+		return fmt.Errorf("%w: a late-night fee needs start_time and end_time", ErrPricingRuleInvalid)
+	}
+	window := &models.TimeRestriction{StartTime: req.StartTime, EndTime: req.EndTime, Days: append([]string(nil), req.Days...)}
+	if window.StartTime == "" {
+		window.StartTime, window.EndTime = "00:00", "23:59"
+	}
+	if err := validateTimeRestriction(window); err != nil {
+		return fmt.Errorf("%w: %v", ErrPricingRuleInvalid, err)
+	}
+
+	if req.ValidFrom != nil && req.ValidUntil != nil && !req.ValidUntil.After(*req.ValidFrom) {
+		return fmt.Errorf("%w: valid_until must be after valid_from", ErrPricingRuleInvalid)
+	}
+
+	rule.RestaurantID = nil
+	if req.RestaurantID != nil && *req.RestaurantID != "" {
+		restaurantUUID, err := uuid.Parse(*req.RestaurantID)
+		if err != nil {
+			return fmt.Errorf("%w: invalid restaurant ID", ErrPricingRuleInvalid)
+		}
+		if _, err := s.restaurantRepo.GetByID(ctx, restaurantUUID); err != nil {
+			return fmt.Errorf("%w: restaurant not found", ErrPricingRuleInvalid)
+		}
+		rule.RestaurantID = &restaurantUUID
+	}
+
+	rule.Name = strings.TrimSpace(req.Name)
+	rule.RuleType = req.RuleType
+	rule.Amount = req.Amount
+	rule.Multiplier = req.Multiplier
+	rule.BelowSubtotal = req.BelowSubtotal
+	rule.Condition = req.Condition
+	rule.StartTime = req.StartTime
+	rule.EndTime = req.EndTime
+	rule.Days = models.StringArray(window.Days)
+	rule.ValidFrom = req.ValidFrom
+	rule.ValidUntil = req.ValidUntil
+	rule.IsActive = req.IsActive == nil || *req.IsActive
+	rule.UpdatedAt = time.Now()
+	return nil
+}
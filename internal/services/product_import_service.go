@@ -0,0 +1,515 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/cache"
+	"golang-food-backend/pkg/jobs"
+	"golang-food-backend/pkg/spreadsheet"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ProductImportProcessing = "processing"
+	ProductImportCompleted  = "completed"
+	ProductImportFailed     = "failed"
+
+	productImportMaxRows       = 2000
+	productImportJobTTL        = 24 * time.Hour
+	productImportProgressEvery = 25
+	productExportBatch         = 100
+	productListSeparator       = ";"
+)
+
+// productSheetColumns is the column order used for export and the accepted headers for import
+var productSheetColumns = []string{
+	"name", "description", "category", "price", "discount_price", "preparation_time",
+	"tags", "image_urls", "video_url", "is_available", "initial_stock", "min_stock_level",
+	"diet_type", "allergens", "spice_level",
+}
+
+type ProductImportService struct {
+	productService *ProductService
+	productRepo    repositories.ProductRepository
+	categoryRepo   repositories.ProductCategoryRepository
+	inventoryRepo  repositories.InventoryRepository
+	cache          *cache.RedisCache
+	queue          *jobs.Queue
+}
+
+func NewProductImportService(
+	productService *ProductService,
+	productRepo repositories.ProductRepository,
+	categoryRepo repositories.ProductCategoryRepository,
+	inventoryRepo repositories.InventoryRepository,
+	cache *cache.RedisCache,
+) *ProductImportService {
+	return &ProductImportService{
+		productService: productService,
+		productRepo:    productRepo,
+		categoryRepo:   categoryRepo,
+		inventoryRepo:  inventoryRepo,
+		cache:          cache,
+	}
+}
+
+// ImportRowError describes why a spreadsheet row was rejected. Row is the 1-based spreadsheet row.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ProductImportJob tracks a background import; clients poll it for progress
+type ProductImportJob struct {
+	ID                string           `json:"id"`
+	RestaurantID      string           `json:"restaurant_id"`
+	FileName          string           `json:"file_name"`
+	Status            string           `json:"status"`
+	TotalRows         int              `json:"total_rows"`
+	ValidRows         int              `json:"valid_rows"`
+	Processed         int              `json:"processed"`
+	Created           int              `json:"created"`
+	Failed            int              `json:"failed"`
+	CreatedCategories []string         `json:"created_categories"`
+	Errors            []ImportRowError `json:"errors"`
+	StartedAt         time.Time        `json:"started_at"`
+	CompletedAt       *time.Time       `json:"completed_at,omitempty"`
+}
+
+type importRow struct {
+	Row          int                  `json:"row"`
+	CategoryName string               `json:"category_name"`
+	Available    bool                 `json:"available"`
+	Request      CreateProductRequest `json:"request"`
+}
+
+// JobProductImport creates the valid rows of an import in the background
+const JobProductImport = "product:import"
+
+type productImportPayload struct {
+	JobID string      `json:"job_id"`
+	Rows  []importRow `json:"rows"`
+}
+
+func productImportJobKey(jobID string) string {
+	return "product_import:" + jobID
+}
+
+// StartImport validates every row of a CSV/XLSX file up front, then creates the valid
+// products in the background. Rows with errors are reported and skipped.
+func (s *ProductImportService) StartImport(ctx context.Context, restaurantID, fileName string, data []byte) (*ProductImportJob, error) {
+	format, err := spreadsheet.FormatFromFilename(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := spreadsheet.Read(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	if len(rows) < 2 {
+		return nil, errors.New("file has no product rows")
+	}
+	if len(rows)-1 > productImportMaxRows {
+		return nil, fmt.Errorf("file has more than %d product rows", productImportMaxRows)
+	}
+
+	columns, err := parseProductHeader(rows[0])
+	if err != nil {
+		return nil, err
+	}
+
+	job := &ProductImportJob{
+		ID:                uuid.New().String(),
+		RestaurantID:      restaurantID,
+		FileName:          fileName,
+		Status:            ProductImportProcessing,
+		CreatedCategories: []string{},
+		Errors:            []ImportRowError{},
+		StartedAt:         time.Now(),
+	}
+
+	var valid []importRow
+	for i, values := range rows[1:] {
+		if isBlankRow(values) {
+			continue
+		}
+		job.TotalRows++
+
+		row, rowErrors := parseProductRow(i+2, columns, values)
+		if len(rowErrors) > 0 {
+			job.Errors = append(job.Errors, rowErrors...)
+			job.Failed++
+			continue
+		}
+		valid = append(valid, row)
+	}
+	job.ValidRows = len(valid)
+	job.Processed = job.Failed
+
+	if len(valid) == 0 {
+		s.finishImport(ctx, job, ProductImportFailed)
+		return job, nil
+	}
+
+	if err := s.saveImportJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to start import: %v", err)
+	}
+
+	// Imports are not retried: a second attempt would create the products already imported
+	payload := productImportPayload{JobID: job.ID, Rows: valid}
+	if _, err := s.queue.Enqueue(ctx, JobProductImport, payload, jobs.MaxAttempts(1), jobs.Timeout(30*time.Minute)); err != nil {
+		job.Errors = append(job.Errors, ImportRowError{Message: "failed to queue import"})
+		s.finishImport(ctx, job, ProductImportFailed)
+		return nil, fmt.Errorf("failed to start import: %v", err)
+	}
+
+	return job, nil
+}
+
+// RegisterJobs registers the import job handler and the queue imports are started on
+func (s *ProductImportService) RegisterJobs(queue *jobs.Queue) {
+	s.queue = queue
+	queue.Handle(JobProductImport, s.handleImportJob)
+}
+
+func (s *ProductImportService) handleImportJob(ctx context.Context, queued *jobs.Job) error {
+	var payload productImportPayload
+	if err := queued.Decode(&payload); err != nil {
+		return err
+	}
+
+	var job ProductImportJob
+	if err := s.cache.Get(ctx, productImportJobKey(payload.JobID), &job); err != nil {
+		return fmt.Errorf("import job %s not found: %v", payload.JobID, err)
+	}
+	s.runImport(ctx, &job, payload.Rows)
+	return nil
+}
+
+// GetImportJob returns the progress of an import started for the restaurant
+func (s *ProductImportService) GetImportJob(ctx context.Context, restaurantID, jobID string) (*ProductImportJob, error) {
+	var job ProductImportJob
+	if err := s.cache.Get(ctx, productImportJobKey(jobID), &job); err != nil {
+		return nil, errors.New("import job not found")
+	}
+	if job.RestaurantID != restaurantID {
+		return nil, errors.New("import job not found")
+	}
+	return &job, nil
+}
+
+func (s *ProductImportService) runImport(ctx context.Context, job *ProductImportJob, rows []importRow) {
+
+	categories, err := s.categoryRepo.GetByRestaurantID(ctx, job.RestaurantID)
+	if err != nil {
+		log.Printf("Product import %s failed to load categories: %v", job.ID, err)
+		job.Errors = append(job.Errors, ImportRowError{Message: "failed to load categories: " + err.Error()})
+		s.finishImport(ctx, job, ProductImportFailed)
+		return
+	}
+
+	categoryIDs := make(map[string]string, len(categories))
+	for _, category := range categories {
+		categoryIDs[strings.ToLower(category.Name)] = category.ID.Hex()
+	}
+
+	for i, row := range rows {
+		if err := s.importRow(ctx, job, categoryIDs, row); err != nil {
+			job.Errors = append(job.Errors, ImportRowError{Row: row.Row, Message: err.Error()})
+			job.Failed++
+		} else {
+			job.Created++
+		}
+		job.Processed++
+
+		if (i+1)%productImportProgressEvery == 0 {
+			s.saveImportJob(ctx, job)
+		}
+	}
+
+	// Rows marked unavailable were updated after creation; drop any listings cached in between
+	s.productService.clearProductCache(job.RestaurantID)
+
+	status := ProductImportCompleted
+	if job.Created == 0 {
+		status = ProductImportFailed
+	}
+	s.finishImport(ctx, job, status)
+}
+
+// importRow creates one product, creating its category first if the restaurant doesn't have it yet
+func (s *ProductImportService) importRow(ctx context.Context, job *ProductImportJob, categoryIDs map[string]string, row importRow) error {
+	key := strings.ToLower(row.CategoryName)
+	categoryID, ok := categoryIDs[key]
+	if !ok {
+		category := &models.ProductCategory{
+			RestaurantID: job.RestaurantID,
+			Name:         row.CategoryName,
+			SortOrder:    len(categoryIDs),
+			IsActive:     true,
+		}
+		if err := s.categoryRepo.Create(ctx, category); err != nil {
+			return fmt.Errorf("failed to create category %q: %v", row.CategoryName, err)
+		}
+		categoryID = category.ID.Hex()
+		categoryIDs[key] = categoryID
+		job.CreatedCategories = append(job.CreatedCategories, row.CategoryName)
+		s.cache.Delete(ctx, "categories:"+job.RestaurantID)
+	}
+
+	req := row.Request
+	req.CategoryID = categoryID
+
+	// CreateProduct also seeds the inventory record and publishes product_created
+	product, err := s.productService.CreateProduct(ctx, job.RestaurantID, &req)
+	if err != nil {
+		return fmt.Errorf("failed to create product: %v", err)
+	}
+
+	if !row.Available {
+		product.IsAvailable = false
+		if err := s.productRepo.Update(ctx, product); err != nil {
+			return fmt.Errorf("product created but failed to mark unavailable: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *ProductImportService) saveImportJob(ctx context.Context, job *ProductImportJob) error {
+	return s.cache.Set(ctx, productImportJobKey(job.ID), job, productImportJobTTL)
+}
+
+func (s *ProductImportService) finishImport(ctx context.Context, job *ProductImportJob, status string) {
+	now := time.Now()
+	job.Status = status
+	job.CompletedAt = &now
+	if err := s.saveImportJob(ctx, job); err != nil {
+		log.Printf("Failed to save product import %s: %v", job.ID, err)
+	}
+}
+
+// ExportProducts writes all of a restaurant's products, with category names and current
+// stock, in the same layout the importer accepts
+func (s *ProductImportService) ExportProducts(ctx context.Context, restaurantID, format string) ([]byte, error) {
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		return nil, errors.New("format must be csv or xlsx")
+	}
+
+	categories, err := s.categoryRepo.GetByRestaurantID(ctx, restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %v", err)
+	}
+	categoryNames := make(map[string]string, len(categories))
+	for _, category := range categories {
+		categoryNames[category.ID.Hex()] = category.Name
+	}
+
+	rows := [][]string{productSheetColumns}
+	offset := 0
+	for {
+		products, err := s.productRepo.GetByRestaurantID(ctx, restaurantID, models.DietaryFilter{}, productExportBatch, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get products: %v", err)
+		}
+
+		for _, product := range products {
+			discountPrice := ""
+			if product.DiscountPrice != nil {
+				discountPrice = product.DiscountPrice.String()
+			}
+
+			stock, minStock := "", ""
+			if inventory, err := s.inventoryRepo.GetByProductID(ctx, product.ID); err == nil {
+				stock = strconv.Itoa(inventory.Quantity)
+				minStock = strconv.Itoa(inventory.MinStockLevel)
+			}
+
+			rows = append(rows, []string{
+				product.Name,
+				product.Description,
+				categoryNames[product.CategoryID.Hex()],
+				product.Price.String(),
+				discountPrice,
+				strconv.Itoa(product.PreparationTime),
+				strings.Join(product.Tags, productListSeparator),
+				strings.Join(product.ImageUrls, productListSeparator),
+				product.VideoUrl,
+				strconv.FormatBool(product.IsAvailable),
+				stock,
+				minStock,
+				product.DietType,
+				strings.Join(product.Allergens, productListSeparator),
+				strconv.Itoa(product.SpiceLevel),
+			})
+		}
+
+		if len(products) < productExportBatch {
+			break
+		}
+		offset += productExportBatch
+	}
+
+	var buf bytes.Buffer
+	if err := spreadsheet.Write(format, &buf, "Products", rows); err != nil {
+		return nil, fmt.Errorf("failed to write export: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// parseProductHeader maps known column names to their position; name, category and price are required
+func parseProductHeader(header []string) (map[string]int, error) {
+	known := make(map[string]bool, len(productSheetColumns))
+	for _, column := range productSheetColumns {
+		known[column] = true
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.ReplaceAll(name, " ", "_")
+		if known[name] {
+			columns[name] = i
+		}
+	}
+
+	var missing []string
+	for _, required := range []string{"name", "category", "price"} {
+		if _, ok := columns[required]; !ok {
+			missing = append(missing, required)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required columns: %s", strings.Join(missing, ", "))
+	}
+
+	return columns, nil
+}
+
+func parseProductRow(rowNumber int, columns map[string]int, values []string) (importRow, []ImportRowError) {
+	var rowErrors []ImportRowError
+	fail := func(field, message string) {
+		rowErrors = append(rowErrors, ImportRowError{Row: rowNumber, Field: field, Message: message})
+	}
+
+	get := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(values) {
+			return ""
+		}
+		return strings.TrimSpace(values[i])
+	}
+
+	row := importRow{Row: rowNumber, Available: true}
+	req := &row.Request
+
+	if req.Name = get("name"); req.Name == "" {
+		fail("name", "name is required")
+	}
+	if row.CategoryName = get("category"); row.CategoryName == "" {
+		fail("category", "category is required")
+	}
+	req.Description = get("description")
+	req.VideoUrl = get("video_url")
+	req.Tags = splitSheetList(get("tags"))
+	req.ImageUrls = splitSheetList(get("image_urls"))
+
+	if price, err := models.ParseMoney(get("price")); err != nil || price <= 0 {
+		fail("price", "price must be a number greater than 0")
+	} else {
+		req.Price = price
+	}
+
+	if value := get("discount_price"); value != "" {
+		discount, err := models.ParseMoney(value)
+		if err != nil || discount < 0 {
+			fail("discount_price", "discount_price must be a non-negative number")
+		} else if discount >= req.Price && req.Price > 0 {
+			fail("discount_price", "discount_price must be less than price")
+		} else {
+			req.DiscountPrice = &discount
+		}
+	}
+
+	parseInt := func(field string, dest *int) {
+		value := get(field)
+		if value == "" {
+			return
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			fail(field, field+" must be a non-negative whole number")
+			return
+		}
+		*dest = n
+	}
+	parseInt("preparation_time", &req.PreparationTime)
+	parseInt("initial_stock", &req.InitialStock)
+	parseInt("min_stock_level", &req.MinStockLevel)
+	parseInt("spice_level", &req.SpiceLevel)
+	if err := validateSpiceLevel(req.SpiceLevel); err != nil {
+		fail("spice_level", fmt.Sprintf("spice_level must be between 0 and %d", MaxSpiceLevel))
+	}
+
+	if diet, err := normalizeDietType(get("diet_type")); err != nil {
+		fail("diet_type", "diet_type must be veg, non_veg or vegan")
+	} else {
+		req.DietType = diet
+	}
+	if allergens, err := normalizeAllergens(splitSheetList(get("allergens"))); err != nil {
+		fail("allergens", "allergens lists an allergen the menu does not recognise")
+	} else {
+		req.Allergens = allergens
+	}
+
+	if value := get("is_available"); value != "" {
+		available, err := parseSheetBool(value)
+		if err != nil {
+			fail("is_available", "is_available must be true/false or yes/no")
+		} else {
+			row.Available = available
+		}
+	}
+
+	return row, rowErrors
+}
+
+func parseSheetBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "y", "1":
+		return true, nil
+	case "false", "no", "n", "0":
+		return false, nil
+	}
+	return false, errors.New("invalid boolean")
+}
+
+func splitSheetList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, productListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func isBlankRow(values []string) bool {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/apperr"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	OptionDisplayRadio    = "radio"
+	OptionDisplayCheckbox = "checkbox"
+	OptionDisplayDropdown = "dropdown"
+)
+
+var (
+	ErrProductNotFound     = apperr.NotFound("product_not_found", "product not found")
+	ErrOptionGroupsInvalid = apperr.Validation("option_groups_invalid", "invalid option groups")
+	// ErrOptionSelection is returned when a cart line's options break its product's rules
+	ErrOptionSelection = apperr.Validation("option_selection_invalid", "the options chosen do not fit the product's rules")
+)
+
+type SetOptionGroupsRequest struct {
+	OptionGroups []models.ProductOptionGroup `json:"option_groups"` // replaces every group; empty removes them
+}
+
+// SetOptionGroups replaces a product's option groups. Groups and options sent with their ID
+// keep it, so carts holding them stay valid.
+func (s *ProductService) SetOptionGroups(ctx context.Context, productID, restaurantID string, req *SetOptionGroupsRequest) (*models.Product, error) {
+	objectID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid product ID", ErrOptionGroupsInvalid)
+	}
+	product, err := s.productRepo.GetByID(ctx, objectID)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && product.RestaurantID != restaurantID) {
+		return nil, fmt.Errorf("%w: product %s not found", ErrProductNotFound, productID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %v", err)
+	}
+
+	groups := req.OptionGroups
+	if err := prepareOptionGroups(groups); err != nil {
+		return nil, err
+	}
+	if hasRequiredOptions(groups) {
+		combos, err := s.productRepo.GetCombosWithComponent(ctx, product.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check combos: %v", err)
+		}
+		if len(combos) > 0 {
+			return nil, fmt.Errorf("%w: %s is in the combo %s, which cannot choose its options", ErrOptionGroupsInvalid, product.Name, combos[0].Name)
+		}
+	}
+
+	product.OptionGroups = groups
+	if err := s.productRepo.Update(ctx, product); err != nil {
+		return nil, err
+	}
+
+	// Carts priced with the old options are repriced, dropping lines that no longer fit
+	s.clearProductCache(restaurantID)
+	s.cache.InvalidateTags(ctx, productCartsTag(productID))
+
+	return product, nil
+}
+
+// prepareOptionGroups checks each group's rules against its options, gives new groups and
+// options their IDs and fills in the rendering hints
+func prepareOptionGroups(groups []models.ProductOptionGroup) error {
+	seen := make(map[primitive.ObjectID]bool)
+	for i := range groups {
+		group := &groups[i]
+		group.Name = strings.TrimSpace(group.Name)
+		if group.Name == "" {
+			return fmt.Errorf("%w: every group needs a name", ErrOptionGroupsInvalid)
+		}
+		if len(group.Options) == 0 {
+			return fmt.Errorf("%w: %s has no options", ErrOptionGroupsInvalid, group.Name)
+		}
+		if group.MaxSelect == 0 {
+			group.MaxSelect = len(group.Options)
+		}
+		if group.MinSelect < 0 || group.MaxSelect < 1 || group.MinSelect > group.MaxSelect || group.MaxSelect > len(group.Options) {
+			return fmt.Errorf("%w: %s must allow between min_select and max_select of its %d options", ErrOptionGroupsInvalid, group.Name, len(group.Options))
+		}
+
+		defaults := 0
+		for j := range group.Options {
+			option := &group.Options[j]
+			option.Name = strings.TrimSpace(option.Name)
+			if option.Name == "" {
+				return fmt.Errorf("%w: every option of %s needs a name", ErrOptionGroupsInvalid, group.Name)
+			}
+			if option.ID.IsZero() {
+				option.ID = primitive.NewObjectID()
+			}
+			if seen[option.ID] {
+				return fmt.Errorf("%w: option %s appears twice", ErrOptionGroupsInvalid, option.ID.Hex())
+			}
+			seen[option.ID] = true
+			if option.IsDefault {
+				defaults++
+			}
+		}
+		if defaults > group.MaxSelect {
+			return fmt.Errorf("%w: %s has more default options than it allows", ErrOptionGroupsInvalid, group.Name)
+		}
+
+		if group.ID.IsZero() {
+			group.ID = primitive.NewObjectID()
+		}
+		if seen[group.ID] {
+			return fmt.Errorf("%w: group %s appears twice", ErrOptionGroupsInvalid, group.ID.Hex())
+		}
+		seen[group.ID] = true
+
+		switch group.Display {
+		case "":
+			group.Display = OptionDisplayCheckbox
+			if group.MaxSelect == 1 {
+				group.Display = OptionDisplayRadio
+			}
+		case OptionDisplayRadio, OptionDisplayDropdown:
+			if group.MaxSelect != 1 {
+				return fmt.Errorf("%w: %s allows several options, so it cannot show as a %s", ErrOptionGroupsInvalid, group.Name, group.Display)
+			}
+		case OptionDisplayCheckbox:
+		default:
+			return fmt.Errorf("%w: display must be radio, checkbox or dropdown", ErrOptionGroupsInvalid)
+		}
+		group.Required = group.MinSelect > 0
+		group.SelectionHint = optionSelectionHint(group.MinSelect, group.MaxSelect)
+	}
+	return nil
+}
+
+// optionSelectionHint words a group's rule for clients, e.g. "Choose exactly 1"
+func optionSelectionHint(min, max int) string {
+	switch {
+	case min == max:
+		return fmt.Sprintf("Choose exactly %d", min)
+	case min == 0:
+		return fmt.Sprintf("Choose up to %d", max)
+	default:
+		return fmt.Sprintf("Choose %d to %d", min, max)
+	}
+}
+
+func hasRequiredOptions(groups []models.ProductOptionGroup) bool {
+	for _, group := range groups {
+		if group.MinSelect > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// selectOptions checks the chosen options against the product's option groups: each must be
+// an option of one of them that is not sold out, and each group must get between its minimum
+// and maximum. It returns the options in group order with their price deltas.
+func selectOptions(product *models.Product, optionIDs []string) ([]models.SelectedOption, models.Money, error) {
+	chosen := make(map[string]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		chosen[id] = true
+	}
+
+	var selected []models.SelectedOption
+	var delta models.Money
+	for _, group := range product.OptionGroups {
+		count := 0
+		for _, option := range group.Options {
+			id := option.ID.Hex()
+			if !chosen[id] {
+				continue
+			}
+			delete(chosen, id)
+			if option.SoldOut {
+				return nil, 0, fmt.Errorf("%w: %s is sold out", ErrOptionSelection, option.Name)
+			}
+			count++
+			delta += option.PriceDelta
+			selected = append(selected, models.SelectedOption{
+				GroupID:    group.ID.Hex(),
+				GroupName:  group.Name,
+				ID:         id,
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+			})
+		}
+		if count < group.MinSelect || count > group.MaxSelect {
+			return nil, 0, fmt.Errorf("%w: %s of %s: %s", ErrOptionSelection, group.Name, product.Name, strings.ToLower(group.SelectionHint))
+		}
+	}
+	for id := range chosen {
+		return nil, 0, fmt.Errorf("%w: option %s is not available for %s", ErrOptionSelection, id, product.Name)
+	}
+	return selected, delta, nil
+}
@@ -8,15 +8,28 @@ import (
 	"golang-food-backend/internal/repositories"
 	"golang-food-backend/pkg/cache"
 	"golang-food-backend/pkg/messaging"
+	"log"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Sources of a price change
+const (
+	PriceSourceManual         = "manual"
+	PriceSourceScheduled      = "scheduled"
+	PriceSourceBrandSync      = "brand_sync"
+	PriceSourceOutletOverride = "outlet_override"
+
+	// wasPriceWindow is how long a price cut shows the old price struck through
+	wasPriceWindow = 30 * 24 * time.Hour
+)
+
 type ProductService struct {
 	productRepo   repositories.ProductRepository
 	categoryRepo  repositories.ProductCategoryRepository
 	inventoryRepo repositories.InventoryRepository
+	historyRepo   repositories.PriceHistoryRepository
 	cache         *cache.RedisCache
 	kafkaProducer *messaging.KafkaProducer
 	kafkaBrokers  []string
@@ -26,6 +39,7 @@ func NewProductService(
 	productRepo repositories.ProductRepository,
 	categoryRepo repositories.ProductCategoryRepository,
 	inventoryRepo repositories.InventoryRepository,
+	historyRepo repositories.PriceHistoryRepository,
 	cache *cache.RedisCache,
 	kafkaProducer *messaging.KafkaProducer,
 	kafkaBrokers []string,
@@ -34,6 +48,7 @@ func NewProductService(
 		productRepo:   productRepo,
 		categoryRepo:  categoryRepo,
 		inventoryRepo: inventoryRepo,
+		historyRepo:   historyRepo,
 		cache:         cache,
 		kafkaProducer: kafkaProducer,
 		kafkaBrokers:  kafkaBrokers,
@@ -41,18 +56,25 @@ func NewProductService(
 }
 
 type CreateProductRequest struct {
-	Name            string                 `json:"name" binding:"required"`
-	Description     string                 `json:"description"`
-	CategoryID      string                 `json:"category_id" binding:"required"`
-	Price           float64                `json:"price" binding:"required,gt=0"`
-	DiscountPrice   *float64               `json:"discount_price,omitempty"`
-	ImageUrls       []string               `json:"image_urls"`
-	PreparationTime int                    `json:"preparation_time"`
-	Tags            []string               `json:"tags"`
-	VideoUrl        string                 `json:"video_url,omitempty"`
-	NutritionalInfo map[string]interface{} `json:"nutritional_info,omitempty"`
-	InitialStock    int                    `json:"initial_stock"`
-	MinStockLevel   int                    `json:"min_stock_level"`
+	Name            string                      `json:"name" binding:"required"`
+	Description     string                      `json:"description"`
+	CategoryID      string                      `json:"category_id" binding:"required"`
+	Price           models.Money                `json:"price" binding:"required,gt=0"`
+	DiscountPrice   *models.Money               `json:"discount_price,omitempty"`
+	ImageUrls       []string                    `json:"image_urls"`
+	PreparationTime int                         `json:"preparation_time"`
+	Tags            []string                    `json:"tags"`
+	VideoUrl        string                      `json:"video_url,omitempty"`
+	NutritionalInfo map[string]interface{}      `json:"nutritional_info,omitempty"`
+	Variants        []models.ProductVariant     `json:"variants,omitempty"`
+	Addons          []models.ProductAddon       `json:"addons,omitempty"`
+	InitialStock    int                         `json:"initial_stock"`
+	MinStockLevel   int                         `json:"min_stock_level"`
+	Components      []ComboComponentRequest     `json:"components,omitempty" binding:"omitempty,dive"` // makes the product a combo of these items, sold at its price
+	OptionGroups    []models.ProductOptionGroup `json:"option_groups,omitempty"`
+	DietType        string                      `json:"diet_type,omitempty"` // veg, non_veg or vegan
+	Allergens       []string                    `json:"allergens,omitempty"`
+	SpiceLevel      int                         `json:"spice_level"` // 0 (not spicy) to 3 (hot)
 }
 
 func (s *ProductService) CreateProduct(ctx context.Context, restaurantID string, req *CreateProductRequest) (*models.Product, error) {
@@ -71,6 +93,23 @@ func (s *ProductService) CreateProduct(ctx context.Context, restaurantID string,
 		return nil, errors.New("category does not belong to this restaurant")
 	}
 
+	// A combo's stock is its items' stock
+	if len(req.Components) > 0 && (len(req.Variants) > 0 || req.InitialStock > 0) {
+		return nil, fmt.Errorf("%w: a combo cannot have variants or its own stock", ErrComboInvalid)
+	}
+
+	dietType, err := normalizeDietType(req.DietType)
+	if err != nil {
+		return nil, err
+	}
+	allergens, err := normalizeAllergens(req.Allergens)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSpiceLevel(req.SpiceLevel); err != nil {
+		return nil, err
+	}
+
 	// Create product
 	product := &models.Product{
 		RestaurantID:    restaurantID,
@@ -85,6 +124,34 @@ func (s *ProductService) CreateProduct(ctx context.Context, restaurantID string,
 		Tags:            req.Tags,
 		VideoUrl:        req.VideoUrl,
 		NutritionalInfo: req.NutritionalInfo,
+		Variants:        req.Variants,
+		Addons:          req.Addons,
+		OptionGroups:    req.OptionGroups,
+		DietType:        dietType,
+		Allergens:       allergens,
+		SpiceLevel:      req.SpiceLevel,
+	}
+
+	// Variant and addon IDs are what carts reference, so every option needs one
+	for i := range product.Variants {
+		if product.Variants[i].ID.IsZero() {
+			product.Variants[i].ID = primitive.NewObjectID()
+		}
+	}
+	for i := range product.Addons {
+		if product.Addons[i].ID.IsZero() {
+			product.Addons[i].ID = primitive.NewObjectID()
+		}
+	}
+
+	if err := prepareOptionGroups(product.OptionGroups); err != nil {
+		return nil, err
+	}
+	if len(req.Components) > 0 {
+		product.Components, err = comboComponents(ctx, s.productRepo, restaurantID, primitive.NilObjectID, req.Components)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if err := s.productRepo.Create(ctx, product); err != nil {
@@ -100,20 +167,31 @@ func (s *ProductService) CreateProduct(ctx context.Context, restaurantID string,
 		MinStockLevel:    req.MinStockLevel,
 		MaxStockLevel:    req.InitialStock * 2, // Default max stock
 		LastRestocked:    time.Now(),
+		TrackStock:       req.InitialStock > 0,
 	}
 
 	if err := s.inventoryRepo.Create(ctx, inventory); err != nil {
 		return nil, err
 	}
+	if product.IsCombo() {
+		if err := syncCombo(ctx, s.productRepo, s.inventoryRepo, s.cache, product); err != nil {
+			log.Printf("Failed to sync combo %s: %v", product.ID.Hex(), err)
+		}
+	}
 
 	// Send kafka event for product creation
-	event := messaging.InventoryEvent{
-		Type:         "product_created",
+	envelope, err := messaging.Events.NewEnvelope(messaging.EventInventoryChanged, restaurantID, messaging.InventoryChanged{
 		ProductID:    product.ID.Hex(),
-		Quantity:     req.InitialStock,
 		RestaurantID: restaurantID,
+		Change:       "product_created",
+		Available:    req.InitialStock,
+	})
+	if err == nil {
+		err = s.kafkaProducer.PublishEvent(ctx, "inventory_events", s.kafkaBrokers, product.ID.Hex(), envelope)
+	}
+	if err != nil {
+		log.Printf("Failed to publish product_created for product %s: %v", product.ID.Hex(), err)
 	}
-	s.kafkaProducer.SendMessage("inventory_events", s.kafkaBrokers, product.ID.Hex(), event)
 
 	// Clear cache
 	s.clearProductCache(restaurantID)
@@ -121,28 +199,28 @@ func (s *ProductService) CreateProduct(ctx context.Context, restaurantID string,
 	return product, nil
 }
 
-func (s *ProductService) GetProductsByRestaurant(ctx context.Context, restaurantID string, limit, offset int) ([]models.Product, error) {
+func (s *ProductService) GetProductsByRestaurant(ctx context.Context, restaurantID string, diet models.DietaryFilter, limit, offset int) ([]models.Product, error) {
 	// Try cache first
-	cacheKey := fmt.Sprintf("products:%s:%d:%d", restaurantID, limit, offset)
+	cacheKey := fmt.Sprintf("products:%s:%s:%d:%d", restaurantID, diet.Key(), limit, offset)
 	var cachedProducts []models.Product
 	if err := s.cache.Get(ctx, cacheKey, &cachedProducts); err == nil {
 		return cachedProducts, nil
 	}
 
 	// Get from database
-	products, err := s.productRepo.GetByRestaurantID(ctx, restaurantID, limit, offset)
+	products, err := s.productRepo.GetByRestaurantID(ctx, restaurantID, diet, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 
 	// Cache for 15 minutes
-	s.cache.Set(ctx, cacheKey, products, time.Minute*15)
+	s.cache.SetWithTags(ctx, cacheKey, products, time.Minute*15, restaurantProductsTag(restaurantID))
 
 	return products, nil
 }
 
-func (s *ProductService) SearchProducts(ctx context.Context, restaurantID, query string, limit, offset int) ([]models.Product, error) {
-	return s.productRepo.Search(ctx, query, restaurantID, limit, offset)
+func (s *ProductService) SearchProducts(ctx context.Context, restaurantID, query string, diet models.DietaryFilter, limit, offset int) ([]models.Product, error) {
+	return s.productRepo.Search(ctx, query, restaurantID, diet, limit, offset)
 }
 
 func (s *ProductService) GetProductByID(ctx context.Context, productID string) (*models.Product, error) {
@@ -165,12 +243,12 @@ func (s *ProductService) GetProductByID(ctx context.Context, productID string) (
 	}
 
 	// Cache for 30 minutes
-	s.cache.Set(ctx, cacheKey, product, time.Minute*30)
+	s.cache.SetWithTags(ctx, cacheKey, product, time.Minute*30, restaurantProductsTag(product.RestaurantID))
 
 	return product, nil
 }
 
-func (s *ProductService) UpdateProduct(ctx context.Context, productID string, restaurantID string, updates map[string]interface{}) error {
+func (s *ProductService) UpdateProduct(ctx context.Context, productID, restaurantID, userID string, updates map[string]interface{}) error {
 	objectID, err := primitive.ObjectIDFromHex(productID)
 	if err != nil {
 		return errors.New("invalid product ID")
@@ -184,6 +262,7 @@ func (s *ProductService) UpdateProduct(ctx context.Context, productID string, re
 	if product.RestaurantID != restaurantID {
 		return errors.New("product does not belong to this restaurant")
 	}
+	before := product.PriceSnapshot()
 
 	// Update allowed fields
 	if name, ok := updates["name"]; ok {
@@ -198,22 +277,33 @@ func (s *ProductService) UpdateProduct(ctx context.Context, productID string, re
 	}
 	if price, ok := updates["price"]; ok {
 		if priceFloat, ok := price.(float64); ok {
-			product.Price = priceFloat
+			product.Price = models.NewMoney(priceFloat)
 		}
 	}
 	if isAvailable, ok := updates["is_available"]; ok {
 		if availBool, ok := isAvailable.(bool); ok {
 			product.IsAvailable = availBool
+			// A manual change takes over from the stock watcher
+			product.OutOfStock = false
 		}
 	}
+	if err := applyDietaryUpdates(product, updates); err != nil {
+		return err
+	}
 
-	if err := s.productRepo.Update(ctx, product); err != nil {
+	if err := s.updatePricedProduct(ctx, product, before, PriceSourceManual, userID); err != nil {
 		return err
 	}
+	if product.IsCombo() {
+		if err := syncCombo(ctx, s.productRepo, s.inventoryRepo, s.cache, product); err != nil {
+			log.Printf("Failed to sync combo %s: %v", product.ID.Hex(), err)
+		}
+	}
+	syncCombos(ctx, s.productRepo, s.inventoryRepo, s.cache, product.ID)
 
-	// Clear caches
-	s.cache.Delete(ctx, "product:"+productID)
+	// Clear caches, including carts priced with this product
 	s.clearProductCache(restaurantID)
+	s.cache.InvalidateTags(ctx, productCartsTag(productID))
 
 	return nil
 }
@@ -236,10 +326,11 @@ func (s *ProductService) DeleteProduct(ctx context.Context, productID string, re
 	if err := s.productRepo.Delete(ctx, objectID); err != nil {
 		return err
 	}
+	syncCombos(ctx, s.productRepo, s.inventoryRepo, s.cache, objectID)
 
-	// Clear caches
-	s.cache.Delete(ctx, "product:"+productID)
+	// Clear caches, including carts priced with this product
 	s.clearProductCache(restaurantID)
+	s.cache.InvalidateTags(ctx, productCartsTag(productID))
 
 	return nil
 }
@@ -250,6 +341,8 @@ type GetProductsRequest struct {
 	AvailableOnly bool   `json:"available_only"`
 	Page          int    `json:"page"`
 	Limit         int    `json:"limit"`
+
+	Dietary models.DietaryFilter `json:"-"`
 }
 
 type GetProductsResponse struct {
@@ -301,8 +394,8 @@ func (s *ProductService) GetProductsByRestaurantCategoryAndTime(ctx context.Cont
 	}
 
 	// Try cache first
-	cacheKey := fmt.Sprintf("products_filtered:%s:%s:%v:%s:%d:%d",
-		req.RestaurantID, req.CategoryID, req.AvailableOnly, currentTime, req.Limit, offset)
+	cacheKey := fmt.Sprintf("products_filtered:%s:%s:%v:%s:%s:%d:%d",
+		req.RestaurantID, req.CategoryID, req.AvailableOnly, currentTime, req.Dietary.Key(), req.Limit, offset)
 	var cachedResponse *GetProductsResponse
 	if err := s.cache.Get(ctx, cacheKey, &cachedResponse); err == nil {
 		return cachedResponse, nil
@@ -310,7 +403,7 @@ func (s *ProductService) GetProductsByRestaurantCategoryAndTime(ctx context.Cont
 
 	// Get products from repository
 	products, total, err := s.productRepo.GetByRestaurantCategoryAndTime(
-		ctx, req.RestaurantID, categoryID, req.AvailableOnly, currentTime, req.Limit, offset)
+		ctx, req.RestaurantID, categoryID, req.AvailableOnly, currentTime, req.Dietary, req.Limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -335,7 +428,7 @@ func (s *ProductService) GetProductsByRestaurantCategoryAndTime(ctx context.Cont
 	}
 
 	// Cache for 5 minutes (shorter than other caches due to time sensitivity)
-	s.cache.Set(ctx, cacheKey, response, time.Minute*5)
+	s.cache.SetWithTags(ctx, cacheKey, response, time.Minute*5, restaurantProductsTag(req.RestaurantID))
 
 	return response, nil
 }
@@ -346,16 +439,18 @@ func isBusinessDay(t time.Time) bool {
 	return weekday != time.Saturday && weekday != time.Sunday
 }
 
+// restaurantProductsTag groups every cached product listing and product of a restaurant
+func restaurantProductsTag(restaurantID string) string {
+	return "restaurant:" + restaurantID + ":products"
+}
+
+// productCartsTag groups cached carts that contain a product, since they embed its price
+func productCartsTag(productID string) string {
+	return "product:" + productID + ":carts"
+}
+
 func (s *ProductService) clearProductCache(restaurantID string) {
-	// In a real implementation, you'd want to use cache tags or patterns
-	// For now, we'll just clear some common cache patterns
-	ctx := context.Background()
-	for limit := 10; limit <= 50; limit += 10 {
-		for offset := 0; offset <= 100; offset += 10 {
-			cacheKey := fmt.Sprintf("products:%s:%d:%d", restaurantID, limit, offset)
-			s.cache.Delete(ctx, cacheKey)
-		}
-	}
+	s.cache.InvalidateTags(context.Background(), restaurantProductsTag(restaurantID))
 }
 
 // Category Service
@@ -579,6 +674,7 @@ func (s *CategoryService) DeleteCategoryWithProducts(ctx context.Context, catego
 			if err := s.productRepo.Delete(ctx, product.ID); err != nil {
 				return errors.New("failed to delete product: " + err.Error())
 			}
+			s.cache.InvalidateTags(ctx, productCartsTag(product.ID.Hex()))
 			deletedCount++
 		}
 
@@ -597,8 +693,7 @@ func (s *CategoryService) DeleteCategoryWithProducts(ctx context.Context, catego
 
 	// Clear caches
 	s.cache.Delete(ctx, "categories:"+restaurantID)
-	// Clear product caches too - simplified version
-	s.clearProductCaches(restaurantID)
+	s.cache.InvalidateTags(ctx, restaurantProductsTag(restaurantID))
 
 	return nil
 }
@@ -658,6 +753,7 @@ func (s *CategoryService) MoveProductsAndDeleteCategory(ctx context.Context, cat
 			if err := s.productRepo.Update(ctx, &product); err != nil {
 				return errors.New("failed to update product category: " + err.Error())
 			}
+			s.cache.InvalidateTags(ctx, productCartsTag(product.ID.Hex()))
 			movedCount++
 		}
 
@@ -676,21 +772,65 @@ func (s *CategoryService) MoveProductsAndDeleteCategory(ctx context.Context, cat
 
 	// Clear caches
 	s.cache.Delete(ctx, "categories:"+restaurantID)
-	// Clear product caches too - simplified version
-	s.clearProductCaches(restaurantID)
+	s.cache.InvalidateTags(ctx, restaurantProductsTag(restaurantID))
 
 	return nil
 }
 
-// Helper method to clear product caches
-func (s *CategoryService) clearProductCaches(restaurantID string) {
-	ctx := context.Background()
-	// Clear simple pattern-based caches
-	// In a real implementation, you'd want to use cache tags or more sophisticated invalidation
-	for limit := 10; limit <= 50; limit += 10 {
-		for offset := 0; offset <= 100; offset += 10 {
-			cacheKey := fmt.Sprintf("products:%s:%d:%d", restaurantID, limit, offset)
-			s.cache.Delete(ctx, cacheKey)
+// updatePricedProduct saves a product whose prices may have changed from before. A change is
+// added to the price history, and a cut keeps the old selling price as the struck-through
+// was price for wasPriceWindow.
+func (s *ProductService) updatePricedProduct(ctx context.Context, product *models.Product, before models.PriceSnapshot, source, changedBy string) error {
+	return s.updatePricedProductFor(ctx, product, before, source, changedBy, nil)
+}
+
+func (s *ProductService) updatePricedProductFor(ctx context.Context, product *models.Product, before models.PriceSnapshot, source, changedBy string, scheduleID *primitive.ObjectID) error {
+	after := product.PriceSnapshot()
+	changed := !before.Equal(after)
+	now := time.Now()
+	if changed {
+		setWasPrice(product, before, after, now)
+	}
+
+	if err := s.productRepo.Update(ctx, product); err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+
+	entry := &models.PriceHistory{
+		ProductID:    product.ID,
+		RestaurantID: product.RestaurantID,
+		Before:       before,
+		After:        after,
+		Source:       source,
+		ChangedBy:    changedBy,
+		ScheduleID:   scheduleID,
+		ChangedAt:    now,
+	}
+	if err := s.historyRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to record price change for product %s: %v", product.ID.Hex(), err)
+	}
+	return nil
+}
+
+// setWasPrice keeps the highest selling price of the last wasPriceWindow while the product
+// sells below it, and drops it once the price is back up
+func setWasPrice(product *models.Product, before, after models.PriceSnapshot, now time.Time) {
+	if product.WasPriceUntil != nil && !now.Before(*product.WasPriceUntil) {
+		product.WasPrice, product.WasPriceUntil = nil, nil
+	}
+
+	old, current := before.SellingPrice(), after.SellingPrice()
+	switch {
+	case current < old:
+		if product.WasPrice == nil || *product.WasPrice < old {
+			product.WasPrice = &old
 		}
+		until := now.Add(wasPriceWindow)
+		product.WasPriceUntil = &until
+	case product.WasPrice != nil && current >= *product.WasPrice:
+		product.WasPrice, product.WasPriceUntil = nil, nil
 	}
 }
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/cache"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Wrong codes allowed before a pending contact change is dropped
+const maxContactChangeAttempts = 5
+
+var ErrContactInUse = apperr.Conflict("contact_in_use", "this contact is already used by another account")
+
+// ProfileService lets users edit their own profile. Phone numbers and emails identify the
+// account at login, so changing one takes a code sent to the new address first.
+type ProfileService struct {
+	userRepo   repositories.UserRepository
+	auditRepo  repositories.AuditLogRepository
+	otpService *OTPService
+	cache      *cache.RedisCache
+}
+
+func NewProfileService(
+	userRepo repositories.UserRepository,
+	auditRepo repositories.AuditLogRepository,
+	otpService *OTPService,
+	cache *cache.RedisCache,
+) *ProfileService {
+	return &ProfileService{
+		userRepo:   userRepo,
+		auditRepo:  auditRepo,
+		otpService: otpService,
+		cache:      cache,
+	}
+}
+
+type UpdateProfileRequest struct {
+	Name      *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Email     *string `json:"email" binding:"omitempty,email"` // starts a verified email change
+	AvatarURL *string `json:"avatar_url" binding:"omitempty,url"`
+}
+
+type ProfileResponse struct {
+	User models.User `json:"user"`
+	// Set when the update started an email change that waits for the code sent to the new address
+	PendingVerification string `json:"pending_verification,omitempty"`
+}
+
+type ContactChangeRequest struct {
+	Type  string `json:"type" binding:"required,oneof=phone email"`
+	Value string `json:"value" binding:"required"`
+}
+
+type ConfirmContactChangeRequest struct {
+	Type string `json:"type" binding:"required,oneof=phone email"`
+	Code string `json:"code" binding:"required"`
+}
+
+type pendingContactChange struct {
+	Value     string    `json:"value"`
+	Code      string    `json:"code"`
+	Attempts  int       `json:"attempts"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func contactChangeKey(userID, contactType string) string {
+	return fmt.Sprintf("contact_change:%s:%s", userID, contactType)
+}
+
+func userSessionKey(userID string) string {
+	return "user_session:" + userID
+}
+
+func (s *ProfileService) GetProfile(ctx context.Context, userID string) (*models.User, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+// UpdateProfile applies name and avatar changes directly. A new email is not applied; a
+// verification code is sent to it and the change completes through ConfirmContactChange.
+func (s *ProfileService) UpdateProfile(ctx context.Context, userID string, req *UpdateProfileRequest) (*ProfileResponse, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("name cannot be empty")
+		}
+		user.Name = name
+		changed = true
+	}
+	if req.AvatarURL != nil {
+		user.AvatarURL = *req.AvatarURL
+		changed = true
+	}
+
+	if changed {
+		user.UpdatedAt = time.Now()
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to update profile: %v", err)
+		}
+		s.cache.Delete(ctx, userSessionKey(userID))
+	}
+
+	response := &ProfileResponse{User: *user}
+	if req.Email != nil && !strings.EqualFold(strings.TrimSpace(*req.Email), user.Email) {
+		if err := s.RequestContactChange(ctx, userID, &ContactChangeRequest{Type: "email", Value: *req.Email}); err != nil {
+			return nil, err
+		}
+		response.PendingVerification = "email"
+	}
+
+	return response, nil
+}
+
+// RequestContactChange sends a verification code to a new phone number or email. A new
+// request replaces any pending change of the same type.
+func (s *ProfileService) RequestContactChange(ctx context.Context, userID string, req *ContactChangeRequest) error {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	value := normalizeContact(req.Type, req.Value)
+	if value == "" {
+		return fmt.Errorf("invalid %s", req.Type)
+	}
+	if value == currentContact(user, req.Type) {
+		return fmt.Errorf("this is already your %s", req.Type)
+	}
+	if err := s.ensureContactAvailable(ctx, user, req.Type, value); err != nil {
+		return err
+	}
+
+	code, err := s.otpService.generateOTP()
+	if err != nil {
+		return errors.New("failed to generate verification code")
+	}
+
+	pending := pendingContactChange{
+		Value:     value,
+		Code:      code,
+		ExpiresAt: time.Now().Add(otpExpiry),
+	}
+	if err := s.cache.Set(ctx, contactChangeKey(userID, req.Type), pending, otpExpiry); err != nil {
+		return errors.New("failed to save verification code")
+	}
+
+	return s.otpService.SendVerificationCode(ctx, req.Type, value, code)
+}
+
+// ConfirmContactChange switches the phone number or email once the code sent to it is entered
+func (s *ProfileService) ConfirmContactChange(ctx context.Context, userID string, req *ConfirmContactChangeRequest) (*models.User, error) {
+	key := contactChangeKey(userID, req.Type)
+	var pending pendingContactChange
+	if err := s.cache.Get(ctx, key, &pending); err != nil || time.Now().After(pending.ExpiresAt) {
+		return nil, errors.New("no pending change or the code has expired")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(pending.Code), []byte(req.Code)) != 1 {
+		pending.Attempts++
+		if pending.Attempts >= maxContactChangeAttempts {
+			s.cache.Delete(ctx, key)
+			return nil, errors.New("too many wrong codes, please request a new one")
+		}
+		s.cache.Set(ctx, key, pending, time.Until(pending.ExpiresAt))
+		return nil, errors.New("invalid verification code")
+	}
+
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// Someone may have taken the address while the code was on its way
+	if err := s.ensureContactAvailable(ctx, user, req.Type, pending.Value); err != nil {
+		s.cache.Delete(ctx, key)
+		return nil, err
+	}
+
+	previous := currentContact(user, req.Type)
+	if req.Type == "phone" {
+		user.Phone = pending.Value
+	} else {
+		user.Email = pending.Value
+	}
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update %s: %v", req.Type, err)
+	}
+
+	s.cache.Delete(ctx, key)
+	s.cache.Delete(ctx, userSessionKey(userID))
+
+	entry := &models.AuditLog{
+		EntityType:  "user",
+		EntityID:    userID,
+		Action:      req.Type + "_changed",
+		PerformedBy: &user.ID,
+		Metadata: models.JSONB{
+			"from": previous,
+			"to":   pending.Value,
+		},
+	}
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write audit entry for user %s: %v", userID, err)
+	}
+
+	return user, nil
+}
+
+// ensureContactAvailable applies the same uniqueness as registration: within the restaurant
+// for customers, across all users for every other role
+func (s *ProfileService) ensureContactAvailable(ctx context.Context, user *models.User, contactType, value string) error {
+	var existing *models.User
+	switch {
+	case contactType == "phone" && user.Role == "customer" && user.RestaurantID != nil:
+		existing, _ = s.userRepo.GetByPhoneAndRestaurant(ctx, value, *user.RestaurantID)
+	case contactType == "phone":
+		existing, _ = s.userRepo.GetByPhone(ctx, value)
+	case user.Role == "customer" && user.RestaurantID != nil:
+		existing, _ = s.userRepo.GetByEmailAndRestaurant(ctx, value, *user.RestaurantID)
+	default:
+		existing, _ = s.userRepo.GetByEmail(ctx, value)
+	}
+
+	if existing != nil && existing.ID != user.ID {
+		return ErrContactInUse
+	}
+	return nil
+}
+
+func normalizeContact(contactType, value string) string {
+	value = strings.TrimSpace(value)
+	if contactType == "email" {
+		if !strings.Contains(value, "@") {
+			return ""
+		}
+		return strings.ToLower(value)
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+	if len(digits) < 10 || len(digits) > 15 {
+		return ""
+	}
+	return digits
+}
+
+func currentContact(user *models.User, contactType string) string {
+	if contactType == "phone" {
+		return user.Phone
+	}
+	return user.Email
+}
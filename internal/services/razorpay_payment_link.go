@@ -0,0 +1,561 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/i18n"
+	"golang-food-backend/pkg/messaging"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PaymentMethodLink marks a payment collected through a Razorpay payment link
+const PaymentMethodLink = "razorpay_link"
+
+// minPaymentLinkLife is the shortest expiry Razorpay accepts for a payment link
+const minPaymentLinkLife = 15 * time.Minute
+
+var (
+	ErrOrderPaymentInvalid  = apperr.Validation("order_payment_invalid", "invalid payment request")
+	ErrOrderPaymentNotFound = apperr.NotFound("order_not_found", "order not found")
+	ErrOrderPaymentState    = apperr.Conflict("order_payment_state", "the order's payment cannot be changed in its current state")
+)
+
+// OrderPaymentLinkRequest asks for a payment link for an order taken by phone or WhatsApp
+type OrderPaymentLinkRequest struct {
+	ExpiresInMinutes int      `json:"expires_in_minutes,omitempty" binding:"omitempty,min=15,max=10080"` // defaults to the unpaid order window
+	Channels         []string `json:"channels,omitempty" binding:"omitempty,dive,oneof=sms email whatsapp"`
+	Description      string   `json:"description,omitempty" binding:"max=250"`
+}
+
+type ResendPaymentLinkRequest struct {
+	Channel string `json:"channel" binding:"required,oneof=sms email whatsapp"`
+}
+
+// OrderPaymentStatus is an order's current payment attempt and how the customer can complete it
+type OrderPaymentStatus struct {
+	OrderID      string       `json:"order_id"`
+	OrderStatus  string       `json:"order_status"`
+	PaymentID    string       `json:"payment_id,omitempty"`
+	Method       string       `json:"method,omitempty"` // razorpay, razorpay_link or razorpay_upi
+	Status       string       `json:"status,omitempty"` // pending, partially_paid, success, failed, expired or abandoned
+	Amount       models.Money `json:"amount"`
+	AmountPaid   models.Money `json:"amount_paid"`
+	Currency     string       `json:"currency"`
+	PaymentURL   string       `json:"payment_url,omitempty"`    // payment link
+	UPIFlow      string       `json:"upi_flow,omitempty"`       // intent, collect or qr
+	UPIIntentURL string       `json:"upi_intent_url,omitempty"` // upi:// link that opens the customer's UPI app
+	QRImageURL   string       `json:"qr_image_url,omitempty"`
+	VPA          string       `json:"vpa,omitempty"` // UPI ID a collect request was sent to
+	ExpiresAt    *time.Time   `json:"expires_at,omitempty"`
+}
+
+type RazorpayPaymentLinkCustomer struct {
+	Name    string `json:"name,omitempty"`
+	Contact string `json:"contact,omitempty"`
+	Email   string `json:"email,omitempty"`
+}
+
+type RazorpayPaymentLinkRequest struct {
+	Amount                int                         `json:"amount"` // in paise
+	Currency              string                      `json:"currency"`
+	AcceptPartial         bool                        `json:"accept_partial"`
+	FirstMinPartialAmount int                         `json:"first_min_partial_amount,omitempty"` // in paise
+	Description           string                      `json:"description"`
+	ReferenceID           string                      `json:"reference_id"`
+	ExpireBy              int64                       `json:"expire_by,omitempty"` // unix seconds
+	Customer              RazorpayPaymentLinkCustomer `json:"customer"`
+	Notify                map[string]bool             `json:"notify,omitempty"`
+	Notes                 map[string]interface{}      `json:"notes,omitempty"`
+}
+
+type RazorpayPaymentLinkResponse struct {
+	ID          string                 `json:"id"`
+	ShortURL    string                 `json:"short_url"`
+	Status      string                 `json:"status"` // created, partially_paid, paid, expired, cancelled
+	Amount      int                    `json:"amount"`
+	AmountPaid  int                    `json:"amount_paid"`
+	Currency    string                 `json:"currency"`
+	ReferenceID string                 `json:"reference_id"`
+	ExpireBy    int64                  `json:"expire_by"`
+	Notes       map[string]interface{} `json:"notes"`
+	CreatedAt   int64                  `json:"created_at"`
+}
+
+// CreatePaymentLink creates a Razorpay payment link for the order's total and records it as the
+// order's pending payment; the caller saves the order. With minFirstPayment set the customer can
+// pay in parts, the first of at least that amount. Razorpay sends the link to the customer on
+// the given channels, by SMS when none are given.
+func (s *RazorpayService) CreatePaymentLink(ctx context.Context, order *models.Order, minFirstPayment models.Money, expiresAt time.Time, description string, channels []string) (*models.Payment, error) {
+	currency := i18n.CurrencyOf(order.Currency)
+	amountInPaise := int(currency.ToMinor(order.TotalAmount.Float64()))
+
+	req := &RazorpayPaymentLinkRequest{
+		Amount:      amountInPaise,
+		Currency:    currency.Code,
+		Description: description,
+		ReferenceID: order.ID.String(),
+		ExpireBy:    expiresAt.Unix(),
+		Customer: RazorpayPaymentLinkCustomer{
+			Name:    order.CustomerName,
+			Contact: order.CustomerContact,
+		},
+		Notify: make(map[string]bool),
+		Notes: map[string]interface{}{
+			"order_id": order.ID.String(),
+		},
+	}
+	if len(channels) == 0 {
+		channels = []string{"sms"}
+	}
+	for _, channel := range channels {
+		req.Notify[channel] = true
+	}
+	if req.Notify["email"] {
+		req.Customer.Email = order.User.Email
+	}
+	partial := minFirstPayment > 0 && minFirstPayment < order.TotalAmount
+	if partial {
+		req.AcceptPartial = true
+		req.FirstMinPartialAmount = int(currency.ToMinor(minFirstPayment.Float64()))
+	}
+
+	link, err := s.createPaymentLinkAPI(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Razorpay payment link: %v", err)
+	}
+
+	payment := &models.Payment{
+		ID:            uuid.New(),
+		OrderID:       order.ID,
+		UserID:        order.UserID,
+		Amount:        order.TotalAmount,
+		Method:        PaymentMethodLink,
+		Status:        "pending",
+		TransactionID: link.ID,
+		CreatedAt:     time.Now(),
+		Metadata: models.JSONB{
+			"payment_link_id": link.ID,
+			"short_url":       link.ShortURL,
+			"currency":        currency.Code,
+			"amount_paise":    amountInPaise,
+			"expires_at":      expiresAt,
+			"channels":        channels,
+		},
+	}
+	if partial {
+		payment.Metadata["min_first_payment"] = minFirstPayment
+	}
+	if err := s.paymentRepo.Create(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to create payment record: %v", err)
+	}
+
+	order.PaymentID = &payment.ID
+	return payment, nil
+}
+
+// handlePaymentLinkPaid records a payment made through a payment link. The first payment, in
+// full or of at least the link's minimum part, confirms the order, or schedules it when it is
+// for a later slot; later parts only add to the amount paid.
+func (s *RazorpayService) handlePaymentLinkPaid(ctx context.Context, payload map[string]interface{}) error {
+	linkData, ok := webhookEntity(payload, "payment_link")
+	if !ok {
+		return fmt.Errorf("invalid payment link data in webhook")
+	}
+	linkID, ok := linkData["id"].(string)
+	if !ok {
+		return fmt.Errorf("missing id in payment link data")
+	}
+
+	amountPaid, _ := linkData["amount_paid"].(float64)
+	gatewayPaymentID := ""
+	if paymentData, ok := webhookEntity(payload, "payment"); ok {
+		gatewayPaymentID, _ = paymentData["id"].(string)
+	}
+
+	// The payment and the order are saved together, so a conflict on the order leaves the
+	// payment unrecorded and the whole change is read and applied again
+	var (
+		order          *models.Order
+		payment        *models.Payment
+		paid, previous models.Money
+		confirmed      bool
+	)
+	err := retryOnConflict(ctx, func() error {
+		var err error
+		if payment, err = s.paymentRepo.GetByTransactionID(ctx, linkID); err != nil {
+			return fmt.Errorf("payment not found for payment link %s: %v", linkID, err)
+		}
+		if order, err = s.orderRepo.GetByID(ctx, payment.OrderID); err != nil {
+			return fmt.Errorf("failed to get order: %v", err)
+		}
+
+		// Razorpay reports the link's running total; a replayed or out of order event adds nothing
+		paid = models.NewMoney(i18n.CurrencyOf(order.Currency).FromMinor(int64(amountPaid)))
+		if payment.Metadata == nil {
+			payment.Metadata = make(models.JSONB)
+		}
+		previous = models.MoneyOf(payment.Metadata["amount_paid"])
+		if paid <= previous {
+			return nil
+		}
+		payment.Metadata["amount_paid"] = paid
+		if gatewayPaymentID != "" {
+			payment.Metadata["razorpay_payment_id"] = gatewayPaymentID
+		}
+		payment.Status = "partially_paid"
+		if paid >= payment.Amount {
+			payment.Status = "success"
+		}
+
+		if order.OrderStatus != "pending_payment" {
+			if order.OrderStatus == "cancelled" {
+				payment.Metadata["requires_refund"] = true
+				log.Printf("Payment link %s paid for cancelled order %s, refund required", linkID, order.ID)
+			}
+			if err := s.paymentRepo.Update(ctx, payment); err != nil {
+				return fmt.Errorf("failed to update payment status: %v", err)
+			}
+			return nil
+		}
+
+		captured, err := messaging.Events.NewEnvelope(messaging.EventPaymentCaptured, order.RestaurantID.String(), messaging.PaymentCaptured{
+			PaymentID:        payment.ID.String(),
+			OrderID:          order.ID.String(),
+			UserID:           order.UserID.String(),
+			Amount:           paid.Float64(),
+			Method:           payment.Method,
+			GatewayPaymentID: gatewayPaymentID,
+			CapturedAt:       time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		capturedEvent, err := NewOutboxEvent("payment_events", order.ID.String(), captured)
+		if err != nil {
+			return err
+		}
+
+		order.OrderStatus = "confirmed"
+		if order.ScheduledFor != nil {
+			order.OrderStatus = "scheduled"
+		}
+		appendOrderLog(order, order.OrderStatus, fmt.Sprintf("%s paid through the payment link", i18n.FormatMoney(paid.Float64(), order.Currency)))
+		if err := s.orderRepo.UpdateWithPayment(ctx, order, payment, []models.OutboxEvent{capturedEvent}); err != nil {
+			return fmt.Errorf("failed to update order status: %w", err)
+		}
+		confirmed = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if paid <= previous || order.OrderStatus == "cancelled" {
+		return nil
+	}
+	s.ledger.PaymentCaptured(ctx, order, payment.ID, fmt.Sprintf("payment:%s:%s", payment.ID, paid), paid-previous)
+	if !confirmed {
+		return nil
+	}
+	s.tracking.PublishStatus(ctx, order)
+
+	if order.OrderStatus == "confirmed" && s.dispatcher != nil {
+		s.dispatcher.OrderConfirmed(order)
+	}
+	return nil
+}
+
+// SendOrderPaymentLink creates a payment link for a restaurant's order awaiting payment, such
+// as one taken over the phone, and sends it to the customer. It replaces the order's unpaid
+// attempt; the order is cancelled if the link expires unpaid.
+func (s *RazorpayService) SendOrderPaymentLink(ctx context.Context, restaurantID, orderID string, req *OrderPaymentLinkRequest) (*OrderPaymentStatus, error) {
+	order, err := s.restaurantOrder(ctx, restaurantID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.retirePaymentAttempt(ctx, order); err != nil {
+		return nil, err
+	}
+
+	expiresIn := s.recovery.ExpireAfter
+	if req.ExpiresInMinutes > 0 {
+		expiresIn = time.Duration(req.ExpiresInMinutes) * time.Minute
+	}
+	if expiresIn < minPaymentLinkLife {
+		expiresIn = minPaymentLinkLife
+	}
+	description := req.Description
+	if description == "" {
+		description = fmt.Sprintf("Order %s from %s", order.ID.String()[:8], order.Restaurant.Name)
+	}
+
+	channels := req.Channels
+	if len(channels) == 0 {
+		channels = []string{"sms"}
+	}
+
+	payment, err := s.CreatePaymentLink(ctx, order, 0, time.Now().Add(expiresIn), description, channels)
+	if err != nil {
+		return nil, err
+	}
+	appendOrderLog(order, order.OrderStatus, "Payment link sent by "+strings.Join(channels, ", "))
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to update order with payment ID: %v", err)
+	}
+	return orderPaymentStatus(order, payment), nil
+}
+
+// ResendOrderPaymentLink sends the order's open payment link to the customer again
+func (s *RazorpayService) ResendOrderPaymentLink(ctx context.Context, restaurantID, orderID string, req *ResendPaymentLinkRequest) (*OrderPaymentStatus, error) {
+	order, payment, err := s.openPaymentLink(ctx, restaurantID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.notifyPaymentLinkAPI(ctx, payment.TransactionID, req.Channel); err != nil {
+		return nil, fmt.Errorf("failed to resend Razorpay payment link: %v", err)
+	}
+
+	resent, _ := payment.Metadata["resent"].(float64)
+	payment.Metadata["resent"] = resent + 1
+	payment.Metadata["resent_at"] = time.Now()
+	if err := s.paymentRepo.Update(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to update payment: %v", err)
+	}
+	return orderPaymentStatus(order, payment), nil
+}
+
+// CancelOrderPaymentLink cancels the order's payment link before anything is paid through it.
+// The order keeps waiting for payment, by a new link or at checkout, until it expires.
+func (s *RazorpayService) CancelOrderPaymentLink(ctx context.Context, restaurantID, orderID string) (*OrderPaymentStatus, error) {
+	order, payment, err := s.openPaymentLink(ctx, restaurantID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != "pending" {
+		return nil, fmt.Errorf("%w: part of the order is already paid through the link", ErrOrderPaymentState)
+	}
+	if err := s.cancelPaymentLinkAPI(ctx, payment.TransactionID); err != nil {
+		return nil, fmt.Errorf("failed to cancel Razorpay payment link: %v", err)
+	}
+
+	payment.Status = "abandoned"
+	payment.Metadata["link_status"] = "cancelled"
+	if err := s.paymentRepo.Update(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to update payment: %v", err)
+	}
+	appendOrderLog(order, order.OrderStatus, "Payment link cancelled")
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to update order: %v", err)
+	}
+	return orderPaymentStatus(order, payment), nil
+}
+
+// GetOrderPayment returns the current payment attempt of one of the restaurant's orders
+func (s *RazorpayService) GetOrderPayment(ctx context.Context, restaurantID, orderID string) (*OrderPaymentStatus, error) {
+	order, err := s.restaurantOrder(ctx, restaurantID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	var payment *models.Payment
+	if order.PaymentID != nil {
+		if payment, err = s.paymentRepo.GetByID(ctx, *order.PaymentID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get payment: %v", err)
+		}
+	}
+	return orderPaymentStatus(order, payment), nil
+}
+
+// restaurantOrder loads one of the restaurant's orders
+func (s *RazorpayService) restaurantOrder(ctx context.Context, restaurantID, orderID string) (*models.Order, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, ErrOrderPaymentNotFound
+	}
+	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && order.RestaurantID.String() != restaurantID) {
+		return nil, ErrOrderPaymentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %v", err)
+	}
+	return order, nil
+}
+
+// openPaymentLink loads a restaurant's order awaiting payment through a link that is still open
+func (s *RazorpayService) openPaymentLink(ctx context.Context, restaurantID, orderID string) (*models.Order, *models.Payment, error) {
+	order, err := s.restaurantOrder(ctx, restaurantID, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if order.OrderStatus != "pending_payment" || order.PaymentID == nil {
+		return nil, nil, fmt.Errorf("%w: the order is not awaiting payment through a link", ErrOrderPaymentState)
+	}
+	payment, err := s.paymentRepo.GetByID(ctx, *order.PaymentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get payment: %v", err)
+	}
+	if payment.Method != PaymentMethodLink || (payment.Status != "pending" && payment.Status != "partially_paid") {
+		return nil, nil, fmt.Errorf("%w: the order is not awaiting payment through a link", ErrOrderPaymentState)
+	}
+	if payment.Metadata == nil {
+		payment.Metadata = make(models.JSONB)
+	}
+	return order, payment, nil
+}
+
+// retirePaymentAttempt abandons the open payment attempt of an order awaiting payment before a
+// new one is started, closing a link or QR code at Razorpay so it cannot be paid as well
+func (s *RazorpayService) retirePaymentAttempt(ctx context.Context, order *models.Order) error {
+	if order.OrderStatus != "pending_payment" {
+		return fmt.Errorf("%w: the order is %s", ErrOrderPaymentState, order.OrderStatus)
+	}
+	if order.PaymentID == nil {
+		return nil
+	}
+	previous, err := s.paymentRepo.GetByID(ctx, *order.PaymentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get previous payment: %v", err)
+	}
+
+	switch previous.Status {
+	case "success", "partially_paid":
+		return fmt.Errorf("%w: the order is already paid or part paid", ErrOrderPaymentState)
+	case "pending":
+	default:
+		return nil
+	}
+	switch {
+	case previous.Method == PaymentMethodLink:
+		err = s.cancelPaymentLinkAPI(ctx, previous.TransactionID)
+	case previous.Method == PaymentMethodUPI && previous.Metadata["upi_flow"] == UPIFlowQR:
+		err = s.closeQRCodeAPI(ctx, previous.TransactionID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to close previous payment at Razorpay: %v", err)
+	}
+
+	previous.Status = "abandoned"
+	if err := s.paymentRepo.Update(ctx, previous); err != nil {
+		return fmt.Errorf("failed to update previous payment: %v", err)
+	}
+	return nil
+}
+
+// handleAttemptClosed marks the pending payment behind a payment link or QR code that Razorpay
+// closed as expired or abandoned. The order is left to the unpaid order sweep.
+func (s *RazorpayService) handleAttemptClosed(ctx context.Context, payload map[string]interface{}, entity, status string) error {
+	data, ok := webhookEntity(payload, entity)
+	if !ok {
+		return fmt.Errorf("invalid %s data in webhook", entity)
+	}
+	id, ok := data["id"].(string)
+	if !ok {
+		return fmt.Errorf("missing id in %s data", entity)
+	}
+
+	payment, err := s.paymentRepo.GetByTransactionID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("No payment for Razorpay %s %s, ignoring close event", entity, id)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("payment not found for %s %s: %v", entity, id, err)
+	}
+	if payment.Status != "pending" {
+		return nil
+	}
+
+	payment.Status = status
+	if err := s.paymentRepo.Update(ctx, payment); err != nil {
+		return fmt.Errorf("failed to update payment status: %v", err)
+	}
+	return nil
+}
+
+// orderPaymentStatus describes the order's payment attempt; payment may be nil
+func orderPaymentStatus(order *models.Order, payment *models.Payment) *OrderPaymentStatus {
+	status := &OrderPaymentStatus{
+		OrderID:     order.ID.String(),
+		OrderStatus: order.OrderStatus,
+		Amount:      order.TotalAmount,
+		Currency:    order.Currency,
+	}
+	if payment == nil {
+		return status
+	}
+
+	status.PaymentID = payment.ID.String()
+	status.Method = payment.Method
+	status.Status = payment.Status
+	status.Amount = payment.Amount
+	switch {
+	case payment.Status == "success" && payment.Method != PaymentMethodLink:
+		status.AmountPaid = payment.Amount
+	default:
+		status.AmountPaid = models.MoneyOf(payment.Metadata["amount_paid"])
+	}
+	status.PaymentURL, _ = payment.Metadata["short_url"].(string)
+	status.UPIFlow, _ = payment.Metadata["upi_flow"].(string)
+	status.UPIIntentURL, _ = payment.Metadata["intent_url"].(string)
+	status.QRImageURL, _ = payment.Metadata["qr_image_url"].(string)
+	status.VPA, _ = payment.Metadata["vpa"].(string)
+	if expiresAt, ok := paymentLinkExpiry(payment); ok {
+		status.ExpiresAt = &expiresAt
+	}
+	return status
+}
+
+// paymentLinkExpiry returns when the payment's link stops taking payments
+func paymentLinkExpiry(payment *models.Payment) (time.Time, bool) {
+	switch expiresAt := payment.Metadata["expires_at"].(type) {
+	case time.Time:
+		return expiresAt, true
+	case string:
+		parsed, err := time.Parse(time.RFC3339, expiresAt)
+		return parsed, err == nil
+	}
+	return time.Time{}, false
+}
+
+// webhookEntity reads an entity from a webhook payload, where Razorpay may wrap it in "entity"
+func webhookEntity(payload map[string]interface{}, name string) (map[string]interface{}, bool) {
+	data, ok := payload[name].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	if entity, ok := data["entity"].(map[string]interface{}); ok {
+		return entity, true
+	}
+	return data, true
+}
+
+func (s *RazorpayService) notifyPaymentLinkAPI(ctx context.Context, linkID, medium string) error {
+	return s.callAPI(ctx, http.MethodPost, s.baseURL+"/payment_links/"+linkID+"/notify_by/"+medium, nil, nil)
+}
+
+func (s *RazorpayService) cancelPaymentLinkAPI(ctx context.Context, linkID string) error {
+	return s.callAPI(ctx, http.MethodPost, s.baseURL+"/payment_links/"+linkID+"/cancel", nil, nil)
+}
+
+func (s *RazorpayService) createPaymentLinkAPI(ctx context.Context, req *RazorpayPaymentLinkRequest) (*RazorpayPaymentLinkResponse, error) {
+	var link RazorpayPaymentLinkResponse
+	if err := s.callAPI(ctx, http.MethodPost, s.baseURL+"/payment_links", req, &link); err != nil {
+		return nil, err
+	}
+	if link.ID == "" || link.ShortURL == "" {
+		return nil, errors.New("Razorpay returned no payment link")
+	}
+	return &link, nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/i18n"
+)
+
+// RecurringMandate is what Razorpay needs to charge a customer's recurring payment token
+// without them being present
+type RecurringMandate struct {
+	CustomerID string // the Razorpay customer the token was issued to
+	Token      string
+	Email      string
+	Contact    string
+}
+
+type RazorpayRecurringPaymentRequest struct {
+	Email       string                 `json:"email"`
+	Contact     string                 `json:"contact"`
+	Amount      int                    `json:"amount"` // in paise
+	Currency    string                 `json:"currency"`
+	OrderID     string                 `json:"order_id"`
+	CustomerID  string                 `json:"customer_id"`
+	Token       string                 `json:"token"`
+	Recurring   string                 `json:"recurring"`
+	Description string                 `json:"description"`
+	Notes       map[string]interface{} `json:"notes,omitempty"`
+}
+
+type RazorpayRecurringPaymentResponse struct {
+	PaymentID string `json:"razorpay_payment_id"`
+	OrderID   string `json:"razorpay_order_id"`
+	Signature string `json:"razorpay_signature"`
+}
+
+// ChargeRecurring charges a customer's recurring payment mandate without them being present,
+// returning the gateway payment ID. Each charge is made against a gateway order of its own
+// whose receipt is the given one.
+func (s *RazorpayService) ChargeRecurring(ctx context.Context, mandate RecurringMandate, amount models.Money, currencyCode, receipt, description string) (string, error) {
+	if !strings.HasPrefix(mandate.Token, "token_") || mandate.CustomerID == "" {
+		return "", errors.New("invalid recurring payment mandate")
+	}
+	currency := i18n.CurrencyOf(currencyCode)
+	minor := int(currency.ToMinor(amount.Float64()))
+
+	order, err := s.createRazorpayOrderAPI(ctx, &RazorpayOrderRequest{
+		Amount:   minor,
+		Currency: currency.Code,
+		Receipt:  receipt,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create recurring payment order: %v", err)
+	}
+
+	charged, err := s.createRecurringPaymentAPI(ctx, &RazorpayRecurringPaymentRequest{
+		Email:       mandate.Email,
+		Contact:     mandate.Contact,
+		Amount:      minor,
+		Currency:    currency.Code,
+		OrderID:     order.ID,
+		CustomerID:  mandate.CustomerID,
+		Token:       mandate.Token,
+		Recurring:   "1",
+		Description: description,
+		Notes:       map[string]interface{}{"receipt": receipt},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to charge recurring payment: %v", err)
+	}
+	return charged.PaymentID, nil
+}
+
+type RazorpayRefundRequest struct {
+	Amount  int                    `json:"amount"` // in paise
+	Receipt string                 `json:"receipt,omitempty"`
+	Notes   map[string]interface{} `json:"notes,omitempty"`
+}
+
+type RazorpayRefundResponse struct {
+	ID        string `json:"id"`
+	PaymentID string `json:"payment_id"`
+	Status    string `json:"status"`
+}
+
+// RefundRecurring refunds a recurring charge in full, returning the gateway refund ID
+func (s *RazorpayService) RefundRecurring(ctx context.Context, paymentID string, amount models.Money, currencyCode, receipt string) (string, error) {
+	currency := i18n.CurrencyOf(currencyCode)
+	req := &RazorpayRefundRequest{
+		Amount:  int(currency.ToMinor(amount.Float64())),
+		Receipt: receipt,
+	}
+	refund, err := s.createRefundAPI(ctx, paymentID, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to refund recurring payment: %v", err)
+	}
+	return refund.ID, nil
+}
+
+func (s *RazorpayService) createRefundAPI(ctx context.Context, paymentID string, req *RazorpayRefundRequest) (*RazorpayRefundResponse, error) {
+	var refund RazorpayRefundResponse
+	if err := s.callAPI(ctx, http.MethodPost, s.baseURL+"/payments/"+paymentID+"/refund", req, &refund); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (s *RazorpayService) createRecurringPaymentAPI(ctx context.Context, req *RazorpayRecurringPaymentRequest) (*RazorpayRecurringPaymentResponse, error) {
+	var charged RazorpayRecurringPaymentResponse
+	if err := s.callAPI(ctx, http.MethodPost, s.baseURL+"/payments/create/recurring", req, &charged); err != nil {
+		return nil, err
+	}
+	if charged.PaymentID == "" {
+		return nil, errors.New("Razorpay returned no payment ID")
+	}
+	return &charged, nil
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RazorpayCustomerRequest struct {
+	Name         string `json:"name"`
+	Contact      string `json:"contact"`
+	Email        string `json:"email,omitempty"`
+	FailExisting string `json:"fail_existing"` // "0" returns the customer already holding the contact
+}
+
+type RazorpayCustomerResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Contact   string `json:"contact"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type RazorpayTokenPaymentRequest struct {
+	Amount     int    `json:"amount"` // in paise
+	Currency   string `json:"currency"`
+	OrderID    string `json:"order_id"`
+	CustomerID string `json:"customer_id"`
+	Token      string `json:"token"`
+	Method     string `json:"method"` // card, upi
+	Contact    string `json:"contact"`
+	Email      string `json:"email,omitempty"`
+}
+
+type RazorpayTokenPaymentResponse struct {
+	PaymentID string                   `json:"razorpay_payment_id"`
+	Next      []map[string]interface{} `json:"next,omitempty"` // actions left to the customer, such as a 3-D Secure redirect
+}
+
+// userSavedMethod loads one of the user's saved payment methods
+func userSavedMethod(ctx context.Context, savedMethods repositories.SavedPaymentMethodRepository, userID uuid.UUID, methodID string) (*models.SavedPaymentMethod, error) {
+	id, err := uuid.Parse(methodID)
+	if err != nil {
+		return nil, ErrPaymentMethodNotFound
+	}
+	method, err := savedMethods.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && method.UserID != userID) {
+		return nil, ErrPaymentMethodNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved payment method: %v", err)
+	}
+	return method, nil
+}
+
+// chargeSavedMethod pays a new order's gateway order with a saved card or UPI ID. A declined
+// charge fails the payment but leaves the order awaiting payment, so the customer can retry.
+func (s *RazorpayService) chargeSavedMethod(ctx context.Context, order *models.Order, payment *models.Payment, method *models.SavedPaymentMethod, response *PlaceOrderResponse) error {
+	charged, err := s.createTokenPaymentAPI(ctx, &RazorpayTokenPaymentRequest{
+		Amount:     response.Amount,
+		Currency:   response.Currency,
+		OrderID:    payment.TransactionID,
+		CustomerID: method.RazorpayCustomerID,
+		Token:      method.Token,
+		Method:     method.Type,
+		Contact:    order.CustomerContact,
+		Email:      order.User.Email,
+	})
+
+	payment.Metadata["saved_method_id"] = method.ID.String()
+	if err != nil {
+		log.Printf("Saved payment method %s declined for order %s: %v", method.ID, order.ID, err)
+		payment.Status = "failed"
+		payment.Metadata["failure_reason"] = err.Error()
+	} else {
+		payment.Metadata["razorpay_payment_id"] = charged.PaymentID
+		for _, next := range charged.Next {
+			if url, ok := next["url"].(string); ok && next["action"] == "redirect" {
+				response.NextActionURL = url
+			}
+		}
+	}
+	if err := s.paymentRepo.Update(ctx, payment); err != nil {
+		return fmt.Errorf("failed to update payment: %v", err)
+	}
+	if err := s.savedMethods.MarkUsed(ctx, method.ID, time.Now()); err != nil {
+		log.Printf("Failed to mark saved payment method %s used: %v", method.ID, err)
+	}
+
+	response.RazorpayCustomerID = method.RazorpayCustomerID
+	response.PaymentStatus = payment.Status
+	return nil
+}
+
+// prepareMethodSaving sets up a new order's payment so the card or UPI ID it is paid with is
+// saved. Checkout has to be opened for the returned customer; if Razorpay cannot create one the
+// order is still paid as usual, only without saving.
+func (s *RazorpayService) prepareMethodSaving(ctx context.Context, order *models.Order, payment *models.Payment, response *PlaceOrderResponse) error {
+	customerID, err := s.razorpayCustomer(ctx, order)
+	if err != nil {
+		log.Printf("Failed to set up saving the payment method for order %s: %v", order.ID, err)
+		return nil
+	}
+
+	payment.Metadata["save_method"] = true
+	payment.Metadata["razorpay_customer_id"] = customerID
+	if err := s.paymentRepo.Update(ctx, payment); err != nil {
+		return fmt.Errorf("failed to update payment: %v", err)
+	}
+	response.RazorpayCustomerID = customerID
+	return nil
+}
+
+// razorpayCustomer returns the customer's Razorpay customer ID, creating the customer on first use
+func (s *RazorpayService) razorpayCustomer(ctx context.Context, order *models.Order) (string, error) {
+	customer, err := s.savedMethods.GetCustomer(ctx, order.UserID)
+	if err == nil {
+		return customer.RazorpayCustomerID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+
+	created, err := s.createCustomerAPI(ctx, &RazorpayCustomerRequest{
+		Name:         order.CustomerName,
+		Contact:      order.CustomerContact,
+		Email:        order.User.Email,
+		FailExisting: "0",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create Razorpay customer: %v", err)
+	}
+	if err := s.savedMethods.CreateCustomer(ctx, &models.PaymentCustomer{
+		UserID:             order.UserID,
+		RazorpayCustomerID: created.ID,
+		CreatedAt:          time.Now(),
+	}); err != nil {
+		return "", err
+	}
+
+	// A concurrent checkout may have recorded its customer first
+	customer, err = s.savedMethods.GetCustomer(ctx, order.UserID)
+	if err != nil {
+		return "", err
+	}
+	return customer.RazorpayCustomerID, nil
+}
+
+// saveMethodFromPayment saves the card or UPI ID of a captured payment the customer asked to
+// keep. Razorpay only tokenizes it when Checkout was opened for their customer.
+func (s *RazorpayService) saveMethodFromPayment(ctx context.Context, payment *models.Payment, paymentData map[string]interface{}) {
+	if save, _ := payment.Metadata["save_method"].(bool); !save || s.savedMethods == nil {
+		return
+	}
+	token, _ := paymentData["token_id"].(string)
+	customerID, _ := paymentData["customer_id"].(string)
+	if token == "" || customerID == "" {
+		return
+	}
+
+	now := time.Now()
+	method := &models.SavedPaymentMethod{
+		UserID:             payment.UserID,
+		Token:              token,
+		RazorpayCustomerID: customerID,
+		LastUsedAt:         &now,
+		CreatedAt:          now,
+	}
+	switch paymentData["method"] {
+	case "card":
+		card, _ := paymentData["card"].(map[string]interface{})
+		method.Type = "card"
+		method.CardNetwork, _ = card["network"].(string)
+		method.CardIssuer, _ = card["issuer"].(string)
+		method.CardLast4, _ = card["last4"].(string)
+	case "upi":
+		vpa, _ := paymentData["vpa"].(string)
+		method.Type = "upi"
+		method.VPA = maskVPA(vpa)
+	default:
+		return
+	}
+
+	if err := s.savedMethods.Save(ctx, method); err != nil {
+		log.Printf("Failed to save payment method for payment %s: %v", payment.ID, err)
+	}
+}
+
+// DeleteSavedMethod removes a saved card or UPI ID's token at Razorpay
+func (s *RazorpayService) DeleteSavedMethod(ctx context.Context, method *models.SavedPaymentMethod) error {
+	if err := s.deleteTokenAPI(ctx, method.RazorpayCustomerID, method.Token); err != nil {
+		return fmt.Errorf("failed to delete Razorpay token: %v", err)
+	}
+	return nil
+}
+
+// maskVPA keeps the first two characters of a UPI ID's handle and its bank, as in jo****@okaxis
+func maskVPA(vpa string) string {
+	handle, bank, ok := strings.Cut(vpa, "@")
+	if !ok {
+		return ""
+	}
+	if len(handle) <= 2 {
+		return handle + "@" + bank
+	}
+	return handle[:2] + strings.Repeat("*", len(handle)-2) + "@" + bank
+}
+
+func (s *RazorpayService) createCustomerAPI(ctx context.Context, req *RazorpayCustomerRequest) (*RazorpayCustomerResponse, error) {
+	var customer RazorpayCustomerResponse
+	if err := s.callAPI(ctx, http.MethodPost, s.baseURL+"/customers", req, &customer); err != nil {
+		return nil, err
+	}
+	if customer.ID == "" {
+		return nil, errors.New("Razorpay returned no customer ID")
+	}
+	return &customer, nil
+}
+
+func (s *RazorpayService) createTokenPaymentAPI(ctx context.Context, req *RazorpayTokenPaymentRequest) (*RazorpayTokenPaymentResponse, error) {
+	if !strings.HasPrefix(req.Token, "token_") {
+		return nil, errors.New("invalid payment token")
+	}
+
+	var charged RazorpayTokenPaymentResponse
+	if err := s.callAPI(ctx, http.MethodPost, s.baseURL+"/payments/create/json", req, &charged); err != nil {
+		return nil, err
+	}
+	if charged.PaymentID == "" {
+		return nil, errors.New("Razorpay returned no payment ID")
+	}
+	return &charged, nil
+}
+
+func (s *RazorpayService) deleteTokenAPI(ctx context.Context, customerID, token string) error {
+	return s.callAPI(ctx, http.MethodDelete, s.baseURL+"/customers/"+customerID+"/tokens/"+token, nil, nil)
+}
@@ -7,42 +7,93 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"golang-food-backend/internal/models"
 	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/i18n"
+	"golang-food-backend/pkg/messaging"
+	"io"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type RazorpayService struct {
-	apiKey          string
-	apiSecret       string
-	webhookSecret   string
-	baseURL         string
-	paymentRepo     repositories.PaymentRepository
-	orderRepo       repositories.OrderRepository
-	deliveryService *DeliveryPartnerService
+	apiKey        string
+	apiSecret     string
+	webhookSecret string
+	baseURL       string
+	httpClient    *http.Client
+	paymentRepo   repositories.PaymentRepository
+	orderRepo     repositories.OrderRepository
+	savedMethods  repositories.SavedPaymentMethodRepository
+	restaurants   *RestaurantService
+	dispatcher    *DispatchService
+	tracking      *OrderTrackingService
+	risk          *RiskService
+	ledger        *LedgerService
+	kafkaProducer *messaging.KafkaProducer
+	kafkaBrokers  []string
+	recovery      PaymentRecoveryPolicy
+	orders        UnpaidOrderExpirer
+}
+
+// UnpaidOrderExpirer cancels orders whose payment window passed, releasing what they reserved
+type UnpaidOrderExpirer interface {
+	ExpireUnpaidOrder(ctx context.Context, orderID uuid.UUID) (bool, error)
+}
+
+// PaymentRecoveryPolicy controls payment retries and when unpaid orders are chased or expired
+type PaymentRecoveryPolicy struct {
+	MaxRetries    int           // extra gateway orders allowed after the first attempt
+	ReminderAfter time.Duration // idle time before a payment_abandoned reminder event
+	ExpireAfter   time.Duration // order age after which an unpaid order is cancelled
 }
 
 func NewRazorpayService(
 	apiKey, apiSecret, webhookSecret string,
 	paymentRepo repositories.PaymentRepository,
 	orderRepo repositories.OrderRepository,
-	deliveryService *DeliveryPartnerService,
+	savedMethods repositories.SavedPaymentMethodRepository,
+	restaurants *RestaurantService,
+	dispatcher *DispatchService,
+	tracking *OrderTrackingService,
+	risk *RiskService,
+	ledger *LedgerService,
+	kafkaProducer *messaging.KafkaProducer,
+	kafkaBrokers []string,
+	recovery PaymentRecoveryPolicy,
 ) *RazorpayService {
 	return &RazorpayService{
-		apiKey:          apiKey,
-		apiSecret:       apiSecret,
-		webhookSecret:   webhookSecret,
-		baseURL:         "https://api.razorpay.com/v1",
-		paymentRepo:     paymentRepo,
-		orderRepo:       orderRepo,
-		deliveryService: deliveryService,
+		apiKey:        apiKey,
+		apiSecret:     apiSecret,
+		webhookSecret: webhookSecret,
+		baseURL:       "https://api.razorpay.com/v1",
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+		paymentRepo:   paymentRepo,
+		orderRepo:     orderRepo,
+		savedMethods:  savedMethods,
+		restaurants:   restaurants,
+		dispatcher:    dispatcher,
+		tracking:      tracking,
+		risk:          risk,
+		ledger:        ledger,
+		kafkaProducer: kafkaProducer,
+		kafkaBrokers:  kafkaBrokers,
+		recovery:      recovery,
 	}
 }
 
+// SetOrderExpirer sets what cancels orders left unpaid
+func (s *RazorpayService) SetOrderExpirer(orders UnpaidOrderExpirer) {
+	s.orders = orders
+}
+
 type RazorpayOrderRequest struct {
 	Amount         int                    `json:"amount"`   // Amount in paise
 	Currency       string                 `json:"currency"` // INR
@@ -75,22 +126,33 @@ type RazorpayWebhookPayload struct {
 }
 
 type PlaceOrderRequest struct {
-	UserID          string                 `json:"user_id" binding:"required"`
+	UserID          string                 `json:"-"` // set from the authenticated user
 	RestaurantID    string                 `json:"restaurant_id" binding:"required"`
 	CartID          string                 `json:"cart_id" binding:"required"`
 	AddressID       *string                `json:"address_id,omitempty"`
-	Amount          float64                `json:"amount" binding:"required,min=1"`
+	Amount          models.Money           `json:"amount" binding:"required,gte=100"` // at least 1.00
 	CustomerName    string                 `json:"customer_name" binding:"required"`
 	CustomerContact string                 `json:"customer_contact" binding:"required"`
 	DeliveryAddress map[string]interface{} `json:"delivery_address" binding:"required"`
+	CustomerNotes   string                 `json:"customer_notes,omitempty" binding:"max=250"` // cooking or delivery instructions
+	// Pay with a saved card or UPI ID, or save the card or UPI ID paid with at Checkout
+	SavedPaymentMethodID string `json:"saved_payment_method_id,omitempty"`
+	SavePaymentMethod    bool   `json:"save_payment_method,omitempty"`
 }
 
 type PlaceOrderResponse struct {
-	OrderID         string `json:"order_id"`
-	RazorpayOrderID string `json:"razorpay_order_id"`
-	Amount          int    `json:"amount"` // in paise
-	Currency        string `json:"currency"`
-	PaymentID       string `json:"payment_id"`
+	OrderID         string     `json:"order_id"`
+	RazorpayOrderID string     `json:"razorpay_order_id"`
+	Amount          int        `json:"amount"` // in paise
+	Currency        string     `json:"currency"`
+	PaymentID       string     `json:"payment_id"`
+	PromisedAt      *time.Time `json:"promised_at,omitempty"` // when the order should arrive
+	// Customer to open Checkout for, so Razorpay saves the method paid with
+	RazorpayCustomerID string `json:"razorpay_customer_id,omitempty"`
+	// A saved method is charged straight away; the customer may still need to authenticate
+	// the payment at NextActionURL. A failed charge can be retried at checkout.
+	PaymentStatus string `json:"payment_status,omitempty"`
+	NextActionURL string `json:"next_action_url,omitempty"`
 }
 
 // CreateRazorpayOrder creates a Razorpay order and stores payment record
@@ -105,12 +167,25 @@ func (s *RazorpayService) CreateRazorpayOrder(ctx context.Context, req *PlaceOrd
 	if err != nil {
 		return nil, fmt.Errorf("invalid restaurant ID: %v", err)
 	}
+	if err := s.restaurants.EnsureAcceptingOrders(ctx, restaurantUUID); err != nil {
+		return nil, err
+	}
+	if err := s.restaurants.EnsureNotPaused(ctx, restaurantUUID, time.Now()); err != nil {
+		return nil, err
+	}
 
 	cartUUID, err := uuid.Parse(req.CartID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid cart ID: %v", err)
 	}
 
+	var savedMethod *models.SavedPaymentMethod
+	if req.SavedPaymentMethodID != "" {
+		if savedMethod, err = userSavedMethod(ctx, s.savedMethods, userUUID, req.SavedPaymentMethodID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create order record
 	order := &models.Order{
 		ID:                             uuid.New(),
@@ -121,7 +196,10 @@ func (s *RazorpayService) CreateRazorpayOrder(ctx context.Context, req *PlaceOrd
 		TotalAmount:                    req.Amount,
 		CustomerName:                   req.CustomerName,
 		CustomerContact:                req.CustomerContact,
+		CustomerNotes:                  strings.TrimSpace(req.CustomerNotes),
 		DeliveryFullAddressWithLatLong: req.DeliveryAddress,
+		Currency:                       s.restaurants.Currency(ctx, restaurantUUID),
+		Language:                       i18n.LanguageFromContext(ctx),
 		CreatedAt:                      time.Now(),
 	}
 
@@ -131,14 +209,30 @@ func (s *RazorpayService) CreateRazorpayOrder(ctx context.Context, req *PlaceOrd
 			order.AddressID = &addressUUID
 		}
 	}
+	if err := assignDeliveryCode(order); err != nil {
+		return nil, err
+	}
+	s.restaurants.PromiseDelivery(ctx, restaurantUUID, 0, nil).apply(order)
+
+	assessment, err := s.risk.Assess(ctx, RiskCheck{
+		Event:           RiskEventCheckout,
+		UserID:          userUUID,
+		PaymentMethod:   "razorpay",
+		AddressID:       order.AddressID,
+		DeliveryAddress: req.DeliveryAddress,
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	// Save order to database
 	if err := s.orderRepo.Create(ctx, order); err != nil {
 		return nil, fmt.Errorf("failed to create order: %v", err)
 	}
 
-	// Create Razorpay order
-	amountInPaise := int(req.Amount * 100) // Convert to paise
+	// Create Razorpay order; Razorpay takes amounts in the currency's minor unit
+	currency := i18n.CurrencyOf(order.Currency)
+	amountInPaise := int(currency.ToMinor(req.Amount.Float64()))
 
 	// Mock Razorpay order creation (in real implementation, make HTTP request to Razorpay)
 	razorpayOrderID := fmt.Sprintf("order_%s", uuid.New().String()[:8])
@@ -155,7 +249,7 @@ func (s *RazorpayService) CreateRazorpayOrder(ctx context.Context, req *PlaceOrd
 		CreatedAt:     time.Now(),
 		Metadata: models.JSONB{
 			"razorpay_order_id": razorpayOrderID,
-			"currency":          "INR",
+			"currency":          currency.Code,
 			"amount_paise":      amountInPaise,
 		},
 	}
@@ -169,14 +263,27 @@ func (s *RazorpayService) CreateRazorpayOrder(ctx context.Context, req *PlaceOrd
 	if err := s.orderRepo.Update(ctx, order); err != nil {
 		return nil, fmt.Errorf("failed to update order with payment ID: %v", err)
 	}
+	s.risk.Record(ctx, assessment, &order.ID, nil)
 
-	return &PlaceOrderResponse{
+	response := &PlaceOrderResponse{
 		OrderID:         order.ID.String(),
 		RazorpayOrderID: razorpayOrderID,
 		Amount:          amountInPaise,
-		Currency:        "INR",
+		Currency:        currency.Code,
 		PaymentID:       payment.ID.String(),
-	}, nil
+		PromisedAt:      order.PromisedAt,
+	}
+	switch {
+	case savedMethod != nil:
+		if err := s.chargeSavedMethod(ctx, order, payment, savedMethod, response); err != nil {
+			return nil, err
+		}
+	case req.SavePaymentMethod:
+		if err := s.prepareMethodSaving(ctx, order, payment, response); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
 }
 
 // HandlePaymentWebhook processes Razorpay webhooks for payment updates
@@ -197,6 +304,16 @@ func (s *RazorpayService) HandlePaymentWebhook(ctx context.Context, payload []by
 		return s.handlePaymentSuccess(ctx, webhook.Payload)
 	case "payment.failed":
 		return s.handlePaymentFailure(ctx, webhook.Payload)
+	case "payment_link.paid", "payment_link.partially_paid":
+		return s.handlePaymentLinkPaid(ctx, webhook.Payload)
+	case "payment_link.expired":
+		return s.handleAttemptClosed(ctx, webhook.Payload, "payment_link", "expired")
+	case "payment_link.cancelled":
+		return s.handleAttemptClosed(ctx, webhook.Payload, "payment_link", "abandoned")
+	case "qr_code.credited":
+		return s.handleQRCodeCredited(ctx, webhook.Payload)
+	case "qr_code.closed":
+		return s.handleAttemptClosed(ctx, webhook.Payload, "qr_code", "expired")
 	default:
 		// Log unhandled event but don't return error
 		fmt.Printf("Unhandled webhook event: %s\n", webhook.Event)
@@ -210,41 +327,109 @@ func (s *RazorpayService) handlePaymentSuccess(ctx context.Context, payload map[
 		return fmt.Errorf("invalid payment data in webhook")
 	}
 
-	razorpayOrderID, ok := paymentData["order_id"].(string)
-	if !ok {
-		return fmt.Errorf("missing order_id in payment data")
+	// Payments through a link or a QR code are settled by their own events
+	razorpayOrderID, _ := paymentData["order_id"].(string)
+	if razorpayOrderID == "" {
+		return nil
 	}
 
 	// Find payment by razorpay order ID
 	payment, err := s.paymentRepo.GetByTransactionID(ctx, razorpayOrderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("No payment for Razorpay order %s, ignoring payment event", razorpayOrderID)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("payment not found for order ID %s: %v", razorpayOrderID, err)
 	}
 
-	// Update payment status
-	payment.Status = "success"
-	if err := s.paymentRepo.Update(ctx, payment); err != nil {
-		return fmt.Errorf("failed to update payment status: %v", err)
+	return s.confirmPayment(ctx, payment, paymentData)
+}
+
+// confirmPayment records a captured gateway payment against our payment and confirms its order
+func (s *RazorpayService) confirmPayment(ctx context.Context, payment *models.Payment, paymentData map[string]interface{}) error {
+	// A replayed event, or capture after authorization, finds the payment already recorded
+	if payment.Status == "success" {
+		return nil
 	}
 
-	// Update order status
-	order, err := s.orderRepo.GetByID(ctx, payment.OrderID)
-	if err != nil {
-		return fmt.Errorf("failed to get order: %v", err)
+	gatewayPaymentID, _ := paymentData["id"].(string)
+	if payment.Metadata == nil {
+		payment.Metadata = make(models.JSONB)
 	}
+	payment.Metadata["razorpay_payment_id"] = gatewayPaymentID
+	if method, ok := paymentData["method"].(string); ok {
+		payment.Metadata["gateway_method"] = method
+	}
+	if vpa, ok := paymentData["vpa"].(string); ok && vpa != "" {
+		payment.Metadata["vpa"] = vpa
+	}
+	s.saveMethodFromPayment(ctx, payment, paymentData)
+
+	// The payment is saved with the order's confirmation, so a conflict on the order leaves it
+	// unrecorded and the confirmation is read and applied again
+	var (
+		order          *models.Order
+		requiresRefund bool
+	)
+	err := retryOnConflict(ctx, func() error {
+		var err error
+		order, err = s.orderRepo.GetByID(ctx, payment.OrderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %v", err)
+		}
+		payment.Status = "success"
+
+		// A late capture on an expired order, or on an order already paid through
+		// another attempt, must be refunded rather than fulfilled
+		paidElsewhere := order.OrderStatus != "pending_payment" && order.PaymentID != nil && *order.PaymentID != payment.ID
+		requiresRefund = order.OrderStatus == "cancelled" || paidElsewhere
+		if requiresRefund {
+			payment.Metadata["requires_refund"] = true
+			if err := s.paymentRepo.Update(ctx, payment); err != nil {
+				return fmt.Errorf("failed to update payment status: %v", err)
+			}
+			return nil
+		}
 
-	order.OrderStatus = "confirmed"
-	if err := s.orderRepo.Update(ctx, order); err != nil {
-		return fmt.Errorf("failed to update order status: %v", err)
+		// The PaymentCaptured event is written with the order confirmation
+		captured, err := messaging.Events.NewEnvelope(messaging.EventPaymentCaptured, order.RestaurantID.String(), messaging.PaymentCaptured{
+			PaymentID:        payment.ID.String(),
+			OrderID:          order.ID.String(),
+			UserID:           order.UserID.String(),
+			Amount:           payment.Amount.Float64(),
+			Method:           payment.Method,
+			GatewayPaymentID: gatewayPaymentID,
+			CapturedAt:       time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		capturedEvent, err := NewOutboxEvent("payment_events", order.ID.String(), captured)
+		if err != nil {
+			return err
+		}
+
+		order.PaymentID = &payment.ID
+		order.OrderStatus = "confirmed"
+		if err := s.orderRepo.UpdateWithPayment(ctx, order, payment, []models.OutboxEvent{capturedEvent}); err != nil {
+			return fmt.Errorf("failed to update order status: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	if requiresRefund {
+		log.Printf("Payment %s captured for %s order %s, refund required", payment.ID, order.OrderStatus, order.ID)
+		return nil
+	}
+	s.ledger.PaymentCaptured(ctx, order, payment.ID, "payment:"+payment.ID.String(), payment.Amount)
+	s.tracking.PublishStatus(ctx, order)
 
-	// Create delivery order with restaurant's delivery partner
-	if s.deliveryService != nil {
-		go func() {
-			if err := s.deliveryService.CreateDeliveryOrder(context.Background(), order); err != nil {
-				fmt.Printf("Failed to create delivery order for order %s: %v\n", order.ID.String(), err)
-			}
-		}()
+	// Book the delivery when payment confirmation is the dispatch trigger
+	if s.dispatcher != nil {
+		s.dispatcher.OrderConfirmed(order)
 	}
 
 	return nil
@@ -256,31 +441,328 @@ func (s *RazorpayService) handlePaymentFailure(ctx context.Context, payload map[
 		return fmt.Errorf("invalid payment data in webhook")
 	}
 
-	razorpayOrderID, ok := paymentData["order_id"].(string)
-	if !ok {
-		return fmt.Errorf("missing order_id in payment data")
+	// A failed attempt on a link or a QR code leaves it open for another try
+	razorpayOrderID, _ := paymentData["order_id"].(string)
+	if razorpayOrderID == "" {
+		return nil
 	}
 
 	// Find payment by razorpay order ID
 	payment, err := s.paymentRepo.GetByTransactionID(ctx, razorpayOrderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("No payment for Razorpay order %s, ignoring payment event", razorpayOrderID)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("payment not found for order ID %s: %v", razorpayOrderID, err)
 	}
 
 	// Update payment status
 	payment.Status = "failed"
+	if reason, ok := paymentData["error_description"].(string); ok {
+		if payment.Metadata == nil {
+			payment.Metadata = make(models.JSONB)
+		}
+		payment.Metadata["failure_reason"] = reason
+	}
 	if err := s.paymentRepo.Update(ctx, payment); err != nil {
 		return fmt.Errorf("failed to update payment status: %v", err)
 	}
 
-	// Delete the order as payment failed
-	if err := s.orderRepo.Delete(ctx, payment.OrderID); err != nil {
-		return fmt.Errorf("failed to delete order after payment failure: %v", err)
+	// Keep the order awaiting payment so the customer can retry until it expires
+	order, err := s.orderRepo.GetByID(ctx, payment.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to get order: %v", err)
 	}
 
+	err = updateOrder(ctx, s.orderRepo, order, func(order *models.Order) error {
+		appendOrderLog(order, order.OrderStatus, "Payment failed")
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update order: %v", err)
+	}
+
+	s.publishPaymentAbandoned(order, payment, "failed")
 	return nil
 }
 
+// RetryPayment creates a fresh gateway order for a food order whose payment failed or was abandoned
+func (s *RazorpayService) RetryPayment(ctx context.Context, orderID, userID string) (*PlaceOrderResponse, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, errors.New("invalid order ID")
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	if err != nil || order.UserID.String() != userID {
+		return nil, errors.New("order not found")
+	}
+
+	if order.OrderStatus != "pending_payment" {
+		return nil, fmt.Errorf("cannot retry payment for a %s order", order.OrderStatus)
+	}
+
+	if s.recovery.ExpireAfter > 0 && time.Since(order.CreatedAt) > s.recovery.ExpireAfter {
+		return nil, errors.New("payment window for this order has expired")
+	}
+
+	attempts, err := s.paymentRepo.CountByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count payment attempts: %v", err)
+	}
+	if int(attempts) > s.recovery.MaxRetries {
+		return nil, errors.New("maximum payment retries reached")
+	}
+
+	// Retire the previous attempt so a late webhook for it is easy to spot
+	if order.PaymentID != nil {
+		previous, err := s.paymentRepo.GetByID(ctx, *order.PaymentID)
+		if err == nil {
+			if previous.Status == "success" {
+				return nil, errors.New("order is already paid")
+			}
+			if previous.Method == PaymentMethodLink && previous.Status != "abandoned" {
+				return nil, errors.New("order is paid through its payment link")
+			}
+			if previous.Status == "pending" {
+				previous.Status = "abandoned"
+				if err := s.paymentRepo.Update(ctx, previous); err != nil {
+					return nil, fmt.Errorf("failed to update previous payment: %v", err)
+				}
+			}
+		}
+	}
+
+	currency := i18n.CurrencyOf(order.Currency)
+	amountInPaise := int(currency.ToMinor(order.TotalAmount.Float64()))
+	attempt := int(attempts) + 1
+
+	razorpayOrder, err := s.createRazorpayOrderAPI(ctx, &RazorpayOrderRequest{
+		Amount:   amountInPaise,
+		Currency: currency.Code,
+		Receipt:  fmt.Sprintf("%s-%d", order.ID.String()[:8], attempt),
+		Notes: map[string]interface{}{
+			"order_id": order.ID.String(),
+			"attempt":  attempt,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Razorpay order: %v", err)
+	}
+
+	payment := &models.Payment{
+		ID:            uuid.New(),
+		OrderID:       order.ID,
+		UserID:        order.UserID,
+		Amount:        order.TotalAmount,
+		Method:        "razorpay",
+		Status:        "pending",
+		TransactionID: razorpayOrder.ID,
+		CreatedAt:     time.Now(),
+		Metadata: models.JSONB{
+			"razorpay_order_id": razorpayOrder.ID,
+			"currency":          currency.Code,
+			"amount_paise":      amountInPaise,
+			"attempt":           attempt,
+		},
+	}
+
+	if err := s.paymentRepo.Create(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to create payment record: %v", err)
+	}
+
+	order.PaymentID = &payment.ID
+	appendOrderLog(order, order.OrderStatus, fmt.Sprintf("Payment retry %d started", attempt-1))
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to update order with payment ID: %v", err)
+	}
+
+	return &PlaceOrderResponse{
+		OrderID:         order.ID.String(),
+		RazorpayOrderID: razorpayOrder.ID,
+		Amount:          amountInPaise,
+		Currency:        currency.Code,
+		PaymentID:       payment.ID.String(),
+		PromisedAt:      order.PromisedAt,
+	}, nil
+}
+
+// RecoverUnpaidOrders emits reminder events for idle payments and cancels orders
+// that stayed unpaid past the expiry window
+func (s *RazorpayService) RecoverUnpaidOrders(ctx context.Context) (reminded, expired int, err error) {
+	if s.recovery.ReminderAfter <= 0 {
+		return 0, 0, nil
+	}
+
+	now := time.Now()
+	orders, err := s.orderRepo.GetAwaitingPaymentSince(ctx, now.Add(-s.recovery.ReminderAfter), 100)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get unpaid orders: %v", err)
+	}
+
+	for i := range orders {
+		order := &orders[i]
+
+		var payment *models.Payment
+		if order.PaymentID != nil {
+			payment, _ = s.paymentRepo.GetByID(ctx, *order.PaymentID)
+		}
+
+		// Orders paid through a payment link wait until the link expires, and Razorpay reminds
+		// the customer itself
+		if payment != nil && payment.Method == PaymentMethodLink {
+			if expiresAt, ok := paymentLinkExpiry(payment); ok && now.After(expiresAt) {
+				if s.expireOrder(ctx, order, payment) {
+					expired++
+				}
+			}
+			continue
+		}
+
+		if s.recovery.ExpireAfter > 0 && now.Sub(order.CreatedAt) >= s.recovery.ExpireAfter {
+			if s.expireOrder(ctx, order, payment) {
+				expired++
+			}
+			continue
+		}
+
+		// One reminder per attempt, once the latest attempt has been idle long enough
+		if payment == nil || payment.Status != "pending" || now.Sub(payment.CreatedAt) < s.recovery.ReminderAfter {
+			continue
+		}
+		if _, sent := payment.Metadata["reminder_sent_at"]; sent {
+			continue
+		}
+
+		if payment.Metadata == nil {
+			payment.Metadata = make(models.JSONB)
+		}
+		payment.Metadata["reminder_sent_at"] = now
+		if err := s.paymentRepo.Update(ctx, payment); err != nil {
+			log.Printf("Failed to record payment reminder for order %s: %v", order.ID, err)
+			continue
+		}
+
+		s.publishPaymentAbandoned(order, payment, "idle")
+		reminded++
+	}
+
+	return reminded, expired, nil
+}
+
+// expireOrder cancels an order that was never paid, reporting whether it was. Razorpay is
+// asked first whether the open attempt was paid without its webhook arriving, in which case
+// the payment is recorded instead. Failures are logged so the sweep goes on to other orders.
+func (s *RazorpayService) expireOrder(ctx context.Context, order *models.Order, payment *models.Payment) bool {
+	if payment != nil {
+		switch payment.Status {
+		case "success", "partially_paid":
+			return false
+		case "pending", "failed":
+			// A failed attempt can still be paid on retry at Checkout
+			paid, err := s.settleFromGateway(ctx, payment)
+			if err != nil {
+				log.Printf("Failed to check payment %s of unpaid order %s at Razorpay: %v", payment.ID, order.ID, err)
+				return false
+			}
+			if paid {
+				return false
+			}
+		}
+	}
+	if s.orders == nil {
+		log.Printf("Unpaid order %s not expired: no order expirer is set", order.ID)
+		return false
+	}
+
+	expired, err := s.orders.ExpireUnpaidOrder(ctx, order.ID)
+	if err != nil {
+		log.Printf("Failed to expire unpaid order %s: %v", order.ID, err)
+		return false
+	}
+	if !expired {
+		return false
+	}
+
+	if payment != nil && payment.Status == "pending" {
+		payment.Status = "expired"
+		if err := s.paymentRepo.Update(ctx, payment); err != nil {
+			log.Printf("Failed to expire payment %s: %v", payment.ID, err)
+		}
+	}
+	order.OrderStatus = "cancelled"
+	s.publishPaymentAbandoned(order, payment, "expired")
+	return true
+}
+
+// settleFromGateway asks Razorpay whether a pending payment attempt was paid and records the
+// payment if it was, reporting whether it was paid
+func (s *RazorpayService) settleFromGateway(ctx context.Context, payment *models.Payment) (bool, error) {
+	if payment.TransactionID == "" {
+		return false, nil
+	}
+
+	var url string
+	switch {
+	case payment.Method == PaymentMethodLink:
+		var link map[string]interface{}
+		if err := s.callAPI(ctx, http.MethodGet, s.baseURL+"/payment_links/"+payment.TransactionID, nil, &link); err != nil {
+			return false, err
+		}
+		if amountPaid, _ := link["amount_paid"].(float64); amountPaid <= 0 {
+			return false, nil
+		}
+		return true, s.handlePaymentLinkPaid(ctx, map[string]interface{}{"payment_link": link})
+	case payment.Method == PaymentMethodUPI && payment.Metadata["upi_flow"] == UPIFlowQR:
+		url = s.baseURL + "/payments/qr_codes/" + payment.TransactionID + "/payments"
+	default:
+		url = s.baseURL + "/orders/" + payment.TransactionID + "/payments"
+	}
+
+	var page struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := s.callAPI(ctx, http.MethodGet, url, nil, &page); err != nil {
+		return false, err
+	}
+	for _, paymentData := range page.Items {
+		if paymentData["status"] == "captured" {
+			return true, s.confirmPayment(ctx, payment, paymentData)
+		}
+	}
+	return false, nil
+}
+
+// publishPaymentAbandoned emits a payment_abandoned event for reminder notifications.
+// reason is failed, idle or expired.
+func (s *RazorpayService) publishPaymentAbandoned(order *models.Order, payment *models.Payment, reason string) {
+	data := map[string]interface{}{
+		"order_id":      order.ID.String(),
+		"restaurant_id": order.RestaurantID.String(),
+		"amount":        order.TotalAmount,
+		"reason":        reason,
+		"can_retry":     reason != "expired",
+	}
+	if payment != nil {
+		data["payment_id"] = payment.ID.String()
+		data["payment_status"] = payment.Status
+	}
+	if s.recovery.ExpireAfter > 0 {
+		data["expires_at"] = order.CreatedAt.Add(s.recovery.ExpireAfter)
+	}
+
+	orderEvent := messaging.OrderEvent{
+		Type:    "payment_abandoned",
+		OrderID: order.ID.String(),
+		UserID:  order.UserID.String(),
+		Data:    data,
+	}
+	if err := s.kafkaProducer.SendMessage("order_events", s.kafkaBrokers, order.ID.String(), orderEvent); err != nil {
+		log.Printf("Failed to publish payment_abandoned for order %s: %v", order.ID, err)
+	}
+}
+
 // verifyWebhookSignature verifies the Razorpay webhook signature
 func (s *RazorpayService) verifyWebhookSignature(payload []byte, signature string) bool {
 	expectedSignature := s.generateWebhookSignature(payload)
@@ -293,35 +775,69 @@ func (s *RazorpayService) generateWebhookSignature(payload []byte) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-// Mock method for real Razorpay API call (replace with actual HTTP client call)
-func (s *RazorpayService) createRazorpayOrderAPI(req *RazorpayOrderRequest) (*RazorpayOrderResponse, error) {
-	// This would be a real HTTP request to Razorpay API
-	// For now, returning a mock response
-
-	reqBody, err := json.Marshal(req)
-	if err != nil {
+func (s *RazorpayService) createRazorpayOrderAPI(ctx context.Context, req *RazorpayOrderRequest) (*RazorpayOrderResponse, error) {
+	var order RazorpayOrderResponse
+	if err := s.callAPI(ctx, http.MethodPost, s.baseURL+"/orders", req, &order); err != nil {
 		return nil, err
 	}
+	if order.ID == "" {
+		return nil, errors.New("Razorpay returned no order ID")
+	}
+	return &order, nil
+}
 
-	httpReq, err := http.NewRequest("POST", s.baseURL+"/orders", bytes.NewBuffer(reqBody))
-	if err != nil {
-		return nil, err
+// razorpayError is the body Razorpay answers a failed request with
+type razorpayError struct {
+	Error struct {
+		Code        string `json:"code"`
+		Description string `json:"description"`
+	} `json:"error"`
+}
+
+// callAPI makes an authenticated request to Razorpay, sending body as JSON when it is not nil
+// and decoding the response into out when it is not nil. Declines and other failures come back
+// as errors carrying Razorpay's description.
+func (s *RazorpayService) callAPI(ctx context.Context, method, url string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %v", err)
+		}
+		reqBody = bytes.NewReader(encoded)
 	}
 
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
 	httpReq.SetBasicAuth(s.apiKey, s.apiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
 
-	// Mock response for now
-	mockResponse := &RazorpayOrderResponse{
-		ID:        fmt.Sprintf("order_%s", uuid.New().String()[:8]),
-		Entity:    "order",
-		Amount:    req.Amount,
-		Currency:  req.Currency,
-		Receipt:   req.Receipt,
-		Status:    "created",
-		CreatedAt: time.Now().Unix(),
-		Notes:     req.Notes,
+	resp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %v", err)
 	}
+	defer resp.Body.Close()
 
-	return mockResponse, nil
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var failure razorpayError
+		if json.Unmarshal(respBody, &failure) == nil && failure.Error.Description != "" {
+			return fmt.Errorf("Razorpay API error (status %d, %s): %s", resp.StatusCode, failure.Error.Code, failure.Error.Description)
+		}
+		return fmt.Errorf("Razorpay API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
 }
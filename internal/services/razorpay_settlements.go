@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// razorpayPageSize is the most items Razorpay returns per list request
+const razorpayPageSize = 100
+
+// RazorpayPaymentEntity is a payment as Razorpay lists it
+type RazorpayPaymentEntity struct {
+	ID        string                 `json:"id"`
+	Amount    int64                  `json:"amount"` // in paise
+	Currency  string                 `json:"currency"`
+	Status    string                 `json:"status"` // created, authorized, captured, refunded, failed
+	OrderID   string                 `json:"order_id"`
+	Method    string                 `json:"method"`
+	Fee       int64                  `json:"fee"`
+	Tax       int64                  `json:"tax"`
+	Notes     map[string]interface{} `json:"notes"`
+	CreatedAt int64                  `json:"created_at"`
+}
+
+type RazorpayPaymentCollection struct {
+	Count int                     `json:"count"`
+	Items []RazorpayPaymentEntity `json:"items"`
+}
+
+// RazorpaySettlementItem is a line of Razorpay's settlement report for a day
+type RazorpaySettlementItem struct {
+	EntityID      string                 `json:"entity_id"`
+	Type          string                 `json:"type"` // payment, refund, adjustment
+	Amount        int64                  `json:"amount"`
+	Currency      string                 `json:"currency"`
+	Fee           int64                  `json:"fee"`
+	Tax           int64                  `json:"tax"`
+	PaymentID     string                 `json:"payment_id"`
+	OrderID       string                 `json:"order_id"`
+	SettlementID  string                 `json:"settlement_id"`
+	SettlementUTR string                 `json:"settlement_utr"`
+	Notes         map[string]interface{} `json:"notes"`
+	SettledAt     int64                  `json:"settled_at"`
+}
+
+type RazorpaySettlementCollection struct {
+	Count int                      `json:"count"`
+	Items []RazorpaySettlementItem `json:"items"`
+}
+
+// FetchPayments lists the payments created at Razorpay in [from, to)
+func (s *RazorpayService) FetchPayments(ctx context.Context, from, to time.Time) ([]RazorpayPaymentEntity, error) {
+	var payments []RazorpayPaymentEntity
+	for skip := 0; ; skip += razorpayPageSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		page, err := s.fetchPaymentsAPI(ctx, from.Unix(), to.Unix()-1, skip)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch Razorpay payments: %v", err)
+		}
+		payments = append(payments, page.Items...)
+		if page.Count < razorpayPageSize {
+			return payments, nil
+		}
+	}
+}
+
+// FetchSettlements lists the lines of the settlements Razorpay made on the given day
+func (s *RazorpayService) FetchSettlements(ctx context.Context, day time.Time) ([]RazorpaySettlementItem, error) {
+	var items []RazorpaySettlementItem
+	for skip := 0; ; skip += razorpayPageSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		page, err := s.fetchSettlementReconAPI(ctx, day, skip)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch Razorpay settlements: %v", err)
+		}
+		items = append(items, page.Items...)
+		if page.Count < razorpayPageSize {
+			return items, nil
+		}
+	}
+}
+
+func (s *RazorpayService) fetchPaymentsAPI(ctx context.Context, from, to int64, skip int) (*RazorpayPaymentCollection, error) {
+	url := fmt.Sprintf("%s/payments?from=%d&to=%d&count=%d&skip=%d", s.baseURL, from, to, razorpayPageSize, skip)
+	var page RazorpayPaymentCollection
+	if err := s.callAPI(ctx, http.MethodGet, url, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (s *RazorpayService) fetchSettlementReconAPI(ctx context.Context, day time.Time, skip int) (*RazorpaySettlementCollection, error) {
+	url := fmt.Sprintf("%s/settlements/recon/combined?year=%d&month=%d&day=%d&count=%d&skip=%d",
+		s.baseURL, day.Year(), int(day.Month()), day.Day(), razorpayPageSize, skip)
+	var page RazorpaySettlementCollection
+	if err := s.callAPI(ctx, http.MethodGet, url, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/i18n"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PaymentMethodUPI marks a payment collected by UPI intent, collect request or QR code
+const PaymentMethodUPI = "razorpay_upi"
+
+const (
+	UPIFlowIntent  = "intent"  // the customer's UPI app opens from a upi:// link
+	UPIFlowCollect = "collect" // a request is sent to the customer's UPI ID to approve in their app
+	UPIFlowQR      = "qr"      // the customer scans a QR code for the exact amount
+
+	upiPaymentWindow = 15 * time.Minute
+)
+
+var upiVPAPattern = regexp.MustCompile(`^[a-zA-Z0-9.\-_]{2,256}@[a-zA-Z]{2,64}$`)
+
+// UPIPaymentRequest starts a UPI payment for an order awaiting payment
+type UPIPaymentRequest struct {
+	Flow string `json:"flow" binding:"required,oneof=intent collect qr"`
+	VPA  string `json:"vpa,omitempty"` // the customer's UPI ID, required for collect
+}
+
+type RazorpayUPIDetails struct {
+	Flow       string `json:"flow"`
+	VPA        string `json:"vpa,omitempty"`
+	ExpiryTime int    `json:"expiry_time,omitempty"` // minutes a collect request stays open
+}
+
+type RazorpayUPIPaymentRequest struct {
+	Amount      int                `json:"amount"` // in paise
+	Currency    string             `json:"currency"`
+	OrderID     string             `json:"order_id"`
+	Method      string             `json:"method"`
+	Contact     string             `json:"contact"`
+	Email       string             `json:"email,omitempty"`
+	Description string             `json:"description,omitempty"`
+	UPI         RazorpayUPIDetails `json:"upi"`
+}
+
+type RazorpayUPIPaymentResponse struct {
+	PaymentID string `json:"razorpay_payment_id"`
+	Link      string `json:"link,omitempty"` // upi:// intent link
+}
+
+type RazorpayQRCodeRequest struct {
+	Type          string                 `json:"type"`
+	Name          string                 `json:"name"`
+	Usage         string                 `json:"usage"`
+	FixedAmount   bool                   `json:"fixed_amount"`
+	PaymentAmount int                    `json:"payment_amount"` // in paise
+	Description   string                 `json:"description"`
+	CloseBy       int64                  `json:"close_by"` // unix seconds
+	Notes         map[string]interface{} `json:"notes,omitempty"`
+}
+
+type RazorpayQRCodeResponse struct {
+	ID            string `json:"id"`
+	ImageURL      string `json:"image_url"`
+	Status        string `json:"status"` // active, closed
+	PaymentAmount int    `json:"payment_amount"`
+	CloseBy       int64  `json:"close_by"`
+	CreatedAt     int64  `json:"created_at"`
+}
+
+// StartUPIPayment starts a UPI payment for the customer's own order awaiting payment, replacing
+// the order's unpaid attempt
+func (s *RazorpayService) StartUPIPayment(ctx context.Context, userID, orderID string, req *UPIPaymentRequest) (*OrderPaymentStatus, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, ErrOrderPaymentNotFound
+	}
+	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && order.UserID.String() != userID) {
+		return nil, ErrOrderPaymentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %v", err)
+	}
+	if s.recovery.ExpireAfter > 0 && time.Since(order.CreatedAt) > s.recovery.ExpireAfter {
+		return nil, fmt.Errorf("%w: the payment window for this order has expired", ErrOrderPaymentState)
+	}
+	return s.startUPIPayment(ctx, order, req)
+}
+
+// StartRestaurantUPIPayment starts a UPI payment for one of the restaurant's orders awaiting
+// payment: a QR code to show the customer at the counter, or a collect request to their UPI ID
+func (s *RazorpayService) StartRestaurantUPIPayment(ctx context.Context, restaurantID, orderID string, req *UPIPaymentRequest) (*OrderPaymentStatus, error) {
+	order, err := s.restaurantOrder(ctx, restaurantID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return s.startUPIPayment(ctx, order, req)
+}
+
+func (s *RazorpayService) startUPIPayment(ctx context.Context, order *models.Order, req *UPIPaymentRequest) (*OrderPaymentStatus, error) {
+	if req.Flow == UPIFlowCollect && !upiVPAPattern.MatchString(req.VPA) {
+		return nil, fmt.Errorf("%w: a collect request needs a valid UPI ID", ErrOrderPaymentInvalid)
+	}
+	if err := s.retirePaymentAttempt(ctx, order); err != nil {
+		return nil, err
+	}
+
+	currency := i18n.CurrencyOf(order.Currency)
+	amountInPaise := int(currency.ToMinor(order.TotalAmount.Float64()))
+	expiresAt := time.Now().Add(upiPaymentWindow)
+	description := fmt.Sprintf("Order %s from %s", order.ID.String()[:8], order.Restaurant.Name)
+
+	payment := &models.Payment{
+		ID:        uuid.New(),
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		Amount:    order.TotalAmount,
+		Method:    PaymentMethodUPI,
+		Status:    "pending",
+		CreatedAt: time.Now(),
+		Metadata: models.JSONB{
+			"upi_flow":     req.Flow,
+			"currency":     currency.Code,
+			"amount_paise": amountInPaise,
+			"expires_at":   expiresAt,
+		},
+	}
+
+	switch req.Flow {
+	case UPIFlowQR:
+		// QR payments have no Razorpay order; qr_code.credited settles them by the QR code's ID
+		qr, err := s.createQRCodeAPI(ctx, &RazorpayQRCodeRequest{
+			Type:          "upi_qr",
+			Name:          order.Restaurant.Name,
+			Usage:         "single_use",
+			FixedAmount:   true,
+			PaymentAmount: amountInPaise,
+			Description:   description,
+			CloseBy:       expiresAt.Unix(),
+			Notes: map[string]interface{}{
+				"order_id": order.ID.String(),
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Razorpay QR code: %v", err)
+		}
+		payment.TransactionID = qr.ID
+		payment.Metadata["qr_code_id"] = qr.ID
+		payment.Metadata["qr_image_url"] = qr.ImageURL
+	default:
+		// Intent and collect payments settle through the usual payment events for their Razorpay order
+		razorpayOrder, err := s.createRazorpayOrderAPI(ctx, &RazorpayOrderRequest{
+			Amount:   amountInPaise,
+			Currency: currency.Code,
+			Receipt:  fmt.Sprintf("%s-upi", order.ID.String()[:8]),
+			Notes: map[string]interface{}{
+				"order_id": order.ID.String(),
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Razorpay order: %v", err)
+		}
+		upi, err := s.createUPIPaymentAPI(ctx, &RazorpayUPIPaymentRequest{
+			Amount:      amountInPaise,
+			Currency:    currency.Code,
+			OrderID:     razorpayOrder.ID,
+			Method:      "upi",
+			Contact:     order.CustomerContact,
+			Email:       order.User.Email,
+			Description: description,
+			UPI: RazorpayUPIDetails{
+				Flow:       req.Flow,
+				VPA:        req.VPA,
+				ExpiryTime: int(upiPaymentWindow / time.Minute),
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create UPI payment: %v", err)
+		}
+		payment.TransactionID = razorpayOrder.ID
+		payment.Metadata["razorpay_order_id"] = razorpayOrder.ID
+		payment.Metadata["razorpay_payment_id"] = upi.PaymentID
+		if req.Flow == UPIFlowCollect {
+			payment.Metadata["vpa"] = req.VPA
+		} else {
+			payment.Metadata["intent_url"] = upi.Link
+		}
+	}
+
+	if err := s.paymentRepo.Create(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to create payment record: %v", err)
+	}
+
+	order.PaymentID = &payment.ID
+	appendOrderLog(order, order.OrderStatus, fmt.Sprintf("UPI %s payment started", req.Flow))
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to update order with payment ID: %v", err)
+	}
+	return orderPaymentStatus(order, payment), nil
+}
+
+// handleQRCodeCredited confirms the order whose QR code the customer paid
+func (s *RazorpayService) handleQRCodeCredited(ctx context.Context, payload map[string]interface{}) error {
+	qrData, ok := webhookEntity(payload, "qr_code")
+	if !ok {
+		return fmt.Errorf("invalid QR code data in webhook")
+	}
+	qrID, ok := qrData["id"].(string)
+	if !ok {
+		return fmt.Errorf("missing id in QR code data")
+	}
+	paymentData, ok := webhookEntity(payload, "payment")
+	if !ok {
+		return fmt.Errorf("invalid payment data in webhook")
+	}
+
+	payment, err := s.paymentRepo.GetByTransactionID(ctx, qrID)
+	if err != nil {
+		return fmt.Errorf("payment not found for QR code %s: %v", qrID, err)
+	}
+	return s.confirmPayment(ctx, payment, paymentData)
+}
+
+func (s *RazorpayService) createUPIPaymentAPI(ctx context.Context, req *RazorpayUPIPaymentRequest) (*RazorpayUPIPaymentResponse, error) {
+	var upi RazorpayUPIPaymentResponse
+	if err := s.callAPI(ctx, http.MethodPost, s.baseURL+"/payments/create/upi", req, &upi); err != nil {
+		return nil, err
+	}
+	if upi.PaymentID == "" {
+		return nil, errors.New("Razorpay returned no payment ID")
+	}
+	if req.UPI.Flow == UPIFlowIntent && upi.Link == "" {
+		return nil, errors.New("Razorpay returned no UPI intent link")
+	}
+	return &upi, nil
+}
+
+func (s *RazorpayService) createQRCodeAPI(ctx context.Context, req *RazorpayQRCodeRequest) (*RazorpayQRCodeResponse, error) {
+	var qr RazorpayQRCodeResponse
+	if err := s.callAPI(ctx, http.MethodPost, s.baseURL+"/payments/qr_codes", req, &qr); err != nil {
+		return nil, err
+	}
+	if qr.ID == "" || qr.ImageURL == "" {
+		return nil, errors.New("Razorpay returned no QR code")
+	}
+	return &qr, nil
+}
+
+func (s *RazorpayService) closeQRCodeAPI(ctx context.Context, qrID string) error {
+	return s.callAPI(ctx, http.MethodPost, s.baseURL+"/payments/qr_codes/"+qrID+"/close", nil, nil)
+}
@@ -0,0 +1,413 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/cache"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	recommendationWindow          = 30 * 24 * time.Hour
+	recommendationUserHistory     = 90 * 24 * time.Hour
+	recommendationCacheTTL        = 48 * time.Hour
+	recommendationStored          = 20
+	recommendationDefaultLimit    = 10
+	recommendationRecentViews     = 50
+	recommendationViewWeight      = 0.2 // a co-view counts for a fifth of a co-order
+	recommendationMaxViewsPerUser = 50
+	recommendationRestaurantBatch = 100
+)
+
+type RecommendationService struct {
+	orderRepo        repositories.OrderRepository
+	productRepo      repositories.ProductRepository
+	restaurantRepo   repositories.RestaurantRepository
+	userActivityRepo repositories.UserActivityRepository
+	cache            *cache.RedisCache
+	stopChan         chan bool
+	timezone         *time.Location
+	isRunning        bool
+}
+
+func NewRecommendationService(
+	orderRepo repositories.OrderRepository,
+	productRepo repositories.ProductRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	userActivityRepo repositories.UserActivityRepository,
+	cache *cache.RedisCache,
+) *RecommendationService {
+	// Default to Asia/Kolkata timezone
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		loc = time.UTC
+		log.Printf("Failed to load timezone, using UTC: %v", err)
+	}
+
+	return &RecommendationService{
+		orderRepo:        orderRepo,
+		productRepo:      productRepo,
+		restaurantRepo:   restaurantRepo,
+		userActivityRepo: userActivityRepo,
+		cache:            cache,
+		stopChan:         make(chan bool),
+		timezone:         loc,
+	}
+}
+
+// RecommendationScore is a cached suggestion before products are loaded
+type RecommendationScore struct {
+	ProductID string  `json:"product_id"`
+	Score     float64 `json:"score"`
+}
+
+type RecommendedProduct struct {
+	Product models.Product `json:"product"`
+	Score   float64        `json:"score"`
+}
+
+type RecommendationsResponse struct {
+	ProductID       string               `json:"product_id,omitempty"`
+	UserID          string               `json:"user_id,omitempty"`
+	Recommendations []RecommendedProduct `json:"recommendations"`
+}
+
+func productRecommendationsKey(productID string) string {
+	return "recommendations:product:" + productID
+}
+
+func userRecommendationsKey(userID string) string {
+	return "recommendations:user:" + userID
+}
+
+// Start refreshes recommendations every night at 1 AM, after popularity badges
+func (s *RecommendationService) Start() error {
+	if s.isRunning {
+		return fmt.Errorf("recommendation service is already running")
+	}
+
+	s.isRunning = true
+	go s.runNightlyTicker()
+
+	log.Println("🤝 Recommendations: Every day at 1 AM")
+	return nil
+}
+
+// Stop stops the nightly recommendation refresh
+func (s *RecommendationService) Stop() {
+	if !s.isRunning {
+		return
+	}
+
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+func (s *RecommendationService) runNightlyTicker() {
+	now := time.Now().In(s.timezone)
+	next := time.Date(now.Year(), now.Month(), now.Day(), 1, 0, 0, 0, s.timezone)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	timer := time.NewTimer(next.Sub(now))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if err := s.RefreshAll(context.Background()); err != nil {
+				log.Printf("Failed to refresh recommendations: %v", err)
+			}
+			timer.Reset(24 * time.Hour)
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// RefreshAll recomputes product suggestions for every restaurant, then for every
+// customer who ordered in the window
+func (s *RecommendationService) RefreshAll(ctx context.Context) error {
+	customers := make(map[string]bool)
+
+	offset := 0
+	for {
+		restaurants, err := s.restaurantRepo.Search(ctx, "", recommendationRestaurantBatch, offset)
+		if err != nil {
+			return fmt.Errorf("failed to list restaurants: %v", err)
+		}
+
+		for _, restaurant := range restaurants {
+			userIDs, err := s.RefreshRestaurantRecommendations(ctx, restaurant.ID.String())
+			if err != nil {
+				log.Printf("Failed to refresh recommendations for restaurant %s: %v", restaurant.ID, err)
+				continue
+			}
+			for _, userID := range userIDs {
+				customers[userID] = true
+			}
+		}
+
+		if len(restaurants) < recommendationRestaurantBatch {
+			break
+		}
+		offset += recommendationRestaurantBatch
+	}
+
+	for userID := range customers {
+		if _, err := s.RefreshUserRecommendations(ctx, userID); err != nil {
+			log.Printf("Failed to refresh recommendations for user %s: %v", userID, err)
+		}
+	}
+
+	return nil
+}
+
+// RefreshRestaurantRecommendations computes "customers also ordered" suggestions for every
+// product of a restaurant from items ordered together, plus a smaller weight for items
+// viewed in the same session. It returns the customers who ordered in the window.
+func (s *RecommendationService) RefreshRestaurantRecommendations(ctx context.Context, restaurantID string) ([]string, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, errors.New("invalid restaurant ID")
+	}
+
+	since := time.Now().Add(-recommendationWindow)
+
+	orders, err := s.orderRepo.GetByRestaurantIDSince(ctx, restaurantUUID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %v", err)
+	}
+
+	scores := make(map[string]map[string]float64)
+	addTogether := func(productIDs []string, weight float64) {
+		for _, a := range productIDs {
+			for _, b := range productIDs {
+				if a == b {
+					continue
+				}
+				if scores[a] == nil {
+					scores[a] = make(map[string]float64)
+				}
+				scores[a][b] += weight
+			}
+		}
+	}
+
+	customers := make(map[string]bool)
+	for _, order := range orders {
+		if !countsTowardsPopularity(order.OrderStatus) {
+			continue
+		}
+		customers[order.UserID.String()] = true
+		addTogether(uniqueOrderProductIDs(order), 1)
+	}
+
+	views, err := s.userActivityRepo.GetByRestaurantSince(ctx, restaurantID, ActivityViewProduct, since)
+	if err != nil {
+		log.Printf("Failed to get product views for restaurant %s: %v", restaurantID, err)
+	}
+	viewed := make(map[string][]string)
+	seen := make(map[string]map[string]bool)
+	for _, view := range views {
+		visitor := view.SessionID
+		if visitor == "" {
+			visitor = view.UserID
+		}
+		if visitor == "" || view.EntityID == "" {
+			continue
+		}
+		if seen[visitor] == nil {
+			seen[visitor] = make(map[string]bool)
+		}
+		if seen[visitor][view.EntityID] || len(viewed[visitor]) >= recommendationMaxViewsPerUser {
+			continue
+		}
+		seen[visitor][view.EntityID] = true
+		viewed[visitor] = append(viewed[visitor], view.EntityID)
+	}
+	for _, productIDs := range viewed {
+		addTogether(productIDs, recommendationViewWeight)
+	}
+
+	for productID, related := range scores {
+		s.cache.Set(ctx, productRecommendationsKey(productID), rankRecommendations(related), recommendationCacheTTL)
+	}
+
+	userIDs := make([]string, 0, len(customers))
+	for userID := range customers {
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, nil
+}
+
+// RefreshUserRecommendations combines the suggestions of everything a customer ordered
+// recently or viewed, leaving out products they have already ordered
+func (s *RecommendationService) RefreshUserRecommendations(ctx context.Context, userID string) ([]RecommendationScore, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	orders, err := s.orderRepo.GetByUserIDSince(ctx, userUUID, time.Now().Add(-recommendationUserHistory))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %v", err)
+	}
+
+	seeds := make(map[string]float64)
+	ordered := make(map[string]bool)
+	for _, order := range orders {
+		if !countsTowardsPopularity(order.OrderStatus) {
+			continue
+		}
+		for _, productID := range uniqueOrderProductIDs(order) {
+			seeds[productID]++
+			ordered[productID] = true
+		}
+	}
+
+	views, err := s.userActivityRepo.GetRecentByUser(ctx, userID, ActivityViewProduct, recommendationRecentViews)
+	if err != nil {
+		log.Printf("Failed to get product views for user %s: %v", userID, err)
+	}
+	for _, view := range views {
+		if view.EntityID != "" {
+			seeds[view.EntityID] += recommendationViewWeight
+		}
+	}
+
+	candidates := make(map[string]float64)
+	for productID, weight := range seeds {
+		var related []RecommendationScore
+		if err := s.cache.Get(ctx, productRecommendationsKey(productID), &related); err != nil {
+			continue
+		}
+		for _, suggestion := range related {
+			if ordered[suggestion.ProductID] {
+				continue
+			}
+			candidates[suggestion.ProductID] += weight * suggestion.Score
+		}
+	}
+
+	ranked := rankRecommendations(candidates)
+	s.cache.Set(ctx, userRecommendationsKey(userID), ranked, recommendationCacheTTL)
+	return ranked, nil
+}
+
+// GetProductRecommendations returns available products frequently ordered with the given product
+func (s *RecommendationService) GetProductRecommendations(ctx context.Context, productID string, limit int) (*RecommendationsResponse, error) {
+	objectID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, errors.New("invalid product ID")
+	}
+
+	var scores []RecommendationScore
+	if err := s.cache.Get(ctx, productRecommendationsKey(productID), &scores); err != nil {
+		// Not computed yet: build the product's restaurant now rather than waiting for the nightly run
+		product, err := s.productRepo.GetByID(ctx, objectID)
+		if err != nil {
+			return nil, errors.New("product not found")
+		}
+		if _, err := s.RefreshRestaurantRecommendations(ctx, product.RestaurantID); err != nil {
+			return nil, err
+		}
+		if err := s.cache.Get(ctx, productRecommendationsKey(productID), &scores); err != nil {
+			// Never ordered with anything else; remember that until the next refresh
+			scores = []RecommendationScore{}
+			s.cache.Set(ctx, productRecommendationsKey(productID), scores, recommendationCacheTTL)
+		}
+	}
+
+	return &RecommendationsResponse{
+		ProductID:       productID,
+		Recommendations: s.loadRecommendedProducts(ctx, scores, limit),
+	}, nil
+}
+
+// GetUserRecommendations returns personalised suggestions for a customer
+func (s *RecommendationService) GetUserRecommendations(ctx context.Context, userID string, limit int) (*RecommendationsResponse, error) {
+	var scores []RecommendationScore
+	if err := s.cache.Get(ctx, userRecommendationsKey(userID), &scores); err != nil {
+		scores, err = s.RefreshUserRecommendations(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &RecommendationsResponse{
+		UserID:          userID,
+		Recommendations: s.loadRecommendedProducts(ctx, scores, limit),
+	}, nil
+}
+
+// loadRecommendedProducts loads suggested products in score order, skipping deleted and unavailable ones
+func (s *RecommendationService) loadRecommendedProducts(ctx context.Context, scores []RecommendationScore, limit int) []RecommendedProduct {
+	if limit < 1 || limit > recommendationStored {
+		limit = recommendationDefaultLimit
+	}
+
+	products := []RecommendedProduct{}
+	for _, score := range scores {
+		if len(products) >= limit {
+			break
+		}
+
+		objectID, err := primitive.ObjectIDFromHex(score.ProductID)
+		if err != nil {
+			continue
+		}
+		product, err := s.productRepo.GetByID(ctx, objectID)
+		if err != nil || !product.IsAvailable {
+			continue
+		}
+		products = append(products, RecommendedProduct{Product: *product, Score: score.Score})
+	}
+
+	return products
+}
+
+// rankRecommendations sorts scored products best first and keeps the top ones
+func rankRecommendations(scores map[string]float64) []RecommendationScore {
+	ranked := make([]RecommendationScore, 0, len(scores))
+	for productID, score := range scores {
+		ranked = append(ranked, RecommendationScore{ProductID: productID, Score: math.Round(score*100) / 100})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score == ranked[j].Score {
+			return ranked[i].ProductID < ranked[j].ProductID
+		}
+		return ranked[i].Score > ranked[j].Score
+	})
+	if len(ranked) > recommendationStored {
+		ranked = ranked[:recommendationStored]
+	}
+	return ranked
+}
+
+// uniqueOrderProductIDs returns the distinct products in an order's line items, which keep what
+// was ordered after the cart moves on
+func uniqueOrderProductIDs(order models.Order) []string {
+	items := models.DecodeOrderLineItems(order.LineItems)
+
+	seen := make(map[string]bool)
+	productIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.ProductID == "" || seen[item.ProductID] {
+			continue
+		}
+		seen[item.ProductID] = true
+		productIDs = append(productIDs, item.ProductID)
+	}
+	return productIDs
+}
@@ -0,0 +1,570 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/i18n"
+	"golang-food-backend/pkg/jobs"
+	"golang-food-backend/pkg/spreadsheet"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ReconciliationRunning   = "running"
+	ReconciliationCompleted = "completed"
+	ReconciliationFailed    = "failed"
+
+	MismatchMissingCapture = "missing_capture"     // captured at Razorpay, still unpaid here
+	MismatchAmount         = "amount_mismatch"     // Razorpay captured a different amount
+	MismatchOrphanPayment  = "orphan_payment"      // captured at Razorpay with no payment here
+	MismatchUnconfirmed    = "unconfirmed_payment" // paid here with nothing captured at Razorpay
+
+	JobPaymentReconciliation = "payments:reconcile"
+
+	reconciliationDateLayout = "2006-01-02"
+	// reconciliationLag widens the Razorpay side so a payment made just after midnight still
+	// matches an attempt started just before it
+	reconciliationLag        = time.Hour
+	reconciliationStaleAfter = time.Hour
+)
+
+var (
+	ErrReconciliationInvalid  = apperr.Validation("reconciliation_invalid", "invalid reconciliation request")
+	ErrReconciliationNotFound = apperr.NotFound("reconciliation_not_found", "no reconciliation for this date")
+	ErrReconciliationRunning  = apperr.Conflict("reconciliation_running", "the reconciliation for this date is still running")
+)
+
+// gatewayPaymentMethods are the payment methods Razorpay collects
+var gatewayPaymentMethods = []string{"razorpay", PaymentMethodLink, PaymentMethodUPI}
+
+var mismatchKinds = map[string]bool{
+	MismatchMissingCapture: true,
+	MismatchAmount:         true,
+	MismatchOrphanPayment:  true,
+	MismatchUnconfirmed:    true,
+}
+
+type reconciliationPayload struct {
+	Date  string `json:"date"`
+	Force bool   `json:"force"`
+}
+
+type ReconciliationListResponse struct {
+	Reconciliations []models.PaymentReconciliation `json:"reconciliations"`
+	Total           int64                          `json:"total"`
+	Page            int                            `json:"page"`
+	Limit           int                            `json:"limit"`
+}
+
+type ReconciliationReport struct {
+	Reconciliation *models.PaymentReconciliation   `json:"reconciliation"`
+	ByKind         map[string]int                  `json:"by_kind"`
+	Mismatches     []models.ReconciliationMismatch `json:"mismatches"`
+}
+
+// ReconciliationService checks every day's Razorpay payments and settlements against our
+// payments and records where they disagree, for finance to follow up
+type ReconciliationService struct {
+	reconciliationRepo       repositories.ReconciliationRepository
+	paymentRepo              repositories.PaymentRepository
+	mealPlanSubscriptionRepo repositories.MealPlanSubscriptionRepository
+	razorpay                 *RazorpayService
+	queue                    *jobs.Queue
+	stopChan                 chan bool
+	timezone                 *time.Location
+	isRunning                bool
+}
+
+func NewReconciliationService(
+	reconciliationRepo repositories.ReconciliationRepository,
+	paymentRepo repositories.PaymentRepository,
+	mealPlanSubscriptionRepo repositories.MealPlanSubscriptionRepository,
+	razorpay *RazorpayService,
+) *ReconciliationService {
+	// Default to Asia/Kolkata timezone
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		loc = time.UTC
+		log.Printf("Failed to load timezone, using UTC: %v", err)
+	}
+
+	return &ReconciliationService{
+		reconciliationRepo:       reconciliationRepo,
+		paymentRepo:              paymentRepo,
+		mealPlanSubscriptionRepo: mealPlanSubscriptionRepo,
+		razorpay:                 razorpay,
+		stopChan:                 make(chan bool),
+		timezone:                 loc,
+	}
+}
+
+// RegisterJobs registers the reconciliation job handler and the queue the ticker uses
+func (s *ReconciliationService) RegisterJobs(queue *jobs.Queue) {
+	s.queue = queue
+	queue.Handle(JobPaymentReconciliation, func(ctx context.Context, job *jobs.Job) error {
+		var payload reconciliationPayload
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		_, err := s.Reconcile(ctx, payload.Date, payload.Force)
+		return err
+	})
+}
+
+// Start queues the reconciliation of the previous day every day at 04:00
+func (s *ReconciliationService) Start() error {
+	if s.isRunning {
+		return fmt.Errorf("reconciliation service is already running")
+	}
+
+	s.isRunning = true
+	go s.runDailyTicker()
+
+	log.Println("🧾 Payment reconciliation: Every day at 04:00")
+	return nil
+}
+
+// Stop stops the daily runs
+func (s *ReconciliationService) Stop() {
+	if !s.isRunning {
+		return
+	}
+
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// runDailyTicker wakes at 04:00, once Razorpay has published the previous day's settlements
+func (s *ReconciliationService) runDailyTicker() {
+	now := time.Now().In(s.timezone)
+	next := time.Date(now.Year(), now.Month(), now.Day(), 4, 0, 0, 0, s.timezone)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	timer := time.NewTimer(next.Sub(now))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			// A date is claimed by one run, so a job queued by every instance reconciles it once
+			yesterday := time.Now().In(s.timezone).AddDate(0, 0, -1).Format(reconciliationDateLayout)
+			if _, err := s.queue.Enqueue(context.Background(), JobPaymentReconciliation, reconciliationPayload{Date: yesterday}, jobs.Timeout(30*time.Minute)); err != nil {
+				log.Printf("Failed to queue %s: %v", JobPaymentReconciliation, err)
+			}
+			timer.Reset(24 * time.Hour)
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// Reconcile checks the payments of date (YYYY-MM-DD, yesterday when empty). A date already
+// reconciled returns its run unless force is set, which redoes it.
+func (s *ReconciliationService) Reconcile(ctx context.Context, date string, force bool) (*models.PaymentReconciliation, error) {
+	day, err := s.day(date)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &models.PaymentReconciliation{
+		ID:        uuid.New(),
+		Date:      day.Format(reconciliationDateLayout),
+		Status:    ReconciliationRunning,
+		StartedAt: time.Now(),
+	}
+	claimed, err := s.reconciliationRepo.Claim(ctx, run, force, reconciliationStaleAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start reconciliation: %v", err)
+	}
+	if !claimed {
+		existing, err := s.reconciliationRepo.GetByDate(ctx, run.Date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get reconciliation: %v", err)
+		}
+		if force && existing.Status == ReconciliationRunning {
+			return nil, ErrReconciliationRunning
+		}
+		return existing, nil
+	}
+
+	mismatches, reconcileErr := s.reconcile(ctx, run, day)
+	completedAt := time.Now()
+	run.CompletedAt = &completedAt
+	run.Status = ReconciliationCompleted
+	if reconcileErr != nil {
+		run.Status = ReconciliationFailed
+		run.Error = reconcileErr.Error()
+		mismatches = nil
+	}
+	run.Mismatches = len(mismatches)
+
+	if err := s.reconciliationRepo.Complete(ctx, run, mismatches); err != nil {
+		return nil, fmt.Errorf("failed to save reconciliation: %v", err)
+	}
+	if reconcileErr != nil {
+		return nil, fmt.Errorf("reconciliation of %s failed: %v", run.Date, reconcileErr)
+	}
+	return run, nil
+}
+
+// reconcile matches the day's Razorpay payments, and the payments Razorpay settled that day,
+// to ours by gateway payment ID or transaction ID, filling in the run's totals
+func (s *ReconciliationService) reconcile(ctx context.Context, run *models.PaymentReconciliation, day time.Time) ([]models.ReconciliationMismatch, error) {
+	from, to := day, day.AddDate(0, 0, 1)
+	gatewayPayments, err := s.razorpay.FetchPayments(ctx, from, to.Add(reconciliationLag))
+	if err != nil {
+		return nil, err
+	}
+	settlements, err := s.razorpay.FetchSettlements(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	locals, err := s.paymentRepo.GetByMethodsBetween(ctx, gatewayPaymentMethods, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payments: %v", err)
+	}
+
+	// Settled payments made on earlier days are checked too, since the day's list misses them
+	listed := make(map[string]bool, len(gatewayPayments))
+	for _, payment := range gatewayPayments {
+		listed[payment.ID] = true
+	}
+	settledIn := make(map[string]string)
+	for _, item := range settlements {
+		amount := gatewayMoney(item.Currency, item.Amount)
+		run.Fees += gatewayMoney(item.Currency, item.Fee+item.Tax)
+		switch item.Type {
+		case "payment":
+			run.SettledAmount += amount
+			settledIn[item.PaymentID] = item.SettlementID
+			if !listed[item.PaymentID] {
+				listed[item.PaymentID] = true
+				gatewayPayments = append(gatewayPayments, RazorpayPaymentEntity{
+					ID:        item.PaymentID,
+					Amount:    item.Amount,
+					Currency:  item.Currency,
+					Status:    "captured",
+					OrderID:   item.OrderID,
+					Notes:     item.Notes,
+					CreatedAt: from.Unix(),
+				})
+			}
+		case "refund":
+			run.SettledAmount -= amount
+		}
+	}
+
+	matcher := newPaymentMatcher(locals)
+	captured := make(map[uuid.UUID]models.Money)
+	matched := make(map[uuid.UUID]*models.Payment)
+	var mismatches []models.ReconciliationMismatch
+	for i := range gatewayPayments {
+		gateway := &gatewayPayments[i]
+		if gateway.Status != "authorized" && gateway.Status != "captured" && gateway.Status != "refunded" {
+			continue
+		}
+		local, err := matcher.match(ctx, s.paymentRepo, gateway)
+		if err != nil {
+			return nil, err
+		}
+
+		// Payments made just after the day are only looked at to match attempts from it
+		if gateway.CreatedAt >= to.Unix() || gateway.Status == "authorized" {
+			continue
+		}
+		amount := gatewayMoney(gateway.Currency, gateway.Amount)
+		run.GatewayPayments++
+		run.CapturedAmount += amount
+
+		if local == nil {
+			mismatch, err := s.unmatchedPayment(ctx, run, gateway, settledIn[gateway.ID])
+			if err != nil {
+				return nil, err
+			}
+			if mismatch != nil {
+				mismatches = append(mismatches, *mismatch)
+			} else {
+				run.Matched++
+			}
+			continue
+		}
+
+		run.Matched++
+		captured[local.ID] += amount
+		matched[local.ID] = local
+		if local.Status != "success" && local.Status != "partially_paid" {
+			mismatches = append(mismatches, newMismatch(run, MismatchMissingCapture, local, gateway, settledIn[gateway.ID],
+				fmt.Sprintf("Razorpay captured the payment but it is %s here", local.Status)))
+		}
+	}
+
+	for id, amount := range captured {
+		local := matched[id]
+		expected, disagrees := local.Amount, amount != local.Amount
+		if local.Method == PaymentMethodLink {
+			// A link may be paid in parts over several days, so it only disagrees when
+			// Razorpay took more than we recorded
+			expected = models.MoneyOf(local.Metadata["amount_paid"])
+			disagrees = amount > expected
+		}
+		if !disagrees || (local.Status != "success" && local.Status != "partially_paid") {
+			continue
+		}
+		mismatch := newMismatch(run, MismatchAmount, local, nil, "",
+			fmt.Sprintf("Razorpay captured %s against %s recorded here", amount, expected))
+		mismatch.GatewayAmount = amount
+		mismatches = append(mismatches, mismatch)
+	}
+
+	for i := range matcher.payments {
+		local := &matcher.payments[i]
+		if !matcher.seen[local.ID] && (local.Status == "success" || local.Status == "partially_paid") {
+			mismatches = append(mismatches, newMismatch(run, MismatchUnconfirmed, local, nil, "",
+				"Paid here but Razorpay reports no captured payment for it"))
+		}
+	}
+	return mismatches, nil
+}
+
+// unmatchedPayment checks a captured Razorpay payment none of our payments match, which may be
+// a meal plan's mandate charge
+func (s *ReconciliationService) unmatchedPayment(ctx context.Context, run *models.PaymentReconciliation, gateway *RazorpayPaymentEntity, settlementID string) (*models.ReconciliationMismatch, error) {
+	bill, err := s.mealPlanSubscriptionRepo.GetBillByGatewayPaymentID(ctx, gateway.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		mismatch := newMismatch(run, MismatchOrphanPayment, nil, gateway, settlementID, "Razorpay captured a payment that matches none here")
+		if orderID, ok := gateway.Notes["order_id"].(string); ok {
+			if id, err := uuid.Parse(orderID); err == nil {
+				mismatch.OrderID = &id
+			}
+		}
+		return &mismatch, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get meal plan bill: %v", err)
+	}
+
+	amount := gatewayMoney(gateway.Currency, gateway.Amount)
+	if amount == bill.Amount {
+		return nil, nil
+	}
+	mismatch := newMismatch(run, MismatchAmount, nil, gateway, settlementID,
+		fmt.Sprintf("Razorpay captured %s for meal plan bill %s of %s", amount, bill.ID, bill.Amount))
+	mismatch.LocalAmount = bill.Amount
+	mismatch.LocalStatus = bill.Status
+	return &mismatch, nil
+}
+
+// ListReconciliations lists reconciliation runs, newest date first
+func (s *ReconciliationService) ListReconciliations(ctx context.Context, page, limit int) (*ReconciliationListResponse, error) {
+	page, limit = normalizePage(page, limit)
+
+	runs, total, err := s.reconciliationRepo.List(ctx, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reconciliations: %v", err)
+	}
+
+	return &ReconciliationListResponse{
+		Reconciliations: runs,
+		Total:           total,
+		Page:            page,
+		Limit:           limit,
+	}, nil
+}
+
+// GetReport returns a date's reconciliation with its mismatches, of one kind when kind is set
+func (s *ReconciliationService) GetReport(ctx context.Context, date, kind string) (*ReconciliationReport, error) {
+	if kind != "" && !mismatchKinds[kind] {
+		return nil, fmt.Errorf("%w: unknown mismatch kind %q", ErrReconciliationInvalid, kind)
+	}
+	run, err := s.reconciliationRepo.GetByDate(ctx, date)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReconciliationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reconciliation: %v", err)
+	}
+
+	mismatches, err := s.reconciliationRepo.GetMismatches(ctx, run.ID, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load mismatches: %v", err)
+	}
+	byKind := make(map[string]int)
+	for _, mismatch := range mismatches {
+		byKind[mismatch.Kind]++
+	}
+
+	return &ReconciliationReport{
+		Reconciliation: run,
+		ByKind:         byKind,
+		Mismatches:     mismatches,
+	}, nil
+}
+
+// ExportReport writes a date's mismatches as CSV for finance
+func (s *ReconciliationService) ExportReport(ctx context.Context, date, kind string) ([]byte, error) {
+	report, err := s.GetReport(ctx, date, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{{
+		"date", "kind", "payment_id", "order_id", "transaction_id", "gateway_payment_id", "local_status",
+		"gateway_status", "local_amount", "gateway_amount", "currency", "settlement_id", "detail",
+	}}
+	for _, mismatch := range report.Mismatches {
+		var paymentID, orderID string
+		if mismatch.PaymentID != nil {
+			paymentID = mismatch.PaymentID.String()
+		}
+		if mismatch.OrderID != nil {
+			orderID = mismatch.OrderID.String()
+		}
+		rows = append(rows, []string{
+			report.Reconciliation.Date,
+			mismatch.Kind,
+			paymentID,
+			orderID,
+			mismatch.TransactionID,
+			mismatch.GatewayPaymentID,
+			mismatch.LocalStatus,
+			mismatch.GatewayStatus,
+			mismatch.LocalAmount.String(),
+			mismatch.GatewayAmount.String(),
+			mismatch.Currency,
+			mismatch.SettlementID,
+			mismatch.Detail,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := spreadsheet.Write(spreadsheet.FormatCSV, &buf, "Reconciliation", rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// day parses a reconciliation date in the platform timezone; it has to be over
+func (s *ReconciliationService) day(date string) (time.Time, error) {
+	today := time.Now().In(s.timezone)
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, s.timezone)
+	if date == "" {
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	day, err := time.ParseInLocation(reconciliationDateLayout, date, s.timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: date must be in YYYY-MM-DD format", ErrReconciliationInvalid)
+	}
+	if !day.Before(today) {
+		return time.Time{}, fmt.Errorf("%w: %s has not ended", ErrReconciliationInvalid, date)
+	}
+	return day, nil
+}
+
+// paymentMatcher finds our payment for a Razorpay payment among the day's payments, then by
+// transaction ID for attempts started on earlier days
+type paymentMatcher struct {
+	payments      []models.Payment
+	byGatewayID   map[string]*models.Payment
+	byTransaction map[string]*models.Payment
+	byOrder       map[string]*models.Payment
+	seen          map[uuid.UUID]bool
+}
+
+func newPaymentMatcher(payments []models.Payment) *paymentMatcher {
+	m := &paymentMatcher{
+		payments:      payments,
+		byGatewayID:   make(map[string]*models.Payment),
+		byTransaction: make(map[string]*models.Payment),
+		byOrder:       make(map[string]*models.Payment),
+		seen:          make(map[uuid.UUID]bool),
+	}
+	for i := range payments {
+		payment := &payments[i]
+		if id, ok := payment.Metadata["razorpay_payment_id"].(string); ok && id != "" {
+			m.byGatewayID[id] = payment
+		}
+		if payment.TransactionID != "" {
+			m.byTransaction[payment.TransactionID] = payment
+		}
+		// Payment links and QR codes tag their payments with our order; the paid attempt wins
+		if previous, ok := m.byOrder[payment.OrderID.String()]; !ok || previous.Status != "success" {
+			m.byOrder[payment.OrderID.String()] = payment
+		}
+	}
+	return m
+}
+
+func (m *paymentMatcher) match(ctx context.Context, paymentRepo repositories.PaymentRepository, gateway *RazorpayPaymentEntity) (*models.Payment, error) {
+	payment := m.byGatewayID[gateway.ID]
+	if payment == nil && gateway.OrderID != "" {
+		payment = m.byTransaction[gateway.OrderID]
+	}
+	if payment == nil {
+		if orderID, ok := gateway.Notes["order_id"].(string); ok {
+			payment = m.byOrder[orderID]
+		}
+	}
+	if payment == nil && gateway.OrderID != "" {
+		earlier, err := paymentRepo.GetByTransactionID(ctx, gateway.OrderID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get payment: %v", err)
+		}
+		payment = earlier
+	}
+	if payment != nil {
+		m.seen[payment.ID] = true
+	}
+	return payment, nil
+}
+
+// newMismatch describes a disagreement; local or gateway may be nil
+func newMismatch(run *models.PaymentReconciliation, kind string, local *models.Payment, gateway *RazorpayPaymentEntity, settlementID, detail string) models.ReconciliationMismatch {
+	mismatch := models.ReconciliationMismatch{
+		ID:               uuid.New(),
+		ReconciliationID: run.ID,
+		Kind:             kind,
+		SettlementID:     settlementID,
+		Detail:           detail,
+		CreatedAt:        time.Now(),
+	}
+	if local != nil {
+		mismatch.PaymentID = &local.ID
+		mismatch.OrderID = &local.OrderID
+		mismatch.TransactionID = local.TransactionID
+		mismatch.LocalStatus = local.Status
+		mismatch.LocalAmount = local.Amount
+		mismatch.Currency, _ = local.Metadata["currency"].(string)
+		mismatch.GatewayPaymentID, _ = local.Metadata["razorpay_payment_id"].(string)
+	}
+	if gateway != nil {
+		mismatch.GatewayPaymentID = gateway.ID
+		mismatch.GatewayStatus = gateway.Status
+		mismatch.GatewayAmount = gatewayMoney(gateway.Currency, gateway.Amount)
+		mismatch.Currency = i18n.CurrencyOf(gateway.Currency).Code
+		if mismatch.TransactionID == "" {
+			mismatch.TransactionID = gateway.OrderID
+		}
+	}
+	if mismatch.Currency == "" {
+		mismatch.Currency = i18n.CurrencyOf("").Code
+	}
+	return mismatch
+}
+
+// gatewayMoney converts an amount Razorpay reports in the currency's minor unit
+func gatewayMoney(currency string, minor int64) models.Money {
+	return models.NewMoney(i18n.CurrencyOf(currency).FromMinor(minor))
+}
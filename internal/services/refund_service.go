@@ -15,25 +15,34 @@ type RefundService struct {
 	refundRepo  repositories.RefundRepository
 	orderRepo   repositories.OrderRepository
 	paymentRepo repositories.PaymentRepository
+	support     *SupportService
+	risk        *RiskService
+	ledger      *LedgerService
 }
 
 func NewRefundService(
 	refundRepo repositories.RefundRepository,
 	orderRepo repositories.OrderRepository,
 	paymentRepo repositories.PaymentRepository,
+	support *SupportService,
+	risk *RiskService,
+	ledger *LedgerService,
 ) *RefundService {
 	return &RefundService{
 		refundRepo:  refundRepo,
 		orderRepo:   orderRepo,
 		paymentRepo: paymentRepo,
+		support:     support,
+		risk:        risk,
+		ledger:      ledger,
 	}
 }
 
 // Request and Response types
 type CreateRefundRequest struct {
-	OrderID string  `json:"order_id" binding:"required"`
-	Amount  float64 `json:"amount" binding:"required,min=0"`
-	Reason  string  `json:"reason" binding:"required"`
+	OrderID string       `json:"order_id" binding:"required"`
+	Amount  models.Money `json:"amount" binding:"required,min=0"`
+	Reason  string       `json:"reason" binding:"required"`
 }
 
 type UpdateRefundStatusRequest struct {
@@ -85,6 +94,11 @@ func (s *RefundService) CreateRefund(ctx context.Context, userID string, req *Cr
 		return nil, errors.New("refund request already exists for this order")
 	}
 
+	assessment, err := s.risk.Assess(ctx, RiskCheck{Event: RiskEventRefund, UserID: userUUID})
+	if err != nil {
+		return nil, err
+	}
+
 	// Create refund record
 	refund := &models.Refund{
 		OrderID: orderID,
@@ -96,6 +110,7 @@ func (s *RefundService) CreateRefund(ctx context.Context, userID string, req *Cr
 	if err := s.refundRepo.Create(ctx, refund); err != nil {
 		return nil, err
 	}
+	s.risk.Record(ctx, assessment, &orderID, &refund.ID)
 
 	return refund, nil
 }
@@ -178,6 +193,14 @@ func (s *RefundService) UpdateRefundStatus(ctx context.Context, adminID, refundI
 		return nil, err
 	}
 
+	// The customer is owed money the gateway could not return; support follows it up
+	if refund.Status == "failed" {
+		s.support.RefundFailed(ctx, refund)
+	}
+	if refund.Status == "processed" {
+		s.ledger.RefundProcessed(ctx, refund)
+	}
+
 	return refund, nil
 }
 
@@ -212,6 +235,7 @@ func (s *RefundService) ProcessRefund(ctx context.Context, adminID, refundID str
 	if err := s.refundRepo.Update(ctx, refund); err != nil {
 		return nil, err
 	}
+	s.ledger.RefundProcessed(ctx, refund)
 
 	// TODO: Update payment status and create refund transaction record
 
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/apperr"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrReorderNotFound = apperr.NotFound("order_not_found", "order not found")
+	// ErrNothingToReorder is returned when none of a past order's items can be added to a cart
+	ErrNothingToReorder = apperr.Conflict("nothing_to_reorder", "none of the order's items can be ordered again")
+)
+
+// ReorderSkippedItem is a line of a past order that could not be added back to the cart
+type ReorderSkippedItem struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	VariantName string `json:"variant_name,omitempty"`
+	Quantity    int    `json:"quantity"`
+	Reason      string `json:"reason"`
+}
+
+// ReorderPriceChange is a line added back at a unit price different from the one paid
+type ReorderPriceChange struct {
+	ItemKey     string       `json:"item_key"`
+	ProductName string       `json:"product_name"`
+	OldPrice    models.Money `json:"old_price"`
+	NewPrice    models.Money `json:"new_price"`
+}
+
+type ReorderResponse struct {
+	OrderID      string               `json:"order_id"`
+	Cart         *CartResponse        `json:"cart"`
+	SkippedItems []ReorderSkippedItem `json:"skipped_items"`
+	PriceChanges []ReorderPriceChange `json:"price_changes"`
+}
+
+// Reorder rebuilds the user's cart for a past order's restaurant from the order's items at
+// current prices. The cart's previous items are replaced and it becomes the current cart.
+// Items no longer on the menu, unavailable, or whose variant or addons are gone are skipped
+// and reported, as are items whose price changed.
+func (s *CartService) Reorder(ctx context.Context, userID, orderID string) (*ReorderResponse, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid order ID", ErrReorderNotFound)
+	}
+	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	if err != nil || order.UserID.String() != userID {
+		return nil, fmt.Errorf("%w: order %s", ErrReorderNotFound, orderID)
+	}
+	if err := s.restaurants.EnsureAcceptingOrders(ctx, order.RestaurantID); err != nil {
+		return nil, err
+	}
+
+	lines := models.DecodeOrderLineItems(order.LineItems)
+	if len(lines) == 0 {
+		// Orders placed before line items were kept only have their cart
+		for _, item := range models.DecodeCartItems(order.Cart.Items) {
+			lines = append(lines, models.OrderLineItem{ProductID: item.ProductID, Quantity: item.Quantity, VariantID: item.VariantID})
+		}
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(lines))
+	for _, line := range lines {
+		if productID, err := primitive.ObjectIDFromHex(line.ProductID); err == nil {
+			ids = append(ids, productID)
+		}
+	}
+	products, err := s.productRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order products: %v", err)
+	}
+	productsByID := make(map[string]*models.Product, len(products))
+	for i := range products {
+		productsByID[products[i].ID.Hex()] = &products[i]
+	}
+
+	response := &ReorderResponse{
+		OrderID:      order.ID.String(),
+		SkippedItems: []ReorderSkippedItem{},
+		PriceChanges: []ReorderPriceChange{},
+	}
+	var items []models.CartItem
+	for _, line := range lines {
+		skip := func(reason string) {
+			response.SkippedItems = append(response.SkippedItems, ReorderSkippedItem{
+				ProductID:   line.ProductID,
+				ProductName: line.ProductName,
+				VariantName: line.VariantName,
+				Quantity:    line.Quantity,
+				Reason:      reason,
+			})
+		}
+
+		product, ok := productsByID[line.ProductID]
+		switch {
+		case !ok || product.RestaurantID != order.RestaurantID.String():
+			skip("no longer on the menu")
+			continue
+		case product.OutOfStock:
+			skip("out of stock")
+			continue
+		case !product.IsAvailable:
+			skip("currently unavailable")
+			continue
+		}
+
+		addonIDs := make([]string, 0, len(line.Addons))
+		for _, addon := range line.Addons {
+			addonIDs = append(addonIDs, addon.ID)
+		}
+		optionIDs := make([]string, 0, len(line.Options))
+		for _, option := range line.Options {
+			optionIDs = append(optionIDs, option.ID)
+		}
+		item := models.CartItem{
+			ProductID: line.ProductID,
+			Quantity:  line.Quantity,
+			VariantID: line.VariantID,
+			AddonIDs:  normalizeAddonIDs(addonIDs),
+			OptionIDs: normalizeAddonIDs(optionIDs),
+		}
+		priced, err := priceCartItem(product, item)
+		if err != nil {
+			skip(err.Error())
+			continue
+		}
+
+		items = addCartItem(items, item)
+		if line.UnitPrice > 0 && priced.Price != line.UnitPrice {
+			response.PriceChanges = append(response.PriceChanges, ReorderPriceChange{
+				ItemKey:     priced.ItemKey,
+				ProductName: priced.ProductName,
+				OldPrice:    line.UnitPrice,
+				NewPrice:    priced.Price,
+			})
+		}
+	}
+
+	if len(items) == 0 {
+		reasons := make([]string, 0, len(response.SkippedItems))
+		for _, skipped := range response.SkippedItems {
+			name := skipped.ProductName
+			if name == "" {
+				name = skipped.ProductID
+			}
+			reasons = append(reasons, name+": "+skipped.Reason)
+		}
+		return nil, fmt.Errorf("%w (%s)", ErrNothingToReorder, strings.Join(reasons, "; "))
+	}
+
+	response.Cart, err = s.replaceCartItems(ctx, userID, order.RestaurantID.String(), items)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/cache"
+	"golang-food-backend/pkg/messaging"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// Processed order IDs are remembered this long so redelivered events are not counted twice
+	analyticsDedupTTL     = 7 * 24 * time.Hour
+	analyticsMaxRangeDays = 92
+	analyticsTopItems     = 10
+	analyticsDateLayout   = "2006-01-02"
+	analyticsDefaultDays  = 7
+)
+
+// ErrAnalyticsRange is returned for malformed or oversized analytics date ranges
+var ErrAnalyticsRange = apperr.Validation("date_range_invalid", "invalid date range")
+
+type RestaurantAnalyticsService struct {
+	analyticsRepo  repositories.RestaurantAnalyticsRepository
+	orderRepo      repositories.OrderRepository
+	productRepo    repositories.ProductRepository
+	restaurantRepo repositories.RestaurantRepository
+	cache          *cache.RedisCache
+}
+
+func NewRestaurantAnalyticsService(
+	analyticsRepo repositories.RestaurantAnalyticsRepository,
+	orderRepo repositories.OrderRepository,
+	productRepo repositories.ProductRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	cache *cache.RedisCache,
+) *RestaurantAnalyticsService {
+	return &RestaurantAnalyticsService{
+		analyticsRepo:  analyticsRepo,
+		orderRepo:      orderRepo,
+		productRepo:    productRepo,
+		restaurantRepo: restaurantRepo,
+		cache:          cache,
+	}
+}
+
+type RestaurantAnalyticsResponse struct {
+	RestaurantID  string                       `json:"restaurant_id"`
+	From          string                       `json:"from"`
+	To            string                       `json:"to"`
+	TotalOrders   int                          `json:"total_orders"`
+	TotalRevenue  float64                      `json:"total_revenue"`
+	AOV           float64                      `json:"average_order_value"`
+	PopularItems  []models.PopularItem         `json:"popular_items"`
+	PeakHours     map[string]int               `json:"peak_hours"`
+	CustomerStats models.CustomerStats         `json:"customer_stats"`
+	Daily         []models.RestaurantAnalytics `json:"daily"`
+}
+
+func analyticsProcessedKey(orderID string) string {
+	return "analytics_processed:" + orderID
+}
+
+// HandleOrderEvent is the Kafka handler for the order_events topic. Only order_completed
+// events are aggregated; everything else is ignored.
+func (s *RestaurantAnalyticsService) HandleOrderEvent(payload []byte) error {
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("invalid order event: %v", err)
+	}
+	if event.Type != messaging.EventOrderCompleted {
+		return nil
+	}
+
+	var completed messaging.OrderCompleted
+	if _, err := messaging.Events.Decode(payload, &completed); err != nil {
+		return err
+	}
+	return s.RecordCompletedOrder(context.Background(), &completed)
+}
+
+// RecordCompletedOrder folds a delivered order into the restaurant's aggregate for the day it was placed
+func (s *RestaurantAnalyticsService) RecordCompletedOrder(ctx context.Context, data *messaging.OrderCompleted) error {
+	if exists, _ := s.cache.Exists(ctx, analyticsProcessedKey(data.OrderID)); exists {
+		return nil
+	}
+
+	restaurantUUID, err := uuid.Parse(data.RestaurantID)
+	if err != nil {
+		return errors.New("invalid restaurant ID")
+	}
+	userUUID, err := uuid.Parse(data.UserID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	placedAt := data.PlacedAt
+	if placedAt.IsZero() {
+		placedAt = data.DeliveredAt
+	}
+	date := placedAt.Format(analyticsDateLayout)
+
+	analytics, err := s.analyticsRepo.GetByRestaurantAndDate(ctx, data.RestaurantID, date)
+	if err != nil {
+		analytics = &models.RestaurantAnalytics{
+			RestaurantID: data.RestaurantID,
+			Date:         date,
+			PopularItems: []models.PopularItem{},
+			PeakHours:    map[string]int{},
+		}
+	}
+	if analytics.PeakHours == nil {
+		analytics.PeakHours = map[string]int{}
+	}
+
+	analytics.TotalOrders++
+	analytics.TotalRevenue = roundMoney(analytics.TotalRevenue + data.TotalAmount)
+	analytics.PeakHours[placedAt.Format("15")]++
+
+	s.addPopularItems(ctx, analytics, data.Items)
+
+	// A customer counts once per day: new if they had no earlier delivered order here
+	dayStart, _ := time.ParseInLocation(analyticsDateLayout, date, placedAt.Location())
+	earlierToday, err := s.orderRepo.CountDeliveredByUserAndRestaurant(ctx, userUUID, restaurantUUID, dayStart, placedAt)
+	if err != nil {
+		return fmt.Errorf("failed to count customer orders: %v", err)
+	}
+	if earlierToday == 0 {
+		previous, err := s.orderRepo.CountDeliveredByUserAndRestaurant(ctx, userUUID, restaurantUUID, time.Time{}, dayStart)
+		if err != nil {
+			return fmt.Errorf("failed to count customer orders: %v", err)
+		}
+		analytics.CustomerStats.TotalCustomers++
+		if previous == 0 {
+			analytics.CustomerStats.NewCustomers++
+		} else {
+			analytics.CustomerStats.ReturnCustomers++
+		}
+	}
+
+	if err := s.analyticsRepo.Upsert(ctx, analytics); err != nil {
+		return fmt.Errorf("failed to save analytics: %v", err)
+	}
+
+	s.cache.Set(ctx, analyticsProcessedKey(data.OrderID), true, analyticsDedupTTL)
+	return nil
+}
+
+// addPopularItems adds the order's lines to the day's item counts, priced at the current menu price
+func (s *RestaurantAnalyticsService) addPopularItems(ctx context.Context, analytics *models.RestaurantAnalytics, items []messaging.OrderEventItem) {
+	for _, item := range items {
+		productID, err := primitive.ObjectIDFromHex(item.ProductID)
+		if err != nil {
+			continue
+		}
+
+		index := -1
+		for i := range analytics.PopularItems {
+			if analytics.PopularItems[i].ProductID == productID {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			analytics.PopularItems = append(analytics.PopularItems, models.PopularItem{ProductID: productID})
+			index = len(analytics.PopularItems) - 1
+		}
+
+		popular := &analytics.PopularItems[index]
+		popular.OrderCount += item.Quantity
+		if product, err := s.productRepo.GetByID(ctx, productID); err == nil {
+			price := product.Price
+			if product.DiscountPrice != nil && *product.DiscountPrice > 0 {
+				price = *product.DiscountPrice
+			}
+			popular.Name = product.Name
+			popular.Revenue = roundMoney(popular.Revenue + (price * models.Money(item.Quantity)).Float64())
+		}
+	}
+
+	sort.Slice(analytics.PopularItems, func(i, j int) bool {
+		return analytics.PopularItems[i].OrderCount > analytics.PopularItems[j].OrderCount
+	})
+}
+
+// GetAnalytics returns aggregated analytics for a restaurant between two dates (YYYY-MM-DD, inclusive).
+// Only the restaurant owner or an admin may view them.
+func (s *RestaurantAnalyticsService) GetAnalytics(ctx context.Context, userID, role, restaurantID, from, to string) (*RestaurantAnalyticsResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, errors.New("invalid restaurant ID")
+	}
+
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantUUID)
+	if err != nil {
+		return nil, errors.New("restaurant not found")
+	}
+	if role != "admin" && restaurant.OwnerID.String() != userID {
+		return nil, errors.New("access denied: you don't own this restaurant")
+	}
+
+	return s.Summarize(ctx, restaurantID, from, to)
+}
+
+// Summarize aggregates a restaurant's daily analytics between two dates (YYYY-MM-DD, inclusive)
+func (s *RestaurantAnalyticsService) Summarize(ctx context.Context, restaurantID, from, to string) (*RestaurantAnalyticsResponse, error) {
+	var err error
+	end := time.Now()
+	if to != "" {
+		if end, err = time.Parse(analyticsDateLayout, to); err != nil {
+			return nil, fmt.Errorf("%w: invalid 'to' date, expected YYYY-MM-DD", ErrAnalyticsRange)
+		}
+	}
+	start := end.AddDate(0, 0, -(analyticsDefaultDays - 1))
+	if from != "" {
+		if start, err = time.Parse(analyticsDateLayout, from); err != nil {
+			return nil, fmt.Errorf("%w: invalid 'from' date, expected YYYY-MM-DD", ErrAnalyticsRange)
+		}
+	}
+	if start.After(end) {
+		return nil, fmt.Errorf("%w: 'from' date must not be after 'to' date", ErrAnalyticsRange)
+	}
+	if end.Sub(start) > analyticsMaxRangeDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: date range cannot exceed %d days", ErrAnalyticsRange, analyticsMaxRangeDays)
+	}
+
+	response := &RestaurantAnalyticsResponse{
+		RestaurantID: restaurantID,
+		From:         start.Format(analyticsDateLayout),
+		To:           end.Format(analyticsDateLayout),
+		PeakHours:    map[string]int{},
+		PopularItems: []models.PopularItem{},
+	}
+
+	days, err := s.analyticsRepo.GetRange(ctx, restaurantID, response.From, response.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analytics: %v", err)
+	}
+	response.Daily = days
+
+	items := make(map[primitive.ObjectID]*models.PopularItem)
+	for _, day := range days {
+		response.TotalOrders += day.TotalOrders
+		response.TotalRevenue += day.TotalRevenue
+		for hour, count := range day.PeakHours {
+			response.PeakHours[hour] += count
+		}
+		response.CustomerStats.NewCustomers += day.CustomerStats.NewCustomers
+		response.CustomerStats.ReturnCustomers += day.CustomerStats.ReturnCustomers
+		response.CustomerStats.TotalCustomers += day.CustomerStats.TotalCustomers
+
+		for _, item := range day.PopularItems {
+			total, ok := items[item.ProductID]
+			if !ok {
+				total = &models.PopularItem{ProductID: item.ProductID, Name: item.Name}
+				items[item.ProductID] = total
+			}
+			total.OrderCount += item.OrderCount
+			total.Revenue = roundMoney(total.Revenue + item.Revenue)
+		}
+	}
+
+	response.TotalRevenue = roundMoney(response.TotalRevenue)
+	if response.TotalOrders > 0 {
+		response.AOV = roundMoney(response.TotalRevenue / float64(response.TotalOrders))
+	}
+
+	for _, item := range items {
+		response.PopularItems = append(response.PopularItems, *item)
+	}
+	sort.Slice(response.PopularItems, func(i, j int) bool {
+		return response.PopularItems[i].OrderCount > response.PopularItems[j].OrderCount
+	})
+	if len(response.PopularItems) > analyticsTopItems {
+		response.PopularItems = response.PopularItems[:analyticsTopItems]
+	}
+
+	return response, nil
+}
+
+// Subscribe consumes order events into restaurant analytics
+func (s *RestaurantAnalyticsService) Subscribe(consumer *messaging.KafkaConsumer, groupID string) {
+	consumer.Subscribe("order_events", groupID, s.HandleOrderEvent, messaging.SubscribeOptions{})
+}
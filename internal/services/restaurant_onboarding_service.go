@@ -0,0 +1,544 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OnboardingDraft             = "draft"
+	OnboardingDocumentsUploaded = "documents_uploaded"
+	OnboardingUnderReview       = "under_review"
+	OnboardingApproved          = "approved"
+	OnboardingRejected          = "rejected"
+
+	DocumentFSSAI       = "fssai"
+	DocumentGST         = "gst"
+	DocumentBankDetails = "bank_details"
+
+	DocumentStatusPendingUpload = "pending_upload"
+	DocumentStatusUploaded      = "uploaded"
+	DocumentStatusVerified      = "verified"
+	DocumentStatusRejected      = "rejected"
+)
+
+var (
+	ErrOnboardingForbidden = apperr.Forbidden("onboarding_forbidden", "you don't have permission to manage this restaurant")
+	ErrOnboardingInvalid   = apperr.Validation("onboarding_invalid", "invalid onboarding request")
+	ErrOnboardingNotFound  = apperr.NotFound("onboarding_not_found", "restaurant or document not found")
+)
+
+// requiredDocuments must all be uploaded before submitting and verified before approval
+var requiredDocuments = []string{DocumentFSSAI, DocumentGST, DocumentBankDetails}
+
+// onboardingTransitions are the moves admins can make from each onboarding status. Owners
+// move draft and rejected restaurants to documents_uploaded by submitting.
+var onboardingTransitions = map[string][]string{
+	OnboardingDocumentsUploaded: {OnboardingUnderReview, OnboardingRejected},
+	OnboardingUnderReview:       {OnboardingApproved, OnboardingRejected},
+}
+
+var (
+	fssaiNumberPattern = regexp.MustCompile(`^[0-9]{14}$`)
+	gstinPattern       = regexp.MustCompile(`^[0-9]{2}[A-Z]{5}[0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+	bankAccountPattern = regexp.MustCompile(`^[0-9]{9,18}$`)
+	ifscPattern        = regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`)
+)
+
+const onboardingDateLayout = "2006-01-02"
+
+type RestaurantOnboardingService struct {
+	restaurantRepo repositories.RestaurantRepository
+	documentRepo   repositories.RestaurantDocumentRepository
+	auditRepo      repositories.AuditLogRepository
+	media          *MediaService
+}
+
+func NewRestaurantOnboardingService(
+	restaurantRepo repositories.RestaurantRepository,
+	documentRepo repositories.RestaurantDocumentRepository,
+	auditRepo repositories.AuditLogRepository,
+	media *MediaService,
+) *RestaurantOnboardingService {
+	return &RestaurantOnboardingService{
+		restaurantRepo: restaurantRepo,
+		documentRepo:   documentRepo,
+		auditRepo:      auditRepo,
+		media:          media,
+	}
+}
+
+type OnboardingDocumentRequest struct {
+	Type          string `json:"type" binding:"required,oneof=fssai gst bank_details"`
+	Number        string `json:"number" binding:"required"` // FSSAI licence number, GSTIN or bank account number
+	ExpiresOn     string `json:"expires_on"`                // fssai: licence expiry, YYYY-MM-DD
+	AccountHolder string `json:"account_holder"`            // bank_details
+	IFSC          string `json:"ifsc"`                      // bank_details
+	FileName      string `json:"file_name"`                 // licence, GST certificate or cancelled cheque
+	ContentType   string `json:"content_type" binding:"required"`
+	Size          int64  `json:"size" binding:"required,gt=0"`
+}
+
+type ConfirmDocumentRequest struct {
+	MediaID string `json:"media_id" binding:"required"`
+}
+
+type DocumentReviewRequest struct {
+	Status string `json:"status" binding:"required,oneof=verified rejected"`
+	Note   string `json:"note"`
+}
+
+type OnboardingTransitionRequest struct {
+	Status string `json:"status" binding:"required,oneof=under_review approved rejected"`
+	Note   string `json:"note"`
+}
+
+type DocumentUploadResponse struct {
+	Document *models.RestaurantDocument `json:"document"`
+	Upload   *UploadResponse            `json:"upload"`
+}
+
+// OnboardingDocument is a document with a signed link to its file
+type OnboardingDocument struct {
+	models.RestaurantDocument
+	FileURL string `json:"file_url,omitempty"`
+}
+
+type OnboardingOverview struct {
+	Restaurant *models.Restaurant   `json:"restaurant"`
+	Documents  []OnboardingDocument `json:"documents"`
+	Missing    []string             `json:"missing"` // required documents not uploaded yet, or rejected
+	CanSubmit  bool                 `json:"can_submit"`
+	History    []models.AuditLog    `json:"history"`
+}
+
+type OnboardingQueueResponse struct {
+	Restaurants []models.Restaurant `json:"restaurants"`
+	Total       int64               `json:"total"`
+	Page        int                 `json:"page"`
+	Limit       int                 `json:"limit"`
+}
+
+// GetOverview returns a restaurant's onboarding status, documents and checklist for its owner
+func (s *RestaurantOnboardingService) GetOverview(ctx context.Context, userID, restaurantID string) (*OnboardingOverview, error) {
+	restaurant, err := s.ownRestaurant(ctx, userID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	return s.overview(ctx, restaurant)
+}
+
+// UploadDocument records a document's details and returns a signed URL to upload its file to.
+// Uploading a document again replaces it once the new file is confirmed.
+func (s *RestaurantOnboardingService) UploadDocument(ctx context.Context, userID, restaurantID string, req *OnboardingDocumentRequest) (*DocumentUploadResponse, error) {
+	restaurant, err := s.ownRestaurant(ctx, userID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDocumentsEditable(restaurant); err != nil {
+		return nil, err
+	}
+
+	document, err := s.documentRepo.GetByRestaurantAndType(ctx, restaurant.ID, req.Type)
+	if err != nil {
+		document = &models.RestaurantDocument{
+			RestaurantID: restaurant.ID,
+			Type:         req.Type,
+		}
+	}
+	if err := applyDocumentDetails(document, restaurant, req); err != nil {
+		return nil, err
+	}
+
+	// New details need a new file and a fresh review; the old file stays until then
+	document.Status = DocumentStatusPendingUpload
+	document.ReviewNote = ""
+	document.ReviewedBy = nil
+	document.ReviewedAt = nil
+
+	if document.ID == uuid.Nil {
+		err = s.documentRepo.Create(ctx, document)
+	} else {
+		err = s.documentRepo.Update(ctx, document)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save document: %v", err)
+	}
+
+	upload, err := s.media.CreateDocumentUpload(ctx, userID, restaurant.ID.String(), document.ID.String(), req.FileName, req.ContentType, req.Size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOnboardingInvalid, err)
+	}
+
+	return &DocumentUploadResponse{Document: document, Upload: upload}, nil
+}
+
+// ConfirmDocument attaches an uploaded file to its document, replacing any earlier file
+func (s *RestaurantOnboardingService) ConfirmDocument(ctx context.Context, userID, restaurantID, documentID string, req *ConfirmDocumentRequest) (*models.RestaurantDocument, error) {
+	restaurant, err := s.ownRestaurant(ctx, userID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDocumentsEditable(restaurant); err != nil {
+		return nil, err
+	}
+	document, err := s.restaurantDocument(ctx, restaurant.ID, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	media, err := s.media.ConfirmDocumentUpload(ctx, restaurant.ID.String(), document.ID.String(), req.MediaID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOnboardingInvalid, err)
+	}
+
+	previous := document.MediaID
+	document.MediaID = media.ID.Hex()
+	document.FileName = media.FileName
+	document.Status = DocumentStatusUploaded
+	document.ReviewNote = ""
+	document.ReviewedBy = nil
+	document.ReviewedAt = nil
+	if err := s.documentRepo.Update(ctx, document); err != nil {
+		return nil, fmt.Errorf("failed to save document: %v", err)
+	}
+
+	if previous != "" && previous != document.MediaID {
+		s.media.RemoveDocument(ctx, previous)
+	}
+	return document, nil
+}
+
+// Submit sends a draft or rejected restaurant for review once every required document is uploaded
+func (s *RestaurantOnboardingService) Submit(ctx context.Context, userID, restaurantID string) (*OnboardingOverview, error) {
+	restaurant, err := s.ownRestaurant(ctx, userID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	if restaurant.IsBrand {
+		return nil, fmt.Errorf("%w: brands are not onboarded; submit their outlets instead", ErrOnboardingInvalid)
+	}
+	if restaurant.OnboardingStatus != OnboardingDraft && restaurant.OnboardingStatus != OnboardingRejected {
+		return nil, fmt.Errorf("%w: restaurant is %s and cannot be submitted", ErrOnboardingInvalid, restaurant.OnboardingStatus)
+	}
+
+	documents, err := s.documentRepo.GetByRestaurant(ctx, restaurant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load documents: %v", err)
+	}
+	if missing := missingDocuments(documents); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: upload %s before submitting", ErrOnboardingInvalid, strings.Join(missing, ", "))
+	}
+
+	now := time.Now()
+	from := restaurant.OnboardingStatus
+	restaurant.OnboardingStatus = OnboardingDocumentsUploaded
+	restaurant.SubmittedAt = &now
+	if err := s.restaurantRepo.Update(ctx, restaurant); err != nil {
+		return nil, fmt.Errorf("failed to submit restaurant: %v", err)
+	}
+	s.audit(ctx, restaurant, userID, from, "")
+
+	return s.overview(ctx, restaurant)
+}
+
+// ListQueue returns restaurants in an onboarding status, oldest submission first
+func (s *RestaurantOnboardingService) ListQueue(ctx context.Context, status string, page, limit int) (*OnboardingQueueResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	restaurants, total, err := s.restaurantRepo.GetByOnboardingStatus(ctx, status, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list restaurants: %v", err)
+	}
+	if restaurants == nil {
+		restaurants = []models.Restaurant{}
+	}
+
+	return &OnboardingQueueResponse{Restaurants: restaurants, Total: total, Page: page, Limit: limit}, nil
+}
+
+// GetReview returns a restaurant's onboarding details for an admin
+func (s *RestaurantOnboardingService) GetReview(ctx context.Context, restaurantID string) (*OnboardingOverview, error) {
+	restaurant, err := s.restaurant(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	return s.overview(ctx, restaurant)
+}
+
+// ReviewDocument marks a document verified or rejected. Rejections need a note for the owner.
+func (s *RestaurantOnboardingService) ReviewDocument(ctx context.Context, adminID, restaurantID, documentID string, req *DocumentReviewRequest) (*models.RestaurantDocument, error) {
+	restaurant, err := s.restaurant(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	if restaurant.OnboardingStatus != OnboardingUnderReview {
+		return nil, fmt.Errorf("%w: start the review before checking documents", ErrOnboardingInvalid)
+	}
+	document, err := s.restaurantDocument(ctx, restaurant.ID, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if document.Status == DocumentStatusPendingUpload {
+		return nil, fmt.Errorf("%w: the document file has not been uploaded", ErrOnboardingInvalid)
+	}
+	if req.Status == DocumentStatusRejected && strings.TrimSpace(req.Note) == "" {
+		return nil, fmt.Errorf("%w: a note is required when rejecting a document", ErrOnboardingInvalid)
+	}
+
+	now := time.Now()
+	document.Status = req.Status
+	document.ReviewNote = strings.TrimSpace(req.Note)
+	document.ReviewedAt = &now
+	if adminUUID, err := uuid.Parse(adminID); err == nil {
+		document.ReviewedBy = &adminUUID
+	}
+	if err := s.documentRepo.Update(ctx, document); err != nil {
+		return nil, fmt.Errorf("failed to save document: %v", err)
+	}
+	return document, nil
+}
+
+// Transition moves a restaurant through review. Approval needs every required document
+// verified and makes the restaurant active; rejection needs a note for the owner.
+func (s *RestaurantOnboardingService) Transition(ctx context.Context, adminID, restaurantID string, req *OnboardingTransitionRequest) (*OnboardingOverview, error) {
+	restaurant, err := s.restaurant(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, next := range onboardingTransitions[restaurant.OnboardingStatus] {
+		if next == req.Status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: cannot move a restaurant from %s to %s", ErrOnboardingInvalid, restaurant.OnboardingStatus, req.Status)
+	}
+
+	note := strings.TrimSpace(req.Note)
+	now := time.Now()
+	switch req.Status {
+	case OnboardingApproved:
+		documents, err := s.documentRepo.GetByRestaurant(ctx, restaurant.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load documents: %v", err)
+		}
+		if unverified := unverifiedDocuments(documents); len(unverified) > 0 {
+			return nil, fmt.Errorf("%w: verify %s before approving", ErrOnboardingInvalid, strings.Join(unverified, ", "))
+		}
+		restaurant.Status = "active"
+		restaurant.ApprovedAt = &now
+		restaurant.OnboardingNote = ""
+	case OnboardingRejected:
+		if note == "" {
+			return nil, fmt.Errorf("%w: a note is required when rejecting a restaurant", ErrOnboardingInvalid)
+		}
+		restaurant.OnboardingNote = note
+	}
+
+	from := restaurant.OnboardingStatus
+	restaurant.OnboardingStatus = req.Status
+	if err := s.restaurantRepo.Update(ctx, restaurant); err != nil {
+		return nil, fmt.Errorf("failed to update restaurant: %v", err)
+	}
+	s.audit(ctx, restaurant, adminID, from, note)
+
+	return s.overview(ctx, restaurant)
+}
+
+func (s *RestaurantOnboardingService) overview(ctx context.Context, restaurant *models.Restaurant) (*OnboardingOverview, error) {
+	documents, err := s.documentRepo.GetByRestaurant(ctx, restaurant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load documents: %v", err)
+	}
+
+	overview := &OnboardingOverview{
+		Restaurant: restaurant,
+		Documents:  make([]OnboardingDocument, 0, len(documents)),
+		Missing:    missingDocuments(documents),
+		History:    []models.AuditLog{},
+	}
+	overview.CanSubmit = len(overview.Missing) == 0 &&
+		(restaurant.OnboardingStatus == OnboardingDraft || restaurant.OnboardingStatus == OnboardingRejected)
+
+	for _, document := range documents {
+		entry := OnboardingDocument{RestaurantDocument: document}
+		if document.MediaID != "" {
+			if url, err := s.media.DocumentURL(ctx, document.MediaID); err == nil {
+				entry.FileURL = url
+			}
+		}
+		overview.Documents = append(overview.Documents, entry)
+	}
+
+	if history, err := s.auditRepo.GetByEntity(ctx, "restaurant", restaurant.ID.String(), 50); err == nil && history != nil {
+		overview.History = history
+	}
+	return overview, nil
+}
+
+// audit records an onboarding status change
+func (s *RestaurantOnboardingService) audit(ctx context.Context, restaurant *models.Restaurant, actorID, from, note string) {
+	entry := &models.AuditLog{
+		EntityType: "restaurant",
+		EntityID:   restaurant.ID.String(),
+		Action:     "onboarding_" + restaurant.OnboardingStatus,
+		Metadata: models.JSONB{
+			"from": from,
+			"to":   restaurant.OnboardingStatus,
+		},
+	}
+	if note != "" {
+		entry.Metadata["note"] = note
+	}
+	if actorUUID, err := uuid.Parse(actorID); err == nil {
+		entry.PerformedBy = &actorUUID
+	}
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write onboarding audit entry for restaurant %s: %v", restaurant.ID, err)
+	}
+}
+
+func (s *RestaurantOnboardingService) restaurant(ctx context.Context, restaurantID string) (*models.Restaurant, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrOnboardingInvalid)
+	}
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: restaurant %s", ErrOnboardingNotFound, restaurantID)
+	}
+	return restaurant, nil
+}
+
+// ownRestaurant loads a restaurant the user owns
+func (s *RestaurantOnboardingService) ownRestaurant(ctx context.Context, userID, restaurantID string) (*models.Restaurant, error) {
+	restaurant, err := s.restaurant(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	if restaurant.OwnerID.String() != userID {
+		return nil, ErrOnboardingForbidden
+	}
+	return restaurant, nil
+}
+
+func (s *RestaurantOnboardingService) restaurantDocument(ctx context.Context, restaurantID uuid.UUID, documentID string) (*models.RestaurantDocument, error) {
+	documentUUID, err := uuid.Parse(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid document ID", ErrOnboardingInvalid)
+	}
+	document, err := s.documentRepo.GetByID(ctx, documentUUID)
+	if err != nil || document.RestaurantID != restaurantID {
+		return nil, fmt.Errorf("%w: document %s", ErrOnboardingNotFound, documentID)
+	}
+	return document, nil
+}
+
+// checkDocumentsEditable stops documents changing while an admin reviews them or after approval
+func checkDocumentsEditable(restaurant *models.Restaurant) error {
+	switch restaurant.OnboardingStatus {
+	case OnboardingDraft, OnboardingDocumentsUploaded, OnboardingRejected:
+		return nil
+	}
+	return fmt.Errorf("%w: documents cannot be changed while the restaurant is %s", ErrOnboardingInvalid, restaurant.OnboardingStatus)
+}
+
+// applyDocumentDetails validates the document number and type-specific fields
+func applyDocumentDetails(document *models.RestaurantDocument, restaurant *models.Restaurant, req *OnboardingDocumentRequest) error {
+	number := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(req.Number), " ", ""))
+	document.Details = models.JSONB{}
+	document.ExpiresAt = nil
+
+	switch req.Type {
+	case DocumentFSSAI:
+		if !fssaiNumberPattern.MatchString(number) {
+			return fmt.Errorf("%w: FSSAI licence number must be 14 digits", ErrOnboardingInvalid)
+		}
+		expiresAt, err := time.Parse(onboardingDateLayout, req.ExpiresOn)
+		if err != nil {
+			return fmt.Errorf("%w: expires_on must be a date in YYYY-MM-DD format", ErrOnboardingInvalid)
+		}
+		if !expiresAt.After(time.Now()) {
+			return fmt.Errorf("%w: FSSAI licence has expired", ErrOnboardingInvalid)
+		}
+		document.ExpiresAt = &expiresAt
+	case DocumentGST:
+		if !gstinPattern.MatchString(number) {
+			return fmt.Errorf("%w: GSTIN must be 15 characters, e.g. 29ABCDE1234F1Z5", ErrOnboardingInvalid)
+		}
+		if restaurant.GSTNumber != "" && !strings.EqualFold(restaurant.GSTNumber, number) {
+			return fmt.Errorf("%w: GSTIN does not match the restaurant's GST number %s", ErrOnboardingInvalid, restaurant.GSTNumber)
+		}
+	case DocumentBankDetails:
+		ifsc := strings.ToUpper(strings.TrimSpace(req.IFSC))
+		holder := strings.TrimSpace(req.AccountHolder)
+		if !bankAccountPattern.MatchString(number) {
+			return fmt.Errorf("%w: bank account number must be 9 to 18 digits", ErrOnboardingInvalid)
+		}
+		if !ifscPattern.MatchString(ifsc) {
+			return fmt.Errorf("%w: IFSC must be 11 characters, e.g. HDFC0001234", ErrOnboardingInvalid)
+		}
+		if holder == "" {
+			return fmt.Errorf("%w: account_holder is required", ErrOnboardingInvalid)
+		}
+		document.Details["account_holder"] = holder
+		document.Details["ifsc"] = ifsc
+	}
+
+	document.Number = number
+	return nil
+}
+
+// missingDocuments lists required documents without an accepted upload
+func missingDocuments(documents []models.RestaurantDocument) []string {
+	uploaded := make(map[string]bool, len(documents))
+	for _, document := range documents {
+		if document.Status == DocumentStatusUploaded || document.Status == DocumentStatusVerified {
+			uploaded[document.Type] = true
+		}
+	}
+
+	missing := []string{}
+	for _, documentType := range requiredDocuments {
+		if !uploaded[documentType] {
+			missing = append(missing, documentType)
+		}
+	}
+	return missing
+}
+
+// unverifiedDocuments lists required documents an admin has not verified
+func unverifiedDocuments(documents []models.RestaurantDocument) []string {
+	verified := make(map[string]bool, len(documents))
+	for _, document := range documents {
+		if document.Status == DocumentStatusVerified {
+			verified[document.Type] = true
+		}
+	}
+
+	var unverified []string
+	for _, documentType := range requiredDocuments {
+		if !verified[documentType] {
+			unverified = append(unverified, documentType)
+		}
+	}
+	return unverified
+}
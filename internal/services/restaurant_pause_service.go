@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/cache"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// How a pause ended
+const (
+	PauseEndExpired  = "expired"  // ran its duration and was resumed by the cron service
+	PauseEndResumed  = "resumed"  // resumed early by the restaurant
+	PauseEndReplaced = "replaced" // a new pause took over
+)
+
+// pauseExpiryBatch is how many expired pauses the cron service closes per query
+const pauseExpiryBatch = 100
+
+// restaurantPauseDurations are the busy mode durations restaurants can choose, in minutes
+var restaurantPauseDurations = map[int]bool{15: true, 30: true, 60: true}
+
+var (
+	ErrPauseInvalid        = apperr.Validation("pause_invalid", "invalid pause request")
+	ErrPauseNotFound       = apperr.NotFound("pause_restaurant_not_found", "restaurant not found")
+	ErrPauseForbidden      = apperr.Forbidden("pause_forbidden", "you cannot pause or resume this restaurant")
+	ErrRestaurantNotPaused = apperr.Conflict("restaurant_not_paused", "restaurant is not paused")
+)
+
+// RestaurantPauseService puts restaurants in busy mode: while paused they take no new orders.
+// Pauses end by themselves when the cron service finds them expired, or earlier when resumed.
+type RestaurantPauseService struct {
+	pauseRepo      repositories.RestaurantPauseRepository
+	restaurantRepo repositories.RestaurantRepository
+	staffRepo      repositories.StaffRepository
+	cache          *cache.RedisCache
+}
+
+func NewRestaurantPauseService(
+	pauseRepo repositories.RestaurantPauseRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	staffRepo repositories.StaffRepository,
+	cache *cache.RedisCache,
+) *RestaurantPauseService {
+	return &RestaurantPauseService{
+		pauseRepo:      pauseRepo,
+		restaurantRepo: restaurantRepo,
+		staffRepo:      staffRepo,
+		cache:          cache,
+	}
+}
+
+type PauseRestaurantRequest struct {
+	DurationMinutes int    `json:"duration_minutes" binding:"required" example:"30"` // 15, 30 or 60
+	Reason          string `json:"reason"`                                           // e.g. "kitchen rush"
+}
+
+// RestaurantPauseStatus tells whether the restaurant is paused and until when
+type RestaurantPauseStatus struct {
+	Paused    bool                    `json:"paused"`
+	ResumesAt *time.Time              `json:"resumes_at,omitempty"`
+	Pause     *models.RestaurantPause `json:"pause,omitempty"`
+}
+
+type RestaurantPausesResponse struct {
+	Range      DashboardRange                     `json:"range"`
+	Totals     repositories.RestaurantPauseTotals `json:"totals"`
+	Pauses     []models.RestaurantPause           `json:"pauses"`
+	Pagination PaginationInfo                     `json:"pagination"`
+}
+
+// Pause stops new orders for the chosen duration. Pausing a paused restaurant replaces the
+// running pause, so the new duration counts from now.
+func (s *RestaurantPauseService) Pause(ctx context.Context, userID, role, restaurantID string, req *PauseRestaurantRequest) (*models.RestaurantPause, error) {
+	if !restaurantPauseDurations[req.DurationMinutes] {
+		return nil, fmt.Errorf("%w: duration_minutes must be 15, 30 or 60", ErrPauseInvalid)
+	}
+	restaurant, err := s.manageRestaurant(ctx, userID, role, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.endActivePause(ctx, restaurant.ID, now, PauseEndReplaced, userID); err != nil {
+		return nil, err
+	}
+
+	pause := &models.RestaurantPause{
+		RestaurantID:    restaurant.ID,
+		DurationMinutes: req.DurationMinutes,
+		Reason:          req.Reason,
+		StartedAt:       now,
+		EndsAt:          now.Add(time.Duration(req.DurationMinutes) * time.Minute),
+	}
+	if actorID, err := uuid.Parse(userID); err == nil {
+		pause.PausedBy = &actorID
+	}
+	if err := s.pauseRepo.Create(ctx, pause); err != nil {
+		return nil, fmt.Errorf("failed to pause restaurant: %v", err)
+	}
+
+	s.cache.InvalidateTags(ctx, restaurantTimingTag(restaurant.ID.String()))
+	return pause, nil
+}
+
+// Resume ends the running pause early
+func (s *RestaurantPauseService) Resume(ctx context.Context, userID, role, restaurantID string) (*models.RestaurantPause, error) {
+	restaurant, err := s.manageRestaurant(ctx, userID, role, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	pause, err := s.pauseRepo.GetActive(ctx, restaurant.ID, time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRestaurantNotPaused
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pause: %v", err)
+	}
+	if err := s.endPause(ctx, pause, time.Now(), PauseEndResumed, userID); err != nil {
+		return nil, err
+	}
+	return pause, nil
+}
+
+// GetPauseStatus returns whether the restaurant is paused now
+func (s *RestaurantPauseService) GetPauseStatus(ctx context.Context, userID, role, restaurantID string) (*RestaurantPauseStatus, error) {
+	restaurant, err := s.manageRestaurant(ctx, userID, role, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	pause, err := s.pauseRepo.GetActive(ctx, restaurant.ID, time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &RestaurantPauseStatus{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pause: %v", err)
+	}
+	return &RestaurantPauseStatus{Paused: true, ResumesAt: &pause.EndsAt, Pause: pause}, nil
+}
+
+// ListPauses returns the pauses started between from and to (YYYY-MM-DD, the last 30 days by
+// default), newest first, with how often and how long the restaurant was paused
+func (s *RestaurantPauseService) ListPauses(ctx context.Context, userID, role, restaurantID, from, to string, page, limit int) (*RestaurantPausesResponse, error) {
+	restaurant, err := s.manageRestaurant(ctx, userID, role, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	dateRange, err := ParseDashboardRange(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPauseInvalid, err)
+	}
+	page, limit = normalizePage(page, limit)
+
+	filter := repositories.RestaurantPauseFilter{
+		RestaurantID: restaurant.ID,
+		From:         dateRange.from,
+		To:           dateRange.to,
+	}
+	pauses, total, err := s.pauseRepo.List(ctx, filter, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pauses: %v", err)
+	}
+	totals, err := s.pauseRepo.Summarize(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize pauses: %v", err)
+	}
+
+	return &RestaurantPausesResponse{
+		Range:  dateRange,
+		Totals: *totals,
+		Pauses: pauses,
+		Pagination: PaginationInfo{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		},
+	}, nil
+}
+
+// ResumeExpired closes the pauses whose time ran out, as of when they were due to end. The
+// restaurants take orders again from that moment; this keeps the history accurate and lets
+// cached shop timing refresh.
+func (s *RestaurantPauseService) ResumeExpired(ctx context.Context) (int, error) {
+	resumed := 0
+	for {
+		pauses, err := s.pauseRepo.GetExpired(ctx, time.Now(), pauseExpiryBatch)
+		if err != nil {
+			return resumed, fmt.Errorf("failed to get expired pauses: %v", err)
+		}
+		for i := range pauses {
+			if err := s.endPause(ctx, &pauses[i], pauses[i].EndsAt, PauseEndExpired, ""); err != nil {
+				return resumed, err
+			}
+			resumed++
+		}
+		if len(pauses) < pauseExpiryBatch {
+			return resumed, nil
+		}
+	}
+}
+
+func (s *RestaurantPauseService) endActivePause(ctx context.Context, restaurantID uuid.UUID, at time.Time, reason, userID string) error {
+	pause, err := s.pauseRepo.GetActive(ctx, restaurantID, at)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get pause: %v", err)
+	}
+	return s.endPause(ctx, pause, at, reason, userID)
+}
+
+func (s *RestaurantPauseService) endPause(ctx context.Context, pause *models.RestaurantPause, at time.Time, reason, userID string) error {
+	pause.ResumedAt = &at
+	pause.EndReason = reason
+	if actorID, err := uuid.Parse(userID); err == nil {
+		pause.ResumedBy = &actorID
+	}
+	if err := s.pauseRepo.Update(ctx, pause); err != nil {
+		return fmt.Errorf("failed to resume restaurant: %v", err)
+	}
+
+	s.cache.InvalidateTags(ctx, restaurantTimingTag(pause.RestaurantID.String()))
+	return nil
+}
+
+// manageRestaurant loads a restaurant the user owns or is staff of with permission to manage
+// orders, or any restaurant for admins
+func (s *RestaurantPauseService) manageRestaurant(ctx context.Context, userID, role, restaurantID string) (*models.Restaurant, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrPauseInvalid)
+	}
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrPauseNotFound, restaurantID)
+	}
+	if role != "admin" && restaurant.OwnerID.String() != userID &&
+		!staffHasPermission(ctx, s.staffRepo, userID, restaurant.ID, StaffPermissionOrders) {
+		return nil, ErrPauseForbidden
+	}
+	return restaurant, nil
+}
@@ -2,29 +2,58 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"golang-food-backend/internal/models"
 	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/i18n"
+	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// ErrRestaurantNotApproved is returned when ordering from a restaurant whose onboarding
+// has not been approved yet
+var ErrRestaurantNotApproved = apperr.Conflict("restaurant_not_approved", "restaurant is not accepting orders until its onboarding is approved")
+
+// ErrBrandNotOrderable is returned when ordering from a parent brand instead of one of its outlets
+var ErrBrandNotOrderable = apperr.Conflict("brand_not_orderable", "brands take no orders; order from one of their outlets")
+
+// ErrRestaurantPaused is returned at checkout while the restaurant is in busy mode
+var ErrRestaurantPaused = apperr.Conflict("restaurant_paused", "restaurant is busy and not taking new orders")
+
 type RestaurantService struct {
 	restaurantRepo repositories.RestaurantRepository
+	pauseRepo      repositories.RestaurantPauseRepository
+	orderRepo      repositories.OrderRepository
 }
 
-func NewRestaurantService(restaurantRepo repositories.RestaurantRepository) *RestaurantService {
+func NewRestaurantService(
+	restaurantRepo repositories.RestaurantRepository,
+	pauseRepo repositories.RestaurantPauseRepository,
+	orderRepo repositories.OrderRepository,
+) *RestaurantService {
 	return &RestaurantService{
 		restaurantRepo: restaurantRepo,
+		pauseRepo:      pauseRepo,
+		orderRepo:      orderRepo,
 	}
 }
 
-func (s *RestaurantService) CreateRestaurant(restaurant *models.Restaurant) error {
-	ctx := context.Background()
+// CreateRestaurant saves a new restaurant as an onboarding draft; it goes live once an admin
+// approves its documents
+func (s *RestaurantService) CreateRestaurant(ctx context.Context, restaurant *models.Restaurant) error {
+	if err := normalizeRestaurantCurrency(restaurant); err != nil {
+		return err
+	}
+	restaurant.Status = "inactive"
+	restaurant.OnboardingStatus = OnboardingDraft
 	return s.restaurantRepo.Create(ctx, restaurant)
 }
 
-func (s *RestaurantService) GetRestaurants(page, limit int, cuisine, search string) ([]models.Restaurant, int, error) {
-	ctx := context.Background()
+func (s *RestaurantService) GetRestaurants(ctx context.Context, page, limit int, cuisine, search string) ([]models.Restaurant, int, error) {
 	offset := (page - 1) * limit
 
 	// For now, just use the search method from interface
@@ -38,22 +67,77 @@ func (s *RestaurantService) GetRestaurants(page, limit int, cuisine, search stri
 	return restaurants, total, nil
 }
 
-func (s *RestaurantService) GetRestaurantByID(id uuid.UUID) (*models.Restaurant, error) {
-	ctx := context.Background()
+func (s *RestaurantService) GetRestaurantByID(ctx context.Context, id uuid.UUID) (*models.Restaurant, error) {
 	return s.restaurantRepo.GetByID(ctx, id)
 }
 
-func (s *RestaurantService) UpdateRestaurant(restaurant *models.Restaurant) error {
-	ctx := context.Background()
+func (s *RestaurantService) UpdateRestaurant(ctx context.Context, restaurant *models.Restaurant) error {
+	if err := normalizeRestaurantCurrency(restaurant); err != nil {
+		return err
+	}
+	if err := validateOrderTypes(restaurant); err != nil {
+		return err
+	}
 	return s.restaurantRepo.Update(ctx, restaurant)
 }
 
-func (s *RestaurantService) DeleteRestaurant(id uuid.UUID) error {
-	ctx := context.Background()
+func (s *RestaurantService) DeleteRestaurant(ctx context.Context, id uuid.UUID) error {
 	return s.restaurantRepo.Delete(ctx, id)
 }
 
-func (s *RestaurantService) GetRestaurantsByOwner(ownerID uuid.UUID) ([]models.Restaurant, error) {
-	ctx := context.Background()
+func (s *RestaurantService) GetRestaurantsByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.Restaurant, error) {
 	return s.restaurantRepo.GetByOwnerID(ctx, ownerID)
 }
+
+// EnsureAcceptingOrders fails with ErrRestaurantNotApproved until the restaurant's onboarding is
+// approved, and with ErrBrandNotOrderable for parent brands
+func (s *RestaurantService) EnsureAcceptingOrders(ctx context.Context, restaurantID uuid.UUID) error {
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantID)
+	if err != nil {
+		return errors.New("restaurant not found")
+	}
+	if restaurant.IsBrand {
+		return ErrBrandNotOrderable
+	}
+	if restaurant.OnboardingStatus != OnboardingApproved {
+		return ErrRestaurantNotApproved
+	}
+	return nil
+}
+
+// EnsureNotPaused fails with ErrRestaurantPaused, naming the resume time, when the restaurant is
+// paused at the time an order would go to the kitchen
+func (s *RestaurantService) EnsureNotPaused(ctx context.Context, restaurantID uuid.UUID, at time.Time) error {
+	pause, err := s.pauseRepo.GetActive(ctx, restaurantID, at)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check restaurant pause: %v", err)
+	}
+	return fmt.Errorf("%w: orders resume at %s", ErrRestaurantPaused, pause.EndsAt.Format(time.RFC3339))
+}
+
+// Currency returns the code of the currency the restaurant charges in, or the default currency
+// when the restaurant cannot be loaded
+func (s *RestaurantService) Currency(ctx context.Context, restaurantID uuid.UUID) string {
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantID)
+	if err != nil {
+		return i18n.DefaultCurrency
+	}
+	return i18n.CurrencyOf(restaurant.Currency).Code
+}
+
+// normalizeRestaurantCurrency defaults the currency and rejects unsupported ones
+func normalizeRestaurantCurrency(restaurant *models.Restaurant) error {
+	if restaurant.Currency == "" {
+		restaurant.Currency = i18n.DefaultCurrency
+		return nil
+	}
+	currency, err := i18n.LookupCurrency(restaurant.Currency)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, restaurant.Currency)
+	}
+	restaurant.Currency = currency.Code
+	return nil
+}
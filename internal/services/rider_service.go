@@ -0,0 +1,607 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/sms"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Completed jobs returned in a rider's history
+const riderHistoryLimit = 50
+
+// RiderService manages a restaurant's own delivery riders and their jobs
+type RiderService struct {
+	riderRepo      repositories.RiderRepository
+	assignmentRepo repositories.RiderAssignmentRepository
+	userRepo       repositories.UserRepository
+	orderRepo      repositories.OrderRepository
+	tracking       *OrderTrackingService
+	smsService     *sms.SMSService
+}
+
+func NewRiderService(
+	riderRepo repositories.RiderRepository,
+	assignmentRepo repositories.RiderAssignmentRepository,
+	userRepo repositories.UserRepository,
+	orderRepo repositories.OrderRepository,
+	tracking *OrderTrackingService,
+	smsService *sms.SMSService,
+) *RiderService {
+	return &RiderService{
+		riderRepo:      riderRepo,
+		assignmentRepo: assignmentRepo,
+		userRepo:       userRepo,
+		orderRepo:      orderRepo,
+		tracking:       tracking,
+		smsService:     smsService,
+	}
+}
+
+type CreateRiderRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Phone         string `json:"phone" binding:"required"`
+	VehicleType   string `json:"vehicle_type"`
+	VehicleNumber string `json:"vehicle_number"`
+}
+
+type UpdateRiderRequest struct {
+	Name          *string `json:"name"`
+	VehicleType   *string `json:"vehicle_type"`
+	VehicleNumber *string `json:"vehicle_number"`
+	IsActive      *bool   `json:"is_active"`
+}
+
+type AssignRiderRequest struct {
+	RiderID string `json:"rider_id" binding:"required"`
+}
+
+type RiderAvailabilityRequest struct {
+	Online bool `json:"online"`
+}
+
+type RiderLocationRequest struct {
+	Latitude  float64 `json:"latitude" binding:"required"`
+	Longitude float64 `json:"longitude" binding:"required"`
+}
+
+type CompleteDeliveryRequest struct {
+	OTP string `json:"otp" binding:"required"`
+}
+
+// CreateRider registers a rider for a restaurant. The rider logs in through the
+// regular OTP flow with role "rider".
+func (s *RiderService) CreateRider(ctx context.Context, restaurantID string, req *CreateRiderRequest) (*models.Rider, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, errors.New("invalid restaurant ID")
+	}
+
+	user, err := s.userRepo.GetByPhone(ctx, req.Phone)
+	if err == nil {
+		if user.Role != "rider" {
+			return nil, errors.New("phone number is already registered with another role")
+		}
+		if existing, err := s.riderRepo.GetByUserID(ctx, user.ID); err == nil && existing != nil {
+			return nil, errors.New("rider with this phone already exists")
+		}
+	} else {
+		user = &models.User{
+			Name:         req.Name,
+			Phone:        req.Phone,
+			Email:        "", // riders log in with OTP only
+			PasswordHash: "",
+			RestaurantID: &restaurantUUID,
+			Role:         "rider",
+			Status:       "active",
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		}
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to create rider account: %v", err)
+		}
+	}
+
+	rider := &models.Rider{
+		UserID:        user.ID,
+		RestaurantID:  restaurantUUID,
+		Name:          req.Name,
+		Phone:         req.Phone,
+		VehicleType:   req.VehicleType,
+		VehicleNumber: req.VehicleNumber,
+		Status:        "offline",
+		IsActive:      true,
+	}
+
+	if err := s.riderRepo.Create(ctx, rider); err != nil {
+		return nil, fmt.Errorf("failed to create rider: %v", err)
+	}
+
+	return rider, nil
+}
+
+// ListRiders returns all riders of a restaurant
+func (s *RiderService) ListRiders(ctx context.Context, restaurantID string) ([]models.Rider, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, errors.New("invalid restaurant ID")
+	}
+
+	return s.riderRepo.GetByRestaurantID(ctx, restaurantUUID)
+}
+
+// UpdateRider updates a rider's details or deactivates them
+func (s *RiderService) UpdateRider(ctx context.Context, restaurantID, riderID string, req *UpdateRiderRequest) (*models.Rider, error) {
+	rider, err := s.getRestaurantRider(ctx, restaurantID, riderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		rider.Name = *req.Name
+	}
+	if req.VehicleType != nil {
+		rider.VehicleType = *req.VehicleType
+	}
+	if req.VehicleNumber != nil {
+		rider.VehicleNumber = *req.VehicleNumber
+	}
+	if req.IsActive != nil {
+		rider.IsActive = *req.IsActive
+		if !rider.IsActive && rider.Status == "available" {
+			rider.Status = "offline"
+		}
+	}
+
+	if err := s.riderRepo.Update(ctx, rider); err != nil {
+		return nil, fmt.Errorf("failed to update rider: %v", err)
+	}
+
+	return rider, nil
+}
+
+// AssignRider manually assigns a restaurant order to one of its riders
+func (s *RiderService) AssignRider(ctx context.Context, restaurantID, orderID string, req *AssignRiderRequest) (*models.RiderAssignment, error) {
+	rider, err := s.getRestaurantRider(ctx, restaurantID, req.RiderID)
+	if err != nil {
+		return nil, err
+	}
+	if !rider.IsActive {
+		return nil, errors.New("rider is not active")
+	}
+	if rider.Status == "offline" {
+		return nil, errors.New("rider is offline")
+	}
+
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, errors.New("invalid order ID")
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderUUID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	if order.RestaurantID != rider.RestaurantID {
+		return nil, errors.New("order does not belong to this restaurant")
+	}
+	if IsFinalOrderStatus(order.OrderStatus) {
+		return nil, fmt.Errorf("cannot assign a rider to a %s order", order.OrderStatus)
+	}
+
+	return s.assign(ctx, order, rider)
+}
+
+// AutoAssign assigns the order to the restaurant's longest-idle available rider
+func (s *RiderService) AutoAssign(ctx context.Context, order *models.Order) (*models.RiderAssignment, error) {
+	riders, err := s.riderRepo.GetAvailableByRestaurantID(ctx, order.RestaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get available riders: %v", err)
+	}
+	if len(riders) == 0 {
+		return nil, errors.New("no riders available")
+	}
+
+	return s.assign(ctx, order, &riders[0])
+}
+
+// assign replaces any open assignment for the order with a new one for the rider
+func (s *RiderService) assign(ctx context.Context, order *models.Order, rider *models.Rider) (*models.RiderAssignment, error) {
+	if !isDeliveryOrder(order) {
+		return nil, fmt.Errorf("%w: %s orders have no rider", ErrNotDeliveryOrder, order.OrderType)
+	}
+	if existing, err := s.assignmentRepo.GetActiveByOrderID(ctx, order.ID); err == nil && existing != nil {
+		if existing.Status == "picked_up" {
+			return nil, errors.New("order has already been picked up by a rider")
+		}
+		if err := s.closeAssignment(ctx, existing, "cancelled"); err != nil {
+			return nil, err
+		}
+	}
+
+	assignment := &models.RiderAssignment{
+		OrderID:      order.ID,
+		RiderID:      rider.ID,
+		RestaurantID: order.RestaurantID,
+		Status:       "assigned",
+		AssignedAt:   time.Now(),
+	}
+
+	if err := s.assignmentRepo.Create(ctx, assignment); err != nil {
+		return nil, fmt.Errorf("failed to create rider assignment: %v", err)
+	}
+
+	rider.Status = "on_delivery"
+	if err := s.riderRepo.Update(ctx, rider); err != nil {
+		log.Printf("Failed to mark rider %s on delivery: %v", rider.ID, err)
+	}
+
+	assignment.Rider = *rider
+	return assignment, nil
+}
+
+// CancelAssignment cancels an open assignment and frees the rider
+func (s *RiderService) CancelAssignment(ctx context.Context, assignmentID string) error {
+	assignmentUUID, err := uuid.Parse(assignmentID)
+	if err != nil {
+		return errors.New("invalid assignment ID")
+	}
+
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentUUID)
+	if err != nil {
+		return errors.New("assignment not found")
+	}
+
+	switch assignment.Status {
+	case "delivered":
+		return errors.New("delivery is already completed")
+	case "cancelled", "rejected":
+		return nil
+	}
+
+	return s.closeAssignment(ctx, assignment, "cancelled")
+}
+
+// GetAssignment returns a rider assignment with its rider
+func (s *RiderService) GetAssignment(ctx context.Context, assignmentID string) (*models.RiderAssignment, error) {
+	assignmentUUID, err := uuid.Parse(assignmentID)
+	if err != nil {
+		return nil, errors.New("invalid assignment ID")
+	}
+
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentUUID)
+	if err != nil {
+		return nil, errors.New("assignment not found")
+	}
+
+	return assignment, nil
+}
+
+// GetMe returns the rider profile for a logged-in rider
+func (s *RiderService) GetMe(ctx context.Context, userID string) (*models.Rider, error) {
+	return s.getRiderForUser(ctx, userID)
+}
+
+// SetAvailability toggles a rider between offline and available
+func (s *RiderService) SetAvailability(ctx context.Context, userID string, req *RiderAvailabilityRequest) (*models.Rider, error) {
+	rider, err := s.getRiderForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if rider.Status == "on_delivery" {
+		return nil, errors.New("cannot change availability during a delivery")
+	}
+
+	rider.Status = "offline"
+	if req.Online {
+		rider.Status = "available"
+	}
+	now := time.Now()
+	rider.LastSeenAt = &now
+
+	if err := s.riderRepo.Update(ctx, rider); err != nil {
+		return nil, fmt.Errorf("failed to update availability: %v", err)
+	}
+
+	return rider, nil
+}
+
+// GetMyAssignments returns the rider's open jobs, or their recent history
+func (s *RiderService) GetMyAssignments(ctx context.Context, userID string, history bool) ([]models.RiderAssignment, error) {
+	rider, err := s.getRiderForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if history {
+		return s.assignmentRepo.GetByRiderID(ctx, rider.ID, riderHistoryLimit)
+	}
+	return s.assignmentRepo.GetActiveByRiderID(ctx, rider.ID)
+}
+
+// AcceptAssignment confirms the rider will take the job
+func (s *RiderService) AcceptAssignment(ctx context.Context, userID, assignmentID string) (*models.RiderAssignment, error) {
+	assignment, err := s.getRiderAssignment(ctx, userID, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.Status != "assigned" {
+		return nil, fmt.Errorf("cannot accept a %s assignment", assignment.Status)
+	}
+
+	now := time.Now()
+	assignment.Status = "accepted"
+	assignment.AcceptedAt = &now
+
+	if err := s.assignmentRepo.Update(ctx, assignment); err != nil {
+		return nil, fmt.Errorf("failed to accept assignment: %v", err)
+	}
+	s.publishDeliveryStatus(ctx, assignment, "")
+
+	return assignment, nil
+}
+
+// RejectAssignment hands the job back to the restaurant for reassignment
+func (s *RiderService) RejectAssignment(ctx context.Context, userID, assignmentID string) (*models.RiderAssignment, error) {
+	assignment, err := s.getRiderAssignment(ctx, userID, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.Status != "assigned" && assignment.Status != "accepted" {
+		return nil, fmt.Errorf("cannot reject a %s assignment", assignment.Status)
+	}
+
+	if err := s.closeAssignment(ctx, assignment, "rejected"); err != nil {
+		return nil, err
+	}
+
+	return assignment, nil
+}
+
+// PickUpOrder marks the order as collected from the restaurant and sends the
+// customer the order's delivery code, which the rider must collect at the door
+func (s *RiderService) PickUpOrder(ctx context.Context, userID, assignmentID string) (*models.RiderAssignment, error) {
+	assignment, err := s.getRiderAssignment(ctx, userID, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.Status != "accepted" {
+		return nil, errors.New("assignment must be accepted before pickup")
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, assignment.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %v", err)
+	}
+	if order.OrderStatus == "cancelled" {
+		return nil, errors.New("order has been cancelled")
+	}
+
+	// Orders placed before delivery codes were issued get one now
+	if order.DeliveryCode == "" {
+		err := updateOrder(ctx, s.orderRepo, order, func(order *models.Order) error {
+			if order.DeliveryCode != "" {
+				return nil
+			}
+			return assignDeliveryCode(order)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update order: %w", err)
+		}
+	}
+	otp := order.DeliveryCode
+
+	now := time.Now()
+	assignment.Status = "picked_up"
+	assignment.PickedUpAt = &now
+	assignment.HandoverOTP = otp
+
+	if err := s.assignmentRepo.Update(ctx, assignment); err != nil {
+		return nil, fmt.Errorf("failed to update assignment: %v", err)
+	}
+
+	if err := s.updateOrderStatus(ctx, order, "dispatched"); err != nil {
+		return nil, err
+	}
+	s.publishDeliveryStatus(ctx, assignment, order.OrderStatus)
+
+	if order.CustomerContact != "" {
+		message := fmt.Sprintf("Your order is on the way with %s. Share OTP %s with the rider to receive it.", assignment.Rider.Name, otp)
+		if _, err := s.smsService.Send(ctx, order.CustomerContact, message); err != nil {
+			log.Printf("Failed to send handover OTP for order %s: %v", order.ID, err)
+		}
+	}
+
+	return assignment, nil
+}
+
+// CompleteDelivery verifies the customer's delivery code and closes the job
+func (s *RiderService) CompleteDelivery(ctx context.Context, userID, assignmentID string, req *CompleteDeliveryRequest) (*models.RiderAssignment, error) {
+	assignment, err := s.getRiderAssignment(ctx, userID, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.Status != "picked_up" {
+		return nil, errors.New("order has not been picked up")
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, assignment.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %v", err)
+	}
+	if err := verifyDeliveryCode(order, req.OTP); err != nil {
+		return nil, err
+	}
+
+	if err := s.closeAssignment(ctx, assignment, "delivered"); err != nil {
+		return nil, err
+	}
+
+	if err := s.updateOrderStatus(ctx, order, "delivered"); err != nil {
+		return nil, err
+	}
+
+	return assignment, nil
+}
+
+// UpdateLocation stores the rider's position and streams it for their open jobs
+func (s *RiderService) UpdateLocation(ctx context.Context, userID string, req *RiderLocationRequest) error {
+	rider, err := s.getRiderForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	rider.LastLatitude = &req.Latitude
+	rider.LastLongitude = &req.Longitude
+	rider.LastSeenAt = &now
+
+	if err := s.riderRepo.Update(ctx, rider); err != nil {
+		return fmt.Errorf("failed to update location: %v", err)
+	}
+
+	assignments, err := s.assignmentRepo.GetActiveByRiderID(ctx, rider.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get assignments: %v", err)
+	}
+
+	for _, assignment := range assignments {
+		if assignment.Status == "assigned" {
+			continue
+		}
+		if err := s.tracking.RecordPartnerLocation(ctx, assignment.OrderID, "self", assignment.ID.String(), assignment.Status, req.Latitude, req.Longitude); err != nil {
+			log.Printf("Failed to record rider location for order %s: %v", assignment.OrderID, err)
+		}
+	}
+
+	return nil
+}
+
+// closeAssignment moves an assignment to a terminal status and frees the rider
+func (s *RiderService) closeAssignment(ctx context.Context, assignment *models.RiderAssignment, status string) error {
+	now := time.Now()
+	assignment.Status = status
+	assignment.HandoverOTP = ""
+	if status == "delivered" {
+		assignment.DeliveredAt = &now
+	} else {
+		assignment.CancelledAt = &now
+	}
+
+	if err := s.assignmentRepo.Update(ctx, assignment); err != nil {
+		return fmt.Errorf("failed to update assignment: %v", err)
+	}
+	orderStatus := ""
+	if status == "delivered" {
+		orderStatus = "delivered"
+	}
+	s.publishDeliveryStatus(ctx, assignment, orderStatus)
+
+	rider, err := s.riderRepo.GetByID(ctx, assignment.RiderID)
+	if err != nil {
+		return nil
+	}
+
+	// Riders can hold several jobs; only free them once the last one closes
+	if open, err := s.assignmentRepo.GetActiveByRiderID(ctx, rider.ID); err == nil && len(open) == 0 {
+		rider.Status = "available"
+		if !rider.IsActive {
+			rider.Status = "offline"
+		}
+		if err := s.riderRepo.Update(ctx, rider); err != nil {
+			log.Printf("Failed to free rider %s: %v", rider.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// publishDeliveryStatus emits the DeliveryStatusChanged event for an in-house delivery
+func (s *RiderService) publishDeliveryStatus(ctx context.Context, assignment *models.RiderAssignment, orderStatus string) {
+	s.tracking.PublishDeliveryStatus(ctx, assignment.OrderID, assignment.RestaurantID, "self", assignment.ID.String(), assignment.Status, orderStatus)
+}
+
+func (s *RiderService) updateOrderStatus(ctx context.Context, order *models.Order, status string) error {
+	if order.OrderStatus == status {
+		return nil
+	}
+
+	err := updateOrder(ctx, s.orderRepo, order, func(order *models.Order) error {
+		order.OrderStatus = status
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	s.tracking.PublishStatus(ctx, order)
+	return nil
+}
+
+func (s *RiderService) getRestaurantRider(ctx context.Context, restaurantID, riderID string) (*models.Rider, error) {
+	riderUUID, err := uuid.Parse(riderID)
+	if err != nil {
+		return nil, errors.New("invalid rider ID")
+	}
+
+	rider, err := s.riderRepo.GetByID(ctx, riderUUID)
+	if err != nil || rider.RestaurantID.String() != restaurantID {
+		return nil, errors.New("rider not found")
+	}
+
+	return rider, nil
+}
+
+func (s *RiderService) getRiderForUser(ctx context.Context, userID string) (*models.Rider, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	rider, err := s.riderRepo.GetByUserID(ctx, userUUID)
+	if err != nil {
+		return nil, errors.New("rider profile not found")
+	}
+	if !rider.IsActive {
+		return nil, errors.New("rider account is not active")
+	}
+
+	return rider, nil
+}
+
+func (s *RiderService) getRiderAssignment(ctx context.Context, userID, assignmentID string) (*models.RiderAssignment, error) {
+	rider, err := s.getRiderForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	assignmentUUID, err := uuid.Parse(assignmentID)
+	if err != nil {
+		return nil, errors.New("invalid assignment ID")
+	}
+
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentUUID)
+	if err != nil || assignment.RiderID != rider.ID {
+		return nil, errors.New("assignment not found")
+	}
+
+	return assignment, nil
+}
+
+// generateHandoverOTP generates a 4-digit OTP for doorstep handover
+func generateHandoverOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(10000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%04d", n.Int64()), nil
+}
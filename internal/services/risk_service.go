@@ -0,0 +1,547 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/clientinfo"
+	"golang-food-backend/pkg/geo"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Requests the fraud rules screen
+const (
+	RiskEventCheckout = "checkout"
+	RiskEventRefund   = "refund"
+)
+
+// Decisions, from weakest to strongest. Review lets the request through and queues it for an
+// admin; block refuses it.
+const (
+	RiskDecisionAllow  = "allow"
+	RiskDecisionReview = "review"
+	RiskDecisionBlock  = "block"
+)
+
+const (
+	RiskReviewPending  = "pending"
+	RiskReviewApproved = "approved"
+	RiskReviewRejected = "rejected"
+)
+
+// Built-in fraud rules
+const (
+	RiskRuleDeviceVelocity = "device_order_velocity"
+	RiskRulePhoneVelocity  = "phone_order_velocity"
+	RiskRuleCODAbuse       = "cod_abuse"
+	RiskRuleRefundClaims   = "refund_claims"
+	RiskRuleGeoMismatch    = "geo_mismatch"
+)
+
+// riskRuleDefault is a rule's setting while none is stored for it
+type riskRuleDefault struct {
+	description string
+	event       string
+	action      string
+	threshold   float64
+	windowHours int
+	blockHours  int
+}
+
+var riskRuleDefaults = map[string]riskRuleDefault{
+	RiskRuleDeviceVelocity: {description: "Checkouts from one device within the window", event: RiskEventCheckout, action: RiskDecisionReview, threshold: 5, windowHours: 1, blockHours: 24},
+	RiskRulePhoneVelocity:  {description: "Checkouts by accounts with one phone number within the window", event: RiskEventCheckout, action: RiskDecisionReview, threshold: 5, windowHours: 1, blockHours: 24},
+	RiskRuleCODAbuse:       {description: "Cancelled cash on delivery orders within the window; applies to cash checkouts", event: RiskEventCheckout, action: RiskDecisionBlock, threshold: 3, windowHours: 30 * 24},
+	RiskRuleRefundClaims:   {description: "Refunds requested within the window", event: RiskEventRefund, action: RiskDecisionReview, threshold: 3, windowHours: 30 * 24},
+	RiskRuleGeoMismatch:    {description: "Kilometres between the device's reported location and the delivery address", event: RiskEventCheckout, action: RiskDecisionReview, threshold: 50},
+}
+
+var riskDecisionRank = map[string]int{
+	RiskDecisionAllow:  0,
+	RiskDecisionReview: 1,
+	RiskDecisionBlock:  2,
+}
+
+var (
+	ErrRiskInvalid         = apperr.Validation("risk_invalid", "invalid risk request")
+	ErrRiskRuleNotFound    = apperr.NotFound("risk_rule_not_found", "risk rule not found")
+	ErrRiskNotFound        = apperr.NotFound("risk_assessment_not_found", "risk assessment not found")
+	ErrRiskReviewClosed    = apperr.Conflict("risk_review_closed", "assessment is not awaiting review")
+	ErrRiskBlocked         = apperr.Forbidden("risk_blocked", "this request cannot be accepted; please contact support")
+	errRiskRuleUnavailable = errors.New("signal unavailable")
+)
+
+// RiskOrderCanceller cancels orders rejected in review
+type RiskOrderCanceller interface {
+	CancelOrder(ctx context.Context, orderID uuid.UUID) error
+}
+
+// RiskService screens checkouts and refund requests against the fraud rules and keeps the
+// review queue for the ones they flag
+type RiskService struct {
+	riskRepo    repositories.RiskRepository
+	userRepo    repositories.UserRepository
+	addressRepo repositories.AddressRepository
+	refundRepo  repositories.RefundRepository
+	blocklist   *BlocklistService
+	orders      RiskOrderCanceller
+}
+
+func NewRiskService(
+	riskRepo repositories.RiskRepository,
+	userRepo repositories.UserRepository,
+	addressRepo repositories.AddressRepository,
+	refundRepo repositories.RefundRepository,
+	blocklist *BlocklistService,
+) *RiskService {
+	return &RiskService{
+		riskRepo:    riskRepo,
+		userRepo:    userRepo,
+		addressRepo: addressRepo,
+		refundRepo:  refundRepo,
+		blocklist:   blocklist,
+	}
+}
+
+// SetOrderCanceller sets what cancels orders rejected in review
+func (s *RiskService) SetOrderCanceller(orders RiskOrderCanceller) {
+	s.orders = orders
+}
+
+// RiskCheck is a checkout or refund request to screen. The client's device, location and IP
+// address come from the request context.
+type RiskCheck struct {
+	Event           string
+	UserID          uuid.UUID
+	PaymentMethod   string                 // checkout: cash for cash on delivery
+	AddressID       *uuid.UUID             // checkout: saved delivery address
+	DeliveryAddress map[string]interface{} // checkout: delivery address with latitude and longitude, when not saved
+}
+
+type RiskRuleRequest struct {
+	Enabled     *bool   `json:"enabled" binding:"required"`
+	Action      string  `json:"action" binding:"required,oneof=review block"`
+	Threshold   float64 `json:"threshold" binding:"gt=0"`
+	WindowHours int     `json:"window_hours" binding:"min=0"`
+	BlockHours  int     `json:"block_hours" binding:"min=0"` // blocklist the offender when the rule blocks; 0 for no block
+}
+
+// RiskRuleStatus is a rule's setting in effect
+type RiskRuleStatus struct {
+	Key         string     `json:"key"`
+	Description string     `json:"description"`
+	Event       string     `json:"event"` // checkout or refund
+	Enabled     bool       `json:"enabled"`
+	Action      string     `json:"action"`
+	Threshold   float64    `json:"threshold"`
+	WindowHours int        `json:"window_hours,omitempty"`
+	BlockHours  int        `json:"block_hours"`
+	Customized  bool       `json:"customized"` // a stored setting overrides the default
+	UpdatedBy   *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// RiskTrigger is a rule that fired, as recorded on an assessment
+type RiskTrigger struct {
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Action    string  `json:"action"`
+}
+
+type RiskReviewRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+// RiskAssessmentQuery filters the recorded decisions
+type RiskAssessmentQuery struct {
+	Event        string
+	Decision     string
+	ReviewStatus string
+	UserID       string
+	Page         int
+	Limit        int
+}
+
+type RiskAssessmentsResponse struct {
+	Assessments []models.RiskAssessment `json:"assessments"`
+	Pagination  PaginationInfo          `json:"pagination"`
+}
+
+// Assess screens the request. A blocked request is recorded and returns ErrRiskBlocked; any
+// other assessment is returned unsaved for Record once the order or refund exists. A rule
+// whose signal cannot be read is skipped rather than holding up the customer. Checkouts by
+// blocklisted users, phone numbers and devices fail with ErrBlocked.
+func (s *RiskService) Assess(ctx context.Context, check RiskCheck) (*models.RiskAssessment, error) {
+	client := clientinfo.FromContext(ctx)
+	assessment := &models.RiskAssessment{
+		Event:         check.Event,
+		UserID:        check.UserID,
+		PaymentMethod: check.PaymentMethod,
+		DeviceID:      client.DeviceID,
+		IPAddress:     client.IPAddress,
+		Decision:      RiskDecisionAllow,
+		Triggered:     models.JSONB{},
+	}
+	if user, err := s.userRepo.GetByID(ctx, check.UserID); err == nil {
+		assessment.Phone = user.Phone
+	}
+	if check.Event == RiskEventCheckout {
+		if err := s.blocklist.Check(ctx, BlockSubject{UserID: check.UserID.String(), Phone: assessment.Phone, DeviceID: assessment.DeviceID}); err != nil {
+			return nil, err
+		}
+	}
+
+	rules, err := s.ListRules(ctx)
+	if err != nil {
+		log.Printf("Failed to load risk rules, using defaults: %v", err)
+		rules = defaultRiskRules()
+	}
+	codOnly := true
+	var blocking []RiskRuleStatus
+	for _, rule := range rules {
+		if !rule.Enabled || rule.Event != check.Event {
+			continue
+		}
+		value, err := s.measure(ctx, rule, check, assessment, client)
+		if err != nil {
+			if !errors.Is(err, errRiskRuleUnavailable) {
+				log.Printf("Failed to evaluate risk rule %s for user %s: %v", rule.Key, check.UserID, err)
+			}
+			continue
+		}
+		if value < rule.Threshold {
+			continue
+		}
+		assessment.Triggered[rule.Key] = RiskTrigger{Value: value, Threshold: rule.Threshold, Action: rule.Action}
+		if riskDecisionRank[rule.Action] > riskDecisionRank[assessment.Decision] {
+			assessment.Decision = rule.Action
+		}
+		if rule.Action == RiskDecisionBlock {
+			blocking = append(blocking, rule)
+			if rule.Key != RiskRuleCODAbuse {
+				codOnly = false
+			}
+		}
+	}
+
+	if assessment.Decision != RiskDecisionBlock {
+		return assessment, nil
+	}
+	assessment.ID = uuid.New()
+	assessment.CreatedAt = time.Now()
+	if err := s.riskRepo.CreateAssessment(ctx, assessment); err != nil {
+		log.Printf("Failed to record blocked %s for user %s: %v", check.Event, check.UserID, err)
+	}
+	for _, rule := range blocking {
+		s.blockOffender(ctx, rule, assessment)
+	}
+	if codOnly {
+		return assessment, fmt.Errorf("%w: cash on delivery is not available for this account, please pay online", ErrRiskBlocked)
+	}
+	return assessment, ErrRiskBlocked
+}
+
+// Record saves an assessment from Assess against the order or refund it let through, queueing
+// it for review when a rule flagged it. Failures are logged; the request already succeeded.
+func (s *RiskService) Record(ctx context.Context, assessment *models.RiskAssessment, orderID, refundID *uuid.UUID) {
+	if assessment == nil {
+		return
+	}
+	assessment.OrderID = orderID
+	assessment.RefundID = refundID
+	if assessment.Decision == RiskDecisionReview {
+		assessment.ReviewStatus = RiskReviewPending
+	}
+	if assessment.CreatedAt.IsZero() {
+		assessment.CreatedAt = time.Now()
+	}
+	if err := s.riskRepo.CreateAssessment(ctx, assessment); err != nil {
+		log.Printf("Failed to record risk assessment for user %s: %v", assessment.UserID, err)
+	}
+}
+
+// blockOffender blocklists what tripped a blocking rule for the rule's block hours: the device or
+// phone number for velocity rules, otherwise the user
+func (s *RiskService) blockOffender(ctx context.Context, rule RiskRuleStatus, assessment *models.RiskAssessment) {
+	if rule.BlockHours <= 0 {
+		return
+	}
+	kind, value := BlockKindUser, assessment.UserID.String()
+	switch rule.Key {
+	case RiskRuleDeviceVelocity:
+		kind, value = BlockKindDevice, assessment.DeviceID
+	case RiskRulePhoneVelocity:
+		kind, value = BlockKindPhone, assessment.Phone
+	}
+	reason := fmt.Sprintf("Automatic: fraud rule %s", rule.Key)
+	if _, err := s.blocklist.BlockAutomatically(ctx, kind, value, reason, time.Duration(rule.BlockHours)*time.Hour, assessment.ID); err != nil {
+		log.Printf("Failed to blocklist %s %s after rule %s: %v", kind, value, rule.Key, err)
+	}
+}
+
+// measure reads the rule's signal for the request; errRiskRuleUnavailable means the request
+// lacks what the rule needs
+func (s *RiskService) measure(ctx context.Context, rule RiskRuleStatus, check RiskCheck, assessment *models.RiskAssessment, client clientinfo.Info) (float64, error) {
+	since := time.Now().Add(-time.Duration(rule.WindowHours) * time.Hour)
+	switch rule.Key {
+	case RiskRuleDeviceVelocity:
+		if assessment.DeviceID == "" {
+			return 0, errRiskRuleUnavailable
+		}
+		count, err := s.riskRepo.CountAssessments(ctx, repositories.RiskAssessmentFilter{Event: check.Event, DeviceID: assessment.DeviceID, Since: since})
+		return float64(count + 1), err
+	case RiskRulePhoneVelocity:
+		if assessment.Phone == "" {
+			return 0, errRiskRuleUnavailable
+		}
+		count, err := s.riskRepo.CountAssessments(ctx, repositories.RiskAssessmentFilter{Event: check.Event, Phone: assessment.Phone, Since: since})
+		return float64(count + 1), err
+	case RiskRuleCODAbuse:
+		if check.PaymentMethod != "cash" {
+			return 0, errRiskRuleUnavailable
+		}
+		count, err := s.riskRepo.CountCancelledOrders(ctx, check.UserID, "cash", since)
+		return float64(count), err
+	case RiskRuleRefundClaims:
+		count, err := s.riskRepo.CountRefunds(ctx, check.UserID, since)
+		return float64(count + 1), err
+	case RiskRuleGeoMismatch:
+		if client.Location == nil {
+			return 0, errRiskRuleUnavailable
+		}
+		delivery := s.deliveryPoint(ctx, check)
+		if delivery == nil {
+			return 0, errRiskRuleUnavailable
+		}
+		return math.Round(geo.DistanceKm(*client.Location, *delivery)*10) / 10, nil
+	}
+	return 0, errRiskRuleUnavailable
+}
+
+func (s *RiskService) deliveryPoint(ctx context.Context, check RiskCheck) *geo.Point {
+	if check.AddressID != nil {
+		if address, err := s.addressRepo.GetByID(ctx, *check.AddressID); err == nil && (address.Latitude != 0 || address.Longitude != 0) {
+			return &geo.Point{Lat: address.Latitude, Lng: address.Longitude}
+		}
+	}
+	lat, latOK := check.DeliveryAddress["latitude"].(float64)
+	lng, lngOK := check.DeliveryAddress["longitude"].(float64)
+	if !latOK || !lngOK {
+		return nil
+	}
+	return &geo.Point{Lat: lat, Lng: lng}
+}
+
+// ListRules lists the rules with their settings in effect, by key
+func (s *RiskService) ListRules(ctx context.Context) ([]RiskRuleStatus, error) {
+	stored, err := s.riskRepo.ListRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list risk rules: %v", err)
+	}
+
+	rules := defaultRiskRules()
+	for i := range rules {
+		for j := range stored {
+			if stored[j].Key == rules[i].Key {
+				applyRiskRule(&rules[i], &stored[j])
+			}
+		}
+	}
+	return rules, nil
+}
+
+// UpdateRule stores a rule's setting, overriding its default
+func (s *RiskService) UpdateRule(ctx context.Context, adminID, key string, req *RiskRuleRequest) (*RiskRuleStatus, error) {
+	defaults, ok := riskRuleDefaults[key]
+	if !ok {
+		return nil, ErrRiskRuleNotFound
+	}
+	if req.Action != RiskDecisionReview && req.Action != RiskDecisionBlock {
+		return nil, fmt.Errorf("%w: action must be review or block", ErrRiskInvalid)
+	}
+	if req.Threshold <= 0 {
+		return nil, fmt.Errorf("%w: threshold must be positive", ErrRiskInvalid)
+	}
+	if key != RiskRuleGeoMismatch && req.WindowHours < 1 {
+		return nil, fmt.Errorf("%w: window_hours must be at least 1", ErrRiskInvalid)
+	}
+
+	rule, err := s.riskRepo.GetRule(ctx, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		rule = &models.RiskRule{Key: key}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load risk rule: %v", err)
+	}
+	rule.Enabled = *req.Enabled
+	rule.Action = req.Action
+	rule.Threshold = req.Threshold
+	rule.WindowHours = req.WindowHours
+	rule.BlockHours = req.BlockHours
+	if adminUUID, err := uuid.Parse(adminID); err == nil {
+		rule.UpdatedBy = &adminUUID
+	}
+	if err := s.riskRepo.SaveRule(ctx, rule); err != nil {
+		return nil, fmt.Errorf("failed to save risk rule: %v", err)
+	}
+
+	status := riskRuleStatus(key, defaults)
+	applyRiskRule(&status, rule)
+	return &status, nil
+}
+
+// ListAssessments lists recorded decisions, newest first
+func (s *RiskService) ListAssessments(ctx context.Context, query RiskAssessmentQuery) (*RiskAssessmentsResponse, error) {
+	filter := repositories.RiskAssessmentFilter{
+		Event:        query.Event,
+		Decision:     query.Decision,
+		ReviewStatus: query.ReviewStatus,
+	}
+	if query.UserID != "" {
+		userUUID, err := uuid.Parse(query.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid user ID", ErrRiskInvalid)
+		}
+		filter.UserID = userUUID
+	}
+
+	page, limit := normalizePage(query.Page, query.Limit)
+	assessments, total, err := s.riskRepo.ListAssessments(ctx, filter, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list risk assessments: %v", err)
+	}
+	return &RiskAssessmentsResponse{
+		Assessments: assessments,
+		Pagination: PaginationInfo{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		},
+	}, nil
+}
+
+// GetAssessment returns one recorded decision
+func (s *RiskService) GetAssessment(ctx context.Context, assessmentID string) (*models.RiskAssessment, error) {
+	assessmentUUID, err := uuid.Parse(assessmentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid assessment ID", ErrRiskInvalid)
+	}
+	assessment, err := s.riskRepo.GetAssessment(ctx, assessmentUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRiskNotFound
+		}
+		return nil, fmt.Errorf("failed to load risk assessment: %v", err)
+	}
+	return assessment, nil
+}
+
+// ReviewAssessment settles a queued assessment. Rejecting a checkout cancels its order; rejecting
+// a refund request rejects the refund while it is still pending.
+func (s *RiskService) ReviewAssessment(ctx context.Context, adminID, assessmentID string, approve bool, req *RiskReviewRequest) (*models.RiskAssessment, error) {
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid admin ID", ErrRiskInvalid)
+	}
+	assessment, err := s.GetAssessment(ctx, assessmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assessment.ReviewStatus != RiskReviewPending {
+		return nil, ErrRiskReviewClosed
+	}
+
+	note := strings.TrimSpace(req.Note)
+	if !approve {
+		if err := s.reject(ctx, assessment, note); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	assessment.ReviewStatus = RiskReviewApproved
+	if !approve {
+		assessment.ReviewStatus = RiskReviewRejected
+	}
+	assessment.ReviewedBy = &adminUUID
+	assessment.ReviewedAt = &now
+	assessment.ReviewNote = note
+	if err := s.riskRepo.UpdateAssessment(ctx, assessment); err != nil {
+		return nil, fmt.Errorf("failed to save risk assessment: %v", err)
+	}
+	return assessment, nil
+}
+
+func (s *RiskService) reject(ctx context.Context, assessment *models.RiskAssessment, note string) error {
+	if assessment.RefundID != nil {
+		refund, err := s.refundRepo.GetByID(ctx, *assessment.RefundID)
+		if err != nil {
+			return fmt.Errorf("failed to load refund: %v", err)
+		}
+		if refund.Status != "pending" {
+			return nil
+		}
+		reason := "Declined by fraud review"
+		if note != "" {
+			reason += ": " + note
+		}
+		refund.Status = "rejected"
+		refund.AdminComment = &reason
+		if err := s.refundRepo.Update(ctx, refund); err != nil {
+			return fmt.Errorf("failed to reject refund: %v", err)
+		}
+		return nil
+	}
+
+	if assessment.OrderID != nil && s.orders != nil {
+		if err := s.orders.CancelOrder(ctx, *assessment.OrderID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func defaultRiskRules() []RiskRuleStatus {
+	rules := make([]RiskRuleStatus, 0, len(riskRuleDefaults))
+	for key, defaults := range riskRuleDefaults {
+		rules = append(rules, riskRuleStatus(key, defaults))
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Key < rules[j].Key })
+	return rules
+}
+
+func riskRuleStatus(key string, defaults riskRuleDefault) RiskRuleStatus {
+	return RiskRuleStatus{
+		Key:         key,
+		Description: defaults.description,
+		Event:       defaults.event,
+		Enabled:     true,
+		Action:      defaults.action,
+		Threshold:   defaults.threshold,
+		WindowHours: defaults.windowHours,
+		BlockHours:  defaults.blockHours,
+	}
+}
+
+func applyRiskRule(status *RiskRuleStatus, rule *models.RiskRule) {
+	status.Enabled = rule.Enabled
+	status.Action = rule.Action
+	status.Threshold = rule.Threshold
+	status.WindowHours = rule.WindowHours
+	status.BlockHours = rule.BlockHours
+	status.Customized = true
+	status.UpdatedBy = rule.UpdatedBy
+	updatedAt := rule.UpdatedAt
+	status.UpdatedAt = &updatedAt
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/cache"
+	"golang-food-backend/pkg/geo"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Average rider speed used for delivery ETA estimates
+const averageDeliverySpeedKmph = 20.0
+
+type SearchService struct {
+	boundaryRepo repositories.DeliveryBoundaryRepository
+	addressRepo  repositories.AddressRepository
+	productRepo  repositories.ProductRepository
+	cache        *cache.RedisCache
+}
+
+func NewSearchService(
+	boundaryRepo repositories.DeliveryBoundaryRepository,
+	addressRepo repositories.AddressRepository,
+	productRepo repositories.ProductRepository,
+	cache *cache.RedisCache,
+) *SearchService {
+	return &SearchService{
+		boundaryRepo: boundaryRepo,
+		addressRepo:  addressRepo,
+		productRepo:  productRepo,
+		cache:        cache,
+	}
+}
+
+type PlatformSearchRequest struct {
+	Query string  `form:"q" binding:"required"`
+	Lat   float64 `form:"lat" binding:"required"`
+	Lng   float64 `form:"lng" binding:"required"`
+	Limit int     `form:"limit"`
+
+	Dietary models.DietaryFilter `form:"-"`
+}
+
+type RestaurantSearchResult struct {
+	Restaurant        models.Restaurant `json:"restaurant"`
+	DistanceKm        float64           `json:"distance_km"`
+	DeliveryFee       models.Money      `json:"delivery_fee"`
+	MinOrderValue     models.Money      `json:"min_order_value"`
+	EstimatedMinutes  int               `json:"estimated_delivery_minutes"`
+	MatchedRestaurant bool              `json:"matched_restaurant"`
+	Products          []models.Product  `json:"products"`
+}
+
+type PlatformSearchResponse struct {
+	Query   string                   `json:"query"`
+	Results []RestaurantSearchResult `json:"results"`
+	Total   int                      `json:"total"`
+}
+
+// ServiceableRestaurant is a restaurant that delivers to a given customer location
+type ServiceableRestaurant struct {
+	Restaurant    models.Restaurant
+	DistanceKm    float64
+	DeliveryFee   models.Money
+	MinOrderValue models.Money
+}
+
+// Search finds products and restaurants across every restaurant that delivers to the customer
+func (s *SearchService) Search(ctx context.Context, req *PlatformSearchRequest) (*PlatformSearchResponse, error) {
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return nil, errors.New("search query is required")
+	}
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 50
+	}
+
+	cacheKey := fmt.Sprintf("search:%s:%.3f:%.3f:%s:%d", strings.ToLower(query), req.Lat, req.Lng, req.Dietary.Key(), req.Limit)
+	var cachedResponse PlatformSearchResponse
+	if err := s.cache.Get(ctx, cacheKey, &cachedResponse); err == nil {
+		return &cachedResponse, nil
+	}
+
+	serviceable, err := s.GetServiceableRestaurants(ctx, geo.Point{Lat: req.Lat, Lng: req.Lng})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PlatformSearchResponse{Query: query, Results: []RestaurantSearchResult{}}
+	if len(serviceable) == 0 {
+		return response, nil
+	}
+
+	restaurantIDs := make([]string, 0, len(serviceable))
+	for id := range serviceable {
+		restaurantIDs = append(restaurantIDs, id)
+	}
+
+	products, err := s.productRepo.SearchAcrossRestaurants(ctx, query, restaurantIDs, req.Dietary, req.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search products: %v", err)
+	}
+
+	productsByRestaurant := make(map[string][]models.Product)
+	for _, product := range products {
+		productsByRestaurant[product.RestaurantID] = append(productsByRestaurant[product.RestaurantID], product)
+	}
+
+	lowerQuery := strings.ToLower(query)
+	for id, entry := range serviceable {
+		matched := restaurantMatches(&entry.Restaurant, lowerQuery)
+		if !matched && len(productsByRestaurant[id]) == 0 {
+			continue
+		}
+
+		response.Results = append(response.Results, RestaurantSearchResult{
+			Restaurant:        entry.Restaurant,
+			DistanceKm:        math.Round(entry.DistanceKm*100) / 100,
+			DeliveryFee:       entry.DeliveryFee,
+			MinOrderValue:     entry.MinOrderValue,
+			EstimatedMinutes:  estimateDeliveryMinutes(&entry.Restaurant, entry.DistanceKm),
+			MatchedRestaurant: matched,
+			Products:          productsByRestaurant[id],
+		})
+	}
+
+	// Nearest restaurants first
+	sort.Slice(response.Results, func(i, j int) bool {
+		return response.Results[i].DistanceKm < response.Results[j].DistanceKm
+	})
+	response.Total = len(response.Results)
+
+	s.cache.Set(ctx, cacheKey, response, 2*time.Minute)
+
+	return response, nil
+}
+
+// GetServiceableRestaurants returns active restaurants whose delivery boundary covers the point,
+// keyed by restaurant ID. When several boundaries match, the cheapest delivery fee wins.
+func (s *SearchService) GetServiceableRestaurants(ctx context.Context, point geo.Point) (map[string]ServiceableRestaurant, error) {
+	boundaries, err := s.boundaryRepo.GetAllActive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery boundaries: %v", err)
+	}
+
+	locations := make(map[string]*geo.Point)
+	serviceable := make(map[string]ServiceableRestaurant)
+
+	for _, boundary := range boundaries {
+		restaurant := boundary.Restaurant
+		restaurantID := restaurant.ID.String()
+
+		location, cached := locations[restaurantID]
+		if !cached {
+			location = s.restaurantLocation(ctx, &restaurant)
+			locations[restaurantID] = location
+		}
+
+		distance := 0.0
+		if location != nil {
+			distance = geo.DistanceKm(*location, point)
+		}
+
+		if !boundaryCovers(&boundary, point, location, distance) {
+			continue
+		}
+
+		if existing, ok := serviceable[restaurantID]; ok && existing.DeliveryFee <= boundary.DeliveryFee {
+			continue
+		}
+
+		serviceable[restaurantID] = ServiceableRestaurant{
+			Restaurant:    restaurant,
+			DistanceKm:    distance,
+			DeliveryFee:   boundary.DeliveryFee,
+			MinOrderValue: boundary.MinOrderValue,
+		}
+	}
+
+	return serviceable, nil
+}
+
+// restaurantLocation resolves the restaurant's pickup address coordinates
+func (s *SearchService) restaurantLocation(ctx context.Context, restaurant *models.Restaurant) *geo.Point {
+	if restaurant.PickupLocationID == nil {
+		return nil
+	}
+
+	address, err := s.addressRepo.GetByID(ctx, *restaurant.PickupLocationID)
+	if err != nil || (address.Latitude == 0 && address.Longitude == 0) {
+		return nil
+	}
+
+	return &geo.Point{Lat: address.Latitude, Lng: address.Longitude}
+}
+
+// boundaryCovers checks the polygon when one is configured, otherwise the delivery radius
+func boundaryCovers(boundary *models.RestaurantDeliveryLocationBoundary, point geo.Point, location *geo.Point, distance float64) bool {
+	if boundary.GeoPolygon != nil {
+		if polygon := geo.PolygonFromGeoJSON(boundary.GeoPolygon); len(polygon) > 0 {
+			return geo.InPolygon(point, polygon)
+		}
+	}
+
+	if location == nil || boundary.DeliveryRadiusKm <= 0 {
+		return false
+	}
+
+	return distance <= boundary.DeliveryRadiusKm
+}
+
+// boundaryCheckable reports whether boundaryCovers can tell if a point is inside the boundary
+func boundaryCheckable(boundary *models.RestaurantDeliveryLocationBoundary, location *geo.Point) bool {
+	if boundary.GeoPolygon != nil && len(geo.PolygonFromGeoJSON(boundary.GeoPolygon)) > 0 {
+		return true
+	}
+	return location != nil && boundary.DeliveryRadiusKm > 0
+}
+
+func restaurantMatches(restaurant *models.Restaurant, lowerQuery string) bool {
+	if strings.Contains(strings.ToLower(restaurant.Name), lowerQuery) ||
+		strings.Contains(strings.ToLower(restaurant.Description), lowerQuery) {
+		return true
+	}
+
+	for _, cuisine := range restaurant.CuisineTypes {
+		if strings.Contains(strings.ToLower(cuisine), lowerQuery) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// estimateDeliveryMinutes adds travel time at average rider speed to the restaurant's prep time
+func estimateDeliveryMinutes(restaurant *models.Restaurant, distanceKm float64) int {
+	return restaurant.PreparationTime + estimateTravelMinutes(distanceKm)
+}
+
+// estimateTravelMinutes is the riding time for a distance at the average delivery speed
+func estimateTravelMinutes(distanceKm float64) int {
+	return int(math.Ceil(distanceKm / averageDeliverySpeedKmph * 60))
+}
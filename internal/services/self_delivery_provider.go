@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/geo"
+)
+
+// SelfDeliveryProvider delivers orders with the restaurant's own riders
+type SelfDeliveryProvider struct {
+	riderService *RiderService
+}
+
+func NewSelfDeliveryProvider(riderService *RiderService) *SelfDeliveryProvider {
+	return &SelfDeliveryProvider{riderService: riderService}
+}
+
+func (p *SelfDeliveryProvider) Name() string {
+	return "self"
+}
+
+// GetQuote estimates the trip from the order's pickup and drop points. Restaurants
+// bear their own rider costs, so there is no provider fee.
+func (p *SelfDeliveryProvider) GetQuote(ctx context.Context, order *models.Order, restaurant *models.Restaurant) (*DeliveryQuote, error) {
+	quote := &DeliveryQuote{
+		Provider:         p.Name(),
+		Currency:         "INR",
+		EstimatedMinutes: restaurant.PreparationTime,
+	}
+
+	pickupLat, pickupLatOK := order.PickupFullAddressWithLatLong["latitude"].(float64)
+	pickupLng, pickupLngOK := order.PickupFullAddressWithLatLong["longitude"].(float64)
+	dropLat, dropLatOK := order.DeliveryFullAddressWithLatLong["latitude"].(float64)
+	dropLng, dropLngOK := order.DeliveryFullAddressWithLatLong["longitude"].(float64)
+	if pickupLatOK && pickupLngOK && dropLatOK && dropLngOK {
+		distance := geo.DistanceKm(geo.Point{Lat: pickupLat, Lng: pickupLng}, geo.Point{Lat: dropLat, Lng: dropLng})
+		quote.EstimatedMinutes = estimateDeliveryMinutes(restaurant, distance)
+		quote.Distance = fmt.Sprintf("%.2f km", distance)
+	}
+
+	return quote, nil
+}
+
+func (p *SelfDeliveryProvider) CreateOrder(ctx context.Context, order *models.Order, restaurant *models.Restaurant) (*DeliveryBooking, error) {
+	assignment, err := p.riderService.AutoAssign(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeliveryBooking{
+		Provider:        p.Name(),
+		ProviderOrderID: assignment.ID.String(),
+	}, nil
+}
+
+func (p *SelfDeliveryProvider) Track(ctx context.Context, providerOrderID string) (*DeliveryTrackingInfo, error) {
+	assignment, err := p.riderService.GetAssignment(ctx, providerOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeliveryTrackingInfo{
+		Provider:        p.Name(),
+		ProviderOrderID: assignment.ID.String(),
+		Status:          assignment.Status,
+		PartnerName:     assignment.Rider.Name,
+		PartnerPhone:    assignment.Rider.Phone,
+		VehicleNumber:   assignment.Rider.VehicleNumber,
+		Latitude:        assignment.Rider.LastLatitude,
+		Longitude:       assignment.Rider.LastLongitude,
+	}, nil
+}
+
+func (p *SelfDeliveryProvider) Cancel(ctx context.Context, providerOrderID string) error {
+	return p.riderService.CancelAssignment(ctx, providerOrderID)
+}
+
+// Riders report progress through the rider API, so there are no webhooks
+func (p *SelfDeliveryProvider) ParseWebhook(body []byte) (*DeliveryWebhookEvent, error) {
+	return nil, errors.New("self delivery does not accept webhooks")
+}
+
+func (p *SelfDeliveryProvider) HandleWebhook(ctx context.Context, event *DeliveryWebhookEvent) error {
+	return errors.New("self delivery does not accept webhooks")
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/auth"
+	"golang-food-backend/pkg/cache"
+	"golang-food-backend/pkg/clientinfo"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSessionRevoked    = apperr.Unauthorized("session_revoked", "session has been revoked, please log in again")
+	ErrRefreshTokenReuse = apperr.Unauthorized("refresh_token_reused", "refresh token has already been used, session revoked")
+)
+
+// SessionService issues refresh tokens per login session, rotates them on every refresh and
+// revokes sessions on logout or when a rotated-out refresh token is presented again.
+// Each session is one signed-in device, and sign-ins and sign-outs are written to the audit log.
+type SessionService struct {
+	sessionRepo repositories.AuthSessionRepository
+	auditRepo   repositories.AuditLogRepository
+	staffRepo   repositories.StaffRepository
+	jwtManager  *auth.JWTManager
+	cache       *cache.RedisCache
+	blocklist   *BlocklistService
+}
+
+func NewSessionService(
+	sessionRepo repositories.AuthSessionRepository,
+	auditRepo repositories.AuditLogRepository,
+	staffRepo repositories.StaffRepository,
+	jwtManager *auth.JWTManager,
+	cache *cache.RedisCache,
+) *SessionService {
+	return &SessionService{
+		sessionRepo: sessionRepo,
+		auditRepo:   auditRepo,
+		staffRepo:   staffRepo,
+		jwtManager:  jwtManager,
+		cache:       cache,
+	}
+}
+
+// DeviceInfo identifies the device a login comes from. IPAddress and UserAgent are filled
+// in from the request rather than the body.
+type DeviceInfo struct {
+	DeviceID  string `json:"device_id"`
+	Platform  string `json:"platform"` // android, ios, web
+	PushToken string `json:"push_token"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type DeviceResponse struct {
+	ID           uuid.UUID `json:"id"` // session ID, used to sign the device out
+	DeviceID     string    `json:"device_id"`
+	Platform     string    `json:"platform"`
+	IPAddress    string    `json:"ip_address"`
+	UserAgent    string    `json:"user_agent"`
+	SignedInAt   time.Time `json:"signed_in_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+	Current      bool      `json:"current"`
+}
+
+// SetBlocklist sets the blocklist checked before a session is started or refreshed
+func (s *SessionService) SetBlocklist(blocklist *BlocklistService) {
+	s.blocklist = blocklist
+}
+
+func revokedSessionKey(sessionID string) string {
+	return "revoked_session:" + sessionID
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// StartSession opens a login session for the user on a device and issues its first token pair.
+// An earlier session on the same device is signed out. Method records how the user authenticated.
+func (s *SessionService) StartSession(ctx context.Context, user *models.User, device DeviceInfo, method string) (*auth.TokenPair, error) {
+	if err := s.checkBlocklist(ctx, user, device.DeviceID); err != nil {
+		return nil, err
+	}
+	if device.DeviceID != "" {
+		replaced, err := s.sessionRepo.RevokeByDevice(ctx, user.ID, device.DeviceID, "replaced")
+		if err != nil {
+			return nil, fmt.Errorf("failed to replace device session: %v", err)
+		}
+		for _, sessionID := range replaced {
+			s.markRevoked(ctx, sessionID)
+		}
+	}
+
+	session := &models.AuthSession{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		DeviceID:  device.DeviceID,
+		Platform:  device.Platform,
+		PushToken: device.PushToken,
+		IPAddress: device.IPAddress,
+		UserAgent: device.UserAgent,
+	}
+
+	tokenPair, err := s.generateTokenPair(ctx, user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	session.RefreshTokenHash = hashRefreshToken(tokenPair.RefreshToken)
+	session.ExpiresAt = time.Now().Add(s.jwtManager.RefreshTokenTTL())
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %v", err)
+	}
+
+	s.audit(ctx, "login", session, models.JSONB{"method": method})
+	return tokenPair, nil
+}
+
+// ValidateRefreshToken checks a refresh token against its session. Presenting a token that has
+// already been rotated out revokes the whole session, since it means the token was copied.
+func (s *SessionService) ValidateRefreshToken(ctx context.Context, refreshToken string) (*auth.Claims, *models.AuthSession, error) {
+	claims, err := s.jwtManager.ValidateToken(refreshToken)
+	if err != nil {
+		return nil, nil, errors.New("invalid refresh token")
+	}
+	if claims.TokenType != auth.RefreshToken {
+		return nil, nil, errors.New("invalid token type: expected refresh token")
+	}
+	// Admin tokens are refreshed by the admin service
+	if claims.Scope == auth.AdminScope {
+		return nil, nil, errors.New("invalid token scope: admin tokens cannot be refreshed here")
+	}
+
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil, nil, ErrSessionRevoked
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil || session.UserID.String() != claims.UserID {
+		return nil, nil, errors.New("refresh token not found or invalid")
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, nil, ErrSessionRevoked
+	}
+
+	if session.RefreshTokenHash != hashRefreshToken(refreshToken) {
+		s.revokeReused(ctx, session)
+		return nil, nil, ErrRefreshTokenReuse
+	}
+
+	return claims, session, nil
+}
+
+// RotateSession issues a new token pair for a validated session and retires the presented refresh token
+func (s *SessionService) RotateSession(ctx context.Context, session *models.AuthSession, refreshToken string, user *models.User) (*auth.TokenPair, error) {
+	if err := s.checkBlocklist(ctx, user, session.DeviceID); err != nil {
+		return nil, err
+	}
+	tokenPair, err := s.generateTokenPair(ctx, user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rotated, err := s.sessionRepo.Rotate(ctx, session.ID, hashRefreshToken(refreshToken), hashRefreshToken(tokenPair.RefreshToken), time.Now().Add(s.jwtManager.RefreshTokenTTL()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %v", err)
+	}
+	// Another request rotated the same token first
+	if !rotated {
+		s.revokeReused(ctx, session)
+		return nil, ErrRefreshTokenReuse
+	}
+
+	return tokenPair, nil
+}
+
+// RevokeSession signs out one login session of the user. Reason is logout when the device signs
+// itself out and device_signed_out when it is removed from another device.
+func (s *SessionService) RevokeSession(ctx context.Context, userID, sessionID, reason string) error {
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return errors.New("invalid session ID")
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, id)
+	if err != nil || session.UserID.String() != userID || session.RevokedAt != nil {
+		return errors.New("session not found")
+	}
+
+	if err := s.sessionRepo.Revoke(ctx, id, reason); err != nil {
+		return fmt.Errorf("failed to revoke session: %v", err)
+	}
+
+	s.markRevoked(ctx, id)
+	s.audit(ctx, reason, session, nil)
+	return nil
+}
+
+// RevokeAllSessions signs the user out on every device
+func (s *SessionService) RevokeAllSessions(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	sessionIDs, err := s.sessionRepo.RevokeAllByUser(ctx, id, "logout_all")
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %v", err)
+	}
+
+	for _, sessionID := range sessionIDs {
+		s.markRevoked(ctx, sessionID)
+	}
+
+	entry := &models.AuditLog{
+		EntityType:  "user",
+		EntityID:    userID,
+		Action:      "logout_all",
+		PerformedBy: &id,
+		Metadata:    models.JSONB{"sessions_revoked": len(sessionIDs)},
+	}
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write audit entry for user %s: %v", userID, err)
+	}
+	return nil
+}
+
+// SignOutUser revokes every session of a user on someone else's behalf, such as when a staff
+// member is removed. The caller records its own audit entry.
+func (s *SessionService) SignOutUser(ctx context.Context, userID uuid.UUID, reason string) error {
+	sessionIDs, err := s.sessionRepo.RevokeAllByUser(ctx, userID, reason)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %v", err)
+	}
+	for _, sessionID := range sessionIDs {
+		s.markRevoked(ctx, sessionID)
+	}
+	return nil
+}
+
+// SignOutOtherSessions revokes every session of the user but the one given, such as after the
+// user changed their password from it
+func (s *SessionService) SignOutOtherSessions(ctx context.Context, userID uuid.UUID, keepSessionID, reason string) error {
+	sessions, err := s.sessionRepo.GetActiveByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load sessions: %v", err)
+	}
+	for _, session := range sessions {
+		if session.ID.String() == keepSessionID {
+			continue
+		}
+		if err := s.sessionRepo.Revoke(ctx, session.ID, reason); err != nil {
+			return fmt.Errorf("failed to revoke session: %v", err)
+		}
+		s.markRevoked(ctx, session.ID)
+	}
+	return nil
+}
+
+// ListDevices returns the devices the user is signed in on, marking the one making the request
+func (s *SessionService) ListDevices(ctx context.Context, userID, currentSessionID string) ([]DeviceResponse, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	sessions, err := s.sessionRepo.GetActiveByUser(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get devices: %v", err)
+	}
+
+	devices := make([]DeviceResponse, 0, len(sessions))
+	for _, session := range sessions {
+		lastActive := session.CreatedAt
+		if session.LastRefreshedAt != nil {
+			lastActive = *session.LastRefreshedAt
+		}
+		devices = append(devices, DeviceResponse{
+			ID:           session.ID,
+			DeviceID:     session.DeviceID,
+			Platform:     session.Platform,
+			IPAddress:    session.IPAddress,
+			UserAgent:    session.UserAgent,
+			SignedInAt:   session.CreatedAt,
+			LastActiveAt: lastActive,
+			Current:      session.ID.String() == currentSessionID,
+		})
+	}
+
+	return devices, nil
+}
+
+// IsSessionRevoked reports whether access tokens of the session must be rejected before they expire
+func (s *SessionService) IsSessionRevoked(ctx context.Context, sessionID string) bool {
+	revoked, err := s.cache.Exists(ctx, revokedSessionKey(sessionID))
+	return err == nil && revoked
+}
+
+// generateTokenPair issues tokens for the user. Restaurant staff get the permissions of their
+// active staff membership, which are re-read on every refresh.
+func (s *SessionService) generateTokenPair(ctx context.Context, user *models.User, sessionID uuid.UUID) (*auth.TokenPair, error) {
+	restaurantID := ""
+	if user.RestaurantID != nil {
+		restaurantID = user.RestaurantID.String()
+	}
+
+	var permissions []string
+	if user.Role == "restaurant_staff" {
+		if staff, err := s.staffRepo.GetActiveByUser(ctx, user.ID); err == nil {
+			restaurantID = staff.RestaurantID.String()
+			permissions = staff.Permissions
+		} else {
+			restaurantID = ""
+		}
+	}
+
+	return s.jwtManager.GenerateTokenPair(user.ID.String(), restaurantID, user.Role, user.Email, sessionID.String(), permissions)
+}
+
+// checkBlocklist refuses blocked users, phone numbers and devices; the device is the one the
+// session is for, or else the one the request comes from
+func (s *SessionService) checkBlocklist(ctx context.Context, user *models.User, deviceID string) error {
+	if s.blocklist == nil {
+		return nil
+	}
+	if deviceID == "" {
+		deviceID = clientinfo.FromContext(ctx).DeviceID
+	}
+	return s.blocklist.Check(ctx, BlockSubject{UserID: user.ID.String(), Phone: user.Phone, DeviceID: deviceID})
+}
+
+func (s *SessionService) revokeReused(ctx context.Context, session *models.AuthSession) {
+	log.Printf("Refresh token reuse detected for user %s, revoking session %s", session.UserID, session.ID)
+	if err := s.sessionRepo.Revoke(ctx, session.ID, "refresh_token_reuse"); err != nil {
+		log.Printf("Failed to revoke session %s: %v", session.ID, err)
+	}
+	s.markRevoked(ctx, session.ID)
+	s.audit(ctx, "refresh_token_reuse", session, nil)
+}
+
+// audit records a session event against the user, with the device it happened on
+func (s *SessionService) audit(ctx context.Context, action string, session *models.AuthSession, extra models.JSONB) {
+	metadata := models.JSONB{
+		"session_id": session.ID.String(),
+		"device_id":  session.DeviceID,
+		"platform":   session.Platform,
+		"ip_address": session.IPAddress,
+		"user_agent": session.UserAgent,
+	}
+	for key, value := range extra {
+		metadata[key] = value
+	}
+
+	userID := session.UserID
+	entry := &models.AuditLog{
+		EntityType:  "user",
+		EntityID:    userID.String(),
+		Action:      action,
+		PerformedBy: &userID,
+		Metadata:    metadata,
+	}
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write audit entry for user %s: %v", userID, err)
+	}
+}
+
+// markRevoked remembers the revocation for as long as an access token issued to the session can live
+func (s *SessionService) markRevoked(ctx context.Context, sessionID uuid.UUID) {
+	s.cache.Set(ctx, revokedSessionKey(sessionID.String()), true, s.jwtManager.AccessTokenTTL())
+}
@@ -2,10 +2,12 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"golang-food-backend/internal/models"
 	"golang-food-backend/internal/repositories"
 	"golang-food-backend/pkg/cache"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +18,8 @@ type ShopTimeService struct {
 	restaurantRepo       repositories.RestaurantRepository
 	timeRangeProductRepo repositories.TimeRangeProductRepository
 	productRepo          repositories.ProductRepository
+	specialHoursRepo     repositories.RestaurantSpecialHoursRepository
+	pauseRepo            repositories.RestaurantPauseRepository
 	cache                *cache.RedisCache
 }
 
@@ -23,12 +27,16 @@ func NewShopTimeService(
 	restaurantRepo repositories.RestaurantRepository,
 	timeRangeProductRepo repositories.TimeRangeProductRepository,
 	productRepo repositories.ProductRepository,
+	specialHoursRepo repositories.RestaurantSpecialHoursRepository,
+	pauseRepo repositories.RestaurantPauseRepository,
 	cache *cache.RedisCache,
 ) *ShopTimeService {
 	return &ShopTimeService{
 		restaurantRepo:       restaurantRepo,
 		timeRangeProductRepo: timeRangeProductRepo,
 		productRepo:          productRepo,
+		specialHoursRepo:     specialHoursRepo,
+		pauseRepo:            pauseRepo,
 		cache:                cache,
 	}
 }
@@ -108,6 +116,7 @@ func (s *ShopTimeService) UpdateShopTiming(ctx context.Context, restaurantID str
 
 	// Clear cache
 	s.cache.DeleteWithPrefix(ctx, "restaurant", restaurantID)
+	s.cache.InvalidateTags(ctx, restaurantTimingTag(restaurantID))
 
 	return restaurant, nil
 }
@@ -132,7 +141,7 @@ func (s *ShopTimeService) GetShopTiming(ctx context.Context, restaurantID string
 	}
 
 	// Cache for 5 minutes
-	s.cache.Set(ctx, cacheKey, restaurantPtr, 5*time.Minute)
+	s.cache.SetWithTags(ctx, cacheKey, restaurantPtr, 5*time.Minute, restaurantTimingTag(restaurantID))
 
 	return restaurantPtr, nil
 }
@@ -158,6 +167,7 @@ func (s *ShopTimeService) UpdateShopStatus(ctx context.Context, restaurantID str
 
 	// Clear cache
 	s.cache.DeleteWithPrefix(ctx, "restaurant", restaurantID)
+	s.cache.InvalidateTags(ctx, restaurantTimingTag(restaurantID))
 
 	return restaurant, nil
 }
@@ -192,6 +202,7 @@ func (s *ShopTimeService) CreateTimeGroup(ctx context.Context, req *CreateTimeGr
 		return nil, fmt.Errorf("failed to create time group: %v", err)
 	}
 
+	s.cache.InvalidateTags(ctx, restaurantTimingTag(group.RestaurantID))
 	return group, nil
 }
 
@@ -228,6 +239,7 @@ func (s *ShopTimeService) UpdateTimeGroup(ctx context.Context, groupID string, r
 		return nil, fmt.Errorf("failed to update time group: %v", err)
 	}
 
+	s.cache.InvalidateTags(ctx, restaurantTimingTag(group.RestaurantID))
 	return group, nil
 }
 
@@ -309,7 +321,7 @@ func (s *ShopTimeService) GetProductsByTime(ctx context.Context, restaurantID st
 		return nil, fmt.Errorf("failed to get restaurant timing: %v", err)
 	}
 
-	isOpen := s.isRestaurantOpenAtTime(restaurant, currentTime)
+	isOpen := s.isRestaurantOpenAtTime(restaurant, s.restaurantSpecialHours(ctx, restaurant), currentTime)
 
 	return &TimeBasedProductResponse{
 		Products:         products,
@@ -319,10 +331,15 @@ func (s *ShopTimeService) GetProductsByTime(ctx context.Context, restaurantID st
 	}, nil
 }
 
-func (s *ShopTimeService) isRestaurantOpenAtTime(restaurant *models.Restaurant, checkTime time.Time) bool {
+// isRestaurantOpenAtTime follows the weekly opening hours, or the special hours on dates that
+// have them, when the restaurant opens and closes automatically
+func (s *ShopTimeService) isRestaurantOpenAtTime(restaurant *models.Restaurant, specialHours []models.RestaurantSpecialHours, checkTime time.Time) bool {
 	if !restaurant.AutoOpenClose {
 		return restaurant.IsOpen
 	}
+	if open, _, ok := specialHoursAt(specialHours, checkTime); ok {
+		return open
+	}
 
 	// Check opening hours
 	currentDay := checkTime.Weekday().String()
@@ -356,3 +373,188 @@ func (s *ShopTimeService) isRestaurantOpenAtTime(restaurant *models.Restaurant,
 
 	return currentTimeStr >= openTime && currentTimeStr <= closeTime
 }
+
+// Scheduled order (order-ahead) slots
+const (
+	scheduleSlotInterval = 30 * time.Minute
+	scheduleMinLeadTime  = 30 * time.Minute
+	scheduleMaxDaysAhead = 7
+)
+
+type ScheduleSlot struct {
+	StartTime time.Time `json:"start_time"`
+	Label     string    `json:"label"`
+}
+
+type ScheduleSlotsResponse struct {
+	RestaurantID string         `json:"restaurant_id"`
+	Date         string         `json:"date"`
+	Timezone     string         `json:"timezone"`
+	Slots        []ScheduleSlot `json:"slots"`
+}
+
+// GetScheduleSlots returns the order-ahead slots for a restaurant on the given date (YYYY-MM-DD)
+func (s *ShopTimeService) GetScheduleSlots(ctx context.Context, restaurantID string, date string) (*ScheduleSlotsResponse, error) {
+	restaurant, err := s.GetShopTiming(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := s.restaurantLocation(restaurant)
+	now := time.Now().In(loc)
+
+	day := now
+	if date != "" {
+		day, err = time.ParseInLocation("2006-01-02", date, loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date: %v", err)
+		}
+	}
+
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	lastDay := time.Date(now.Year(), now.Month(), now.Day()+scheduleMaxDaysAhead, 0, 0, 0, 0, loc)
+	if dayStart.After(lastDay) {
+		return nil, fmt.Errorf("orders can only be scheduled up to %d days ahead", scheduleMaxDaysAhead)
+	}
+
+	earliest := s.earliestScheduleTime(restaurant, now)
+	specialHours := s.restaurantSpecialHours(ctx, restaurant)
+	slots := []ScheduleSlot{}
+	for slot := dayStart; slot.Before(dayStart.AddDate(0, 0, 1)); slot = slot.Add(scheduleSlotInterval) {
+		if slot.Before(earliest) || !s.isWithinOpeningHours(restaurant, specialHours, slot) {
+			continue
+		}
+		slots = append(slots, ScheduleSlot{
+			StartTime: slot,
+			Label:     slot.Format("15:04"),
+		})
+	}
+
+	return &ScheduleSlotsResponse{
+		RestaurantID: restaurantID,
+		Date:         dayStart.Format("2006-01-02"),
+		Timezone:     loc.String(),
+		Slots:        slots,
+	}, nil
+}
+
+// ValidateScheduleSlot checks that a restaurant can fulfil the given products at the scheduled time
+func (s *ShopTimeService) ValidateScheduleSlot(ctx context.Context, restaurantID string, productIDs []string, scheduledFor time.Time) error {
+	restaurant, err := s.GetShopTiming(ctx, restaurantID)
+	if err != nil {
+		return err
+	}
+
+	loc := s.restaurantLocation(restaurant)
+	now := time.Now().In(loc)
+	slot := scheduledFor.In(loc)
+
+	if slot.Before(s.earliestScheduleTime(restaurant, now)) {
+		return errors.New("scheduled time is too soon")
+	}
+	if slot.After(now.AddDate(0, 0, scheduleMaxDaysAhead)) {
+		return fmt.Errorf("orders can only be scheduled up to %d days ahead", scheduleMaxDaysAhead)
+	}
+	if !s.isWithinOpeningHours(restaurant, s.restaurantSpecialHours(ctx, restaurant), slot) {
+		return errors.New("restaurant is closed at the scheduled time")
+	}
+
+	// Products that belong to time groups are only available inside those groups
+	timeGroups, err := s.timeRangeProductRepo.GetTimeGroupsByRestaurant(ctx, restaurantID)
+	if err != nil {
+		return fmt.Errorf("failed to get time groups: %v", err)
+	}
+
+	slotTime := slot.Format("15:04")
+	restricted := make(map[string]bool)
+	available := make(map[string]bool)
+	for _, group := range timeGroups {
+		items, err := s.timeRangeProductRepo.GetProductsByTimeGroup(ctx, group.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get time group products: %v", err)
+		}
+		inRange := isTimeInRange(slotTime, group.StartTime, group.EndTime)
+		for _, item := range items {
+			productID := item.ProductID.Hex()
+			restricted[productID] = true
+			if inRange {
+				available[productID] = true
+			}
+		}
+	}
+
+	for _, productID := range productIDs {
+		if restricted[productID] && !available[productID] {
+			return fmt.Errorf("product %s is not available at the scheduled time", productID)
+		}
+	}
+
+	return nil
+}
+
+// restaurantTimingTag groups cached data that depends on a restaurant's hours, open status or time groups
+func restaurantTimingTag(restaurantID string) string {
+	return "restaurant:" + restaurantID + ":timing"
+}
+
+func (s *ShopTimeService) restaurantLocation(restaurant *models.Restaurant) *time.Location {
+	if restaurant.TimeZone != "" {
+		if loc, err := time.LoadLocation(restaurant.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+func (s *ShopTimeService) earliestScheduleTime(restaurant *models.Restaurant, now time.Time) time.Time {
+	leadTime := time.Duration(restaurant.PreparationTime) * time.Minute
+	if leadTime < scheduleMinLeadTime {
+		leadTime = scheduleMinLeadTime
+	}
+	return now.Add(leadTime)
+}
+
+// isWithinOpeningHours checks the configured opening hours, or the special hours on dates that
+// have them, regardless of the live open/closed flag
+func (s *ShopTimeService) isWithinOpeningHours(restaurant *models.Restaurant, specialHours []models.RestaurantSpecialHours, checkTime time.Time) bool {
+	if open, _, ok := specialHoursAt(specialHours, checkTime); ok {
+		return open
+	}
+	if restaurant.OpeningHours == nil {
+		return false
+	}
+
+	dayTiming, exists := restaurant.OpeningHours[checkTime.Weekday().String()]
+	if !exists {
+		dayTiming, exists = restaurant.OpeningHours[strings.ToLower(checkTime.Weekday().String())]
+	}
+	if !exists {
+		return false
+	}
+
+	dayTimingMap, ok := dayTiming.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	isOpen, _ := dayTimingMap["is_open"].(bool)
+	if !isOpen {
+		return false
+	}
+
+	openTime, _ := dayTimingMap["open_time"].(string)
+	closeTime, _ := dayTimingMap["close_time"].(string)
+	if openTime == "" || closeTime == "" {
+		return false
+	}
+
+	return isTimeInRange(checkTime.Format("15:04"), openTime, closeTime)
+}
+
+// isTimeInRange compares HH:MM strings, handling ranges that cross midnight
+func isTimeInRange(t, start, end string) bool {
+	if end < start {
+		return t >= start || t <= end
+	}
+	return t >= start && t <= end
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/sms"
+)
+
+// SMSDeliveryService stores outbound messages and their delivery reports for the SMS service
+type SMSDeliveryService struct {
+	repo repositories.SMSDeliveryRepository
+}
+
+func NewSMSDeliveryService(repo repositories.SMSDeliveryRepository) *SMSDeliveryService {
+	return &SMSDeliveryService{repo: repo}
+}
+
+func (s *SMSDeliveryService) RecordSent(ctx context.Context, result sms.SendResult) error {
+	return s.repo.Create(ctx, &models.SMSDelivery{
+		Provider:          result.Provider,
+		ProviderMessageID: result.MessageID,
+		Phone:             result.To,
+		Status:            sms.StatusSent,
+		SentAt:            result.SentAt,
+	})
+}
+
+func (s *SMSDeliveryService) RecordReport(ctx context.Context, report sms.DeliveryReport) error {
+	return s.repo.UpdateStatus(ctx, report.Provider, report.MessageID, report.Status, report.ErrorCode,
+		models.JSONB(report.RawResponse), report.ReportedAt)
+}
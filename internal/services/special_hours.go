@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/apperr"
+
+	"github.com/google/uuid"
+)
+
+const (
+	specialHoursDateLayout   = "2006-01-02"
+	specialHoursMaxDays      = 90 // longest range one entry may cover
+	specialHoursUpcomingDays = 30 // how far ahead customers see closures and changed hours
+	specialHoursCacheTTL     = 5 * time.Minute
+)
+
+var (
+	ErrSpecialHoursInvalid  = apperr.Validation("special_hours_invalid", "invalid special hours")
+	ErrSpecialHoursNotFound = apperr.NotFound("special_hours_not_found", "special hours not found")
+)
+
+// SpecialHoursRequest closes a restaurant or sets different hours on a range of dates
+type SpecialHoursRequest struct {
+	StartDate string `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate   string `json:"end_date"`                      // inclusive; defaults to start_date
+	IsClosed  bool   `json:"is_closed"`
+	OpenTime  string `json:"open_time"`  // HH:MM, required unless closed
+	CloseTime string `json:"close_time"` // HH:MM
+	Reason    string `json:"reason"`
+}
+
+// ShopStatusResponse tells customers whether a restaurant is open now and why, with the
+// closures and changed hours coming up
+type ShopStatusResponse struct {
+	RestaurantID string                          `json:"restaurant_id"`
+	IsOpen       bool                            `json:"is_open"`
+	Reason       string                          `json:"reason"`                  // manual, opening_hours, special_hours, holiday or paused
+	PausedUntil  *time.Time                      `json:"paused_until,omitempty"`  // busy mode; no new orders until then
+	SpecialHours *models.RestaurantSpecialHours  `json:"special_hours,omitempty"` // today's entry
+	Upcoming     []models.RestaurantSpecialHours `json:"upcoming"`                // entries from today on, within 30 days
+	Timezone     string                          `json:"timezone"`
+	CheckedAt    time.Time                       `json:"checked_at"`
+}
+
+func specialHoursCacheKey(restaurantID, from string) string {
+	return fmt.Sprintf("restaurant:%s:special_hours:%s", restaurantID, from)
+}
+
+// specialHoursOn returns the entry covering the date. Where entries overlap, the one covering
+// the fewest days wins, so a one-day closure inside a festival schedule applies.
+func specialHoursOn(entries []models.RestaurantSpecialHours, date string) *models.RestaurantSpecialHours {
+	var match *models.RestaurantSpecialHours
+	for i := range entries {
+		entry := &entries[i]
+		if entry.StartDate > date || entry.EndDate < date {
+			continue
+		}
+		if match == nil || specialHoursDays(entry) < specialHoursDays(match) {
+			match = entry
+		}
+	}
+	return match
+}
+
+func specialHoursDays(entry *models.RestaurantSpecialHours) int {
+	start, _ := time.Parse(specialHoursDateLayout, entry.StartDate)
+	end, _ := time.Parse(specialHoursDateLayout, entry.EndDate)
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// specialHoursAt reports whether special hours keep the restaurant open at t. ok is false when
+// no entry covers t's date, so the weekly opening hours apply.
+func specialHoursAt(entries []models.RestaurantSpecialHours, t time.Time) (open bool, entry *models.RestaurantSpecialHours, ok bool) {
+	entry = specialHoursOn(entries, t.Format(specialHoursDateLayout))
+	if entry == nil {
+		return false, nil, false
+	}
+	if entry.IsClosed {
+		return false, entry, true
+	}
+	return isTimeInRange(t.Format("15:04"), entry.OpenTime, entry.CloseTime), entry, true
+}
+
+// upcomingSpecialHours returns the restaurant's entries ending on or after the date
+func (s *ShopTimeService) upcomingSpecialHours(ctx context.Context, restaurantID uuid.UUID, from string) ([]models.RestaurantSpecialHours, error) {
+	cacheKey := specialHoursCacheKey(restaurantID.String(), from)
+	var entries []models.RestaurantSpecialHours
+	if err := s.cache.Get(ctx, cacheKey, &entries); err == nil {
+		return entries, nil
+	}
+
+	entries, err := s.specialHoursRepo.GetByRestaurant(ctx, restaurantID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get special hours: %v", err)
+	}
+	s.cache.SetWithTags(ctx, cacheKey, entries, specialHoursCacheTTL, restaurantTimingTag(restaurantID.String()))
+	return entries, nil
+}
+
+// restaurantSpecialHours returns the restaurant's entries from today on in its time zone. Open
+// checks treat a lookup failure as no special hours.
+func (s *ShopTimeService) restaurantSpecialHours(ctx context.Context, restaurant *models.Restaurant) []models.RestaurantSpecialHours {
+	today := time.Now().In(s.restaurantLocation(restaurant)).Format(specialHoursDateLayout)
+	entries, err := s.upcomingSpecialHours(ctx, restaurant.ID, today)
+	if err != nil {
+		return nil
+	}
+	return entries
+}
+
+// activePause returns the restaurant's running busy mode pause, or nil when it takes orders or
+// the lookup fails
+func (s *ShopTimeService) activePause(ctx context.Context, restaurantID uuid.UUID) *models.RestaurantPause {
+	pause, err := s.pauseRepo.GetActive(ctx, restaurantID, time.Now())
+	if err != nil {
+		return nil
+	}
+	return pause
+}
+
+// ListSpecialHours returns the restaurant's special hours ending on or after from (YYYY-MM-DD),
+// today by default
+func (s *ShopTimeService) ListSpecialHours(ctx context.Context, restaurantID, from string) ([]models.RestaurantSpecialHours, error) {
+	restaurant, err := s.GetShopTiming(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	if from == "" {
+		from = time.Now().In(s.restaurantLocation(restaurant)).Format(specialHoursDateLayout)
+	} else if _, err := time.Parse(specialHoursDateLayout, from); err != nil {
+		return nil, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrSpecialHoursInvalid)
+	}
+
+	entries, err := s.upcomingSpecialHours(ctx, restaurant.ID, from)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []models.RestaurantSpecialHours{}
+	}
+	return entries, nil
+}
+
+func (s *ShopTimeService) CreateSpecialHours(ctx context.Context, restaurantID string, req *SpecialHoursRequest) (*models.RestaurantSpecialHours, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrSpecialHoursInvalid)
+	}
+	if _, err := s.restaurantRepo.GetByID(ctx, restaurantUUID); err != nil {
+		return nil, fmt.Errorf("restaurant not found: %v", err)
+	}
+
+	entry := &models.RestaurantSpecialHours{RestaurantID: restaurantUUID}
+	if err := applySpecialHoursRequest(entry, req); err != nil {
+		return nil, err
+	}
+	if err := s.specialHoursRepo.Create(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to create special hours: %v", err)
+	}
+
+	s.cache.InvalidateTags(ctx, restaurantTimingTag(restaurantID))
+	return entry, nil
+}
+
+func (s *ShopTimeService) UpdateSpecialHours(ctx context.Context, restaurantID, entryID string, req *SpecialHoursRequest) (*models.RestaurantSpecialHours, error) {
+	entry, err := s.restaurantSpecialHoursEntry(ctx, restaurantID, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySpecialHoursRequest(entry, req); err != nil {
+		return nil, err
+	}
+	if err := s.specialHoursRepo.Update(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to update special hours: %v", err)
+	}
+
+	s.cache.InvalidateTags(ctx, restaurantTimingTag(restaurantID))
+	return entry, nil
+}
+
+func (s *ShopTimeService) DeleteSpecialHours(ctx context.Context, restaurantID, entryID string) error {
+	entry, err := s.restaurantSpecialHoursEntry(ctx, restaurantID, entryID)
+	if err != nil {
+		return err
+	}
+	if err := s.specialHoursRepo.Delete(ctx, entry.ID); err != nil {
+		return fmt.Errorf("failed to delete special hours: %v", err)
+	}
+
+	s.cache.InvalidateTags(ctx, restaurantTimingTag(restaurantID))
+	return nil
+}
+
+// restaurantSpecialHoursEntry loads an entry, which must belong to the restaurant
+func (s *ShopTimeService) restaurantSpecialHoursEntry(ctx context.Context, restaurantID, entryID string) (*models.RestaurantSpecialHours, error) {
+	id, err := uuid.Parse(entryID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid special hours ID", ErrSpecialHoursInvalid)
+	}
+	entry, err := s.specialHoursRepo.GetByID(ctx, id)
+	if err != nil || entry.RestaurantID.String() != restaurantID {
+		return nil, fmt.Errorf("%w: %s", ErrSpecialHoursNotFound, entryID)
+	}
+	return entry, nil
+}
+
+func applySpecialHoursRequest(entry *models.RestaurantSpecialHours, req *SpecialHoursRequest) error {
+	start, err := time.Parse(specialHoursDateLayout, req.StartDate)
+	if err != nil {
+		return fmt.Errorf("%w: start_date must be YYYY-MM-DD", ErrSpecialHoursInvalid)
+	}
+	end := start
+	if req.EndDate != "" {
+		if end, err = time.Parse(specialHoursDateLayout, req.EndDate); err != nil {
+			return fmt.Errorf("%w: end_date must be YYYY-MM-DD", ErrSpecialHoursInvalid)
+		}
+	}
+	if end.Before(start) {
+		return fmt.Errorf("%w: end_date is before start_date", ErrSpecialHoursInvalid)
+	}
+	if end.Sub(start) >= specialHoursMaxDays*24*time.Hour {
+		return fmt.Errorf("%w: special hours can cover at most %d days", ErrSpecialHoursInvalid, specialHoursMaxDays)
+	}
+
+	openTime, closeTime := "", ""
+	if !req.IsClosed {
+		if !validClockTime(req.OpenTime) || !validClockTime(req.CloseTime) {
+			return fmt.Errorf("%w: open_time and close_time must be HH:MM unless is_closed is set", ErrSpecialHoursInvalid)
+		}
+		openTime, closeTime = req.OpenTime, req.CloseTime
+	}
+
+	entry.StartDate = start.Format(specialHoursDateLayout)
+	entry.EndDate = end.Format(specialHoursDateLayout)
+	entry.IsClosed = req.IsClosed
+	entry.OpenTime = openTime
+	entry.CloseTime = closeTime
+	entry.Reason = req.Reason
+	return nil
+}
+
+func validClockTime(value string) bool {
+	_, err := time.Parse("15:04", value)
+	return err == nil && len(value) == 5
+}
+
+// GetShopStatus returns whether the restaurant is open now, taking holidays and special hours
+// into account, with the special hours of the next 30 days
+func (s *ShopTimeService) GetShopStatus(ctx context.Context, restaurantID string) (*ShopStatusResponse, error) {
+	restaurant, err := s.GetShopTiming(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := s.restaurantLocation(restaurant)
+	now := time.Now().In(loc)
+	entries, err := s.upcomingSpecialHours(ctx, restaurant.ID, now.Format(specialHoursDateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ShopStatusResponse{
+		RestaurantID: restaurantID,
+		IsOpen:       s.isRestaurantOpenAtTime(restaurant, entries, now),
+		Reason:       "opening_hours",
+		Upcoming:     []models.RestaurantSpecialHours{},
+		Timezone:     loc.String(),
+		CheckedAt:    now,
+	}
+	if !restaurant.AutoOpenClose {
+		status.Reason = "manual"
+	} else if _, entry, ok := specialHoursAt(entries, now); ok {
+		status.SpecialHours = entry
+		status.Reason = "special_hours"
+		if entry.IsClosed {
+			status.Reason = "holiday"
+		}
+	}
+
+	if pause := s.activePause(ctx, restaurant.ID); pause != nil {
+		status.IsOpen = false
+		status.Reason = "paused"
+		status.PausedUntil = &pause.EndsAt
+	}
+
+	horizon := now.AddDate(0, 0, specialHoursUpcomingDays).Format(specialHoursDateLayout)
+	for _, entry := range entries {
+		if entry.StartDate <= horizon {
+			status.Upcoming = append(status.Upcoming, entry)
+		}
+	}
+
+	return status, nil
+}
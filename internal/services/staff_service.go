@@ -0,0 +1,434 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Restaurant staff permissions checked by middleware.RestaurantPermissionRequired.
+// Owners and admins hold all of them.
+const (
+	StaffPermissionMenuEdit    = "menu.edit"
+	StaffPermissionOrders      = "orders.manage"
+	StaffPermissionPayoutsView = "payouts.view"
+)
+
+var validStaffPermissions = map[string]bool{
+	StaffPermissionMenuEdit:    true,
+	StaffPermissionOrders:      true,
+	StaffPermissionPayoutsView: true,
+}
+
+const (
+	StaffInvited = "invited"
+	StaffActive  = "active"
+	StaffRevoked = "revoked"
+)
+
+// staffInviteExpiry is how long an invitation can be accepted. The OTP itself expires sooner;
+// the invitee can ask for a new one until the invitation does.
+const staffInviteExpiry = 7 * 24 * time.Hour
+
+var (
+	ErrStaffForbidden = apperr.Forbidden("staff_forbidden", "you do not manage this restaurant's staff")
+	ErrStaffInvalid   = apperr.Validation("staff_invalid", "invalid staff request")
+	ErrStaffNotFound  = apperr.NotFound("staff_not_found", "staff member not found")
+)
+
+type InviteStaffRequest struct {
+	Phone       string   `json:"phone" binding:"required"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions" binding:"required,min=1"`
+}
+
+type UpdateStaffPermissionsRequest struct {
+	Permissions []string `json:"permissions" binding:"required,min=1"`
+}
+
+type StaffInviteOTPRequest struct {
+	Phone        string `json:"phone" binding:"required"`
+	RestaurantID string `json:"restaurant_id" binding:"required"`
+}
+
+type AcceptStaffInviteRequest struct {
+	Phone        string `json:"phone" binding:"required"`
+	RestaurantID string `json:"restaurant_id" binding:"required"`
+	OTPCode      string `json:"otp_code" binding:"required"`
+	Name         string `json:"name"` // used when the phone number has no account yet
+	DeviceInfo
+}
+
+// StaffService lets restaurant owners invite staff by phone, grant them permissions and remove
+// them. A user is staff of at most one restaurant at a time; their tokens carry the permissions
+// of that membership.
+type StaffService struct {
+	staffRepo      repositories.StaffRepository
+	restaurantRepo repositories.RestaurantRepository
+	userRepo       repositories.UserRepository
+	auditRepo      repositories.AuditLogRepository
+	otpService     *OTPService
+	sessionService *SessionService
+}
+
+func NewStaffService(
+	staffRepo repositories.StaffRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	userRepo repositories.UserRepository,
+	auditRepo repositories.AuditLogRepository,
+	otpService *OTPService,
+	sessionService *SessionService,
+) *StaffService {
+	return &StaffService{
+		staffRepo:      staffRepo,
+		restaurantRepo: restaurantRepo,
+		userRepo:       userRepo,
+		auditRepo:      auditRepo,
+		otpService:     otpService,
+		sessionService: sessionService,
+	}
+}
+
+// ListStaff returns a restaurant's staff and open invitations, newest first
+func (s *StaffService) ListStaff(ctx context.Context, userID, role, restaurantID string) ([]models.RestaurantStaff, error) {
+	restaurant, err := s.manageRestaurant(ctx, userID, role, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	staff, err := s.staffRepo.GetByRestaurant(ctx, restaurant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staff: %v", err)
+	}
+	return staff, nil
+}
+
+// InviteStaff invites a phone number to the restaurant's staff and sends it an OTP to accept
+// with. Inviting a number that already has an open invitation updates and resends it.
+func (s *StaffService) InviteStaff(ctx context.Context, userID, role, restaurantID string, req *InviteStaffRequest) (*models.RestaurantStaff, error) {
+	restaurant, err := s.manageRestaurant(ctx, userID, role, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := staffPermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	phone := strings.TrimSpace(req.Phone)
+	if len(phone) < 10 {
+		return nil, fmt.Errorf("%w: invalid phone number", ErrStaffInvalid)
+	}
+
+	members, err := s.staffRepo.GetByRestaurant(ctx, restaurant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staff: %v", err)
+	}
+	for _, member := range members {
+		if member.Phone == phone && member.Status == StaffActive {
+			return nil, fmt.Errorf("%w: %s is already on the staff", ErrStaffInvalid, phone)
+		}
+	}
+	if owner, err := s.userRepo.GetByID(ctx, restaurant.OwnerID); err == nil && owner.Phone == phone {
+		return nil, fmt.Errorf("%w: the owner cannot be invited as staff", ErrStaffInvalid)
+	}
+
+	inviterID, _ := uuid.Parse(userID)
+	staff, err := s.staffRepo.GetPendingInvite(ctx, restaurant.ID, phone)
+	if err != nil {
+		staff = &models.RestaurantStaff{
+			RestaurantID: restaurant.ID,
+			Phone:        phone,
+			Status:       StaffInvited,
+		}
+	}
+	staff.Name = strings.TrimSpace(req.Name)
+	staff.Permissions = permissions
+	staff.InvitedBy = inviterID
+	staff.InviteExpiresAt = time.Now().Add(staffInviteExpiry)
+
+	if staff.ID == uuid.Nil {
+		err = s.staffRepo.Create(ctx, staff)
+	} else {
+		err = s.staffRepo.Update(ctx, staff)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save invitation: %v", err)
+	}
+
+	if err := s.sendInviteOTP(ctx, staff); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, staff, userID, "staff_invited")
+	return staff, nil
+}
+
+// SendInviteOTP sends a fresh OTP for an open invitation, for invitees whose first one expired
+func (s *StaffService) SendInviteOTP(ctx context.Context, req *StaffInviteOTPRequest) error {
+	staff, err := s.pendingInvite(ctx, req.RestaurantID, req.Phone)
+	if err != nil {
+		return err
+	}
+	return s.sendInviteOTP(ctx, staff)
+}
+
+// AcceptInvite verifies the invitation OTP, joins the phone's account to the restaurant's
+// staff, creating the account if needed, and signs it in
+func (s *StaffService) AcceptInvite(ctx context.Context, req *AcceptStaffInviteRequest) (*AuthResponse, error) {
+	staff, err := s.pendingInvite(ctx, req.RestaurantID, req.Phone)
+	if err != nil {
+		return nil, err
+	}
+
+	// The code is spent only once the invitation is accepted, so a failure on the way leaves it
+	// usable for another try
+	otp, err := s.otpService.checkCode(ctx, staff.Phone, &staff.RestaurantID, OTPPurposeStaffInvite, req.OTPCode)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByPhone(ctx, staff.Phone)
+	if err != nil || user.Role == "customer" {
+		user = nil
+	} else if user.Role != "restaurant_staff" {
+		return nil, fmt.Errorf("%w: this phone number belongs to a %s account", ErrStaffInvalid, user.Role)
+	} else if current, err := s.staffRepo.GetActiveByUser(ctx, user.ID); err == nil && current.RestaurantID != staff.RestaurantID {
+		return nil, fmt.Errorf("%w: this account is already staff of another restaurant", ErrStaffInvalid)
+	}
+
+	now := time.Now()
+	if user == nil {
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			name = staff.Name
+		}
+		if name == "" {
+			name = fmt.Sprintf("Staff-%s", staff.Phone[len(staff.Phone)-4:])
+		}
+		user = &models.User{
+			Name:         name,
+			Phone:        staff.Phone,
+			Role:         "restaurant_staff",
+			RestaurantID: &staff.RestaurantID,
+			Status:       "active",
+			IsVerified:   true, // the OTP proved the phone number
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to create staff account: %v", err)
+		}
+	} else {
+		user.RestaurantID = &staff.RestaurantID
+		user.Status = "active"
+		user.UpdatedAt = now
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to update staff account: %v", err)
+		}
+	}
+
+	staff.UserID = &user.ID
+	staff.Status = StaffActive
+	staff.AcceptedAt = &now
+	if err := s.staffRepo.Update(ctx, staff); err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %v", err)
+	}
+	if err := s.otpService.spendCode(ctx, otp); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, staff, user.ID.String(), "staff_joined")
+
+	tokenPair, err := s.sessionService.StartSession(ctx, user, req.DeviceInfo, "staff_invite")
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResponse{
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    3600, // 1 hour in seconds
+		User:         *user,
+	}, nil
+}
+
+// UpdatePermissions replaces a staff member's permissions. Active members are signed out so
+// their next sign-in carries the new permissions.
+func (s *StaffService) UpdatePermissions(ctx context.Context, userID, role, restaurantID, staffID string, req *UpdateStaffPermissionsRequest) (*models.RestaurantStaff, error) {
+	staff, err := s.staffMember(ctx, userID, role, restaurantID, staffID)
+	if err != nil {
+		return nil, err
+	}
+	if staff.Status == StaffRevoked {
+		return nil, fmt.Errorf("%w: staff member was removed", ErrStaffInvalid)
+	}
+	permissions, err := staffPermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	staff.Permissions = permissions
+	if err := s.staffRepo.Update(ctx, staff); err != nil {
+		return nil, fmt.Errorf("failed to update permissions: %v", err)
+	}
+
+	if staff.UserID != nil {
+		if err := s.sessionService.SignOutUser(ctx, *staff.UserID, "staff_permissions_changed"); err != nil {
+			log.Printf("Failed to sign out staff member %s: %v", staff.ID, err)
+		}
+	}
+
+	s.audit(ctx, staff, userID, "staff_permissions_updated")
+	return staff, nil
+}
+
+// RevokeStaff removes a staff member, or cancels an open invitation. Removed members are signed
+// out everywhere and lose access to the restaurant.
+func (s *StaffService) RevokeStaff(ctx context.Context, userID, role, restaurantID, staffID string) error {
+	staff, err := s.staffMember(ctx, userID, role, restaurantID, staffID)
+	if err != nil {
+		return err
+	}
+	if staff.Status == StaffRevoked {
+		return nil
+	}
+
+	now := time.Now()
+	staff.Status = StaffRevoked
+	staff.RevokedAt = &now
+	if err := s.staffRepo.Update(ctx, staff); err != nil {
+		return fmt.Errorf("failed to remove staff member: %v", err)
+	}
+
+	if staff.UserID != nil {
+		if user, err := s.userRepo.GetByID(ctx, *staff.UserID); err == nil && user.RestaurantID != nil && *user.RestaurantID == staff.RestaurantID {
+			user.RestaurantID = nil
+			user.UpdatedAt = now
+			if err := s.userRepo.Update(ctx, user); err != nil {
+				log.Printf("Failed to detach user %s from restaurant %s: %v", user.ID, staff.RestaurantID, err)
+			}
+		}
+		if err := s.sessionService.SignOutUser(ctx, *staff.UserID, "staff_revoked"); err != nil {
+			log.Printf("Failed to sign out staff member %s: %v", staff.ID, err)
+		}
+	}
+
+	s.audit(ctx, staff, userID, "staff_revoked")
+	return nil
+}
+
+func (s *StaffService) sendInviteOTP(ctx context.Context, staff *models.RestaurantStaff) error {
+	code, err := s.otpService.issueCode(ctx, staff.Phone, &staff.RestaurantID, OTPPurposeStaffInvite)
+	if err != nil {
+		return err
+	}
+	return s.otpService.SendVerificationCode(ctx, "phone", staff.Phone, code)
+}
+
+// pendingInvite loads an invitation that can still be accepted
+func (s *StaffService) pendingInvite(ctx context.Context, restaurantID, phone string) (*models.RestaurantStaff, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrStaffInvalid)
+	}
+	staff, err := s.staffRepo.GetPendingInvite(ctx, restaurantUUID, strings.TrimSpace(phone))
+	if err != nil || time.Now().After(staff.InviteExpiresAt) {
+		return nil, fmt.Errorf("%w: no open invitation for this phone number", ErrStaffNotFound)
+	}
+	return staff, nil
+}
+
+func (s *StaffService) staffMember(ctx context.Context, userID, role, restaurantID, staffID string) (*models.RestaurantStaff, error) {
+	restaurant, err := s.manageRestaurant(ctx, userID, role, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	staffUUID, err := uuid.Parse(staffID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid staff ID", ErrStaffInvalid)
+	}
+	staff, err := s.staffRepo.GetByID(ctx, staffUUID)
+	if err != nil || staff.RestaurantID != restaurant.ID {
+		return nil, fmt.Errorf("%w: %s", ErrStaffNotFound, staffID)
+	}
+	return staff, nil
+}
+
+// manageRestaurant loads a restaurant the user owns, or any restaurant for admins
+func (s *StaffService) manageRestaurant(ctx context.Context, userID, role, restaurantID string) (*models.Restaurant, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrStaffInvalid)
+	}
+	restaurant, err := s.restaurantRepo.GetByID(ctx, restaurantUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: restaurant %s", ErrStaffNotFound, restaurantID)
+	}
+	if role != "admin" && restaurant.OwnerID.String() != userID {
+		return nil, ErrStaffForbidden
+	}
+	return restaurant, nil
+}
+
+func (s *StaffService) audit(ctx context.Context, staff *models.RestaurantStaff, performedBy, action string) {
+	entry := &models.AuditLog{
+		EntityType: "restaurant",
+		EntityID:   staff.RestaurantID.String(),
+		Action:     action,
+		Metadata: models.JSONB{
+			"staff_id":    staff.ID.String(),
+			"phone":       staff.Phone,
+			"permissions": []string(staff.Permissions),
+		},
+	}
+	if performerID, err := uuid.Parse(performedBy); err == nil {
+		entry.PerformedBy = &performerID
+	}
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write staff audit entry for restaurant %s: %v", staff.RestaurantID, err)
+	}
+}
+
+// staffPermissions validates and de-duplicates requested permissions
+func staffPermissions(requested []string) (models.StringArray, error) {
+	seen := make(map[string]bool, len(requested))
+	permissions := models.StringArray{}
+	for _, permission := range requested {
+		if !validStaffPermissions[permission] {
+			return nil, fmt.Errorf("%w: unknown permission %q", ErrStaffInvalid, permission)
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions, nil
+}
+
+// staffHasPermission reports whether the user is active staff of the restaurant holding the
+// permission
+func staffHasPermission(ctx context.Context, staffRepo repositories.StaffRepository, userID string, restaurantID uuid.UUID, permission string) bool {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return false
+	}
+	staff, err := staffRepo.GetActiveByUser(ctx, userUUID)
+	if err != nil || staff.RestaurantID != restaurantID {
+		return false
+	}
+	for _, granted := range staff.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
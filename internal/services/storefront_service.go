@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/pkg/cache"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Longest time a storefront is served from cache; kept short because open status follows the clock
+const storefrontCacheTTL = time.Minute
+
+type StorefrontService struct {
+	shopTimeService  *ShopTimeService
+	categoryService  *CategoryService
+	highlightService *HighlightService
+	bannerService    *BannerService
+	cache            *cache.RedisCache
+}
+
+func NewStorefrontService(
+	shopTimeService *ShopTimeService,
+	categoryService *CategoryService,
+	highlightService *HighlightService,
+	bannerService *BannerService,
+	cache *cache.RedisCache,
+) *StorefrontService {
+	return &StorefrontService{
+		shopTimeService:  shopTimeService,
+		categoryService:  categoryService,
+		highlightService: highlightService,
+		bannerService:    bannerService,
+		cache:            cache,
+	}
+}
+
+type StorefrontResponse struct {
+	Restaurant       *models.Restaurant              `json:"restaurant"`
+	IsOpen           bool                            `json:"is_open"`
+	PausedUntil      *time.Time                      `json:"paused_until,omitempty"` // busy mode; no new orders until then
+	SpecialHours     []models.RestaurantSpecialHours `json:"special_hours"`          // holidays and changed hours from today on
+	Categories       []models.ProductCategory        `json:"categories"`
+	FeaturedProducts []models.Product                `json:"featured_products"`
+	Banners          []models.Banner                 `json:"banners"`
+	ActiveTimeGroups []models.TimeRangeProductsGroup `json:"active_time_groups"`
+	GeneratedAt      time.Time                       `json:"generated_at"`
+}
+
+func storefrontCacheKey(restaurantID string) string {
+	return "storefront:" + restaurantID
+}
+
+// GetStorefront composes everything the app's restaurant home screen needs in one response.
+// The parts are loaded concurrently; any failure fails the whole response.
+func (s *StorefrontService) GetStorefront(ctx context.Context, restaurantID string) (*StorefrontResponse, error) {
+	cacheKey := storefrontCacheKey(restaurantID)
+	var cached StorefrontResponse
+	if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+		return &cached, nil
+	}
+
+	// The restaurant is loaded first so an unknown ID fails fast instead of fanning out
+	restaurant, err := s.shopTimeService.GetShopTiming(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(s.shopTimeService.restaurantLocation(restaurant))
+	specialHours := s.shopTimeService.restaurantSpecialHours(ctx, restaurant)
+	if specialHours == nil {
+		specialHours = []models.RestaurantSpecialHours{}
+	}
+	storefront := &StorefrontResponse{
+		Restaurant:       restaurant,
+		IsOpen:           s.shopTimeService.isRestaurantOpenAtTime(restaurant, specialHours, now),
+		SpecialHours:     specialHours,
+		Categories:       []models.ProductCategory{},
+		FeaturedProducts: []models.Product{},
+		Banners:          []models.Banner{},
+		ActiveTimeGroups: []models.TimeRangeProductsGroup{},
+		GeneratedAt:      time.Now(),
+	}
+	if pause := s.shopTimeService.activePause(ctx, restaurant.ID); pause != nil {
+		storefront.IsOpen = false
+		storefront.PausedUntil = &pause.EndsAt
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		categories, err := s.categoryService.GetCategoriesByRestaurant(gctx, restaurantID)
+		if err != nil {
+			return fmt.Errorf("failed to get categories: %v", err)
+		}
+		if categories != nil {
+			storefront.Categories = categories
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		products, err := s.highlightService.GetHighlightedProducts(gctx, restaurantID, "featured")
+		if err != nil {
+			return err
+		}
+		storefront.FeaturedProducts = products
+		return nil
+	})
+
+	g.Go(func() error {
+		banners, err := s.bannerService.GetActiveBanners(gctx, "restaurant", restaurantID)
+		if err != nil {
+			return err
+		}
+		storefront.Banners = banners
+		return nil
+	})
+
+	g.Go(func() error {
+		groups, err := s.shopTimeService.GetTimeGroups(gctx, restaurantID)
+		if err != nil {
+			return fmt.Errorf("failed to get time groups: %v", err)
+		}
+		currentTime := now.Format("15:04")
+		for _, group := range groups {
+			if isTimeInRange(currentTime, group.StartTime, group.EndTime) {
+				storefront.ActiveTimeGroups = append(storefront.ActiveTimeGroups, group)
+			}
+		}
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	s.cache.SetWithTags(ctx, cacheKey, storefront, storefrontCacheTTL,
+		restaurantProductsTag(restaurantID),
+		restaurantTimingTag(restaurantID),
+	)
+
+	return storefront, nil
+}
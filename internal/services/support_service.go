@@ -0,0 +1,695 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/i18n"
+	"golang-food-backend/pkg/messaging"
+
+	"github.com/google/uuid"
+)
+
+// Support ticket statuses
+const (
+	SupportStatusOpen             = "open"              // waiting on support
+	SupportStatusAwaitingCustomer = "awaiting_customer" // support replied and waits on the customer
+	SupportStatusResolved         = "resolved"          // reopened if the customer replies
+	SupportStatusClosed           = "closed"            // closed by the customer, final
+)
+
+// Support ticket priorities, which set the SLA deadlines
+const (
+	SupportPriorityLow    = "low"
+	SupportPriorityNormal = "normal"
+	SupportPriorityHigh   = "high"
+	SupportPriorityUrgent = "urgent"
+)
+
+// Who wrote a ticket message
+const (
+	SupportAuthorCustomer = "customer"
+	SupportAuthorAgent    = "agent"
+	SupportAuthorSystem   = "system"
+)
+
+// Issue types of tickets opened by the system rather than a customer
+const (
+	SupportIssueRefundFailed   = "refund_failed"
+	SupportIssueDeliveryFailed = "delivery_failed"
+)
+
+const (
+	supportMaxAttachments = 5
+	// supportDeliveryFailures is how many failed dispatch attempts of one order open a ticket
+	supportDeliveryFailures = 3
+)
+
+// supportIssueTypes are the issues customers raise tickets for, with their priority
+var supportIssueTypes = map[string]string{
+	"missing_items": SupportPriorityNormal,
+	"wrong_items":   SupportPriorityNormal,
+	"food_quality":  SupportPriorityNormal,
+	"late_delivery": SupportPriorityNormal,
+	"payment":       SupportPriorityHigh,
+	"refund":        SupportPriorityHigh,
+	"other":         SupportPriorityLow,
+}
+
+// supportSLA is how long support has to first reply to a ticket and to resolve it
+type supportSLA struct {
+	firstResponse time.Duration
+	resolution    time.Duration
+}
+
+var supportSLAs = map[string]supportSLA{
+	SupportPriorityUrgent: {firstResponse: 15 * time.Minute, resolution: 4 * time.Hour},
+	SupportPriorityHigh:   {firstResponse: time.Hour, resolution: 8 * time.Hour},
+	SupportPriorityNormal: {firstResponse: 4 * time.Hour, resolution: 24 * time.Hour},
+	SupportPriorityLow:    {firstResponse: 8 * time.Hour, resolution: 72 * time.Hour},
+}
+
+var (
+	ErrSupportInvalid  = apperr.Validation("support_ticket_invalid", "invalid support ticket request")
+	ErrSupportNotFound = apperr.NotFound("support_ticket_not_found", "support ticket not found")
+	ErrSupportClosed   = apperr.Conflict("support_ticket_closed", "support ticket is closed")
+)
+
+// SupportService runs customer support tickets. Customers raise tickets about their orders and
+// follow them up; support agents, admins with support.manage, pick them up, reply and resolve
+// them against deadlines set by the ticket's priority. Failed refunds and orders no delivery
+// partner could be booked for open tickets by themselves.
+type SupportService struct {
+	supportRepo repositories.SupportTicketRepository
+	orderRepo   repositories.OrderRepository
+	adminRepo   repositories.AdminRepository
+	media       *MediaService
+}
+
+func NewSupportService(
+	supportRepo repositories.SupportTicketRepository,
+	orderRepo repositories.OrderRepository,
+	adminRepo repositories.AdminRepository,
+	media *MediaService,
+) *SupportService {
+	return &SupportService{
+		supportRepo: supportRepo,
+		orderRepo:   orderRepo,
+		adminRepo:   adminRepo,
+		media:       media,
+	}
+}
+
+type CreateSupportTicketRequest struct {
+	OrderID       string   `json:"order_id"`                                              // the order the issue is about, if any
+	IssueType     string   `json:"issue_type" binding:"required" example:"missing_items"` // missing_items, wrong_items, food_quality, late_delivery, payment, refund or other
+	Subject       string   `json:"subject" binding:"required,max=200" example:"Garlic naan was missing"`
+	Message       string   `json:"message" binding:"required,max=5000"`
+	AttachmentIDs []string `json:"attachment_ids"` // confirmed uploads from POST /support/attachments, at most 5
+}
+
+type SupportMessageRequest struct {
+	Message       string   `json:"message" binding:"required,max=5000"`
+	AttachmentIDs []string `json:"attachment_ids"` // at most 5
+	Internal      bool     `json:"internal"`       // agents only: a note the customer does not see
+}
+
+type AssignSupportTicketRequest struct {
+	AgentID string `json:"agent_id"` // admin to assign; empty assigns the caller
+}
+
+type ResolveSupportTicketRequest struct {
+	Resolution string `json:"resolution" binding:"required,max=2000" example:"Refunded the missing item"`
+}
+
+type SupportUploadRequest struct {
+	FileName    string `json:"file_name" binding:"required" example:"photo.jpg"`
+	ContentType string `json:"content_type" binding:"required" example:"image/jpeg"` // application/pdf, image/jpeg or image/png
+	Size        int64  `json:"size" binding:"required,gt=0"`
+}
+
+// SupportTicketSLA reports the ticket's deadlines and whether they were missed
+type SupportTicketSLA struct {
+	FirstResponseDueAt    time.Time `json:"first_response_due_at"`
+	ResolutionDueAt       time.Time `json:"resolution_due_at"`
+	FirstResponseBreached bool      `json:"first_response_breached"`
+	ResolutionBreached    bool      `json:"resolution_breached"`
+}
+
+type SupportTicketResponse struct {
+	models.SupportTicket
+	SLA SupportTicketSLA `json:"sla"`
+}
+
+type SupportTicketsResponse struct {
+	Tickets    []SupportTicketResponse `json:"tickets"`
+	Pagination PaginationInfo          `json:"pagination"`
+}
+
+// SupportTicketQuery filters the support queue
+type SupportTicketQuery struct {
+	Status      string
+	Priority    string
+	IssueType   string
+	AssignedTo  string // an admin ID, or "unassigned"
+	SLABreached bool   // unresolved tickets past a deadline
+	Page        int
+	Limit       int
+}
+
+type SupportAttachmentResponse struct {
+	URL string `json:"url"` // short-lived link to the file
+}
+
+// CreateTicket raises a ticket for the customer. A ticket about an order must be about one of
+// theirs; it records the order's restaurant.
+func (s *SupportService) CreateTicket(ctx context.Context, userID string, req *CreateSupportTicketRequest) (*SupportTicketResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user ID", ErrSupportInvalid)
+	}
+	priority, ok := supportIssueTypes[req.IssueType]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown issue type %q", ErrSupportInvalid, req.IssueType)
+	}
+
+	ticket := &models.SupportTicket{
+		UserID:    userUUID,
+		IssueType: req.IssueType,
+		Subject:   strings.TrimSpace(req.Subject),
+		Priority:  priority,
+		Source:    SupportAuthorCustomer,
+	}
+	if req.OrderID != "" {
+		orderUUID, err := uuid.Parse(req.OrderID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid order ID", ErrSupportInvalid)
+		}
+		order, err := s.orderRepo.GetByID(ctx, orderUUID)
+		if err != nil || order.UserID != userUUID {
+			return nil, fmt.Errorf("%w: order %s not found", ErrSupportInvalid, req.OrderID)
+		}
+		ticket.OrderID = &order.ID
+		ticket.RestaurantID = &order.RestaurantID
+	}
+
+	attachments, err := s.confirmAttachments(ctx, userID, req.AttachmentIDs)
+	if err != nil {
+		return nil, err
+	}
+	message := &models.SupportTicketMessage{
+		AuthorType:  SupportAuthorCustomer,
+		AuthorID:    &userUUID,
+		Body:        req.Message,
+		Attachments: attachments,
+	}
+
+	startSupportClock(ticket, time.Now())
+	if _, err := s.supportRepo.Create(ctx, ticket, message, nil); err != nil {
+		return nil, fmt.Errorf("failed to create ticket: %v", err)
+	}
+	ticket.Messages = []models.SupportTicketMessage{*message}
+
+	return supportTicketResponse(ticket, time.Now()), nil
+}
+
+// ListMyTickets lists the customer's tickets, most recently active first
+func (s *SupportService) ListMyTickets(ctx context.Context, userID, status string, page, limit int) (*SupportTicketsResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user ID", ErrSupportInvalid)
+	}
+	return s.list(ctx, repositories.SupportTicketFilter{UserID: userUUID, Status: status}, page, limit)
+}
+
+// GetMyTicket returns one of the customer's tickets without the agents' internal notes
+func (s *SupportService) GetMyTicket(ctx context.Context, userID, ticketID string) (*SupportTicketResponse, error) {
+	ticket, err := s.customerTicket(ctx, userID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	return supportTicketResponse(ticket, time.Now()), nil
+}
+
+// Reply adds the customer's message. The ticket goes back to support, which reopens a
+// resolved ticket.
+func (s *SupportService) Reply(ctx context.Context, userID, ticketID string, req *SupportMessageRequest) (*SupportTicketResponse, error) {
+	ticket, err := s.customerTicket(ctx, userID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.Status == SupportStatusClosed {
+		return nil, ErrSupportClosed
+	}
+	attachments, err := s.confirmAttachments(ctx, userID, req.AttachmentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if ticket.Status == SupportStatusResolved {
+		// A reopened ticket gets a fresh resolution deadline
+		ticket.ResolvedAt = nil
+		ticket.ResolutionDueAt = now.Add(supportSLAs[ticket.Priority].resolution)
+	}
+	ticket.Status = SupportStatusOpen
+	ticket.LastMessageAt = now
+
+	message := &models.SupportTicketMessage{
+		AuthorType:  SupportAuthorCustomer,
+		AuthorID:    &ticket.UserID,
+		Body:        req.Message,
+		Attachments: attachments,
+	}
+	if err := s.supportRepo.Save(ctx, ticket, message, nil); err != nil {
+		return nil, fmt.Errorf("failed to save ticket: %v", err)
+	}
+	ticket.Messages = append(ticket.Messages, *message)
+
+	return supportTicketResponse(ticket, now), nil
+}
+
+// CloseTicket closes the customer's ticket for good
+func (s *SupportService) CloseTicket(ctx context.Context, userID, ticketID string) (*SupportTicketResponse, error) {
+	ticket, err := s.customerTicket(ctx, userID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.Status == SupportStatusClosed {
+		return nil, ErrSupportClosed
+	}
+
+	now := time.Now()
+	ticket.Status = SupportStatusClosed
+	if ticket.ResolvedAt == nil {
+		ticket.ResolvedAt = &now
+	}
+	message := &models.SupportTicketMessage{
+		AuthorType: SupportAuthorSystem,
+		Body:       "The customer closed the ticket.",
+	}
+	if err := s.supportRepo.Save(ctx, ticket, message, nil); err != nil {
+		return nil, fmt.Errorf("failed to save ticket: %v", err)
+	}
+	ticket.Messages = append(ticket.Messages, *message)
+
+	return supportTicketResponse(ticket, now), nil
+}
+
+// CreateUpload starts the upload of a ticket attachment by a customer or agent
+func (s *SupportService) CreateUpload(ctx context.Context, uploaderID string, req *SupportUploadRequest) (*UploadResponse, error) {
+	upload, err := s.media.CreateSupportUpload(ctx, uploaderID, req.FileName, req.ContentType, req.Size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSupportInvalid, err)
+	}
+	return upload, nil
+}
+
+// MyAttachmentURL links to an attachment of a message the customer can see on their ticket
+func (s *SupportService) MyAttachmentURL(ctx context.Context, userID, ticketID, mediaID string) (*SupportAttachmentResponse, error) {
+	ticket, err := s.customerTicket(ctx, userID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	return s.attachmentURL(ctx, ticket, mediaID)
+}
+
+// ListTickets lists the support queue, most recently active first
+func (s *SupportService) ListTickets(ctx context.Context, query SupportTicketQuery) (*SupportTicketsResponse, error) {
+	filter := repositories.SupportTicketFilter{
+		Status:    query.Status,
+		Priority:  query.Priority,
+		IssueType: query.IssueType,
+	}
+	switch query.AssignedTo {
+	case "":
+	case "unassigned":
+		filter.Unassigned = true
+	default:
+		agentUUID, err := uuid.Parse(query.AssignedTo)
+		if err != nil {
+			return nil, fmt.Errorf("%w: assigned_to must be an admin ID, me or unassigned", ErrSupportInvalid)
+		}
+		filter.AssignedTo = agentUUID
+	}
+	if query.SLABreached {
+		filter.BreachedAt = time.Now()
+	}
+	return s.list(ctx, filter, query.Page, query.Limit)
+}
+
+// GetTicket returns a ticket with its whole thread, internal notes included
+func (s *SupportService) GetTicket(ctx context.Context, ticketID string) (*SupportTicketResponse, error) {
+	ticket, err := s.getTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	return supportTicketResponse(ticket, time.Now()), nil
+}
+
+// AssignTicket hands the ticket to an active admin who works support
+func (s *SupportService) AssignTicket(ctx context.Context, adminID, ticketID string, req *AssignSupportTicketRequest) (*SupportTicketResponse, error) {
+	agentID := req.AgentID
+	if agentID == "" {
+		agentID = adminID
+	}
+	agentUUID, err := uuid.Parse(agentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid agent ID", ErrSupportInvalid)
+	}
+	agent, err := s.adminRepo.GetByID(ctx, agentUUID)
+	if err != nil || !agent.IsActive || !hasAdminPermission(agent.Permissions, AdminPermissionSupport) {
+		return nil, fmt.Errorf("%w: %s is not an active support agent", ErrSupportInvalid, agentID)
+	}
+
+	ticket, err := s.getTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.Status == SupportStatusClosed {
+		return nil, ErrSupportClosed
+	}
+
+	ticket.AssignedTo = &agent.ID
+	message := &models.SupportTicketMessage{
+		AuthorType: SupportAuthorSystem,
+		Body:       "Assigned to " + agent.Name + ".",
+		Internal:   true,
+	}
+	if err := s.supportRepo.Save(ctx, ticket, message, nil); err != nil {
+		return nil, fmt.Errorf("failed to save ticket: %v", err)
+	}
+	ticket.Messages = append(ticket.Messages, *message)
+
+	return supportTicketResponse(ticket, time.Now()), nil
+}
+
+// Respond adds an agent's message. A reply the customer can see meets the first response
+// deadline, puts the ticket in awaiting_customer and notifies the customer; an internal note
+// does neither. An unassigned ticket is assigned to the agent who answers it.
+func (s *SupportService) Respond(ctx context.Context, adminID, ticketID string, req *SupportMessageRequest) (*SupportTicketResponse, error) {
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid admin ID", ErrSupportInvalid)
+	}
+	ticket, err := s.getTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.Status == SupportStatusClosed {
+		return nil, ErrSupportClosed
+	}
+	attachments, err := s.confirmAttachments(ctx, adminID, req.AttachmentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if ticket.AssignedTo == nil {
+		ticket.AssignedTo = &adminUUID
+	}
+	message := &models.SupportTicketMessage{
+		AuthorType:  SupportAuthorAgent,
+		AuthorID:    &adminUUID,
+		Body:        req.Message,
+		Attachments: attachments,
+		Internal:    req.Internal,
+	}
+
+	var events []models.OutboxEvent
+	if !req.Internal {
+		if ticket.FirstRespondedAt == nil {
+			ticket.FirstRespondedAt = &now
+		}
+		if ticket.Status != SupportStatusResolved {
+			ticket.Status = SupportStatusAwaitingCustomer
+		}
+		ticket.LastMessageAt = now
+
+		notification, err := supportNotification(ticket, "support_ticket_reply", "Support Replied",
+			fmt.Sprintf("Support replied to your ticket \"%s\".", ticket.Subject))
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, notification)
+	}
+
+	if err := s.supportRepo.Save(ctx, ticket, message, events); err != nil {
+		return nil, fmt.Errorf("failed to save ticket: %v", err)
+	}
+	ticket.Messages = append(ticket.Messages, *message)
+
+	return supportTicketResponse(ticket, now), nil
+}
+
+// ResolveTicket marks the ticket resolved with what was done about it and tells the customer.
+// The customer can reopen it by replying.
+func (s *SupportService) ResolveTicket(ctx context.Context, adminID, ticketID string, req *ResolveSupportTicketRequest) (*SupportTicketResponse, error) {
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid admin ID", ErrSupportInvalid)
+	}
+	ticket, err := s.getTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	switch ticket.Status {
+	case SupportStatusClosed:
+		return nil, ErrSupportClosed
+	case SupportStatusResolved:
+		return nil, fmt.Errorf("%w: ticket is already resolved", ErrSupportInvalid)
+	}
+
+	now := time.Now()
+	ticket.Status = SupportStatusResolved
+	ticket.ResolvedAt = &now
+	ticket.Resolution = req.Resolution
+	ticket.LastMessageAt = now
+	if ticket.AssignedTo == nil {
+		ticket.AssignedTo = &adminUUID
+	}
+	if ticket.FirstRespondedAt == nil {
+		ticket.FirstRespondedAt = &now
+	}
+	message := &models.SupportTicketMessage{
+		AuthorType: SupportAuthorAgent,
+		AuthorID:   &adminUUID,
+		Body:       req.Resolution,
+	}
+
+	notification, err := supportNotification(ticket, "support_ticket_resolved", "Ticket Resolved",
+		fmt.Sprintf("Your ticket \"%s\" was resolved: %s", ticket.Subject, req.Resolution))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.supportRepo.Save(ctx, ticket, message, []models.OutboxEvent{notification}); err != nil {
+		return nil, fmt.Errorf("failed to save ticket: %v", err)
+	}
+	ticket.Messages = append(ticket.Messages, *message)
+
+	return supportTicketResponse(ticket, now), nil
+}
+
+// AttachmentURL links to any attachment on the ticket
+func (s *SupportService) AttachmentURL(ctx context.Context, ticketID, mediaID string) (*SupportAttachmentResponse, error) {
+	ticket, err := s.getTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	return s.attachmentURL(ctx, ticket, mediaID)
+}
+
+// RefundFailed opens a ticket for a refund that failed, once per refund
+func (s *SupportService) RefundFailed(ctx context.Context, refund *models.Refund) {
+	body := fmt.Sprintf("The refund of %s for order #%s failed.",
+		i18n.FormatMoney(refund.Amount.Float64(), refund.Order.Currency), refund.OrderID.String()[:8])
+	if refund.AdminComment != nil && *refund.AdminComment != "" {
+		body += " " + *refund.AdminComment
+	}
+	s.openSystemTicket(ctx, "refund_failed:"+refund.ID.String(), &models.SupportTicket{
+		UserID:    refund.UserID,
+		OrderID:   &refund.OrderID,
+		IssueType: SupportIssueRefundFailed,
+		Subject:   fmt.Sprintf("Refund failed for order #%s", refund.OrderID.String()[:8]),
+	}, body)
+}
+
+// DeliveryFailed opens a ticket for an order no delivery partner could be booked for after
+// repeated attempts, once per order
+func (s *SupportService) DeliveryFailed(ctx context.Context, order *models.Order, attempts int, cause error) {
+	if attempts < supportDeliveryFailures {
+		return
+	}
+	s.openSystemTicket(ctx, "delivery_failed:"+order.ID.String(), &models.SupportTicket{
+		UserID:       order.UserID,
+		OrderID:      &order.ID,
+		RestaurantID: &order.RestaurantID,
+		IssueType:    SupportIssueDeliveryFailed,
+		Subject:      fmt.Sprintf("Delivery could not be arranged for order #%s", order.ID.String()[:8]),
+	}, fmt.Sprintf("Booking a delivery partner failed %d times. Last error: %v", attempts, cause))
+}
+
+// openSystemTicket opens a high priority ticket for a failure the customer is affected by and
+// lets them know support is on it. The source key keeps a failure reported twice to one
+// ticket. Failures to open a ticket are logged, never returned: they must not fail the
+// operation that reported them.
+func (s *SupportService) openSystemTicket(ctx context.Context, sourceKey string, ticket *models.SupportTicket, body string) {
+	ticket.ID = uuid.New() // known before saving, for the notification
+	ticket.Priority = SupportPriorityHigh
+	ticket.Source = SupportAuthorSystem
+	ticket.SourceKey = &sourceKey
+	startSupportClock(ticket, time.Now())
+
+	message := &models.SupportTicketMessage{AuthorType: SupportAuthorSystem, Body: body}
+	notification, err := supportNotification(ticket, "support_ticket_opened", "We're On It",
+		ticket.Subject+". Our support team is looking into it and will get back to you.")
+	if err != nil {
+		log.Printf("Failed to open support ticket %s: %v", sourceKey, err)
+		return
+	}
+	created, err := s.supportRepo.Create(ctx, ticket, message, []models.OutboxEvent{notification})
+	if err != nil {
+		log.Printf("Failed to open support ticket %s: %v", sourceKey, err)
+		return
+	}
+	if created {
+		log.Printf("🎫 Opened support ticket %s for %s", ticket.ID, sourceKey)
+	}
+}
+
+func (s *SupportService) list(ctx context.Context, filter repositories.SupportTicketFilter, page, limit int) (*SupportTicketsResponse, error) {
+	page, limit = normalizePage(page, limit)
+	tickets, total, err := s.supportRepo.List(ctx, filter, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tickets: %v", err)
+	}
+
+	now := time.Now()
+	responses := make([]SupportTicketResponse, 0, len(tickets))
+	for i := range tickets {
+		responses = append(responses, *supportTicketResponse(&tickets[i], now))
+	}
+	return &SupportTicketsResponse{
+		Tickets: responses,
+		Pagination: PaginationInfo{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		},
+	}, nil
+}
+
+func (s *SupportService) getTicket(ctx context.Context, ticketID string) (*models.SupportTicket, error) {
+	ticketUUID, err := uuid.Parse(ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSupportNotFound, ticketID)
+	}
+	ticket, err := s.supportRepo.GetByID(ctx, ticketUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSupportNotFound, ticketID)
+	}
+	return ticket, nil
+}
+
+// customerTicket returns the user's ticket with internal notes taken out of the thread
+func (s *SupportService) customerTicket(ctx context.Context, userID, ticketID string) (*models.SupportTicket, error) {
+	ticket, err := s.getTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.UserID.String() != userID {
+		return nil, fmt.Errorf("%w: %s", ErrSupportNotFound, ticketID)
+	}
+
+	visible := ticket.Messages[:0]
+	for _, message := range ticket.Messages {
+		if !message.Internal {
+			visible = append(visible, message)
+		}
+	}
+	ticket.Messages = visible
+	return ticket, nil
+}
+
+// confirmAttachments checks the uploader's attachments are uploaded and really PDFs or images
+func (s *SupportService) confirmAttachments(ctx context.Context, uploaderID string, mediaIDs []string) (models.StringArray, error) {
+	if len(mediaIDs) > supportMaxAttachments {
+		return nil, fmt.Errorf("%w: at most %d attachments per message", ErrSupportInvalid, supportMaxAttachments)
+	}
+	attachments := models.StringArray{}
+	for _, mediaID := range mediaIDs {
+		media, err := s.media.ConfirmSupportUpload(ctx, uploaderID, mediaID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSupportInvalid, err)
+		}
+		attachments = append(attachments, media.ID.Hex())
+	}
+	return attachments, nil
+}
+
+// attachmentURL links to the attachment if it is on one of the ticket's messages as loaded
+func (s *SupportService) attachmentURL(ctx context.Context, ticket *models.SupportTicket, mediaID string) (*SupportAttachmentResponse, error) {
+	for _, message := range ticket.Messages {
+		for _, attachment := range message.Attachments {
+			if attachment != mediaID {
+				continue
+			}
+			url, err := s.media.SupportAttachmentURL(ctx, mediaID)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrSupportNotFound, err)
+			}
+			return &SupportAttachmentResponse{URL: url}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: attachment %s", ErrSupportNotFound, mediaID)
+}
+
+// startSupportClock sets a new ticket's deadlines from its priority
+func startSupportClock(ticket *models.SupportTicket, now time.Time) {
+	sla := supportSLAs[ticket.Priority]
+	ticket.Status = SupportStatusOpen
+	ticket.FirstResponseDueAt = now.Add(sla.firstResponse)
+	ticket.ResolutionDueAt = now.Add(sla.resolution)
+	ticket.LastMessageAt = now
+}
+
+// supportTicketResponse adds the SLA status as of now. A deadline is breached when it passed
+// before it was met, or passed while the ticket is still unmet.
+func supportTicketResponse(ticket *models.SupportTicket, now time.Time) *SupportTicketResponse {
+	sla := SupportTicketSLA{
+		FirstResponseDueAt: ticket.FirstResponseDueAt,
+		ResolutionDueAt:    ticket.ResolutionDueAt,
+	}
+	if ticket.FirstRespondedAt != nil {
+		sla.FirstResponseBreached = ticket.FirstRespondedAt.After(ticket.FirstResponseDueAt)
+	} else {
+		sla.FirstResponseBreached = ticket.ResolvedAt == nil && now.After(ticket.FirstResponseDueAt)
+	}
+	if ticket.ResolvedAt != nil {
+		sla.ResolutionBreached = ticket.ResolvedAt.After(ticket.ResolutionDueAt)
+	} else {
+		sla.ResolutionBreached = now.After(ticket.ResolutionDueAt)
+	}
+	return &SupportTicketResponse{SupportTicket: *ticket, SLA: sla}
+}
+
+func supportNotification(ticket *models.SupportTicket, eventType, title, message string) (models.OutboxEvent, error) {
+	metadata := map[string]interface{}{"ticket_id": ticket.ID.String()}
+	if ticket.OrderID != nil {
+		metadata["order_id"] = ticket.OrderID.String()
+	}
+	return NewOutboxEvent("notification_events", ticket.UserID.String(), messaging.NotificationEvent{
+		Type:     eventType,
+		UserID:   ticket.UserID.String(),
+		Title:    title,
+		Message:  message,
+		Metadata: metadata,
+	})
+}
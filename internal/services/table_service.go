@@ -0,0 +1,456 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	TabOpen   = "open"
+	TabClosed = "closed"
+
+	// PaymentMethodTab marks the payment of a dine-in round, settled when its tab is closed
+	PaymentMethodTab = "tab"
+)
+
+var (
+	ErrTableInvalid      = apperr.Validation("table_invalid", "invalid table")
+	ErrTableNotFound     = apperr.NotFound("table_not_found", "table not found")
+	ErrTableExists       = apperr.Conflict("table_exists", "the restaurant already has a table with this number")
+	ErrTableRequired     = apperr.Validation("table_required", "dine-in orders are placed from a table's QR code")
+	ErrTableUnavailable  = apperr.Conflict("table_unavailable", "the table is not taking orders")
+	ErrTableHasOpenTab   = apperr.Conflict("table_has_open_tab", "the table's open tab has to be closed first")
+	ErrTabNotFound       = apperr.NotFound("tab_not_found", "the table has no open tab")
+	ErrTabHasOpenRounds  = apperr.Conflict("tab_has_open_rounds", "every round has to be served or cancelled before the tab is closed")
+	ErrTabPaymentInvalid = apperr.Validation("tab_payment_invalid", "payment_method must be cash, card or upi")
+)
+
+var tabPaymentMethods = map[string]bool{"cash": true, "card": true, "upi": true}
+
+// TableRequest creates or replaces a table
+type TableRequest struct {
+	Number   string `json:"number" binding:"required,max=20" example:"12"`
+	Seats    int    `json:"seats" binding:"min=0,max=50"`
+	IsActive *bool  `json:"is_active"` // defaults to true; inactive tables take no orders
+}
+
+// TableResponse is a table as shown to staff, with the link its QR code should hold and the
+// tab running at it
+type TableResponse struct {
+	models.RestaurantTable
+	QRURL   string       `json:"qr_url,omitempty"`
+	OpenTab *TabResponse `json:"open_tab,omitempty"`
+}
+
+// TableInfo is what a customer sees after scanning a table's QR code
+type TableInfo struct {
+	RestaurantID   uuid.UUID `json:"restaurant_id"`
+	RestaurantName string    `json:"restaurant_name"`
+	TableNumber    string    `json:"table_number"`
+	Available      bool      `json:"available"` // dine-in orders can be placed at the table now
+}
+
+// TabRound is one order placed on a tab
+type TabRound struct {
+	Round       int                    `json:"round"`
+	OrderID     uuid.UUID              `json:"order_id"`
+	OrderStatus string                 `json:"order_status"`
+	TotalAmount models.Money           `json:"total_amount"`
+	Items       []models.OrderLineItem `json:"items"`
+	CreatedAt   time.Time              `json:"created_at"`
+}
+
+// TabResponse is a running bill with its rounds. Cancelled rounds are listed but not charged.
+type TabResponse struct {
+	ID            uuid.UUID    `json:"id"`
+	TableID       uuid.UUID    `json:"table_id"`
+	TableNumber   string       `json:"table_number"`
+	Status        string       `json:"status"` // open, closed
+	Rounds        []TabRound   `json:"rounds"`
+	TotalAmount   models.Money `json:"total_amount"`
+	Currency      string       `json:"currency,omitempty"`
+	PaymentMethod string       `json:"payment_method,omitempty"`
+	OpenedAt      time.Time    `json:"opened_at"`
+	ClosedAt      *time.Time   `json:"closed_at,omitempty"`
+}
+
+// CloseTabRequest settles a tab
+type CloseTabRequest struct {
+	PaymentMethod string `json:"payment_method" binding:"required" example:"cash"` // cash, card or upi
+}
+
+// TableService manages dine-in tables, their QR codes and the running tabs dine-in rounds are
+// charged to
+type TableService struct {
+	tableRepo      repositories.RestaurantTableRepository
+	tabRepo        repositories.TableTabRepository
+	restaurantRepo repositories.RestaurantRepository
+	ledger         *LedgerService
+	tableURL       string // QR codes link here with ?table=<token>; without one they hold the token
+}
+
+func NewTableService(
+	tableRepo repositories.RestaurantTableRepository,
+	tabRepo repositories.TableTabRepository,
+	restaurantRepo repositories.RestaurantRepository,
+	ledger *LedgerService,
+	tableURL string,
+) *TableService {
+	return &TableService{
+		tableRepo:      tableRepo,
+		tabRepo:        tabRepo,
+		restaurantRepo: restaurantRepo,
+		ledger:         ledger,
+		tableURL:       tableURL,
+	}
+}
+
+// ListTables returns the restaurant's tables with their open tabs
+func (s *TableService) ListTables(ctx context.Context, restaurantID string) ([]TableResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrTableInvalid)
+	}
+	tables, err := s.tableRepo.GetByRestaurantID(ctx, restaurantUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %v", err)
+	}
+	tabs, err := s.tabRepo.GetOpenByRestaurantID(ctx, restaurantUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open tabs: %v", err)
+	}
+	openTabs := make(map[uuid.UUID]*models.TableTab, len(tabs))
+	for i := range tabs {
+		openTabs[tabs[i].TableID] = &tabs[i]
+	}
+
+	response := make([]TableResponse, 0, len(tables))
+	for i := range tables {
+		table := s.tableResponse(&tables[i])
+		if tab, ok := openTabs[tables[i].ID]; ok {
+			table.OpenTab = newTabResponse(tab)
+		}
+		response = append(response, *table)
+	}
+	return response, nil
+}
+
+// CreateTable adds a table with a new QR token
+func (s *TableService) CreateTable(ctx context.Context, restaurantID string, req *TableRequest) (*TableResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrTableInvalid)
+	}
+	table := &models.RestaurantTable{RestaurantID: restaurantUUID}
+	if err := s.applyTableRequest(ctx, table, req); err != nil {
+		return nil, err
+	}
+	if table.QRToken, err = generateTableToken(); err != nil {
+		return nil, err
+	}
+	if err := s.tableRepo.Create(ctx, table); err != nil {
+		return nil, fmt.Errorf("failed to create table: %v", err)
+	}
+	return s.tableResponse(table), nil
+}
+
+// UpdateTable replaces a table's number, seats and active flag
+func (s *TableService) UpdateTable(ctx context.Context, restaurantID, tableID string, req *TableRequest) (*TableResponse, error) {
+	table, err := s.restaurantTable(ctx, restaurantID, tableID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyTableRequest(ctx, table, req); err != nil {
+		return nil, err
+	}
+	if err := s.tableRepo.Update(ctx, table); err != nil {
+		return nil, fmt.Errorf("failed to update table: %v", err)
+	}
+	return s.tableResponse(table), nil
+}
+
+// DeleteTable removes a table without an open tab. Its past orders keep the table number.
+func (s *TableService) DeleteTable(ctx context.Context, restaurantID, tableID string) error {
+	table, err := s.restaurantTable(ctx, restaurantID, tableID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.tabRepo.GetOpenByTableID(ctx, table.ID); err == nil {
+		return ErrTableHasOpenTab
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to check open tab: %v", err)
+	}
+	if err := s.tableRepo.Delete(ctx, table.ID); err != nil {
+		return fmt.Errorf("failed to delete table: %v", err)
+	}
+	return nil
+}
+
+// RotateQRCode gives the table a new QR token; codes printed with the old one stop working
+func (s *TableService) RotateQRCode(ctx context.Context, restaurantID, tableID string) (*TableResponse, error) {
+	table, err := s.restaurantTable(ctx, restaurantID, tableID)
+	if err != nil {
+		return nil, err
+	}
+	if table.QRToken, err = generateTableToken(); err != nil {
+		return nil, err
+	}
+	if err := s.tableRepo.Update(ctx, table); err != nil {
+		return nil, fmt.Errorf("failed to update table: %v", err)
+	}
+	return s.tableResponse(table), nil
+}
+
+// GetOpenTab returns the tab running at one of the restaurant's tables
+func (s *TableService) GetOpenTab(ctx context.Context, restaurantID, tableID string) (*TabResponse, error) {
+	table, err := s.restaurantTable(ctx, restaurantID, tableID)
+	if err != nil {
+		return nil, err
+	}
+	tab, err := s.openTab(ctx, table.ID)
+	if err != nil {
+		return nil, err
+	}
+	return newTabResponse(tab), nil
+}
+
+// CloseTab settles the table's tab with the payment taken by staff and frees the table for the
+// next guests. Every round has to be served or cancelled first.
+func (s *TableService) CloseTab(ctx context.Context, userID, restaurantID, tableID string, req *CloseTabRequest) (*TabResponse, error) {
+	method := strings.ToLower(strings.TrimSpace(req.PaymentMethod))
+	if !tabPaymentMethods[method] {
+		return nil, ErrTabPaymentInvalid
+	}
+	table, err := s.restaurantTable(ctx, restaurantID, tableID)
+	if err != nil {
+		return nil, err
+	}
+	tab, err := s.openTab(ctx, table.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range tab.Orders {
+		if !IsFinalOrderStatus(order.OrderStatus) {
+			return nil, fmt.Errorf("%w: order %s is %s", ErrTabHasOpenRounds, order.ID.String()[:8], order.OrderStatus)
+		}
+	}
+
+	now := time.Now()
+	tab.Status = TabClosed
+	tab.TotalAmount = tabTotal(tab)
+	tab.PaymentMethod = method
+	tab.ClosedAt = &now
+	if closedBy, err := uuid.Parse(userID); err == nil {
+		tab.ClosedBy = &closedBy
+	}
+	payments, err := s.tabRepo.Close(ctx, tab)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTabNotFound // closed meanwhile
+		}
+		return nil, fmt.Errorf("failed to close tab: %v", err)
+	}
+
+	// Each round's payment is posted like any other captured payment
+	rounds := make(map[uuid.UUID]*models.Order, len(tab.Orders))
+	for i := range tab.Orders {
+		rounds[tab.Orders[i].ID] = &tab.Orders[i]
+	}
+	for _, payment := range payments {
+		if order, ok := rounds[payment.OrderID]; ok {
+			s.ledger.PaymentCaptured(ctx, order, payment.ID, "payment:"+payment.ID.String(), payment.Amount)
+		}
+	}
+	return newTabResponse(tab), nil
+}
+
+// GetTableInfo resolves a scanned QR code to its restaurant and table
+func (s *TableService) GetTableInfo(ctx context.Context, token string) (*TableInfo, error) {
+	table, restaurant, err := s.scannedTable(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return &TableInfo{
+		RestaurantID:   restaurant.ID,
+		RestaurantName: restaurant.Name,
+		TableNumber:    table.Number,
+		Available:      table.IsActive && restaurant.DineInEnabled,
+	}, nil
+}
+
+// GetTableTab returns the running bill of the table whose QR code was scanned, for everyone
+// ordering at it
+func (s *TableService) GetTableTab(ctx context.Context, token string) (*TabResponse, error) {
+	table, _, err := s.scannedTable(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	tab, err := s.openTab(ctx, table.ID)
+	if err != nil {
+		return nil, err
+	}
+	return newTabResponse(tab), nil
+}
+
+// attach seats a new dine-in order at the table whose QR code was scanned and puts it on the
+// table's open tab, opening one for the first round
+func (s *TableService) attach(ctx context.Context, order *models.Order, token string) error {
+	if strings.TrimSpace(token) == "" {
+		return ErrTableRequired
+	}
+	table, err := s.tableRepo.GetByQRToken(ctx, token)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && table.RestaurantID != order.RestaurantID) {
+		return ErrTableNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get table: %v", err)
+	}
+	if !table.IsActive {
+		return ErrTableUnavailable
+	}
+	tab, err := s.tabRepo.GetOrOpen(ctx, table)
+	if err != nil {
+		return fmt.Errorf("failed to open tab: %v", err)
+	}
+
+	order.TableID = &table.ID
+	order.TableNumber = table.Number
+	order.TabID = &tab.ID
+	return nil
+}
+
+// applyTableRequest validates the request and sets it on the table
+func (s *TableService) applyTableRequest(ctx context.Context, table *models.RestaurantTable, req *TableRequest) error {
+	number := strings.TrimSpace(req.Number)
+	if number == "" {
+		return fmt.Errorf("%w: number is required", ErrTableInvalid)
+	}
+	tables, err := s.tableRepo.GetByRestaurantID(ctx, table.RestaurantID)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %v", err)
+	}
+	for _, other := range tables {
+		if other.ID != table.ID && strings.EqualFold(other.Number, number) {
+			return fmt.Errorf("%w: %s", ErrTableExists, number)
+		}
+	}
+
+	table.Number = number
+	table.Seats = req.Seats
+	table.IsActive = req.IsActive == nil || *req.IsActive
+	return nil
+}
+
+// restaurantTable loads one of the restaurant's tables
+func (s *TableService) restaurantTable(ctx context.Context, restaurantID, tableID string) (*models.RestaurantTable, error) {
+	id, err := uuid.Parse(tableID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, tableID)
+	}
+	table, err := s.tableRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && table.RestaurantID.String() != restaurantID) {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, tableID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// scannedTable loads the table a QR token belongs to with its restaurant
+func (s *TableService) scannedTable(ctx context.Context, token string) (*models.RestaurantTable, *models.Restaurant, error) {
+	table, err := s.tableRepo.GetByQRToken(ctx, token)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrTableNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get table: %v", err)
+	}
+	restaurant, err := s.restaurantRepo.GetByID(ctx, table.RestaurantID)
+	if err != nil {
+		return nil, nil, ErrTableNotFound
+	}
+	return table, restaurant, nil
+}
+
+func (s *TableService) openTab(ctx context.Context, tableID uuid.UUID) (*models.TableTab, error) {
+	tab, err := s.tabRepo.GetOpenByTableID(ctx, tableID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTabNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tab: %v", err)
+	}
+	return tab, nil
+}
+
+func (s *TableService) tableResponse(table *models.RestaurantTable) *TableResponse {
+	response := &TableResponse{RestaurantTable: *table}
+	if s.tableURL != "" {
+		if link, err := url.Parse(s.tableURL); err == nil {
+			query := link.Query()
+			query.Set("table", table.QRToken)
+			link.RawQuery = query.Encode()
+			response.QRURL = link.String()
+		}
+	}
+	return response
+}
+
+func newTabResponse(tab *models.TableTab) *TabResponse {
+	response := &TabResponse{
+		ID:            tab.ID,
+		TableID:       tab.TableID,
+		TableNumber:   tab.TableNumber,
+		Status:        tab.Status,
+		Rounds:        make([]TabRound, 0, len(tab.Orders)),
+		TotalAmount:   tabTotal(tab),
+		PaymentMethod: tab.PaymentMethod,
+		OpenedAt:      tab.OpenedAt,
+		ClosedAt:      tab.ClosedAt,
+	}
+	for i, order := range tab.Orders {
+		response.Rounds = append(response.Rounds, TabRound{
+			Round:       i + 1,
+			OrderID:     order.ID,
+			OrderStatus: order.OrderStatus,
+			TotalAmount: order.TotalAmount,
+			Items:       models.DecodeOrderLineItems(order.LineItems),
+			CreatedAt:   order.CreatedAt,
+		})
+		response.Currency = order.Currency
+	}
+	return response
+}
+
+// tabTotal adds up the rounds that were not cancelled
+func tabTotal(tab *models.TableTab) models.Money {
+	var total models.Money
+	for _, order := range tab.Orders {
+		if order.OrderStatus != "cancelled" {
+			total += order.TotalAmount
+		}
+	}
+	return total
+}
+
+func generateTableToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate table token: %v", err)
+	}
+	return hex.EncodeToString(token), nil
+}
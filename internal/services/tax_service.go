@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// GST on platform fees is charged at the services rate regardless of the menu rate
+const platformFeeGSTRate = 18.0
+
+// TaxDefaults apply to restaurants without their own tax configuration
+type TaxDefaults struct {
+	GSTRate      float64
+	PackagingFee models.Money
+	PlatformFee  models.Money
+}
+
+type TaxService struct {
+	taxConfigRepo repositories.TaxConfigRepository
+	defaults      TaxDefaults
+}
+
+func NewTaxService(taxConfigRepo repositories.TaxConfigRepository, defaults TaxDefaults) *TaxService {
+	return &TaxService{
+		taxConfigRepo: taxConfigRepo,
+		defaults:      defaults,
+	}
+}
+
+type UpdateTaxConfigRequest struct {
+	GSTRate          *float64           `json:"gst_rate" binding:"omitempty,gte=0,lte=28"`
+	CategoryGSTRates map[string]float64 `json:"category_gst_rates"`
+	PricesIncludeTax *bool              `json:"prices_include_tax"`
+	PackagingFee     *models.Money      `json:"packaging_fee" binding:"omitempty,gte=0"`
+	PlatformFee      *models.Money      `json:"platform_fee" binding:"omitempty,gte=0"`
+}
+
+// TaxableItem is a cart line to be taxed
+type TaxableItem struct {
+	ProductID  string
+	CategoryID string
+	Amount     models.Money // line total at menu price
+}
+
+type ItemTax struct {
+	ProductID    string       `json:"product_id"`
+	GSTRate      float64      `json:"gst_rate"`
+	TaxableValue models.Money `json:"taxable_value"`
+	CGST         models.Money `json:"cgst"`
+	SGST         models.Money `json:"sgst"`
+}
+
+// TaxLine groups tax by component and rate as printed on a GST invoice
+type TaxLine struct {
+	Component    string       `json:"component"` // items, packaging, platform_fee
+	GSTRate      float64      `json:"gst_rate"`
+	TaxableValue models.Money `json:"taxable_value"`
+	CGST         models.Money `json:"cgst"`
+	SGST         models.Money `json:"sgst"`
+}
+
+type TaxBreakdown struct {
+	PricesIncludeTax bool         `json:"prices_include_tax"`
+	ItemsTaxable     models.Money `json:"items_taxable_value"`
+	PackagingFee     models.Money `json:"packaging_fee"`
+	PlatformFee      models.Money `json:"platform_fee"`
+	SmallOrderFee    models.Money `json:"small_order_fee,omitempty"`
+	LateNightFee     models.Money `json:"late_night_fee,omitempty"`
+	CGST             models.Money `json:"cgst"`
+	SGST             models.Money `json:"sgst"`
+	TotalTax         models.Money `json:"total_tax"`
+	ChargedTax       models.Money `json:"charged_tax"` // tax added on top of menu prices and fees
+	Lines            []TaxLine    `json:"lines"`
+	Items            []ItemTax    `json:"items"`
+}
+
+// GetConfig returns the restaurant's tax configuration, or the platform defaults
+func (s *TaxService) GetConfig(ctx context.Context, restaurantID uuid.UUID) *models.RestaurantTaxConfig {
+	config, err := s.taxConfigRepo.GetByRestaurantID(ctx, restaurantID)
+	if err == nil {
+		return config
+	}
+
+	return &models.RestaurantTaxConfig{
+		RestaurantID: restaurantID,
+		GSTRate:      s.defaults.GSTRate,
+		PackagingFee: s.defaults.PackagingFee,
+		PlatformFee:  s.defaults.PlatformFee,
+	}
+}
+
+// UpdateConfig creates or updates a restaurant's tax configuration
+func (s *TaxService) UpdateConfig(ctx context.Context, restaurantID string, req *UpdateTaxConfigRequest) (*models.RestaurantTaxConfig, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, errors.New("invalid restaurant ID")
+	}
+
+	config := s.GetConfig(ctx, restaurantUUID)
+
+	if req.GSTRate != nil {
+		config.GSTRate = *req.GSTRate
+	}
+	if req.CategoryGSTRates != nil {
+		rates := make(models.JSONB, len(req.CategoryGSTRates))
+		for categoryID, rate := range req.CategoryGSTRates {
+			if rate < 0 || rate > 28 {
+				return nil, fmt.Errorf("invalid GST rate %.2f for category %s", rate, categoryID)
+			}
+			rates[categoryID] = rate
+		}
+		config.CategoryGSTRates = rates
+	}
+	if req.PricesIncludeTax != nil {
+		config.PricesIncludeTax = *req.PricesIncludeTax
+	}
+	if req.PackagingFee != nil {
+		config.PackagingFee = *req.PackagingFee
+	}
+	if req.PlatformFee != nil {
+		config.PlatformFee = *req.PlatformFee
+	}
+
+	if config.ID == uuid.Nil {
+		err = s.taxConfigRepo.Create(ctx, config)
+	} else {
+		err = s.taxConfigRepo.Update(ctx, config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save tax configuration: %v", err)
+	}
+
+	return config, nil
+}
+
+// Calculate computes GST on items, packaging and platform fees for a restaurant.
+// Packaging is taxed at the restaurant's rate, platform fees at the services rate. Fees from
+// pricing rules are platform fees too; a platform fee rule replaces the configured one.
+func (s *TaxService) Calculate(ctx context.Context, restaurantID uuid.UUID, items []TaxableItem, pricing *PricingResult) *TaxBreakdown {
+	config := s.GetConfig(ctx, restaurantID)
+
+	breakdown := &TaxBreakdown{
+		PricesIncludeTax: config.PricesIncludeTax,
+		PackagingFee:     config.PackagingFee,
+		PlatformFee:      config.PlatformFee,
+		Lines:            []TaxLine{},
+		Items:            []ItemTax{},
+	}
+	if pricing != nil {
+		if pricing.PlatformFee != nil {
+			breakdown.PlatformFee = *pricing.PlatformFee
+		}
+		breakdown.SmallOrderFee = pricing.SmallOrderFee
+		breakdown.LateNightFee = pricing.LateNightFee
+	}
+
+	itemLines := make(map[float64]*TaxLine)
+	for _, item := range items {
+		rate := categoryGSTRate(config, item.CategoryID)
+		taxable, tax := splitTax(item.Amount, rate, config.PricesIncludeTax)
+		halves := tax.Split(2)
+
+		itemTax := ItemTax{
+			ProductID:    item.ProductID,
+			GSTRate:      rate,
+			TaxableValue: taxable,
+			CGST:         halves[0],
+			SGST:         halves[1],
+		}
+		breakdown.Items = append(breakdown.Items, itemTax)
+
+		line, ok := itemLines[rate]
+		if !ok {
+			line = &TaxLine{Component: "items", GSTRate: rate}
+			itemLines[rate] = line
+		}
+		line.TaxableValue += itemTax.TaxableValue
+		line.CGST += itemTax.CGST
+		line.SGST += itemTax.SGST
+		breakdown.ItemsTaxable += itemTax.TaxableValue
+	}
+
+	rates := make([]float64, 0, len(itemLines))
+	for rate := range itemLines {
+		rates = append(rates, rate)
+	}
+	sort.Float64s(rates)
+	for _, rate := range rates {
+		breakdown.Lines = append(breakdown.Lines, *itemLines[rate])
+	}
+
+	if config.PackagingFee > 0 {
+		breakdown.Lines = append(breakdown.Lines, feeTaxLine("packaging", config.PackagingFee, config.GSTRate, config.PricesIncludeTax))
+	}
+	if breakdown.PlatformFee > 0 {
+		breakdown.Lines = append(breakdown.Lines, feeTaxLine("platform_fee", breakdown.PlatformFee, platformFeeGSTRate, config.PricesIncludeTax))
+	}
+	if breakdown.SmallOrderFee > 0 {
+		breakdown.Lines = append(breakdown.Lines, feeTaxLine("small_order_fee", breakdown.SmallOrderFee, platformFeeGSTRate, config.PricesIncludeTax))
+	}
+	if breakdown.LateNightFee > 0 {
+		breakdown.Lines = append(breakdown.Lines, feeTaxLine("late_night_fee", breakdown.LateNightFee, platformFeeGSTRate, config.PricesIncludeTax))
+	}
+
+	for _, line := range breakdown.Lines {
+		breakdown.CGST += line.CGST
+		breakdown.SGST += line.SGST
+	}
+	breakdown.TotalTax = breakdown.CGST + breakdown.SGST
+	if !config.PricesIncludeTax {
+		breakdown.ChargedTax = breakdown.TotalTax
+	}
+
+	return breakdown
+}
+
+// ToJSONB converts the breakdown for storage on the order
+func (b *TaxBreakdown) ToJSONB() models.JSONB {
+	lines := make([]interface{}, 0, len(b.Lines))
+	for _, line := range b.Lines {
+		lines = append(lines, map[string]interface{}{
+			"component":     line.Component,
+			"gst_rate":      line.GSTRate,
+			"taxable_value": line.TaxableValue,
+			"cgst":          line.CGST,
+			"sgst":          line.SGST,
+		})
+	}
+
+	return models.JSONB{
+		"prices_include_tax":  b.PricesIncludeTax,
+		"items_taxable_value": b.ItemsTaxable,
+		"packaging_fee":       b.PackagingFee,
+		"platform_fee":        b.PlatformFee,
+		"small_order_fee":     b.SmallOrderFee,
+		"late_night_fee":      b.LateNightFee,
+		"cgst":                b.CGST,
+		"sgst":                b.SGST,
+		"total_tax":           b.TotalTax,
+		"charged_tax":         b.ChargedTax,
+		"lines":               lines,
+	}
+}
+
+func categoryGSTRate(config *models.RestaurantTaxConfig, categoryID string) float64 {
+	if categoryID != "" {
+		if rate, ok := config.CategoryGSTRates[categoryID].(float64); ok {
+			return rate
+		}
+	}
+	return config.GSTRate
+}
+
+func feeTaxLine(component string, fee models.Money, rate float64, inclusive bool) TaxLine {
+	taxable, tax := splitTax(fee, rate, inclusive)
+	halves := tax.Split(2)
+	return TaxLine{
+		Component:    component,
+		GSTRate:      rate,
+		TaxableValue: taxable,
+		CGST:         halves[0],
+		SGST:         halves[1],
+	}
+}
+
+// splitTax returns the taxable value and GST for an amount. Inclusive amounts
+// already contain the tax; exclusive amounts have it added on top. CGST and SGST are the
+// tax's halves, the first taking the odd paisa.
+func splitTax(amount models.Money, rate float64, inclusive bool) (taxable, tax models.Money) {
+	if inclusive {
+		taxable = amount.Scale(1 / (1 + rate/100))
+		return taxable, amount - taxable
+	}
+	return amount, amount.Percent(rate)
+}
+
+// roundMoney rounds report figures still computed in float64, such as averages, to the paisa
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
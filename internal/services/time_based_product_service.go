@@ -46,11 +46,13 @@ type GetProductsByTimeRequest struct {
 	Availability *bool        `json:"availability,omitempty"`
 	Page         int          `json:"page"`
 	Limit        int          `json:"limit"`
+
+	Dietary models.DietaryFilter `json:"-"`
 }
 
 type PriceFilter struct {
-	Min *float64 `json:"min"`
-	Max *float64 `json:"max"`
+	Min *models.Money `json:"min"`
+	Max *models.Money `json:"max"`
 }
 
 type ProductTimeInfo struct {
@@ -111,7 +113,7 @@ func (s *TimeBasedProductService) GetProductsByTimeAdvanced(ctx context.Context,
 	}
 
 	// Get all restaurant products for filtering
-	allProducts, err := s.productRepo.GetByRestaurantID(ctx, req.RestaurantID, 1000, 0) // Get a large number
+	allProducts, err := s.productRepo.GetByRestaurantID(ctx, req.RestaurantID, req.Dietary, 1000, 0) // Get a large number
 	if err != nil {
 		return nil, fmt.Errorf("failed to get restaurant products: %v", err)
 	}
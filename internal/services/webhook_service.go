@@ -0,0 +1,640 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang-food-backend/internal/models"
+	"golang-food-backend/internal/repositories"
+	"golang-food-backend/pkg/apperr"
+	"golang-food-backend/pkg/messaging"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Webhook event types restaurants can subscribe to
+const (
+	WebhookOrderCreated    = "order.created"
+	WebhookOrderCancelled  = "order.cancelled"
+	WebhookPaymentCaptured = "payment.captured"
+	WebhookTicketPrint     = "ticket.print" // kitchen tickets for printer agents
+	WebhookPing            = "ping"         // sent by the test-ping endpoint only
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed" // retries exhausted, or the subscription was removed or disabled
+)
+
+const (
+	webhookRelayInterval = 5 * time.Second
+	webhookBatchSize     = 50
+	webhookLease         = 2 * time.Minute     // how long a claimed delivery is hidden from other relays
+	webhookRetention     = 30 * 24 * time.Hour // delivery logs are kept this long
+	webhookResponseLimit = 1024                // bytes of the response body kept in the log
+)
+
+var (
+	ErrWebhookInvalid  = apperr.Validation("webhook_invalid", "invalid webhook subscription")
+	ErrWebhookNotFound = apperr.NotFound("webhook_not_found", "webhook subscription not found")
+)
+
+var webhookEventTypes = map[string]bool{
+	WebhookOrderCreated:    true,
+	WebhookOrderCancelled:  true,
+	WebhookPaymentCaptured: true,
+	WebhookTicketPrint:     true,
+}
+
+// WebhookService lets restaurants register callback URLs for their POS integrations. Order
+// and payment events from Kafka are queued as deliveries, signed with the subscription's
+// secret and retried with exponential backoff until they succeed or their attempts run out.
+type WebhookService struct {
+	webhookRepo    repositories.WebhookRepository
+	restaurantRepo repositories.RestaurantRepository
+	httpClient     *http.Client
+	retry          messaging.RetryPolicy
+	stopChan       chan struct{}
+}
+
+func NewWebhookService(webhookRepo repositories.WebhookRepository, restaurantRepo repositories.RestaurantRepository, timeout time.Duration, retry messaging.RetryPolicy) *WebhookService {
+	return &WebhookService{
+		webhookRepo:    webhookRepo,
+		restaurantRepo: restaurantRepo,
+		httpClient: &http.Client{
+			Timeout: timeout,
+			// Addresses are checked as they are dialled, so a public hostname that resolves into
+			// our network, now or after the subscription was made, is never reached
+			Transport: &http.Transport{
+				DialContext: (&net.Dialer{
+					Timeout:   timeout,
+					KeepAlive: 30 * time.Second,
+					Control:   dialPublicOnly,
+				}).DialContext,
+				TLSHandshakeTimeout: 10 * time.Second,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			// Redirects are not followed so a subscription cannot be bounced to another host
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		retry:    retry,
+		stopChan: make(chan struct{}),
+	}
+}
+
+type WebhookSubscriptionRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	EventTypes  []string `json:"event_types" binding:"required,min=1"` // order.created, order.cancelled, payment.captured, ticket.print
+	Description string   `json:"description"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+// WebhookSecretResponse carries a subscription's signing secret, which is only shown when the
+// subscription is created or its secret rotated
+type WebhookSecretResponse struct {
+	models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+	Pagination PaginationInfo           `json:"pagination"`
+}
+
+// WebhookEvent is the JSON body POSTed to subscribers. ID is the same on every retry of an
+// event so receivers can drop duplicates.
+type WebhookEvent struct {
+	ID           string      `json:"id"`
+	Type         string      `json:"type"`
+	RestaurantID string      `json:"restaurant_id"`
+	CreatedAt    time.Time   `json:"created_at"`
+	Data         interface{} `json:"data"`
+}
+
+// Subscribe queues webhook deliveries for order and payment events
+func (s *WebhookService) Subscribe(consumer *messaging.KafkaConsumer, groupID string) {
+	consumer.Subscribe("order_events", groupID, s.HandleOrderEvent, messaging.SubscribeOptions{})
+	consumer.Subscribe("payment_events", groupID, s.HandlePaymentEvent, messaging.SubscribeOptions{})
+}
+
+// HandleOrderEvent is the Kafka handler for the order_events topic
+func (s *WebhookService) HandleOrderEvent(payload []byte) error {
+	var event messaging.OrderEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("invalid order event: %v", err)
+	}
+
+	ctx := context.Background()
+	switch event.Type {
+	case messaging.EventOrderCreated:
+		var created messaging.OrderCreated
+		envelope, err := messaging.Events.Decode(payload, &created)
+		if err != nil {
+			return err
+		}
+		_, err = s.enqueue(ctx, created.RestaurantID, WebhookOrderCreated, envelope.EventID, created)
+		return err
+	case messaging.EventOrderCancelled:
+		var cancelled messaging.OrderCancelled
+		envelope, err := messaging.Events.Decode(payload, &cancelled)
+		if err != nil {
+			return err
+		}
+		_, err = s.enqueue(ctx, cancelled.RestaurantID, WebhookOrderCancelled, envelope.EventID, cancelled)
+		return err
+	}
+	return nil
+}
+
+// HandlePaymentEvent is the Kafka handler for the payment_events topic
+func (s *WebhookService) HandlePaymentEvent(payload []byte) error {
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("invalid payment event: %v", err)
+	}
+	if event.Type != messaging.EventPaymentCaptured {
+		return nil
+	}
+
+	var captured messaging.PaymentCaptured
+	envelope, err := messaging.Events.Decode(payload, &captured)
+	if err != nil {
+		return err
+	}
+	_, err = s.enqueue(context.Background(), envelope.Tenant, WebhookPaymentCaptured, envelope.EventID, captured)
+	return err
+}
+
+// QueuePrintJob sends a kitchen ticket to the restaurant's printer agents, its subscriptions to
+// ticket.print, and returns how many agents it was queued for. A job ID seen again is not
+// queued twice.
+func (s *WebhookService) QueuePrintJob(ctx context.Context, restaurantID, jobID string, ticket interface{}) (int, error) {
+	return s.enqueue(ctx, restaurantID, WebhookTicketPrint, jobID, ticket)
+}
+
+// enqueue records a delivery of the event for each active subscription of the restaurant to
+// its type and returns how many it recorded. An event seen again, e.g. after a consumer
+// restart, is not queued twice.
+func (s *WebhookService) enqueue(ctx context.Context, restaurantID, eventType, eventID string, data interface{}) (int, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return 0, nil // not a restaurant event; nothing to retry
+	}
+	subscriptions, err := s.webhookRepo.GetActiveSubscriptions(ctx, restaurantUUID, eventType)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load webhook subscriptions: %v", err)
+	}
+	if len(subscriptions) == 0 {
+		return 0, nil
+	}
+
+	payload, err := json.Marshal(WebhookEvent{
+		ID:           eventID,
+		Type:         eventType,
+		RestaurantID: restaurantID,
+		CreatedAt:    time.Now().UTC(),
+		Data:         data,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode %s webhook: %v", eventType, err)
+	}
+
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventType:      eventType,
+			EventID:        eventID,
+			Payload:        string(payload),
+			Status:         WebhookDeliveryPending,
+			NextAttemptAt:  now,
+		})
+	}
+	if err := s.webhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		return 0, fmt.Errorf("failed to queue %s webhooks: %v", eventType, err)
+	}
+	return len(deliveries), nil
+}
+
+// Start runs the delivery relay until Stop is called
+func (s *WebhookService) Start() {
+	go s.run()
+	log.Println("🪝 Webhook relay started")
+}
+
+func (s *WebhookService) Stop() {
+	close(s.stopChan)
+}
+
+func (s *WebhookService) run() {
+	relay := time.NewTicker(webhookRelayInterval)
+	defer relay.Stop()
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-relay.C:
+			if err := s.Relay(context.Background()); err != nil {
+				log.Printf("❌ Error sending webhooks: %v", err)
+			}
+		case <-cleanup.C:
+			deleted, err := s.webhookRepo.DeleteDeliveriesBefore(context.Background(), time.Now().Add(-webhookRetention))
+			if err != nil {
+				log.Printf("❌ Error cleaning up webhook deliveries: %v", err)
+			} else if deleted > 0 {
+				log.Printf("🪝 Removed %d webhook delivery logs", deleted)
+			}
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// Relay sends the deliveries that are due
+func (s *WebhookService) Relay(ctx context.Context) error {
+	deliveries, err := s.webhookRepo.ClaimDueDeliveries(ctx, time.Now(), webhookLease, webhookBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to claim webhook deliveries: %v", err)
+	}
+
+	subscriptions := make(map[uuid.UUID]*models.WebhookSubscription)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			subscription, _ = s.webhookRepo.GetSubscription(ctx, delivery.SubscriptionID)
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+
+		if subscription == nil || !subscription.IsActive {
+			delivery.Status = WebhookDeliveryFailed
+			delivery.LastError = "subscription removed or disabled"
+		} else if !s.send(ctx, subscription, delivery) {
+			if delivery.Attempts >= s.retry.MaxAttempts {
+				delivery.Status = WebhookDeliveryFailed
+				log.Printf("🪝 Webhook %s to %s failed after %d attempts: %s", delivery.EventType, subscription.URL, delivery.Attempts, delivery.LastError)
+			} else {
+				delivery.NextAttemptAt = time.Now().Add(s.retry.Backoff(delivery.Attempts))
+			}
+		}
+		if err := s.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+			log.Printf("❌ Failed to record webhook delivery %s: %v", delivery.ID, err)
+		}
+	}
+	return nil
+}
+
+// send makes one attempt at a delivery and records the response on it. The delivery is marked
+// delivered on a 2xx response and otherwise left pending.
+func (s *WebhookService) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) bool {
+	delivery.Attempts++
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		delivery.LastError = err.Error()
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "food-delivery-webhooks/1.0")
+	req.Header.Set("X-Webhook-ID", delivery.EventID)
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Signature", "t="+timestamp+",v1="+signWebhook(subscription.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		delivery.ResponseStatus = 0
+		delivery.ResponseBody = ""
+		delivery.LastError = err.Error()
+		return false
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	delivery.ResponseStatus = resp.StatusCode
+	delivery.ResponseBody = string(bytes.ToValidUTF8(body, nil))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		delivery.LastError = fmt.Sprintf("endpoint responded %d", resp.StatusCode)
+		return false
+	}
+
+	now := time.Now()
+	delivery.Status = WebhookDeliveryDelivered
+	delivery.DeliveredAt = &now
+	delivery.LastError = ""
+	return true
+}
+
+// signWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>". Receivers recompute it with
+// their secret and should reject timestamps older than a few minutes to stop replays.
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %v", err)
+	}
+	return "whsec_" + hex.EncodeToString(raw), nil
+}
+
+// validateWebhookURL requires an HTTPS URL that does not point into our own network
+func validateWebhookURL(raw string) error {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be absolute", ErrWebhookInvalid)
+	}
+	if parsed.Scheme != "https" {
+		return fmt.Errorf("%w: url must use https", ErrWebhookInvalid)
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") {
+		return fmt.Errorf("%w: url must be publicly reachable", ErrWebhookInvalid)
+	}
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return fmt.Errorf("%w: url must be publicly reachable", ErrWebhookInvalid)
+	}
+	return nil
+}
+
+// isPublicIP reports whether an address is outside loopback, private, link-local and other
+// special-purpose ranges
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// dialPublicOnly refuses connections to addresses that are not public. It runs after the
+// hostname is resolved, so DNS cannot point a webhook at an internal service.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("webhook endpoint resolves to non-public address %s", host)
+	}
+	return nil
+}
+
+func normalizeWebhookEventTypes(eventTypes []string) (models.StringArray, error) {
+	seen := make(map[string]bool, len(eventTypes))
+	normalized := models.StringArray{}
+	for _, eventType := range eventTypes {
+		eventType = strings.ToLower(strings.TrimSpace(eventType))
+		if !webhookEventTypes[eventType] {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrWebhookInvalid, eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			normalized = append(normalized, eventType)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required", ErrWebhookInvalid)
+	}
+	return normalized, nil
+}
+
+// getSubscription loads a subscription of the restaurant
+func (s *WebhookService) getSubscription(ctx context.Context, restaurantID, subscriptionID string) (*models.WebhookSubscription, error) {
+	id, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookNotFound, subscriptionID)
+	}
+	subscription, err := s.webhookRepo.GetSubscription(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && subscription.RestaurantID.String() != restaurantID) {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookNotFound, subscriptionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// ListSubscriptions returns the restaurant's webhook subscriptions
+func (s *WebhookService) ListSubscriptions(ctx context.Context, restaurantID string) ([]models.WebhookSubscription, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrWebhookInvalid)
+	}
+	subscriptions, err := s.webhookRepo.ListSubscriptions(ctx, restaurantUUID)
+	if err != nil {
+		return nil, err
+	}
+	if subscriptions == nil {
+		subscriptions = []models.WebhookSubscription{}
+	}
+	return subscriptions, nil
+}
+
+// CreateSubscription registers a callback URL for the restaurant and returns it with its
+// signing secret
+func (s *WebhookService) CreateSubscription(ctx context.Context, restaurantID, userID string, req *WebhookSubscriptionRequest) (*WebhookSecretResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid restaurant ID", ErrWebhookInvalid)
+	}
+	if _, err := s.restaurantRepo.GetByID(ctx, restaurantUUID); err != nil {
+		return nil, fmt.Errorf("%w: restaurant not found", ErrWebhookInvalid)
+	}
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	eventTypes, err := normalizeWebhookEventTypes(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := &models.WebhookSubscription{
+		RestaurantID: restaurantUUID,
+		URL:          strings.TrimSpace(req.URL),
+		EventTypes:   eventTypes,
+		Secret:       secret,
+		Description:  req.Description,
+		IsActive:     req.IsActive == nil || *req.IsActive,
+	}
+	if createdBy, err := uuid.Parse(userID); err == nil {
+		subscription.CreatedBy = &createdBy
+	}
+	if err := s.webhookRepo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %v", err)
+	}
+	// The column default would turn an explicit false back into true on insert
+	if !subscription.IsActive {
+		if err := s.webhookRepo.UpdateSubscription(ctx, subscription); err != nil {
+			return nil, fmt.Errorf("failed to create webhook subscription: %v", err)
+		}
+	}
+
+	return &WebhookSecretResponse{WebhookSubscription: *subscription, Secret: secret}, nil
+}
+
+// UpdateSubscription replaces a subscription's URL, event types and description, and pauses
+// or resumes it
+func (s *WebhookService) UpdateSubscription(ctx context.Context, restaurantID, subscriptionID string, req *WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	subscription, err := s.getSubscription(ctx, restaurantID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	eventTypes, err := normalizeWebhookEventTypes(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.URL = strings.TrimSpace(req.URL)
+	subscription.EventTypes = eventTypes
+	subscription.Description = req.Description
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+	if err := s.webhookRepo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to update webhook subscription: %v", err)
+	}
+	return subscription, nil
+}
+
+// DeleteSubscription removes a subscription and its delivery log
+func (s *WebhookService) DeleteSubscription(ctx context.Context, restaurantID, subscriptionID string) error {
+	subscription, err := s.getSubscription(ctx, restaurantID, subscriptionID)
+	if err != nil {
+		return err
+	}
+	return s.webhookRepo.DeleteSubscription(ctx, subscription.ID)
+}
+
+// RotateSecret replaces a subscription's signing secret. Deliveries still pending are signed
+// with the new secret.
+func (s *WebhookService) RotateSecret(ctx context.Context, restaurantID, subscriptionID string) (*WebhookSecretResponse, error) {
+	subscription, err := s.getSubscription(ctx, restaurantID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret = secret
+	if err := s.webhookRepo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to rotate webhook secret: %v", err)
+	}
+	return &WebhookSecretResponse{WebhookSubscription: *subscription, Secret: secret}, nil
+}
+
+// Ping sends a signed test event to a subscription right away, once, and returns the logged
+// delivery with the endpoint's response
+func (s *WebhookService) Ping(ctx context.Context, restaurantID, subscriptionID string) (*models.WebhookDelivery, error) {
+	subscription, err := s.getSubscription(ctx, restaurantID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	eventID := uuid.New().String()
+	payload, err := json.Marshal(WebhookEvent{
+		ID:           eventID,
+		Type:         WebhookPing,
+		RestaurantID: restaurantID,
+		CreatedAt:    time.Now().UTC(),
+		Data:         map[string]string{"subscription_id": subscription.ID.String()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ping: %v", err)
+	}
+
+	pings := []models.WebhookDelivery{{
+		SubscriptionID: subscription.ID,
+		EventType:      WebhookPing,
+		EventID:        eventID,
+		Payload:        string(payload),
+		Status:         WebhookDeliveryPending,
+		NextAttemptAt:  time.Now().Add(webhookLease), // sent here, not by the relay
+	}}
+	if err := s.webhookRepo.CreateDeliveries(ctx, pings); err != nil {
+		return nil, fmt.Errorf("failed to log ping: %v", err)
+	}
+	delivery := &pings[0]
+
+	s.send(ctx, subscription, delivery)
+	if delivery.Status != WebhookDeliveryDelivered {
+		delivery.Status = WebhookDeliveryFailed // pings are not retried
+	}
+	if err := s.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		log.Printf("❌ Failed to record webhook ping %s: %v", delivery.ID, err)
+	}
+	return delivery, nil
+}
+
+// ListDeliveries returns a subscription's delivery log, newest first
+func (s *WebhookService) ListDeliveries(ctx context.Context, restaurantID, subscriptionID, status string, page, limit int) (*WebhookDeliveriesResponse, error) {
+	subscription, err := s.getSubscription(ctx, restaurantID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case "", WebhookDeliveryPending, WebhookDeliveryDelivered, WebhookDeliveryFailed:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrWebhookInvalid, status)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	deliveries, total, err := s.webhookRepo.ListDeliveries(ctx, subscription.ID, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %v", err)
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+	return &WebhookDeliveriesResponse{
+		Deliveries: deliveries,
+		Pagination: PaginationInfo{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		},
+	}, nil
+}
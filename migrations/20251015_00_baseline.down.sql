@@ -0,0 +1,32 @@
+-- Drops every baseline table. This deletes all relational data.
+
+DROP TABLE IF EXISTS "order_settlements" CASCADE;
+DROP TABLE IF EXISTS "restaurant_payouts" CASCADE;
+DROP TABLE IF EXISTS "payout_batches" CASCADE;
+DROP TABLE IF EXISTS "restaurant_staffs" CASCADE;
+DROP TABLE IF EXISTS "outbox_events" CASCADE;
+DROP TABLE IF EXISTS "delivery_dispatches" CASCADE;
+DROP TABLE IF EXISTS "restaurant_documents" CASCADE;
+DROP TABLE IF EXISTS "coupon_redemptions" CASCADE;
+DROP TABLE IF EXISTS "sms_deliveries" CASCADE;
+DROP TABLE IF EXISTS "user_identities" CASCADE;
+DROP TABLE IF EXISTS "auth_sessions" CASCADE;
+DROP TABLE IF EXISTS "audit_logs" CASCADE;
+DROP TABLE IF EXISTS "admin_users" CASCADE;
+DROP TABLE IF EXISTS "restaurant_tax_configs" CASCADE;
+DROP TABLE IF EXISTS "rider_assignments" CASCADE;
+DROP TABLE IF EXISTS "riders" CASCADE;
+DROP TABLE IF EXISTS "maintenance_windows" CASCADE;
+DROP TABLE IF EXISTS "otps" CASCADE;
+DROP TABLE IF EXISTS "porter_deliveries" CASCADE;
+DROP TABLE IF EXISTS "refunds" CASCADE;
+DROP TABLE IF EXISTS "payments" CASCADE;
+DROP TABLE IF EXISTS "order_logs" CASCADE;
+DROP TABLE IF EXISTS "orders" CASCADE;
+DROP TABLE IF EXISTS "carts" CASCADE;
+DROP TABLE IF EXISTS "commission_to_restaurants" CASCADE;
+DROP TABLE IF EXISTS "restaurant_delivery_location_boundaries" CASCADE;
+DROP TABLE IF EXISTS "restaurant_delivery_partners" CASCADE;
+DROP TABLE IF EXISTS "delivery_partner_companies" CASCADE;
+DROP TABLE IF EXISTS "restaurants" CASCADE;
+DROP TABLE IF EXISTS "users" CASCADE;
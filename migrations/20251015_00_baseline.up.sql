@@ -0,0 +1,533 @@
+-- Baseline schema: every table AutoMigrate created before versioned migrations.
+-- Existing databases already have these tables, so each statement is a no-op there.
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "name" text NOT NULL,
+    "email" text NOT NULL,
+    "phone" text NOT NULL,
+    "avatar_url" text,
+    "password_hash" text NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "default_address_id" uuid,
+    "wallet_balance" decimal DEFAULT 0,
+    "is_verified" boolean DEFAULT false,
+    "status" text DEFAULT 'active',
+    "restaurant_id" uuid,
+    "role" text DEFAULT 'customer',
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "restaurants" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "name" text NOT NULL,
+    "description" text,
+    "logo" text,
+    "cuisine_types" jsonb,
+    "owner_id" uuid NOT NULL,
+    "gst_number" text,
+    "status" text DEFAULT 'active',
+    "is_open" boolean DEFAULT true,
+    "opening_hours" jsonb,
+    "auto_open_close" boolean DEFAULT true,
+    "time_zone" text DEFAULT 'Asia/Kolkata',
+    "last_status_update" timestamptz,
+    "preparation_time" bigint DEFAULT 15,
+    "created_at" timestamptz,
+    "pickup_location_id" uuid,
+    "franchise_parent_id" uuid,
+    "contact_number" text,
+    "is_brand" boolean DEFAULT false,
+    "onboarding_status" text DEFAULT 'approved',
+    "onboarding_note" text,
+    "submitted_at" timestamptz,
+    "approved_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_restaurants_owner" FOREIGN KEY ("owner_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_restaurants_franchise_parent_id" ON "restaurants" ("franchise_parent_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_restaurants_gst_number" ON "restaurants" ("gst_number");
+CREATE INDEX IF NOT EXISTS "idx_restaurants_onboarding_status" ON "restaurants" ("onboarding_status");
+CREATE INDEX IF NOT EXISTS "idx_restaurants_is_brand" ON "restaurants" ("is_brand");
+
+CREATE TABLE IF NOT EXISTS "delivery_partner_companies" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "name" text NOT NULL,
+    "provider_code" text,
+    "api_key" text NOT NULL,
+    "contact_info" jsonb,
+    "gst_number" text,
+    "created_at" timestamptz,
+    "status" text DEFAULT 'active',
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_delivery_partner_companies_gst_number" ON "delivery_partner_companies" ("gst_number");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_delivery_partner_companies_api_key" ON "delivery_partner_companies" ("api_key");
+CREATE INDEX IF NOT EXISTS "idx_delivery_partner_companies_provider_code" ON "delivery_partner_companies" ("provider_code");
+
+CREATE TABLE IF NOT EXISTS "restaurant_delivery_partners" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "restaurant_id" uuid NOT NULL,
+    "delivery_partner_company_id" uuid NOT NULL,
+    "priority" bigint DEFAULT 0,
+    "is_active" boolean DEFAULT true,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_restaurant_delivery_partners_restaurant" FOREIGN KEY ("restaurant_id") REFERENCES "restaurants"("id"),
+    CONSTRAINT "fk_restaurant_delivery_partners_delivery_partner_company" FOREIGN KEY ("delivery_partner_company_id") REFERENCES "delivery_partner_companies"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "restaurant_delivery_location_boundaries" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "restaurant_id" uuid NOT NULL,
+    "geo_polygon" jsonb,
+    "delivery_radius_km" decimal,
+    "min_order_value" decimal,
+    "delivery_fee" decimal,
+    "free_delivery_above" decimal,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_restaurant_delivery_location_boundaries_restaurant" FOREIGN KEY ("restaurant_id") REFERENCES "restaurants"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "commission_to_restaurants" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "restaurant_id" uuid NOT NULL,
+    "commission_type" text NOT NULL,
+    "commission_value" decimal NOT NULL,
+    "effective_from" timestamptz NOT NULL DEFAULT '1970-01-01 00:00:00+00',
+    "effective_to" timestamptz,
+    "note" text,
+    "created_by" uuid,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_commission_to_restaurants_restaurant" FOREIGN KEY ("restaurant_id") REFERENCES "restaurants"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_commission_effective_from" ON "commission_to_restaurants" ("restaurant_id","effective_from");
+
+CREATE TABLE IF NOT EXISTS "carts" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL,
+    "items" jsonb,
+    "restaurant_id" uuid NOT NULL,
+    "coupon_id" uuid,
+    "total_amount" decimal,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "status" text DEFAULT 'active',
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_carts_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_carts_restaurant" FOREIGN KEY ("restaurant_id") REFERENCES "restaurants"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "orders" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL,
+    "restaurant_id" uuid NOT NULL,
+    "cart_id" uuid NOT NULL,
+    "order_status" text DEFAULT 'pending',
+    "scheduled_for" timestamptz,
+    "delivery_partner_id" uuid,
+    "payment_id" uuid,
+    "address_id" uuid,
+    "order_logs" jsonb,
+    "total_amount" decimal,
+    "created_at" timestamptz,
+    "discount_details" jsonb,
+    "pickup_full_address_with_lat_long" jsonb,
+    "delivery_full_address_with_lat_long" jsonb,
+    "customer_name" text,
+    "customer_contact" text,
+    "active_porter_delivery_id" uuid,
+    "tax_details" jsonb,
+    "delivery_fee" decimal,
+    "delivery_fee_details" jsonb,
+    "line_items" jsonb,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_orders_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_orders_restaurant" FOREIGN KEY ("restaurant_id") REFERENCES "restaurants"("id"),
+    CONSTRAINT "fk_orders_cart" FOREIGN KEY ("cart_id") REFERENCES "carts"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_orders_scheduled_for" ON "orders" ("scheduled_for");
+
+CREATE TABLE IF NOT EXISTS "order_logs" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "order_id" uuid NOT NULL,
+    "status" text NOT NULL,
+    "note" text,
+    "timestamp" timestamptz DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_order_logs_order" FOREIGN KEY ("order_id") REFERENCES "orders"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "payments" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "order_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "amount" decimal NOT NULL,
+    "method" text NOT NULL,
+    "status" text DEFAULT 'pending',
+    "transaction_id" text,
+    "created_at" timestamptz,
+    "metadata" jsonb,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_payments_order" FOREIGN KEY ("order_id") REFERENCES "orders"("id"),
+    CONSTRAINT "fk_payments_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "refunds" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "order_id" uuid NOT NULL,
+    "payment_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "amount" decimal NOT NULL,
+    "reason" text,
+    "status" text DEFAULT 'pending',
+    "admin_comment" text,
+    "processed_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_refunds_order" FOREIGN KEY ("order_id") REFERENCES "orders"("id"),
+    CONSTRAINT "fk_refunds_payment" FOREIGN KEY ("payment_id") REFERENCES "payments"("id"),
+    CONSTRAINT "fk_refunds_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "porter_deliveries" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "order_id" uuid NOT NULL,
+    "porter_order_id" text NOT NULL,
+    "status" text DEFAULT 'created',
+    "partner_name" text,
+    "partner_phone_number" text,
+    "partner_picture" text,
+    "vehicle_type" text,
+    "vehicle_number" text,
+    "tracking_url" text,
+    "estimated_delivery_time" timestamptz,
+    "actual_delivery_time" timestamptz,
+    "pickup_time" timestamptz,
+    "delivery_fee" decimal,
+    "distance" decimal,
+    "is_active" boolean DEFAULT true,
+    "reconciled_at" timestamptz,
+    "needs_attention" boolean DEFAULT false,
+    "attention_reason" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "porter_response" jsonb,
+    "status_history" jsonb,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_orders_porter_deliveries" FOREIGN KEY ("order_id") REFERENCES "orders"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_porter_deliveries_porter_order_id" ON "porter_deliveries" ("porter_order_id");
+
+CREATE TABLE IF NOT EXISTS "otps" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "phone" text NOT NULL,
+    "restaurant_id" uuid,
+    "otp_code" text NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "is_used" boolean DEFAULT false,
+    "attempt_count" bigint DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "maintenance_windows" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "starts_at" timestamptz NOT NULL,
+    "ends_at" timestamptz NOT NULL,
+    "message" text,
+    "created_by" uuid NOT NULL,
+    "cancelled_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_maintenance_windows_ends_at" ON "maintenance_windows" ("ends_at");
+CREATE INDEX IF NOT EXISTS "idx_maintenance_windows_starts_at" ON "maintenance_windows" ("starts_at");
+
+CREATE TABLE IF NOT EXISTS "riders" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL,
+    "restaurant_id" uuid NOT NULL,
+    "name" text NOT NULL,
+    "phone" text NOT NULL,
+    "vehicle_type" text,
+    "vehicle_number" text,
+    "status" text DEFAULT 'offline',
+    "is_active" boolean DEFAULT true,
+    "last_latitude" decimal,
+    "last_longitude" decimal,
+    "last_seen_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_riders_restaurant_id" ON "riders" ("restaurant_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_riders_user_id" ON "riders" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_riders_status" ON "riders" ("status");
+
+CREATE TABLE IF NOT EXISTS "rider_assignments" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "order_id" uuid NOT NULL,
+    "rider_id" uuid NOT NULL,
+    "restaurant_id" uuid NOT NULL,
+    "status" text DEFAULT 'assigned',
+    "handover_otp" text,
+    "assigned_at" timestamptz,
+    "accepted_at" timestamptz,
+    "picked_up_at" timestamptz,
+    "delivered_at" timestamptz,
+    "cancelled_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_rider_assignments_rider" FOREIGN KEY ("rider_id") REFERENCES "riders"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_rider_assignments_status" ON "rider_assignments" ("status");
+CREATE INDEX IF NOT EXISTS "idx_rider_assignments_restaurant_id" ON "rider_assignments" ("restaurant_id");
+CREATE INDEX IF NOT EXISTS "idx_rider_assignments_rider_id" ON "rider_assignments" ("rider_id");
+CREATE INDEX IF NOT EXISTS "idx_rider_assignments_order_id" ON "rider_assignments" ("order_id");
+
+CREATE TABLE IF NOT EXISTS "restaurant_tax_configs" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "restaurant_id" uuid NOT NULL,
+    "gst_rate" decimal NOT NULL,
+    "category_gst_rates" jsonb,
+    "prices_include_tax" boolean DEFAULT false,
+    "packaging_fee" decimal,
+    "platform_fee" decimal,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_restaurant_tax_configs_restaurant_id" ON "restaurant_tax_configs" ("restaurant_id");
+
+CREATE TABLE IF NOT EXISTS "admin_users" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "name" text NOT NULL,
+    "email" text NOT NULL,
+    "role" text NOT NULL,
+    "permissions" jsonb,
+    "password_hash" text NOT NULL,
+    "totp_secret" text,
+    "totp_enabled" boolean DEFAULT false,
+    "last_login_at" timestamptz,
+    "created_by" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "is_active" boolean DEFAULT true,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_admin_users_email" ON "admin_users" ("email");
+
+CREATE TABLE IF NOT EXISTS "audit_logs" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "entity_type" text NOT NULL,
+    "entity_id" text NOT NULL,
+    "action" text NOT NULL,
+    "performed_by" uuid,
+    "timestamp" timestamptz DEFAULT now(),
+    "metadata" jsonb,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "auth_sessions" (
+    "id" uuid,
+    "user_id" uuid NOT NULL,
+    "refresh_token_hash" text NOT NULL,
+    "device_id" text,
+    "platform" text,
+    "push_token" text,
+    "ip_address" text,
+    "user_agent" text,
+    "expires_at" timestamptz NOT NULL,
+    "last_refreshed_at" timestamptz,
+    "revoked_at" timestamptz,
+    "revoked_reason" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_auth_sessions_revoked_at" ON "auth_sessions" ("revoked_at");
+CREATE INDEX IF NOT EXISTS "idx_auth_sessions_device_id" ON "auth_sessions" ("device_id");
+CREATE INDEX IF NOT EXISTS "idx_auth_sessions_user_id" ON "auth_sessions" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "user_identities" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL,
+    "provider" text NOT NULL,
+    "subject" text NOT NULL,
+    "restaurant_id" uuid,
+    "email" text,
+    "last_login_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_identity_subject" ON "user_identities" ("provider","subject","restaurant_id");
+CREATE INDEX IF NOT EXISTS "idx_user_identities_user_id" ON "user_identities" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "sms_deliveries" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "provider" text NOT NULL,
+    "provider_message_id" text,
+    "phone" text NOT NULL,
+    "status" text NOT NULL DEFAULT 'sent',
+    "error_code" text,
+    "last_report" jsonb,
+    "sent_at" timestamptz NOT NULL,
+    "delivered_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_sms_deliveries_phone" ON "sms_deliveries" ("phone");
+CREATE INDEX IF NOT EXISTS "idx_sms_delivery_message" ON "sms_deliveries" ("provider","provider_message_id");
+
+CREATE TABLE IF NOT EXISTS "coupon_redemptions" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "coupon_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "order_id" uuid NOT NULL,
+    "discount_amount" decimal NOT NULL,
+    "status" text NOT NULL DEFAULT 'redeemed',
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_coupon_redemptions_order_id" ON "coupon_redemptions" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_coupon_redemption_user" ON "coupon_redemptions" ("coupon_id","user_id");
+
+CREATE TABLE IF NOT EXISTS "restaurant_documents" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "restaurant_id" uuid NOT NULL,
+    "type" text NOT NULL,
+    "number" text NOT NULL,
+    "details" jsonb,
+    "expires_at" timestamptz,
+    "media_id" text,
+    "file_name" text,
+    "status" text DEFAULT 'pending_upload',
+    "review_note" text,
+    "reviewed_by" uuid,
+    "reviewed_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_restaurant_document_type" ON "restaurant_documents" ("restaurant_id","type");
+
+CREATE TABLE IF NOT EXISTS "delivery_dispatches" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "order_id" uuid NOT NULL,
+    "attempt" bigint NOT NULL,
+    "strategy" text NOT NULL,
+    "provider" text NOT NULL,
+    "delivery_partner_id" uuid,
+    "quoted_fee" decimal,
+    "estimated_minutes" bigint,
+    "status" text NOT NULL,
+    "provider_order_id" text,
+    "tracking_url" text,
+    "error" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_delivery_dispatches_order_id" ON "delivery_dispatches" ("order_id");
+
+CREATE TABLE IF NOT EXISTS "outbox_events" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "topic" text NOT NULL,
+    "key" text,
+    "payload" jsonb NOT NULL,
+    "status" text NOT NULL DEFAULT 'pending',
+    "attempts" bigint DEFAULT 0,
+    "last_error" text,
+    "next_attempt_at" timestamptz NOT NULL,
+    "published_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_outbox_due" ON "outbox_events" ("status","next_attempt_at");
+
+CREATE TABLE IF NOT EXISTS "restaurant_staffs" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "restaurant_id" uuid NOT NULL,
+    "user_id" uuid,
+    "name" text,
+    "phone" text NOT NULL,
+    "permissions" jsonb,
+    "status" text DEFAULT 'invited',
+    "invited_by" uuid NOT NULL,
+    "invite_expires_at" timestamptz,
+    "accepted_at" timestamptz,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_restaurant_staffs_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_restaurant_staffs_status" ON "restaurant_staffs" ("status");
+CREATE INDEX IF NOT EXISTS "idx_restaurant_staffs_phone" ON "restaurant_staffs" ("phone");
+CREATE INDEX IF NOT EXISTS "idx_restaurant_staffs_user_id" ON "restaurant_staffs" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_restaurant_staffs_restaurant_id" ON "restaurant_staffs" ("restaurant_id");
+
+CREATE TABLE IF NOT EXISTS "payout_batches" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "period_start" timestamptz NOT NULL,
+    "period_end" timestamptz NOT NULL,
+    "status" text DEFAULT 'generated',
+    "payout_count" bigint,
+    "total_amount" decimal,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_payout_batch_period" ON "payout_batches" ("period_start","period_end");
+
+CREATE TABLE IF NOT EXISTS "restaurant_payouts" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "batch_id" uuid NOT NULL,
+    "restaurant_id" uuid NOT NULL,
+    "period_start" timestamptz,
+    "period_end" timestamptz,
+    "order_count" bigint,
+    "order_value" decimal,
+    "commission" decimal,
+    "fees" decimal,
+    "refunds" decimal,
+    "carried_over" decimal,
+    "net_amount" decimal,
+    "status" text DEFAULT 'pending',
+    "reference" text,
+    "note" text,
+    "paid_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_restaurant_payouts_restaurant" FOREIGN KEY ("restaurant_id") REFERENCES "restaurants"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_restaurant_payouts_restaurant_id" ON "restaurant_payouts" ("restaurant_id");
+CREATE INDEX IF NOT EXISTS "idx_restaurant_payouts_batch_id" ON "restaurant_payouts" ("batch_id");
+
+CREATE TABLE IF NOT EXISTS "order_settlements" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "source_id" uuid NOT NULL,
+    "type" text NOT NULL,
+    "order_id" uuid NOT NULL,
+    "restaurant_id" uuid NOT NULL,
+    "payout_id" uuid NOT NULL,
+    "order_value" decimal,
+    "commission" decimal,
+    "fees" decimal,
+    "refunds" decimal,
+    "net_amount" decimal,
+    "occurred_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_settlements_payout_id" ON "order_settlements" ("payout_id");
+CREATE INDEX IF NOT EXISTS "idx_order_settlements_order_id" ON "order_settlements" ("order_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_order_settlements_source_id" ON "order_settlements" ("source_id");
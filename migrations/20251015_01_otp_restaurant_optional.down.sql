@@ -0,0 +1,4 @@
+-- Fails while admin or staff OTPs without a restaurant remain
+COMMENT ON COLUMN otps.restaurant_id IS NULL;
+
+ALTER TABLE otps ALTER COLUMN restaurant_id SET NOT NULL;
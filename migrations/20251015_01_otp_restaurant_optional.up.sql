@@ -0,0 +1,4 @@
+-- OTPs for admins and staff are not tied to a restaurant
+ALTER TABLE otps ALTER COLUMN restaurant_id DROP NOT NULL;
+
+COMMENT ON COLUMN otps.restaurant_id IS 'Restaurant ID for customer OTPs, NULL for admin/staff OTPs';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_customer_email_restaurant;
+DROP INDEX IF EXISTS idx_users_customer_phone_restaurant;
+DROP INDEX IF EXISTS idx_users_staff_email;
+DROP INDEX IF EXISTS idx_users_staff_phone;
@@ -0,0 +1,13 @@
+-- Unique customer contacts per restaurant and unique staff contacts.
+-- Deleted accounts have their email and phone cleared, so they never conflict.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_customer_email_restaurant
+    ON users (email, restaurant_id) WHERE role = 'customer' AND email <> '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_customer_phone_restaurant
+    ON users (phone, restaurant_id) WHERE role = 'customer' AND phone <> '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_staff_email
+    ON users (email) WHERE role <> 'customer' AND email <> '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_staff_phone
+    ON users (phone) WHERE role <> 'customer' AND phone <> '';
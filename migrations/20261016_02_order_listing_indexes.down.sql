@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_orders_restaurant_created;
+DROP INDEX IF EXISTS idx_orders_user_created;
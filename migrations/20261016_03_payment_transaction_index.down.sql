@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_payments_order;
+DROP INDEX IF EXISTS idx_payments_transaction;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_transaction_id ON payments (transaction_id);
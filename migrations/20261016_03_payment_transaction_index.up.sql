@@ -0,0 +1,8 @@
+-- Unique payment transaction IDs that ignore payments not yet given one.
+-- Replaces the plain unique index, which rejected a second payment without a transaction ID.
+DROP INDEX IF EXISTS idx_payments_transaction_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_transaction
+    ON payments (transaction_id) WHERE transaction_id <> '';
+
+CREATE INDEX IF NOT EXISTS idx_payments_order ON payments (order_id);
@@ -0,0 +1 @@
+-- Nothing to restore: 20261016_01_user_unique_contacts enforces the same rules
@@ -0,0 +1,6 @@
+-- Drop the user indexes left by the old hand-run add_user_constraints.sql.
+-- 20261016_01_user_unique_contacts enforces the same rules.
+DROP INDEX IF EXISTS idx_users_email_restaurant_customer;
+DROP INDEX IF EXISTS idx_users_phone_restaurant_customer;
+DROP INDEX IF EXISTS idx_users_email_non_customer;
+DROP INDEX IF EXISTS idx_users_phone_non_customer;
@@ -0,0 +1,8 @@
+-- Archived orders are lost; restore them into orders from their snapshots first
+DROP TABLE IF EXISTS archived_orders;
+
+DROP INDEX IF EXISTS idx_orders_deleted_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS deleted_at;
+
+DROP INDEX IF EXISTS idx_restaurants_deleted_at;
+ALTER TABLE restaurants DROP COLUMN IF EXISTS deleted_at;
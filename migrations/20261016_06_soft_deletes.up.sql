@@ -0,0 +1,21 @@
+-- Soft deletes for restaurants and orders, and the order archive
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_restaurants_deleted_at ON restaurants (deleted_at);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders (deleted_at);
+
+CREATE TABLE IF NOT EXISTS archived_orders (
+    "id" uuid,
+    "user_id" uuid NOT NULL,
+    "restaurant_id" uuid NOT NULL,
+    "order_status" text,
+    "total_amount" decimal,
+    "created_at" timestamptz,
+    "archived_at" timestamptz,
+    "snapshot" jsonb,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_archived_orders_user_id ON archived_orders (user_id);
+CREATE INDEX IF NOT EXISTS idx_archived_orders_restaurant_id ON archived_orders (restaurant_id);
+CREATE INDEX IF NOT EXISTS idx_archived_orders_archived_at ON archived_orders (archived_at);
@@ -0,0 +1,3 @@
+-- Version columns for optimistic locking of carts and orders
+ALTER TABLE carts ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+-- Carts cancelled as duplicates stay cancelled
+DROP INDEX IF EXISTS idx_carts_user_restaurant_active;
@@ -0,0 +1,16 @@
+-- One active cart per user and restaurant
+-- Duplicates left by the old single-cart logic are cancelled, keeping the most recently changed.
+UPDATE carts SET status = 'cancelled', version = version + 1
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, row_number() OVER (
+            PARTITION BY user_id, restaurant_id ORDER BY updated_at DESC, created_at DESC
+        ) AS position
+        FROM carts
+        WHERE status = 'active'
+    ) ranked
+    WHERE position > 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_carts_user_restaurant_active
+    ON carts (user_id, restaurant_id) WHERE status = 'active';
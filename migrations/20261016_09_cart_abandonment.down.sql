@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_carts_status_updated_at;
+DROP INDEX IF EXISTS idx_carts_abandoned_at;
+ALTER TABLE carts DROP COLUMN IF EXISTS abandoned_at;
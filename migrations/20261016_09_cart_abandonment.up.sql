@@ -0,0 +1,4 @@
+-- Abandoned-at timestamp for carts marked abandoned after inactivity
+ALTER TABLE carts ADD COLUMN IF NOT EXISTS abandoned_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_carts_abandoned_at ON carts (abandoned_at);
+CREATE INDEX IF NOT EXISTS idx_carts_status_updated_at ON carts (status, updated_at);
@@ -0,0 +1,28 @@
+-- Order invoices and per-restaurant invoice number counters
+CREATE TABLE IF NOT EXISTS invoices (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "order_id" uuid NOT NULL,
+    "restaurant_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "invoice_number" text NOT NULL,
+    "financial_year" text NOT NULL,
+    "gst_number" text,
+    "taxable_value" decimal,
+    "total_tax" decimal,
+    "total_amount" decimal,
+    "media_id" text NOT NULL,
+    "issued_at" timestamptz,
+    "emailed_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_order_id ON invoices (order_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_restaurant_number ON invoices (restaurant_id, invoice_number);
+CREATE INDEX IF NOT EXISTS idx_invoices_user_id ON invoices (user_id);
+
+CREATE TABLE IF NOT EXISTS invoice_counters (
+    "restaurant_id" uuid,
+    "financial_year" text,
+    "last_number" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("restaurant_id", "financial_year")
+);
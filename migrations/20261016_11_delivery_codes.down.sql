@@ -0,0 +1,3 @@
+ALTER TABLE restaurants DROP COLUMN IF EXISTS require_delivery_code;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_code_verified_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_code;
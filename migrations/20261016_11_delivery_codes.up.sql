@@ -0,0 +1,4 @@
+-- Per-order delivery codes verified at handoff, optionally required per restaurant
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_code varchar(4);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_code_verified_at timestamptz;
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS require_delivery_code boolean NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS pricing_details;
+DROP TABLE IF EXISTS pricing_rules;
@@ -0,0 +1,25 @@
+-- Pricing rules for platform, small-order and late-night fees and delivery surges, and the pricing snapshot on orders
+CREATE TABLE IF NOT EXISTS pricing_rules (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "restaurant_id" uuid,
+    "name" text NOT NULL,
+    "rule_type" text NOT NULL,
+    "amount" decimal,
+    "multiplier" decimal,
+    "below_subtotal" decimal,
+    "condition" text,
+    "start_time" text,
+    "end_time" text,
+    "days" jsonb,
+    "valid_from" timestamptz,
+    "valid_until" timestamptz,
+    "is_active" boolean DEFAULT true,
+    "created_by" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_pricing_rules_restaurant_id ON pricing_rules (restaurant_id);
+CREATE INDEX IF NOT EXISTS idx_pricing_rules_rule_type ON pricing_rules (rule_type);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS pricing_details jsonb;
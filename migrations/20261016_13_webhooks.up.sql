@@ -0,0 +1,35 @@
+-- Webhook subscriptions for restaurant POS integrations and their delivery log
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "restaurant_id" uuid NOT NULL,
+    "url" text NOT NULL,
+    "event_types" jsonb,
+    "secret" text NOT NULL,
+    "description" text,
+    "is_active" boolean DEFAULT true,
+    "created_by" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_restaurant_id ON webhook_subscriptions (restaurant_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "subscription_id" uuid NOT NULL,
+    "event_type" text NOT NULL,
+    "event_id" text NOT NULL,
+    "payload" jsonb NOT NULL,
+    "status" text NOT NULL DEFAULT 'pending',
+    "attempts" bigint DEFAULT 0,
+    "response_status" bigint,
+    "response_body" text,
+    "last_error" text,
+    "next_attempt_at" timestamptz NOT NULL,
+    "delivered_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_delivery_event ON webhook_deliveries (subscription_id, event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_due ON webhook_deliveries (status, next_attempt_at);
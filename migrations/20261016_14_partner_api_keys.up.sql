@@ -0,0 +1,26 @@
+-- Hashed, scoped API keys for the delivery partner API; existing plaintext company keys are imported with every scope
+CREATE TABLE IF NOT EXISTS partner_api_keys (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "delivery_partner_company_id" uuid NOT NULL,
+    "name" text NOT NULL,
+    "prefix" text NOT NULL,
+    "key_hash" text NOT NULL,
+    "scopes" jsonb,
+    "rate_limit_per_minute" bigint NOT NULL,
+    "last_used_at" timestamptz,
+    "expires_at" timestamptz,
+    "revoked_at" timestamptz,
+    "created_by" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_partner_api_keys_delivery_partner_company_id ON partner_api_keys (delivery_partner_company_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_partner_api_keys_key_hash ON partner_api_keys (key_hash);
+
+INSERT INTO partner_api_keys (delivery_partner_company_id, name, prefix, key_hash, scopes, rate_limit_per_minute, created_at, updated_at)
+SELECT id, 'Imported key', LEFT(api_key, 4), encode(sha256(convert_to(api_key, 'UTF8')), 'hex'),
+       '["orders:read","orders:write","settlements:read"]'::jsonb, 120, NOW(), NOW()
+FROM delivery_partner_companies
+WHERE api_key <> ''
+ON CONFLICT (key_hash) DO NOTHING;
@@ -0,0 +1,15 @@
+-- Feature flags with per-restaurant and percentage rollouts
+CREATE TABLE IF NOT EXISTS feature_flags (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "key" text NOT NULL,
+    "description" text,
+    "enabled" boolean DEFAULT false,
+    "rollout_percent" bigint DEFAULT 0,
+    "restaurant_ids" jsonb,
+    "excluded_restaurant_ids" jsonb,
+    "updated_by" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_feature_flags_key ON feature_flags ("key");
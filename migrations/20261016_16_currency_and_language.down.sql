@@ -0,0 +1,3 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS language;
+ALTER TABLE orders DROP COLUMN IF EXISTS currency;
+ALTER TABLE restaurants DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,4 @@
+-- Currency on restaurants and orders, and the customer's language on orders
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS currency varchar(3) NOT NULL DEFAULT 'INR';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency varchar(3) NOT NULL DEFAULT 'INR';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS language varchar(8);
@@ -0,0 +1,51 @@
+ALTER TABLE restaurant_tax_configs
+    ALTER COLUMN packaging_fee TYPE decimal,
+    ALTER COLUMN platform_fee TYPE decimal;
+ALTER TABLE coupon_redemptions
+    ALTER COLUMN discount_amount TYPE decimal;
+ALTER TABLE pricing_rules
+    ALTER COLUMN amount TYPE decimal,
+    ALTER COLUMN below_subtotal TYPE decimal;
+ALTER TABLE coupons
+    ALTER COLUMN max_discount TYPE decimal,
+    ALTER COLUMN min_order_value TYPE decimal;
+ALTER TABLE invoices
+    ALTER COLUMN taxable_value TYPE decimal,
+    ALTER COLUMN total_tax TYPE decimal,
+    ALTER COLUMN total_amount TYPE decimal;
+ALTER TABLE refunds
+    ALTER COLUMN amount TYPE decimal;
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE decimal;
+ALTER TABLE porter_deliveries
+    ALTER COLUMN delivery_fee TYPE decimal;
+ALTER TABLE archived_orders
+    ALTER COLUMN total_amount TYPE decimal;
+ALTER TABLE orders
+    ALTER COLUMN total_amount TYPE decimal,
+    ALTER COLUMN delivery_fee TYPE decimal;
+ALTER TABLE carts
+    ALTER COLUMN total_amount TYPE decimal;
+ALTER TABLE order_settlements
+    ALTER COLUMN order_value TYPE decimal,
+    ALTER COLUMN commission TYPE decimal,
+    ALTER COLUMN fees TYPE decimal,
+    ALTER COLUMN refunds TYPE decimal,
+    ALTER COLUMN net_amount TYPE decimal;
+ALTER TABLE restaurant_payouts
+    ALTER COLUMN order_value TYPE decimal,
+    ALTER COLUMN commission TYPE decimal,
+    ALTER COLUMN fees TYPE decimal,
+    ALTER COLUMN refunds TYPE decimal,
+    ALTER COLUMN carried_over TYPE decimal,
+    ALTER COLUMN net_amount TYPE decimal;
+ALTER TABLE payout_batches
+    ALTER COLUMN total_amount TYPE decimal;
+ALTER TABLE restaurant_delivery_location_boundaries
+    ALTER COLUMN min_order_value TYPE decimal,
+    ALTER COLUMN delivery_fee TYPE decimal,
+    ALTER COLUMN free_delivery_above TYPE decimal;
+ALTER TABLE delivery_dispatches
+    ALTER COLUMN quoted_fee TYPE decimal;
+ALTER TABLE users
+    ALTER COLUMN wallet_balance TYPE decimal;
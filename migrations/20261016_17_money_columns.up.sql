@@ -0,0 +1,52 @@
+-- Store money columns as exact numeric(12,2) amounts instead of floating point
+ALTER TABLE users
+    ALTER COLUMN wallet_balance TYPE numeric(12,2) USING round(wallet_balance::numeric, 2);
+ALTER TABLE delivery_dispatches
+    ALTER COLUMN quoted_fee TYPE numeric(12,2) USING round(quoted_fee::numeric, 2);
+ALTER TABLE restaurant_delivery_location_boundaries
+    ALTER COLUMN min_order_value TYPE numeric(12,2) USING round(min_order_value::numeric, 2),
+    ALTER COLUMN delivery_fee TYPE numeric(12,2) USING round(delivery_fee::numeric, 2),
+    ALTER COLUMN free_delivery_above TYPE numeric(12,2) USING round(free_delivery_above::numeric, 2);
+ALTER TABLE payout_batches
+    ALTER COLUMN total_amount TYPE numeric(12,2) USING round(total_amount::numeric, 2);
+ALTER TABLE restaurant_payouts
+    ALTER COLUMN order_value TYPE numeric(12,2) USING round(order_value::numeric, 2),
+    ALTER COLUMN commission TYPE numeric(12,2) USING round(commission::numeric, 2),
+    ALTER COLUMN fees TYPE numeric(12,2) USING round(fees::numeric, 2),
+    ALTER COLUMN refunds TYPE numeric(12,2) USING round(refunds::numeric, 2),
+    ALTER COLUMN carried_over TYPE numeric(12,2) USING round(carried_over::numeric, 2),
+    ALTER COLUMN net_amount TYPE numeric(12,2) USING round(net_amount::numeric, 2);
+ALTER TABLE order_settlements
+    ALTER COLUMN order_value TYPE numeric(12,2) USING round(order_value::numeric, 2),
+    ALTER COLUMN commission TYPE numeric(12,2) USING round(commission::numeric, 2),
+    ALTER COLUMN fees TYPE numeric(12,2) USING round(fees::numeric, 2),
+    ALTER COLUMN refunds TYPE numeric(12,2) USING round(refunds::numeric, 2),
+    ALTER COLUMN net_amount TYPE numeric(12,2) USING round(net_amount::numeric, 2);
+ALTER TABLE carts
+    ALTER COLUMN total_amount TYPE numeric(12,2) USING round(total_amount::numeric, 2);
+ALTER TABLE orders
+    ALTER COLUMN total_amount TYPE numeric(12,2) USING round(total_amount::numeric, 2),
+    ALTER COLUMN delivery_fee TYPE numeric(12,2) USING round(delivery_fee::numeric, 2);
+ALTER TABLE archived_orders
+    ALTER COLUMN total_amount TYPE numeric(12,2) USING round(total_amount::numeric, 2);
+ALTER TABLE porter_deliveries
+    ALTER COLUMN delivery_fee TYPE numeric(12,2) USING round(delivery_fee::numeric, 2);
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE numeric(12,2) USING round(amount::numeric, 2);
+ALTER TABLE refunds
+    ALTER COLUMN amount TYPE numeric(12,2) USING round(amount::numeric, 2);
+ALTER TABLE invoices
+    ALTER COLUMN taxable_value TYPE numeric(12,2) USING round(taxable_value::numeric, 2),
+    ALTER COLUMN total_tax TYPE numeric(12,2) USING round(total_tax::numeric, 2),
+    ALTER COLUMN total_amount TYPE numeric(12,2) USING round(total_amount::numeric, 2);
+ALTER TABLE coupons
+    ALTER COLUMN max_discount TYPE numeric(12,2) USING round(max_discount::numeric, 2),
+    ALTER COLUMN min_order_value TYPE numeric(12,2) USING round(min_order_value::numeric, 2);
+ALTER TABLE pricing_rules
+    ALTER COLUMN amount TYPE numeric(12,2) USING round(amount::numeric, 2),
+    ALTER COLUMN below_subtotal TYPE numeric(12,2) USING round(below_subtotal::numeric, 2);
+ALTER TABLE coupon_redemptions
+    ALTER COLUMN discount_amount TYPE numeric(12,2) USING round(discount_amount::numeric, 2);
+ALTER TABLE restaurant_tax_configs
+    ALTER COLUMN packaging_fee TYPE numeric(12,2) USING round(packaging_fee::numeric, 2),
+    ALTER COLUMN platform_fee TYPE numeric(12,2) USING round(platform_fee::numeric, 2);
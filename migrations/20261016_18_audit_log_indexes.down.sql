@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_audit_actor;
+DROP INDEX IF EXISTS idx_audit_entity;
@@ -0,0 +1,3 @@
+-- Audit log indexes for entity history and actor searches
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_logs ("entity_type", "entity_id", "timestamp");
+CREATE INDEX IF NOT EXISTS idx_audit_actor ON audit_logs ("performed_by", "timestamp");
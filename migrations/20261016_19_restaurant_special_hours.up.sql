@@ -0,0 +1,15 @@
+-- Restaurant holidays and special hours overriding the weekly opening hours
+CREATE TABLE IF NOT EXISTS restaurant_special_hours (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "restaurant_id" uuid NOT NULL,
+    "start_date" varchar(10) NOT NULL,
+    "end_date" varchar(10) NOT NULL,
+    "is_closed" boolean DEFAULT false,
+    "open_time" text,
+    "close_time" text,
+    "reason" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_special_hours_restaurant ON restaurant_special_hours ("restaurant_id", "start_date");
@@ -0,0 +1,18 @@
+-- Busy mode: spells when a restaurant stops taking new orders, kept for analytics
+CREATE TABLE IF NOT EXISTS restaurant_pauses (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "restaurant_id" uuid NOT NULL,
+    "duration_minutes" bigint NOT NULL,
+    "reason" text,
+    "started_at" timestamptz NOT NULL,
+    "ends_at" timestamptz NOT NULL,
+    "resumed_at" timestamptz,
+    "end_reason" text,
+    "paused_by" uuid,
+    "resumed_by" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_restaurant_pause_restaurant ON restaurant_pauses ("restaurant_id", "started_at");
+CREATE INDEX IF NOT EXISTS idx_restaurant_pauses_resumed_at ON restaurant_pauses ("resumed_at");
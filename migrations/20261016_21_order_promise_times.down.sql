@@ -0,0 +1,3 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS promise_details;
+ALTER TABLE orders DROP COLUMN IF EXISTS original_promised_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS promised_at;
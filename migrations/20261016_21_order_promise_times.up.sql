@@ -0,0 +1,4 @@
+-- Promised delivery times of orders, with what they were computed from and later delays
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS promised_at timestamptz;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS original_promised_at timestamptz;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS promise_details jsonb;
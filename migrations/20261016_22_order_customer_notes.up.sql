@@ -0,0 +1,2 @@
+-- Cooking or delivery instructions customers give at checkout, printed on kitchen tickets
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS customer_notes varchar(250);
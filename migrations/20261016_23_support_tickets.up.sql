@@ -0,0 +1,41 @@
+-- Customer support tickets with their message threads and SLA deadlines
+CREATE TABLE IF NOT EXISTS support_tickets (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL,
+    "order_id" uuid,
+    "restaurant_id" uuid,
+    "issue_type" text NOT NULL,
+    "subject" text NOT NULL,
+    "status" text NOT NULL DEFAULT 'open',
+    "priority" text NOT NULL DEFAULT 'normal',
+    "source" text NOT NULL DEFAULT 'customer',
+    "source_key" text,
+    "assigned_to" uuid,
+    "first_response_due_at" timestamptz,
+    "resolution_due_at" timestamptz,
+    "first_responded_at" timestamptz,
+    "resolved_at" timestamptz,
+    "resolution" text,
+    "last_message_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_support_tickets_user_id ON support_tickets ("user_id");
+CREATE INDEX IF NOT EXISTS idx_support_tickets_order_id ON support_tickets ("order_id");
+CREATE INDEX IF NOT EXISTS idx_support_tickets_status ON support_tickets ("status");
+CREATE INDEX IF NOT EXISTS idx_support_tickets_assigned_to ON support_tickets ("assigned_to");
+CREATE UNIQUE INDEX IF NOT EXISTS idx_support_tickets_source_key ON support_tickets ("source_key");
+
+CREATE TABLE IF NOT EXISTS support_ticket_messages (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "ticket_id" uuid NOT NULL,
+    "author_type" text NOT NULL,
+    "author_id" uuid,
+    "body" text NOT NULL,
+    "attachments" jsonb,
+    "internal" boolean DEFAULT false,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_support_ticket_messages_ticket_id ON support_ticket_messages ("ticket_id");
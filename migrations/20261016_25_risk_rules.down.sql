@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS risk_assessments;
+DROP TABLE IF EXISTS risk_rules;
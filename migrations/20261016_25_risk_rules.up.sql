@@ -0,0 +1,41 @@
+-- Fraud rules engine: stored rule settings and the decisions made at checkout and refund request
+CREATE TABLE IF NOT EXISTS risk_rules (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "key" text NOT NULL,
+    "enabled" boolean DEFAULT true,
+    "action" text NOT NULL,
+    "threshold" decimal NOT NULL,
+    "window_hours" bigint DEFAULT 0,
+    "updated_by" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_risk_rules_key ON risk_rules ("key");
+
+CREATE TABLE IF NOT EXISTS risk_assessments (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "event" text NOT NULL,
+    "user_id" uuid NOT NULL,
+    "order_id" uuid,
+    "refund_id" uuid,
+    "payment_method" text,
+    "device_id" text,
+    "phone" text,
+    "ip_address" text,
+    "decision" text NOT NULL,
+    "triggered" jsonb,
+    "review_status" text,
+    "reviewed_by" uuid,
+    "reviewed_at" timestamptz,
+    "review_note" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_risk_assessments_event ON risk_assessments ("event");
+CREATE INDEX IF NOT EXISTS idx_risk_assessments_user_id ON risk_assessments ("user_id");
+CREATE INDEX IF NOT EXISTS idx_risk_assessments_order_id ON risk_assessments ("order_id");
+CREATE INDEX IF NOT EXISTS idx_risk_assessments_decision ON risk_assessments ("decision");
+CREATE INDEX IF NOT EXISTS idx_risk_assessments_review_status ON risk_assessments ("review_status");
+CREATE INDEX IF NOT EXISTS idx_risk_assessment_device ON risk_assessments ("device_id", "created_at");
+CREATE INDEX IF NOT EXISTS idx_risk_assessment_phone ON risk_assessments ("phone", "created_at");
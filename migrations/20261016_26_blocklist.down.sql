@@ -0,0 +1,2 @@
+ALTER TABLE risk_rules DROP COLUMN IF EXISTS block_hours;
+DROP TABLE IF EXISTS blocklist_entries;
@@ -0,0 +1,20 @@
+-- Blocklist of users, phone numbers and devices, and automatic blocks from the fraud rules
+CREATE TABLE IF NOT EXISTS blocklist_entries (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "kind" text NOT NULL,
+    "value" text NOT NULL,
+    "reason" text NOT NULL,
+    "source" text NOT NULL DEFAULT 'admin',
+    "risk_assessment_id" uuid,
+    "expires_at" timestamptz,
+    "created_by" uuid,
+    "lifted_at" timestamptz,
+    "lifted_by" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_blocklist_subject ON blocklist_entries ("kind", "value");
+CREATE INDEX IF NOT EXISTS idx_blocklist_entries_lifted_at ON blocklist_entries ("lifted_at");
+
+ALTER TABLE risk_rules ADD COLUMN IF NOT EXISTS block_hours bigint DEFAULT 0;
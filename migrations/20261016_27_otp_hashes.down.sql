@@ -0,0 +1 @@
+ALTER TABLE otps DROP COLUMN IF EXISTS code_salt;
@@ -0,0 +1,3 @@
+-- OTPs are stored as salted hashes; codes issued in plaintext before this are retired
+ALTER TABLE otps ADD COLUMN IF NOT EXISTS code_salt text NOT NULL DEFAULT '';
+UPDATE otps SET is_used = true WHERE code_salt = '' AND is_used = false;
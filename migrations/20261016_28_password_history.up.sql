@@ -0,0 +1,9 @@
+-- Recent password hashes per user, checked so new passwords do not reuse them
+CREATE TABLE IF NOT EXISTS password_histories (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL,
+    "password_hash" text NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_password_histories_user_id" ON "password_histories" ("user_id");
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_users_staff_phone_hash;
+DROP INDEX IF EXISTS idx_users_staff_email_hash;
+DROP INDEX IF EXISTS idx_users_customer_phone_hash_restaurant;
+DROP INDEX IF EXISTS idx_users_customer_email_hash_restaurant;
+ALTER TABLE users DROP COLUMN IF EXISTS phone_hash;
+ALTER TABLE users DROP COLUMN IF EXISTS email_hash;
@@ -0,0 +1,17 @@
+-- Blind indexes for encrypted user emails and phone numbers, and uniqueness on them.
+-- The plaintext indexes stay: they still cover rows written while encryption was off.
+-- Existing rows get their hashes from the pii:reencrypt job.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_hash text NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_hash text NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_customer_email_hash_restaurant
+    ON users (email_hash, restaurant_id) WHERE role = 'customer' AND email_hash <> '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_customer_phone_hash_restaurant
+    ON users (phone_hash, restaurant_id) WHERE role = 'customer' AND phone_hash <> '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_staff_email_hash
+    ON users (email_hash) WHERE role <> 'customer' AND email_hash <> '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_staff_phone_hash
+    ON users (phone_hash) WHERE role <> 'customer' AND phone_hash <> '';
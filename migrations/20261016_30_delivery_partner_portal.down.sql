@@ -0,0 +1,6 @@
+ALTER TABLE partner_api_keys DROP COLUMN IF EXISTS replaced_by_id;
+DROP INDEX IF EXISTS idx_restaurant_delivery_partners_company_status;
+ALTER TABLE restaurant_delivery_partners DROP COLUMN IF EXISTS decline_reason;
+ALTER TABLE restaurant_delivery_partners DROP COLUMN IF EXISTS responded_at;
+ALTER TABLE restaurant_delivery_partners DROP COLUMN IF EXISTS requested_at;
+ALTER TABLE restaurant_delivery_partners DROP COLUMN IF EXISTS status;
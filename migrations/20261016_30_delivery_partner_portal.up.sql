@@ -0,0 +1,12 @@
+-- Link requests between restaurants and delivery partner companies. Partners accept or decline
+-- pending links through the partner API; links made before requests existed stay active.
+ALTER TABLE restaurant_delivery_partners ADD COLUMN IF NOT EXISTS status varchar(20) NOT NULL DEFAULT 'active';
+ALTER TABLE restaurant_delivery_partners ADD COLUMN IF NOT EXISTS requested_at timestamptz;
+ALTER TABLE restaurant_delivery_partners ADD COLUMN IF NOT EXISTS responded_at timestamptz;
+ALTER TABLE restaurant_delivery_partners ADD COLUMN IF NOT EXISTS decline_reason text;
+
+CREATE INDEX IF NOT EXISTS idx_restaurant_delivery_partners_company_status
+    ON restaurant_delivery_partners (delivery_partner_company_id, status);
+
+-- Keys rotated through the partner API point to the key that replaced them
+ALTER TABLE partner_api_keys ADD COLUMN IF NOT EXISTS replaced_by_id uuid;
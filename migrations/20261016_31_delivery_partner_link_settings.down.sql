@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_restaurant_delivery_partners_restaurant;
+ALTER TABLE restaurant_delivery_partners DROP COLUMN IF EXISTS requested_by;
+ALTER TABLE restaurant_delivery_partners DROP COLUMN IF EXISTS fee_override;
+ALTER TABLE restaurant_delivery_partners DROP COLUMN IF EXISTS weight;
@@ -0,0 +1,8 @@
+-- Per-link dispatch settings owners set when requesting a delivery partner: a weight splitting
+-- orders between links of the same priority and a negotiated fee used instead of quotes.
+ALTER TABLE restaurant_delivery_partners ADD COLUMN IF NOT EXISTS weight integer NOT NULL DEFAULT 1;
+ALTER TABLE restaurant_delivery_partners ADD COLUMN IF NOT EXISTS fee_override numeric(12,2);
+ALTER TABLE restaurant_delivery_partners ADD COLUMN IF NOT EXISTS requested_by uuid;
+
+CREATE INDEX IF NOT EXISTS idx_restaurant_delivery_partners_restaurant
+    ON restaurant_delivery_partners (restaurant_id);
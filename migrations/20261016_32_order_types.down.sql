@@ -0,0 +1,6 @@
+ALTER TABLE restaurants DROP COLUMN IF EXISTS dine_in_enabled;
+ALTER TABLE restaurants DROP COLUMN IF EXISTS pickup_enabled;
+ALTER TABLE restaurants DROP COLUMN IF EXISTS delivery_enabled;
+
+DROP INDEX IF EXISTS idx_orders_order_type;
+ALTER TABLE orders DROP COLUMN IF EXISTS order_type;
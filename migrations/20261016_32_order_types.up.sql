@@ -0,0 +1,8 @@
+-- Orders can be for delivery, pickup or dine-in; restaurants choose which they take. Existing
+-- orders are deliveries and existing restaurants keep taking only deliveries.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS order_type varchar(16) NOT NULL DEFAULT 'delivery';
+CREATE INDEX IF NOT EXISTS idx_orders_order_type ON orders (order_type);
+
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS delivery_enabled boolean NOT NULL DEFAULT true;
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS pickup_enabled boolean NOT NULL DEFAULT false;
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS dine_in_enabled boolean NOT NULL DEFAULT false;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_orders_tab_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS tab_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS table_number;
+ALTER TABLE orders DROP COLUMN IF EXISTS table_id;
+
+DROP TABLE IF EXISTS table_tabs;
+DROP TABLE IF EXISTS restaurant_tables;
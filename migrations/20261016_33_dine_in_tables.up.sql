@@ -0,0 +1,36 @@
+-- Dine-in tables with QR codes, and running tabs the rounds ordered at a table go on until
+-- staff close them. Orders record the table and tab they were placed at.
+CREATE TABLE IF NOT EXISTS restaurant_tables (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    restaurant_id uuid NOT NULL,
+    number varchar(20) NOT NULL,
+    seats bigint,
+    qr_token varchar(64) NOT NULL,
+    is_active boolean NOT NULL DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_restaurant_table_number ON restaurant_tables (restaurant_id, number);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_restaurant_tables_qr_token ON restaurant_tables (qr_token);
+
+CREATE TABLE IF NOT EXISTS table_tabs (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    restaurant_id uuid NOT NULL,
+    table_id uuid NOT NULL,
+    table_number varchar(20),
+    status varchar(10) NOT NULL DEFAULT 'open',
+    total_amount numeric(12,2),
+    payment_method text,
+    opened_at timestamptz,
+    closed_at timestamptz,
+    closed_by uuid
+);
+CREATE INDEX IF NOT EXISTS idx_table_tabs_restaurant_id ON table_tabs (restaurant_id);
+CREATE INDEX IF NOT EXISTS idx_table_tabs_status ON table_tabs (status);
+-- One open tab per table; concurrent first rounds end up on the same tab
+CREATE UNIQUE INDEX IF NOT EXISTS idx_table_tabs_open ON table_tabs (table_id) WHERE status = 'open';
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS table_id uuid;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS table_number varchar(20);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tab_id uuid;
+CREATE INDEX IF NOT EXISTS idx_orders_tab_id ON orders (tab_id);
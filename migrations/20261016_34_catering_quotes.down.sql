@@ -0,0 +1 @@
+DROP TABLE IF EXISTS catering_quotes;
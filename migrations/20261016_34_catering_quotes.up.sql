@@ -0,0 +1,35 @@
+-- Catering quotes: customers request a quote for a large order, the restaurant prices it and an
+-- accepted quote becomes a scheduled order paid through a payment link, advance first
+CREATE TABLE IF NOT EXISTS catering_quotes (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL,
+    "restaurant_id" uuid NOT NULL,
+    "status" varchar(16) NOT NULL DEFAULT 'requested',
+    "order_type" varchar(16) NOT NULL,
+    "headcount" bigint NOT NULL,
+    "event_date" timestamptz,
+    "items" jsonb,
+    "line_items" jsonb,
+    "menu_total" numeric(12,2),
+    "address_id" uuid,
+    "customer_name" text,
+    "customer_contact" text,
+    "customer_notes" varchar(1000),
+    "quoted_amount" numeric(12,2),
+    "advance_percent" bigint,
+    "quote_notes" varchar(1000),
+    "quoted_at" timestamptz,
+    "quoted_by" uuid,
+    "valid_until" timestamptz,
+    "decline_reason" text,
+    "accepted_at" timestamptz,
+    "order_id" uuid,
+    "currency" varchar(3) NOT NULL DEFAULT 'INR',
+    "version" bigint NOT NULL DEFAULT 1,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_catering_quotes_user_id ON catering_quotes (user_id);
+CREATE INDEX IF NOT EXISTS idx_catering_quotes_restaurant_id ON catering_quotes (restaurant_id);
+CREATE INDEX IF NOT EXISTS idx_catering_quotes_status ON catering_quotes (status);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS meal_plan_bills;
+DROP TABLE IF EXISTS meal_plan_deliveries;
+DROP TABLE IF EXISTS meal_plan_subscriptions;
+DROP TABLE IF EXISTS meal_plans;
@@ -0,0 +1,87 @@
+-- Meal plans: customers subscribe to a restaurant's bundle for recurring deliveries, billed a
+-- week ahead from their wallet or a mandate, with each delivery ordered at the plan's cutoff
+CREATE TABLE IF NOT EXISTS meal_plans (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "restaurant_id" uuid NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "description" varchar(500),
+    "items" jsonb,
+    "price_per_delivery" numeric(12,2) NOT NULL,
+    "cutoff_hours" bigint NOT NULL,
+    "is_active" boolean DEFAULT true,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_meal_plans_restaurant_id ON meal_plans (restaurant_id);
+
+CREATE TABLE IF NOT EXISTS meal_plan_subscriptions (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "plan_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "restaurant_id" uuid NOT NULL,
+    "status" varchar(16) NOT NULL DEFAULT 'active',
+    "days" varchar(30),
+    "delivery_time" varchar(5) NOT NULL,
+    "order_type" varchar(16) NOT NULL,
+    "address_id" uuid,
+    "customer_name" text,
+    "customer_contact" text,
+    "price_per_delivery" numeric(12,2) NOT NULL,
+    "payment_method" varchar(16) NOT NULL,
+    "mandate_token" text,
+    "start_date" varchar(10) NOT NULL,
+    "end_date" varchar(10),
+    "paused_until" varchar(10),
+    "paid_through" varchar(10),
+    "currency" varchar(3) NOT NULL DEFAULT 'INR',
+    "cancelled_at" timestamptz,
+    "version" bigint NOT NULL DEFAULT 1,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_meal_plan_subscriptions_plan_id ON meal_plan_subscriptions (plan_id);
+CREATE INDEX IF NOT EXISTS idx_meal_plan_subscriptions_user_id ON meal_plan_subscriptions (user_id);
+CREATE INDEX IF NOT EXISTS idx_meal_plan_subscriptions_restaurant_id ON meal_plan_subscriptions (restaurant_id);
+CREATE INDEX IF NOT EXISTS idx_meal_plan_subscriptions_status ON meal_plan_subscriptions (status);
+
+-- One delivery per subscription and date, so concurrent generator runs place each order once
+CREATE TABLE IF NOT EXISTS meal_plan_deliveries (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "subscription_id" uuid NOT NULL,
+    "plan_id" uuid NOT NULL,
+    "restaurant_id" uuid NOT NULL,
+    "date" varchar(10) NOT NULL,
+    "status" varchar(16) NOT NULL,
+    "order_id" uuid,
+    "credited" numeric(12,2),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_meal_plan_delivery_date ON meal_plan_deliveries (subscription_id, date);
+CREATE INDEX IF NOT EXISTS idx_meal_plan_deliveries_plan_id ON meal_plan_deliveries (plan_id);
+CREATE INDEX IF NOT EXISTS idx_meal_plan_deliveries_restaurant_id ON meal_plan_deliveries (restaurant_id);
+
+CREATE TABLE IF NOT EXISTS meal_plan_bills (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "subscription_id" uuid NOT NULL,
+    "plan_id" uuid NOT NULL,
+    "restaurant_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "kind" varchar(10) NOT NULL,
+    "period_start" varchar(10),
+    "period_end" varchar(10),
+    "deliveries" bigint,
+    "amount" numeric(12,2),
+    "method" varchar(16),
+    "status" varchar(10) NOT NULL,
+    "gateway_payment_id" text,
+    "failure_reason" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_meal_plan_bills_subscription_id ON meal_plan_bills (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_meal_plan_bills_plan_id ON meal_plan_bills (plan_id);
+CREATE INDEX IF NOT EXISTS idx_meal_plan_bills_restaurant_id ON meal_plan_bills (restaurant_id);
+CREATE INDEX IF NOT EXISTS idx_meal_plan_bills_created_at ON meal_plan_bills (created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS saved_payment_methods;
+DROP TABLE IF EXISTS payment_customers;
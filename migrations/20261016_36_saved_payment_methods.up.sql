@@ -0,0 +1,27 @@
+-- Saved payment methods: each customer's Razorpay customer, and the cards and UPI IDs they
+-- saved at checkout, kept only as Razorpay tokens with display details
+CREATE TABLE IF NOT EXISTS payment_customers (
+    "user_id" uuid,
+    "razorpay_customer_id" varchar(32) NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("user_id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_customers_razorpay_customer_id ON payment_customers (razorpay_customer_id);
+
+CREATE TABLE IF NOT EXISTS saved_payment_methods (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL,
+    "type" varchar(10) NOT NULL,
+    "token" varchar(64) NOT NULL,
+    "razorpay_customer_id" varchar(32) NOT NULL,
+    "card_network" varchar(32),
+    "card_issuer" varchar(32),
+    "card_last4" varchar(4),
+    "vpa" varchar(100),
+    "is_default" boolean DEFAULT false,
+    "last_used_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_saved_payment_methods_user_id ON saved_payment_methods (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_saved_payment_methods_token ON saved_payment_methods (token);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payments_method_created_at;
+DROP TABLE IF EXISTS reconciliation_mismatches;
+DROP TABLE IF EXISTS payment_reconciliations;
@@ -0,0 +1,40 @@
+-- Daily payment reconciliation: each day's Razorpay payments and settlements checked against
+-- our payments, with the payments on which they disagree
+CREATE TABLE IF NOT EXISTS payment_reconciliations (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "date" varchar(10) NOT NULL,
+    "status" varchar(10) NOT NULL,
+    "gateway_payments" bigint,
+    "matched" bigint,
+    "mismatches" bigint,
+    "captured_amount" numeric(12,2),
+    "settled_amount" numeric(12,2),
+    "fees" numeric(12,2),
+    "error" text,
+    "started_at" timestamptz,
+    "completed_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_reconciliations_date ON payment_reconciliations (date);
+
+CREATE TABLE IF NOT EXISTS reconciliation_mismatches (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "reconciliation_id" uuid NOT NULL,
+    "kind" varchar(24) NOT NULL,
+    "payment_id" uuid,
+    "order_id" uuid,
+    "transaction_id" text,
+    "gateway_payment_id" varchar(32),
+    "local_status" varchar(20),
+    "gateway_status" varchar(20),
+    "local_amount" numeric(12,2),
+    "gateway_amount" numeric(12,2),
+    "currency" varchar(3),
+    "settlement_id" varchar(32),
+    "detail" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_mismatches_reconciliation_id ON reconciliation_mismatches (reconciliation_id);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_mismatches_kind ON reconciliation_mismatches (kind);
+CREATE INDEX IF NOT EXISTS idx_payments_method_created_at ON payments (method, created_at);
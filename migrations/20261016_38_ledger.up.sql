@@ -0,0 +1,42 @@
+-- Double-entry ledger: accounts for customer wallets, restaurant payables, platform revenue and
+-- gateway clearing, and the balanced journal entries posted to them
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "code" varchar(96) NOT NULL,
+    "kind" varchar(32) NOT NULL,
+    "type" varchar(16) NOT NULL,
+    "owner_id" uuid,
+    "currency" varchar(3) NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_code ON ledger_accounts (code);
+CREATE INDEX IF NOT EXISTS idx_ledger_accounts_kind ON ledger_accounts (kind);
+CREATE INDEX IF NOT EXISTS idx_ledger_accounts_owner_id ON ledger_accounts (owner_id);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "reference" varchar(128) NOT NULL,
+    "kind" varchar(32) NOT NULL,
+    "source_id" uuid NOT NULL,
+    "description" text,
+    "currency" varchar(3) NOT NULL,
+    "posted_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_journal_entries_reference ON journal_entries (reference);
+CREATE INDEX IF NOT EXISTS idx_journal_entries_kind ON journal_entries (kind);
+CREATE INDEX IF NOT EXISTS idx_journal_entries_source_id ON journal_entries (source_id);
+CREATE INDEX IF NOT EXISTS idx_journal_entries_posted_at ON journal_entries (posted_at);
+
+CREATE TABLE IF NOT EXISTS journal_lines (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "entry_id" uuid NOT NULL,
+    "account_id" uuid NOT NULL,
+    "debit" numeric(12,2),
+    "credit" numeric(12,2),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS idx_journal_lines_entry_id ON journal_lines (entry_id);
+CREATE INDEX IF NOT EXISTS idx_journal_lines_account_id ON journal_lines (account_id);
@@ -0,0 +1,11 @@
+-- Dietary preferences: each customer's diet, allergens to avoid and spice limit, optionally
+-- applied to the menus they browse
+CREATE TABLE IF NOT EXISTS dietary_preferences (
+    "user_id" uuid,
+    "diet" varchar(10),
+    "avoid_allergens" jsonb,
+    "max_spice_level" bigint,
+    "auto_filter" boolean DEFAULT false,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("user_id")
+);
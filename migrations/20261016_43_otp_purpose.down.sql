@@ -0,0 +1 @@
+ALTER TABLE otps DROP COLUMN IF EXISTS purpose;
@@ -0,0 +1,4 @@
+-- OTPs are bound to what they were sent for, so a login code cannot reset a password or accept
+-- a staff invitation; codes issued before this are retired
+ALTER TABLE otps ADD COLUMN IF NOT EXISTS purpose varchar(32) NOT NULL DEFAULT 'login';
+UPDATE otps SET is_used = true WHERE is_used = false;
@@ -0,0 +1,2 @@
+ALTER TABLE meal_plan_subscriptions DROP COLUMN IF EXISTS customer_email;
+ALTER TABLE meal_plan_subscriptions DROP COLUMN IF EXISTS mandate_customer;
@@ -0,0 +1,4 @@
+-- Recurring charges are made for the Razorpay customer a mandate token was issued to, with the
+-- subscriber's email
+ALTER TABLE meal_plan_subscriptions ADD COLUMN IF NOT EXISTS mandate_customer varchar(32);
+ALTER TABLE meal_plan_subscriptions ADD COLUMN IF NOT EXISTS customer_email text;
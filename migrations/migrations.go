@@ -0,0 +1,8 @@
+// Package migrations holds the versioned PostgreSQL migrations: an <id>.up.sql and an
+// <id>.down.sql file per change, applied in ID order by internal/migrations.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var Files embed.FS
@@ -0,0 +1,145 @@
+// Package apperr defines domain errors that carry what went wrong for the client: a kind,
+// which decides the HTTP status, and a machine-readable code. Services declare them as
+// sentinels and wrap them with detail; the error middleware turns them into responses.
+package apperr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"golang-food-backend/pkg/response"
+)
+
+type Kind string
+
+const (
+	KindValidation    Kind = "validation"    // the request is malformed or breaks a rule
+	KindUnprocessable Kind = "unprocessable" // the request is well formed but a value is wrong, such as a code
+	KindUnauthorized  Kind = "unauthorized"
+	KindForbidden     Kind = "forbidden"
+	KindNotFound      Kind = "not_found"
+	KindConflict      Kind = "conflict" // the resource's state does not allow it
+	KindRateLimited   Kind = "rate_limited"
+	KindUpstream      Kind = "upstream" // a provider failed
+	KindUnavailable   Kind = "unavailable"
+	KindTimeout       Kind = "timeout" // the request ran out of time
+	KindInternal      Kind = "internal"
+)
+
+// Status returns the HTTP status of errors of the kind
+func (k Kind) Status() int {
+	switch k {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnprocessable:
+		return http.StatusUnprocessableEntity
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindRateLimited:
+		return http.StatusTooManyRequests
+	case KindUpstream:
+		return http.StatusBadGateway
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	case KindTimeout:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// Code returns the shared error code of the kind, for errors without their own
+func (k Kind) Code() string {
+	if k == KindValidation {
+		return response.CodeValidation
+	}
+	return response.CodeForStatus(k.Status())
+}
+
+// Error is a domain error. Wrap it to add detail, as fmt.Errorf("%w: slot is full", ErrX);
+// errors.Is and errors.As still find it.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Validation(code, message string) *Error    { return New(KindValidation, code, message) }
+func Unprocessable(code, message string) *Error { return New(KindUnprocessable, code, message) }
+func Unauthorized(code, message string) *Error  { return New(KindUnauthorized, code, message) }
+func Forbidden(code, message string) *Error     { return New(KindForbidden, code, message) }
+func NotFound(code, message string) *Error      { return New(KindNotFound, code, message) }
+func Conflict(code, message string) *Error      { return New(KindConflict, code, message) }
+func RateLimited(code, message string) *Error   { return New(KindRateLimited, code, message) }
+func Upstream(code, message string) *Error      { return New(KindUpstream, code, message) }
+func Unavailable(code, message string) *Error   { return New(KindUnavailable, code, message) }
+
+// classified is an untyped error given a kind by Classify
+type classified struct {
+	kind Kind
+	err  error
+}
+
+func (c *classified) Error() string { return c.err.Error() }
+func (c *classified) Unwrap() error { return c.err }
+
+// Classify gives an error without a domain error in its chain a kind, for services that still
+// return plain errors. Domain errors keep their own kind.
+func Classify(err error, kind Kind) error {
+	if err == nil {
+		return nil
+	}
+	if _, _, ok := of(err); ok {
+		return err
+	}
+	return &classified{kind: kind, err: err}
+}
+
+// codeTimeout is the code of errors caused by the request's deadline passing
+const codeTimeout = "request_timeout"
+
+// Of returns the kind and code of err. Errors the request's deadline caused are timeouts;
+// others without a kind are internal.
+func Of(err error) (kind Kind, code string) {
+	kind, code, _ = of(err)
+	return kind, code
+}
+
+func of(err error) (Kind, string, bool) {
+	var domain *Error
+	if errors.As(err, &domain) {
+		code := domain.Code
+		if code == "" {
+			code = domain.Kind.Code()
+		}
+		return domain.Kind, code, true
+	}
+	var class *classified
+	if errors.As(err, &class) {
+		return class.kind, class.kind.Code(), true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return KindTimeout, codeTimeout, true
+	}
+	return KindInternal, KindInternal.Code(), false
+}
+
+// Status returns the HTTP status of err
+func Status(err error) int {
+	kind, _ := Of(err)
+	return kind.Status()
+}
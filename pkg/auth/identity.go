@@ -0,0 +1,362 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Identity providers users can sign in with instead of an OTP
+const (
+	ProviderGoogle     = "google"
+	ProviderApple      = "apple"
+	ProviderTruecaller = "truecaller"
+)
+
+var ErrInvalidIdentityToken = errors.New("invalid identity token")
+
+// Identity is an account verified by an external identity provider. Subject is the provider's
+// stable ID for the account; email and phone may change or be missing.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Phone         string // national number, only set when the provider verified it
+	Name          string
+}
+
+// IdentityCredential is what the app received from the provider's SDK: an ID token for
+// Google and Apple, or an authorization code and its PKCE verifier for Truecaller.
+type IdentityCredential struct {
+	Token        string
+	CodeVerifier string
+}
+
+// IdentityProvider verifies a credential issued by an external identity provider
+type IdentityProvider interface {
+	Name() string
+	Verify(ctx context.Context, credential IdentityCredential) (*Identity, error)
+}
+
+// OIDCProvider verifies RS256 ID tokens against the issuer's published signing keys.
+// Google and Apple sign-in both issue such tokens.
+type OIDCProvider struct {
+	name      string
+	issuers   []string
+	audiences []string
+	keys      *jwksCache
+}
+
+// NewGoogleProvider accepts ID tokens issued to any of the app's OAuth client IDs
+func NewGoogleProvider(clientIDs []string) *OIDCProvider {
+	return &OIDCProvider{
+		name:      ProviderGoogle,
+		issuers:   []string{"https://accounts.google.com", "accounts.google.com"},
+		audiences: clientIDs,
+		keys:      newJWKSCache("https://www.googleapis.com/oauth2/v3/certs"),
+	}
+}
+
+// NewAppleProvider accepts ID tokens issued to the app's bundle IDs or web services IDs
+func NewAppleProvider(clientIDs []string) *OIDCProvider {
+	return &OIDCProvider{
+		name:      ProviderApple,
+		issuers:   []string{"https://appleid.apple.com"},
+		audiences: clientIDs,
+		keys:      newJWKSCache("https://appleid.apple.com/auth/keys"),
+	}
+}
+
+type oidcClaims struct {
+	Email string `json:"email"`
+	// Google sends a boolean, Apple the string "true" or "false"
+	EmailVerified interface{} `json:"email_verified"`
+	Name          string      `json:"name"`
+	jwt.RegisteredClaims
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+func (p *OIDCProvider) Verify(ctx context.Context, credential IdentityCredential) (*Identity, error) {
+	if len(p.audiences) == 0 {
+		return nil, fmt.Errorf("%s sign-in is not configured", p.name)
+	}
+
+	claims := &oidcClaims{}
+	token, err := jwt.ParseWithClaims(credential.Token, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.keys.key(ctx, kid)
+	}, jwt.WithValidMethods([]string{"RS256"}))
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidIdentityToken
+	}
+
+	// The parser only checks exp when present, and knows nothing about issuers or audiences
+	if claims.ExpiresAt == nil || claims.Subject == "" {
+		return nil, ErrInvalidIdentityToken
+	}
+	if !containsString(p.issuers, claims.Issuer) {
+		return nil, ErrInvalidIdentityToken
+	}
+	audienceMatched := false
+	for _, audience := range claims.Audience {
+		if containsString(p.audiences, audience) {
+			audienceMatched = true
+			break
+		}
+	}
+	if !audienceMatched {
+		return nil, ErrInvalidIdentityToken
+	}
+
+	emailVerified := false
+	switch verified := claims.EmailVerified.(type) {
+	case bool:
+		emailVerified = verified
+	case string:
+		emailVerified = verified == "true"
+	}
+
+	return &Identity{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: emailVerified && claims.Email != "",
+		Name:          claims.Name,
+	}, nil
+}
+
+// jwksCache holds an issuer's RSA signing keys by key ID. Keys are refetched hourly, or
+// sooner when a token names an unknown key since issuers rotate keys without notice.
+type jwksCache struct {
+	url       string
+	client    *http.Client
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+const (
+	jwksMaxAge          = time.Hour
+	jwksMinRefreshDelay = time.Minute
+)
+
+func newJWKSCache(url string) *jwksCache {
+	return &jwksCache{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *jwksCache) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, ok := c.keys[kid]
+	age := time.Since(c.fetchedAt)
+	if ok && age < jwksMaxAge {
+		return key, nil
+	}
+	// Don't let tokens with made-up key IDs hammer the issuer
+	if !ok && c.keys != nil && age < jwksMinRefreshDelay {
+		return nil, errors.New("unknown signing key")
+	}
+
+	if err := c.refresh(ctx); err != nil {
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	key, ok = c.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	return key, nil
+}
+
+func (c *jwksCache) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch signing keys: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch signing keys: status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode signing keys: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	return nil
+}
+
+// TruecallerProvider completes Truecaller's OAuth flow: the app's authorization code is
+// exchanged for an access token, which is used to read the verified phone number.
+type TruecallerProvider struct {
+	baseURL            string
+	clientID           string
+	defaultCountryCode string
+	client             *http.Client
+}
+
+func NewTruecallerProvider(baseURL, clientID, defaultCountryCode string) *TruecallerProvider {
+	return &TruecallerProvider{
+		baseURL:            strings.TrimSuffix(baseURL, "/"),
+		clientID:           clientID,
+		defaultCountryCode: defaultCountryCode,
+		client:             &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *TruecallerProvider) Name() string {
+	return ProviderTruecaller
+}
+
+func (p *TruecallerProvider) Verify(ctx context.Context, credential IdentityCredential) (*Identity, error) {
+	if p.clientID == "" {
+		return nil, errors.New("truecaller sign-in is not configured")
+	}
+	if credential.CodeVerifier == "" {
+		return nil, errors.New("code verifier is required for truecaller sign-in")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("client_id", p.clientID)
+	form.Set("code", credential.Token)
+	form.Set("code_verifier", credential.CodeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := p.do(req, &tokenResp); err != nil {
+		return nil, err
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, ErrInvalidIdentityToken
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/v1/userinfo", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+tokenResp.AccessToken)
+
+	var profile struct {
+		Sub         string `json:"sub"`
+		GivenName   string `json:"given_name"`
+		FamilyName  string `json:"family_name"`
+		PhoneNumber string `json:"phone_number"`
+		Email       string `json:"email"`
+	}
+	if err := p.do(req, &profile); err != nil {
+		return nil, err
+	}
+	if profile.Sub == "" || profile.PhoneNumber == "" {
+		return nil, ErrInvalidIdentityToken
+	}
+
+	// Truecaller verifies the phone number only; the email is whatever the user typed
+	return &Identity{
+		Provider: ProviderTruecaller,
+		Subject:  profile.Sub,
+		Email:    strings.ToLower(profile.Email),
+		Phone:    p.nationalNumber(profile.PhoneNumber),
+		Name:     strings.TrimSpace(profile.GivenName + " " + profile.FamilyName),
+	}, nil
+}
+
+func (p *TruecallerProvider) do(req *http.Request, out interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach truecaller: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		return ErrInvalidIdentityToken
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("truecaller request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// nationalNumber strips the default country code so numbers match the ones users log in with
+func (p *TruecallerProvider) nationalNumber(phone string) string {
+	digits := strings.TrimPrefix(phone, "+")
+	if p.defaultCountryCode != "" && len(digits) == len(p.defaultCountryCode)+10 && strings.HasPrefix(digits, p.defaultCountryCode) {
+		return digits[len(p.defaultCountryCode):]
+	}
+	return digits
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type TokenType string
@@ -14,6 +15,9 @@ const (
 	RefreshToken TokenType = "refresh"
 )
 
+// AdminScope marks tokens issued to back-office admin users rather than app users
+const AdminScope = "admin"
+
 type JWTManager struct {
 	secretKey         string
 	accessExpiryHours int
@@ -26,6 +30,9 @@ type Claims struct {
 	Role         string    `json:"role"`
 	Email        string    `json:"email"`
 	TokenType    TokenType `json:"token_type"`
+	Scope        string    `json:"scope,omitempty"`
+	Permissions  []string  `json:"permissions,omitempty"`
+	SessionID    string    `json:"sid,omitempty"` // login session the token belongs to; empty for admin tokens
 	jwt.RegisteredClaims
 }
 
@@ -42,25 +49,40 @@ func NewJWTManager(secretKey string, accessExpiryHours, refreshExpiryDays int) *
 	}
 }
 
-func (j *JWTManager) generateToken(userID, restaurantID, role, email string, tokenType TokenType) (string, error) {
-	var expiryTime time.Time
-	if tokenType == AccessToken {
-		expiryTime = time.Now().Add(time.Hour * time.Duration(j.accessExpiryHours))
-	} else {
-		expiryTime = time.Now().Add(time.Hour * 24 * time.Duration(j.refreshExpiryDays))
-	}
+// AccessTokenTTL is how long an access token stays valid
+func (j *JWTManager) AccessTokenTTL() time.Duration {
+	return time.Hour * time.Duration(j.accessExpiryHours)
+}
+
+// RefreshTokenTTL is how long a refresh token stays valid
+func (j *JWTManager) RefreshTokenTTL() time.Duration {
+	return time.Hour * 24 * time.Duration(j.refreshExpiryDays)
+}
 
-	claims := &Claims{
+func (j *JWTManager) generateToken(userID, restaurantID, role, email, sessionID string, permissions []string, tokenType TokenType) (string, error) {
+	return j.signClaims(&Claims{
 		UserID:       userID,
 		RestaurantID: restaurantID,
 		Role:         role,
 		Email:        email,
 		TokenType:    tokenType,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expiryTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+		Permissions:  permissions,
+		SessionID:    sessionID,
+	})
+}
+
+func (j *JWTManager) signClaims(claims *Claims) (string, error) {
+	expiryTime := time.Now().Add(j.RefreshTokenTTL())
+	if claims.TokenType == AccessToken {
+		expiryTime = time.Now().Add(j.AccessTokenTTL())
+	}
+
+	// A unique token ID keeps tokens issued within the same second distinct
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		ExpiresAt: jwt.NewNumericDate(expiryTime),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		NotBefore: jwt.NewNumericDate(time.Now()),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -68,16 +90,51 @@ func (j *JWTManager) generateToken(userID, restaurantID, role, email string, tok
 }
 
 func (j *JWTManager) GenerateToken(userID, restaurantID, role, email string) (string, error) {
-	return j.generateToken(userID, restaurantID, role, email, AccessToken)
+	return j.generateToken(userID, restaurantID, role, email, "", nil, AccessToken)
 }
 
-func (j *JWTManager) GenerateTokenPair(userID, restaurantID, role, email string) (*TokenPair, error) {
-	accessToken, err := j.generateToken(userID, restaurantID, role, email, AccessToken)
+// GenerateTokenPair issues an access and refresh token bound to a login session. Permissions
+// are only set for restaurant staff; the refresh token leaves them out since they are looked up
+// again when it is used.
+func (j *JWTManager) GenerateTokenPair(userID, restaurantID, role, email, sessionID string, permissions []string) (*TokenPair, error) {
+	accessToken, err := j.generateToken(userID, restaurantID, role, email, sessionID, permissions, AccessToken)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := j.generateToken(userID, restaurantID, role, email, RefreshToken)
+	refreshToken, err := j.generateToken(userID, restaurantID, role, email, sessionID, nil, RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// GenerateAdminTokenPair issues admin-scoped tokens carrying the admin's permissions.
+// The role is "admin" so admin-only routes accept them.
+func (j *JWTManager) GenerateAdminTokenPair(adminID, email string, permissions []string) (*TokenPair, error) {
+	accessToken, err := j.signClaims(&Claims{
+		UserID:      adminID,
+		Role:        "admin",
+		Email:       email,
+		TokenType:   AccessToken,
+		Scope:       AdminScope,
+		Permissions: permissions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := j.signClaims(&Claims{
+		UserID:    adminID,
+		Role:      "admin",
+		Email:     email,
+		TokenType: RefreshToken,
+		Scope:     AdminScope,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -106,18 +163,3 @@ func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 
 	return nil, errors.New("invalid token")
 }
-
-func (j *JWTManager) RefreshAccessToken(refreshTokenString string) (string, error) {
-	claims, err := j.ValidateToken(refreshTokenString)
-	if err != nil {
-		return "", err
-	}
-
-	// Ensure this is a refresh token
-	if claims.TokenType != RefreshToken {
-		return "", errors.New("invalid token type: expected refresh token")
-	}
-
-	// Generate new access token
-	return j.generateToken(claims.UserID, claims.RestaurantID, claims.Role, claims.Email, AccessToken)
-}
@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing algorithms
+const (
+	PasswordBcrypt   = "bcrypt"
+	PasswordArgon2id = "argon2id"
+)
+
+// Argon2Params are the argon2id cost settings. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// PasswordHasher hashes passwords with the configured algorithm and cost. Verify accepts hashes
+// made with either algorithm and any cost, and reports when one should be replaced, so changing
+// the settings upgrades stored hashes as their users log in.
+type PasswordHasher struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+var argon2Encoding = base64.RawStdEncoding
+
+// Hash returns the password's hash in the configured algorithm
+func (h PasswordHasher) Hash(password string) (string, error) {
+	if h.Algorithm != PasswordArgon2id {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost())
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	params := h.argon2Params()
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, 32)
+	// PHC string format, as written by the reference implementation
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		argon2Encoding.EncodeToString(salt), argon2Encoding.EncodeToString(key),
+	), nil
+}
+
+// Verify reports whether the password matches the hash, and whether the hash was made with
+// other settings than the current ones and should be rehashed
+func (h PasswordHasher) Verify(hash, password string) (ok, rehash bool) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := parseArgon2Hash(hash)
+		if err != nil {
+			return false, false
+		}
+		candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(candidate, key) != 1 {
+			return false, false
+		}
+		return true, h.Algorithm != PasswordArgon2id || params != h.argon2Params()
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false, false
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return true, h.Algorithm == PasswordArgon2id || err != nil || cost != h.bcryptCost()
+}
+
+func (h PasswordHasher) bcryptCost() int {
+	if h.BcryptCost < bcrypt.MinCost || h.BcryptCost > bcrypt.MaxCost {
+		return bcrypt.DefaultCost
+	}
+	return h.BcryptCost
+}
+
+func (h PasswordHasher) argon2Params() Argon2Params {
+	params := h.Argon2
+	if params.Memory == 0 {
+		params.Memory = 64 * 1024
+	}
+	if params.Iterations == 0 {
+		params.Iterations = 3
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = 2
+	}
+	return params
+}
+
+func parseArgon2Hash(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, errors.New("malformed argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, errors.New("malformed argon2id parameters")
+	}
+	salt, err := argon2Encoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errors.New("malformed argon2id salt")
+	}
+	key, err := argon2Encoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("malformed argon2id key")
+	}
+	return params, salt, key, nil
+}
+
+// PasswordPolicy is the strength a new password needs. Character classes are lower case,
+// upper case, digits and symbols.
+type PasswordPolicy struct {
+	MinLength  int
+	MinClasses int
+}
+
+// Passwords too common to allow whatever their length
+var commonPasswords = map[string]bool{
+	"password": true, "password1": true, "password123": true, "passw0rd": true, "p@ssw0rd": true,
+	"12345678": true, "123456789": true, "1234567890": true, "qwerty123": true, "qwertyuiop": true,
+	"iloveyou": true, "letmein123": true, "welcome123": true, "admin123": true, "abc12345": true,
+	"11111111": true, "00000000": true, "987654321": true, "zomato123": true, "swiggy123": true,
+}
+
+// Check returns why the password is too weak, or nil. Personal details such as the account's
+// name, email and phone number must not appear in it.
+func (p PasswordPolicy) Check(password string, personal ...string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+	if len(password) > 72 {
+		return errors.New("password must be at most 72 bytes")
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	if classes < p.MinClasses {
+		return fmt.Errorf("password must mix at least %d of lower case letters, upper case letters, digits and symbols", p.MinClasses)
+	}
+
+	folded := strings.ToLower(password)
+	if commonPasswords[folded] {
+		return errors.New("password is too common")
+	}
+	for _, detail := range personal {
+		detail = strings.ToLower(strings.TrimSpace(detail))
+		if at := strings.Index(detail, "@"); at > 0 {
+			detail = detail[:at]
+		}
+		if len(detail) >= 4 && strings.Contains(folded, detail) {
+			return errors.New("password must not contain your name, email or phone number")
+		}
+	}
+	return nil
+}
@@ -13,12 +13,17 @@ type RedisCache struct {
 	client *redis.Client
 }
 
-func NewRedisCache(addr, password string, db int) *RedisCache {
+// NewRedisCache connects to Redis. Commands sent with a context that has no deadline get
+// commandTimeout (zero leaves them unbounded); those sent for a request keep its deadline.
+func NewRedisCache(addr, password string, db int, commandTimeout time.Duration) *RedisCache {
 	client := redis.NewClient(&redis.Options{
 		Addr:     addr,
 		Password: password,
 		DB:       db,
 	})
+	if commandTimeout > 0 {
+		client.AddHook(commandDeadline{timeout: commandTimeout})
+	}
 
 	// Test connection
 	ctx := context.Background()
@@ -68,6 +73,142 @@ func (r *RedisCache) DeleteWithPrefix(ctx context.Context, prefix, key string) e
 	return r.Delete(ctx, prefix+":"+key)
 }
 
+const scanBatchSize = 500
+
+// DeleteByPattern removes every key matching a glob pattern (e.g. "products:123:*").
+// It walks the keyspace with SCAN rather than KEYS so Redis is never blocked.
+func (r *RedisCache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	var deleted int64
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			n, err := r.client.Del(ctx, keys...).Result()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += n
+		}
+		if next == 0 {
+			return deleted, nil
+		}
+		cursor = next
+	}
+}
+
+func tagKey(tag string) string {
+	return "tag:" + tag
+}
+
+// SetWithTags caches a value and records its key under each tag, so everything
+// tagged together can be dropped with InvalidateTags
+func (r *RedisCache) SetWithTags(ctx context.Context, key string, value interface{}, expiration time.Duration, tags ...string) error {
+	if err := r.Set(ctx, key, value, expiration); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		setKey := tagKey(tag)
+		if err := r.client.SAdd(ctx, setKey, key).Err(); err != nil {
+			return err
+		}
+		// The tag set must outlive its longest-lived member, so its TTL is only ever extended
+		if expiration <= 0 {
+			if err := r.client.Persist(ctx, setKey).Err(); err != nil {
+				return err
+			}
+			continue
+		}
+		ttl, err := r.client.TTL(ctx, setKey).Result()
+		if err != nil {
+			return err
+		}
+		// A negative TTL means the set was just created without one
+		if ttl < expiration {
+			if err := r.client.Expire(ctx, setKey, expiration).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// InvalidateTags deletes every key recorded under the given tags, and the tag sets themselves
+func (r *RedisCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		setKey := tagKey(tag)
+		keys, err := r.client.SMembers(ctx, setKey).Result()
+		if err != nil {
+			return err
+		}
+		if err := r.client.Del(ctx, append(keys, setKey)...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Publish sends a JSON-encoded message to a pub/sub channel
+func (r *RedisCache) Publish(ctx context.Context, channel string, message interface{}) error {
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return r.client.Publish(ctx, channel, jsonData).Err()
+}
+
+// Subscribe subscribes to pub/sub channels; callers must Close the returned subscription
+func (r *RedisCache) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return r.client.Subscribe(ctx, channels...)
+}
+
+// Client exposes the underlying connection for packages built on Redis, like the job queue
+func (r *RedisCache) Client() *redis.Client {
+	return r.client
+}
+
 func (r *RedisCache) Close() error {
 	return r.client.Close()
 }
+
+type commandCancelKey struct{}
+
+// commandDeadline is a client hook that gives commands and pipelines without a deadline one
+type commandDeadline struct {
+	timeout time.Duration
+}
+
+func (h commandDeadline) bound(ctx context.Context) context.Context {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	return context.WithValue(ctx, commandCancelKey{}, cancel)
+}
+
+func (h commandDeadline) release(ctx context.Context) {
+	if cancel, ok := ctx.Value(commandCancelKey{}).(context.CancelFunc); ok {
+		cancel()
+	}
+}
+
+func (h commandDeadline) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return h.bound(ctx), nil
+}
+
+func (h commandDeadline) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.release(ctx)
+	return nil
+}
+
+func (h commandDeadline) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return h.bound(ctx), nil
+}
+
+func (h commandDeadline) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	h.release(ctx)
+	return nil
+}
@@ -0,0 +1,52 @@
+// Package clientinfo carries what a client reports about itself, its device and location, and
+// its network address through a request's context to the services that screen orders and refunds
+package clientinfo
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"golang-food-backend/pkg/geo"
+)
+
+// Info describes the client behind a request. Fields the client did not send are empty.
+type Info struct {
+	DeviceID  string
+	IPAddress string
+	Location  *geo.Point // where the device says it is
+}
+
+type infoKey struct{}
+
+// WithInfo attaches the client's details to ctx
+func WithInfo(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, infoKey{}, info)
+}
+
+// FromContext returns the client's details attached to ctx, or an empty Info
+func FromContext(ctx context.Context) Info {
+	if ctx != nil {
+		if info, ok := ctx.Value(infoKey{}).(Info); ok {
+			return info
+		}
+	}
+	return Info{}
+}
+
+// ParseLocation parses a "lat,lng" pair in degrees, returning nil when it is missing or invalid
+func ParseLocation(value string) *geo.Point {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return nil
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return nil
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return nil
+	}
+	return &geo.Point{Lat: lat, Lng: lng}
+}
@@ -2,10 +2,12 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"time"
 
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gorm.io/driver/postgres"
@@ -13,64 +15,135 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+// Options tunes the connection pools and slow-query logging, and adds an optional read replica
+type Options struct {
+	PostgresReplicaURL string // reads from requests that allow it go here when set
+	MaxOpenConns       int
+	MaxIdleConns       int
+	ConnMaxLifetime    time.Duration
+	ConnMaxIdleTime    time.Duration
+	MongoMaxPoolSize   uint64
+	MongoMinPoolSize   uint64
+	SlowQueryThreshold time.Duration // zero disables slow-query logging
+	QueryTimeout       time.Duration // bounds Postgres and Mongo calls made without a deadline; zero leaves them unbounded
+}
+
 type Database struct {
 	Postgres *gorm.DB
 	MongoDB  *mongo.Database
+
+	replica   *sql.DB
+	options   Options
+	mongoPool *mongoPoolMonitor
+	slow      *slowQueryRecorder
 }
 
-func NewDatabase(postgresURL, mongoURL, mongoDBName string) (*Database, error) {
+func NewDatabase(postgresURL, mongoURL, mongoDBName string, opts Options) (*Database, error) {
+	db := &Database{
+		options:   opts,
+		mongoPool: &mongoPoolMonitor{},
+		slow:      newSlowQueryRecorder(),
+	}
+
 	// Initialize PostgreSQL
-	postgresDB, err := initPostgreSQL(postgresURL)
+	postgresDB, err := db.initPostgreSQL(postgresURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to PostgreSQL: %v", err)
 	}
+	db.Postgres = postgresDB
 
 	// Initialize MongoDB
-	mongoDB, err := initMongoDB(mongoURL, mongoDBName)
+	mongoDB, err := db.initMongoDB(mongoURL, mongoDBName)
 	if err != nil {
 		// return nil, fmt.Errorf("failed to connect to MongoDB: %v", err)
 	}
+	db.MongoDB = mongoDB
 
-	return &Database{
-		Postgres: postgresDB,
-		MongoDB:  mongoDB,
-	}, nil
+	return db, nil
 }
 
-func initPostgreSQL(url string) (*gorm.DB, error) {
+func (db *Database) initPostgreSQL(url string) (*gorm.DB, error) {
 	config := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: &slowQueryLogger{
+			Interface: logger.Default.LogMode(logger.Info),
+			threshold: db.options.SlowQueryThreshold,
+			recorder:  db.slow,
+		},
 	}
 
-	db, err := gorm.Open(postgres.Open(url), config)
+	gormDB, err := gorm.Open(postgres.Open(url), config)
 	if err != nil {
 		return nil, err
 	}
 
-	sqlDB, err := db.DB()
+	sqlDB, err := gormDB.DB()
 	if err != nil {
 		return nil, err
 	}
+	if err := db.configurePool(sqlDB); err != nil {
+		return nil, err
+	}
+	log.Println("Connected to PostgreSQL successfully")
 
-	// Configure connection pool
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-
-	// Test connection
-	if err := sqlDB.Ping(); err != nil {
+	if err := gormDB.Use(&tenantGuard{}); err != nil {
 		return nil, err
 	}
+	if db.options.QueryTimeout > 0 {
+		if err := gormDB.Use(&queryTimeout{timeout: db.options.QueryTimeout}); err != nil {
+			return nil, err
+		}
+	}
 
-	log.Println("Connected to PostgreSQL successfully")
-	return db, nil
+	if db.options.PostgresReplicaURL != "" {
+		replica, err := sql.Open("pgx", db.options.PostgresReplicaURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open read replica: %v", err)
+		}
+		if err := db.configurePool(replica); err != nil {
+			return nil, fmt.Errorf("failed to connect to read replica: %v", err)
+		}
+		if err := gormDB.Use(&replicaResolver{replica: replica}); err != nil {
+			return nil, err
+		}
+		db.replica = replica
+		log.Println("Connected to PostgreSQL read replica successfully")
+	}
+
+	return gormDB, nil
+}
+
+// configurePool applies the pool settings and tests the connection
+func (db *Database) configurePool(sqlDB *sql.DB) error {
+	sqlDB.SetMaxOpenConns(db.options.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(db.options.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(db.options.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(db.options.ConnMaxIdleTime)
+	return sqlDB.Ping()
 }
 
-func initMongoDB(url, dbName string) (*mongo.Database, error) {
+func (db *Database) initMongoDB(url, dbName string) (*mongo.Database, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(url))
+	clientOptions := options.Client().ApplyURI(url).
+		SetPoolMonitor(&event.PoolMonitor{Event: db.mongoPool.event})
+	if db.options.MongoMaxPoolSize > 0 {
+		clientOptions.SetMaxPoolSize(db.options.MongoMaxPoolSize)
+	}
+	clientOptions.SetMinPoolSize(db.options.MongoMinPoolSize)
+	if db.options.QueryTimeout > 0 {
+		// The driver applies it only to operations whose context has no deadline
+		clientOptions.SetTimeout(db.options.QueryTimeout)
+	}
+	if db.options.SlowQueryThreshold > 0 {
+		clientOptions.SetMonitor(newMongoCommandMonitor(db.options.SlowQueryThreshold, db.slow))
+	}
+	db.mongoPool.maxPoolSize = defaultMongoPoolLimit // driver default
+	if clientOptions.MaxPoolSize != nil {
+		db.mongoPool.maxPoolSize = *clientOptions.MaxPoolSize
+	}
+
+	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -85,11 +158,22 @@ func initMongoDB(url, dbName string) (*mongo.Database, error) {
 	return client.Database(dbName), nil
 }
 
+// OnSlowQuery sets where slow queries are sent. The sink runs on a background goroutine, so a
+// slow sink delays the log rather than the queries; queries are dropped if it falls behind.
+func (db *Database) OnSlowQuery(sink func(SlowQuery)) {
+	db.slow.start(sink)
+}
+
 func (db *Database) Close() error {
+	db.slow.stop()
+
 	// Close PostgreSQL
 	if sqlDB, err := db.Postgres.DB(); err == nil {
 		sqlDB.Close()
 	}
+	if db.replica != nil {
+		db.replica.Close()
+	}
 
 	// Close MongoDB
 	if db.MongoDB != nil {
@@ -0,0 +1,258 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	slowQueryBuffer       = 256
+	maxStatementLength    = 2000
+	systemLogsCollection  = "system_logs" // where slow queries end up, so never itself monitored
+	defaultMongoPoolLimit = 100
+)
+
+// SlowQuery is a query that took longer than the slow-query threshold
+type SlowQuery struct {
+	Store      string        `json:"store"` // postgres or mongo
+	Statement  string        `json:"statement"`
+	Duration   time.Duration `json:"duration"`
+	Rows       int64         `json:"rows"` // postgres only; -1 when unknown
+	Error      string        `json:"error,omitempty"`
+	OccurredAt time.Time     `json:"occurred_at"`
+}
+
+// slowQueryRecorder hands slow queries to the sink without blocking the query path
+type slowQueryRecorder struct {
+	queue    chan SlowQuery
+	done     chan struct{}
+	once     sync.Once
+	started  int32
+	recorded int64
+	dropped  int64
+}
+
+func newSlowQueryRecorder() *slowQueryRecorder {
+	return &slowQueryRecorder{
+		queue: make(chan SlowQuery, slowQueryBuffer),
+		done:  make(chan struct{}),
+	}
+}
+
+func (r *slowQueryRecorder) start(sink func(SlowQuery)) {
+	if !atomic.CompareAndSwapInt32(&r.started, 0, 1) {
+		return
+	}
+	go func() {
+		for {
+			select {
+			case query := <-r.queue:
+				sink(query)
+			case <-r.done:
+				return
+			}
+		}
+	}()
+}
+
+func (r *slowQueryRecorder) stop() {
+	r.once.Do(func() { close(r.done) })
+}
+
+func (r *slowQueryRecorder) record(query SlowQuery) {
+	atomic.AddInt64(&r.recorded, 1)
+	if len(query.Statement) > maxStatementLength {
+		query.Statement = query.Statement[:maxStatementLength] + "…"
+	}
+	select {
+	case r.queue <- query:
+	default:
+		atomic.AddInt64(&r.dropped, 1)
+	}
+}
+
+// slowQueryLogger wraps the GORM logger to record statements slower than the threshold
+type slowQueryLogger struct {
+	logger.Interface
+	threshold time.Duration
+	recorder  *slowQueryRecorder
+}
+
+func (l *slowQueryLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.Interface = l.Interface.LogMode(level)
+	return &copied
+}
+
+func (l *slowQueryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	l.Interface.Trace(ctx, begin, fc, err)
+
+	elapsed := time.Since(begin)
+	if l.threshold <= 0 || elapsed < l.threshold {
+		return
+	}
+	statement, rows := fc()
+	query := SlowQuery{
+		Store:      "postgres",
+		Statement:  statement,
+		Duration:   elapsed,
+		Rows:       rows,
+		OccurredAt: begin,
+	}
+	if err != nil {
+		query.Error = err.Error()
+	}
+	l.recorder.record(query)
+}
+
+// newMongoCommandMonitor records Mongo commands slower than the threshold. The command text
+// is kept from the started event until the command finishes.
+func newMongoCommandMonitor(threshold time.Duration, recorder *slowQueryRecorder) *event.CommandMonitor {
+	var commands sync.Map // request ID -> command text
+
+	finished := func(e event.CommandFinishedEvent, failure string) {
+		statement, ok := commands.LoadAndDelete(e.RequestID)
+		if !ok || e.Duration < threshold {
+			return
+		}
+		recorder.record(SlowQuery{
+			Store:      "mongo",
+			Statement:  statement.(string),
+			Duration:   e.Duration,
+			Rows:       -1,
+			Error:      failure,
+			OccurredAt: time.Now().Add(-e.Duration),
+		})
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			elements, err := e.Command.Elements()
+			if err != nil || len(elements) == 0 {
+				return
+			}
+			if collection, ok := elements[0].Value().StringValueOK(); ok && collection == systemLogsCollection {
+				return
+			}
+			commands.Store(e.RequestID, e.Command.String())
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			finished(e.CommandFinishedEvent, "")
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			finished(e.CommandFinishedEvent, e.Failure)
+		},
+	}
+}
+
+// mongoPoolMonitor counts the Mongo driver's connections from its pool events
+type mongoPoolMonitor struct {
+	maxPoolSize      uint64
+	open             int64
+	inUse            int64
+	checkoutFailures int64
+}
+
+func (m *mongoPoolMonitor) event(e *event.PoolEvent) {
+	switch e.Type {
+	case event.ConnectionCreated:
+		atomic.AddInt64(&m.open, 1)
+	case event.ConnectionClosed:
+		atomic.AddInt64(&m.open, -1)
+	case event.GetSucceeded:
+		atomic.AddInt64(&m.inUse, 1)
+	case event.ConnectionReturned:
+		atomic.AddInt64(&m.inUse, -1)
+	case event.GetFailed:
+		atomic.AddInt64(&m.checkoutFailures, 1)
+	}
+}
+
+// PoolStats reports connection pool utilization and slow-query counts since startup
+type PoolStats struct {
+	Postgres        PostgresPoolStats  `json:"postgres"`
+	PostgresReplica *PostgresPoolStats `json:"postgres_replica,omitempty"`
+	Mongo           MongoPoolStats     `json:"mongo"`
+	SlowQueries     SlowQueryStats     `json:"slow_queries"`
+}
+
+type PostgresPoolStats struct {
+	MaxOpen           int     `json:"max_open"`
+	Open              int     `json:"open"`
+	InUse             int     `json:"in_use"`
+	Idle              int     `json:"idle"`
+	Utilization       float64 `json:"utilization"` // in use / max open
+	WaitCount         int64   `json:"wait_count"`  // connections callers had to wait for
+	WaitDurationMs    int64   `json:"wait_duration_ms"`
+	MaxIdleClosed     int64   `json:"max_idle_closed"`
+	MaxIdleTimeClosed int64   `json:"max_idle_time_closed"`
+	MaxLifetimeClosed int64   `json:"max_lifetime_closed"`
+}
+
+type MongoPoolStats struct {
+	MaxPoolSize      uint64  `json:"max_pool_size"`
+	Open             int64   `json:"open"`
+	InUse            int64   `json:"in_use"`
+	Utilization      float64 `json:"utilization"` // in use / max pool size
+	CheckoutFailures int64   `json:"checkout_failures"`
+}
+
+type SlowQueryStats struct {
+	ThresholdMs int64 `json:"threshold_ms"`
+	Recorded    int64 `json:"recorded"`
+	Dropped     int64 `json:"dropped"` // not logged because the sink fell behind
+}
+
+// Stats returns the current pool utilization
+func (db *Database) Stats() PoolStats {
+	var stats PoolStats
+
+	if sqlDB, err := db.Postgres.DB(); err == nil {
+		stats.Postgres = postgresPoolStats(sqlDB.Stats())
+	}
+	if db.replica != nil {
+		replica := postgresPoolStats(db.replica.Stats())
+		stats.PostgresReplica = &replica
+	}
+
+	stats.Mongo = MongoPoolStats{
+		MaxPoolSize:      db.mongoPool.maxPoolSize,
+		Open:             atomic.LoadInt64(&db.mongoPool.open),
+		InUse:            atomic.LoadInt64(&db.mongoPool.inUse),
+		CheckoutFailures: atomic.LoadInt64(&db.mongoPool.checkoutFailures),
+	}
+	if stats.Mongo.MaxPoolSize > 0 {
+		stats.Mongo.Utilization = float64(stats.Mongo.InUse) / float64(stats.Mongo.MaxPoolSize)
+	}
+
+	stats.SlowQueries = SlowQueryStats{
+		ThresholdMs: db.options.SlowQueryThreshold.Milliseconds(),
+		Recorded:    atomic.LoadInt64(&db.slow.recorded),
+		Dropped:     atomic.LoadInt64(&db.slow.dropped),
+	}
+	return stats
+}
+
+func postgresPoolStats(s sql.DBStats) PostgresPoolStats {
+	stats := PostgresPoolStats{
+		MaxOpen:           s.MaxOpenConnections,
+		Open:              s.OpenConnections,
+		InUse:             s.InUse,
+		Idle:              s.Idle,
+		WaitCount:         s.WaitCount,
+		WaitDurationMs:    s.WaitDuration.Milliseconds(),
+		MaxIdleClosed:     s.MaxIdleClosed,
+		MaxIdleTimeClosed: s.MaxIdleTimeClosed,
+		MaxLifetimeClosed: s.MaxLifetimeClosed,
+	}
+	if s.MaxOpenConnections > 0 {
+		stats.Utilization = float64(s.InUse) / float64(s.MaxOpenConnections)
+	}
+	return stats
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type routingKey struct{}
+
+type routing int
+
+const (
+	routeReplica routing = iota + 1
+	routePrimary
+)
+
+// WithReplica lets reads made with ctx go to the read replica. Only contexts that tolerate
+// replication lag should carry it, such as read-only requests.
+func WithReplica(ctx context.Context) context.Context {
+	if ctx.Value(routingKey{}) == routePrimary {
+		return ctx
+	}
+	return context.WithValue(ctx, routingKey{}, routeReplica)
+}
+
+// ForcePrimary sends every query made with ctx to the primary, for flows that read their own
+// writes. It wins over WithReplica whichever is applied first.
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routingKey{}, routePrimary)
+}
+
+// replicaResolver is a GORM plugin that sends reads to the replica when their context allows
+// it. Writes, reads inside transactions and locking reads always use the primary.
+type replicaResolver struct {
+	primary gorm.ConnPool
+	replica gorm.ConnPool
+}
+
+func (r *replicaResolver) Name() string {
+	return "replica_resolver"
+}
+
+func (r *replicaResolver) Initialize(db *gorm.DB) error {
+	r.primary = db.ConnPool
+
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("replica:query", r.route); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("replica:row", r.route); err != nil {
+		return err
+	}
+	// A chained statement that already read from the replica must write to the primary
+	if err := callbacks.Create().Before("gorm:create").Register("replica:create", r.restorePrimary); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("replica:update", r.restorePrimary); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("replica:delete", r.restorePrimary); err != nil {
+		return err
+	}
+	return callbacks.Raw().Before("gorm:raw").Register("replica:raw", r.restorePrimary)
+}
+
+func (r *replicaResolver) restorePrimary(db *gorm.DB) {
+	if db.Statement.ConnPool == r.replica {
+		db.Statement.ConnPool = r.primary
+	}
+}
+
+func (r *replicaResolver) route(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.Context == nil || stmt.Context.Value(routingKey{}) != routeReplica {
+		r.restorePrimary(db)
+		return
+	}
+	if _, inTransaction := stmt.ConnPool.(gorm.TxCommitter); inTransaction {
+		return
+	}
+	_, locking := stmt.Clauses["FOR"]
+	// Raw SQL run through Scan or Row may be a write with RETURNING
+	raw := strings.TrimSpace(stmt.SQL.String())
+	if locking || (raw != "" && !strings.HasPrefix(strings.ToUpper(raw), "SELECT")) {
+		r.restorePrimary(db)
+		return
+	}
+	stmt.ConnPool = r.replica
+}
@@ -0,0 +1,230 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"golang-food-backend/pkg/apperr"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCrossTenant is returned when a request scoped to one restaurant writes another
+// restaurant's records
+var ErrCrossTenant = apperr.Forbidden("cross_tenant", "record belongs to another restaurant")
+
+type tenantKey struct{}
+
+// WithTenant scopes queries made with ctx to the restaurant. Postgres orders, payments, refunds
+// and coupons are guarded by the tenant plugin; Mongo repositories check TenantFromContext.
+func WithTenant(ctx context.Context, restaurantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, restaurantID)
+}
+
+// TenantFromContext returns the restaurant ctx is scoped to, or "" when it is not scoped
+func TenantFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	restaurantID, _ := ctx.Value(tenantKey{}).(string)
+	return restaurantID
+}
+
+// tenantTable describes how a guarded table belongs to a restaurant: directly through its
+// restaurant_id, or through the order in its order_id
+type tenantTable struct {
+	viaOrder bool
+	shared   bool // rows without a restaurant are platform-wide and readable by every tenant
+}
+
+var tenantTables = map[string]tenantTable{
+	"orders":   {},
+	"coupons":  {shared: true},
+	"payments": {viaOrder: true},
+	"refunds":  {viaOrder: true},
+}
+
+// tenantGuard is a GORM plugin that keeps tenant-scoped statements on guarded tables to the
+// tenant's rows: reads and updates get a restaurant condition, and creates or updates carrying
+// another restaurant fail with ErrCrossTenant. Raw SQL is not inspected.
+type tenantGuard struct{}
+
+func (g *tenantGuard) Name() string {
+	return "tenant_guard"
+}
+
+func (g *tenantGuard) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:query", g.scopeRead); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant:row", g.scopeRead); err != nil {
+		return err
+	}
+	if err := callbacks.Create().Before("gorm:create").Register("tenant:create", g.checkCreate); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:update", g.scopeWrite); err != nil {
+		return err
+	}
+	return callbacks.Delete().Before("gorm:delete").Register("tenant:delete", g.scopeWrite)
+}
+
+func (g *tenantGuard) scopeRead(db *gorm.DB) {
+	if table, tenant, ok := guardedStatement(db); ok {
+		db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{tenantCondition(db.Statement.Table, table, tenant, table.shared)}})
+	}
+}
+
+func (g *tenantGuard) scopeWrite(db *gorm.DB) {
+	table, tenant, ok := guardedStatement(db)
+	if !ok {
+		return
+	}
+	if !table.viaOrder {
+		if err := checkTenantField(db, tenant, false); err != nil {
+			db.AddError(err)
+			return
+		}
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{tenantCondition(db.Statement.Table, table, tenant, false)}})
+}
+
+func (g *tenantGuard) checkCreate(db *gorm.DB) {
+	table, tenant, ok := guardedStatement(db)
+	if !ok {
+		return
+	}
+
+	var err error
+	if table.viaOrder {
+		err = checkTenantOrders(db, tenant)
+	} else {
+		err = checkTenantField(db, tenant, true)
+	}
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+
+	// Save falls back to an upsert when its update matched nothing; never let the upsert
+	// overwrite a row of another restaurant with the same key
+	if c, ok := db.Statement.Clauses["ON CONFLICT"]; ok {
+		if onConflict, ok := c.Expression.(clause.OnConflict); ok && (onConflict.UpdateAll || len(onConflict.DoUpdates) > 0) {
+			onConflict.Where.Exprs = append(onConflict.Where.Exprs, tenantCondition(db.Statement.Table, table, tenant, false))
+			db.Statement.AddClause(onConflict)
+		}
+	}
+}
+
+func guardedStatement(db *gorm.DB) (tenantTable, string, bool) {
+	if db.Error != nil || db.Statement.Context == nil {
+		return tenantTable{}, "", false
+	}
+	tenant := TenantFromContext(db.Statement.Context)
+	if tenant == "" {
+		return tenantTable{}, "", false
+	}
+	table, ok := tenantTables[db.Statement.Table]
+	return table, tenant, ok
+}
+
+func tenantCondition(tableName string, table tenantTable, tenant string, includeShared bool) clause.Expression {
+	if table.viaOrder {
+		return clause.Expr{
+			SQL:  "? IN (SELECT id FROM orders WHERE restaurant_id = ?)",
+			Vars: []interface{}{clause.Column{Table: tableName, Name: "order_id"}, tenant},
+		}
+	}
+
+	column := clause.Column{Table: tableName, Name: "restaurant_id"}
+	if includeShared {
+		return clause.Or(clause.Eq{Column: column, Value: tenant}, clause.Eq{Column: column, Value: nil})
+	}
+	return clause.Eq{Column: column, Value: tenant}
+}
+
+// checkTenantField rejects records whose restaurant_id is another restaurant's. On creates a
+// missing restaurant is rejected too; on updates a zero model (Model(&T{}).Updates) is fine as
+// the added condition limits the rows.
+func checkTenantField(db *gorm.DB, tenant string, requireSet bool) error {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	field := db.Statement.Schema.LookUpField("restaurant_id")
+	if field == nil {
+		return nil
+	}
+
+	return eachRecord(db.Statement.ReflectValue, func(record reflect.Value) error {
+		value, zero := field.ValueOf(db.Statement.Context, record)
+		if zero {
+			if requireSet {
+				return fmt.Errorf("%w: %s without a restaurant", ErrCrossTenant, db.Statement.Table)
+			}
+			return nil
+		}
+		if fmt.Sprint(value) != tenant {
+			return ErrCrossTenant
+		}
+		return nil
+	})
+}
+
+// checkTenantOrders rejects records that reference orders of other restaurants
+func checkTenantOrders(db *gorm.DB, tenant string) error {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	field := db.Statement.Schema.LookUpField("order_id")
+	if field == nil {
+		return nil
+	}
+
+	orderIDs := map[string]bool{}
+	err := eachRecord(db.Statement.ReflectValue, func(record reflect.Value) error {
+		value, zero := field.ValueOf(db.Statement.Context, record)
+		if zero {
+			return fmt.Errorf("%w: %s without an order", ErrCrossTenant, db.Statement.Table)
+		}
+		orderIDs[fmt.Sprint(value)] = true
+		return nil
+	})
+	if err != nil || len(orderIDs) == 0 {
+		return err
+	}
+
+	ids := make([]string, 0, len(orderIDs))
+	for id := range orderIDs {
+		ids = append(ids, id)
+	}
+	var owned int64
+	err = db.Session(&gorm.Session{NewDB: true}).
+		Table("orders").
+		Where("id IN ? AND restaurant_id = ?", ids, tenant).
+		Count(&owned).Error
+	if err != nil {
+		return err
+	}
+	if owned != int64(len(ids)) {
+		return ErrCrossTenant
+	}
+	return nil
+}
+
+func eachRecord(value reflect.Value, fn func(reflect.Value) error) error {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := fn(reflect.Indirect(value.Index(i))); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		return fn(value)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const queryTimeoutCancelKey = "query_timeout:cancel"
+
+// queryTimeout is a GORM plugin that bounds statements run without a deadline, such as those of
+// background jobs and Kafka consumers, so a stuck query cannot hold a connection forever.
+// Statements made with a request's context keep the request's deadline. The deadline covers
+// the statement's own transaction and associations. Row statements are left alone, as their
+// rows are read after the callbacks return.
+type queryTimeout struct {
+	timeout time.Duration
+}
+
+func (q *queryTimeout) Name() string {
+	return "query_timeout"
+}
+
+func (q *queryTimeout) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("*").Register("query_timeout:create", q.start); err != nil {
+		return err
+	}
+	if err := callbacks.Create().After("*").Register("query_timeout:create_done", q.finish); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("*").Register("query_timeout:query", q.start); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("*").Register("query_timeout:query_done", q.finish); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("*").Register("query_timeout:update", q.start); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("*").Register("query_timeout:update_done", q.finish); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("*").Register("query_timeout:delete", q.start); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("*").Register("query_timeout:delete_done", q.finish); err != nil {
+		return err
+	}
+	if err := callbacks.Raw().Before("*").Register("query_timeout:raw", q.start); err != nil {
+		return err
+	}
+	return callbacks.Raw().After("*").Register("query_timeout:raw_done", q.finish)
+}
+
+// start gives the statement a deadline when its context has none
+func (q *queryTimeout) start(db *gorm.DB) {
+	if _, ok := db.Statement.Context.Deadline(); ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(db.Statement.Context, q.timeout)
+	db.Statement.Context = ctx
+	db.InstanceSet(queryTimeoutCancelKey, cancel)
+}
+
+// finish releases the deadline start added, once the statement has run
+func (q *queryTimeout) finish(db *gorm.DB) {
+	if cancel, ok := db.InstanceGet(queryTimeoutCancelKey); ok {
+		cancel.(context.CancelFunc)()
+	}
+}
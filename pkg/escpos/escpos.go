@@ -0,0 +1,200 @@
+package escpos
+
+import (
+	"bytes"
+	"strings"
+)
+
+// ESC/POS commands understood by common 58mm and 80mm thermal printers
+var (
+	cmdInit        = []byte{0x1b, '@'}
+	cmdBoldOn      = []byte{0x1b, 'E', 1}
+	cmdBoldOff     = []byte{0x1b, 'E', 0}
+	cmdAlignLeft   = []byte{0x1b, 'a', 0}
+	cmdAlignCenter = []byte{0x1b, 'a', 1}
+	cmdDoubleOn    = []byte{0x1d, '!', 0x11} // double width and height
+	cmdDoubleOff   = []byte{0x1d, '!', 0x00}
+	cmdCut         = []byte{0x1d, 'V', 'B', 0} // feed to the cutter and cut
+)
+
+// Ticket builds a receipt as a plain-text ticket and an ESC/POS byte stream at once, so both
+// formats show the same lines. Width is the number of characters per line in the printer's
+// normal font: 32 for 58mm paper and 48 for 80mm. Text is ASCII; other characters are replaced.
+type Ticket struct {
+	width int
+	text  strings.Builder
+	raw   bytes.Buffer
+}
+
+func New(width int) *Ticket {
+	ticket := &Ticket{width: width}
+	ticket.raw.Write(cmdInit)
+	return ticket
+}
+
+// Line writes text left-aligned, wrapped to the ticket width
+func (t *Ticket) Line(text string) {
+	t.write(text, false, false, false)
+}
+
+// Bold writes text in bold, left-aligned and wrapped
+func (t *Ticket) Bold(text string) {
+	t.write(text, true, false, false)
+}
+
+// Title writes text centred in double-size bold, which fits half as many characters per line
+func (t *Ticket) Title(text string) {
+	t.write(text, true, true, true)
+}
+
+// Center writes text centred
+func (t *Ticket) Center(text string) {
+	t.write(text, false, false, true)
+}
+
+// Indent writes text wrapped with every line indented by prefix's width, starting with prefix
+func (t *Ticket) Indent(prefix, text string) {
+	pad := strings.Repeat(" ", len(prefix))
+	for i, line := range Wrap(sanitize(text), t.width-len(prefix)) {
+		if i == 0 {
+			t.emit(prefix + line)
+		} else {
+			t.emit(pad + line)
+		}
+	}
+}
+
+// Columns writes left and right on one line, right-aligned to the edge. A left side too long
+// for the line wraps and the right side goes on its last line.
+func (t *Ticket) Columns(left, right string) {
+	left, right = sanitize(left), sanitize(right)
+	lines := Wrap(left, t.width-len(right)-1)
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+	for _, line := range lines[:len(lines)-1] {
+		t.emit(line)
+	}
+	last := lines[len(lines)-1]
+	gap := t.width - len(last) - len(right)
+	if gap < 1 {
+		gap = 1
+	}
+	t.emit(last + strings.Repeat(" ", gap) + right)
+}
+
+// Rule writes a dashed line across the ticket
+func (t *Ticket) Rule() {
+	t.emit(strings.Repeat("-", t.width))
+}
+
+// Feed writes blank lines
+func (t *Ticket) Feed(lines int) {
+	for i := 0; i < lines; i++ {
+		t.emit("")
+	}
+}
+
+// Text returns the plain-text ticket
+func (t *Ticket) Text() string {
+	return t.text.String()
+}
+
+// Bytes returns the ESC/POS stream, ending with a paper cut
+func (t *Ticket) Bytes() []byte {
+	out := append([]byte{}, t.raw.Bytes()...)
+	return append(out, cmdCut...)
+}
+
+func (t *Ticket) write(text string, bold, double, center bool) {
+	width := t.width
+	if double {
+		width /= 2
+	}
+	if center {
+		t.raw.Write(cmdAlignCenter)
+	}
+	if bold {
+		t.raw.Write(cmdBoldOn)
+	}
+	if double {
+		t.raw.Write(cmdDoubleOn)
+	}
+
+	for _, line := range Wrap(sanitize(text), width) {
+		// The printer centres by itself; the text ticket is padded
+		padded := line
+		if center {
+			padded = strings.Repeat(" ", (t.width-len(line))/2) + line
+		}
+		t.text.WriteString(strings.TrimRight(padded, " ") + "\n")
+		t.raw.WriteString(line + "\n")
+	}
+
+	if double {
+		t.raw.Write(cmdDoubleOff)
+	}
+	if bold {
+		t.raw.Write(cmdBoldOff)
+	}
+	if center {
+		t.raw.Write(cmdAlignLeft)
+	}
+}
+
+// emit writes a line already fitted to the width
+func (t *Ticket) emit(line string) {
+	t.text.WriteString(strings.TrimRight(line, " ") + "\n")
+	t.raw.WriteString(line + "\n")
+}
+
+// Wrap splits text into lines of at most width characters, breaking at spaces where it can
+func Wrap(text string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if len(candidate) <= width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		// A word longer than the line is cut
+		for len(word) > width {
+			lines = append(lines, word[:width])
+			word = word[width:]
+		}
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// sanitize keeps printable ASCII, spelling out the rupee sign and replacing anything else.
+// Printer code pages differ, so nothing outside ASCII is sent.
+func sanitize(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '₹':
+			b.WriteString("Rs.")
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		case r == '\t' || r == '\n':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,195 @@
+// Package fieldcrypt encrypts personal data before it reaches the database. Values are sealed
+// with AES-256-GCM under a named data key and stored as enc:v1:<key id>:<base64 nonce+ciphertext>,
+// so keys can be rotated while older values stay readable. Encrypted columns cannot be searched,
+// so each one that is looked up gets a blind index: a keyed HMAC of the plaintext kept alongside.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+const prefix = "enc:v1:"
+
+var (
+	ErrUnknownKey = errors.New("fieldcrypt: value is encrypted with an unknown key")
+	ErrMalformed  = errors.New("fieldcrypt: malformed encrypted value")
+	ErrNoKeys     = errors.New("fieldcrypt: value is encrypted but no keys are configured")
+)
+
+// KeyRing holds the data keys values are encrypted with and the key blind indexes are made with.
+// New values are always sealed with the active key; the others are kept to read older values.
+type KeyRing struct {
+	keys     map[string]cipher.AEAD
+	activeID string
+	hashKey  []byte
+}
+
+// NewKeyRing builds a key ring from 32-byte AES keys by ID. Key IDs may only hold letters, digits
+// and dashes, as they are matched with LIKE when looking for values under an older key.
+func NewKeyRing(keys map[string][]byte, activeID string, hashKey []byte) (*KeyRing, error) {
+	if len(hashKey) < 32 {
+		return nil, errors.New("fieldcrypt: the hash key must be at least 32 bytes")
+	}
+	ring := &KeyRing{
+		keys:     make(map[string]cipher.AEAD, len(keys)),
+		activeID: activeID,
+		hashKey:  hashKey,
+	}
+	for id, key := range keys {
+		if !validKeyID(id) {
+			return nil, fmt.Errorf("fieldcrypt: key ID %q may only hold letters, digits and dashes", id)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("fieldcrypt: key %q is %d bytes, want 32", id, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		ring.keys[id] = aead
+	}
+	if _, ok := ring.keys[activeID]; !ok {
+		return nil, fmt.Errorf("fieldcrypt: active key %q is not among the data keys", activeID)
+	}
+	return ring, nil
+}
+
+// Encrypt seals the value with the active key. Empty values stay empty.
+func (r *KeyRing) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	aead := r.keys[r.activeID]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return r.ActivePrefix() + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed with any key on the ring. Values without the encrypted prefix
+// were written before encryption was turned on and are returned as they are.
+func (r *KeyRing) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	keyID, encoded, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !ok {
+		return "", ErrMalformed
+	}
+	aead, ok := r.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrMalformed
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("fieldcrypt: failed to decrypt with key %q: %v", keyID, err)
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex returns the value's lookup hash. It matches exact values only, so callers
+// normalise values the same way before storing and looking them up. Empty values hash to "".
+func (r *KeyRing) BlindIndex(value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, r.hashKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ActivePrefix is how every value sealed with the active key starts; stored values that do not
+// start with it are plaintext or under an older key
+func (r *KeyRing) ActivePrefix() string {
+	return prefix + r.activeID + ":"
+}
+
+// IsEncrypted reports whether a stored value is sealed rather than legacy plaintext
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+func validKeyID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// The ring models use through the pii serializer, set once at startup
+var active atomic.Pointer[KeyRing]
+
+// Use makes the ring the one models encrypt with. Until it is called, or after Use(nil), values
+// are written in plaintext and have no blind index.
+func Use(ring *KeyRing) {
+	active.Store(ring)
+}
+
+// Enabled reports whether a key ring is in use
+func Enabled() bool {
+	return active.Load() != nil
+}
+
+// Encrypt seals the value with the ring in use, or returns it unchanged without one
+func Encrypt(plaintext string) (string, error) {
+	ring := active.Load()
+	if ring == nil {
+		return plaintext, nil
+	}
+	return ring.Encrypt(plaintext)
+}
+
+// Decrypt opens the value with the ring in use; plaintext passes through
+func Decrypt(value string) (string, error) {
+	ring := active.Load()
+	if ring == nil {
+		if IsEncrypted(value) {
+			return "", ErrNoKeys
+		}
+		return value, nil
+	}
+	return ring.Decrypt(value)
+}
+
+// BlindIndex returns the value's lookup hash under the ring in use, or "" without one
+func BlindIndex(value string) string {
+	ring := active.Load()
+	if ring == nil {
+		return ""
+	}
+	return ring.BlindIndex(value)
+}
+
+// ActivePrefix returns the ring's active prefix, or "" when no ring is in use
+func ActivePrefix() string {
+	ring := active.Load()
+	if ring == nil {
+		return ""
+	}
+	return ring.ActivePrefix()
+}
@@ -0,0 +1,172 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Where data keys come from
+const (
+	KeySourceLocal = "local"
+	KeySourceKMS   = "kms"
+)
+
+// KMSConfig holds the AWS credentials data keys are unwrapped with
+type KMSConfig struct {
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// LoadKeys decodes id=value data key entries. With the local source each value is the base64
+// AES-256 key itself. With kms it is a data key wrapped by AWS KMS, the CiphertextBlob that
+// GenerateDataKey returns, and is unwrapped here so the plaintext key only ever lives in memory.
+func LoadKeys(ctx context.Context, source string, entries []string, kms KMSConfig) (map[string][]byte, error) {
+	var client *kmsClient
+	switch source {
+	case KeySourceLocal:
+	case KeySourceKMS:
+		if kms.AccessKey == "" || kms.SecretKey == "" {
+			return nil, errors.New("fieldcrypt: kms credentials are not configured")
+		}
+		client = newKMSClient(kms)
+	default:
+		return nil, fmt.Errorf("fieldcrypt: unknown key source %q", source)
+	}
+
+	keys := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("fieldcrypt: data key entry %q must be id=base64", entry)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, fmt.Errorf("fieldcrypt: data key %q is listed twice", id)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("fieldcrypt: data key %q is not valid base64", id)
+		}
+		if client != nil {
+			if raw, err = client.decrypt(ctx, raw); err != nil {
+				return nil, fmt.Errorf("fieldcrypt: failed to unwrap data key %q: %v", id, err)
+			}
+		}
+		keys[id] = raw
+	}
+	return keys, nil
+}
+
+// kmsClient calls the AWS KMS JSON API, signing requests with SigV4
+type kmsClient struct {
+	region    string
+	accessKey string
+	secretKey string
+	endpoint  string
+	client    *http.Client
+}
+
+func newKMSClient(config KMSConfig) *kmsClient {
+	return &kmsClient{
+		region:    config.Region,
+		accessKey: config.AccessKey,
+		secretKey: config.SecretKey,
+		endpoint:  fmt.Sprintf("https://kms.%s.amazonaws.com/", config.Region),
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// decrypt unwraps a data key. The ciphertext names its KMS key, so none is passed.
+func (c *kmsClient) decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{"CiphertextBlob": base64.StdEncoding.EncodeToString(ciphertext)})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService.Decrypt")
+	c.sign(req, body, time.Now())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("kms decrypt failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Plaintext string `json:"Plaintext"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("invalid kms response: %v", err)
+	}
+	return base64.StdEncoding.DecodeString(result.Plaintext)
+}
+
+// sign adds a SigV4 Authorization header covering the content type, host, date and target
+func (c *kmsClient) sign(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	scope := day + "/" + c.region + "/kms/aws4_request"
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	payloadHash := sha256.Sum256(body)
+	signedHeaders := "content-type;host;x-amz-date;x-amz-target"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + req.URL.Host,
+		"x-amz-date:" + amzDate,
+		"x-amz-target:" + req.Header.Get("X-Amz-Target"),
+		"",
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+c.secretKey), day)
+	signingKey = hmacSHA256(signingKey, c.region)
+	signingKey = hmacSHA256(signingKey, "kms")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
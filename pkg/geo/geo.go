@@ -0,0 +1,115 @@
+package geo
+
+import "math"
+
+const earthRadiusKm = 6371.0
+
+// Point is a latitude/longitude pair in degrees
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// DistanceKm returns the great-circle distance between two points using the haversine formula
+func DistanceKm(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// InPolygon reports whether p lies inside the polygon using ray casting
+func InPolygon(p Point, polygon []Point) bool {
+	if len(polygon) < 3 {
+		return false
+	}
+
+	inside := false
+	j := len(polygon) - 1
+	for i := 0; i < len(polygon); i++ {
+		pi, pj := polygon[i], polygon[j]
+		if (pi.Lat > p.Lat) != (pj.Lat > p.Lat) &&
+			p.Lng < (pj.Lng-pi.Lng)*(p.Lat-pi.Lat)/(pj.Lat-pi.Lat)+pi.Lng {
+			inside = !inside
+		}
+		j = i
+	}
+
+	return inside
+}
+
+// PolygonFromGeoJSON extracts the outer ring of a GeoJSON Polygon
+// ({"type": "Polygon", "coordinates": [[[lng, lat], ...]]})
+func PolygonFromGeoJSON(geometry map[string]interface{}) []Point {
+	rings, ok := geometry["coordinates"].([]interface{})
+	if !ok || len(rings) == 0 {
+		return nil
+	}
+
+	ring, ok := rings[0].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var polygon []Point
+	for _, coord := range ring {
+		pair, ok := coord.([]interface{})
+		if !ok || len(pair) < 2 {
+			continue
+		}
+		lng, lngOK := pair[0].(float64)
+		lat, latOK := pair[1].(float64)
+		if lngOK && latOK {
+			polygon = append(polygon, Point{Lat: lat, Lng: lng})
+		}
+	}
+
+	return polygon
+}
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash encodes p as a geohash string of the given length; precision 7 is a ~150m cell
+func Geohash(p Point, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lngRange := [2]float64{-180, 180}
+
+	hash := make([]byte, 0, precision)
+	bit, ch := 0, 0
+	evenBit := true
+
+	for len(hash) < precision {
+		if evenBit {
+			mid := (lngRange[0] + lngRange[1]) / 2
+			if p.Lng >= mid {
+				ch = ch<<1 | 1
+				lngRange[0] = mid
+			} else {
+				ch <<= 1
+				lngRange[1] = mid
+			}
+		} else {
+			mid := (latRange[0] + latRange[1]) / 2
+			if p.Lat >= mid {
+				ch = ch<<1 | 1
+				latRange[0] = mid
+			} else {
+				ch <<= 1
+				latRange[1] = mid
+			}
+		}
+		evenBit = !evenBit
+
+		if bit++; bit == 5 {
+			hash = append(hash, geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+
+	return string(hash)
+}
@@ -0,0 +1,41 @@
+package geo
+
+import (
+	"context"
+
+	"golang-food-backend/pkg/apperr"
+)
+
+var ErrNoResults = apperr.NotFound("place_not_found", "no matching place found")
+
+// Place is a geocoded address split into the parts the address book stores
+type Place struct {
+	PlaceID          string `json:"place_id"`
+	FormattedAddress string `json:"formatted_address"`
+	Street           string `json:"street,omitempty"`
+	Locality         string `json:"locality,omitempty"` // neighbourhood or sublocality
+	City             string `json:"city"`
+	State            string `json:"state"`
+	PostalCode       string `json:"postal_code"`
+	Country          string `json:"country"`
+	CountryCode      string `json:"country_code"`
+	Location         Point  `json:"location"`
+}
+
+// Suggestion is an autocomplete match. Location is set when the provider returns coordinates
+// with the match; otherwise the PlaceID is resolved with Lookup.
+type Suggestion struct {
+	PlaceID     string `json:"place_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Location    *Point `json:"location,omitempty"`
+}
+
+// Geocoder is a forward, reverse and autocomplete address lookup provider
+type Geocoder interface {
+	Name() string
+	Geocode(ctx context.Context, address string) ([]Place, error)
+	Reverse(ctx context.Context, p Point) (*Place, error)
+	Autocomplete(ctx context.Context, input string, near *Point) ([]Suggestion, error)
+	Lookup(ctx context.Context, placeID string) (*Place, error)
+}
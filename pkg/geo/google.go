@@ -0,0 +1,204 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GoogleGeocoder uses the Google Geocoding and Places Autocomplete APIs. Results are
+// restricted to countryCode when it is set.
+type GoogleGeocoder struct {
+	baseURL     string
+	apiKey      string
+	countryCode string
+	client      *http.Client
+}
+
+func NewGoogleGeocoder(apiKey, countryCode string) *GoogleGeocoder {
+	return &GoogleGeocoder{
+		baseURL:     "https://maps.googleapis.com/maps/api",
+		apiKey:      apiKey,
+		countryCode: strings.ToLower(countryCode),
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (g *GoogleGeocoder) Name() string {
+	return "google"
+}
+
+type googleGeocodeResult struct {
+	PlaceID           string `json:"place_id"`
+	FormattedAddress  string `json:"formatted_address"`
+	AddressComponents []struct {
+		LongName  string   `json:"long_name"`
+		ShortName string   `json:"short_name"`
+		Types     []string `json:"types"`
+	} `json:"address_components"`
+	Geometry struct {
+		Location struct {
+			Lat float64 `json:"lat"`
+			Lng float64 `json:"lng"`
+		} `json:"location"`
+	} `json:"geometry"`
+}
+
+func (g *GoogleGeocoder) Geocode(ctx context.Context, address string) ([]Place, error) {
+	params := url.Values{}
+	params.Set("address", address)
+	if g.countryCode != "" {
+		params.Set("components", "country:"+g.countryCode)
+	}
+	return g.geocode(ctx, params)
+}
+
+func (g *GoogleGeocoder) Reverse(ctx context.Context, p Point) (*Place, error) {
+	params := url.Values{}
+	params.Set("latlng", fmt.Sprintf("%f,%f", p.Lat, p.Lng))
+	places, err := g.geocode(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return &places[0], nil
+}
+
+func (g *GoogleGeocoder) Lookup(ctx context.Context, placeID string) (*Place, error) {
+	params := url.Values{}
+	params.Set("place_id", placeID)
+	places, err := g.geocode(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return &places[0], nil
+}
+
+func (g *GoogleGeocoder) geocode(ctx context.Context, params url.Values) ([]Place, error) {
+	var resp struct {
+		Status       string                `json:"status"`
+		ErrorMessage string                `json:"error_message"`
+		Results      []googleGeocodeResult `json:"results"`
+	}
+	if err := g.get(ctx, "/geocode/json", params, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Status == "ZERO_RESULTS" || (resp.Status == "OK" && len(resp.Results) == 0) {
+		return nil, ErrNoResults
+	}
+	if resp.Status != "OK" {
+		return nil, fmt.Errorf("google geocoding failed: %s %s", resp.Status, resp.ErrorMessage)
+	}
+
+	places := make([]Place, 0, len(resp.Results))
+	for _, result := range resp.Results {
+		places = append(places, googlePlace(result))
+	}
+	return places, nil
+}
+
+func (g *GoogleGeocoder) Autocomplete(ctx context.Context, input string, near *Point) ([]Suggestion, error) {
+	params := url.Values{}
+	params.Set("input", input)
+	if g.countryCode != "" {
+		params.Set("components", "country:"+g.countryCode)
+	}
+	if near != nil {
+		params.Set("location", fmt.Sprintf("%f,%f", near.Lat, near.Lng))
+		params.Set("radius", "20000")
+	}
+
+	var resp struct {
+		Status       string `json:"status"`
+		ErrorMessage string `json:"error_message"`
+		Predictions  []struct {
+			PlaceID              string `json:"place_id"`
+			Description          string `json:"description"`
+			StructuredFormatting struct {
+				MainText      string `json:"main_text"`
+				SecondaryText string `json:"secondary_text"`
+			} `json:"structured_formatting"`
+		} `json:"predictions"`
+	}
+	if err := g.get(ctx, "/place/autocomplete/json", params, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Status != "OK" && resp.Status != "ZERO_RESULTS" {
+		return nil, fmt.Errorf("google autocomplete failed: %s %s", resp.Status, resp.ErrorMessage)
+	}
+
+	suggestions := make([]Suggestion, 0, len(resp.Predictions))
+	for _, prediction := range resp.Predictions {
+		suggestions = append(suggestions, Suggestion{
+			PlaceID:     prediction.PlaceID,
+			Title:       prediction.StructuredFormatting.MainText,
+			Description: prediction.StructuredFormatting.SecondaryText,
+		})
+	}
+	return suggestions, nil
+}
+
+func (g *GoogleGeocoder) get(ctx context.Context, path string, params url.Values, dest interface{}) error {
+	params.Set("key", g.apiKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("google maps request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("google maps request failed with status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
+
+// googlePlace maps address components onto a Place. Cities fall back to the district when
+// Google has no locality, which happens for villages and some suburbs.
+func googlePlace(result googleGeocodeResult) Place {
+	place := Place{
+		PlaceID:          result.PlaceID,
+		FormattedAddress: result.FormattedAddress,
+		Location:         Point{Lat: result.Geometry.Location.Lat, Lng: result.Geometry.Location.Lng},
+	}
+
+	var streetNumber, route, district string
+	for _, component := range result.AddressComponents {
+		for _, componentType := range component.Types {
+			switch componentType {
+			case "street_number":
+				streetNumber = component.LongName
+			case "route":
+				route = component.LongName
+			case "sublocality_level_1", "sublocality", "neighborhood":
+				if place.Locality == "" {
+					place.Locality = component.LongName
+				}
+			case "locality":
+				place.City = component.LongName
+			case "administrative_area_level_2":
+				district = component.LongName
+			case "administrative_area_level_1":
+				place.State = component.LongName
+			case "postal_code":
+				place.PostalCode = component.LongName
+			case "country":
+				place.Country = component.LongName
+				place.CountryCode = strings.ToLower(component.ShortName)
+			}
+		}
+	}
+
+	place.Street = strings.TrimSpace(streetNumber + " " + route)
+	if place.City == "" {
+		place.City = district
+	}
+	return place
+}
@@ -0,0 +1,221 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NominatimGeocoder uses an OpenStreetMap Nominatim server. The public osm.org instance
+// forbids autocomplete and allows one request per second with an identifying user agent,
+// so production traffic should go to a self-hosted server.
+type NominatimGeocoder struct {
+	baseURL     string
+	userAgent   string
+	countryCode string
+	client      *http.Client
+}
+
+func NewNominatimGeocoder(baseURL, userAgent, countryCode string) *NominatimGeocoder {
+	return &NominatimGeocoder{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		userAgent:   userAgent,
+		countryCode: strings.ToLower(countryCode),
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *NominatimGeocoder) Name() string {
+	return "nominatim"
+}
+
+type nominatimResult struct {
+	OSMType     string `json:"osm_type"`
+	OSMID       int64  `json:"osm_id"`
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	DisplayName string `json:"display_name"`
+	Name        string `json:"name"`
+	Address     struct {
+		HouseNumber   string `json:"house_number"`
+		Road          string `json:"road"`
+		Neighbourhood string `json:"neighbourhood"`
+		Suburb        string `json:"suburb"`
+		City          string `json:"city"`
+		Town          string `json:"town"`
+		Village       string `json:"village"`
+		County        string `json:"county"`
+		StateDistrict string `json:"state_district"`
+		State         string `json:"state"`
+		Postcode      string `json:"postcode"`
+		Country       string `json:"country"`
+		CountryCode   string `json:"country_code"`
+	} `json:"address"`
+	Error string `json:"error"`
+}
+
+func (n *NominatimGeocoder) Geocode(ctx context.Context, address string) ([]Place, error) {
+	results, err := n.search(ctx, address, 5)
+	if err != nil {
+		return nil, err
+	}
+
+	places := make([]Place, 0, len(results))
+	for _, result := range results {
+		places = append(places, nominatimPlace(result))
+	}
+	return places, nil
+}
+
+func (n *NominatimGeocoder) Reverse(ctx context.Context, p Point) (*Place, error) {
+	params := url.Values{}
+	params.Set("lat", strconv.FormatFloat(p.Lat, 'f', -1, 64))
+	params.Set("lon", strconv.FormatFloat(p.Lng, 'f', -1, 64))
+
+	var result nominatimResult
+	if err := n.get(ctx, "/reverse", params, &result); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, ErrNoResults
+	}
+
+	place := nominatimPlace(result)
+	return &place, nil
+}
+
+// Lookup resolves a place ID of the form N123, W123 or R123 (OSM node, way or relation)
+func (n *NominatimGeocoder) Lookup(ctx context.Context, placeID string) (*Place, error) {
+	params := url.Values{}
+	params.Set("osm_ids", placeID)
+
+	var results []nominatimResult
+	if err := n.get(ctx, "/lookup", params, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNoResults
+	}
+
+	place := nominatimPlace(results[0])
+	return &place, nil
+}
+
+// Autocomplete runs a free-text search; Nominatim matches partial words at the end of the query
+func (n *NominatimGeocoder) Autocomplete(ctx context.Context, input string, near *Point) ([]Suggestion, error) {
+	results, err := n.search(ctx, input, 8)
+	if err == ErrNoResults {
+		return []Suggestion{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]Suggestion, 0, len(results))
+	for _, result := range results {
+		place := nominatimPlace(result)
+		title := result.Name
+		if title == "" {
+			title = strings.TrimSpace(strings.SplitN(result.DisplayName, ",", 2)[0])
+		}
+		location := place.Location
+		suggestions = append(suggestions, Suggestion{
+			PlaceID:     place.PlaceID,
+			Title:       title,
+			Description: result.DisplayName,
+			Location:    &location,
+		})
+	}
+
+	if near != nil {
+		// Nominatim has no location bias, so nearer matches are moved up instead
+		sortByDistance(suggestions, *near)
+	}
+	return suggestions, nil
+}
+
+func (n *NominatimGeocoder) search(ctx context.Context, query string, limit int) ([]nominatimResult, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("limit", strconv.Itoa(limit))
+	if n.countryCode != "" {
+		params.Set("countrycodes", n.countryCode)
+	}
+
+	var results []nominatimResult
+	if err := n.get(ctx, "/search", params, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNoResults
+	}
+	return results, nil
+}
+
+func (n *NominatimGeocoder) get(ctx context.Context, path string, params url.Values, dest interface{}) error {
+	params.Set("format", "jsonv2")
+	params.Set("addressdetails", "1")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", n.userAgent)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("nominatim request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("nominatim request failed with status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
+
+func nominatimPlace(result nominatimResult) Place {
+	lat, _ := strconv.ParseFloat(result.Lat, 64)
+	lng, _ := strconv.ParseFloat(result.Lon, 64)
+
+	address := result.Address
+	city := firstNonEmpty(address.City, address.Town, address.Village, address.StateDistrict, address.County)
+
+	placeID := ""
+	if result.OSMType != "" {
+		placeID = strings.ToUpper(result.OSMType[:1]) + strconv.FormatInt(result.OSMID, 10)
+	}
+
+	return Place{
+		PlaceID:          placeID,
+		FormattedAddress: result.DisplayName,
+		Street:           strings.TrimSpace(address.HouseNumber + " " + address.Road),
+		Locality:         firstNonEmpty(address.Suburb, address.Neighbourhood),
+		City:             city,
+		State:            address.State,
+		PostalCode:       address.Postcode,
+		Country:          address.Country,
+		CountryCode:      address.CountryCode,
+		Location:         Point{Lat: lat, Lng: lng},
+	}
+}
+
+func sortByDistance(suggestions []Suggestion, origin Point) {
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return DistanceKm(origin, *suggestions[i].Location) < DistanceKm(origin, *suggestions[j].Location)
+	})
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package i18n
+
+// catalogues translate English messages, keyed by language and then by the English text. Format
+// verbs and template actions must be kept as they are in the English text. Configured templates
+// (OTP messages) are only translated while they match the English default.
+var catalogues = map[string]map[string]string{
+	Hindi: {
+		// API errors
+		"Authorization header required":                  "ऑथराइज़ेशन हेडर आवश्यक है",
+		"Invalid authorization header format":            "ऑथराइज़ेशन हेडर का प्रारूप अमान्य है",
+		"Invalid token":                                  "अमान्य टोकन",
+		"Session has been revoked":                       "सत्र रद्द कर दिया गया है",
+		"Role information missing":                       "भूमिका की जानकारी उपलब्ध नहीं है",
+		"Insufficient permissions":                       "पर्याप्त अनुमतियाँ नहीं हैं",
+		"Admin login required":                           "एडमिन लॉगिन आवश्यक है",
+		"Restaurant access required":                     "रेस्टोरेंट एक्सेस आवश्यक है",
+		"X-Restaurant-ID does not match your restaurant": "X-Restaurant-ID आपके रेस्टोरेंट से मेल नहीं खाता",
+		"This feature is not available":                  "यह सुविधा उपलब्ध नहीं है",
+		"Internal server error":                          "आंतरिक सर्वर त्रुटि",
+
+		// Bill summary
+		"Item total":        "आइटम कुल",
+		"Coupon discount":   "कूपन छूट",
+		"Delivery fee":      "डिलीवरी शुल्क",
+		"Taxes":             "कर",
+		"Packaging charges": "पैकेजिंग शुल्क",
+		"Platform fee":      "प्लेटफ़ॉर्म शुल्क",
+		"Small order fee":   "छोटे ऑर्डर का शुल्क",
+		"Late night fee":    "देर रात का शुल्क",
+		"To pay":            "भुगतान राशि",
+
+		// Invoice email
+		"Your invoice %s from %s": "%[2]s से आपका इनवॉइस %[1]s",
+		"Hi %s,\n\nThank you for ordering from %s. Your invoice for order %s is attached.\n": "नमस्ते %s,\n\n%s से ऑर्डर करने के लिए धन्यवाद। ऑर्डर %s का इनवॉइस संलग्न है।\n",
+
+		// OTP templates (WhatsApp and email; SMS text must match its DLT registration)
+		"{{.Code}} is your verification code. It expires in {{.ExpiresInMinutes}} minutes. Never share it with anyone.": "{{.Code}} आपका सत्यापन कोड है। यह {{.ExpiresInMinutes}} मिनट में समाप्त हो जाएगा। इसे कभी किसी से साझा न करें।",
+		"Your verification code": "आपका सत्यापन कोड",
+		"Your verification code is {{.Code}}.\n\nIt expires in {{.ExpiresInMinutes}} minutes. If you did not request it, you can ignore this email.": "आपका सत्यापन कोड {{.Code}} है।\n\nयह {{.ExpiresInMinutes}} मिनट में समाप्त हो जाएगा। यदि आपने इसका अनुरोध नहीं किया है, तो इस ईमेल को अनदेखा करें।",
+	},
+}
@@ -0,0 +1,120 @@
+package i18n
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"golang-food-backend/pkg/apperr"
+)
+
+const DefaultCurrency = "INR"
+
+var ErrUnsupportedCurrency = apperr.Validation("currency_unsupported", "unsupported currency")
+
+// Currency is an ISO 4217 currency. Digits is the number of minor units in a major unit as a
+// power of ten: 2 for paise in a rupee, 0 for yen.
+type Currency struct {
+	Code   string `json:"code"`
+	Symbol string `json:"symbol"`
+	Digits int    `json:"digits"`
+}
+
+var currencies = map[string]Currency{
+	"INR": {Code: "INR", Symbol: "₹", Digits: 2},
+	"USD": {Code: "USD", Symbol: "$", Digits: 2},
+	"EUR": {Code: "EUR", Symbol: "€", Digits: 2},
+	"GBP": {Code: "GBP", Symbol: "£", Digits: 2},
+	"AED": {Code: "AED", Symbol: "AED ", Digits: 2},
+	"SGD": {Code: "SGD", Symbol: "S$", Digits: 2},
+	"NPR": {Code: "NPR", Symbol: "Rs ", Digits: 2},
+	"LKR": {Code: "LKR", Symbol: "Rs ", Digits: 2},
+	"JPY": {Code: "JPY", Symbol: "¥", Digits: 0},
+}
+
+// LookupCurrency returns a supported currency by its code
+func LookupCurrency(code string) (Currency, error) {
+	currency, ok := currencies[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return Currency{}, ErrUnsupportedCurrency
+	}
+	return currency, nil
+}
+
+// CurrencyOf returns the currency of a code, or the default currency for empty or unknown codes
+// (rows written before currencies were stored)
+func CurrencyOf(code string) Currency {
+	if currency, err := LookupCurrency(code); err == nil {
+		return currency
+	}
+	return currencies[DefaultCurrency]
+}
+
+// ToMinor converts an amount to whole minor units, rounding half away from zero, so payment
+// providers are never sent a truncated amount
+func (c Currency) ToMinor(amount float64) int64 {
+	return int64(math.Round(amount * math.Pow10(c.Digits)))
+}
+
+// FromMinor converts whole minor units to an amount
+func (c Currency) FromMinor(minor int64) float64 {
+	return float64(minor) / math.Pow10(c.Digits)
+}
+
+// Round rounds an amount to the currency's minor unit
+func (c Currency) Round(amount float64) float64 {
+	return c.FromMinor(c.ToMinor(amount))
+}
+
+// FormatAmount formats an amount with the currency's minor digits and digit grouping, without
+// a symbol: 1,23,456.50 for rupees (lakh grouping), 123,456.50 for other currencies
+func FormatAmount(amount float64, currencyCode string) string {
+	currency := CurrencyOf(currencyCode)
+	minor := currency.ToMinor(amount)
+
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	unit := int64(math.Pow10(currency.Digits))
+	whole := group(strconv.FormatInt(minor/unit, 10), currency.Code == "INR")
+	if currency.Digits == 0 {
+		return sign + whole
+	}
+
+	fraction := strconv.FormatInt(minor%unit, 10)
+	fraction = strings.Repeat("0", currency.Digits-len(fraction)) + fraction
+	return sign + whole + "." + fraction
+}
+
+// FormatMoney formats an amount with the currency's symbol, as ₹1,250.00 or -$3.20
+func FormatMoney(amount float64, currencyCode string) string {
+	formatted := FormatAmount(amount, currencyCode)
+	symbol := CurrencyOf(currencyCode).Symbol
+	if strings.HasPrefix(formatted, "-") {
+		return "-" + symbol + formatted[1:]
+	}
+	return symbol + formatted
+}
+
+// group inserts thousands separators; lakh grouping keeps the last three digits together and
+// groups the rest in twos
+func group(digits string, lakh bool) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	head, tail := digits[:len(digits)-3], digits[len(digits)-3:]
+	size := 3
+	if lakh {
+		size = 2
+	}
+	var groups []string
+	for len(head) > size {
+		groups = append([]string{head[len(head)-size:]}, groups...)
+		head = head[:len(head)-size]
+	}
+	groups = append([]string{head}, groups...)
+	return strings.Join(append(groups, tail), ",")
+}
@@ -0,0 +1,107 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Languages with a message catalogue. English is the default and the fallback for messages a
+// catalogue does not translate.
+const (
+	English = "en"
+	Hindi   = "hi"
+)
+
+const DefaultLanguage = English
+
+type languageKey struct{}
+
+// Supported reports whether lang has a message catalogue
+func Supported(lang string) bool {
+	if lang == English {
+		return true
+	}
+	_, ok := catalogues[lang]
+	return ok
+}
+
+// Normalize returns the supported language of a language tag such as hi-IN, or "" when the
+// language is not supported
+func Normalize(tag string) string {
+	lang := strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if Supported(lang) {
+		return lang
+	}
+	return ""
+}
+
+// Negotiate picks the supported language the client prefers most from an Accept-Language
+// header, or the default language
+func Negotiate(acceptLanguage string) string {
+	type preference struct {
+		lang    string
+		quality float64
+	}
+
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if lang := Normalize(fields[0]); lang != "" && quality > 0 {
+			preferences = append(preferences, preference{lang: lang, quality: quality})
+		}
+	}
+	if len(preferences) == 0 {
+		return DefaultLanguage
+	}
+
+	// Stable so equally weighted languages keep the client's order
+	sort.SliceStable(preferences, func(i, j int) bool { return preferences[i].quality > preferences[j].quality })
+	return preferences[0].lang
+}
+
+// WithLanguage sets the language messages rendered with ctx are translated to
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageKey{}, lang)
+}
+
+// LanguageFromContext returns the language of ctx, or the default language
+func LanguageFromContext(ctx context.Context) string {
+	if ctx != nil {
+		if lang, ok := ctx.Value(languageKey{}).(string); ok && lang != "" {
+			return lang
+		}
+	}
+	return DefaultLanguage
+}
+
+// T translates an English message to lang and formats it with args like fmt.Sprintf. Messages
+// are looked up by their English text, so untranslated messages come back in English.
+func T(lang, message string, args ...interface{}) string {
+	if translated, ok := catalogues[lang][message]; ok {
+		message = translated
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Translate translates a message to the language of ctx
+func Translate(ctx context.Context, message string, args ...interface{}) string {
+	return T(LanguageFromContext(ctx), message, args...)
+}
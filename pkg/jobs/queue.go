@@ -0,0 +1,502 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"golang-food-backend/pkg/apperr"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// Job statuses
+const (
+	StatusPending   = "pending"   // waiting for a worker
+	StatusScheduled = "scheduled" // waiting for its run time, including retries
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusDead      = "dead" // attempts exhausted; kept until retried
+)
+
+// Statuses lists every job status
+var Statuses = []string{StatusPending, StatusScheduled, StatusRunning, StatusCompleted, StatusDead}
+
+const (
+	keyPrefix      = "jobs:"
+	pendingKey     = keyPrefix + "pending"   // list of job IDs ready to run
+	scheduledKey   = keyPrefix + "scheduled" // zset of job IDs by run time
+	runningKey     = keyPrefix + "running"   // zset of job IDs by lease expiry
+	pollInterval   = time.Second
+	promoteBatch   = 100
+	leaseMargin    = time.Minute        // added to the job timeout before a running job is presumed lost
+	retention      = 7 * 24 * time.Hour // completed jobs are kept this long
+	initialBackoff = 10 * time.Second
+	maxBackoff     = 30 * time.Minute
+)
+
+var (
+	ErrJobNotFound = apperr.NotFound("job_not_found", "job not found")
+	ErrJobInvalid  = apperr.Validation("job_invalid", "invalid job request")
+)
+
+// Job is one unit of background work. Payload holds the typed arguments of its Type.
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	Timeout     time.Duration   `json:"timeout"`
+	LastError   string          `json:"last_error,omitempty"`
+	RunAt       time.Time       `json:"run_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+}
+
+// Decode unmarshals the job's payload
+func (j *Job) Decode(v interface{}) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return fmt.Errorf("invalid %s payload: %v", j.Type, err)
+	}
+	return nil
+}
+
+// HandlerFunc runs a job. An error retries the job with backoff until its attempts run out.
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+// Option customises a job when it is enqueued
+type Option func(*Job)
+
+// ProcessAt schedules the job to run at t
+func ProcessAt(t time.Time) Option {
+	return func(j *Job) { j.RunAt = t }
+}
+
+// ProcessIn schedules the job to run after d
+func ProcessIn(d time.Duration) Option {
+	return func(j *Job) { j.RunAt = time.Now().Add(d) }
+}
+
+// MaxAttempts overrides the queue's default number of attempts
+func MaxAttempts(n int) Option {
+	return func(j *Job) {
+		if n > 0 {
+			j.MaxAttempts = n
+		}
+	}
+}
+
+// Timeout bounds a single attempt
+func Timeout(d time.Duration) Option {
+	return func(j *Job) {
+		if d > 0 {
+			j.Timeout = d
+		}
+	}
+}
+
+// Config tunes the queue
+type Config struct {
+	Workers     int           // jobs run concurrently
+	MaxAttempts int           // default attempts per job
+	Timeout     time.Duration // default time limit per attempt
+}
+
+// Queue is a Redis-backed job queue. Services register a handler per job type and enqueue
+// jobs from request paths; the worker pool started with Start runs them, retrying failures
+// with exponential backoff. Jobs whose worker died mid-run are requeued once their lease expires.
+type Queue struct {
+	client   *redis.Client
+	config   Config
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewQueue(client *redis.Client, config Config) *Queue {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Minute
+	}
+	return &Queue{
+		client:   client,
+		config:   config,
+		handlers: make(map[string]HandlerFunc),
+	}
+}
+
+// Handle registers the handler for a job type
+func (q *Queue) Handle(jobType string, handler HandlerFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+func jobKey(id string) string {
+	return keyPrefix + "job:" + id
+}
+
+func statusKey(status string) string {
+	return keyPrefix + "status:" + status
+}
+
+// Enqueue adds a job whose payload is JSON-encoded from payload
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload interface{}, options ...Option) (*Job, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to encode %s payload: %v", ErrJobInvalid, jobType, err)
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:          uuid.NewString(),
+		Type:        jobType,
+		Payload:     encoded,
+		MaxAttempts: q.config.MaxAttempts,
+		Timeout:     q.config.Timeout,
+		RunAt:       now,
+		CreatedAt:   now,
+	}
+	for _, option := range options {
+		option(job)
+	}
+
+	if job.RunAt.After(now) {
+		err = q.transition(ctx, job, StatusScheduled, func(pipe redis.Pipeliner) {
+			pipe.ZAdd(ctx, scheduledKey, &redis.Z{Score: float64(job.RunAt.Unix()), Member: job.ID})
+		})
+	} else {
+		err = q.transition(ctx, job, StatusPending, func(pipe redis.Pipeliner) {
+			pipe.LPush(ctx, pendingKey, job.ID)
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue %s job: %v", jobType, err)
+	}
+	return job, nil
+}
+
+// transition saves the job under its new status and moves it to that status's index in one
+// transaction, together with any queue changes queued by extra
+func (q *Queue) transition(ctx context.Context, job *Job, to string, extra func(redis.Pipeliner)) error {
+	job.Status = to
+	job.UpdatedAt = time.Now()
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	expiration := time.Duration(0)
+	if to == StatusCompleted {
+		expiration = retention
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, jobKey(job.ID), data, expiration)
+		for _, status := range Statuses {
+			if status != to {
+				pipe.ZRem(ctx, statusKey(status), job.ID)
+			}
+		}
+		pipe.ZAdd(ctx, statusKey(to), &redis.Z{Score: float64(job.UpdatedAt.Unix()), Member: job.ID})
+		if extra != nil {
+			extra(pipe)
+		}
+		return nil
+	})
+	return err
+}
+
+// Get returns a job by ID
+func (q *Queue) Get(ctx context.Context, id string) (*Job, error) {
+	data, err := q.client.Get(ctx, jobKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("corrupt job %s: %v", id, err)
+	}
+	return &job, nil
+}
+
+// List returns jobs in a status, most recently updated first, and the number in that status
+func (q *Queue) List(ctx context.Context, status string, offset, limit int) ([]Job, int64, error) {
+	if !validStatus(status) {
+		return nil, 0, fmt.Errorf("%w: unknown status %q", ErrJobInvalid, status)
+	}
+
+	key := statusKey(status)
+	total, err := q.client.ZCard(ctx, key).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	ids, err := q.client.ZRevRange(ctx, key, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	jobs := make([]Job, 0, len(ids))
+	for _, id := range ids {
+		job, err := q.Get(ctx, id)
+		if errors.Is(err, ErrJobNotFound) {
+			// Completed jobs expire before their index entry is trimmed
+			q.client.ZRem(ctx, key, id)
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, total, nil
+}
+
+// Counts returns the number of jobs in each status
+func (q *Queue) Counts(ctx context.Context) (map[string]int64, error) {
+	counts := make(map[string]int64, len(Statuses))
+	for _, status := range Statuses {
+		count, err := q.client.ZCard(ctx, statusKey(status)).Result()
+		if err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, nil
+}
+
+// Retry gives a dead job a fresh set of attempts
+func (q *Queue) Retry(ctx context.Context, id string) (*Job, error) {
+	job, err := q.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != StatusDead {
+		return nil, fmt.Errorf("%w: only dead jobs can be retried, job is %s", ErrJobInvalid, job.Status)
+	}
+
+	job.Attempts = 0
+	job.RunAt = time.Now()
+	if err := q.transition(ctx, job, StatusPending, func(pipe redis.Pipeliner) {
+		pipe.LPush(ctx, pendingKey, job.ID)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to retry job: %v", err)
+	}
+	return job, nil
+}
+
+func validStatus(status string) bool {
+	for _, known := range Statuses {
+		if known == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Start runs the scheduler and the worker pool until Stop is called
+func (q *Queue) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	q.wg.Add(1)
+	go q.schedule(ctx)
+	for i := 0; i < q.config.Workers; i++ {
+		q.wg.Add(1)
+		go q.work(ctx)
+	}
+	log.Printf("🧵 Job queue started with %d workers", q.config.Workers)
+}
+
+// Stop stops taking new jobs and waits for running ones to finish
+func (q *Queue) Stop() {
+	if q.cancel == nil {
+		return
+	}
+	q.cancel()
+	q.wg.Wait()
+	q.cancel = nil
+	log.Println("🛑 Job queue stopped")
+}
+
+// promoteScript moves up to ARGV[2] members of the zset KEYS[1] scored at or below ARGV[1]
+// onto the pending list KEYS[2]
+var promoteScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('LPUSH', KEYS[2], id)
+end
+return ids
+`)
+
+// schedule moves scheduled jobs that are due, and running jobs whose lease expired, onto the
+// pending list
+func (q *Queue) schedule(ctx context.Context) {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := fmt.Sprint(time.Now().Unix())
+			if _, err := q.promote(ctx, scheduledKey, now); err != nil {
+				log.Printf("❌ Error promoting scheduled jobs: %v", err)
+			}
+			expired, err := q.promote(ctx, runningKey, now)
+			if err != nil {
+				log.Printf("❌ Error requeueing expired jobs: %v", err)
+			}
+			for _, id := range expired {
+				log.Printf("⚠️ Job %s lease expired; requeueing", id)
+			}
+		case <-cleanup.C:
+			cutoff := fmt.Sprint(time.Now().Add(-retention).Unix())
+			if err := q.client.ZRemRangeByScore(ctx, statusKey(StatusCompleted), "-inf", cutoff).Err(); err != nil {
+				log.Printf("❌ Error trimming completed jobs: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// promote moves due job IDs onto the pending list. The job records keep their old status until
+// a worker picks them up.
+func (q *Queue) promote(ctx context.Context, from, now string) ([]string, error) {
+	ids, err := promoteScript.Run(ctx, q.client, []string{from, pendingKey}, now, promoteBatch).StringSlice()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (q *Queue) work(ctx context.Context) {
+	defer q.wg.Done()
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		result, err := q.client.BRPop(ctx, 2*time.Second, pendingKey).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error fetching job: %v", err)
+			time.Sleep(pollInterval)
+			continue
+		}
+
+		q.run(result[1])
+	}
+}
+
+// run executes one attempt of a job. It runs to completion even if the queue is stopping, so
+// Stop waits for it rather than abandoning it half done.
+func (q *Queue) run(id string) {
+	ctx := context.Background()
+	job, err := q.Get(ctx, id)
+	if err != nil {
+		log.Printf("Skipping job %s: %v", id, err)
+		return
+	}
+
+	job.Attempts++
+	lease := time.Now().Add(job.Timeout + leaseMargin)
+	if err := q.transition(ctx, job, StatusRunning, func(pipe redis.Pipeliner) {
+		pipe.ZAdd(ctx, runningKey, &redis.Z{Score: float64(lease.Unix()), Member: job.ID})
+	}); err != nil {
+		log.Printf("Failed to start job %s: %v", id, err)
+		return
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	err = q.execute(runCtx, job)
+	cancel()
+
+	releaseLease := func(pipe redis.Pipeliner) {
+		pipe.ZRem(ctx, runningKey, job.ID)
+	}
+
+	if err == nil {
+		now := time.Now()
+		job.CompletedAt = &now
+		job.LastError = ""
+		if err := q.transition(ctx, job, StatusCompleted, releaseLease); err != nil {
+			log.Printf("Failed to complete job %s: %v", id, err)
+		}
+		return
+	}
+
+	job.LastError = err.Error()
+	if job.Attempts >= job.MaxAttempts {
+		log.Printf("⚠️ Job %s (%s) failed after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+		if err := q.transition(ctx, job, StatusDead, releaseLease); err != nil {
+			log.Printf("Failed to update job %s: %v", id, err)
+		}
+		return
+	}
+
+	job.RunAt = time.Now().Add(backoff(job.Attempts))
+	if err := q.transition(ctx, job, StatusScheduled, func(pipe redis.Pipeliner) {
+		releaseLease(pipe)
+		pipe.ZAdd(ctx, scheduledKey, &redis.Z{Score: float64(job.RunAt.Unix()), Member: job.ID})
+	}); err != nil {
+		log.Printf("Failed to reschedule job %s: %v", id, err)
+	}
+}
+
+// execute runs the job's handler, turning a panic into an error
+func (q *Queue) execute(ctx context.Context, job *Job) (err error) {
+	q.mu.RLock()
+	handler, ok := q.handlers[job.Type]
+	q.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no handler for job type %s", job.Type)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// backoff doubles the wait after each failed attempt
+func backoff(attempt int) time.Duration {
+	wait := initialBackoff
+	for i := 1; i < attempt && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	return wait
+}
@@ -0,0 +1,226 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Handler processes one message. An error retries the message and, once retries run out,
+// sends it to DeadLetterTopic.
+type Handler func([]byte) error
+
+// SubscribeOptions tunes one subscription
+type SubscribeOptions struct {
+	Concurrency int         // readers in the group; Kafka gives each its own partitions
+	Retry       RetryPolicy // handler attempts before the message is dead-lettered
+}
+
+// DefaultConsumerRetry is used by subscriptions that do not set a retry policy
+var DefaultConsumerRetry = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+type subscription struct {
+	topic   string
+	groupID string
+	handler Handler
+	options SubscribeOptions
+}
+
+// KafkaConsumer runs the service's consumer groups. Services subscribe handlers before Start;
+// each message is committed only after its handler succeeds or the message is dead-lettered, so
+// messages in flight at shutdown are redelivered rather than lost.
+type KafkaConsumer struct {
+	mu            sync.Mutex
+	brokers       []string
+	producer      *KafkaProducer // publishes dead letters
+	subscriptions []subscription
+	readers       []*kafka.Reader
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+func NewKafkaConsumer(brokers []string, producer *KafkaProducer) *KafkaConsumer {
+	return &KafkaConsumer{
+		brokers:  brokers,
+		producer: producer,
+	}
+}
+
+// Subscribe registers a handler for a topic under a consumer group. Subscriptions made after
+// Start begin consuming immediately.
+func (kc *KafkaConsumer) Subscribe(topic, groupID string, handler Handler, options SubscribeOptions) {
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+	if options.Retry.MaxAttempts < 1 {
+		options.Retry = DefaultConsumerRetry
+	}
+	sub := subscription{topic: topic, groupID: groupID, handler: handler, options: options}
+
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	kc.subscriptions = append(kc.subscriptions, sub)
+	if kc.ctx != nil {
+		kc.run(sub)
+	}
+}
+
+// Start begins consuming every subscription
+func (kc *KafkaConsumer) Start() error {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+
+	if kc.ctx != nil {
+		return errors.New("kafka consumer is already running")
+	}
+	kc.ctx, kc.cancel = context.WithCancel(context.Background())
+
+	for _, sub := range kc.subscriptions {
+		kc.run(sub)
+	}
+	log.Printf("📥 Kafka consumer started with %d subscriptions", len(kc.subscriptions))
+	return nil
+}
+
+// Stop stops fetching, waits for handlers in flight to finish and closes the readers
+func (kc *KafkaConsumer) Stop() {
+	kc.mu.Lock()
+	if kc.ctx == nil {
+		kc.mu.Unlock()
+		return
+	}
+	kc.cancel()
+	kc.mu.Unlock()
+
+	kc.wg.Wait()
+
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	for _, reader := range kc.readers {
+		reader.Close()
+	}
+	kc.readers = nil
+	kc.ctx = nil
+	log.Println("🛑 Kafka consumer stopped")
+}
+
+// run starts the subscription's readers. Callers hold kc.mu.
+func (kc *KafkaConsumer) run(sub subscription) {
+	for i := 0; i < sub.options.Concurrency; i++ {
+		reader := kafka.NewReader(kafka.ReaderConfig{
+			Brokers:  kc.brokers,
+			Topic:    sub.topic,
+			GroupID:  sub.groupID,
+			MinBytes: 10e3, // 10KB
+			MaxBytes: 10e6, // 10MB
+		})
+		kc.readers = append(kc.readers, reader)
+
+		kc.wg.Add(1)
+		go kc.consume(kc.ctx, reader, sub)
+	}
+}
+
+func (kc *KafkaConsumer) consume(ctx context.Context, reader *kafka.Reader, sub subscription) {
+	defer kc.wg.Done()
+
+	for {
+		message, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error reading message from topic %s: %v", sub.topic, err)
+			if !sleep(ctx, time.Second) {
+				return
+			}
+			continue
+		}
+
+		if !kc.handle(ctx, sub, message) {
+			return // stopped while retrying; the message is redelivered after restart
+		}
+		if err := reader.CommitMessages(context.Background(), message); err != nil {
+			log.Printf("Failed to commit offset %d on %s/%d for group %s: %v", message.Offset, sub.topic, message.Partition, sub.groupID, err)
+		}
+	}
+}
+
+// handle runs the handler with retries and dead-letters the message when they run out. It
+// returns false if the consumer was stopped before the message was settled.
+func (kc *KafkaConsumer) handle(ctx context.Context, sub subscription, message kafka.Message) bool {
+	var err error
+	for attempt := 1; attempt <= sub.options.Retry.MaxAttempts; attempt++ {
+		if err = safeHandle(sub.handler, message.Value); err == nil {
+			return true
+		}
+		if attempt == sub.options.Retry.MaxAttempts {
+			break
+		}
+		if !sleep(ctx, sub.options.Retry.Backoff(attempt)) {
+			return false
+		}
+	}
+
+	log.Printf("Error handling message at %s/%d offset %d for group %s: %v", sub.topic, message.Partition, message.Offset, sub.groupID, err)
+	kc.deadLetter(sub, message, err)
+	return true
+}
+
+// deadLetter sends a message its handler gave up on to DeadLetterTopic
+func (kc *KafkaConsumer) deadLetter(sub subscription, message kafka.Message, cause error) {
+	payload := json.RawMessage(message.Value)
+	if !json.Valid(message.Value) {
+		payload, _ = json.Marshal(string(message.Value))
+	}
+
+	letter, err := json.Marshal(DeadLetter{
+		Topic:         sub.topic,
+		Key:           string(message.Key),
+		Payload:       payload,
+		Error:         cause.Error(),
+		Attempts:      sub.options.Retry.MaxAttempts,
+		FailedAt:      time.Now(),
+		ConsumerGroup: sub.groupID,
+		Partition:     message.Partition,
+		Offset:        message.Offset,
+	})
+	if err != nil {
+		log.Printf("Failed to encode dead letter for %s offset %d: %v", sub.topic, message.Offset, err)
+		return
+	}
+	if err := kc.producer.Publish(context.Background(), DeadLetterTopic, kc.brokers, string(message.Key), letter); err != nil {
+		log.Printf("Failed to publish dead letter for %s offset %d: %v", sub.topic, message.Offset, err)
+	}
+}
+
+// safeHandle turns a handler panic into an error so one bad message cannot stop the reader
+func safeHandle(handler Handler, value []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handler(value)
+}
+
+// sleep waits for d and reports false if ctx was cancelled first
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
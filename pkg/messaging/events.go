@@ -0,0 +1,440 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Versioned event contracts. Each type is registered with a JSON Schema per version in Events;
+// payloads are wrapped in an Envelope, validated when built and again when decoded.
+const (
+	EventOrderCreated          = "order_created"
+	EventOrderCancelled        = "order_cancelled"
+	EventOrderCompleted        = "order_completed"
+	EventPaymentCaptured       = "payment_captured"
+	EventInventoryChanged      = "inventory_changed"
+	EventDeliveryStatusChanged = "delivery_status_changed"
+	EventCartAbandoned         = "cart_abandoned"
+	EventOTPBruteForce         = "otp_brute_force"
+)
+
+// ErrInvalidEvent is returned when an event does not match its registered schema
+var ErrInvalidEvent = errors.New("invalid event")
+
+// Envelope is the common wrapper for contract events
+type Envelope struct {
+	EventID    string          `json:"event_id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Tenant     string          `json:"tenant,omitempty"` // restaurant the event belongs to
+	Data       json.RawMessage `json:"data"`
+}
+
+// OrderCreated (v1) is published on order_events when an order is placed
+type OrderCreated struct {
+	OrderID      string           `json:"order_id"`
+	UserID       string           `json:"user_id"`
+	RestaurantID string           `json:"restaurant_id"`
+	Status       string           `json:"status"`
+	TotalAmount  float64          `json:"total_amount"`
+	PaymentID    string           `json:"payment_id,omitempty"`
+	ScheduledFor *time.Time       `json:"scheduled_for,omitempty"`
+	Items        []OrderEventItem `json:"items"`
+}
+
+type OrderEventItem struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// OrderCancelled (v1) is published on order_events when an order is cancelled, so the stock and
+// coupon use it reserved are released
+type OrderCancelled struct {
+	OrderID      string           `json:"order_id"`
+	UserID       string           `json:"user_id"`
+	RestaurantID string           `json:"restaurant_id"`
+	Items        []OrderEventItem `json:"items"`
+	CancelledAt  time.Time        `json:"cancelled_at"`
+}
+
+// OrderCompleted (v1) is published on order_events when an order is delivered, collected or served
+type OrderCompleted struct {
+	OrderID      string           `json:"order_id"`
+	UserID       string           `json:"user_id"`
+	RestaurantID string           `json:"restaurant_id"`
+	TotalAmount  float64          `json:"total_amount"`
+	Items        []OrderEventItem `json:"items"`
+	PlacedAt     time.Time        `json:"placed_at"`
+	DeliveredAt  time.Time        `json:"delivered_at"`
+}
+
+// PaymentCaptured (v1) is published on payment_events when a gateway payment succeeds
+type PaymentCaptured struct {
+	PaymentID        string    `json:"payment_id"`
+	OrderID          string    `json:"order_id"`
+	UserID           string    `json:"user_id"`
+	Amount           float64   `json:"amount"`
+	Method           string    `json:"method"`
+	GatewayPaymentID string    `json:"gateway_payment_id,omitempty"`
+	CapturedAt       time.Time `json:"captured_at"`
+}
+
+// InventoryChanged (v1) is published on inventory_events when stock changes a product's
+// availability or a product is created with stock
+type InventoryChanged struct {
+	ProductID    string `json:"product_id"`
+	RestaurantID string `json:"restaurant_id"`
+	Change       string `json:"change"` // product_created, out_of_stock, back_in_stock
+	Available    int    `json:"available"`
+}
+
+// DeliveryStatusChanged (v1) is published on delivery_events when a delivery moves
+type DeliveryStatusChanged struct {
+	OrderID         string `json:"order_id"`
+	Provider        string `json:"provider"`
+	ProviderOrderID string `json:"provider_order_id"`
+	Status          string `json:"status"`                 // provider status
+	OrderStatus     string `json:"order_status,omitempty"` // dispatched, delivered, cancelled
+}
+
+// CartAbandoned (v1) is published on cart_events when a cart with items goes unused for the
+// abandonment period, for recovery campaigns. CouponCode is the recovery coupon to offer, if any.
+type CartAbandoned struct {
+	CartID         string           `json:"cart_id"`
+	UserID         string           `json:"user_id"`
+	RestaurantID   string           `json:"restaurant_id"`
+	ItemCount      int              `json:"item_count"`
+	TotalAmount    float64          `json:"total_amount"`
+	Items          []OrderEventItem `json:"items"`
+	LastActivityAt time.Time        `json:"last_activity_at"`
+	AbandonedAt    time.Time        `json:"abandoned_at"`
+	CouponCode     string           `json:"coupon_code,omitempty"`
+}
+
+// OTPBruteForce (v1) is published on security_events when a phone number is locked out for
+// wrong OTPs, or an IP address goes over its verification limit
+type OTPBruteForce struct {
+	Subject     string     `json:"subject"` // phone or ip
+	Phone       string     `json:"phone,omitempty"`
+	IPAddress   string     `json:"ip_address,omitempty"`
+	Attempts    int        `json:"attempts"` // wrong codes in a row for a phone, verifications this hour for an IP
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	DetectedAt  time.Time  `json:"detected_at"`
+}
+
+var contractSchemas = []struct {
+	eventType string
+	version   int
+	schema    string
+}{
+	{EventOrderCreated, 1, `{
+		"type": "object",
+		"required": ["order_id", "user_id", "restaurant_id", "status", "total_amount", "items"],
+		"properties": {
+			"order_id": {"type": "string", "format": "uuid"},
+			"user_id": {"type": "string", "format": "uuid"},
+			"restaurant_id": {"type": "string", "format": "uuid"},
+			"status": {"type": "string"},
+			"total_amount": {"type": "number"},
+			"payment_id": {"type": "string", "format": "uuid"},
+			"scheduled_for": {"type": ["string", "null"], "format": "date-time"},
+			"items": {"type": ["array", "null"], "items": {
+				"type": "object",
+				"required": ["product_id", "quantity"],
+				"properties": {"product_id": {"type": "string"}, "quantity": {"type": "integer"}}
+			}}
+		}
+	}`},
+	{EventOrderCancelled, 1, `{
+		"type": "object",
+		"required": ["order_id", "user_id", "restaurant_id", "items", "cancelled_at"],
+		"properties": {
+			"order_id": {"type": "string", "format": "uuid"},
+			"user_id": {"type": "string", "format": "uuid"},
+			"restaurant_id": {"type": "string", "format": "uuid"},
+			"items": {"type": ["array", "null"], "items": {
+				"type": "object",
+				"required": ["product_id", "quantity"],
+				"properties": {"product_id": {"type": "string"}, "quantity": {"type": "integer"}}
+			}},
+			"cancelled_at": {"type": "string", "format": "date-time"}
+		}
+	}`},
+	{EventOrderCompleted, 1, `{
+		"type": "object",
+		"required": ["order_id", "user_id", "restaurant_id", "total_amount", "items", "placed_at", "delivered_at"],
+		"properties": {
+			"order_id": {"type": "string", "format": "uuid"},
+			"user_id": {"type": "string", "format": "uuid"},
+			"restaurant_id": {"type": "string", "format": "uuid"},
+			"total_amount": {"type": "number"},
+			"items": {"type": ["array", "null"], "items": {
+				"type": "object",
+				"required": ["product_id", "quantity"],
+				"properties": {"product_id": {"type": "string"}, "quantity": {"type": "integer"}}
+			}},
+			"placed_at": {"type": "string", "format": "date-time"},
+			"delivered_at": {"type": "string", "format": "date-time"}
+		}
+	}`},
+	{EventPaymentCaptured, 1, `{
+		"type": "object",
+		"required": ["payment_id", "order_id", "user_id", "amount", "method", "captured_at"],
+		"properties": {
+			"payment_id": {"type": "string", "format": "uuid"},
+			"order_id": {"type": "string", "format": "uuid"},
+			"user_id": {"type": "string", "format": "uuid"},
+			"amount": {"type": "number"},
+			"method": {"type": "string"},
+			"gateway_payment_id": {"type": "string"},
+			"captured_at": {"type": "string", "format": "date-time"}
+		}
+	}`},
+	{EventInventoryChanged, 1, `{
+		"type": "object",
+		"required": ["product_id", "restaurant_id", "change", "available"],
+		"properties": {
+			"product_id": {"type": "string"},
+			"restaurant_id": {"type": "string"},
+			"change": {"type": "string", "enum": ["product_created", "out_of_stock", "back_in_stock"]},
+			"available": {"type": "integer"}
+		}
+	}`},
+	{EventDeliveryStatusChanged, 1, `{
+		"type": "object",
+		"required": ["order_id", "provider", "provider_order_id", "status"],
+		"properties": {
+			"order_id": {"type": "string", "format": "uuid"},
+			"provider": {"type": "string"},
+			"provider_order_id": {"type": "string"},
+			"status": {"type": "string"},
+			"order_status": {"type": "string"}
+		}
+	}`},
+	{EventCartAbandoned, 1, `{
+		"type": "object",
+		"required": ["cart_id", "user_id", "restaurant_id", "item_count", "total_amount", "items", "last_activity_at", "abandoned_at"],
+		"properties": {
+			"cart_id": {"type": "string", "format": "uuid"},
+			"user_id": {"type": "string", "format": "uuid"},
+			"restaurant_id": {"type": "string", "format": "uuid"},
+			"item_count": {"type": "integer"},
+			"total_amount": {"type": "number"},
+			"items": {"type": ["array", "null"], "items": {
+				"type": "object",
+				"required": ["product_id", "quantity"],
+				"properties": {"product_id": {"type": "string"}, "quantity": {"type": "integer"}}
+			}},
+			"last_activity_at": {"type": "string", "format": "date-time"},
+			"abandoned_at": {"type": "string", "format": "date-time"},
+			"coupon_code": {"type": "string"}
+		}
+	}`},
+	{EventOTPBruteForce, 1, `{
+		"type": "object",
+		"required": ["subject", "attempts", "detected_at"],
+		"properties": {
+			"subject": {"type": "string", "enum": ["phone", "ip"]},
+			"phone": {"type": "string"},
+			"ip_address": {"type": "string"},
+			"attempts": {"type": "integer"},
+			"locked_until": {"type": ["string", "null"], "format": "date-time"},
+			"detected_at": {"type": "string", "format": "date-time"}
+		}
+	}`},
+}
+
+// Upcaster rewrites the data of one version into the shape of the next
+type Upcaster func(data map[string]interface{}) (map[string]interface{}, error)
+
+// Registry holds the schema of every version of every event type. A new version must either
+// be backward compatible with the previous one or come with an upcaster from it.
+type Registry struct {
+	mu        sync.RWMutex
+	schemas   map[string]map[int]*Schema
+	upcasters map[string]map[int]Upcaster // keyed by the version upcast from
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		schemas:   make(map[string]map[int]*Schema),
+		upcasters: make(map[string]map[int]Upcaster),
+	}
+}
+
+// Events is the registry of the service's event contracts
+var Events = newContractRegistry()
+
+func newContractRegistry() *Registry {
+	registry := NewRegistry()
+	for _, contract := range contractSchemas {
+		if err := registry.Register(contract.eventType, contract.version, contract.schema); err != nil {
+			panic(fmt.Sprintf("event contract %s v%d: %v", contract.eventType, contract.version, err))
+		}
+	}
+	return registry
+}
+
+// RegisterUpcaster registers how to move an event's data from fromVersion to fromVersion+1.
+// Register it before the new version's schema.
+func (r *Registry) RegisterUpcaster(eventType string, fromVersion int, upcaster Upcaster) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.upcasters[eventType] == nil {
+		r.upcasters[eventType] = make(map[int]Upcaster)
+	}
+	r.upcasters[eventType][fromVersion] = upcaster
+}
+
+// Register adds a schema version. Versions are registered in order; a version without an
+// upcaster from its predecessor must be backward compatible with it.
+func (r *Registry) Register(eventType string, version int, document string) error {
+	schema, err := ParseSchema(document)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions := r.schemas[eventType]
+	if versions == nil {
+		versions = make(map[int]*Schema)
+		r.schemas[eventType] = versions
+	}
+	if _, exists := versions[version]; exists {
+		return fmt.Errorf("version %d is already registered", version)
+	}
+	if version > 1 {
+		previous, ok := versions[version-1]
+		if !ok {
+			return fmt.Errorf("version %d registered before version %d", version, version-1)
+		}
+		if _, upcast := r.upcasters[eventType][version-1]; !upcast {
+			if err := CheckBackwardCompatible(previous, schema); err != nil {
+				return fmt.Errorf("version %d is not backward compatible with version %d: %v", version, version-1, err)
+			}
+		}
+	}
+
+	versions[version] = schema
+	return nil
+}
+
+// Latest returns the newest registered version of an event type, or 0 if it is unknown
+func (r *Registry) Latest(eventType string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	latest := 0
+	for version := range r.schemas[eventType] {
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// NewEnvelope wraps data as the latest version of the event type, validating it
+func (r *Registry) NewEnvelope(eventType, tenant string, data interface{}) (Envelope, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+
+	envelope := Envelope{
+		EventID:    uuid.New().String(),
+		Type:       eventType,
+		Version:    r.Latest(eventType),
+		OccurredAt: time.Now().UTC(),
+		Tenant:     tenant,
+		Data:       encoded,
+	}
+	if err := r.Validate(envelope); err != nil {
+		return Envelope{}, err
+	}
+	return envelope, nil
+}
+
+// Validate checks an envelope's data against the schema of its version
+func (r *Registry) Validate(envelope Envelope) error {
+	r.mu.RLock()
+	schema, ok := r.schemas[envelope.Type][envelope.Version]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: no schema for %s v%d", ErrInvalidEvent, envelope.Type, envelope.Version)
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(envelope.Data, &data); err != nil {
+		return fmt.Errorf("%w: %s data is not JSON: %v", ErrInvalidEvent, envelope.Type, err)
+	}
+	if err := schema.Validate(data); err != nil {
+		return fmt.Errorf("%w: %s v%d: %v", ErrInvalidEvent, envelope.Type, envelope.Version, err)
+	}
+	return nil
+}
+
+// Decode parses and validates an envelope, upcasts its data to the latest version and
+// unmarshals the data into v
+func (r *Registry) Decode(payload []byte, v interface{}) (*Envelope, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if err := r.Validate(envelope); err != nil {
+		return nil, err
+	}
+
+	latest := r.Latest(envelope.Type)
+	if envelope.Version < latest {
+		var data map[string]interface{}
+		if err := json.Unmarshal(envelope.Data, &data); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+		}
+		for version := envelope.Version; version < latest; version++ {
+			r.mu.RLock()
+			upcaster := r.upcasters[envelope.Type][version]
+			r.mu.RUnlock()
+			if upcaster == nil {
+				continue // compatible without rewriting
+			}
+			upcast, err := upcaster(data)
+			if err != nil {
+				return nil, fmt.Errorf("%w: upcasting %s v%d: %v", ErrInvalidEvent, envelope.Type, version, err)
+			}
+			data = upcast
+		}
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+		}
+		envelope.Data = encoded
+		envelope.Version = latest
+	}
+
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		return nil, fmt.Errorf("%w: %s data: %v", ErrInvalidEvent, envelope.Type, err)
+	}
+	return &envelope, nil
+}
+
+// PublishEvent validates an envelope against the contracts and publishes it
+func (kp *KafkaProducer) PublishEvent(ctx context.Context, topic string, brokers []string, key string, envelope Envelope) error {
+	if err := Events.Validate(envelope); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	return kp.Publish(ctx, topic, brokers, key, payload)
+}
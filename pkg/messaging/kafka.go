@@ -3,32 +3,67 @@ package messaging
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"sync"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 )
 
-type KafkaProducer struct {
-	writers map[string]*kafka.Writer
+// DeadLetterTopic receives events that could not be published after every retry
+const DeadLetterTopic = "dead_letter_events"
+
+// RetryPolicy controls producer retries. Backoff doubles after each failed attempt up to MaxBackoff.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy retries a failed write twice within about a second, so a request is not held
+// up for long; events that still fail are retried by the outbox relay
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
 }
 
-type KafkaConsumer struct {
-	readers map[string]*kafka.Reader
+// Backoff returns the wait before the given retry, counting from 1
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < retry && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	return backoff
+}
+
+type KafkaProducer struct {
+	mu      sync.Mutex
+	writers map[string]*kafka.Writer
+	retry   RetryPolicy
 }
 
 func NewKafkaProducer(brokers []string) *KafkaProducer {
 	return &KafkaProducer{
 		writers: make(map[string]*kafka.Writer),
+		retry:   DefaultRetryPolicy,
 	}
 }
 
-func NewKafkaConsumer(brokers []string, groupID string) *KafkaConsumer {
-	return &KafkaConsumer{
-		readers: make(map[string]*kafka.Reader),
+// SetRetryPolicy replaces the producer's retry policy
+func (kp *KafkaProducer) SetRetryPolicy(policy RetryPolicy) {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
 	}
+	kp.retry = policy
 }
 
 func (kp *KafkaProducer) GetWriter(topic string, brokers []string) *kafka.Writer {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+
 	if writer, exists := kp.writers[topic]; exists {
 		return writer
 	}
@@ -43,19 +78,38 @@ func (kp *KafkaProducer) GetWriter(topic string, brokers []string) *kafka.Writer
 }
 
 func (kp *KafkaProducer) SendMessage(topic string, brokers []string, key string, value interface{}) error {
-	writer := kp.GetWriter(topic, brokers)
-
 	jsonData, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
 
+	return kp.Publish(context.Background(), topic, brokers, key, jsonData)
+}
+
+// Publish writes an already encoded message, retrying with exponential backoff
+func (kp *KafkaProducer) Publish(ctx context.Context, topic string, brokers []string, key string, value []byte) error {
+	writer := kp.GetWriter(topic, brokers)
 	message := kafka.Message{
 		Key:   []byte(key),
-		Value: jsonData,
+		Value: value,
 	}
 
-	return writer.WriteMessages(context.Background(), message)
+	var err error
+	for attempt := 1; attempt <= kp.retry.MaxAttempts; attempt++ {
+		if err = writer.WriteMessages(ctx, message); err == nil {
+			return nil
+		}
+		if attempt == kp.retry.MaxAttempts {
+			break
+		}
+
+		select {
+		case <-time.After(kp.retry.Backoff(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
 }
 
 func (kp *KafkaProducer) Close() {
@@ -64,45 +118,24 @@ func (kp *KafkaProducer) Close() {
 	}
 }
 
-func (kc *KafkaConsumer) GetReader(topic string, brokers []string, groupID string) *kafka.Reader {
-	if reader, exists := kc.readers[topic]; exists {
-		return reader
-	}
-
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:  brokers,
-		Topic:    topic,
-		GroupID:  groupID,
-		MinBytes: 10e3, // 10KB
-		MaxBytes: 10e6, // 10MB
-	})
-	kc.readers[topic] = reader
-	return reader
-}
-
-func (kc *KafkaConsumer) ConsumeMessages(topic string, brokers []string, groupID string, handler func([]byte) error) {
-	reader := kc.GetReader(topic, brokers, groupID)
-
-	for {
-		message, err := reader.ReadMessage(context.Background())
-		if err != nil {
-			log.Printf("Error reading message from topic %s: %v", topic, err)
-			continue
-		}
-
-		if err := handler(message.Value); err != nil {
-			log.Printf("Error handling message: %v", err)
-		}
-	}
-}
-
-func (kc *KafkaConsumer) Close() {
-	for _, reader := range kc.readers {
-		reader.Close()
-	}
+// DeadLetter wraps an event sent to DeadLetterTopic
+type DeadLetter struct {
+	EventID  string          `json:"event_id"`
+	Topic    string          `json:"topic"`
+	Key      string          `json:"key"`
+	Payload  json.RawMessage `json:"payload"`
+	Error    string          `json:"error"`
+	Attempts int             `json:"attempts"`
+	FailedAt time.Time       `json:"failed_at"`
+
+	// Set when a consumer gave up on the message rather than the producer
+	ConsumerGroup string `json:"consumer_group,omitempty"`
+	Partition     int    `json:"partition,omitempty"`
+	Offset        int64  `json:"offset,omitempty"`
 }
 
-// Event types for async processing
+// Event types for async processing. Contract events such as order_created are published as
+// an Envelope instead (see events.go).
 type OrderEvent struct {
 	Type    string      `json:"type"`
 	OrderID string      `json:"order_id"`
@@ -110,13 +143,6 @@ type OrderEvent struct {
 	Data    interface{} `json:"data"`
 }
 
-type InventoryEvent struct {
-	Type         string `json:"type"`
-	ProductID    string `json:"product_id"`
-	Quantity     int    `json:"quantity"`
-	RestaurantID string `json:"restaurant_id"`
-}
-
 type NotificationEvent struct {
 	Type     string                 `json:"type"`
 	UserID   string                 `json:"user_id"`
@@ -0,0 +1,186 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schema is the subset of JSON Schema used by event contracts: type (a name or a list of
+// names), required, properties, items, enum and the date-time and uuid formats
+type Schema struct {
+	Type       schemaTypes        `json:"type,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Enum       []interface{}      `json:"enum,omitempty"`
+	Format     string             `json:"format,omitempty"`
+}
+
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("schema type must be a string or a list of strings")
+	}
+	*t = list
+	return nil
+}
+
+func (t schemaTypes) allows(name string) bool {
+	if len(t) == 0 {
+		return true
+	}
+	for _, allowed := range t {
+		// JSON Schema integers are numbers without a fraction
+		if allowed == name || (allowed == "number" && name == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSchema parses a JSON Schema document
+func ParseSchema(document string) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal([]byte(document), &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	return &schema, nil
+}
+
+// Validate checks a decoded JSON value (as produced by json.Unmarshal into interface{})
+// against the schema
+func (s *Schema) Validate(value interface{}) error {
+	return s.validate(value, "$")
+}
+
+func (s *Schema) validate(value interface{}, path string) error {
+	kind := jsonKind(value)
+	if !s.Type.allows(kind) {
+		return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(s.Type, " or "), kind)
+	}
+
+	if len(s.Enum) > 0 && !enumContains(s.Enum, value) {
+		return fmt.Errorf("%s: %v is not one of %v", path, value, s.Enum)
+	}
+
+	switch v := value.(type) {
+	case string:
+		switch s.Format {
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				return fmt.Errorf("%s: %q is not an RFC 3339 date-time", path, v)
+			}
+		case "uuid":
+			if _, err := uuid.Parse(v); err != nil {
+				return fmt.Errorf("%s: %q is not a UUID", path, v)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		for name, property := range s.Properties {
+			if field, ok := v[name]; ok {
+				if err := property.validate(field, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// CheckBackwardCompatible reports why data written against old would not validate against
+// next: a property required by next that old did not require, or a property whose type
+// next narrows. Adding optional properties and widening types are compatible.
+func CheckBackwardCompatible(old, next *Schema) error {
+	return checkCompatible(old, next, "$")
+}
+
+func checkCompatible(old, next *Schema, path string) error {
+	for _, kind := range old.Type {
+		if !next.Type.allows(kind) {
+			return fmt.Errorf("%s: type %s is no longer allowed", path, kind)
+		}
+	}
+
+	oldRequired := make(map[string]bool, len(old.Required))
+	for _, name := range old.Required {
+		oldRequired[name] = true
+	}
+	for _, name := range next.Required {
+		if !oldRequired[name] {
+			return fmt.Errorf("%s: new required property %q; make it optional or add an upcaster", path, name)
+		}
+	}
+
+	names := make([]string, 0, len(next.Properties))
+	for name := range next.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if previous, ok := old.Properties[name]; ok {
+			if err := checkCompatible(previous, next.Properties[name], path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if old.Items != nil && next.Items != nil {
+		return checkCompatible(old.Items, next.Items, path+"[]")
+	}
+	return nil
+}
+
+func jsonKind(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func enumContains(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
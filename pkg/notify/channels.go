@@ -0,0 +1,244 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"golang-food-backend/pkg/sms"
+)
+
+// Delivery channels a message can be sent over
+const (
+	ChannelSMS      = "sms"
+	ChannelWhatsApp = "whatsapp"
+	ChannelEmail    = "email"
+)
+
+var ErrNoRecipient = errors.New("no recipient for channel")
+
+// Message is a rendered notification. Code carries the one-time code on its own for
+// channels that fill it into a provider-side template (WhatsApp authentication templates).
+type Message struct {
+	To          string
+	Subject     string
+	Body        string
+	Code        string
+	Attachments []Attachment // sent by email only
+}
+
+// Attachment is a file sent with an email
+type Attachment struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// Sender delivers messages over one channel
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMSSender sends the message body as a text message
+type SMSSender struct {
+	smsService *sms.SMSService
+}
+
+func NewSMSSender(smsService *sms.SMSService) *SMSSender {
+	return &SMSSender{smsService: smsService}
+}
+
+func (s *SMSSender) Send(ctx context.Context, msg Message) error {
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+	_, err := s.smsService.Send(ctx, msg.To, msg.Body)
+	return err
+}
+
+// WhatsAppSender uses the WhatsApp Business Cloud API. With a template name set it sends that
+// approved template with the code as its parameter; otherwise it sends the body as plain text,
+// which WhatsApp only delivers inside an open conversation window.
+type WhatsAppSender struct {
+	baseURL            string
+	phoneNumberID      string
+	accessToken        string
+	templateName       string
+	languageCode       string
+	defaultCountryCode string
+	client             *http.Client
+}
+
+func NewWhatsAppSender(baseURL, phoneNumberID, accessToken, templateName, languageCode, defaultCountryCode string) *WhatsAppSender {
+	return &WhatsAppSender{
+		baseURL:            strings.TrimSuffix(baseURL, "/"),
+		phoneNumberID:      phoneNumberID,
+		accessToken:        accessToken,
+		templateName:       templateName,
+		languageCode:       languageCode,
+		defaultCountryCode: defaultCountryCode,
+		client:             &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *WhatsAppSender) Send(ctx context.Context, msg Message) error {
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+	if s.phoneNumberID == "" || s.accessToken == "" {
+		return errors.New("whatsapp provider is not configured")
+	}
+
+	payload := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"to":                s.internationalNumber(msg.To),
+	}
+	if s.templateName != "" {
+		parameters := []map[string]string{{"type": "text", "text": msg.Code}}
+		payload["type"] = "template"
+		payload["template"] = map[string]interface{}{
+			"name":     s.templateName,
+			"language": map[string]string{"code": s.languageCode},
+			"components": []map[string]interface{}{
+				{"type": "body", "parameters": parameters},
+				// Authentication templates carry a copy-code button that takes the code as well
+				{"type": "button", "sub_type": "url", "index": "0", "parameters": parameters},
+			},
+		}
+	} else {
+		payload["type"] = "text"
+		payload["text"] = map[string]string{"body": msg.Body}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/%s/messages", s.baseURL, s.phoneNumberID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send WhatsApp message: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("WhatsApp message failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// internationalNumber strips formatting and prefixes the default country code to local numbers
+func (s *WhatsAppSender) internationalNumber(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	if len(digits) == 10 && s.defaultCountryCode != "" {
+		return s.defaultCountryCode + digits
+	}
+	return digits
+}
+
+// EmailSender sends plain-text mail, with any attachments, through an SMTP relay
+type EmailSender struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+func NewEmailSender(host string, port int, username, password, from string) *EmailSender {
+	return &EmailSender{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (s *EmailSender) Send(ctx context.Context, msg Message) error {
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+	if s.host == "" || s.from == "" {
+		return errors.New("email provider is not configured")
+	}
+
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", s.from)
+	fmt.Fprintf(&body, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", msg.Subject)
+	body.WriteString("MIME-Version: 1.0\r\n")
+	if len(msg.Attachments) == 0 {
+		body.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+		body.WriteString(msg.Body)
+	} else {
+		writeMultipart(&body, msg)
+	}
+
+	addr := fmt.Sprintf("%s:%d", s.host, s.port)
+	if err := smtp.SendMail(addr, auth, s.from, []string{msg.To}, []byte(body.String())); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	return nil
+}
+
+// writeMultipart writes a multipart/mixed body holding the text and the attachments
+func writeMultipart(body *strings.Builder, msg Message) {
+	var parts bytes.Buffer
+	writer := multipart.NewWriter(&parts)
+
+	text, _ := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"text/plain; charset=\"utf-8\""},
+	})
+	io.WriteString(text, msg.Body)
+
+	for _, attachment := range msg.Attachments {
+		part, _ := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName})},
+		})
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			io.WriteString(part, encoded[:76]+"\r\n")
+			encoded = encoded[76:]
+		}
+		io.WriteString(part, encoded+"\r\n")
+	}
+	writer.Close()
+
+	fmt.Fprintf(body, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", writer.Boundary())
+	body.Write(parts.Bytes())
+}
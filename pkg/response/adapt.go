@@ -0,0 +1,111 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// Adapt wraps a v1 JSON response body in an envelope. Error bodies ({"error", "message"}) become
+// an Error with the code of the status, or the code the body names. Lists with a pagination
+// object, or page/total_pages fields, have the pagination moved to meta and the list itself
+// becomes data.
+func Adapt(status int, body []byte) Envelope {
+	var decoded interface{}
+	if len(bytes.TrimSpace(body)) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		// Numbers are kept as written, so amounts keep their two decimals
+		decoder.UseNumber()
+		if err := decoder.Decode(&decoded); err != nil {
+			decoded = string(body)
+		}
+	}
+
+	if status >= http.StatusBadRequest {
+		return Envelope{Error: adaptError(status, decoded)}
+	}
+
+	object, ok := decoded.(map[string]interface{})
+	if !ok {
+		return Envelope{Data: decoded}
+	}
+	pagination := takePagination(object)
+	if pagination == nil {
+		return Envelope{Data: object}
+	}
+
+	envelope := Envelope{Data: object, Meta: &Meta{Pagination: pagination}}
+	if len(object) == 1 {
+		for _, list := range object {
+			envelope.Data = list
+		}
+	}
+	return envelope
+}
+
+func adaptError(status int, decoded interface{}) *Error {
+	failure := &Error{Code: CodeForStatus(status), Message: http.StatusText(status)}
+	object, ok := decoded.(map[string]interface{})
+	if !ok {
+		return failure
+	}
+
+	for key, value := range object {
+		text, isText := value.(string)
+		switch {
+		case key == "error" && isText && text != "":
+			failure.Message = text
+		case key == "message" && isText:
+			failure.Detail = text
+		case key == "code" && isText && text != "":
+			failure.Code = text
+		default:
+			if failure.Fields == nil {
+				failure.Fields = make(map[string]interface{})
+			}
+			failure.Fields[key] = value
+		}
+	}
+	if failure.Detail == failure.Message {
+		failure.Detail = ""
+	}
+	return failure
+}
+
+// takePagination removes the pagination of a list response from it
+func takePagination(object map[string]interface{}) *Pagination {
+	if nested, ok := object["pagination"].(map[string]interface{}); ok {
+		delete(object, "pagination")
+		return paginationOf(nested, nil)
+	}
+	if _, ok := object["total_pages"]; !ok {
+		return nil
+	}
+	return paginationOf(object, object)
+}
+
+// paginationOf reads pagination fields from source, deleting them from remove when it is set
+func paginationOf(source, remove map[string]interface{}) *Pagination {
+	number := func(key string) int64 {
+		value := source[key]
+		if remove != nil {
+			delete(remove, key)
+		}
+		switch v := value.(type) {
+		case json.Number:
+			n, _ := strconv.ParseInt(v.String(), 10, 64)
+			return n
+		case float64:
+			return int64(v)
+		}
+		return 0
+	}
+
+	return &Pagination{
+		Page:       int(number("page")),
+		Limit:      int(number("limit")),
+		Total:      number("total"),
+		TotalPages: int(number("total_pages")),
+	}
+}
@@ -0,0 +1,88 @@
+package response
+
+import "net/http"
+
+// Error codes shared by every v2 response. Clients branch on the code; the message is for
+// people and may change or be translated.
+const (
+	CodeBadRequest         = "bad_request"
+	CodeValidation         = "validation_failed"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeConflict           = "conflict"
+	CodeGone               = "gone"
+	CodePayloadTooLarge    = "payload_too_large"
+	CodeUnsupportedMedia   = "unsupported_media_type"
+	CodeRateLimited        = "rate_limited"
+	CodeInternal           = "internal_error"
+	CodeUpstream           = "upstream_error"
+	CodeServiceUnavailable = "service_unavailable"
+)
+
+// Envelope is the body of every JSON response under /api/v2: data on success, error on
+// failure, never both. Data is null on failure.
+type Envelope struct {
+	Data      interface{} `json:"data"`
+	Error     *Error      `json:"error,omitempty"`
+	Meta      *Meta       `json:"meta,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// Error describes a failed request. Detail carries the underlying reason when there is one;
+// Fields carries anything else the endpoint reported, such as retry_after_seconds.
+type Error struct {
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Detail  string                 `json:"detail,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Meta is information about the response rather than the resource
+type Meta struct {
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+type Pagination struct {
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit,omitempty"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// CodeForStatus returns the error code of an HTTP error status
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnprocessableEntity:
+		return CodeValidation
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMedia
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return CodeUpstream
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
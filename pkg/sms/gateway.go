@@ -0,0 +1,71 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GatewayProvider is the plain HTTP GET gateway the platform started with. It takes the
+// message in the query string and neither returns message IDs nor reports delivery.
+type GatewayProvider struct {
+	apiKey   string
+	senderID string
+	baseURL  string
+	client   *http.Client
+}
+
+func NewGatewayProvider(apiKey, senderID, baseURL string) *GatewayProvider {
+	return &GatewayProvider{
+		apiKey:   apiKey,
+		senderID: senderID,
+		baseURL:  baseURL,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *GatewayProvider) Name() string {
+	return "gateway"
+}
+
+func (p *GatewayProvider) Send(ctx context.Context, to, message string) (string, error) {
+	if p.apiKey == "" {
+		return "", fmt.Errorf("gateway provider is not configured")
+	}
+
+	params := url.Values{}
+	params.Add("apikey", p.apiKey)
+	params.Add("senderid", p.senderID)
+	params.Add("number", to)
+	params.Add("message", message)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send SMS: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", fmt.Errorf("failed to read SMS response: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK && !strings.Contains(strings.ToLower(string(body)), "success") {
+		return "", fmt.Errorf("SMS sending failed: %s", string(body))
+	}
+
+	return "", nil
+}
+
+func (p *GatewayProvider) ParseDeliveryReport(r *http.Request) ([]DeliveryReport, error) {
+	return nil, ErrDeliveryReportsUnsupported
+}
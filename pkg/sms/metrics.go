@@ -0,0 +1,88 @@
+package sms
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ProviderMetrics counts a provider's sends and delivery reports since the process started
+type ProviderMetrics struct {
+	Provider     string     `json:"provider"`
+	Sent         int64      `json:"sent"`
+	SendFailures int64      `json:"send_failures"`
+	Delivered    int64      `json:"delivered"`
+	Undelivered  int64      `json:"undelivered"`
+	AvgLatencyMs float64    `json:"avg_latency_ms"` // time for the provider to accept a message
+	LastError    string     `json:"last_error,omitempty"`
+	LastErrorAt  *time.Time `json:"last_error_at,omitempty"`
+}
+
+// Metrics collects per-provider counters. It is safe for concurrent use.
+type Metrics struct {
+	mu           sync.Mutex
+	providers    map[string]*ProviderMetrics
+	totalLatency map[string]time.Duration
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		providers:    make(map[string]*ProviderMetrics),
+		totalLatency: make(map[string]time.Duration),
+	}
+}
+
+func (m *Metrics) provider(name string) *ProviderMetrics {
+	metrics, ok := m.providers[name]
+	if !ok {
+		metrics = &ProviderMetrics{Provider: name}
+		m.providers[name] = metrics
+	}
+	return metrics
+}
+
+func (m *Metrics) recordSend(provider string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	metrics := m.provider(provider)
+	if err != nil {
+		now := time.Now()
+		metrics.SendFailures++
+		metrics.LastError = err.Error()
+		metrics.LastErrorAt = &now
+		return
+	}
+
+	metrics.Sent++
+	m.totalLatency[provider] += latency
+	metrics.AvgLatencyMs = float64(m.totalLatency[provider].Milliseconds()) / float64(metrics.Sent)
+}
+
+func (m *Metrics) recordReport(report DeliveryReport) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	metrics := m.provider(report.Provider)
+	switch report.Status {
+	case StatusDelivered:
+		metrics.Delivered++
+	case StatusUndelivered, StatusFailed:
+		metrics.Undelivered++
+	}
+}
+
+// Snapshot returns a copy of the counters ordered by provider name
+func (m *Metrics) Snapshot() []ProviderMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make([]ProviderMetrics, 0, len(m.providers))
+	for _, metrics := range m.providers {
+		snapshot = append(snapshot, *metrics)
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Provider < snapshot[j].Provider
+	})
+	return snapshot
+}
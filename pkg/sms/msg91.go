@@ -0,0 +1,145 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MSG91Provider sends through MSG91's SMS API. MSG91 posts delivery reports to the webhook
+// configured in its panel; they carry no signature, so the webhook URL includes a shared token.
+type MSG91Provider struct {
+	baseURL      string
+	authKey      string
+	senderID     string
+	route        string
+	countryCode  string
+	webhookToken string
+	client       *http.Client
+}
+
+func NewMSG91Provider(baseURL, authKey, senderID, route, countryCode, webhookToken string) *MSG91Provider {
+	return &MSG91Provider{
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		authKey:      authKey,
+		senderID:     senderID,
+		route:        route,
+		countryCode:  countryCode,
+		webhookToken: webhookToken,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *MSG91Provider) Name() string {
+	return "msg91"
+}
+
+func (p *MSG91Provider) Send(ctx context.Context, to, message string) (string, error) {
+	if p.authKey == "" {
+		return "", errors.New("msg91 provider is not configured")
+	}
+
+	payload := map[string]interface{}{
+		"sender":  p.senderID,
+		"route":   p.route,
+		"country": p.countryCode,
+		"sms": []map[string]interface{}{
+			{"message": message, "to": []string{internationalNumber(to, p.countryCode)}},
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/v2/sendsms", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("authkey", p.authKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send SMS via msg91: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var result struct {
+		Type    string `json:"type"`
+		Message string `json:"message"` // request ID on success, error text otherwise
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil || resp.StatusCode >= 300 || result.Type != "success" {
+		return "", fmt.Errorf("msg91 SMS failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return result.Message, nil
+}
+
+// msg91StatusCodes maps MSG91 report codes to delivery states; unlisted codes are still pending
+var msg91StatusCodes = map[string]string{
+	"1":  StatusDelivered,
+	"2":  StatusUndelivered,
+	"9":  StatusFailed, // NDNC registered number
+	"16": StatusFailed, // rejected
+	"17": StatusFailed, // blocked number
+	"25": StatusFailed, // rejected by operator
+	"26": StatusFailed, // DND
+}
+
+// ParseDeliveryReport decodes MSG91's report, a JSON array sent in the "data" form field
+func (p *MSG91Provider) ParseDeliveryReport(r *http.Request) ([]DeliveryReport, error) {
+	if p.webhookToken == "" || subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(p.webhookToken)) != 1 {
+		return nil, errors.New("invalid webhook token")
+	}
+
+	var batches []struct {
+		RequestID string `json:"requestId"`
+		Report    []struct {
+			Date   string `json:"date"`
+			Number string `json:"number"`
+			Status string `json:"status"`
+			Desc   string `json:"desc"`
+		} `json:"report"`
+	}
+	if err := json.Unmarshal([]byte(r.PostFormValue("data")), &batches); err != nil {
+		return nil, fmt.Errorf("invalid msg91 delivery report: %v", err)
+	}
+
+	var reports []DeliveryReport
+	for _, batch := range batches {
+		for _, entry := range batch.Report {
+			status, ok := msg91StatusCodes[entry.Status]
+			if !ok {
+				continue
+			}
+			errorCode := ""
+			if status != StatusDelivered {
+				errorCode = entry.Status
+			}
+			reports = append(reports, DeliveryReport{
+				Provider:   p.Name(),
+				MessageID:  batch.RequestID,
+				Status:     status,
+				ErrorCode:  errorCode,
+				ReportedAt: time.Now(),
+				RawResponse: map[string]interface{}{
+					"number": entry.Number,
+					"status": entry.Status,
+					"desc":   entry.Desc,
+					"date":   entry.Date,
+				},
+			})
+		}
+	}
+
+	return reports, nil
+}
@@ -0,0 +1,60 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Delivery states reported back by providers
+const (
+	StatusSent        = "sent"      // accepted by the provider
+	StatusDelivered   = "delivered" // confirmed on the handset
+	StatusUndelivered = "undelivered"
+	StatusFailed      = "failed"
+)
+
+var ErrDeliveryReportsUnsupported = errors.New("provider does not send delivery reports")
+
+// Provider sends text messages through one vendor
+type Provider interface {
+	Name() string
+	// Send submits a message and returns the provider's ID for it, used to match delivery reports
+	Send(ctx context.Context, to, message string) (string, error)
+	// ParseDeliveryReport verifies and decodes a delivery report callback from the provider
+	ParseDeliveryReport(r *http.Request) ([]DeliveryReport, error)
+}
+
+// SendResult describes a message accepted by a provider
+type SendResult struct {
+	Provider  string
+	MessageID string
+	To        string
+	SentAt    time.Time
+}
+
+// DeliveryReport is a provider's final or intermediate status for a sent message
+type DeliveryReport struct {
+	Provider    string
+	MessageID   string
+	Status      string
+	ErrorCode   string
+	ReportedAt  time.Time
+	RawResponse map[string]interface{}
+}
+
+// internationalNumber strips formatting and prefixes the country code to local 10-digit numbers
+func internationalNumber(phone, countryCode string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	if len(digits) == 10 && countryCode != "" {
+		return countryCode + digits
+	}
+	return digits
+}
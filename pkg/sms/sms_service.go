@@ -1,94 +1,104 @@
 package sms
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"log"
 	"net/http"
-	"net/url"
-	"strings"
+	"time"
 )
 
-type SMSService struct {
-	apiKey   string
-	senderID string
-	baseURL  string
+var ErrNoProviders = errors.New("no SMS provider is configured")
+
+// DeliveryStore persists sent messages and their delivery reports
+type DeliveryStore interface {
+	RecordSent(ctx context.Context, result SendResult) error
+	RecordReport(ctx context.Context, report DeliveryReport) error
 }
 
-type SMSResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+// SMSService sends through the configured providers in order, failing over to the next
+// provider when one rejects a message or cannot be reached
+type SMSService struct {
+	providers []Provider
+	byName    map[string]Provider
+	store     DeliveryStore
+	metrics   *Metrics
 }
 
-func NewSMSService(apiKey, senderID string) *SMSService {
+// NewSMSService takes providers in failover order. The store may be nil.
+func NewSMSService(providers []Provider, store DeliveryStore) *SMSService {
+	byName := make(map[string]Provider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
 	return &SMSService{
-		apiKey:   apiKey,
-		senderID: senderID,
-		baseURL:  "http://app.mydreamstechnology.in/vb/apikey.php",
+		providers: providers,
+		byName:    byName,
+		store:     store,
+		metrics:   NewMetrics(),
 	}
 }
 
-func (s *SMSService) SendOTP(phone, otp string) error {
-	message := fmt.Sprintf("Use OTP %s to log in to your Account. Never share your OTP with anyone.", otp)
-
-	// Build URL with parameters
-	params := url.Values{}
-	params.Add("apikey", s.apiKey)
-	params.Add("senderid", s.senderID)
-	params.Add("number", phone)
-	params.Add("message", message)
-
-	fullURL := s.baseURL + "?" + params.Encode()
-
-	// Make HTTP GET request
-	resp, err := http.Get(fullURL)
-	if err != nil {
-		return fmt.Errorf("failed to send SMS: %v", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read SMS response: %v", err)
+// Send delivers a message through the first provider that accepts it
+func (s *SMSService) Send(ctx context.Context, to, message string) (*SendResult, error) {
+	if len(s.providers) == 0 {
+		return nil, ErrNoProviders
 	}
 
-	// Check if the response indicates success
-	responseText := strings.ToLower(string(body))
-	if !strings.Contains(responseText, "success") && resp.StatusCode != 200 {
-		return fmt.Errorf("SMS sending failed: %s", string(body))
+	var lastErr error
+	for _, provider := range s.providers {
+		started := time.Now()
+		messageID, err := provider.Send(ctx, to, message)
+		s.metrics.recordSend(provider.Name(), time.Since(started), err)
+		if err != nil {
+			log.Printf("SMS via %s to %s failed: %v", provider.Name(), to, err)
+			lastErr = err
+			continue
+		}
+
+		result := &SendResult{
+			Provider:  provider.Name(),
+			MessageID: messageID,
+			To:        to,
+			SentAt:    time.Now(),
+		}
+		if s.store != nil {
+			if err := s.store.RecordSent(ctx, *result); err != nil {
+				log.Printf("Failed to record SMS %s from %s: %v", messageID, provider.Name(), err)
+			}
+		}
+		return result, nil
 	}
 
-	return nil
+	return nil, fmt.Errorf("failed to send SMS: %v", lastErr)
 }
 
-func (s *SMSService) SendCustomMessage(phone, message string) error {
-	// Build URL with parameters
-	params := url.Values{}
-	params.Add("apikey", s.apiKey)
-	params.Add("senderid", s.senderID)
-	params.Add("number", phone)
-	params.Add("message", message)
-
-	fullURL := s.baseURL + "?" + params.Encode()
-
-	// Make HTTP GET request
-	resp, err := http.Get(fullURL)
-	if err != nil {
-		return fmt.Errorf("failed to send SMS: %v", err)
+// HandleDeliveryReport verifies a provider's delivery report callback and records its statuses
+func (s *SMSService) HandleDeliveryReport(ctx context.Context, providerName string, r *http.Request) (int, error) {
+	provider, ok := s.byName[providerName]
+	if !ok {
+		return 0, fmt.Errorf("unknown SMS provider: %s", providerName)
 	}
-	defer resp.Body.Close()
 
-	// Read response
-	body, err := ioutil.ReadAll(resp.Body)
+	reports, err := provider.ParseDeliveryReport(r)
 	if err != nil {
-		return fmt.Errorf("failed to read SMS response: %v", err)
+		return 0, err
 	}
 
-	// Check if the response indicates success
-	responseText := strings.ToLower(string(body))
-	if !strings.Contains(responseText, "success") && resp.StatusCode != 200 {
-		return fmt.Errorf("SMS sending failed: %s", string(body))
+	for _, report := range reports {
+		s.metrics.recordReport(report)
+		if s.store != nil {
+			if err := s.store.RecordReport(ctx, report); err != nil {
+				log.Printf("Failed to record delivery report for SMS %s: %v", report.MessageID, err)
+			}
+		}
 	}
 
-	return nil
+	return len(reports), nil
+}
+
+// Metrics returns the per-provider counters
+func (s *SMSService) Metrics() []ProviderMetrics {
+	return s.metrics.Snapshot()
 }
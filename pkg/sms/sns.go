@@ -0,0 +1,145 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SNSProvider publishes SMS through AWS SNS, signing requests with SigV4. SNS logs delivery
+// status to CloudWatch rather than calling back, so it has no delivery report webhook.
+type SNSProvider struct {
+	region      string
+	accessKey   string
+	secretKey   string
+	senderID    string
+	countryCode string
+	endpoint    string
+	client      *http.Client
+}
+
+func NewSNSProvider(region, accessKey, secretKey, senderID, countryCode string) *SNSProvider {
+	return &SNSProvider{
+		region:      region,
+		accessKey:   accessKey,
+		secretKey:   secretKey,
+		senderID:    senderID,
+		countryCode: countryCode,
+		endpoint:    fmt.Sprintf("https://sns.%s.amazonaws.com/", region),
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *SNSProvider) Name() string {
+	return "sns"
+}
+
+func (p *SNSProvider) Send(ctx context.Context, to, message string) (string, error) {
+	if p.accessKey == "" || p.secretKey == "" {
+		return "", errors.New("sns provider is not configured")
+	}
+
+	form := url.Values{}
+	form.Set("Action", "Publish")
+	form.Set("Version", "2010-03-31")
+	form.Set("PhoneNumber", "+"+internationalNumber(to, p.countryCode))
+	form.Set("Message", message)
+	// Transactional messages are routed for reliability rather than cost
+	form.Set("MessageAttributes.entry.1.Name", "AWS.SNS.SMS.SMSType")
+	form.Set("MessageAttributes.entry.1.Value.DataType", "String")
+	form.Set("MessageAttributes.entry.1.Value.StringValue", "Transactional")
+	if p.senderID != "" {
+		form.Set("MessageAttributes.entry.2.Name", "AWS.SNS.SMS.SenderID")
+		form.Set("MessageAttributes.entry.2.Value.DataType", "String")
+		form.Set("MessageAttributes.entry.2.Value.StringValue", p.senderID)
+	}
+	body := form.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, strings.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	p.sign(req, body, time.Now())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send SMS via sns: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("sns SMS failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		MessageID string `xml:"PublishResult>MessageId"`
+	}
+	if err := xml.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("invalid sns response: %v", err)
+	}
+
+	return result.MessageID, nil
+}
+
+func (p *SNSProvider) ParseDeliveryReport(r *http.Request) ([]DeliveryReport, error) {
+	return nil, ErrDeliveryReportsUnsupported
+}
+
+// sign adds a SigV4 Authorization header covering the content type, host and date
+func (p *SNSProvider) sign(req *http.Request, body string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	scope := day + "/" + p.region + "/sns/aws4_request"
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	payloadHash := sha256.Sum256([]byte(body))
+	signedHeaders := "content-type;host;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + req.URL.Host,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+p.secretKey), day)
+	signingKey = hmacSHA256(signingKey, p.region)
+	signingKey = hmacSHA256(signingKey, "sns")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		p.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
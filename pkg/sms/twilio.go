@@ -0,0 +1,158 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TwilioProvider sends through Twilio's Messages API. Each message asks Twilio to post its
+// status changes to statusCallbackURL, and callbacks are checked against X-Twilio-Signature.
+type TwilioProvider struct {
+	baseURL             string
+	accountSID          string
+	authToken           string
+	from                string
+	messagingServiceSID string
+	countryCode         string
+	statusCallbackURL   string
+	client              *http.Client
+}
+
+// NewTwilioProvider sends from messagingServiceSID when set, otherwise from the from number
+func NewTwilioProvider(baseURL, accountSID, authToken, from, messagingServiceSID, countryCode, statusCallbackURL string) *TwilioProvider {
+	return &TwilioProvider{
+		baseURL:             strings.TrimSuffix(baseURL, "/"),
+		accountSID:          accountSID,
+		authToken:           authToken,
+		from:                from,
+		messagingServiceSID: messagingServiceSID,
+		countryCode:         countryCode,
+		statusCallbackURL:   statusCallbackURL,
+		client:              &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *TwilioProvider) Name() string {
+	return "twilio"
+}
+
+func (p *TwilioProvider) Send(ctx context.Context, to, message string) (string, error) {
+	if p.accountSID == "" || p.authToken == "" {
+		return "", errors.New("twilio provider is not configured")
+	}
+
+	form := url.Values{}
+	form.Set("To", "+"+internationalNumber(to, p.countryCode))
+	form.Set("Body", message)
+	if p.messagingServiceSID != "" {
+		form.Set("MessagingServiceSid", p.messagingServiceSID)
+	} else {
+		form.Set("From", p.from)
+	}
+	if p.statusCallbackURL != "" {
+		form.Set("StatusCallback", p.statusCallbackURL)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", p.baseURL, p.accountSID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(p.accountSID, p.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send SMS via twilio: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("twilio SMS failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		SID string `json:"sid"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("invalid twilio response: %v", err)
+	}
+
+	return result.SID, nil
+}
+
+// twilioStatuses maps Twilio message states to delivery states; queued and sending are skipped
+var twilioStatuses = map[string]string{
+	"sent":        StatusSent,
+	"delivered":   StatusDelivered,
+	"undelivered": StatusUndelivered,
+	"failed":      StatusFailed,
+}
+
+func (p *TwilioProvider) ParseDeliveryReport(r *http.Request) ([]DeliveryReport, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("invalid twilio callback: %v", err)
+	}
+	if !p.validSignature(r.Header.Get("X-Twilio-Signature"), r.PostForm) {
+		return nil, errors.New("invalid twilio signature")
+	}
+
+	status, ok := twilioStatuses[r.PostForm.Get("MessageStatus")]
+	if !ok {
+		return nil, nil
+	}
+
+	raw := make(map[string]interface{}, len(r.PostForm))
+	for key := range r.PostForm {
+		raw[key] = r.PostForm.Get(key)
+	}
+
+	return []DeliveryReport{{
+		Provider:    p.Name(),
+		MessageID:   r.PostForm.Get("MessageSid"),
+		Status:      status,
+		ErrorCode:   r.PostForm.Get("ErrorCode"),
+		ReportedAt:  time.Now(),
+		RawResponse: raw,
+	}}, nil
+}
+
+// validSignature checks Twilio's HMAC-SHA1 over the callback URL followed by every POST
+// parameter name and value in name order
+func (p *TwilioProvider) validSignature(signature string, params url.Values) bool {
+	if signature == "" || p.authToken == "" || p.statusCallbackURL == "" {
+		return false
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var data strings.Builder
+	data.WriteString(p.statusCallbackURL)
+	for _, key := range keys {
+		for _, value := range params[key] {
+			data.WriteString(key)
+			data.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(p.authToken))
+	mac.Write([]byte(data.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
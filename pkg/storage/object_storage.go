@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	amzDateLayout   = "20060102T150405Z"
+	amzDayLayout    = "20060102"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+var (
+	ErrObjectNotFound = errors.New("object not found")
+	ErrObjectTooLarge = errors.New("object exceeds the maximum size")
+)
+
+// ObjectStorage talks to any S3-compatible bucket (AWS S3, GCS interoperability
+// mode with HMAC keys, MinIO) using path-style URLs and SigV4 query signing
+type ObjectStorage struct {
+	endpoint      *url.URL
+	region        string
+	bucket        string
+	accessKey     string
+	secretKey     string
+	publicBaseURL string
+	client        *http.Client
+}
+
+// NewObjectStorage creates a bucket client. publicBaseURL is where stored objects are served
+// from (e.g. a CDN); when empty the bucket's own URL is used.
+func NewObjectStorage(endpoint, region, bucket, accessKey, secretKey, publicBaseURL string) (*ObjectStorage, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid storage endpoint: %s", endpoint)
+	}
+	if bucket == "" {
+		return nil, errors.New("storage bucket is required")
+	}
+
+	if publicBaseURL == "" {
+		publicBaseURL = strings.TrimSuffix(parsed.String(), "/") + "/" + bucket
+	}
+
+	return &ObjectStorage{
+		endpoint:      parsed,
+		region:        region,
+		bucket:        bucket,
+		accessKey:     accessKey,
+		secretKey:     secretKey,
+		publicBaseURL: strings.TrimSuffix(publicBaseURL, "/"),
+		client:        &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// PresignPut returns a URL the client can PUT an object to until it expires
+func (s *ObjectStorage) PresignPut(key string, expires time.Duration) string {
+	return s.presign(http.MethodPut, key, expires, time.Now())
+}
+
+// PresignGet returns a URL that reads an object until it expires
+func (s *ObjectStorage) PresignGet(key string, expires time.Duration) string {
+	return s.presign(http.MethodGet, key, expires, time.Now())
+}
+
+// PublicURL is the address stored on products and other records for a key
+func (s *ObjectStorage) PublicURL(key string) string {
+	return s.publicBaseURL + "/" + encodePath(key)
+}
+
+// Put uploads an object
+func (s *ObjectStorage) Put(ctx context.Context, key, contentType string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.PresignPut(key, 5*time.Minute), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.ContentLength = int64(len(data))
+
+	_, err = s.do(req, 0)
+	return err
+}
+
+// Get downloads an object, failing if it is larger than maxBytes
+func (s *ObjectStorage) Get(ctx context.Context, key string, maxBytes int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.PresignGet(key, 5*time.Minute), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.do(req, maxBytes)
+}
+
+// Delete removes an object; deleting a missing object is not an error
+func (s *ObjectStorage) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.presign(http.MethodDelete, key, 5*time.Minute, time.Now()), nil)
+	if err != nil {
+		return err
+	}
+	_, err = s.do(req, 0)
+	return err
+}
+
+func (s *ObjectStorage) do(req *http.Request, maxBytes int64) ([]byte, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && req.Method == http.MethodDelete {
+		return nil, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("storage returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if maxBytes <= 0 {
+		return io.ReadAll(resp.Body)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, ErrObjectTooLarge
+	}
+	return data, nil
+}
+
+// presign builds a SigV4 query-signed URL. Only the host header is signed, so clients
+// may send any Content-Type with a presigned PUT.
+func (s *ObjectStorage) presign(method, key string, expires time.Duration, now time.Time) string {
+	now = now.UTC()
+	amzDate := now.Format(amzDateLayout)
+	day := now.Format(amzDayLayout)
+	scope := day + "/" + s.region + "/s3/aws4_request"
+
+	canonicalURI := "/" + encodePath(s.bucket) + "/" + encodePath(key)
+	if basePath := strings.TrimSuffix(s.endpoint.EscapedPath(), "/"); basePath != "" {
+		canonicalURI = basePath + canonicalURI
+	}
+
+	query := map[string]string{
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    s.accessKey + "/" + scope,
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       fmt.Sprintf("%d", int(expires.Seconds())),
+		"X-Amz-SignedHeaders": "host",
+	}
+	canonicalQuery := canonicalQueryString(query)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalURI,
+		canonicalQuery,
+		"host:" + s.endpoint.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	return s.endpoint.Scheme + "://" + s.endpoint.Host + canonicalURI + "?" + canonicalQuery + "&X-Amz-Signature=" + signature
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func canonicalQueryString(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, uriEncode(key, true)+"="+uriEncode(params[key], true))
+	}
+	return strings.Join(parts, "&")
+}
+
+// encodePath encodes each segment of an object key, keeping the slashes
+func encodePath(key string) string {
+	return uriEncode(key, false)
+}
+
+// uriEncode applies SigV4 encoding: everything except unreserved characters is percent-encoded
+func uriEncode(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}